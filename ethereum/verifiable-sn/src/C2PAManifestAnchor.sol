@@ -202,4 +202,76 @@ contract C2PAManifestAnchor {
     function getManifestAttestationCount(bytes32 manifestHash) external view returns (uint256) {
         return attestationsByHash[manifestHash].length;
     }
+
+    // ============================================================
+    // Batched Manifest Anchoring (Merkle roots)
+    // ============================================================
+
+    /// @notice On-chain record of a Merkle root committing to a batch of manifests.
+    /// @dev
+    ///  - Leaves are keccak256(bytes.concat(keccak256(abi.encode(manifestHash, certFingerprint)))),
+    ///    as in OpenZeppelin's StandardMerkleTree.
+    ///  - Inner nodes hash their two children in sorted order, as OpenZeppelin's
+    ///    MerkleProof does, so proofs carry no left/right flags.
+    ///  - Each manifest's proof is stored off-chain by the backend that built the batch.
+    struct ManifestRoot {
+        uint64 timestamp; // block timestamp when anchored
+        uint32 leafCount; // number of manifests in the batch
+    }
+
+    /// @dev Mapping from Merkle root to when it was anchored.
+    mapping(bytes32 => ManifestRoot) private manifestRoots;
+
+    event ManifestRootAnchored(bytes32 indexed root, uint32 leafCount, uint64 timestamp);
+
+    /// @notice Anchor the Merkle root of a batch of validated manifests.
+    /// @dev Anchoring a root that's already anchored keeps the original timestamp.
+    /// @param root Merkle root over the batch's leaves.
+    /// @param leafCount Number of manifests in the batch.
+    function anchorManifestRoot(bytes32 root, uint32 leafCount) external onlyOwner returns (bool) {
+        require(root != bytes32(0), "C2PA: root is zero");
+        require(leafCount > 0, "C2PA: batch is empty");
+
+        if (manifestRoots[root].timestamp != 0) {
+            return true;
+        }
+
+        uint64 timestamp = uint64(block.timestamp);
+        manifestRoots[root] = ManifestRoot({timestamp: timestamp, leafCount: leafCount});
+
+        emit ManifestRootAnchored(root, leafCount, timestamp);
+
+        return true;
+    }
+
+    /// @notice Get when a Merkle root was anchored (zero timestamp if it wasn't).
+    function getManifestRoot(bytes32 root) external view returns (ManifestRoot memory) {
+        return manifestRoots[root];
+    }
+
+    /// @notice Check that a manifest is included in an anchored batch.
+    /// @param root Merkle root of the batch.
+    /// @param manifestHash SHA-256 digest of the C2PA manifest bytes.
+    /// @param certFingerprint Fingerprint of the certificate used to sign the manifest.
+    /// @param proof Sibling hashes from the manifest's leaf up to the root.
+    function verifyManifestInclusion(
+        bytes32 root,
+        bytes32 manifestHash,
+        bytes32 certFingerprint,
+        bytes32[] calldata proof
+    ) external view returns (bool) {
+        if (manifestRoots[root].timestamp == 0) {
+            return false;
+        }
+
+        bytes32 node = keccak256(bytes.concat(keccak256(abi.encode(manifestHash, certFingerprint))));
+        for (uint256 i = 0; i < proof.length; i++) {
+            node = _hashPair(node, proof[i]);
+        }
+        return node == root;
+    }
+
+    function _hashPair(bytes32 a, bytes32 b) private pure returns (bytes32) {
+        return a < b ? keccak256(abi.encodePacked(a, b)) : keccak256(abi.encodePacked(b, a));
+    }
 }
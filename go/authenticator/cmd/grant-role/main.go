@@ -0,0 +1,100 @@
+// Command grant-role grants (or with --revoke, revokes) a role — the
+// bootstrap step for the very first super-admin, since the role admin API
+// (/sonar/admin/roles) requires already holding auth:roles:write. Every
+// grant after the first can be made through that API instead.
+//
+//	go run ./cmd/grant-role --config-name local --email you@example.com --role super-admin
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+
+	"github.com/MaxBlaushild/authenticator/internal/config"
+	"github.com/MaxBlaushild/poltergeist/pkg/auth"
+	"github.com/MaxBlaushild/poltergeist/pkg/db"
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/MaxBlaushild/poltergeist/pkg/util"
+	"github.com/redis/go-redis/v9"
+)
+
+func main() {
+	email := flag.String("email", "", "Look up the user by email.")
+	phone := flag.String("phone", "", "Look up the user by phone number, e.g. +15555550123.")
+	roleName := flag.String("role", "", "Role to grant, e.g. super-admin or sonar-admin.")
+	revoke := flag.Bool("revoke", false, "Revoke the role instead of granting it.")
+
+	cfg, err := config.ParseFlagsAndGetConfig()
+	if err != nil {
+		log.Fatalf("failed to load config: %v", err)
+	}
+	if (*email == "") == (*phone == "") {
+		log.Fatalf("pass exactly one of --email or --phone")
+	}
+	if *roleName == "" {
+		log.Fatalf("pass --role")
+	}
+
+	dbClient, err := db.NewClient(db.ClientConfig{
+		Name:     cfg.Public.DbName,
+		Host:     cfg.Public.DbHost,
+		Port:     cfg.Public.DbPort,
+		User:     cfg.Public.DbUser,
+		Password: cfg.Secret.DbPassword,
+	})
+	if err != nil {
+		log.Fatalf("failed to connect to db: %v", err)
+	}
+
+	ctx := context.Background()
+
+	var user *models.User
+	if *email != "" {
+		user, err = dbClient.User().FindByEmail(ctx, *email)
+	} else {
+		user, err = dbClient.User().FindByPhoneNumber(ctx, *phone)
+	}
+	if err != nil {
+		log.Fatalf("failed to look up user: %v", err)
+	}
+	if user == nil {
+		log.Fatalf("no user found — they need an account (sign up first) before they can be granted a role")
+	}
+
+	role, err := dbClient.Authorization().FindRoleByName(ctx, *roleName)
+	if err != nil {
+		log.Fatalf("failed to look up role: %v", err)
+	}
+	if role == nil {
+		log.Fatalf("no role named %q", *roleName)
+	}
+
+	if *revoke {
+		held, err := dbClient.Authorization().RevokeRole(ctx, user.ID, role.ID)
+		if err != nil {
+			log.Fatalf("failed to revoke role: %v", err)
+		}
+		if !held {
+			log.Printf("%s (%s) did not hold %s", user.Name, user.ID, role.Name)
+			return
+		}
+	} else if err := dbClient.Authorization().GrantRole(ctx, user.ID, role.ID, nil); err != nil {
+		log.Fatalf("failed to grant role: %v", err)
+	}
+
+	// Without Redis, services keep honoring the old scopes until the
+	// user's access token expires.
+	if cfg.Public.RedisUrl != "" {
+		redisClient := redis.NewClient(&redis.Options{Addr: util.NormalizeRedisAddr(cfg.Public.RedisUrl)})
+		if err := auth.PublishRolesChanged(ctx, redisClient, user.ID); err != nil {
+			log.Printf("failed to publish roles changed, services will catch up on token refresh: %v", err)
+		}
+	}
+
+	if *revoke {
+		log.Printf("%s (%s) no longer holds %s", user.Name, user.ID, role.Name)
+		return
+	}
+	log.Printf("%s (%s) now holds %s %v", user.Name, user.ID, role.Name, []string(role.Scopes))
+}
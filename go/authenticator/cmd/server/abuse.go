@@ -0,0 +1,216 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/MaxBlaushild/authenticator/internal/throttle"
+	"github.com/MaxBlaushild/poltergeist/pkg/auth"
+	"github.com/MaxBlaushild/poltergeist/pkg/db"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// maxCodeAttempts is how many wrong guesses burn a texted code.
+const maxCodeAttempts = 5
+
+const (
+	scopeBlockedActorsRead  = "auth:blocked-actors:read"
+	scopeBlockedActorsWrite = "auth:blocked-actors:write"
+)
+
+// Verification texts cost money per message, so they are limited per
+// number, per IP and per app; the per-app limit is the backstop against
+// SMS pumping spread over many numbers and IPs.
+var (
+	smsPerPhone      = throttle.Window{Name: "sms-phone", Limit: 5, Window: time.Hour}
+	smsPerPhoneDaily = throttle.Window{Name: "sms-phone-day", Limit: 10, Window: 24 * time.Hour}
+	smsPerIP         = throttle.Window{Name: "sms-ip", Limit: 20, Window: time.Hour}
+	smsPerApp        = throttle.Window{Name: "sms-app", Limit: 1000, Window: time.Hour}
+	// smsResend counts every text as a strike, so resends to one number
+	// wait 30s, then 1m, 2m and so on.
+	smsResend = throttle.Backoff{Name: "sms-resend", Free: 0, Base: 30 * time.Second, Max: 30 * time.Minute, Reset: 24 * time.Hour}
+)
+
+// emailLinkCodes limits how many addresses one account can email codes to.
+var emailLinkCodes = throttle.Window{Name: "email-link", Limit: 5, Window: time.Hour}
+
+// Failed sign-ins back off per account and, more leniently, per IP so one
+// address can't work through many accounts.
+var (
+	loginPerAccount = throttle.Backoff{Name: "login-account", Free: 5, Base: 30 * time.Second, Max: time.Hour, Reset: time.Hour}
+	loginPerIP      = throttle.Backoff{Name: "login-ip", Free: 20, Base: 30 * time.Second, Max: time.Hour, Reset: time.Hour}
+)
+
+type abuseGuard struct {
+	dbClient  db.DbClient
+	limiter   *throttle.Limiter
+	smsPolicy throttle.SMSPolicy
+	sessions  *sessionManager
+}
+
+// allowSMS answers with 403 or 429 and returns false when a verification
+// text to phoneNumber should not be sent for appName.
+func (g *abuseGuard) allowSMS(c *gin.Context, phoneNumber string, appName string) bool {
+	// Unknown apps are refused outright; a made-up name per request would
+	// otherwise get a fresh per-app window every time.
+	app, ok := g.smsPolicy.App(appName)
+	if !ok {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "we can't send verification codes for that app",
+		})
+		return false
+	}
+	if !g.smsPolicy.Permits(phoneNumber) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "we can't send verification codes to that number",
+		})
+		return false
+	}
+
+	phone := phoneActor(phoneNumber)
+	if wait := g.limiter.Wait(c, smsResend, phone); wait > 0 {
+		tooManyRequests(c, wait)
+		return false
+	}
+	// Every window is checked before the text is counted against any of
+	// them, so a refused request doesn't use up the per-app allowance.
+	if wait := g.limiter.AllowAll(c,
+		throttle.Limit{Rule: smsPerApp, Actor: throttle.Actor{Kind: "app", Value: app}},
+		throttle.Limit{Rule: smsPerIP, Actor: ipActor(c)},
+		throttle.Limit{Rule: smsPerPhone, Actor: phone},
+		throttle.Limit{Rule: smsPerPhoneDaily, Actor: phone},
+	); wait > 0 {
+		tooManyRequests(c, wait)
+		return false
+	}
+	return true
+}
+
+// allowEmailLinkCode answers with 429 and returns false when the user has
+// sent too many email link codes.
+func (g *abuseGuard) allowEmailLinkCode(c *gin.Context, userID uuid.UUID) bool {
+	if wait := g.limiter.Allow(c, emailLinkCodes, userActor(userID)); wait > 0 {
+		tooManyRequests(c, wait)
+		return false
+	}
+	return true
+}
+
+func (g *abuseGuard) sentSMS(c *gin.Context, phoneNumber string) {
+	g.limiter.Fail(c, smsResend, phoneActor(phoneNumber))
+}
+
+// allowLogin answers with 429 and returns false while the account or the
+// caller's IP is backed off.
+func (g *abuseGuard) allowLogin(c *gin.Context, account throttle.Actor) bool {
+	wait := max(g.limiter.Wait(c, loginPerAccount, account), g.limiter.Wait(c, loginPerIP, ipActor(c)))
+	if wait > 0 {
+		tooManyRequests(c, wait)
+		return false
+	}
+	return true
+}
+
+func (g *abuseGuard) failedLogin(c *gin.Context, account throttle.Actor) {
+	g.limiter.Fail(c, loginPerAccount, account)
+	g.limiter.Fail(c, loginPerIP, ipActor(c))
+}
+
+// wrongCode counts a bad guess at a texted code against the code itself as
+// well as the account.
+func (g *abuseGuard) wrongCode(c *gin.Context, phoneNumber string, account throttle.Actor) {
+	if err := g.dbClient.TextVerificationCode().RecordFailedAttempt(c, phoneNumber, maxCodeAttempts); err != nil {
+		log.Printf("[auth][throttle] record code attempt failed err=%v", err)
+	}
+	g.failedLogin(c, account)
+}
+
+func (g *abuseGuard) succeededLogin(c *gin.Context, account throttle.Actor) {
+	g.limiter.Succeed(c, loginPerAccount, account)
+}
+
+func (g *abuseGuard) listBlocked(c *gin.Context) {
+	var requestBody auth.VerifyTokenRequest
+	if err := c.Bind(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if _, ok := g.sessions.requireScope(c, requestBody.Token, scopeBlockedActorsRead); !ok {
+		return
+	}
+
+	blocked, err := g.limiter.ListBlocked(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	actors := make([]auth.BlockedActor, 0, len(blocked))
+	for _, entry := range blocked {
+		actors = append(actors, auth.BlockedActor(entry))
+	}
+
+	c.JSON(http.StatusOK, auth.BlockedActorsResponse{BlockedActors: actors})
+}
+
+func (g *abuseGuard) unblock(c *gin.Context) {
+	var requestBody auth.UnblockActorRequest
+	if err := c.Bind(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if _, ok := g.sessions.requireScope(c, requestBody.Token, scopeBlockedActorsWrite); !ok {
+		return
+	}
+
+	unblocked, err := g.limiter.Unblock(c, requestBody.Key)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if !unblocked {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "not a throttle key",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"unblocked": true})
+}
+
+func tooManyRequests(c *gin.Context, wait time.Duration) {
+	seconds := int(math.Ceil(wait.Seconds()))
+	c.Header("Retry-After", fmt.Sprint(seconds))
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error":      "too many attempts, try again later",
+		"retryAfter": seconds,
+	})
+}
+
+func ipActor(c *gin.Context) throttle.Actor {
+	return throttle.Actor{Kind: "ip", Value: c.ClientIP()}
+}
+
+func phoneActor(phoneNumber string) throttle.Actor {
+	return throttle.Actor{Kind: "phone", Value: formatPhoneNumber(phoneNumber)}
+}
+
+func userActor(userID uuid.UUID) throttle.Actor {
+	return throttle.Actor{Kind: "user", Value: userID.String()}
+}
+
+func emailActor(email string) throttle.Actor {
+	return throttle.Actor{Kind: "email", Value: strings.ToLower(strings.TrimSpace(email))}
+}
@@ -0,0 +1,567 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/MaxBlaushild/authenticator/internal/token"
+	"github.com/MaxBlaushild/poltergeist/pkg/auth"
+	"github.com/MaxBlaushild/poltergeist/pkg/db"
+	"github.com/MaxBlaushild/poltergeist/pkg/email"
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/api/idtoken"
+	"gorm.io/gorm"
+)
+
+const (
+	emailLinkCodeTTL = 15 * time.Minute
+	scopeUsersWrite  = "auth:users:write"
+)
+
+var errInvalidLinkCode = stderrors.New("invalid or expired code")
+
+// accountManager lets one person keep one account: linking more ways to
+// sign in to it, taking their data out, deleting it, and for admins,
+// merging the duplicates made before linking existed.
+type accountManager struct {
+	dbClient db.DbClient
+	sessions *sessionManager
+	abuse    *abuseGuard
+	// mailer is nil when no sender address is configured.
+	mailer         email.EmailClient
+	appName        string
+	googleClientID string
+}
+
+func (m *accountManager) loginMethods(c *gin.Context) {
+	claims, ok := m.authenticate(c)
+	if !ok {
+		return
+	}
+	m.respondWithLoginMethods(c, claims.UserID)
+}
+
+// linkPhone adds a phone number proven with a code from
+// /authenticator/text/verification-code, replacing any the account had.
+func (m *accountManager) linkPhone(c *gin.Context) {
+	var requestBody auth.LinkPhoneRequest
+	if err := c.Bind(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	claims, err := m.sessions.authenticate(c, requestBody.Token)
+	if err != nil {
+		c.JSON(sessionErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	account := phoneActor(formatPhoneNumber(requestBody.PhoneNumber))
+	if !m.abuse.allowLogin(c, account) {
+		return
+	}
+	code, err := m.dbClient.TextVerificationCode().Find(c, requestBody.PhoneNumber, requestBody.Code)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			m.abuse.wrongCode(c, requestBody.PhoneNumber, account)
+		}
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	m.abuse.succeededLogin(c, account)
+
+	existing, err := m.dbClient.User().FindByPhoneNumber(c, requestBody.PhoneNumber)
+	if err != nil && err != gorm.ErrRecordNotFound {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if existing != nil && existing.ID != claims.UserID {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": db.ErrLoginInUse.Error(),
+		})
+		return
+	}
+
+	if err := m.dbClient.TextVerificationCode().MarkUsed(c, code.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err := m.dbClient.User().Update(c, claims.UserID, models.User{PhoneNumber: requestBody.PhoneNumber}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	log.Printf("[auth][accounts] linked phone user_id=%s", claims.UserID)
+
+	m.respondWithLoginMethods(c, claims.UserID)
+}
+
+// sendEmailLinkCode emails the code linkEmail asks for, so nobody can claim
+// an address they can't read.
+func (m *accountManager) sendEmailLinkCode(c *gin.Context) {
+	var requestBody auth.LinkEmailCodeRequest
+	if err := c.Bind(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	claims, err := m.sessions.authenticate(c, requestBody.Token)
+	if err != nil {
+		c.JSON(sessionErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if m.mailer == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": "linking an email address is not available",
+		})
+		return
+	}
+
+	address := strings.TrimSpace(requestBody.Email)
+	if !m.emailAvailable(c, address, claims.UserID) {
+		return
+	}
+	if !m.abuse.allowEmailLinkCode(c, claims.UserID) {
+		return
+	}
+
+	code, err := newEmailLinkCode()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	expiresAt := time.Now().Add(emailLinkCodeTTL)
+	if err := m.dbClient.AuthChallenge().Create(c, &models.AuthChallenge{
+		Kind:          models.AuthChallengeKindLinkEmail,
+		ChallengeHash: emailLinkCodeHash(claims.UserID, address, code),
+		UserID:        &claims.UserID,
+		ExpiresAt:     expiresAt,
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if err := m.mailer.SendMail(email.Email{
+		Subject:          fmt.Sprintf("Your %s verification code", m.appName),
+		Email:            address,
+		PlainTextContent: fmt.Sprintf("%s is your code for adding this email address to your %s account. It expires in %d minutes.", code, m.appName, int(emailLinkCodeTTL.Minutes())),
+	}); err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"expiresAt": expiresAt})
+}
+
+// linkEmail adds an email and password login once the emailed code checks
+// out, replacing any the account had.
+func (m *accountManager) linkEmail(c *gin.Context) {
+	var requestBody auth.LinkEmailRequest
+	if err := c.Bind(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	claims, err := m.sessions.authenticate(c, requestBody.Token)
+	if err != nil {
+		c.JSON(sessionErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	account := userActor(claims.UserID)
+	if !m.abuse.allowLogin(c, account) {
+		return
+	}
+	address := strings.TrimSpace(requestBody.Email)
+	challenge, err := m.dbClient.AuthChallenge().FindActive(c, models.AuthChallengeKindLinkEmail, emailLinkCodeHash(claims.UserID, address, strings.TrimSpace(requestBody.Code)))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if challenge == nil {
+		m.abuse.failedLogin(c, account)
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": errInvalidLinkCode.Error(),
+		})
+		return
+	}
+	m.abuse.succeededLogin(c, account)
+
+	if !m.emailAvailable(c, address, claims.UserID) {
+		return
+	}
+	if consumed, err := m.dbClient.AuthChallenge().Consume(c, challenge.ID); err != nil || !consumed {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": errInvalidLinkCode.Error(),
+		})
+		return
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(requestBody.Password), bcrypt.DefaultCost)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	passwordHash := string(hash)
+	if err := m.dbClient.User().Update(c, claims.UserID, models.User{Email: &address, PasswordHash: &passwordHash}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	log.Printf("[auth][accounts] linked email user_id=%s", claims.UserID)
+
+	m.respondWithLoginMethods(c, claims.UserID)
+}
+
+func (m *accountManager) linkGoogle(c *gin.Context) {
+	var requestBody auth.LinkGoogleRequest
+	if err := c.Bind(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	claims, err := m.sessions.authenticate(c, requestBody.Token)
+	if err != nil {
+		c.JSON(sessionErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	payload, err := idtoken.Validate(c.Request.Context(), requestBody.IDToken, m.googleClientID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "invalid google id token",
+		})
+		return
+	}
+
+	existing, err := m.dbClient.User().FindByGoogleID(c, payload.Subject)
+	if err != nil && err != gorm.ErrRecordNotFound {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if existing != nil && existing.ID != claims.UserID {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": db.ErrLoginInUse.Error(),
+		})
+		return
+	}
+
+	if err := m.dbClient.User().SetGoogleID(c, claims.UserID, payload.Subject); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	log.Printf("[auth][accounts] linked google user_id=%s", claims.UserID)
+
+	m.respondWithLoginMethods(c, claims.UserID)
+}
+
+// exportData answers with a ZIP of everything every product stores about
+// the caller, one JSON file per product.
+func (m *accountManager) exportData(c *gin.Context) {
+	claims, ok := m.authenticate(c)
+	if !ok {
+		return
+	}
+
+	export, err := m.dbClient.UserData().Export(c, claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	var archive bytes.Buffer
+	if err := writeUserDataArchive(&archive, export); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	log.Printf("[auth][accounts] exported user_id=%s bytes=%d", claims.UserID, archive.Len())
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, userDataArchiveName(claims.UserID)))
+	c.Data(http.StatusOK, "application/zip", archive.Bytes())
+}
+
+// deleteAccount deletes the caller and their data in every product.
+func (m *accountManager) deleteAccount(c *gin.Context) {
+	claims, ok := m.authenticate(c)
+	if !ok {
+		return
+	}
+	if err := m.deleteUser(c, claims.UserID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	log.Printf("[auth][accounts] deleted user_id=%s by=self", claims.UserID)
+
+	c.JSON(http.StatusOK, gin.H{"deleted": true})
+}
+
+// deleteOther deletes any user and their data in every product, for admins
+// acting on a request made outside the app.
+func (m *accountManager) deleteOther(c *gin.Context) {
+	claims, ok := m.sessions.requireScope(c, bearerToken(c), scopeUsersWrite)
+	if !ok {
+		return
+	}
+	userID, err := uuid.Parse(c.Param("userID"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err := m.deleteUser(c, userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	log.Printf("[auth][accounts] deleted user_id=%s by=%s", userID, claims.UserID)
+
+	c.JSON(http.StatusOK, gin.H{"deleted": true})
+}
+
+func (m *accountManager) deleteUser(c *gin.Context, userID uuid.UUID) error {
+	return m.sessions.removeWithSessions(c, userID, func() error {
+		return m.dbClient.UserData().Delete(c, userID)
+	})
+}
+
+// merge folds one account into another. The source is signed out once its
+// data has moved; its sessions are never moved.
+func (m *accountManager) merge(c *gin.Context) {
+	var requestBody auth.MergeUsersRequest
+	if err := c.Bind(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	claims, ok := m.sessions.requireScope(c, requestBody.Token, scopeUsersWrite)
+	if !ok {
+		return
+	}
+	if requestBody.FromUserID == requestBody.IntoUserID {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": db.ErrMergeSameUser.Error(),
+		})
+		return
+	}
+
+	for _, userID := range []uuid.UUID{requestBody.FromUserID, requestBody.IntoUserID} {
+		if _, err := m.dbClient.User().FindByID(c, userID); err != nil {
+			if err == gorm.ErrRecordNotFound {
+				err = db.ErrUserNotFound
+			}
+			c.JSON(mergeErrorStatus(err), gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+	}
+	unregistered, err := m.dbClient.UserData().Unregistered(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if len(unregistered) > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": (&db.UnregisteredUserDataError{Columns: unregistered}).Error(),
+		})
+		return
+	}
+
+	if err := m.sessions.removeWithSessions(c, requestBody.FromUserID, func() error {
+		return m.dbClient.UserData().Merge(c, requestBody.FromUserID, requestBody.IntoUserID)
+	}); err != nil {
+		c.JSON(mergeErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	// Roles may have come across with the rest.
+	if err := auth.PublishRolesChanged(c, m.sessions.redisClient, requestBody.IntoUserID); err != nil {
+		log.Printf("[auth][events] publish roles changed failed user_id=%s err=%v", requestBody.IntoUserID, err)
+	}
+	log.Printf("[auth][accounts] merged from=%s into=%s by=%s", requestBody.FromUserID, requestBody.IntoUserID, claims.UserID)
+
+	user, err := m.dbClient.User().FindByID(c, requestBody.IntoUserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
+
+func (m *accountManager) authenticate(c *gin.Context) (*token.Claims, bool) {
+	var requestBody auth.VerifyTokenRequest
+	if err := c.Bind(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return nil, false
+	}
+	claims, err := m.sessions.authenticate(c, requestBody.Token)
+	if err != nil {
+		c.JSON(sessionErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return nil, false
+	}
+	return claims, true
+}
+
+// emailAvailable answers with 409 and returns false when another account
+// signs in with address.
+func (m *accountManager) emailAvailable(c *gin.Context, address string, userID uuid.UUID) bool {
+	existing, err := m.dbClient.User().FindByEmail(c, address)
+	if err != nil && err != gorm.ErrRecordNotFound {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return false
+	}
+	if existing != nil && existing.ID != userID {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": db.ErrLoginInUse.Error(),
+		})
+		return false
+	}
+	return true
+}
+
+func (m *accountManager) respondWithLoginMethods(c *gin.Context, userID uuid.UUID) {
+	user, err := m.dbClient.User().FindByID(c, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	passkeys, err := m.dbClient.Passkey().FindByUserID(c, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, auth.LoginMethodsResponse{
+		PhoneNumber: user.PhoneNumber,
+		Email:       user.Email,
+		Password:    user.PasswordHash != nil,
+		Google:      user.GoogleID != nil,
+		Passkeys:    len(passkeys),
+	})
+}
+
+func mergeErrorStatus(err error) int {
+	var unregistered *db.UnregisteredUserDataError
+	switch {
+	case stderrors.Is(err, db.ErrUserNotFound):
+		return http.StatusNotFound
+	case stderrors.Is(err, db.ErrMergeSameUser):
+		return http.StatusBadRequest
+	case stderrors.As(err, &unregistered):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+func newEmailLinkCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+// emailLinkCodeHash ties a code to the account and address it was sent
+// for.
+func emailLinkCodeHash(userID uuid.UUID, address string, code string) string {
+	return models.HashChallenge(userID.String() + ":" + strings.ToLower(address) + ":" + code)
+}
+
+func userDataArchiveName(userID uuid.UUID) string {
+	return "user-data-" + userID.String() + ".zip"
+}
+
+// writeUserDataArchive writes one <domain>.json per domain, each an object
+// of table name to rows.
+func writeUserDataArchive(w io.Writer, export db.UserDataExport) error {
+	domains := make([]string, 0, len(export))
+	for domain := range export {
+		domains = append(domains, domain)
+	}
+	sort.Strings(domains)
+
+	archive := zip.NewWriter(w)
+	for _, domain := range domains {
+		file, err := archive.Create(domain + ".json")
+		if err != nil {
+			return err
+		}
+		encoder := json.NewEncoder(file)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(export[domain]); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MaxBlaushild/authenticator/internal/token"
+	"github.com/MaxBlaushild/poltergeist/pkg/db"
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func TestWriteUserDataArchiveWritesOneFilePerDomain(t *testing.T) {
+	export := db.UserDataExport{
+		"sonar": {"user_levels": {json.RawMessage(`{"level":3}`)}},
+		"auth":  {"users": {json.RawMessage(`{"name":"Sam"}`)}},
+	}
+
+	var archive bytes.Buffer
+	if err := writeUserDataArchive(&archive, export); err != nil {
+		t.Fatalf("writeUserDataArchive: %v", err)
+	}
+
+	reader, err := zip.NewReader(bytes.NewReader(archive.Bytes()), int64(archive.Len()))
+	if err != nil {
+		t.Fatalf("reading archive: %v", err)
+	}
+	if len(reader.File) != 2 || reader.File[0].Name != "auth.json" || reader.File[1].Name != "sonar.json" {
+		t.Fatalf("expected auth.json and sonar.json, got %v", reader.File)
+	}
+
+	file, err := reader.File[1].Open()
+	if err != nil {
+		t.Fatalf("opening sonar.json: %v", err)
+	}
+	defer file.Close()
+	contents, _ := io.ReadAll(file)
+	var tables map[string][]map[string]int
+	if err := json.Unmarshal(contents, &tables); err != nil {
+		t.Fatalf("decoding sonar.json: %v", err)
+	}
+	if tables["user_levels"][0]["level"] != 3 {
+		t.Fatalf("expected the user_levels row, got %s", contents)
+	}
+}
+
+func TestEmailLinkCodeHashIsBoundToUserAndAddress(t *testing.T) {
+	userID := uuid.New()
+	hash := emailLinkCodeHash(userID, "Sam@Example.com", "123456")
+
+	if emailLinkCodeHash(userID, "sam@example.com", "123456") != hash {
+		t.Fatal("expected addresses to match regardless of case")
+	}
+	if emailLinkCodeHash(uuid.New(), "sam@example.com", "123456") == hash {
+		t.Fatal("expected a code sent to one user not to work for another")
+	}
+	if emailLinkCodeHash(userID, "other@example.com", "123456") == hash {
+		t.Fatal("expected a code sent to one address not to link another")
+	}
+}
+
+func TestMergeErrorStatus(t *testing.T) {
+	cases := map[error]int{
+		db.ErrUserNotFound:  http.StatusNotFound,
+		db.ErrMergeSameUser: http.StatusBadRequest,
+		fmt.Errorf("merging: %w", &db.UnregisteredUserDataError{Columns: []string{"widgets.user_id"}}): http.StatusConflict,
+		fmt.Errorf("connection reset"): http.StatusInternalServerError,
+	}
+	for err, want := range cases {
+		if got := mergeErrorStatus(err); got != want {
+			t.Fatalf("mergeErrorStatus(%v) = %d, want %d", err, got, want)
+		}
+	}
+}
+
+// rejectingTokens fails every token. Embedding the interface makes any
+// other call panic.
+type rejectingTokens struct {
+	token.Client
+}
+
+func (rejectingTokens) Verify(string) (*token.Claims, error) {
+	return nil, fmt.Errorf("token is invalid")
+}
+
+func TestDeleteOtherRequiresAToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	// A nil db client panics if the handler gets as far as deleting.
+	accounts := &accountManager{sessions: &sessionManager{tokenClient: rejectingTokens{}}}
+	r := gin.New()
+	r.DELETE("/authenticator/users/:userID", accounts.deleteOther)
+
+	for _, authorization := range []string{"", "Bearer forged"} {
+		request := httptest.NewRequest(http.MethodDelete, "/authenticator/users/"+uuid.NewString(), nil)
+		if authorization != "" {
+			request.Header.Set("Authorization", authorization)
+		}
+		response := httptest.NewRecorder()
+		r.ServeHTTP(response, request)
+		if response.Code != http.StatusUnauthorized {
+			t.Fatalf("expected %q to be refused with 401, got %d", authorization, response.Code)
+		}
+	}
+}
+
+// The merge fakes embed the interfaces they stand in for, so a call the
+// test doesn't expect, like revoking sessions outside the merge, panics.
+type mergeTestTokens struct {
+	token.Client
+	sessionID uuid.UUID
+}
+
+func (f mergeTestTokens) Verify(string) (*token.Claims, error) {
+	return &token.Claims{UserID: mergeTestAdminID, SessionID: &f.sessionID}, nil
+}
+
+var mergeTestAdminID = uuid.New()
+
+type mergeTestDB struct {
+	db.DbClient
+	users    map[uuid.UUID]bool
+	userData *mergeTestUserData
+}
+
+func (f *mergeTestDB) User() db.UserHandle                   { return mergeTestUsers{users: f.users} }
+func (f *mergeTestDB) AuthSession() db.AuthSessionHandle     { return mergeTestSessions{} }
+func (f *mergeTestDB) Authorization() db.AuthorizationHandle { return mergeTestAuthorization{} }
+func (f *mergeTestDB) UserData() db.UserDataHandle           { return f.userData }
+
+type mergeTestUsers struct {
+	db.UserHandle
+	users map[uuid.UUID]bool
+}
+
+func (f mergeTestUsers) FindByID(_ context.Context, id uuid.UUID) (*models.User, error) {
+	if !f.users[id] {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &models.User{ID: id}, nil
+}
+
+type mergeTestSessions struct {
+	db.AuthSessionHandle
+}
+
+func (mergeTestSessions) FindByID(_ context.Context, id uuid.UUID) (*models.AuthSession, error) {
+	verifiedAt := time.Now()
+	return &models.AuthSession{ID: id, UserID: mergeTestAdminID, ExpiresAt: time.Now().Add(time.Hour), MFAVerifiedAt: &verifiedAt}, nil
+}
+
+func (mergeTestSessions) FindActiveByUserID(_ context.Context, userID uuid.UUID) ([]models.AuthSession, error) {
+	return []models.AuthSession{{ID: uuid.New(), UserID: userID}}, nil
+}
+
+type mergeTestAuthorization struct {
+	db.AuthorizationHandle
+}
+
+func (mergeTestAuthorization) FindScopesForUser(context.Context, uuid.UUID) ([]string, error) {
+	return []string{scopeUsersWrite}, nil
+}
+
+type mergeTestUserData struct {
+	db.UserDataHandle
+	unregistered []string
+	mergeErr     error
+	merges       int
+}
+
+func (f *mergeTestUserData) Unregistered(context.Context) ([]string, error) {
+	return f.unregistered, nil
+}
+
+func (f *mergeTestUserData) Merge(context.Context, uuid.UUID, uuid.UUID) error {
+	f.merges++
+	return f.mergeErr
+}
+
+func TestMergeChecksFirstAndSignsOutOnlyThroughTheMerge(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	fromID, intoID := uuid.New(), uuid.New()
+
+	for _, tc := range []struct {
+		name       string
+		users      map[uuid.UUID]bool
+		userData   *mergeTestUserData
+		wantStatus int
+		wantMerges int
+	}{
+		{name: "merged", users: map[uuid.UUID]bool{fromID: true, intoID: true}, userData: &mergeTestUserData{}, wantStatus: http.StatusOK, wantMerges: 1},
+		{name: "missing source", users: map[uuid.UUID]bool{intoID: true}, userData: &mergeTestUserData{}, wantStatus: http.StatusNotFound},
+		{name: "unregistered user data", users: map[uuid.UUID]bool{fromID: true, intoID: true}, userData: &mergeTestUserData{unregistered: []string{"widgets.user_id"}}, wantStatus: http.StatusConflict},
+		{name: "merge failed", users: map[uuid.UUID]bool{fromID: true, intoID: true}, userData: &mergeTestUserData{mergeErr: fmt.Errorf("connection reset")}, wantStatus: http.StatusInternalServerError, wantMerges: 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dbClient := &mergeTestDB{users: tc.users, userData: tc.userData}
+			accounts := &accountManager{
+				dbClient: dbClient,
+				sessions: &sessionManager{dbClient: dbClient, tokenClient: mergeTestTokens{sessionID: uuid.New()}},
+			}
+			r := gin.New()
+			r.POST("/authenticator/users/merge", accounts.merge)
+
+			body := fmt.Sprintf(`{"token":"admin","fromUserId":"%s","intoUserId":"%s"}`, fromID, intoID)
+			request := httptest.NewRequest(http.MethodPost, "/authenticator/users/merge", strings.NewReader(body))
+			request.Header.Set("Content-Type", "application/json")
+			response := httptest.NewRecorder()
+			r.ServeHTTP(response, request)
+
+			if response.Code != tc.wantStatus {
+				t.Fatalf("expected %d, got %d: %s", tc.wantStatus, response.Code, response.Body)
+			}
+			if tc.userData.merges != tc.wantMerges {
+				t.Fatalf("expected %d merges, got %d", tc.wantMerges, tc.userData.merges)
+			}
+		})
+	}
+}
@@ -3,20 +3,26 @@ package main
 import (
 	"context"
 	"fmt"
+	"log"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/MaxBlaushild/authenticator/internal/config"
+	"github.com/MaxBlaushild/authenticator/internal/throttle"
 	"github.com/MaxBlaushild/authenticator/internal/token"
+	"github.com/MaxBlaushild/authenticator/internal/totp"
+	"github.com/MaxBlaushild/authenticator/internal/webauthn"
 	"github.com/MaxBlaushild/poltergeist/pkg/auth"
 	"github.com/MaxBlaushild/poltergeist/pkg/aws"
 	"github.com/MaxBlaushild/poltergeist/pkg/db"
+	"github.com/MaxBlaushild/poltergeist/pkg/email"
 	"github.com/MaxBlaushild/poltergeist/pkg/texter"
 	"github.com/MaxBlaushild/poltergeist/pkg/util"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
 	"golang.org/x/crypto/bcrypt"
 	"google.golang.org/api/idtoken"
 	"gorm.io/gorm"
@@ -55,11 +61,96 @@ func main() {
 		panic(err)
 	}
 
-	tokenClient, err := token.NewClient(cfg.Secret.AuthPrivateKey)
+	settings, err := parseSessionSettings(cfg.Public)
 	if err != nil {
 		panic(err)
 	}
 
+	tokenClient, err := token.NewClient(cfg.Secret.AuthPrivateKey, cfg.Secret.AuthRetiredPrivateKeys, settings.AccessTokenTTL)
+	if err != nil {
+		panic(err)
+	}
+
+	// Services verify tokens locally and cache users; Redis tells them when
+	// a user changes or a session is revoked.
+	var redisClient *redis.Client
+	if cfg.Public.RedisUrl != "" {
+		redisClient = redis.NewClient(&redis.Options{
+			Addr: util.NormalizeRedisAddr(cfg.Public.RedisUrl),
+		})
+		dbClient.SetUserChangeNotifier(func(ctx context.Context, userID uuid.UUID) {
+			if err := auth.PublishUserChanged(ctx, redisClient, userID); err != nil {
+				log.Printf("[auth][events] publish user changed failed user_id=%s err=%v", userID, err)
+			}
+		})
+	}
+
+	sessions := &sessionManager{
+		dbClient:    dbClient,
+		tokenClient: tokenClient,
+		redisClient: redisClient,
+		settings:    settings,
+	}
+
+	totpCipher, err := totp.NewCipher(cfg.Secret.TOTPEncryptionKey)
+	if err != nil {
+		panic(err)
+	}
+	smsAllowed, smsDenied := cfg.Public.SmsPrefixes()
+	abuse := &abuseGuard{
+		dbClient: dbClient,
+		limiter:  throttle.New(redisClient),
+		smsPolicy: throttle.SMSPolicy{
+			Allowed: throttle.ParsePrefixList(smsAllowed),
+			Denied:  throttle.ParsePrefixList(smsDenied),
+			Apps:    cfg.Public.SmsApps(),
+		},
+		sessions: sessions,
+	}
+	twoFactor := &twoFactorManager{
+		dbClient: dbClient,
+		sessions: sessions,
+		abuse:    abuse,
+		cipher:   totpCipher,
+		issuer:   cfg.Public.TOTPIssuerName(),
+	}
+	passkeys := &passkeyManager{
+		dbClient: dbClient,
+		sessions: sessions,
+		rp: webauthn.New(webauthn.Config{
+			RPID:    cfg.Public.RpID,
+			RPName:  cfg.Public.RpDisplayName,
+			Origins: cfg.Public.RpOrigins(),
+		}),
+	}
+
+	var mailer email.EmailClient
+	if cfg.Public.EmailFromAddress != "" {
+		mailer = email.NewClient(email.ClientConfig{
+			AccountSid:  cfg.Secret.TwilioAccountSid,
+			AuthToken:   cfg.Secret.TwilioAuthToken,
+			FromAddress: cfg.Public.EmailFromAddress,
+			FromName:    cfg.Public.RpDisplayName,
+		})
+	}
+	accounts := &accountManager{
+		dbClient:       dbClient,
+		sessions:       sessions,
+		abuse:          abuse,
+		mailer:         mailer,
+		appName:        cfg.Public.RpDisplayName,
+		googleClientID: cfg.Secret.GoogleClientID,
+	}
+
+	// MFA tokens, passkey challenges and email codes are dead once expired.
+	go func() {
+		for range time.Tick(time.Hour) {
+			if _, err := dbClient.AuthChallenge().DeleteExpired(ctx); err != nil {
+				log.Printf("[auth][challenges] delete expired failed err=%v", err)
+			}
+		}
+	}()
+
 	texterClient := texter.NewClient()
 
 	awsClient := aws.NewAWSClient("us-east-1")
@@ -139,6 +230,11 @@ func main() {
 		})
 	})
 
+	r.GET(auth.JWKSPath, func(c *gin.Context) {
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(http.StatusOK, tokenClient.JWKS())
+	})
+
 	r.POST("/authenticator/token/verify", func(c *gin.Context) {
 		var requestBody auth.VerifyTokenRequest
 
@@ -149,15 +245,25 @@ func main() {
 			return
 		}
 
-		userID, err := tokenClient.Verify(requestBody.Token)
+		claims, session, err := sessions.authenticateSession(c, requestBody.Token)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{
+			c.JSON(sessionErrorStatus(err), gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		user, err := dbClient.User().FindByID(c, claims.UserID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
 				"error": err.Error(),
 			})
 			return
 		}
 
-		user, err := dbClient.User().FindByID(c, *userID)
+		// The current grants rather than the token's, so a revoked role
+		// stops working here immediately.
+		user.Scopes, _, err = sessions.sessionScopes(c, claims.UserID, session)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"error": err.Error(),
@@ -168,6 +274,226 @@ func main() {
 		c.JSON(http.StatusOK, user)
 	})
 
+	r.POST("/authenticator/token/refresh", func(c *gin.Context) {
+		var requestBody auth.RefreshTokenRequest
+
+		if err := c.Bind(&requestBody); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		response, err := sessions.refresh(c, requestBody.RefreshToken)
+		if err != nil {
+			c.JSON(sessionErrorStatus(err), gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, response)
+	})
+
+	// Clients holding a token from before sessions existed trade it here for
+	// an expiring access token and a refresh token, without signing in again.
+	r.POST("/authenticator/token/exchange", func(c *gin.Context) {
+		var requestBody auth.VerifyTokenRequest
+
+		if err := c.Bind(&requestBody); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		claims, err := sessions.authenticate(c, requestBody.Token)
+		if err != nil {
+			c.JSON(sessionErrorStatus(err), gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		if !claims.Legacy() {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "token already belongs to a session",
+			})
+			return
+		}
+
+		user, err := dbClient.User().FindByID(c, claims.UserID)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		response, err := sessions.login(c, user)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, response)
+	})
+
+	r.POST("/authenticator/sessions", func(c *gin.Context) {
+		var requestBody auth.VerifyTokenRequest
+
+		if err := c.Bind(&requestBody); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		claims, err := sessions.authenticate(c, requestBody.Token)
+		if err != nil {
+			c.JSON(sessionErrorStatus(err), gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		activeSessions, err := dbClient.AuthSession().FindActiveByUserID(c, claims.UserID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, auth.SessionsResponse{
+			Sessions:         activeSessions,
+			CurrentSessionID: claims.SessionID,
+		})
+	})
+
+	r.POST("/authenticator/sessions/revoke", func(c *gin.Context) {
+		var requestBody auth.RevokeSessionRequest
+
+		if err := c.Bind(&requestBody); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		claims, err := sessions.authenticate(c, requestBody.Token)
+		if err != nil {
+			c.JSON(sessionErrorStatus(err), gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		session, err := dbClient.AuthSession().FindByID(c, requestBody.SessionID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		if session == nil || session.UserID != claims.UserID {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "session not found",
+			})
+			return
+		}
+
+		if err := dbClient.AuthSession().Revoke(c, session.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		sessions.publishRevoked(c, session.UserID, []uuid.UUID{session.ID})
+
+		c.JSON(http.StatusOK, gin.H{"message": "ok"})
+	})
+
+	// Legacy tokens carry no session, so revoking everything does not touch
+	// them; only LEGACY_TOKEN_CUTOFF retires those.
+	r.POST("/authenticator/sessions/revoke-all", func(c *gin.Context) {
+		var requestBody auth.RevokeAllSessionsRequest
+
+		if err := c.Bind(&requestBody); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		claims, err := sessions.authenticate(c, requestBody.Token)
+		if err != nil {
+			c.JSON(sessionErrorStatus(err), gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		var except *uuid.UUID
+		if requestBody.KeepCurrent {
+			except = claims.SessionID
+		}
+
+		activeSessions, err := dbClient.AuthSession().FindActiveByUserID(c, claims.UserID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		revoked, err := dbClient.AuthSession().RevokeAllForUser(c, claims.UserID, except)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		revokedIDs := make([]uuid.UUID, 0, len(activeSessions))
+		for _, session := range activeSessions {
+			if except == nil || session.ID != *except {
+				revokedIDs = append(revokedIDs, session.ID)
+			}
+		}
+		sessions.publishRevoked(c, claims.UserID, revokedIDs)
+
+		c.JSON(http.StatusOK, auth.RevokeAllSessionsResponse{Revoked: revoked})
+	})
+
+	r.POST("/authenticator/mfa/verify", twoFactor.verifyMFA)
+	r.POST("/authenticator/mfa/status", twoFactor.status)
+	r.POST("/authenticator/totp/enroll", twoFactor.enroll)
+	r.POST("/authenticator/totp/confirm", twoFactor.confirm)
+	r.POST("/authenticator/totp/disable", twoFactor.disable)
+	r.POST("/authenticator/totp/recovery-codes", twoFactor.regenerateRecoveryCodes)
+
+	r.POST("/authenticator/blocked-actors", abuse.listBlocked)
+	r.POST("/authenticator/blocked-actors/unblock", abuse.unblock)
+
+	r.POST("/authenticator/passkeys", passkeys.list)
+	r.POST("/authenticator/passkeys/delete", passkeys.delete)
+	r.POST("/authenticator/passkeys/register/begin", passkeys.beginRegistration)
+	r.POST("/authenticator/passkeys/register/finish", passkeys.finishRegistration)
+	r.POST("/authenticator/passkeys/login/begin", passkeys.beginLogin)
+	r.POST("/authenticator/passkeys/login/finish", passkeys.finishLogin)
+
+	r.POST("/authenticator/logins", accounts.loginMethods)
+	r.POST("/authenticator/logins/phone", accounts.linkPhone)
+	r.POST("/authenticator/logins/email/code", accounts.sendEmailLinkCode)
+	r.POST("/authenticator/logins/email", accounts.linkEmail)
+	r.POST("/authenticator/logins/google", accounts.linkGoogle)
+	r.POST("/authenticator/users/export", accounts.exportData)
+	r.POST("/authenticator/users/delete", accounts.deleteAccount)
+	r.POST("/authenticator/users/merge", accounts.merge)
+
 	r.POST("/authenticator/text/verification-code", func(c *gin.Context) {
 		var requestBody struct {
 			PhoneNumber string `json:"phoneNumber" binding:"required"`
@@ -196,6 +522,10 @@ func main() {
 			return
 		}
 
+		if !abuse.allowSMS(c, formatted, requestBody.AppName) {
+			return
+		}
+
 		user, err := dbClient.User().FindByPhoneNumber(c, requestBody.PhoneNumber)
 		if err != nil && err != gorm.ErrRecordNotFound {
 			c.JSON(http.StatusInternalServerError, gin.H{
@@ -227,6 +557,7 @@ func main() {
 			})
 			return
 		}
+		abuse.sentSMS(c, formatted)
 
 		c.JSON(200, user)
 	})
@@ -278,6 +609,10 @@ func main() {
 		}
 
 		formatted := formatPhoneNumber(requestBody.PhoneNumber)
+		account := phoneActor(formatted)
+		if !abuse.allowLogin(c, account) {
+			return
+		}
 
 		// Allowlisted test accounts skip code lookup and issue tokens directly.
 		if testUser, ok := lookupTestAuthUserForLogin(formatted, requestBody.Code); ok {
@@ -288,27 +623,28 @@ func main() {
 				})
 				return
 			}
-			tok, err := tokenClient.New(user.ID)
+			response, err := sessions.login(c, user)
 			if err != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{
-					"error": errors.Wrap(err, "jwt creation error").Error(),
+					"error": err.Error(),
 				})
 				return
 			}
-			c.JSON(200, gin.H{
-				"user":  user,
-				"token": tok,
-			})
+			c.JSON(200, response)
 			return
 		}
 
 		code, err := dbClient.TextVerificationCode().Find(c, requestBody.PhoneNumber, requestBody.Code)
 		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				abuse.wrongCode(c, requestBody.PhoneNumber, account)
+			}
 			c.JSON(http.StatusNotFound, gin.H{
 				"error": err.Error(),
 			})
 			return
 		}
+		abuse.succeededLogin(c, account)
 
 		user, err := dbClient.User().FindByPhoneNumber(ctx, requestBody.PhoneNumber)
 		if err != nil {
@@ -325,18 +661,15 @@ func main() {
 			return
 		}
 
-		token, err := tokenClient.New(user.ID)
+		response, err := sessions.login(c, user)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": errors.Wrap(err, "jwt creation error").Error(),
+				"error": err.Error(),
 			})
 			return
 		}
 
-		c.JSON(200, gin.H{
-			"user":  user,
-			"token": token,
-		})
+		c.JSON(200, response)
 	})
 
 	r.POST("/authenticator/text/register", func(c *gin.Context) {
@@ -350,6 +683,10 @@ func main() {
 		}
 
 		formatted := formatPhoneNumber(requestBody.PhoneNumber)
+		account := phoneActor(formatted)
+		if !abuse.allowLogin(c, account) {
+			return
+		}
 
 		// Allowlisted test accounts skip code lookup and issue tokens directly.
 		if testUser, ok := lookupTestAuthUserForLogin(formatted, requestBody.Code); ok {
@@ -368,14 +705,14 @@ func main() {
 				})
 				return
 			}
-			tok, err := tokenClient.New(user.ID)
+			response, err := sessions.login(c, user)
 			if err != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{
-					"error": errors.Wrap(err, "jwt creation error").Error(),
+					"error": err.Error(),
 				})
 				return
 			}
-			c.JSON(200, gin.H{"user": user, "token": tok})
+			c.JSON(200, response)
 			return
 		}
 
@@ -388,11 +725,15 @@ func main() {
 
 		code, err := dbClient.TextVerificationCode().Find(c, requestBody.PhoneNumber, requestBody.Code)
 		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				abuse.wrongCode(c, requestBody.PhoneNumber, account)
+			}
 			c.JSON(http.StatusNotFound, gin.H{
 				"error": errors.Wrap(err, "text verification code finding error").Error(),
 			})
 			return
 		}
+		abuse.succeededLogin(c, account)
 
 		if err := dbClient.TextVerificationCode().MarkUsed(ctx, code.ID); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
@@ -454,18 +795,15 @@ func main() {
 			return
 		}
 
-		token, err := tokenClient.New(user.ID)
+		response, err := sessions.login(c, user)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": errors.Wrap(err, "jwt creation error").Error(),
+				"error": err.Error(),
 			})
 			return
 		}
 
-		c.JSON(200, gin.H{
-			"user":  user,
-			"token": token,
-		})
+		c.JSON(200, response)
 	})
 
 	// Email+password login (reef-site's own customer accounts) — added
@@ -505,18 +843,15 @@ func main() {
 			return
 		}
 
-		tok, err := tokenClient.New(user.ID)
+		response, err := sessions.login(c, user)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": errors.Wrap(err, "jwt creation error").Error(),
+				"error": err.Error(),
 			})
 			return
 		}
 
-		c.JSON(200, gin.H{
-			"user":  user,
-			"token": tok,
-		})
+		c.JSON(200, response)
 	})
 
 	r.POST("/authenticator/email/login", func(c *gin.Context) {
@@ -529,8 +864,14 @@ func main() {
 			return
 		}
 
+		account := emailActor(requestBody.Email)
+		if !abuse.allowLogin(c, account) {
+			return
+		}
+
 		user, err := dbClient.User().FindByEmail(c, requestBody.Email)
 		if err != nil || user.PasswordHash == nil {
+			abuse.failedLogin(c, account)
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"error": "invalid email or password",
 			})
@@ -538,24 +879,23 @@ func main() {
 		}
 
 		if err := bcrypt.CompareHashAndPassword([]byte(*user.PasswordHash), []byte(requestBody.Password)); err != nil {
+			abuse.failedLogin(c, account)
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"error": "invalid email or password",
 			})
 			return
 		}
+		abuse.succeededLogin(c, account)
 
-		tok, err := tokenClient.New(user.ID)
+		response, err := sessions.login(c, user)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": errors.Wrap(err, "jwt creation error").Error(),
+				"error": err.Error(),
 			})
 			return
 		}
 
-		c.JSON(200, gin.H{
-			"user":  user,
-			"token": tok,
-		})
+		c.JSON(200, response)
 	})
 
 	// "Sign in with Google" via Google Identity Services' ID-token flow
@@ -581,7 +921,8 @@ func main() {
 		}
 
 		googleID := payload.Subject
-		email, _ := payload.Claims["email"].(string)
+		emailAddress, _ := payload.Claims["email"].(string)
+		emailVerified, _ := payload.Claims["email_verified"].(bool)
 		name, _ := payload.Claims["name"].(string)
 
 		user, err := dbClient.User().FindByGoogleID(c, googleID)
@@ -594,9 +935,16 @@ func main() {
 
 		// Not linked yet — match by email so an existing email/password
 		// account gets Google linked onto it instead of creating a
-		// duplicate.
-		if user == nil && email != "" {
-			if existing, findErr := dbClient.User().FindByEmail(c, email); findErr == nil && existing != nil {
+		// duplicate. Only an address Google has verified counts; anything
+		// else has to be linked from the signed-in account.
+		if user == nil && emailAddress != "" {
+			if existing, findErr := dbClient.User().FindByEmail(c, emailAddress); findErr == nil && existing != nil {
+				if !emailVerified {
+					c.JSON(http.StatusConflict, gin.H{
+						"error": "an account already uses this email; sign in to it and link Google from there",
+					})
+					return
+				}
 				if linkErr := dbClient.User().SetGoogleID(c, existing.ID, googleID); linkErr != nil {
 					c.JSON(http.StatusInternalServerError, gin.H{
 						"error": errors.Wrap(linkErr, "linking google account error").Error(),
@@ -610,9 +958,9 @@ func main() {
 
 		if user == nil {
 			if name == "" {
-				name = email
+				name = emailAddress
 			}
-			created, insertErr := dbClient.User().InsertWithGoogle(c, name, email, googleID)
+			created, insertErr := dbClient.User().InsertWithGoogle(c, name, emailAddress, googleID)
 			if insertErr != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{
 					"error": errors.Wrap(insertErr, "creating google user error").Error(),
@@ -622,41 +970,19 @@ func main() {
 			user = created
 		}
 
-		tok, err := tokenClient.New(user.ID)
+		response, err := sessions.login(c, user)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": errors.Wrap(err, "jwt creation error").Error(),
-			})
-			return
-		}
-
-		c.JSON(200, gin.H{
-			"user":  user,
-			"token": tok,
-		})
-	})
-
-	r.DELETE("/authenticator/users/:userID", func(c *gin.Context) {
-		stringUserID := c.Param("userID")
-
-		userID, err := uuid.Parse(stringUserID)
-		if err != nil {
-			c.JSON(500, gin.H{
 				"error": err.Error(),
 			})
 			return
 		}
 
-		if err := dbClient.User().Delete(c, userID); err != nil {
-			c.JSON(500, gin.H{
-				"error": err.Error(),
-			})
-			return
-		}
-
-		c.JSON(200, gin.H{"message": "everything ok"})
+		c.JSON(200, response)
 	})
 
+	r.DELETE("/authenticator/users/:userID", accounts.deleteOther)
+
 	r.GET("/authenticator/get-all-users", func(c *gin.Context) {
 		users, err := dbClient.User().FindAll(c)
 		if err != nil {
@@ -0,0 +1,413 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/MaxBlaushild/authenticator/internal/webauthn"
+	"github.com/MaxBlaushild/poltergeist/pkg/auth"
+	"github.com/MaxBlaushild/poltergeist/pkg/db"
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+const maxPasskeyNameLength = 100
+
+var errPasskeyRejected = errors.New("passkey not recognized")
+
+// passkeyManager registers passkeys for signed-in users and signs users in
+// with them. The WebAuthn user handle is the user's ID, so a passkey always
+// belongs to exactly one models.User.
+type passkeyManager struct {
+	dbClient db.DbClient
+	sessions *sessionManager
+	rp       *webauthn.RelyingParty
+}
+
+func (m *passkeyManager) beginRegistration(c *gin.Context) {
+	var requestBody auth.VerifyTokenRequest
+	if err := c.Bind(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	claims, err := m.sessions.authenticate(c, requestBody.Token)
+	if err != nil {
+		c.JSON(sessionErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	user, err := m.dbClient.User().FindByID(c, claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	existing, err := m.dbClient.Passkey().FindByUserID(c, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	exclude := make([]webauthn.CredentialDescriptor, 0, len(existing))
+	for _, credential := range existing {
+		exclude = append(exclude, webauthn.CredentialDescriptor{Type: "public-key", ID: credential.CredentialID, Transports: credential.Transports})
+	}
+
+	challenge, expiresAt, err := m.newChallenge(c, models.AuthChallengeKindPasskeyRegistration, user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	displayName := user.Name
+	if displayName == "" {
+		displayName = accountName(user)
+	}
+	options := m.rp.CreationOptions(challenge, user.ID[:], accountName(user), displayName, exclude)
+	respondWithOptions(c, options, expiresAt)
+}
+
+func (m *passkeyManager) finishRegistration(c *gin.Context) {
+	var requestBody auth.PasskeyRegistrationRequest
+	if err := c.Bind(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	claims, err := m.sessions.authenticate(c, requestBody.Token)
+	if err != nil {
+		c.JSON(sessionErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	var response webauthn.RegistrationResponse
+	if err := json.Unmarshal(requestBody.Credential, &response); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": webauthn.ErrInvalidResponse.Error(),
+		})
+		return
+	}
+	challenge, err := m.consumeChallenge(c, models.AuthChallengeKindPasskeyRegistration, response.Response.ClientDataJSON)
+	if err != nil {
+		c.JSON(passkeyErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if challenge.record.UserID == nil || *challenge.record.UserID != claims.UserID {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": errPasskeyRejected.Error(),
+		})
+		return
+	}
+
+	verified, err := m.rp.VerifyRegistration(response, challenge.value)
+	if err != nil {
+		log.Printf("[auth][passkeys] registration rejected user_id=%s err=%v", claims.UserID, err)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if existing, err := m.dbClient.Passkey().FindByCredentialID(c, verified.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	} else if existing != nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "passkey is already registered",
+		})
+		return
+	}
+
+	name := strings.TrimSpace(requestBody.Name)
+	if name == "" {
+		name = deviceName(c)
+	}
+	if len(name) > maxPasskeyNameLength {
+		name = name[:maxPasskeyNameLength]
+	}
+	credential := &models.WebAuthnCredential{
+		UserID:       claims.UserID,
+		CredentialID: verified.ID,
+		PublicKey:    verified.PublicKey,
+		Algorithm:    verified.Algorithm,
+		SignCount:    verified.SignCount,
+		AAGUID:       verified.AAGUID,
+		Name:         name,
+		Transports:   verified.Transports,
+	}
+	if err := m.dbClient.Passkey().Create(c, credential); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	log.Printf("[auth][passkeys] registered user_id=%s passkey_id=%s", claims.UserID, credential.ID)
+
+	c.JSON(http.StatusOK, credential)
+}
+
+// beginLogin needs no username: the browser offers whichever of the site's
+// passkeys the user picks, and the assertion says whose it is.
+func (m *passkeyManager) beginLogin(c *gin.Context) {
+	challenge, expiresAt, err := m.newChallenge(c, models.AuthChallengeKindPasskeyAuthentication, nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	respondWithOptions(c, m.rp.RequestOptions(challenge), expiresAt)
+}
+
+// finishLogin signs the passkey's owner in. A passkey that verified the
+// user (biometric or PIN) is two factors on its own; one that only proved
+// presence counts as a first factor, so TOTP may still be asked for.
+func (m *passkeyManager) finishLogin(c *gin.Context) {
+	var requestBody auth.PasskeyLoginRequest
+	if err := c.Bind(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	var response webauthn.AuthenticationResponse
+	if err := json.Unmarshal(requestBody.Credential, &response); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": webauthn.ErrInvalidResponse.Error(),
+		})
+		return
+	}
+	challenge, err := m.consumeChallenge(c, models.AuthChallengeKindPasskeyAuthentication, response.Response.ClientDataJSON)
+	if err != nil {
+		c.JSON(passkeyErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	rawID, err := webauthn.Decode(response.RawID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": webauthn.ErrInvalidResponse.Error(),
+		})
+		return
+	}
+	credential, err := m.dbClient.Passkey().FindByCredentialID(c, webauthn.Encode(rawID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if credential == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": errPasskeyRejected.Error(),
+		})
+		return
+	}
+	if response.Response.UserHandle != "" {
+		userHandle, err := webauthn.Decode(response.Response.UserHandle)
+		if err != nil || !bytes.Equal(userHandle, credential.UserID[:]) {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": errPasskeyRejected.Error(),
+			})
+			return
+		}
+	}
+
+	assertion, err := m.rp.VerifyAuthentication(response, challenge.value, credential.PublicKey)
+	if err != nil {
+		log.Printf("[auth][passkeys] sign-in rejected passkey_id=%s err=%v", credential.ID, err)
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": errPasskeyRejected.Error(),
+		})
+		return
+	}
+	counted, err := m.dbClient.Passkey().RecordUse(c, credential.ID, assertion.SignCount)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if !counted {
+		// A counter that didn't move forward means two copies of the key.
+		log.Printf("[auth][passkeys] sign count went backwards passkey_id=%s user_id=%s count=%d", credential.ID, credential.UserID, assertion.SignCount)
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": errPasskeyRejected.Error(),
+		})
+		return
+	}
+
+	user, err := m.dbClient.User().FindByID(c, credential.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	var body gin.H
+	if assertion.UserVerified {
+		body, err = m.sessions.issue(c, user, true)
+	} else {
+		body, err = m.sessions.login(c, user)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, body)
+}
+
+func (m *passkeyManager) list(c *gin.Context) {
+	var requestBody auth.VerifyTokenRequest
+	if err := c.Bind(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	claims, err := m.sessions.authenticate(c, requestBody.Token)
+	if err != nil {
+		c.JSON(sessionErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	passkeys, err := m.dbClient.Passkey().FindByUserID(c, claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, auth.PasskeysResponse{Passkeys: passkeys})
+}
+
+func (m *passkeyManager) delete(c *gin.Context) {
+	var requestBody auth.DeletePasskeyRequest
+	if err := c.Bind(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	claims, err := m.sessions.authenticate(c, requestBody.Token)
+	if err != nil {
+		c.JSON(sessionErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	deleted, err := m.dbClient.Passkey().Delete(c, claims.UserID, requestBody.PasskeyID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if !deleted {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "passkey not found",
+		})
+		return
+	}
+	log.Printf("[auth][passkeys] deleted user_id=%s passkey_id=%s", claims.UserID, requestBody.PasskeyID)
+
+	c.JSON(http.StatusOK, gin.H{"deleted": true})
+}
+
+// newChallenge stores a ceremony's challenge, bound to the user when there
+// is one.
+func (m *passkeyManager) newChallenge(c *gin.Context, kind string, user *models.User) (string, time.Time, error) {
+	challenge, err := webauthn.NewChallenge()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	record := &models.AuthChallenge{
+		Kind:          kind,
+		ChallengeHash: models.HashChallenge(challenge),
+		ExpiresAt:     time.Now().Add(m.rp.Timeout()),
+	}
+	if user != nil {
+		record.UserID = &user.ID
+	}
+	if err := m.dbClient.AuthChallenge().Create(c, record); err != nil {
+		return "", time.Time{}, errors.Wrap(err, "challenge creation error")
+	}
+	return challenge, record.ExpiresAt, nil
+}
+
+type passkeyChallenge struct {
+	value  string
+	record *models.AuthChallenge
+}
+
+// consumeChallenge finds the ceremony a response answers and uses it up,
+// so a response can't be replayed even if it then fails verification.
+func (m *passkeyManager) consumeChallenge(c *gin.Context, kind string, clientDataJSON string) (*passkeyChallenge, error) {
+	value, err := webauthn.ClientChallenge(clientDataJSON)
+	if err != nil {
+		return nil, err
+	}
+	record, err := m.dbClient.AuthChallenge().FindActive(c, kind, models.HashChallenge(value))
+	if err != nil {
+		return nil, storageError{err}
+	}
+	if record == nil {
+		return nil, errPasskeyRejected
+	}
+	consumed, err := m.dbClient.AuthChallenge().Consume(c, record.ID)
+	if err != nil {
+		return nil, storageError{err}
+	}
+	if !consumed {
+		return nil, errPasskeyRejected
+	}
+	return &passkeyChallenge{value: value, record: record}, nil
+}
+
+func passkeyErrorStatus(err error) int {
+	if errors.Is(err, webauthn.ErrInvalidResponse) {
+		return http.StatusBadRequest
+	}
+	return sessionErrorStatus(err)
+}
+
+func respondWithOptions(c *gin.Context, options interface{}, expiresAt time.Time) {
+	publicKey, err := json.Marshal(options)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, auth.PasskeyOptionsResponse{PublicKey: publicKey, ExpiresAt: expiresAt})
+}
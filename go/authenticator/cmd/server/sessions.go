@@ -0,0 +1,362 @@
+package main
+
+import (
+	"context"
+	stderrors "errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/MaxBlaushild/authenticator/internal/config"
+	"github.com/MaxBlaushild/authenticator/internal/token"
+	"github.com/MaxBlaushild/poltergeist/pkg/auth"
+	"github.com/MaxBlaushild/poltergeist/pkg/db"
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	deviceNameHeader    = "X-Device-Name"
+	maxDeviceNameLength = 100
+	defaultAccessTTL    = 15 * time.Minute
+	defaultRefreshTTL   = 30 * 24 * time.Hour
+	bearerPrefix        = "Bearer "
+	mfaTokenTTL         = 5 * time.Minute
+)
+
+var (
+	errSessionRevoked      = errors.New("session has been revoked or has expired")
+	errLegacyTokenRetired  = errors.New("legacy token is no longer accepted, sign in again")
+	errInvalidRefreshToken = errors.New("invalid refresh token")
+	errRefreshTokenReused  = errors.New("refresh token was already used, session revoked")
+)
+
+type sessionSettings struct {
+	AccessTokenTTL    time.Duration
+	RefreshTokenTTL   time.Duration
+	LegacyTokenCutoff *time.Time
+}
+
+func parseSessionSettings(cfg config.PublicConfig) (sessionSettings, error) {
+	settings := sessionSettings{
+		AccessTokenTTL:  defaultAccessTTL,
+		RefreshTokenTTL: defaultRefreshTTL,
+	}
+
+	if cfg.AccessTokenTTL != "" {
+		ttl, err := time.ParseDuration(cfg.AccessTokenTTL)
+		if err != nil {
+			return settings, errors.Wrap(err, "invalid ACCESS_TOKEN_TTL")
+		}
+		settings.AccessTokenTTL = ttl
+	}
+
+	if cfg.RefreshTokenTTL != "" {
+		ttl, err := time.ParseDuration(cfg.RefreshTokenTTL)
+		if err != nil {
+			return settings, errors.Wrap(err, "invalid REFRESH_TOKEN_TTL")
+		}
+		settings.RefreshTokenTTL = ttl
+	}
+
+	if cfg.LegacyTokenCutoff != "" {
+		cutoff, err := time.Parse(time.RFC3339, cfg.LegacyTokenCutoff)
+		if err != nil {
+			return settings, errors.Wrap(err, "invalid LEGACY_TOKEN_CUTOFF")
+		}
+		settings.LegacyTokenCutoff = &cutoff
+	}
+
+	return settings, nil
+}
+
+// legacyTokenAllowed keeps pre-session tokens working until the configured
+// cutoff so clients have time to trade them in at /token/exchange.
+func (s sessionSettings) legacyTokenAllowed(now time.Time) bool {
+	return s.LegacyTokenCutoff == nil || now.Before(*s.LegacyTokenCutoff)
+}
+
+type sessionManager struct {
+	dbClient    db.DbClient
+	tokenClient token.Client
+	redisClient *redis.Client
+	settings    sessionSettings
+}
+
+// login finishes a first-factor sign-in. Users with TOTP turned on get an
+// MFA token to redeem at /mfa/verify instead of a session.
+func (m *sessionManager) login(c *gin.Context, user *models.User) (gin.H, error) {
+	enrollment, err := m.dbClient.TwoFactor().FindTOTP(c, user.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, "two-factor lookup error")
+	}
+	if !enrollment.Confirmed() {
+		return m.issue(c, user, false)
+	}
+
+	mfaToken, err := m.tokenClient.NewRefreshToken()
+	if err != nil {
+		return nil, errors.Wrap(err, "mfa token creation error")
+	}
+	challenge := &models.AuthChallenge{
+		Kind:          models.AuthChallengeKindMFA,
+		ChallengeHash: models.HashChallenge(mfaToken),
+		UserID:        &user.ID,
+		ExpiresAt:     time.Now().Add(mfaTokenTTL),
+	}
+	if err := m.dbClient.AuthChallenge().Create(c, challenge); err != nil {
+		return nil, errors.Wrap(err, "mfa challenge creation error")
+	}
+
+	return gin.H{
+		"mfaRequired": true,
+		"mfaToken":    mfaToken,
+		"expiresAt":   challenge.ExpiresAt,
+	}, nil
+}
+
+// issue starts a new session for a user who has just proven who they are
+// and returns the login response body. mfaVerified is true when that proof
+// included a second factor.
+func (m *sessionManager) issue(c *gin.Context, user *models.User, mfaVerified bool) (gin.H, error) {
+	refreshToken, err := m.tokenClient.NewRefreshToken()
+	if err != nil {
+		return nil, errors.Wrap(err, "refresh token creation error")
+	}
+
+	session := &models.AuthSession{
+		UserID:           user.ID,
+		RefreshTokenHash: models.HashRefreshToken(refreshToken),
+		DeviceName:       deviceName(c),
+		UserAgent:        c.Request.UserAgent(),
+		IPAddress:        c.ClientIP(),
+		ExpiresAt:        time.Now().Add(m.settings.RefreshTokenTTL),
+	}
+	if mfaVerified {
+		now := time.Now()
+		session.MFAVerifiedAt = &now
+	}
+	if err := m.dbClient.AuthSession().Create(c, session); err != nil {
+		return nil, errors.Wrap(err, "session creation error")
+	}
+
+	scopes, withheld, err := m.sessionScopes(c, user.ID, session)
+	if err != nil {
+		return nil, errors.Wrap(err, "scope lookup error")
+	}
+	user.Scopes = scopes
+
+	accessToken, expiresAt, err := m.tokenClient.New(user.ID, session.ID, scopes)
+	if err != nil {
+		return nil, errors.Wrap(err, "jwt creation error")
+	}
+
+	return sessionResponse(user, accessToken, expiresAt, refreshToken, session.ID, withheld), nil
+}
+
+// refresh trades a refresh token for a new access token and a new refresh
+// token. Presenting a refresh token that was already rotated out means it
+// leaked, so the whole session is revoked.
+func (m *sessionManager) refresh(c *gin.Context, refreshToken string) (gin.H, error) {
+	session, current, err := m.dbClient.AuthSession().FindByRefreshToken(c, refreshToken)
+	if err != nil {
+		return nil, storageError{err}
+	}
+	if session == nil {
+		return nil, errInvalidRefreshToken
+	}
+	if !current {
+		if err := m.dbClient.AuthSession().Revoke(c, session.ID); err != nil {
+			return nil, storageError{err}
+		}
+		m.publishRevoked(c, session.UserID, []uuid.UUID{session.ID})
+		log.Printf("[auth][refresh] reused refresh token session_id=%s user_id=%s ip=%s", session.ID, session.UserID, c.ClientIP())
+		return nil, errRefreshTokenReused
+	}
+	if !session.Active(time.Now()) {
+		return nil, errSessionRevoked
+	}
+
+	nextRefreshToken, err := m.tokenClient.NewRefreshToken()
+	if err != nil {
+		return nil, storageError{errors.Wrap(err, "refresh token creation error")}
+	}
+	rotated, err := m.dbClient.AuthSession().Rotate(c, session.ID, refreshToken, nextRefreshToken, c.ClientIP(), time.Now().Add(m.settings.RefreshTokenTTL))
+	if err != nil {
+		return nil, storageError{err}
+	}
+	if !rotated {
+		return nil, errInvalidRefreshToken
+	}
+
+	user, err := m.dbClient.User().FindByID(c, session.UserID)
+	if err != nil {
+		return nil, storageError{err}
+	}
+
+	// Refreshing is how a grant or revocation reaches the token.
+	scopes, withheld, err := m.sessionScopes(c, user.ID, session)
+	if err != nil {
+		return nil, storageError{err}
+	}
+	user.Scopes = scopes
+
+	accessToken, expiresAt, err := m.tokenClient.New(user.ID, session.ID, scopes)
+	if err != nil {
+		return nil, storageError{errors.Wrap(err, "jwt creation error")}
+	}
+
+	return sessionResponse(user, accessToken, expiresAt, nextRefreshToken, session.ID, withheld), nil
+}
+
+// sessionScopes returns the scopes tokens for the session may carry. Role
+// scopes need a second factor, so a session that never passed one gets
+// none; withheld reports that the user had some.
+func (m *sessionManager) sessionScopes(ctx context.Context, userID uuid.UUID, session *models.AuthSession) ([]string, bool, error) {
+	scopes, err := m.dbClient.Authorization().FindScopesForUser(ctx, userID)
+	if err != nil {
+		return nil, false, err
+	}
+	if session == nil || session.MFAVerifiedAt == nil {
+		return []string{}, len(scopes) > 0, nil
+	}
+	return scopes, false, nil
+}
+
+func sessionResponse(user *models.User, accessToken string, expiresAt time.Time, refreshToken string, sessionID uuid.UUID, scopesWithheld bool) gin.H {
+	response := gin.H{
+		"user":         user,
+		"token":        accessToken,
+		"expiresAt":    expiresAt,
+		"refreshToken": refreshToken,
+		"sessionId":    sessionID,
+	}
+	if scopesWithheld {
+		response["mfaEnrollmentRequired"] = true
+	}
+	return response
+}
+
+// authenticate verifies an access token and checks that the session it was
+// issued for is still live.
+func (m *sessionManager) authenticate(ctx context.Context, tokenString string) (*token.Claims, error) {
+	claims, _, err := m.authenticateSession(ctx, tokenString)
+	return claims, err
+}
+
+// authenticateSession is authenticate, also returning the session. The
+// session is nil for legacy tokens.
+func (m *sessionManager) authenticateSession(ctx context.Context, tokenString string) (*token.Claims, *models.AuthSession, error) {
+	claims, err := m.tokenClient.Verify(tokenString)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if claims.Legacy() {
+		if !m.settings.legacyTokenAllowed(time.Now()) {
+			return nil, nil, errLegacyTokenRetired
+		}
+		return claims, nil, nil
+	}
+
+	session, err := m.dbClient.AuthSession().FindByID(ctx, *claims.SessionID)
+	if err != nil {
+		return nil, nil, storageError{err}
+	}
+	if session == nil || session.UserID != claims.UserID || !session.Active(time.Now()) {
+		return nil, nil, errSessionRevoked
+	}
+
+	return claims, session, nil
+}
+
+// requireScope checks the caller's current scopes, as /token/verify would
+// report them, since these routes are reached through other services. It
+// answers the request itself when they fall short.
+func (m *sessionManager) requireScope(c *gin.Context, tokenString string, scope string) (*token.Claims, bool) {
+	claims, session, err := m.authenticateSession(c, tokenString)
+	if err != nil {
+		c.JSON(sessionErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return nil, false
+	}
+	scopes, _, err := m.sessionScopes(c, claims.UserID, session)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return nil, false
+	}
+	if !models.ScopeAllows(scopes, scope) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "missing scope " + scope,
+		})
+		return nil, false
+	}
+	return claims, true
+}
+
+// removeWithSessions runs remove, which deletes the user's sessions along
+// with the account, then tells services that verify tokens locally that the
+// sessions active beforehand are gone. When remove fails nobody is signed
+// out.
+func (m *sessionManager) removeWithSessions(ctx context.Context, userID uuid.UUID, remove func() error) error {
+	activeSessions, err := m.dbClient.AuthSession().FindActiveByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := remove(); err != nil {
+		return err
+	}
+	revokedIDs := make([]uuid.UUID, 0, len(activeSessions))
+	for _, session := range activeSessions {
+		revokedIDs = append(revokedIDs, session.ID)
+	}
+	m.publishRevoked(ctx, userID, revokedIDs)
+	return nil
+}
+
+// publishRevoked lets services that verify tokens locally stop honoring
+// the sessions before their access tokens expire.
+func (m *sessionManager) publishRevoked(ctx context.Context, userID uuid.UUID, sessionIDs []uuid.UUID) {
+	if err := auth.PublishSessionsRevoked(ctx, m.redisClient, userID, sessionIDs); err != nil {
+		log.Printf("[auth][events] publish sessions revoked failed user_id=%s err=%v", userID, err)
+	}
+}
+
+// storageError marks failures that are ours rather than the caller's, so
+// they surface as 500s instead of 401s.
+type storageError struct {
+	error
+}
+
+func (e storageError) Unwrap() error {
+	return e.error
+}
+
+func sessionErrorStatus(err error) int {
+	var se storageError
+	if stderrors.As(err, &se) {
+		return http.StatusInternalServerError
+	}
+	return http.StatusUnauthorized
+}
+
+func deviceName(c *gin.Context) string {
+	name := strings.TrimSpace(c.GetHeader(deviceNameHeader))
+	if len(name) > maxDeviceNameLength {
+		name = name[:maxDeviceNameLength]
+	}
+	return name
+}
+
+// bearerToken reads the access token from the Authorization header, for
+// routes whose method carries no body.
+func bearerToken(c *gin.Context) string {
+	return strings.TrimPrefix(c.GetHeader("Authorization"), bearerPrefix)
+}
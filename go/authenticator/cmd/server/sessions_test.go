@@ -0,0 +1,44 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MaxBlaushild/authenticator/internal/config"
+)
+
+func TestParseSessionSettingsDefaults(t *testing.T) {
+	settings, err := parseSessionSettings(config.PublicConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settings.AccessTokenTTL != defaultAccessTTL || settings.RefreshTokenTTL != defaultRefreshTTL {
+		t.Fatalf("unexpected defaults %+v", settings)
+	}
+	if !settings.legacyTokenAllowed(time.Now()) {
+		t.Fatal("legacy tokens should be accepted until a cutoff is configured")
+	}
+}
+
+func TestLegacyTokensRetireAtCutoff(t *testing.T) {
+	settings, err := parseSessionSettings(config.PublicConfig{
+		AccessTokenTTL:    "5m",
+		LegacyTokenCutoff: "2026-01-01T00:00:00Z",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settings.AccessTokenTTL != 5*time.Minute {
+		t.Fatalf("expected 5m access tokens, got %v", settings.AccessTokenTTL)
+	}
+	if !settings.legacyTokenAllowed(time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC)) {
+		t.Fatal("expected legacy tokens before the cutoff")
+	}
+	if settings.legacyTokenAllowed(time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Fatal("expected legacy tokens to be rejected after the cutoff")
+	}
+
+	if _, err := parseSessionSettings(config.PublicConfig{RefreshTokenTTL: "a month"}); err == nil {
+		t.Fatal("expected an invalid duration to be rejected")
+	}
+}
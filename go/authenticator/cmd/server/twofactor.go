@@ -0,0 +1,479 @@
+package main
+
+import (
+	"context"
+	stderrors "errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/MaxBlaushild/authenticator/internal/token"
+	"github.com/MaxBlaushild/authenticator/internal/totp"
+	"github.com/MaxBlaushild/poltergeist/pkg/auth"
+	"github.com/MaxBlaushild/poltergeist/pkg/db"
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// maxMFAAttempts is how many wrong codes one MFA token survives before the
+// user has to sign in again.
+const maxMFAAttempts = 5
+
+var (
+	errInvalidMFAToken   = errors.New("sign-in expired, start again")
+	errInvalidSecondCode = errors.New("invalid code")
+	errTOTPNotEnabled    = errors.New("two-factor authentication is not enabled")
+)
+
+type twoFactorManager struct {
+	dbClient db.DbClient
+	sessions *sessionManager
+	abuse    *abuseGuard
+	cipher   *totp.Cipher
+	issuer   string
+}
+
+// verifyMFA redeems the MFA token from a first-factor login, plus a TOTP or
+// recovery code, for a session.
+func (m *twoFactorManager) verifyMFA(c *gin.Context) {
+	var requestBody auth.VerifyMFARequest
+	if err := c.Bind(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	challenge, err := m.dbClient.AuthChallenge().FindActive(c, models.AuthChallengeKindMFA, models.HashChallenge(requestBody.MFAToken))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if challenge == nil || challenge.UserID == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": errInvalidMFAToken.Error(),
+		})
+		return
+	}
+	// Per user as well as per token, since each sign-in mints a new token.
+	account := userActor(*challenge.UserID)
+	if !m.abuse.allowLogin(c, account) {
+		return
+	}
+
+	if err := m.checkSecondFactor(c, *challenge.UserID, requestBody.Code, requestBody.RecoveryCode); err != nil {
+		if stderrors.Is(err, errInvalidSecondCode) {
+			m.abuse.failedLogin(c, account)
+			attempts, recordErr := m.dbClient.AuthChallenge().RecordFailedAttempt(c, challenge.ID, maxMFAAttempts)
+			if recordErr != nil {
+				log.Printf("[auth][mfa] record failed attempt failed challenge_id=%s err=%v", challenge.ID, recordErr)
+			}
+			log.Printf("[auth][mfa] wrong code user_id=%s attempts=%d ip=%s", *challenge.UserID, attempts, c.ClientIP())
+		}
+		c.JSON(secondFactorErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	consumed, err := m.dbClient.AuthChallenge().Consume(c, challenge.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if !consumed {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": errInvalidMFAToken.Error(),
+		})
+		return
+	}
+	m.abuse.succeededLogin(c, account)
+
+	user, err := m.dbClient.User().FindByID(c, *challenge.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	response, err := m.sessions.issue(c, user, true)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (m *twoFactorManager) status(c *gin.Context) {
+	claims, ok := m.authenticate(c)
+	if !ok {
+		return
+	}
+
+	enrollment, err := m.dbClient.TwoFactor().FindTOTP(c, claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	remaining, err := m.dbClient.TwoFactor().CountUnusedRecoveryCodes(c, claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	passkeys, err := m.dbClient.Passkey().FindByUserID(c, claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	scopes, err := m.dbClient.Authorization().FindScopesForUser(c, claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, auth.TwoFactorStatusResponse{
+		TOTPEnabled:            enrollment.Confirmed(),
+		RecoveryCodesRemaining: remaining,
+		Passkeys:               len(passkeys),
+		Required:               len(scopes) > 0,
+	})
+}
+
+// enroll starts TOTP enrollment with a fresh secret. Nothing changes for
+// the user until they confirm a code from it.
+func (m *twoFactorManager) enroll(c *gin.Context) {
+	claims, ok := m.authenticate(c)
+	if !ok {
+		return
+	}
+	if !m.cipher.Configured() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": totp.ErrNoKey.Error(),
+		})
+		return
+	}
+
+	user, err := m.dbClient.User().FindByID(c, claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	secret, err := totp.NewSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	ciphertext, err := m.cipher.Seal(secret)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	started, err := m.dbClient.TwoFactor().StartTOTPEnrollment(c, user.ID, ciphertext)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if !started {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "two-factor authentication is already enabled",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, auth.TOTPEnrollmentResponse{
+		Secret:     secret,
+		OtpauthURL: totp.ProvisioningURI(m.issuer, accountName(user), secret),
+	})
+}
+
+// confirm turns TOTP on once the user types back a code from the new
+// secret. The session confirming it counts as having passed a second
+// factor, so an admin enrolling here gets their scopes on next refresh.
+func (m *twoFactorManager) confirm(c *gin.Context) {
+	var requestBody auth.TOTPCodeRequest
+	if err := c.Bind(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	claims, session, err := m.sessions.authenticateSession(c, requestBody.Token)
+	if err != nil {
+		c.JSON(sessionErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	enrollment, err := m.dbClient.TwoFactor().FindTOTP(c, claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if enrollment == nil || enrollment.Confirmed() {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "no two-factor enrollment in progress",
+		})
+		return
+	}
+	secret, err := m.cipher.Open(enrollment.SecretCiphertext)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	step, err := totp.Validate(secret, requestBody.Code, time.Now())
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": errInvalidSecondCode.Error(),
+		})
+		return
+	}
+
+	codes, hashes, err := totp.NewRecoveryCodes()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err := m.dbClient.TwoFactor().ConfirmTOTP(c, claims.UserID, step, hashes); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if session != nil {
+		if err := m.dbClient.AuthSession().MarkMFAVerified(c, session.ID); err != nil {
+			log.Printf("[auth][totp] mark session verified failed session_id=%s err=%v", session.ID, err)
+		}
+	}
+	m.publishSecondFactorChanged(c, claims.UserID)
+	log.Printf("[auth][totp] enabled user_id=%s", claims.UserID)
+
+	c.JSON(http.StatusOK, auth.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// disable turns TOTP off. Every session loses its second-factor mark, so
+// admin scopes need a fresh second factor (a passkey) to come back.
+func (m *twoFactorManager) disable(c *gin.Context) {
+	var requestBody auth.TOTPCodeRequest
+	if err := c.Bind(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	claims, err := m.sessions.authenticate(c, requestBody.Token)
+	if err != nil {
+		c.JSON(sessionErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err := m.checkCodeOrRecoveryCode(c, claims.UserID, requestBody.Code); err != nil {
+		c.JSON(secondFactorErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if err := m.dbClient.TwoFactor().DeleteTOTP(c, claims.UserID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err := m.dbClient.AuthSession().ClearMFAVerifiedForUser(c, claims.UserID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	m.publishSecondFactorChanged(c, claims.UserID)
+	log.Printf("[auth][totp] disabled user_id=%s ip=%s", claims.UserID, c.ClientIP())
+
+	c.JSON(http.StatusOK, gin.H{"disabled": true})
+}
+
+// regenerateRecoveryCodes replaces every recovery code, used or not.
+func (m *twoFactorManager) regenerateRecoveryCodes(c *gin.Context) {
+	var requestBody auth.TOTPCodeRequest
+	if err := c.Bind(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	claims, err := m.sessions.authenticate(c, requestBody.Token)
+	if err != nil {
+		c.JSON(sessionErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err := m.checkCodeOrRecoveryCode(c, claims.UserID, requestBody.Code); err != nil {
+		c.JSON(secondFactorErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	codes, hashes, err := totp.NewRecoveryCodes()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if err := m.dbClient.TwoFactor().ReplaceRecoveryCodes(c, claims.UserID, hashes); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, auth.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// checkCodeOrRecoveryCode accepts either kind of code in one field, telling
+// them apart by shape: TOTP codes are six digits.
+func (m *twoFactorManager) checkCodeOrRecoveryCode(ctx context.Context, userID uuid.UUID, code string) error {
+	if isTOTPCode(code) {
+		return m.checkSecondFactor(ctx, userID, code, "")
+	}
+	return m.checkSecondFactor(ctx, userID, "", code)
+}
+
+// checkSecondFactor spends a TOTP code's time step or a recovery code, so
+// neither can be used twice.
+func (m *twoFactorManager) checkSecondFactor(ctx context.Context, userID uuid.UUID, code string, recoveryCode string) error {
+	enrollment, err := m.dbClient.TwoFactor().FindTOTP(ctx, userID)
+	if err != nil {
+		return storageError{err}
+	}
+	if !enrollment.Confirmed() {
+		return errTOTPNotEnabled
+	}
+
+	if strings.TrimSpace(recoveryCode) != "" {
+		used, err := m.dbClient.TwoFactor().UseRecoveryCode(ctx, userID, totp.HashRecoveryCode(recoveryCode))
+		if err != nil {
+			return storageError{err}
+		}
+		if !used {
+			return errInvalidSecondCode
+		}
+		log.Printf("[auth][mfa] recovery code used user_id=%s", userID)
+		return nil
+	}
+
+	secret, err := m.cipher.Open(enrollment.SecretCiphertext)
+	if err != nil {
+		return storageError{err}
+	}
+	step, err := totp.Validate(secret, code, time.Now())
+	if err != nil {
+		return errInvalidSecondCode
+	}
+	fresh, err := m.dbClient.TwoFactor().UseTOTPStep(ctx, userID, step)
+	if err != nil {
+		return storageError{err}
+	}
+	if !fresh {
+		return errInvalidSecondCode
+	}
+	return nil
+}
+
+func (m *twoFactorManager) authenticate(c *gin.Context) (*token.Claims, bool) {
+	var requestBody auth.VerifyTokenRequest
+	if err := c.Bind(&requestBody); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return nil, false
+	}
+	claims, err := m.sessions.authenticate(c, requestBody.Token)
+	if err != nil {
+		c.JSON(sessionErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return nil, false
+	}
+	return claims, true
+}
+
+// publishSecondFactorChanged stops services trusting scopes in tokens minted
+// before the change; they ask the authenticator, which knows which sessions
+// passed a second factor.
+func (m *twoFactorManager) publishSecondFactorChanged(ctx context.Context, userID uuid.UUID) {
+	if err := auth.PublishRolesChanged(ctx, m.sessions.redisClient, userID); err != nil {
+		log.Printf("[auth][events] publish roles changed failed user_id=%s err=%v", userID, err)
+	}
+}
+
+func secondFactorErrorStatus(err error) int {
+	if stderrors.Is(err, errTOTPNotEnabled) {
+		return http.StatusConflict
+	}
+	return sessionErrorStatus(err)
+}
+
+func isTOTPCode(code string) bool {
+	code = strings.TrimSpace(code)
+	if len(code) != 6 {
+		return false
+	}
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// accountName labels the user's entry in their authenticator app.
+func accountName(user *models.User) string {
+	switch {
+	case user.Email != nil && *user.Email != "":
+		return *user.Email
+	case user.Username != nil && *user.Username != "":
+		return *user.Username
+	case user.PhoneNumber != "":
+		return user.PhoneNumber
+	}
+	return user.ID.String()
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/google/uuid"
+)
+
+func TestIsTOTPCode(t *testing.T) {
+	for code, want := range map[string]bool{
+		"123456":    true,
+		" 123456 ":  true,
+		"12345":     false,
+		"12345a":    false,
+		"abcd-efgh": false,
+	} {
+		if got := isTOTPCode(code); got != want {
+			t.Errorf("isTOTPCode(%q) = %v, want %v", code, got, want)
+		}
+	}
+}
+
+func TestSessionResponseFlagsWithheldScopes(t *testing.T) {
+	user := &models.User{ID: uuid.New()}
+
+	response := sessionResponse(user, "access", time.Now(), "refresh", uuid.New(), true)
+	if response["mfaEnrollmentRequired"] != true {
+		t.Fatalf("expected withheld scopes to be flagged, got %v", response)
+	}
+	response = sessionResponse(user, "access", time.Now(), "refresh", uuid.New(), false)
+	if _, ok := response["mfaEnrollmentRequired"]; ok {
+		t.Fatalf("expected no flag when nothing was withheld, got %v", response)
+	}
+}
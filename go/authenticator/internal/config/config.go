@@ -3,6 +3,7 @@ package config
 import (
 	"flag"
 	"os"
+	"strings"
 
 	"github.com/spf13/viper"
 )
@@ -10,6 +11,9 @@ import (
 type SecretConfig struct {
 	DbPassword     string
 	AuthPrivateKey string
+	// AuthRetiredPrivateKeys are comma-separated keys that no longer sign
+	// but whose tokens keep verifying until they expire.
+	AuthRetiredPrivateKeys []string
 	// GoogleClientID is also the audience checked when verifying "Sign in
 	// with Google" ID tokens — kept alongside the secret (rather than in
 	// PublicConfig/the baked-in live.env like PHONE_NUMBER) so both values
@@ -17,17 +21,47 @@ type SecretConfig struct {
 	// rotate.
 	GoogleClientID     string
 	GoogleClientSecret string
+	// TOTPEncryptionKey is a hex-encoded 32-byte AES key for the TOTP
+	// secrets stored in user_totp. Unset leaves TOTP enrollment disabled.
+	TOTPEncryptionKey string
+	// TwilioAccountSid and TwilioAuthToken send the codes that prove an
+	// email address before it is linked to an account.
+	TwilioAccountSid string
+	TwilioAuthToken  string
 }
 
 type PublicConfig struct {
-	DbHost        string `mapstructure:"DB_HOST"`
-	DbUser        string `mapstructure:"DB_USER"`
-	DbPort        string `mapstructure:"DB_PORT"`
-	DbName        string `mapstructure:"DB_NAME"`
-	RpID          string `mapstructure:"RP_ID"`
+	DbHost string `mapstructure:"DB_HOST"`
+	DbUser string `mapstructure:"DB_USER"`
+	DbPort string `mapstructure:"DB_PORT"`
+	DbName string `mapstructure:"DB_NAME"`
+	RpID   string `mapstructure:"RP_ID"`
+	// RpOrigin is a comma-separated list of origins passkeys may be used
+	// from; see RpOrigins.
 	RpOrigin      string `mapstructure:"RP_ORIGIN"`
 	RpDisplayName string `mapstructure:"RP_DISPLAY_NAME"`
 	PhoneNumber   string `mapstructure:"PHONE_NUMBER"`
+	// EmailFromAddress sends email link codes. Unset turns linking an
+	// email address off.
+	EmailFromAddress string `mapstructure:"EMAIL_FROM_ADDRESS"`
+	RedisUrl         string `mapstructure:"REDIS_URL"`
+	// TOTPIssuer labels the account in authenticator apps. Defaults to
+	// RP_DISPLAY_NAME.
+	TOTPIssuer string `mapstructure:"TOTP_ISSUER"`
+	// SmsAllowedPrefixes and SmsDeniedPrefixes are comma-separated dialing
+	// prefixes ("1,44") limiting which numbers get verification texts.
+	// Denied wins; no allowed prefixes means anywhere not denied.
+	SmsAllowedPrefixes string `mapstructure:"SMS_ALLOWED_PREFIXES"`
+	SmsDeniedPrefixes  string `mapstructure:"SMS_DENIED_PREFIXES"`
+	// SmsAppNames are the comma-separated app names verification texts may
+	// be sent for. Unset sends none.
+	SmsAppNames string `mapstructure:"SMS_APP_NAMES"`
+	// AccessTokenTTL and RefreshTokenTTL are Go durations ("15m", "720h").
+	AccessTokenTTL  string `mapstructure:"ACCESS_TOKEN_TTL"`
+	RefreshTokenTTL string `mapstructure:"REFRESH_TOKEN_TTL"`
+	// LegacyTokenCutoff is an RFC 3339 time after which tokens minted
+	// before sessions existed stop verifying. Unset keeps accepting them.
+	LegacyTokenCutoff string `mapstructure:"LEGACY_TOKEN_CUTOFF"`
 }
 
 type Config struct {
@@ -66,11 +100,44 @@ func ParseFlagsAndGetConfig() (*Config, error) {
 
 	return &Config{
 		Secret: SecretConfig{
-			DbPassword:         os.Getenv("DB_PASSWORD"),
-			AuthPrivateKey:     os.Getenv("AUTH_PRIVATE_KEY"),
-			GoogleClientID:     os.Getenv("GOOGLE_CLIENT_ID"),
-			GoogleClientSecret: os.Getenv("GOOGLE_CLIENT_SECRET"),
+			DbPassword:             os.Getenv("DB_PASSWORD"),
+			AuthPrivateKey:         os.Getenv("AUTH_PRIVATE_KEY"),
+			AuthRetiredPrivateKeys: splitList(os.Getenv("AUTH_RETIRED_PRIVATE_KEYS")),
+			GoogleClientID:         os.Getenv("GOOGLE_CLIENT_ID"),
+			GoogleClientSecret:     os.Getenv("GOOGLE_CLIENT_SECRET"),
+			TOTPEncryptionKey:      os.Getenv("TOTP_ENCRYPTION_KEY"),
+			TwilioAccountSid:       os.Getenv("TWILIO_ACCOUNT_SID"),
+			TwilioAuthToken:        os.Getenv("TWILIO_AUTH_TOKEN"),
 		},
 		Public: publicCfg,
 	}, nil
 }
+
+func (c PublicConfig) RpOrigins() []string {
+	return splitList(c.RpOrigin)
+}
+
+func (c PublicConfig) SmsPrefixes() (allowed []string, denied []string) {
+	return splitList(c.SmsAllowedPrefixes), splitList(c.SmsDeniedPrefixes)
+}
+
+func (c PublicConfig) SmsApps() []string {
+	return splitList(c.SmsAppNames)
+}
+
+func (c PublicConfig) TOTPIssuerName() string {
+	if c.TOTPIssuer != "" {
+		return c.TOTPIssuer
+	}
+	return c.RpDisplayName
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,378 @@
+// Package throttle limits how often an actor — a phone number, an IP, an
+// app — may do something, using Redis so every authenticator instance
+// shares the same counts. It has two tools: sliding-window limits for
+// things that cost us (sending an SMS) and exponential backoff for things
+// that fail (a wrong password). Actors that hit either are recorded so an
+// admin can see and clear them.
+//
+// A nil Redis client or a Redis error lets everything through: an outage
+// of the limiter should not stop people signing in.
+package throttle
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	keyPrefix  = "throttle:"
+	blockedKey = keyPrefix + "blocked"
+)
+
+// Window is a sliding-window limit: at most Limit events per Window.
+type Window struct {
+	Name   string
+	Limit  int
+	Window time.Duration
+}
+
+// Backoff blocks an actor after Free failures, for Base the first time and
+// doubling with every failure after, up to Max. Failures are forgotten
+// Reset after the last one.
+type Backoff struct {
+	Name  string
+	Free  int
+	Base  time.Duration
+	Max   time.Duration
+	Reset time.Duration
+}
+
+// Delay is how long the nth failure blocks for.
+func (b Backoff) Delay(failures int) time.Duration {
+	if failures <= b.Free {
+		return 0
+	}
+	delay := b.Base
+	for i := b.Free + 1; i < failures; i++ {
+		delay *= 2
+		if delay >= b.Max {
+			return b.Max
+		}
+	}
+	return min(delay, b.Max)
+}
+
+// Actor is who is being limited, e.g. {Kind: "phone", Value: "+15551234567"}.
+type Actor struct {
+	Kind  string
+	Value string
+}
+
+func (a Actor) String() string {
+	return a.Kind + ":" + a.Value
+}
+
+// Blocked is an actor that is currently being refused.
+type Blocked struct {
+	Key    string    `json:"key"`
+	Rule   string    `json:"rule"`
+	Kind   string    `json:"kind"`
+	Value  string    `json:"value"`
+	Reason string    `json:"reason"`
+	Until  time.Time `json:"until"`
+}
+
+type Limiter struct {
+	redis *redis.Client
+	now   func() time.Time
+}
+
+func New(redisClient *redis.Client) *Limiter {
+	return &Limiter{redis: redisClient, now: time.Now}
+}
+
+// slidingWindow drops events older than the window, then records this one
+// if there is room. It returns 0 when allowed, or how long until the
+// oldest event ages out.
+var slidingWindow = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", now - window)
+if redis.call("ZCARD", KEYS[1]) >= limit then
+	local oldest = redis.call("ZRANGE", KEYS[1], 0, 0, "WITHSCORES")
+	return math.max(1, tonumber(oldest[2]) + window - now)
+end
+redis.call("ZADD", KEYS[1], now, ARGV[4])
+redis.call("PEXPIRE", KEYS[1], window)
+return 0
+`)
+
+// Allow counts one event against the window for actor, returning how long
+// to wait when the window is already full (in which case nothing is
+// counted).
+func (l *Limiter) Allow(ctx context.Context, rule Window, actor Actor) time.Duration {
+	if l.redis == nil {
+		return 0
+	}
+	key := windowKey(rule.Name, actor)
+	now := l.now()
+	member := strconv.FormatInt(now.UnixNano(), 10)
+	wait, err := slidingWindow.Run(ctx, l.redis, []string{key},
+		now.UnixMilli(), rule.Window.Milliseconds(), rule.Limit, member).Int64()
+	if err != nil {
+		log.Printf("[auth][throttle] window check failed rule=%s actor=%s err=%v", rule.Name, actor, err)
+		return 0
+	}
+	if wait == 0 {
+		return 0
+	}
+	retryAfter := time.Duration(wait) * time.Millisecond
+	l.recordBlocked(ctx, Blocked{
+		Key:    key,
+		Rule:   rule.Name,
+		Kind:   actor.Kind,
+		Value:  actor.Value,
+		Reason: strconv.Itoa(rule.Limit) + " per " + rule.Window.String(),
+		Until:  now.Add(retryAfter),
+	})
+	return retryAfter
+}
+
+// Limit is one window applied to one actor, for AllowAll.
+type Limit struct {
+	Rule  Window
+	Actor Actor
+}
+
+// slidingWindows checks every window before recording the event in any of
+// them, so a request refused by one limit does not use up the others. It
+// returns 0 when allowed, or the index (from 1) of the window that refused
+// it and how long until that window's oldest event ages out.
+var slidingWindows = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local refused, longest = 0, 0
+for i, key in ipairs(KEYS) do
+	local window = tonumber(ARGV[2 * i + 1])
+	local limit = tonumber(ARGV[2 * i + 2])
+	redis.call("ZREMRANGEBYSCORE", key, "-inf", now - window)
+	if redis.call("ZCARD", key) >= limit then
+		local oldest = redis.call("ZRANGE", key, 0, 0, "WITHSCORES")
+		local wait = math.max(1, tonumber(oldest[2]) + window - now)
+		if wait > longest then
+			refused, longest = i, wait
+		end
+	end
+end
+if refused > 0 then
+	return {refused, longest}
+end
+for i, key in ipairs(KEYS) do
+	redis.call("ZADD", key, now, ARGV[2])
+	redis.call("PEXPIRE", key, tonumber(ARGV[2 * i + 1]))
+end
+return {0, 0}
+`)
+
+// AllowAll counts one event against every limit, or against none of them
+// when any is already full, returning the longest wait among the full
+// ones.
+func (l *Limiter) AllowAll(ctx context.Context, limits ...Limit) time.Duration {
+	if l.redis == nil || len(limits) == 0 {
+		return 0
+	}
+	now := l.now()
+	keys := make([]string, 0, len(limits))
+	args := []interface{}{now.UnixMilli(), strconv.FormatInt(now.UnixNano(), 10)}
+	for _, limit := range limits {
+		keys = append(keys, windowKey(limit.Rule.Name, limit.Actor))
+		args = append(args, limit.Rule.Window.Milliseconds(), limit.Rule.Limit)
+	}
+	result, err := slidingWindows.Run(ctx, l.redis, keys, args...).Int64Slice()
+	if err != nil || len(result) != 2 {
+		log.Printf("[auth][throttle] window check failed keys=%v err=%v", keys, err)
+		return 0
+	}
+	if result[0] == 0 {
+		return 0
+	}
+	limit := limits[result[0]-1]
+	retryAfter := time.Duration(result[1]) * time.Millisecond
+	l.recordBlocked(ctx, Blocked{
+		Key:    keys[result[0]-1],
+		Rule:   limit.Rule.Name,
+		Kind:   limit.Actor.Kind,
+		Value:  limit.Actor.Value,
+		Reason: strconv.Itoa(limit.Rule.Limit) + " per " + limit.Rule.Window.String(),
+		Until:  now.Add(retryAfter),
+	})
+	return retryAfter
+}
+
+// Wait reports how much longer actor is backed off for.
+func (l *Limiter) Wait(ctx context.Context, rule Backoff, actor Actor) time.Duration {
+	if l.redis == nil {
+		return 0
+	}
+	ttl, err := l.redis.PTTL(ctx, backoffKey(rule.Name, actor)+":until").Result()
+	if err != nil {
+		log.Printf("[auth][throttle] backoff check failed rule=%s actor=%s err=%v", rule.Name, actor, err)
+		return 0
+	}
+	return max(ttl, 0)
+}
+
+// Fail records a failure by actor and returns how long they are now backed
+// off for.
+func (l *Limiter) Fail(ctx context.Context, rule Backoff, actor Actor) time.Duration {
+	if l.redis == nil {
+		return 0
+	}
+	key := backoffKey(rule.Name, actor)
+	pipe := l.redis.TxPipeline()
+	failures := pipe.Incr(ctx, key)
+	pipe.PExpire(ctx, key, rule.Reset)
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("[auth][throttle] backoff record failed rule=%s actor=%s err=%v", rule.Name, actor, err)
+		return 0
+	}
+
+	delay := rule.Delay(int(failures.Val()))
+	if delay == 0 {
+		return 0
+	}
+	if err := l.redis.Set(ctx, key+":until", failures.Val(), delay).Err(); err != nil {
+		log.Printf("[auth][throttle] backoff block failed rule=%s actor=%s err=%v", rule.Name, actor, err)
+		return 0
+	}
+	l.recordBlocked(ctx, Blocked{
+		Key:    key,
+		Rule:   rule.Name,
+		Kind:   actor.Kind,
+		Value:  actor.Value,
+		Reason: strconv.FormatInt(failures.Val(), 10) + " failures",
+		Until:  l.now().Add(delay),
+	})
+	return delay
+}
+
+// Succeed forgets actor's failures.
+func (l *Limiter) Succeed(ctx context.Context, rule Backoff, actor Actor) {
+	if l.redis == nil {
+		return
+	}
+	key := backoffKey(rule.Name, actor)
+	if err := l.redis.Del(ctx, key, key+":until").Err(); err != nil {
+		log.Printf("[auth][throttle] backoff reset failed rule=%s actor=%s err=%v", rule.Name, actor, err)
+	}
+}
+
+// ListBlocked returns the actors being refused right now, soonest released
+// last.
+func (l *Limiter) ListBlocked(ctx context.Context) ([]Blocked, error) {
+	if l.redis == nil {
+		return []Blocked{}, nil
+	}
+	entries, err := l.redis.HGetAll(ctx, blockedKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	now := l.now()
+	blocked := make([]Blocked, 0, len(entries))
+	var expired []string
+	for field, raw := range entries {
+		var entry Blocked
+		if err := json.Unmarshal([]byte(raw), &entry); err != nil || !entry.Until.After(now) {
+			expired = append(expired, field)
+			continue
+		}
+		blocked = append(blocked, entry)
+	}
+	if len(expired) > 0 {
+		l.redis.HDel(ctx, blockedKey, expired...)
+	}
+	sort.Slice(blocked, func(i, j int) bool { return blocked[i].Until.After(blocked[j].Until) })
+	return blocked, nil
+}
+
+// Unblock clears the limit or backoff behind a ListBlocked entry. It
+// reports false for keys that are not throttle keys.
+func (l *Limiter) Unblock(ctx context.Context, key string) (bool, error) {
+	if l.redis == nil || !strings.HasPrefix(key, keyPrefix) || key == blockedKey {
+		return false, nil
+	}
+	pipe := l.redis.TxPipeline()
+	pipe.Del(ctx, key, key+":until")
+	pipe.HDel(ctx, blockedKey, key)
+	_, err := pipe.Exec(ctx)
+	return err == nil, err
+}
+
+func (l *Limiter) recordBlocked(ctx context.Context, entry Blocked) {
+	raw, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	if err := l.redis.HSet(ctx, blockedKey, entry.Key, raw).Err(); err != nil {
+		log.Printf("[auth][throttle] record blocked failed key=%s err=%v", entry.Key, err)
+	}
+	log.Printf("[auth][throttle] blocked rule=%s actor=%s:%s until=%s", entry.Rule, entry.Kind, entry.Value, entry.Until.Format(time.RFC3339))
+}
+
+func windowKey(rule string, actor Actor) string {
+	return keyPrefix + "window:" + rule + ":" + actor.String()
+}
+
+func backoffKey(rule string, actor Actor) string {
+	return keyPrefix + "backoff:" + rule + ":" + actor.String()
+}
+
+// PrefixList matches E.164 numbers by dialing prefix, e.g. "1" for
+// North America or "44" for the UK, or "1876" for Jamaica alone.
+type PrefixList []string
+
+func ParsePrefixList(values []string) PrefixList {
+	list := make(PrefixList, 0, len(values))
+	for _, value := range values {
+		if value = strings.TrimPrefix(strings.TrimSpace(value), "+"); value != "" {
+			list = append(list, value)
+		}
+	}
+	return list
+}
+
+func (p PrefixList) Matches(phoneNumber string) bool {
+	digits := strings.TrimPrefix(phoneNumber, "+")
+	for _, prefix := range p {
+		if strings.HasPrefix(digits, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// SMSPolicy decides which numbers we will text and for which apps. Denied
+// prefixes win over allowed ones; an empty allow list allows every number
+// not denied. Apps are matched ignoring case, and none are allowed unless
+// listed, since the app name is both a rate limit key and part of the text.
+type SMSPolicy struct {
+	Allowed PrefixList
+	Denied  PrefixList
+	Apps    []string
+}
+
+func (p SMSPolicy) Permits(phoneNumber string) bool {
+	if p.Denied.Matches(phoneNumber) {
+		return false
+	}
+	return len(p.Allowed) == 0 || p.Allowed.Matches(phoneNumber)
+}
+
+// App returns the configured spelling of appName, and false when it isn't
+// one of Apps.
+func (p SMSPolicy) App(appName string) (string, bool) {
+	appName = strings.TrimSpace(appName)
+	for _, app := range p.Apps {
+		if strings.EqualFold(app, appName) {
+			return app, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,120 @@
+package throttle
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestBackoffDelayDoublesAfterFreeFailures(t *testing.T) {
+	rule := Backoff{Free: 3, Base: time.Second, Max: 10 * time.Second}
+	for failures, want := range map[int]time.Duration{
+		0:  0,
+		3:  0,
+		4:  time.Second,
+		5:  2 * time.Second,
+		6:  4 * time.Second,
+		7:  8 * time.Second,
+		8:  10 * time.Second,
+		50: 10 * time.Second,
+	} {
+		if got := rule.Delay(failures); got != want {
+			t.Errorf("Delay(%d) = %s, want %s", failures, got, want)
+		}
+	}
+}
+
+func TestSMSPolicy(t *testing.T) {
+	policy := SMSPolicy{
+		Allowed: ParsePrefixList([]string{"+1", " 44 "}),
+		Denied:  ParsePrefixList([]string{"1876", ""}),
+	}
+	for number, want := range map[string]bool{
+		"+15551234567":   true,
+		"+447700900123":  true,
+		"+18765551234":   false,
+		"+2348012345678": false,
+	} {
+		if got := policy.Permits(number); got != want {
+			t.Errorf("Permits(%q) = %v, want %v", number, got, want)
+		}
+	}
+	if !(SMSPolicy{}).Permits("+2348012345678") {
+		t.Error("expected an empty policy to permit every number")
+	}
+}
+
+func TestSMSPolicyApps(t *testing.T) {
+	policy := SMSPolicy{Apps: []string{"Sonar", "Guess How Many"}}
+	for appName, want := range map[string]string{
+		"Sonar":            "Sonar",
+		" guess how many ": "Guess How Many",
+		"sonar2":           "",
+		"":                 "",
+	} {
+		got, ok := policy.App(appName)
+		if got != want || ok != (want != "") {
+			t.Errorf("App(%q) = %q, %v, want %q", appName, got, ok, want)
+		}
+	}
+	if _, ok := (SMSPolicy{}).App("Sonar"); ok {
+		t.Error("expected a policy without apps to allow none")
+	}
+}
+
+func TestLimiterWithoutRedisAllowsEverything(t *testing.T) {
+	l := New(nil)
+	ctx := context.Background()
+	actor := Actor{Kind: "ip", Value: "203.0.113.9"}
+	if wait := l.Allow(ctx, Window{Name: "w", Limit: 0, Window: time.Minute}, actor); wait != 0 {
+		t.Fatalf("expected no wait, got %s", wait)
+	}
+	if wait := l.AllowAll(ctx, Limit{Rule: Window{Name: "w", Limit: 0, Window: time.Minute}, Actor: actor}); wait != 0 {
+		t.Fatalf("expected no wait, got %s", wait)
+	}
+	if delay := l.Fail(ctx, Backoff{Name: "b", Base: time.Second, Max: time.Minute}, actor); delay != 0 {
+		t.Fatalf("expected no backoff, got %s", delay)
+	}
+	if blocked, err := l.ListBlocked(ctx); err != nil || len(blocked) != 0 {
+		t.Fatalf("expected nothing blocked, got %v %v", blocked, err)
+	}
+}
+
+// TestAllowAllRecordsOnlyWhenEveryWindowHasRoom needs a Redis to run the
+// window script against, e.g.
+//
+//	docker run --rm -p 6379:6379 redis
+//	THROTTLE_TEST_REDIS_ADDR=localhost:6379 go test ./internal/throttle/
+func TestAllowAllRecordsOnlyWhenEveryWindowHasRoom(t *testing.T) {
+	addr := os.Getenv("THROTTLE_TEST_REDIS_ADDR")
+	if addr == "" {
+		t.Skip("THROTTLE_TEST_REDIS_ADDR not set, skipping Redis test")
+	}
+	ctx := context.Background()
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	defer client.Close()
+
+	l := New(client)
+	wide := Limit{Rule: Window{Name: "test-wide", Limit: 3, Window: time.Minute}, Actor: Actor{Kind: "app", Value: t.Name()}}
+	narrow := Limit{Rule: Window{Name: "test-narrow", Limit: 1, Window: time.Minute}, Actor: Actor{Kind: "phone", Value: t.Name()}}
+	wideKey, narrowKey := windowKey(wide.Rule.Name, wide.Actor), windowKey(narrow.Rule.Name, narrow.Actor)
+	client.Del(ctx, wideKey, narrowKey)
+	defer client.Del(ctx, wideKey, narrowKey)
+	defer client.HDel(ctx, blockedKey, narrowKey)
+
+	if wait := l.AllowAll(ctx, wide, narrow); wait != 0 {
+		t.Fatalf("expected the first event to be allowed, got %s", wait)
+	}
+	for i := 0; i < 3; i++ {
+		l.now = func() time.Time { return time.Now().Add(time.Duration(i+1) * time.Millisecond) }
+		if wait := l.AllowAll(ctx, wide, narrow); wait == 0 {
+			t.Fatal("expected the narrow window to refuse the event")
+		}
+	}
+	if count := client.ZCard(ctx, wideKey).Val(); count != 1 {
+		t.Fatalf("expected refused events to leave the wide window alone, it holds %d", count)
+	}
+}
@@ -3,41 +3,126 @@ package token
 import (
 	"crypto/ecdsa"
 	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/hex"
+	"fmt"
 	"math/big"
+	"time"
 
+	"github.com/MaxBlaushild/poltergeist/pkg/auth"
 	"github.com/golang-jwt/jwt"
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
 )
 
 const (
-	userIDKey = "userID"
+	userIDKey    = "userID"
+	sessionIDKey = "sid"
+	scopesKey    = "scopes"
+	issuedAtKey  = "iat"
+	expiresAtKey = "exp"
+	keyIDHeader  = "kid"
+
+	refreshTokenBytes = 32
 )
 
 var (
 	ErrInvalidToken  = errors.New("invalid token")
 	ErrInvalidClaims = errors.New("invalid claims")
+	ErrUnknownKey    = errors.New("token signed with an unknown key")
 )
 
 type client struct {
-	privateKey *ecdsa.PrivateKey
+	privateKey     *ecdsa.PrivateKey
+	keyID          string
+	publicKeys     map[string]*ecdsa.PublicKey
+	accessTokenTTL time.Duration
+}
+
+// Claims is what a verified access token says about its bearer. Tokens
+// minted before sessions existed have no session, issue time or expiry.
+type Claims struct {
+	UserID    uuid.UUID
+	SessionID *uuid.UUID
+	IssuedAt  *time.Time
+	ExpiresAt *time.Time
+	// Scopes are those of the user's roles when the token was minted.
+	// Legacy tokens carry none.
+	Scopes []string
+}
+
+// Legacy reports whether the token predates sessions and so cannot be
+// revoked.
+func (c *Claims) Legacy() bool {
+	return c.SessionID == nil
 }
 
 type Client interface {
-	New(userID uuid.UUID) (string, error)
-	Verify(tokenString string) (*uuid.UUID, error)
+	New(userID uuid.UUID, sessionID uuid.UUID, scopes []string) (string, time.Time, error)
+	Verify(tokenString string) (*Claims, error)
+	NewRefreshToken() (string, error)
+	JWKS() auth.JWKS
 }
 
-func NewClient(hexPrivateKey string) (Client, error) {
+// NewClient signs with hexPrivateKey. Tokens signed by any of the retired
+// keys still verify, and those keys stay in the JWKS, so a key can be
+// rotated without signing everyone out.
+func NewClient(hexPrivateKey string, retiredHexPrivateKeys []string, accessTokenTTL time.Duration) (Client, error) {
 	privateKey, err := hexToECDSAPrivateKey(hexPrivateKey)
 	if err != nil {
 		return nil, err
 	}
 
-	return &client{
-		privateKey: privateKey,
-	}, nil
+	c := &client{
+		privateKey:     privateKey,
+		keyID:          KeyID(&privateKey.PublicKey),
+		publicKeys:     map[string]*ecdsa.PublicKey{},
+		accessTokenTTL: accessTokenTTL,
+	}
+	c.publicKeys[c.keyID] = &privateKey.PublicKey
+
+	for _, hexKey := range retiredHexPrivateKeys {
+		retired, err := hexToECDSAPrivateKey(hexKey)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid retired key")
+		}
+		c.publicKeys[KeyID(&retired.PublicKey)] = &retired.PublicKey
+	}
+
+	return c, nil
+}
+
+// KeyID is the RFC 7638 thumbprint of a P-256 public key.
+func KeyID(publicKey *ecdsa.PublicKey) string {
+	jwk := JWK(publicKey, "")
+	canonical := fmt.Sprintf(`{"crv":"%s","kty":"%s","x":"%s","y":"%s"}`, jwk.Curve, jwk.KeyType, jwk.X, jwk.Y)
+	sum := sha256.Sum256([]byte(canonical))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func JWK(publicKey *ecdsa.PublicKey, keyID string) auth.JWK {
+	return auth.JWK{
+		KeyType:   "EC",
+		Curve:     "P-256",
+		X:         base64.RawURLEncoding.EncodeToString(publicKey.X.FillBytes(make([]byte, 32))),
+		Y:         base64.RawURLEncoding.EncodeToString(publicKey.Y.FillBytes(make([]byte, 32))),
+		KeyID:     keyID,
+		Algorithm: jwt.SigningMethodES256.Alg(),
+		Use:       "sig",
+	}
+}
+
+func (c *client) JWKS() auth.JWKS {
+	keys := []auth.JWK{JWK(&c.privateKey.PublicKey, c.keyID)}
+	for keyID, publicKey := range c.publicKeys {
+		if keyID != c.keyID {
+			keys = append(keys, JWK(publicKey, keyID))
+		}
+	}
+
+	return auth.JWKS{Keys: keys}
 }
 
 func hexToECDSAPrivateKey(hexKey string) (*ecdsa.PrivateKey, error) {
@@ -61,22 +146,52 @@ func hexToECDSAPrivateKey(hexKey string) (*ecdsa.PrivateKey, error) {
 	return privateKey, nil
 }
 
-func (c *client) New(userID uuid.UUID) (string, error) {
+func (c *client) New(userID uuid.UUID, sessionID uuid.UUID, scopes []string) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(c.accessTokenTTL)
 	t := jwt.NewWithClaims(jwt.SigningMethodES256,
 		jwt.MapClaims{
-			userIDKey: userID.String(),
+			userIDKey:    userID.String(),
+			sessionIDKey: sessionID.String(),
+			issuedAtKey:  now.Unix(),
+			expiresAtKey: expiresAt.Unix(),
+			scopesKey:    scopes,
 		})
+	t.Header[keyIDHeader] = c.keyID
 	s, err := t.SignedString(c.privateKey)
 	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return s, expiresAt, nil
+}
+
+// NewRefreshToken returns an opaque random token. Only its hash is stored.
+func (c *client) NewRefreshToken() (string, error) {
+	b := make([]byte, refreshTokenBytes)
+	if _, err := rand.Read(b); err != nil {
 		return "", err
 	}
 
-	return s, nil
+	return base64.RawURLEncoding.EncodeToString(b), nil
 }
 
-func (c *client) Verify(tokenString string) (*uuid.UUID, error) {
+func (c *client) Verify(tokenString string) (*Claims, error) {
 	token, err := jwt.Parse(tokenString, func(t *jwt.Token) (interface{}, error) {
-		return &c.privateKey.PublicKey, nil
+		if t.Method != jwt.SigningMethodES256 {
+			return nil, ErrInvalidToken
+		}
+		keyID, ok := t.Header[keyIDHeader].(string)
+		if !ok {
+			// Legacy tokens predate key IDs and were signed with the
+			// current key.
+			return &c.privateKey.PublicKey, nil
+		}
+		publicKey, ok := c.publicKeys[keyID]
+		if !ok {
+			return nil, ErrUnknownKey
+		}
+		return publicKey, nil
 	})
 	if err != nil {
 		return nil, errors.Wrap(err, "jwt parse error")
@@ -86,27 +201,77 @@ func (c *client) Verify(tokenString string) (*uuid.UUID, error) {
 		return nil, ErrInvalidToken
 	}
 
-	claims, ok := token.Claims.(jwt.MapClaims)
+	mapClaims, ok := token.Claims.(jwt.MapClaims)
 	if !ok {
 		return nil, ErrInvalidClaims
 	}
 
-	maybeStringUseID, ok := claims[userIDKey]
-	if !ok {
+	userID, err := uuidClaim(mapClaims, userIDKey)
+	if err != nil || userID == nil {
 		return nil, ErrInvalidClaims
 	}
 
-	stringUserID, ok := maybeStringUseID.(string)
+	sessionID, err := uuidClaim(mapClaims, sessionIDKey)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := &Claims{
+		UserID:    *userID,
+		SessionID: sessionID,
+		IssuedAt:  timeClaim(mapClaims, issuedAtKey),
+		ExpiresAt: timeClaim(mapClaims, expiresAtKey),
+		Scopes:    stringsClaim(mapClaims, scopesKey),
+	}
+
+	// Session tokens must always expire; only legacy tokens may omit exp.
+	if claims.SessionID != nil && claims.ExpiresAt == nil {
+		return nil, ErrInvalidClaims
+	}
+
+	return claims, nil
+}
+
+func uuidClaim(claims jwt.MapClaims, key string) (*uuid.UUID, error) {
+	raw, ok := claims[key]
+	if !ok {
+		return nil, nil
+	}
+
+	str, ok := raw.(string)
 	if !ok {
 		return nil, ErrInvalidClaims
 	}
 
-	userID, err := uuid.Parse(stringUserID)
+	id, err := uuid.Parse(str)
 	if err != nil {
-		if !ok {
-			return nil, ErrInvalidClaims
-		}
+		return nil, ErrInvalidClaims
+	}
+
+	return &id, nil
+}
+
+func timeClaim(claims jwt.MapClaims, key string) *time.Time {
+	seconds, ok := claims[key].(float64)
+	if !ok {
+		return nil
 	}
 
-	return &userID, nil
+	t := time.Unix(int64(seconds), 0)
+	return &t
+}
+
+func stringsClaim(claims jwt.MapClaims, key string) []string {
+	raw, ok := claims[key].([]interface{})
+	if !ok {
+		return nil
+	}
+
+	values := make([]string, 0, len(raw))
+	for _, v := range raw {
+		if str, ok := v.(string); ok {
+			values = append(values, str)
+		}
+	}
+	return values
 }
@@ -0,0 +1,112 @@
+package token
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
+)
+
+const testPrivateKey = "c4f1a6e0d6a1b2c3d4e5f60718293a4b5c6d7e8f90a1b2c3d4e5f60718293a4b"
+
+func TestNewAndVerifyRoundTrip(t *testing.T) {
+	c, err := NewClient(testPrivateKey, nil, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	userID, sessionID := uuid.New(), uuid.New()
+
+	tokenString, expiresAt, err := c.New(userID, sessionID, []string{"sonar:*", "reef:orders:refund"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := c.Verify(tokenString)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.UserID != userID || claims.SessionID == nil || *claims.SessionID != sessionID {
+		t.Fatalf("unexpected claims %+v", claims)
+	}
+	if claims.Legacy() {
+		t.Fatal("session token reported as legacy")
+	}
+	if claims.ExpiresAt == nil || claims.ExpiresAt.Unix() != expiresAt.Unix() {
+		t.Fatalf("expected expiry %v, got %v", expiresAt, claims.ExpiresAt)
+	}
+	if len(claims.Scopes) != 2 || claims.Scopes[0] != "sonar:*" || claims.Scopes[1] != "reef:orders:refund" {
+		t.Fatalf("unexpected scopes %v", claims.Scopes)
+	}
+}
+
+func TestVerifyRejectsExpiredToken(t *testing.T) {
+	c, err := NewClient(testPrivateKey, nil, -time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokenString, _, err := c.New(uuid.New(), uuid.New(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Verify(tokenString); err == nil {
+		t.Fatal("expected expired token to fail verification")
+	}
+}
+
+func TestVerifyAcceptsLegacyToken(t *testing.T) {
+	c, err := NewClient(testPrivateKey, nil, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	userID := uuid.New()
+	legacy, err := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		userIDKey: userID.String(),
+	}).SignedString(c.(*client).privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims, err := c.Verify(legacy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !claims.Legacy() || claims.UserID != userID || claims.ExpiresAt != nil {
+		t.Fatalf("unexpected legacy claims %+v", claims)
+	}
+}
+
+func TestVerifyRejectsSessionTokenWithoutExpiry(t *testing.T) {
+	c, err := NewClient(testPrivateKey, nil, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokenString, err := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		userIDKey:    uuid.New().String(),
+		sessionIDKey: uuid.New().String(),
+	}).SignedString(c.(*client).privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.Verify(tokenString); err != ErrInvalidClaims {
+		t.Fatalf("expected ErrInvalidClaims, got %v", err)
+	}
+}
+
+func TestNewRefreshTokenIsUnique(t *testing.T) {
+	c, err := NewClient(testPrivateKey, nil, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := c.NewRefreshToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := c.NewRefreshToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first == second || len(first) < 40 {
+		t.Fatalf("weak refresh tokens %q %q", first, second)
+	}
+}
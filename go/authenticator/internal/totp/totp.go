@@ -0,0 +1,193 @@
+// Package totp implements RFC 6238 time-based one-time passwords as used by
+// authenticator apps (SHA-1, 6 digits, 30-second steps), plus encryption of
+// the shared secrets at rest and recovery-code generation.
+package totp
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	secretBytes = 20
+	digits      = 6
+	period      = 30 * time.Second
+	// skewSteps accepts the code from one step either side of now, for
+	// phones whose clocks drift.
+	skewSteps = 1
+
+	recoveryCodeCount = 10
+	recoveryCodeBytes = 5
+)
+
+var (
+	ErrInvalidCode = errors.New("invalid code")
+	ErrNoKey       = errors.New("two-factor encryption key is not configured")
+
+	secretEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+)
+
+// NewSecret returns a random base32 secret to show as a QR code.
+func NewSecret() (string, error) {
+	b := make([]byte, secretBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return secretEncoding.EncodeToString(b), nil
+}
+
+// ProvisioningURI is the otpauth:// URL authenticator apps scan.
+func ProvisioningURI(issuer string, accountName string, secret string) string {
+	label := url.PathEscape(issuer) + ":" + url.PathEscape(accountName)
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(digits))
+	query.Set("period", fmt.Sprint(int(period.Seconds())))
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+// Validate checks code against the secret at now and returns the time step
+// it matched, so callers can refuse a second use of the same step.
+func Validate(secret string, code string, now time.Time) (int64, error) {
+	key, err := secretEncoding.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+	if err != nil {
+		return 0, errors.Wrap(err, "invalid secret")
+	}
+	code = strings.ReplaceAll(strings.TrimSpace(code), " ", "")
+	if len(code) != digits {
+		return 0, ErrInvalidCode
+	}
+
+	current := now.Unix() / int64(period.Seconds())
+	for offset := -skewSteps; offset <= skewSteps; offset++ {
+		step := current + int64(offset)
+		if hmac.Equal([]byte(codeAt(key, step)), []byte(code)) {
+			return step, nil
+		}
+	}
+	return 0, ErrInvalidCode
+}
+
+// Code returns the code for the step containing t.
+func Code(secret string, t time.Time) (string, error) {
+	key, err := secretEncoding.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+	if err != nil {
+		return "", errors.Wrap(err, "invalid secret")
+	}
+	return codeAt(key, t.Unix()/int64(period.Seconds())), nil
+}
+
+func codeAt(key []byte, step int64) string {
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", digits, value%1000000)
+}
+
+// NewRecoveryCodes returns codes formatted for people ("abcd-efgh") and
+// their hashes for storage.
+func NewRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, recoveryCodeBytes)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		raw := strings.ToLower(secretEncoding.EncodeToString(b))
+		code := raw[:4] + "-" + raw[4:]
+		codes = append(codes, code)
+		hashes = append(hashes, HashRecoveryCode(code))
+	}
+	return codes, hashes, nil
+}
+
+// HashRecoveryCode ignores case, spaces and dashes so codes can be typed
+// back however they were written down.
+func HashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(strings.TrimSpace(code)))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+// Cipher encrypts secrets at rest with AES-256-GCM.
+type Cipher struct {
+	aead cipher.AEAD
+}
+
+// NewCipher takes a hex-encoded 32-byte key. An empty key yields a Cipher
+// that refuses to work, so two-factor can be left unconfigured.
+func NewCipher(hexKey string) (*Cipher, error) {
+	if hexKey == "" {
+		return &Cipher{}, nil
+	}
+	key, err := hex.DecodeString(hexKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid TOTP_ENCRYPTION_KEY")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid TOTP_ENCRYPTION_KEY")
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Cipher{aead: aead}, nil
+}
+
+func (c *Cipher) Configured() bool {
+	return c.aead != nil
+}
+
+func (c *Cipher) Seal(plaintext string) (string, error) {
+	if c.aead == nil {
+		return "", ErrNoKey
+	}
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := c.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (c *Cipher) Open(ciphertext string) (string, error) {
+	if c.aead == nil {
+		return "", ErrNoKey
+	}
+	sealed, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < c.aead.NonceSize() {
+		return "", errors.New("ciphertext too short")
+	}
+	nonce, body := sealed[:c.aead.NonceSize()], sealed[c.aead.NonceSize():]
+	plaintext, err := c.aead.Open(nil, nonce, body, nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
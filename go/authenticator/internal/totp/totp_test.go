@@ -0,0 +1,82 @@
+package totp
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+)
+
+// RFC 6238 appendix B, SHA-1, truncated to six digits.
+func TestCodeMatchesRFC6238Vectors(t *testing.T) {
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+	cases := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+	for unix, want := range cases {
+		got, err := Code(secret, time.Unix(unix, 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("at %d expected %s, got %s", unix, want, got)
+		}
+	}
+}
+
+func TestValidateAllowsOneStepOfSkew(t *testing.T) {
+	secret, err := NewSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1700000000, 0)
+	previous, _ := Code(secret, now.Add(-30*time.Second))
+	step, err := Validate(secret, previous, now)
+	if err != nil {
+		t.Fatalf("expected previous step to validate: %v", err)
+	}
+	if step != now.Unix()/30-1 {
+		t.Fatalf("expected the previous step, got %d", step)
+	}
+
+	stale, _ := Code(secret, now.Add(-90*time.Second))
+	if _, err := Validate(secret, stale, now); err == nil {
+		t.Fatal("expected a code three steps old to fail")
+	}
+}
+
+func TestCipherRoundTrip(t *testing.T) {
+	c, err := NewCipher("000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := c.Seal("JBSWY3DPEHPK3PXP")
+	if err != nil {
+		t.Fatal(err)
+	}
+	opened, err := c.Open(sealed)
+	if err != nil || opened != "JBSWY3DPEHPK3PXP" {
+		t.Fatalf("round trip failed: %q %v", opened, err)
+	}
+
+	unconfigured, _ := NewCipher("")
+	if _, err := unconfigured.Seal("x"); err != ErrNoKey {
+		t.Fatalf("expected ErrNoKey, got %v", err)
+	}
+}
+
+func TestRecoveryCodeHashIgnoresFormatting(t *testing.T) {
+	codes, hashes, err := NewRecoveryCodes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != recoveryCodeCount {
+		t.Fatalf("expected %d codes, got %d", recoveryCodeCount, len(codes))
+	}
+	retyped := " " + codes[0][:4] + codes[0][5:] + " "
+	if HashRecoveryCode(retyped) != hashes[0] {
+		t.Fatal("expected a retyped code without the dash to match")
+	}
+}
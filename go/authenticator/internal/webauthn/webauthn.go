@@ -0,0 +1,502 @@
+// Package webauthn verifies passkey registrations and sign-ins (WebAuthn
+// Level 2) for a single relying party. Attestation is not checked — we
+// ask for "none", so a passkey proves possession of a key, not which
+// device holds it.
+package webauthn
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/pkg/errors"
+)
+
+const (
+	flagUserPresent        = 0x01
+	flagUserVerified       = 0x04
+	flagAttestedCredData   = 0x40
+	minAuthenticatorData   = 37
+	aaguidLength           = 16
+	ceremonyTimeout        = 5 * time.Minute
+	challengeBytes         = 32
+	clientDataTypeCreate   = "webauthn.create"
+	clientDataTypeGet      = "webauthn.get"
+	publicKeyCredential    = "public-key"
+	userVerificationPrefer = "preferred"
+)
+
+// COSE algorithm identifiers we accept, in order of preference.
+const (
+	AlgES256 = -7
+	AlgEdDSA = -8
+	AlgRS256 = -257
+)
+
+var (
+	ErrInvalidResponse = errors.New("invalid passkey response")
+	ErrUnsupportedKey  = errors.New("unsupported passkey algorithm")
+)
+
+type Config struct {
+	RPID   string
+	RPName string
+	// Origins the browser (or native app) may report in clientDataJSON.
+	Origins []string
+}
+
+type RelyingParty struct {
+	config   Config
+	rpIDHash [32]byte
+}
+
+func New(config Config) *RelyingParty {
+	return &RelyingParty{config: config, rpIDHash: sha256.Sum256([]byte(config.RPID))}
+}
+
+// Timeout is how long a begun ceremony stays valid.
+func (rp *RelyingParty) Timeout() time.Duration {
+	return ceremonyTimeout
+}
+
+type RelyingPartyEntity struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type UserEntity struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+type CredentialParameter struct {
+	Type string `json:"type"`
+	Alg  int    `json:"alg"`
+}
+
+type CredentialDescriptor struct {
+	Type       string   `json:"type"`
+	ID         string   `json:"id"`
+	Transports []string `json:"transports,omitempty"`
+}
+
+type AuthenticatorSelection struct {
+	ResidentKey      string `json:"residentKey"`
+	UserVerification string `json:"userVerification"`
+}
+
+// CreationOptions is PublicKeyCredentialCreationOptionsJSON: binary fields
+// are base64url, ready for PublicKeyCredential.parseCreationOptionsFromJSON.
+type CreationOptions struct {
+	Challenge              string                 `json:"challenge"`
+	RP                     RelyingPartyEntity     `json:"rp"`
+	User                   UserEntity             `json:"user"`
+	PubKeyCredParams       []CredentialParameter  `json:"pubKeyCredParams"`
+	Timeout                int64                  `json:"timeout"`
+	ExcludeCredentials     []CredentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection AuthenticatorSelection `json:"authenticatorSelection"`
+	Attestation            string                 `json:"attestation"`
+}
+
+// RequestOptions is PublicKeyCredentialRequestOptionsJSON. AllowCredentials
+// is empty so the browser offers any discoverable passkey for the site.
+type RequestOptions struct {
+	Challenge        string                 `json:"challenge"`
+	Timeout          int64                  `json:"timeout"`
+	RPID             string                 `json:"rpId"`
+	AllowCredentials []CredentialDescriptor `json:"allowCredentials"`
+	UserVerification string                 `json:"userVerification"`
+}
+
+// RegistrationResponse is what navigator.credentials.create() resolves to,
+// serialized with toJSON().
+type RegistrationResponse struct {
+	ID       string `json:"id"`
+	RawID    string `json:"rawId"`
+	Type     string `json:"type"`
+	Response struct {
+		ClientDataJSON    string   `json:"clientDataJSON"`
+		AttestationObject string   `json:"attestationObject"`
+		Transports        []string `json:"transports"`
+	} `json:"response"`
+}
+
+// AuthenticationResponse is what navigator.credentials.get() resolves to,
+// serialized with toJSON().
+type AuthenticationResponse struct {
+	ID       string `json:"id"`
+	RawID    string `json:"rawId"`
+	Type     string `json:"type"`
+	Response struct {
+		ClientDataJSON    string `json:"clientDataJSON"`
+		AuthenticatorData string `json:"authenticatorData"`
+		Signature         string `json:"signature"`
+		UserHandle        string `json:"userHandle"`
+	} `json:"response"`
+}
+
+// Credential is a verified registration, ready to store.
+type Credential struct {
+	ID           string
+	PublicKey    []byte
+	Algorithm    int
+	SignCount    uint32
+	AAGUID       string
+	Transports   []string
+	UserVerified bool
+}
+
+// Assertion is a verified sign-in.
+type Assertion struct {
+	SignCount    uint32
+	UserVerified bool
+}
+
+func (rp *RelyingParty) CreationOptions(challenge string, userHandle []byte, name string, displayName string, exclude []CredentialDescriptor) CreationOptions {
+	return CreationOptions{
+		Challenge: challenge,
+		RP:        RelyingPartyEntity{ID: rp.config.RPID, Name: rp.config.RPName},
+		User: UserEntity{
+			ID:          Encode(userHandle),
+			Name:        name,
+			DisplayName: displayName,
+		},
+		PubKeyCredParams: []CredentialParameter{
+			{Type: publicKeyCredential, Alg: AlgES256},
+			{Type: publicKeyCredential, Alg: AlgEdDSA},
+			{Type: publicKeyCredential, Alg: AlgRS256},
+		},
+		Timeout:            ceremonyTimeout.Milliseconds(),
+		ExcludeCredentials: exclude,
+		AuthenticatorSelection: AuthenticatorSelection{
+			ResidentKey:      "required",
+			UserVerification: userVerificationPrefer,
+		},
+		Attestation: "none",
+	}
+}
+
+func (rp *RelyingParty) RequestOptions(challenge string) RequestOptions {
+	return RequestOptions{
+		Challenge:        challenge,
+		Timeout:          ceremonyTimeout.Milliseconds(),
+		RPID:             rp.config.RPID,
+		AllowCredentials: []CredentialDescriptor{},
+		UserVerification: userVerificationPrefer,
+	}
+}
+
+// VerifyRegistration checks a create() response against the challenge we
+// issued and extracts the new credential.
+func (rp *RelyingParty) VerifyRegistration(response RegistrationResponse, challenge string) (*Credential, error) {
+	if response.Type != publicKeyCredential {
+		return nil, ErrInvalidResponse
+	}
+	if _, err := rp.verifyClientData(response.Response.ClientDataJSON, clientDataTypeCreate, challenge); err != nil {
+		return nil, err
+	}
+
+	rawAttestation, err := Decode(response.Response.AttestationObject)
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidResponse, "attestation object")
+	}
+	var attestation struct {
+		Format   string          `cbor:"fmt"`
+		AuthData []byte          `cbor:"authData"`
+		AttStmt  cbor.RawMessage `cbor:"attStmt"`
+	}
+	if err := cbor.Unmarshal(rawAttestation, &attestation); err != nil {
+		return nil, errors.Wrap(ErrInvalidResponse, "attestation object")
+	}
+
+	authData, err := rp.parseAuthenticatorData(attestation.AuthData)
+	if err != nil {
+		return nil, err
+	}
+	if authData.flags&flagAttestedCredData == 0 || authData.credentialID == nil {
+		return nil, errors.Wrap(ErrInvalidResponse, "no attested credential")
+	}
+	if rawID, err := Decode(response.RawID); err != nil || !bytes.Equal(rawID, authData.credentialID) {
+		return nil, errors.Wrap(ErrInvalidResponse, "credential id mismatch")
+	}
+
+	algorithm, err := coseAlgorithm(authData.publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Credential{
+		ID:           Encode(authData.credentialID),
+		PublicKey:    authData.publicKey,
+		Algorithm:    algorithm,
+		SignCount:    authData.signCount,
+		AAGUID:       hex.EncodeToString(authData.aaguid),
+		Transports:   response.Response.Transports,
+		UserVerified: authData.flags&flagUserVerified != 0,
+	}, nil
+}
+
+// VerifyAuthentication checks a get() response against the challenge we
+// issued and the stored public key.
+func (rp *RelyingParty) VerifyAuthentication(response AuthenticationResponse, challenge string, publicKey []byte) (*Assertion, error) {
+	if response.Type != publicKeyCredential {
+		return nil, ErrInvalidResponse
+	}
+	clientData, err := rp.verifyClientData(response.Response.ClientDataJSON, clientDataTypeGet, challenge)
+	if err != nil {
+		return nil, err
+	}
+
+	rawAuthData, err := Decode(response.Response.AuthenticatorData)
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidResponse, "authenticator data")
+	}
+	authData, err := rp.parseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := Decode(response.Response.Signature)
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidResponse, "signature")
+	}
+
+	clientDataHash := sha256.Sum256(clientData)
+	signed := append(append([]byte{}, rawAuthData...), clientDataHash[:]...)
+	if err := verifySignature(publicKey, signed, signature); err != nil {
+		return nil, err
+	}
+
+	return &Assertion{
+		SignCount:    authData.signCount,
+		UserVerified: authData.flags&flagUserVerified != 0,
+	}, nil
+}
+
+func (rp *RelyingParty) verifyClientData(encoded string, ceremony string, challenge string) ([]byte, error) {
+	raw, err := Decode(encoded)
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidResponse, "client data")
+	}
+	var clientData struct {
+		Type      string `json:"type"`
+		Challenge string `json:"challenge"`
+		Origin    string `json:"origin"`
+	}
+	if err := json.Unmarshal(raw, &clientData); err != nil {
+		return nil, errors.Wrap(ErrInvalidResponse, "client data")
+	}
+	if clientData.Type != ceremony {
+		return nil, errors.Wrap(ErrInvalidResponse, "wrong ceremony")
+	}
+	if strings.TrimRight(clientData.Challenge, "=") != strings.TrimRight(challenge, "=") {
+		return nil, errors.Wrap(ErrInvalidResponse, "challenge mismatch")
+	}
+	if !rp.allowedOrigin(clientData.Origin) {
+		return nil, errors.Wrapf(ErrInvalidResponse, "origin %q not allowed", clientData.Origin)
+	}
+	return raw, nil
+}
+
+func (rp *RelyingParty) allowedOrigin(origin string) bool {
+	for _, allowed := range rp.config.Origins {
+		if origin == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+type authenticatorData struct {
+	flags        byte
+	signCount    uint32
+	aaguid       []byte
+	credentialID []byte
+	publicKey    []byte
+}
+
+func (rp *RelyingParty) parseAuthenticatorData(raw []byte) (*authenticatorData, error) {
+	if len(raw) < minAuthenticatorData {
+		return nil, errors.Wrap(ErrInvalidResponse, "authenticator data too short")
+	}
+	if !bytes.Equal(raw[:32], rp.rpIDHash[:]) {
+		return nil, errors.Wrap(ErrInvalidResponse, "relying party mismatch")
+	}
+	data := &authenticatorData{
+		flags:     raw[32],
+		signCount: binary.BigEndian.Uint32(raw[33:37]),
+	}
+	if data.flags&flagUserPresent == 0 {
+		return nil, errors.Wrap(ErrInvalidResponse, "user not present")
+	}
+	if data.flags&flagAttestedCredData == 0 {
+		return data, nil
+	}
+
+	rest := raw[minAuthenticatorData:]
+	if len(rest) < aaguidLength+2 {
+		return nil, errors.Wrap(ErrInvalidResponse, "attested credential data too short")
+	}
+	data.aaguid = rest[:aaguidLength]
+	idLength := int(binary.BigEndian.Uint16(rest[aaguidLength : aaguidLength+2]))
+	rest = rest[aaguidLength+2:]
+	if len(rest) < idLength {
+		return nil, errors.Wrap(ErrInvalidResponse, "credential id too short")
+	}
+	data.credentialID = rest[:idLength]
+	rest = rest[idLength:]
+
+	var key cbor.RawMessage
+	if _, err := cbor.UnmarshalFirst(rest, &key); err != nil {
+		return nil, errors.Wrap(ErrInvalidResponse, "credential public key")
+	}
+	data.publicKey = []byte(key)
+	return data, nil
+}
+
+func coseKey(raw []byte) (map[int]cbor.RawMessage, int, error) {
+	var key map[int]cbor.RawMessage
+	if err := cbor.Unmarshal(raw, &key); err != nil {
+		return nil, 0, errors.Wrap(ErrInvalidResponse, "credential public key")
+	}
+	var algorithm int
+	if err := cbor.Unmarshal(key[3], &algorithm); err != nil {
+		return nil, 0, errors.Wrap(ErrInvalidResponse, "credential public key algorithm")
+	}
+	return key, algorithm, nil
+}
+
+func coseAlgorithm(raw []byte) (int, error) {
+	key, algorithm, err := coseKey(raw)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := publicKeyFromCOSE(key, algorithm); err != nil {
+		return 0, err
+	}
+	return algorithm, nil
+}
+
+func publicKeyFromCOSE(key map[int]cbor.RawMessage, algorithm int) (crypto.PublicKey, error) {
+	bytesParam := func(label int) ([]byte, error) {
+		var b []byte
+		if err := cbor.Unmarshal(key[label], &b); err != nil {
+			return nil, errors.Wrap(ErrInvalidResponse, "credential public key")
+		}
+		return b, nil
+	}
+
+	switch algorithm {
+	case AlgES256:
+		x, err := bytesParam(-2)
+		if err != nil {
+			return nil, err
+		}
+		y, err := bytesParam(-3)
+		if err != nil {
+			return nil, err
+		}
+		publicKey := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !publicKey.Curve.IsOnCurve(publicKey.X, publicKey.Y) {
+			return nil, errors.Wrap(ErrInvalidResponse, "credential public key is not on P-256")
+		}
+		return publicKey, nil
+	case AlgEdDSA:
+		x, err := bytesParam(-2)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, ErrUnsupportedKey
+		}
+		return ed25519.PublicKey(x), nil
+	case AlgRS256:
+		n, err := bytesParam(-1)
+		if err != nil {
+			return nil, err
+		}
+		e, err := bytesParam(-2)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	default:
+		return nil, ErrUnsupportedKey
+	}
+}
+
+func verifySignature(rawKey []byte, signed []byte, signature []byte) error {
+	key, algorithm, err := coseKey(rawKey)
+	if err != nil {
+		return err
+	}
+	publicKey, err := publicKeyFromCOSE(key, algorithm)
+	if err != nil {
+		return err
+	}
+
+	digest := sha256.Sum256(signed)
+	var ok bool
+	switch k := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		ok = ecdsa.VerifyASN1(k, digest[:], signature)
+	case ed25519.PublicKey:
+		ok = ed25519.Verify(k, signed, signature)
+	case *rsa.PublicKey:
+		ok = rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], signature) == nil
+	}
+	if !ok {
+		return errors.Wrap(ErrInvalidResponse, "bad signature")
+	}
+	return nil
+}
+
+// Encode is the unpadded base64url WebAuthn JSON uses for binary fields.
+func Encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// Decode accepts base64url with or without padding.
+func Decode(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
+
+// NewChallenge returns a random challenge for CreationOptions or
+// RequestOptions.
+func NewChallenge() (string, error) {
+	b := make([]byte, challengeBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return Encode(b), nil
+}
+
+// ClientChallenge reads the challenge a response claims to answer, so a
+// discoverable-credential sign-in can find the ceremony it belongs to.
+// The response still has to pass VerifyAuthentication.
+func ClientChallenge(clientDataJSON string) (string, error) {
+	raw, err := Decode(clientDataJSON)
+	if err != nil {
+		return "", errors.Wrap(ErrInvalidResponse, "client data")
+	}
+	var clientData struct {
+		Challenge string `json:"challenge"`
+	}
+	if err := json.Unmarshal(raw, &clientData); err != nil || clientData.Challenge == "" {
+		return "", errors.Wrap(ErrInvalidResponse, "client data")
+	}
+	return strings.TrimRight(clientData.Challenge, "="), nil
+}
@@ -0,0 +1,166 @@
+package webauthn
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+const testOrigin = "https://example.com"
+
+// fakeAuthenticator plays the browser and the security key.
+type fakeAuthenticator struct {
+	t            *testing.T
+	key          *ecdsa.PrivateKey
+	credentialID []byte
+	signCount    uint32
+}
+
+func newFakeAuthenticator(t *testing.T) *fakeAuthenticator {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &fakeAuthenticator{t: t, key: key, credentialID: []byte("credential-1")}
+}
+
+func (f *fakeAuthenticator) clientData(ceremony string, challenge string) []byte {
+	raw, _ := json.Marshal(map[string]string{"type": ceremony, "challenge": challenge, "origin": testOrigin})
+	return raw
+}
+
+func (f *fakeAuthenticator) authData(rpID string, flags byte, attested []byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(rpID))
+	data := append([]byte{}, rpIDHash[:]...)
+	data = append(data, flags)
+	data = binary.BigEndian.AppendUint32(data, f.signCount)
+	return append(data, attested...)
+}
+
+func (f *fakeAuthenticator) register(rpID string, challenge string) RegistrationResponse {
+	publicKey, err := cbor.Marshal(map[int]interface{}{
+		1:  2,
+		3:  AlgES256,
+		-1: 1,
+		-2: f.key.X.FillBytes(make([]byte, 32)),
+		-3: f.key.Y.FillBytes(make([]byte, 32)),
+	})
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	attested := make([]byte, aaguidLength)
+	attested = binary.BigEndian.AppendUint16(attested, uint16(len(f.credentialID)))
+	attested = append(attested, f.credentialID...)
+	attested = append(attested, publicKey...)
+
+	attestation, err := cbor.Marshal(map[string]interface{}{
+		"fmt":      "none",
+		"attStmt":  map[string]interface{}{},
+		"authData": f.authData(rpID, flagUserPresent|flagUserVerified|flagAttestedCredData, attested),
+	})
+	if err != nil {
+		f.t.Fatal(err)
+	}
+
+	var response RegistrationResponse
+	response.ID = Encode(f.credentialID)
+	response.RawID = Encode(f.credentialID)
+	response.Type = publicKeyCredential
+	response.Response.ClientDataJSON = Encode(f.clientData(clientDataTypeCreate, challenge))
+	response.Response.AttestationObject = Encode(attestation)
+	return response
+}
+
+func (f *fakeAuthenticator) signIn(rpID string, challenge string) AuthenticationResponse {
+	f.signCount++
+	authData := f.authData(rpID, flagUserPresent|flagUserVerified, nil)
+	clientData := f.clientData(clientDataTypeGet, challenge)
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, f.key, digest[:])
+	if err != nil {
+		f.t.Fatal(err)
+	}
+
+	var response AuthenticationResponse
+	response.ID = Encode(f.credentialID)
+	response.RawID = Encode(f.credentialID)
+	response.Type = publicKeyCredential
+	response.Response.ClientDataJSON = Encode(clientData)
+	response.Response.AuthenticatorData = Encode(authData)
+	response.Response.Signature = Encode(signature)
+	return response
+}
+
+func TestRegisterThenSignIn(t *testing.T) {
+	rp := New(Config{RPID: "example.com", RPName: "Example", Origins: []string{testOrigin}})
+	device := newFakeAuthenticator(t)
+
+	credential, err := rp.VerifyRegistration(device.register("example.com", "register-challenge"), "register-challenge")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if credential.ID != Encode(device.credentialID) || credential.Algorithm != AlgES256 || !credential.UserVerified {
+		t.Fatalf("unexpected credential %+v", credential)
+	}
+
+	assertion, err := rp.VerifyAuthentication(device.signIn("example.com", "login-challenge"), "login-challenge", credential.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if assertion.SignCount != 1 || !assertion.UserVerified {
+		t.Fatalf("unexpected assertion %+v", assertion)
+	}
+}
+
+func TestSignInRejectsWrongChallengeOriginAndRP(t *testing.T) {
+	rp := New(Config{RPID: "example.com", Origins: []string{testOrigin}})
+	device := newFakeAuthenticator(t)
+	credential, err := rp.VerifyRegistration(device.register("example.com", "c"), "c")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := rp.VerifyAuthentication(device.signIn("example.com", "replayed"), "expected", credential.PublicKey); err == nil {
+		t.Fatal("expected a challenge mismatch to fail")
+	}
+	if _, err := rp.VerifyAuthentication(device.signIn("evil.example", "c2"), "c2", credential.PublicKey); err == nil {
+		t.Fatal("expected another relying party's assertion to fail")
+	}
+
+	other := New(Config{RPID: "example.com", Origins: []string{"https://other.example"}})
+	if _, err := other.VerifyAuthentication(device.signIn("example.com", "c3"), "c3", credential.PublicKey); err == nil {
+		t.Fatal("expected an unlisted origin to fail")
+	}
+
+	tampered := device.signIn("example.com", "c4")
+	tampered.Response.Signature = Encode([]byte("not a signature"))
+	if _, err := rp.VerifyAuthentication(tampered, "c4", credential.PublicKey); err == nil {
+		t.Fatal("expected a bad signature to fail")
+	}
+}
+
+func TestClientChallengeFindsTheCeremony(t *testing.T) {
+	device := newFakeAuthenticator(t)
+	challenge, err := NewChallenge()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ClientChallenge(device.signIn("example.com", challenge).Response.ClientDataJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != challenge {
+		t.Fatalf("expected %q, got %q", challenge, got)
+	}
+	if _, err := ClientChallenge(Encode([]byte("{}"))); err == nil {
+		t.Fatal("expected client data without a challenge to fail")
+	}
+}
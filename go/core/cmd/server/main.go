@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"log"
 
 	"github.com/MaxBlaushild/core/internal/config"
@@ -8,13 +9,17 @@ import (
 	bgisite "github.com/MaxBlaushild/poltergeist/bgi-site/pkg"
 	"github.com/MaxBlaushild/poltergeist/pkg/auth"
 	"github.com/MaxBlaushild/poltergeist/pkg/db"
+	"github.com/MaxBlaushild/poltergeist/pkg/middleware"
 	"github.com/MaxBlaushild/poltergeist/pkg/texter"
+	"github.com/MaxBlaushild/poltergeist/pkg/util"
 	reefsite "github.com/MaxBlaushild/poltergeist/reef-site/pkg"
 	sonar "github.com/MaxBlaushild/poltergeist/sonar/pkg"
 	tradesarglasses "github.com/MaxBlaushild/poltergeist/trades-ar-glasses/pkg"
 	travelangels "github.com/MaxBlaushild/poltergeist/travel-angels/pkg"
 	vampireascendancy "github.com/MaxBlaushild/poltergeist/vampire-ascendancy/pkg"
 	verifiablesn "github.com/MaxBlaushild/poltergeist/verifiable-sn/pkg"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
 )
 
 func main() {
@@ -23,8 +28,19 @@ func main() {
 
 	texterClient := texter.NewClient()
 
-	// Initialize auth client
-	authClient := auth.NewClient()
+	var redisClient *redis.Client
+	if cfg.Public.RedisUrl != "" {
+		redisClient = redis.NewClient(&redis.Options{
+			Addr: util.NormalizeRedisAddr(cfg.Public.RedisUrl),
+		})
+	}
+
+	// Initialize auth client. Tokens are verified against the
+	// authenticator's JWKS here; the authenticator itself is only asked
+	// about legacy tokens and users missing from the cache.
+	authClient := middleware.NewLocallyVerifyingAuthClient(auth.NewClient(), middleware.LocalVerificationConfig{
+		RedisClient: redisClient,
+	})
 
 	// Initialize database client using shared config
 	dbClient, err := db.NewClient(db.ClientConfig{
@@ -38,6 +54,13 @@ func main() {
 		log.Printf("Warning: Failed to initialize database client: %v. Routes will not be available.", err)
 		panic(err)
 	}
+	if redisClient != nil {
+		dbClient.SetUserChangeNotifier(func(ctx context.Context, userID uuid.UUID) {
+			if err := auth.PublishUserChanged(ctx, redisClient, userID); err != nil {
+				log.Printf("[auth][events] publish user changed failed user_id=%s err=%v", userID, err)
+			}
+		})
+	}
 
 	// Initialize Hue OAuth client if credentials are provided
 	// var hueOAuthClient hue.OAuthClient
@@ -1,10 +1,17 @@
 package main
 
 import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/MaxBlaushild/poltergeist/ethereum-transactor/internal/anchor"
 	"github.com/MaxBlaushild/poltergeist/ethereum-transactor/internal/config"
 	"github.com/MaxBlaushild/poltergeist/ethereum-transactor/internal/server"
+	"github.com/MaxBlaushild/poltergeist/ethereum-transactor/internal/transactor"
 	"github.com/MaxBlaushild/poltergeist/pkg/db"
 	"github.com/MaxBlaushild/poltergeist/pkg/ethereum"
+	"github.com/ethereum/go-ethereum/common"
 )
 
 func main() {
@@ -23,6 +30,9 @@ func main() {
 	if cfg.Public.ChainID == 0 {
 		panic("CHAIN_ID environment variable is required")
 	}
+	if !common.IsHexAddress(cfg.Public.C2PAContractAddress) {
+		panic("C2PA_CONTRACT_ADDRESS environment variable is required")
+	}
 
 	dbClient, err := db.NewClient(db.ClientConfig{
 		Name:     cfg.Public.DbName,
@@ -40,5 +50,36 @@ func main() {
 		panic(err)
 	}
 
-	server.NewServer(dbClient, ethereumClient, cfg.Public.ChainID).ListenAndServe("8088")
+	txr := transactor.New(dbClient, ethereumClient, cfg.Public.ChainID, transactor.Config{
+		ReplaceAfter:    cfg.Public.TxReplaceAfter,
+		MaxReplacements: cfg.Public.TxMaxReplacements,
+		ReorgDepth:      cfg.Public.TxReorgDepth,
+	})
+	batcher := anchor.NewBatcher(dbClient, txr, anchor.Config{
+		ContractAddress: common.HexToAddress(cfg.Public.C2PAContractAddress),
+		MaxBatchSize:    cfg.Public.ManifestBatchSize,
+		MaxBatchAge:     cfg.Public.ManifestBatchMaxAge,
+	})
+
+	// Follow sent transactions until they're mined, replacing stuck ones.
+	go func() {
+		ctx := context.Background()
+		for range time.Tick(transactor.MonitorInterval) {
+			if err := txr.Monitor(ctx); err != nil {
+				log.Printf("Failed to monitor transactions: %v", err)
+			}
+		}
+	}()
+
+	// Cut and send manifest batches in the background.
+	go func() {
+		ctx := context.Background()
+		for range time.Tick(anchor.RunInterval) {
+			if err := batcher.Run(ctx); err != nil {
+				log.Printf("Failed to anchor manifest batches: %v", err)
+			}
+		}
+	}()
+
+	server.NewServer(dbClient, txr, batcher).ListenAndServe("8088")
 }
@@ -0,0 +1,193 @@
+package anchor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/MaxBlaushild/poltergeist/ethereum-transactor/internal/merkle"
+	"github.com/MaxBlaushild/poltergeist/ethereum-transactor/internal/transactor"
+	"github.com/MaxBlaushild/poltergeist/pkg/db"
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	// RunInterval is how often the service runs the batcher.
+	RunInterval = 30 * time.Second
+
+	DefaultMaxBatchSize = 256
+	DefaultMaxBatchAge  = 10 * time.Minute
+)
+
+// Config controls when batches are cut. A batch is cut once MaxBatchSize
+// manifests are queued, or once the oldest queued manifest has waited
+// MaxBatchAge, whichever comes first.
+type Config struct {
+	ContractAddress common.Address
+	MaxBatchSize    int
+	MaxBatchAge     time.Duration
+}
+
+// Batcher anchors manifests in batches: it builds a Merkle tree over queued
+// manifests, stores each manifest's inclusion proof, and anchors only the
+// tree's root on chain.
+type Batcher interface {
+	Queue(ctx context.Context, manifestHash []byte, certFingerprint []byte) error
+	Run(ctx context.Context) error
+}
+
+type batcher struct {
+	dbClient   db.DbClient
+	transactor transactor.Transactor
+	config     Config
+}
+
+func NewBatcher(dbClient db.DbClient, transactor transactor.Transactor, config Config) Batcher {
+	if config.MaxBatchSize <= 0 {
+		config.MaxBatchSize = DefaultMaxBatchSize
+	}
+	if config.MaxBatchAge <= 0 {
+		config.MaxBatchAge = DefaultMaxBatchAge
+	}
+	return &batcher{
+		dbClient:   dbClient,
+		transactor: transactor,
+		config:     config,
+	}
+}
+
+func (b *batcher) Queue(ctx context.Context, manifestHash []byte, certFingerprint []byte) error {
+	if len(manifestHash) != 32 {
+		return fmt.Errorf("manifest hash must be 32 bytes, got %d", len(manifestHash))
+	}
+	if len(certFingerprint) != 32 {
+		return fmt.Errorf("certificate fingerprint must be 32 bytes, got %d", len(certFingerprint))
+	}
+
+	leaf := merkle.Leaf([32]byte(manifestHash), [32]byte(certFingerprint))
+	return b.dbClient.ManifestAnchor().Queue(ctx, manifestHash, certFingerprint, leaf.Bytes())
+}
+
+// Run cuts a new batch if one is due and sends any batches that haven't
+// been sent, or whose transaction failed or was dropped. The transactor
+// follows the sent transactions from there.
+func (b *batcher) Run(ctx context.Context) error {
+	if b.config.ContractAddress == (common.Address{}) {
+		return fmt.Errorf("no contract address to anchor manifests to")
+	}
+
+	if err := b.cutBatch(ctx); err != nil {
+		return err
+	}
+	return b.sendBatches(ctx)
+}
+
+func (b *batcher) cutBatch(ctx context.Context) error {
+	anchors, err := b.dbClient.ManifestAnchor().FindUnbatched(ctx, b.config.MaxBatchSize)
+	if err != nil {
+		return fmt.Errorf("failed to find queued manifests: %w", err)
+	}
+	if len(anchors) == 0 {
+		return nil
+	}
+	if len(anchors) < b.config.MaxBatchSize && time.Since(anchors[0].CreatedAt) < b.config.MaxBatchAge {
+		return nil
+	}
+
+	leaves := make([]merkle.Hash, len(anchors))
+	for i, anchor := range anchors {
+		leaves[i] = merkle.Hash(anchor.LeafHash)
+	}
+	tree, err := merkle.New(leaves)
+	if err != nil {
+		return err
+	}
+
+	for i := range anchors {
+		proof, err := tree.Proof(i)
+		if err != nil {
+			return err
+		}
+		leafIndex := i
+		anchors[i].LeafIndex = &leafIndex
+		anchors[i].Proof = make(models.StringArray, len(proof))
+		for j, sibling := range proof {
+			anchors[i].Proof[j] = common.Hash(sibling).Hex()
+		}
+	}
+
+	root := tree.Root()
+	batch, err := b.dbClient.ManifestAnchorBatch().Create(ctx, &models.ManifestAnchorBatch{
+		ChainID:         b.transactor.ChainID(),
+		ContractAddress: b.config.ContractAddress.Hex(),
+		MerkleRoot:      root.Bytes(),
+		LeafCount:       len(anchors),
+	}, anchors)
+	if err != nil {
+		return fmt.Errorf("failed to save manifest batch: %w", err)
+	}
+
+	log.Printf("Cut manifest batch %s with %d manifests (root %s)", batch.ID, batch.LeafCount, common.Hash(root).Hex())
+	return nil
+}
+
+func (b *batcher) sendBatches(ctx context.Context) error {
+	batches, err := b.dbClient.ManifestAnchorBatch().FindUnsent(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to find unsent manifest batches: %w", err)
+	}
+
+	anchorManifestRootType := string(models.AnchorManifestRootType)
+	for _, batch := range batches {
+		data, err := encodeAnchorManifestRootCall(batch.MerkleRoot, batch.LeafCount)
+		if err != nil {
+			return err
+		}
+
+		contractAddress := common.HexToAddress(batch.ContractAddress)
+		tx, err := b.transactor.Send(ctx, transactor.Request{
+			To:   &contractAddress,
+			Data: data,
+			Type: &anchorManifestRootType,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to anchor manifest batch %s: %w", batch.ID, err)
+		}
+
+		if err := b.dbClient.ManifestAnchorBatch().SetTransaction(ctx, batch.ID, tx.ID); err != nil {
+			return fmt.Errorf("failed to record transaction for manifest batch %s: %w", batch.ID, err)
+		}
+
+		if previous := batch.BlockchainTransaction; previous != nil {
+			log.Printf("Re-anchoring manifest batch %s in transaction %s after transaction %s was %s", batch.ID, *tx.TxHash, previous.ID, previous.Status)
+			continue
+		}
+		log.Printf("Anchoring manifest batch %s in transaction %s", batch.ID, *tx.TxHash)
+	}
+
+	return nil
+}
+
+// encodeAnchorManifestRootCall ABI encodes the anchorManifestRoot(bytes32,uint32) function call
+func encodeAnchorManifestRootCall(root []byte, leafCount int) ([]byte, error) {
+	abiJSON := `[{"inputs":[{"name":"root","type":"bytes32"},{"name":"leafCount","type":"uint32"}],"name":"anchorManifestRoot","outputs":[{"name":"","type":"bool"}],"stateMutability":"nonpayable","type":"function"}]`
+
+	contractABI, err := abi.JSON(strings.NewReader(abiJSON))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ABI: %w", err)
+	}
+
+	var rootBytes32 [32]byte
+	copy(rootBytes32[:], root)
+
+	data, err := contractABI.Pack("anchorManifestRoot", rootBytes32, uint32(leafCount))
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack function call: %w", err)
+	}
+
+	return data, nil
+}
@@ -0,0 +1,278 @@
+package anchor
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MaxBlaushild/poltergeist/ethereum-transactor/internal/merkle"
+	"github.com/MaxBlaushild/poltergeist/ethereum-transactor/internal/transactor"
+	"github.com/MaxBlaushild/poltergeist/pkg/db"
+	"github.com/MaxBlaushild/poltergeist/pkg/ethereum"
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	geth "github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/google/uuid"
+)
+
+// anvilPrivateKey is the first of anvil's well-known dev accounts.
+const anvilPrivateKey = "0xac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80"
+
+const verifyManifestInclusionABI = `[{"inputs":[{"name":"root","type":"bytes32"},{"name":"manifestHash","type":"bytes32"},{"name":"certFingerprint","type":"bytes32"},{"name":"proof","type":"bytes32[]"}],"name":"verifyManifestInclusion","outputs":[{"name":"","type":"bool"}],"stateMutability":"view","type":"function"}]`
+
+// TestBatchAnchorsOnDevChain anchors a batch on a local dev chain through
+// the pkg/ethereum client and checks every manifest's stored proof, both
+// offline and against the contract. It skips unless pointed at a chain with
+// C2PAManifestAnchor deployed, and needs a migrated database whose
+// blockchain_transactions don't predate the chain (or nonces won't line up):
+//
+//	anvil
+//	cd ethereum/verifiable-sn && PRIVATE_KEY=<anvil key> forge script script/C2PAManifestAnchor.s.sol --rpc-url http://localhost:8545 --broadcast
+//	ANCHOR_TEST_RPC_URL=http://localhost:8545 ANCHOR_TEST_CONTRACT_ADDRESS=<address> go test ./internal/anchor/
+func TestBatchAnchorsOnDevChain(t *testing.T) {
+	rpcURL := os.Getenv("ANCHOR_TEST_RPC_URL")
+	contractAddress := os.Getenv("ANCHOR_TEST_CONTRACT_ADDRESS")
+	if rpcURL == "" || contractAddress == "" {
+		t.Skip("ANCHOR_TEST_RPC_URL and ANCHOR_TEST_CONTRACT_ADDRESS not set, skipping dev chain test")
+	}
+	chainID, err := strconv.ParseInt(envOr("ANCHOR_TEST_CHAIN_ID", "31337"), 10, 64)
+	if err != nil {
+		t.Fatalf("invalid ANCHOR_TEST_CHAIN_ID: %v", err)
+	}
+
+	ctx := context.Background()
+	dbClient := testDB(t)
+	ethereumClient, err := ethereum.NewClient(rpcURL, envOr("ANCHOR_TEST_PRIVATE_KEY", anvilPrivateKey), chainID)
+	if err != nil {
+		t.Fatalf("failed to connect to dev chain: %v", err)
+	}
+
+	const batchSize = 5
+	txr := transactor.New(dbClient, ethereumClient, chainID, transactor.Config{})
+	b := NewBatcher(dbClient, txr, Config{
+		ContractAddress: common.HexToAddress(contractAddress),
+		MaxBatchSize:    batchSize,
+		MaxBatchAge:     time.Nanosecond,
+	})
+
+	certFingerprint := randomHash(t)
+	manifestHashes := make([][32]byte, batchSize)
+	for i := range manifestHashes {
+		manifestHashes[i] = randomHash(t)
+		if err := b.Queue(ctx, manifestHashes[i][:], certFingerprint[:]); err != nil {
+			t.Fatalf("failed to queue manifest: %v", err)
+		}
+	}
+
+	anchors := make([]*models.ManifestAnchor, batchSize)
+	deadline := time.Now().Add(time.Minute)
+	for {
+		if err := b.Run(ctx); err != nil {
+			t.Fatalf("batcher run failed: %v", err)
+		}
+		if err := txr.Monitor(ctx); err != nil {
+			t.Fatalf("transactor monitor failed: %v", err)
+		}
+
+		confirmed := true
+		for i, manifestHash := range manifestHashes {
+			anchors[i], err = dbClient.ManifestAnchor().FindByManifestHash(ctx, manifestHash[:])
+			if err != nil {
+				t.Fatalf("failed to find manifest anchor: %v", err)
+			}
+			batch := anchors[i].Batch
+			if batch == nil || batch.BlockchainTransaction == nil || batch.BlockchainTransaction.Status != string(models.ConfirmedStatus) {
+				confirmed = false
+			}
+		}
+		if confirmed {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("manifest batch wasn't confirmed within a minute")
+		}
+		time.Sleep(time.Second)
+	}
+
+	ethClient, err := ethclient.Dial(rpcURL)
+	if err != nil {
+		t.Fatalf("failed to connect to dev chain: %v", err)
+	}
+	contractABI, err := abi.JSON(strings.NewReader(verifyManifestInclusionABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	contract := common.HexToAddress(contractAddress)
+
+	verifyOnChain := func(root [32]byte, manifestHash [32]byte, certFingerprint [32]byte, proof [][32]byte) bool {
+		t.Helper()
+		data, err := contractABI.Pack("verifyManifestInclusion", root, manifestHash, certFingerprint, proof)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result, err := ethClient.CallContract(ctx, geth.CallMsg{To: &contract, Data: data}, nil)
+		if err != nil {
+			t.Fatalf("verifyManifestInclusion call failed: %v", err)
+		}
+		values, err := contractABI.Unpack("verifyManifestInclusion", result)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return values[0].(bool)
+	}
+
+	for i, anchor := range anchors {
+		root := merkle.Hash(anchor.Batch.MerkleRoot)
+		leaf := merkle.Leaf(manifestHashes[i], certFingerprint)
+		if merkle.Hash(anchor.LeafHash) != leaf {
+			t.Fatalf("manifest %d stored the wrong leaf", i)
+		}
+
+		proof := make([]merkle.Hash, len(anchor.Proof))
+		onChainProof := make([][32]byte, len(anchor.Proof))
+		for j, sibling := range anchor.Proof {
+			proof[j] = merkle.Hash(common.HexToHash(sibling))
+			onChainProof[j] = proof[j]
+		}
+
+		if !merkle.Verify(root, leaf, proof) {
+			t.Fatalf("manifest %d proof doesn't verify offline", i)
+		}
+		if !verifyOnChain(root, manifestHashes[i], certFingerprint, onChainProof) {
+			t.Fatalf("manifest %d proof doesn't verify on chain", i)
+		}
+		if verifyOnChain(root, manifestHashes[i], randomHash(t), onChainProof) {
+			t.Fatalf("manifest %d proof verified on chain with the wrong certificate", i)
+		}
+	}
+}
+
+func testDB(t *testing.T) db.DbClient {
+	t.Helper()
+
+	host := envOr("ANCHOR_TEST_DB_HOST", "localhost")
+	port := envOr("ANCHOR_TEST_DB_PORT", "5432")
+	user := envOr("ANCHOR_TEST_DB_USER", "db_user")
+	name := envOr("ANCHOR_TEST_DB_NAME", "poltergeist")
+	password := envOr("ANCHOR_TEST_DB_PASSWORD", "x") // must be non-empty, see go/pkg/db DSN builder
+
+	dbClient, err := db.NewClient(db.ClientConfig{Host: host, Port: port, User: user, Name: name, Password: password})
+	if err != nil {
+		t.Skipf("no reachable test database (%s:%s/%s), skipping: %v", host, port, name, err)
+	}
+	return dbClient
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func randomHash(t *testing.T) [32]byte {
+	t.Helper()
+	var h [32]byte
+	if _, err := rand.Read(h[:]); err != nil {
+		t.Fatal(err)
+	}
+	return h
+}
+
+// The fakes embed the interfaces they stand in for, so calls the test does
+// not expect panic instead of silently succeeding.
+
+type resendTestDB struct {
+	db.DbClient
+	anchors *resendTestAnchors
+	batches *resendTestBatches
+}
+
+func (f *resendTestDB) ManifestAnchor() db.ManifestAnchorHandle { return f.anchors }
+
+func (f *resendTestDB) ManifestAnchorBatch() db.ManifestAnchorBatchHandle { return f.batches }
+
+type resendTestAnchors struct {
+	db.ManifestAnchorHandle
+}
+
+func (resendTestAnchors) FindUnbatched(context.Context, int) ([]models.ManifestAnchor, error) {
+	return nil, nil
+}
+
+type resendTestBatches struct {
+	db.ManifestAnchorBatchHandle
+	unsent []models.ManifestAnchorBatch
+	linked map[uuid.UUID]uuid.UUID
+}
+
+func (f *resendTestBatches) FindUnsent(context.Context) ([]models.ManifestAnchorBatch, error) {
+	return f.unsent, nil
+}
+
+func (f *resendTestBatches) SetTransaction(_ context.Context, id uuid.UUID, blockchainTransactionID uuid.UUID) error {
+	f.linked[id] = blockchainTransactionID
+	return nil
+}
+
+type resendTestTransactor struct {
+	transactor.Transactor
+	sent []transactor.Request
+}
+
+func (f *resendTestTransactor) Send(_ context.Context, req transactor.Request) (*models.BlockchainTransaction, error) {
+	f.sent = append(f.sent, req)
+	txHash := fmt.Sprintf("0x%064x", len(f.sent))
+	return &models.BlockchainTransaction{ID: uuid.New(), TxHash: &txHash}, nil
+}
+
+func (f *resendTestTransactor) ChainID() int64 { return 31337 }
+
+func TestRunResendsBatchesWhoseTransactionFailedOrWasDropped(t *testing.T) {
+	contractAddress := common.HexToAddress("0x5FbDB2315678afecb367f032d93F642f64180aa3")
+	var unsent []models.ManifestAnchorBatch
+	for _, status := range []models.BlockchainTransactionStatus{models.FailedStatus, models.DroppedStatus} {
+		root := randomHash(t)
+		previousID := uuid.New()
+		unsent = append(unsent, models.ManifestAnchorBatch{
+			ID:                      uuid.New(),
+			ContractAddress:         contractAddress.Hex(),
+			MerkleRoot:              root[:],
+			LeafCount:               3,
+			BlockchainTransactionID: &previousID,
+			BlockchainTransaction:   &models.BlockchainTransaction{ID: previousID, Status: string(status)},
+		})
+	}
+	batches := &resendTestBatches{unsent: unsent, linked: map[uuid.UUID]uuid.UUID{}}
+	txr := &resendTestTransactor{}
+	b := NewBatcher(&resendTestDB{anchors: &resendTestAnchors{}, batches: batches}, txr, Config{ContractAddress: contractAddress})
+
+	if err := b.Run(context.Background()); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	if len(txr.sent) != len(unsent) {
+		t.Fatalf("expected %d batches to be resent, got %d", len(unsent), len(txr.sent))
+	}
+	for i, batch := range unsent {
+		want, err := encodeAnchorManifestRootCall(batch.MerkleRoot, batch.LeafCount)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if req := txr.sent[i]; *req.To != contractAddress || !bytes.Equal(req.Data, want) {
+			t.Fatalf("expected batch %d's root to be anchored again, got %+v", i, req)
+		}
+		linked, ok := batches.linked[batch.ID]
+		if !ok || linked == *batch.BlockchainTransactionID {
+			t.Fatalf("expected batch %d to be linked to its new transaction, got %v", i, linked)
+		}
+	}
+}
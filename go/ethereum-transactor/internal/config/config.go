@@ -4,6 +4,7 @@ import (
 	"flag"
 	"os"
 	"strconv"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -20,6 +21,20 @@ type PublicConfig struct {
 	DbName  string `mapstructure:"DB_NAME"`
 	ChainID int64  `mapstructure:"CHAIN_ID"`
 	RPCURL  string `mapstructure:"RPC_URL"`
+
+	// C2PAContractAddress is the contract manifest batch roots are anchored to.
+	C2PAContractAddress string `mapstructure:"C2PA_CONTRACT_ADDRESS"`
+	// ManifestBatchSize and ManifestBatchMaxAge bound how many manifests a
+	// batch holds and how long a queued manifest waits for one.
+	ManifestBatchSize   int           `mapstructure:"MANIFEST_BATCH_SIZE"`
+	ManifestBatchMaxAge time.Duration `mapstructure:"MANIFEST_BATCH_MAX_AGE"`
+
+	// TxReplaceAfter is how long a transaction waits to be mined before it's
+	// replaced with higher fees, at most TxMaxReplacements times.
+	TxReplaceAfter    time.Duration `mapstructure:"TX_REPLACE_AFTER"`
+	TxMaxReplacements int           `mapstructure:"TX_MAX_REPLACEMENTS"`
+	// TxReorgDepth is how many blocks deep a mined transaction is checked for reorgs.
+	TxReorgDepth uint64 `mapstructure:"TX_REORG_DEPTH"`
 }
 
 type Config struct {
@@ -72,6 +87,55 @@ func ParseFlagsAndGetConfig() (*Config, error) {
 		publicCfg.RPCURL = os.Getenv("RPC_URL")
 	}
 
+	if publicCfg.C2PAContractAddress == "" {
+		publicCfg.C2PAContractAddress = os.Getenv("C2PA_CONTRACT_ADDRESS")
+	}
+
+	if publicCfg.ManifestBatchSize == 0 {
+		if batchSizeStr := os.Getenv("MANIFEST_BATCH_SIZE"); batchSizeStr != "" {
+			batchSize, err := strconv.Atoi(batchSizeStr)
+			if err == nil {
+				publicCfg.ManifestBatchSize = batchSize
+			}
+		}
+	}
+
+	if publicCfg.ManifestBatchMaxAge == 0 {
+		if maxAgeStr := os.Getenv("MANIFEST_BATCH_MAX_AGE"); maxAgeStr != "" {
+			maxAge, err := time.ParseDuration(maxAgeStr)
+			if err == nil {
+				publicCfg.ManifestBatchMaxAge = maxAge
+			}
+		}
+	}
+
+	if publicCfg.TxReplaceAfter == 0 {
+		if replaceAfterStr := os.Getenv("TX_REPLACE_AFTER"); replaceAfterStr != "" {
+			replaceAfter, err := time.ParseDuration(replaceAfterStr)
+			if err == nil {
+				publicCfg.TxReplaceAfter = replaceAfter
+			}
+		}
+	}
+
+	if publicCfg.TxMaxReplacements == 0 {
+		if maxReplacementsStr := os.Getenv("TX_MAX_REPLACEMENTS"); maxReplacementsStr != "" {
+			maxReplacements, err := strconv.Atoi(maxReplacementsStr)
+			if err == nil {
+				publicCfg.TxMaxReplacements = maxReplacements
+			}
+		}
+	}
+
+	if publicCfg.TxReorgDepth == 0 {
+		if reorgDepthStr := os.Getenv("TX_REORG_DEPTH"); reorgDepthStr != "" {
+			reorgDepth, err := strconv.ParseUint(reorgDepthStr, 10, 64)
+			if err == nil {
+				publicCfg.TxReorgDepth = reorgDepth
+			}
+		}
+	}
+
 	return &Config{
 		Secret: SecretConfig{
 			DbPassword: os.Getenv("DB_PASSWORD"),
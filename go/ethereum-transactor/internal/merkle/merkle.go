@@ -0,0 +1,104 @@
+// Package merkle builds the Merkle trees that batches of manifests are
+// anchored under. Leaves and pairs are hashed the way OpenZeppelin's
+// MerkleProof and StandardMerkleTree hash them, so a proof from here verifies
+// on chain, or with any off-the-shelf verifier, without modification.
+package merkle
+
+import (
+	"bytes"
+	"fmt"
+
+	"golang.org/x/crypto/sha3"
+)
+
+type Hash [32]byte
+
+// Leaf hashes a manifest and the fingerprint of the certificate that signed
+// it into a leaf: keccak256(keccak256(abi.encode(manifestHash,
+// certFingerprint))). Hashing twice keeps a leaf from being passed off as an
+// inner node.
+func Leaf(manifestHash [32]byte, certFingerprint [32]byte) Hash {
+	return keccak256(keccak256(manifestHash[:], certFingerprint[:]).Bytes())
+}
+
+// Tree is a binary Merkle tree over a batch's leaves. A level with an odd
+// number of nodes carries its last node up unpaired.
+type Tree struct {
+	levels [][]Hash
+}
+
+func New(leaves []Hash) (*Tree, error) {
+	if len(leaves) == 0 {
+		return nil, fmt.Errorf("merkle tree needs at least one leaf")
+	}
+
+	level := append([]Hash(nil), leaves...)
+	levels := [][]Hash{level}
+	for len(level) > 1 {
+		next := make([]Hash, 0, (len(level)+1)/2)
+		for i := 0; i < len(level); i += 2 {
+			if i+1 == len(level) {
+				next = append(next, level[i])
+				continue
+			}
+			next = append(next, hashPair(level[i], level[i+1]))
+		}
+		levels = append(levels, next)
+		level = next
+	}
+
+	return &Tree{levels: levels}, nil
+}
+
+func (t *Tree) Root() Hash {
+	return t.levels[len(t.levels)-1][0]
+}
+
+// Proof returns the sibling hashes from the leaf at index up to the root.
+func (t *Tree) Proof(index int) ([]Hash, error) {
+	if index < 0 || index >= len(t.levels[0]) {
+		return nil, fmt.Errorf("leaf index %d out of range", index)
+	}
+
+	proof := []Hash{}
+	for _, level := range t.levels[:len(t.levels)-1] {
+		sibling := index ^ 1
+		if sibling < len(level) {
+			proof = append(proof, level[sibling])
+		}
+		index /= 2
+	}
+	return proof, nil
+}
+
+// Verify reports whether proof leads from leaf to root.
+func Verify(root Hash, leaf Hash, proof []Hash) bool {
+	node := leaf
+	for _, sibling := range proof {
+		node = hashPair(node, sibling)
+	}
+	return node == root
+}
+
+func (h Hash) Bytes() []byte {
+	return h[:]
+}
+
+// hashPair hashes two nodes in sorted order, so proofs don't need to say
+// which side each sibling is on.
+func hashPair(a Hash, b Hash) Hash {
+	if bytes.Compare(a[:], b[:]) > 0 {
+		a, b = b, a
+	}
+	return keccak256(a[:], b[:])
+}
+
+func keccak256(data ...[]byte) Hash {
+	h := sha3.NewLegacyKeccak256()
+	for _, d := range data {
+		h.Write(d)
+	}
+	var out Hash
+	h.Sum(out[:0])
+	return out
+}
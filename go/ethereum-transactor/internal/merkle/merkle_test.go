@@ -0,0 +1,92 @@
+package merkle
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func testLeaves(n int) []Hash {
+	leaves := make([]Hash, n)
+	for i := range leaves {
+		var manifestHash, certFingerprint [32]byte
+		manifestHash[0] = byte(i)
+		manifestHash[31] = byte(i >> 8)
+		certFingerprint[0] = 0xcf
+		leaves[i] = Leaf(manifestHash, certFingerprint)
+	}
+	return leaves
+}
+
+func TestEveryLeafProvesAgainstTheRoot(t *testing.T) {
+	for n := 1; n <= 33; n++ {
+		leaves := testLeaves(n)
+		tree, err := New(leaves)
+		if err != nil {
+			t.Fatalf("%d leaves: %v", n, err)
+		}
+		for i, leaf := range leaves {
+			proof, err := tree.Proof(i)
+			if err != nil {
+				t.Fatalf("%d leaves, leaf %d: %v", n, i, err)
+			}
+			if !Verify(tree.Root(), leaf, proof) {
+				t.Fatalf("%d leaves: proof for leaf %d doesn't verify", n, i)
+			}
+		}
+	}
+}
+
+func TestSingleLeafIsTheRoot(t *testing.T) {
+	leaves := testLeaves(1)
+	tree, err := New(leaves)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tree.Root() != leaves[0] {
+		t.Fatalf("expected the root of a one-leaf tree to be the leaf")
+	}
+	proof, _ := tree.Proof(0)
+	if len(proof) != 0 {
+		t.Fatalf("expected an empty proof, got %d hashes", len(proof))
+	}
+}
+
+func TestVerifyRejectsWrongLeafAndTamperedProof(t *testing.T) {
+	leaves := testLeaves(7)
+	tree, err := New(leaves)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, _ := tree.Proof(3)
+
+	if Verify(tree.Root(), leaves[4], proof) {
+		t.Fatalf("proof for leaf 3 verified leaf 4")
+	}
+
+	tampered := append([]Hash(nil), proof...)
+	tampered[0][0] ^= 1
+	if Verify(tree.Root(), leaves[3], tampered) {
+		t.Fatalf("tampered proof verified")
+	}
+}
+
+func TestHashPairIsOrderIndependent(t *testing.T) {
+	leaves := testLeaves(2)
+	if hashPair(leaves[0], leaves[1]) != hashPair(leaves[1], leaves[0]) {
+		t.Fatalf("expected sorted pair hashing")
+	}
+}
+
+func TestKeccak256MatchesEthereum(t *testing.T) {
+	// keccak256("") as returned by Solidity and go-ethereum.
+	const empty = "c5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470"
+	if got := hex.EncodeToString(keccak256().Bytes()); got != empty {
+		t.Fatalf("expected legacy keccak256, got %s", got)
+	}
+}
+
+func TestNewRejectsEmptyBatch(t *testing.T) {
+	if _, err := New(nil); err == nil {
+		t.Fatalf("expected an error for an empty tree")
+	}
+}
@@ -0,0 +1,59 @@
+package server
+
+import (
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// QueueManifestAnchor queues a manifest for the next anchored batch. Its
+// inclusion proof is stored on the manifest's anchor once the batch is cut.
+func (s *server) QueueManifestAnchor(ctx *gin.Context) {
+	var requestBody struct {
+		ManifestHash    string `json:"manifestHash" binding:"required"`
+		CertFingerprint string `json:"certFingerprint" binding:"required"`
+	}
+
+	if err := ctx.Bind(&requestBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	manifestHash, err := hex.DecodeString(strings.TrimPrefix(requestBody.ManifestHash, "0x"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid manifestHash format: " + err.Error(),
+		})
+		return
+	}
+
+	certFingerprint, err := hex.DecodeString(strings.TrimPrefix(requestBody.CertFingerprint, "0x"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid certFingerprint format: " + err.Error(),
+		})
+		return
+	}
+
+	if len(manifestHash) != 32 || len(certFingerprint) != 32 {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "manifestHash and certFingerprint must be 32 bytes",
+		})
+		return
+	}
+
+	if err := s.batcher.Queue(ctx, manifestHash, certFingerprint); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to queue manifest: " + err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"status": "queued",
+	})
+}
@@ -3,15 +3,16 @@ package server
 import (
 	"fmt"
 
+	"github.com/MaxBlaushild/poltergeist/ethereum-transactor/internal/anchor"
+	"github.com/MaxBlaushild/poltergeist/ethereum-transactor/internal/transactor"
 	"github.com/MaxBlaushild/poltergeist/pkg/db"
-	"github.com/MaxBlaushild/poltergeist/pkg/ethereum"
 	"github.com/gin-gonic/gin"
 )
 
 type server struct {
-	dbClient       db.DbClient
-	ethereumClient ethereum.EthereumClient
-	chainID        int64
+	dbClient   db.DbClient
+	transactor transactor.Transactor
+	batcher    anchor.Batcher
 }
 
 type Server interface {
@@ -21,13 +22,13 @@ type Server interface {
 
 func NewServer(
 	dbClient db.DbClient,
-	ethereumClient ethereum.EthereumClient,
-	chainID int64,
+	transactor transactor.Transactor,
+	batcher anchor.Batcher,
 ) Server {
 	return &server{
-		dbClient:       dbClient,
-		ethereumClient: ethereumClient,
-		chainID:        chainID,
+		dbClient:   dbClient,
+		transactor: transactor,
+		batcher:    batcher,
 	}
 }
 
@@ -36,6 +37,9 @@ func (s *server) SetupRoutes(r *gin.Engine) {
 
 	// Transaction routes
 	r.POST("/ethereum-transactor/transactions", s.CreateTransaction)
+
+	// Manifest anchoring routes
+	r.POST("/ethereum-transactor/manifest-anchors", s.QueueManifestAnchor)
 }
 
 func (s *server) ListenAndServe(port string) {
@@ -0,0 +1,302 @@
+package transactor
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/ethereum"
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Monitor follows the account's transactions: it moves mined transactions
+// out of pending, puts reorged ones back, marks ones whose nonce was taken
+// by another transaction as dropped, and replaces stuck ones with higher
+// fees, which also sends saved transactions that never were. Every change
+// is recorded as an event on the transaction.
+func (t *transactor) Monitor(ctx context.Context) error {
+	fromAddress := t.ethereumClient.GetAddress()
+
+	if err := t.checkReorgs(ctx, fromAddress); err != nil {
+		return err
+	}
+
+	// Read the confirmed nonce before looking for receipts, so a transaction
+	// mined in between is found rather than taken for dropped.
+	confirmedNonce, err := t.ethereumClient.GetConfirmedNonce(ctx, fromAddress)
+	if err != nil {
+		return fmt.Errorf("failed to get confirmed nonce: %w", err)
+	}
+
+	txs, err := t.dbClient.BlockchainTransaction().FindPendingByAccount(ctx, t.chainID, fromAddress.Hex())
+	if err != nil {
+		return fmt.Errorf("failed to find pending transactions: %w", err)
+	}
+
+	for _, tx := range txs {
+		if err := t.checkPending(ctx, tx, confirmedNonce); err != nil {
+			log.Printf("Failed to check transaction %s: %v", tx.ID, err)
+		}
+	}
+
+	return nil
+}
+
+func (t *transactor) checkPending(ctx context.Context, tx models.BlockchainTransaction, confirmedNonce uint64) error {
+	txHash, status, err := t.findMined(ctx, tx)
+	if err != nil {
+		return err
+	}
+	if status != nil {
+		reason := "mined"
+		if status.Status == string(models.FailedStatus) {
+			reason = "reverted"
+		}
+		if err := t.setMined(ctx, tx, txHash, status, reason); err != nil {
+			return err
+		}
+		log.Printf("Transaction %s is %s in block %d", txHash.Hex(), status.Status, *status.BlockNumber)
+		return nil
+	}
+
+	broadcastAt := tx.CreatedAt
+	if tx.BroadcastAt != nil {
+		broadcastAt = *tx.BroadcastAt
+	}
+	waited := time.Since(broadcastAt)
+	if waited < t.config.ReplaceAfter {
+		return nil
+	}
+
+	if confirmedNonce > tx.Nonce {
+		reason := fmt.Sprintf("nonce %d was used by another transaction", tx.Nonce)
+		if tx.TxHash == nil {
+			// Possibly this one, if it was sent but its hash wasn't saved.
+			reason = fmt.Sprintf("nonce %d was used without a recorded attempt", tx.Nonce)
+		}
+		if err := t.dbClient.BlockchainTransaction().SetStatus(ctx, tx.ID, models.BlockchainTransactionStatusChange{
+			Status: models.DroppedStatus,
+			Reason: reason,
+		}); err != nil {
+			return fmt.Errorf("failed to update transaction status: %w", err)
+		}
+		log.Printf("Transaction %s was dropped", tx.ID)
+		return nil
+	}
+
+	if len(tx.ReplacedTxHashes) >= t.config.MaxReplacements {
+		log.Printf("Transaction %s is stuck after %d replacements", tx.ID, len(tx.ReplacedTxHashes))
+		return nil
+	}
+
+	if tx.TxHash == nil {
+		// Saved but never sent, or sent without its hash being saved.
+		return t.replace(ctx, tx, fmt.Sprintf("no attempt recorded after %s", waited.Round(time.Second)))
+	}
+	return t.replace(ctx, tx, fmt.Sprintf("not mined after %s", waited.Round(time.Second)))
+}
+
+// findMined looks for a receipt for any attempt at the transaction, latest
+// first, since a replaced attempt can still be mined instead of its
+// replacement.
+func (t *transactor) findMined(ctx context.Context, tx models.BlockchainTransaction) (common.Hash, *ethereum.TransactionStatus, error) {
+	var hashes []string
+	if tx.TxHash != nil {
+		hashes = append(hashes, *tx.TxHash)
+	}
+	for i := len(tx.ReplacedTxHashes) - 1; i >= 0; i-- {
+		hashes = append(hashes, tx.ReplacedTxHashes[i])
+	}
+
+	for _, hash := range hashes {
+		txHash := common.HexToHash(hash)
+		status, err := t.ethereumClient.GetTransactionStatus(ctx, txHash)
+		if err != nil {
+			return common.Hash{}, nil, fmt.Errorf("failed to get transaction status for %s: %w", hash, err)
+		}
+		if status.Status == string(models.ConfirmedStatus) || status.Status == string(models.FailedStatus) {
+			return txHash, status, nil
+		}
+	}
+
+	return common.Hash{}, nil, nil
+}
+
+func (t *transactor) setMined(ctx context.Context, tx models.BlockchainTransaction, txHash common.Hash, status *ethereum.TransactionStatus, reason string) error {
+	txHashStr := txHash.Hex()
+	change := models.BlockchainTransactionStatusChange{
+		Status:      models.BlockchainTransactionStatus(status.Status),
+		TxHash:      &txHashStr,
+		BlockNumber: status.BlockNumber,
+		ConfirmedAt: status.ConfirmedAt,
+		Reason:      reason,
+	}
+	if status.BlockHash != nil {
+		blockHash := status.BlockHash.Hex()
+		change.BlockHash = &blockHash
+	}
+
+	if err := t.dbClient.BlockchainTransaction().SetStatus(ctx, tx.ID, change); err != nil {
+		return fmt.Errorf("failed to update transaction status: %w", err)
+	}
+	return nil
+}
+
+// replace resends a stuck transaction at the same nonce with fees bumped
+// enough for nodes to accept it as a replacement, or the currently suggested
+// fees if those are higher.
+func (t *transactor) replace(ctx context.Context, tx models.BlockchainTransaction, reason string) error {
+	suggested, err := t.suggestFees(ctx)
+	if err != nil {
+		return err
+	}
+
+	next := suggested
+	if suggested.gasPrice != nil {
+		if gasPrice, ok := parseBig(tx.GasPrice); ok {
+			next.gasPrice = maxBig(bumpFee(gasPrice), suggested.gasPrice)
+		}
+	} else {
+		gasTipCap, tipOK := parseBig(tx.GasTipCap)
+		gasFeeCap, feeCapOK := parseBig(tx.GasFeeCap)
+		if !tipOK || !feeCapOK {
+			// A legacy transaction's fees both have to be bumped over its
+			// gas price to replace it.
+			gasTipCap, tipOK = parseBig(tx.GasPrice)
+			gasFeeCap, feeCapOK = gasTipCap, tipOK
+		}
+		if tipOK && feeCapOK {
+			next.dynamic = &ethereum.Fees{
+				GasTipCap: maxBig(bumpFee(gasTipCap), suggested.dynamic.GasTipCap),
+				GasFeeCap: maxBig(bumpFee(gasFeeCap), suggested.dynamic.GasFeeCap),
+			}
+		}
+		if next.dynamic.GasFeeCap.Cmp(next.dynamic.GasTipCap) < 0 {
+			next.dynamic.GasFeeCap = next.dynamic.GasTipCap
+		}
+	}
+
+	var to *common.Address
+	if tx.ToAddress != nil && *tx.ToAddress != "" {
+		toAddress := common.HexToAddress(*tx.ToAddress)
+		to = &toAddress
+	}
+	value, ok := new(big.Int).SetString(tx.Value, 10)
+	if !ok {
+		return fmt.Errorf("invalid value %q", tx.Value)
+	}
+	var data []byte
+	if tx.Data != nil && *tx.Data != "" {
+		data, err = hex.DecodeString(strings.TrimPrefix(*tx.Data, "0x"))
+		if err != nil {
+			return fmt.Errorf("invalid data: %w", err)
+		}
+	}
+	if tx.GasLimit == nil {
+		return fmt.Errorf("transaction has no gas limit")
+	}
+
+	txHash, err := t.send(ctx, to, value, data, *tx.GasLimit, next, tx.Nonce)
+	if err != nil {
+		return fmt.Errorf("failed to send replacement transaction: %w", err)
+	}
+
+	gasPrice, gasTipCap, gasFeeCap := next.strings()
+	if err := t.dbClient.BlockchainTransaction().Replace(ctx, tx.ID, models.BlockchainTransactionReplacement{
+		TxHash:    txHash.Hex(),
+		GasPrice:  gasPrice,
+		GasTipCap: gasTipCap,
+		GasFeeCap: gasFeeCap,
+		Reason:    reason,
+	}); err != nil {
+		return fmt.Errorf("failed to save replacement transaction: %w", err)
+	}
+
+	log.Printf("Replaced transaction %s at nonce %d with %s", tx.ID, tx.Nonce, txHash.Hex())
+	return nil
+}
+
+// checkReorgs rechecks transactions mined within the last ReorgDepth blocks
+// whose block is no longer on the canonical chain.
+func (t *transactor) checkReorgs(ctx context.Context, fromAddress common.Address) error {
+	head, err := t.ethereumClient.GetBlockNumber(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get block number: %w", err)
+	}
+	var since uint64
+	if head > t.config.ReorgDepth {
+		since = head - t.config.ReorgDepth
+	}
+
+	txs, err := t.dbClient.BlockchainTransaction().FindMinedSinceBlock(ctx, t.chainID, fromAddress.Hex(), since)
+	if err != nil {
+		return fmt.Errorf("failed to find mined transactions: %w", err)
+	}
+
+	for _, tx := range txs {
+		if tx.BlockNumber == nil || tx.BlockHash == nil || tx.TxHash == nil {
+			continue
+		}
+
+		blockHash, err := t.ethereumClient.GetBlockHash(ctx, *tx.BlockNumber)
+		if err != nil {
+			log.Printf("Failed to get hash of block %d: %v", *tx.BlockNumber, err)
+			continue
+		}
+		if blockHash == common.HexToHash(*tx.BlockHash) {
+			continue
+		}
+
+		txHash := common.HexToHash(*tx.TxHash)
+		status, err := t.ethereumClient.GetTransactionStatus(ctx, txHash)
+		if err != nil {
+			log.Printf("Failed to get transaction status for %s: %v", *tx.TxHash, err)
+			continue
+		}
+
+		if status.Status == string(models.ConfirmedStatus) || status.Status == string(models.FailedStatus) {
+			reason := fmt.Sprintf("block %d was reorged; mined again in block %d", *tx.BlockNumber, *status.BlockNumber)
+			if err := t.setMined(ctx, tx, txHash, status, reason); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := t.dbClient.BlockchainTransaction().SetStatus(ctx, tx.ID, models.BlockchainTransactionStatusChange{
+			Status: models.PendingStatus,
+			Reason: fmt.Sprintf("block %d was reorged out", *tx.BlockNumber),
+		}); err != nil {
+			return fmt.Errorf("failed to update transaction status: %w", err)
+		}
+		log.Printf("Transaction %s was reorged out of block %d", *tx.TxHash, *tx.BlockNumber)
+	}
+
+	return nil
+}
+
+// bumpFee raises a fee by 12.5%, a little over the 10% most nodes require of
+// a replacement.
+func bumpFee(fee *big.Int) *big.Int {
+	bumped := new(big.Int).Mul(fee, big.NewInt(9))
+	bumped.Div(bumped, big.NewInt(8))
+	return bumped.Add(bumped, big.NewInt(1))
+}
+
+func maxBig(a, b *big.Int) *big.Int {
+	if a.Cmp(b) >= 0 {
+		return a
+	}
+	return b
+}
+
+func parseBig(s *string) (*big.Int, bool) {
+	if s == nil || *s == "" {
+		return nil, false
+	}
+	return new(big.Int).SetString(*s, 10)
+}
@@ -0,0 +1,29 @@
+package transactor
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestBumpFeeClearsReplacementMinimum(t *testing.T) {
+	for _, fee := range []int64{0, 1, 7, 1_000_000_000, 123_456_789_012} {
+		bumped := bumpFee(big.NewInt(fee))
+
+		// Nodes accept a replacement paying at least 10% more.
+		minimum := new(big.Int).Mul(big.NewInt(fee), big.NewInt(11))
+		minimum.Div(minimum, big.NewInt(10))
+		if bumped.Cmp(minimum) < 0 {
+			t.Errorf("bumpFee(%d) = %s, want at least %s", fee, bumped, minimum)
+		}
+		if bumped.Cmp(big.NewInt(fee)) <= 0 {
+			t.Errorf("bumpFee(%d) = %s, want more than the original fee", fee, bumped)
+		}
+	}
+}
+
+func TestFeesStrings(t *testing.T) {
+	gasPrice, gasTipCap, gasFeeCap := fees{gasPrice: big.NewInt(42)}.strings()
+	if gasPrice == nil || *gasPrice != "42" || gasTipCap != nil || gasFeeCap != nil {
+		t.Errorf("legacy fees recorded as %v, %v, %v", gasPrice, gasTipCap, gasFeeCap)
+	}
+}
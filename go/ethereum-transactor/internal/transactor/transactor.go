@@ -0,0 +1,261 @@
+package transactor
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/db"
+	"github.com/MaxBlaushild/poltergeist/pkg/ethereum"
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	// MonitorInterval is how often the service runs Monitor.
+	MonitorInterval = 15 * time.Second
+
+	DefaultReplaceAfter    = 5 * time.Minute
+	DefaultMaxReplacements = 10
+	DefaultReorgDepth      = 12
+)
+
+// Config controls stuck-transaction recovery. A pending transaction is
+// replaced with higher fees once its latest attempt has gone ReplaceAfter
+// without being mined, at most MaxReplacements times. Mined transactions are
+// checked for reorgs until they're ReorgDepth blocks deep.
+type Config struct {
+	ReplaceAfter    time.Duration
+	MaxReplacements int
+	ReorgDepth      uint64
+}
+
+// Request describes a transaction to send from the transactor's account.
+// GasLimit is estimated when it's nil. A transaction with a GasPrice is sent
+// as a legacy transaction; otherwise it's sent with EIP-1559 fees suggested
+// by the node, where the chain supports them.
+type Request struct {
+	To       *common.Address
+	Value    *big.Int
+	Data     []byte
+	GasLimit *uint64
+	GasPrice *big.Int
+	Type     *string
+}
+
+// Transactor sends transactions and records them as BlockchainTransactions,
+// then follows them until they're mined, replacing them when they're stuck.
+type Transactor interface {
+	Send(ctx context.Context, req Request) (*models.BlockchainTransaction, error)
+	Monitor(ctx context.Context) error
+	ChainID() int64
+}
+
+type transactor struct {
+	dbClient       db.DbClient
+	ethereumClient ethereum.EthereumClient
+	chainID        int64
+	config         Config
+
+	// mu is held from allocating a nonce until the transaction using it is
+	// sent, so concurrent sends never share a nonce. nextNonce is the nonce
+	// after the last one sent, or nil if it's unknown.
+	mu        sync.Mutex
+	nextNonce *uint64
+}
+
+func New(dbClient db.DbClient, ethereumClient ethereum.EthereumClient, chainID int64, config Config) Transactor {
+	if config.ReplaceAfter <= 0 {
+		config.ReplaceAfter = DefaultReplaceAfter
+	}
+	if config.MaxReplacements <= 0 {
+		config.MaxReplacements = DefaultMaxReplacements
+	}
+	if config.ReorgDepth == 0 {
+		config.ReorgDepth = DefaultReorgDepth
+	}
+	return &transactor{
+		dbClient:       dbClient,
+		ethereumClient: ethereumClient,
+		chainID:        chainID,
+		config:         config,
+	}
+}
+
+func (t *transactor) ChainID() int64 {
+	return t.chainID
+}
+
+func (t *transactor) Send(ctx context.Context, req Request) (*models.BlockchainTransaction, error) {
+	value := req.Value
+	if value == nil {
+		value = big.NewInt(0)
+	}
+
+	// Calculate gas limit
+	var gasLimit uint64
+	if req.GasLimit != nil {
+		// Use provided gas limit
+		gasLimit = *req.GasLimit
+	} else if len(req.Data) > 0 && req.To != nil {
+		// For contract calls with data, estimate gas
+		estimatedGas, err := t.ethereumClient.EstimateGas(ctx, req.To, value, req.Data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to estimate gas: %w", err)
+		}
+		gasLimit = estimatedGas
+	} else {
+		// Default gas limit for simple ETH transfers
+		gasLimit = uint64(21000)
+	}
+
+	txFees := fees{gasPrice: req.GasPrice}
+	if txFees.gasPrice == nil {
+		suggested, err := t.suggestFees(ctx)
+		if err != nil {
+			return nil, err
+		}
+		txFees = suggested
+	}
+
+	fromAddress := t.ethereumClient.GetAddress()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	nonce, err := t.allocateNonce(ctx, fromAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	// Save the transaction before sending it, so a crash or a database
+	// outage after sending can't lose track of a transaction holding the
+	// nonce. The monitor sends saved transactions that never were.
+	toAddressStr := ""
+	if req.To != nil {
+		toAddressStr = req.To.Hex()
+	}
+	dataStr := ""
+	if len(req.Data) > 0 {
+		dataStr = "0x" + hex.EncodeToString(req.Data)
+	}
+	gasPrice, gasTipCap, gasFeeCap := txFees.strings()
+
+	blockchainTx, err := t.dbClient.BlockchainTransaction().Create(ctx, &models.BlockchainTransaction{
+		ChainID:     t.chainID,
+		FromAddress: fromAddress.Hex(),
+		ToAddress:   &toAddressStr,
+		Value:       value.String(),
+		Data:        &dataStr,
+		GasLimit:    &gasLimit,
+		GasPrice:    gasPrice,
+		GasTipCap:   gasTipCap,
+		GasFeeCap:   gasFeeCap,
+		Nonce:       nonce,
+		Status:      string(models.PendingStatus),
+		Type:        req.Type,
+		ExpiresAt:   time.Now().Add(24 * time.Hour),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save transaction: %w", err)
+	}
+
+	txHash, err := t.send(ctx, req.To, value, req.Data, gasLimit, txFees, nonce)
+	if err != nil {
+		// The node may have taken the nonce anyway, so sync it again next time.
+		t.nextNonce = nil
+		if statusErr := t.dbClient.BlockchainTransaction().SetStatus(ctx, blockchainTx.ID, models.BlockchainTransactionStatusChange{
+			Status: models.UnsentStatus,
+			Reason: fmt.Sprintf("failed to send: %v", err),
+		}); statusErr != nil {
+			log.Printf("Failed to mark transaction %s at nonce %d unsent, the monitor will send it again: %v", blockchainTx.ID, nonce, statusErr)
+		}
+		return nil, fmt.Errorf("failed to send transaction: %w", err)
+	}
+	next := nonce + 1
+	t.nextNonce = &next
+
+	txHashStr := txHash.Hex()
+	now := time.Now()
+	blockchainTx.TxHash = &txHashStr
+	blockchainTx.BroadcastAt = &now
+	if err := t.dbClient.BlockchainTransaction().SetBroadcast(ctx, blockchainTx.ID, txHashStr); err != nil {
+		// The transaction is out, so it's returned rather than sent again;
+		// only its hash is missing from the database.
+		log.Printf("SENT TRANSACTION NOT RECORDED: transaction %s was sent as %s at nonce %d from %s, but saving its hash failed: %v",
+			blockchainTx.ID, txHashStr, nonce, fromAddress.Hex(), err)
+	}
+
+	return blockchainTx, nil
+}
+
+// allocateNonce returns the next nonce for the account: the highest of the
+// one after its last recorded transaction, the node's pending nonce, and the
+// one after the last transaction sent from this process. mu must be held.
+func (t *transactor) allocateNonce(ctx context.Context, fromAddress common.Address) (uint64, error) {
+	dbNonce, err := t.dbClient.BlockchainTransaction().GetNextNonce(ctx, t.chainID, fromAddress.Hex())
+	if err != nil {
+		return 0, fmt.Errorf("failed to get nonce from database: %w", err)
+	}
+
+	rpcNonce, err := t.ethereumClient.GetPendingNonce(ctx, fromAddress)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get nonce from RPC: %w", err)
+	}
+
+	nonce := dbNonce
+	if rpcNonce > nonce {
+		nonce = rpcNonce
+	}
+	if t.nextNonce != nil && *t.nextNonce > nonce {
+		nonce = *t.nextNonce
+	}
+	return nonce, nil
+}
+
+// fees is what a transaction pays for gas: a legacy gas price, or EIP-1559
+// fees when gasPrice is nil.
+type fees struct {
+	gasPrice *big.Int
+	dynamic  *ethereum.Fees
+}
+
+func (f fees) strings() (gasPrice, gasTipCap, gasFeeCap *string) {
+	if f.gasPrice != nil {
+		price := f.gasPrice.String()
+		return &price, nil, nil
+	}
+	tip := f.dynamic.GasTipCap.String()
+	feeCap := f.dynamic.GasFeeCap.String()
+	return nil, &tip, &feeCap
+}
+
+// suggestFees suggests EIP-1559 fees, or a legacy gas price on chains that
+// don't support them.
+func (t *transactor) suggestFees(ctx context.Context) (fees, error) {
+	suggested, err := t.ethereumClient.SuggestFees(ctx)
+	if err == nil {
+		return fees{dynamic: suggested}, nil
+	}
+	if !errors.Is(err, ethereum.ErrDynamicFeesUnsupported) {
+		return fees{}, fmt.Errorf("failed to suggest fees: %w", err)
+	}
+
+	gasPrice, err := t.ethereumClient.SuggestGasPrice(ctx)
+	if err != nil {
+		return fees{}, fmt.Errorf("failed to suggest gas price: %w", err)
+	}
+	return fees{gasPrice: gasPrice}, nil
+}
+
+func (t *transactor) send(ctx context.Context, to *common.Address, value *big.Int, data []byte, gasLimit uint64, txFees fees, nonce uint64) (common.Hash, error) {
+	if txFees.gasPrice != nil {
+		return t.ethereumClient.SendTransaction(ctx, to, value, data, gasLimit, txFees.gasPrice, nonce)
+	}
+	return t.ethereumClient.SendDynamicFeeTransaction(ctx, to, value, data, gasLimit, txFees.dynamic, nonce)
+}
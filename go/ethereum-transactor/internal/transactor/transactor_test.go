@@ -0,0 +1,479 @@
+package transactor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/db"
+	"github.com/MaxBlaushild/poltergeist/pkg/ethereum"
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/google/uuid"
+)
+
+// The fakes embed the interfaces they stand in for, so calls the test does
+// not expect panic instead of silently succeeding.
+
+type fakeChain struct {
+	ethereum.EthereumClient
+
+	mu             sync.Mutex
+	address        common.Address
+	pendingNonce   uint64
+	confirmedNonce uint64
+	head           uint64
+	blockHashes    map[uint64]common.Hash
+	statuses       map[common.Hash]*ethereum.TransactionStatus
+	// sendErrs fail the next sends, in order.
+	sendErrs []error
+	// takesFailedNonces makes the node take a nonce even when sending
+	// returns an error, as after a timeout.
+	takesFailedNonces bool
+	sent              []sentAttempt
+}
+
+type sentAttempt struct {
+	hash  common.Hash
+	nonce uint64
+	fees  *ethereum.Fees
+}
+
+func newFakeChain() *fakeChain {
+	return &fakeChain{
+		address:     common.HexToAddress("0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266"),
+		blockHashes: map[uint64]common.Hash{},
+		statuses:    map[common.Hash]*ethereum.TransactionStatus{},
+	}
+}
+
+func (c *fakeChain) GetAddress() common.Address { return c.address }
+
+func (c *fakeChain) SuggestFees(context.Context) (*ethereum.Fees, error) {
+	return &ethereum.Fees{GasTipCap: big.NewInt(1_000_000_000), GasFeeCap: big.NewInt(30_000_000_000)}, nil
+}
+
+func (c *fakeChain) SendDynamicFeeTransaction(_ context.Context, _ *common.Address, _ *big.Int, _ []byte, _ uint64, fees *ethereum.Fees, nonce uint64) (common.Hash, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.sendErrs) > 0 {
+		err := c.sendErrs[0]
+		c.sendErrs = c.sendErrs[1:]
+		if c.takesFailedNonces && nonce >= c.pendingNonce {
+			c.pendingNonce = nonce + 1
+		}
+		return common.Hash{}, err
+	}
+	if nonce >= c.pendingNonce {
+		c.pendingNonce = nonce + 1
+	}
+	hash := common.HexToHash(fmt.Sprintf("0x%x", len(c.sent)+1))
+	c.sent = append(c.sent, sentAttempt{hash: hash, nonce: nonce, fees: fees})
+	return hash, nil
+}
+
+func (c *fakeChain) GetPendingNonce(context.Context, common.Address) (uint64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.pendingNonce, nil
+}
+
+func (c *fakeChain) GetConfirmedNonce(context.Context, common.Address) (uint64, error) {
+	return c.confirmedNonce, nil
+}
+
+func (c *fakeChain) GetBlockNumber(context.Context) (uint64, error) {
+	return c.head, nil
+}
+
+func (c *fakeChain) GetBlockHash(_ context.Context, blockNumber uint64) (common.Hash, error) {
+	return c.blockHashes[blockNumber], nil
+}
+
+func (c *fakeChain) GetTransactionStatus(_ context.Context, txHash common.Hash) (*ethereum.TransactionStatus, error) {
+	if status, ok := c.statuses[txHash]; ok {
+		return status, nil
+	}
+	return &ethereum.TransactionStatus{Status: "pending"}, nil
+}
+
+func (c *fakeChain) mine(txHash common.Hash, blockNumber uint64, blockHash common.Hash) {
+	c.blockHashes[blockNumber] = blockHash
+	c.statuses[txHash] = &ethereum.TransactionStatus{Status: string(models.ConfirmedStatus), BlockNumber: &blockNumber, BlockHash: &blockHash}
+}
+
+type fakeTxDB struct {
+	db.DbClient
+	txs *fakeTxStore
+}
+
+func (f *fakeTxDB) BlockchainTransaction() db.BlockchainTransactionHandle { return f.txs }
+
+type fakeTxStore struct {
+	db.BlockchainTransactionHandle
+
+	mu      sync.Mutex
+	rows    []*models.BlockchainTransaction
+	reasons map[uuid.UUID][]string
+}
+
+func newFakeTxStore(rows ...*models.BlockchainTransaction) *fakeTxStore {
+	return &fakeTxStore{rows: rows, reasons: map[uuid.UUID][]string{}}
+}
+
+func (s *fakeTxStore) find(id uuid.UUID) *models.BlockchainTransaction {
+	for _, row := range s.rows {
+		if row.ID == id {
+			return row
+		}
+	}
+	return nil
+}
+
+func (s *fakeTxStore) Create(_ context.Context, tx *models.BlockchainTransaction) (*models.BlockchainTransaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tx.ID = uuid.New()
+	tx.CreatedAt = time.Now()
+	stored := *tx
+	s.rows = append(s.rows, &stored)
+	return tx, nil
+}
+
+func (s *fakeTxStore) SetBroadcast(_ context.Context, id uuid.UUID, txHash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	row := s.find(id)
+	row.TxHash = &txHash
+	row.BroadcastAt = &now
+	return nil
+}
+
+func (s *fakeTxStore) SetStatus(_ context.Context, id uuid.UUID, change models.BlockchainTransactionStatusChange) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	row := s.find(id)
+	row.Status = string(change.Status)
+	if change.TxHash != nil {
+		row.TxHash = change.TxHash
+	}
+	if change.Status == models.PendingStatus {
+		row.BlockNumber, row.BlockHash = nil, nil
+	} else {
+		if change.BlockNumber != nil {
+			row.BlockNumber = change.BlockNumber
+		}
+		if change.BlockHash != nil {
+			row.BlockHash = change.BlockHash
+		}
+	}
+	s.reasons[id] = append(s.reasons[id], change.Reason)
+	return nil
+}
+
+func (s *fakeTxStore) Replace(_ context.Context, id uuid.UUID, replacement models.BlockchainTransactionReplacement) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	row := s.find(id)
+	if row.TxHash != nil {
+		row.ReplacedTxHashes = append(row.ReplacedTxHashes, *row.TxHash)
+	}
+	row.TxHash = &replacement.TxHash
+	row.GasPrice, row.GasTipCap, row.GasFeeCap = replacement.GasPrice, replacement.GasTipCap, replacement.GasFeeCap
+	row.BroadcastAt = &now
+	s.reasons[id] = append(s.reasons[id], replacement.Reason)
+	return nil
+}
+
+func (s *fakeTxStore) GetNextNonce(context.Context, int64, string) (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var next uint64
+	for _, row := range s.rows {
+		if row.Status != string(models.UnsentStatus) && row.Nonce+1 > next {
+			next = row.Nonce + 1
+		}
+	}
+	return next, nil
+}
+
+func (s *fakeTxStore) FindPendingByAccount(context.Context, int64, string) ([]models.BlockchainTransaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var pending []models.BlockchainTransaction
+	for _, row := range s.rows {
+		if row.Status == string(models.PendingStatus) {
+			pending = append(pending, *row)
+		}
+	}
+	return pending, nil
+}
+
+func (s *fakeTxStore) FindMinedSinceBlock(_ context.Context, _ int64, _ string, blockNumber uint64) ([]models.BlockchainTransaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var mined []models.BlockchainTransaction
+	for _, row := range s.rows {
+		if (row.Status == string(models.ConfirmedStatus) || row.Status == string(models.FailedStatus)) && row.BlockNumber != nil && *row.BlockNumber >= blockNumber {
+			mined = append(mined, *row)
+		}
+	}
+	return mined, nil
+}
+
+func newTestTransactor(chain *fakeChain, store *fakeTxStore) *transactor {
+	return New(&fakeTxDB{txs: store}, chain, 31337, Config{}).(*transactor)
+}
+
+// stuckTx is a pending transaction whose latest attempt was broadcast long
+// enough ago to be replaced.
+func stuckTx(nonce uint64, txHash string, replaced ...string) *models.BlockchainTransaction {
+	broadcastAt := time.Now().Add(-2 * DefaultReplaceAfter)
+	gasLimit := uint64(21000)
+	tip, feeCap := "1000000000", "30000000000"
+	return &models.BlockchainTransaction{
+		ID:               uuid.New(),
+		CreatedAt:        broadcastAt,
+		Value:            "0",
+		GasLimit:         &gasLimit,
+		GasTipCap:        &tip,
+		GasFeeCap:        &feeCap,
+		Nonce:            nonce,
+		TxHash:           &txHash,
+		Status:           string(models.PendingStatus),
+		BroadcastAt:      &broadcastAt,
+		ReplacedTxHashes: replaced,
+	}
+}
+
+func TestSendAllocatesDistinctNoncesConcurrently(t *testing.T) {
+	chain := newFakeChain()
+	store := newFakeTxStore()
+	txr := newTestTransactor(chain, store)
+
+	const sends = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, sends)
+	for i := 0; i < sends; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := txr.Send(context.Background(), Request{})
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("send: %v", err)
+		}
+	}
+
+	seen := map[uint64]bool{}
+	for _, row := range store.rows {
+		if seen[row.Nonce] {
+			t.Fatalf("nonce %d was used twice", row.Nonce)
+		}
+		seen[row.Nonce] = true
+		if row.TxHash == nil || row.BroadcastAt == nil {
+			t.Fatalf("expected the transaction at nonce %d to be recorded as sent", row.Nonce)
+		}
+	}
+	for nonce := uint64(0); nonce < sends; nonce++ {
+		if !seen[nonce] {
+			t.Fatalf("expected nonces 0 to %d without gaps, missing %d", sends-1, nonce)
+		}
+	}
+}
+
+func TestSendResyncsNonceAfterSendError(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		// takesFailedNonces is whether the node took the nonce despite the
+		// error.
+		takesFailedNonces bool
+		wantNonce         uint64
+	}{
+		{name: "node rejected the transaction", takesFailedNonces: false, wantNonce: 1},
+		{name: "node took the nonce anyway", takesFailedNonces: true, wantNonce: 2},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			chain := newFakeChain()
+			chain.takesFailedNonces = tc.takesFailedNonces
+			store := newFakeTxStore()
+			txr := newTestTransactor(chain, store)
+			ctx := context.Background()
+
+			if _, err := txr.Send(ctx, Request{}); err != nil {
+				t.Fatalf("send: %v", err)
+			}
+			chain.sendErrs = []error{errors.New("context deadline exceeded")}
+			if _, err := txr.Send(ctx, Request{}); err == nil {
+				t.Fatal("expected the failed send to return an error")
+			}
+			if failed := store.rows[1]; failed.Status != string(models.UnsentStatus) || failed.TxHash != nil {
+				t.Fatalf("expected the failed transaction to be saved as unsent, got %s", failed.Status)
+			}
+
+			tx, err := txr.Send(ctx, Request{})
+			if err != nil {
+				t.Fatalf("send: %v", err)
+			}
+			if tx.Nonce != tc.wantNonce {
+				t.Fatalf("expected nonce %d after resyncing, got %d", tc.wantNonce, tx.Nonce)
+			}
+		})
+	}
+}
+
+func TestMonitorDropsOnlyTransactionsWhoseNonceWasUsed(t *testing.T) {
+	for _, tc := range []struct {
+		name           string
+		confirmedNonce uint64
+		wantStatus     models.BlockchainTransactionStatus
+		wantReplaced   bool
+	}{
+		{name: "nonce used by another transaction", confirmedNonce: 4, wantStatus: models.DroppedStatus},
+		{name: "nonce still free", confirmedNonce: 3, wantStatus: models.PendingStatus, wantReplaced: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			chain := newFakeChain()
+			chain.confirmedNonce = tc.confirmedNonce
+			tx := stuckTx(3, common.HexToHash("0xaa").Hex())
+			store := newFakeTxStore(tx)
+
+			if err := newTestTransactor(chain, store).Monitor(context.Background()); err != nil {
+				t.Fatalf("monitor: %v", err)
+			}
+
+			if tx.Status != string(tc.wantStatus) {
+				t.Fatalf("expected %s, got %s", tc.wantStatus, tx.Status)
+			}
+			if replaced := len(chain.sent) > 0; replaced != tc.wantReplaced {
+				t.Fatalf("expected replaced %v, sent %+v", tc.wantReplaced, chain.sent)
+			}
+			if tc.wantReplaced {
+				if chain.sent[0].nonce != 3 || chain.sent[0].fees.GasTipCap.Cmp(big.NewInt(1_000_000_000)) <= 0 {
+					t.Fatalf("expected a replacement at nonce 3 with a higher tip, got %+v", chain.sent[0])
+				}
+				if len(tx.ReplacedTxHashes) != 1 {
+					t.Fatalf("expected the first attempt to be kept, got %v", tx.ReplacedTxHashes)
+				}
+			}
+		})
+	}
+}
+
+func TestMonitorSendsTransactionSavedButNeverSent(t *testing.T) {
+	chain := newFakeChain()
+	chain.confirmedNonce = 5
+	tx := stuckTx(5, "")
+	tx.TxHash, tx.BroadcastAt = nil, nil
+	store := newFakeTxStore(tx)
+
+	if err := newTestTransactor(chain, store).Monitor(context.Background()); err != nil {
+		t.Fatalf("monitor: %v", err)
+	}
+	if len(chain.sent) != 1 || chain.sent[0].nonce != 5 {
+		t.Fatalf("expected the saved transaction to be sent at nonce 5, got %+v", chain.sent)
+	}
+	if tx.TxHash == nil || *tx.TxHash != chain.sent[0].hash.Hex() || len(tx.ReplacedTxHashes) != 0 {
+		t.Fatalf("expected the sent hash to be recorded, got %v %v", tx.TxHash, tx.ReplacedTxHashes)
+	}
+}
+
+func TestMonitorFindsReplacedAttemptMinedInsteadOfReplacement(t *testing.T) {
+	first := common.HexToHash("0xa1")
+	second := common.HexToHash("0xa2")
+	latest := common.HexToHash("0xa3")
+
+	chain := newFakeChain()
+	chain.head = 60
+	chain.confirmedNonce = 8
+	chain.mine(second, 55, common.HexToHash("0xb55"))
+	tx := stuckTx(7, latest.Hex(), first.Hex(), second.Hex())
+	store := newFakeTxStore(tx)
+
+	if err := newTestTransactor(chain, store).Monitor(context.Background()); err != nil {
+		t.Fatalf("monitor: %v", err)
+	}
+	if tx.Status != string(models.ConfirmedStatus) {
+		t.Fatalf("expected the transaction to be confirmed, got %s", tx.Status)
+	}
+	if tx.TxHash == nil || *tx.TxHash != second.Hex() {
+		t.Fatalf("expected the mined attempt %s to be recorded, got %v", second.Hex(), tx.TxHash)
+	}
+	if tx.BlockNumber == nil || *tx.BlockNumber != 55 {
+		t.Fatalf("expected block 55, got %v", tx.BlockNumber)
+	}
+	if len(chain.sent) != 0 {
+		t.Fatalf("expected no replacement for a mined transaction, sent %+v", chain.sent)
+	}
+}
+
+func TestCheckReorgs(t *testing.T) {
+	minedIn := common.HexToHash("0xb100")
+	for _, tc := range []struct {
+		name       string
+		reorg      func(chain *fakeChain, txHash common.Hash)
+		wantStatus models.BlockchainTransactionStatus
+		wantBlock  *uint64
+	}{
+		{
+			name:       "block still canonical",
+			reorg:      func(*fakeChain, common.Hash) {},
+			wantStatus: models.ConfirmedStatus,
+			wantBlock:  func() *uint64 { n := uint64(100); return &n }(),
+		},
+		{
+			name: "reorged back to pending",
+			reorg: func(chain *fakeChain, txHash common.Hash) {
+				chain.blockHashes[100] = common.HexToHash("0xc100")
+				delete(chain.statuses, txHash)
+			},
+			wantStatus: models.PendingStatus,
+		},
+		{
+			name: "mined again in a later block",
+			reorg: func(chain *fakeChain, txHash common.Hash) {
+				chain.blockHashes[100] = common.HexToHash("0xc100")
+				chain.mine(txHash, 102, common.HexToHash("0xc102"))
+			},
+			wantStatus: models.ConfirmedStatus,
+			wantBlock:  func() *uint64 { n := uint64(102); return &n }(),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			txHash := common.HexToHash("0xa1")
+			chain := newFakeChain()
+			chain.head = 105
+			chain.confirmedNonce = 1
+			chain.mine(txHash, 100, minedIn)
+			tc.reorg(chain, txHash)
+
+			blockNumber := uint64(100)
+			blockHash := minedIn.Hex()
+			tx := stuckTx(0, txHash.Hex())
+			tx.Status = string(models.ConfirmedStatus)
+			tx.BlockNumber, tx.BlockHash = &blockNumber, &blockHash
+			store := newFakeTxStore(tx)
+
+			if err := newTestTransactor(chain, store).checkReorgs(context.Background(), chain.address); err != nil {
+				t.Fatalf("check reorgs: %v", err)
+			}
+			if tx.Status != string(tc.wantStatus) {
+				t.Fatalf("expected %s, got %s", tc.wantStatus, tx.Status)
+			}
+			if (tc.wantBlock == nil) != (tx.BlockNumber == nil) || (tc.wantBlock != nil && *tc.wantBlock != *tx.BlockNumber) {
+				t.Fatalf("expected block %v, got %v", tc.wantBlock, tx.BlockNumber)
+			}
+		})
+	}
+}
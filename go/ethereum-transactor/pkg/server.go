@@ -1,9 +1,11 @@
 package pkg
 
 import (
+	"github.com/MaxBlaushild/poltergeist/ethereum-transactor/internal/anchor"
+	"github.com/MaxBlaushild/poltergeist/ethereum-transactor/internal/server"
+	"github.com/MaxBlaushild/poltergeist/ethereum-transactor/internal/transactor"
 	"github.com/MaxBlaushild/poltergeist/pkg/db"
 	"github.com/MaxBlaushild/poltergeist/pkg/ethereum"
-	"github.com/MaxBlaushild/poltergeist/ethereum-transactor/internal/server"
 	"github.com/gin-gonic/gin"
 )
 
@@ -19,15 +21,18 @@ func NewServerFromDependencies(
 	ethereumClient ethereum.EthereumClient,
 	chainID int64,
 ) Server {
-	return server.NewServer(dbClient, ethereumClient, chainID)
+	return NewServer(dbClient, ethereumClient, chainID)
 }
 
-// NewServer creates a new ethereum-transactor server with all dependencies provided
+// NewServer creates a new ethereum-transactor server with all dependencies provided.
+// Servers built here queue manifests for anchoring but don't run the batcher
+// or monitor transactions; the standalone service does both.
 func NewServer(
 	dbClient db.DbClient,
 	ethereumClient ethereum.EthereumClient,
 	chainID int64,
 ) Server {
-	return server.NewServer(dbClient, ethereumClient, chainID)
+	txr := transactor.New(dbClient, ethereumClient, chainID, transactor.Config{})
+	batcher := anchor.NewBatcher(dbClient, txr, anchor.Config{})
+	return server.NewServer(dbClient, txr, batcher)
 }
-
@@ -13,6 +13,7 @@ import (
 
 	"github.com/MaxBlaushild/job-runner/internal/config"
 	"github.com/MaxBlaushild/job-runner/internal/processors"
+	"github.com/MaxBlaushild/poltergeist/pkg/auth"
 	"github.com/MaxBlaushild/poltergeist/pkg/aws"
 	"github.com/MaxBlaushild/poltergeist/pkg/db"
 	"github.com/MaxBlaushild/poltergeist/pkg/deep_priest"
@@ -21,6 +22,7 @@ import (
 	"github.com/MaxBlaushild/poltergeist/pkg/jobs"
 	"github.com/MaxBlaushild/poltergeist/pkg/locationseeder"
 
+	"github.com/google/uuid"
 	"github.com/hibiken/asynq"
 	"github.com/redis/go-redis/v9"
 )
@@ -68,6 +70,13 @@ func main() {
 	defer client.Close()
 	redisClient := newRedisClient(cfg.Public.RedisUrl)
 	defer redisClient.Close()
+	// Processors rewrite profile pictures; services caching users need to
+	// hear about it.
+	dbClient.SetUserChangeNotifier(func(ctx context.Context, userID uuid.UUID) {
+		if err := auth.PublishUserChanged(ctx, redisClient, userID); err != nil {
+			log.Printf("[auth][events] publish user changed failed user_id=%s err=%v", userID, err)
+		}
+	})
 
 	awsClient := aws.NewAWSClient("us-east-1")
 
@@ -83,6 +92,7 @@ func main() {
 	processRecurringQuestsProcessor := processors.NewProcessRecurringQuestsProcessor(dbClient)
 	processRecurringStandaloneContentProcessor := processors.NewProcessRecurringStandaloneContentProcessor(dbClient)
 	cleanupOrphanedQuestActionsProcessor := processors.NewCleanupOrphanedQuestActionsProcessor(dbClient)
+	expirePlayerTradesProcessor := processors.NewExpirePlayerTradesProcessor(dbClient)
 	createProfilePictureProcessor := processors.NewCreateProfilePictureProcessor(dbClient, deepPriestClient, awsClient)
 	generateOutfitProfilePictureProcessor := processors.NewGenerateOutfitProfilePictureProcessor(dbClient, deepPriestClient, awsClient)
 	generateInventoryItemImageProcessor := processors.NewGenerateInventoryItemImageProcessor(dbClient, deepPriestClient, awsClient)
@@ -98,7 +108,9 @@ func main() {
 	processMainStoryDistrictRunProcessor := processors.NewProcessMainStoryDistrictRunProcessor(dbClient, dungeonmasterClient)
 	generateCharacterImageProcessor := processors.NewGenerateCharacterImageProcessor(dbClient, deepPriestClient, awsClient, client)
 	generatePointOfInterestImageProcessor := processors.NewGeneratePointOfInterestImageProcessor(dbClient, locationSeederClient, client)
-	generateScenarioImageProcessor := processors.NewGenerateScenarioImageProcessor(dbClient, deepPriestClient, awsClient)
+	// No image classifier is configured; generated scenario images still get
+	// the duplicate check and human review.
+	generateScenarioImageProcessor := processors.NewGenerateScenarioImageProcessor(dbClient, deepPriestClient, awsClient, nil)
 	generateExpositionImageProcessor := processors.NewGenerateExpositionImageProcessor(dbClient, deepPriestClient, awsClient)
 	generateExpositionTemplateSpeakerPortraitsProcessor := processors.NewGenerateExpositionTemplateSpeakerPortraitsProcessor(dbClient, deepPriestClient, awsClient)
 	generateTutorialImageProcessor := processors.NewGenerateTutorialImageProcessor(dbClient, deepPriestClient, awsClient)
@@ -113,6 +125,8 @@ func main() {
 	generateScenarioTemplatesProcessor := processors.NewGenerateScenarioTemplatesProcessor(dbClient, deepPriestClient)
 	generateChallengeTemplatesProcessor := processors.NewGenerateChallengeTemplatesProcessor(dbClient, deepPriestClient)
 	generateShrineTemplatesProcessor := processors.NewGenerateShrineTemplatesProcessor(dbClient, deepPriestClient)
+	runPromptEvaluationProcessor := processors.NewRunPromptEvaluationProcessor(dbClient, deepPriestClient)
+	translateContentProcessor := processors.NewTranslateContentProcessor(dbClient, deepPriestClient)
 	generateLocationArchetypesProcessor := processors.NewGenerateLocationArchetypesProcessor(dbClient, deepPriestClient)
 	generateQuestArchetypeSuggestionsProcessor := processors.NewGenerateQuestArchetypeSuggestionsProcessor(dbClient, deepPriestClient)
 	generateMainStorySuggestionsProcessor := processors.NewGenerateMainStorySuggestionsProcessor(dbClient, deepPriestClient)
@@ -131,6 +145,7 @@ func main() {
 	applyZoneSeedDraftProcessor := processors.NewApplyZoneSeedDraftProcessor(dbClient, locationSeederClient, deepPriestClient, client)
 	shuffleZoneSeedChallengeProcessor := processors.NewShuffleZoneSeedChallengeProcessor(dbClient)
 	backfillContentZoneKindsProcessor := processors.NewBackfillContentZoneKindsProcessor(dbClient, redisClient, deepPriestClient)
+	backfillAchievementsProcessor := processors.NewBackfillAchievementsProcessor(dbClient)
 	generateReefFullProcessor := processors.NewGenerateReefFullProcessor(dbClient, awsClient, cfg.Public)
 	generateBgiSetProcessor := processors.NewGenerateBgiSetProcessor(dbClient, awsClient, cfg.Public)
 
@@ -180,6 +195,7 @@ func main() {
 	mux.Handle(jobs.ProcessRecurringQuestsTaskType, &processRecurringQuestsProcessor)
 	mux.Handle(jobs.ProcessRecurringStandaloneContentTaskType, &processRecurringStandaloneContentProcessor)
 	mux.Handle(jobs.CleanupOrphanedQuestActionsTaskType, &cleanupOrphanedQuestActionsProcessor)
+	mux.Handle(jobs.ExpirePlayerTradesTaskType, &expirePlayerTradesProcessor)
 	mux.Handle(jobs.CreateProfilePictureTaskType, &createProfilePictureProcessor)
 	mux.Handle(jobs.GenerateOutfitProfilePictureTaskType, &generateOutfitProfilePictureProcessor)
 	mux.Handle(jobs.GenerateInventoryItemImageTaskType, &generateInventoryItemImageProcessor)
@@ -211,6 +227,8 @@ func main() {
 	mux.Handle(jobs.GenerateScenarioTemplatesTaskType, &generateScenarioTemplatesProcessor)
 	mux.Handle(jobs.GenerateChallengeTemplatesTaskType, &generateChallengeTemplatesProcessor)
 	mux.Handle(jobs.GenerateShrineTemplatesTaskType, &generateShrineTemplatesProcessor)
+	mux.Handle(jobs.RunPromptEvaluationTaskType, &runPromptEvaluationProcessor)
+	mux.Handle(jobs.TranslateContentTaskType, &translateContentProcessor)
 	mux.Handle(jobs.GenerateLocationArchetypesTaskType, &generateLocationArchetypesProcessor)
 	mux.Handle(jobs.GenerateQuestArchetypeSuggestionsTaskType, &generateQuestArchetypeSuggestionsProcessor)
 	mux.Handle(jobs.GenerateMainStorySuggestionsTaskType, &generateMainStorySuggestionsProcessor)
@@ -233,6 +251,7 @@ func main() {
 	mux.Handle(jobs.ApplyZoneSeedDraftTaskType, &applyZoneSeedDraftProcessor)
 	mux.Handle(jobs.ShuffleZoneSeedChallengeTaskType, &shuffleZoneSeedChallengeProcessor)
 	mux.Handle(jobs.BackfillContentZoneKindsTaskType, &backfillContentZoneKindsProcessor)
+	mux.Handle(jobs.BackfillAchievementsTaskType, &backfillAchievementsProcessor)
 	mux.Handle(jobs.GenerateReefFullTaskType, generateReefFullProcessor)
 	mux.Handle(jobs.GenerateBgiSetTaskType, generateBgiSetProcessor)
 	mux.Handle(jobs.MonitorPolymarketTradesTaskType, asynq.HandlerFunc(func(ctx context.Context, t *asynq.Task) error {
@@ -285,6 +304,10 @@ func main() {
 		log.Fatalf("could not register the orphaned quest action cleanup schedule: %v", err)
 	}
 
+	if _, err = scheduler.Register("@every 15m", asynq.NewTask(jobs.ExpirePlayerTradesTaskType, nil)); err != nil {
+		log.Fatalf("could not register the player trade expiry schedule: %v", err)
+	}
+
 	if _, err = scheduler.Register("@weekly", asynq.NewTask(jobs.SeedTreasureChestsTaskType, nil)); err != nil {
 		log.Fatalf("could not register the schedule: %v", err)
 	}
@@ -0,0 +1,72 @@
+package processors
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/db"
+	"github.com/MaxBlaushild/poltergeist/pkg/jobs"
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+)
+
+const achievementBackfillBatchSize = 250
+
+type BackfillAchievementsProcessor struct {
+	dbClient db.DbClient
+}
+
+func NewBackfillAchievementsProcessor(dbClient db.DbClient) BackfillAchievementsProcessor {
+	log.Println("Initializing BackfillAchievementsProcessor")
+	return BackfillAchievementsProcessor{dbClient: dbClient}
+}
+
+// ProcessTask evaluates achievements for every existing user so definitions
+// added after the fact pick up history. Unlocks are marked as backfilled and
+// their rewards are left for the user to claim.
+func (p *BackfillAchievementsProcessor) ProcessTask(ctx context.Context, task *asynq.Task) error {
+	log.Printf("Processing backfill achievements task: %v", task.Type())
+
+	var payload jobs.BackfillAchievementsTaskPayload
+	if len(task.Payload()) > 0 {
+		if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+			return fmt.Errorf("failed to unmarshal payload: %w", err)
+		}
+	}
+
+	evaluated := 0
+	unlocked := 0
+	after := uuid.Nil
+	for {
+		userIDs, err := p.dbClient.Achievement().FindUserIDsAfter(ctx, after, achievementBackfillBatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to load users: %w", err)
+		}
+		if len(userIDs) == 0 {
+			break
+		}
+
+		for _, userID := range userIDs {
+			records, err := p.dbClient.Achievement().Evaluate(ctx, models.AchievementEvaluation{
+				UserID:        userID,
+				AchievementID: payload.AchievementID,
+				Backfill:      true,
+				Now:           time.Now(),
+			})
+			if err != nil {
+				log.Printf("Failed to backfill achievements for user %s: %v", userID, err)
+				continue
+			}
+			evaluated++
+			unlocked += len(records)
+		}
+		after = userIDs[len(userIDs)-1]
+	}
+
+	log.Printf("Backfilled achievements for %d users, %d unlocks", evaluated, unlocked)
+	return nil
+}
@@ -195,7 +195,7 @@ func (p *BackfillContentZoneKindsProcessor) backfillScenarioTemplateZoneKinds(
 		}
 		missingCount++
 		zoneKind := models.NormalizeZoneKind(
-			classifyScenarioTemplateZoneKind(ctx, template, zoneKinds, p.deepPriest),
+			classifyScenarioTemplateZoneKind(ctx, p.dbClient, template, zoneKinds, p.deepPriest),
 		)
 		if zoneKind == "" {
 			continue
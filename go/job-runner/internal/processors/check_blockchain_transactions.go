@@ -155,7 +155,7 @@ func (p *CheckBlockchainTransactionsProcessor) activateCertificateFromTransactio
 	}
 
 	// Activate the certificate
-	err = p.dbClient.UserCertificate().UpdateActive(ctx, cert.UserID, true)
+	err = p.dbClient.UserCertificate().Activate(ctx, cert.ID)
 	if err != nil {
 		return fmt.Errorf("failed to activate certificate: %w", err)
 	}
@@ -0,0 +1,236 @@
+package processors
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"log"
+	"strings"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/db"
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/google/uuid"
+)
+
+// ImageClassifier inspects a generated image for content a reviewer should
+// see before it reaches players. Implementations report problems as
+// findings; an error means the image could not be classified at all.
+type ImageClassifier interface {
+	ClassifyImage(ctx context.Context, img image.Image) (models.ContentModerationFindings, error)
+}
+
+// noopImageClassifier is used when no classifier is configured; review still
+// happens, the queue just carries no classifier findings.
+type noopImageClassifier struct{}
+
+func (noopImageClassifier) ClassifyImage(context.Context, image.Image) (models.ContentModerationFindings, error) {
+	return nil, nil
+}
+
+// submitForModeration runs the text checks and puts the content in the
+// review queue. Callers must not publish the content if this fails.
+func submitForModeration(
+	ctx context.Context,
+	dbClient db.DbClient,
+	contentType string,
+	contentID uuid.UUID,
+	summary string,
+	fields []models.ModerationTextField,
+	businessNames []string,
+) error {
+	terms, err := dbClient.ContentModeration().FindTerms(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load moderation terms: %w", err)
+	}
+	findings := models.ModerateText(fields, terms, businessNames)
+	if err := dbClient.ContentModeration().Submit(ctx, &models.ContentModerationItem{
+		ContentType: contentType,
+		ContentID:   contentID,
+		Summary:     truncateModerationSummary(summary),
+		Findings:    findings,
+	}); err != nil {
+		return fmt.Errorf("failed to queue %s %s for moderation: %w", contentType, contentID, err)
+	}
+	return nil
+}
+
+// moderateGeneratedImage hashes a generated image, flags near-duplicates of
+// earlier images of the same content type and runs the classifier.
+// Problems are attached to the content's queue entry as findings; content
+// that was never queued is left alone.
+func moderateGeneratedImage(
+	ctx context.Context,
+	dbClient db.DbClient,
+	classifier ImageClassifier,
+	contentType string,
+	contentID uuid.UUID,
+	imageURL string,
+	imageBytes []byte,
+) error {
+	queued, err := dbClient.ContentModeration().FindByContent(ctx, contentType, contentID)
+	if err != nil {
+		return err
+	}
+	if queued == nil {
+		return nil
+	}
+	img, _, err := image.Decode(bytes.NewReader(imageBytes))
+	if err != nil {
+		return fmt.Errorf("failed to decode generated image: %w", err)
+	}
+	hash := models.DifferenceHash(img)
+
+	findings := models.ContentModerationFindings{}
+	existing, err := dbClient.ContentModeration().FindImageHashes(ctx, contentType, contentID)
+	if err != nil {
+		return fmt.Errorf("failed to load image hashes: %w", err)
+	}
+	for _, item := range existing {
+		if item.ImageHash == nil {
+			continue
+		}
+		if distance := models.ImageHashDistance(hash, *item.ImageHash); distance <= models.DuplicateImageHashDistance {
+			findings = append(findings, models.ContentModerationFinding{
+				Rule:     models.ContentModerationRuleDuplicateImage,
+				Severity: models.ContentModerationSeverityBlock,
+				Field:    "image",
+				Detail:   fmt.Sprintf("matches the image of %s %s (distance %d)", item.ContentType, item.ContentID, distance),
+			})
+		}
+	}
+
+	if classifier != nil {
+		classified, err := classifier.ClassifyImage(ctx, img)
+		if err != nil {
+			findings = append(findings, models.ContentModerationFinding{
+				Rule:     models.ContentModerationRuleImageClassifier,
+				Severity: models.ContentModerationSeverityWarn,
+				Field:    "image",
+				Detail:   fmt.Sprintf("classifier unavailable: %v", err),
+			})
+		} else {
+			for _, finding := range classified {
+				if finding.Rule == "" {
+					finding.Rule = models.ContentModerationRuleImageClassifier
+				}
+				if finding.Field == "" {
+					finding.Field = "image"
+				}
+				findings = append(findings, finding)
+			}
+		}
+	}
+
+	return dbClient.ContentModeration().RecordImage(ctx, contentType, contentID, imageURL, hash, findings)
+}
+
+func truncateModerationSummary(summary string) string {
+	summary = strings.Join(strings.Fields(summary), " ")
+	runes := []rune(summary)
+	if len(runes) <= 240 {
+		return summary
+	}
+	return strings.TrimSpace(string(runes[:240])) + "..."
+}
+
+func scenarioModerationFields(scenario *models.Scenario, options []models.ScenarioOption) []models.ModerationTextField {
+	fields := []models.ModerationTextField{
+		{Name: "prompt", Text: scenario.Prompt, Required: true, MaxLength: 900},
+	}
+	for index, option := range options {
+		fields = append(fields,
+			models.ModerationTextField{Name: fmt.Sprintf("options[%d].optionText", index), Text: option.OptionText, Required: true, MaxLength: 200},
+			models.ModerationTextField{Name: fmt.Sprintf("options[%d].successText", index), Text: option.SuccessText, MaxLength: 500},
+			models.ModerationTextField{Name: fmt.Sprintf("options[%d].failureText", index), Text: option.FailureText, MaxLength: 500},
+		)
+	}
+	return fields
+}
+
+func inventoryItemSuggestionModerationFields(draft *models.InventoryItemSuggestionDraft) []models.ModerationTextField {
+	return []models.ModerationTextField{
+		{Name: "name", Text: draft.Name, Required: true, MaxLength: 60},
+		{Name: "flavorText", Text: draft.Payload.Item.FlavorText, MaxLength: 400},
+		{Name: "effectText", Text: draft.Payload.Item.EffectText, MaxLength: 400},
+		{Name: "whyItFits", Text: draft.WhyItFits, MaxLength: 600},
+	}
+}
+
+func zoneSeedDraftModerationFields(draft models.ZoneSeedDraft) []models.ModerationTextField {
+	fields := []models.ModerationTextField{
+		{Name: "fantasyName", Text: draft.FantasyName, MaxLength: 80},
+		{Name: "zoneDescription", Text: draft.ZoneDescription, MaxLength: 1200},
+	}
+	for index, character := range draft.Characters {
+		prefix := fmt.Sprintf("characters[%d]", index)
+		fields = append(fields,
+			models.ModerationTextField{Name: prefix + ".name", Text: character.Name, Required: true, MaxLength: 60},
+			models.ModerationTextField{Name: prefix + ".description", Text: character.Description, MaxLength: 600},
+		)
+		for lineIndex, line := range character.Dialogue {
+			fields = append(fields, models.ModerationTextField{Name: fmt.Sprintf("%s.dialogue[%d]", prefix, lineIndex), Text: line, MaxLength: 300})
+		}
+	}
+	for index, exposition := range draft.Expositions {
+		prefix := fmt.Sprintf("expositions[%d]", index)
+		fields = append(fields,
+			models.ModerationTextField{Name: prefix + ".title", Text: exposition.Title, Required: true, MaxLength: 80},
+			models.ModerationTextField{Name: prefix + ".description", Text: exposition.Description, MaxLength: 800},
+		)
+		for lineIndex, line := range exposition.Dialogue {
+			fields = append(fields, models.ModerationTextField{Name: fmt.Sprintf("%s.dialogue[%d]", prefix, lineIndex), Text: line.Text, MaxLength: 300})
+		}
+	}
+	for index, quest := range draft.Quests {
+		prefix := fmt.Sprintf("quests[%d]", index)
+		fields = append(fields,
+			models.ModerationTextField{Name: prefix + ".name", Text: quest.Name, Required: true, MaxLength: 80},
+			models.ModerationTextField{Name: prefix + ".description", Text: quest.Description, MaxLength: 800},
+			models.ModerationTextField{Name: prefix + ".challengeQuestion", Text: quest.ChallengeQuestion, MaxLength: 300},
+		)
+		if quest.RewardItem != nil {
+			fields = append(fields, models.ModerationTextField{Name: prefix + ".rewardItem.name", Text: quest.RewardItem.Name, MaxLength: 60})
+		}
+	}
+	for index, quest := range draft.MainQuests {
+		prefix := fmt.Sprintf("mainQuests[%d]", index)
+		fields = append(fields,
+			models.ModerationTextField{Name: prefix + ".name", Text: quest.Name, Required: true, MaxLength: 80},
+			models.ModerationTextField{Name: prefix + ".description", Text: quest.Description, MaxLength: 800},
+		)
+		for nodeIndex, node := range quest.Nodes {
+			fields = append(fields, models.ModerationTextField{Name: fmt.Sprintf("%s.nodes[%d].story", prefix, nodeIndex), Text: node.Story, MaxLength: 800})
+		}
+	}
+	return fields
+}
+
+// zoneSeedDraftBusinessNames are the real names of the places the draft was
+// seeded from; the fiction written around them must use fantasy names.
+func zoneSeedDraftBusinessNames(draft models.ZoneSeedDraft) []string {
+	names := make([]string, 0, len(draft.PointsOfInterest))
+	for _, poi := range draft.PointsOfInterest {
+		if name := strings.TrimSpace(poi.Name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func zoneBusinessNames(ctx context.Context, dbClient db.DbClient, zoneID uuid.UUID) []string {
+	pointsOfInterest, err := dbClient.PointOfInterest().FindAllForZone(ctx, zoneID)
+	if err != nil {
+		log.Printf("[moderation][business-names] failed to load points of interest zone_id=%s err=%v", zoneID, err)
+		return nil
+	}
+	names := make([]string, 0, len(pointsOfInterest))
+	for _, poi := range pointsOfInterest {
+		if name := strings.TrimSpace(poi.OriginalName); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
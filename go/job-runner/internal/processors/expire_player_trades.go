@@ -0,0 +1,43 @@
+package processors
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/db"
+	"github.com/hibiken/asynq"
+)
+
+const expiredMarketListingBatchSize = 200
+
+type ExpirePlayerTradesProcessor struct {
+	dbClient db.DbClient
+}
+
+func NewExpirePlayerTradesProcessor(dbClient db.DbClient) ExpirePlayerTradesProcessor {
+	log.Println("Initializing ExpirePlayerTradesProcessor")
+	return ExpirePlayerTradesProcessor{dbClient: dbClient}
+}
+
+// ProcessTask closes pending trade offers and marketplace listings that have
+// run past their expiry. Expired listings hand their escrowed items back to
+// the seller.
+func (p *ExpirePlayerTradesProcessor) ProcessTask(ctx context.Context, task *asynq.Task) error {
+	log.Printf("Processing expire player trades task: %v", task.Type())
+
+	now := time.Now()
+	expiredOffers, err := p.dbClient.TradeOffer().ExpireStale(ctx, now)
+	if err != nil {
+		return fmt.Errorf("failed to expire trade offers: %w", err)
+	}
+
+	expiredListings, err := p.dbClient.MarketListing().ExpireStale(ctx, now, expiredMarketListingBatchSize)
+	if err != nil {
+		return fmt.Errorf("failed to expire market listings: %w", err)
+	}
+
+	log.Printf("Expired %d trade offers and %d market listings", expiredOffers, expiredListings)
+	return nil
+}
@@ -16,46 +16,6 @@ import (
 	"github.com/hibiken/asynq"
 )
 
-const challengeTemplateGenerationPromptTemplate = `
-You are designing %d reusable fantasy MMORPG challenge templates for a location archetype.
-
-Location archetype:
-- name: %s
-- included place types: %s
-- excluded place types: %s
-- existing built-in archetype challenge examples: %s
-
-Recent challenge templates for this archetype to avoid echoing:
-%s
-
-Return JSON only:
-{
-  "challenges": [
-    {
-      "question": "One short sentence (6-18 words) stating exactly what the player must do",
-      "description": "40-140 words of scene flavor and context that supports the action",
-      "submissionType": "photo or text",
-      "difficulty": 0-40,
-      "reward": 0-100,
-      "statTags": ["0-3 of: strength,dexterity,constitution,intelligence,wisdom,charisma"],
-      "proficiency": "optional short phrase or null"
-    }
-  ]
-}
-
-Hard rules:
-- Output exactly %d challenges.
-- These are reusable templates, not tied to any specific business name, coordinates, or one-off landmark.
-- Each challenge should clearly fit the archetype and feel fun to do in a public-space fantasy MMO tone.
-- Every challenge must be a concrete real-world task the player can actually complete on site right now.
-- The result must be gradable from the player's photo or text submission alone.
-- Never rely on fictional missing items, hidden clues, asking strangers, interviewing locals, NPC cooperation, or facts that may not exist at the real place.
-- If the idea is really about how the player would solve a problem, investigate, negotiate, persuade, or respond to a roleplaying situation, that belongs in a scenario template, not a challenge template.
-- Write challenge questions as direct action prompts, not mystery questions.
-- Keep each challenge materially distinct from the others and from the recent templates list.
-- Use only submissionType values: "photo" or "text".
-`
-
 type GenerateChallengeTemplatesProcessor struct {
 	dbClient         db.DbClient
 	deepPriestClient deep_priest.DeepPriest
@@ -116,17 +76,19 @@ func (p *GenerateChallengeTemplatesProcessor) generateChallengeTemplates(ctx con
 		return fmt.Errorf("failed to load challenge template zone kind: %w", err)
 	}
 
-	prompt := fmt.Sprintf(
-		challengeTemplateGenerationPromptTemplate,
-		job.Count,
-		strings.TrimSpace(locationArchetype.Name),
-		joinPlaceTypes(placeTypesToStrings(locationArchetype.IncludedTypes)),
-		joinPlaceTypes(placeTypesToStrings(locationArchetype.ExcludedTypes)),
-		joinLocationArchetypeChallengeExamples(locationArchetype.Challenges),
-		p.buildRecentChallengeTemplateAvoidance(ctx, job.LocationArchetypeID, 12),
-		job.Count,
-	)
-	if zoneKindBlock := zoneKindInstructionBlock(zoneKind); zoneKindBlock != "" {
+	resolved, err := db.ResolvePrompt(ctx, p.dbClient, models.PromptKeyChallengeTemplateGeneration, nil, job.ZoneKind, map[string]interface{}{
+		"Count":                 job.Count,
+		"LocationArchetypeName": strings.TrimSpace(locationArchetype.Name),
+		"IncludedPlaceTypes":    joinPlaceTypes(placeTypesToStrings(locationArchetype.IncludedTypes)),
+		"ExcludedPlaceTypes":    joinPlaceTypes(placeTypesToStrings(locationArchetype.ExcludedTypes)),
+		"ChallengeExamples":     joinLocationArchetypeChallengeExamples(locationArchetype.Challenges),
+		"RecentTemplates":       p.buildRecentChallengeTemplateAvoidance(ctx, job.LocationArchetypeID, 12),
+	})
+	if err != nil {
+		return err
+	}
+	prompt := resolved.Text
+	if zoneKindBlock := zoneKindInstructionBlock(ctx, p.dbClient, zoneKind); zoneKindBlock != "" {
 		prompt = strings.TrimSpace(zoneKindBlock + "\n\n" + prompt)
 	}
 	answer, err := p.deepPriestClient.PetitionTheFount(&deep_priest.Question{Question: prompt})
@@ -163,6 +125,7 @@ func (p *GenerateChallengeTemplatesProcessor) generateChallengeTemplates(ctx con
 			job.CreatedCount = createdCount
 			return fmt.Errorf("failed to create challenge template: %w", err)
 		}
+		db.RecordGeneratedPrompt(ctx, p.dbClient, resolved, models.GeneratedRecordTypeChallengeTemplate, template.ID)
 		createdCount++
 	}
 
@@ -15,41 +15,6 @@ import (
 	"github.com/hibiken/asynq"
 )
 
-const expositionTemplateGenerationPromptTemplate = `
-You are designing %d reusable fantasy MMORPG exposition templates for map encounters.
-
-Recent exposition templates to avoid echoing:
-%s
-
-Return JSON only:
-{
-  "templates": [
-    {
-      "title": "2-5 word evocative title",
-      "description": "2-4 vivid sentences of setup and atmosphere",
-      "dialogue": [
-        "3-6 short lines of in-world dialogue"
-      ],
-      "rewardMode": "random or explicit",
-      "randomRewardSize": "small or medium or large",
-      "rewardExperience": 0-70,
-      "rewardGold": 0-70
-    }
-  ]
-}
-
-Hard rules:
-- Output exactly %d templates.
-- These are reusable templates, not tied to a specific business, landmark, district, or coordinates.
-- Each exposition should feel like a brief discoverable encounter, omen, witness account, magical residue, local warning, or atmospheric vignette.
-- Let the requested zone kind strongly influence imagery, props, hazards, folklore, traversal, and mood.
-- Dialogue should work without a specific named NPC. It can sound like a traveler, spirit, ranger, sentry, survivor, scavenger, or ambient supernatural voice.
-- Keep dialogue lines short, punchy, and easy to present one after another.
-- Keep each template materially distinct from the others and from the recent templates list.
-- If rewardMode is "random", set rewardExperience and rewardGold to 0.
-- If rewardMode is "explicit", keep rewardExperience and rewardGold modest but meaningful.
-`
-
 type generatedExpositionTemplateSpec struct {
 	Title            string   `json:"title"`
 	Description      string   `json:"description"`
@@ -123,13 +88,15 @@ func (p *GenerateExpositionTemplatesProcessor) generateExpositionTemplates(
 		return fmt.Errorf("exposition template generation requires a zone kind")
 	}
 
-	prompt := fmt.Sprintf(
-		expositionTemplateGenerationPromptTemplate,
-		job.Count,
-		p.buildRecentExpositionTemplateAvoidance(ctx, zoneKind.Slug, 12),
-		job.Count,
-	)
-	if zoneKindBlock := zoneKindInstructionBlock(zoneKind); zoneKindBlock != "" {
+	resolved, err := db.ResolvePrompt(ctx, p.dbClient, models.PromptKeyExpositionTemplateGeneration, nil, zoneKind.Slug, map[string]interface{}{
+		"Count":           job.Count,
+		"RecentTemplates": p.buildRecentExpositionTemplateAvoidance(ctx, zoneKind.Slug, 12),
+	})
+	if err != nil {
+		return err
+	}
+	prompt := resolved.Text
+	if zoneKindBlock := zoneKindInstructionBlock(ctx, p.dbClient, zoneKind); zoneKindBlock != "" {
 		prompt = strings.TrimSpace(zoneKindBlock + "\n\n" + prompt)
 	}
 
@@ -150,6 +117,7 @@ func (p *GenerateExpositionTemplatesProcessor) generateExpositionTemplates(
 			job.CreatedCount = createdCount
 			return fmt.Errorf("failed to create exposition template: %w", err)
 		}
+		db.RecordGeneratedPrompt(ctx, p.dbClient, resolved, models.GeneratedRecordTypeExpositionTemplate, template.ID)
 		createdCount++
 	}
 
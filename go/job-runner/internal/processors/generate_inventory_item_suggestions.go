@@ -16,127 +16,6 @@ import (
 	"github.com/hibiken/asynq"
 )
 
-const inventoryItemSuggestionPromptTemplate = `
-You are designing draft inventory items for StreetSekai, an urban fantasy MMORPG.
-%s
-%s
-
-Generate exactly %d item drafts.
-
-Requested direction:
-- theme prompt: %s
-- categories to bias toward: %s
-- rarity tiers to bias toward: %s
-- equip slots to bias toward: %s
-- stat tags to support when relevant: %s
-- benefit tags to support when relevant: %s
-- statuses to apply when relevant: %s
-- internal tags to bias toward: %s
-- item level band: %d to %d
-
-Existing inventory items to avoid echoing:
-%s
-
-Return JSON only:
-{
-  "drafts": [
-    {
-      "category": "equippable|consumable|material|utility",
-      "whyItFits": "1-2 short sentences",
-      "warnings": ["optional warning"],
-      "item": {
-        "name": "string",
-        "flavorText": "1-3 sentences of evocative item description",
-        "effectText": "one short sentence describing the gameplay effect",
-        "zoneKind": "one allowed zone kind slug",
-        "rarityTier": "Common|Uncommon|Epic|Mythic|Not Droppable",
-        "itemLevel": 1,
-        "buyPrice": 10,
-        "unlockTier": 1,
-        "equipSlot": "hat|necklace|chest|legs|shoes|gloves|ring|dominant_hand|off_hand or empty",
-        "strengthMod": 0,
-        "dexterityMod": 0,
-        "constitutionMod": 0,
-        "intelligenceMod": 0,
-        "wisdomMod": 0,
-        "charismaMod": 0,
-        "physicalDamageBonusPercent": 0,
-        "piercingDamageBonusPercent": 0,
-        "slashingDamageBonusPercent": 0,
-        "bludgeoningDamageBonusPercent": 0,
-        "fireDamageBonusPercent": 0,
-        "iceDamageBonusPercent": 0,
-        "lightningDamageBonusPercent": 0,
-        "poisonDamageBonusPercent": 0,
-        "arcaneDamageBonusPercent": 0,
-        "holyDamageBonusPercent": 0,
-        "shadowDamageBonusPercent": 0,
-        "physicalResistancePercent": 0,
-        "piercingResistancePercent": 0,
-        "slashingResistancePercent": 0,
-        "bludgeoningResistancePercent": 0,
-        "fireResistancePercent": 0,
-        "iceResistancePercent": 0,
-        "lightningResistancePercent": 0,
-        "poisonResistancePercent": 0,
-        "arcaneResistancePercent": 0,
-        "holyResistancePercent": 0,
-        "shadowResistancePercent": 0,
-        "handItemCategory": "weapon|shield|orb|staff or empty",
-        "handedness": "one_handed|two_handed or empty",
-        "damageMin": 0,
-        "damageMax": 0,
-        "damageAffinity": "physical|piercing|slashing|bludgeoning|fire|ice|lightning|poison|arcane|holy|shadow or empty",
-        "swipesPerAttack": 0,
-        "blockPercentage": 0,
-        "damageBlocked": 0,
-        "spellDamageBonusPercent": 0,
-        "consumeHealthDelta": 0,
-        "consumeManaDelta": 0,
-        "consumeRevivePartyMemberHealth": 0,
-        "consumeReviveAllDownedPartyMembersHealth": 0,
-        "consumeDealDamage": 0,
-        "consumeDealDamageHits": 0,
-        "consumeDealDamageAllEnemies": 0,
-        "consumeDealDamageAllEnemiesHits": 0,
-        "consumeCreateBase": false,
-        "consumeStatusesToAdd": [],
-        "consumeStatusesToRemove": [],
-        "consumeSpellIds": [],
-        "consumeTeachRecipeIds": [],
-        "alchemyRecipes": [],
-        "workshopRecipes": [],
-        "internalTags": ["snake_case_tag"]
-      }
-    }
-  ]
-}
-
-Rules:
-- Output exactly %d drafts.
-- Output JSON only. No markdown.
-- Keep the tone urban fantasy, tactile, and gameable.
-- Drafts should feel materially distinct from one another.
-- Each draft item must include zoneKind.
-- Bias toward items that support recognizable builds, professions, exploration, or social play.
-- If stat tags are requested, strongly prefer items whose actual gameplay bonuses clearly support those stats.
-- If benefit tags are requested, strongly prefer items whose gameplay effects clearly match those requested benefits.
-- If status names are requested, strongly prefer consumables or utility items that actually apply those statuses.
-- Do not invent recipe links, taught recipe IDs, or spell IDs in this generator version. Leave consumeSpellIds, consumeTeachRecipeIds, alchemyRecipes, and workshopRecipes empty.
-- Do not set unlockLocksStrength in generated drafts. Leave lock-unlocking behavior empty unless it is added manually later.
-- Equippable drafts should have coherent stats for their slot and fantasy.
-- Consumables should create clear, concrete gameplay effects.
-- Materials should still feel desirable and specific, not generic vendor trash.
-- Utility items should unlock a play pattern, traversal trick, social angle, or base-related use.
-- If equipSlot is dominant_hand or off_hand, use only valid hand item combinations:
-  - dominant_hand: weapon or staff
-  - off_hand: shield or orb
-  - staff must be two_handed
-  - off_hand items must be one_handed
-- Keep effectText to one line.
-- Use lowercase snake_case for internalTags.
-`
-
 type inventoryItemSuggestionResponse struct {
 	Drafts []inventoryItemSuggestionDraftPayload `json:"drafts"`
 }
@@ -222,26 +101,27 @@ func (p *GenerateInventoryItemSuggestionsProcessor) generateDraftBatchDrafts(
 		return fmt.Errorf("failed to resolve preferred zone kind: %w", err)
 	}
 
-	prompt := fmt.Sprintf(
-		inventoryItemSuggestionPromptTemplate,
-		inventoryItemSuggestionGenreInstructionBlock(job.Genre),
-		buildInventoryItemZoneKindInstructionBlock(zoneKinds, preferredZoneKind),
-		maxInt(1, job.Count),
-		quotedOrNone(strings.TrimSpace(job.ThemePrompt)),
-		renderTagList(job.Categories),
-		renderTagList(job.RarityTiers),
-		renderTagList(job.EquipSlots),
-		renderTagList(job.StatTags),
-		renderTagList(job.BenefitTags),
-		renderTagList(job.StatusNames),
-		renderTagList(job.InternalTags),
-		maxInt(1, job.MinItemLevel),
-		maxInt(maxInt(1, job.MinItemLevel), job.MaxItemLevel),
-		buildInventoryItemSuggestionAvoidance(existingItems, 80),
-		maxInt(1, job.Count),
-	)
-
-	answer, err := p.deepPriestClient.PetitionTheFount(&deep_priest.Question{Question: prompt})
+	resolved, err := db.ResolvePrompt(ctx, p.dbClient, models.PromptKeyInventoryItemSuggestion, &job.GenreID, job.ZoneKind, map[string]interface{}{
+		"GenreDirection":    inventoryItemSuggestionGenreInstructionBlock(job.Genre),
+		"ZoneKindDirection": buildInventoryItemZoneKindInstructionBlock(zoneKinds, preferredZoneKind),
+		"Count":             maxInt(1, job.Count),
+		"ThemePrompt":       quotedOrNone(strings.TrimSpace(job.ThemePrompt)),
+		"Categories":        renderTagList(job.Categories),
+		"RarityTiers":       renderTagList(job.RarityTiers),
+		"EquipSlots":        renderTagList(job.EquipSlots),
+		"StatTags":          renderTagList(job.StatTags),
+		"BenefitTags":       renderTagList(job.BenefitTags),
+		"StatusNames":       renderTagList(job.StatusNames),
+		"InternalTags":      renderTagList(job.InternalTags),
+		"MinItemLevel":      maxInt(1, job.MinItemLevel),
+		"MaxItemLevel":      maxInt(maxInt(1, job.MinItemLevel), job.MaxItemLevel),
+		"ExistingItems":     buildInventoryItemSuggestionAvoidance(existingItems, 80),
+	})
+	if err != nil {
+		return err
+	}
+
+	answer, err := p.deepPriestClient.PetitionTheFount(&deep_priest.Question{Question: resolved.Text})
 	if err != nil {
 		return fmt.Errorf("failed to generate inventory item suggestions: %w", err)
 	}
@@ -271,6 +151,22 @@ func (p *GenerateInventoryItemSuggestionsProcessor) generateDraftBatchDrafts(
 			job.CreatedCount = createdCount
 			return fmt.Errorf("failed to create inventory item suggestion draft: %w", err)
 		}
+		if err := submitForModeration(
+			ctx,
+			p.dbClient,
+			models.ModeratedContentTypeInventoryItemSuggestionDraft,
+			draft.ID,
+			draft.Name,
+			inventoryItemSuggestionModerationFields(draft),
+			nil,
+		); err != nil {
+			if deleteErr := p.dbClient.InventoryItemSuggestionDraft().Delete(ctx, draft.ID); deleteErr != nil {
+				log.Printf("[moderation][inventory-suggestion] failed to remove unqueued draft id=%s err=%v", draft.ID, deleteErr)
+			}
+			job.CreatedCount = createdCount
+			return err
+		}
+		db.RecordGeneratedPrompt(ctx, p.dbClient, resolved, models.GeneratedRecordTypeInventoryItemDraft, draft.ID)
 		createdCount++
 	}
 
@@ -90,13 +90,16 @@ func (p *GenerateMonsterImageProcessor) ProcessTask(ctx context.Context, task *a
 		genre = monster.Template.Genre
 	}
 	prompt := fmt.Sprintf(monsterImagePromptTemplate, monsterName, description, zoneName)
+	var genrePrompt db.ResolvedPrompt
 	if !isBaselineFantasyMonsterGenre(genre) {
+		var directive string
+		directive, genrePrompt = monsterGenreVisualDirective(ctx, p.dbClient, genre)
 		prompt = fmt.Sprintf(
 			monsterImagePromptWithGenreTemplate,
 			monsterName,
 			description,
 			zoneName,
-			monsterGenreVisualDirective(genre),
+			directive,
 		)
 	}
 	request := deep_priest.GenerateImageRequest{Prompt: prompt}
@@ -128,6 +131,7 @@ func (p *GenerateMonsterImageProcessor) ProcessTask(ctx context.Context, task *a
 		log.Printf("Failed to update monster image URLs: %v", err)
 		return fmt.Errorf("failed to update monster image urls: %w", err)
 	}
+	db.RecordGeneratedPrompt(ctx, p.dbClient, genrePrompt, models.GeneratedRecordTypeMonster, payload.MonsterID)
 
 	log.Printf("Monster image generated successfully for ID: %s", payload.MonsterID)
 	return nil
@@ -84,7 +84,10 @@ func (p *GenerateMonsterTemplateImageProcessor) ProcessTask(ctx context.Context,
 		clampMinInt(template.BaseWisdom, 1),
 		clampMinInt(template.BaseCharisma, 1),
 	)
+	var genrePrompt db.ResolvedPrompt
 	if !isBaselineFantasyMonsterGenre(template.Genre) {
+		var directive string
+		directive, genrePrompt = monsterGenreVisualDirective(ctx, p.dbClient, template.Genre)
 		prompt = fmt.Sprintf(
 			monsterTemplateImagePromptWithGenreTemplate,
 			monsterTemplateImagePromptLabel(template.MonsterType),
@@ -96,7 +99,7 @@ func (p *GenerateMonsterTemplateImageProcessor) ProcessTask(ctx context.Context,
 			clampMinInt(template.BaseIntelligence, 1),
 			clampMinInt(template.BaseWisdom, 1),
 			clampMinInt(template.BaseCharisma, 1),
-			monsterGenreVisualDirective(template.Genre),
+			directive,
 		)
 	}
 
@@ -129,6 +132,7 @@ func (p *GenerateMonsterTemplateImageProcessor) ProcessTask(ctx context.Context,
 		log.Printf("Failed to update monster template image URLs: %v", err)
 		return fmt.Errorf("failed to update monster template image urls: %w", err)
 	}
+	db.RecordGeneratedPrompt(ctx, p.dbClient, genrePrompt, models.GeneratedRecordTypeMonsterTemplate, payload.MonsterTemplateID)
 
 	log.Printf("Monster template image generated successfully for ID: %s", payload.MonsterTemplateID)
 	return nil
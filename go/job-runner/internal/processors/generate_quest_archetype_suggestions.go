@@ -326,7 +326,7 @@ func (p *GenerateQuestArchetypeSuggestionsProcessor) generateDrafts(
 		buildAllowedMonsterTemplatesPrompt(promptMonsterTemplates),
 		candidateCount,
 	)
-	if zoneKindBlock := zoneKindInstructionBlock(zoneKind); zoneKindBlock != "" {
+	if zoneKindBlock := zoneKindInstructionBlock(ctx, p.dbClient, zoneKind); zoneKindBlock != "" {
 		prompt = strings.TrimSpace(zoneKindBlock + "\n\n" + prompt)
 	}
 
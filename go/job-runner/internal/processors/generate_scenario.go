@@ -31,89 +31,6 @@ var scenarioGenerationValidStatTags = map[string]struct{}{
 	"charisma":     {},
 }
 
-const openEndedScenarioGenerationPromptTemplate = `
-You are designing one fantasy RPG map scenario for a location-based game.
-
-Zone:
-- name: %s
-- description: %s
-
-Location context:
-- latitude: %.6f
-- longitude: %.6f
-
-Variance directives (treat these as hard constraints for diversity):
-%s
-
-Recent scenarios in this zone to avoid echoing:
-%s
-
-Create an OPEN-ENDED scenario (free-text response from player).
-
-Return JSON only:
-{
-  "zoneKind": "forest",
-  "prompt": "2-4 vivid sentences",
-  "difficulty": 0-40,
-  "rewardExperience": 0-120,
-  "rewardGold": 0-120
-}
-
-Rules:
-- zoneKind must be one of the allowed slugs exactly as written.
-- Choose the single best-fit zone kind for this generated scenario.
-- Prompt must be specific to this zone and location, with a clear conflict/opportunity.
-- The scenario must feel materially different from the recent scenarios listed above.
-- Keep tone adventurous and grounded in physical surroundings.
-`
-
-const choiceScenarioGenerationPromptTemplate = `
-You are designing one fantasy RPG map scenario for a location-based game.
-
-Zone:
-- name: %s
-- description: %s
-
-Location context:
-- latitude: %.6f
-- longitude: %.6f
-
-Variance directives (treat these as hard constraints for diversity):
-%s
-
-Recent scenarios in this zone to avoid echoing:
-%s
-
-Create a CHOICE-BASED scenario with 3 options.
-
-Return JSON only:
-{
-  "zoneKind": "forest",
-  "prompt": "2-4 vivid sentences",
-  "difficulty": 0-40,
-  "options": [
-    {
-      "optionText": "player action text",
-      "successText": "one or two sentences",
-      "failureText": "one or two sentences",
-      "statTag": "strength|dexterity|constitution|intelligence|wisdom|charisma",
-      "proficiencies": ["0-3 short proficiencies"],
-      "difficulty": null or 0-40,
-      "rewardExperience": 0-80,
-      "rewardGold": 0-80
-    }
-  ]
-}
-
-Rules:
-- zoneKind must be one of the allowed slugs exactly as written.
-- Choose the single best-fit zone kind for this generated scenario.
-- Prompt must be specific to this zone and location, with a clear conflict/opportunity.
-- The scenario must feel materially different from the recent scenarios listed above.
-- options must contain exactly 3 entries and each option should feel distinct.
-- proficiencies should be practical, short labels.
-`
-
 var scenarioGenerationEncounterAnchors = []string{
 	"a rooftop weather-vane platform exposed to strong winds",
 	"a cellar beneath a public gathering place",
@@ -307,9 +224,13 @@ func (p *GenerateScenarioProcessor) generateScenario(ctx context.Context, job *m
 	}
 	varianceSalt := buildScenarioVarianceSalt(job, zoneName)
 	recentScenarioAvoidance := p.buildRecentScenarioAvoidance(ctx, job, genre, 6)
+	var generationPrompt db.ResolvedPrompt
 
 	if job.OpenEnded {
-		prompt := buildOpenEndedScenarioGenerationPrompt(
+		prompt, resolved, err := buildScenarioGenerationPrompt(
+			ctx,
+			p.dbClient,
+			models.PromptKeyOpenEndedScenarioGeneration,
 			zoneName,
 			zoneDescription,
 			lat,
@@ -320,6 +241,10 @@ func (p *GenerateScenarioProcessor) generateScenario(ctx context.Context, job *m
 			zoneKinds,
 			parentZoneKind,
 		)
+		if err != nil {
+			return err
+		}
+		generationPrompt = resolved
 		answer, err := p.deepPriestClient.PetitionTheFount(&deep_priest.Question{Question: prompt})
 		if err != nil {
 			return fmt.Errorf("failed to generate open-ended scenario: %w", err)
@@ -346,7 +271,10 @@ func (p *GenerateScenarioProcessor) generateScenario(ctx context.Context, job *m
 			),
 		)
 	} else {
-		prompt := buildChoiceScenarioGenerationPrompt(
+		prompt, resolved, err := buildScenarioGenerationPrompt(
+			ctx,
+			p.dbClient,
+			models.PromptKeyChoiceScenarioGeneration,
 			zoneName,
 			zoneDescription,
 			lat,
@@ -357,6 +285,10 @@ func (p *GenerateScenarioProcessor) generateScenario(ctx context.Context, job *m
 			zoneKinds,
 			parentZoneKind,
 		)
+		if err != nil {
+			return err
+		}
+		generationPrompt = resolved
 		answer, err := p.deepPriestClient.PetitionTheFount(&deep_priest.Question{Question: prompt})
 		if err != nil {
 			return fmt.Errorf("failed to generate choice scenario: %w", err)
@@ -389,12 +321,29 @@ func (p *GenerateScenarioProcessor) generateScenario(ctx context.Context, job *m
 	if err := p.dbClient.Scenario().Create(ctx, scenario); err != nil {
 		return fmt.Errorf("failed to create scenario: %w", err)
 	}
+	// Freshly generated text stays hidden until a reviewer approves it; if it
+	// cannot be queued it must not go live unreviewed.
+	if err := submitForModeration(
+		ctx,
+		p.dbClient,
+		models.ModeratedContentTypeScenario,
+		scenario.ID,
+		scenario.Prompt,
+		scenarioModerationFields(scenario, options),
+		zoneBusinessNames(ctx, p.dbClient, zone.ID),
+	); err != nil {
+		if deleteErr := p.dbClient.Scenario().Delete(ctx, scenario.ID); deleteErr != nil {
+			log.Printf("[moderation][scenario] failed to remove unqueued scenario id=%s err=%v", scenario.ID, deleteErr)
+		}
+		return err
+	}
 	if err := p.dbClient.Scenario().ReplaceOptions(ctx, scenario.ID, options); err != nil {
 		return fmt.Errorf("failed to create scenario options: %w", err)
 	}
 	if err := p.dbClient.Scenario().ReplaceItemRewards(ctx, scenario.ID, rewards); err != nil {
 		return fmt.Errorf("failed to create scenario rewards: %w", err)
 	}
+	db.RecordGeneratedPrompt(ctx, p.dbClient, generationPrompt, models.GeneratedRecordTypeScenario, scenario.ID)
 
 	job.Status = models.ScenarioGenerationStatusCompleted
 	job.GeneratedScenarioID = &scenario.ID
@@ -604,7 +553,12 @@ func (p *GenerateScenarioProcessor) buildRecentScenarioAvoidance(
 	return strings.Join(lines, "\n")
 }
 
-func buildOpenEndedScenarioGenerationPrompt(
+// buildScenarioGenerationPrompt resolves the open-ended or choice scenario
+// prompt and prepends the zone kind and genre direction to it.
+func buildScenarioGenerationPrompt(
+	ctx context.Context,
+	dbClient db.DbClient,
+	key models.PromptKey,
 	zoneName string,
 	zoneDescription string,
 	latitude float64,
@@ -614,56 +568,22 @@ func buildOpenEndedScenarioGenerationPrompt(
 	genre *models.ZoneGenre,
 	zoneKinds []models.ZoneKind,
 	parentZoneKind *models.ZoneKind,
-) string {
-	base := fmt.Sprintf(
-		openEndedScenarioGenerationPromptTemplate,
-		zoneName,
-		zoneDescription,
-		latitude,
-		longitude,
-		varianceSalt,
-		recentScenarioAvoidance,
-	)
-	instructionBlocks := []string{}
-	if zoneKindBlock := buildScenarioZoneKindInstructionBlock(
-		zoneKinds,
-		parentZoneKind,
-		"parent zone kind",
-	); zoneKindBlock != "" {
-		instructionBlocks = append(instructionBlocks, zoneKindBlock)
-	}
-	if !isBaselineFantasyScenarioGenre(genre) {
-		instructionBlocks = append(
-			instructionBlocks,
-			scenarioGenreInstructionBlock(genre),
-		)
-	}
-	if len(instructionBlocks) == 0 {
-		return base
+) (string, db.ResolvedPrompt, error) {
+	zoneKind := ""
+	if parentZoneKind != nil {
+		zoneKind = parentZoneKind.Slug
+	}
+	resolved, err := db.ResolvePrompt(ctx, dbClient, key, &genre.ID, zoneKind, map[string]interface{}{
+		"ZoneName":           zoneName,
+		"ZoneDescription":    zoneDescription,
+		"Latitude":           fmt.Sprintf("%.6f", latitude),
+		"Longitude":          fmt.Sprintf("%.6f", longitude),
+		"VarianceDirectives": varianceSalt,
+		"RecentScenarios":    recentScenarioAvoidance,
+	})
+	if err != nil {
+		return "", db.ResolvedPrompt{}, err
 	}
-	return strings.TrimSpace(strings.Join(instructionBlocks, "\n\n") + "\n\n" + base)
-}
-
-func buildChoiceScenarioGenerationPrompt(
-	zoneName string,
-	zoneDescription string,
-	latitude float64,
-	longitude float64,
-	varianceSalt string,
-	recentScenarioAvoidance string,
-	genre *models.ZoneGenre,
-	zoneKinds []models.ZoneKind,
-	parentZoneKind *models.ZoneKind,
-) string {
-	base := fmt.Sprintf(
-		choiceScenarioGenerationPromptTemplate,
-		zoneName,
-		zoneDescription,
-		latitude,
-		longitude,
-		varianceSalt,
-		recentScenarioAvoidance,
-	)
 	instructionBlocks := []string{}
 	if zoneKindBlock := buildScenarioZoneKindInstructionBlock(
 		zoneKinds,
@@ -675,13 +595,13 @@ func buildChoiceScenarioGenerationPrompt(
 	if !isBaselineFantasyScenarioGenre(genre) {
 		instructionBlocks = append(
 			instructionBlocks,
-			scenarioGenreInstructionBlock(genre),
+			scenarioGenreInstructionBlock(ctx, dbClient, genre),
 		)
 	}
 	if len(instructionBlocks) == 0 {
-		return base
+		return resolved.Text, resolved, nil
 	}
-	return strings.TrimSpace(strings.Join(instructionBlocks, "\n\n") + "\n\n" + base)
+	return strings.TrimSpace(strings.Join(instructionBlocks, "\n\n") + "\n\n" + resolved.Text), resolved, nil
 }
 
 func buildScenarioVarianceSalt(job *models.ScenarioGenerationJob, zoneName string) string {
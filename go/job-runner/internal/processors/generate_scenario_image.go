@@ -37,18 +37,26 @@ type GenerateScenarioImageProcessor struct {
 	dbClient         db.DbClient
 	deepPriestClient deep_priest.DeepPriest
 	awsClient        aws.AWSClient
+	imageClassifier  ImageClassifier
 }
 
+// NewGenerateScenarioImageProcessor builds the processor. A nil
+// imageClassifier leaves generated images to the duplicate check and review.
 func NewGenerateScenarioImageProcessor(
 	dbClient db.DbClient,
 	deepPriestClient deep_priest.DeepPriest,
 	awsClient aws.AWSClient,
+	imageClassifier ImageClassifier,
 ) GenerateScenarioImageProcessor {
 	log.Println("Initializing GenerateScenarioImageProcessor")
+	if imageClassifier == nil {
+		imageClassifier = noopImageClassifier{}
+	}
 	return GenerateScenarioImageProcessor{
 		dbClient:         dbClient,
 		deepPriestClient: deepPriestClient,
 		awsClient:        awsClient,
+		imageClassifier:  imageClassifier,
 	}
 }
 
@@ -74,7 +82,7 @@ func (p *GenerateScenarioImageProcessor) ProcessTask(ctx context.Context, task *
 		zoneName = strings.TrimSpace(scenario.Zone.Name)
 	}
 
-	prompt := buildScenarioImagePrompt(scenario, zoneName)
+	prompt := buildScenarioImagePrompt(ctx, p.dbClient, scenario, zoneName)
 	request := deep_priest.GenerateImageRequest{
 		Prompt: prompt,
 	}
@@ -94,6 +102,21 @@ func (p *GenerateScenarioImageProcessor) ProcessTask(ctx context.Context, task *
 		return err
 	}
 
+	// Moderate before the scenario points at the image: an approved scenario
+	// is visible, and a blocking finding has to send it back for review
+	// before players can see the new image.
+	if err := moderateGeneratedImage(
+		ctx,
+		p.dbClient,
+		p.imageClassifier,
+		models.ModeratedContentTypeScenario,
+		payload.ScenarioID,
+		imageURL,
+		imageBytes,
+	); err != nil {
+		return fmt.Errorf("failed to moderate scenario image: %w", err)
+	}
+
 	scenario.ImageURL = imageURL
 	scenario.ThumbnailURL = imageURL
 	if err := p.dbClient.Scenario().Update(ctx, payload.ScenarioID, scenario); err != nil {
@@ -123,6 +146,8 @@ func (p *GenerateScenarioImageProcessor) uploadScenarioImage(ctx context.Context
 }
 
 func buildScenarioImagePrompt(
+	ctx context.Context,
+	dbClient db.DbClient,
 	scenario *models.Scenario,
 	zoneName string,
 ) string {
@@ -134,7 +159,7 @@ func buildScenarioImagePrompt(
 	if scenario == nil || isBaselineFantasyScenarioGenre(scenario.Genre) {
 		return base
 	}
-	direction := scenarioGenreImageDirection(scenario.Genre)
+	direction := scenarioGenreImageDirection(ctx, dbClient, scenario.Genre)
 	if direction == "" {
 		return base
 	}
@@ -0,0 +1,179 @@
+package processors
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/aws"
+	"github.com/MaxBlaushild/poltergeist/pkg/db"
+	"github.com/MaxBlaushild/poltergeist/pkg/deep_priest"
+	"github.com/MaxBlaushild/poltergeist/pkg/jobs"
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+)
+
+// The fakes embed the interfaces they stand in for, so calls the test does
+// not expect panic instead of silently succeeding.
+
+type scenarioImageTestDB struct {
+	db.DbClient
+	scenarios  *scenarioImageTestScenarios
+	moderation *scenarioImageTestModeration
+}
+
+func (f *scenarioImageTestDB) Scenario() db.ScenarioHandle { return f.scenarios }
+
+func (f *scenarioImageTestDB) ContentModeration() db.ContentModerationHandle { return f.moderation }
+
+type scenarioImageTestScenarios struct {
+	db.ScenarioHandle
+	scenario *models.Scenario
+	updates  []string
+	// statusAtUpdate is the moderation status when the scenario started
+	// pointing at the new image.
+	statusAtUpdate models.ContentModerationStatus
+	moderation     *scenarioImageTestModeration
+}
+
+func (f *scenarioImageTestScenarios) FindByID(context.Context, uuid.UUID) (*models.Scenario, error) {
+	copied := *f.scenario
+	return &copied, nil
+}
+
+func (f *scenarioImageTestScenarios) Update(_ context.Context, _ uuid.UUID, scenario *models.Scenario) error {
+	f.updates = append(f.updates, scenario.ImageURL)
+	f.statusAtUpdate = f.moderation.item.Status
+	return nil
+}
+
+type scenarioImageTestModeration struct {
+	db.ContentModerationHandle
+	item      *models.ContentModerationItem
+	hashesErr error
+	recorded  models.ContentModerationFindings
+}
+
+func (f *scenarioImageTestModeration) FindByContent(context.Context, string, uuid.UUID) (*models.ContentModerationItem, error) {
+	return f.item, nil
+}
+
+func (f *scenarioImageTestModeration) FindImageHashes(context.Context, string, uuid.UUID) ([]models.ContentModerationItem, error) {
+	return nil, f.hashesErr
+}
+
+func (f *scenarioImageTestModeration) RecordImage(_ context.Context, _ string, _ uuid.UUID, imageURL string, _ int64, findings models.ContentModerationFindings) error {
+	f.recorded = findings
+	f.item.ImageURL = imageURL
+	if f.item.Status == models.ContentModerationStatusApproved && findings.HasBlocking() {
+		f.item.Status = models.ContentModerationStatusPending
+	}
+	return nil
+}
+
+type scenarioImageTestDeepPriest struct {
+	deep_priest.DeepPriest
+	image string
+}
+
+func (f *scenarioImageTestDeepPriest) GenerateImage(deep_priest.GenerateImageRequest) (string, error) {
+	return f.image, nil
+}
+
+type scenarioImageTestAWS struct {
+	aws.AWSClient
+}
+
+func (scenarioImageTestAWS) UploadImageToS3(bucket, key string, _ []byte) (string, error) {
+	return "https://" + bucket + ".example.com/" + key, nil
+}
+
+type blockingImageClassifier struct {
+	calls int
+}
+
+func (c *blockingImageClassifier) ClassifyImage(context.Context, image.Image) (models.ContentModerationFindings, error) {
+	c.calls++
+	return models.ContentModerationFindings{{Severity: models.ContentModerationSeverityBlock, Detail: "unsafe"}}, nil
+}
+
+func testScenarioImage(t *testing.T) string {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 16, 16))
+	for x := 0; x < 16; x++ {
+		img.Set(x, x, color.RGBA{R: 200, A: 255})
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+func newScenarioImageTest(t *testing.T, status models.ContentModerationStatus, classifier ImageClassifier) (GenerateScenarioImageProcessor, *scenarioImageTestDB, *asynq.Task) {
+	t.Helper()
+	scenarioID := uuid.New()
+	moderation := &scenarioImageTestModeration{item: &models.ContentModerationItem{
+		ContentType: models.ModeratedContentTypeScenario,
+		ContentID:   scenarioID,
+		Status:      status,
+	}}
+	dbClient := &scenarioImageTestDB{
+		scenarios: &scenarioImageTestScenarios{
+			scenario:   &models.Scenario{ID: scenarioID, Prompt: "A lantern flickers in the mill"},
+			moderation: moderation,
+		},
+		moderation: moderation,
+	}
+	processor := NewGenerateScenarioImageProcessor(
+		dbClient,
+		&scenarioImageTestDeepPriest{image: testScenarioImage(t)},
+		scenarioImageTestAWS{},
+		classifier,
+	)
+	payload, err := json.Marshal(jobs.GenerateScenarioImageTaskPayload{ScenarioID: scenarioID})
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return processor, dbClient, asynq.NewTask(jobs.GenerateScenarioImageTaskType, payload)
+}
+
+func TestGenerateScenarioImageReopensReviewBeforePublishingImage(t *testing.T) {
+	classifier := &blockingImageClassifier{}
+	processor, dbClient, task := newScenarioImageTest(t, models.ContentModerationStatusApproved, classifier)
+
+	if err := processor.ProcessTask(context.Background(), task); err != nil {
+		t.Fatalf("process: %v", err)
+	}
+	if classifier.calls != 1 {
+		t.Fatalf("expected the injected classifier to run once, ran %d times", classifier.calls)
+	}
+	if len(dbClient.moderation.recorded) != 1 || dbClient.moderation.recorded[0].Rule != models.ContentModerationRuleImageClassifier {
+		t.Fatalf("expected the classifier finding to be recorded, got %+v", dbClient.moderation.recorded)
+	}
+	if len(dbClient.scenarios.updates) != 1 {
+		t.Fatalf("expected the scenario image to be set once, got %v", dbClient.scenarios.updates)
+	}
+	if dbClient.scenarios.statusAtUpdate != models.ContentModerationStatusPending {
+		t.Fatalf("expected the scenario to be back in review before it pointed at the image, was %s", dbClient.scenarios.statusAtUpdate)
+	}
+}
+
+func TestGenerateScenarioImageFailsWithoutPublishingWhenModerationFails(t *testing.T) {
+	processor, dbClient, task := newScenarioImageTest(t, models.ContentModerationStatusApproved, nil)
+	dbClient.moderation.hashesErr = errors.New("connection reset")
+
+	if err := processor.ProcessTask(context.Background(), task); err == nil {
+		t.Fatal("expected a moderation failure to fail the job")
+	}
+	if len(dbClient.scenarios.updates) != 0 {
+		t.Fatalf("expected the scenario image to be left alone, got %v", dbClient.scenarios.updates)
+	}
+}
@@ -16,85 +16,6 @@ import (
 	"github.com/hibiken/asynq"
 )
 
-const openEndedScenarioTemplatePromptTemplate = `
-You are designing %d reusable fantasy MMORPG scenario templates.
-
-Recent scenario templates to avoid echoing:
-%s
-
-Return JSON only:
-{
-  "templates": [
-    {
-      "zoneKind": "forest",
-      "prompt": "2-4 vivid sentences",
-      "difficulty": 0-40,
-      "rewardExperience": 0-120,
-      "rewardGold": 0-120,
-      "itemRewards": [
-        { "inventoryItemId": <id from allowed list>, "quantity": 1-3 }
-      ]
-    }
-  ]
-}
-
-Rules:
-- Output exactly %d templates.
-- zoneKind must be one of the allowed slugs exactly as written.
-- Choose the single best-fit zone kind for where each template would most naturally belong.
-- These are generic templates, not tied to any specific zone, city, landmark, or coordinates.
-- Each prompt should describe a clear fantasy conflict or opportunity that could fit many places.
-- Keep the scenarios materially distinct from one another and from the recent templates list.
-- itemRewards can be empty.
-- Use only inventoryItemId values from this allowed list:
-%s
-`
-
-const choiceScenarioTemplatePromptTemplate = `
-You are designing %d reusable fantasy MMORPG scenario templates.
-
-Recent scenario templates to avoid echoing:
-%s
-
-Return JSON only:
-{
-  "templates": [
-    {
-      "zoneKind": "forest",
-      "prompt": "2-4 vivid sentences",
-      "difficulty": 0-40,
-      "options": [
-        {
-          "optionText": "player action text",
-          "successText": "one or two sentences",
-          "failureText": "one or two sentences",
-          "statTag": "strength|dexterity|constitution|intelligence|wisdom|charisma",
-          "proficiencies": ["0-3 short proficiencies"],
-          "difficulty": null or 0-40,
-          "rewardExperience": 0-80,
-          "rewardGold": 0-80,
-          "itemRewards": [
-            { "inventoryItemId": <id from allowed list>, "quantity": 1-2 }
-          ]
-        }
-      ]
-    }
-  ]
-}
-
-Rules:
-- Output exactly %d templates.
-- zoneKind must be one of the allowed slugs exactly as written.
-- Choose the single best-fit zone kind for where each template would most naturally belong.
-- These are generic templates, not tied to any specific zone, city, landmark, or coordinates.
-- Each template prompt should describe a reusable fantasy situation with 3 distinct player options.
-- Keep templates materially distinct from one another and from the recent templates list.
-- Each template must have exactly 3 options.
-- itemRewards can be empty.
-- Use only inventoryItemId values from this allowed list:
-%s
-`
-
 type openEndedScenarioTemplatePayload struct {
 	ZoneKind         string                            `json:"zoneKind"`
 	Prompt           string                            `json:"prompt"`
@@ -187,19 +108,22 @@ func (p *GenerateScenarioTemplatesProcessor) generateScenarioTemplates(ctx conte
 	for _, item := range inventoryItems {
 		allowedItemIDs[item.ID] = struct{}{}
 	}
-	allowedItemsPrompt := buildAllowedItemsPrompt(inventoryItems)
-	recentAvoidance := p.buildRecentScenarioTemplateAvoidance(ctx, genre, 12)
+	promptKey := models.PromptKeyChoiceScenarioTemplateGeneration
+	if job.OpenEnded {
+		promptKey = models.PromptKeyOpenEndedScenarioTemplateGeneration
+	}
+	resolved, err := db.ResolvePrompt(ctx, p.dbClient, promptKey, &genre.ID, job.ZoneKind, map[string]interface{}{
+		"Count":           job.Count,
+		"RecentTemplates": p.buildRecentScenarioTemplateAvoidance(ctx, genre, 12),
+		"AllowedItems":    buildAllowedItemsPrompt(inventoryItems),
+	})
+	if err != nil {
+		return err
+	}
+	prompt := wrapScenarioTemplatePrompt(ctx, p.dbClient, resolved.Text, genre, zoneKind, zoneKinds)
 
 	createdCount := 0
 	if job.OpenEnded {
-		prompt := buildOpenEndedScenarioTemplatePrompt(
-			job.Count,
-			recentAvoidance,
-			allowedItemsPrompt,
-			genre,
-			zoneKind,
-			zoneKinds,
-		)
 		answer, err := p.deepPriestClient.PetitionTheFount(&deep_priest.Question{Question: prompt})
 		if err != nil {
 			return fmt.Errorf("failed to generate open-ended scenario templates: %w", err)
@@ -246,26 +170,13 @@ func (p *GenerateScenarioTemplatesProcessor) generateScenarioTemplates(ctx conte
 			if template.RewardExperience == 0 && template.RewardGold == 0 && len(template.ItemRewards) == 0 {
 				template.RewardMode = models.RewardModeRandom
 			}
-			if job.YeetIt {
-				if err := p.dbClient.ScenarioTemplate().Create(ctx, template); err != nil {
-					job.CreatedCount = createdCount
-					return fmt.Errorf("failed to create scenario template: %w", err)
-				}
-			} else if err := p.createGeneratedScenarioTemplateDraft(ctx, job, template); err != nil {
+			if err := p.saveGeneratedScenarioTemplate(ctx, job, template, resolved); err != nil {
 				job.CreatedCount = createdCount
-				return fmt.Errorf("failed to create scenario template draft: %w", err)
+				return err
 			}
 			createdCount++
 		}
 	} else {
-		prompt := buildChoiceScenarioTemplatePrompt(
-			job.Count,
-			recentAvoidance,
-			allowedItemsPrompt,
-			genre,
-			zoneKind,
-			zoneKinds,
-		)
 		answer, err := p.deepPriestClient.PetitionTheFount(&deep_priest.Question{Question: prompt})
 		if err != nil {
 			return fmt.Errorf("failed to generate choice scenario templates: %w", err)
@@ -313,14 +224,9 @@ func (p *GenerateScenarioTemplatesProcessor) generateScenarioTemplates(ctx conte
 				ItemChoiceRewards:        models.ScenarioTemplateRewards{},
 				SpellRewards:             models.ScenarioTemplateSpellRewards{},
 			}
-			if job.YeetIt {
-				if err := p.dbClient.ScenarioTemplate().Create(ctx, template); err != nil {
-					job.CreatedCount = createdCount
-					return fmt.Errorf("failed to create scenario template: %w", err)
-				}
-			} else if err := p.createGeneratedScenarioTemplateDraft(ctx, job, template); err != nil {
+			if err := p.saveGeneratedScenarioTemplate(ctx, job, template, resolved); err != nil {
 				job.CreatedCount = createdCount
-				return fmt.Errorf("failed to create scenario template draft: %w", err)
+				return err
 			}
 			createdCount++
 		}
@@ -382,59 +288,16 @@ func (p *GenerateScenarioTemplatesProcessor) buildRecentScenarioTemplateAvoidanc
 	return strings.Join(lines, "\n")
 }
 
-func buildOpenEndedScenarioTemplatePrompt(
-	count int,
-	recentAvoidance string,
-	allowedItemsPrompt string,
-	genre *models.ZoneGenre,
-	zoneKind *models.ZoneKind,
-	zoneKinds []models.ZoneKind,
-) string {
-	base := fmt.Sprintf(
-		openEndedScenarioTemplatePromptTemplate,
-		count,
-		recentAvoidance,
-		count,
-		allowedItemsPrompt,
-	)
-	var instructionBlocks []string
-	if zoneKindSelectionBlock := buildScenarioZoneKindInstructionBlock(
-		zoneKinds,
-		zoneKind,
-		"requested zone kind direction",
-	); zoneKindSelectionBlock != "" {
-		instructionBlocks = append(instructionBlocks, zoneKindSelectionBlock)
-	}
-	if zoneKindBlock := zoneKindInstructionBlock(zoneKind); zoneKindBlock != "" {
-		instructionBlocks = append(instructionBlocks, zoneKindBlock)
-	}
-	if !isBaselineFantasyScenarioGenre(genre) {
-		instructionBlocks = append(
-			instructionBlocks,
-			scenarioGenreInstructionBlock(genre),
-		)
-	}
-	if len(instructionBlocks) == 0 {
-		return base
-	}
-	return strings.TrimSpace(strings.Join(instructionBlocks, "\n\n") + "\n\n" + base)
-}
-
-func buildChoiceScenarioTemplatePrompt(
-	count int,
-	recentAvoidance string,
-	allowedItemsPrompt string,
+// wrapScenarioTemplatePrompt prepends the zone kind and genre direction to a
+// rendered scenario template prompt.
+func wrapScenarioTemplatePrompt(
+	ctx context.Context,
+	dbClient db.DbClient,
+	base string,
 	genre *models.ZoneGenre,
 	zoneKind *models.ZoneKind,
 	zoneKinds []models.ZoneKind,
 ) string {
-	base := fmt.Sprintf(
-		choiceScenarioTemplatePromptTemplate,
-		count,
-		recentAvoidance,
-		count,
-		allowedItemsPrompt,
-	)
 	var instructionBlocks []string
 	if zoneKindSelectionBlock := buildScenarioZoneKindInstructionBlock(
 		zoneKinds,
@@ -443,13 +306,13 @@ func buildChoiceScenarioTemplatePrompt(
 	); zoneKindSelectionBlock != "" {
 		instructionBlocks = append(instructionBlocks, zoneKindSelectionBlock)
 	}
-	if zoneKindBlock := zoneKindInstructionBlock(zoneKind); zoneKindBlock != "" {
+	if zoneKindBlock := zoneKindInstructionBlock(ctx, dbClient, zoneKind); zoneKindBlock != "" {
 		instructionBlocks = append(instructionBlocks, zoneKindBlock)
 	}
 	if !isBaselineFantasyScenarioGenre(genre) {
 		instructionBlocks = append(
 			instructionBlocks,
-			scenarioGenreInstructionBlock(genre),
+			scenarioGenreInstructionBlock(ctx, dbClient, genre),
 		)
 	}
 	if len(instructionBlocks) == 0 {
@@ -545,14 +408,40 @@ func scenarioOptionSpellRewardsToTemplateRewards(rewards []models.ScenarioOption
 	return out
 }
 
-func (p *GenerateScenarioTemplatesProcessor) createGeneratedScenarioTemplateDraft(
+// saveGeneratedScenarioTemplate publishes the template directly when the job
+// asked to skip review and otherwise queues it as a draft, recording the
+// prompt version against whichever record was created.
+func (p *GenerateScenarioTemplatesProcessor) saveGeneratedScenarioTemplate(
 	ctx context.Context,
 	job *models.ScenarioTemplateGenerationJob,
 	template *models.ScenarioTemplate,
+	prompt db.ResolvedPrompt,
 ) error {
-	if job == nil || template == nil {
+	if job.YeetIt {
+		if err := p.dbClient.ScenarioTemplate().Create(ctx, template); err != nil {
+			return fmt.Errorf("failed to create scenario template: %w", err)
+		}
+		db.RecordGeneratedPrompt(ctx, p.dbClient, prompt, models.GeneratedRecordTypeScenarioTemplate, template.ID)
 		return nil
 	}
+	draft, err := p.createGeneratedScenarioTemplateDraft(ctx, job, template)
+	if err != nil {
+		return fmt.Errorf("failed to create scenario template draft: %w", err)
+	}
+	if draft != nil {
+		db.RecordGeneratedPrompt(ctx, p.dbClient, prompt, models.GeneratedRecordTypeScenarioTemplateDraft, draft.ID)
+	}
+	return nil
+}
+
+func (p *GenerateScenarioTemplatesProcessor) createGeneratedScenarioTemplateDraft(
+	ctx context.Context,
+	job *models.ScenarioTemplateGenerationJob,
+	template *models.ScenarioTemplate,
+) (*models.ScenarioTemplateGenerationDraft, error) {
+	if job == nil || template == nil {
+		return nil, nil
+	}
 
 	now := time.Now().UTC()
 	payload := models.ScenarioTemplateGenerationDraftPayloadFromTemplate(template)
@@ -570,5 +459,8 @@ func (p *GenerateScenarioTemplatesProcessor) createGeneratedScenarioTemplateDraf
 		Difficulty: payload.Difficulty,
 		Payload:    payload,
 	}
-	return p.dbClient.ScenarioTemplateGenerationDraft().Create(ctx, draft)
+	if err := p.dbClient.ScenarioTemplateGenerationDraft().Create(ctx, draft); err != nil {
+		return nil, err
+	}
+	return draft, nil
 }
@@ -75,7 +75,9 @@ func (p *GenerateSpellIconProcessor) ProcessTask(ctx context.Context, task *asyn
 		effectText = "Mystic energy effect"
 	}
 
-	prompt := spellIconPrompt(
+	prompt, iconPrompt := spellIconPrompt(
+		ctx,
+		p.dbClient,
 		strings.TrimSpace(spell.Name),
 		description,
 		school,
@@ -114,6 +116,7 @@ func (p *GenerateSpellIconProcessor) ProcessTask(ctx context.Context, task *asyn
 		log.Printf("Failed to update spell with icon URL: %v", err)
 		return fmt.Errorf("failed to update spell icon url: %w", err)
 	}
+	db.RecordGeneratedPrompt(ctx, p.dbClient, iconPrompt, models.GeneratedRecordTypeSpell, payload.SpellID)
 
 	log.Printf("Spell icon generated successfully for ID: %s", payload.SpellID)
 	return nil
@@ -19,60 +19,6 @@ import (
 	"github.com/redis/go-redis/v9"
 )
 
-const spellProgressionFromPromptSpellTemplate = `
-You are designing ONE %s RPG spell concept from a creator brief.
-%s
-
-Creator brief:
-%s
-
-Return JSON only:
-{
-  "spell": {
-    "name": "2-4 words",
-    "description": "one vivid sentence, 8-18 words",
-    "effectText": "one concise sentence",
-    "schoolOfMagic": "short label",
-    "manaCost": 0-60,
-    "preferredEffectType": "deal_damage|deal_damage_all_enemies|restore_life_party_member|restore_life_all_party_members|apply_beneficial_statuses|remove_detrimental_statuses"
-  }
-}
-
-Rules:
-- Keep tone adventurous and original.
-- Make the description a single punchy one-liner, not a paragraph.
-- Keep it safe and suitable for a public game.
-- No copyrighted franchises or references.
-`
-
-const spellProgressionFromPromptTechniqueTemplate = `
-You are designing ONE %s RPG combat technique concept from a creator brief.
-%s
-
-Creator brief:
-%s
-
-Return JSON only:
-{
-  "spell": {
-    "name": "2-4 words",
-    "description": "one vivid sentence, 8-18 words",
-    "effectText": "one concise sentence",
-    "schoolOfMagic": "short label, usually Martial",
-    "manaCost": 0,
-    "preferredEffectType": "deal_damage|deal_damage_all_enemies|restore_life_party_member|restore_life_all_party_members|apply_beneficial_statuses|remove_detrimental_statuses"
-  }
-}
-
-Rules:
-- This is a technique, not a spell.
-- Keep it practical and grounded in physical execution.
-- manaCost must be 0.
-- Make the description a single punchy one-liner, not a paragraph.
-- Keep it safe and suitable for a public game.
-- No copyrighted franchises or references.
-`
-
 var spellProgressionFromPromptLevelBands = []int{10, 25, 50, 70}
 
 type generatedSpellProgressionPromptEnvelope struct {
@@ -210,7 +156,7 @@ func (p *GenerateSpellProgressionFromPromptProcessor) generateSpellProgressionFr
 		usedNames[key] = struct{}{}
 	}
 
-	seedSpec, preferredEffectType, err := p.buildSeedSpec(ctx, prompt, abilityType, genre)
+	seedSpec, preferredEffectType, seedPrompt, err := p.buildSeedSpec(ctx, prompt, abilityType, genre)
 	if err != nil {
 		return nil, fmt.Errorf("failed to build seed spell spec: %w", err)
 	}
@@ -317,6 +263,9 @@ func (p *GenerateSpellProgressionFromPromptProcessor) generateSpellProgressionFr
 		}
 		createdSpellIDs = append(createdSpellIDs, variant.ID)
 	}
+	for _, spellID := range createdSpellIDs {
+		db.RecordGeneratedPrompt(ctx, p.dbClient, seedPrompt, models.GeneratedRecordTypeSpell, spellID)
+	}
 
 	return &spellProgressionFromPromptResult{
 		ProgressionID: progression.ID,
@@ -330,31 +279,34 @@ func (p *GenerateSpellProgressionFromPromptProcessor) buildSeedSpec(
 	prompt string,
 	abilityType models.SpellAbilityType,
 	genre *models.ZoneGenre,
-) (jobs.SpellCreationSpec, models.SpellEffectType, error) {
+) (jobs.SpellCreationSpec, models.SpellEffectType, db.ResolvedPrompt, error) {
 	fallback := fallbackSpellSpecFromPrompt(prompt, abilityType)
 	if p.deepPriestClient == nil {
-		return fallback, fallbackPreferredEffectType(prompt), nil
+		return fallback, fallbackPreferredEffectType(prompt), db.ResolvedPrompt{}, nil
 	}
 
-	promptTemplate := spellProgressionFromPromptSpellTemplate
+	promptKey := models.PromptKeySpellFromBrief
 	if abilityType == models.SpellAbilityTypeTechnique {
-		promptTemplate = spellProgressionFromPromptTechniqueTemplate
+		promptKey = models.PromptKeyTechniqueFromBrief
+	}
+	resolved, err := db.ResolvePrompt(ctx, p.dbClient, promptKey, &genre.ID, "", map[string]interface{}{
+		"Genre":          spellGenrePromptLabel(genre),
+		"GenreDirection": spellAbilityGenreInstructionBlock(ctx, p.dbClient, genre, abilityType),
+		"Brief":          prompt,
+	})
+	if err != nil {
+		return jobs.SpellCreationSpec{}, "", db.ResolvedPrompt{}, err
 	}
 	answer, err := p.deepPriestClient.PetitionTheFount(&deep_priest.Question{
-		Question: fmt.Sprintf(
-			promptTemplate,
-			spellGenrePromptLabel(genre),
-			spellAbilityGenreInstructionBlock(genre, abilityType),
-			prompt,
-		),
+		Question: resolved.Text,
 	})
 	if err != nil {
-		return fallback, fallbackPreferredEffectType(prompt), nil
+		return fallback, fallbackPreferredEffectType(prompt), db.ResolvedPrompt{}, nil
 	}
 
 	spec, preferred, parseErr := parseGeneratedSpellProgressionPromptSpec(answer.Answer)
 	if parseErr != nil {
-		return fallback, fallbackPreferredEffectType(prompt), nil
+		return fallback, fallbackPreferredEffectType(prompt), db.ResolvedPrompt{}, nil
 	}
 	if strings.TrimSpace(spec.Name) == "" {
 		spec.Name = fallback.Name
@@ -379,7 +331,7 @@ func (p *GenerateSpellProgressionFromPromptProcessor) buildSeedSpec(
 		spec.AbilityLevel = fallback.AbilityLevel
 	}
 	spec.AbilityLevel = normalizePromptAbilityLevel(spec.AbilityLevel)
-	return spec, preferred, nil
+	return spec, preferred, resolved, nil
 }
 
 func parseGeneratedSpellProgressionPromptSpec(
@@ -17,38 +17,6 @@ import (
 	"github.com/paulmach/orb"
 )
 
-const zoneFlavorPromptTemplate = `
-You are writing zone naming and flavor text for a fantasy MMORPG-style location-based game.
-
-Zone:
-- current name: %s
-- current description: %s
-
-Geometry context:
-%s
-
-World naming context:
-%s
-
-Return JSON only:
-{
-  "name": "1-3 words",
-  "description": "2-4 vivid sentences, about 55-110 words"
-}
-
-Rules:
-- Generate a zone name that fits the description and sounds like an explorable MMO region, district, ward, harbor, pass, or quarter.
-- The name and description must clearly belong to the same fictional place.
-- Write in the same adventurous, mystical voice as a fantasy MMO map or quest journal.
-- Base the fiction on the shape, scale, and positioning cues implied by the coordinates.
-- Treat the real-world geometry as inspiration for in-world terrain, districts, routes, choke points, shorelines, courtyards, or edges.
-- Avoid repeated fantasy crutches and do not reuse an overrepresented opening word from the world naming context.
-- Do not mention GPS, coordinates, polygons, latitude, longitude, OpenStreetMap, apps, or modern map tooling.
-- Do not mention brands, real businesses, or modern infrastructure by name.
-- Keep it useful as a zone description players might read in the UI.
-- Avoid second-person instructions and avoid explicit quest hooks; this is setting flavor, not a task prompt.
-`
-
 type zoneFlavorGenerationResponse struct {
 	Name        string `json:"name"`
 	Description string `json:"description"`
@@ -120,13 +88,16 @@ func (p *GenerateZoneFlavorProcessor) generateFlavor(ctx context.Context, job *m
 	}
 
 	diversityContext := buildZoneNameDiversityContext(ctx, p.dbClient, zone.ID)
-	basePrompt := fmt.Sprintf(
-		zoneFlavorPromptTemplate,
-		zoneName,
-		currentDescription,
-		buildZoneFlavorGeometrySummary(*zone),
-		diversityContext.Guidance,
-	)
+	resolved, err := db.ResolvePrompt(ctx, p.dbClient, models.PromptKeyZoneFlavorGeneration, nil, zone.Kind, map[string]interface{}{
+		"ZoneName":        zoneName,
+		"ZoneDescription": currentDescription,
+		"Geometry":        buildZoneFlavorGeometrySummary(*zone),
+		"NamingGuidance":  diversityContext.Guidance,
+	})
+	if err != nil {
+		return err
+	}
+	basePrompt := resolved.Text
 
 	var (
 		name        string
@@ -182,6 +153,7 @@ func (p *GenerateZoneFlavorProcessor) generateFlavor(ctx context.Context, job *m
 	if err := p.dbClient.Zone().UpdateNameAndDescription(ctx, zone.ID, name, description); err != nil {
 		return fmt.Errorf("failed to update zone name and description: %w", err)
 	}
+	db.RecordGeneratedPrompt(ctx, p.dbClient, resolved, models.GeneratedRecordTypeZone, zone.ID)
 
 	job.Status = models.ZoneFlavorGenerationStatusCompleted
 	job.GeneratedDescription = &description
@@ -16,6 +16,7 @@ import (
 
 	"github.com/MaxBlaushild/poltergeist/pkg/db"
 	"github.com/MaxBlaushild/poltergeist/pkg/jobs"
+	"github.com/MaxBlaushild/poltergeist/pkg/locationseeder"
 	"github.com/MaxBlaushild/poltergeist/pkg/models"
 	"github.com/google/uuid"
 	"github.com/hibiken/asynq"
@@ -70,17 +71,7 @@ func (p *ImportZonesForMetroProcessor) ProcessTask(ctx context.Context, task *as
 		return nil
 	}
 
-	areaID, err := p.lookupMetroAreaID(ctx, metroName)
-	if err != nil {
-		msg := err.Error()
-		importItem.Status = "failed"
-		importItem.ErrorMessage = &msg
-		importItem.UpdatedAt = time.Now()
-		_ = p.dbClient.ZoneImport().Update(ctx, importItem)
-		return err
-	}
-
-	neighborhoods, err := p.fetchNeighborhoods(ctx, areaID)
+	neighborhoods, err := p.findNeighborhoods(ctx, importItem, metroName)
 	if err != nil {
 		msg := err.Error()
 		importItem.Status = "failed"
@@ -181,6 +172,48 @@ func (p *ImportZonesForMetroProcessor) ProcessTask(ctx context.Context, task *as
 	return p.dbClient.ZoneImport().Update(ctx, importItem)
 }
 
+// findNeighborhoods reads boundaries from the import's local OSM extract when
+// one is set, and otherwise asks Nominatim and Overpass.
+func (p *ImportZonesForMetroProcessor) findNeighborhoods(ctx context.Context, importItem *models.ZoneImport, metroName string) ([]neighborhoodBoundary, error) {
+	if importItem.OSMExtractPath != nil && strings.TrimSpace(*importItem.OSMExtractPath) != "" {
+		return loadNeighborhoodsFromExtract(strings.TrimSpace(*importItem.OSMExtractPath))
+	}
+
+	areaID, err := p.lookupMetroAreaID(ctx, metroName)
+	if err != nil {
+		return nil, err
+	}
+	return p.fetchNeighborhoods(ctx, areaID)
+}
+
+// loadNeighborhoodsFromExtract reads neighborhood boundaries from a PBF file.
+// The extract is expected to already be clipped to the metro, e.g. with
+// osmium extract, so every boundary in it is imported.
+func loadNeighborhoodsFromExtract(path string) ([]neighborhoodBoundary, error) {
+	data, err := locationseeder.ReadOSMFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OSM extract %s: %w", path, err)
+	}
+	extract := locationseeder.ExtractOSM(data)
+
+	elements := make([]overpassElement, 0, len(extract.Boundaries))
+	for _, boundary := range extract.Boundaries {
+		element := overpassElement{
+			Type: "relation",
+			Tags: map[string]string{"name": boundary.Name},
+		}
+		for _, line := range boundary.Outer {
+			member := overpassMember{Type: "way", Role: "outer"}
+			for _, coord := range line {
+				member.Geometry = append(member.Geometry, overpassCoord{Lat: coord.Lat, Lon: coord.Lng})
+			}
+			element.Members = append(element.Members, member)
+		}
+		elements = append(elements, element)
+	}
+	return neighborhoodsFromElements(elements), nil
+}
+
 type nominatimResult struct {
 	OsmID       int64  `json:"osm_id"`
 	OsmType     string `json:"osm_type"`
@@ -306,8 +339,12 @@ out body geom;`, areaID)
 		return nil, err
 	}
 
-	out := make([]neighborhoodBoundary, 0, len(parsed.Elements))
-	for _, element := range parsed.Elements {
+	return neighborhoodsFromElements(parsed.Elements), nil
+}
+
+func neighborhoodsFromElements(elements []overpassElement) []neighborhoodBoundary {
+	out := make([]neighborhoodBoundary, 0, len(elements))
+	for _, element := range elements {
 		name := ""
 		if element.Tags != nil {
 			name = element.Tags["name"]
@@ -342,7 +379,7 @@ out body geom;`, areaID)
 		})
 	}
 
-	return out, nil
+	return out
 }
 
 func simplifyBoundary(points []overpassCoord) []overpassCoord {
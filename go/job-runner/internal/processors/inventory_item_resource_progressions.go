@@ -125,7 +125,7 @@ func (p *GenerateInventoryItemSuggestionsProcessor) generateResourceProgressionD
 	prompt := fmt.Sprintf(
 		inventoryResourceProgressionPromptTemplate,
 		inventoryItemSuggestionGenreInstructionBlock(job.Genre),
-		zoneKindInstructionBlock(fixedZoneKind),
+		zoneKindInstructionBlock(ctx, p.dbClient, fixedZoneKind),
 		inventoryResourceTypeInstructionBlock(resourceType, fixedZoneKind),
 		len(inventoryResourceProgressionTargets),
 		renderInventoryResourceProgressionTargets(inventoryResourceProgressionTargets),
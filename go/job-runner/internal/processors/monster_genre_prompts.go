@@ -1,9 +1,10 @@
 package processors
 
 import (
-	"fmt"
+	"context"
 	"strings"
 
+	"github.com/MaxBlaushild/poltergeist/pkg/db"
 	"github.com/MaxBlaushild/poltergeist/pkg/models"
 )
 
@@ -44,20 +45,19 @@ func monsterGenreName(genre *models.ZoneGenre) string {
 	return strings.ToLower(trimmedName)
 }
 
-func monsterGenreVisualDirective(genre *models.ZoneGenre) string {
+// monsterGenreVisualDirective returns the creature direction for a monster
+// image and, outside baseline fantasy, the registry prompt it came from.
+func monsterGenreVisualDirective(
+	ctx context.Context,
+	dbClient db.DbClient,
+	genre *models.ZoneGenre,
+) (string, db.ResolvedPrompt) {
 	if isBaselineFantasyMonsterGenre(genre) {
-		return "Aggressive fantasy creature"
-	}
-	promptSeed := monsterGenrePromptSeed(genre)
-	if promptSeed == "" {
-		return fmt.Sprintf(
-			"Aggressive %s creature with genre-authentic visual language",
-			monsterGenreName(genre),
-		)
-	}
-	return fmt.Sprintf(
-		"Aggressive %s creature. Genre direction: %s",
-		monsterGenreName(genre),
-		promptSeed,
-	)
+		return "Aggressive fantasy creature", db.ResolvedPrompt{}
+	}
+	resolved := resolvePromptBlock(ctx, dbClient, models.PromptKeyMonsterGenreVisualDirection, &genre.ID, "", map[string]interface{}{
+		"Genre":        monsterGenreName(genre),
+		"CreativeSeed": monsterGenrePromptSeed(genre),
+	})
+	return resolved.Text, resolved
 }
@@ -0,0 +1,29 @@
+package processors
+
+import (
+	"context"
+	"log"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/db"
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/google/uuid"
+)
+
+// resolvePromptBlock resolves a registered instruction block that is joined
+// into a larger prompt. A block that can't be rendered is left out rather
+// than failing the job, so the result may be empty.
+func resolvePromptBlock(
+	ctx context.Context,
+	dbClient db.DbClient,
+	key models.PromptKey,
+	genreID *uuid.UUID,
+	zoneKind string,
+	variables map[string]interface{},
+) db.ResolvedPrompt {
+	resolved, err := db.ResolvePrompt(ctx, dbClient, key, genreID, zoneKind, variables)
+	if err != nil {
+		log.Printf("[prompts][resolve] leaving out key=%s err=%v", key, err)
+		return db.ResolvedPrompt{}
+	}
+	return resolved
+}
@@ -0,0 +1,154 @@
+package processors
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/db"
+	"github.com/MaxBlaushild/poltergeist/pkg/deep_priest"
+	"github.com/MaxBlaushild/poltergeist/pkg/jobs"
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/hibiken/asynq"
+)
+
+type RunPromptEvaluationProcessor struct {
+	dbClient         db.DbClient
+	deepPriestClient deep_priest.DeepPriest
+}
+
+func NewRunPromptEvaluationProcessor(
+	dbClient db.DbClient,
+	deepPriestClient deep_priest.DeepPriest,
+) RunPromptEvaluationProcessor {
+	log.Println("Initializing RunPromptEvaluationProcessor")
+	return RunPromptEvaluationProcessor{
+		dbClient:         dbClient,
+		deepPriestClient: deepPriestClient,
+	}
+}
+
+func (p *RunPromptEvaluationProcessor) ProcessTask(ctx context.Context, task *asynq.Task) error {
+	log.Printf("Processing run prompt evaluation task: %v", task.Type())
+
+	var payload jobs.RunPromptEvaluationTaskPayload
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %w", err)
+	}
+
+	evaluation, err := p.dbClient.PromptEvaluation().FindByID(ctx, payload.EvaluationID)
+	if err != nil {
+		return err
+	}
+	if evaluation == nil {
+		log.Printf("Prompt evaluation %s not found", payload.EvaluationID)
+		return nil
+	}
+
+	evaluation.Status = models.PromptEvaluationStatusInProgress
+	evaluation.ErrorMessage = nil
+	if err := p.dbClient.PromptEvaluation().Update(ctx, evaluation); err != nil {
+		return err
+	}
+
+	if err := p.runPromptEvaluation(ctx, evaluation); err != nil {
+		msg := err.Error()
+		evaluation.Status = models.PromptEvaluationStatusFailed
+		evaluation.ErrorMessage = &msg
+		if updateErr := p.dbClient.PromptEvaluation().Update(ctx, evaluation); updateErr != nil {
+			log.Printf("Failed to mark prompt evaluation %s as failed: %v", evaluation.ID, updateErr)
+		}
+		return err
+	}
+
+	evaluation.Status = models.PromptEvaluationStatusCompleted
+	return p.dbClient.PromptEvaluation().Update(ctx, evaluation)
+}
+
+// runPromptEvaluation sends every input through both versions. A failure on
+// one input is stored on that output so the rest of the comparison survives.
+func (p *RunPromptEvaluationProcessor) runPromptEvaluation(ctx context.Context, evaluation *models.PromptEvaluation) error {
+	definition, ok := models.FindPromptDefinition(evaluation.PromptKey)
+	if !ok {
+		return fmt.Errorf("unknown prompt key %q", evaluation.PromptKey)
+	}
+	inputs, err := evaluation.DecodeInputs()
+	if err != nil {
+		return err
+	}
+	if len(inputs) == 0 {
+		return fmt.Errorf("prompt evaluation has no inputs")
+	}
+
+	bodies := map[string]*models.PromptTemplate{}
+	for _, variant := range []string{models.PromptEvaluationVariantA, models.PromptEvaluationVariantB} {
+		versionID := evaluation.VersionID(variant)
+		if versionID == nil {
+			bodies[variant] = nil
+			continue
+		}
+		promptTemplate, err := p.dbClient.PromptTemplate().FindByID(ctx, *versionID)
+		if err != nil {
+			return fmt.Errorf("failed to load prompt version %s: %w", versionID, err)
+		}
+		if promptTemplate == nil || promptTemplate.Key != evaluation.PromptKey {
+			return fmt.Errorf("prompt version %s not found for %s", versionID, evaluation.PromptKey)
+		}
+		bodies[variant] = promptTemplate
+	}
+
+	for index, input := range inputs {
+		zoneKind, err := loadOptionalZoneKind(ctx, p.dbClient, input.ZoneKind)
+		if err != nil {
+			return fmt.Errorf("failed to load zone kind for input %d: %w", index, err)
+		}
+		for _, variant := range []string{models.PromptEvaluationVariantA, models.PromptEvaluationVariantB} {
+			output := p.runPromptEvaluationVariant(ctx, definition, bodies[variant], input, zoneKind)
+			output.EvaluationID = evaluation.ID
+			output.InputIndex = index
+			output.Variant = variant
+			if err := p.dbClient.PromptEvaluation().SaveOutput(ctx, output); err != nil {
+				return fmt.Errorf("failed to save prompt evaluation output: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+func (p *RunPromptEvaluationProcessor) runPromptEvaluationVariant(
+	ctx context.Context,
+	definition models.PromptDefinition,
+	promptTemplate *models.PromptTemplate,
+	input models.PromptEvaluationInput,
+	zoneKind *models.ZoneKind,
+) *models.PromptEvaluationOutput {
+	output := &models.PromptEvaluationOutput{}
+	body := definition.DefaultBody
+	if promptTemplate != nil {
+		body = promptTemplate.Body
+		id := promptTemplate.ID
+		output.PromptTemplateID = &id
+	}
+
+	prompt, err := models.RenderPrompt(body, input.Variables)
+	if err != nil {
+		msg := err.Error()
+		output.ErrorMessage = &msg
+		return output
+	}
+	if zoneKindBlock := zoneKindInstructionBlock(ctx, p.dbClient, zoneKind); zoneKindBlock != "" {
+		prompt = strings.TrimSpace(zoneKindBlock + "\n\n" + prompt)
+	}
+	output.RenderedPrompt = prompt
+
+	answer, err := p.deepPriestClient.PetitionTheFount(&deep_priest.Question{Question: prompt})
+	if err != nil {
+		msg := err.Error()
+		output.ErrorMessage = &msg
+		return output
+	}
+	output.Output = strings.TrimSpace(answer.Answer)
+	return output
+}
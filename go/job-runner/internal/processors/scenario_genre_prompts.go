@@ -76,7 +76,11 @@ func scenarioGenrePromptLabel(genre *models.ZoneGenre) string {
 	return strings.ToLower(trimmedName)
 }
 
-func scenarioGenreInstructionBlock(genre *models.ZoneGenre) string {
+func scenarioGenreInstructionBlock(
+	ctx context.Context,
+	dbClient db.DbClient,
+	genre *models.ZoneGenre,
+) string {
 	if isBaselineFantasyScenarioGenre(genre) {
 		return ""
 	}
@@ -87,22 +91,17 @@ func scenarioGenreInstructionBlock(genre *models.ZoneGenre) string {
 			scenarioGenrePromptLabel(genre),
 		)
 	}
-	return fmt.Sprintf(
-		`Genre direction:
-- genre: %s
-- creative seed: %s
-
-Additional rules:
-- The scenario must feel authentically %s rather than generic fantasy.
-- Use genre-appropriate stakes, props, factions, and environmental logic.
-`,
-		scenarioGenrePromptLabel(genre),
-		promptSeed,
-		scenarioGenrePromptLabel(genre),
-	)
+	return resolvePromptBlock(ctx, dbClient, models.PromptKeyScenarioGenreDirection, &genre.ID, "", map[string]interface{}{
+		"Genre":        scenarioGenrePromptLabel(genre),
+		"CreativeSeed": promptSeed,
+	}).Text
 }
 
-func scenarioGenreImageDirection(genre *models.ZoneGenre) string {
+func scenarioGenreImageDirection(
+	ctx context.Context,
+	dbClient db.DbClient,
+	genre *models.ZoneGenre,
+) string {
 	if isBaselineFantasyScenarioGenre(genre) {
 		return ""
 	}
@@ -113,9 +112,8 @@ func scenarioGenreImageDirection(genre *models.ZoneGenre) string {
 			scenarioGenrePromptLabel(genre),
 		)
 	}
-	return fmt.Sprintf(
-		"Render the scene using unmistakable %s visual language. %s",
-		scenarioGenrePromptLabel(genre),
-		promptSeed,
-	)
+	return resolvePromptBlock(ctx, dbClient, models.PromptKeyScenarioGenreImageDirection, &genre.ID, "", map[string]interface{}{
+		"Genre":        scenarioGenrePromptLabel(genre),
+		"CreativeSeed": promptSeed,
+	}).Text
 }
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/MaxBlaushild/poltergeist/pkg/db"
 	"github.com/MaxBlaushild/poltergeist/pkg/deep_priest"
 	"github.com/MaxBlaushild/poltergeist/pkg/models"
 )
@@ -40,6 +41,7 @@ type scenarioTemplateZoneKindPayload struct {
 
 func classifyScenarioTemplateZoneKind(
 	ctx context.Context,
+	dbClient db.DbClient,
 	template *models.ScenarioTemplate,
 	zoneKinds []models.ZoneKind,
 	priest deep_priest.DeepPriest,
@@ -50,6 +52,7 @@ func classifyScenarioTemplateZoneKind(
 	if priest != nil && len(zoneKinds) > 0 {
 		if generated, err := generateScenarioTemplateZoneKindWithLLM(
 			ctx,
+			dbClient,
 			template,
 			zoneKinds,
 			priest,
@@ -66,7 +69,8 @@ func classifyScenarioTemplateZoneKind(
 }
 
 func generateScenarioTemplateZoneKindWithLLM(
-	_ context.Context,
+	ctx context.Context,
+	dbClient db.DbClient,
 	template *models.ScenarioTemplate,
 	zoneKinds []models.ZoneKind,
 	priest deep_priest.DeepPriest,
@@ -83,7 +87,7 @@ func generateScenarioTemplateZoneKindWithLLM(
 
 	prompt := fmt.Sprintf(
 		scenarioTemplateZoneKindPromptTemplate,
-		scenarioGenreInstructionBlock(template.Genre),
+		scenarioGenreInstructionBlock(ctx, dbClient, template.Genre),
 		buildScenarioZoneKindInstructionBlock(
 			zoneKinds,
 			findZoneKindBySlug(zoneKinds, template.ZoneKind),
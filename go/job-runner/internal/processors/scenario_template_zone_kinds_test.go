@@ -19,7 +19,7 @@ func TestClassifyScenarioTemplateZoneKindFallsBackHeuristicallyWithoutPriest(t *
 		RewardMode: models.RewardModeExplicit,
 	}
 
-	got := classifyScenarioTemplateZoneKind(nil, template, zoneKinds, nil)
+	got := classifyScenarioTemplateZoneKind(nil, nil, template, zoneKinds, nil)
 	if got != "swamp" {
 		t.Fatalf("expected swamp, got %q", got)
 	}
@@ -184,6 +184,17 @@ func (p *SeedZoneDraftProcessor) ProcessTask(ctx context.Context, task *asynq.Ta
 		Quests:           quests,
 		MainQuests:       mainQuests,
 	}
+	if err := submitForModeration(
+		ctx,
+		p.dbClient,
+		models.ModeratedContentTypeZoneSeedDraft,
+		job.ID,
+		strings.TrimSpace(job.Draft.FantasyName+": "+job.Draft.ZoneDescription),
+		zoneSeedDraftModerationFields(job.Draft),
+		zoneSeedDraftBusinessNames(job.Draft),
+	); err != nil {
+		return err
+	}
 	job.Status = models.ZoneSeedStatusAwaitingApproval
 	job.ErrorMessage = nil
 	job.UpdatedAt = time.Now()
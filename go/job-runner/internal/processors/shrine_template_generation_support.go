@@ -12,37 +12,6 @@ import (
 	"github.com/MaxBlaushild/poltergeist/pkg/models"
 )
 
-const shrineTemplateGenerationPromptTemplate = `
-You are designing %d reusable fantasy MMORPG shrine templates.
-
-Recent shrine templates to avoid echoing:
-%s
-
-Return JSON only:
-{
-  "templates": [
-    {
-      "name": "Shrine of Vigor",
-      "blessingName": "Blessing of Vigor",
-      "description": "2-4 vivid sentences describing the shrine itself",
-      "effectDescription": "One sentence explaining the boon the player receives",
-      "effectKind": "strength|dexterity|constitution|intelligence|wisdom|charisma|health_regen|mana_regen|physical_damage|arcane_damage|holy_damage|shadow_damage|fire_resistance|ice_resistance|lightning_resistance|poison_resistance|physical_resistance|warding",
-      "baseMagnitude": 1-4
-    }
-  ]
-}
-
-Hard rules:
-- Output exactly %d templates.
-- Every shrine is beneficial, mystical, and reusable across many locations.
-- The template name must clearly imply the effect.
-- The blessingName must sound like a status the player receives after invoking the shrine.
-- description should describe the shrine itself, not a one-off event.
-- effectDescription must describe the benefit in player-facing language.
-- baseMagnitude should stay conservative because the blessing scales with player level.
-- Keep the templates materially distinct from one another and from the recent shrine list.
-`
-
 type generatedShrineTemplatePayload struct {
 	Name              string `json:"name"`
 	BlessingName      string `json:"blessingName"`
@@ -72,13 +41,15 @@ func generateShrineTemplatesForZoneKind(
 		return nil, fmt.Errorf("failed to load shrine template zone kind: %w", err)
 	}
 
-	prompt := fmt.Sprintf(
-		shrineTemplateGenerationPromptTemplate,
-		count,
-		buildRecentShrineTemplateAvoidance(ctx, dbClient, rawZoneKind, 12),
-		count,
-	)
-	if zoneKindBlock := zoneKindInstructionBlock(zoneKind); zoneKindBlock != "" {
+	resolved, err := db.ResolvePrompt(ctx, dbClient, models.PromptKeyShrineTemplateGeneration, nil, rawZoneKind, map[string]interface{}{
+		"Count":           count,
+		"RecentTemplates": buildRecentShrineTemplateAvoidance(ctx, dbClient, rawZoneKind, 12),
+	})
+	if err != nil {
+		return nil, err
+	}
+	prompt := resolved.Text
+	if zoneKindBlock := zoneKindInstructionBlock(ctx, dbClient, zoneKind); zoneKindBlock != "" {
 		prompt = strings.TrimSpace(zoneKindBlock + "\n\n" + prompt)
 	}
 
@@ -99,6 +70,7 @@ func generateShrineTemplatesForZoneKind(
 		if err := dbClient.ShrineTemplate().Create(ctx, &next); err != nil {
 			return created, fmt.Errorf("failed to create shrine template: %w", err)
 		}
+		db.RecordGeneratedPrompt(ctx, dbClient, resolved, models.GeneratedRecordTypeShrineTemplate, next.ID)
 		created = append(created, next)
 	}
 	return created, nil
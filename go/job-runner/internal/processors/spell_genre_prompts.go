@@ -10,8 +10,6 @@ import (
 	"github.com/google/uuid"
 )
 
-const nonFantasySpellIconPromptTemplate = "A retro 16-bit RPG %s icon for %s. School: %s. %s. %s. Genre direction: %s. Make the icon unmistakably %s rather than default fantasy. No characters, no text, no logos, transparent background, centered composition, crisp outlines, limited palette."
-
 func loadSpellGenre(
 	ctx context.Context,
 	dbClient db.DbClient,
@@ -90,6 +88,8 @@ func spellAbilityTypePromptLabel(
 }
 
 func spellAbilityGenreInstructionBlock(
+	ctx context.Context,
+	dbClient db.DbClient,
 	genre *models.ZoneGenre,
 	abilityType models.SpellAbilityType,
 ) string {
@@ -104,34 +104,27 @@ func spellAbilityGenreInstructionBlock(
 			spellGenrePromptLabel(genre),
 		)
 	}
-	return fmt.Sprintf(
-		`Genre direction:
-- genre: %s
-- creative seed: %s
-
-Additional rules:
-- Override the default fantasy RPG baseline when needed; the generated %s should feel authentically %s.
-- Favor names, schools, motifs, effects, silhouettes, and combat framing that fit %s conventions.
-- Keep the results playable inside the current RPG mechanics even when the fiction shifts genres.
-`,
-		spellGenrePromptLabel(genre),
-		promptSeed,
-		spellAbilityTypePromptLabel(abilityType, true),
-		spellGenrePromptLabel(genre),
-		spellGenrePromptLabel(genre),
-	)
+	return resolvePromptBlock(ctx, dbClient, models.PromptKeySpellGenreDirection, &genre.ID, "", map[string]interface{}{
+		"Genre":        spellGenrePromptLabel(genre),
+		"CreativeSeed": promptSeed,
+		"Abilities":    spellAbilityTypePromptLabel(abilityType, true),
+	}).Text
 }
 
+// spellIconPrompt returns the icon prompt and, outside baseline fantasy, the
+// registry prompt it was resolved from.
 func spellIconPrompt(
+	ctx context.Context,
+	dbClient db.DbClient,
 	name string,
 	description string,
 	school string,
 	effectText string,
 	abilityType models.SpellAbilityType,
 	genre *models.ZoneGenre,
-) string {
+) (string, db.ResolvedPrompt) {
 	if isBaselineFantasySpellGenre(genre) {
-		return fmt.Sprintf(spellIconPromptTemplate, name, school, description, effectText)
+		return fmt.Sprintf(spellIconPromptTemplate, name, school, description, effectText), db.ResolvedPrompt{}
 	}
 	promptSeed := spellGenrePromptSeed(genre)
 	if promptSeed == "" {
@@ -140,14 +133,17 @@ func spellIconPrompt(
 			spellGenrePromptLabel(genre),
 		)
 	}
-	return fmt.Sprintf(
-		nonFantasySpellIconPromptTemplate,
-		spellAbilityTypePromptLabel(abilityType, false),
-		name,
-		school,
-		description,
-		effectText,
-		promptSeed,
-		spellGenrePromptLabel(genre),
-	)
+	resolved := resolvePromptBlock(ctx, dbClient, models.PromptKeySpellGenreIcon, &genre.ID, "", map[string]interface{}{
+		"AbilityType":  spellAbilityTypePromptLabel(abilityType, false),
+		"Name":         name,
+		"School":       school,
+		"Description":  description,
+		"EffectText":   effectText,
+		"CreativeSeed": promptSeed,
+		"Genre":        spellGenrePromptLabel(genre),
+	})
+	if resolved.Text == "" {
+		return fmt.Sprintf(spellIconPromptTemplate, name, school, description, effectText), db.ResolvedPrompt{}
+	}
+	return resolved.Text, resolved
 }
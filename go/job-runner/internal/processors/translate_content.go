@@ -0,0 +1,211 @@
+package processors
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/db"
+	"github.com/MaxBlaushild/poltergeist/pkg/deep_priest"
+	"github.com/MaxBlaushild/poltergeist/pkg/jobs"
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/hibiken/asynq"
+)
+
+// translationBatchSize caps how many fields go into one request so long
+// tutorial scripts do not produce replies that get cut off.
+const translationBatchSize = 40
+
+type TranslateContentProcessor struct {
+	dbClient         db.DbClient
+	deepPriestClient deep_priest.DeepPriest
+}
+
+func NewTranslateContentProcessor(
+	dbClient db.DbClient,
+	deepPriestClient deep_priest.DeepPriest,
+) TranslateContentProcessor {
+	log.Println("Initializing TranslateContentProcessor")
+	return TranslateContentProcessor{
+		dbClient:         dbClient,
+		deepPriestClient: deepPriestClient,
+	}
+}
+
+func (p *TranslateContentProcessor) ProcessTask(ctx context.Context, task *asynq.Task) error {
+	log.Printf("Processing translate content task: %v", task.Type())
+
+	var payload jobs.TranslateContentTaskPayload
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %w", err)
+	}
+
+	source, err := p.dbClient.Localization().FindTranslationSource(ctx, payload.EntityType, payload.EntityID)
+	if err != nil {
+		return err
+	}
+	if source == nil {
+		log.Printf("Translatable %s %s not found", payload.EntityType, payload.EntityID)
+		return nil
+	}
+
+	locales, err := p.targetLocales(ctx, payload.Locales)
+	if err != nil {
+		return err
+	}
+	sourceText := source.SourceText()
+	if len(locales) == 0 || len(sourceText) == 0 {
+		return nil
+	}
+
+	existing, err := p.dbClient.Localization().FindTranslations(ctx, source.EntityType, []string{source.EntityID}, localeCodes(locales))
+	if err != nil {
+		return fmt.Errorf("failed to load existing translations: %w", err)
+	}
+
+	for _, locale := range locales {
+		pending := pendingTranslationFields(sourceText, existing, locale.Code, payload.Force)
+		if len(pending) == 0 {
+			continue
+		}
+		glossary, err := p.dbClient.Localization().FindGlossary(ctx, source.GenreID, locale.Code)
+		if err != nil {
+			return fmt.Errorf("failed to load %s glossary: %w", locale.Code, err)
+		}
+		if err := p.translateLocale(ctx, source, locale, glossary, sourceText, pending); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// targetLocales narrows the enabled machine-translated locales to the ones
+// requested, if any were.
+func (p *TranslateContentProcessor) targetLocales(ctx context.Context, requested []string) ([]models.TranslationLocale, error) {
+	enabled, err := p.dbClient.Localization().FindEnabledLocales(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load locales: %w", err)
+	}
+	wanted := map[string]bool{}
+	for _, code := range requested {
+		if normalized := models.NormalizeLocale(code); normalized != "" {
+			wanted[normalized] = true
+		}
+	}
+	locales := make([]models.TranslationLocale, 0, len(enabled))
+	for _, locale := range enabled {
+		if !locale.MachineTranslate || locale.Code == models.DefaultLocale {
+			continue
+		}
+		if len(wanted) > 0 && !wanted[locale.Code] {
+			continue
+		}
+		locales = append(locales, locale)
+	}
+	return locales, nil
+}
+
+func (p *TranslateContentProcessor) translateLocale(
+	ctx context.Context,
+	source *models.TranslationSource,
+	locale models.TranslationLocale,
+	glossary []models.TranslationGlossaryTerm,
+	sourceText map[string]string,
+	fields []string,
+) error {
+	localeName := strings.TrimSpace(locale.Name)
+	if localeName == "" {
+		localeName = locale.Code
+	}
+	genreID := source.GenreID
+	for start := 0; start < len(fields); start += translationBatchSize {
+		batch := fields[start:min(start+translationBatchSize, len(fields))]
+		input := make(map[string]string, len(batch))
+		for _, field := range batch {
+			input[field] = sourceText[field]
+		}
+		encoded, err := json.MarshalIndent(input, "", "  ")
+		if err != nil {
+			return err
+		}
+		prompt, err := db.ResolvePrompt(ctx, p.dbClient, models.PromptKeyContentTranslation, &genreID, "", map[string]interface{}{
+			"Locale":     locale.Code,
+			"LocaleName": localeName,
+			"Glossary":   models.GlossaryPromptSection(glossary),
+			"Fields":     string(encoded),
+		})
+		if err != nil {
+			return err
+		}
+		answer, err := p.deepPriestClient.PetitionTheFount(&deep_priest.Question{Question: prompt.Text})
+		if err != nil {
+			return fmt.Errorf("failed to translate %s %s into %s: %w", source.EntityType, source.EntityID, locale.Code, err)
+		}
+		var translated map[string]string
+		if err := json.Unmarshal([]byte(extractGeneratedJSONObject(answer.Answer)), &translated); err != nil {
+			return fmt.Errorf("failed to parse %s translation: %w", locale.Code, err)
+		}
+
+		rows := make([]models.ContentTranslation, 0, len(batch))
+		for _, field := range batch {
+			text := strings.TrimSpace(translated[field])
+			if text == "" {
+				log.Printf("[localization][translate] missing field entity_type=%s entity_id=%s locale=%s field=%s", source.EntityType, source.EntityID, locale.Code, field)
+				continue
+			}
+			rows = append(rows, models.ContentTranslation{
+				EntityType: source.EntityType,
+				EntityID:   source.EntityID,
+				Locale:     locale.Code,
+				Field:      field,
+				Text:       text,
+				SourceHash: models.TranslationSourceHash(sourceText[field]),
+			})
+		}
+		if err := p.dbClient.Localization().SaveMachineTranslations(ctx, rows); err != nil {
+			return fmt.Errorf("failed to save %s translations: %w", locale.Code, err)
+		}
+	}
+	return nil
+}
+
+// pendingTranslationFields lists the fields a locale still needs: ones with
+// no translation and ones whose machine translation was made from text
+// that has since changed. Manual overrides are never retranslated.
+func pendingTranslationFields(
+	sourceText map[string]string,
+	existing []models.ContentTranslation,
+	locale string,
+	force bool,
+) []string {
+	current := map[string]models.ContentTranslation{}
+	for _, translation := range existing {
+		if translation.Locale == locale {
+			current[translation.Field] = translation
+		}
+	}
+	fields := make([]string, 0, len(sourceText))
+	for field, text := range sourceText {
+		translation, ok := current[field]
+		switch {
+		case !ok:
+			fields = append(fields, field)
+		case translation.Source == models.ContentTranslationSourceManual:
+		case force || !translation.Current(text):
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+func localeCodes(locales []models.TranslationLocale) []string {
+	codes := make([]string, 0, len(locales))
+	for _, locale := range locales {
+		codes = append(codes, locale.Code)
+	}
+	return codes
+}
@@ -0,0 +1,33 @@
+package processors
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+)
+
+func TestPendingTranslationFieldsSkipsCurrentAndManual(t *testing.T) {
+	sourceText := map[string]string{
+		"name":        "Ember Blade",
+		"flavorText":  "Still warm from the forge.",
+		"effectText":  "Deals fire damage.",
+		"description": "A blade.",
+	}
+	existing := []models.ContentTranslation{
+		{Locale: "fr", Field: "name", Source: models.ContentTranslationSourceMachine, SourceHash: models.TranslationSourceHash("Ember Blade")},
+		{Locale: "fr", Field: "flavorText", Source: models.ContentTranslationSourceMachine, SourceHash: models.TranslationSourceHash("Cold from the forge.")},
+		{Locale: "fr", Field: "effectText", Source: models.ContentTranslationSourceManual},
+		{Locale: "de", Field: "description", Source: models.ContentTranslationSourceMachine, SourceHash: models.TranslationSourceHash("A blade.")},
+	}
+
+	got := pendingTranslationFields(sourceText, existing, "fr", false)
+	if want := []string{"description", "flavorText"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+
+	got = pendingTranslationFields(sourceText, existing, "fr", true)
+	if want := []string{"description", "flavorText", "name"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected forced run to keep manual overrides, got %v", got)
+	}
+}
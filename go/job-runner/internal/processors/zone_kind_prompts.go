@@ -33,7 +33,13 @@ func loadOptionalZoneKind(
 	return zoneKind, nil
 }
 
-func zoneKindInstructionBlock(zoneKind *models.ZoneKind) string {
+// zoneKindInstructionBlock is the zone kind direction prepended to generation
+// prompts, resolved from the registry for the zone kind.
+func zoneKindInstructionBlock(
+	ctx context.Context,
+	dbClient db.DbClient,
+	zoneKind *models.ZoneKind,
+) string {
 	if zoneKind == nil {
 		return ""
 	}
@@ -52,19 +58,10 @@ func zoneKindInstructionBlock(zoneKind *models.ZoneKind) string {
 			strings.ToLower(label),
 		)
 	}
-	return fmt.Sprintf(
-		`Zone kind direction:
-- zone kind: %s
-- slug: %s
-- creative seed: %s
-
-Additional rules:
-- The content should feel naturally suited to %s zones while remaining reusable across many places of that kind.
-- Let the environment influence props, hazards, traversal, factions, and scene logic.
-`,
-		label,
-		slug,
-		seed,
-		strings.ToLower(label),
-	)
+	return resolvePromptBlock(ctx, dbClient, models.PromptKeyZoneKindDirection, nil, slug, map[string]interface{}{
+		"ZoneKind":      label,
+		"ZoneKindSlug":  slug,
+		"CreativeSeed":  seed,
+		"ZoneKindLower": strings.ToLower(label),
+	}).Text
 }
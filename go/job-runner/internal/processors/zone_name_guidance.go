@@ -2,12 +2,12 @@ package processors
 
 import (
 	"context"
-	"fmt"
 	"log"
 	"sort"
 	"strings"
 
 	"github.com/MaxBlaushild/poltergeist/pkg/db"
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
 	"github.com/google/uuid"
 )
 
@@ -69,24 +69,13 @@ func buildZoneNameDiversityContext(
 	}
 	sort.Strings(overusedLeadingRoots)
 
-	lines := []string{}
-	if len(existingNames) > 0 {
-		lines = append(lines, fmt.Sprintf("- Existing zone names: %s", strings.Join(existingNames, ", ")))
-	} else {
-		lines = append(lines, "- Existing zone names: none yet")
-	}
-
-	if len(overusedLeadingRoots) > 0 {
-		lines = append(
-			lines,
-			fmt.Sprintf("- Avoid starting the new name with these overused opening roots or variants: %s", strings.Join(overusedLeadingRoots, ", ")),
-		)
-	} else {
-		lines = append(lines, "- No repeated opening word is currently dominant, but still avoid cliché repeated prefixes.")
-	}
+	guidance := resolvePromptBlock(ctx, dbClient, models.PromptKeyZoneNameGuidance, nil, "", map[string]interface{}{
+		"ExistingZoneNames": strings.Join(existingNames, ", "),
+		"OverusedRoots":     strings.Join(overusedLeadingRoots, ", "),
+	})
 
 	return zoneNameDiversityContext{
-		Guidance:              strings.Join(lines, "\n"),
+		Guidance:              guidance.Text,
 		ForbiddenLeadingRoots: overusedLeadingRoots,
 	}
 }
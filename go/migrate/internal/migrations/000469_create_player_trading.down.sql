@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_market_listings_inventory_item_id;
+DROP INDEX IF EXISTS idx_market_listings_seller_status;
+DROP INDEX IF EXISTS idx_market_listings_zone_status_expires;
+DROP TABLE IF EXISTS market_listings;
+DROP INDEX IF EXISTS idx_trade_offer_items_trade_offer_id;
+DROP TABLE IF EXISTS trade_offer_items;
+DROP INDEX IF EXISTS idx_trade_offers_recipient_status;
+DROP INDEX IF EXISTS idx_trade_offers_initiator_status;
+DROP TABLE IF EXISTS trade_offers;
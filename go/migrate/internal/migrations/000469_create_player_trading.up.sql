@@ -0,0 +1,65 @@
+CREATE TABLE trade_offers (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  initiator_user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  recipient_user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  initiator_gold INTEGER NOT NULL DEFAULT 0 CHECK (initiator_gold >= 0),
+  recipient_gold INTEGER NOT NULL DEFAULT 0 CHECK (recipient_gold >= 0),
+  status TEXT NOT NULL DEFAULT 'pending' CHECK (
+    status IN ('pending', 'completed', 'declined', 'cancelled', 'expired')
+  ),
+  initiator_confirmed_at TIMESTAMP WITH TIME ZONE,
+  recipient_confirmed_at TIMESTAMP WITH TIME ZONE,
+  completed_at TIMESTAMP WITH TIME ZONE,
+  expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  CHECK (initiator_user_id <> recipient_user_id)
+);
+
+CREATE INDEX idx_trade_offers_initiator_status
+  ON trade_offers(initiator_user_id, status);
+
+CREATE INDEX idx_trade_offers_recipient_status
+  ON trade_offers(recipient_user_id, status);
+
+CREATE TABLE trade_offer_items (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  trade_offer_id UUID NOT NULL REFERENCES trade_offers(id) ON DELETE CASCADE,
+  owner_user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  inventory_item_id INTEGER NOT NULL REFERENCES inventory_items(id) ON DELETE CASCADE,
+  quantity INTEGER NOT NULL CHECK (quantity > 0),
+  UNIQUE (trade_offer_id, owner_user_id, inventory_item_id)
+);
+
+CREATE INDEX idx_trade_offer_items_trade_offer_id
+  ON trade_offer_items(trade_offer_id);
+
+CREATE TABLE market_listings (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  zone_id UUID NOT NULL REFERENCES zones(id) ON DELETE CASCADE,
+  seller_user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  buyer_user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+  inventory_item_id INTEGER NOT NULL REFERENCES inventory_items(id) ON DELETE CASCADE,
+  quantity INTEGER NOT NULL CHECK (quantity > 0),
+  price INTEGER NOT NULL CHECK (price > 0),
+  listing_fee INTEGER NOT NULL DEFAULT 0 CHECK (listing_fee >= 0),
+  status TEXT NOT NULL DEFAULT 'active' CHECK (
+    status IN ('active', 'sold', 'cancelled', 'expired')
+  ),
+  expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+  sold_at TIMESTAMP WITH TIME ZONE,
+  closed_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_market_listings_zone_status_expires
+  ON market_listings(zone_id, status, expires_at);
+
+CREATE INDEX idx_market_listings_seller_status
+  ON market_listings(seller_user_id, status);
+
+CREATE INDEX idx_market_listings_inventory_item_id
+  ON market_listings(inventory_item_id);
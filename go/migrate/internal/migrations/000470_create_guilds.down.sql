@@ -0,0 +1,12 @@
+DROP INDEX IF EXISTS idx_guild_quest_node_completions_guild_quest;
+DROP TABLE IF EXISTS guild_quest_node_completions;
+DROP INDEX IF EXISTS idx_guild_audit_entries_guild_created_at;
+DROP TABLE IF EXISTS guild_audit_entries;
+DROP TABLE IF EXISTS guild_bank_items;
+DROP INDEX IF EXISTS idx_guild_invites_invitee_status;
+DROP INDEX IF EXISTS idx_guild_invites_pending_guild_invitee;
+DROP TABLE IF EXISTS guild_invites;
+DROP INDEX IF EXISTS idx_guild_members_guild_id;
+DROP TABLE IF EXISTS guild_members;
+DROP INDEX IF EXISTS idx_guilds_lower_name;
+DROP TABLE IF EXISTS guilds;
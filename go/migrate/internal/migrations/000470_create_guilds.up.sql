@@ -0,0 +1,88 @@
+CREATE TABLE guilds (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  name TEXT NOT NULL,
+  tag TEXT NOT NULL DEFAULT '',
+  description TEXT NOT NULL DEFAULT '',
+  leader_user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  gold INTEGER NOT NULL DEFAULT 0 CHECK (gold >= 0),
+  member_withdrawals_enabled BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+CREATE UNIQUE INDEX idx_guilds_lower_name
+  ON guilds(LOWER(name));
+
+CREATE TABLE guild_members (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  guild_id UUID NOT NULL REFERENCES guilds(id) ON DELETE CASCADE,
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  role TEXT NOT NULL DEFAULT 'member' CHECK (
+    role IN ('leader', 'officer', 'member')
+  ),
+  UNIQUE (user_id)
+);
+
+CREATE INDEX idx_guild_members_guild_id
+  ON guild_members(guild_id);
+
+CREATE TABLE guild_invites (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  guild_id UUID NOT NULL REFERENCES guilds(id) ON DELETE CASCADE,
+  inviter_user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  invitee_user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  status TEXT NOT NULL DEFAULT 'pending' CHECK (
+    status IN ('pending', 'accepted', 'declined', 'cancelled')
+  ),
+  responded_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX idx_guild_invites_pending_guild_invitee
+  ON guild_invites(guild_id, invitee_user_id)
+  WHERE status = 'pending';
+
+CREATE INDEX idx_guild_invites_invitee_status
+  ON guild_invites(invitee_user_id, status);
+
+CREATE TABLE guild_bank_items (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  guild_id UUID NOT NULL REFERENCES guilds(id) ON DELETE CASCADE,
+  inventory_item_id INTEGER NOT NULL REFERENCES inventory_items(id) ON DELETE CASCADE,
+  quantity INTEGER NOT NULL CHECK (quantity > 0),
+  UNIQUE (guild_id, inventory_item_id)
+);
+
+CREATE TABLE guild_audit_entries (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  guild_id UUID NOT NULL REFERENCES guilds(id) ON DELETE CASCADE,
+  actor_user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+  target_user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+  action TEXT NOT NULL,
+  inventory_item_id INTEGER REFERENCES inventory_items(id) ON DELETE SET NULL,
+  quantity INTEGER NOT NULL DEFAULT 0,
+  gold INTEGER NOT NULL DEFAULT 0,
+  details TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX idx_guild_audit_entries_guild_created_at
+  ON guild_audit_entries(guild_id, created_at DESC);
+
+CREATE TABLE guild_quest_node_completions (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  guild_id UUID NOT NULL REFERENCES guilds(id) ON DELETE CASCADE,
+  quest_id UUID NOT NULL REFERENCES quests(id) ON DELETE CASCADE,
+  quest_node_id UUID NOT NULL REFERENCES quest_nodes(id) ON DELETE CASCADE,
+  completed_by_user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+  UNIQUE (guild_id, quest_node_id)
+);
+
+CREATE INDEX idx_guild_quest_node_completions_guild_quest
+  ON guild_quest_node_completions(guild_id, quest_id);
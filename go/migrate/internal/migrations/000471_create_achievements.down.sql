@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_user_titles_active_user;
+DROP TABLE IF EXISTS user_titles;
+DROP INDEX IF EXISTS idx_user_achievements_user_unlocked;
+DROP TABLE IF EXISTS user_achievements;
+DROP INDEX IF EXISTS idx_achievement_definitions_metric_active;
+DROP TABLE IF EXISTS achievement_definitions;
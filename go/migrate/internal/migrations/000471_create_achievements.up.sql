@@ -0,0 +1,67 @@
+CREATE TABLE achievement_definitions (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  key TEXT NOT NULL UNIQUE,
+  name TEXT NOT NULL,
+  description TEXT NOT NULL DEFAULT '',
+  icon_url TEXT NOT NULL DEFAULT '',
+  metric TEXT NOT NULL CHECK (
+    metric IN (
+      'challenge_completions',
+      'monster_encounter_victories',
+      'treasure_chests_opened',
+      'shrine_uses',
+      'healing_fountains_discovered',
+      'zones_discovered',
+      'user_level'
+    )
+  ),
+  threshold INTEGER NOT NULL CHECK (threshold > 0),
+  zone_id UUID REFERENCES zones(id) ON DELETE SET NULL,
+  zone_kind TEXT NOT NULL DEFAULT '',
+  genre_id UUID REFERENCES zone_genres(id) ON DELETE SET NULL,
+  hidden BOOLEAN NOT NULL DEFAULT FALSE,
+  active BOOLEAN NOT NULL DEFAULT TRUE,
+  sort_order INTEGER NOT NULL DEFAULT 0,
+  title_name TEXT NOT NULL DEFAULT '',
+  reward_mode TEXT NOT NULL DEFAULT 'explicit',
+  random_reward_size TEXT NOT NULL DEFAULT 'small',
+  reward_experience INTEGER NOT NULL DEFAULT 0 CHECK (reward_experience >= 0),
+  reward_gold INTEGER NOT NULL DEFAULT 0 CHECK (reward_gold >= 0),
+  item_rewards_json JSONB NOT NULL DEFAULT '[]'
+);
+
+CREATE INDEX idx_achievement_definitions_metric_active
+  ON achievement_definitions(metric, active);
+
+CREATE TABLE user_achievements (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  achievement_id UUID NOT NULL REFERENCES achievement_definitions(id) ON DELETE CASCADE,
+  progress INTEGER NOT NULL DEFAULT 0,
+  unlocked_at TIMESTAMP WITH TIME ZONE,
+  rewards_granted_at TIMESTAMP WITH TIME ZONE,
+  backfilled BOOLEAN NOT NULL DEFAULT FALSE,
+  UNIQUE (user_id, achievement_id)
+);
+
+CREATE INDEX idx_user_achievements_user_unlocked
+  ON user_achievements(user_id, unlocked_at);
+
+CREATE TABLE user_titles (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  achievement_id UUID REFERENCES achievement_definitions(id) ON DELETE SET NULL,
+  title TEXT NOT NULL,
+  active BOOLEAN NOT NULL DEFAULT FALSE,
+  UNIQUE (user_id, title)
+);
+
+CREATE UNIQUE INDEX idx_user_titles_active_user
+  ON user_titles(user_id)
+  WHERE active;
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS user_bounty_streaks;
+DROP INDEX IF EXISTS idx_user_bounty_progress_user_zone_period;
+DROP TABLE IF EXISTS user_bounty_progress;
+DROP INDEX IF EXISTS idx_bounty_templates_cadence_active;
+DROP TABLE IF EXISTS bounty_templates;
@@ -0,0 +1,58 @@
+CREATE TABLE bounty_templates (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  name TEXT NOT NULL,
+  description TEXT NOT NULL DEFAULT '',
+  cadence TEXT NOT NULL CHECK (cadence IN ('daily', 'weekly')),
+  objective_type TEXT NOT NULL CHECK (
+    objective_type IN (
+      'defeat_monsters',
+      'open_treasure_chests',
+      'complete_scenarios',
+      'complete_challenges'
+    )
+  ),
+  target_count INTEGER NOT NULL CHECK (target_count > 0),
+  monster_affinity TEXT NOT NULL DEFAULT '',
+  point_of_interest_tag TEXT NOT NULL DEFAULT '',
+  zone_id UUID REFERENCES zones(id) ON DELETE CASCADE,
+  zone_kind TEXT NOT NULL DEFAULT '',
+  weight INTEGER NOT NULL DEFAULT 1 CHECK (weight > 0),
+  random_reward_size TEXT NOT NULL DEFAULT 'small',
+  active BOOLEAN NOT NULL DEFAULT TRUE
+);
+
+CREATE INDEX idx_bounty_templates_cadence_active
+  ON bounty_templates(cadence, active);
+
+CREATE TABLE user_bounty_progress (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  zone_id UUID NOT NULL REFERENCES zones(id) ON DELETE CASCADE,
+  bounty_template_id UUID NOT NULL REFERENCES bounty_templates(id) ON DELETE CASCADE,
+  cadence TEXT NOT NULL,
+  period_start TIMESTAMP WITH TIME ZONE NOT NULL,
+  progress INTEGER NOT NULL DEFAULT 0 CHECK (progress >= 0),
+  target_count INTEGER NOT NULL CHECK (target_count > 0),
+  completed_at TIMESTAMP WITH TIME ZONE,
+  rewarded_at TIMESTAMP WITH TIME ZONE,
+  UNIQUE (user_id, zone_id, bounty_template_id, period_start)
+);
+
+CREATE INDEX idx_user_bounty_progress_user_zone_period
+  ON user_bounty_progress(user_id, zone_id, period_start);
+
+CREATE TABLE user_bounty_streaks (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  cadence TEXT NOT NULL,
+  current_streak INTEGER NOT NULL DEFAULT 0,
+  longest_streak INTEGER NOT NULL DEFAULT 0,
+  last_period_start TIMESTAMP WITH TIME ZONE,
+  UNIQUE (user_id, cadence)
+);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_spawn_rules_active;
+DROP TABLE IF EXISTS spawn_rules;
@@ -0,0 +1,25 @@
+CREATE TABLE spawn_rules (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  name TEXT NOT NULL,
+  zone_kind TEXT NOT NULL DEFAULT '',
+  genre_id UUID REFERENCES zone_genres(id) ON DELETE CASCADE,
+  target_type TEXT NOT NULL CHECK (
+    target_type IN ('monster_template', 'monster_affinity', 'scenario_kind')
+  ),
+  target_value TEXT NOT NULL,
+  start_hour INTEGER CHECK (start_hour BETWEEN 0 AND 23),
+  end_hour INTEGER CHECK (end_hour BETWEEN 0 AND 24),
+  days_of_week JSONB NOT NULL DEFAULT '[]',
+  moon_phases JSONB NOT NULL DEFAULT '[]',
+  weather JSONB NOT NULL DEFAULT '[]',
+  festival_dates JSONB NOT NULL DEFAULT '[]',
+  active_from TIMESTAMP WITH TIME ZONE,
+  active_until TIMESTAMP WITH TIME ZONE,
+  weight_multiplier DOUBLE PRECISION NOT NULL DEFAULT 1 CHECK (weight_multiplier >= 0),
+  required BOOLEAN NOT NULL DEFAULT FALSE,
+  active BOOLEAN NOT NULL DEFAULT TRUE
+);
+
+CREATE INDEX idx_spawn_rules_active ON spawn_rules(active);
@@ -0,0 +1,3 @@
+ALTER TABLE zone_imports DROP COLUMN IF EXISTS osm_extract_path, DROP COLUMN IF EXISTS source;
+DROP INDEX IF EXISTS idx_points_of_interest_osm_id;
+ALTER TABLE points_of_interest DROP COLUMN IF EXISTS osm_id, DROP COLUMN IF EXISTS source;
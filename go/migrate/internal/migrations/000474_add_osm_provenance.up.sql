@@ -0,0 +1,11 @@
+ALTER TABLE points_of_interest
+  ADD COLUMN source TEXT NOT NULL DEFAULT 'manual' CHECK (source IN ('manual', 'google', 'osm')),
+  ADD COLUMN osm_id TEXT;
+
+UPDATE points_of_interest SET source = 'google' WHERE google_maps_place_id IS NOT NULL;
+
+CREATE UNIQUE INDEX idx_points_of_interest_osm_id ON points_of_interest(osm_id) WHERE osm_id IS NOT NULL;
+
+ALTER TABLE zone_imports
+  ADD COLUMN source TEXT NOT NULL DEFAULT 'overpass' CHECK (source IN ('overpass', 'osm_extract')),
+  ADD COLUMN osm_extract_path TEXT;
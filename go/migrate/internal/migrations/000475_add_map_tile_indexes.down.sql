@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_points_of_interest_geometry;
+DROP INDEX IF EXISTS idx_zones_boundary;
@@ -0,0 +1,3 @@
+-- Vector tiles look zones and points of interest up by envelope.
+CREATE INDEX IF NOT EXISTS idx_zones_boundary ON zones USING GIST(boundary);
+CREATE INDEX IF NOT EXISTS idx_points_of_interest_geometry ON points_of_interest USING GIST(geometry);
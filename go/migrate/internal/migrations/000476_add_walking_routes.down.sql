@@ -0,0 +1,10 @@
+ALTER TABLE quests
+  DROP COLUMN IF EXISTS estimated_walking_minutes,
+  DROP COLUMN IF EXISTS estimated_walking_meters;
+
+ALTER TABLE quest_archetypes
+  DROP COLUMN IF EXISTS max_walking_minutes,
+  DROP COLUMN IF EXISTS max_walking_distance_meters;
+
+DROP TABLE IF EXISTS walking_graph_edges;
+DROP TABLE IF EXISTS walking_graph_nodes;
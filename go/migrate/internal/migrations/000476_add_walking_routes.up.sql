@@ -0,0 +1,32 @@
+CREATE TABLE IF NOT EXISTS walking_graph_nodes (
+  id BIGINT PRIMARY KEY,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  latitude DOUBLE PRECISION NOT NULL,
+  longitude DOUBLE PRECISION NOT NULL,
+  geometry geometry(Point, 4326) NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_walking_graph_nodes_geometry
+  ON walking_graph_nodes USING GIST (geometry);
+
+CREATE TABLE IF NOT EXISTS walking_graph_edges (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  from_node_id BIGINT NOT NULL REFERENCES walking_graph_nodes(id) ON DELETE CASCADE,
+  to_node_id BIGINT NOT NULL REFERENCES walking_graph_nodes(id) ON DELETE CASCADE,
+  osm_way_id BIGINT NOT NULL,
+  highway TEXT NOT NULL DEFAULT '',
+  length_meters DOUBLE PRECISION NOT NULL CHECK (length_meters >= 0),
+  CONSTRAINT walking_graph_edges_way_nodes_unique UNIQUE (osm_way_id, from_node_id, to_node_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_walking_graph_edges_from_node ON walking_graph_edges (from_node_id);
+CREATE INDEX IF NOT EXISTS idx_walking_graph_edges_to_node ON walking_graph_edges (to_node_id);
+
+ALTER TABLE quest_archetypes
+  ADD COLUMN IF NOT EXISTS max_walking_distance_meters INTEGER NOT NULL DEFAULT 0,
+  ADD COLUMN IF NOT EXISTS max_walking_minutes INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE quests
+  ADD COLUMN IF NOT EXISTS estimated_walking_meters INTEGER NOT NULL DEFAULT 0,
+  ADD COLUMN IF NOT EXISTS estimated_walking_minutes INTEGER NOT NULL DEFAULT 0;
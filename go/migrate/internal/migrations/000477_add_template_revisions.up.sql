@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS template_revisions (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  template_type TEXT NOT NULL,
+  template_id UUID NOT NULL,
+  revision INTEGER NOT NULL CHECK (revision > 0),
+  status TEXT NOT NULL DEFAULT 'draft' CHECK (status IN ('draft', 'published')),
+  note TEXT NOT NULL DEFAULT '',
+  author_user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+  published_by_user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+  published_at TIMESTAMP WITH TIME ZONE,
+  restored_from_revision INTEGER,
+  snapshot JSONB NOT NULL,
+  diff JSONB NOT NULL DEFAULT '{}'::jsonb,
+  CONSTRAINT template_revisions_template_revision_unique UNIQUE (template_type, template_id, revision)
+);
+
+CREATE INDEX IF NOT EXISTS idx_template_revisions_published
+  ON template_revisions (template_type, template_id, published_at DESC)
+  WHERE status = 'published';
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS prompt_evaluation_outputs;
+DROP TABLE IF EXISTS prompt_evaluations;
+DROP TABLE IF EXISTS generated_record_prompts;
+DROP TABLE IF EXISTS prompt_templates;
@@ -0,0 +1,67 @@
+CREATE TABLE IF NOT EXISTS prompt_templates (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  prompt_key TEXT NOT NULL,
+  genre_id UUID REFERENCES zone_genres(id) ON DELETE CASCADE,
+  zone_kind TEXT NOT NULL DEFAULT '',
+  version INTEGER NOT NULL CHECK (version > 0),
+  body TEXT NOT NULL,
+  notes TEXT NOT NULL DEFAULT '',
+  active BOOLEAN NOT NULL DEFAULT FALSE,
+  created_by_user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+  CONSTRAINT prompt_templates_key_version_unique UNIQUE (prompt_key, version)
+);
+
+CREATE INDEX IF NOT EXISTS idx_prompt_templates_active
+  ON prompt_templates (prompt_key)
+  WHERE active;
+
+CREATE TABLE IF NOT EXISTS generated_record_prompts (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  record_type TEXT NOT NULL,
+  record_id UUID NOT NULL,
+  prompt_key TEXT NOT NULL,
+  prompt_template_id UUID REFERENCES prompt_templates(id) ON DELETE SET NULL,
+  prompt_version INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_generated_record_prompts_record
+  ON generated_record_prompts (record_type, record_id);
+
+CREATE INDEX IF NOT EXISTS idx_generated_record_prompts_template
+  ON generated_record_prompts (prompt_template_id);
+
+CREATE TABLE IF NOT EXISTS prompt_evaluations (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  prompt_key TEXT NOT NULL,
+  version_a_id UUID REFERENCES prompt_templates(id) ON DELETE CASCADE,
+  version_b_id UUID REFERENCES prompt_templates(id) ON DELETE CASCADE,
+  inputs JSONB NOT NULL DEFAULT '[]'::jsonb,
+  status TEXT NOT NULL DEFAULT 'queued' CHECK (status IN ('queued', 'in_progress', 'completed', 'failed')),
+  error_message TEXT,
+  created_by_user_id UUID REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_prompt_evaluations_created_at
+  ON prompt_evaluations (created_at DESC);
+
+CREATE TABLE IF NOT EXISTS prompt_evaluation_outputs (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  evaluation_id UUID NOT NULL REFERENCES prompt_evaluations(id) ON DELETE CASCADE,
+  input_index INTEGER NOT NULL CHECK (input_index >= 0),
+  variant TEXT NOT NULL CHECK (variant IN ('a', 'b')),
+  prompt_template_id UUID REFERENCES prompt_templates(id) ON DELETE SET NULL,
+  rendered_prompt TEXT NOT NULL DEFAULT '',
+  output TEXT NOT NULL DEFAULT '',
+  error_message TEXT,
+  rating INTEGER CHECK (rating BETWEEN 1 AND 5),
+  rating_comment TEXT NOT NULL DEFAULT '',
+  rated_by_user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+  CONSTRAINT prompt_evaluation_outputs_unique UNIQUE (evaluation_id, input_index, variant)
+);
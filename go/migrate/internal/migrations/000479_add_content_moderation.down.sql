@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS moderation_terms;
+DROP TABLE IF EXISTS content_moderation_items;
@@ -0,0 +1,34 @@
+CREATE TABLE IF NOT EXISTS content_moderation_items (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  content_type TEXT NOT NULL,
+  content_id UUID NOT NULL,
+  status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected', 'regenerating')),
+  summary TEXT NOT NULL DEFAULT '',
+  findings JSONB NOT NULL DEFAULT '[]'::jsonb,
+  image_url TEXT NOT NULL DEFAULT '',
+  image_hash BIGINT,
+  reviewed_by_user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+  reviewed_at TIMESTAMP WITH TIME ZONE,
+  review_note TEXT NOT NULL DEFAULT '',
+  CONSTRAINT content_moderation_items_content_unique UNIQUE (content_type, content_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_content_moderation_items_status
+  ON content_moderation_items (status, created_at);
+
+CREATE INDEX IF NOT EXISTS idx_content_moderation_items_image_hash
+  ON content_moderation_items (content_type)
+  WHERE image_hash IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS moderation_terms (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  term TEXT NOT NULL,
+  kind TEXT NOT NULL CHECK (kind IN ('blocked', 'brand', 'business')),
+  created_by_user_id UUID REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_moderation_terms_term_kind
+  ON moderation_terms (LOWER(term), kind);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS translation_glossary_terms;
+DROP TABLE IF EXISTS content_translations;
+DROP TABLE IF EXISTS translation_locales;
@@ -0,0 +1,37 @@
+CREATE TABLE IF NOT EXISTS translation_locales (
+  code TEXT PRIMARY KEY,
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  name TEXT NOT NULL DEFAULT '',
+  enabled BOOLEAN NOT NULL DEFAULT TRUE,
+  machine_translate BOOLEAN NOT NULL DEFAULT TRUE
+);
+
+CREATE TABLE IF NOT EXISTS content_translations (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  entity_type TEXT NOT NULL,
+  entity_id TEXT NOT NULL,
+  locale TEXT NOT NULL REFERENCES translation_locales(code) ON DELETE CASCADE,
+  field TEXT NOT NULL,
+  text TEXT NOT NULL,
+  source_hash TEXT NOT NULL DEFAULT '',
+  source TEXT NOT NULL DEFAULT 'machine' CHECK (source IN ('manual', 'machine')),
+  edited_by_user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+  CONSTRAINT content_translations_field_unique UNIQUE (entity_type, entity_id, locale, field)
+);
+
+CREATE TABLE IF NOT EXISTS translation_glossary_terms (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  genre_id UUID NOT NULL REFERENCES zone_genres(id) ON DELETE CASCADE,
+  locale TEXT NOT NULL REFERENCES translation_locales(code) ON DELETE CASCADE,
+  term TEXT NOT NULL,
+  translation TEXT NOT NULL,
+  notes TEXT NOT NULL DEFAULT ''
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_translation_glossary_terms_term
+  ON translation_glossary_terms (genre_id, locale, LOWER(term));
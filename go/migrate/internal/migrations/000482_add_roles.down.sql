@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS audit_items_scope_created_at_idx;
+
+ALTER TABLE audit_items
+  DROP COLUMN IF EXISTS ip_address,
+  DROP COLUMN IF EXISTS status_code,
+  DROP COLUMN IF EXISTS path,
+  DROP COLUMN IF EXISTS method,
+  DROP COLUMN IF EXISTS scope;
+
+DROP TABLE IF EXISTS user_roles;
+DROP TABLE IF EXISTS roles;
@@ -0,0 +1,34 @@
+CREATE TABLE IF NOT EXISTS roles (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  name TEXT NOT NULL UNIQUE,
+  description TEXT NOT NULL DEFAULT '',
+  scopes JSONB NOT NULL DEFAULT '[]'::jsonb
+);
+
+CREATE TABLE IF NOT EXISTS user_roles (
+  id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  role_id UUID NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+  granted_by UUID REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS user_roles_user_id_role_id_idx ON user_roles(user_id, role_id);
+
+INSERT INTO roles (name, description, scopes) VALUES
+  ('super-admin', 'Every scope in every service, including granting roles.', '["*"]'::jsonb),
+  ('sonar-admin', 'The sonar admin surface.', '["sonar:*"]'::jsonb),
+  ('reef-operator', 'The reef-site operator console.', '["reef:*"]'::jsonb),
+  ('vampire-library-editor', 'The vampire-ascendancy shared content library.', '["vampire:library:*"]'::jsonb)
+ON CONFLICT (name) DO NOTHING;
+
+ALTER TABLE audit_items
+  ADD COLUMN IF NOT EXISTS scope TEXT,
+  ADD COLUMN IF NOT EXISTS method TEXT,
+  ADD COLUMN IF NOT EXISTS path TEXT,
+  ADD COLUMN IF NOT EXISTS status_code INTEGER,
+  ADD COLUMN IF NOT EXISTS ip_address TEXT;
+
+CREATE INDEX IF NOT EXISTS audit_items_scope_created_at_idx ON audit_items(scope, created_at) WHERE scope IS NOT NULL;
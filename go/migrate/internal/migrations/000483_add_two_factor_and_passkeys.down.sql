@@ -0,0 +1,6 @@
+ALTER TABLE auth_sessions DROP COLUMN IF EXISTS mfa_verified_at;
+
+DROP TABLE IF EXISTS auth_challenges;
+DROP TABLE IF EXISTS webauthn_credentials;
+DROP TABLE IF EXISTS user_recovery_codes;
+DROP TABLE IF EXISTS user_totp;
@@ -0,0 +1 @@
+ALTER TABLE text_verification_codes DROP COLUMN IF EXISTS attempts;
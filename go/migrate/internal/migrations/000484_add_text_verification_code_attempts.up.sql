@@ -0,0 +1 @@
+ALTER TABLE text_verification_codes ADD COLUMN IF NOT EXISTS attempts INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,16 @@
+DROP INDEX IF EXISTS idx_user_certificates_revoked_at;
+DROP INDEX IF EXISTS idx_user_certificates_serial_number;
+
+ALTER TABLE user_certificates DROP COLUMN IF EXISTS renews_certificate_id;
+ALTER TABLE user_certificates DROP COLUMN IF EXISTS revocation_reason;
+ALTER TABLE user_certificates DROP COLUMN IF EXISTS revoked_at;
+ALTER TABLE user_certificates DROP COLUMN IF EXISTS expires_at;
+ALTER TABLE user_certificates DROP COLUMN IF EXISTS serial_number;
+
+-- Keep each user's newest certificate so the one-per-user constraint can
+-- come back.
+DELETE FROM user_certificates older
+USING user_certificates newer
+WHERE older.user_id = newer.user_id
+  AND (older.created_at, older.id) < (newer.created_at, newer.id);
+ALTER TABLE user_certificates ADD CONSTRAINT user_certificates_user_id_key UNIQUE (user_id);
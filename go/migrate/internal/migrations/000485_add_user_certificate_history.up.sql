@@ -0,0 +1,18 @@
+-- Users keep every certificate they've been issued so renewals and
+-- revocations don't erase what older posts were signed with.
+ALTER TABLE user_certificates DROP CONSTRAINT IF EXISTS user_certificates_user_id_key;
+
+ALTER TABLE user_certificates ADD COLUMN IF NOT EXISTS serial_number TEXT;
+ALTER TABLE user_certificates ADD COLUMN IF NOT EXISTS expires_at TIMESTAMP;
+ALTER TABLE user_certificates ADD COLUMN IF NOT EXISTS revoked_at TIMESTAMP;
+ALTER TABLE user_certificates ADD COLUMN IF NOT EXISTS revocation_reason TEXT;
+ALTER TABLE user_certificates ADD COLUMN IF NOT EXISTS renews_certificate_id UUID REFERENCES user_certificates(id) ON DELETE SET NULL;
+
+-- Every certificate issued so far was valid for a year.
+UPDATE user_certificates SET expires_at = created_at + INTERVAL '365 days' WHERE expires_at IS NULL;
+ALTER TABLE user_certificates ALTER COLUMN expires_at SET NOT NULL;
+
+-- Serial numbers for existing rows are filled in from the stored DER by
+-- verifiable-sn at startup.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_certificates_serial_number ON user_certificates(serial_number) WHERE serial_number IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_user_certificates_revoked_at ON user_certificates(revoked_at) WHERE revoked_at IS NOT NULL;
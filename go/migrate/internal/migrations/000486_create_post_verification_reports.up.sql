@@ -0,0 +1,9 @@
+CREATE TABLE post_verification_reports (
+    post_id UUID PRIMARY KEY REFERENCES posts(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    status TEXT NOT NULL,
+    report JSONB NOT NULL
+);
+
+CREATE INDEX idx_post_verification_reports_status ON post_verification_reports(status);
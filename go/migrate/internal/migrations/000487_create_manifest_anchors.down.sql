@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS manifest_anchors;
+DROP TABLE IF EXISTS manifest_anchor_batches;
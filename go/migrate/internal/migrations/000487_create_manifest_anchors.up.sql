@@ -0,0 +1,27 @@
+CREATE TABLE manifest_anchor_batches (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    chain_id BIGINT NOT NULL,
+    contract_address TEXT NOT NULL,
+    merkle_root BYTEA NOT NULL UNIQUE,
+    leaf_count INTEGER NOT NULL,
+    blockchain_transaction_id UUID REFERENCES blockchain_transactions(id) ON DELETE SET NULL
+);
+
+CREATE INDEX idx_manifest_anchor_batches_blockchain_transaction_id ON manifest_anchor_batches(blockchain_transaction_id);
+
+CREATE TABLE manifest_anchors (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    manifest_hash BYTEA NOT NULL UNIQUE,
+    cert_fingerprint BYTEA NOT NULL,
+    leaf_hash BYTEA NOT NULL,
+    batch_id UUID REFERENCES manifest_anchor_batches(id) ON DELETE SET NULL,
+    leaf_index INTEGER,
+    proof JSONB
+);
+
+CREATE INDEX idx_manifest_anchors_batch_id ON manifest_anchors(batch_id);
+CREATE INDEX idx_manifest_anchors_unbatched ON manifest_anchors(created_at) WHERE batch_id IS NULL;
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS blockchain_transaction_events;
+
+ALTER TABLE blockchain_transactions DROP COLUMN IF EXISTS replaced_tx_hashes;
+ALTER TABLE blockchain_transactions DROP COLUMN IF EXISTS broadcast_at;
+ALTER TABLE blockchain_transactions DROP COLUMN IF EXISTS block_hash;
+ALTER TABLE blockchain_transactions DROP COLUMN IF EXISTS gas_fee_cap;
+ALTER TABLE blockchain_transactions DROP COLUMN IF EXISTS gas_tip_cap;
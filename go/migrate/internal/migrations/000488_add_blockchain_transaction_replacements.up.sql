@@ -0,0 +1,23 @@
+ALTER TABLE blockchain_transactions ADD COLUMN IF NOT EXISTS gas_tip_cap TEXT;
+ALTER TABLE blockchain_transactions ADD COLUMN IF NOT EXISTS gas_fee_cap TEXT;
+ALTER TABLE blockchain_transactions ADD COLUMN IF NOT EXISTS block_hash TEXT;
+ALTER TABLE blockchain_transactions ADD COLUMN IF NOT EXISTS broadcast_at TIMESTAMP;
+ALTER TABLE blockchain_transactions ADD COLUMN IF NOT EXISTS replaced_tx_hashes JSONB;
+
+UPDATE blockchain_transactions SET broadcast_at = created_at WHERE broadcast_at IS NULL;
+
+CREATE TABLE blockchain_transaction_events (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    blockchain_transaction_id UUID NOT NULL REFERENCES blockchain_transactions(id) ON DELETE CASCADE,
+    from_status TEXT,
+    to_status TEXT NOT NULL,
+    tx_hash TEXT,
+    gas_price TEXT,
+    gas_tip_cap TEXT,
+    gas_fee_cap TEXT,
+    block_number BIGINT,
+    reason TEXT NOT NULL
+);
+
+CREATE INDEX idx_blockchain_transaction_events_blockchain_transaction_id ON blockchain_transaction_events(blockchain_transaction_id);
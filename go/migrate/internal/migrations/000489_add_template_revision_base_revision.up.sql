@@ -0,0 +1,2 @@
+ALTER TABLE template_revisions
+  ADD COLUMN IF NOT EXISTS base_revision INTEGER;
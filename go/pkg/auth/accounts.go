@@ -0,0 +1,93 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/google/uuid"
+)
+
+// LoginMethodsResponse is every way the user can sign in to their account.
+type LoginMethodsResponse struct {
+	PhoneNumber string  `json:"phoneNumber,omitempty"`
+	Email       *string `json:"email,omitempty"`
+	Password    bool    `json:"password"`
+	Google      bool    `json:"google"`
+	Passkeys    int     `json:"passkeys"`
+}
+
+// LinkPhoneRequest adds a phone number to the signed-in account. The code
+// is one texted by /authenticator/text/verification-code.
+type LinkPhoneRequest struct {
+	Token       string `json:"token" binding:"required"`
+	PhoneNumber string `json:"phoneNumber" binding:"required"`
+	Code        string `json:"code" binding:"required"`
+}
+
+// LinkEmailCodeRequest emails a code proving the user reads the address.
+type LinkEmailCodeRequest struct {
+	Token string `json:"token" binding:"required"`
+	Email string `json:"email" binding:"required"`
+}
+
+// LinkEmailRequest adds an email and password login with the emailed code.
+type LinkEmailRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Email    string `json:"email" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+type LinkGoogleRequest struct {
+	Token   string `json:"token" binding:"required"`
+	IDToken string `json:"idToken" binding:"required"`
+}
+
+// MergeUsersRequest folds FromUserID into IntoUserID, for people who ended
+// up with an account per login method before they could be linked.
+type MergeUsersRequest struct {
+	Token      string    `json:"token" binding:"required"`
+	FromUserID uuid.UUID `json:"fromUserId" binding:"required"`
+	IntoUserID uuid.UUID `json:"intoUserId" binding:"required"`
+}
+
+func (c *client) GetLoginMethods(ctx context.Context, request *VerifyTokenRequest) (*LoginMethodsResponse, error) {
+	var res LoginMethodsResponse
+	return &res, c.post(ctx, "/authenticator/logins", request, &res)
+}
+
+func (c *client) LinkPhoneNumber(ctx context.Context, request *LinkPhoneRequest) (*LoginMethodsResponse, error) {
+	var res LoginMethodsResponse
+	return &res, c.post(ctx, "/authenticator/logins/phone", request, &res)
+}
+
+func (c *client) SendEmailLinkCode(ctx context.Context, request *LinkEmailCodeRequest) error {
+	return c.post(ctx, "/authenticator/logins/email/code", request, nil)
+}
+
+func (c *client) LinkEmail(ctx context.Context, request *LinkEmailRequest) (*LoginMethodsResponse, error) {
+	var res LoginMethodsResponse
+	return &res, c.post(ctx, "/authenticator/logins/email", request, &res)
+}
+
+func (c *client) LinkGoogle(ctx context.Context, request *LinkGoogleRequest) (*LoginMethodsResponse, error) {
+	var res LoginMethodsResponse
+	return &res, c.post(ctx, "/authenticator/logins/google", request, &res)
+}
+
+// ExportUserData returns a ZIP holding one JSON file per product with
+// everything stored about the signed-in user.
+func (c *client) ExportUserData(ctx context.Context, request *VerifyTokenRequest) ([]byte, error) {
+	return c.httpClient.Post(ctx, "/authenticator/users/export", request)
+}
+
+// DeleteAccount deletes the signed-in user and their data in every product.
+func (c *client) DeleteAccount(ctx context.Context, request *VerifyTokenRequest) error {
+	return c.post(ctx, "/authenticator/users/delete", request, nil)
+}
+
+// MergeUsers needs auth:users:write and returns the merged user.
+func (c *client) MergeUsers(ctx context.Context, request *MergeUsersRequest) (*models.User, error) {
+	var res models.User
+	return &res, c.post(ctx, "/authenticator/users/merge", request, &res)
+}
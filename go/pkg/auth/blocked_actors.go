@@ -0,0 +1,38 @@
+package auth
+
+import (
+	"context"
+	"time"
+)
+
+// BlockedActor is a phone number, IP, app or account the authenticator is
+// currently refusing for hitting a rate limit or too many failed sign-ins.
+type BlockedActor struct {
+	// Key identifies the limit; pass it to UnblockActor to lift it early.
+	Key    string    `json:"key"`
+	Rule   string    `json:"rule"`
+	Kind   string    `json:"kind"`
+	Value  string    `json:"value"`
+	Reason string    `json:"reason"`
+	Until  time.Time `json:"until"`
+}
+
+type BlockedActorsResponse struct {
+	BlockedActors []BlockedActor `json:"blockedActors"`
+}
+
+type UnblockActorRequest struct {
+	Token string `json:"token" binding:"required"`
+	Key   string `json:"key" binding:"required"`
+}
+
+// GetBlockedActors needs auth:blocked-actors:read.
+func (c *client) GetBlockedActors(ctx context.Context, request *VerifyTokenRequest) (*BlockedActorsResponse, error) {
+	var res BlockedActorsResponse
+	return &res, c.post(ctx, "/authenticator/blocked-actors", request, &res)
+}
+
+// UnblockActor needs auth:blocked-actors:write.
+func (c *client) UnblockActor(ctx context.Context, request *UnblockActorRequest) error {
+	return c.post(ctx, "/authenticator/blocked-actors/unblock", request, nil)
+}
@@ -54,15 +54,51 @@ type LoginWithGoogleRequest struct {
 	IDToken string `json:"idToken" binding:"required"`
 }
 
+// AuthenicateResponse is returned by every sign-in and by a refresh. Token
+// is a short-lived access token; RefreshToken gets the next one.
 type AuthenicateResponse struct {
-	Token string `json:"token"`
-	User  User   `json:"user"`
+	Token        string    `json:"token"`
+	User         User      `json:"user"`
+	ExpiresAt    time.Time `json:"expiresAt"`
+	RefreshToken string    `json:"refreshToken"`
+	SessionID    uuid.UUID `json:"sessionId"`
+	// MFARequired means the first factor checked out but the user has
+	// two-factor on. Only MFAToken and ExpiresAt are set; the token goes to
+	// VerifyMFA along with their code before ExpiresAt.
+	MFARequired bool   `json:"mfaRequired,omitempty"`
+	MFAToken    string `json:"mfaToken,omitempty"`
+	// MFAEnrollmentRequired means the user holds a role but has no second
+	// factor, so the token carries none of the role's scopes yet.
+	MFAEnrollmentRequired bool `json:"mfaEnrollmentRequired,omitempty"`
 }
 
 type VerifyTokenRequest struct {
 	Token string `json:"token" binding:"required"`
 }
 
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+}
+
+type RevokeSessionRequest struct {
+	Token     string    `json:"token" binding:"required"`
+	SessionID uuid.UUID `json:"sessionId" binding:"required"`
+}
+
+type RevokeAllSessionsRequest struct {
+	Token       string `json:"token" binding:"required"`
+	KeepCurrent bool   `json:"keepCurrent"`
+}
+
+type SessionsResponse struct {
+	Sessions         []models.AuthSession `json:"sessions"`
+	CurrentSessionID *uuid.UUID           `json:"currentSessionId"`
+}
+
+type RevokeAllSessionsResponse struct {
+	Revoked int64 `json:"revoked"`
+}
+
 type User struct {
 	ID          uuid.UUID `db:"id" gorm:"type:uuid;default:uuid_generate_v4()"`
 	CreatedAt   time.Time `db:"created_at"`
@@ -84,6 +120,34 @@ type Client interface {
 	LoginByEmail(ctx context.Context, request *LoginByEmailRequest) (*AuthenicateResponse, error)
 	LoginWithGoogle(ctx context.Context, request *LoginWithGoogleRequest) (*AuthenicateResponse, error)
 	VerifyToken(ctx context.Context, request *VerifyTokenRequest) (*models.User, error)
+	RefreshToken(ctx context.Context, request *RefreshTokenRequest) (*AuthenicateResponse, error)
+	ExchangeLegacyToken(ctx context.Context, request *VerifyTokenRequest) (*AuthenicateResponse, error)
+	GetSessions(ctx context.Context, request *VerifyTokenRequest) (*SessionsResponse, error)
+	RevokeSession(ctx context.Context, request *RevokeSessionRequest) error
+	RevokeAllSessions(ctx context.Context, request *RevokeAllSessionsRequest) (*RevokeAllSessionsResponse, error)
+	GetJWKS(ctx context.Context) (*JWKS, error)
+	VerifyMFA(ctx context.Context, request *VerifyMFARequest) (*AuthenicateResponse, error)
+	GetTwoFactorStatus(ctx context.Context, request *VerifyTokenRequest) (*TwoFactorStatusResponse, error)
+	EnrollTOTP(ctx context.Context, request *VerifyTokenRequest) (*TOTPEnrollmentResponse, error)
+	ConfirmTOTP(ctx context.Context, request *TOTPCodeRequest) (*RecoveryCodesResponse, error)
+	DisableTOTP(ctx context.Context, request *TOTPCodeRequest) error
+	RegenerateRecoveryCodes(ctx context.Context, request *TOTPCodeRequest) (*RecoveryCodesResponse, error)
+	BeginPasskeyRegistration(ctx context.Context, request *VerifyTokenRequest) (*PasskeyOptionsResponse, error)
+	FinishPasskeyRegistration(ctx context.Context, request *PasskeyRegistrationRequest) (*models.WebAuthnCredential, error)
+	BeginPasskeyLogin(ctx context.Context) (*PasskeyOptionsResponse, error)
+	FinishPasskeyLogin(ctx context.Context, request *PasskeyLoginRequest) (*AuthenicateResponse, error)
+	GetPasskeys(ctx context.Context, request *VerifyTokenRequest) (*PasskeysResponse, error)
+	DeletePasskey(ctx context.Context, request *DeletePasskeyRequest) error
+	GetBlockedActors(ctx context.Context, request *VerifyTokenRequest) (*BlockedActorsResponse, error)
+	UnblockActor(ctx context.Context, request *UnblockActorRequest) error
+	GetLoginMethods(ctx context.Context, request *VerifyTokenRequest) (*LoginMethodsResponse, error)
+	LinkPhoneNumber(ctx context.Context, request *LinkPhoneRequest) (*LoginMethodsResponse, error)
+	SendEmailLinkCode(ctx context.Context, request *LinkEmailCodeRequest) error
+	LinkEmail(ctx context.Context, request *LinkEmailRequest) (*LoginMethodsResponse, error)
+	LinkGoogle(ctx context.Context, request *LinkGoogleRequest) (*LoginMethodsResponse, error)
+	ExportUserData(ctx context.Context, request *VerifyTokenRequest) ([]byte, error)
+	DeleteAccount(ctx context.Context, request *VerifyTokenRequest) error
+	MergeUsers(ctx context.Context, request *MergeUsersRequest) (*models.User, error)
 }
 
 const (
@@ -203,3 +267,70 @@ func (c *client) VerifyToken(ctx context.Context, request *VerifyTokenRequest) (
 
 	return &user, nil
 }
+
+func (c *client) RefreshToken(ctx context.Context, request *RefreshTokenRequest) (*AuthenicateResponse, error) {
+	respBytes, err := c.httpClient.Post(ctx, "/authenticator/token/refresh", request)
+	if err != nil {
+		return nil, err
+	}
+
+	var res AuthenicateResponse
+	err = json.Unmarshal(respBytes, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// ExchangeLegacyToken trades a token minted before sessions existed for a
+// session-backed access token and refresh token.
+func (c *client) ExchangeLegacyToken(ctx context.Context, request *VerifyTokenRequest) (*AuthenicateResponse, error) {
+	respBytes, err := c.httpClient.Post(ctx, "/authenticator/token/exchange", request)
+	if err != nil {
+		return nil, err
+	}
+
+	var res AuthenicateResponse
+	err = json.Unmarshal(respBytes, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+func (c *client) GetSessions(ctx context.Context, request *VerifyTokenRequest) (*SessionsResponse, error) {
+	respBytes, err := c.httpClient.Post(ctx, "/authenticator/sessions", request)
+	if err != nil {
+		return nil, err
+	}
+
+	var res SessionsResponse
+	err = json.Unmarshal(respBytes, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+func (c *client) RevokeSession(ctx context.Context, request *RevokeSessionRequest) error {
+	_, err := c.httpClient.Post(ctx, "/authenticator/sessions/revoke", request)
+	return err
+}
+
+func (c *client) RevokeAllSessions(ctx context.Context, request *RevokeAllSessionsRequest) (*RevokeAllSessionsResponse, error) {
+	respBytes, err := c.httpClient.Post(ctx, "/authenticator/sessions/revoke-all", request)
+	if err != nil {
+		return nil, err
+	}
+
+	var res RevokeAllSessionsResponse
+	err = json.Unmarshal(respBytes, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
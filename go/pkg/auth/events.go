@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// EventsChannel is the Redis pub/sub channel services listen on to drop
+// cached users and refuse revoked sessions without asking the authenticator.
+const EventsChannel = "authenticator:events"
+
+const (
+	EventTypeUserChanged     = "user_changed"
+	EventTypeSessionsRevoked = "sessions_revoked"
+	// EventTypeRolesChanged means the scopes in the user's outstanding
+	// access tokens are stale and must not be trusted until they refresh —
+	// a role was granted or revoked, or two-factor was turned on or off.
+	EventTypeRolesChanged = "roles_changed"
+)
+
+type Event struct {
+	Type       string      `json:"type"`
+	UserID     uuid.UUID   `json:"userId"`
+	SessionIDs []uuid.UUID `json:"sessionIds,omitempty"`
+}
+
+func PublishUserChanged(ctx context.Context, redisClient *redis.Client, userID uuid.UUID) error {
+	return publishEvent(ctx, redisClient, Event{Type: EventTypeUserChanged, UserID: userID})
+}
+
+func PublishSessionsRevoked(ctx context.Context, redisClient *redis.Client, userID uuid.UUID, sessionIDs []uuid.UUID) error {
+	if len(sessionIDs) == 0 {
+		return nil
+	}
+	return publishEvent(ctx, redisClient, Event{Type: EventTypeSessionsRevoked, UserID: userID, SessionIDs: sessionIDs})
+}
+
+func PublishRolesChanged(ctx context.Context, redisClient *redis.Client, userID uuid.UUID) error {
+	return publishEvent(ctx, redisClient, Event{Type: EventTypeRolesChanged, UserID: userID})
+}
+
+func publishEvent(ctx context.Context, redisClient *redis.Client, event Event) error {
+	if redisClient == nil {
+		return nil
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return redisClient.Publish(ctx, EventsChannel, payload).Err()
+}
@@ -0,0 +1,41 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// JWKSPath is where the authenticator publishes the public keys its access
+// tokens are signed with.
+const JWKSPath = "/authenticator/.well-known/jwks.json"
+
+// JWK is one P-256 public key in RFC 7517 form. KeyID matches the "kid"
+// header of the tokens it signed.
+type JWK struct {
+	KeyType   string `json:"kty"`
+	Curve     string `json:"crv"`
+	X         string `json:"x"`
+	Y         string `json:"y"`
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+func (c *client) GetJWKS(ctx context.Context) (*JWKS, error) {
+	respBytes, err := c.httpClient.Get(ctx, JWKSPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var res JWKS
+	err = json.Unmarshal(respBytes, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
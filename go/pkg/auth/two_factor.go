@@ -0,0 +1,145 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/google/uuid"
+)
+
+// VerifyMFARequest finishes a sign-in that answered with MFARequired, using
+// either a code from the authenticator app or an unused recovery code.
+type VerifyMFARequest struct {
+	MFAToken     string `json:"mfaToken" binding:"required"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recoveryCode"`
+}
+
+// TOTPCodeRequest proves the signed-in user still holds their second
+// factor before it is changed. Code may be a recovery code.
+type TOTPCodeRequest struct {
+	Token string `json:"token" binding:"required"`
+	Code  string `json:"code" binding:"required"`
+}
+
+type TwoFactorStatusResponse struct {
+	TOTPEnabled            bool  `json:"totpEnabled"`
+	RecoveryCodesRemaining int64 `json:"recoveryCodesRemaining"`
+	Passkeys               int   `json:"passkeys"`
+	// Required is set for users holding a role: their scopes only reach
+	// tokens from sessions that passed a second factor.
+	Required bool `json:"required"`
+}
+
+// TOTPEnrollmentResponse is shown to the user as a QR code (OtpauthURL) with
+// the secret as a typed fallback. Enrollment only takes effect once a code
+// is confirmed.
+type TOTPEnrollmentResponse struct {
+	Secret     string `json:"secret"`
+	OtpauthURL string `json:"otpauthUrl"`
+}
+
+// RecoveryCodesResponse is the only time recovery codes are shown.
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+type PasskeyRegistrationRequest struct {
+	Token string `json:"token" binding:"required"`
+	Name  string `json:"name"`
+	// Credential is navigator.credentials.create()'s result, via toJSON().
+	Credential json.RawMessage `json:"credential" binding:"required"`
+}
+
+type PasskeyLoginRequest struct {
+	// Credential is navigator.credentials.get()'s result, via toJSON().
+	Credential json.RawMessage `json:"credential" binding:"required"`
+}
+
+// PasskeyOptionsResponse carries WebAuthn options in their JSON form, for
+// PublicKeyCredential.parseCreationOptionsFromJSON or
+// parseRequestOptionsFromJSON.
+type PasskeyOptionsResponse struct {
+	PublicKey json.RawMessage `json:"publicKey"`
+	ExpiresAt time.Time       `json:"expiresAt"`
+}
+
+type PasskeysResponse struct {
+	Passkeys []models.WebAuthnCredential `json:"passkeys"`
+}
+
+type DeletePasskeyRequest struct {
+	Token     string    `json:"token" binding:"required"`
+	PasskeyID uuid.UUID `json:"passkeyId" binding:"required"`
+}
+
+func (c *client) VerifyMFA(ctx context.Context, request *VerifyMFARequest) (*AuthenicateResponse, error) {
+	var res AuthenicateResponse
+	return &res, c.post(ctx, "/authenticator/mfa/verify", request, &res)
+}
+
+func (c *client) GetTwoFactorStatus(ctx context.Context, request *VerifyTokenRequest) (*TwoFactorStatusResponse, error) {
+	var res TwoFactorStatusResponse
+	return &res, c.post(ctx, "/authenticator/mfa/status", request, &res)
+}
+
+func (c *client) EnrollTOTP(ctx context.Context, request *VerifyTokenRequest) (*TOTPEnrollmentResponse, error) {
+	var res TOTPEnrollmentResponse
+	return &res, c.post(ctx, "/authenticator/totp/enroll", request, &res)
+}
+
+func (c *client) ConfirmTOTP(ctx context.Context, request *TOTPCodeRequest) (*RecoveryCodesResponse, error) {
+	var res RecoveryCodesResponse
+	return &res, c.post(ctx, "/authenticator/totp/confirm", request, &res)
+}
+
+func (c *client) DisableTOTP(ctx context.Context, request *TOTPCodeRequest) error {
+	return c.post(ctx, "/authenticator/totp/disable", request, nil)
+}
+
+func (c *client) RegenerateRecoveryCodes(ctx context.Context, request *TOTPCodeRequest) (*RecoveryCodesResponse, error) {
+	var res RecoveryCodesResponse
+	return &res, c.post(ctx, "/authenticator/totp/recovery-codes", request, &res)
+}
+
+func (c *client) BeginPasskeyRegistration(ctx context.Context, request *VerifyTokenRequest) (*PasskeyOptionsResponse, error) {
+	var res PasskeyOptionsResponse
+	return &res, c.post(ctx, "/authenticator/passkeys/register/begin", request, &res)
+}
+
+func (c *client) FinishPasskeyRegistration(ctx context.Context, request *PasskeyRegistrationRequest) (*models.WebAuthnCredential, error) {
+	var res models.WebAuthnCredential
+	return &res, c.post(ctx, "/authenticator/passkeys/register/finish", request, &res)
+}
+
+func (c *client) BeginPasskeyLogin(ctx context.Context) (*PasskeyOptionsResponse, error) {
+	var res PasskeyOptionsResponse
+	return &res, c.post(ctx, "/authenticator/passkeys/login/begin", struct{}{}, &res)
+}
+
+func (c *client) FinishPasskeyLogin(ctx context.Context, request *PasskeyLoginRequest) (*AuthenicateResponse, error) {
+	var res AuthenicateResponse
+	return &res, c.post(ctx, "/authenticator/passkeys/login/finish", request, &res)
+}
+
+func (c *client) GetPasskeys(ctx context.Context, request *VerifyTokenRequest) (*PasskeysResponse, error) {
+	var res PasskeysResponse
+	return &res, c.post(ctx, "/authenticator/passkeys", request, &res)
+}
+
+func (c *client) DeletePasskey(ctx context.Context, request *DeletePasskeyRequest) error {
+	return c.post(ctx, "/authenticator/passkeys/delete", request, nil)
+}
+
+func (c *client) post(ctx context.Context, path string, request interface{}, response interface{}) error {
+	respBytes, err := c.httpClient.Post(ctx, path, request)
+	if err != nil {
+		return err
+	}
+	if response == nil {
+		return nil
+	}
+	return json.Unmarshal(respBytes, response)
+}
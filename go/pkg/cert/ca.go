@@ -1,6 +1,9 @@
 package cert
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/sha256"
@@ -15,37 +18,71 @@ import (
 )
 
 type Client interface {
-	IssueCertificate(publicKeyPEM string, userID uuid.UUID, validityPeriod time.Duration) (certificateDER []byte, certificatePEM string, fingerprint []byte, err error)
+	IssueCertificate(publicKey string, userID uuid.UUID, validityPeriod time.Duration) (certificateDER []byte, certificatePEM string, fingerprint []byte, err error)
 	ComputeFingerprint(certificateDER []byte) []byte
+	// GetCACertificate returns the CA that signs user certificates: the
+	// intermediate when one is configured, the root otherwise.
 	GetCACertificate() *x509.Certificate
+	GetRootCertificate() *x509.Certificate
+	// GetCertificateChain returns the issuing CA followed by any certificates
+	// up to and including the root.
+	GetCertificateChain() []*x509.Certificate
+	// VerifyCertificate checks that a user certificate chains to the root and
+	// was within its validity period at the given time.
+	VerifyCertificate(certificateDER []byte, at time.Time) (*x509.Certificate, error)
+	CreateRevocationList(revoked []RevokedCertificate) ([]byte, error)
+	RespondOCSP(requestDER []byte, lookup func(serialNumber *big.Int) (CertificateStatus, error)) ([]byte, error)
+}
+
+// Config describes where the CA's keys and certificates come from.
+//
+// With only PrivateKeyPEM set the key is a self-signed root, as before. To
+// keep the root offline, set CertificatePEM to an intermediate issued by
+// the root (see IssueIntermediateCA), PrivateKeyPEM to the intermediate's
+// key and RootCertificatePEM to the root's certificate.
+type Config struct {
+	PrivateKeyPEM      string
+	CertificatePEM     string
+	RootCertificatePEM string
+	// CRLURL and OCSPURL are embedded in issued certificates so that
+	// verifiers can find revocation information. Either may be empty.
+	CRLURL  string
+	OCSPURL string
 }
 
 type client struct {
 	caCert       *x509.Certificate
-	caPrivateKey *rsa.PrivateKey
+	caPrivateKey crypto.Signer
+	rootCert     *x509.Certificate
+	crlURL       string
+	ocspURL      string
 }
 
 // NewClient creates a new CA client. If caPrivateKeyPEM is empty, it generates a new CA.
 // If caPrivateKeyPEM is provided, it loads the CA from the PEM-encoded private key.
 func NewClient(caPrivateKeyPEM string) (Client, error) {
+	return NewClientFromConfig(Config{PrivateKeyPEM: caPrivateKeyPEM})
+}
+
+// NewClientFromConfig creates a CA client from cfg. An empty private key
+// generates a throwaway root, which is only useful for local development.
+func NewClientFromConfig(cfg Config) (Client, error) {
 	var caCert *x509.Certificate
-	var caPrivateKey *rsa.PrivateKey
+	var caPrivateKey crypto.Signer
 	var err error
 
-	if caPrivateKeyPEM == "" {
+	switch {
+	case cfg.PrivateKeyPEM == "":
+		if cfg.CertificatePEM != "" {
+			return nil, fmt.Errorf("a CA certificate was configured without its private key")
+		}
 		// Generate new CA
 		caCert, caPrivateKey, err = generateCA()
 		if err != nil {
 			return nil, fmt.Errorf("failed to generate CA: %w", err)
 		}
-	} else {
-		// Load CA from PEM
-		block, _ := pem.Decode([]byte(caPrivateKeyPEM))
-		if block == nil {
-			return nil, fmt.Errorf("failed to decode CA private key PEM")
-		}
-
-		caPrivateKey, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case cfg.CertificatePEM == "":
+		caPrivateKey, err = ParsePrivateKey(cfg.PrivateKeyPEM)
 		if err != nil {
 			return nil, fmt.Errorf("failed to parse CA private key: %w", err)
 		}
@@ -55,15 +92,46 @@ func NewClient(caPrivateKeyPEM string) (Client, error) {
 		if err != nil {
 			return nil, fmt.Errorf("failed to generate CA certificate: %w", err)
 		}
+	default:
+		caPrivateKey, err = ParsePrivateKey(cfg.PrivateKeyPEM)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse CA private key: %w", err)
+		}
+		caCert, err = ParseCertificatePEM(cfg.CertificatePEM)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse CA certificate: %w", err)
+		}
+		if !caCert.IsCA {
+			return nil, fmt.Errorf("the configured CA certificate is not a CA")
+		}
+		if !publicKeysEqual(caPrivateKey.Public(), caCert.PublicKey) {
+			return nil, fmt.Errorf("the CA private key does not match the CA certificate")
+		}
+	}
+
+	rootCert := caCert
+	if cfg.RootCertificatePEM != "" {
+		rootCert, err = ParseCertificatePEM(cfg.RootCertificatePEM)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse root certificate: %w", err)
+		}
+		if err := caCert.CheckSignatureFrom(rootCert); err != nil {
+			return nil, fmt.Errorf("the CA certificate is not signed by the root: %w", err)
+		}
+	} else if err := caCert.CheckSignatureFrom(caCert); err != nil {
+		return nil, fmt.Errorf("the CA certificate is not self-signed, so its root certificate is required: %w", err)
 	}
 
 	return &client{
 		caCert:       caCert,
 		caPrivateKey: caPrivateKey,
+		rootCert:     rootCert,
+		crlURL:       cfg.CRLURL,
+		ocspURL:      cfg.OCSPURL,
 	}, nil
 }
 
-func generateCA() (*x509.Certificate, *rsa.PrivateKey, error) {
+func generateCA() (*x509.Certificate, crypto.Signer, error) {
 	// Generate CA private key
 	caPrivateKey, err := rsa.GenerateKey(rand.Reader, 2048)
 	if err != nil {
@@ -79,7 +147,7 @@ func generateCA() (*x509.Certificate, *rsa.PrivateKey, error) {
 	return caCert, caPrivateKey, nil
 }
 
-func generateCACertificate(caPrivateKey *rsa.PrivateKey) (*x509.Certificate, error) {
+func generateCACertificate(caPrivateKey crypto.Signer) (*x509.Certificate, error) {
 	caTemplate := &x509.Certificate{
 		SerialNumber: big.NewInt(1),
 		Subject: pkix.Name{
@@ -95,11 +163,11 @@ func generateCACertificate(caPrivateKey *rsa.PrivateKey) (*x509.Certificate, err
 		NotAfter:              time.Now().AddDate(10, 0, 0), // 10 years validity
 		IsCA:                  true,
 		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
-		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
 		BasicConstraintsValid: true,
 	}
 
-	caCertDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caPrivateKey.PublicKey, caPrivateKey)
+	caCertDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, caPrivateKey.Public(), caPrivateKey)
 	if err != nil {
 		return nil, err
 	}
@@ -112,22 +180,29 @@ func generateCACertificate(caPrivateKey *rsa.PrivateKey) (*x509.Certificate, err
 	return caCert, nil
 }
 
-func (c *client) IssueCertificate(publicKeyPEM string, userID uuid.UUID, validityPeriod time.Duration) ([]byte, string, []byte, error) {
-	// Parse the public key from PEM
-	block, _ := pem.Decode([]byte(publicKeyPEM))
-	if block == nil {
-		return nil, "", nil, fmt.Errorf("failed to decode public key PEM")
-	}
-
-	publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+// IssueCertificate signs a user certificate for publicKey, which may be in
+// any of the encodings ParsePublicKey accepts. Keys must be ECDSA P-256, as
+// produced by mobile secure enclaves, or RSA of at least 2048 bits.
+func (c *client) IssueCertificate(publicKey string, userID uuid.UUID, validityPeriod time.Duration) ([]byte, string, []byte, error) {
+	key, err := ParsePublicKey(publicKey)
 	if err != nil {
-		return nil, "", nil, fmt.Errorf("failed to parse public key: %w", err)
+		return nil, "", nil, err
+	}
+	if err := checkUserKey(key); err != nil {
+		return nil, "", nil, err
 	}
 
 	// Create certificate template
-	serialNumber, err := rand.Int(rand.Reader, big.NewInt(0).Exp(big.NewInt(2), big.NewInt(159), nil))
+	serialNumber, err := newSerialNumber()
 	if err != nil {
-		return nil, "", nil, fmt.Errorf("failed to generate serial number: %w", err)
+		return nil, "", nil, err
+	}
+
+	notBefore := time.Now()
+	notAfter := notBefore.Add(validityPeriod)
+	// A certificate can't outlive the CA that vouches for it.
+	if notAfter.After(c.caCert.NotAfter) {
+		notAfter = c.caCert.NotAfter
 	}
 
 	template := &x509.Certificate{
@@ -138,16 +213,22 @@ func (c *client) IssueCertificate(publicKeyPEM string, userID uuid.UUID, validit
 			CommonName:   userID.String(),
 			SerialNumber: userID.String(),
 		},
-		NotBefore:             time.Now(),
-		NotAfter:              time.Now().Add(validityPeriod),
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
 		KeyUsage:              x509.KeyUsageDigitalSignature,
 		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
 		BasicConstraintsValid: false,
 		IsCA:                  false,
 	}
+	if c.crlURL != "" {
+		template.CRLDistributionPoints = []string{c.crlURL}
+	}
+	if c.ocspURL != "" {
+		template.OCSPServer = []string{c.ocspURL}
+	}
 
 	// Sign the certificate
-	certDER, err := x509.CreateCertificate(rand.Reader, template, c.caCert, publicKey, c.caPrivateKey)
+	certDER, err := x509.CreateCertificate(rand.Reader, template, c.caCert, key, c.caPrivateKey)
 	if err != nil {
 		return nil, "", nil, fmt.Errorf("failed to create certificate: %w", err)
 	}
@@ -172,3 +253,63 @@ func (c *client) ComputeFingerprint(certificateDER []byte) []byte {
 func (c *client) GetCACertificate() *x509.Certificate {
 	return c.caCert
 }
+
+func (c *client) GetRootCertificate() *x509.Certificate {
+	return c.rootCert
+}
+
+func (c *client) GetCertificateChain() []*x509.Certificate {
+	if c.caCert == c.rootCert {
+		return []*x509.Certificate{c.caCert}
+	}
+	return []*x509.Certificate{c.caCert, c.rootCert}
+}
+
+func (c *client) VerifyCertificate(certificateDER []byte, at time.Time) (*x509.Certificate, error) {
+	certificate, err := x509.ParseCertificate(certificateDER)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate: %w", err)
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(c.rootCert)
+	intermediates := x509.NewCertPool()
+	if c.caCert != c.rootCert {
+		intermediates.AddCert(c.caCert)
+	}
+
+	if _, err := certificate.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   at,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}); err != nil {
+		return nil, err
+	}
+
+	return certificate, nil
+}
+
+func newSerialNumber() (*big.Int, error) {
+	serialNumber, err := rand.Int(rand.Reader, big.NewInt(0).Exp(big.NewInt(2), big.NewInt(159), nil))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate serial number: %w", err)
+	}
+	return serialNumber, nil
+}
+
+func checkUserKey(key crypto.PublicKey) error {
+	switch k := key.(type) {
+	case *ecdsa.PublicKey:
+		if k.Curve != elliptic.P256() {
+			return fmt.Errorf("unsupported ECDSA curve %s: keys must be P-256", k.Curve.Params().Name)
+		}
+	case *rsa.PublicKey:
+		if k.N.BitLen() < 2048 {
+			return fmt.Errorf("RSA keys must be at least 2048 bits")
+		}
+	default:
+		return fmt.Errorf("unsupported public key type %T", key)
+	}
+	return nil
+}
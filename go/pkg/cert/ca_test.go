@@ -0,0 +1,190 @@
+package cert
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/ocsp"
+)
+
+func newIntermediateClient(t *testing.T) Client {
+	t.Helper()
+	rootPEM, rootKeyPEM, err := GenerateRootCA("Test Root", 10*365*24*time.Hour)
+	if err != nil {
+		t.Fatalf("GenerateRootCA: %v", err)
+	}
+	keyPEM, publicKeyPEM, err := GeneratePrivateKey()
+	if err != nil {
+		t.Fatalf("GeneratePrivateKey: %v", err)
+	}
+	intermediatePEM, err := IssueIntermediateCA(rootPEM, rootKeyPEM, publicKeyPEM, "Test Intermediate", 365*24*time.Hour)
+	if err != nil {
+		t.Fatalf("IssueIntermediateCA: %v", err)
+	}
+	client, err := NewClientFromConfig(Config{
+		PrivateKeyPEM:      keyPEM,
+		CertificatePEM:     intermediatePEM,
+		RootCertificatePEM: rootPEM,
+		OCSPURL:            "https://example.com/ocsp",
+	})
+	if err != nil {
+		t.Fatalf("NewClientFromConfig: %v", err)
+	}
+	return client
+}
+
+// enclavePublicKey returns a device key the way a secure enclave exports
+// it: a base64 uncompressed P-256 point.
+func enclavePublicKey(t *testing.T) (*ecdsa.PrivateKey, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	point, err := key.PublicKey.ECDH()
+	if err != nil {
+		t.Fatalf("ECDH: %v", err)
+	}
+	return key, base64.StdEncoding.EncodeToString(point.Bytes())
+}
+
+func TestIssueCertificateFromEnclaveKeyChainsThroughIntermediate(t *testing.T) {
+	client := newIntermediateClient(t)
+	key, publicKey := enclavePublicKey(t)
+
+	certificateDER, _, _, err := client.IssueCertificate(publicKey, uuid.New(), 24*time.Hour)
+	if err != nil {
+		t.Fatalf("IssueCertificate: %v", err)
+	}
+
+	certificate, err := client.VerifyCertificate(certificateDER, time.Now())
+	if err != nil {
+		t.Fatalf("VerifyCertificate: %v", err)
+	}
+	if !key.PublicKey.Equal(certificate.PublicKey) {
+		t.Fatal("expected the certificate to carry the enclave key")
+	}
+	if len(certificate.OCSPServer) != 1 || certificate.OCSPServer[0] != "https://example.com/ocsp" {
+		t.Fatalf("expected the OCSP URL to be embedded, got %v", certificate.OCSPServer)
+	}
+	if len(client.GetCertificateChain()) != 2 {
+		t.Fatalf("expected the intermediate and root in the chain")
+	}
+
+	if _, err := client.VerifyCertificate(certificateDER, time.Now().Add(48*time.Hour)); err == nil {
+		t.Fatal("expected the certificate not to verify after it expires")
+	}
+}
+
+func TestIssueCertificateRejectsOtherCurves(t *testing.T) {
+	client, err := NewClient("")
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	publicKeyPEM, err := EncodePublicKeyPEM(key.Public())
+	if err != nil {
+		t.Fatalf("EncodePublicKeyPEM: %v", err)
+	}
+
+	if _, _, _, err := client.IssueCertificate(publicKeyPEM, uuid.New(), time.Hour); err == nil {
+		t.Fatal("expected a P-384 key to be rejected")
+	}
+}
+
+func TestNewClientFromConfigRejectsMismatchedKey(t *testing.T) {
+	rootPEM, _, err := GenerateRootCA("Test Root", time.Hour)
+	if err != nil {
+		t.Fatalf("GenerateRootCA: %v", err)
+	}
+	otherKeyPEM, _, err := GeneratePrivateKey()
+	if err != nil {
+		t.Fatalf("GeneratePrivateKey: %v", err)
+	}
+
+	if _, err := NewClientFromConfig(Config{PrivateKeyPEM: otherKeyPEM, CertificatePEM: rootPEM}); err == nil {
+		t.Fatal("expected a key that doesn't match the certificate to be rejected")
+	}
+}
+
+func TestCreateRevocationListIsSignedByIssuingCA(t *testing.T) {
+	client := newIntermediateClient(t)
+	revokedAt := time.Now().Add(-time.Minute).UTC().Truncate(time.Second)
+
+	crlDER, err := client.CreateRevocationList([]RevokedCertificate{
+		{SerialNumber: big.NewInt(42), RevokedAt: revokedAt, Reason: ReasonKeyCompromise},
+	})
+	if err != nil {
+		t.Fatalf("CreateRevocationList: %v", err)
+	}
+
+	crl, err := x509.ParseRevocationList(crlDER)
+	if err != nil {
+		t.Fatalf("ParseRevocationList: %v", err)
+	}
+	if err := crl.CheckSignatureFrom(client.GetCACertificate()); err != nil {
+		t.Fatalf("expected the CRL to be signed by the issuing CA: %v", err)
+	}
+	if len(crl.RevokedCertificateEntries) != 1 {
+		t.Fatalf("expected one revoked entry, got %d", len(crl.RevokedCertificateEntries))
+	}
+	entry := crl.RevokedCertificateEntries[0]
+	if entry.SerialNumber.Int64() != 42 || !entry.RevocationTime.Equal(revokedAt) || entry.ReasonCode != int(ReasonKeyCompromise) {
+		t.Fatalf("unexpected entry %+v", entry)
+	}
+}
+
+func TestRespondOCSP(t *testing.T) {
+	client := newIntermediateClient(t)
+	_, publicKey := enclavePublicKey(t)
+	certificateDER, _, _, err := client.IssueCertificate(publicKey, uuid.New(), time.Hour)
+	if err != nil {
+		t.Fatalf("IssueCertificate: %v", err)
+	}
+	certificate, _ := x509.ParseCertificate(certificateDER)
+	requestDER, err := ocsp.CreateRequest(certificate, client.GetCACertificate(), nil)
+	if err != nil {
+		t.Fatalf("CreateRequest: %v", err)
+	}
+	revokedAt := time.Now().Add(-time.Minute).UTC().Truncate(time.Second)
+
+	responseDER, err := client.RespondOCSP(requestDER, func(serialNumber *big.Int) (CertificateStatus, error) {
+		if serialNumber.Cmp(certificate.SerialNumber) != 0 {
+			return CertificateStatus{Status: StatusUnknown}, nil
+		}
+		return CertificateStatus{Status: StatusRevoked, RevokedAt: revokedAt, Reason: ReasonSuperseded}, nil
+	})
+	if err != nil {
+		t.Fatalf("RespondOCSP: %v", err)
+	}
+
+	response, err := ocsp.ParseResponseForCert(responseDER, certificate, client.GetCACertificate())
+	if err != nil {
+		t.Fatalf("ParseResponseForCert: %v", err)
+	}
+	if response.Status != ocsp.Revoked || !response.RevokedAt.Equal(revokedAt) || response.RevocationReason != ocsp.Superseded {
+		t.Fatalf("unexpected response %+v", response)
+	}
+
+	other := newIntermediateClient(t)
+	responseDER, err = other.RespondOCSP(requestDER, func(*big.Int) (CertificateStatus, error) {
+		t.Fatal("expected no lookup for another issuer's certificate")
+		return CertificateStatus{}, nil
+	})
+	if err != nil {
+		t.Fatalf("RespondOCSP: %v", err)
+	}
+	if _, err := ocsp.ParseResponse(responseDER, nil); err != (ocsp.ResponseError{Status: ocsp.Unauthorized}) {
+		t.Fatalf("expected an unauthorized response, got %v", err)
+	}
+}
@@ -1,5 +1,8 @@
 module github.com/MaxBlaushild/poltergeist/pkg/cert
 
-go 1.22
+go 1.24.0
 
-require github.com/google/uuid v1.6.0
+require (
+	github.com/google/uuid v1.6.0
+	golang.org/x/crypto v0.47.0
+)
@@ -0,0 +1,107 @@
+package cert
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"strings"
+)
+
+// p256SPKIPrefix is the DER SubjectPublicKeyInfo header for an uncompressed
+// P-256 point. Secure enclaves (SecKeyCopyExternalRepresentation on iOS,
+// Android Keystore's raw export) hand out the bare 65-byte point, so it is
+// wrapped in this header before parsing.
+var p256SPKIPrefix = []byte{
+	0x30, 0x59, 0x30, 0x13, 0x06, 0x07, 0x2a, 0x86, 0x48, 0xce, 0x3d, 0x02, 0x01,
+	0x06, 0x08, 0x2a, 0x86, 0x48, 0xce, 0x3d, 0x03, 0x01, 0x07, 0x03, 0x42, 0x00,
+}
+
+// ParsePublicKey reads a public key encoded as a PEM "PUBLIC KEY" block, as
+// base64 PKIX DER, or as a base64 uncompressed P-256 point.
+func ParsePublicKey(encoded string) (crypto.PublicKey, error) {
+	encoded = strings.TrimSpace(encoded)
+
+	if block, _ := pem.Decode([]byte(encoded)); block != nil {
+		publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse public key: %w", err)
+		}
+		return publicKey, nil
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode public key: expected PEM or base64")
+	}
+
+	if len(raw) == 65 && raw[0] == 0x04 {
+		raw = append(append([]byte{}, p256SPKIPrefix...), raw...)
+	}
+
+	publicKey, err := x509.ParsePKIXPublicKey(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+	return publicKey, nil
+}
+
+// EncodePublicKeyPEM encodes publicKey as a PEM "PUBLIC KEY" block.
+func EncodePublicKeyPEM(publicKey crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return "", err
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})), nil
+}
+
+// ParsePrivateKey reads an RSA or ECDSA private key from PKCS #1
+// ("RSA PRIVATE KEY"), SEC 1 ("EC PRIVATE KEY") or PKCS #8 ("PRIVATE KEY")
+// PEM.
+func ParsePrivateKey(privateKeyPEM string) (crypto.Signer, error) {
+	block, _ := pem.Decode([]byte(privateKeyPEM))
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode private key PEM")
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	default:
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("unsupported private key type %T", key)
+		}
+		return signer, nil
+	}
+}
+
+// EncodePrivateKeyPEM encodes privateKey as a PKCS #8 "PRIVATE KEY" block.
+func EncodePrivateKeyPEM(privateKey crypto.Signer) (string, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return "", err
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})), nil
+}
+
+// ParseCertificatePEM reads the first certificate in certificatePEM.
+func ParseCertificatePEM(certificatePEM string) (*x509.Certificate, error) {
+	block, _ := pem.Decode([]byte(certificatePEM))
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("failed to decode certificate PEM")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+func publicKeysEqual(a, b crypto.PublicKey) bool {
+	key, ok := a.(interface{ Equal(crypto.PublicKey) bool })
+	return ok && key.Equal(b)
+}
@@ -0,0 +1,125 @@
+package cert
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"time"
+)
+
+// The functions below run the offline half of a two-tier CA: the root key
+// signs an intermediate once and then goes back in the safe, and the
+// online service only ever holds the intermediate's key.
+
+// GenerateRootCA creates a self-signed ECDSA P-256 root that may only sign
+// a single level of intermediates.
+func GenerateRootCA(commonName string, validity time.Duration) (certificatePEM string, privateKeyPEM string, err error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+
+	serialNumber, err := newSerialNumber()
+	if err != nil {
+		return "", "", err
+	}
+	template := &x509.Certificate{
+		SerialNumber:          serialNumber,
+		Subject:               caSubject(commonName),
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(validity),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		MaxPathLen:            1,
+	}
+
+	certificateDER, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		return "", "", err
+	}
+
+	privateKeyPEM, err = EncodePrivateKeyPEM(key)
+	if err != nil {
+		return "", "", err
+	}
+	return encodeCertificatePEM(certificateDER), privateKeyPEM, nil
+}
+
+// GeneratePrivateKey creates an ECDSA P-256 key, returning it and its public
+// half as PEM.
+func GeneratePrivateKey() (privateKeyPEM string, publicKeyPEM string, err error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+	if privateKeyPEM, err = EncodePrivateKeyPEM(key); err != nil {
+		return "", "", err
+	}
+	if publicKeyPEM, err = EncodePublicKeyPEM(key.Public()); err != nil {
+		return "", "", err
+	}
+	return privateKeyPEM, publicKeyPEM, nil
+}
+
+// IssueIntermediateCA signs an intermediate CA certificate for publicKeyPEM
+// with the root. The intermediate can sign user certificates, CRLs and OCSP
+// responses but not further CAs.
+func IssueIntermediateCA(rootCertificatePEM, rootPrivateKeyPEM, publicKeyPEM, commonName string, validity time.Duration) (string, error) {
+	rootCert, err := ParseCertificatePEM(rootCertificatePEM)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse root certificate: %w", err)
+	}
+	rootKey, err := ParsePrivateKey(rootPrivateKeyPEM)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse root private key: %w", err)
+	}
+	if !publicKeysEqual(rootKey.Public(), rootCert.PublicKey) {
+		return "", fmt.Errorf("the root private key does not match the root certificate")
+	}
+	publicKey, err := ParsePublicKey(publicKeyPEM)
+	if err != nil {
+		return "", err
+	}
+
+	serialNumber, err := newSerialNumber()
+	if err != nil {
+		return "", err
+	}
+	notAfter := time.Now().Add(validity)
+	if notAfter.After(rootCert.NotAfter) {
+		notAfter = rootCert.NotAfter
+	}
+	template := &x509.Certificate{
+		SerialNumber:          serialNumber,
+		Subject:               caSubject(commonName),
+		NotBefore:             time.Now(),
+		NotAfter:              notAfter,
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		MaxPathLenZero:        true,
+	}
+
+	certificateDER, err := x509.CreateCertificate(rand.Reader, template, rootCert, publicKey, rootKey)
+	if err != nil {
+		return "", err
+	}
+	return encodeCertificatePEM(certificateDER), nil
+}
+
+func caSubject(commonName string) pkix.Name {
+	return pkix.Name{
+		Organization: []string{"Verifiable SN"},
+		Country:      []string{"US"},
+		CommonName:   commonName,
+	}
+}
+
+func encodeCertificatePEM(certificateDER []byte) string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificateDER}))
+}
@@ -0,0 +1,353 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type achievementHandle struct {
+	db *gorm.DB
+}
+
+// achievementMetricSource describes where the records behind a metric live.
+// zoneColumn is the column that ties a record to a zone.
+type achievementMetricSource struct {
+	from       string
+	zoneColumn string
+	countExpr  string
+}
+
+var achievementMetricSources = map[models.AchievementMetric]achievementMetricSource{
+	models.AchievementMetricChallengeCompletions: {
+		from:       "user_challenge_completions r JOIN challenges src ON src.id = r.challenge_id",
+		zoneColumn: "src.zone_id",
+		countExpr:  "COUNT(DISTINCT r.challenge_id)",
+	},
+	models.AchievementMetricMonsterEncounterVictories: {
+		from:       "user_monster_encounter_victories r JOIN monster_encounters src ON src.id = r.monster_encounter_id",
+		zoneColumn: "src.zone_id",
+		countExpr:  "COUNT(DISTINCT r.monster_encounter_id)",
+	},
+	models.AchievementMetricTreasureChestsOpened: {
+		from:       "user_treasure_chest_openings r JOIN treasure_chests src ON src.id = r.treasure_chest_id",
+		zoneColumn: "src.zone_id",
+		countExpr:  "COUNT(DISTINCT r.treasure_chest_id)",
+	},
+	models.AchievementMetricShrineUses: {
+		from:       "user_shrine_uses r JOIN shrines src ON src.id = r.shrine_id",
+		zoneColumn: "src.zone_id",
+		countExpr:  "COUNT(*)",
+	},
+	models.AchievementMetricHealingFountainsDiscovered: {
+		from:       "user_healing_fountain_discoveries r JOIN healing_fountains src ON src.id = r.healing_fountain_id",
+		zoneColumn: "src.zone_id",
+		countExpr:  "COUNT(DISTINCT r.healing_fountain_id)",
+	},
+	models.AchievementMetricZonesDiscovered: {
+		from:       "zone_discoveries r",
+		zoneColumn: "r.zone_id",
+		countExpr:  "COUNT(DISTINCT r.zone_id)",
+	},
+}
+
+func (h *achievementHandle) CreateDefinition(ctx context.Context, definition *models.AchievementDefinition) error {
+	now := time.Now()
+	if definition.ID == uuid.Nil {
+		definition.ID = uuid.New()
+	}
+	definition.CreatedAt = now
+	definition.UpdatedAt = now
+	return h.db.WithContext(ctx).Create(definition).Error
+}
+
+func (h *achievementHandle) UpdateDefinition(ctx context.Context, definition *models.AchievementDefinition) error {
+	definition.UpdatedAt = time.Now()
+	return h.db.WithContext(ctx).Save(definition).Error
+}
+
+func (h *achievementHandle) DeleteDefinition(ctx context.Context, id uuid.UUID) error {
+	return h.db.WithContext(ctx).Delete(&models.AchievementDefinition{}, "id = ?", id).Error
+}
+
+func (h *achievementHandle) FindDefinitionByID(ctx context.Context, id uuid.UUID) (*models.AchievementDefinition, error) {
+	definition := &models.AchievementDefinition{}
+	if err := h.db.WithContext(ctx).Where("id = ?", id).First(definition).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return definition, nil
+}
+
+func (h *achievementHandle) FindDefinitions(ctx context.Context, includeInactive bool) ([]models.AchievementDefinition, error) {
+	definitions := []models.AchievementDefinition{}
+	query := h.db.WithContext(ctx)
+	if !includeInactive {
+		query = query.Where("active = ?", true)
+	}
+	if err := query.Order("sort_order ASC, created_at ASC").Find(&definitions).Error; err != nil {
+		return nil, err
+	}
+	return definitions, nil
+}
+
+// CountMetric returns the user's current count for the definition's metric,
+// honoring its zone, zone kind and genre filters.
+func (h *achievementHandle) CountMetric(ctx context.Context, userID uuid.UUID, definition *models.AchievementDefinition) (int, error) {
+	if definition.Metric == models.AchievementMetricUserLevel {
+		var level int
+		if err := h.db.WithContext(ctx).
+			Raw("SELECT COALESCE(MAX(level), 0) FROM user_levels WHERE user_id = ?", userID).
+			Scan(&level).Error; err != nil {
+			return 0, err
+		}
+		return level, nil
+	}
+
+	source, ok := achievementMetricSources[definition.Metric]
+	if !ok {
+		return 0, fmt.Errorf("unsupported achievement metric %q", definition.Metric)
+	}
+
+	query := "SELECT " + source.countExpr + " FROM " + source.from
+	conditions := []string{"r.user_id = ?"}
+	args := []interface{}{userID}
+	if kind := strings.TrimSpace(definition.ZoneKind); kind != "" {
+		query += " JOIN zones z ON z.id = " + source.zoneColumn
+		conditions = append(conditions, "z.kind = ?")
+		args = append(args, kind)
+	}
+	if definition.ZoneID != nil {
+		conditions = append(conditions, source.zoneColumn+" = ?")
+		args = append(args, *definition.ZoneID)
+	}
+	if definition.GenreID != nil {
+		conditions = append(conditions, "EXISTS (SELECT 1 FROM zone_genre_scores zgs WHERE zgs.zone_id = "+
+			source.zoneColumn+" AND zgs.genre_id = ? AND zgs.score > 0)")
+		args = append(args, *definition.GenreID)
+	}
+	query += " WHERE " + strings.Join(conditions, " AND ")
+
+	var count int
+	if err := h.db.WithContext(ctx).Raw(query, args...).Scan(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// Evaluate recounts every matching definition for the user and stores the
+// progress. It returns the achievements that unlocked during this call, with
+// their definitions loaded. Titles are granted as part of the unlock.
+func (h *achievementHandle) Evaluate(ctx context.Context, evaluation models.AchievementEvaluation) ([]models.UserAchievement, error) {
+	now := evaluation.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	query := h.db.WithContext(ctx).Where("active = ?", true)
+	if evaluation.AchievementID != nil {
+		query = query.Where("id = ?", *evaluation.AchievementID)
+	}
+	if len(evaluation.Metrics) > 0 {
+		query = query.Where("metric IN ?", evaluation.Metrics)
+	}
+	definitions := []models.AchievementDefinition{}
+	if err := query.Find(&definitions).Error; err != nil {
+		return nil, err
+	}
+
+	unlocked := []models.UserAchievement{}
+	for i := range definitions {
+		definition := &definitions[i]
+		if !definition.TracksZone(evaluation.ZoneID) {
+			continue
+		}
+		count, err := h.CountMetric(ctx, evaluation.UserID, definition)
+		if err != nil {
+			return unlocked, err
+		}
+		if count <= 0 {
+			continue
+		}
+		record, unlockedNow, err := h.recordProgress(ctx, evaluation.UserID, definition, count, evaluation.Backfill, now)
+		if err != nil {
+			return unlocked, err
+		}
+		if unlockedNow {
+			record.Achievement = *definition
+			unlocked = append(unlocked, *record)
+		}
+	}
+	return unlocked, nil
+}
+
+func (h *achievementHandle) recordProgress(
+	ctx context.Context,
+	userID uuid.UUID,
+	definition *models.AchievementDefinition,
+	count int,
+	backfill bool,
+	now time.Time,
+) (*models.UserAchievement, bool, error) {
+	record := &models.UserAchievement{}
+	unlockedNow := false
+	err := h.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Clauses(clause.OnConflict{DoNothing: true}).Create(&models.UserAchievement{
+			ID:            uuid.New(),
+			CreatedAt:     now,
+			UpdatedAt:     now,
+			UserID:        userID,
+			AchievementID: definition.ID,
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? AND achievement_id = ?", userID, definition.ID).
+			First(record).Error; err != nil {
+			return err
+		}
+
+		updates := map[string]interface{}{
+			"progress":   count,
+			"updated_at": now,
+		}
+		if record.UnlockedAt == nil && count >= definition.Threshold {
+			unlockedNow = true
+			updates["unlocked_at"] = now
+			updates["backfilled"] = backfill
+			if !definition.HasRewards() {
+				updates["rewards_granted_at"] = now
+			}
+		}
+		if err := tx.Model(record).Updates(updates).Error; err != nil {
+			return err
+		}
+		if unlockedNow {
+			record.UnlockedAt = &now
+			record.Backfilled = backfill
+			if !definition.HasRewards() {
+				record.RewardsGrantedAt = &now
+			}
+		}
+		record.Progress = count
+
+		title := strings.TrimSpace(definition.TitleName)
+		if !unlockedNow || title == "" {
+			return nil
+		}
+		achievementID := definition.ID
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.UserTitle{
+			ID:            uuid.New(),
+			CreatedAt:     now,
+			UpdatedAt:     now,
+			UserID:        userID,
+			AchievementID: &achievementID,
+			Title:         title,
+		}).Error
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return record, unlockedNow, nil
+}
+
+func (h *achievementHandle) FindUserAchievements(ctx context.Context, userID uuid.UUID) ([]models.UserAchievement, error) {
+	records := []models.UserAchievement{}
+	if err := h.db.WithContext(ctx).
+		Preload("Achievement").
+		Where("user_id = ?", userID).
+		Find(&records).Error; err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// FindUnclaimedRewards returns unlocked achievements whose rewards haven't
+// been paid out yet, which is how backfilled unlocks are left.
+func (h *achievementHandle) FindUnclaimedRewards(ctx context.Context, userID uuid.UUID) ([]models.UserAchievement, error) {
+	records := []models.UserAchievement{}
+	if err := h.db.WithContext(ctx).
+		Preload("Achievement").
+		Where("user_id = ? AND unlocked_at IS NOT NULL AND rewards_granted_at IS NULL", userID).
+		Order("unlocked_at ASC").
+		Find(&records).Error; err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// MarkRewardsGranted claims the achievement's rewards. It reports false when
+// another request already claimed them, so callers grant rewards only once.
+func (h *achievementHandle) MarkRewardsGranted(ctx context.Context, userAchievementID uuid.UUID, now time.Time) (bool, error) {
+	result := h.db.WithContext(ctx).
+		Model(&models.UserAchievement{}).
+		Where("id = ? AND unlocked_at IS NOT NULL AND rewards_granted_at IS NULL", userAchievementID).
+		Updates(map[string]interface{}{
+			"rewards_granted_at": now,
+			"updated_at":         now,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (h *achievementHandle) FindUserTitles(ctx context.Context, userID uuid.UUID) ([]models.UserTitle, error) {
+	titles := []models.UserTitle{}
+	if err := h.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at ASC").
+		Find(&titles).Error; err != nil {
+		return nil, err
+	}
+	return titles, nil
+}
+
+// SetActiveTitle displays one of the user's titles, or clears the displayed
+// title when titleID is nil.
+func (h *achievementHandle) SetActiveTitle(ctx context.Context, userID uuid.UUID, titleID *uuid.UUID) error {
+	return h.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.UserTitle{}).
+			Where("user_id = ? AND active = ?", userID, true).
+			Updates(map[string]interface{}{"active": false, "updated_at": time.Now()}).Error; err != nil {
+			return err
+		}
+		if titleID == nil {
+			return nil
+		}
+		result := tx.Model(&models.UserTitle{}).
+			Where("id = ? AND user_id = ?", *titleID, userID).
+			Updates(map[string]interface{}{"active": true, "updated_at": time.Now()})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+// FindUserIDsAfter pages through every user in ID order for backfills.
+func (h *achievementHandle) FindUserIDsAfter(ctx context.Context, after uuid.UUID, limit int) ([]uuid.UUID, error) {
+	userIDs := []uuid.UUID{}
+	if err := h.db.WithContext(ctx).
+		Model(&models.User{}).
+		Where("id > ?", after).
+		Order("id ASC").
+		Limit(limit).
+		Pluck("id", &userIDs).Error; err != nil {
+		return nil, err
+	}
+	return userIDs, nil
+}
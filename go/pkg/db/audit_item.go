@@ -25,6 +25,30 @@ func (h *auditItemHandler) Create(ctx context.Context, matchID *uuid.UUID, userI
 	return h.db.WithContext(ctx).Create(auditItem).Error
 }
 
+// CreatePrivilegedCall records a request admitted by a scope check. The
+// caller fills in UserID, Message and the request fields.
+func (h *auditItemHandler) CreatePrivilegedCall(ctx context.Context, item *models.AuditItem) error {
+	now := time.Now()
+	item.ID = uuid.New()
+	item.CreatedAt = now
+	item.UpdatedAt = now
+	return h.db.WithContext(ctx).Create(item).Error
+}
+
+// GetPrivilegedCalls returns the most recent privileged calls, optionally
+// only those made by one user.
+func (h *auditItemHandler) GetPrivilegedCalls(ctx context.Context, userID *uuid.UUID, limit int) ([]*models.AuditItem, error) {
+	var auditItems []*models.AuditItem
+	query := h.db.WithContext(ctx).Preload("User").Where("scope IS NOT NULL")
+	if userID != nil {
+		query = query.Where("user_id = ?", *userID)
+	}
+	if err := query.Order("created_at DESC").Limit(limit).Find(&auditItems).Error; err != nil {
+		return nil, err
+	}
+	return auditItems, nil
+}
+
 func (h *auditItemHandler) GetAuditItemsForMatch(ctx context.Context, matchID uuid.UUID) ([]*models.AuditItem, error) {
 	var auditItems []*models.AuditItem
 	if err := h.db.WithContext(ctx).Where("match_id = ?", matchID).Order("created_at DESC").Find(&auditItems).Error; err != nil {
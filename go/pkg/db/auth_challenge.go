@@ -0,0 +1,70 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type authChallengeHandle struct {
+	db *gorm.DB
+}
+
+func (h *authChallengeHandle) Create(ctx context.Context, challenge *models.AuthChallenge) error {
+	challenge.ID = uuid.New()
+	challenge.CreatedAt = time.Now()
+	return h.db.WithContext(ctx).Create(challenge).Error
+}
+
+// FindActive returns the unexpired, unconsumed challenge of the given kind
+// with the given hash, or nil.
+func (h *authChallengeHandle) FindActive(ctx context.Context, kind string, challengeHash string) (*models.AuthChallenge, error) {
+	var challenge models.AuthChallenge
+	err := h.db.WithContext(ctx).
+		Where("kind = ? AND challenge_hash = ? AND consumed_at IS NULL AND expires_at > ?", kind, challengeHash, time.Now()).
+		First(&challenge).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &challenge, nil
+}
+
+// Consume marks the challenge used, reporting false when another request
+// got there first.
+func (h *authChallengeHandle) Consume(ctx context.Context, id uuid.UUID) (bool, error) {
+	result := h.db.WithContext(ctx).Model(&models.AuthChallenge{}).
+		Where("id = ? AND consumed_at IS NULL", id).
+		Update("consumed_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
+
+// RecordFailedAttempt counts a wrong answer and consumes the challenge once
+// maxAttempts is reached. It returns the attempts used so far.
+func (h *authChallengeHandle) RecordFailedAttempt(ctx context.Context, id uuid.UUID, maxAttempts int) (int, error) {
+	var challenge models.AuthChallenge
+	err := h.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.AuthChallenge{}).Where("id = ?", id).
+			Update("attempts", gorm.Expr("attempts + 1")).Error; err != nil {
+			return err
+		}
+		if err := tx.First(&challenge, "id = ?", id).Error; err != nil {
+			return err
+		}
+		if challenge.Attempts >= maxAttempts && challenge.ConsumedAt == nil {
+			return tx.Model(&models.AuthChallenge{}).Where("id = ?", id).Update("consumed_at", time.Now()).Error
+		}
+		return nil
+	})
+	return challenge.Attempts, err
+}
+
+func (h *authChallengeHandle) DeleteExpired(ctx context.Context) (int64, error) {
+	result := h.db.WithContext(ctx).Where("expires_at < ?", time.Now()).Delete(&models.AuthChallenge{})
+	return result.RowsAffected, result.Error
+}
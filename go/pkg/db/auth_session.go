@@ -0,0 +1,127 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type authSessionHandle struct {
+	db *gorm.DB
+}
+
+func (h *authSessionHandle) Create(ctx context.Context, session *models.AuthSession) error {
+	now := time.Now()
+	session.ID = uuid.New()
+	session.CreatedAt = now
+	session.UpdatedAt = now
+	session.LastUsedAt = now
+	return h.db.WithContext(ctx).Create(session).Error
+}
+
+func (h *authSessionHandle) FindByID(ctx context.Context, id uuid.UUID) (*models.AuthSession, error) {
+	var session models.AuthSession
+	if err := h.db.WithContext(ctx).First(&session, "id = ?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+// FindByRefreshToken matches either the session's current refresh token or
+// the one it replaced, so callers can tell a replayed token from an unknown
+// one.
+func (h *authSessionHandle) FindByRefreshToken(ctx context.Context, refreshToken string) (*models.AuthSession, bool, error) {
+	hash := models.HashRefreshToken(refreshToken)
+	var session models.AuthSession
+	err := h.db.WithContext(ctx).
+		Where("refresh_token_hash = ? OR previous_refresh_token_hash = ?", hash, hash).
+		First(&session).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return &session, session.RefreshTokenHash == hash, nil
+}
+
+func (h *authSessionHandle) FindActiveByUserID(ctx context.Context, userID uuid.UUID) ([]models.AuthSession, error) {
+	var sessions []models.AuthSession
+	if err := h.db.WithContext(ctx).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_used_at DESC").
+		Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// Rotate swaps in a new refresh token, but only if the presented one is
+// still current; false means another refresh won the race.
+func (h *authSessionHandle) Rotate(
+	ctx context.Context,
+	id uuid.UUID,
+	refreshToken string,
+	nextRefreshToken string,
+	ipAddress string,
+	expiresAt time.Time,
+) (bool, error) {
+	now := time.Now()
+	hash := models.HashRefreshToken(refreshToken)
+	result := h.db.WithContext(ctx).Model(&models.AuthSession{}).
+		Where("id = ? AND refresh_token_hash = ? AND revoked_at IS NULL", id, hash).
+		Updates(map[string]interface{}{
+			"refresh_token_hash":          models.HashRefreshToken(nextRefreshToken),
+			"previous_refresh_token_hash": hash,
+			"ip_address":                  ipAddress,
+			"last_used_at":                now,
+			"expires_at":                  expiresAt,
+			"updated_at":                  now,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (h *authSessionHandle) Revoke(ctx context.Context, id uuid.UUID) error {
+	now := time.Now()
+	return h.db.WithContext(ctx).Model(&models.AuthSession{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Updates(map[string]interface{}{"revoked_at": now, "updated_at": now}).Error
+}
+
+// MarkMFAVerified records that the session's user passed a second factor
+// after it started, e.g. by confirming TOTP enrollment.
+func (h *authSessionHandle) MarkMFAVerified(ctx context.Context, id uuid.UUID) error {
+	now := time.Now()
+	return h.db.WithContext(ctx).Model(&models.AuthSession{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"mfa_verified_at": now, "updated_at": now}).Error
+}
+
+// ClearMFAVerifiedForUser drops the second-factor mark from every session,
+// for when the user turns two-factor off.
+func (h *authSessionHandle) ClearMFAVerifiedForUser(ctx context.Context, userID uuid.UUID) error {
+	return h.db.WithContext(ctx).Model(&models.AuthSession{}).
+		Where("user_id = ? AND mfa_verified_at IS NOT NULL", userID).
+		Updates(map[string]interface{}{"mfa_verified_at": nil, "updated_at": time.Now()}).Error
+}
+
+// RevokeAllForUser signs the user out everywhere, optionally keeping the
+// session the request came from.
+func (h *authSessionHandle) RevokeAllForUser(ctx context.Context, userID uuid.UUID, except *uuid.UUID) (int64, error) {
+	now := time.Now()
+	query := h.db.WithContext(ctx).Model(&models.AuthSession{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID)
+	if except != nil {
+		query = query.Where("id <> ?", *except)
+	}
+	result := query.Updates(map[string]interface{}{"revoked_at": now, "updated_at": now})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,97 @@
+package db
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type authorizationHandle struct {
+	db *gorm.DB
+}
+
+func (h *authorizationHandle) ListRoles(ctx context.Context) ([]models.Role, error) {
+	var roles []models.Role
+	if err := h.db.WithContext(ctx).Order("name ASC").Find(&roles).Error; err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+func (h *authorizationHandle) FindRoleByName(ctx context.Context, name string) (*models.Role, error) {
+	var role models.Role
+	if err := h.db.WithContext(ctx).First(&role, "name = ?", name).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &role, nil
+}
+
+// GrantRole is a no-op when the user already holds the role. grantedBy is
+// nil for the ops-only bootstrap grant.
+func (h *authorizationHandle) GrantRole(ctx context.Context, userID uuid.UUID, roleID uuid.UUID, grantedBy *uuid.UUID) error {
+	return h.db.WithContext(ctx).
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "user_id"}, {Name: "role_id"}}, DoNothing: true}).
+		Create(&models.UserRole{
+			ID:        uuid.New(),
+			CreatedAt: time.Now(),
+			UserID:    userID,
+			RoleID:    roleID,
+			GrantedBy: grantedBy,
+		}).Error
+}
+
+// RevokeRole reports whether the user held the role.
+func (h *authorizationHandle) RevokeRole(ctx context.Context, userID uuid.UUID, roleID uuid.UUID) (bool, error) {
+	result := h.db.WithContext(ctx).Delete(&models.UserRole{}, "user_id = ? AND role_id = ?", userID, roleID)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (h *authorizationHandle) FindRolesForUser(ctx context.Context, userID uuid.UUID) ([]models.UserRole, error) {
+	var grants []models.UserRole
+	if err := h.db.WithContext(ctx).Preload("Role").
+		Where("user_id = ?", userID).Order("created_at ASC").Find(&grants).Error; err != nil {
+		return nil, err
+	}
+	return grants, nil
+}
+
+func (h *authorizationHandle) ListGrants(ctx context.Context) ([]models.UserRole, error) {
+	var grants []models.UserRole
+	if err := h.db.WithContext(ctx).Preload("Role").Preload("User").
+		Order("created_at ASC").Find(&grants).Error; err != nil {
+		return nil, err
+	}
+	return grants, nil
+}
+
+// FindScopesForUser flattens the scopes of every role the user holds, in a
+// stable order so tokens minted from them compare equal.
+func (h *authorizationHandle) FindScopesForUser(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	grants, err := h.FindRolesForUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	scopes := []string{}
+	for _, grant := range grants {
+		if grant.Role == nil {
+			continue
+		}
+		for _, scope := range grant.Role.Scopes {
+			if !seen[scope] {
+				seen[scope] = true
+				scopes = append(scopes, scope)
+			}
+		}
+	}
+	sort.Strings(scopes)
+	return scopes, nil
+}
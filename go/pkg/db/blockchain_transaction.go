@@ -10,24 +10,83 @@ import (
 	"github.com/MaxBlaushild/poltergeist/pkg/models"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type blockchainTransactionHandle struct {
 	db *gorm.DB
 }
 
+// Create records a transaction, along with the event for sending it. A
+// transaction without a TxHash is saved before it's sent, holding its nonce,
+// and SetBroadcast records its hash once it has been.
 func (h *blockchainTransactionHandle) Create(ctx context.Context, tx *models.BlockchainTransaction) (*models.BlockchainTransaction, error) {
-	tx.CreatedAt = time.Now()
-	tx.UpdatedAt = time.Now()
+	now := time.Now()
+	tx.CreatedAt = now
+	tx.UpdatedAt = now
 	if tx.ExpiresAt.IsZero() {
-		tx.ExpiresAt = time.Now().Add(24 * time.Hour)
+		tx.ExpiresAt = now.Add(24 * time.Hour)
+	}
+	reason := "saved before sending"
+	if tx.TxHash != nil {
+		reason = "sent"
+		if tx.BroadcastAt == nil {
+			tx.BroadcastAt = &now
+		}
 	}
-	if err := h.db.WithContext(ctx).Create(tx).Error; err != nil {
+	err := h.db.WithContext(ctx).Transaction(func(db *gorm.DB) error {
+		if err := db.Create(tx).Error; err != nil {
+			return err
+		}
+		return db.Create(&models.BlockchainTransactionEvent{
+			CreatedAt:               now,
+			BlockchainTransactionID: tx.ID,
+			ToStatus:                tx.Status,
+			TxHash:                  tx.TxHash,
+			GasPrice:                tx.GasPrice,
+			GasTipCap:               tx.GasTipCap,
+			GasFeeCap:               tx.GasFeeCap,
+			Reason:                  reason,
+		}).Error
+	})
+	if err != nil {
 		return nil, err
 	}
 	return tx, nil
 }
 
+// SetBroadcast records the hash of a saved transaction that has now been
+// sent.
+func (h *blockchainTransactionHandle) SetBroadcast(ctx context.Context, id uuid.UUID, txHash string) error {
+	return h.db.WithContext(ctx).Transaction(func(db *gorm.DB) error {
+		var current models.BlockchainTransaction
+		if err := db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&current).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if err := db.Model(&models.BlockchainTransaction{}).Where("id = ?", id).Updates(map[string]interface{}{
+			"tx_hash":      txHash,
+			"broadcast_at": now,
+			"updated_at":   now,
+		}).Error; err != nil {
+			return err
+		}
+
+		return db.Create(&models.BlockchainTransactionEvent{
+			CreatedAt:               now,
+			BlockchainTransactionID: id,
+			FromStatus:              &current.Status,
+			ToStatus:                current.Status,
+			TxHash:                  &txHash,
+			GasPrice:                current.GasPrice,
+			GasTipCap:               current.GasTipCap,
+			GasFeeCap:               current.GasFeeCap,
+			Reason:                  "sent",
+		}).Error
+	})
+}
+
 func (h *blockchainTransactionHandle) FindByID(ctx context.Context, id uuid.UUID) (*models.BlockchainTransaction, error) {
 	var tx models.BlockchainTransaction
 	if err := h.db.WithContext(ctx).Where("id = ?", id).First(&tx).Error; err != nil {
@@ -68,24 +127,142 @@ func (h *blockchainTransactionHandle) FindPendingExpired(ctx context.Context) ([
 }
 
 func (h *blockchainTransactionHandle) UpdateStatus(ctx context.Context, id uuid.UUID, status string, blockNumber *uint64, confirmedAt *time.Time) error {
-	updates := map[string]interface{}{
-		"status":     status,
-		"updated_at": time.Now(),
-	}
-	if blockNumber != nil {
-		updates["block_number"] = *blockNumber
+	return h.SetStatus(ctx, id, models.BlockchainTransactionStatusChange{
+		Status:      models.BlockchainTransactionStatus(status),
+		BlockNumber: blockNumber,
+		ConfirmedAt: confirmedAt,
+	})
+}
+
+// SetStatus moves a transaction to a new status and records the transition.
+func (h *blockchainTransactionHandle) SetStatus(ctx context.Context, id uuid.UUID, change models.BlockchainTransactionStatusChange) error {
+	return h.db.WithContext(ctx).Transaction(func(db *gorm.DB) error {
+		var current models.BlockchainTransaction
+		if err := db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&current).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		updates := map[string]interface{}{
+			"status":     string(change.Status),
+			"updated_at": now,
+		}
+		txHash := current.TxHash
+		if change.TxHash != nil {
+			updates["tx_hash"] = *change.TxHash
+			txHash = change.TxHash
+		}
+		if change.Status == models.PendingStatus {
+			// A transaction that's pending again, say after a reorg, waits
+			// out a fresh replacement period.
+			updates["block_number"] = nil
+			updates["block_hash"] = nil
+			updates["confirmed_at"] = nil
+			updates["broadcast_at"] = now
+		} else {
+			if change.BlockNumber != nil {
+				updates["block_number"] = *change.BlockNumber
+			}
+			if change.BlockHash != nil {
+				updates["block_hash"] = *change.BlockHash
+			}
+			if change.ConfirmedAt != nil {
+				updates["confirmed_at"] = *change.ConfirmedAt
+			}
+		}
+
+		if err := db.Model(&models.BlockchainTransaction{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		return db.Create(&models.BlockchainTransactionEvent{
+			CreatedAt:               now,
+			BlockchainTransactionID: id,
+			FromStatus:              &current.Status,
+			ToStatus:                string(change.Status),
+			TxHash:                  txHash,
+			BlockNumber:             change.BlockNumber,
+			Reason:                  change.Reason,
+		}).Error
+	})
+}
+
+// Replace records a new attempt at a pending transaction's nonce. Earlier
+// attempts' hashes are kept, since any of them may still be mined.
+func (h *blockchainTransactionHandle) Replace(ctx context.Context, id uuid.UUID, replacement models.BlockchainTransactionReplacement) error {
+	return h.db.WithContext(ctx).Transaction(func(db *gorm.DB) error {
+		var current models.BlockchainTransaction
+		if err := db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&current).Error; err != nil {
+			return err
+		}
+
+		replaced := append(models.StringArray{}, current.ReplacedTxHashes...)
+		if current.TxHash != nil {
+			replaced = append(replaced, *current.TxHash)
+		}
+
+		now := time.Now()
+		if err := db.Model(&models.BlockchainTransaction{}).Where("id = ?", id).Updates(map[string]interface{}{
+			"tx_hash":            replacement.TxHash,
+			"gas_price":          replacement.GasPrice,
+			"gas_tip_cap":        replacement.GasTipCap,
+			"gas_fee_cap":        replacement.GasFeeCap,
+			"replaced_tx_hashes": replaced,
+			"broadcast_at":       now,
+			"updated_at":         now,
+		}).Error; err != nil {
+			return err
+		}
+
+		return db.Create(&models.BlockchainTransactionEvent{
+			CreatedAt:               now,
+			BlockchainTransactionID: id,
+			FromStatus:              &current.Status,
+			ToStatus:                current.Status,
+			TxHash:                  &replacement.TxHash,
+			GasPrice:                replacement.GasPrice,
+			GasTipCap:               replacement.GasTipCap,
+			GasFeeCap:               replacement.GasFeeCap,
+			Reason:                  replacement.Reason,
+		}).Error
+	})
+}
+
+// FindPendingByAccount returns an account's pending transactions in nonce
+// order, including ones past their expiry, since each holds a nonce that
+// later transactions wait on.
+func (h *blockchainTransactionHandle) FindPendingByAccount(ctx context.Context, chainID int64, fromAddress string) ([]models.BlockchainTransaction, error) {
+	var txs []models.BlockchainTransaction
+	if err := h.db.WithContext(ctx).
+		Where("chain_id = ? AND from_address = ? AND status = ?", chainID, fromAddress, string(models.PendingStatus)).
+		Order("nonce ASC").
+		Find(&txs).Error; err != nil {
+		return nil, err
 	}
-	if confirmedAt != nil {
-		updates["confirmed_at"] = *confirmedAt
+	return txs, nil
+}
+
+// FindMinedSinceBlock returns an account's transactions mined at or after
+// blockNumber, whether they succeeded or not.
+func (h *blockchainTransactionHandle) FindMinedSinceBlock(ctx context.Context, chainID int64, fromAddress string, blockNumber uint64) ([]models.BlockchainTransaction, error) {
+	var txs []models.BlockchainTransaction
+	if err := h.db.WithContext(ctx).
+		Where("chain_id = ? AND from_address = ? AND status IN ? AND block_number >= ?",
+			chainID, fromAddress, []string{string(models.ConfirmedStatus), string(models.FailedStatus)}, blockNumber).
+		Order("block_number ASC").
+		Find(&txs).Error; err != nil {
+		return nil, err
 	}
-	return h.db.WithContext(ctx).Model(&models.BlockchainTransaction{}).Where("id = ?", id).Updates(updates).Error
+	return txs, nil
 }
 
+// GetNextNonce returns the nonce after the account's last recorded
+// transaction, skipping ones that were never sent.
 func (h *blockchainTransactionHandle) GetNextNonce(ctx context.Context, chainID int64, fromAddress string) (uint64, error) {
 	var maxNonce sql.NullInt64
 	err := h.db.WithContext(ctx).
 		Model(&models.BlockchainTransaction{}).
-		Where("chain_id = ? AND from_address = ?", chainID, fromAddress).
+		Where("chain_id = ? AND from_address = ? AND status <> ?", chainID, fromAddress, string(models.UnsentStatus)).
 		Select("COALESCE(MAX(nonce), -1)").
 		Scan(&maxNonce).Error
 
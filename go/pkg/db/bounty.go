@@ -0,0 +1,221 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type bountyHandle struct {
+	db *gorm.DB
+}
+
+func (h *bountyHandle) CreateTemplate(ctx context.Context, template *models.BountyTemplate) error {
+	return h.db.WithContext(ctx).Create(template).Error
+}
+
+func (h *bountyHandle) UpdateTemplate(ctx context.Context, template *models.BountyTemplate) error {
+	return h.db.WithContext(ctx).Save(template).Error
+}
+
+func (h *bountyHandle) DeleteTemplate(ctx context.Context, id uuid.UUID) error {
+	return h.db.WithContext(ctx).Delete(&models.BountyTemplate{}, "id = ?", id).Error
+}
+
+func (h *bountyHandle) FindTemplateByID(ctx context.Context, id uuid.UUID) (*models.BountyTemplate, error) {
+	var template models.BountyTemplate
+	if err := h.db.WithContext(ctx).Where("id = ?", id).First(&template).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &template, nil
+}
+
+func (h *bountyHandle) FindTemplates(ctx context.Context, includeInactive bool) ([]models.BountyTemplate, error) {
+	templates := []models.BountyTemplate{}
+	query := h.db.WithContext(ctx).Order("cadence ASC, name ASC")
+	if !includeInactive {
+		query = query.Where("active = ?", true)
+	}
+	if err := query.Find(&templates).Error; err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+// FindProgressForZone returns the user's progress rows for the zone in any of
+// the given periods.
+func (h *bountyHandle) FindProgressForZone(
+	ctx context.Context,
+	userID uuid.UUID,
+	zoneID uuid.UUID,
+	periodStarts []time.Time,
+) ([]models.UserBountyProgress, error) {
+	progress := []models.UserBountyProgress{}
+	if len(periodStarts) == 0 {
+		return progress, nil
+	}
+	if err := h.db.WithContext(ctx).
+		Where("user_id = ? AND zone_id = ? AND period_start IN ?", userID, zoneID, periodStarts).
+		Find(&progress).Error; err != nil {
+		return nil, err
+	}
+	return progress, nil
+}
+
+func (h *bountyHandle) FindProgressByID(ctx context.Context, id uuid.UUID) (*models.UserBountyProgress, error) {
+	var progress models.UserBountyProgress
+	if err := h.db.WithContext(ctx).
+		Preload("BountyTemplate").
+		Where("id = ?", id).
+		First(&progress).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &progress, nil
+}
+
+// IncrementProgress adds one completion to the user's bounty for the period,
+// creating the row on first progress. The returned bool is true when this
+// call completed the bounty.
+func (h *bountyHandle) IncrementProgress(
+	ctx context.Context,
+	userID uuid.UUID,
+	zoneID uuid.UUID,
+	template *models.BountyTemplate,
+	periodStart time.Time,
+	now time.Time,
+) (*models.UserBountyProgress, bool, error) {
+	record := &models.UserBountyProgress{}
+	completedNow := false
+	err := h.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Clauses(clause.OnConflict{DoNothing: true}).Create(&models.UserBountyProgress{
+			ID:               uuid.New(),
+			CreatedAt:        now,
+			UpdatedAt:        now,
+			UserID:           userID,
+			ZoneID:           zoneID,
+			BountyTemplateID: template.ID,
+			Cadence:          template.Cadence,
+			PeriodStart:      periodStart,
+			TargetCount:      template.TargetCount,
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where(
+				"user_id = ? AND zone_id = ? AND bounty_template_id = ? AND period_start = ?",
+				userID,
+				zoneID,
+				template.ID,
+				periodStart,
+			).
+			First(record).Error; err != nil {
+			return err
+		}
+		if record.CompletedAt != nil {
+			return nil
+		}
+
+		record.Progress = min(record.TargetCount, record.Progress+1)
+		updates := map[string]interface{}{
+			"progress":   record.Progress,
+			"updated_at": now,
+		}
+		if record.Progress >= record.TargetCount {
+			completedNow = true
+			record.CompletedAt = &now
+			updates["completed_at"] = now
+		}
+		return tx.Model(record).Updates(updates).Error
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return record, completedNow, nil
+}
+
+// ClaimReward marks a completed bounty as rewarded and advances the user's
+// streak for its cadence. It returns ErrBountyNotClaimable if the bounty
+// isn't the user's, isn't complete or was already claimed.
+func (h *bountyHandle) ClaimReward(
+	ctx context.Context,
+	userID uuid.UUID,
+	progressID uuid.UUID,
+	now time.Time,
+) (*models.UserBountyProgress, *models.UserBountyStreak, error) {
+	record := &models.UserBountyProgress{}
+	streak := &models.UserBountyStreak{}
+	err := h.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND user_id = ?", progressID, userID).
+			First(record).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrBountyNotClaimable
+			}
+			return err
+		}
+		if !record.IsClaimable() {
+			return ErrBountyNotClaimable
+		}
+		if err := tx.Model(record).Updates(map[string]interface{}{
+			"rewarded_at": now,
+			"updated_at":  now,
+		}).Error; err != nil {
+			return err
+		}
+		record.RewardedAt = &now
+
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.UserBountyStreak{
+			ID:        uuid.New(),
+			CreatedAt: now,
+			UpdatedAt: now,
+			UserID:    userID,
+			Cadence:   record.Cadence,
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? AND cadence = ?", userID, record.Cadence).
+			First(streak).Error; err != nil {
+			return err
+		}
+		streak.Advance(record.PeriodStart)
+		return tx.Model(streak).Updates(map[string]interface{}{
+			"current_streak":    streak.CurrentStreak,
+			"longest_streak":    streak.LongestStreak,
+			"last_period_start": streak.LastPeriodStart,
+			"updated_at":        now,
+		}).Error
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := h.db.WithContext(ctx).Where("id = ?", record.BountyTemplateID).First(&record.BountyTemplate).Error; err != nil {
+		return nil, nil, err
+	}
+	return record, streak, nil
+}
+
+func (h *bountyHandle) FindStreaks(ctx context.Context, userID uuid.UUID) ([]models.UserBountyStreak, error) {
+	streaks := []models.UserBountyStreak{}
+	if err := h.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("cadence ASC").
+		Find(&streaks).Error; err != nil {
+		return nil, err
+	}
+	return streaks, nil
+}
@@ -118,6 +118,7 @@ type client struct {
 	postHandle                                *postHandle
 	postTagHandle                             *postTagHandle
 	postFlagHandle                            *postFlagHandle
+	postVerificationReportHandle              *postVerificationReportHandle
 	postReactionHandle                        *postReactionHandle
 	postCommentHandle                         *postCommentHandle
 	activityHandle                            *activityHandle
@@ -155,6 +156,30 @@ type client struct {
 	monsterBattleHandle                       *monsterBattleHandler
 	monsterBattleParticipantHandle            *monsterBattleParticipantHandler
 	monsterBattleInviteHandle                 *monsterBattleInviteHandler
+	tradeOfferHandle                          *tradeOfferHandle
+	marketListingHandle                       *marketListingHandle
+	guildHandle                               *guildHandle
+	guildInviteHandle                         *guildInviteHandle
+	guildBankHandle                           *guildBankHandle
+	guildQuestProgressHandle                  *guildQuestProgressHandle
+	achievementHandle                         *achievementHandle
+	bountyHandle                              *bountyHandle
+	spawnRuleHandle                           *spawnRuleHandle
+	contentBundleHandle                       *contentBundleHandle
+	mapTileHandle                             *mapTileHandle
+	walkingGraphHandle                        *walkingGraphHandle
+	templateRevisionHandle                    *templateRevisionHandle
+	promptTemplateHandle                      *promptTemplateHandle
+	generatedRecordPromptHandle               *generatedRecordPromptHandle
+	promptEvaluationHandle                    *promptEvaluationHandle
+	contentModerationHandle                   *contentModerationHandle
+	localizationHandle                        *localizationHandle
+	authSessionHandle                         *authSessionHandle
+	authorizationHandle                       *authorizationHandle
+	twoFactorHandle                           *twoFactorHandle
+	passkeyHandle                             *passkeyHandle
+	authChallengeHandle                       *authChallengeHandle
+	userDataHandle                            *userDataHandle
 	scenarioHandle                            *scenarioHandle
 	documentHandle                            *documentHandler
 	documentTagHandle                         *documentTagHandler
@@ -171,6 +196,8 @@ type client struct {
 	feteRoomLinkedListTeamHandle              *feteRoomLinkedListTeamHandler
 	feteRoomTeamHandle                        *feteRoomTeamHandler
 	blockchainTransactionHandle               *blockchainTransactionHandle
+	manifestAnchorHandle                      *manifestAnchorHandle
+	manifestAnchorBatchHandle                 *manifestAnchorBatchHandle
 	userCertificateHandle                     *userCertificateHandle
 	albumHandle                               *albumHandle
 	albumMemberHandle                         *albumMemberHandle
@@ -308,10 +335,16 @@ func NewClient(cfg ClientConfig) (DbClient, error) {
 	sqlDB.SetConnMaxLifetime(connMaxLifetime)
 	sqlDB.SetConnMaxIdleTime(connMaxIdleTime)
 
+	return newClient(db), nil
+}
+
+func newClient(db *gorm.DB) *client {
+	users := &userHandle{db: db}
+
 	return &client{
 		db:                                        db,
 		scoreHandle:                               &scoreHandler{db: db},
-		userHandle:                                &userHandle{db: db},
+		userHandle:                                users,
 		howManyQuestionHandle:                     &howManyQuestionHandle{db: db},
 		howManyAnswerHandle:                       &howManyAnswerHandle{db: db},
 		teamHandle:                                &teamHandle{db: db},
@@ -411,6 +444,7 @@ func NewClient(cfg ClientConfig) (DbClient, error) {
 		postHandle:                                &postHandle{db: db},
 		postTagHandle:                             &postTagHandle{db: db},
 		postFlagHandle:                            &postFlagHandle{db: db},
+		postVerificationReportHandle:              &postVerificationReportHandle{db: db},
 		albumHandle:                               &albumHandle{db: db},
 		albumMemberHandle:                         &albumMemberHandle{db: db},
 		albumInviteHandle:                         &albumInviteHandle{db: db},
@@ -456,6 +490,30 @@ func NewClient(cfg ClientConfig) (DbClient, error) {
 		monsterBattleHandle:                       &monsterBattleHandler{db: db},
 		monsterBattleParticipantHandle:            &monsterBattleParticipantHandler{db: db},
 		monsterBattleInviteHandle:                 &monsterBattleInviteHandler{db: db},
+		tradeOfferHandle:                          &tradeOfferHandle{db: db},
+		marketListingHandle:                       &marketListingHandle{db: db},
+		guildHandle:                               &guildHandle{db: db},
+		guildInviteHandle:                         &guildInviteHandle{db: db},
+		guildBankHandle:                           &guildBankHandle{db: db},
+		guildQuestProgressHandle:                  &guildQuestProgressHandle{db: db},
+		achievementHandle:                         &achievementHandle{db: db},
+		bountyHandle:                              &bountyHandle{db: db},
+		spawnRuleHandle:                           &spawnRuleHandle{db: db},
+		contentBundleHandle:                       &contentBundleHandle{db: db},
+		mapTileHandle:                             &mapTileHandle{db: db},
+		walkingGraphHandle:                        &walkingGraphHandle{db: db},
+		templateRevisionHandle:                    &templateRevisionHandle{db: db},
+		promptTemplateHandle:                      &promptTemplateHandle{db: db},
+		generatedRecordPromptHandle:               &generatedRecordPromptHandle{db: db},
+		promptEvaluationHandle:                    &promptEvaluationHandle{db: db},
+		contentModerationHandle:                   &contentModerationHandle{db: db},
+		localizationHandle:                        &localizationHandle{db: db},
+		authSessionHandle:                         &authSessionHandle{db: db},
+		authorizationHandle:                       &authorizationHandle{db: db},
+		twoFactorHandle:                           &twoFactorHandle{db: db},
+		passkeyHandle:                             &passkeyHandle{db: db},
+		authChallengeHandle:                       &authChallengeHandle{db: db},
+		userDataHandle:                            &userDataHandle{db: db, users: users},
 		scenarioHandle:                            &scenarioHandle{db: db},
 		documentHandle:                            &documentHandler{db: db},
 		documentTagHandle:                         &documentTagHandler{db: db},
@@ -472,6 +530,8 @@ func NewClient(cfg ClientConfig) (DbClient, error) {
 		feteRoomLinkedListTeamHandle:              &feteRoomLinkedListTeamHandler{db: db},
 		feteRoomTeamHandle:                        &feteRoomTeamHandler{db: db},
 		blockchainTransactionHandle:               &blockchainTransactionHandle{db: db},
+		manifestAnchorHandle:                      &manifestAnchorHandle{db: db},
+		manifestAnchorBatchHandle:                 &manifestAnchorBatchHandle{db: db},
 		userCertificateHandle:                     &userCertificateHandle{db: db},
 		socialAccountHandle:                       &socialAccountHandler{db: db},
 		insiderTradeHandle:                        &insiderTradeHandle{db: db},
@@ -503,7 +563,18 @@ func NewClient(cfg ClientConfig) (DbClient, error) {
 		bgiGenerationJobHandle:     &bgiGenerationJobHandle{db: db},
 		bgiOrderHandle:             &bgiOrderHandle{db: db},
 		bgiEventHandle:             &bgiEventHandle{db: db},
-	}, nil
+	}
+}
+
+// Transaction runs fn with a client whose handles all work inside one
+// database transaction, committed if fn returns nil and rolled back
+// otherwise.
+func (c *client) Transaction(ctx context.Context, fn func(tx DbClient) error) error {
+	return c.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		txClient := newClient(tx)
+		txClient.userHandle.notify = c.userHandle.notify
+		return fn(txClient)
+	})
 }
 
 func (c *client) Activity() ActivityHandle {
@@ -538,6 +609,10 @@ func (c *client) PostFlag() PostFlagHandle {
 	return c.postFlagHandle
 }
 
+func (c *client) PostVerificationReport() PostVerificationReportHandle {
+	return c.postVerificationReportHandle
+}
+
 func (c *client) Album() AlbumHandle {
 	return c.albumHandle
 }
@@ -906,6 +981,13 @@ func (c *client) HowManyQuestion() HowManyQuestionHandle {
 	return c.howManyQuestionHandle
 }
 
+// SetUserChangeNotifier registers a callback run after each successful
+// write through User(), so services can invalidate cached copies of that
+// user. Call it before serving traffic.
+func (c *client) SetUserChangeNotifier(notify UserChangeNotifier) {
+	c.userHandle.notify = notify
+}
+
 func (c *client) User() UserHandle {
 	return c.userHandle
 }
@@ -1094,6 +1176,102 @@ func (c *client) MonsterBattleInvite() MonsterBattleInviteHandle {
 	return c.monsterBattleInviteHandle
 }
 
+func (c *client) TradeOffer() TradeOfferHandle {
+	return c.tradeOfferHandle
+}
+
+func (c *client) MarketListing() MarketListingHandle {
+	return c.marketListingHandle
+}
+
+func (c *client) Guild() GuildHandle {
+	return c.guildHandle
+}
+
+func (c *client) GuildInvite() GuildInviteHandle {
+	return c.guildInviteHandle
+}
+
+func (c *client) GuildBank() GuildBankHandle {
+	return c.guildBankHandle
+}
+
+func (c *client) GuildQuestProgress() GuildQuestProgressHandle {
+	return c.guildQuestProgressHandle
+}
+
+func (c *client) Achievement() AchievementHandle {
+	return c.achievementHandle
+}
+
+func (c *client) Bounty() BountyHandle {
+	return c.bountyHandle
+}
+
+func (c *client) SpawnRule() SpawnRuleHandle {
+	return c.spawnRuleHandle
+}
+
+func (c *client) ContentBundle() ContentBundleHandle {
+	return c.contentBundleHandle
+}
+
+func (c *client) MapTile() MapTileHandle {
+	return c.mapTileHandle
+}
+
+func (c *client) WalkingGraph() WalkingGraphHandle {
+	return c.walkingGraphHandle
+}
+
+func (c *client) TemplateRevision() TemplateRevisionHandle {
+	return c.templateRevisionHandle
+}
+
+func (c *client) PromptTemplate() PromptTemplateHandle {
+	return c.promptTemplateHandle
+}
+
+func (c *client) GeneratedRecordPrompt() GeneratedRecordPromptHandle {
+	return c.generatedRecordPromptHandle
+}
+
+func (c *client) PromptEvaluation() PromptEvaluationHandle {
+	return c.promptEvaluationHandle
+}
+
+func (c *client) ContentModeration() ContentModerationHandle {
+	return c.contentModerationHandle
+}
+
+func (c *client) Localization() LocalizationHandle {
+	return c.localizationHandle
+}
+
+func (c *client) AuthSession() AuthSessionHandle {
+	return c.authSessionHandle
+}
+
+func (c *client) Authorization() AuthorizationHandle {
+	return c.authorizationHandle
+}
+
+func (c *client) TwoFactor() TwoFactorHandle {
+	return c.twoFactorHandle
+}
+
+func (c *client) Passkey() PasskeyHandle {
+	return c.passkeyHandle
+}
+
+func (c *client) AuthChallenge() AuthChallengeHandle {
+	return c.authChallengeHandle
+}
+
+func (c *client) UserData() UserDataHandle {
+	return c.userDataHandle
+}
+
 func (c *client) Scenario() ScenarioHandle {
 	return c.scenarioHandle
 }
@@ -1158,6 +1336,14 @@ func (c *client) BlockchainTransaction() BlockchainTransactionHandle {
 	return c.blockchainTransactionHandle
 }
 
+func (c *client) ManifestAnchor() ManifestAnchorHandle {
+	return c.manifestAnchorHandle
+}
+
+func (c *client) ManifestAnchorBatch() ManifestAnchorBatchHandle {
+	return c.manifestAnchorBatchHandle
+}
+
 func (c *client) UserCertificate() UserCertificateHandle {
 	return c.userCertificateHandle
 }
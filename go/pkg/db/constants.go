@@ -1,3 +1,5 @@
 package db
 
 const MaxPartySize = 5
+
+const MaxGuildSize = 50
@@ -0,0 +1,92 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type contentBundleHandle struct {
+	db *gorm.DB
+}
+
+// Apply writes an import plan in a single transaction, so a bundle either
+// lands completely or not at all.
+func (h *contentBundleHandle) Apply(ctx context.Context, plan *models.ContentBundleImportPlan) error {
+	if plan == nil {
+		return nil
+	}
+	if plan.HasConflicts() {
+		return ErrContentBundleConflicts
+	}
+	return h.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for idx, write := range plan.Writes {
+			if err := applyContentBundleWrite(tx, write); err != nil {
+				return fmt.Errorf("content bundle write %d (%s %T): %w", idx, write.Op, write.Record, err)
+			}
+		}
+		return nil
+	})
+}
+
+func applyContentBundleWrite(tx *gorm.DB, write models.ContentBundleWrite) error {
+	switch record := write.Record.(type) {
+	case *models.MonsterTemplate:
+		if err := saveContentBundleRecord(tx, write.Op, record); err != nil {
+			return err
+		}
+		if err := tx.Where("monster_template_id = ?", record.ID).Delete(&models.MonsterTemplateSpell{}).Error; err != nil {
+			return err
+		}
+		for idx := range record.Spells {
+			if err := tx.Omit(clause.Associations).Create(&record.Spells[idx]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	case *models.QuestArchetype:
+		if err := saveContentBundleRecord(tx, write.Op, record); err != nil {
+			return err
+		}
+		if err := tx.Where("quest_archetype_id = ?", record.ID).Delete(&models.QuestArchetypeItemReward{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("quest_archetype_id = ?", record.ID).Delete(&models.QuestArchetypeSpellReward{}).Error; err != nil {
+			return err
+		}
+		for idx := range record.ItemRewards {
+			if err := tx.Omit(clause.Associations).Create(&record.ItemRewards[idx]).Error; err != nil {
+				return err
+			}
+		}
+		for idx := range record.SpellRewards {
+			if err := tx.Omit(clause.Associations).Create(&record.SpellRewards[idx]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	case *models.QuestArchetypeNodeChallenge:
+		if err := tx.Omit(clause.Associations).Create(&record.QuestArchetypeChallenge).Error; err != nil {
+			return err
+		}
+		return tx.Omit(clause.Associations).Create(record).Error
+	default:
+		return saveContentBundleRecord(tx, write.Op, record)
+	}
+}
+
+func saveContentBundleRecord(tx *gorm.DB, op models.ContentBundleWriteOp, record interface{}) error {
+	switch op {
+	case models.ContentBundleWriteOpCreate:
+		return tx.Omit(clause.Associations).Create(record).Error
+	case models.ContentBundleWriteOpUpdate:
+		return tx.Omit(clause.Associations).Save(record).Error
+	case models.ContentBundleWriteOpDelete:
+		return tx.Delete(record).Error
+	default:
+		return fmt.Errorf("unknown content bundle write op %q", op)
+	}
+}
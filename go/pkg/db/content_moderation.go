@@ -0,0 +1,208 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type contentModerationHandle struct {
+	db *gorm.DB
+}
+
+// Submit queues content for review, replacing the findings of any earlier
+// submission for the same content and sending it back to pending. Image
+// fields are left alone; the image job records those separately.
+func (h *contentModerationHandle) Submit(ctx context.Context, item *models.ContentModerationItem) error {
+	now := time.Now()
+	item.ID = uuid.New()
+	item.CreatedAt = now
+	item.UpdatedAt = now
+	item.Status = models.ContentModerationStatusPending
+	if item.Findings == nil {
+		item.Findings = models.ContentModerationFindings{}
+	}
+	return h.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "content_type"}, {Name: "content_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"status", "summary", "findings", "updated_at", "reviewed_by_user_id", "reviewed_at", "review_note",
+		}),
+	}).Create(item).Error
+}
+
+// RecordImage attaches an image's hash and classifier findings to the
+// content's queue entry, appending to whatever the text checks found. An
+// approved entry that picks up blocking findings goes back to pending, so a
+// reviewer sees the new image before players do. It does nothing for content
+// that was never queued, such as admin-authored content that happens to go
+// through the same image job.
+func (h *contentModerationHandle) RecordImage(
+	ctx context.Context,
+	contentType string,
+	contentID uuid.UUID,
+	imageURL string,
+	imageHash int64,
+	findings models.ContentModerationFindings,
+) error {
+	return h.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var item models.ContentModerationItem
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("content_type = ? AND content_id = ?", contentType, contentID).
+			First(&item).Error
+		if err == gorm.ErrRecordNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return tx.Model(&models.ContentModerationItem{}).
+			Where("id = ?", item.ID).
+			Updates(recordedImageUpdates(item, imageURL, imageHash, findings, time.Now())).Error
+	})
+}
+
+// recordedImageUpdates replaces the item's earlier image findings with the
+// new ones, and sends approved items with blocking image findings back to
+// pending.
+func recordedImageUpdates(
+	item models.ContentModerationItem,
+	imageURL string,
+	imageHash int64,
+	findings models.ContentModerationFindings,
+	now time.Time,
+) map[string]interface{} {
+	merged := make(models.ContentModerationFindings, 0, len(item.Findings)+len(findings))
+	for _, finding := range item.Findings {
+		if finding.Rule == models.ContentModerationRuleDuplicateImage || finding.Rule == models.ContentModerationRuleImageClassifier {
+			continue
+		}
+		merged = append(merged, finding)
+	}
+	merged = append(merged, findings...)
+
+	updates := map[string]interface{}{
+		"image_url":  imageURL,
+		"image_hash": imageHash,
+		"findings":   merged,
+		"updated_at": now,
+	}
+	if item.Status == models.ContentModerationStatusApproved && findings.HasBlocking() {
+		updates["status"] = models.ContentModerationStatusPending
+		updates["reviewed_by_user_id"] = nil
+		updates["reviewed_at"] = nil
+		updates["review_note"] = ""
+	}
+	return updates
+}
+
+func (h *contentModerationHandle) FindByID(ctx context.Context, id uuid.UUID) (*models.ContentModerationItem, error) {
+	var item models.ContentModerationItem
+	if err := h.db.WithContext(ctx).Where("id = ?", id).First(&item).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &item, nil
+}
+
+func (h *contentModerationHandle) FindByContent(ctx context.Context, contentType string, contentID uuid.UUID) (*models.ContentModerationItem, error) {
+	var item models.ContentModerationItem
+	if err := h.db.WithContext(ctx).
+		Where("content_type = ? AND content_id = ?", contentType, contentID).
+		First(&item).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &item, nil
+}
+
+func (h *contentModerationHandle) FindQueue(
+	ctx context.Context,
+	status models.ContentModerationStatus,
+	contentType string,
+	limit int,
+) ([]models.ContentModerationItem, error) {
+	var items []models.ContentModerationItem
+	query := h.db.WithContext(ctx).Order("created_at ASC")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if contentType != "" {
+		query = query.Where("content_type = ?", contentType)
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if err := query.Find(&items).Error; err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// FindImageHashes returns the hashes of every other image of the content
+// type; dHash comparison is a Hamming distance, so it happens in Go.
+func (h *contentModerationHandle) FindImageHashes(
+	ctx context.Context,
+	contentType string,
+	excludeContentID uuid.UUID,
+) ([]models.ContentModerationItem, error) {
+	var items []models.ContentModerationItem
+	if err := h.db.WithContext(ctx).
+		Select("id", "content_type", "content_id", "image_url", "image_hash", "status").
+		Where("content_type = ? AND content_id <> ? AND image_hash IS NOT NULL", contentType, excludeContentID).
+		Where("status <> ?", models.ContentModerationStatusRejected).
+		Find(&items).Error; err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (h *contentModerationHandle) Review(
+	ctx context.Context,
+	id uuid.UUID,
+	status models.ContentModerationStatus,
+	reviewerID *uuid.UUID,
+	note string,
+) error {
+	now := time.Now()
+	return h.db.WithContext(ctx).Model(&models.ContentModerationItem{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":              status,
+		"reviewed_by_user_id": reviewerID,
+		"reviewed_at":         now,
+		"review_note":         strings.TrimSpace(note),
+		"updated_at":          now,
+	}).Error
+}
+
+func (h *contentModerationHandle) DeleteByContent(ctx context.Context, contentType string, contentID uuid.UUID) error {
+	return h.db.WithContext(ctx).
+		Where("content_type = ? AND content_id = ?", contentType, contentID).
+		Delete(&models.ContentModerationItem{}).Error
+}
+
+func (h *contentModerationHandle) FindTerms(ctx context.Context) ([]models.ModerationTerm, error) {
+	var terms []models.ModerationTerm
+	if err := h.db.WithContext(ctx).Order("kind ASC").Order("term ASC").Find(&terms).Error; err != nil {
+		return nil, err
+	}
+	return terms, nil
+}
+
+func (h *contentModerationHandle) CreateTerm(ctx context.Context, term *models.ModerationTerm) error {
+	term.ID = uuid.New()
+	term.CreatedAt = time.Now()
+	term.Term = strings.TrimSpace(term.Term)
+	return h.db.WithContext(ctx).Create(term).Error
+}
+
+func (h *contentModerationHandle) DeleteTerm(ctx context.Context, id uuid.UUID) error {
+	return h.db.WithContext(ctx).Delete(&models.ModerationTerm{}, "id = ?", id).Error
+}
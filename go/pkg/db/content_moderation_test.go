@@ -0,0 +1,43 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+)
+
+func TestRecordedImageUpdatesReopensApprovedItemsWithBlockingFindings(t *testing.T) {
+	textFinding := models.ContentModerationFinding{Rule: models.ContentModerationRuleBlockedTerm, Severity: models.ContentModerationSeverityWarn, Field: "prompt"}
+	staleImageFinding := models.ContentModerationFinding{Rule: models.ContentModerationRuleDuplicateImage, Severity: models.ContentModerationSeverityBlock, Field: "image"}
+	duplicate := models.ContentModerationFinding{Rule: models.ContentModerationRuleDuplicateImage, Severity: models.ContentModerationSeverityBlock, Field: "image"}
+	warning := models.ContentModerationFinding{Rule: models.ContentModerationRuleImageClassifier, Severity: models.ContentModerationSeverityWarn, Field: "image"}
+
+	approved := models.ContentModerationItem{
+		Status:   models.ContentModerationStatusApproved,
+		Findings: models.ContentModerationFindings{textFinding, staleImageFinding},
+	}
+
+	updates := recordedImageUpdates(approved, "https://example.com/new.png", 42, models.ContentModerationFindings{duplicate}, time.Now())
+	if updates["status"] != models.ContentModerationStatusPending {
+		t.Fatalf("expected a blocking image finding to reopen the item, got status %v", updates["status"])
+	}
+	if _, ok := updates["reviewed_at"]; !ok {
+		t.Fatal("expected the earlier review to be cleared")
+	}
+	findings := updates["findings"].(models.ContentModerationFindings)
+	if len(findings) != 2 || findings[0] != textFinding || findings[1] != duplicate {
+		t.Fatalf("expected text findings kept and image findings replaced, got %+v", findings)
+	}
+
+	updates = recordedImageUpdates(approved, "https://example.com/new.png", 42, models.ContentModerationFindings{warning}, time.Now())
+	if _, ok := updates["status"]; ok {
+		t.Fatal("expected warnings alone to leave an approved item approved")
+	}
+
+	pending := models.ContentModerationItem{Status: models.ContentModerationStatusPending}
+	updates = recordedImageUpdates(pending, "https://example.com/new.png", 42, models.ContentModerationFindings{duplicate}, time.Now())
+	if _, ok := updates["status"]; ok {
+		t.Fatal("expected a pending item's status to be left alone")
+	}
+}
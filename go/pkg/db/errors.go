@@ -4,3 +4,20 @@ import "errors"
 
 var ErrUserNotFound = errors.New("user not found")
 var ErrMaxPartySizeReached = errors.New("max party size reached")
+var ErrInsufficientGold = errors.New("insufficient gold")
+var ErrInsufficientItemQuantity = errors.New("insufficient item quantity")
+var ErrTradeOfferNotOpen = errors.New("trade offer is no longer open")
+var ErrMarketListingNotAvailable = errors.New("market listing is no longer available")
+var ErrAlreadyInGuild = errors.New("user is already in a guild")
+var ErrNotGuildMember = errors.New("user is not a member of this guild")
+var ErrGuildFull = errors.New("guild is full")
+var ErrGuildNameTaken = errors.New("guild name is already taken")
+var ErrGuildPermissionDenied = errors.New("insufficient guild permissions")
+var ErrGuildLeaderMustTransfer = errors.New("guild leader must transfer leadership before leaving")
+var ErrGuildInviteNotPending = errors.New("guild invite is no longer pending")
+var ErrBountyNotClaimable = errors.New("bounty is not ready to claim")
+var ErrContentBundleConflicts = errors.New("content bundle has unresolved conflicts")
+var ErrZoneSplitLineMissesZone = errors.New("split line must cross the zone boundary")
+var ErrZonesNotAdjacent = errors.New("zones must be adjacent to merge")
+var ErrMergeSameUser = errors.New("cannot merge a user into itself")
+var ErrLoginInUse = errors.New("that login belongs to another account")
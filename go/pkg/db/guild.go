@@ -0,0 +1,399 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const defaultGuildLeaderboardLimit = 50
+
+type guildHandle struct {
+	db *gorm.DB
+}
+
+func (h *guildHandle) preloadBase(ctx context.Context) *gorm.DB {
+	return h.db.WithContext(ctx).
+		Preload("Leader").
+		Preload("Members", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at ASC")
+		}).
+		Preload("Members.User")
+}
+
+// Create inserts the guild and enrolls its creator as leader.
+func (h *guildHandle) Create(ctx context.Context, guild *models.Guild) error {
+	if guild == nil {
+		return nil
+	}
+	now := time.Now()
+	if guild.ID == uuid.Nil {
+		guild.ID = uuid.New()
+	}
+	guild.Name = strings.TrimSpace(guild.Name)
+	guild.CreatedAt = now
+	guild.UpdatedAt = now
+
+	return h.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if _, err := lockUsersForUpdate(tx, guild.LeaderUserID); err != nil {
+			return err
+		}
+
+		var existingMemberships int64
+		if err := tx.Model(&models.GuildMember{}).
+			Where("user_id = ?", guild.LeaderUserID).
+			Count(&existingMemberships).Error; err != nil {
+			return err
+		}
+		if existingMemberships > 0 {
+			return ErrAlreadyInGuild
+		}
+
+		var sameName int64
+		if err := tx.Model(&models.Guild{}).
+			Where("LOWER(name) = LOWER(?)", guild.Name).
+			Count(&sameName).Error; err != nil {
+			return err
+		}
+		if sameName > 0 {
+			return ErrGuildNameTaken
+		}
+
+		if err := tx.Omit(clause.Associations).Create(guild).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&models.GuildMember{
+			ID:        uuid.New(),
+			CreatedAt: now,
+			UpdatedAt: now,
+			GuildID:   guild.ID,
+			UserID:    guild.LeaderUserID,
+			Role:      models.GuildRoleLeader,
+		}).Error; err != nil {
+			return err
+		}
+		return writeGuildAuditTx(tx, &models.GuildAuditEntry{
+			GuildID:     guild.ID,
+			ActorUserID: &guild.LeaderUserID,
+			Action:      models.GuildAuditActionCreated,
+			Details:     guild.Name,
+		})
+	})
+}
+
+func (h *guildHandle) FindByID(ctx context.Context, id uuid.UUID) (*models.Guild, error) {
+	guild := &models.Guild{}
+	if err := h.preloadBase(ctx).Where("id = ?", id).First(guild).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return guild, nil
+}
+
+// FindMembership returns the user's guild membership, or nil when the user
+// hasn't joined a guild.
+func (h *guildHandle) FindMembership(ctx context.Context, userID uuid.UUID) (*models.GuildMember, error) {
+	member := &models.GuildMember{}
+	if err := h.db.WithContext(ctx).Where("user_id = ?", userID).First(member).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return member, nil
+}
+
+func (h *guildHandle) FindMemberUserIDs(ctx context.Context, guildID uuid.UUID) ([]uuid.UUID, error) {
+	userIDs := []uuid.UUID{}
+	if err := h.db.WithContext(ctx).
+		Model(&models.GuildMember{}).
+		Where("guild_id = ?", guildID).
+		Pluck("user_id", &userIDs).Error; err != nil {
+		return nil, err
+	}
+	return userIDs, nil
+}
+
+// UpdateSettings changes the guild description and bank withdrawal policy.
+// Only the leader may do this; nil arguments are left unchanged.
+func (h *guildHandle) UpdateSettings(
+	ctx context.Context,
+	guildID uuid.UUID,
+	actorID uuid.UUID,
+	description *string,
+	memberWithdrawalsEnabled *bool,
+) error {
+	return h.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		guild, err := lockGuildForUpdate(tx, guildID)
+		if err != nil {
+			return err
+		}
+		if guild.LeaderUserID != actorID {
+			return ErrGuildPermissionDenied
+		}
+
+		updates := map[string]interface{}{"updated_at": time.Now()}
+		if description != nil {
+			updates["description"] = strings.TrimSpace(*description)
+		}
+		if memberWithdrawalsEnabled != nil {
+			updates["member_withdrawals_enabled"] = *memberWithdrawalsEnabled
+		}
+		if err := tx.Model(&models.Guild{}).Where("id = ?", guildID).Updates(updates).Error; err != nil {
+			return err
+		}
+		return writeGuildAuditTx(tx, &models.GuildAuditEntry{
+			GuildID:     guildID,
+			ActorUserID: &actorID,
+			Action:      models.GuildAuditActionSettingsUpdated,
+		})
+	})
+}
+
+// RemoveMember takes targetID out of the guild. When actor and target are
+// the same user this is a voluntary leave; otherwise the actor must be an
+// officer or leader who outranks the target. A leader can only leave once
+// they're the last member, at which point the guild is disbanded and the
+// bank is paid out to them. The returned bool reports whether that happened.
+func (h *guildHandle) RemoveMember(ctx context.Context, guildID uuid.UUID, actorID uuid.UUID, targetID uuid.UUID) (bool, error) {
+	disbanded := false
+	err := h.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		guild, err := lockGuildForUpdate(tx, guildID)
+		if err != nil {
+			return err
+		}
+		target, err := findGuildMemberTx(tx, guildID, targetID)
+		if err != nil {
+			return err
+		}
+
+		if actorID != targetID {
+			actor, err := findGuildMemberTx(tx, guildID, actorID)
+			if err != nil {
+				return err
+			}
+			if !actor.Role.CanManageMembers() || !actor.Role.Outranks(target.Role) {
+				return ErrGuildPermissionDenied
+			}
+			if err := tx.Delete(target).Error; err != nil {
+				return err
+			}
+			return writeGuildAuditTx(tx, &models.GuildAuditEntry{
+				GuildID:      guildID,
+				ActorUserID:  &actorID,
+				TargetUserID: &targetID,
+				Action:       models.GuildAuditActionMemberRemoved,
+			})
+		}
+
+		if target.Role == models.GuildRoleLeader {
+			var memberCount int64
+			if err := tx.Model(&models.GuildMember{}).
+				Where("guild_id = ?", guildID).
+				Count(&memberCount).Error; err != nil {
+				return err
+			}
+			if memberCount > 1 {
+				return ErrGuildLeaderMustTransfer
+			}
+			disbanded = true
+			return disbandGuildTx(tx, guild, targetID)
+		}
+
+		if err := tx.Delete(target).Error; err != nil {
+			return err
+		}
+		return writeGuildAuditTx(tx, &models.GuildAuditEntry{
+			GuildID:     guildID,
+			ActorUserID: &actorID,
+			Action:      models.GuildAuditActionMemberLeft,
+		})
+	})
+	if err != nil {
+		return false, err
+	}
+	return disbanded, nil
+}
+
+// SetMemberRole promotes or demotes a member between officer and member.
+// Leadership changes go through TransferLeadership instead.
+func (h *guildHandle) SetMemberRole(
+	ctx context.Context,
+	guildID uuid.UUID,
+	actorID uuid.UUID,
+	targetID uuid.UUID,
+	role models.GuildRole,
+) error {
+	if role != models.GuildRoleOfficer && role != models.GuildRoleMember {
+		return ErrGuildPermissionDenied
+	}
+	return h.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		guild, err := lockGuildForUpdate(tx, guildID)
+		if err != nil {
+			return err
+		}
+		if guild.LeaderUserID != actorID || actorID == targetID {
+			return ErrGuildPermissionDenied
+		}
+		target, err := findGuildMemberTx(tx, guildID, targetID)
+		if err != nil {
+			return err
+		}
+		if target.Role == role {
+			return nil
+		}
+		previous := target.Role
+		if err := tx.Model(target).Updates(map[string]interface{}{
+			"role":       role,
+			"updated_at": time.Now(),
+		}).Error; err != nil {
+			return err
+		}
+		return writeGuildAuditTx(tx, &models.GuildAuditEntry{
+			GuildID:      guildID,
+			ActorUserID:  &actorID,
+			TargetUserID: &targetID,
+			Action:       models.GuildAuditActionRoleChanged,
+			Details:      string(previous) + " -> " + string(role),
+		})
+	})
+}
+
+// TransferLeadership hands the guild to another member. The outgoing leader
+// stays on as an officer.
+func (h *guildHandle) TransferLeadership(ctx context.Context, guildID uuid.UUID, actorID uuid.UUID, targetID uuid.UUID) error {
+	return h.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		guild, err := lockGuildForUpdate(tx, guildID)
+		if err != nil {
+			return err
+		}
+		if guild.LeaderUserID != actorID || actorID == targetID {
+			return ErrGuildPermissionDenied
+		}
+		if _, err := findGuildMemberTx(tx, guildID, targetID); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if err := tx.Model(&models.GuildMember{}).
+			Where("guild_id = ? AND user_id = ?", guildID, actorID).
+			Updates(map[string]interface{}{"role": models.GuildRoleOfficer, "updated_at": now}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.GuildMember{}).
+			Where("guild_id = ? AND user_id = ?", guildID, targetID).
+			Updates(map[string]interface{}{"role": models.GuildRoleLeader, "updated_at": now}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Guild{}).
+			Where("id = ?", guildID).
+			Updates(map[string]interface{}{"leader_user_id": targetID, "updated_at": now}).Error; err != nil {
+			return err
+		}
+		return writeGuildAuditTx(tx, &models.GuildAuditEntry{
+			GuildID:      guildID,
+			ActorUserID:  &actorID,
+			TargetUserID: &targetID,
+			Action:       models.GuildAuditActionLeaderTransferred,
+		})
+	})
+}
+
+// Leaderboard ranks guilds by the summed reputation of their members. When
+// zoneID is set only reputation earned in that zone counts.
+func (h *guildHandle) Leaderboard(ctx context.Context, zoneID *uuid.UUID, limit int) ([]models.GuildLeaderboardEntry, error) {
+	if limit <= 0 {
+		limit = defaultGuildLeaderboardLimit
+	}
+
+	reputationJoin := "LEFT JOIN user_zone_reputations uzr ON uzr.user_id = gm.user_id"
+	args := []interface{}{}
+	if zoneID != nil {
+		reputationJoin += " AND uzr.zone_id = ?"
+		args = append(args, *zoneID)
+	}
+
+	entries := []models.GuildLeaderboardEntry{}
+	if err := h.db.WithContext(ctx).
+		Table("guilds g").
+		Select(`g.id AS guild_id,
+			g.name AS name,
+			g.tag AS tag,
+			COUNT(DISTINCT gm.user_id) AS member_count,
+			COALESCE(SUM(uzr.total_reputation), 0) AS total_reputation`).
+		Joins("JOIN guild_members gm ON gm.guild_id = g.id").
+		Joins(reputationJoin, args...).
+		Group("g.id, g.name, g.tag").
+		Order("total_reputation DESC, g.created_at ASC").
+		Limit(limit).
+		Scan(&entries).Error; err != nil {
+		return nil, err
+	}
+	for i := range entries {
+		entries[i].Rank = i + 1
+	}
+	return entries, nil
+}
+
+func lockGuildForUpdate(tx *gorm.DB, guildID uuid.UUID) (*models.Guild, error) {
+	guild := &models.Guild{}
+	if err := tx.
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", guildID).
+		First(guild).Error; err != nil {
+		return nil, err
+	}
+	return guild, nil
+}
+
+func findGuildMemberTx(tx *gorm.DB, guildID uuid.UUID, userID uuid.UUID) (*models.GuildMember, error) {
+	member := &models.GuildMember{}
+	if err := tx.Where("guild_id = ? AND user_id = ?", guildID, userID).First(member).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotGuildMember
+		}
+		return nil, err
+	}
+	return member, nil
+}
+
+func writeGuildAuditTx(tx *gorm.DB, entry *models.GuildAuditEntry) error {
+	if entry.ID == uuid.Nil {
+		entry.ID = uuid.New()
+	}
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	return tx.Omit(clause.Associations).Create(entry).Error
+}
+
+// disbandGuildTx pays the bank out to the last remaining member and deletes
+// the guild. Members, invites, bank rows and the audit log cascade with it.
+func disbandGuildTx(tx *gorm.DB, guild *models.Guild, recipientID uuid.UUID) error {
+	users, err := lockUsersForUpdate(tx, recipientID)
+	if err != nil {
+		return err
+	}
+	if err := adjustUserGoldTx(tx, users[recipientID], guild.Gold); err != nil {
+		return err
+	}
+
+	bankItems := []models.GuildBankItem{}
+	if err := tx.Where("guild_id = ?", guild.ID).Find(&bankItems).Error; err != nil {
+		return err
+	}
+	for _, item := range bankItems {
+		if err := addUserInventoryItemTx(tx, recipientID, item.InventoryItemID, item.Quantity); err != nil {
+			return err
+		}
+	}
+	return tx.Delete(&models.Guild{}, "id = ?", guild.ID).Error
+}
@@ -0,0 +1,246 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const defaultGuildAuditLimit = 100
+
+type guildBankHandle struct {
+	db *gorm.DB
+}
+
+func (h *guildBankHandle) FindItems(ctx context.Context, guildID uuid.UUID) ([]models.GuildBankItem, error) {
+	items := []models.GuildBankItem{}
+	if err := h.db.WithContext(ctx).
+		Preload("InventoryItem").
+		Where("guild_id = ?", guildID).
+		Order("inventory_item_id ASC").
+		Find(&items).Error; err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (h *guildBankHandle) FindAuditEntries(ctx context.Context, guildID uuid.UUID, limit int) ([]models.GuildAuditEntry, error) {
+	if limit <= 0 {
+		limit = defaultGuildAuditLimit
+	}
+	entries := []models.GuildAuditEntry{}
+	if err := h.db.WithContext(ctx).
+		Preload("ActorUser").
+		Preload("TargetUser").
+		Preload("InventoryItem").
+		Where("guild_id = ?", guildID).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// DepositGold moves gold from a member's purse into the guild bank. Any
+// member may deposit.
+func (h *guildBankHandle) DepositGold(ctx context.Context, guildID uuid.UUID, userID uuid.UUID, amount int) error {
+	if amount <= 0 {
+		return fmt.Errorf("invalid gold amount %d", amount)
+	}
+	return h.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		guild, _, user, err := lockGuildBankParticipantsTx(tx, guildID, userID)
+		if err != nil {
+			return err
+		}
+		if err := adjustUserGoldTx(tx, user, -amount); err != nil {
+			return err
+		}
+		if err := adjustGuildGoldTx(tx, guild, amount); err != nil {
+			return err
+		}
+		return writeGuildAuditTx(tx, &models.GuildAuditEntry{
+			GuildID:     guildID,
+			ActorUserID: &userID,
+			Action:      models.GuildAuditActionGoldDeposited,
+			Gold:        amount,
+		})
+	})
+}
+
+// WithdrawGold moves gold from the guild bank to the member, subject to the
+// guild's withdrawal permissions.
+func (h *guildBankHandle) WithdrawGold(ctx context.Context, guildID uuid.UUID, userID uuid.UUID, amount int) error {
+	if amount <= 0 {
+		return fmt.Errorf("invalid gold amount %d", amount)
+	}
+	return h.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		guild, member, user, err := lockGuildBankParticipantsTx(tx, guildID, userID)
+		if err != nil {
+			return err
+		}
+		if !guild.CanWithdraw(member.Role) {
+			return ErrGuildPermissionDenied
+		}
+		if err := adjustGuildGoldTx(tx, guild, -amount); err != nil {
+			return err
+		}
+		if err := adjustUserGoldTx(tx, user, amount); err != nil {
+			return err
+		}
+		return writeGuildAuditTx(tx, &models.GuildAuditEntry{
+			GuildID:     guildID,
+			ActorUserID: &userID,
+			Action:      models.GuildAuditActionGoldWithdrawn,
+			Gold:        amount,
+		})
+	})
+}
+
+func (h *guildBankHandle) DepositItem(ctx context.Context, guildID uuid.UUID, userID uuid.UUID, inventoryItemID int, quantity int) error {
+	return h.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if _, _, _, err := lockGuildBankParticipantsTx(tx, guildID, userID); err != nil {
+			return err
+		}
+		if err := removeUserInventoryItemTx(tx, userID, inventoryItemID, quantity); err != nil {
+			return err
+		}
+		if err := addGuildBankItemTx(tx, guildID, inventoryItemID, quantity); err != nil {
+			return err
+		}
+		return writeGuildAuditTx(tx, &models.GuildAuditEntry{
+			GuildID:         guildID,
+			ActorUserID:     &userID,
+			Action:          models.GuildAuditActionItemDeposited,
+			InventoryItemID: &inventoryItemID,
+			Quantity:        quantity,
+		})
+	})
+}
+
+func (h *guildBankHandle) WithdrawItem(ctx context.Context, guildID uuid.UUID, userID uuid.UUID, inventoryItemID int, quantity int) error {
+	return h.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		guild, member, _, err := lockGuildBankParticipantsTx(tx, guildID, userID)
+		if err != nil {
+			return err
+		}
+		if !guild.CanWithdraw(member.Role) {
+			return ErrGuildPermissionDenied
+		}
+		if err := removeGuildBankItemTx(tx, guildID, inventoryItemID, quantity); err != nil {
+			return err
+		}
+		if err := addUserInventoryItemTx(tx, userID, inventoryItemID, quantity); err != nil {
+			return err
+		}
+		return writeGuildAuditTx(tx, &models.GuildAuditEntry{
+			GuildID:         guildID,
+			ActorUserID:     &userID,
+			Action:          models.GuildAuditActionItemWithdrawn,
+			InventoryItemID: &inventoryItemID,
+			Quantity:        quantity,
+		})
+	})
+}
+
+// lockGuildBankParticipantsTx locks the guild row before the member's user
+// row so bank operations always acquire locks in the same order.
+func lockGuildBankParticipantsTx(
+	tx *gorm.DB,
+	guildID uuid.UUID,
+	userID uuid.UUID,
+) (*models.Guild, *models.GuildMember, *models.User, error) {
+	guild, err := lockGuildForUpdate(tx, guildID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	member, err := findGuildMemberTx(tx, guildID, userID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	users, err := lockUsersForUpdate(tx, userID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return guild, member, users[userID], nil
+}
+
+func adjustGuildGoldTx(tx *gorm.DB, guild *models.Guild, delta int) error {
+	if delta == 0 {
+		return nil
+	}
+	if guild.Gold+delta < 0 {
+		return ErrInsufficientGold
+	}
+	if err := tx.Model(&models.Guild{}).
+		Where("id = ?", guild.ID).
+		Updates(map[string]interface{}{
+			"gold":       gorm.Expr("gold + ?", delta),
+			"updated_at": time.Now(),
+		}).Error; err != nil {
+		return err
+	}
+	guild.Gold += delta
+	return nil
+}
+
+func addGuildBankItemTx(tx *gorm.DB, guildID uuid.UUID, inventoryItemID int, quantity int) error {
+	if quantity <= 0 {
+		return fmt.Errorf("invalid quantity for item %d", inventoryItemID)
+	}
+
+	bankItem := &models.GuildBankItem{}
+	err := tx.
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("guild_id = ? AND inventory_item_id = ?", guildID, inventoryItemID).
+		First(bankItem).Error
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		now := time.Now()
+		return tx.Omit(clause.Associations).Create(&models.GuildBankItem{
+			ID:              uuid.New(),
+			CreatedAt:       now,
+			UpdatedAt:       now,
+			GuildID:         guildID,
+			InventoryItemID: inventoryItemID,
+			Quantity:        quantity,
+		}).Error
+	}
+
+	bankItem.Quantity += quantity
+	return tx.Omit(clause.Associations).Save(bankItem).Error
+}
+
+func removeGuildBankItemTx(tx *gorm.DB, guildID uuid.UUID, inventoryItemID int, quantity int) error {
+	if quantity <= 0 {
+		return fmt.Errorf("invalid quantity for item %d", inventoryItemID)
+	}
+
+	bankItem := &models.GuildBankItem{}
+	if err := tx.
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("guild_id = ? AND inventory_item_id = ?", guildID, inventoryItemID).
+		First(bankItem).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%w: item %d", ErrInsufficientItemQuantity, inventoryItemID)
+		}
+		return err
+	}
+	if bankItem.Quantity < quantity {
+		return fmt.Errorf("%w: item %d", ErrInsufficientItemQuantity, inventoryItemID)
+	}
+
+	bankItem.Quantity -= quantity
+	if bankItem.Quantity <= 0 {
+		return tx.Delete(bankItem).Error
+	}
+	return tx.Omit(clause.Associations).Save(bankItem).Error
+}
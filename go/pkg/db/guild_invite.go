@@ -0,0 +1,213 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type guildInviteHandle struct {
+	db *gorm.DB
+}
+
+func (h *guildInviteHandle) preloadBase(ctx context.Context) *gorm.DB {
+	return h.db.WithContext(ctx).
+		Preload("Guild").
+		Preload("Inviter").
+		Preload("Invitee")
+}
+
+// Create records a pending invite. The inviter must be allowed to invite and
+// the invitee must not already belong to a guild. Re-inviting someone who
+// already has a pending invite to the same guild returns that invite.
+func (h *guildInviteHandle) Create(ctx context.Context, invite *models.GuildInvite) error {
+	if invite == nil {
+		return nil
+	}
+	now := time.Now()
+	if invite.ID == uuid.Nil {
+		invite.ID = uuid.New()
+	}
+	invite.Status = models.GuildInviteStatusPending
+	invite.CreatedAt = now
+	invite.UpdatedAt = now
+
+	return h.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if _, err := lockGuildForUpdate(tx, invite.GuildID); err != nil {
+			return err
+		}
+		inviter, err := findGuildMemberTx(tx, invite.GuildID, invite.InviterUserID)
+		if err != nil {
+			return err
+		}
+		if !inviter.Role.CanInvite() {
+			return ErrGuildPermissionDenied
+		}
+
+		var inviteeMemberships int64
+		if err := tx.Model(&models.GuildMember{}).
+			Where("user_id = ?", invite.InviteeUserID).
+			Count(&inviteeMemberships).Error; err != nil {
+			return err
+		}
+		if inviteeMemberships > 0 {
+			return ErrAlreadyInGuild
+		}
+
+		existing := &models.GuildInvite{}
+		err = tx.Where(
+			"guild_id = ? AND invitee_user_id = ? AND status = ?",
+			invite.GuildID,
+			invite.InviteeUserID,
+			models.GuildInviteStatusPending,
+		).First(existing).Error
+		if err == nil {
+			*invite = *existing
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		return tx.Omit(clause.Associations).Create(invite).Error
+	})
+}
+
+func (h *guildInviteHandle) FindByID(ctx context.Context, id uuid.UUID) (*models.GuildInvite, error) {
+	invite := &models.GuildInvite{}
+	if err := h.preloadBase(ctx).Where("id = ?", id).First(invite).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return invite, nil
+}
+
+func (h *guildInviteHandle) FindPendingForUser(ctx context.Context, userID uuid.UUID) ([]models.GuildInvite, error) {
+	invites := []models.GuildInvite{}
+	if err := h.preloadBase(ctx).
+		Where("invitee_user_id = ? AND status = ?", userID, models.GuildInviteStatusPending).
+		Order("created_at DESC").
+		Find(&invites).Error; err != nil {
+		return nil, err
+	}
+	return invites, nil
+}
+
+func (h *guildInviteHandle) FindPendingForGuild(ctx context.Context, guildID uuid.UUID) ([]models.GuildInvite, error) {
+	invites := []models.GuildInvite{}
+	if err := h.preloadBase(ctx).
+		Where("guild_id = ? AND status = ?", guildID, models.GuildInviteStatusPending).
+		Order("created_at DESC").
+		Find(&invites).Error; err != nil {
+		return nil, err
+	}
+	return invites, nil
+}
+
+// Accept joins the invitee to the guild and cancels any other invites they
+// were holding.
+func (h *guildInviteHandle) Accept(ctx context.Context, inviteID uuid.UUID, userID uuid.UUID, now time.Time) error {
+	return h.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		invite := &models.GuildInvite{}
+		if err := tx.
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", inviteID).
+			First(invite).Error; err != nil {
+			return err
+		}
+		if invite.InviteeUserID != userID {
+			return ErrGuildPermissionDenied
+		}
+		if invite.Status != models.GuildInviteStatusPending {
+			return ErrGuildInviteNotPending
+		}
+
+		if _, err := lockGuildForUpdate(tx, invite.GuildID); err != nil {
+			return err
+		}
+		if _, err := lockUsersForUpdate(tx, userID); err != nil {
+			return err
+		}
+
+		var existingMemberships int64
+		if err := tx.Model(&models.GuildMember{}).
+			Where("user_id = ?", userID).
+			Count(&existingMemberships).Error; err != nil {
+			return err
+		}
+		if existingMemberships > 0 {
+			return ErrAlreadyInGuild
+		}
+
+		var memberCount int64
+		if err := tx.Model(&models.GuildMember{}).
+			Where("guild_id = ?", invite.GuildID).
+			Count(&memberCount).Error; err != nil {
+			return err
+		}
+		if memberCount >= MaxGuildSize {
+			return ErrGuildFull
+		}
+
+		if err := tx.Create(&models.GuildMember{
+			ID:        uuid.New(),
+			CreatedAt: now,
+			UpdatedAt: now,
+			GuildID:   invite.GuildID,
+			UserID:    userID,
+			Role:      models.GuildRoleMember,
+		}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.GuildInvite{}).
+			Where("id = ?", invite.ID).
+			Updates(map[string]interface{}{
+				"status":       models.GuildInviteStatusAccepted,
+				"responded_at": now,
+				"updated_at":   now,
+			}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.GuildInvite{}).
+			Where("invitee_user_id = ? AND status = ? AND id <> ?", userID, models.GuildInviteStatusPending, invite.ID).
+			Updates(map[string]interface{}{
+				"status":     models.GuildInviteStatusCancelled,
+				"updated_at": now,
+			}).Error; err != nil {
+			return err
+		}
+
+		return writeGuildAuditTx(tx, &models.GuildAuditEntry{
+			GuildID:      invite.GuildID,
+			ActorUserID:  &invite.InviterUserID,
+			TargetUserID: &userID,
+			Action:       models.GuildAuditActionMemberJoined,
+		})
+	})
+}
+
+// Close moves a pending invite to declined or cancelled.
+func (h *guildInviteHandle) Close(ctx context.Context, inviteID uuid.UUID, status models.GuildInviteStatus, now time.Time) error {
+	result := h.db.WithContext(ctx).
+		Model(&models.GuildInvite{}).
+		Where("id = ? AND status = ?", inviteID, models.GuildInviteStatusPending).
+		Updates(map[string]interface{}{
+			"status":       status,
+			"responded_at": now,
+			"updated_at":   now,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrGuildInviteNotPending
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type guildQuestProgressHandle struct {
+	db *gorm.DB
+}
+
+// RecordNodeCompletion stores the guild's first completion of a quest node.
+// It reports false when some member had already completed that node.
+func (h *guildQuestProgressHandle) RecordNodeCompletion(ctx context.Context, completion *models.GuildQuestNodeCompletion) (bool, error) {
+	if completion == nil {
+		return false, nil
+	}
+	if completion.ID == uuid.Nil {
+		completion.ID = uuid.New()
+	}
+	if completion.CreatedAt.IsZero() {
+		completion.CreatedAt = time.Now()
+	}
+
+	result := h.db.WithContext(ctx).
+		Omit(clause.Associations).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "guild_id"}, {Name: "quest_node_id"}},
+			DoNothing: true,
+		}).
+		Create(completion)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (h *guildQuestProgressHandle) FindByGuildAndQuest(ctx context.Context, guildID uuid.UUID, questID uuid.UUID) ([]models.GuildQuestNodeCompletion, error) {
+	completions := []models.GuildQuestNodeCompletion{}
+	if err := h.db.WithContext(ctx).
+		Preload("CompletedByUser").
+		Where("guild_id = ? AND quest_id = ?", guildID, questID).
+		Order("created_at ASC").
+		Find(&completions).Error; err != nil {
+		return nil, err
+	}
+	return completions, nil
+}
@@ -9,9 +9,15 @@ import (
 	"github.com/google/uuid"
 )
 
+// UserChangeNotifier is told the ID of every user written through the user
+// handle.
+type UserChangeNotifier func(ctx context.Context, userID uuid.UUID)
+
 type DbClient interface {
 	Score() ScoreHandle
 	User() UserHandle
+	SetUserChangeNotifier(notify UserChangeNotifier)
+	Transaction(ctx context.Context, fn func(tx DbClient) error) error
 	HowManyQuestion() HowManyQuestionHandle
 	HowManyAnswer() HowManyAnswerHandle
 	Team() TeamHandle
@@ -110,6 +116,7 @@ type DbClient interface {
 	Post() PostHandle
 	PostTag() PostTagHandle
 	PostFlag() PostFlagHandle
+	PostVerificationReport() PostVerificationReportHandle
 	Album() AlbumHandle
 	AlbumMember() AlbumMemberHandle
 	AlbumInvite() AlbumInviteHandle
@@ -156,6 +163,30 @@ type DbClient interface {
 	MonsterBattle() MonsterBattleHandle
 	MonsterBattleParticipant() MonsterBattleParticipantHandle
 	MonsterBattleInvite() MonsterBattleInviteHandle
+	TradeOffer() TradeOfferHandle
+	MarketListing() MarketListingHandle
+	Guild() GuildHandle
+	GuildInvite() GuildInviteHandle
+	GuildBank() GuildBankHandle
+	GuildQuestProgress() GuildQuestProgressHandle
+	Achievement() AchievementHandle
+	Bounty() BountyHandle
+	SpawnRule() SpawnRuleHandle
+	ContentBundle() ContentBundleHandle
+	MapTile() MapTileHandle
+	WalkingGraph() WalkingGraphHandle
+	TemplateRevision() TemplateRevisionHandle
+	PromptTemplate() PromptTemplateHandle
+	GeneratedRecordPrompt() GeneratedRecordPromptHandle
+	PromptEvaluation() PromptEvaluationHandle
+	ContentModeration() ContentModerationHandle
+	Localization() LocalizationHandle
+	AuthSession() AuthSessionHandle
+	Authorization() AuthorizationHandle
+	TwoFactor() TwoFactorHandle
+	Passkey() PasskeyHandle
+	AuthChallenge() AuthChallengeHandle
+	UserData() UserDataHandle
 	Scenario() ScenarioHandle
 	Document() DocumentHandle
 	DocumentTag() DocumentTagHandle
@@ -172,6 +203,8 @@ type DbClient interface {
 	FeteRoomLinkedListTeam() FeteRoomLinkedListTeamHandle
 	FeteRoomTeam() FeteRoomTeamHandle
 	BlockchainTransaction() BlockchainTransactionHandle
+	ManifestAnchor() ManifestAnchorHandle
+	ManifestAnchorBatch() ManifestAnchorBatchHandle
 	UserCertificate() UserCertificateHandle
 	SocialAccount() SocialAccountHandle
 	InsiderTrade() InsiderTradeHandle
@@ -757,6 +790,9 @@ type PointOfInterestHandle interface {
 	CreateForGroup(ctx context.Context, pointOfInterest *models.PointOfInterest, pointOfInterestGroupID uuid.UUID) error
 	FindAllForZone(ctx context.Context, zoneID uuid.UUID) ([]models.PointOfInterest, error)
 	FindByGoogleMapsPlaceID(ctx context.Context, googleMapsPlaceID string) (*models.PointOfInterest, error)
+	FindByOSMID(ctx context.Context, osmID string) (*models.PointOfInterest, error)
+	FindNearbyByName(ctx context.Context, name string, lat float64, lng float64, radiusMeters float64) (*models.PointOfInterest, error)
+	LinkProvenance(ctx context.Context, pointOfInterestID uuid.UUID, googleMapsPlaceID *string, osmID *string) error
 	Update(ctx context.Context, pointOfInterestID uuid.UUID, updates *models.PointOfInterest) error
 	ReplaceItemRewards(ctx context.Context, pointOfInterestID uuid.UUID, rewards []models.PointOfInterestItemReward) error
 	ReplaceSpellRewards(ctx context.Context, pointOfInterestID uuid.UUID, rewards []models.PointOfInterestSpellReward) error
@@ -774,6 +810,7 @@ type TextVerificationCodeHandle interface {
 	Insert(ctx context.Context, phoneNumber string) (*models.TextVerificationCode, error)
 	Find(ctx context.Context, phoneNumber string, code string) (*models.TextVerificationCode, error)
 	MarkUsed(ctx context.Context, id uuid.UUID) error
+	RecordFailedAttempt(ctx context.Context, phoneNumber string, maxAttempts int) error
 }
 
 type SentTextHandle interface {
@@ -917,6 +954,7 @@ type ScenarioGenerationJobHandle interface {
 	FindByID(ctx context.Context, id uuid.UUID) (*models.ScenarioGenerationJob, error)
 	FindRecent(ctx context.Context, limit int) ([]models.ScenarioGenerationJob, error)
 	FindByZoneID(ctx context.Context, zoneID uuid.UUID, limit int) ([]models.ScenarioGenerationJob, error)
+	FindByGeneratedScenarioID(ctx context.Context, scenarioID uuid.UUID) (*models.ScenarioGenerationJob, error)
 	ListAdmin(ctx context.Context, params ScenarioGenerationJobAdminListParams) (*ScenarioGenerationJobAdminListResult, error)
 }
 
@@ -1198,6 +1236,8 @@ type TutorialHandle interface {
 
 type AuditItemHandle interface {
 	Create(ctx context.Context, matchID *uuid.UUID, userID *uuid.UUID, message string) error
+	CreatePrivilegedCall(ctx context.Context, item *models.AuditItem) error
+	GetPrivilegedCalls(ctx context.Context, userID *uuid.UUID, limit int) ([]*models.AuditItem, error)
 	GetAuditItemsForMatch(ctx context.Context, matchID uuid.UUID) ([]*models.AuditItem, error)
 	GetAuditItemsForUser(ctx context.Context, userID uuid.UUID) ([]*models.AuditItem, error)
 	DeleteAllForUser(ctx context.Context, userID uuid.UUID) error
@@ -1291,6 +1331,10 @@ type ZoneHandle interface {
 	SetKind(ctx context.Context, zoneIDs []uuid.UUID, kind string) (int, error)
 	ReplaceKind(ctx context.Context, currentKind string, nextKind string) (int, error)
 	FindByPointOfInterestID(ctx context.Context, pointOfInterestID uuid.UUID) (*models.Zone, error)
+	ImportBoundaries(ctx context.Context, drafts []models.ZoneBoundaryDraft) ([]*models.Zone, error)
+	Split(ctx context.Context, zoneID uuid.UUID, line [][]float64) (*models.ZoneGeometryEdit, error)
+	Merge(ctx context.Context, zoneIDs []uuid.UUID, name string) (*models.ZoneGeometryEdit, error)
+	Simplify(ctx context.Context, zoneIDs []uuid.UUID, toleranceMeters float64) (*models.ZoneGeometryEdit, error)
 }
 
 type ZoneKindHandle interface {
@@ -1345,6 +1389,8 @@ type QuestArchetypeHandle interface {
 	Delete(ctx context.Context, id uuid.UUID) error
 	DeletePermanent(ctx context.Context, id uuid.UUID) error
 	ClearQuestGiverCharacterIDByCharacterID(ctx context.Context, characterID uuid.UUID) error
+	FindIDsByNode(ctx context.Context, nodeID uuid.UUID) ([]uuid.UUID, error)
+	FindIDsByChallenge(ctx context.Context, challengeID uuid.UUID) ([]uuid.UUID, error)
 }
 
 type QuestArchetypeSuggestionJobHandle interface {
@@ -1401,6 +1447,8 @@ type QuestArchetypeNodeHandle interface {
 	FindAll(ctx context.Context) ([]*models.QuestArchetypeNode, error)
 	Update(ctx context.Context, questArchetypeNode *models.QuestArchetypeNode) error
 	Delete(ctx context.Context, id uuid.UUID) error
+	FindGraph(ctx context.Context, rootID uuid.UUID) ([]models.QuestArchetypeNode, error)
+	RestoreGraph(ctx context.Context, nodes []models.QuestArchetypeNode) error
 }
 
 type QuestArchetypeChallengeHandle interface {
@@ -1573,6 +1621,232 @@ type MonsterBattleInviteHandle interface {
 	CountPendingByBattle(ctx context.Context, battleID uuid.UUID, now time.Time) (int64, error)
 }
 
+type TradeOfferHandle interface {
+	Create(ctx context.Context, offer *models.TradeOffer) error
+	FindByID(ctx context.Context, id uuid.UUID) (*models.TradeOffer, error)
+	FindOpenForUser(ctx context.Context, userID uuid.UUID, now time.Time) ([]models.TradeOffer, error)
+	FindRecentForUser(ctx context.Context, userID uuid.UUID, limit int) ([]models.TradeOffer, error)
+	Confirm(ctx context.Context, offerID uuid.UUID, userID uuid.UUID, now time.Time) (*models.TradeOffer, error)
+	Close(ctx context.Context, offerID uuid.UUID, status models.TradeOfferStatus, now time.Time) error
+	ExpireStale(ctx context.Context, now time.Time) (int64, error)
+}
+
+type MarketListingHandle interface {
+	Create(ctx context.Context, listing *models.MarketListing) error
+	FindByID(ctx context.Context, id uuid.UUID) (*models.MarketListing, error)
+	FindBySeller(ctx context.Context, sellerID uuid.UUID, limit int) ([]models.MarketListing, error)
+	Search(ctx context.Context, filter models.MarketListingFilter, now time.Time) ([]models.MarketListing, error)
+	Purchase(ctx context.Context, listingID uuid.UUID, buyerID uuid.UUID, now time.Time) (*models.MarketListing, error)
+	Cancel(ctx context.Context, listingID uuid.UUID, sellerID uuid.UUID, now time.Time) (*models.MarketListing, error)
+	ExpireStale(ctx context.Context, now time.Time, limit int) (int, error)
+}
+
+type GuildHandle interface {
+	Create(ctx context.Context, guild *models.Guild) error
+	FindByID(ctx context.Context, id uuid.UUID) (*models.Guild, error)
+	FindMembership(ctx context.Context, userID uuid.UUID) (*models.GuildMember, error)
+	FindMemberUserIDs(ctx context.Context, guildID uuid.UUID) ([]uuid.UUID, error)
+	UpdateSettings(ctx context.Context, guildID uuid.UUID, actorID uuid.UUID, description *string, memberWithdrawalsEnabled *bool) error
+	RemoveMember(ctx context.Context, guildID uuid.UUID, actorID uuid.UUID, targetID uuid.UUID) (bool, error)
+	SetMemberRole(ctx context.Context, guildID uuid.UUID, actorID uuid.UUID, targetID uuid.UUID, role models.GuildRole) error
+	TransferLeadership(ctx context.Context, guildID uuid.UUID, actorID uuid.UUID, targetID uuid.UUID) error
+	Leaderboard(ctx context.Context, zoneID *uuid.UUID, limit int) ([]models.GuildLeaderboardEntry, error)
+}
+
+type GuildInviteHandle interface {
+	Create(ctx context.Context, invite *models.GuildInvite) error
+	FindByID(ctx context.Context, id uuid.UUID) (*models.GuildInvite, error)
+	FindPendingForUser(ctx context.Context, userID uuid.UUID) ([]models.GuildInvite, error)
+	FindPendingForGuild(ctx context.Context, guildID uuid.UUID) ([]models.GuildInvite, error)
+	Accept(ctx context.Context, inviteID uuid.UUID, userID uuid.UUID, now time.Time) error
+	Close(ctx context.Context, inviteID uuid.UUID, status models.GuildInviteStatus, now time.Time) error
+}
+
+type GuildBankHandle interface {
+	FindItems(ctx context.Context, guildID uuid.UUID) ([]models.GuildBankItem, error)
+	FindAuditEntries(ctx context.Context, guildID uuid.UUID, limit int) ([]models.GuildAuditEntry, error)
+	DepositGold(ctx context.Context, guildID uuid.UUID, userID uuid.UUID, amount int) error
+	WithdrawGold(ctx context.Context, guildID uuid.UUID, userID uuid.UUID, amount int) error
+	DepositItem(ctx context.Context, guildID uuid.UUID, userID uuid.UUID, inventoryItemID int, quantity int) error
+	WithdrawItem(ctx context.Context, guildID uuid.UUID, userID uuid.UUID, inventoryItemID int, quantity int) error
+}
+
+type GuildQuestProgressHandle interface {
+	RecordNodeCompletion(ctx context.Context, completion *models.GuildQuestNodeCompletion) (bool, error)
+	FindByGuildAndQuest(ctx context.Context, guildID uuid.UUID, questID uuid.UUID) ([]models.GuildQuestNodeCompletion, error)
+}
+
+type AchievementHandle interface {
+	CreateDefinition(ctx context.Context, definition *models.AchievementDefinition) error
+	UpdateDefinition(ctx context.Context, definition *models.AchievementDefinition) error
+	DeleteDefinition(ctx context.Context, id uuid.UUID) error
+	FindDefinitionByID(ctx context.Context, id uuid.UUID) (*models.AchievementDefinition, error)
+	FindDefinitions(ctx context.Context, includeInactive bool) ([]models.AchievementDefinition, error)
+	CountMetric(ctx context.Context, userID uuid.UUID, definition *models.AchievementDefinition) (int, error)
+	Evaluate(ctx context.Context, evaluation models.AchievementEvaluation) ([]models.UserAchievement, error)
+	FindUserAchievements(ctx context.Context, userID uuid.UUID) ([]models.UserAchievement, error)
+	FindUnclaimedRewards(ctx context.Context, userID uuid.UUID) ([]models.UserAchievement, error)
+	MarkRewardsGranted(ctx context.Context, userAchievementID uuid.UUID, now time.Time) (bool, error)
+	FindUserTitles(ctx context.Context, userID uuid.UUID) ([]models.UserTitle, error)
+	SetActiveTitle(ctx context.Context, userID uuid.UUID, titleID *uuid.UUID) error
+	FindUserIDsAfter(ctx context.Context, after uuid.UUID, limit int) ([]uuid.UUID, error)
+}
+
+type BountyHandle interface {
+	CreateTemplate(ctx context.Context, template *models.BountyTemplate) error
+	UpdateTemplate(ctx context.Context, template *models.BountyTemplate) error
+	DeleteTemplate(ctx context.Context, id uuid.UUID) error
+	FindTemplateByID(ctx context.Context, id uuid.UUID) (*models.BountyTemplate, error)
+	FindTemplates(ctx context.Context, includeInactive bool) ([]models.BountyTemplate, error)
+	FindProgressForZone(ctx context.Context, userID uuid.UUID, zoneID uuid.UUID, periodStarts []time.Time) ([]models.UserBountyProgress, error)
+	FindProgressByID(ctx context.Context, id uuid.UUID) (*models.UserBountyProgress, error)
+	IncrementProgress(ctx context.Context, userID uuid.UUID, zoneID uuid.UUID, template *models.BountyTemplate, periodStart time.Time, now time.Time) (*models.UserBountyProgress, bool, error)
+	ClaimReward(ctx context.Context, userID uuid.UUID, progressID uuid.UUID, now time.Time) (*models.UserBountyProgress, *models.UserBountyStreak, error)
+	FindStreaks(ctx context.Context, userID uuid.UUID) ([]models.UserBountyStreak, error)
+}
+
+type SpawnRuleHandle interface {
+	Create(ctx context.Context, rule *models.SpawnRule) error
+	Update(ctx context.Context, rule *models.SpawnRule) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	FindByID(ctx context.Context, id uuid.UUID) (*models.SpawnRule, error)
+	FindAll(ctx context.Context, includeInactive bool) ([]models.SpawnRule, error)
+}
+
+type ContentBundleHandle interface {
+	Apply(ctx context.Context, plan *models.ContentBundleImportPlan) error
+}
+
+type MapTileHandle interface {
+	Render(ctx context.Context, tile models.MapTile, viewer models.MapTileViewer) ([]byte, error)
+	Version(ctx context.Context, tile models.MapTile, viewer models.MapTileViewer) (*models.MapTileVersion, error)
+}
+
+type WalkingGraphHandle interface {
+	Import(ctx context.Context, nodes []models.WalkingGraphNode, edges []models.WalkingGraphEdge) error
+	FindWithin(ctx context.Context, minLat float64, minLng float64, maxLat float64, maxLng float64) (*models.WalkingGraph, error)
+}
+
+type TemplateRevisionHandle interface {
+	Create(ctx context.Context, revision *models.TemplateRevision) error
+	FindByTemplate(ctx context.Context, templateType models.TemplateRevisionType, templateID uuid.UUID) ([]models.TemplateRevision, error)
+	FindByRevision(ctx context.Context, templateType models.TemplateRevisionType, templateID uuid.UUID, revision int) (*models.TemplateRevision, error)
+	FindLatestPublished(ctx context.Context, templateType models.TemplateRevisionType, templateID uuid.UUID) (*models.TemplateRevision, error)
+	LockLatestPublished(ctx context.Context, templateType models.TemplateRevisionType, templateID uuid.UUID) (*models.TemplateRevision, error)
+	MarkPublished(ctx context.Context, revision *models.TemplateRevision) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+type PromptTemplateHandle interface {
+	Create(ctx context.Context, promptTemplate *models.PromptTemplate) error
+	FindByID(ctx context.Context, id uuid.UUID) (*models.PromptTemplate, error)
+	FindByKey(ctx context.Context, key models.PromptKey) ([]models.PromptTemplate, error)
+	FindActive(ctx context.Context, key models.PromptKey, genreID *uuid.UUID, zoneKind string) (*models.PromptTemplate, error)
+	Activate(ctx context.Context, id uuid.UUID) error
+	Deactivate(ctx context.Context, id uuid.UUID) error
+}
+
+type GeneratedRecordPromptHandle interface {
+	Create(ctx context.Context, recordPrompt *models.GeneratedRecordPrompt) error
+	FindByRecord(ctx context.Context, recordType string, recordID uuid.UUID) (*models.GeneratedRecordPrompt, error)
+	CountByPromptTemplate(ctx context.Context, promptTemplateID uuid.UUID) (int64, error)
+}
+
+type PromptEvaluationHandle interface {
+	Create(ctx context.Context, evaluation *models.PromptEvaluation) error
+	FindByID(ctx context.Context, id uuid.UUID) (*models.PromptEvaluation, error)
+	FindRecent(ctx context.Context, key models.PromptKey, limit int) ([]models.PromptEvaluation, error)
+	Update(ctx context.Context, evaluation *models.PromptEvaluation) error
+	SaveOutput(ctx context.Context, output *models.PromptEvaluationOutput) error
+	FindOutputByID(ctx context.Context, id uuid.UUID) (*models.PromptEvaluationOutput, error)
+	RateOutput(ctx context.Context, id uuid.UUID, rating int, comment string, ratedByUserID *uuid.UUID) error
+}
+
+type ContentModerationHandle interface {
+	Submit(ctx context.Context, item *models.ContentModerationItem) error
+	RecordImage(ctx context.Context, contentType string, contentID uuid.UUID, imageURL string, imageHash int64, findings models.ContentModerationFindings) error
+	FindByID(ctx context.Context, id uuid.UUID) (*models.ContentModerationItem, error)
+	FindByContent(ctx context.Context, contentType string, contentID uuid.UUID) (*models.ContentModerationItem, error)
+	FindQueue(ctx context.Context, status models.ContentModerationStatus, contentType string, limit int) ([]models.ContentModerationItem, error)
+	FindImageHashes(ctx context.Context, contentType string, excludeContentID uuid.UUID) ([]models.ContentModerationItem, error)
+	Review(ctx context.Context, id uuid.UUID, status models.ContentModerationStatus, reviewerID *uuid.UUID, note string) error
+	DeleteByContent(ctx context.Context, contentType string, contentID uuid.UUID) error
+	FindTerms(ctx context.Context) ([]models.ModerationTerm, error)
+	CreateTerm(ctx context.Context, term *models.ModerationTerm) error
+	DeleteTerm(ctx context.Context, id uuid.UUID) error
+}
+
+type LocalizationHandle interface {
+	FindLocales(ctx context.Context) ([]models.TranslationLocale, error)
+	FindEnabledLocales(ctx context.Context) ([]models.TranslationLocale, error)
+	UpsertLocale(ctx context.Context, locale *models.TranslationLocale) error
+	DeleteLocale(ctx context.Context, code string) error
+	FindTranslations(ctx context.Context, entityType string, entityIDs []string, locales []string) ([]models.ContentTranslation, error)
+	SaveManualTranslations(ctx context.Context, translations []models.ContentTranslation) error
+	SaveMachineTranslations(ctx context.Context, translations []models.ContentTranslation) error
+	DeleteTranslation(ctx context.Context, id uuid.UUID) error
+	FindGlossary(ctx context.Context, genreID uuid.UUID, locale string) ([]models.TranslationGlossaryTerm, error)
+	CreateGlossaryTerm(ctx context.Context, term *models.TranslationGlossaryTerm) error
+	DeleteGlossaryTerm(ctx context.Context, id uuid.UUID) error
+	FindTranslationSource(ctx context.Context, entityType string, entityID string) (*models.TranslationSource, error)
+}
+
+type AuthSessionHandle interface {
+	Create(ctx context.Context, session *models.AuthSession) error
+	FindByID(ctx context.Context, id uuid.UUID) (*models.AuthSession, error)
+	FindByRefreshToken(ctx context.Context, refreshToken string) (*models.AuthSession, bool, error)
+	FindActiveByUserID(ctx context.Context, userID uuid.UUID) ([]models.AuthSession, error)
+	Rotate(ctx context.Context, id uuid.UUID, refreshToken string, nextRefreshToken string, ipAddress string, expiresAt time.Time) (bool, error)
+	Revoke(ctx context.Context, id uuid.UUID) error
+	RevokeAllForUser(ctx context.Context, userID uuid.UUID, except *uuid.UUID) (int64, error)
+	MarkMFAVerified(ctx context.Context, id uuid.UUID) error
+	ClearMFAVerifiedForUser(ctx context.Context, userID uuid.UUID) error
+}
+
+type AuthorizationHandle interface {
+	ListRoles(ctx context.Context) ([]models.Role, error)
+	FindRoleByName(ctx context.Context, name string) (*models.Role, error)
+	GrantRole(ctx context.Context, userID uuid.UUID, roleID uuid.UUID, grantedBy *uuid.UUID) error
+	RevokeRole(ctx context.Context, userID uuid.UUID, roleID uuid.UUID) (bool, error)
+	FindRolesForUser(ctx context.Context, userID uuid.UUID) ([]models.UserRole, error)
+	ListGrants(ctx context.Context) ([]models.UserRole, error)
+	FindScopesForUser(ctx context.Context, userID uuid.UUID) ([]string, error)
+}
+
+type TwoFactorHandle interface {
+	FindTOTP(ctx context.Context, userID uuid.UUID) (*models.UserTOTP, error)
+	StartTOTPEnrollment(ctx context.Context, userID uuid.UUID, secretCiphertext string) (bool, error)
+	ConfirmTOTP(ctx context.Context, userID uuid.UUID, step int64, recoveryCodeHashes []string) error
+	UseTOTPStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error)
+	DeleteTOTP(ctx context.Context, userID uuid.UUID) error
+	UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string) (bool, error)
+	ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, codeHashes []string) error
+	CountUnusedRecoveryCodes(ctx context.Context, userID uuid.UUID) (int64, error)
+}
+
+type PasskeyHandle interface {
+	Create(ctx context.Context, credential *models.WebAuthnCredential) error
+	FindByCredentialID(ctx context.Context, credentialID string) (*models.WebAuthnCredential, error)
+	FindByUserID(ctx context.Context, userID uuid.UUID) ([]models.WebAuthnCredential, error)
+	RecordUse(ctx context.Context, id uuid.UUID, signCount uint32) (bool, error)
+	Delete(ctx context.Context, userID uuid.UUID, id uuid.UUID) (bool, error)
+}
+
+type AuthChallengeHandle interface {
+	Create(ctx context.Context, challenge *models.AuthChallenge) error
+	FindActive(ctx context.Context, kind string, challengeHash string) (*models.AuthChallenge, error)
+	Consume(ctx context.Context, id uuid.UUID) (bool, error)
+	RecordFailedAttempt(ctx context.Context, id uuid.UUID, maxAttempts int) (int, error)
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+type UserDataHandle interface {
+	Export(ctx context.Context, userID uuid.UUID) (UserDataExport, error)
+	Delete(ctx context.Context, userID uuid.UUID) error
+	Merge(ctx context.Context, fromID uuid.UUID, intoID uuid.UUID) error
+	Unregistered(ctx context.Context) ([]string, error)
+}
+
 type UserZoneReputationHandle interface {
 	ProcessReputationPointAdditions(ctx context.Context, userID uuid.UUID, zoneID uuid.UUID, reputationPoints int) (*models.UserZoneReputation, error)
 	FindOrCreateForUserAndZone(ctx context.Context, userID uuid.UUID, zoneID uuid.UUID) (*models.UserZoneReputation, error)
@@ -1625,6 +1899,11 @@ type PostFlagHandle interface {
 	IsFlaggedByUser(ctx context.Context, postID, userID uuid.UUID) (bool, error)
 }
 
+type PostVerificationReportHandle interface {
+	Upsert(ctx context.Context, postID uuid.UUID, status models.PostVerificationStatus, report []byte) error
+	FindByPostID(ctx context.Context, postID uuid.UUID) (*models.PostVerificationReport, error)
+}
+
 type AlbumHandle interface {
 	Create(ctx context.Context, userID uuid.UUID, name string, tags []string) (*models.Album, error)
 	FindByUserID(ctx context.Context, userID uuid.UUID) ([]models.Album, error)
@@ -1721,16 +2000,33 @@ type PostCommentHandle interface {
 
 type BlockchainTransactionHandle interface {
 	Create(ctx context.Context, tx *models.BlockchainTransaction) (*models.BlockchainTransaction, error)
+	SetBroadcast(ctx context.Context, id uuid.UUID, txHash string) error
 	FindByID(ctx context.Context, id uuid.UUID) (*models.BlockchainTransaction, error)
 	FindByTxHash(ctx context.Context, txHash string) (*models.BlockchainTransaction, error)
 	FindPending(ctx context.Context) ([]models.BlockchainTransaction, error)
 	FindPendingExpired(ctx context.Context) ([]models.BlockchainTransaction, error)
 	UpdateStatus(ctx context.Context, id uuid.UUID, status string, blockNumber *uint64, confirmedAt *time.Time) error
+	SetStatus(ctx context.Context, id uuid.UUID, change models.BlockchainTransactionStatusChange) error
+	Replace(ctx context.Context, id uuid.UUID, replacement models.BlockchainTransactionReplacement) error
+	FindPendingByAccount(ctx context.Context, chainID int64, fromAddress string) ([]models.BlockchainTransaction, error)
+	FindMinedSinceBlock(ctx context.Context, chainID int64, fromAddress string, blockNumber uint64) ([]models.BlockchainTransaction, error)
 	GetNextNonce(ctx context.Context, chainID int64, fromAddress string) (uint64, error)
 	FindByCertificateFingerprint(ctx context.Context, fingerprint []byte) (*models.BlockchainTransaction, error)
 	FindByManifestHash(ctx context.Context, manifestHash []byte) (*models.BlockchainTransaction, error)
 }
 
+type ManifestAnchorHandle interface {
+	Queue(ctx context.Context, manifestHash []byte, certFingerprint []byte, leafHash []byte) error
+	FindByManifestHash(ctx context.Context, manifestHash []byte) (*models.ManifestAnchor, error)
+	FindUnbatched(ctx context.Context, limit int) ([]models.ManifestAnchor, error)
+}
+
+type ManifestAnchorBatchHandle interface {
+	Create(ctx context.Context, batch *models.ManifestAnchorBatch, anchors []models.ManifestAnchor) (*models.ManifestAnchorBatch, error)
+	FindUnsent(ctx context.Context) ([]models.ManifestAnchorBatch, error)
+	SetTransaction(ctx context.Context, id uuid.UUID, blockchainTransactionID uuid.UUID) error
+}
+
 type PartyInviteHandle interface {
 	Create(ctx context.Context, inviter *models.User, inviteeID uuid.UUID) (*models.PartyInvite, error)
 	FindAllInvites(ctx context.Context, userID uuid.UUID) ([]models.PartyInvite, error)
@@ -1815,6 +2111,7 @@ type QuestHandle interface {
 	FindByQuestGiverCharacterID(ctx context.Context, characterID uuid.UUID) ([]models.Quest, error)
 	FindAll(ctx context.Context) ([]models.Quest, error)
 	FindDueRecurring(ctx context.Context, asOf time.Time, limit int) ([]models.Quest, error)
+	UpdateWalkingEstimate(ctx context.Context, id uuid.UUID, meters int, minutes int) error
 	Delete(ctx context.Context, id uuid.UUID) error
 }
 
@@ -2026,6 +2323,7 @@ type ExpositionHandle interface {
 type ScenarioHandle interface {
 	Create(ctx context.Context, scenario *models.Scenario) error
 	FindByID(ctx context.Context, id uuid.UUID) (*models.Scenario, error)
+	FindApprovedByID(ctx context.Context, id uuid.UUID) (*models.Scenario, error)
 	FindAll(ctx context.Context) ([]models.Scenario, error)
 	ListAdmin(ctx context.Context, params ScenarioAdminListParams, userID *uuid.UUID) (*ScenarioAdminListResult, error)
 	FindByZoneID(ctx context.Context, zoneID uuid.UUID) ([]models.Scenario, error)
@@ -2290,8 +2588,15 @@ type UtilityClosetPuzzleHandle interface {
 
 type UserCertificateHandle interface {
 	Create(ctx context.Context, userID uuid.UUID, certificateDER []byte, certificatePEM string, publicKeyPEM string, fingerprint []byte) (*models.UserCertificate, error)
+	CreateRenewal(ctx context.Context, renews *models.UserCertificate, certificateDER []byte, certificatePEM string, publicKeyPEM string, fingerprint []byte) (*models.UserCertificate, error)
 	FindByUserID(ctx context.Context, userID uuid.UUID) (*models.UserCertificate, error)
+	FindAllByUserID(ctx context.Context, userID uuid.UUID) ([]models.UserCertificate, error)
 	FindByFingerprint(ctx context.Context, fingerprint []byte) (*models.UserCertificate, error)
-	UpdateActive(ctx context.Context, userID uuid.UUID, active bool) error
+	FindBySerialNumber(ctx context.Context, serialNumber string) (*models.UserCertificate, error)
+	FindPendingRenewal(ctx context.Context, certificateID uuid.UUID) (*models.UserCertificate, error)
+	FindRevoked(ctx context.Context) ([]models.UserCertificate, error)
+	Activate(ctx context.Context, id uuid.UUID) error
+	Revoke(ctx context.Context, id uuid.UUID, reason models.CertificateRevocationReason) error
+	BackfillSerialNumbers(ctx context.Context) (int, error)
 	Delete(ctx context.Context, userID uuid.UUID) error
 }
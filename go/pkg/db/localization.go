@@ -0,0 +1,206 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type localizationHandle struct {
+	db *gorm.DB
+}
+
+func (h *localizationHandle) FindLocales(ctx context.Context) ([]models.TranslationLocale, error) {
+	var locales []models.TranslationLocale
+	if err := h.db.WithContext(ctx).Order("code ASC").Find(&locales).Error; err != nil {
+		return nil, err
+	}
+	return locales, nil
+}
+
+func (h *localizationHandle) FindEnabledLocales(ctx context.Context) ([]models.TranslationLocale, error) {
+	var locales []models.TranslationLocale
+	if err := h.db.WithContext(ctx).Where("enabled = ?", true).Order("code ASC").Find(&locales).Error; err != nil {
+		return nil, err
+	}
+	return locales, nil
+}
+
+func (h *localizationHandle) UpsertLocale(ctx context.Context, locale *models.TranslationLocale) error {
+	now := time.Now()
+	locale.CreatedAt = now
+	locale.UpdatedAt = now
+	return h.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "code"}},
+		DoUpdates: clause.AssignmentColumns([]string{"name", "enabled", "machine_translate", "updated_at"}),
+	}).Create(locale).Error
+}
+
+func (h *localizationHandle) DeleteLocale(ctx context.Context, code string) error {
+	return h.db.WithContext(ctx).Delete(&models.TranslationLocale{}, "code = ?", code).Error
+}
+
+// FindTranslations loads the translations of many entities of one type at
+// once, limited to the given locales when any are passed.
+func (h *localizationHandle) FindTranslations(
+	ctx context.Context,
+	entityType string,
+	entityIDs []string,
+	locales []string,
+) ([]models.ContentTranslation, error) {
+	var translations []models.ContentTranslation
+	if len(entityIDs) == 0 {
+		return translations, nil
+	}
+	query := h.db.WithContext(ctx).
+		Where("entity_type = ? AND entity_id IN ?", entityType, entityIDs)
+	if len(locales) > 0 {
+		query = query.Where("locale IN ?", locales)
+	}
+	if err := query.Order("locale ASC").Order("field ASC").Find(&translations).Error; err != nil {
+		return nil, err
+	}
+	return translations, nil
+}
+
+// SaveManualTranslations stores editor overrides, replacing whatever was
+// there before, machine or manual.
+func (h *localizationHandle) SaveManualTranslations(ctx context.Context, translations []models.ContentTranslation) error {
+	if len(translations) == 0 {
+		return nil
+	}
+	now := time.Now()
+	for index := range translations {
+		translations[index].ID = uuid.New()
+		translations[index].CreatedAt = now
+		translations[index].UpdatedAt = now
+		translations[index].Source = models.ContentTranslationSourceManual
+	}
+	return h.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "entity_type"}, {Name: "entity_id"}, {Name: "locale"}, {Name: "field"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"text", "source_hash", "source", "edited_by_user_id", "updated_at",
+		}),
+	}).Create(&translations).Error
+}
+
+// SaveMachineTranslations stores job output without touching fields an
+// editor has overridden by hand.
+func (h *localizationHandle) SaveMachineTranslations(ctx context.Context, translations []models.ContentTranslation) error {
+	if len(translations) == 0 {
+		return nil
+	}
+	now := time.Now()
+	for index := range translations {
+		translations[index].ID = uuid.New()
+		translations[index].CreatedAt = now
+		translations[index].UpdatedAt = now
+		translations[index].Source = models.ContentTranslationSourceMachine
+		translations[index].EditedByUser = nil
+	}
+	return h.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "entity_type"}, {Name: "entity_id"}, {Name: "locale"}, {Name: "field"}},
+		DoUpdates: clause.AssignmentColumns([]string{"text", "source_hash", "updated_at"}),
+		Where: clause.Where{Exprs: []clause.Expression{
+			clause.Eq{Column: clause.Column{Table: "content_translations", Name: "source"}, Value: models.ContentTranslationSourceMachine},
+		}},
+	}).Create(&translations).Error
+}
+
+func (h *localizationHandle) DeleteTranslation(ctx context.Context, id uuid.UUID) error {
+	return h.db.WithContext(ctx).Delete(&models.ContentTranslation{}, "id = ?", id).Error
+}
+
+func (h *localizationHandle) FindGlossary(ctx context.Context, genreID uuid.UUID, locale string) ([]models.TranslationGlossaryTerm, error) {
+	var terms []models.TranslationGlossaryTerm
+	query := h.db.WithContext(ctx).Where("genre_id = ?", genreID)
+	if locale != "" {
+		query = query.Where("locale = ?", locale)
+	}
+	if err := query.Order("locale ASC").Order("term ASC").Find(&terms).Error; err != nil {
+		return nil, err
+	}
+	return terms, nil
+}
+
+func (h *localizationHandle) CreateGlossaryTerm(ctx context.Context, term *models.TranslationGlossaryTerm) error {
+	now := time.Now()
+	term.ID = uuid.New()
+	term.CreatedAt = now
+	term.UpdatedAt = now
+	term.Term = strings.TrimSpace(term.Term)
+	term.Translation = strings.TrimSpace(term.Translation)
+	return h.db.WithContext(ctx).Create(term).Error
+}
+
+func (h *localizationHandle) DeleteGlossaryTerm(ctx context.Context, id uuid.UUID) error {
+	return h.db.WithContext(ctx).Delete(&models.TranslationGlossaryTerm{}, "id = ?", id).Error
+}
+
+// FindTranslationSource loads an entity's English text. Entities without a
+// genre of their own use the default genre's glossary.
+func (h *localizationHandle) FindTranslationSource(
+	ctx context.Context,
+	entityType string,
+	entityID string,
+) (*models.TranslationSource, error) {
+	source := &models.TranslationSource{EntityType: entityType, EntityID: entityID}
+	database := h.db.WithContext(ctx)
+	var err error
+	switch entityType {
+	case models.TranslatableEntityTypeScenario:
+		var scenario models.Scenario
+		err = database.Preload("Options").First(&scenario, "id = ?", entityID).Error
+		source.GenreID = scenario.GenreID
+		source.Fields = models.ScenarioTranslatableText(&scenario)
+	case models.TranslatableEntityTypeExposition:
+		var exposition models.Exposition
+		err = database.First(&exposition, "id = ?", entityID).Error
+		source.Fields = models.ExpositionTranslatableText(&exposition)
+	case models.TranslatableEntityTypeCharacter:
+		var character models.Character
+		err = database.First(&character, "id = ?", entityID).Error
+		source.GenreID = character.GenreID
+		source.Fields = models.CharacterTranslatableText(&character)
+	case models.TranslatableEntityTypeQuest:
+		var quest models.Quest
+		err = database.First(&quest, "id = ?", entityID).Error
+		source.Fields = models.QuestTranslatableText(&quest)
+	case models.TranslatableEntityTypeInventoryItem:
+		id, parseErr := strconv.Atoi(entityID)
+		if parseErr != nil {
+			return nil, fmt.Errorf("invalid inventory item ID %q", entityID)
+		}
+		var item models.InventoryItem
+		err = database.First(&item, "id = ?", id).Error
+		source.GenreID = item.GenreID
+		source.Fields = models.InventoryItemTranslatableText(&item)
+	case models.TranslatableEntityTypeTutorialConfig:
+		var config models.TutorialConfig
+		err = database.First(&config, "id = ?", entityID).Error
+		source.Fields = models.TutorialConfigTranslatableText(&config)
+	default:
+		return nil, fmt.Errorf("unsupported translatable entity type %q", entityType)
+	}
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if source.GenreID == uuid.Nil {
+		genreID, err := defaultCharacterGenreID(ctx, h.db)
+		if err != nil {
+			return nil, err
+		}
+		source.GenreID = genreID
+	}
+	return source, nil
+}
@@ -0,0 +1,62 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type manifestAnchorHandle struct {
+	db *gorm.DB
+}
+
+// Queue adds a manifest to the next batch. Queuing a manifest that's already
+// queued or anchored does nothing.
+func (h *manifestAnchorHandle) Queue(ctx context.Context, manifestHash []byte, certFingerprint []byte, leafHash []byte) error {
+	now := time.Now()
+	return h.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "manifest_hash"}},
+			DoNothing: true,
+		}).
+		Create(&models.ManifestAnchor{
+			CreatedAt:       now,
+			UpdatedAt:       now,
+			ManifestHash:    manifestHash,
+			CertFingerprint: certFingerprint,
+			LeafHash:        leafHash,
+		}).Error
+}
+
+// FindByManifestHash returns a manifest's anchor along with its batch and
+// the batch's transaction, if it's been batched.
+func (h *manifestAnchorHandle) FindByManifestHash(ctx context.Context, manifestHash []byte) (*models.ManifestAnchor, error) {
+	var anchor models.ManifestAnchor
+	if err := h.db.WithContext(ctx).
+		Preload("Batch.BlockchainTransaction").
+		Where("manifest_hash = ?", manifestHash).
+		First(&anchor).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &anchor, nil
+}
+
+// FindUnbatched returns up to limit manifests waiting for a batch, oldest
+// first.
+func (h *manifestAnchorHandle) FindUnbatched(ctx context.Context, limit int) ([]models.ManifestAnchor, error) {
+	var anchors []models.ManifestAnchor
+	if err := h.db.WithContext(ctx).
+		Where("batch_id IS NULL").
+		Order("created_at ASC").
+		Limit(limit).
+		Find(&anchors).Error; err != nil {
+		return nil, err
+	}
+	return anchors, nil
+}
@@ -0,0 +1,80 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type manifestAnchorBatchHandle struct {
+	db *gorm.DB
+}
+
+// Create saves a batch and assigns it the anchors it was built from, along
+// with each anchor's leaf index and proof. It fails without saving anything
+// if another batch has already claimed one of the anchors.
+func (h *manifestAnchorBatchHandle) Create(ctx context.Context, batch *models.ManifestAnchorBatch, anchors []models.ManifestAnchor) (*models.ManifestAnchorBatch, error) {
+	now := time.Now()
+	batch.CreatedAt = now
+	batch.UpdatedAt = now
+
+	err := h.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(batch).Error; err != nil {
+			return err
+		}
+		for _, anchor := range anchors {
+			result := tx.Model(&models.ManifestAnchor{}).
+				Where("id = ? AND batch_id IS NULL", anchor.ID).
+				Updates(map[string]interface{}{
+					"batch_id":   batch.ID,
+					"leaf_index": anchor.LeafIndex,
+					"proof":      anchor.Proof,
+					"updated_at": now,
+				})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return fmt.Errorf("manifest anchor %s is already batched", anchor.ID)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return batch, nil
+}
+
+// FindUnsent returns batches whose root hasn't been sent to the chain yet,
+// or whose transaction failed or was dropped and so has to be sent again.
+// Batches being resent come with their last transaction.
+func (h *manifestAnchorBatchHandle) FindUnsent(ctx context.Context) ([]models.ManifestAnchorBatch, error) {
+	var batches []models.ManifestAnchorBatch
+	if err := h.db.WithContext(ctx).
+		Preload("BlockchainTransaction").
+		Where("blockchain_transaction_id IS NULL OR blockchain_transaction_id IN (?)",
+			h.db.Model(&models.BlockchainTransaction{}).
+				Select("id").
+				Where("status IN ?", []string{string(models.FailedStatus), string(models.DroppedStatus)}),
+		).
+		Order("created_at ASC").
+		Find(&batches).Error; err != nil {
+		return nil, err
+	}
+	return batches, nil
+}
+
+func (h *manifestAnchorBatchHandle) SetTransaction(ctx context.Context, id uuid.UUID, blockchainTransactionID uuid.UUID) error {
+	return h.db.WithContext(ctx).
+		Model(&models.ManifestAnchorBatch{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"blockchain_transaction_id": blockchainTransactionID,
+			"updated_at":                time.Now(),
+		}).Error
+}
@@ -0,0 +1,251 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"gorm.io/gorm"
+)
+
+type mapTileHandle struct {
+	db *gorm.DB
+}
+
+// mapTileEnvelopeCTE exposes the tile envelope in web mercator (for
+// ST_AsMVTGeom) and in 4326 (for index lookups against stored geometry).
+const mapTileEnvelopeCTE = `env AS (
+	SELECT ST_TileEnvelope(@z, @x, @y) AS merc,
+		ST_Transform(ST_TileEnvelope(@z, @x, @y), 4326) AS geo
+)`
+
+// mapTileDiscoveredZonesCTE is the shroud: point content is only drawn in
+// zones the viewer has discovered.
+const mapTileDiscoveredZonesCTE = `discovered_zones AS (
+	SELECT DISTINCT zone_id FROM zone_discoveries WHERE user_id = @user_id
+)`
+
+func mapTileGeom(column string) string {
+	return fmt.Sprintf(
+		"ST_AsMVTGeom(ST_Transform(%s, 3857), env.merc, %d, %d, true)",
+		column,
+		models.MapTileExtent,
+		models.MapTileBuffer,
+	)
+}
+
+type mapTileLayer struct {
+	name  string
+	query string
+}
+
+func mapTileLayers(tile models.MapTile) []mapTileLayer {
+	layers := []mapTileLayer{{
+		name: models.MapTileLayerZones,
+		query: `SELECT ` + mapTileGeom("z.boundary") + ` AS geom,
+			z.id::text AS id,
+			z.name,
+			COALESCE(z.kind, '') AS kind,
+			EXISTS (SELECT 1 FROM discovered_zones dz WHERE dz.zone_id = z.id) AS discovered
+		FROM zones z, env
+		WHERE z.boundary IS NOT NULL AND z.boundary && env.geo`,
+	}}
+	if !tile.IncludesContent() {
+		return layers
+	}
+
+	return append(layers,
+		mapTileLayer{
+			name: models.MapTileLayerPointsOfInterest,
+			query: `SELECT ` + mapTileGeom("poi.geometry") + ` AS geom,
+				poi.id::text AS id,
+				poi.name,
+				COALESCE(poi.marker_category, '') AS marker_category,
+				poi.unlock_tier,
+				EXISTS (
+					SELECT 1 FROM point_of_interest_discoveries d
+					WHERE d.point_of_interest_id = poi.id AND d.user_id = @user_id
+				) AS discovered
+			FROM points_of_interest poi, env
+			WHERE poi.geometry && env.geo
+				AND EXISTS (
+					SELECT 1 FROM point_of_interest_zones pz
+					JOIN discovered_zones dz ON dz.zone_id = pz.zone_id
+					WHERE pz.point_of_interest_id = poi.id AND pz.deleted_at IS NULL
+				)`,
+		},
+		mapTileLayer{
+			name: models.MapTileLayerTreasureChests,
+			query: `SELECT ` + mapTileGeom("tc.geometry") + ` AS geom,
+				tc.id::text AS id,
+				tc.zone_id::text AS zone_id,
+				tc.unlock_tier,
+				EXISTS (
+					SELECT 1 FROM user_treasure_chest_openings o
+					WHERE o.treasure_chest_id = tc.id AND o.user_id = @user_id
+				) AS opened
+			FROM treasure_chests tc, env
+			WHERE tc.geometry && env.geo
+				AND NOT tc.invalidated
+				AND tc.zone_id IN (SELECT zone_id FROM discovered_zones)`,
+		},
+		mapTileLayer{
+			name: models.MapTileLayerShrines,
+			query: `SELECT ` + mapTileGeom("sh.geometry") + ` AS geom,
+				sh.id::text AS id,
+				sh.zone_id::text AS zone_id,
+				sh.shrine_template_id::text AS shrine_template_id
+			FROM shrines sh, env
+			WHERE sh.geometry && env.geo
+				AND NOT sh.invalidated
+				AND sh.zone_id IN (SELECT zone_id FROM discovered_zones)`,
+		},
+		mapTileLayer{
+			name: models.MapTileLayerHealingFountains,
+			query: `SELECT ` + mapTileGeom("hf.geometry") + ` AS geom,
+				hf.id::text AS id,
+				hf.zone_id::text AS zone_id,
+				hf.name,
+				EXISTS (
+					SELECT 1 FROM user_healing_fountain_discoveries d
+					WHERE d.healing_fountain_id = hf.id AND d.user_id = @user_id
+				) AS discovered
+			FROM healing_fountains hf, env
+			WHERE hf.geometry && env.geo
+				AND NOT hf.invalidated
+				AND hf.zone_id IN (SELECT zone_id FROM discovered_zones)`,
+		},
+		mapTileLayer{
+			// Mirrors the map snapshot: quest-node encounters, retired and
+			// defeated encounters, other users' encounters and encounters
+			// gated on story flags the viewer lacks are all hidden.
+			name: models.MapTileLayerMonsters,
+			query: `SELECT ` + mapTileGeom("me.geometry") + ` AS geom,
+				me.id::text AS id,
+				me.zone_id::text AS zone_id,
+				me.name,
+				COALESCE(me.encounter_type, '') AS encounter_type,
+				COALESCE(NULLIF(me.thumbnail_url, ''), me.image_url, '') AS thumbnail_url
+			FROM monster_encounters me, env
+			WHERE me.geometry && env.geo
+				AND me.retired_at IS NULL
+				AND me.zone_id IN (SELECT zone_id FROM discovered_zones)
+				AND (me.owner_user_id IS NULL OR me.owner_user_id = @user_id)
+				AND NOT EXISTS (SELECT 1 FROM quest_nodes qn WHERE qn.monster_encounter_id = me.id)
+				AND NOT EXISTS (
+					SELECT 1 FROM user_monster_encounter_victories v
+					WHERE v.monster_encounter_id = me.id AND v.user_id = @user_id
+				)
+				AND NOT EXISTS (
+					SELECT 1 FROM jsonb_array_elements_text(COALESCE(me.required_story_flags, '[]'::jsonb)) AS required(flag)
+					WHERE btrim(required.flag) <> ''
+						AND lower(btrim(required.flag)) NOT IN (SELECT jsonb_array_elements_text(@story_flags::jsonb))
+				)`,
+		},
+	)
+}
+
+func mapTileParams(tile models.MapTile, viewer models.MapTileViewer) (map[string]interface{}, error) {
+	storyFlags, err := json.Marshal(viewer.NormalizedStoryFlags())
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"z":           tile.Z,
+		"x":           tile.X,
+		"y":           tile.Y,
+		"user_id":     viewer.UserID,
+		"story_flags": string(storyFlags),
+	}, nil
+}
+
+// Render builds the tile in one round trip. Each layer is encoded by
+// ST_AsMVT and the results are concatenated, which is a valid MVT.
+func (h *mapTileHandle) Render(ctx context.Context, tile models.MapTile, viewer models.MapTileViewer) ([]byte, error) {
+	params, err := mapTileParams(tile, viewer)
+	if err != nil {
+		return nil, err
+	}
+
+	layers := mapTileLayers(tile)
+	ctes := []string{mapTileEnvelopeCTE, mapTileDiscoveredZonesCTE}
+	parts := make([]string, 0, len(layers))
+	for i, layer := range layers {
+		alias := fmt.Sprintf("layer_%d", i)
+		ctes = append(ctes, fmt.Sprintf("%s AS (%s)", alias, layer.query))
+		parts = append(parts, fmt.Sprintf(
+			"COALESCE((SELECT ST_AsMVT(%[1]s.*, '%[2]s', %[3]d, 'geom') FROM %[1]s WHERE geom IS NOT NULL), ''::bytea)",
+			alias,
+			layer.name,
+			models.MapTileExtent,
+		))
+	}
+	query := "WITH " + strings.Join(ctes, ",\n") + "\nSELECT " + strings.Join(parts, " || ") + " AS tile"
+
+	var rendered []byte
+	if err := h.db.WithContext(ctx).Raw(query, params).Row().Scan(&rendered); err != nil {
+		return nil, err
+	}
+	return rendered, nil
+}
+
+// mapTileTableVersion summarizes a table's rows inside the tile as
+// "count.max(updated_at)". Counting catches deletes that don't move the max.
+func mapTileTableVersion(table string, where string) string {
+	return fmt.Sprintf(
+		"(SELECT count(*) || '.' || COALESCE(floor(extract(epoch FROM max(updated_at)) * 1000)::bigint, 0) FROM %s, env WHERE %s)",
+		table,
+		where,
+	)
+}
+
+// mapTileViewerTableVersion is the same summary over a user's rows.
+func mapTileViewerTableVersion(table string) string {
+	return fmt.Sprintf(
+		"(SELECT count(*) || '.' || COALESCE(floor(extract(epoch FROM max(updated_at)) * 1000)::bigint, 0) FROM %s WHERE user_id = @user_id)",
+		table,
+	)
+}
+
+// Version is a cheap aggregate over the same rows Render reads, used as the
+// tile's cache key. It never needs to decode geometry.
+func (h *mapTileHandle) Version(ctx context.Context, tile models.MapTile, viewer models.MapTileViewer) (*models.MapTileVersion, error) {
+	params, err := mapTileParams(tile, viewer)
+	if err != nil {
+		return nil, err
+	}
+
+	content := []string{mapTileTableVersion("zones", "boundary IS NOT NULL AND boundary && env.geo")}
+	if tile.IncludesContent() {
+		content = append(content,
+			mapTileTableVersion("points_of_interest", "geometry && env.geo"),
+			mapTileTableVersion("treasure_chests", "geometry && env.geo"),
+			mapTileTableVersion("shrines", "geometry && env.geo"),
+			mapTileTableVersion("healing_fountains", "geometry && env.geo"),
+			mapTileTableVersion("monster_encounters", "geometry && env.geo"),
+			// Zone membership changes don't touch points_of_interest rows.
+			"(SELECT count(*) || '.' || COALESCE(floor(extract(epoch FROM max(pz.updated_at)) * 1000)::bigint, 0) FROM point_of_interest_zones pz JOIN points_of_interest poi ON poi.id = pz.point_of_interest_id, env WHERE poi.geometry && env.geo)",
+		)
+	}
+	viewerParts := []string{
+		mapTileViewerTableVersion("zone_discoveries"),
+	}
+	if tile.IncludesContent() {
+		viewerParts = append(viewerParts,
+			mapTileViewerTableVersion("point_of_interest_discoveries"),
+			mapTileViewerTableVersion("user_treasure_chest_openings"),
+			mapTileViewerTableVersion("user_healing_fountain_discoveries"),
+			mapTileViewerTableVersion("user_monster_encounter_victories"),
+		)
+	}
+
+	query := "WITH " + mapTileEnvelopeCTE + "\nSELECT concat_ws(':', " + strings.Join(content, ", ") + ") AS content, concat_ws(':', " + strings.Join(viewerParts, ", ") + ") AS viewer"
+
+	version := &models.MapTileVersion{}
+	if err := h.db.WithContext(ctx).Raw(query, params).Row().Scan(&version.Content, &version.Viewer); err != nil {
+		return nil, err
+	}
+	return version, nil
+}
@@ -0,0 +1,267 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const defaultMarketListingSearchLimit = 50
+
+type marketListingHandle struct {
+	db *gorm.DB
+}
+
+func (h *marketListingHandle) preloadBase(ctx context.Context) *gorm.DB {
+	return h.db.WithContext(ctx).
+		Preload("Seller").
+		Preload("InventoryItem")
+}
+
+// Create charges the listing fee and moves the listed quantity out of the
+// seller's inventory into escrow in the same transaction that inserts the
+// listing.
+func (h *marketListingHandle) Create(ctx context.Context, listing *models.MarketListing) error {
+	if listing == nil {
+		return nil
+	}
+	now := time.Now()
+	if listing.ID == uuid.Nil {
+		listing.ID = uuid.New()
+	}
+	listing.Status = models.MarketListingStatusActive
+	listing.CreatedAt = now
+	listing.UpdatedAt = now
+
+	return h.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		users, err := lockUsersForUpdate(tx, listing.SellerUserID)
+		if err != nil {
+			return err
+		}
+		if err := adjustUserGoldTx(tx, users[listing.SellerUserID], -listing.ListingFee); err != nil {
+			return err
+		}
+		if err := removeUserInventoryItemTx(tx, listing.SellerUserID, listing.InventoryItemID, listing.Quantity); err != nil {
+			return err
+		}
+		return tx.Omit(clause.Associations).Create(listing).Error
+	})
+}
+
+func (h *marketListingHandle) FindByID(ctx context.Context, id uuid.UUID) (*models.MarketListing, error) {
+	listing := &models.MarketListing{}
+	if err := h.preloadBase(ctx).Where("id = ?", id).First(listing).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return listing, nil
+}
+
+func (h *marketListingHandle) FindBySeller(ctx context.Context, sellerID uuid.UUID, limit int) ([]models.MarketListing, error) {
+	if limit <= 0 {
+		limit = defaultMarketListingSearchLimit
+	}
+	listings := []models.MarketListing{}
+	if err := h.preloadBase(ctx).
+		Where("seller_user_id = ?", sellerID).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&listings).Error; err != nil {
+		return nil, err
+	}
+	return listings, nil
+}
+
+func (h *marketListingHandle) Search(ctx context.Context, filter models.MarketListingFilter, now time.Time) ([]models.MarketListing, error) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultMarketListingSearchLimit
+	}
+
+	query := h.preloadBase(ctx).
+		Joins("JOIN inventory_items ON inventory_items.id = market_listings.inventory_item_id").
+		Where("market_listings.zone_id = ?", filter.ZoneID).
+		Where("market_listings.status = ? AND market_listings.expires_at > ?", models.MarketListingStatusActive, now)
+
+	if filter.ExcludeSeller != nil {
+		query = query.Where("market_listings.seller_user_id <> ?", *filter.ExcludeSeller)
+	}
+	if rarities := normalizedLowerStrings(filter.RarityTiers); len(rarities) > 0 {
+		query = query.Where("LOWER(inventory_items.rarity_tier) IN ?", rarities)
+	}
+	if filter.MinItemLevel > 0 {
+		query = query.Where("inventory_items.item_level >= ?", filter.MinItemLevel)
+	}
+	if filter.MaxItemLevel > 0 {
+		query = query.Where("inventory_items.item_level <= ?", filter.MaxItemLevel)
+	}
+	if tags := normalizedLowerStrings(filter.Tags); len(tags) > 0 {
+		query = query.Where(
+			"EXISTS (SELECT 1 FROM jsonb_array_elements_text(COALESCE(inventory_items.internal_tags, '[]'::jsonb)) AS tag WHERE LOWER(tag) IN ?)",
+			tags,
+		)
+	}
+
+	listings := []models.MarketListing{}
+	if err := query.
+		Order("market_listings.price ASC, market_listings.created_at ASC").
+		Limit(limit).
+		Offset(filter.Offset).
+		Find(&listings).Error; err != nil {
+		return nil, err
+	}
+	return listings, nil
+}
+
+// Purchase pays the seller, hands the escrowed items to the buyer and closes
+// the listing. The listing row is locked first, so only one of several
+// concurrent buyers can win it.
+func (h *marketListingHandle) Purchase(ctx context.Context, listingID uuid.UUID, buyerID uuid.UUID, now time.Time) (*models.MarketListing, error) {
+	err := h.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		listing := &models.MarketListing{}
+		if err := tx.
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", listingID).
+			First(listing).Error; err != nil {
+			return err
+		}
+		if !listing.IsPurchasable(now) {
+			return ErrMarketListingNotAvailable
+		}
+		if listing.SellerUserID == buyerID {
+			return errors.New("you cannot buy your own listing")
+		}
+
+		users, err := lockUsersForUpdate(tx, listing.SellerUserID, buyerID)
+		if err != nil {
+			return err
+		}
+		if err := adjustUserGoldTx(tx, users[buyerID], -listing.Price); err != nil {
+			return err
+		}
+		if err := adjustUserGoldTx(tx, users[listing.SellerUserID], listing.Price); err != nil {
+			return err
+		}
+		if err := addUserInventoryItemTx(tx, buyerID, listing.InventoryItemID, listing.Quantity); err != nil {
+			return err
+		}
+
+		return tx.Model(&models.MarketListing{}).
+			Where("id = ?", listing.ID).
+			Updates(map[string]interface{}{
+				"status":        models.MarketListingStatusSold,
+				"buyer_user_id": buyerID,
+				"sold_at":       now,
+				"closed_at":     now,
+				"updated_at":    now,
+			}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return h.FindByID(ctx, listingID)
+}
+
+// Cancel returns the escrowed items to the seller. The listing fee is not
+// refunded.
+func (h *marketListingHandle) Cancel(ctx context.Context, listingID uuid.UUID, sellerID uuid.UUID, now time.Time) (*models.MarketListing, error) {
+	err := h.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		listing := &models.MarketListing{}
+		if err := tx.
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND seller_user_id = ?", listingID, sellerID).
+			First(listing).Error; err != nil {
+			return err
+		}
+		if listing.Status != models.MarketListingStatusActive {
+			return ErrMarketListingNotAvailable
+		}
+		return closeMarketListingTx(tx, listing, models.MarketListingStatusCancelled, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return h.FindByID(ctx, listingID)
+}
+
+// ExpireStale closes active listings past their expiry and returns their
+// escrowed items to the sellers. Each listing is closed in its own
+// transaction so one bad row doesn't hold back the rest of the batch.
+func (h *marketListingHandle) ExpireStale(ctx context.Context, now time.Time, limit int) (int, error) {
+	if limit <= 0 {
+		limit = defaultMarketListingSearchLimit
+	}
+	ids := []uuid.UUID{}
+	if err := h.db.WithContext(ctx).
+		Model(&models.MarketListing{}).
+		Where("status = ? AND expires_at <= ?", models.MarketListingStatusActive, now).
+		Order("expires_at ASC").
+		Limit(limit).
+		Pluck("id", &ids).Error; err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, id := range ids {
+		closed := false
+		err := h.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			listing := &models.MarketListing{}
+			if err := tx.
+				Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("id = ?", id).
+				First(listing).Error; err != nil {
+				return err
+			}
+			if listing.Status != models.MarketListingStatusActive || listing.ExpiresAt.After(now) {
+				return nil
+			}
+			closed = true
+			return closeMarketListingTx(tx, listing, models.MarketListingStatusExpired, now)
+		})
+		if err != nil {
+			return expired, err
+		}
+		if closed {
+			expired++
+		}
+	}
+	return expired, nil
+}
+
+func closeMarketListingTx(tx *gorm.DB, listing *models.MarketListing, status models.MarketListingStatus, now time.Time) error {
+	if err := addUserInventoryItemTx(tx, listing.SellerUserID, listing.InventoryItemID, listing.Quantity); err != nil {
+		return err
+	}
+	return tx.Model(&models.MarketListing{}).
+		Where("id = ?", listing.ID).
+		Updates(map[string]interface{}{
+			"status":     status,
+			"closed_at":  now,
+			"updated_at": now,
+		}).Error
+}
+
+func normalizedLowerStrings(values []string) []string {
+	normalized := make([]string, 0, len(values))
+	seen := map[string]struct{}{}
+	for _, value := range values {
+		value = strings.ToLower(strings.TrimSpace(value))
+		if value == "" {
+			continue
+		}
+		if _, ok := seen[value]; ok {
+			continue
+		}
+		seen[value] = struct{}{}
+		normalized = append(normalized, value)
+	}
+	return normalized
+}
@@ -0,0 +1,123 @@
+package db
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// lockUsersForUpdate row-locks the given users in a stable order so that two
+// transactions touching the same pair of players can't deadlock each other.
+func lockUsersForUpdate(tx *gorm.DB, userIDs ...uuid.UUID) (map[uuid.UUID]*models.User, error) {
+	unique := make([]uuid.UUID, 0, len(userIDs))
+	seen := map[uuid.UUID]struct{}{}
+	for _, id := range userIDs {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		unique = append(unique, id)
+	}
+	sort.Slice(unique, func(i, j int) bool {
+		return bytes.Compare(unique[i][:], unique[j][:]) < 0
+	})
+
+	locked := make(map[uuid.UUID]*models.User, len(unique))
+	for _, id := range unique {
+		user := &models.User{}
+		if err := tx.
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", id).
+			First(user).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, ErrUserNotFound
+			}
+			return nil, err
+		}
+		locked[id] = user
+	}
+	return locked, nil
+}
+
+func adjustUserGoldTx(tx *gorm.DB, user *models.User, delta int) error {
+	if delta == 0 {
+		return nil
+	}
+	if user.Gold+delta < 0 {
+		return ErrInsufficientGold
+	}
+	if err := tx.Model(&models.User{}).
+		Where("id = ?", user.ID).
+		UpdateColumn("gold", gorm.Expr("gold + ?", delta)).Error; err != nil {
+		return err
+	}
+	user.Gold += delta
+	return nil
+}
+
+// removeUserInventoryItemTx takes quantity copies of an item out of a user's
+// inventory. Equipped copies are never removed.
+func removeUserInventoryItemTx(tx *gorm.DB, userID uuid.UUID, inventoryItemID int, quantity int) error {
+	if quantity <= 0 {
+		return fmt.Errorf("invalid quantity for item %d", inventoryItemID)
+	}
+
+	owned := &models.OwnedInventoryItem{}
+	if err := tx.
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ? AND inventory_item_id = ?", userID, inventoryItemID).
+		First(owned).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%w: item %d", ErrInsufficientItemQuantity, inventoryItemID)
+		}
+		return err
+	}
+
+	var equippedCount int64
+	if err := tx.Model(&models.UserEquipment{}).
+		Where("owned_inventory_item_id = ?", owned.ID).
+		Count(&equippedCount).Error; err != nil {
+		return err
+	}
+	if owned.Quantity-int(equippedCount) < quantity {
+		return fmt.Errorf("%w: item %d", ErrInsufficientItemQuantity, inventoryItemID)
+	}
+
+	owned.Quantity -= quantity
+	if owned.Quantity <= 0 {
+		return tx.Delete(owned).Error
+	}
+	return tx.Save(owned).Error
+}
+
+func addUserInventoryItemTx(tx *gorm.DB, userID uuid.UUID, inventoryItemID int, quantity int) error {
+	if quantity <= 0 {
+		return fmt.Errorf("invalid quantity for item %d", inventoryItemID)
+	}
+
+	owned := &models.OwnedInventoryItem{}
+	err := tx.
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ? AND inventory_item_id = ?", userID, inventoryItemID).
+		First(owned).Error
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		return tx.Create(&models.OwnedInventoryItem{
+			ID:              uuid.New(),
+			UserID:          &userID,
+			InventoryItemID: inventoryItemID,
+			Quantity:        quantity,
+		}).Error
+	}
+
+	owned.Quantity += quantity
+	return tx.Save(owned).Error
+}
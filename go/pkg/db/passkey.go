@@ -0,0 +1,61 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type passkeyHandle struct {
+	db *gorm.DB
+}
+
+func (h *passkeyHandle) Create(ctx context.Context, credential *models.WebAuthnCredential) error {
+	now := time.Now()
+	credential.ID = uuid.New()
+	credential.CreatedAt = now
+	credential.UpdatedAt = now
+	return h.db.WithContext(ctx).Create(credential).Error
+}
+
+func (h *passkeyHandle) FindByCredentialID(ctx context.Context, credentialID string) (*models.WebAuthnCredential, error) {
+	var credential models.WebAuthnCredential
+	if err := h.db.WithContext(ctx).First(&credential, "credential_id = ?", credentialID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &credential, nil
+}
+
+func (h *passkeyHandle) FindByUserID(ctx context.Context, userID uuid.UUID) ([]models.WebAuthnCredential, error) {
+	var credentials []models.WebAuthnCredential
+	if err := h.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at ASC").Find(&credentials).Error; err != nil {
+		return nil, err
+	}
+	return credentials, nil
+}
+
+// RecordUse stores the authenticator's new signature counter. It reports
+// false when the counter did not move forward — a sign the credential was
+// cloned — except for authenticators that always report zero.
+func (h *passkeyHandle) RecordUse(ctx context.Context, id uuid.UUID, signCount uint32) (bool, error) {
+	now := time.Now()
+	query := h.db.WithContext(ctx).Model(&models.WebAuthnCredential{}).Where("id = ?", id)
+	if signCount > 0 {
+		query = query.Where("sign_count < ?", signCount)
+	} else {
+		query = query.Where("sign_count = 0")
+	}
+	result := query.Updates(map[string]interface{}{"sign_count": signCount, "last_used_at": now, "updated_at": now})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (h *passkeyHandle) Delete(ctx context.Context, userID uuid.UUID, id uuid.UUID) (bool, error) {
+	result := h.db.WithContext(ctx).Delete(&models.WebAuthnCredential{}, "id = ? AND user_id = ?", id, userID)
+	return result.RowsAffected > 0, result.Error
+}
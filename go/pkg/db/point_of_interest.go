@@ -394,6 +394,52 @@ func (c *pointOfInterestHandle) FindByGoogleMapsPlaceID(ctx context.Context, goo
 	return &pointOfInterest, nil
 }
 
+func (c *pointOfInterestHandle) FindByOSMID(ctx context.Context, osmID string) (*models.PointOfInterest, error) {
+	var pointOfInterest models.PointOfInterest
+	if err := c.preloadBase(ctx).Where("osm_id = ?", osmID).First(&pointOfInterest).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	normalizePointOfInterest(&pointOfInterest)
+
+	return &pointOfInterest, nil
+}
+
+// FindNearbyByName finds the closest point of interest within radiusMeters
+// whose original or Google name matches name case-insensitively. It is how
+// Google and OpenStreetMap imports of the same place find each other.
+func (c *pointOfInterestHandle) FindNearbyByName(ctx context.Context, name string, lat float64, lng float64, radiusMeters float64) (*models.PointOfInterest, error) {
+	var pointOfInterest models.PointOfInterest
+	if err := c.preloadBase(ctx).
+		Where("ST_DWithin(geometry::geography, ST_SetSRID(ST_MakePoint(?, ?), 4326)::geography, ?)", lng, lat, radiusMeters).
+		Where("LOWER(original_name) = LOWER(?) OR LOWER(google_maps_place_name) = LOWER(?)", name, name).
+		Order(gorm.Expr("ST_Distance(geometry::geography, ST_SetSRID(ST_MakePoint(?, ?), 4326)::geography)", lng, lat)).
+		First(&pointOfInterest).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	normalizePointOfInterest(&pointOfInterest)
+
+	return &pointOfInterest, nil
+}
+
+// LinkProvenance records an additional source ID on an existing point of
+// interest. Nil IDs are left untouched so linking never clears a match.
+func (c *pointOfInterestHandle) LinkProvenance(ctx context.Context, pointOfInterestID uuid.UUID, googleMapsPlaceID *string, osmID *string) error {
+	updates := map[string]interface{}{"updated_at": time.Now()}
+	if googleMapsPlaceID != nil {
+		updates["google_maps_place_id"] = *googleMapsPlaceID
+	}
+	if osmID != nil {
+		updates["osm_id"] = *osmID
+	}
+	return c.db.WithContext(ctx).Model(&models.PointOfInterest{}).Where("id = ?", pointOfInterestID).Updates(updates).Error
+}
+
 func (c *pointOfInterestHandle) Update(ctx context.Context, pointOfInterestID uuid.UUID, updates *models.PointOfInterest) error {
 	if updates == nil {
 		return nil
@@ -513,6 +559,9 @@ func (c *pointOfInterestHandle) FindRecentlyUsedInZone(ctx context.Context, zone
 		if poi.GoogleMapsPlaceID != nil {
 			recentlyUsed[*poi.GoogleMapsPlaceID] = true
 		}
+		if poi.OSMID != nil {
+			recentlyUsed[*poi.OSMID] = true
+		}
 	}
 
 	return recentlyUsed, nil
@@ -0,0 +1,48 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type postVerificationReportHandle struct {
+	db *gorm.DB
+}
+
+// Upsert stores a post's verification report, replacing any earlier one.
+func (h *postVerificationReportHandle) Upsert(ctx context.Context, postID uuid.UUID, status models.PostVerificationStatus, report []byte) error {
+	now := time.Now()
+	return h.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "post_id"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"status":     status,
+				"report":     datatypes.JSON(report),
+				"updated_at": now,
+			}),
+		}).
+		Create(&models.PostVerificationReport{
+			PostID:    postID,
+			CreatedAt: now,
+			UpdatedAt: now,
+			Status:    status,
+			Report:    datatypes.JSON(report),
+		}).Error
+}
+
+func (h *postVerificationReportHandle) FindByPostID(ctx context.Context, postID uuid.UUID) (*models.PostVerificationReport, error) {
+	var report models.PostVerificationReport
+	if err := h.db.WithContext(ctx).Where("post_id = ?", postID).First(&report).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &report, nil
+}
@@ -0,0 +1,93 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/google/uuid"
+)
+
+// ResolvedPrompt is a rendered prompt plus the registry version it came
+// from. Template is nil when the built-in default body was used.
+type ResolvedPrompt struct {
+	Key      models.PromptKey
+	Text     string
+	Template *models.PromptTemplate
+}
+
+func (r ResolvedPrompt) TemplateID() *uuid.UUID {
+	if r.Template == nil {
+		return nil
+	}
+	id := r.Template.ID
+	return &id
+}
+
+func (r ResolvedPrompt) Version() int {
+	if r.Template == nil {
+		return 0
+	}
+	return r.Template.Version
+}
+
+// ResolvePrompt renders the active registry version for the genre and zone
+// kind. A broken stored version must not stop generation, so lookup and
+// render failures fall back to the built-in default body, as does a nil
+// client.
+func ResolvePrompt(
+	ctx context.Context,
+	dbClient DbClient,
+	key models.PromptKey,
+	genreID *uuid.UUID,
+	zoneKind string,
+	variables map[string]interface{},
+) (ResolvedPrompt, error) {
+	definition, ok := models.FindPromptDefinition(key)
+	if !ok {
+		return ResolvedPrompt{}, fmt.Errorf("unknown prompt key %q", key)
+	}
+
+	if dbClient != nil {
+		promptTemplate, err := dbClient.PromptTemplate().FindActive(ctx, key, genreID, zoneKind)
+		if err != nil {
+			log.Printf("[prompts][resolve] falling back to default key=%s err=%v", key, err)
+		} else if promptTemplate != nil {
+			text, err := models.RenderPrompt(promptTemplate.Body, variables)
+			if err == nil {
+				return ResolvedPrompt{Key: key, Text: text, Template: promptTemplate}, nil
+			}
+			log.Printf("[prompts][resolve] falling back to default key=%s version=%d err=%v", key, promptTemplate.Version, err)
+		}
+	}
+
+	text, err := models.RenderPrompt(definition.DefaultBody, variables)
+	if err != nil {
+		return ResolvedPrompt{}, fmt.Errorf("failed to render default %s prompt: %w", key, err)
+	}
+	return ResolvedPrompt{Key: key, Text: text}, nil
+}
+
+// RecordGeneratedPrompt stores which prompt version produced a record.
+// Provenance is best effort; the generated record is already saved.
+func RecordGeneratedPrompt(
+	ctx context.Context,
+	dbClient DbClient,
+	prompt ResolvedPrompt,
+	recordType string,
+	recordID uuid.UUID,
+) {
+	if dbClient == nil || prompt.Key == "" || recordID == uuid.Nil {
+		return
+	}
+	if err := dbClient.GeneratedRecordPrompt().Create(ctx, &models.GeneratedRecordPrompt{
+		RecordType:       recordType,
+		RecordID:         recordID,
+		PromptKey:        prompt.Key,
+		PromptTemplateID: prompt.TemplateID(),
+		PromptVersion:    prompt.Version(),
+	}); err != nil {
+		log.Printf("[prompts][provenance] failed to record prompt record_type=%s record_id=%s err=%v", recordType, recordID, err)
+	}
+}
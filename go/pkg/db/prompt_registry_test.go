@@ -0,0 +1,37 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+)
+
+func TestResolvePromptFallsBackToDefaultBody(t *testing.T) {
+	resolved, err := ResolvePrompt(context.Background(), nil, models.PromptKeyShrineTemplateGeneration, nil, "forest", map[string]interface{}{
+		"Count":           4,
+		"RecentTemplates": "- Shrine of Dawn: heals the weary",
+	})
+	if err != nil {
+		t.Fatalf("ResolvePrompt returned error: %v", err)
+	}
+	if resolved.Template != nil || resolved.Version() != 0 || resolved.TemplateID() != nil {
+		t.Fatalf("expected the built-in default, got %+v", resolved.Template)
+	}
+	if !strings.Contains(resolved.Text, "designing 4 reusable") || !strings.Contains(resolved.Text, "Output exactly 4 templates.") {
+		t.Fatalf("expected the count to be rendered twice, got %q", resolved.Text)
+	}
+	if !strings.Contains(resolved.Text, "- Shrine of Dawn: heals the weary") {
+		t.Fatalf("expected recent templates in the prompt, got %q", resolved.Text)
+	}
+}
+
+func TestResolvePromptRejectsMissingVariablesAndUnknownKeys(t *testing.T) {
+	if _, err := ResolvePrompt(context.Background(), nil, models.PromptKeyShrineTemplateGeneration, nil, "", map[string]interface{}{"Count": 2}); err == nil {
+		t.Fatal("expected a missing variable to fail the default render")
+	}
+	if _, err := ResolvePrompt(context.Background(), nil, models.PromptKey("zone_flavor"), nil, "", nil); err == nil {
+		t.Fatal("expected an unknown prompt key to be rejected")
+	}
+}
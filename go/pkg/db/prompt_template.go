@@ -0,0 +1,245 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type promptTemplateHandle struct {
+	db *gorm.DB
+}
+
+// Create numbers the version after the key's latest one. Versions are
+// numbered per key rather than per scope so "v7" names a single body.
+func (h *promptTemplateHandle) Create(ctx context.Context, promptTemplate *models.PromptTemplate) error {
+	return h.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var next int
+		if err := tx.Raw(
+			`SELECT COALESCE(MAX(version), 0) + 1 FROM prompt_templates WHERE prompt_key = ?`,
+			promptTemplate.Key,
+		).Scan(&next).Error; err != nil {
+			return err
+		}
+		promptTemplate.ID = uuid.New()
+		promptTemplate.CreatedAt = time.Now()
+		promptTemplate.UpdatedAt = promptTemplate.CreatedAt
+		promptTemplate.Version = next
+		promptTemplate.Active = false
+		return tx.Create(promptTemplate).Error
+	})
+}
+
+func (h *promptTemplateHandle) FindByID(ctx context.Context, id uuid.UUID) (*models.PromptTemplate, error) {
+	var promptTemplate models.PromptTemplate
+	if err := h.db.WithContext(ctx).Where("id = ?", id).First(&promptTemplate).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &promptTemplate, nil
+}
+
+func (h *promptTemplateHandle) FindByKey(ctx context.Context, key models.PromptKey) ([]models.PromptTemplate, error) {
+	var promptTemplates []models.PromptTemplate
+	if err := h.db.WithContext(ctx).
+		Where("prompt_key = ?", key).
+		Order("version DESC").
+		Find(&promptTemplates).Error; err != nil {
+		return nil, err
+	}
+	return promptTemplates, nil
+}
+
+// FindActive returns the active version a job should use for the genre and
+// zone kind, or nil when the built-in default applies.
+func (h *promptTemplateHandle) FindActive(
+	ctx context.Context,
+	key models.PromptKey,
+	genreID *uuid.UUID,
+	zoneKind string,
+) (*models.PromptTemplate, error) {
+	var candidates []models.PromptTemplate
+	if err := h.db.WithContext(ctx).
+		Where("prompt_key = ? AND active = ?", key, true).
+		Find(&candidates).Error; err != nil {
+		return nil, err
+	}
+	return models.SelectPromptTemplate(candidates, genreID, zoneKind), nil
+}
+
+// Activate makes the version the live one for its genre and zone kind scope,
+// deactivating whichever version held that scope before.
+func (h *promptTemplateHandle) Activate(ctx context.Context, id uuid.UUID) error {
+	return h.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var promptTemplate models.PromptTemplate
+		if err := tx.Where("id = ?", id).First(&promptTemplate).Error; err != nil {
+			return err
+		}
+		now := time.Now()
+		scope := tx.Model(&models.PromptTemplate{}).
+			Where("prompt_key = ? AND zone_kind = ? AND id <> ?", promptTemplate.Key, promptTemplate.ZoneKind, id)
+		if promptTemplate.GenreID != nil {
+			scope = scope.Where("genre_id = ?", *promptTemplate.GenreID)
+		} else {
+			scope = scope.Where("genre_id IS NULL")
+		}
+		if err := scope.Updates(map[string]interface{}{"active": false, "updated_at": now}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.PromptTemplate{}).Where("id = ?", id).Updates(map[string]interface{}{
+			"active":     true,
+			"updated_at": now,
+		}).Error
+	})
+}
+
+func (h *promptTemplateHandle) Deactivate(ctx context.Context, id uuid.UUID) error {
+	return h.db.WithContext(ctx).Model(&models.PromptTemplate{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"active":     false,
+		"updated_at": time.Now(),
+	}).Error
+}
+
+type generatedRecordPromptHandle struct {
+	db *gorm.DB
+}
+
+func (h *generatedRecordPromptHandle) Create(ctx context.Context, recordPrompt *models.GeneratedRecordPrompt) error {
+	if recordPrompt.ID == uuid.Nil {
+		recordPrompt.ID = uuid.New()
+	}
+	if recordPrompt.CreatedAt.IsZero() {
+		recordPrompt.CreatedAt = time.Now()
+	}
+	return h.db.WithContext(ctx).Create(recordPrompt).Error
+}
+
+func (h *generatedRecordPromptHandle) FindByRecord(
+	ctx context.Context,
+	recordType string,
+	recordID uuid.UUID,
+) (*models.GeneratedRecordPrompt, error) {
+	var recordPrompt models.GeneratedRecordPrompt
+	if err := h.db.WithContext(ctx).
+		Where("record_type = ? AND record_id = ?", recordType, recordID).
+		Order("created_at DESC").
+		First(&recordPrompt).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &recordPrompt, nil
+}
+
+func (h *generatedRecordPromptHandle) CountByPromptTemplate(ctx context.Context, promptTemplateID uuid.UUID) (int64, error) {
+	var count int64
+	if err := h.db.WithContext(ctx).
+		Model(&models.GeneratedRecordPrompt{}).
+		Where("prompt_template_id = ?", promptTemplateID).
+		Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+type promptEvaluationHandle struct {
+	db *gorm.DB
+}
+
+func (h *promptEvaluationHandle) Create(ctx context.Context, evaluation *models.PromptEvaluation) error {
+	evaluation.ID = uuid.New()
+	evaluation.CreatedAt = time.Now()
+	evaluation.UpdatedAt = evaluation.CreatedAt
+	return h.db.WithContext(ctx).Omit("Outputs").Create(evaluation).Error
+}
+
+func (h *promptEvaluationHandle) FindByID(ctx context.Context, id uuid.UUID) (*models.PromptEvaluation, error) {
+	var evaluation models.PromptEvaluation
+	if err := h.db.WithContext(ctx).
+		Preload("Outputs", func(db *gorm.DB) *gorm.DB {
+			return db.Order("input_index ASC").Order("variant ASC")
+		}).
+		Where("id = ?", id).
+		First(&evaluation).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &evaluation, nil
+}
+
+func (h *promptEvaluationHandle) FindRecent(ctx context.Context, key models.PromptKey, limit int) ([]models.PromptEvaluation, error) {
+	var evaluations []models.PromptEvaluation
+	query := h.db.WithContext(ctx).Order("created_at DESC")
+	if key != "" {
+		query = query.Where("prompt_key = ?", key)
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if err := query.Find(&evaluations).Error; err != nil {
+		return nil, err
+	}
+	return evaluations, nil
+}
+
+func (h *promptEvaluationHandle) Update(ctx context.Context, evaluation *models.PromptEvaluation) error {
+	evaluation.UpdatedAt = time.Now()
+	return h.db.WithContext(ctx).Model(&models.PromptEvaluation{}).Where("id = ?", evaluation.ID).Updates(map[string]interface{}{
+		"status":        evaluation.Status,
+		"error_message": evaluation.ErrorMessage,
+		"updated_at":    evaluation.UpdatedAt,
+	}).Error
+}
+
+// SaveOutput replaces the output for an input and variant so a retried
+// evaluation job overwrites rather than duplicates what it already ran.
+func (h *promptEvaluationHandle) SaveOutput(ctx context.Context, output *models.PromptEvaluationOutput) error {
+	return h.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where(
+			"evaluation_id = ? AND input_index = ? AND variant = ?",
+			output.EvaluationID,
+			output.InputIndex,
+			output.Variant,
+		).Delete(&models.PromptEvaluationOutput{}).Error; err != nil {
+			return err
+		}
+		output.ID = uuid.New()
+		output.CreatedAt = time.Now()
+		output.UpdatedAt = output.CreatedAt
+		return tx.Create(output).Error
+	})
+}
+
+func (h *promptEvaluationHandle) FindOutputByID(ctx context.Context, id uuid.UUID) (*models.PromptEvaluationOutput, error) {
+	var output models.PromptEvaluationOutput
+	if err := h.db.WithContext(ctx).Where("id = ?", id).First(&output).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &output, nil
+}
+
+func (h *promptEvaluationHandle) RateOutput(
+	ctx context.Context,
+	id uuid.UUID,
+	rating int,
+	comment string,
+	ratedByUserID *uuid.UUID,
+) error {
+	return h.db.WithContext(ctx).Model(&models.PromptEvaluationOutput{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"rating":           rating,
+		"rating_comment":   comment,
+		"rated_by_user_id": ratedByUserID,
+		"updated_at":       time.Now(),
+	}).Error
+}
@@ -282,6 +282,17 @@ func (h *questHandle) FindDueRecurring(ctx context.Context, asOf time.Time, limi
 	return quests, nil
 }
 
+func (h *questHandle) UpdateWalkingEstimate(ctx context.Context, id uuid.UUID, meters int, minutes int) error {
+	return h.db.WithContext(ctx).
+		Model(&models.Quest{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"estimated_walking_meters":  meters,
+			"estimated_walking_minutes": minutes,
+			"updated_at":                time.Now(),
+		}).Error
+}
+
 func (h *questHandle) Delete(ctx context.Context, id uuid.UUID) error {
 	return h.db.WithContext(ctx).Delete(&models.Quest{}, "id = ?", id).Error
 }
@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"time"
 
@@ -158,3 +159,45 @@ func (h *questArchetypeHandle) FindAll(ctx context.Context) ([]*models.QuestArch
 	}
 	return questArchetypes, nil
 }
+
+// questArchetypeIDsByGraphQuery finds the archetypes whose node graph
+// contains any of the seed nodes, by walking challenge unlocks back up to
+// the archetypes' roots.
+const questArchetypeIDsByGraphQuery = `
+WITH RECURSIVE ancestors(node_id) AS (
+	%s
+	UNION
+	SELECT links.quest_archetype_node_id
+	FROM quest_archetype_node_challenges links
+	JOIN quest_archetype_challenges challenges ON challenges.id = links.quest_archetype_challenge_id
+	JOIN ancestors ON challenges.unlocked_node_id = ancestors.node_id
+		OR challenges.failure_unlocked_node_id = ancestors.node_id
+	WHERE challenges.deleted_at IS NULL
+)
+SELECT DISTINCT quest_archetypes.id
+FROM quest_archetypes
+JOIN ancestors ON quest_archetypes.root_id = ancestors.node_id
+WHERE quest_archetypes.deleted_at IS NULL`
+
+// FindIDsByNode returns the archetypes whose node graph contains nodeID.
+func (h *questArchetypeHandle) FindIDsByNode(ctx context.Context, nodeID uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	if err := h.db.WithContext(ctx).
+		Raw(fmt.Sprintf(questArchetypeIDsByGraphQuery, "SELECT CAST(? AS uuid)"), nodeID).
+		Scan(&ids).Error; err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// FindIDsByChallenge returns the archetypes whose node graph contains
+// challengeID.
+func (h *questArchetypeHandle) FindIDsByChallenge(ctx context.Context, challengeID uuid.UUID) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	if err := h.db.WithContext(ctx).
+		Raw(fmt.Sprintf(questArchetypeIDsByGraphQuery, "SELECT quest_archetype_node_id FROM quest_archetype_node_challenges WHERE quest_archetype_challenge_id = ?"), challengeID).
+		Scan(&ids).Error; err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
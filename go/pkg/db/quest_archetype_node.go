@@ -93,3 +93,79 @@ func (h *questArchetypeNodeHandle) Update(ctx context.Context, questArchetypeNod
 func (h *questArchetypeNodeHandle) Delete(ctx context.Context, id uuid.UUID) error {
 	return h.db.WithContext(ctx).Delete(&models.QuestArchetypeNode{}, "id = ?", id).Error
 }
+
+// FindGraph returns every node reachable from rootID through challenge
+// unlocks, breadth first. Nodes come with their challenges and none of their
+// other relations, so the graph can be saved back as it was.
+func (h *questArchetypeNodeHandle) FindGraph(ctx context.Context, rootID uuid.UUID) ([]models.QuestArchetypeNode, error) {
+	var nodes []models.QuestArchetypeNode
+	seen := map[uuid.UUID]bool{rootID: true}
+	frontier := []uuid.UUID{rootID}
+	for len(frontier) > 0 {
+		var level []models.QuestArchetypeNode
+		if err := h.db.WithContext(ctx).
+			Preload("Challenges", func(db *gorm.DB) *gorm.DB {
+				return db.Order("quest_archetype_challenges.created_at ASC, quest_archetype_challenges.id ASC")
+			}).
+			Where("id IN ?", frontier).
+			Order("id ASC").
+			Find(&level).Error; err != nil {
+			return nil, err
+		}
+		frontier = nil
+		for _, node := range level {
+			nodes = append(nodes, node)
+			for _, challenge := range node.Challenges {
+				for _, nextID := range []*uuid.UUID{challenge.UnlockedNodeID, challenge.FailureUnlockedNodeID} {
+					if nextID != nil && !seen[*nextID] {
+						seen[*nextID] = true
+						frontier = append(frontier, *nextID)
+					}
+				}
+			}
+		}
+	}
+	return nodes, nil
+}
+
+// RestoreGraph saves nodes and their challenges as given, recreating any
+// that were deleted, and resets each node's challenge links to match. Nodes
+// left out are not deleted; they're just no longer reachable from the nodes
+// given.
+func (h *questArchetypeNodeHandle) RestoreGraph(ctx context.Context, nodes []models.QuestArchetypeNode) error {
+	return h.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, node := range nodes {
+			challenges := node.Challenges
+			node.Challenges = nil
+			node.DeletedAt = gorm.DeletedAt{}
+			if err := tx.Omit(clause.Associations).
+				Clauses(clause.OnConflict{UpdateAll: true}).
+				Create(&node).Error; err != nil {
+				return err
+			}
+
+			for i := range challenges {
+				challenges[i].DeletedAt = gorm.DeletedAt{}
+				if err := tx.Omit(clause.Associations).
+					Clauses(clause.OnConflict{UpdateAll: true}).
+					Create(&challenges[i]).Error; err != nil {
+					return err
+				}
+			}
+
+			if err := tx.Where("quest_archetype_node_id = ?", node.ID).
+				Delete(&models.QuestArchetypeNodeChallenge{}).Error; err != nil {
+				return err
+			}
+			for _, challenge := range challenges {
+				if err := tx.Omit(clause.Associations).Create(&models.QuestArchetypeNodeChallenge{
+					QuestArchetypeNodeID:      node.ID,
+					QuestArchetypeChallengeID: challenge.ID,
+				}).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
@@ -154,8 +154,20 @@ func (h *scenarioHandle) preloadBase(ctx context.Context) *gorm.DB {
 		Preload("SpellRewards.Spell")
 }
 
+// approvedQuery hides generated scenarios the moderation queue has not
+// approved yet.
+func (h *scenarioHandle) approvedQuery(ctx context.Context) *gorm.DB {
+	return h.preloadBase(ctx).
+		Where(
+			"NOT EXISTS (SELECT 1 FROM content_moderation_items cmi WHERE cmi.content_type = ? AND cmi.content_id = scenarios.id AND cmi.status <> ?)",
+			models.ModeratedContentTypeScenario,
+			models.ContentModerationStatusApproved,
+		)
+}
+
+// visibleQuery also hides retired scenarios.
 func (h *scenarioHandle) visibleQuery(ctx context.Context) *gorm.DB {
-	return h.preloadBase(ctx).Where("retired_at IS NULL")
+	return h.approvedQuery(ctx).Where("retired_at IS NULL")
 }
 
 func (h *scenarioHandle) Create(ctx context.Context, scenario *models.Scenario) error {
@@ -187,6 +199,16 @@ func (h *scenarioHandle) FindByID(ctx context.Context, id uuid.UUID) (*models.Sc
 	return &scenario, nil
 }
 
+// FindApprovedByID is FindByID for players: a generated scenario still in
+// the moderation queue is not found.
+func (h *scenarioHandle) FindApprovedByID(ctx context.Context, id uuid.UUID) (*models.Scenario, error) {
+	var scenario models.Scenario
+	if err := h.approvedQuery(ctx).First(&scenario, id).Error; err != nil {
+		return nil, err
+	}
+	return &scenario, nil
+}
+
 func (h *scenarioHandle) FindAll(ctx context.Context) ([]models.Scenario, error) {
 	var scenarios []models.Scenario
 	if err := h.visibleQuery(ctx).Find(&scenarios).Error; err != nil {
@@ -65,6 +65,21 @@ func (h *scenarioGenerationJobHandle) FindByZoneID(ctx context.Context, zoneID u
 	return jobs, nil
 }
 
+func (h *scenarioGenerationJobHandle) FindByGeneratedScenarioID(ctx context.Context, scenarioID uuid.UUID) (*models.ScenarioGenerationJob, error) {
+	var job models.ScenarioGenerationJob
+	if err := h.db.WithContext(ctx).
+		Preload("Genre").
+		Where("generated_scenario_id = ?", scenarioID).
+		Order("created_at DESC").
+		First(&job).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
 func (h *scenarioGenerationJobHandle) ListAdmin(
 	ctx context.Context,
 	params ScenarioGenerationJobAdminListParams,
@@ -0,0 +1,49 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type spawnRuleHandle struct {
+	db *gorm.DB
+}
+
+func (h *spawnRuleHandle) Create(ctx context.Context, rule *models.SpawnRule) error {
+	return h.db.WithContext(ctx).Create(rule).Error
+}
+
+func (h *spawnRuleHandle) Update(ctx context.Context, rule *models.SpawnRule) error {
+	return h.db.WithContext(ctx).Save(rule).Error
+}
+
+func (h *spawnRuleHandle) Delete(ctx context.Context, id uuid.UUID) error {
+	return h.db.WithContext(ctx).Delete(&models.SpawnRule{}, "id = ?", id).Error
+}
+
+func (h *spawnRuleHandle) FindByID(ctx context.Context, id uuid.UUID) (*models.SpawnRule, error) {
+	var rule models.SpawnRule
+	if err := h.db.WithContext(ctx).Where("id = ?", id).First(&rule).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &rule, nil
+}
+
+func (h *spawnRuleHandle) FindAll(ctx context.Context, includeInactive bool) ([]models.SpawnRule, error) {
+	rules := []models.SpawnRule{}
+	query := h.db.WithContext(ctx).Order("name ASC")
+	if !includeInactive {
+		query = query.Where("active = ?", true)
+	}
+	if err := query.Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
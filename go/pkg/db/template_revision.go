@@ -0,0 +1,132 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type templateRevisionHandle struct {
+	db *gorm.DB
+}
+
+// Create numbers the revision after the template's latest one. Admin saves
+// to a single template are rare enough that the unique constraint is the
+// only guard against two saves racing for the same number.
+func (h *templateRevisionHandle) Create(ctx context.Context, revision *models.TemplateRevision) error {
+	return h.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var next int
+		if err := tx.Raw(
+			`SELECT COALESCE(MAX(revision), 0) + 1 FROM template_revisions WHERE template_type = ? AND template_id = ?`,
+			revision.TemplateType,
+			revision.TemplateID,
+		).Scan(&next).Error; err != nil {
+			return err
+		}
+		revision.ID = uuid.New()
+		revision.CreatedAt = time.Now()
+		revision.UpdatedAt = revision.CreatedAt
+		revision.Revision = next
+		return tx.Create(revision).Error
+	})
+}
+
+func (h *templateRevisionHandle) FindByTemplate(
+	ctx context.Context,
+	templateType models.TemplateRevisionType,
+	templateID uuid.UUID,
+) ([]models.TemplateRevision, error) {
+	var revisions []models.TemplateRevision
+	if err := h.db.WithContext(ctx).
+		Where("template_type = ? AND template_id = ?", templateType, templateID).
+		Order("revision DESC").
+		Find(&revisions).Error; err != nil {
+		return nil, err
+	}
+	return revisions, nil
+}
+
+func (h *templateRevisionHandle) FindByRevision(
+	ctx context.Context,
+	templateType models.TemplateRevisionType,
+	templateID uuid.UUID,
+	revision int,
+) (*models.TemplateRevision, error) {
+	var templateRevision models.TemplateRevision
+	if err := h.db.WithContext(ctx).
+		Where("template_type = ? AND template_id = ? AND revision = ?", templateType, templateID, revision).
+		First(&templateRevision).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &templateRevision, nil
+}
+
+// FindLatestPublished returns the revision that matches the live template,
+// or nil when the template has no history yet.
+func (h *templateRevisionHandle) FindLatestPublished(
+	ctx context.Context,
+	templateType models.TemplateRevisionType,
+	templateID uuid.UUID,
+) (*models.TemplateRevision, error) {
+	var templateRevision models.TemplateRevision
+	if err := h.db.WithContext(ctx).
+		Where("template_type = ? AND template_id = ? AND status = ?", templateType, templateID, models.TemplateRevisionStatusPublished).
+		Order("published_at DESC").
+		Order("revision DESC").
+		First(&templateRevision).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &templateRevision, nil
+}
+
+// LockLatestPublished locks the template's latest published revision row
+// until the surrounding transaction ends, so publishes and rollbacks of one
+// template run one at a time. The row is read again after the lock is
+// taken, since a publish that held it may have added a newer revision.
+func (h *templateRevisionHandle) LockLatestPublished(
+	ctx context.Context,
+	templateType models.TemplateRevisionType,
+	templateID uuid.UUID,
+) (*models.TemplateRevision, error) {
+	var locked []models.TemplateRevision
+	if err := h.db.WithContext(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id").
+		Where("template_type = ? AND template_id = ? AND status = ?", templateType, templateID, models.TemplateRevisionStatusPublished).
+		Order("published_at DESC").
+		Order("revision DESC").
+		Limit(1).
+		Find(&locked).Error; err != nil {
+		return nil, err
+	}
+	return h.FindLatestPublished(ctx, templateType, templateID)
+}
+
+func (h *templateRevisionHandle) MarkPublished(ctx context.Context, revision *models.TemplateRevision) error {
+	now := time.Now()
+	revision.Status = models.TemplateRevisionStatusPublished
+	revision.PublishedAt = &now
+	revision.UpdatedAt = now
+	return h.db.WithContext(ctx).Model(&models.TemplateRevision{}).Where("id = ?", revision.ID).Updates(map[string]interface{}{
+		"status":               revision.Status,
+		"published_at":         revision.PublishedAt,
+		"published_by_user_id": revision.PublishedByUserID,
+		"snapshot":             revision.Snapshot,
+		"diff":                 revision.Diff,
+		"updated_at":           revision.UpdatedAt,
+	}).Error
+}
+
+func (h *templateRevisionHandle) Delete(ctx context.Context, id uuid.UUID) error {
+	return h.db.WithContext(ctx).Delete(&models.TemplateRevision{}, "id = ?", id).Error
+}
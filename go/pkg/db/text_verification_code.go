@@ -57,3 +57,16 @@ func (c *textVerificationCodeHandle) Find(ctx context.Context, phoneNumber strin
 func (c *textVerificationCodeHandle) MarkUsed(ctx context.Context, id uuid.UUID) error {
 	return c.db.WithContext(ctx).Model(&models.TextVerificationCode{}).Where("id = ?", id).Update("used", true).Error
 }
+
+// RecordFailedAttempt counts a wrong code against the phone number's live
+// code, burning it once maxAttempts is reached so a six-digit code can't
+// be guessed.
+func (c *textVerificationCodeHandle) RecordFailedAttempt(ctx context.Context, phoneNumber string, maxAttempts int) error {
+	return c.db.WithContext(ctx).Model(&models.TextVerificationCode{}).
+		Where("phone_number = ? AND used = ?", phoneNumber, false).
+		Updates(map[string]interface{}{
+			"attempts":   gorm.Expr("attempts + 1"),
+			"used":       gorm.Expr("attempts + 1 >= ?", maxAttempts),
+			"updated_at": time.Now(),
+		}).Error
+}
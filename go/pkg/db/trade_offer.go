@@ -0,0 +1,220 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type tradeOfferHandle struct {
+	db *gorm.DB
+}
+
+func (h *tradeOfferHandle) preloadBase(ctx context.Context) *gorm.DB {
+	return h.db.WithContext(ctx).
+		Preload("Initiator").
+		Preload("Recipient").
+		Preload("Items").
+		Preload("Items.InventoryItem")
+}
+
+func (h *tradeOfferHandle) Create(ctx context.Context, offer *models.TradeOffer) error {
+	if offer == nil {
+		return nil
+	}
+	now := time.Now()
+	if offer.ID == uuid.Nil {
+		offer.ID = uuid.New()
+	}
+	if offer.Status == "" {
+		offer.Status = models.TradeOfferStatusPending
+	}
+	offer.CreatedAt = now
+	offer.UpdatedAt = now
+	for i := range offer.Items {
+		if offer.Items[i].ID == uuid.Nil {
+			offer.Items[i].ID = uuid.New()
+		}
+		offer.Items[i].TradeOfferID = offer.ID
+		offer.Items[i].CreatedAt = now
+		offer.Items[i].UpdatedAt = now
+	}
+
+	return h.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		items := offer.Items
+		offer.Items = nil
+		if err := tx.Omit(clause.Associations).Create(offer).Error; err != nil {
+			offer.Items = items
+			return err
+		}
+		offer.Items = items
+		if len(items) == 0 {
+			return nil
+		}
+		return tx.Omit(clause.Associations).Create(&items).Error
+	})
+}
+
+func (h *tradeOfferHandle) FindByID(ctx context.Context, id uuid.UUID) (*models.TradeOffer, error) {
+	offer := &models.TradeOffer{}
+	if err := h.preloadBase(ctx).Where("id = ?", id).First(offer).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return offer, nil
+}
+
+func (h *tradeOfferHandle) FindOpenForUser(ctx context.Context, userID uuid.UUID, now time.Time) ([]models.TradeOffer, error) {
+	offers := []models.TradeOffer{}
+	if err := h.preloadBase(ctx).
+		Where("(initiator_user_id = ? OR recipient_user_id = ?) AND status = ? AND expires_at > ?",
+			userID, userID, models.TradeOfferStatusPending, now).
+		Order("created_at DESC").
+		Find(&offers).Error; err != nil {
+		return nil, err
+	}
+	return offers, nil
+}
+
+func (h *tradeOfferHandle) FindRecentForUser(ctx context.Context, userID uuid.UUID, limit int) ([]models.TradeOffer, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	offers := []models.TradeOffer{}
+	if err := h.preloadBase(ctx).
+		Where("initiator_user_id = ? OR recipient_user_id = ?", userID, userID).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&offers).Error; err != nil {
+		return nil, err
+	}
+	return offers, nil
+}
+
+// Confirm records userID's confirmation on the offer. The confirmation that
+// completes the pair performs the swap of items and gold in the same
+// transaction, with the offer and both players row-locked so concurrent
+// confirmations can't apply a trade twice.
+func (h *tradeOfferHandle) Confirm(ctx context.Context, offerID uuid.UUID, userID uuid.UUID, now time.Time) (*models.TradeOffer, error) {
+	err := h.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		offer := &models.TradeOffer{}
+		if err := tx.
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", offerID).
+			First(offer).Error; err != nil {
+			return err
+		}
+		if !offer.IsParticipant(userID) {
+			return gorm.ErrRecordNotFound
+		}
+		if !offer.IsOpen(now) {
+			return ErrTradeOfferNotOpen
+		}
+
+		confirmedAt := now
+		if userID == offer.InitiatorUserID {
+			offer.InitiatorConfirmedAt = &confirmedAt
+		} else {
+			offer.RecipientConfirmedAt = &confirmedAt
+		}
+
+		if offer.InitiatorConfirmedAt != nil && offer.RecipientConfirmedAt != nil {
+			if err := tx.Where("trade_offer_id = ?", offer.ID).Find(&offer.Items).Error; err != nil {
+				return err
+			}
+			if err := executeTradeOfferTx(tx, offer); err != nil {
+				return err
+			}
+			offer.Status = models.TradeOfferStatusCompleted
+			offer.CompletedAt = &confirmedAt
+		}
+
+		offer.UpdatedAt = now
+		return tx.Model(&models.TradeOffer{}).
+			Where("id = ?", offer.ID).
+			Updates(map[string]interface{}{
+				"initiator_confirmed_at": offer.InitiatorConfirmedAt,
+				"recipient_confirmed_at": offer.RecipientConfirmedAt,
+				"status":                 offer.Status,
+				"completed_at":           offer.CompletedAt,
+				"updated_at":             offer.UpdatedAt,
+			}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return h.FindByID(ctx, offerID)
+}
+
+func executeTradeOfferTx(tx *gorm.DB, offer *models.TradeOffer) error {
+	users, err := lockUsersForUpdate(tx, offer.InitiatorUserID, offer.RecipientUserID)
+	if err != nil {
+		return err
+	}
+	initiator := users[offer.InitiatorUserID]
+	recipient := users[offer.RecipientUserID]
+
+	if err := adjustUserGoldTx(tx, initiator, -offer.InitiatorGold); err != nil {
+		return err
+	}
+	if err := adjustUserGoldTx(tx, recipient, -offer.RecipientGold); err != nil {
+		return err
+	}
+	if err := adjustUserGoldTx(tx, initiator, offer.RecipientGold); err != nil {
+		return err
+	}
+	if err := adjustUserGoldTx(tx, recipient, offer.InitiatorGold); err != nil {
+		return err
+	}
+
+	for _, item := range offer.Items {
+		to := offer.RecipientUserID
+		if item.OwnerUserID == offer.RecipientUserID {
+			to = offer.InitiatorUserID
+		}
+		if err := removeUserInventoryItemTx(tx, item.OwnerUserID, item.InventoryItemID, item.Quantity); err != nil {
+			return err
+		}
+		if err := addUserInventoryItemTx(tx, to, item.InventoryItemID, item.Quantity); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close moves a pending offer to a terminal status without transferring
+// anything. It is a no-op error if the offer already left pending.
+func (h *tradeOfferHandle) Close(ctx context.Context, offerID uuid.UUID, status models.TradeOfferStatus, now time.Time) error {
+	result := h.db.WithContext(ctx).
+		Model(&models.TradeOffer{}).
+		Where("id = ? AND status = ?", offerID, models.TradeOfferStatusPending).
+		Updates(map[string]interface{}{
+			"status":     status,
+			"updated_at": now,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrTradeOfferNotOpen
+	}
+	return nil
+}
+
+func (h *tradeOfferHandle) ExpireStale(ctx context.Context, now time.Time) (int64, error) {
+	result := h.db.WithContext(ctx).
+		Model(&models.TradeOffer{}).
+		Where("status = ? AND expires_at <= ?", models.TradeOfferStatusPending, now).
+		Updates(map[string]interface{}{
+			"status":     models.TradeOfferStatusExpired,
+			"updated_at": now,
+		})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,114 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type twoFactorHandle struct {
+	db *gorm.DB
+}
+
+func (h *twoFactorHandle) FindTOTP(ctx context.Context, userID uuid.UUID) (*models.UserTOTP, error) {
+	var totp models.UserTOTP
+	if err := h.db.WithContext(ctx).First(&totp, "user_id = ?", userID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &totp, nil
+}
+
+// StartTOTPEnrollment stores a new unconfirmed secret, replacing any earlier
+// unconfirmed one. It reports false, changing nothing, when the user
+// already has a confirmed enrollment.
+func (h *twoFactorHandle) StartTOTPEnrollment(ctx context.Context, userID uuid.UUID, secretCiphertext string) (bool, error) {
+	now := time.Now()
+	result := h.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{"secret_ciphertext": secretCiphertext, "updated_at": now, "last_used_step": 0}),
+			Where:     clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "user_totp.confirmed_at IS NULL"}}},
+		}).
+		Create(&models.UserTOTP{UserID: userID, CreatedAt: now, UpdatedAt: now, SecretCiphertext: secretCiphertext})
+	return result.RowsAffected > 0, result.Error
+}
+
+// ConfirmTOTP turns enrollment on and issues the first set of recovery
+// codes in one go.
+func (h *twoFactorHandle) ConfirmTOTP(ctx context.Context, userID uuid.UUID, step int64, recoveryCodeHashes []string) error {
+	return h.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		result := tx.Model(&models.UserTOTP{}).
+			Where("user_id = ? AND confirmed_at IS NULL", userID).
+			Updates(map[string]interface{}{"confirmed_at": now, "last_used_step": step, "updated_at": now})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return replaceRecoveryCodes(tx, userID, recoveryCodeHashes)
+	})
+}
+
+// UseTOTPStep records an accepted code's time step, reporting false when
+// that step or a later one was already used.
+func (h *twoFactorHandle) UseTOTPStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error) {
+	result := h.db.WithContext(ctx).Model(&models.UserTOTP{}).
+		Where("user_id = ? AND last_used_step < ?", userID, step).
+		Updates(map[string]interface{}{"last_used_step": step, "updated_at": time.Now()})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (h *twoFactorHandle) DeleteTOTP(ctx context.Context, userID uuid.UUID) error {
+	return h.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.UserRecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&models.UserTOTP{}).Error
+	})
+}
+
+// UseRecoveryCode spends a recovery code, reporting false when it does not
+// exist or was already spent.
+func (h *twoFactorHandle) UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string) (bool, error) {
+	result := h.db.WithContext(ctx).Model(&models.UserRecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
+
+func (h *twoFactorHandle) ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, codeHashes []string) error {
+	return h.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return replaceRecoveryCodes(tx, userID, codeHashes)
+	})
+}
+
+func (h *twoFactorHandle) CountUnusedRecoveryCodes(ctx context.Context, userID uuid.UUID) (int64, error) {
+	var count int64
+	err := h.db.WithContext(ctx).Model(&models.UserRecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).Count(&count).Error
+	return count, err
+}
+
+func replaceRecoveryCodes(tx *gorm.DB, userID uuid.UUID, codeHashes []string) error {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.UserRecoveryCode{}).Error; err != nil {
+		return err
+	}
+	if len(codeHashes) == 0 {
+		return nil
+	}
+	now := time.Now()
+	codes := make([]models.UserRecoveryCode, 0, len(codeHashes))
+	for _, hash := range codeHashes {
+		codes = append(codes, models.UserRecoveryCode{ID: uuid.New(), CreatedAt: now, UserID: userID, CodeHash: hash})
+	}
+	return tx.Create(&codes).Error
+}
@@ -11,19 +11,28 @@ import (
 )
 
 type userHandle struct {
-	db *gorm.DB
+	db     *gorm.DB
+	notify UserChangeNotifier
+}
+
+// changed tells the notifier, if any, that a write to the user succeeded.
+func (h *userHandle) changed(ctx context.Context, userID uuid.UUID, err error) error {
+	if err == nil && h.notify != nil {
+		h.notify(ctx, userID)
+	}
+	return err
 }
 
 func (h *userHandle) LeaveParty(ctx context.Context, userID uuid.UUID) error {
-	return h.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", userID).Update("party_id", nil).Error
+	return h.changed(ctx, userID, h.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", userID).Update("party_id", nil).Error)
 }
 
 func (h *userHandle) Update(ctx context.Context, userID uuid.UUID, updates models.User) error {
-	return h.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", userID).Updates(updates).Error
+	return h.changed(ctx, userID, h.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", userID).Updates(updates).Error)
 }
 
 func (h *userHandle) SetUsername(ctx context.Context, userID uuid.UUID, username string) error {
-	return h.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", userID).Update("username", username).Error
+	return h.changed(ctx, userID, h.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", userID).Update("username", username).Error)
 }
 
 func (h *userHandle) FindLikeByUsername(ctx context.Context, username string) ([]*models.User, error) {
@@ -114,7 +123,7 @@ func (h *userHandle) InsertWithGoogle(ctx context.Context, name string, email st
 // that originally signed up with email+password and later uses "Sign in
 // with Google" with the same email address.
 func (h *userHandle) SetGoogleID(ctx context.Context, userID uuid.UUID, googleID string) error {
-	return h.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", userID).Update("google_id", googleID).Error
+	return h.changed(ctx, userID, h.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", userID).Update("google_id", googleID).Error)
 }
 
 func (h *userHandle) FindByGoogleID(ctx context.Context, googleID string) (*models.User, error) {
@@ -168,7 +177,7 @@ func (h *userHandle) FindAll(ctx context.Context) ([]models.User, error) {
 }
 
 func (h *userHandle) Delete(ctx context.Context, userID uuid.UUID) error {
-	return h.db.WithContext(ctx).Delete(&models.User{}, userID).Error
+	return h.changed(ctx, userID, h.db.WithContext(ctx).Delete(&models.User{}, userID).Error)
 }
 
 func (h *userHandle) DeleteAll(ctx context.Context) error {
@@ -176,11 +185,11 @@ func (h *userHandle) DeleteAll(ctx context.Context) error {
 }
 
 func (h *userHandle) UpdateProfilePictureUrl(ctx context.Context, userID uuid.UUID, url string) error {
-	return h.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", userID).Update("profile_picture_url", url).Error
+	return h.changed(ctx, userID, h.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", userID).Update("profile_picture_url", url).Error)
 }
 
 func (h *userHandle) UpdateHasSeenTutorial(ctx context.Context, userID uuid.UUID, hasSeenTutorial bool) error {
-	return h.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", userID).Update("has_seen_tutorial", hasSeenTutorial).Error
+	return h.changed(ctx, userID, h.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", userID).Update("has_seen_tutorial", hasSeenTutorial).Error)
 }
 
 func (h *userHandle) JoinParty(ctx context.Context, inviterID uuid.UUID, inviteeID uuid.UUID) error {
@@ -210,12 +219,12 @@ func (h *userHandle) JoinParty(ctx context.Context, inviterID uuid.UUID, invitee
 		return ErrMaxPartySizeReached
 	}
 
-	if err := h.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", inviteeID).Update("party_id", partyID).Error; err != nil {
+	if err := h.changed(ctx, inviteeID, h.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", inviteeID).Update("party_id", partyID).Error); err != nil {
 		return err
 	}
 
 	if partyExisted {
-		if err := h.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", inviterID).Update("party_id", partyID).Error; err != nil {
+		if err := h.changed(ctx, inviterID, h.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", inviterID).Update("party_id", partyID).Error); err != nil {
 			return err
 		}
 	}
@@ -251,32 +260,32 @@ func (h *userHandle) AddGold(ctx context.Context, userID uuid.UUID, amount int)
 		// Disallow negative increments here; use Update if debit needed later
 		return gorm.ErrInvalidData
 	}
-	return h.db.WithContext(ctx).
+	return h.changed(ctx, userID, h.db.WithContext(ctx).
 		Model(&models.User{}).
 		Where("id = ?", userID).
 		Clauses(clause.Locking{Strength: "UPDATE"}).
-		UpdateColumn("gold", gorm.Expr("gold + ?", amount)).Error
+		UpdateColumn("gold", gorm.Expr("gold + ?", amount)).Error)
 }
 
 func (h *userHandle) SetGold(ctx context.Context, userID uuid.UUID, amount int) error {
 	if amount < 0 {
 		return gorm.ErrInvalidData
 	}
-	return h.db.WithContext(ctx).
+	return h.changed(ctx, userID, h.db.WithContext(ctx).
 		Model(&models.User{}).
 		Where("id = ?", userID).
-		UpdateColumn("gold", amount).Error
+		UpdateColumn("gold", amount).Error)
 }
 
 func (h *userHandle) SubtractGold(ctx context.Context, userID uuid.UUID, amount int) error {
 	if amount < 0 {
 		return gorm.ErrInvalidData
 	}
-	return h.db.WithContext(ctx).
+	return h.changed(ctx, userID, h.db.WithContext(ctx).
 		Model(&models.User{}).
 		Where("id = ?", userID).
 		Clauses(clause.Locking{Strength: "UPDATE"}).
-		UpdateColumn("gold", gorm.Expr("gold - ?", amount)).Error
+		UpdateColumn("gold", gorm.Expr("gold - ?", amount)).Error)
 }
 
 func (h *userHandle) AddCredits(ctx context.Context, userID uuid.UUID, amount int) error {
@@ -284,30 +293,30 @@ func (h *userHandle) AddCredits(ctx context.Context, userID uuid.UUID, amount in
 		// Disallow negative increments here; use Update if debit needed later
 		return gorm.ErrInvalidData
 	}
-	return h.db.WithContext(ctx).
+	return h.changed(ctx, userID, h.db.WithContext(ctx).
 		Model(&models.User{}).
 		Where("id = ?", userID).
 		Clauses(clause.Locking{Strength: "UPDATE"}).
-		UpdateColumn("credits", gorm.Expr("credits + ?", amount)).Error
+		UpdateColumn("credits", gorm.Expr("credits + ?", amount)).Error)
 }
 
 func (h *userHandle) SetCredits(ctx context.Context, userID uuid.UUID, amount int) error {
 	if amount < 0 {
 		return gorm.ErrInvalidData
 	}
-	return h.db.WithContext(ctx).
+	return h.changed(ctx, userID, h.db.WithContext(ctx).
 		Model(&models.User{}).
 		Where("id = ?", userID).
-		UpdateColumn("credits", amount).Error
+		UpdateColumn("credits", amount).Error)
 }
 
 func (h *userHandle) SubtractCredits(ctx context.Context, userID uuid.UUID, amount int) error {
 	if amount < 0 {
 		return gorm.ErrInvalidData
 	}
-	return h.db.WithContext(ctx).
+	return h.changed(ctx, userID, h.db.WithContext(ctx).
 		Model(&models.User{}).
 		Where("id = ?", userID).
 		Clauses(clause.Locking{Strength: "UPDATE"}).
-		UpdateColumn("credits", gorm.Expr("credits - ?", amount)).Error
+		UpdateColumn("credits", gorm.Expr("credits - ?", amount)).Error)
 }
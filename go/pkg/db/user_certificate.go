@@ -2,6 +2,8 @@ package db
 
 import (
 	"context"
+	"crypto/x509"
+	"fmt"
 	"time"
 
 	"github.com/MaxBlaushild/poltergeist/pkg/models"
@@ -14,15 +16,32 @@ type userCertificateHandle struct {
 }
 
 func (h *userCertificateHandle) Create(ctx context.Context, userID uuid.UUID, certificateDER []byte, certificatePEM string, publicKeyPEM string, fingerprint []byte) (*models.UserCertificate, error) {
+	return h.create(ctx, nil, userID, certificateDER, certificatePEM, publicKeyPEM, fingerprint)
+}
+
+func (h *userCertificateHandle) CreateRenewal(ctx context.Context, renews *models.UserCertificate, certificateDER []byte, certificatePEM string, publicKeyPEM string, fingerprint []byte) (*models.UserCertificate, error) {
+	return h.create(ctx, &renews.ID, renews.UserID, certificateDER, certificatePEM, publicKeyPEM, fingerprint)
+}
+
+func (h *userCertificateHandle) create(ctx context.Context, renewsID *uuid.UUID, userID uuid.UUID, certificateDER []byte, certificatePEM string, publicKeyPEM string, fingerprint []byte) (*models.UserCertificate, error) {
+	parsed, err := x509.ParseCertificate(certificateDER)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate: %w", err)
+	}
+	serialNumber := parsed.SerialNumber.Text(16)
+
 	cert := &models.UserCertificate{
-		UserID:        userID,
-		Certificate:   certificateDER,
-		CertificatePEM: certificatePEM,
-		PublicKey:     publicKeyPEM,
-		Fingerprint:   fingerprint,
-		Active:        false, // Certificates are created as inactive by default
-		CreatedAt:     time.Now(),
-		UpdatedAt:     time.Now(),
+		UserID:              userID,
+		Certificate:         certificateDER,
+		CertificatePEM:      certificatePEM,
+		PublicKey:           publicKeyPEM,
+		Fingerprint:         fingerprint,
+		Active:              false, // Certificates are created as inactive by default
+		SerialNumber:        &serialNumber,
+		ExpiresAt:           parsed.NotAfter,
+		RenewsCertificateID: renewsID,
+		CreatedAt:           time.Now(),
+		UpdatedAt:           time.Now(),
 	}
 
 	if err := h.db.WithContext(ctx).Create(cert).Error; err != nil {
@@ -32,9 +51,14 @@ func (h *userCertificateHandle) Create(ctx context.Context, userID uuid.UUID, ce
 	return cert, nil
 }
 
+// FindByUserID returns the user's current certificate: the newest one that
+// hasn't been revoked, preferring an active one over a pending renewal.
 func (h *userCertificateHandle) FindByUserID(ctx context.Context, userID uuid.UUID) (*models.UserCertificate, error) {
 	var cert models.UserCertificate
-	if err := h.db.WithContext(ctx).Where("user_id = ?", userID).First(&cert).Error; err != nil {
+	if err := h.db.WithContext(ctx).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Order("active DESC, created_at DESC").
+		First(&cert).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, nil
 		}
@@ -43,6 +67,14 @@ func (h *userCertificateHandle) FindByUserID(ctx context.Context, userID uuid.UU
 	return &cert, nil
 }
 
+func (h *userCertificateHandle) FindAllByUserID(ctx context.Context, userID uuid.UUID) ([]models.UserCertificate, error) {
+	var certs []models.UserCertificate
+	if err := h.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at DESC").Find(&certs).Error; err != nil {
+		return nil, err
+	}
+	return certs, nil
+}
+
 func (h *userCertificateHandle) FindByFingerprint(ctx context.Context, fingerprint []byte) (*models.UserCertificate, error) {
 	var cert models.UserCertificate
 	if err := h.db.WithContext(ctx).Where("fingerprint = ?", fingerprint).First(&cert).Error; err != nil {
@@ -54,11 +86,110 @@ func (h *userCertificateHandle) FindByFingerprint(ctx context.Context, fingerpri
 	return &cert, nil
 }
 
-func (h *userCertificateHandle) UpdateActive(ctx context.Context, userID uuid.UUID, active bool) error {
+// FindBySerialNumber looks a certificate up by its serial number in
+// lowercase hex.
+func (h *userCertificateHandle) FindBySerialNumber(ctx context.Context, serialNumber string) (*models.UserCertificate, error) {
+	var cert models.UserCertificate
+	if err := h.db.WithContext(ctx).Where("serial_number = ?", serialNumber).First(&cert).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &cert, nil
+}
+
+// FindPendingRenewal returns the not-yet-active renewal of a certificate,
+// if there is one.
+func (h *userCertificateHandle) FindPendingRenewal(ctx context.Context, certificateID uuid.UUID) (*models.UserCertificate, error) {
+	var cert models.UserCertificate
+	if err := h.db.WithContext(ctx).
+		Where("renews_certificate_id = ? AND active = false AND revoked_at IS NULL", certificateID).
+		First(&cert).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &cert, nil
+}
+
+// FindRevoked returns revoked certificates that haven't expired yet; once
+// expired they no longer need to appear on a CRL.
+func (h *userCertificateHandle) FindRevoked(ctx context.Context) ([]models.UserCertificate, error) {
+	var certs []models.UserCertificate
+	if err := h.db.WithContext(ctx).
+		Where("revoked_at IS NOT NULL AND expires_at > ?", time.Now()).
+		Order("revoked_at").
+		Find(&certs).Error; err != nil {
+		return nil, err
+	}
+	return certs, nil
+}
+
+// Activate marks a certificate active once its on-chain registration
+// confirms. A renewal supersedes the certificate it renews at that point,
+// so the user can keep signing with the old one until then.
+func (h *userCertificateHandle) Activate(ctx context.Context, id uuid.UUID) error {
+	return h.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var cert models.UserCertificate
+		if err := tx.Where("id = ?", id).First(&cert).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&cert).Updates(map[string]interface{}{"active": true, "updated_at": time.Now()}).Error; err != nil {
+			return err
+		}
+		if cert.RenewsCertificateID == nil {
+			return nil
+		}
+		return tx.Model(&models.UserCertificate{}).
+			Where("id = ? AND revoked_at IS NULL", *cert.RenewsCertificateID).
+			Updates(map[string]interface{}{
+				"revoked_at":        time.Now(),
+				"revocation_reason": models.CertificateRevocationSuperseded,
+				"updated_at":        time.Now(),
+			}).Error
+	})
+}
+
+// Revoke revokes a certificate along with any renewal of it that hasn't
+// been activated yet.
+func (h *userCertificateHandle) Revoke(ctx context.Context, id uuid.UUID, reason models.CertificateRevocationReason) error {
 	return h.db.WithContext(ctx).
 		Model(&models.UserCertificate{}).
-		Where("user_id = ?", userID).
-		Update("active", active).Error
+		Where("(id = ? OR (renews_certificate_id = ? AND active = false)) AND revoked_at IS NULL", id, id).
+		Updates(map[string]interface{}{
+			"revoked_at":        time.Now(),
+			"revocation_reason": reason,
+			"updated_at":        time.Now(),
+		}).Error
+}
+
+// BackfillSerialNumbers fills in serial numbers for certificates stored
+// before they were recorded, returning how many it updated.
+func (h *userCertificateHandle) BackfillSerialNumbers(ctx context.Context) (int, error) {
+	var certs []models.UserCertificate
+	if err := h.db.WithContext(ctx).Where("serial_number IS NULL").Find(&certs).Error; err != nil {
+		return 0, err
+	}
+
+	for i, cert := range certs {
+		parsed, err := x509.ParseCertificate(cert.Certificate)
+		if err != nil {
+			return i, fmt.Errorf("failed to parse certificate %s: %w", cert.ID, err)
+		}
+		if err := h.db.WithContext(ctx).
+			Model(&models.UserCertificate{}).
+			Where("id = ?", cert.ID).
+			Updates(map[string]interface{}{
+				"serial_number": parsed.SerialNumber.Text(16),
+				"expires_at":    parsed.NotAfter,
+			}).Error; err != nil {
+			return i, err
+		}
+	}
+
+	return len(certs), nil
 }
 
 func (h *userCertificateHandle) Delete(ctx context.Context, userID uuid.UUID) error {
@@ -0,0 +1,380 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// UserDataAction is what account deletion does to a table's rows.
+type UserDataAction string
+
+const (
+	// UserDataDelete removes the rows: they only mean something to the user.
+	UserDataDelete UserDataAction = "delete"
+	// UserDataAnonymize keeps the rows, which other people's data hangs off
+	// or which we must keep, with the user column nulled and Scrub applied.
+	UserDataAnonymize UserDataAction = "anonymize"
+)
+
+// UserDataTable declares one way a table's rows belong to a user. A table
+// with two user columns (inviter and invitee) is declared twice.
+type UserDataTable struct {
+	Table string
+	// Column holds the user's ID.
+	Column string
+	// Where selects rows that reach the user through a parent row instead
+	// of a column, with @user standing for the user's ID, e.g.
+	// "survey_id IN (SELECT id FROM surveys WHERE user_id = @user)". These
+	// rows are exported and deleted; on merge they follow their parent.
+	Where    string
+	OnDelete UserDataAction
+	// Scrub is written alongside the nulled column when anonymizing, for
+	// personal details the kept row carries, like a shipping address.
+	Scrub map[string]interface{}
+	// Omit keeps columns out of the export: token hashes, encrypted
+	// secrets, OAuth tokens.
+	Omit []string
+	// OnePerUser and UniqueWith describe the table's unique key on Column
+	// (UniqueWith being the other columns in it). On merge the source's
+	// rows that would collide with the target's are dropped: the target
+	// account wins.
+	OnePerUser bool
+	UniqueWith []string
+	// DropOnMerge deletes the source's rows rather than moving them, for
+	// rows tied to the old user ID, like passkeys whose user handle is it.
+	DropOnMerge bool
+	// BeforeMerge clears conflicts that OnePerUser and UniqueWith can't
+	// express, such as partial unique indexes.
+	BeforeMerge func(tx *gorm.DB, fromID uuid.UUID, intoID uuid.UUID) error
+}
+
+func (t UserDataTable) condition() string {
+	if t.Where != "" {
+		return t.Where
+	}
+	return t.Column + " = @user"
+}
+
+func (t UserDataTable) validate() error {
+	switch {
+	case t.Table == "":
+		return fmt.Errorf("user data table has no name")
+	case (t.Column == "") == (t.Where == ""):
+		return fmt.Errorf("user data table %s needs exactly one of Column and Where", t.Table)
+	case t.OnDelete != UserDataDelete && t.OnDelete != UserDataAnonymize:
+		return fmt.Errorf("user data table %s has unknown OnDelete %q", t.Table, t.OnDelete)
+	case t.Where != "" && t.OnDelete != UserDataDelete:
+		return fmt.Errorf("user data table %s selects rows by Where, so can only be deleted", t.Table)
+	case t.Where != "" && !strings.Contains(t.Where, "@user"):
+		return fmt.Errorf("user data table %s Where does not mention @user", t.Table)
+	}
+	return nil
+}
+
+// UserDataDomain is one product's declaration of where it keeps user data.
+// Tables are deleted in order, so children come before their parents.
+type UserDataDomain struct {
+	Name   string
+	Tables []UserDataTable
+}
+
+var userDataDomains []UserDataDomain
+
+// RegisterUserData adds a domain to the registry that export, deletion and
+// merging work from. Domains register themselves from init in a
+// user_data_<domain>.go file.
+func RegisterUserData(domain UserDataDomain) {
+	for _, table := range domain.Tables {
+		if err := table.validate(); err != nil {
+			panic(fmt.Sprintf("db: registering %s user data: %v", domain.Name, err))
+		}
+	}
+	userDataDomains = append(userDataDomains, domain)
+}
+
+// UserDataDomains returns the registered domains in registration order.
+func UserDataDomains() []UserDataDomain {
+	return userDataDomains
+}
+
+// UserDataExport is a user's rows as JSON objects, by domain then table.
+type UserDataExport map[string]map[string][]json.RawMessage
+
+// UnregisteredUserDataError lists foreign keys to users that no domain has
+// declared, as "table.column". Merging refuses to run past them since the
+// source account's rows there would be lost.
+type UnregisteredUserDataError struct {
+	Columns []string
+}
+
+func (e *UnregisteredUserDataError) Error() string {
+	return "user data not registered for " + strings.Join(e.Columns, ", ")
+}
+
+// userAccountDomain is where an export puts the users row itself.
+const userAccountDomain = "auth"
+
+type userDataHandle struct {
+	db    *gorm.DB
+	users *userHandle
+}
+
+func (h *userDataHandle) Export(ctx context.Context, userID uuid.UUID) (UserDataExport, error) {
+	existing, err := existingTables(h.db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	export := UserDataExport{}
+	account, err := exportRows(h.db.WithContext(ctx), UserDataTable{Table: "users", Where: "id = @user", Omit: []string{"password_hash"}}, userID)
+	if err != nil {
+		return nil, err
+	}
+	export[userAccountDomain] = map[string][]json.RawMessage{"users": account}
+
+	for _, domain := range userDataDomains {
+		tables := export[domain.Name]
+		if tables == nil {
+			tables = map[string][]json.RawMessage{}
+			export[domain.Name] = tables
+		}
+		for _, table := range domain.Tables {
+			if !existing[table.Table] {
+				continue
+			}
+			rows, err := exportRows(h.db.WithContext(ctx), table, userID)
+			if err != nil {
+				return nil, fmt.Errorf("exporting %s: %w", table.Table, err)
+			}
+			tables[table.Table] = append(tables[table.Table], rows...)
+		}
+	}
+	return export, nil
+}
+
+// Delete removes the user, deleting or anonymizing each registered table as
+// declared, all or nothing.
+func (h *userDataHandle) Delete(ctx context.Context, userID uuid.UUID) error {
+	err := h.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		existing, err := existingTables(tx)
+		if err != nil {
+			return err
+		}
+		for _, domain := range userDataDomains {
+			for _, table := range domain.Tables {
+				if !existing[table.Table] {
+					continue
+				}
+				if err := forgetRows(tx, table, userID); err != nil {
+					return fmt.Errorf("deleting %s user data from %s: %w", domain.Name, table.Table, err)
+				}
+			}
+		}
+		return tx.Exec("DELETE FROM users WHERE id = ?", userID).Error
+	})
+	return h.users.changed(ctx, userID, err)
+}
+
+// Merge moves everything fromID owns onto intoID and deletes fromID. Where
+// both accounts have something only one may, intoID's is kept; login
+// methods intoID lacks are taken from fromID.
+func (h *userDataHandle) Merge(ctx context.Context, fromID uuid.UUID, intoID uuid.UUID) error {
+	if fromID == intoID {
+		return ErrMergeSameUser
+	}
+	unregistered, err := h.Unregistered(ctx)
+	if err != nil {
+		return err
+	}
+	if len(unregistered) > 0 {
+		return &UnregisteredUserDataError{Columns: unregistered}
+	}
+
+	err = h.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var users []models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id IN ?", []uuid.UUID{fromID, intoID}).Find(&users).Error; err != nil {
+			return err
+		}
+		if len(users) != 2 {
+			return ErrUserNotFound
+		}
+		from, into := users[0], users[1]
+		if from.ID != fromID {
+			from, into = into, from
+		}
+
+		existing, err := existingTables(tx)
+		if err != nil {
+			return err
+		}
+		for _, domain := range userDataDomains {
+			for _, table := range domain.Tables {
+				if table.Column == "" || !existing[table.Table] {
+					continue
+				}
+				if err := moveRows(tx, table, fromID, intoID); err != nil {
+					return fmt.Errorf("merging %s user data in %s.%s: %w", domain.Name, table.Table, table.Column, err)
+				}
+			}
+		}
+
+		// The source row goes first so its unique logins are free to move.
+		if err := tx.Exec("DELETE FROM users WHERE id = ?", fromID).Error; err != nil {
+			return err
+		}
+		if updates := mergedUserUpdates(from, into); len(updates) > 0 {
+			return tx.Model(&models.User{}).Where("id = ?", intoID).Updates(updates).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	h.users.changed(ctx, fromID, nil)
+	return h.users.changed(ctx, intoID, nil)
+}
+
+// Unregistered lists foreign keys to users, as "table.column", that no
+// domain declares.
+func (h *userDataHandle) Unregistered(ctx context.Context) ([]string, error) {
+	var keys []struct {
+		TableName  string
+		ColumnName string
+	}
+	err := h.db.WithContext(ctx).Raw(`
+		SELECT cl.relname AS table_name, a.attname AS column_name
+		FROM pg_constraint c
+		JOIN pg_class cl ON cl.oid = c.conrelid
+		JOIN pg_attribute a ON a.attrelid = c.conrelid AND a.attnum = ANY (c.conkey)
+		WHERE c.contype = 'f' AND c.confrelid = 'users'::regclass`).Scan(&keys).Error
+	if err != nil {
+		return nil, err
+	}
+
+	registered := map[string]bool{}
+	for _, domain := range userDataDomains {
+		for _, table := range domain.Tables {
+			registered[table.Table+"."+table.Column] = true
+		}
+	}
+	var missing []string
+	for _, key := range keys {
+		if name := key.TableName + "." + key.ColumnName; !registered[name] {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(missing)
+	return missing, nil
+}
+
+// existingTables lets domains declare legacy tables that only some
+// databases still have.
+func existingTables(tx *gorm.DB) (map[string]bool, error) {
+	var names []string
+	if err := tx.Raw("SELECT tablename FROM pg_tables WHERE schemaname = current_schema()").Scan(&names).Error; err != nil {
+		return nil, err
+	}
+	existing := make(map[string]bool, len(names))
+	for _, name := range names {
+		existing[name] = true
+	}
+	return existing, nil
+}
+
+func exportRows(tx *gorm.DB, table UserDataTable, userID uuid.UUID) ([]json.RawMessage, error) {
+	selected := "to_jsonb(t)"
+	for _, column := range table.Omit {
+		selected += " - '" + column + "'"
+	}
+	rows, err := tx.Raw(
+		"SELECT "+selected+" FROM "+table.Table+" t WHERE "+table.condition(),
+		sql.Named("user", userID),
+	).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	exported := []json.RawMessage{}
+	for rows.Next() {
+		var row []byte
+		if err := rows.Scan(&row); err != nil {
+			return nil, err
+		}
+		exported = append(exported, json.RawMessage(row))
+	}
+	return exported, rows.Err()
+}
+
+func forgetRows(tx *gorm.DB, table UserDataTable, userID uuid.UUID) error {
+	if table.OnDelete == UserDataDelete {
+		return tx.Exec("DELETE FROM "+table.Table+" WHERE "+table.condition(), sql.Named("user", userID)).Error
+	}
+	updates := map[string]interface{}{table.Column: nil}
+	for column, value := range table.Scrub {
+		updates[column] = value
+	}
+	return tx.Table(table.Table).Where(table.Column+" = ?", userID).Updates(updates).Error
+}
+
+func moveRows(tx *gorm.DB, table UserDataTable, fromID uuid.UUID, intoID uuid.UUID) error {
+	if table.BeforeMerge != nil {
+		if err := table.BeforeMerge(tx, fromID, intoID); err != nil {
+			return err
+		}
+	}
+	if table.DropOnMerge {
+		return tx.Exec("DELETE FROM "+table.Table+" WHERE "+table.Column+" = ?", fromID).Error
+	}
+	if table.OnePerUser || len(table.UniqueWith) > 0 {
+		match := "kept." + table.Column + " = @into"
+		for _, column := range table.UniqueWith {
+			match += " AND kept." + column + " = moved." + column
+		}
+		if err := tx.Exec(
+			"DELETE FROM "+table.Table+" moved WHERE moved."+table.Column+" = @from"+
+				" AND EXISTS (SELECT 1 FROM "+table.Table+" kept WHERE "+match+")",
+			sql.Named("from", fromID), sql.Named("into", intoID),
+		).Error; err != nil {
+			return err
+		}
+	}
+	return tx.Exec("UPDATE "+table.Table+" SET "+table.Column+" = ? WHERE "+table.Column+" = ?", intoID, fromID).Error
+}
+
+// mergedUserUpdates is what into takes from from: each login method it
+// lacks, its username if it has none, and from's gold and credits.
+func mergedUserUpdates(from models.User, into models.User) map[string]interface{} {
+	updates := map[string]interface{}{}
+	if into.PhoneNumber == "" && from.PhoneNumber != "" {
+		updates["phone_number"] = from.PhoneNumber
+	}
+	if into.Email == nil && from.Email != nil {
+		updates["email"] = *from.Email
+		if into.PasswordHash == nil && from.PasswordHash != nil {
+			updates["password_hash"] = *from.PasswordHash
+		}
+	}
+	if into.GoogleID == nil && from.GoogleID != nil {
+		updates["google_id"] = *from.GoogleID
+	}
+	if into.Username == nil && from.Username != nil {
+		updates["username"] = *from.Username
+	}
+	if from.Gold != 0 {
+		updates["gold"] = into.Gold + from.Gold
+	}
+	if from.Credits != 0 {
+		updates["credits"] = into.Credits + from.Credits
+	}
+	return updates
+}
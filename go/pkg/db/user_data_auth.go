@@ -0,0 +1,36 @@
+package db
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func init() {
+	RegisterUserData(UserDataDomain{
+		Name: "auth",
+		Tables: []UserDataTable{
+			// The authenticator revokes the source's sessions before a merge,
+			// so they are dropped rather than handed to the target.
+			{Table: "auth_sessions", Column: "user_id", OnDelete: UserDataDelete, Omit: []string{"refresh_token_hash", "previous_refresh_token_hash"}, DropOnMerge: true},
+			{Table: "auth_challenges", Column: "user_id", OnDelete: UserDataDelete, Omit: []string{"challenge_hash"}, DropOnMerge: true},
+			{Table: "user_recovery_codes", Column: "user_id", OnDelete: UserDataDelete, Omit: []string{"code_hash"}, BeforeMerge: dropRecoveryCodesForOtherTOTP},
+			{Table: "user_totp", Column: "user_id", OnDelete: UserDataDelete, Omit: []string{"secret_ciphertext"}, OnePerUser: true},
+			// A passkey's user handle is the user ID it was registered under.
+			{Table: "webauthn_credentials", Column: "user_id", OnDelete: UserDataDelete, DropOnMerge: true},
+			{Table: "credentials", Column: "user_id", OnDelete: UserDataDelete},
+			{Table: "user_roles", Column: "user_id", OnDelete: UserDataDelete, UniqueWith: []string{"role_id"}},
+			{Table: "user_roles", Column: "granted_by", OnDelete: UserDataAnonymize},
+			{Table: "user_device_tokens", Column: "user_id", OnDelete: UserDataDelete, Omit: []string{"token"}, UniqueWith: []string{"token"}},
+		},
+	})
+}
+
+// dropRecoveryCodesForOtherTOTP drops the source's recovery codes when the
+// target keeps its own authenticator app, since they belong to the
+// source's.
+func dropRecoveryCodesForOtherTOTP(tx *gorm.DB, fromID uuid.UUID, intoID uuid.UUID) error {
+	return tx.Exec(
+		"DELETE FROM user_recovery_codes WHERE user_id = ? AND EXISTS (SELECT 1 FROM user_totp WHERE user_id = ?)",
+		fromID, intoID,
+	).Error
+}
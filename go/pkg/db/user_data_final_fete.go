@@ -0,0 +1,10 @@
+package db
+
+func init() {
+	RegisterUserData(UserDataDomain{
+		Name: "final-fete",
+		Tables: []UserDataTable{
+			{Table: "fete_team_users", Column: "user_id", OnDelete: UserDataDelete},
+		},
+	})
+}
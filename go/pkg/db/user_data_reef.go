@@ -0,0 +1,17 @@
+package db
+
+import "gorm.io/gorm"
+
+func init() {
+	RegisterUserData(UserDataDomain{
+		Name: "reef",
+		Tables: []UserDataTable{
+			// Orders are kept for the books, without who placed them or
+			// where they went.
+			{Table: "reef_orders", Column: "user_id", OnDelete: UserDataAnonymize, Scrub: map[string]interface{}{
+				"customer_email":   "",
+				"shipping_address": gorm.Expr("'{}'::jsonb"),
+			}},
+		},
+	})
+}
@@ -0,0 +1,138 @@
+package db
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const ownSonarSurveys = "sonar_survey_id IN (SELECT id FROM sonar_surveys WHERE user_id = @user)"
+
+func init() {
+	RegisterUserData(UserDataDomain{
+		Name: "sonar",
+		Tables: []UserDataTable{
+			{Table: "sonar_survey_submission_answers", OnDelete: UserDataDelete, Where: ownSonarSurveys +
+				" OR sonar_survey_submission_id IN (SELECT id FROM sonar_survey_submissions WHERE user_id = @user)"},
+			{Table: "sonar_survey_submissions", OnDelete: UserDataDelete, Where: ownSonarSurveys},
+			{Table: "sonar_survey_submissions", Column: "user_id", OnDelete: UserDataDelete},
+			{Table: "sonar_survey_activities", OnDelete: UserDataDelete, Where: ownSonarSurveys},
+			{Table: "sonar_surveys", Column: "user_id", OnDelete: UserDataDelete},
+			{Table: "sonar_users", Column: "viewer_id", OnDelete: UserDataDelete},
+			{Table: "sonar_users", Column: "viewee_id", OnDelete: UserDataDelete},
+			{Table: "activities", Column: "user_id", OnDelete: UserDataDelete},
+			{Table: "audit_items", Column: "user_id", OnDelete: UserDataDelete},
+			{Table: "how_many_answers", Column: "user_id", OnDelete: UserDataDelete},
+			{Table: "how_many_subscriptions", Column: "user_id", OnDelete: UserDataDelete},
+			{Table: "image_generations", Column: "user_id", OnDelete: UserDataDelete},
+			{Table: "match_users", Column: "user_id", OnDelete: UserDataDelete},
+			{Table: "matches", Column: "creator_id", OnDelete: UserDataAnonymize},
+			{Table: "user_teams", Column: "user_id", OnDelete: UserDataDelete},
+			{Table: "challenges_legacy_000228", Column: "user_id", OnDelete: UserDataDelete},
+			{Table: "point_of_interest_challenge_submissions", Column: "user_id", OnDelete: UserDataDelete},
+			{Table: "point_of_interest_discoveries", Column: "user_id", OnDelete: UserDataDelete},
+			{Table: "zone_discoveries", Column: "user_id", OnDelete: UserDataDelete, UniqueWith: []string{"zone_id"}},
+			{Table: "quest_acceptances", Column: "user_id", OnDelete: UserDataDelete, UniqueWith: []string{"point_of_interest_group_id"}},
+			{Table: "quest_acceptances_v2", Column: "user_id", OnDelete: UserDataDelete},
+			{Table: "tracked_point_of_interest_groups", Column: "user_id", OnDelete: UserDataDelete, UniqueWith: []string{"point_of_interest_group_id"}},
+			{Table: "tracked_quests", Column: "user_id", OnDelete: UserDataDelete},
+			{Table: "friends", Column: "first_user_id", OnDelete: UserDataDelete, BeforeMerge: dropOverlappingFriendships},
+			{Table: "friends", Column: "second_user_id", OnDelete: UserDataDelete},
+			{Table: "friend_invites", Column: "inviter_id", OnDelete: UserDataDelete},
+			{Table: "friend_invites", Column: "invitee_id", OnDelete: UserDataDelete},
+			{Table: "party_invites", Column: "inviter_id", OnDelete: UserDataDelete},
+			{Table: "party_invites", Column: "invitee_id", OnDelete: UserDataDelete},
+			{Table: "parties", Column: "leader_id", OnDelete: UserDataAnonymize},
+			{Table: "user_levels", Column: "user_id", OnDelete: UserDataDelete, OnePerUser: true},
+			{Table: "user_zone_reputations", Column: "user_id", OnDelete: UserDataDelete, UniqueWith: []string{"zone_id"}},
+			{Table: "user_proficiencies", Column: "user_id", OnDelete: UserDataDelete, UniqueWith: []string{"proficiency"}},
+			{Table: "user_statuses", Column: "user_id", OnDelete: UserDataDelete},
+			{Table: "user_spells", Column: "user_id", OnDelete: UserDataDelete, UniqueWith: []string{"spell_id"}},
+			{Table: "user_character_stats", Column: "user_id", OnDelete: UserDataDelete, OnePerUser: true},
+			{Table: "user_equipment", Column: "user_id", OnDelete: UserDataDelete, UniqueWith: []string{"slot"}},
+			{Table: "owned_inventory_items", Column: "user_id", OnDelete: UserDataDelete},
+			{Table: "outfit_profile_generations", Column: "user_id", OnDelete: UserDataDelete},
+			{Table: "new_user_starter_grants", Column: "user_id", OnDelete: UserDataDelete, OnePerUser: true},
+			{Table: "user_treasure_chest_openings", Column: "user_id", OnDelete: UserDataDelete, UniqueWith: []string{"treasure_chest_id"}},
+			{Table: "user_healing_fountain_discoveries", Column: "user_id", OnDelete: UserDataDelete, UniqueWith: []string{"healing_fountain_id"}},
+			{Table: "user_healing_fountain_visits", Column: "user_id", OnDelete: UserDataDelete},
+			{Table: "user_challenge_completions", Column: "user_id", OnDelete: UserDataDelete, UniqueWith: []string{"challenge_id"}},
+			{Table: "user_challenge_item_choice_pendings", Column: "user_id", OnDelete: UserDataDelete, UniqueWith: []string{"challenge_id"}},
+			{Table: "user_scenario_attempts", Column: "user_id", OnDelete: UserDataDelete, UniqueWith: []string{"scenario_id"}},
+			{Table: "user_scenario_item_choice_pendings", Column: "user_id", OnDelete: UserDataDelete, UniqueWith: []string{"scenario_id"}},
+			{Table: "user_monster_encounter_victories", Column: "user_id", OnDelete: UserDataDelete, UniqueWith: []string{"monster_encounter_id"}},
+			{Table: "user_exposition_completions", Column: "user_id", OnDelete: UserDataDelete, UniqueWith: []string{"exposition_id"}},
+			{Table: "user_character_relationships", Column: "user_id", OnDelete: UserDataDelete, UniqueWith: []string{"character_id"}},
+			{Table: "user_story_flags", Column: "user_id", OnDelete: UserDataDelete, UniqueWith: []string{"flag_key"}},
+			{Table: "user_learned_recipes", Column: "user_id", OnDelete: UserDataDelete, UniqueWith: []string{"recipe_id"}},
+			{Table: "user_resource_gatherings", Column: "user_id", OnDelete: UserDataDelete, UniqueWith: []string{"resource_id"}},
+			{Table: "user_shrine_uses", Column: "user_id", OnDelete: UserDataDelete},
+			{Table: "user_tutorial_states", Column: "user_id", OnDelete: UserDataDelete, OnePerUser: true},
+			{Table: "user_achievements", Column: "user_id", OnDelete: UserDataDelete, UniqueWith: []string{"achievement_id"}},
+			{Table: "user_titles", Column: "user_id", OnDelete: UserDataDelete, UniqueWith: []string{"title"}, BeforeMerge: deactivateSecondActiveTitle},
+			{Table: "user_bounty_progress", Column: "user_id", OnDelete: UserDataDelete, UniqueWith: []string{"zone_id", "bounty_template_id", "period_start"}},
+			{Table: "user_bounty_streaks", Column: "user_id", OnDelete: UserDataDelete, UniqueWith: []string{"cadence"}},
+			{Table: "user_base_daily_state", Column: "user_id", OnDelete: UserDataDelete, UniqueWith: []string{"state_key", "resets_on"}},
+			{Table: "user_base_structures", Column: "user_id", OnDelete: UserDataDelete},
+			{Table: "base_resource_balances", Column: "user_id", OnDelete: UserDataDelete, UniqueWith: []string{"resource_key"}},
+			{Table: "base_resource_ledger", Column: "user_id", OnDelete: UserDataDelete},
+			{Table: "bases", Column: "user_id", OnDelete: UserDataDelete, OnePerUser: true},
+			{Table: "monster_battle_invites", Column: "invitee_user_id", OnDelete: UserDataDelete, UniqueWith: []string{"battle_id"}},
+			{Table: "monster_battle_invites", Column: "inviter_user_id", OnDelete: UserDataDelete},
+			{Table: "monster_battle_participants", Column: "user_id", OnDelete: UserDataDelete, UniqueWith: []string{"battle_id"}},
+			{Table: "monster_battles", Column: "user_id", OnDelete: UserDataDelete},
+			{Table: "monster_encounters", Column: "owner_user_id", OnDelete: UserDataDelete},
+			{Table: "monsters", Column: "owner_user_id", OnDelete: UserDataDelete},
+			{Table: "scenarios", Column: "owner_user_id", OnDelete: UserDataDelete},
+			{Table: "quests", Column: "owner_user_id", OnDelete: UserDataDelete},
+			{Table: "characters", Column: "owner_user_id", OnDelete: UserDataDelete},
+			{Table: "guild_audit_entries", Column: "actor_user_id", OnDelete: UserDataAnonymize},
+			{Table: "guild_audit_entries", Column: "target_user_id", OnDelete: UserDataAnonymize},
+			{Table: "guild_quest_node_completions", Column: "completed_by_user_id", OnDelete: UserDataAnonymize},
+			{Table: "guild_invites", Column: "invitee_user_id", OnDelete: UserDataDelete, UniqueWith: []string{"guild_id"}},
+			{Table: "guild_invites", Column: "inviter_user_id", OnDelete: UserDataDelete},
+			{Table: "guild_members", Column: "user_id", OnDelete: UserDataDelete, OnePerUser: true},
+			{Table: "guilds", Column: "leader_user_id", OnDelete: UserDataDelete},
+			{Table: "market_listings", Column: "buyer_user_id", OnDelete: UserDataAnonymize},
+			{Table: "market_listings", Column: "seller_user_id", OnDelete: UserDataDelete},
+			{Table: "trade_offer_items", Column: "owner_user_id", OnDelete: UserDataDelete, UniqueWith: []string{"trade_offer_id", "inventory_item_id"}},
+			{Table: "trade_offers", Column: "initiator_user_id", OnDelete: UserDataDelete},
+			{Table: "trade_offers", Column: "recipient_user_id", OnDelete: UserDataDelete},
+			{Table: "feedback_items", Column: "user_id", OnDelete: UserDataDelete},
+			{Table: "content_moderation_items", Column: "reviewed_by_user_id", OnDelete: UserDataAnonymize},
+			{Table: "moderation_terms", Column: "created_by_user_id", OnDelete: UserDataAnonymize},
+			{Table: "content_translations", Column: "edited_by_user_id", OnDelete: UserDataAnonymize},
+			{Table: "prompt_templates", Column: "created_by_user_id", OnDelete: UserDataAnonymize},
+			{Table: "prompt_evaluations", Column: "created_by_user_id", OnDelete: UserDataAnonymize},
+			{Table: "prompt_evaluation_outputs", Column: "rated_by_user_id", OnDelete: UserDataAnonymize},
+			{Table: "template_revisions", Column: "author_user_id", OnDelete: UserDataAnonymize},
+			{Table: "template_revisions", Column: "published_by_user_id", OnDelete: UserDataAnonymize},
+		},
+	})
+}
+
+// dropOverlappingFriendships removes what would become duplicate or
+// self friendships: the two accounts' friendship with each other, and the
+// source's with anyone the target is already friends with.
+func dropOverlappingFriendships(tx *gorm.DB, fromID uuid.UUID, intoID uuid.UUID) error {
+	const friend = "(CASE WHEN f.first_user_id = @from THEN f.second_user_id ELSE f.first_user_id END)"
+	return tx.Exec(`
+		DELETE FROM friends f
+		WHERE (f.first_user_id = @from OR f.second_user_id = @from)
+		AND (
+			`+friend+` = @into
+			OR EXISTS (
+				SELECT 1 FROM friends kept
+				WHERE kept.user1_id = LEAST(CAST(@into AS uuid), `+friend+`)
+				AND kept.user2_id = GREATEST(CAST(@into AS uuid), `+friend+`)
+			)
+		)`, map[string]interface{}{"from": fromID, "into": intoID}).Error
+}
+
+// deactivateSecondActiveTitle keeps the target's active title when both
+// accounts have one.
+func deactivateSecondActiveTitle(tx *gorm.DB, fromID uuid.UUID, intoID uuid.UUID) error {
+	return tx.Exec(
+		"UPDATE user_titles SET active = false WHERE user_id = ? AND active AND EXISTS (SELECT 1 FROM user_titles WHERE user_id = ? AND active)",
+		fromID, intoID,
+	).Error
+}
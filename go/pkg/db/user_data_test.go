@@ -0,0 +1,78 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+)
+
+func TestUserDataTablesAreDeclaredOnce(t *testing.T) {
+	seen := map[string]string{}
+	for _, domain := range UserDataDomains() {
+		for _, table := range domain.Tables {
+			key := table.Table + "." + table.condition()
+			if other, ok := seen[key]; ok {
+				t.Fatalf("%s is declared by both %s and %s", key, other, domain.Name)
+			}
+			seen[key] = domain.Name
+		}
+	}
+}
+
+func TestUserDataTableValidate(t *testing.T) {
+	valid := []UserDataTable{
+		{Table: "posts", Column: "user_id", OnDelete: UserDataDelete},
+		{Table: "orders", Column: "user_id", OnDelete: UserDataAnonymize},
+		{Table: "answers", Where: "survey_id IN (SELECT id FROM surveys WHERE user_id = @user)", OnDelete: UserDataDelete},
+	}
+	for _, table := range valid {
+		if err := table.validate(); err != nil {
+			t.Fatalf("expected %s to be valid, got %v", table.Table, err)
+		}
+	}
+
+	invalid := []UserDataTable{
+		{Column: "user_id", OnDelete: UserDataDelete},
+		{Table: "posts", OnDelete: UserDataDelete},
+		{Table: "posts", Column: "user_id", Where: "user_id = @user", OnDelete: UserDataDelete},
+		{Table: "posts", Column: "user_id"},
+		{Table: "answers", Where: "survey_id IN (SELECT id FROM surveys WHERE user_id = @user)", OnDelete: UserDataAnonymize},
+		{Table: "answers", Where: "user_id IS NULL", OnDelete: UserDataDelete},
+	}
+	for i, table := range invalid {
+		if err := table.validate(); err == nil {
+			t.Fatalf("expected invalid table %d to be rejected", i)
+		}
+	}
+}
+
+func TestMergedUserUpdatesFillsMissingLogins(t *testing.T) {
+	email := "sam@example.com"
+	hash := "hash"
+	googleID := "google-sub"
+	from := models.User{Email: &email, PasswordHash: &hash, GoogleID: &googleID, PhoneNumber: "+15551234567", Gold: 10, Credits: 3}
+	into := models.User{PhoneNumber: "+15557654321", Gold: 5}
+
+	updates := mergedUserUpdates(from, into)
+
+	if updates["email"] != email || updates["password_hash"] != hash || updates["google_id"] != googleID {
+		t.Fatalf("expected email, password and google logins to move, got %v", updates)
+	}
+	if _, ok := updates["phone_number"]; ok {
+		t.Fatalf("expected the target's phone number to be kept, got %v", updates)
+	}
+	if updates["gold"] != 15 || updates["credits"] != 3 {
+		t.Fatalf("expected balances to be summed, got %v", updates)
+	}
+}
+
+func TestMergedUserUpdatesKeepsTargetPassword(t *testing.T) {
+	fromEmail, intoEmail := "old@example.com", "new@example.com"
+	fromHash, intoHash := "old", "new"
+	from := models.User{Email: &fromEmail, PasswordHash: &fromHash}
+	into := models.User{Email: &intoEmail, PasswordHash: &intoHash}
+
+	if updates := mergedUserUpdates(from, into); len(updates) != 0 {
+		t.Fatalf("expected nothing to change, got %v", updates)
+	}
+}
@@ -0,0 +1,14 @@
+package db
+
+func init() {
+	RegisterUserData(UserDataDomain{
+		Name: "travel-angels",
+		Tables: []UserDataTable{
+			{Table: "documents", Column: "user_id", OnDelete: UserDataDelete},
+			{Table: "dropbox_tokens", Column: "user_id", OnDelete: UserDataDelete, Omit: []string{"access_token", "refresh_token"}, OnePerUser: true},
+			{Table: "google_drive_tokens", Column: "user_id", OnDelete: UserDataDelete, Omit: []string{"access_token", "refresh_token"}, OnePerUser: true},
+			{Table: "community_polls", Column: "user_id", OnDelete: UserDataDelete},
+			{Table: "quick_decision_requests", Column: "user_id", OnDelete: UserDataDelete},
+		},
+	})
+}
@@ -0,0 +1,19 @@
+package db
+
+func init() {
+	RegisterUserData(UserDataDomain{
+		Name: "vampire",
+		Tables: []UserDataTable{
+			// Characters stay in their chronicles for the other players.
+			{Table: "vampire_players", Column: "user_id", OnDelete: UserDataAnonymize, UniqueWith: []string{"instance_id"}},
+			{Table: "vampire_player_invites", Column: "invited_by", OnDelete: UserDataAnonymize},
+			{Table: "vampire_player_invites", Column: "accepted_user_id", OnDelete: UserDataAnonymize},
+			{Table: "vampire_instance_admin_invites", Column: "invited_by", OnDelete: UserDataDelete},
+			{Table: "vampire_instance_admins", Column: "user_id", OnDelete: UserDataDelete, UniqueWith: []string{"instance_id"}},
+			{Table: "vampire_instances", Column: "created_by", OnDelete: UserDataAnonymize},
+			{Table: "vampire_super_user_action_log", Column: "user_id", OnDelete: UserDataAnonymize},
+			{Table: "vampire_super_users", Column: "user_id", OnDelete: UserDataDelete, OnePerUser: true},
+			{Table: "vampire_super_users", Column: "created_by", OnDelete: UserDataAnonymize},
+		},
+	})
+}
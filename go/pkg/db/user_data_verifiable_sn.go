@@ -0,0 +1,23 @@
+package db
+
+func init() {
+	RegisterUserData(UserDataDomain{
+		Name: "verifiable-sn",
+		Tables: []UserDataTable{
+			{Table: "notifications", Column: "user_id", OnDelete: UserDataDelete},
+			{Table: "notifications", Column: "actor_id", OnDelete: UserDataDelete},
+			{Table: "post_reactions", Column: "user_id", OnDelete: UserDataDelete, UniqueWith: []string{"post_id"}},
+			{Table: "post_flags", Column: "user_id", OnDelete: UserDataDelete, UniqueWith: []string{"post_id"}},
+			{Table: "post_comments", Column: "user_id", OnDelete: UserDataDelete},
+			{Table: "user_recent_post_tags", Column: "user_id", OnDelete: UserDataDelete, UniqueWith: []string{"tag"}},
+			{Table: "album_invites", Column: "invited_user_id", OnDelete: UserDataDelete, UniqueWith: []string{"album_id"}},
+			{Table: "album_invites", Column: "invited_by_id", OnDelete: UserDataDelete},
+			{Table: "album_members", Column: "user_id", OnDelete: UserDataDelete, UniqueWith: []string{"album_id"}},
+			{Table: "album_shares", Column: "created_by", OnDelete: UserDataDelete},
+			{Table: "albums", Column: "user_id", OnDelete: UserDataDelete},
+			{Table: "posts", Column: "user_id", OnDelete: UserDataDelete},
+			{Table: "social_accounts", Column: "user_id", OnDelete: UserDataDelete, Omit: []string{"access_token", "refresh_token"}, UniqueWith: []string{"provider"}},
+			{Table: "user_certificates", Column: "user_id", OnDelete: UserDataDelete},
+		},
+	})
+}
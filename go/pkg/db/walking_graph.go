@@ -0,0 +1,88 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type walkingGraphHandle struct {
+	db *gorm.DB
+}
+
+const walkingGraphBatchSize = 1000
+
+// Import upserts nodes and edges from an OSM extract. Overlapping extracts
+// share OSM IDs, so re-importing refreshes rows instead of duplicating them.
+func (h *walkingGraphHandle) Import(ctx context.Context, nodes []models.WalkingGraphNode, edges []models.WalkingGraphEdge) error {
+	return h.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for start := 0; start < len(nodes); start += walkingGraphBatchSize {
+			end := min(start+walkingGraphBatchSize, len(nodes))
+			values := make([]string, 0, end-start)
+			args := make([]interface{}, 0, 5*(end-start))
+			for _, node := range nodes[start:end] {
+				values = append(values, "(?, NOW(), ?, ?, ST_SetSRID(ST_MakePoint(?, ?), 4326))")
+				args = append(args, node.ID, node.Latitude, node.Longitude, node.Longitude, node.Latitude)
+			}
+			query := fmt.Sprintf(`
+INSERT INTO walking_graph_nodes (id, created_at, latitude, longitude, geometry)
+VALUES %s
+ON CONFLICT (id) DO UPDATE SET
+	latitude = EXCLUDED.latitude,
+	longitude = EXCLUDED.longitude,
+	geometry = EXCLUDED.geometry`, strings.Join(values, ", "))
+			if err := tx.Exec(query, args...).Error; err != nil {
+				return err
+			}
+		}
+		if len(edges) == 0 {
+			return nil
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "osm_way_id"}, {Name: "from_node_id"}, {Name: "to_node_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"highway", "length_meters"}),
+		}).CreateInBatches(&edges, walkingGraphBatchSize).Error
+	})
+}
+
+// FindWithin loads every edge touching the box and the nodes at both ends,
+// so routes can leave the box briefly without dead-ending at its edge.
+func (h *walkingGraphHandle) FindWithin(ctx context.Context, minLat float64, minLng float64, maxLat float64, maxLng float64) (*models.WalkingGraph, error) {
+	graph := &models.WalkingGraph{}
+	if err := h.db.WithContext(ctx).Raw(`
+WITH inside AS (
+	SELECT id FROM walking_graph_nodes
+	WHERE geometry && ST_MakeEnvelope(?, ?, ?, ?, 4326)
+)
+SELECT e.* FROM walking_graph_edges e
+WHERE e.from_node_id IN (SELECT id FROM inside) OR e.to_node_id IN (SELECT id FROM inside)`,
+		minLng, minLat, maxLng, maxLat,
+	).Scan(&graph.Edges).Error; err != nil {
+		return nil, err
+	}
+	if len(graph.Edges) == 0 {
+		return graph, nil
+	}
+
+	nodeIDs := make([]int64, 0, 2*len(graph.Edges))
+	seen := make(map[int64]bool, 2*len(graph.Edges))
+	for _, edge := range graph.Edges {
+		for _, nodeID := range []int64{edge.FromNodeID, edge.ToNodeID} {
+			if !seen[nodeID] {
+				seen[nodeID] = true
+				nodeIDs = append(nodeIDs, nodeID)
+			}
+		}
+	}
+	if err := h.db.WithContext(ctx).
+		Select("id", "created_at", "latitude", "longitude").
+		Where("id IN ?", nodeIDs).
+		Find(&graph.Nodes).Error; err != nil {
+		return nil, err
+	}
+	return graph, nil
+}
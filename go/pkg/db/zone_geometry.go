@@ -0,0 +1,423 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// zoneMergeSnapDegrees closes hairline gaps (about a meter) between zones
+// that were drawn as neighbors but don't share vertices exactly.
+const zoneMergeSnapDegrees = 0.00001
+
+const metersPerDegree = 111320.0
+
+// zonePointContentTables hold point content with their own zone_id and
+// geometry columns. Points of interest link through point_of_interest_zones
+// and are handled separately.
+var zonePointContentTables = []string{
+	"challenges",
+	"expositions",
+	"healing_fountains",
+	"monsters",
+	"monster_encounters",
+	"resources",
+	"scenarios",
+	"shrines",
+	"treasure_chests",
+}
+
+// zoneScopedUniqueKeys lists the columns that, together with zone_id, are
+// unique in tables a merge repoints. Rows that would collide are dropped
+// before repointing so the merged zone keeps one of each.
+var zoneScopedUniqueKeys = map[string][]string{
+	"district_zones":          {"district_id"},
+	"point_of_interest_zones": {"point_of_interest_id"},
+	"user_bounty_progress":    {"user_id", "bounty_template_id", "period_start"},
+	"user_zone_reputations":   {"user_id"},
+	"zone_discoveries":        {"user_id"},
+	"zone_genre_scores":       {"genre_id"},
+}
+
+func zoneLineWKT(line [][]float64) string {
+	coords := make([]string, 0, len(line))
+	for _, point := range line {
+		coords = append(coords, fmt.Sprintf("%.6f %.6f", point[0], point[1]))
+	}
+	return fmt.Sprintf("LINESTRING(%s)", strings.Join(coords, ", "))
+}
+
+// applyZoneBoundary rewrites a zone's boundary and boundary points and moves
+// its stored center to the new shape's centroid.
+func applyZoneBoundary(ctx context.Context, tx *gorm.DB, zoneID uuid.UUID, boundary [][]float64) error {
+	if err := (&zoneHandler{db: tx}).UpdateBoundary(ctx, zoneID, boundary); err != nil {
+		return err
+	}
+	return tx.WithContext(ctx).Exec(
+		`UPDATE zones SET latitude = ST_Y(ST_Centroid(boundary)), longitude = ST_X(ST_Centroid(boundary)), updated_at = NOW() WHERE id = ?`,
+		zoneID,
+	).Error
+}
+
+// reassignZoneContent moves point content out of fromZoneIDs into whichever
+// candidate zone now contains it, falling back to the nearest candidate for
+// points that landed in a gap. Returns moved row counts per table.
+func reassignZoneContent(ctx context.Context, tx *gorm.DB, fromZoneIDs []uuid.UUID, candidateZoneIDs []uuid.UUID) (map[string]int, error) {
+	reassigned := map[string]int{}
+	if len(fromZoneIDs) == 0 || len(candidateZoneIDs) == 0 {
+		return reassigned, nil
+	}
+	params := map[string]interface{}{
+		"from":       fromZoneIDs,
+		"candidates": candidateZoneIDs,
+	}
+	const nearestZone = `(SELECT z.id FROM zones z WHERE z.id IN @candidates ORDER BY ST_Distance(z.boundary, %s), z.id LIMIT 1)`
+
+	for _, table := range zonePointContentTables {
+		query := fmt.Sprintf(`
+WITH nearest AS (
+	SELECT t.id, `+nearestZone+` AS zone_id
+	FROM %s t
+	WHERE t.zone_id IN @from AND t.geometry IS NOT NULL
+)
+UPDATE %s t SET zone_id = nearest.zone_id, updated_at = NOW()
+FROM nearest
+WHERE t.id = nearest.id AND nearest.zone_id IS NOT NULL AND t.zone_id <> nearest.zone_id`, "t.geometry", table, table)
+		result := tx.WithContext(ctx).Exec(query, params)
+		if result.Error != nil {
+			return nil, fmt.Errorf("reassign %s: %w", table, result.Error)
+		}
+		if result.RowsAffected > 0 {
+			reassigned[table] = int(result.RowsAffected)
+		}
+	}
+
+	query := fmt.Sprintf(`
+WITH nearest AS (
+	SELECT pz.id, `+nearestZone+` AS zone_id
+	FROM point_of_interest_zones pz
+	JOIN points_of_interest poi ON poi.id = pz.point_of_interest_id
+	WHERE pz.zone_id IN @from AND pz.deleted_at IS NULL AND poi.geometry IS NOT NULL
+)
+UPDATE point_of_interest_zones pz SET zone_id = nearest.zone_id, updated_at = NOW()
+FROM nearest
+WHERE pz.id = nearest.id AND nearest.zone_id IS NOT NULL AND pz.zone_id <> nearest.zone_id`, "poi.geometry")
+	result := tx.WithContext(ctx).Exec(query, params)
+	if result.Error != nil {
+		return nil, fmt.Errorf("reassign point_of_interest_zones: %w", result.Error)
+	}
+	if result.RowsAffected > 0 {
+		reassigned["points_of_interest"] = int(result.RowsAffected)
+	}
+	return reassigned, nil
+}
+
+func (h *zoneHandler) reloadZones(ctx context.Context, zoneIDs []uuid.UUID) ([]*models.Zone, error) {
+	zones := make([]*models.Zone, 0, len(zoneIDs))
+	for _, zoneID := range zoneIDs {
+		zone, err := h.FindByID(ctx, zoneID)
+		if err != nil {
+			return nil, err
+		}
+		zones = append(zones, zone)
+	}
+	return zones, nil
+}
+
+// ImportBoundaries creates one zone per draft in a single transaction.
+func (h *zoneHandler) ImportBoundaries(ctx context.Context, drafts []models.ZoneBoundaryDraft) ([]*models.Zone, error) {
+	zoneIDs := make([]uuid.UUID, 0, len(drafts))
+	err := h.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		handle := &zoneHandler{db: tx}
+		for _, draft := range drafts {
+			lat, lng := draft.Center()
+			zone := &models.Zone{
+				Name:         draft.Name,
+				Description:  draft.Description,
+				Kind:         draft.Kind,
+				InternalTags: draft.InternalTags,
+				Latitude:     lat,
+				Longitude:    lng,
+			}
+			if err := handle.Create(ctx, zone); err != nil {
+				return err
+			}
+			if err := applyZoneBoundary(ctx, tx, zone.ID, draft.Boundary); err != nil {
+				return err
+			}
+			zoneIDs = append(zoneIDs, zone.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return h.reloadZones(ctx, zoneIDs)
+}
+
+// Split cuts a zone along a [lng, lat] line. The largest piece keeps the
+// zone's ID and name; the others become new zones with the same kind, tags,
+// districts and discoveries. Content moves to the piece that contains it.
+func (h *zoneHandler) Split(ctx context.Context, zoneID uuid.UUID, line [][]float64) (*models.ZoneGeometryEdit, error) {
+	if len(line) < 2 {
+		return nil, errors.New("split line needs at least 2 points")
+	}
+
+	edit := &models.ZoneGeometryEdit{}
+	zoneIDs := []uuid.UUID{zoneID}
+	err := h.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var zone models.Zone
+		if err := tx.WithContext(ctx).Where("id = ?", zoneID).First(&zone).Error; err != nil {
+			return err
+		}
+
+		var pieces []string
+		if err := tx.WithContext(ctx).Raw(`
+SELECT ST_AsGeoJSON(piece.geom)
+FROM zones z, LATERAL ST_Dump(ST_Split(z.boundary, ST_GeomFromText(?, 4326))) AS piece
+WHERE z.id = ? AND GeometryType(piece.geom) = 'POLYGON'
+ORDER BY ST_Area(piece.geom) DESC`, zoneLineWKT(line), zoneID).Scan(&pieces).Error; err != nil {
+			return err
+		}
+		if len(pieces) < 2 {
+			return ErrZoneSplitLineMissesZone
+		}
+
+		handle := &zoneHandler{db: tx}
+		for i, piece := range pieces {
+			boundary, err := models.ZoneBoundaryFromGeoJSON([]byte(piece))
+			if err != nil {
+				return fmt.Errorf("split piece %d: %w", i+1, err)
+			}
+			pieceID := zoneID
+			if i > 0 {
+				newZone := &models.Zone{
+					Name:         fmt.Sprintf("%s (%d)", zone.Name, i+1),
+					Description:  zone.Description,
+					Kind:         zone.Kind,
+					InternalTags: append(models.StringArray{}, zone.InternalTags...),
+					Latitude:     zone.Latitude,
+					Longitude:    zone.Longitude,
+				}
+				if err := handle.Create(ctx, newZone); err != nil {
+					return err
+				}
+				pieceID = newZone.ID
+				zoneIDs = append(zoneIDs, pieceID)
+
+				// Players who had found the original zone keep the pieces
+				// revealed, and the pieces stay in the same districts.
+				if err := tx.WithContext(ctx).Exec(`
+INSERT INTO zone_discoveries (id, created_at, updated_at, user_id, zone_id)
+SELECT uuid_generate_v4(), NOW(), NOW(), user_id, ? FROM zone_discoveries WHERE zone_id = ?
+ON CONFLICT DO NOTHING`, pieceID, zoneID).Error; err != nil {
+					return err
+				}
+				if err := tx.WithContext(ctx).Exec(`
+INSERT INTO district_zones (id, created_at, updated_at, district_id, zone_id)
+SELECT uuid_generate_v4(), NOW(), NOW(), district_id, ? FROM district_zones WHERE zone_id = ?
+ON CONFLICT DO NOTHING`, pieceID, zoneID).Error; err != nil {
+					return err
+				}
+			}
+			if err := applyZoneBoundary(ctx, tx, pieceID, boundary); err != nil {
+				return err
+			}
+		}
+
+		reassigned, err := reassignZoneContent(ctx, tx, []uuid.UUID{zoneID}, zoneIDs)
+		if err != nil {
+			return err
+		}
+		edit.ReassignedContent = reassigned
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if edit.Zones, err = h.reloadZones(ctx, zoneIDs); err != nil {
+		return nil, err
+	}
+	return edit, nil
+}
+
+// Merge unions adjacent zones into the first one, optionally renaming it.
+// Everything that referenced the other zones is repointed at it before they
+// are deleted.
+func (h *zoneHandler) Merge(ctx context.Context, zoneIDs []uuid.UUID, name string) (*models.ZoneGeometryEdit, error) {
+	zoneIDs = normalizeZoneIDs(zoneIDs)
+	if len(zoneIDs) < 2 {
+		return nil, errors.New("merge needs at least 2 zones")
+	}
+	targetID := zoneIDs[0]
+	sourceIDs := zoneIDs[1:]
+
+	edit := &models.ZoneGeometryEdit{
+		RemovedZoneIDs:    sourceIDs,
+		ReassignedContent: map[string]int{},
+	}
+	err := h.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var merged struct {
+			Found        int
+			GeometryType string
+			GeoJSON      string `gorm:"column:geojson"`
+		}
+		if err := tx.WithContext(ctx).Raw(`
+SELECT found, GeometryType(geom) AS geometry_type, ST_AsGeoJSON(geom) AS geojson
+FROM (
+	SELECT count(*) AS found,
+		ST_Buffer(ST_Union(ST_Buffer(boundary, @snap, 'join=mitre')), -@snap, 'join=mitre') AS geom
+	FROM zones WHERE id IN @ids
+) merged`, map[string]interface{}{"ids": zoneIDs, "snap": zoneMergeSnapDegrees}).Scan(&merged).Error; err != nil {
+			return err
+		}
+		if merged.Found != len(zoneIDs) {
+			return gorm.ErrRecordNotFound
+		}
+		if merged.GeometryType != "POLYGON" {
+			return ErrZonesNotAdjacent
+		}
+		boundary, err := models.ZoneBoundaryFromGeoJSON([]byte(merged.GeoJSON))
+		if err != nil {
+			return err
+		}
+
+		if strings.TrimSpace(name) != "" {
+			if err := tx.WithContext(ctx).Model(&models.Zone{}).Where("id = ?", targetID).Update("name", strings.TrimSpace(name)).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.WithContext(ctx).Where("zone_id IN ?", sourceIDs).Delete(&models.BoundaryPoint{}).Error; err != nil {
+			return err
+		}
+
+		var tables []string
+		if err := tx.WithContext(ctx).Raw(`
+SELECT c.table_name
+FROM information_schema.columns c
+JOIN information_schema.tables t ON t.table_schema = c.table_schema AND t.table_name = c.table_name
+WHERE c.table_schema = current_schema() AND c.column_name = 'zone_id' AND t.table_type = 'BASE TABLE'
+ORDER BY c.table_name`).Scan(&tables).Error; err != nil {
+			return err
+		}
+
+		params := map[string]interface{}{
+			"target":  targetID,
+			"sources": sourceIDs,
+			"all":     zoneIDs,
+		}
+		for _, table := range tables {
+			if keys, ok := zoneScopedUniqueKeys[table]; ok {
+				matches := make([]string, 0, len(keys))
+				for _, key := range keys {
+					matches = append(matches, fmt.Sprintf("b.%[1]s IS NOT DISTINCT FROM a.%[1]s", key))
+				}
+				// Keep the target's row, or else the oldest source row.
+				if err := tx.WithContext(ctx).Exec(fmt.Sprintf(`
+DELETE FROM %[1]s a
+WHERE a.zone_id IN @sources AND EXISTS (
+	SELECT 1 FROM %[1]s b
+	WHERE b.zone_id IN @all AND b.id <> a.id AND %[2]s
+		AND (b.zone_id = @target OR b.id < a.id)
+)`, table, strings.Join(matches, " AND ")), params).Error; err != nil {
+					return fmt.Errorf("dedupe %s: %w", table, err)
+				}
+			}
+
+			result := tx.WithContext(ctx).Exec(fmt.Sprintf(`UPDATE %s SET zone_id = @target WHERE zone_id IN @sources`, table), params)
+			if result.Error != nil {
+				return fmt.Errorf("repoint %s: %w", table, result.Error)
+			}
+			if result.RowsAffected > 0 {
+				edit.ReassignedContent[table] = int(result.RowsAffected)
+			}
+		}
+
+		if err := applyZoneBoundary(ctx, tx, targetID, boundary); err != nil {
+			return err
+		}
+		return tx.WithContext(ctx).Delete(&models.Zone{}, "id IN ?", sourceIDs).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if edit.Zones, err = h.reloadZones(ctx, []uuid.UUID{targetID}); err != nil {
+		return nil, err
+	}
+	return edit, nil
+}
+
+// Simplify drops boundary vertices that move the outline by less than
+// toleranceMeters. Each zone is simplified on its own, so shared edges may
+// drift apart slightly. Content that falls outside a simplified zone moves to
+// whichever neighbor now contains it.
+func (h *zoneHandler) Simplify(ctx context.Context, zoneIDs []uuid.UUID, toleranceMeters float64) (*models.ZoneGeometryEdit, error) {
+	zoneIDs = normalizeZoneIDs(zoneIDs)
+	if len(zoneIDs) == 0 {
+		return nil, errors.New("no zones to simplify")
+	}
+	if toleranceMeters <= 0 || math.IsNaN(toleranceMeters) {
+		return nil, errors.New("tolerance must be greater than 0 meters")
+	}
+
+	edit := &models.ZoneGeometryEdit{}
+	err := h.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, zoneID := range zoneIDs {
+			// Web mercator stretches distances by 1/cos(latitude), so the
+			// tolerance is scaled to stay in ground meters.
+			var simplified string
+			if err := tx.WithContext(ctx).Raw(`
+SELECT ST_AsGeoJSON(ST_Transform(
+	ST_SimplifyPreserveTopology(ST_Transform(boundary, 3857), ? / cos(radians(ST_Y(ST_Centroid(boundary))))),
+	4326
+))
+FROM zones WHERE id = ?`, toleranceMeters, zoneID).Row().Scan(&simplified); err != nil {
+				return err
+			}
+			boundary, err := models.ZoneBoundaryFromGeoJSON([]byte(simplified))
+			if err != nil {
+				return fmt.Errorf("simplify zone %s: %w", zoneID, err)
+			}
+			if err := applyZoneBoundary(ctx, tx, zoneID, boundary); err != nil {
+				return err
+			}
+		}
+
+		var candidateIDs []uuid.UUID
+		if err := tx.WithContext(ctx).Raw(`
+SELECT z.id FROM zones z
+WHERE z.id IN @ids OR ST_DWithin(
+	z.boundary,
+	(SELECT ST_Collect(boundary) FROM zones WHERE id IN @ids),
+	@distance
+)`, map[string]interface{}{
+			"ids":      zoneIDs,
+			"distance": 2 * toleranceMeters / metersPerDegree,
+		}).Scan(&candidateIDs).Error; err != nil {
+			return err
+		}
+
+		reassigned, err := reassignZoneContent(ctx, tx, zoneIDs, candidateIDs)
+		if err != nil {
+			return err
+		}
+		edit.ReassignedContent = reassigned
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if edit.Zones, err = h.reloadZones(ctx, zoneIDs); err != nil {
+		return nil, err
+	}
+	return edit, nil
+}
@@ -282,12 +282,13 @@ func (c *client) GenerateQuest(
 
 	// Track used POIs at the quest level
 	usedPOIs := make(map[uuid.UUID]bool)
+	route := c.newQuestRoutePlan(ctx, zone, questArchType, quest)
 
 	log.Println("Processing quest nodes")
 	orderIndex := 0
 	nodeMap := make(map[uuid.UUID]uuid.UUID)
 	anchorMap := make(map[uuid.UUID]*questNodeAnchor)
-	if _, err := c.processQuestNode(ctx, zone, &questArchType.Root, quest, usedPOIs, &orderIndex, nodeMap, anchorMap, nil); err != nil {
+	if _, err := c.processQuestNode(ctx, zone, &questArchType.Root, quest, usedPOIs, route, &orderIndex, nodeMap, anchorMap, nil); err != nil {
 		log.Printf("Error processing quest nodes: %v", err)
 		if deleteErr := c.dbClient.Quest().Delete(ctx, quest.ID); deleteErr != nil {
 			log.Printf("Error deleting quest after node processing failure: %v", deleteErr)
@@ -295,6 +296,25 @@ func (c *client) GenerateQuest(
 		return nil, err
 	}
 
+	quest.EstimatedWalkingMeters = int(math.Round(route.totalMeters))
+	quest.EstimatedWalkingMinutes = models.EstimateWalkingMinutes(route.totalMeters)
+	log.Printf(
+		"[quest-generation][walking] quest=%s meters=%d minutes=%d budget=%.0f graph=%t",
+		quest.ID,
+		quest.EstimatedWalkingMeters,
+		quest.EstimatedWalkingMinutes,
+		route.budgetMeters,
+		route.router != nil,
+	)
+	if err := c.dbClient.Quest().UpdateWalkingEstimate(
+		ctx,
+		quest.ID,
+		quest.EstimatedWalkingMeters,
+		quest.EstimatedWalkingMinutes,
+	); err != nil {
+		log.Printf("Warning: failed to save walking estimate for quest %s: %v", quest.ID, err)
+	}
+
 	if err := c.applyQuestArchetypeRewards(ctx, quest.ID, questArchType); err != nil {
 		log.Printf("Error applying quest archetype rewards: %v", err)
 		if deleteErr := c.dbClient.Quest().Delete(ctx, quest.ID); deleteErr != nil {
@@ -323,6 +343,7 @@ func (c *client) processQuestNode(
 	questArchTypeNode *models.QuestArchetypeNode,
 	quest *models.Quest,
 	usedPOIs map[uuid.UUID]bool,
+	route *questRoutePlan,
 	orderIndex *int,
 	nodeMap map[uuid.UUID]uuid.UUID,
 	anchorMap map[uuid.UUID]*questNodeAnchor,
@@ -341,6 +362,7 @@ func (c *client) processQuestNode(
 			questArchTypeNode,
 			quest,
 			usedPOIs,
+			route,
 			orderIndex,
 			nodeMap,
 			anchorMap,
@@ -353,6 +375,7 @@ func (c *client) processQuestNode(
 			questArchTypeNode,
 			quest,
 			usedPOIs,
+			route,
 			orderIndex,
 			nodeMap,
 			anchorMap,
@@ -365,6 +388,7 @@ func (c *client) processQuestNode(
 			questArchTypeNode,
 			quest,
 			usedPOIs,
+			route,
 			orderIndex,
 			nodeMap,
 			anchorMap,
@@ -377,6 +401,7 @@ func (c *client) processQuestNode(
 			questArchTypeNode,
 			quest,
 			usedPOIs,
+			route,
 			orderIndex,
 			nodeMap,
 			anchorMap,
@@ -389,6 +414,7 @@ func (c *client) processQuestNode(
 			questArchTypeNode,
 			quest,
 			usedPOIs,
+			route,
 			orderIndex,
 			nodeMap,
 			anchorMap,
@@ -401,6 +427,7 @@ func (c *client) processQuestNode(
 			questArchTypeNode,
 			quest,
 			usedPOIs,
+			route,
 			orderIndex,
 			nodeMap,
 			anchorMap,
@@ -415,6 +442,7 @@ func (c *client) processQuestChallengeNode(
 	questArchTypeNode *models.QuestArchetypeNode,
 	quest *models.Quest,
 	usedPOIs map[uuid.UUID]bool,
+	route *questRoutePlan,
 	orderIndex *int,
 	nodeMap map[uuid.UUID]uuid.UUID,
 	anchorMap map[uuid.UUID]*questNodeAnchor,
@@ -434,6 +462,7 @@ func (c *client) processQuestChallengeNode(
 			questArchTypeNode,
 			quest,
 			usedPOIs,
+			route,
 			orderIndex,
 			nodeMap,
 			anchorMap,
@@ -448,6 +477,7 @@ func (c *client) processQuestChallengeNode(
 		questArchTypeNode,
 		quest,
 		usedPOIs,
+		route,
 		previousAnchor,
 	)
 	if err != nil {
@@ -516,6 +546,7 @@ func (c *client) processQuestChallengeNode(
 		questArchTypeNode,
 		quest,
 		usedPOIs,
+		route,
 		orderIndex,
 		nodeMap,
 		anchorMap,
@@ -733,6 +764,7 @@ func (c *client) processQuestScenarioNode(
 	questArchTypeNode *models.QuestArchetypeNode,
 	quest *models.Quest,
 	usedPOIs map[uuid.UUID]bool,
+	route *questRoutePlan,
 	orderIndex *int,
 	nodeMap map[uuid.UUID]uuid.UUID,
 	anchorMap map[uuid.UUID]*questNodeAnchor,
@@ -750,6 +782,7 @@ func (c *client) processQuestScenarioNode(
 			questArchTypeNode,
 			quest,
 			usedPOIs,
+			route,
 			orderIndex,
 			nodeMap,
 			anchorMap,
@@ -779,6 +812,7 @@ func (c *client) processQuestScenarioNode(
 		questArchTypeNode,
 		quest,
 		usedPOIs,
+		route,
 		previousAnchor,
 	)
 	if err != nil {
@@ -886,6 +920,7 @@ func (c *client) processQuestScenarioNode(
 		questArchTypeNode,
 		quest,
 		usedPOIs,
+		route,
 		orderIndex,
 		nodeMap,
 		anchorMap,
@@ -904,6 +939,7 @@ func (c *client) processQuestExpositionNode(
 	questArchTypeNode *models.QuestArchetypeNode,
 	quest *models.Quest,
 	usedPOIs map[uuid.UUID]bool,
+	route *questRoutePlan,
 	orderIndex *int,
 	nodeMap map[uuid.UUID]uuid.UUID,
 	anchorMap map[uuid.UUID]*questNodeAnchor,
@@ -921,6 +957,7 @@ func (c *client) processQuestExpositionNode(
 			questArchTypeNode,
 			quest,
 			usedPOIs,
+			route,
 			orderIndex,
 			nodeMap,
 			anchorMap,
@@ -952,6 +989,7 @@ func (c *client) processQuestExpositionNode(
 		questArchTypeNode,
 		quest,
 		usedPOIs,
+		route,
 		previousAnchor,
 	)
 	if err != nil {
@@ -1018,6 +1056,7 @@ func (c *client) processQuestExpositionNode(
 		questArchTypeNode,
 		quest,
 		usedPOIs,
+		route,
 		orderIndex,
 		nodeMap,
 		anchorMap,
@@ -1036,6 +1075,7 @@ func (c *client) processQuestStoryFlagNode(
 	questArchTypeNode *models.QuestArchetypeNode,
 	quest *models.Quest,
 	usedPOIs map[uuid.UUID]bool,
+	route *questRoutePlan,
 	orderIndex *int,
 	nodeMap map[uuid.UUID]uuid.UUID,
 	anchorMap map[uuid.UUID]*questNodeAnchor,
@@ -1053,6 +1093,7 @@ func (c *client) processQuestStoryFlagNode(
 			questArchTypeNode,
 			quest,
 			usedPOIs,
+			route,
 			orderIndex,
 			nodeMap,
 			anchorMap,
@@ -1093,6 +1134,7 @@ func (c *client) processQuestStoryFlagNode(
 		questArchTypeNode,
 		quest,
 		usedPOIs,
+		route,
 		orderIndex,
 		nodeMap,
 		anchorMap,
@@ -1111,6 +1153,7 @@ func (c *client) processQuestFetchNode(
 	questArchTypeNode *models.QuestArchetypeNode,
 	quest *models.Quest,
 	usedPOIs map[uuid.UUID]bool,
+	route *questRoutePlan,
 	orderIndex *int,
 	nodeMap map[uuid.UUID]uuid.UUID,
 	anchorMap map[uuid.UUID]*questNodeAnchor,
@@ -1128,6 +1171,7 @@ func (c *client) processQuestFetchNode(
 			questArchTypeNode,
 			quest,
 			usedPOIs,
+			route,
 			orderIndex,
 			nodeMap,
 			anchorMap,
@@ -1155,6 +1199,7 @@ func (c *client) processQuestFetchNode(
 		questArchTypeNode,
 		quest,
 		usedPOIs,
+		route,
 		previousAnchor,
 	)
 	if err != nil {
@@ -1203,6 +1248,7 @@ func (c *client) processQuestFetchNode(
 		questArchTypeNode,
 		quest,
 		usedPOIs,
+		route,
 		orderIndex,
 		nodeMap,
 		anchorMap,
@@ -1221,6 +1267,7 @@ func (c *client) processQuestMonsterEncounterNode(
 	questArchTypeNode *models.QuestArchetypeNode,
 	quest *models.Quest,
 	usedPOIs map[uuid.UUID]bool,
+	route *questRoutePlan,
 	orderIndex *int,
 	nodeMap map[uuid.UUID]uuid.UUID,
 	anchorMap map[uuid.UUID]*questNodeAnchor,
@@ -1238,6 +1285,7 @@ func (c *client) processQuestMonsterEncounterNode(
 			questArchTypeNode,
 			quest,
 			usedPOIs,
+			route,
 			orderIndex,
 			nodeMap,
 			anchorMap,
@@ -1293,6 +1341,7 @@ func (c *client) processQuestMonsterEncounterNode(
 		questArchTypeNode,
 		quest,
 		usedPOIs,
+		route,
 		previousAnchor,
 	)
 	if err != nil {
@@ -1408,6 +1457,7 @@ func (c *client) processQuestMonsterEncounterNode(
 		questArchTypeNode,
 		quest,
 		usedPOIs,
+		route,
 		orderIndex,
 		nodeMap,
 		anchorMap,
@@ -1426,6 +1476,7 @@ func (c *client) attachQuestBranchChildren(
 	questArchTypeNode *models.QuestArchetypeNode,
 	quest *models.Quest,
 	usedPOIs map[uuid.UUID]bool,
+	route *questRoutePlan,
 	orderIndex *int,
 	nodeMap map[uuid.UUID]uuid.UUID,
 	anchorMap map[uuid.UUID]*questNodeAnchor,
@@ -1438,6 +1489,7 @@ func (c *client) attachQuestBranchChildren(
 			zone,
 			quest,
 			usedPOIs,
+			route,
 			orderIndex,
 			nodeMap,
 			anchorMap,
@@ -1453,6 +1505,7 @@ func (c *client) attachQuestBranchChildren(
 			zone,
 			quest,
 			usedPOIs,
+			route,
 			orderIndex,
 			nodeMap,
 			anchorMap,
@@ -1472,6 +1525,7 @@ func (c *client) attachQuestBranchChild(
 	zone *models.Zone,
 	quest *models.Quest,
 	usedPOIs map[uuid.UUID]bool,
+	route *questRoutePlan,
 	orderIndex *int,
 	nodeMap map[uuid.UUID]uuid.UUID,
 	anchorMap map[uuid.UUID]*questNodeAnchor,
@@ -1496,6 +1550,7 @@ func (c *client) attachQuestBranchChild(
 		unlockedNode,
 		quest,
 		usedPOIs,
+		route,
 		orderIndex,
 		nodeMap,
 		anchorMap,
@@ -1621,6 +1676,7 @@ func (c *client) resolveQuestNodePointOfInterest(
 	zone *models.Zone,
 	locationArchetype *models.LocationArchetype,
 	usedPOIs map[uuid.UUID]bool,
+	route *questRoutePlan,
 	selectionMode models.QuestArchetypeNodeLocationSelectionMode,
 	referenceAnchor *questNodeAnchor,
 ) (*models.PointOfInterest, error) {
@@ -1668,6 +1724,9 @@ func (c *client) resolveQuestNodePointOfInterest(
 	}
 
 	selectPointOfInterest := func(points []*models.PointOfInterest) *models.PointOfInterest {
+		if route != nil {
+			return route.selectPointOfInterest(points, usedPOIs, referenceAnchor, selectionMode)
+		}
 		if selectionMode == models.QuestArchetypeNodeLocationSelectionModeClosest {
 			return selectClosestUnusedPointOfInterest(points, usedPOIs, referenceAnchor)
 		}
@@ -1698,6 +1757,16 @@ func (c *client) resolveQuestNodePointOfInterest(
 		pointsOfInterest = morePointsOfInterest
 		pointOfInterest = selectPointOfInterest(pointsOfInterest)
 	}
+	if pointOfInterest == nil && route != nil && route.budgetMeters > 0 {
+		return nil, markNonRetriableQuestGenerationError(
+			fmt.Errorf(
+				"no unused points of interest for location archetype %s in zone %s fit the remaining %.0fm walking budget",
+				locationArchetype.ID,
+				zone.ID,
+				math.Max(route.remainingMeters(), 0),
+			),
+		)
+	}
 	if pointOfInterest == nil {
 		return nil, markNonRetriableQuestGenerationError(
 			fmt.Errorf(
@@ -1755,12 +1824,39 @@ func (c *client) resolveQuestGiverAnchor(
 	return nil, nil
 }
 
+// resolveQuestNodeAnchor picks where a node happens and, when a route plan
+// is given, records the walk there from the previous node.
 func (c *client) resolveQuestNodeAnchor(
 	ctx context.Context,
 	zone *models.Zone,
 	questArchTypeNode *models.QuestArchetypeNode,
 	quest *models.Quest,
 	usedPOIs map[uuid.UUID]bool,
+	route *questRoutePlan,
+	previousAnchor *questNodeAnchor,
+) (*questNodeAnchor, *models.PointOfInterest, error) {
+	anchor, pointOfInterest, err := c.chooseQuestNodeAnchor(
+		ctx,
+		zone,
+		questArchTypeNode,
+		quest,
+		usedPOIs,
+		route,
+		previousAnchor,
+	)
+	if err == nil && route != nil && anchor != nil && anchor != previousAnchor {
+		route.record(previousAnchor, anchor)
+	}
+	return anchor, pointOfInterest, err
+}
+
+func (c *client) chooseQuestNodeAnchor(
+	ctx context.Context,
+	zone *models.Zone,
+	questArchTypeNode *models.QuestArchetypeNode,
+	quest *models.Quest,
+	usedPOIs map[uuid.UUID]bool,
+	route *questRoutePlan,
 	previousAnchor *questNodeAnchor,
 ) (*questNodeAnchor, *models.PointOfInterest, error) {
 	if questArchTypeNode == nil {
@@ -1808,6 +1904,7 @@ func (c *client) resolveQuestNodeAnchor(
 			zone,
 			locationArchetype,
 			usedPOIs,
+			route,
 			selectionMode,
 			referenceAnchor,
 		)
@@ -1826,7 +1923,11 @@ func (c *client) resolveQuestNodeAnchor(
 		}
 		return &questNodeAnchor{Latitude: latitude, Longitude: longitude}, pointOfInterest, nil
 	}
-	return randomQuestEncounterPoint(zone, previousAnchor, questArchTypeNode.EncounterProximityMeters), nil, nil
+	anchor := randomQuestEncounterPoint(zone, previousAnchor, questArchTypeNode.EncounterProximityMeters)
+	for attempt := 0; route != nil && attempt < 8 && !route.fits(previousAnchor, anchor); attempt++ {
+		anchor = randomQuestEncounterPoint(zone, previousAnchor, questArchTypeNode.EncounterProximityMeters)
+	}
+	return anchor, nil, nil
 }
 
 func randomQuestEncounterPoint(
@@ -130,6 +130,7 @@ func TestResolveQuestNodeAnchorSameAsPreviousReusesPreviousAnchor(t *testing.T)
 		},
 		nil,
 		map[uuid.UUID]bool{},
+		nil,
 		previousAnchor,
 	)
 	if err != nil {
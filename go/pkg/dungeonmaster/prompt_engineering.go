@@ -7,6 +7,7 @@ import (
 	"log"
 	"strings"
 
+	"github.com/MaxBlaushild/poltergeist/pkg/db"
 	"github.com/MaxBlaushild/poltergeist/pkg/deep_priest"
 	"github.com/MaxBlaushild/poltergeist/pkg/models"
 	"github.com/google/uuid"
@@ -25,57 +26,6 @@ type QuestImagePrompt struct {
 	Description string `json:"description"`
 }
 
-const generateQuestPromptTemplate = `
-	You are a video game designer tasked with converting real-world tasks and chores into quests for a fantasy RPG.
-
-	The tasks and chores for the quest you are designing are as follows:
-
-	%s
-
-	Please come up with copy for the quest that incorporates the task and chores into a cohesive quest that might appear in a fantasy role playing game. Try to keep the description to 50 words or less. Feel free to make up names or proper nouns.
-
-	Please format your response as a JSON object with the following fields:
-	{
-		"name": "string", // The name of the overarching quest that includes all of the above tasks and chores
-		"description": "string", // A description of why the chores need to be done in the made-up fantasy world and a bit of lore about the quest
-	}
-`
-
-const generateQuestImagePromptTemplate = `
-	You are a video game designer tasked with creating visual assets for quests in a fantasy role playing game.
-
-	The quest is this:
-
-	%s
-
-	Please describe what an iconic moment from this quest would look like to an outside observer.
-
-	Please format your response as a JSON object with the following fields:
-	{
-		"description": "string", // A description of what the hero image for the quest would look like
-	}
-`
-
-const generateQuestAcceptanceDialogueTemplate = `
-	You are a video game writer tasked with scripting the dialogue a quest giver delivers to a player before they accept a quest.
-
-	Quest name: %s
-	Quest description: %s
-	Quest giver: %s
-	Rewards: %s
-
-	The tasks and chores for the quest are:
-
-	%s
-
-	Write 3 to 6 short lines of dialogue. Keep each line under 18 words, in a warm fantasy RPG tone. Speak directly to the player (use "you"). Mention the rewards if provided.
-
-	Please format your response as a JSON object with the following fields:
-	{
-		"acceptanceDialogue": ["string"] // The dialogue lines the player sees before accepting the quest
-	}
-`
-
 func buildQuestTasks(locations []string, descriptions []string, challenges []string) string {
 	count := len(locations)
 	if len(descriptions) < count {
@@ -112,8 +62,16 @@ func (c *client) generateQuestCopy(
 
 	tasks := buildQuestTasks(locations, descriptions, challenges)
 
+	prompt, err := db.ResolvePrompt(ctx, c.dbClient, models.PromptKeyQuestCopy, nil, "", map[string]interface{}{
+		"Tasks": tasks,
+	})
+	if err != nil {
+		log.Printf("Error resolving quest copy prompt: %v", err)
+		return nil, err
+	}
+
 	answer, err := c.deepPriest.PetitionTheFount(&deep_priest.Question{
-		Question: fmt.Sprintf(generateQuestPromptTemplate, tasks),
+		Question: prompt.Text,
 	})
 	if err != nil {
 		log.Printf("Error getting response from DeepPriest: %v", err)
@@ -185,8 +143,19 @@ func (c *client) generateQuestAcceptanceDialogue(
 
 	tasks := buildQuestTasks(locations, descriptions, challenges)
 
+	prompt, err := db.ResolvePrompt(ctx, c.dbClient, models.PromptKeyQuestAcceptanceDialogue, nil, "", map[string]interface{}{
+		"QuestName":        questCopy.Name,
+		"QuestDescription": questCopy.Description,
+		"QuestGiver":       questGiverName,
+		"Rewards":          rewardSummary,
+		"Tasks":            tasks,
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	answer, err := c.deepPriest.PetitionTheFount(&deep_priest.Question{
-		Question: fmt.Sprintf(generateQuestAcceptanceDialogueTemplate, questCopy.Name, questCopy.Description, questGiverName, rewardSummary, tasks),
+		Question: prompt.Text,
 	})
 	if err != nil {
 		return nil, err
@@ -212,7 +181,7 @@ func (c *client) generateQuestAcceptanceDialogue(
 func (c *client) generateQuestImage(ctx context.Context, questCopy QuestCopy) (string, error) {
 	log.Printf("Generating quest image for quest: %s", questCopy.Name)
 
-	prompt, err := c.generateQuestImagePrompt(questCopy)
+	prompt, err := c.generateQuestImagePrompt(ctx, questCopy)
 	if err != nil {
 		log.Printf("Error generating quest image prompt: %v", err)
 		return "", err
@@ -240,12 +209,17 @@ func (c *client) generateQuestImage(ctx context.Context, questCopy QuestCopy) (s
 	return imageUrl, nil
 }
 
-func (c *client) generateQuestImagePrompt(questCopy QuestCopy) (string, error) {
+func (c *client) generateQuestImagePrompt(ctx context.Context, questCopy QuestCopy) (string, error) {
+	prompt, err := db.ResolvePrompt(ctx, c.dbClient, models.PromptKeyQuestImagePrompt, nil, "", map[string]interface{}{
+		"Quest": fmt.Sprintf("%s\n\n%s", questCopy.Name, questCopy.Description),
+	})
+	if err != nil {
+		log.Printf("Error resolving quest image prompt: %v", err)
+		return "", err
+	}
+
 	answer, err := c.deepPriest.PetitionTheFount(&deep_priest.Question{
-		Question: fmt.Sprintf(
-			generateQuestImagePromptTemplate,
-			fmt.Sprintf("%s\n\n%s", questCopy.Name, questCopy.Description),
-		),
+		Question: prompt.Text,
 	})
 	if err != nil {
 		log.Printf("Error getting response from DeepPriest: %v", err)
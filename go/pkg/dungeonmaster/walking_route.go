@@ -0,0 +1,312 @@
+package dungeonmaster
+
+import (
+	"container/heap"
+	"context"
+	"log"
+	"math"
+	"sort"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/MaxBlaushild/poltergeist/pkg/util"
+	"github.com/google/uuid"
+)
+
+const (
+	// questRouteSnapMaxMeters is how far a quest location may sit from the
+	// walking graph before routing falls back to the straight-line estimate.
+	questRouteSnapMaxMeters = 300.0
+	// questRouteGraphMarginMeters pads the zone when loading the graph so
+	// routes can use streets just outside it.
+	questRouteGraphMarginMeters = 800.0
+	// questRouteDetourFactor approximates street distance from straight-line
+	// distance where no walking graph is available.
+	questRouteDetourFactor = 1.3
+	// questRouteBacktrackToleranceMeters is how far a leg may double back
+	// toward earlier quest locations before it counts as backtracking.
+	questRouteBacktrackToleranceMeters = 75.0
+)
+
+type walkingRouterEdge struct {
+	to     int
+	meters float64
+}
+
+// walkingRouter answers walking distances over an in-memory slice of the
+// pedestrian graph. Shortest-path trees are cached per source node since a
+// quest routes from the same anchor to many candidates.
+type walkingRouter struct {
+	latitudes  []float64
+	longitudes []float64
+	adjacency  [][]walkingRouterEdge
+	cache      map[int][]float64
+}
+
+func newWalkingRouter(graph *models.WalkingGraph) *walkingRouter {
+	if graph == nil || len(graph.Edges) == 0 || len(graph.Nodes) == 0 {
+		return nil
+	}
+	router := &walkingRouter{
+		latitudes:  make([]float64, 0, len(graph.Nodes)),
+		longitudes: make([]float64, 0, len(graph.Nodes)),
+		adjacency:  make([][]walkingRouterEdge, len(graph.Nodes)),
+		cache:      map[int][]float64{},
+	}
+	indexes := make(map[int64]int, len(graph.Nodes))
+	for _, node := range graph.Nodes {
+		indexes[node.ID] = len(router.latitudes)
+		router.latitudes = append(router.latitudes, node.Latitude)
+		router.longitudes = append(router.longitudes, node.Longitude)
+	}
+	for _, edge := range graph.Edges {
+		from, okFrom := indexes[edge.FromNodeID]
+		to, okTo := indexes[edge.ToNodeID]
+		if !okFrom || !okTo {
+			continue
+		}
+		router.adjacency[from] = append(router.adjacency[from], walkingRouterEdge{to: to, meters: edge.LengthMeters})
+		router.adjacency[to] = append(router.adjacency[to], walkingRouterEdge{to: from, meters: edge.LengthMeters})
+	}
+	return router
+}
+
+func (r *walkingRouter) nearest(latitude float64, longitude float64) (int, float64) {
+	best := -1
+	bestMeters := math.Inf(1)
+	for i := range r.latitudes {
+		meters := util.HaversineDistance(latitude, longitude, r.latitudes[i], r.longitudes[i])
+		if meters < bestMeters {
+			best = i
+			bestMeters = meters
+		}
+	}
+	return best, bestMeters
+}
+
+type walkingRouterQueueItem struct {
+	node   int
+	meters float64
+}
+
+type walkingRouterQueue []walkingRouterQueueItem
+
+func (q walkingRouterQueue) Len() int            { return len(q) }
+func (q walkingRouterQueue) Less(i, j int) bool  { return q[i].meters < q[j].meters }
+func (q walkingRouterQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *walkingRouterQueue) Push(x interface{}) { *q = append(*q, x.(walkingRouterQueueItem)) }
+func (q *walkingRouterQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+func (r *walkingRouter) distancesFrom(source int) []float64 {
+	if distances, ok := r.cache[source]; ok {
+		return distances
+	}
+	distances := make([]float64, len(r.latitudes))
+	for i := range distances {
+		distances[i] = math.Inf(1)
+	}
+	distances[source] = 0
+	queue := &walkingRouterQueue{{node: source}}
+	for queue.Len() > 0 {
+		item := heap.Pop(queue).(walkingRouterQueueItem)
+		if item.meters > distances[item.node] {
+			continue
+		}
+		for _, edge := range r.adjacency[item.node] {
+			next := item.meters + edge.meters
+			if next < distances[edge.to] {
+				distances[edge.to] = next
+				heap.Push(queue, walkingRouterQueueItem{node: edge.to, meters: next})
+			}
+		}
+	}
+	r.cache[source] = distances
+	return distances
+}
+
+// walkingMeters returns the walking distance between two points, including
+// the walk to and from the graph. ok is false when either point is too far
+// from the graph to route; +Inf means the graph has no path between them.
+func (r *walkingRouter) walkingMeters(fromLat, fromLng, toLat, toLng float64) (float64, bool) {
+	from, fromSnap := r.nearest(fromLat, fromLng)
+	to, toSnap := r.nearest(toLat, toLng)
+	if from < 0 || to < 0 || fromSnap > questRouteSnapMaxMeters || toSnap > questRouteSnapMaxMeters {
+		return 0, false
+	}
+	return fromSnap + r.distancesFrom(from)[to] + toSnap, true
+}
+
+// questRoutePlan tracks the walk a quest asks of the player while its nodes
+// are placed: the legs walked so far, the archetype's budget, and the
+// locations already visited so later picks avoid doubling back.
+type questRoutePlan struct {
+	router       *walkingRouter
+	budgetMeters float64
+	start        *questNodeAnchor
+	visited      []questNodeAnchor
+	totalMeters  float64
+}
+
+func (c *client) newQuestRoutePlan(
+	ctx context.Context,
+	zone *models.Zone,
+	questArchetype *models.QuestArchetype,
+	quest *models.Quest,
+) *questRoutePlan {
+	plan := &questRoutePlan{budgetMeters: questArchetype.WalkingBudgetMeters()}
+	start, err := c.resolveQuestGiverAnchor(ctx, quest)
+	if err != nil {
+		log.Printf("[quest-generation][walking] failed to resolve quest giver anchor quest=%s err=%v", quest.ID, err)
+	}
+	plan.start = start
+
+	boundary := zone.GetBoundary()
+	if len(boundary) == 0 {
+		return plan
+	}
+	minLat, minLng := boundary[0].Latitude, boundary[0].Longitude
+	maxLat, maxLng := minLat, minLng
+	for _, point := range boundary[1:] {
+		minLat = math.Min(minLat, point.Latitude)
+		maxLat = math.Max(maxLat, point.Latitude)
+		minLng = math.Min(minLng, point.Longitude)
+		maxLng = math.Max(maxLng, point.Longitude)
+	}
+	latMargin := questRouteGraphMarginMeters / 111320.0
+	lngMargin := latMargin / math.Max(math.Cos((minLat+maxLat)/2*math.Pi/180), 0.01)
+	graph, err := c.dbClient.WalkingGraph().FindWithin(
+		ctx,
+		minLat-latMargin,
+		minLng-lngMargin,
+		maxLat+latMargin,
+		maxLng+lngMargin,
+	)
+	if err != nil {
+		log.Printf("[quest-generation][walking] failed to load walking graph zone=%s err=%v", zone.ID, err)
+		return plan
+	}
+	plan.router = newWalkingRouter(graph)
+	return plan
+}
+
+func (p *questRoutePlan) origin(reference *questNodeAnchor) *questNodeAnchor {
+	if reference != nil {
+		return reference
+	}
+	return p.start
+}
+
+func (p *questRoutePlan) remainingMeters() float64 {
+	if p.budgetMeters <= 0 {
+		return math.Inf(1)
+	}
+	return p.budgetMeters - p.totalMeters
+}
+
+func (p *questRoutePlan) legMeters(from *questNodeAnchor, to *questNodeAnchor) float64 {
+	if from == nil || to == nil {
+		return 0
+	}
+	if p.router != nil {
+		if meters, ok := p.router.walkingMeters(from.Latitude, from.Longitude, to.Latitude, to.Longitude); ok {
+			return meters
+		}
+	}
+	return questRouteDetourFactor * util.HaversineDistance(from.Latitude, from.Longitude, to.Latitude, to.Longitude)
+}
+
+// backtrackMeters is how much closer a leg brings the player to a location
+// they already passed through before the leg's start.
+func (p *questRoutePlan) backtrackMeters(from *questNodeAnchor, to *questNodeAnchor) float64 {
+	if from == nil || to == nil {
+		return 0
+	}
+	backtrack := 0.0
+	for _, visited := range p.visited {
+		if visited.Latitude == from.Latitude && visited.Longitude == from.Longitude {
+			continue
+		}
+		before := util.HaversineDistance(visited.Latitude, visited.Longitude, from.Latitude, from.Longitude)
+		after := util.HaversineDistance(visited.Latitude, visited.Longitude, to.Latitude, to.Longitude)
+		backtrack = math.Max(backtrack, before-after)
+	}
+	return backtrack
+}
+
+func (p *questRoutePlan) fits(reference *questNodeAnchor, to *questNodeAnchor) bool {
+	return p.legMeters(p.origin(reference), to) <= p.remainingMeters()
+}
+
+func (p *questRoutePlan) record(reference *questNodeAnchor, to *questNodeAnchor) {
+	if to == nil {
+		return
+	}
+	leg := p.legMeters(p.origin(reference), to)
+	if math.IsInf(leg, 0) {
+		from := p.origin(reference)
+		leg = questRouteDetourFactor * util.HaversineDistance(from.Latitude, from.Longitude, to.Latitude, to.Longitude)
+	}
+	p.totalMeters += leg
+	p.visited = append(p.visited, *to)
+}
+
+// selectPointOfInterest picks an unused candidate whose walk from the
+// reference fits the remaining budget. Candidates that don't double back
+// come first; within those, closest mode takes the shortest walk and other
+// modes keep the seeder's order.
+func (p *questRoutePlan) selectPointOfInterest(
+	pointsOfInterest []*models.PointOfInterest,
+	usedPOIs map[uuid.UUID]bool,
+	reference *questNodeAnchor,
+	selectionMode models.QuestArchetypeNodeLocationSelectionMode,
+) *models.PointOfInterest {
+	type candidate struct {
+		poi          *models.PointOfInterest
+		legMeters    float64
+		backtracking bool
+	}
+	from := p.origin(reference)
+	remaining := p.remainingMeters()
+	candidates := make([]candidate, 0, len(pointsOfInterest))
+	for _, poi := range pointsOfInterest {
+		if poi == nil || usedPOIs[poi.ID] {
+			continue
+		}
+		latitude, longitude, err := pointOfInterestCoordinates(poi)
+		if err != nil {
+			continue
+		}
+		to := &questNodeAnchor{Latitude: latitude, Longitude: longitude}
+		leg := p.legMeters(from, to)
+		if leg > remaining {
+			continue
+		}
+		candidates = append(candidates, candidate{
+			poi:          poi,
+			legMeters:    leg,
+			backtracking: p.backtrackMeters(from, to) > questRouteBacktrackToleranceMeters,
+		})
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		iReachable := !math.IsInf(candidates[i].legMeters, 1)
+		jReachable := !math.IsInf(candidates[j].legMeters, 1)
+		if iReachable != jReachable {
+			return iReachable
+		}
+		if candidates[i].backtracking != candidates[j].backtracking {
+			return !candidates[i].backtracking
+		}
+		if selectionMode == models.QuestArchetypeNodeLocationSelectionModeClosest {
+			return candidates[i].legMeters < candidates[j].legMeters
+		}
+		return false
+	})
+	return candidates[0].poi
+}
@@ -0,0 +1,120 @@
+package dungeonmaster
+
+import (
+	"math"
+	"testing"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/google/uuid"
+)
+
+// riverWalkingGraph has two banks 100m apart joined only by a bridge 1km
+// upstream: 1 -> 2 -> 3 (bridge) -> 4 -> 5.
+func riverWalkingGraph() *models.WalkingGraph {
+	return &models.WalkingGraph{
+		Nodes: []models.WalkingGraphNode{
+			{ID: 1, Latitude: 40.0000, Longitude: -73.0000},
+			{ID: 2, Latitude: 40.0090, Longitude: -73.0000},
+			{ID: 3, Latitude: 40.0090, Longitude: -73.0012},
+			{ID: 4, Latitude: 40.0000, Longitude: -73.0012},
+		},
+		Edges: []models.WalkingGraphEdge{
+			{FromNodeID: 1, ToNodeID: 2, LengthMeters: 1000},
+			{FromNodeID: 2, ToNodeID: 3, LengthMeters: 100},
+			{FromNodeID: 3, ToNodeID: 4, LengthMeters: 1000},
+		},
+	}
+}
+
+func TestQuestRoutePlanWalksAroundGaps(t *testing.T) {
+	plan := &questRoutePlan{router: newWalkingRouter(riverWalkingGraph())}
+	meters := plan.legMeters(
+		&questNodeAnchor{Latitude: 40.0000, Longitude: -73.0000},
+		&questNodeAnchor{Latitude: 40.0000, Longitude: -73.0012},
+	)
+	if math.Abs(meters-2100) > 1 {
+		t.Fatalf("expected the walk to cross at the bridge (2100m), got %.1f", meters)
+	}
+}
+
+func TestQuestRoutePlanFallsBackToStraightLineOffGraph(t *testing.T) {
+	plan := &questRoutePlan{router: newWalkingRouter(riverWalkingGraph())}
+	meters := plan.legMeters(
+		&questNodeAnchor{Latitude: 41.0000, Longitude: -73.0000},
+		&questNodeAnchor{Latitude: 41.0010, Longitude: -73.0000},
+	)
+	if meters < 100 || meters > 200 {
+		t.Fatalf("expected a detoured straight-line estimate, got %.1f", meters)
+	}
+}
+
+func TestQuestRoutePlanSelectPointOfInterestRespectsBudget(t *testing.T) {
+	acrossID := uuid.New()
+	plan := &questRoutePlan{
+		router:       newWalkingRouter(riverWalkingGraph()),
+		budgetMeters: 1500,
+	}
+	selected := plan.selectPointOfInterest(
+		[]*models.PointOfInterest{
+			{ID: acrossID, Name: "Across", Lat: "40.0000", Lng: "-73.0012"},
+		},
+		map[uuid.UUID]bool{},
+		&questNodeAnchor{Latitude: 40.0000, Longitude: -73.0000},
+		models.QuestArchetypeNodeLocationSelectionModeClosest,
+	)
+	if selected != nil {
+		t.Fatalf("expected no point of interest within the walking budget, got %s", selected.Name)
+	}
+
+	plan.budgetMeters = 2500
+	selected = plan.selectPointOfInterest(
+		[]*models.PointOfInterest{
+			{ID: acrossID, Name: "Across", Lat: "40.0000", Lng: "-73.0012"},
+		},
+		map[uuid.UUID]bool{},
+		&questNodeAnchor{Latitude: 40.0000, Longitude: -73.0000},
+		models.QuestArchetypeNodeLocationSelectionModeClosest,
+	)
+	if selected == nil || selected.ID != acrossID {
+		t.Fatalf("expected the point of interest across the bridge to fit a 2500m budget")
+	}
+}
+
+func TestQuestRoutePlanSelectPointOfInterestAvoidsBacktracking(t *testing.T) {
+	backID := uuid.New()
+	aheadID := uuid.New()
+	plan := &questRoutePlan{}
+	start := &questNodeAnchor{Latitude: 40.0000, Longitude: -73.0000}
+	current := &questNodeAnchor{Latitude: 40.0050, Longitude: -73.0000}
+	plan.record(nil, start)
+	plan.record(start, current)
+
+	selected := plan.selectPointOfInterest(
+		[]*models.PointOfInterest{
+			{ID: backID, Name: "Back", Lat: "40.0030", Lng: "-73.0000"},
+			{ID: aheadID, Name: "Ahead", Lat: "40.0080", Lng: "-73.0000"},
+		},
+		map[uuid.UUID]bool{},
+		current,
+		models.QuestArchetypeNodeLocationSelectionModeClosest,
+	)
+	if selected == nil || selected.ID != aheadID {
+		t.Fatalf("expected the point of interest ahead to win over the closer one behind")
+	}
+}
+
+func TestQuestRoutePlanRecordAccumulatesLegs(t *testing.T) {
+	plan := &questRoutePlan{router: newWalkingRouter(riverWalkingGraph())}
+	first := &questNodeAnchor{Latitude: 40.0000, Longitude: -73.0000}
+	second := &questNodeAnchor{Latitude: 40.0090, Longitude: -73.0000}
+	third := &questNodeAnchor{Latitude: 40.0090, Longitude: -73.0012}
+	plan.record(nil, first)
+	plan.record(first, second)
+	plan.record(second, third)
+	if math.Abs(plan.totalMeters-1100) > 1 {
+		t.Fatalf("expected 1100m walked, got %.1f", plan.totalMeters)
+	}
+	if len(plan.visited) != 3 {
+		t.Fatalf("expected 3 visited anchors, got %d", len(plan.visited))
+	}
+}
@@ -3,6 +3,7 @@ package ethereum
 import (
 	"context"
 	"crypto/ecdsa"
+	"errors"
 	"fmt"
 	"math/big"
 	"strings"
@@ -16,16 +17,34 @@ import (
 )
 
 type TransactionStatus struct {
-	Status      string     // "pending", "confirmed", "failed"
-	BlockNumber *uint64    // Block number if confirmed
-	ConfirmedAt *time.Time // Confirmation time if confirmed
+	Status      string       // "pending", "confirmed", "failed", "not_found"
+	BlockNumber *uint64      // Block number if confirmed
+	BlockHash   *common.Hash // Block hash if confirmed
+	ConfirmedAt *time.Time   // Confirmation time if confirmed
 }
 
+// Fees are EIP-1559 fees per gas: the tip paid to the block's proposer and
+// the most the transaction pays in total, base fee included.
+type Fees struct {
+	GasTipCap *big.Int
+	GasFeeCap *big.Int
+}
+
+// ErrDynamicFeesUnsupported is returned by SuggestFees on chains without a
+// base fee, which only take legacy transactions.
+var ErrDynamicFeesUnsupported = errors.New("chain does not support EIP-1559 fees")
+
 type EthereumClient interface {
 	SendTransaction(ctx context.Context, to *common.Address, value *big.Int, data []byte, gasLimit uint64, gasPrice *big.Int, nonce uint64) (common.Hash, error)
+	SendDynamicFeeTransaction(ctx context.Context, to *common.Address, value *big.Int, data []byte, gasLimit uint64, fees *Fees, nonce uint64) (common.Hash, error)
+	SuggestFees(ctx context.Context) (*Fees, error)
+	SuggestGasPrice(ctx context.Context) (*big.Int, error)
 	EstimateGas(ctx context.Context, to *common.Address, value *big.Int, data []byte) (uint64, error)
 	GetTransactionStatus(ctx context.Context, txHash common.Hash) (*TransactionStatus, error)
 	GetPendingNonce(ctx context.Context, address common.Address) (uint64, error)
+	GetConfirmedNonce(ctx context.Context, address common.Address) (uint64, error)
+	GetBlockNumber(ctx context.Context) (uint64, error)
+	GetBlockHash(ctx context.Context, blockNumber uint64) (common.Hash, error)
 	GetAddress() common.Address
 }
 
@@ -132,6 +151,12 @@ func (c *client) SendTransaction(ctx context.Context, to *common.Address, value
 
 func (c *client) GetTransactionStatus(ctx context.Context, txHash common.Hash) (*TransactionStatus, error) {
 	_, isPending, err := c.ethClient.TransactionByHash(ctx, txHash)
+	if errors.Is(err, ethereum.NotFound) {
+		// The node hasn't seen the transaction, or has dropped it
+		return &TransactionStatus{
+			Status: "not_found",
+		}, nil
+	}
 	if err != nil {
 		// Transaction might not exist yet
 		return &TransactionStatus{
@@ -163,11 +188,13 @@ func (c *client) GetTransactionStatus(ctx context.Context, txHash common.Hash) (
 	}
 
 	blockNumber := receipt.BlockNumber.Uint64()
+	blockHash := receipt.BlockHash
 
 	if receipt.Status == 0 {
 		return &TransactionStatus{
 			Status:      "failed",
 			BlockNumber: &blockNumber,
+			BlockHash:   &blockHash,
 			ConfirmedAt: &confirmedAt,
 		}, nil
 	}
@@ -175,6 +202,7 @@ func (c *client) GetTransactionStatus(ctx context.Context, txHash common.Hash) (
 	return &TransactionStatus{
 		Status:      "confirmed",
 		BlockNumber: &blockNumber,
+		BlockHash:   &blockHash,
 		ConfirmedAt: &confirmedAt,
 	}, nil
 }
@@ -205,3 +233,82 @@ func (c *client) GetPendingNonce(ctx context.Context, address common.Address) (u
 	}
 	return nonce, nil
 }
+
+func (c *client) GetConfirmedNonce(ctx context.Context, address common.Address) (uint64, error) {
+	nonce, err := c.ethClient.NonceAt(ctx, address, nil)
+	if err != nil {
+		return 0, err
+	}
+	return nonce, nil
+}
+
+func (c *client) GetBlockNumber(ctx context.Context) (uint64, error) {
+	return c.ethClient.BlockNumber(ctx)
+}
+
+// GetBlockHash returns the hash of the canonical block at blockNumber, which
+// changes if the block is reorged out.
+func (c *client) GetBlockHash(ctx context.Context, blockNumber uint64) (common.Hash, error) {
+	header, err := c.ethClient.HeaderByNumber(ctx, new(big.Int).SetUint64(blockNumber))
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return header.Hash(), nil
+}
+
+// SuggestFees suggests EIP-1559 fees for the next block. The fee cap leaves
+// room for the base fee to double before the transaction is priced out.
+func (c *client) SuggestFees(ctx context.Context) (*Fees, error) {
+	header, err := c.ethClient.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	if header.BaseFee == nil {
+		return nil, ErrDynamicFeesUnsupported
+	}
+
+	gasTipCap, err := c.ethClient.SuggestGasTipCap(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	gasFeeCap := new(big.Int).Mul(header.BaseFee, big.NewInt(2))
+	gasFeeCap.Add(gasFeeCap, gasTipCap)
+
+	return &Fees{
+		GasTipCap: gasTipCap,
+		GasFeeCap: gasFeeCap,
+	}, nil
+}
+
+// SuggestGasPrice returns the node's suggested gas price for legacy transactions.
+func (c *client) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	return c.ethClient.SuggestGasPrice(ctx)
+}
+
+func (c *client) SendDynamicFeeTransaction(ctx context.Context, to *common.Address, value *big.Int, data []byte, gasLimit uint64, fees *Fees, nonce uint64) (common.Hash, error) {
+	if c.privateKey == nil {
+		return common.Hash{}, fmt.Errorf("private key not set, cannot send transactions")
+	}
+
+	tx := types.NewTx(&types.DynamicFeeTx{
+		ChainID:   c.chainID,
+		Nonce:     nonce,
+		GasTipCap: fees.GasTipCap,
+		GasFeeCap: fees.GasFeeCap,
+		Gas:       gasLimit,
+		To:        to,
+		Value:     value,
+		Data:      data,
+	})
+	signedTx, err := types.SignTx(tx, types.LatestSignerForChainID(c.chainID), c.privateKey)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	if err := c.ethClient.SendTransaction(ctx, signedTx); err != nil {
+		return common.Hash{}, err
+	}
+
+	return signedTx.Hash(), nil
+}
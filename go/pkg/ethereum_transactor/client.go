@@ -10,6 +10,7 @@ import (
 
 type Client interface {
 	CreateTransaction(ctx context.Context, req CreateTransactionRequest) (*CreateTransactionResponse, error)
+	QueueManifestAnchor(ctx context.Context, req QueueManifestAnchorRequest) error
 }
 
 type client struct {
@@ -51,3 +52,17 @@ func (c *client) CreateTransaction(ctx context.Context, req CreateTransactionReq
 
 	return &resp, nil
 }
+
+// QueueManifestAnchorRequest identifies a manifest to anchor in the next
+// batch. Both fields are hex encoded 32 byte hashes.
+type QueueManifestAnchorRequest struct {
+	ManifestHash    string `json:"manifestHash"`
+	CertFingerprint string `json:"certFingerprint"`
+}
+
+func (c *client) QueueManifestAnchor(ctx context.Context, req QueueManifestAnchorRequest) error {
+	if _, err := c.httpClient.Post(ctx, "/ethereum-transactor/manifest-anchors", req); err != nil {
+		return fmt.Errorf("failed to queue manifest anchor: %w", err)
+	}
+	return nil
+}
@@ -40,6 +40,8 @@ const (
 	GenerateScenarioTemplatesTaskType                  = "generate_scenario_templates"
 	GenerateChallengeTemplatesTaskType                 = "generate_challenge_templates"
 	GenerateShrineTemplatesTaskType                    = "generate_shrine_templates"
+	RunPromptEvaluationTaskType                        = "run_prompt_evaluation"
+	TranslateContentTaskType                           = "translate_content"
 	GenerateLocationArchetypesTaskType                 = "generate_location_archetypes"
 	GenerateQuestArchetypeSuggestionsTaskType          = "generate_quest_archetype_suggestions"
 	GenerateMainStorySuggestionsTaskType               = "generate_main_story_suggestions"
@@ -58,6 +60,7 @@ const (
 	ProcessRecurringQuestsTaskType                     = "process_recurring_quests"
 	ProcessRecurringStandaloneContentTaskType          = "process_recurring_standalone_content"
 	CleanupOrphanedQuestActionsTaskType                = "cleanup_orphaned_quest_actions"
+	ExpirePlayerTradesTaskType                         = "expire_player_trades"
 	CheckBlockchainTransactionsTaskType                = "check_blockchain_transactions"
 	ImportPointOfInterestTaskType                      = "import_point_of_interest"
 	ImportZonesForMetroTaskType                        = "import_zones_for_metro"
@@ -67,6 +70,7 @@ const (
 	ApplyZoneSeedDraftTaskType                         = "apply_zone_seed_draft"
 	ShuffleZoneSeedChallengeTaskType                   = "shuffle_zone_seed_challenge"
 	BackfillContentZoneKindsTaskType                   = "backfill_content_zone_kinds"
+	BackfillAchievementsTaskType                       = "backfill_achievements"
 
 	// reef-site (R-2.10). Preview generation is synchronous in the reef-site
 	// HTTP handler itself (R-2.10's explicit carve-out: "must not block an
@@ -529,6 +533,20 @@ type GenerateShrineTemplatesTaskPayload struct {
 	JobID uuid.UUID `json:"jobId"`
 }
 
+type RunPromptEvaluationTaskPayload struct {
+	EvaluationID uuid.UUID `json:"evaluationId"`
+}
+
+// TranslateContentTaskPayload names one entity to machine-translate. With no
+// locales, every enabled machine-translated locale is filled in.
+type TranslateContentTaskPayload struct {
+	EntityType string   `json:"entityType"`
+	EntityID   string   `json:"entityId"`
+	Locales    []string `json:"locales,omitempty"`
+	// Force retranslates fields whose machine translation is still current.
+	Force bool `json:"force,omitempty"`
+}
+
 type GenerateZoneFlavorTaskPayload struct {
 	JobID uuid.UUID `json:"jobId"`
 }
@@ -571,6 +589,10 @@ type BackfillContentZoneKindsTaskPayload struct {
 	JobID uuid.UUID `json:"jobId"`
 }
 
+type BackfillAchievementsTaskPayload struct {
+	AchievementID *uuid.UUID `json:"achievementId,omitempty"`
+}
+
 func MonsterTemplateBulkStatusKey(jobID uuid.UUID) string {
 	return fmt.Sprintf("admin:monster-templates:bulk:%s", jobID.String())
 }
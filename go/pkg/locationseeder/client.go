@@ -50,6 +50,7 @@ type Client interface {
 	RefreshPointOfInterestImage(ctx context.Context, poi *models.PointOfInterest) error
 	RefreshPointOfInterest(ctx context.Context, poi *models.PointOfInterest) error
 	ImportPlace(ctx context.Context, placeID string, zone models.Zone, genre *models.ZoneGenre) (*models.PointOfInterest, error)
+	SeedPointsOfInterestFromOSM(ctx context.Context, zone models.Zone, places []OSMPlace, includedTypes []googlemaps.PlaceType, excludedTypes []googlemaps.PlaceType, numberOfPlaces int32, genre *models.ZoneGenre) ([]*models.PointOfInterest, error)
 }
 
 func NewClient(
@@ -187,7 +188,7 @@ func (c *client) RefreshPointOfInterest(ctx context.Context, poi *models.PointOf
 		return err
 	}
 
-	generatedPointOfInterest, err := c.generatePointOfInterestTheming(ctx, *place, zone, genre)
+	generatedPointOfInterest, themingPrompt, err := c.generatePointOfInterestTheming(ctx, *place, zone, genre)
 	if err != nil {
 		log.Printf("Error generating point of interest theming: %v", err)
 		return err
@@ -233,6 +234,7 @@ func (c *client) RefreshPointOfInterest(ctx context.Context, poi *models.PointOf
 		log.Printf("Error updating point of interest: %v", err)
 		return err
 	}
+	db.RecordGeneratedPrompt(ctx, c.dbClient, themingPrompt, models.GeneratedRecordTypePointOfInterest, poi.ID)
 
 	tags, err := c.ProccessPlaceTypes(ctx, place.Types)
 	if err != nil {
@@ -560,6 +562,18 @@ func (c *client) SeedPointsOfInterest(ctx context.Context, zone models.Zone, inc
 			continue
 		}
 
+		placeID := place.ID
+		linkedPointOfInterest, err := c.linkNearbyPointOfInterest(ctx, place.DisplayName.Text, place.Location.Latitude, place.Location.Longitude, zone, &placeID, nil)
+		if err != nil {
+			log.Printf("Error linking place %s to a nearby point of interest: %v", place.Name, err)
+			return nil, err
+		}
+		if linkedPointOfInterest != nil {
+			log.Printf("Linked place %s to existing point of interest %s", place.Name, linkedPointOfInterest.ID)
+			pointsOfInterest = append(pointsOfInterest, linkedPointOfInterest)
+			continue
+		}
+
 		poi, err := c.GeneratePointOfInterest(ctx, place, &zone, genre)
 		if err != nil {
 			log.Printf("Error generating point of interest for place %s: %v", place.Name, err)
@@ -585,7 +599,23 @@ func (c *client) GeneratePointOfInterest(ctx context.Context, place googlemaps.P
 	}
 
 	place = *placeDetails
+	placeID := place.ID
 
+	return c.createPointOfInterestForPlace(ctx, place, zone, genre, pointOfInterestProvenance{
+		source:            models.PointOfInterestSourceGoogle,
+		googleMapsPlaceID: &placeID,
+	})
+}
+
+// pointOfInterestProvenance says where a generated point of interest came
+// from; exactly one of the IDs is set.
+type pointOfInterestProvenance struct {
+	source            models.PointOfInterestSource
+	googleMapsPlaceID *string
+	osmID             *string
+}
+
+func (c *client) createPointOfInterestForPlace(ctx context.Context, place googlemaps.Place, zone *models.Zone, genre *models.ZoneGenre, provenance pointOfInterestProvenance) (*models.PointOfInterest, error) {
 	log.Printf("Starting to generate point of interest for place: %s", place.Name)
 
 	resolvedGenre, err := c.resolvePointOfInterestGenre(ctx, genre, uuid.Nil)
@@ -594,7 +624,7 @@ func (c *client) GeneratePointOfInterest(ctx context.Context, place googlemaps.P
 		return nil, err
 	}
 
-	generatedPointOfInterest, err := c.generatePointOfInterestTheming(ctx, place, zone, resolvedGenre)
+	generatedPointOfInterest, themingPrompt, err := c.generatePointOfInterestTheming(ctx, place, zone, resolvedGenre)
 	if err != nil {
 		log.Printf("Error generating point of interest theming: %v", err)
 		return nil, err
@@ -617,9 +647,11 @@ func (c *client) GeneratePointOfInterest(ctx context.Context, place googlemaps.P
 		ImageUrl:          imageUrl,
 		GenreID:           resolvedGenre.ID,
 		MarkerCategory:    pointOfInterestMarkerCategoryForPlace(place),
-		GoogleMapsPlaceID: &place.ID,
+		Source:            provenance.source,
+		GoogleMapsPlaceID: provenance.googleMapsPlaceID,
+		OSMID:             provenance.osmID,
 		GoogleMapsPlaceName: func() *string {
-			if place.DisplayName.Text == "" {
+			if provenance.googleMapsPlaceID == nil || place.DisplayName.Text == "" {
 				return nil
 			}
 			name := place.DisplayName.Text
@@ -644,6 +676,7 @@ func (c *client) GeneratePointOfInterest(ctx context.Context, place googlemaps.P
 		return nil, err
 	}
 	log.Printf("Successfully created point of interest in database")
+	db.RecordGeneratedPrompt(ctx, c.dbClient, themingPrompt, models.GeneratedRecordTypePointOfInterest, poi.ID)
 
 	for _, tag := range tags {
 		log.Printf("Adding tag %s to point of interest", tag.Value)
@@ -0,0 +1,229 @@
+package locationseeder
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/googlemaps"
+)
+
+// OSMPlace is a named OSM node or way that maps onto at least one Google
+// place type. ID is the OSM element reference, e.g. "node/123" or "way/45".
+type OSMPlace struct {
+	ID       string            `json:"id"`
+	Name     string            `json:"name"`
+	Location OSMCoord          `json:"location"`
+	Types    []string          `json:"types"`
+	Tags     map[string]string `json:"tags,omitempty"`
+}
+
+// OSMBoundary is a neighborhood-scale boundary. Outer holds one line per
+// member way; a boundary drawn as a single closed way has one line.
+type OSMBoundary struct {
+	ID    string       `json:"id"`
+	Name  string       `json:"name"`
+	Outer [][]OSMCoord `json:"outer"`
+}
+
+type OSMExtract struct {
+	Places     []OSMPlace    `json:"places"`
+	Boundaries []OSMBoundary `json:"boundaries"`
+}
+
+func osmElementID(elementType string, id int64) string {
+	return fmt.Sprintf("%s/%d", elementType, id)
+}
+
+func isOSMNeighborhoodBoundary(tags map[string]string) bool {
+	switch tags["place"] {
+	case "neighbourhood", "neighborhood", "suburb", "quarter":
+		return true
+	}
+	return tags["boundary"] == "administrative" && tags["admin_level"] == "10"
+}
+
+func osmPlaceName(tags map[string]string) string {
+	if name := strings.TrimSpace(tags["name"]); name != "" {
+		return name
+	}
+	return strings.TrimSpace(tags["name:en"])
+}
+
+func osmPlaceTypeStrings(tags map[string]string) []string {
+	placeTypes := OSMPlaceTypes(tags)
+	if len(placeTypes) == 0 {
+		return nil
+	}
+	types := make([]string, len(placeTypes))
+	for i, placeType := range placeTypes {
+		types[i] = string(placeType)
+	}
+	return types
+}
+
+func osmCentroid(coords []OSMCoord) (OSMCoord, bool) {
+	if len(coords) == 0 {
+		return OSMCoord{}, false
+	}
+	// Closed ways repeat their first node; don't count it twice.
+	if len(coords) > 1 && coords[0] == coords[len(coords)-1] {
+		coords = coords[:len(coords)-1]
+	}
+	var centroid OSMCoord
+	for _, coord := range coords {
+		centroid.Lat += coord.Lat
+		centroid.Lng += coord.Lng
+	}
+	centroid.Lat /= float64(len(coords))
+	centroid.Lng /= float64(len(coords))
+	return centroid, true
+}
+
+// ExtractOSM picks seedable places and neighborhood boundaries out of a
+// decoded extract. Results are sorted by ID so repeated runs are stable.
+func ExtractOSM(data *OSMData) *OSMExtract {
+	extract := &OSMExtract{}
+	waysByID := make(map[int64]OSMWay, len(data.Ways))
+
+	for _, node := range data.Nodes {
+		if isOSMNeighborhoodBoundary(node.Tags) {
+			continue
+		}
+		name := osmPlaceName(node.Tags)
+		types := osmPlaceTypeStrings(node.Tags)
+		if name == "" || len(types) == 0 {
+			continue
+		}
+		extract.Places = append(extract.Places, OSMPlace{
+			ID:       osmElementID("node", node.ID),
+			Name:     name,
+			Location: node.Coord,
+			Types:    types,
+			Tags:     node.Tags,
+		})
+	}
+
+	for _, way := range data.Ways {
+		waysByID[way.ID] = way
+		if len(way.Tags) == 0 {
+			continue
+		}
+		name := osmPlaceName(way.Tags)
+		if name == "" {
+			continue
+		}
+		coords := data.WayCoords(way)
+		if isOSMNeighborhoodBoundary(way.Tags) {
+			if len(coords) >= 4 && coords[0] == coords[len(coords)-1] {
+				extract.Boundaries = append(extract.Boundaries, OSMBoundary{
+					ID:    osmElementID("way", way.ID),
+					Name:  name,
+					Outer: [][]OSMCoord{coords},
+				})
+			}
+			continue
+		}
+		types := osmPlaceTypeStrings(way.Tags)
+		if len(types) == 0 {
+			continue
+		}
+		centroid, ok := osmCentroid(coords)
+		if !ok {
+			continue
+		}
+		extract.Places = append(extract.Places, OSMPlace{
+			ID:       osmElementID("way", way.ID),
+			Name:     name,
+			Location: centroid,
+			Types:    types,
+			Tags:     way.Tags,
+		})
+	}
+
+	for _, relation := range data.Relations {
+		if !isOSMNeighborhoodBoundary(relation.Tags) {
+			continue
+		}
+		name := osmPlaceName(relation.Tags)
+		if name == "" {
+			continue
+		}
+		boundary := OSMBoundary{ID: osmElementID("relation", relation.ID), Name: name}
+		for _, member := range relation.Members {
+			if member.Type != "way" || (member.Role != "outer" && member.Role != "") {
+				continue
+			}
+			way, ok := waysByID[member.ID]
+			if !ok {
+				continue
+			}
+			if coords := data.WayCoords(way); len(coords) >= 2 {
+				boundary.Outer = append(boundary.Outer, coords)
+			}
+		}
+		if len(boundary.Outer) > 0 {
+			extract.Boundaries = append(extract.Boundaries, boundary)
+		}
+	}
+
+	sort.Slice(extract.Places, func(i, j int) bool {
+		return extract.Places[i].ID < extract.Places[j].ID
+	})
+	sort.Slice(extract.Boundaries, func(i, j int) bool {
+		return extract.Boundaries[i].ID < extract.Boundaries[j].ID
+	})
+	return extract
+}
+
+// GooglePlaceID is the synthetic place ID used where the seeding flow expects
+// one, such as S3 image keys. It is never stored as a Google place ID.
+func (p OSMPlace) GooglePlaceID() string {
+	return "osm-" + strings.ReplaceAll(p.ID, "/", "-")
+}
+
+// GooglePlace adapts the place to the Google shape used by theming, image
+// generation and tag processing.
+func (p OSMPlace) GooglePlace() googlemaps.Place {
+	place := googlemaps.Place{
+		Name:        p.ID,
+		ID:          p.GooglePlaceID(),
+		DisplayName: googlemaps.LocalizedText{Text: p.Name},
+		Types:       p.Types,
+	}
+	if len(p.Types) > 0 {
+		place.PrimaryType = p.Types[0]
+	}
+	place.Location.Latitude = p.Location.Lat
+	place.Location.Longitude = p.Location.Lng
+	if description := strings.TrimSpace(p.Tags["description"]); description != "" {
+		place.EditorialSummary = googlemaps.LocalizedText{Text: description}
+	}
+	if address := osmFormattedAddress(p.Tags); address != "" {
+		place.FormattedAddress = address
+	}
+	return place
+}
+
+func osmFormattedAddress(tags map[string]string) string {
+	street := strings.TrimSpace(strings.TrimSpace(tags["addr:housenumber"]) + " " + strings.TrimSpace(tags["addr:street"]))
+	parts := []string{}
+	for _, part := range []string{street, tags["addr:city"], tags["addr:postcode"]} {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// HasAnyType reports whether the place carries any of the given types.
+func (p OSMPlace) HasAnyType(placeTypes []googlemaps.PlaceType) bool {
+	for _, placeType := range placeTypes {
+		for _, t := range p.Types {
+			if t == string(placeType) {
+				return true
+			}
+		}
+	}
+	return false
+}
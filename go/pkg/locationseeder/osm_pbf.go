@@ -0,0 +1,655 @@
+package locationseeder
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// This file is a small, dependency-free reader for the OpenStreetMap PBF
+// format (https://wiki.openstreetmap.org/wiki/PBF_Format). It decodes just
+// enough of the protobuf wire format to walk nodes, ways and relations; it
+// does not support LZMA/zstd blobs or history files.
+
+const (
+	osmMaxBlobHeaderSize = 64 * 1024
+	osmMaxBlobSize       = 32 * 1024 * 1024
+)
+
+type OSMCoord struct {
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+}
+
+type OSMNode struct {
+	ID    int64
+	Coord OSMCoord
+	Tags  map[string]string
+}
+
+type OSMWay struct {
+	ID      int64
+	NodeIDs []int64
+	Tags    map[string]string
+}
+
+type OSMMember struct {
+	Type string
+	ID   int64
+	Role string
+}
+
+type OSMRelation struct {
+	ID      int64
+	Members []OSMMember
+	Tags    map[string]string
+}
+
+// OSMData is everything read from an extract. Coordinates are kept for every
+// node so ways can be resolved, but only tagged nodes are kept in Nodes. Ways
+// are all kept because boundary relations usually reference untagged ways.
+type OSMData struct {
+	Coords    map[int64]OSMCoord
+	Nodes     []OSMNode
+	Ways      []OSMWay
+	Relations []OSMRelation
+}
+
+func (d *OSMData) WayCoords(way OSMWay) []OSMCoord {
+	coords := make([]OSMCoord, 0, len(way.NodeIDs))
+	for _, id := range way.NodeIDs {
+		if coord, ok := d.Coords[id]; ok {
+			coords = append(coords, coord)
+		}
+	}
+	return coords
+}
+
+func ReadOSMFile(path string) (*OSMData, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ReadOSM(file)
+}
+
+func ReadOSM(r io.Reader) (*OSMData, error) {
+	data := &OSMData{Coords: map[int64]OSMCoord{}}
+	for {
+		var headerSize uint32
+		if err := binary.Read(r, binary.BigEndian, &headerSize); err != nil {
+			if errors.Is(err, io.EOF) {
+				return data, nil
+			}
+			return nil, fmt.Errorf("reading blob header size: %w", err)
+		}
+		if headerSize > osmMaxBlobHeaderSize {
+			return nil, fmt.Errorf("blob header too large: %d bytes", headerSize)
+		}
+		headerBytes := make([]byte, headerSize)
+		if _, err := io.ReadFull(r, headerBytes); err != nil {
+			return nil, fmt.Errorf("reading blob header: %w", err)
+		}
+		blobType, blobSize, err := decodeOSMBlobHeader(headerBytes)
+		if err != nil {
+			return nil, err
+		}
+		if blobSize > osmMaxBlobSize {
+			return nil, fmt.Errorf("blob too large: %d bytes", blobSize)
+		}
+		blobBytes := make([]byte, blobSize)
+		if _, err := io.ReadFull(r, blobBytes); err != nil {
+			return nil, fmt.Errorf("reading blob: %w", err)
+		}
+		payload, err := decodeOSMBlob(blobBytes)
+		if err != nil {
+			return nil, err
+		}
+
+		switch blobType {
+		case "OSMHeader":
+			if err := checkOSMHeader(payload); err != nil {
+				return nil, err
+			}
+		case "OSMData":
+			if err := decodeOSMPrimitiveBlock(payload, data); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// pbfBuffer walks protobuf wire data.
+type pbfBuffer struct {
+	data []byte
+	pos  int
+}
+
+func (b *pbfBuffer) done() bool {
+	return b.pos >= len(b.data)
+}
+
+func (b *pbfBuffer) varint() (uint64, error) {
+	value, n := binary.Uvarint(b.data[b.pos:])
+	if n <= 0 {
+		return 0, fmt.Errorf("malformed varint at offset %d", b.pos)
+	}
+	b.pos += n
+	return value, nil
+}
+
+func (b *pbfBuffer) key() (int, int, error) {
+	value, err := b.varint()
+	if err != nil {
+		return 0, 0, err
+	}
+	return int(value >> 3), int(value & 7), nil
+}
+
+func (b *pbfBuffer) bytes() ([]byte, error) {
+	length, err := b.varint()
+	if err != nil {
+		return nil, err
+	}
+	end := b.pos + int(length)
+	if length > uint64(len(b.data)) || end > len(b.data) {
+		return nil, fmt.Errorf("length-delimited field overruns buffer at offset %d", b.pos)
+	}
+	value := b.data[b.pos:end]
+	b.pos = end
+	return value, nil
+}
+
+func (b *pbfBuffer) skip(wireType int) error {
+	switch wireType {
+	case 0:
+		_, err := b.varint()
+		return err
+	case 1:
+		b.pos += 8
+	case 2:
+		_, err := b.bytes()
+		return err
+	case 5:
+		b.pos += 4
+	default:
+		return fmt.Errorf("unsupported wire type %d", wireType)
+	}
+	if b.pos > len(b.data) {
+		return fmt.Errorf("fixed-width field overruns buffer")
+	}
+	return nil
+}
+
+// varints reads a repeated varint field that may be packed or unpacked.
+func (b *pbfBuffer) varints(wireType int, values []uint64) ([]uint64, error) {
+	if wireType == 0 {
+		value, err := b.varint()
+		if err != nil {
+			return nil, err
+		}
+		return append(values, value), nil
+	}
+	packed, err := b.bytes()
+	if err != nil {
+		return nil, err
+	}
+	inner := &pbfBuffer{data: packed}
+	for !inner.done() {
+		value, err := inner.varint()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+func zigzag(value uint64) int64 {
+	return int64(value>>1) ^ -int64(value&1)
+}
+
+func decodeOSMBlobHeader(data []byte) (string, int, error) {
+	buf := &pbfBuffer{data: data}
+	blobType := ""
+	blobSize := 0
+	for !buf.done() {
+		field, wireType, err := buf.key()
+		if err != nil {
+			return "", 0, err
+		}
+		switch {
+		case field == 1 && wireType == 2:
+			value, err := buf.bytes()
+			if err != nil {
+				return "", 0, err
+			}
+			blobType = string(value)
+		case field == 3 && wireType == 0:
+			value, err := buf.varint()
+			if err != nil {
+				return "", 0, err
+			}
+			blobSize = int(value)
+		default:
+			if err := buf.skip(wireType); err != nil {
+				return "", 0, err
+			}
+		}
+	}
+	return blobType, blobSize, nil
+}
+
+func decodeOSMBlob(data []byte) ([]byte, error) {
+	buf := &pbfBuffer{data: data}
+	for !buf.done() {
+		field, wireType, err := buf.key()
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case field == 1 && wireType == 2:
+			return buf.bytes()
+		case field == 3 && wireType == 2:
+			compressed, err := buf.bytes()
+			if err != nil {
+				return nil, err
+			}
+			reader, err := zlib.NewReader(bytes.NewReader(compressed))
+			if err != nil {
+				return nil, fmt.Errorf("opening zlib blob: %w", err)
+			}
+			defer reader.Close()
+			return io.ReadAll(io.LimitReader(reader, osmMaxBlobSize))
+		case field == 4 || field == 6 || field == 7:
+			return nil, fmt.Errorf("unsupported blob compression (field %d); re-encode the extract with zlib", field)
+		default:
+			if err := buf.skip(wireType); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return nil, fmt.Errorf("blob has no data")
+}
+
+var supportedOSMFeatures = map[string]bool{
+	"OsmSchema-V0.6": true,
+	"DenseNodes":     true,
+}
+
+func checkOSMHeader(data []byte) error {
+	buf := &pbfBuffer{data: data}
+	for !buf.done() {
+		field, wireType, err := buf.key()
+		if err != nil {
+			return err
+		}
+		if field == 4 && wireType == 2 {
+			feature, err := buf.bytes()
+			if err != nil {
+				return err
+			}
+			if !supportedOSMFeatures[string(feature)] {
+				return fmt.Errorf("unsupported required feature %q", string(feature))
+			}
+			continue
+		}
+		if err := buf.skip(wireType); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type osmBlockContext struct {
+	strings     []string
+	granularity int64
+	latOffset   int64
+	lonOffset   int64
+}
+
+func (c *osmBlockContext) coord(lat, lon int64) OSMCoord {
+	return OSMCoord{
+		Lat: 1e-9 * float64(c.latOffset+c.granularity*lat),
+		Lng: 1e-9 * float64(c.lonOffset+c.granularity*lon),
+	}
+}
+
+func (c *osmBlockContext) str(index uint64) string {
+	if index >= uint64(len(c.strings)) {
+		return ""
+	}
+	return c.strings[index]
+}
+
+func (c *osmBlockContext) tags(keys, vals []uint64) map[string]string {
+	if len(keys) == 0 {
+		return nil
+	}
+	tags := make(map[string]string, len(keys))
+	for i, key := range keys {
+		if i >= len(vals) {
+			break
+		}
+		tags[c.str(key)] = c.str(vals[i])
+	}
+	return tags
+}
+
+func decodeOSMPrimitiveBlock(data []byte, out *OSMData) error {
+	ctx := &osmBlockContext{granularity: 100}
+	var groups [][]byte
+
+	buf := &pbfBuffer{data: data}
+	for !buf.done() {
+		field, wireType, err := buf.key()
+		if err != nil {
+			return err
+		}
+		switch {
+		case field == 1 && wireType == 2:
+			table, err := buf.bytes()
+			if err != nil {
+				return err
+			}
+			if ctx.strings, err = decodeOSMStringTable(table); err != nil {
+				return err
+			}
+		case field == 2 && wireType == 2:
+			group, err := buf.bytes()
+			if err != nil {
+				return err
+			}
+			groups = append(groups, group)
+		case (field == 17 || field == 19 || field == 20) && wireType == 0:
+			value, err := buf.varint()
+			if err != nil {
+				return err
+			}
+			switch field {
+			case 17:
+				ctx.granularity = int64(value)
+			case 19:
+				ctx.latOffset = int64(value)
+			case 20:
+				ctx.lonOffset = int64(value)
+			}
+		default:
+			if err := buf.skip(wireType); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Groups are decoded after the whole block so the string table and
+	// offsets are known regardless of field order.
+	for _, group := range groups {
+		if err := decodeOSMPrimitiveGroup(group, ctx, out); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func decodeOSMStringTable(data []byte) ([]string, error) {
+	var table []string
+	buf := &pbfBuffer{data: data}
+	for !buf.done() {
+		field, wireType, err := buf.key()
+		if err != nil {
+			return nil, err
+		}
+		if field == 1 && wireType == 2 {
+			value, err := buf.bytes()
+			if err != nil {
+				return nil, err
+			}
+			table = append(table, string(value))
+			continue
+		}
+		if err := buf.skip(wireType); err != nil {
+			return nil, err
+		}
+	}
+	return table, nil
+}
+
+func decodeOSMPrimitiveGroup(data []byte, ctx *osmBlockContext, out *OSMData) error {
+	buf := &pbfBuffer{data: data}
+	for !buf.done() {
+		field, wireType, err := buf.key()
+		if err != nil {
+			return err
+		}
+		if wireType != 2 {
+			if err := buf.skip(wireType); err != nil {
+				return err
+			}
+			continue
+		}
+		message, err := buf.bytes()
+		if err != nil {
+			return err
+		}
+		switch field {
+		case 1:
+			err = decodeOSMNode(message, ctx, out)
+		case 2:
+			err = decodeOSMDenseNodes(message, ctx, out)
+		case 3:
+			err = decodeOSMWay(message, ctx, out)
+		case 4:
+			err = decodeOSMRelation(message, ctx, out)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func decodeOSMNode(data []byte, ctx *osmBlockContext, out *OSMData) error {
+	var id, lat, lon int64
+	var keys, vals []uint64
+	buf := &pbfBuffer{data: data}
+	for !buf.done() {
+		field, wireType, err := buf.key()
+		if err != nil {
+			return err
+		}
+		switch field {
+		case 1, 8, 9:
+			value, err := buf.varint()
+			if err != nil {
+				return err
+			}
+			switch field {
+			case 1:
+				id = zigzag(value)
+			case 8:
+				lat = zigzag(value)
+			case 9:
+				lon = zigzag(value)
+			}
+		case 2:
+			if keys, err = buf.varints(wireType, keys); err != nil {
+				return err
+			}
+		case 3:
+			if vals, err = buf.varints(wireType, vals); err != nil {
+				return err
+			}
+		default:
+			if err := buf.skip(wireType); err != nil {
+				return err
+			}
+		}
+	}
+	out.addNode(id, ctx.coord(lat, lon), ctx.tags(keys, vals))
+	return nil
+}
+
+func decodeOSMDenseNodes(data []byte, ctx *osmBlockContext, out *OSMData) error {
+	var ids, lats, lons, keyVals []uint64
+	buf := &pbfBuffer{data: data}
+	for !buf.done() {
+		field, wireType, err := buf.key()
+		if err != nil {
+			return err
+		}
+		switch field {
+		case 1:
+			ids, err = buf.varints(wireType, ids)
+		case 8:
+			lats, err = buf.varints(wireType, lats)
+		case 9:
+			lons, err = buf.varints(wireType, lons)
+		case 10:
+			keyVals, err = buf.varints(wireType, keyVals)
+		default:
+			err = buf.skip(wireType)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if len(lats) != len(ids) || len(lons) != len(ids) {
+		return fmt.Errorf("dense nodes have mismatched id/lat/lon lengths")
+	}
+
+	var id, lat, lon int64
+	kv := 0
+	for i := range ids {
+		id += zigzag(ids[i])
+		lat += zigzag(lats[i])
+		lon += zigzag(lons[i])
+
+		var tags map[string]string
+		for kv < len(keyVals) && keyVals[kv] != 0 {
+			if kv+1 >= len(keyVals) {
+				return fmt.Errorf("dense node %d has a dangling tag key", id)
+			}
+			if tags == nil {
+				tags = map[string]string{}
+			}
+			tags[ctx.str(keyVals[kv])] = ctx.str(keyVals[kv+1])
+			kv += 2
+		}
+		// Skip the 0 delimiter between nodes.
+		kv++
+
+		out.addNode(id, ctx.coord(lat, lon), tags)
+	}
+	return nil
+}
+
+func decodeOSMWay(data []byte, ctx *osmBlockContext, out *OSMData) error {
+	way := OSMWay{}
+	var keys, vals, refs []uint64
+	buf := &pbfBuffer{data: data}
+	for !buf.done() {
+		field, wireType, err := buf.key()
+		if err != nil {
+			return err
+		}
+		switch field {
+		case 1:
+			value, err := buf.varint()
+			if err != nil {
+				return err
+			}
+			way.ID = int64(value)
+		case 2:
+			keys, err = buf.varints(wireType, keys)
+		case 3:
+			vals, err = buf.varints(wireType, vals)
+		case 8:
+			refs, err = buf.varints(wireType, refs)
+		default:
+			err = buf.skip(wireType)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	way.NodeIDs = make([]int64, len(refs))
+	var ref int64
+	for i, delta := range refs {
+		ref += zigzag(delta)
+		way.NodeIDs[i] = ref
+	}
+	way.Tags = ctx.tags(keys, vals)
+	out.Ways = append(out.Ways, way)
+	return nil
+}
+
+var osmMemberTypes = []string{"node", "way", "relation"}
+
+func decodeOSMRelation(data []byte, ctx *osmBlockContext, out *OSMData) error {
+	relation := OSMRelation{}
+	var keys, vals, roles, memIDs, types []uint64
+	buf := &pbfBuffer{data: data}
+	for !buf.done() {
+		field, wireType, err := buf.key()
+		if err != nil {
+			return err
+		}
+		switch field {
+		case 1:
+			value, err := buf.varint()
+			if err != nil {
+				return err
+			}
+			relation.ID = int64(value)
+		case 2:
+			keys, err = buf.varints(wireType, keys)
+		case 3:
+			vals, err = buf.varints(wireType, vals)
+		case 8:
+			roles, err = buf.varints(wireType, roles)
+		case 9:
+			memIDs, err = buf.varints(wireType, memIDs)
+		case 10:
+			types, err = buf.varints(wireType, types)
+		default:
+			err = buf.skip(wireType)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if len(roles) != len(memIDs) || len(types) != len(memIDs) {
+		return fmt.Errorf("relation %d has mismatched member lengths", relation.ID)
+	}
+
+	var memID int64
+	relation.Members = make([]OSMMember, len(memIDs))
+	for i := range memIDs {
+		memID += zigzag(memIDs[i])
+		memberType := "node"
+		if types[i] < uint64(len(osmMemberTypes)) {
+			memberType = osmMemberTypes[types[i]]
+		}
+		relation.Members[i] = OSMMember{
+			Type: memberType,
+			ID:   memID,
+			Role: ctx.str(roles[i]),
+		}
+	}
+	relation.Tags = ctx.tags(keys, vals)
+	out.Relations = append(out.Relations, relation)
+	return nil
+}
+
+func (d *OSMData) addNode(id int64, coord OSMCoord, tags map[string]string) {
+	d.Coords[id] = coord
+	if len(tags) > 0 {
+		d.Nodes = append(d.Nodes, OSMNode{ID: id, Coord: coord, Tags: tags})
+	}
+}
@@ -0,0 +1,222 @@
+package locationseeder
+
+import (
+	"strings"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/googlemaps"
+)
+
+// osmTagPlaceTypes maps OSM key=value tags onto the Google place taxonomy so
+// OSM places flow through the same type filters, tags and marker categories
+// as Google places. A "*" value matches any value for that key.
+var osmTagPlaceTypes = map[string]map[string][]googlemaps.PlaceType{
+	"amenity": {
+		"restaurant":       {googlemaps.TypeRestaurant},
+		"cafe":             {googlemaps.TypeCafe},
+		"bar":              {googlemaps.TypeBar},
+		"pub":              {googlemaps.TypePub, googlemaps.TypeBar},
+		"biergarten":       {googlemaps.TypeBar},
+		"fast_food":        {googlemaps.TypeFastFoodRestaurant, googlemaps.TypeRestaurant},
+		"food_court":       {googlemaps.TypeFoodCourt},
+		"ice_cream":        {googlemaps.TypeIceCreamShop},
+		"nightclub":        {googlemaps.TypeNightClub},
+		"library":          {googlemaps.TypeLibrary},
+		"theatre":          {googlemaps.TypePerformingArtsTheater},
+		"cinema":           {googlemaps.TypeMovieTheater},
+		"arts_centre":      {googlemaps.TypeCulturalCenter},
+		"community_centre": {googlemaps.TypeCommunityCenter},
+		"townhall":         {googlemaps.TypeCityHall},
+		"courthouse":       {googlemaps.TypeCourthouse},
+		"fire_station":     {googlemaps.TypeFireStation},
+		"police":           {googlemaps.TypePolice},
+		"post_office":      {googlemaps.TypePostOffice},
+		"marketplace":      {googlemaps.TypeMarket},
+		"fountain":         {googlemaps.TypeTouristAttraction},
+		"casino":           {googlemaps.TypeCasino},
+		"planetarium":      {googlemaps.TypePlanetarium},
+		"university":       {googlemaps.TypeUniversity},
+		"bus_station":      {googlemaps.TypeBusStation},
+		"pharmacy":         {googlemaps.TypePharmacy},
+		"hospital":         {googlemaps.TypeHospital},
+		"bank":             {googlemaps.TypeBank},
+	},
+	"leisure": {
+		"park":             {googlemaps.TypePark},
+		"garden":           {googlemaps.TypeGarden},
+		"playground":       {googlemaps.TypePlayground},
+		"stadium":          {googlemaps.TypeStadium},
+		"sports_centre":    {googlemaps.TypeSportsComplex},
+		"fitness_centre":   {googlemaps.TypeFitnessCenter, googlemaps.TypeGym},
+		"dog_park":         {googlemaps.TypeDogPark},
+		"golf_course":      {googlemaps.TypeGolfCourse},
+		"swimming_pool":    {googlemaps.TypeSwimmingPool},
+		"marina":           {googlemaps.TypeMarina},
+		"nature_reserve":   {googlemaps.TypeWildlifeRefuge},
+		"water_park":       {googlemaps.TypeWaterPark},
+		"ice_rink":         {googlemaps.TypeIceSkatingRink},
+		"bowling_alley":    {googlemaps.TypeBowlingAlley},
+		"amusement_arcade": {googlemaps.TypeVideoArcade},
+		"skatepark":        {googlemaps.TypeSkateboardPark},
+	},
+	"tourism": {
+		"museum":      {googlemaps.TypeMuseum},
+		"gallery":     {googlemaps.TypeArtGallery},
+		"attraction":  {googlemaps.TypeTouristAttraction},
+		"viewpoint":   {googlemaps.TypeObservationDeck},
+		"zoo":         {googlemaps.TypeZoo},
+		"aquarium":    {googlemaps.TypeAquarium},
+		"theme_park":  {googlemaps.TypeAmusementPark},
+		"artwork":     {googlemaps.TypeSculpture},
+		"picnic_site": {googlemaps.TypePicnicGround},
+		"camp_site":   {googlemaps.TypeCampground},
+		"hotel":       {googlemaps.TypeHotel, googlemaps.TypeLodging},
+		"hostel":      {googlemaps.TypeHostel, googlemaps.TypeLodging},
+		"motel":       {googlemaps.TypeMotel, googlemaps.TypeLodging},
+		"guest_house": {googlemaps.TypeGuestHouse, googlemaps.TypeLodging},
+	},
+	"historic": {
+		"monument":            {googlemaps.TypeMonument},
+		"memorial":            {googlemaps.TypeMonument},
+		"archaeological_site": {googlemaps.TypeHistoricalPlace},
+		"*":                   {googlemaps.TypeHistoricalLandmark},
+	},
+	"shop": {
+		"bakery":           {googlemaps.TypeBakery},
+		"books":            {googlemaps.TypeBookStore},
+		"clothes":          {googlemaps.TypeClothingStore},
+		"supermarket":      {googlemaps.TypeSupermarket},
+		"convenience":      {googlemaps.TypeConvenienceStore},
+		"florist":          {googlemaps.TypeFlorist},
+		"gift":             {googlemaps.TypeGiftShop},
+		"jewelry":          {googlemaps.TypeJewelryStore},
+		"hardware":         {googlemaps.TypeHardwareStore},
+		"bicycle":          {googlemaps.TypeBicycleStore},
+		"department_store": {googlemaps.TypeDepartmentStore},
+		"mall":             {googlemaps.TypeShoppingMall},
+		"alcohol":          {googlemaps.TypeLiquorStore},
+		"butcher":          {googlemaps.TypeButcherShop},
+		"confectionery":    {googlemaps.TypeCandyStore},
+		"pet":              {googlemaps.TypePetStore},
+		"shoes":            {googlemaps.TypeShoeStore},
+		"electronics":      {googlemaps.TypeElectronicsStore},
+		"furniture":        {googlemaps.TypeFurnitureStore},
+		"sports":           {googlemaps.TypeSportingGoodsStore},
+		"*":                {googlemaps.TypeStore},
+	},
+	"natural": {
+		"beach": {googlemaps.TypeBeach},
+	},
+	"place": {
+		"square": {googlemaps.TypePlaza},
+	},
+	"railway": {
+		"station": {googlemaps.TypeTrainStation},
+	},
+	"landuse": {
+		"cemetery": {googlemaps.TypeCemetery},
+	},
+}
+
+var osmCuisinePlaceTypes = map[string]googlemaps.PlaceType{
+	"american":      googlemaps.TypeAmericanRestaurant,
+	"barbecue":      googlemaps.TypeBarbecueRestaurant,
+	"burger":        googlemaps.TypeHamburgerRestaurant,
+	"chinese":       googlemaps.TypeChineseRestaurant,
+	"coffee_shop":   googlemaps.TypeCoffeeShop,
+	"french":        googlemaps.TypeFrenchRestaurant,
+	"greek":         googlemaps.TypeGreekRestaurant,
+	"indian":        googlemaps.TypeIndianRestaurant,
+	"italian":       googlemaps.TypeItalianRestaurant,
+	"japanese":      googlemaps.TypeJapaneseRestaurant,
+	"korean":        googlemaps.TypeKoreanRestaurant,
+	"lebanese":      googlemaps.TypeLebaneseRestaurant,
+	"mediterranean": googlemaps.TypeMediterraneanRestaurant,
+	"mexican":       googlemaps.TypeMexicanRestaurant,
+	"pizza":         googlemaps.TypePizzaRestaurant,
+	"ramen":         googlemaps.TypeRamenRestaurant,
+	"seafood":       googlemaps.TypeSeafoodRestaurant,
+	"spanish":       googlemaps.TypeSpanishRestaurant,
+	"steak_house":   googlemaps.TypeSteakHouse,
+	"sushi":         googlemaps.TypeSushiRestaurant,
+	"thai":          googlemaps.TypeThaiRestaurant,
+	"turkish":       googlemaps.TypeTurkishRestaurant,
+	"vegan":         googlemaps.TypeVeganRestaurant,
+	"vegetarian":    googlemaps.TypeVegetarianRestaurant,
+	"vietnamese":    googlemaps.TypeVietnameseRestaurant,
+}
+
+var osmReligionPlaceTypes = map[string]googlemaps.PlaceType{
+	"christian": googlemaps.TypeChurch,
+	"muslim":    googlemaps.TypeMosque,
+	"jewish":    googlemaps.TypeSynagogue,
+	"hindu":     googlemaps.TypeHinduTemple,
+}
+
+// osmTagKeyOrder fixes the order keys are consulted in so the primary type
+// is stable: a museum that is also tagged building=yes, shop=gift stays a
+// museum.
+var osmTagKeyOrder = []string{"tourism", "historic", "leisure", "amenity", "natural", "place", "railway", "landuse", "shop"}
+
+// OSMPlaceTypes returns the Google place types an OSM element's tags map
+// onto, most specific first. It returns nil for elements we don't seed from.
+func OSMPlaceTypes(tags map[string]string) []googlemaps.PlaceType {
+	var types []googlemaps.PlaceType
+	seen := map[googlemaps.PlaceType]bool{}
+	add := func(placeType googlemaps.PlaceType) {
+		if !seen[placeType] {
+			seen[placeType] = true
+			types = append(types, placeType)
+		}
+	}
+
+	if tags["amenity"] == "place_of_worship" {
+		if placeType, ok := osmReligionPlaceTypes[tags["religion"]]; ok {
+			add(placeType)
+		} else {
+			add(googlemaps.TypeCulturalLandmark)
+		}
+	}
+	for _, cuisine := range strings.Split(tags["cuisine"], ";") {
+		if placeType, ok := osmCuisinePlaceTypes[strings.TrimSpace(cuisine)]; ok {
+			add(placeType)
+		}
+	}
+
+	for _, key := range osmTagKeyOrder {
+		value, ok := tags[key]
+		if !ok || value == "" || value == "no" {
+			continue
+		}
+		values := osmTagPlaceTypes[key]
+		mapped, ok := values[value]
+		if !ok {
+			mapped = values["*"]
+		}
+		for _, placeType := range mapped {
+			add(placeType)
+		}
+	}
+
+	// Cuisine alone (e.g. on a building) isn't enough to be a place.
+	if len(types) > 0 && !osmHasPlaceTag(tags) {
+		return nil
+	}
+	return types
+}
+
+func osmHasPlaceTag(tags map[string]string) bool {
+	if tags["amenity"] == "place_of_worship" {
+		return true
+	}
+	for _, key := range osmTagKeyOrder {
+		values := osmTagPlaceTypes[key]
+		value := tags[key]
+		if _, ok := values[value]; ok {
+			return true
+		}
+		if _, ok := values["*"]; ok && value != "" && value != "no" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,172 @@
+package locationseeder
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/googlemaps"
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+)
+
+// samePlaceRadiusMeters is how far apart a Google and an OSM record with the
+// same name may be and still count as one place. OSM way centroids and Google
+// pins for large parks can sit a fair distance apart.
+const samePlaceRadiusMeters = 75
+
+// linkNearbyPointOfInterest looks for a point of interest imported from the
+// other source with the same name close by. If one is found and it doesn't
+// already carry an ID of the kind being imported, the ID is linked onto it
+// and it's attached to the zone instead of generating a duplicate.
+func (c *client) linkNearbyPointOfInterest(
+	ctx context.Context,
+	name string,
+	lat float64,
+	lng float64,
+	zone models.Zone,
+	googleMapsPlaceID *string,
+	osmID *string,
+) (*models.PointOfInterest, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, nil
+	}
+
+	existing, err := c.dbClient.PointOfInterest().FindNearbyByName(ctx, name, lat, lng, samePlaceRadiusMeters)
+	if err != nil || existing == nil {
+		return nil, err
+	}
+	if googleMapsPlaceID != nil && existing.GoogleMapsPlaceID != nil {
+		return nil, nil
+	}
+	if osmID != nil && existing.OSMID != nil {
+		return nil, nil
+	}
+
+	if err := c.dbClient.PointOfInterest().LinkProvenance(ctx, existing.ID, googleMapsPlaceID, osmID); err != nil {
+		return nil, err
+	}
+	if googleMapsPlaceID != nil {
+		existing.GoogleMapsPlaceID = googleMapsPlaceID
+	}
+	if osmID != nil {
+		existing.OSMID = osmID
+	}
+	if err := c.dbClient.Zone().AddPointOfInterestToZone(ctx, zone.ID, existing.ID); err != nil {
+		return nil, err
+	}
+	return existing, nil
+}
+
+// filterOSMPlacesForZone keeps places inside the zone that satisfy the type
+// filters. An empty includedTypes admits every type.
+func filterOSMPlacesForZone(zone models.Zone, places []OSMPlace, includedTypes []googlemaps.PlaceType, excludedTypes []googlemaps.PlaceType) []OSMPlace {
+	var candidates []OSMPlace
+	for _, place := range places {
+		if len(includedTypes) > 0 && !place.HasAnyType(includedTypes) {
+			continue
+		}
+		if place.HasAnyType(excludedTypes) {
+			continue
+		}
+		if !zone.IsPointInBoundary(place.Location.Lat, place.Location.Lng) {
+			continue
+		}
+		candidates = append(candidates, place)
+	}
+	return candidates
+}
+
+// pickOSMPlaces picks up to count places at random, preferring ones that
+// haven't been used in a quest recently.
+func pickOSMPlaces(candidates []OSMPlace, recentlyUsed map[string]bool, count int) []OSMPlace {
+	var fresh, stale []OSMPlace
+	for _, place := range candidates {
+		if recentlyUsed[place.ID] {
+			stale = append(stale, place)
+		} else {
+			fresh = append(fresh, place)
+		}
+	}
+	rand.Shuffle(len(fresh), func(i, j int) { fresh[i], fresh[j] = fresh[j], fresh[i] })
+	rand.Shuffle(len(stale), func(i, j int) { stale[i], stale[j] = stale[j], stale[i] })
+
+	picked := append(fresh, stale...)
+	if len(picked) > count {
+		picked = picked[:count]
+	}
+	return picked
+}
+
+func (c *client) SeedPointsOfInterestFromOSM(
+	ctx context.Context,
+	zone models.Zone,
+	places []OSMPlace,
+	includedTypes []googlemaps.PlaceType,
+	excludedTypes []googlemaps.PlaceType,
+	numberOfPlaces int32,
+	genre *models.ZoneGenre,
+) ([]*models.PointOfInterest, error) {
+	log.Printf("Starting to seed points of interest for zone %s from %d OSM places", zone.Name, len(places))
+
+	candidates := filterOSMPlacesForZone(zone, places, includedTypes, excludedTypes)
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("no OSM places in zone %s match the requested types", zone.Name)
+	}
+
+	recentlyUsed, err := c.dbClient.PointOfInterest().FindRecentlyUsedInZone(ctx, zone.ID, time.Now().Add(-7*24*time.Hour))
+	if err != nil {
+		log.Printf("Error finding recently used places: %v", err)
+		recentlyUsed = map[string]bool{}
+	}
+
+	selected := pickOSMPlaces(candidates, recentlyUsed, int(numberOfPlaces))
+	log.Printf("Selected %d of %d candidate OSM places in zone %s", len(selected), len(candidates), zone.Name)
+
+	var pointsOfInterest []*models.PointOfInterest
+	for i, place := range selected {
+		log.Printf("Generating point of interest %d/%d for OSM place: %s (%s)", i+1, len(selected), place.Name, place.ID)
+		osmID := place.ID
+
+		existing, err := c.dbClient.PointOfInterest().FindByOSMID(ctx, osmID)
+		if err != nil {
+			log.Printf("Error checking if OSM place has been imported: %v", err)
+			return nil, err
+		}
+		if existing != nil {
+			if err := c.dbClient.Zone().AddPointOfInterestToZone(ctx, zone.ID, existing.ID); err != nil {
+				return nil, err
+			}
+			log.Printf("OSM place %s has already been imported", place.ID)
+			pointsOfInterest = append(pointsOfInterest, existing)
+			continue
+		}
+
+		linked, err := c.linkNearbyPointOfInterest(ctx, place.Name, place.Location.Lat, place.Location.Lng, zone, nil, &osmID)
+		if err != nil {
+			log.Printf("Error linking OSM place %s to a nearby point of interest: %v", place.ID, err)
+			return nil, err
+		}
+		if linked != nil {
+			log.Printf("Linked OSM place %s to existing point of interest %s", place.ID, linked.ID)
+			pointsOfInterest = append(pointsOfInterest, linked)
+			continue
+		}
+
+		poi, err := c.createPointOfInterestForPlace(ctx, place.GooglePlace(), &zone, genre, pointOfInterestProvenance{
+			source: models.PointOfInterestSourceOSM,
+			osmID:  &osmID,
+		})
+		if err != nil {
+			log.Printf("Error generating point of interest for OSM place %s: %v", place.ID, err)
+			return nil, err
+		}
+		pointsOfInterest = append(pointsOfInterest, poi)
+	}
+
+	log.Printf("Successfully generated %d points of interest from OSM", len(pointsOfInterest))
+	return pointsOfInterest, nil
+}
@@ -0,0 +1,248 @@
+package locationseeder
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"reflect"
+	"testing"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/googlemaps"
+)
+
+// pbWriter builds protobuf wire data for test fixtures.
+type pbWriter struct {
+	buf bytes.Buffer
+}
+
+func (w *pbWriter) varint(field int, value uint64) *pbWriter {
+	w.raw(uint64(field<<3 | 0))
+	w.raw(value)
+	return w
+}
+
+func (w *pbWriter) bytes(field int, value []byte) *pbWriter {
+	w.raw(uint64(field<<3 | 2))
+	w.raw(uint64(len(value)))
+	w.buf.Write(value)
+	return w
+}
+
+func (w *pbWriter) packed(field int, values ...uint64) *pbWriter {
+	inner := &pbWriter{}
+	for _, value := range values {
+		inner.raw(value)
+	}
+	return w.bytes(field, inner.buf.Bytes())
+}
+
+func (w *pbWriter) raw(value uint64) {
+	var scratch [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(scratch[:], value)
+	w.buf.Write(scratch[:n])
+}
+
+func zz(value int64) uint64 {
+	return uint64((value << 1) ^ (value >> 63))
+}
+
+func deltas(values ...int64) []uint64 {
+	out := make([]uint64, len(values))
+	var previous int64
+	for i, value := range values {
+		out[i] = zz(value - previous)
+		previous = value
+	}
+	return out
+}
+
+func writeOSMFileBlock(t *testing.T, out *bytes.Buffer, blobType string, payload []byte) {
+	t.Helper()
+	var compressed bytes.Buffer
+	writer := zlib.NewWriter(&compressed)
+	if _, err := writer.Write(payload); err != nil {
+		t.Fatalf("compress: %v", err)
+	}
+	writer.Close()
+
+	blob := (&pbWriter{}).varint(2, uint64(len(payload))).bytes(3, compressed.Bytes())
+	header := (&pbWriter{}).bytes(1, []byte(blobType)).varint(3, uint64(blob.buf.Len()))
+
+	binary.Write(out, binary.BigEndian, uint32(header.buf.Len()))
+	out.Write(header.buf.Bytes())
+	out.Write(blob.buf.Bytes())
+}
+
+// buildTestOSMFile encodes a tiny extract: a named cafe and an unnamed bench
+// as dense nodes, a park way, an untagged way and a neighbourhood relation
+// that uses it as its outer ring.
+func buildTestOSMFile(t *testing.T) []byte {
+	stringTable := []string{"", "amenity", "cafe", "name", "Bean There", "bench", "leisure", "park", "Gas Works Park", "place", "neighbourhood", "Wallingford", "outer", "type", "multipolygon", "cuisine", "coffee_shop"}
+	table := &pbWriter{}
+	for _, s := range stringTable {
+		table.bytes(1, []byte(s))
+	}
+
+	// Coordinates are in nanodegrees / granularity (100).
+	lat := func(deg float64) int64 { return int64(deg * 1e7) }
+	dense := (&pbWriter{}).
+		packed(1, deltas(1, 2, 10, 11, 12, 13)...).
+		packed(8, deltas(lat(47.65), lat(47.66), lat(47.64), lat(47.64), lat(47.65), lat(47.65))...).
+		packed(9, deltas(lat(-122.33), lat(-122.34), lat(-122.34), lat(-122.33), lat(-122.33), lat(-122.34))...).
+		packed(10,
+			1, 2, 3, 4, 15, 16, 0, // node 1: cafe named Bean There
+			1, 5, 0, // node 2: unnamed bench
+			0, 0, 0, 0,
+		)
+
+	park := (&pbWriter{}).varint(1, 100).
+		packed(2, 6, 3).packed(3, 7, 8).
+		packed(8, deltas(10, 11, 12, 13, 10)...)
+	ring := (&pbWriter{}).varint(1, 101).
+		packed(8, deltas(10, 11, 12, 13, 10)...)
+	relation := (&pbWriter{}).varint(1, 500).
+		packed(2, 9, 3, 13).packed(3, 10, 11, 14).
+		packed(8, 12).packed(9, deltas(101)...).packed(10, 1)
+
+	group := (&pbWriter{}).
+		bytes(2, dense.buf.Bytes()).
+		bytes(3, park.buf.Bytes()).
+		bytes(3, ring.buf.Bytes()).
+		bytes(4, relation.buf.Bytes())
+	block := (&pbWriter{}).bytes(1, table.buf.Bytes()).bytes(2, group.buf.Bytes())
+
+	header := (&pbWriter{}).bytes(4, []byte("OsmSchema-V0.6")).bytes(4, []byte("DenseNodes"))
+
+	var out bytes.Buffer
+	writeOSMFileBlock(t, &out, "OSMHeader", header.buf.Bytes())
+	writeOSMFileBlock(t, &out, "OSMData", block.buf.Bytes())
+	return out.Bytes()
+}
+
+func TestReadOSMDecodesDenseNodesWaysAndRelations(t *testing.T) {
+	data, err := ReadOSM(bytes.NewReader(buildTestOSMFile(t)))
+	if err != nil {
+		t.Fatalf("ReadOSM returned error: %v", err)
+	}
+
+	if len(data.Coords) != 6 {
+		t.Fatalf("expected 6 node coordinates, got %d", len(data.Coords))
+	}
+	if len(data.Nodes) != 2 {
+		t.Fatalf("expected 2 tagged nodes, got %d", len(data.Nodes))
+	}
+	cafe := data.Nodes[0]
+	if cafe.ID != 1 || cafe.Tags["name"] != "Bean There" || cafe.Tags["cuisine"] != "coffee_shop" {
+		t.Fatalf("unexpected cafe node: %+v", cafe)
+	}
+	if diff := cafe.Coord.Lat - 47.65; diff > 1e-9 || diff < -1e-9 {
+		t.Fatalf("expected cafe latitude 47.65, got %f", cafe.Coord.Lat)
+	}
+	if diff := cafe.Coord.Lng + 122.33; diff > 1e-9 || diff < -1e-9 {
+		t.Fatalf("expected cafe longitude -122.33, got %f", cafe.Coord.Lng)
+	}
+
+	if len(data.Ways) != 2 || !reflect.DeepEqual(data.Ways[0].NodeIDs, []int64{10, 11, 12, 13, 10}) {
+		t.Fatalf("unexpected ways: %+v", data.Ways)
+	}
+	if len(data.Relations) != 1 {
+		t.Fatalf("expected 1 relation, got %d", len(data.Relations))
+	}
+	member := data.Relations[0].Members[0]
+	if member.Type != "way" || member.ID != 101 || member.Role != "outer" {
+		t.Fatalf("unexpected relation member: %+v", member)
+	}
+}
+
+func TestReadOSMRejectsUnsupportedFeatures(t *testing.T) {
+	header := (&pbWriter{}).bytes(4, []byte("HistoricalInformation"))
+	var out bytes.Buffer
+	writeOSMFileBlock(t, &out, "OSMHeader", header.buf.Bytes())
+
+	if _, err := ReadOSM(&out); err == nil {
+		t.Fatalf("expected an error for a history extract")
+	}
+}
+
+func TestExtractOSMFindsPlacesAndBoundaries(t *testing.T) {
+	data, err := ReadOSM(bytes.NewReader(buildTestOSMFile(t)))
+	if err != nil {
+		t.Fatalf("ReadOSM returned error: %v", err)
+	}
+	extract := ExtractOSM(data)
+
+	if len(extract.Places) != 2 {
+		t.Fatalf("expected the cafe and the park, got %+v", extract.Places)
+	}
+	cafe, park := extract.Places[0], extract.Places[1]
+	if cafe.ID != "node/1" || park.ID != "way/100" {
+		t.Fatalf("unexpected place IDs %q and %q", cafe.ID, park.ID)
+	}
+	if !reflect.DeepEqual(cafe.Types, []string{"coffee_shop", "cafe"}) {
+		t.Fatalf("unexpected cafe types: %v", cafe.Types)
+	}
+	if diff := park.Location.Lat - 47.645; diff > 1e-9 || diff < -1e-9 {
+		t.Fatalf("expected park centroid latitude 47.645, got %f", park.Location.Lat)
+	}
+
+	if len(extract.Boundaries) != 1 {
+		t.Fatalf("expected one boundary, got %+v", extract.Boundaries)
+	}
+	boundary := extract.Boundaries[0]
+	if boundary.ID != "relation/500" || boundary.Name != "Wallingford" || len(boundary.Outer) != 1 || len(boundary.Outer[0]) != 5 {
+		t.Fatalf("unexpected boundary: %+v", boundary)
+	}
+
+	place := cafe.GooglePlace()
+	if place.ID != "osm-node-1" || place.DisplayName.Text != "Bean There" || place.PrimaryType != "coffee_shop" {
+		t.Fatalf("unexpected google place adapter output: %+v", place)
+	}
+}
+
+func TestOSMPlaceTypes(t *testing.T) {
+	cases := []struct {
+		name string
+		tags map[string]string
+		want []googlemaps.PlaceType
+	}{
+		{
+			name: "italian restaurant",
+			tags: map[string]string{"amenity": "restaurant", "cuisine": "italian;pizza"},
+			want: []googlemaps.PlaceType{googlemaps.TypeItalianRestaurant, googlemaps.TypePizzaRestaurant, googlemaps.TypeRestaurant},
+		},
+		{
+			name: "mosque",
+			tags: map[string]string{"amenity": "place_of_worship", "religion": "muslim"},
+			want: []googlemaps.PlaceType{googlemaps.TypeMosque},
+		},
+		{
+			name: "museum beats shop",
+			tags: map[string]string{"tourism": "museum", "shop": "gift"},
+			want: []googlemaps.PlaceType{googlemaps.TypeMuseum, googlemaps.TypeGiftShop},
+		},
+		{
+			name: "unknown historic value",
+			tags: map[string]string{"historic": "wayside_cross"},
+			want: []googlemaps.PlaceType{googlemaps.TypeHistoricalLandmark},
+		},
+		{
+			name: "cuisine without a place tag",
+			tags: map[string]string{"building": "yes", "cuisine": "thai"},
+			want: nil,
+		},
+		{
+			name: "untracked amenity",
+			tags: map[string]string{"amenity": "bench"},
+			want: nil,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := OSMPlaceTypes(tc.tags)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("OSMPlaceTypes(%v) = %v, want %v", tc.tags, got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package locationseeder
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+)
+
+// osmWalkableHighways are highway values people can walk along. Motorways and
+// trunk roads are left out, so rivers and freeways are only crossed where a
+// bridge, underpass or crossing way connects the two sides.
+var osmWalkableHighways = map[string]bool{
+	"bridleway":      true,
+	"corridor":       true,
+	"cycleway":       true,
+	"footway":        true,
+	"living_street":  true,
+	"path":           true,
+	"pedestrian":     true,
+	"primary":        true,
+	"primary_link":   true,
+	"residential":    true,
+	"road":           true,
+	"secondary":      true,
+	"secondary_link": true,
+	"service":        true,
+	"steps":          true,
+	"tertiary":       true,
+	"tertiary_link":  true,
+	"track":          true,
+	"unclassified":   true,
+}
+
+var osmFootAllowedValues = map[string]bool{
+	"designated": true,
+	"permissive": true,
+	"yes":        true,
+}
+
+func osmWayIsWalkable(tags map[string]string) bool {
+	highway := strings.TrimSpace(tags["highway"])
+	if highway == "" {
+		return false
+	}
+	foot := strings.TrimSpace(tags["foot"])
+	if foot == "no" {
+		return false
+	}
+	if osmFootAllowedValues[foot] {
+		return highway != "motorway" && highway != "motorway_link"
+	}
+	if access := strings.TrimSpace(tags["access"]); access == "no" || access == "private" {
+		return false
+	}
+	return osmWalkableHighways[highway]
+}
+
+type osmWalkingEdgeKey struct {
+	wayID int64
+	from  int64
+	to    int64
+}
+
+// BuildWalkingGraph turns the walkable ways in an extract into a pedestrian
+// routing graph. Ways are collapsed to edges between junctions (nodes shared
+// by several ways, and way ends), with edge lengths summed along the way's
+// geometry, which keeps the graph small enough to route over in memory.
+func BuildWalkingGraph(data *OSMData) ([]models.WalkingGraphNode, []models.WalkingGraphEdge) {
+	if data == nil {
+		return nil, nil
+	}
+
+	// Split ways at nodes missing from the extract so every run is drawable.
+	type walkableRun struct {
+		way     OSMWay
+		nodeIDs []int64
+	}
+	var runs []walkableRun
+	for _, way := range data.Ways {
+		if !osmWayIsWalkable(way.Tags) {
+			continue
+		}
+		var current []int64
+		flush := func() {
+			if len(current) >= 2 {
+				runs = append(runs, walkableRun{way: way, nodeIDs: current})
+			}
+			current = nil
+		}
+		for _, nodeID := range way.NodeIDs {
+			if _, ok := data.Coords[nodeID]; !ok {
+				flush()
+				continue
+			}
+			current = append(current, nodeID)
+		}
+		flush()
+	}
+
+	uses := map[int64]int{}
+	junctions := map[int64]bool{}
+	for _, run := range runs {
+		for i, nodeID := range run.nodeIDs {
+			uses[nodeID]++
+			if i == 0 || i == len(run.nodeIDs)-1 {
+				junctions[nodeID] = true
+			}
+		}
+		// A closed way would otherwise collapse into a single self-loop, so
+		// it is cut in thirds.
+		if last := len(run.nodeIDs) - 1; last >= 3 && run.nodeIDs[0] == run.nodeIDs[last] {
+			junctions[run.nodeIDs[last/3]] = true
+			junctions[run.nodeIDs[2*last/3]] = true
+		}
+	}
+	for nodeID, count := range uses {
+		if count > 1 {
+			junctions[nodeID] = true
+		}
+	}
+
+	seen := map[osmWalkingEdgeKey]int{}
+	usedNodes := map[int64]bool{}
+	var edges []models.WalkingGraphEdge
+	for _, run := range runs {
+		start := run.nodeIDs[0]
+		length := 0.0
+		for i := 1; i < len(run.nodeIDs); i++ {
+			previous := data.Coords[run.nodeIDs[i-1]]
+			nodeID := run.nodeIDs[i]
+			coord := data.Coords[nodeID]
+			length += haversineDistance(previous.Lat, previous.Lng, coord.Lat, coord.Lng)
+			if !junctions[nodeID] {
+				continue
+			}
+			if nodeID != start {
+				from, to := start, nodeID
+				if from > to {
+					from, to = to, from
+				}
+				key := osmWalkingEdgeKey{wayID: run.way.ID, from: from, to: to}
+				if index, ok := seen[key]; ok {
+					// Parallel stretches of one way between the same
+					// junctions; routing only needs the shorter one.
+					if length < edges[index].LengthMeters {
+						edges[index].LengthMeters = length
+					}
+				} else {
+					seen[key] = len(edges)
+					usedNodes[from] = true
+					usedNodes[to] = true
+					edges = append(edges, models.WalkingGraphEdge{
+						FromNodeID:   from,
+						ToNodeID:     to,
+						OSMWayID:     run.way.ID,
+						Highway:      run.way.Tags["highway"],
+						LengthMeters: length,
+					})
+				}
+			}
+			start = nodeID
+			length = 0
+		}
+	}
+
+	nodes := make([]models.WalkingGraphNode, 0, len(usedNodes))
+	for nodeID := range usedNodes {
+		coord := data.Coords[nodeID]
+		nodes = append(nodes, models.WalkingGraphNode{
+			ID:        nodeID,
+			Latitude:  coord.Lat,
+			Longitude: coord.Lng,
+		})
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID < nodes[j].ID })
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].OSMWayID != edges[j].OSMWayID {
+			return edges[i].OSMWayID < edges[j].OSMWayID
+		}
+		if edges[i].FromNodeID != edges[j].FromNodeID {
+			return edges[i].FromNodeID < edges[j].FromNodeID
+		}
+		return edges[i].ToNodeID < edges[j].ToNodeID
+	})
+	return nodes, edges
+}
@@ -0,0 +1,67 @@
+package locationseeder
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBuildWalkingGraphCollapsesWaysToJunctions(t *testing.T) {
+	data := &OSMData{
+		Coords: map[int64]OSMCoord{
+			1: {Lat: 47.6000, Lng: -122.3000},
+			2: {Lat: 47.6010, Lng: -122.3000},
+			3: {Lat: 47.6020, Lng: -122.3000},
+			4: {Lat: 47.6020, Lng: -122.2990},
+			5: {Lat: 47.6020, Lng: -122.3010},
+			6: {Lat: 47.6030, Lng: -122.3000},
+		},
+		Ways: []OSMWay{
+			{ID: 10, NodeIDs: []int64{1, 2, 3}, Tags: map[string]string{"highway": "residential"}},
+			{ID: 11, NodeIDs: []int64{5, 3, 4}, Tags: map[string]string{"highway": "footway"}},
+			{ID: 12, NodeIDs: []int64{3, 6}, Tags: map[string]string{"highway": "motorway"}},
+			{ID: 13, NodeIDs: []int64{3, 6}, Tags: map[string]string{"highway": "residential", "access": "private"}},
+		},
+	}
+
+	nodes, edges := BuildWalkingGraph(data)
+	if len(edges) != 3 {
+		t.Fatalf("expected 3 edges, got %d: %+v", len(edges), edges)
+	}
+	if len(nodes) != 4 {
+		t.Fatalf("expected junction nodes 1, 3, 4 and 5, got %+v", nodes)
+	}
+	for _, node := range nodes {
+		if node.ID == 2 || node.ID == 6 {
+			t.Fatalf("unexpected node %d in graph", node.ID)
+		}
+	}
+
+	first := edges[0]
+	if first.OSMWayID != 10 || first.FromNodeID != 1 || first.ToNodeID != 3 {
+		t.Fatalf("unexpected first edge %+v", first)
+	}
+	want := haversineDistance(47.6000, -122.3000, 47.6020, -122.3000)
+	if math.Abs(first.LengthMeters-want) > 0.01 {
+		t.Fatalf("expected length %.2f, got %.2f", want, first.LengthMeters)
+	}
+}
+
+func TestBuildWalkingGraphKeepsClosedWays(t *testing.T) {
+	data := &OSMData{
+		Coords: map[int64]OSMCoord{
+			1: {Lat: 0, Lng: 0},
+			2: {Lat: 0, Lng: 0.001},
+			3: {Lat: 0.001, Lng: 0.001},
+			4: {Lat: 0.001, Lng: 0},
+		},
+		Ways: []OSMWay{
+			{ID: 20, NodeIDs: []int64{1, 2, 3, 4, 1}, Tags: map[string]string{"highway": "footway"}},
+			{ID: 21, NodeIDs: []int64{1, 99}, Tags: map[string]string{"highway": "path"}},
+		},
+	}
+
+	_, edges := BuildWalkingGraph(data)
+	if len(edges) != 3 {
+		t.Fatalf("expected the loop to split into 3 edges, got %+v", edges)
+	}
+}
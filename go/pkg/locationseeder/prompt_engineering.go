@@ -7,151 +7,54 @@ import (
 	"log"
 	"strings"
 
+	"github.com/MaxBlaushild/poltergeist/pkg/db"
 	"github.com/MaxBlaushild/poltergeist/pkg/deep_priest"
 	"github.com/MaxBlaushild/poltergeist/pkg/googlemaps"
 	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/google/uuid"
 )
 
 type PromptText struct {
 	Text string `json:"text"`
 }
 
-const premise = `
-	You are a video game designer tasked with converting real-world locations into points of interest on a fantasy RPG map.
-
-	Describe how %s would appear if it was in a fantasy role playing video game.
-
-	An editorial summary of %s is: %s.
-
-	Some categories that people use to describe %s are: %v.
-
-	The sophistication of %s is considered to be %s.
-
-	Here is a bit about the region %s is in:
-
-	Name: %s
-	Description: %s
-
-	%s
-
-	Do not use the location's name in your response.
-`
-
-const genrePremiseTemplate = `
-	You are a video game designer tasked with converting real-world locations into points of interest on a %s RPG map.
-
-	Describe how %s would appear if it was in a %s role playing video game.
-
-	An editorial summary of %s is: %s.
-
-	Some categories that people use to describe %s are: %v.
-
-	The sophistication of %s is considered to be %s.
-
-	Here is a bit about the region %s is in:
-
-	Name: %s
-	Description: %s
-
-	%s
-
-	Genre direction:
-	- genre: %s
-	- creative seed: %s
-
-	Additional rules:
-	- Keep the response unmistakably rooted in %s conventions rather than default fantasy.
-	- Use genre-appropriate naming, lore, props, factions, and atmosphere.
-	- Do not use the location's name in your response.
-`
-
-const generateFantasyPointOfInterestPromptTemplate = premise + `
-	Please try to keep the description to 50 words or less.
-
-	Please format your response as a JSON object with the following fields:
-	
-	{
-		"name": "string", // The fantasy name of the point of interest
-		"description": "string", // A description of the appearance of the fantasy point of interest and a bit of made up lore about it
-		"clue": "string", // A clue that can be used to find the point of interest in the real world
-	}
-`
-
-const generateGenrePointOfInterestPromptTemplate = genrePremiseTemplate + `
-	Please try to keep the description to 50 words or less.
-
-	Please format your response as a JSON object with the following fields:
-	
-	{
-		"name": "string", // The genre-appropriate name of the point of interest
-		"description": "string", // A description of the appearance of the point of interest and a bit of made up lore about it
-		"clue": "string", // A clue that can be used to find the point of interest in the real world
-	}
-`
-
-const generateFantasyImagePromptPromptTemplate = premise + `
-	Please describe how the location would look from the outside if it was in a fantasy role playing video game.
-
-	The image should match the aesthetic of retro 16-bit RPG pixel art item and character images:
-	- Crisp outlines, limited color palette, clean background
-	- Centered subject, readable silhouette
-	- No text, no logos, no UI
-	- Exterior view, 3/4 angle or slight isometric perspective
-	- Keep the prompt focused on a single iconic exterior scene
-
-	Please format your response as a JSON object with the following fields:
-	
-	{
-		"text": "string", // A single concise image prompt in the above style
-	}
-`
-
-const generateGenreImagePromptPromptTemplate = genrePremiseTemplate + `
-	Please describe how the location would look from the outside if it was in a %s role playing video game.
-
-	The image should match the aesthetic of retro 16-bit RPG pixel art item and character images:
-	- Crisp outlines, limited color palette, clean background
-	- Centered subject, readable silhouette
-	- No text, no logos, no UI
-	- Exterior view, 3/4 angle or slight isometric perspective
-	- Keep the prompt focused on a single iconic exterior scene
-
-	Please format your response as a JSON object with the following fields:
-	
-	{
-		"text": "string", // A single concise image prompt in the above style
-	}
-`
-
 // const generateFantasyImagePromptTemplate = premise + `
 // 	The goal is to take these real-world values and translate them into fantasy-themed locations while maintaining their core concept but enhancing them with magical, mythical, and pixelated video game-style elements. Each location should evoke a sense of nostalgia for retro video games, with blocky shapes, pixelated visuals, and vibrant colors that evoke classic RPG vibes.
 // `
 
 const style = ""
 
-func (c *client) generatePointOfInterestTheming(ctx context.Context, place googlemaps.Place, zone *models.Zone, genre *models.ZoneGenre) (*GeneratedPointOfInterest, error) {
+// generatePointOfInterestTheming also returns the registry prompt the theming
+// came from so callers can record it once the point of interest is saved.
+func (c *client) generatePointOfInterestTheming(ctx context.Context, place googlemaps.Place, zone *models.Zone, genre *models.ZoneGenre) (*GeneratedPointOfInterest, db.ResolvedPrompt, error) {
 	zoneKind, err := c.resolvePointOfInterestZoneKind(ctx, zone)
 	if err != nil {
 		log.Printf("Error resolving point of interest zone kind: %v", err)
-		return nil, err
+		return nil, db.ResolvedPrompt{}, err
+	}
+
+	prompt, err := c.makePointOfInterestThemingPrompt(ctx, place, zone, genre, zoneKind)
+	if err != nil {
+		log.Printf("Error resolving point of interest theming prompt: %v", err)
+		return nil, db.ResolvedPrompt{}, err
 	}
 
 	answer, err := c.deepPriest.PetitionTheFount(&deep_priest.Question{
-		Question: c.makePointOfInterestThemingPrompt(place, zone, genre, zoneKind),
+		Question: prompt.Text,
 	})
 	if err != nil {
 		log.Printf("Error getting response from DeepPriest: %v", err)
-		return nil, err
+		return nil, db.ResolvedPrompt{}, err
 	}
 
 	var generatedPointOfInterest GeneratedPointOfInterest
 	if err := json.Unmarshal([]byte(answer.Answer), &generatedPointOfInterest); err != nil {
 		log.Printf("Error unmarshaling generated point of interest: %v", err)
-		return nil, err
+		return nil, db.ResolvedPrompt{}, err
 	}
 
 	log.Printf("Successfully generated point of interest theming")
-	return &generatedPointOfInterest, nil
+	return &generatedPointOfInterest, prompt, nil
 }
 
 func (c *client) generatePointOfInterestImage(ctx context.Context, place googlemaps.Place, zone *models.Zone, genre *models.ZoneGenre) (string, error) {
@@ -191,8 +94,14 @@ func (c *client) generatePointOfInterestImagePrompt(ctx context.Context, place g
 		return "", err
 	}
 
+	prompt, err := c.makePointOfInterestImagePromptPrompt(ctx, place, zone, genre, zoneKind)
+	if err != nil {
+		log.Printf("Error resolving point of interest image prompt: %v", err)
+		return "", err
+	}
+
 	answer, err := c.deepPriest.PetitionTheFount(&deep_priest.Question{
-		Question: c.makePointOfInterestImagePromptPrompt(place, zone, genre, zoneKind),
+		Question: prompt.Text,
 	})
 	if err != nil {
 		log.Printf("Error getting response from DeepPriest: %v", err)
@@ -222,7 +131,7 @@ func zoneDescriptionForPointOfInterestPrompt(zone *models.Zone) string {
 	return zone.Description
 }
 
-func zoneKindPromptBlock(zoneKind *models.ZoneKind) string {
+func (c *client) zoneKindPromptBlock(ctx context.Context, zoneKind *models.ZoneKind) string {
 	if zoneKind == nil {
 		return ""
 	}
@@ -240,20 +149,17 @@ func zoneKindPromptBlock(zoneKind *models.ZoneKind) string {
 		)
 	}
 
-	return fmt.Sprintf(
-		`Zone kind direction:
-	- zone kind: %s
-	- slug: %s
-	- creative seed: %s
-
-	Additional zone-kind rules:
-	- Make the location feel natively at home in %s spaces.
-	- Let the fantasy name, lore, architecture, props, and image cues reflect that zone kind while still honoring the real-world location.`,
-		label,
-		slug,
-		seed,
-		strings.ToLower(label),
-	)
+	resolved, err := db.ResolvePrompt(ctx, c.dbClient, models.PromptKeyPointOfInterestZoneKindDirection, nil, slug, map[string]interface{}{
+		"ZoneKind":      label,
+		"ZoneKindSlug":  slug,
+		"CreativeSeed":  seed,
+		"ZoneKindLower": strings.ToLower(label),
+	})
+	if err != nil {
+		log.Printf("Error resolving point of interest zone kind direction: %v", err)
+		return ""
+	}
+	return resolved.Text
 }
 
 func isBaselineFantasyPointOfInterestGenre(genre *models.ZoneGenre) bool {
@@ -304,98 +210,64 @@ func pointOfInterestGenrePromptSeedOrFallback(genre *models.ZoneGenre) string {
 	)
 }
 
-func (c *client) makePointOfInterestImagePromptPrompt(place googlemaps.Place, zone *models.Zone, genre *models.ZoneGenre, zoneKind *models.ZoneKind) string {
-	zoneKindBlock := zoneKindPromptBlock(zoneKind)
-	if isBaselineFantasyPointOfInterestGenre(genre) {
-		return fmt.Sprintf(
-			generateFantasyImagePromptPromptTemplate,
-			place.DisplayName.Text,
-			place.DisplayName.Text,
-			place.EditorialSummary.Text,
-			place.DisplayName.Text,
-			place.Types,
-			place.DisplayName.Text,
-			c.generateSophistication(place),
-			place.DisplayName.Text,
-			zoneNameForPointOfInterestPrompt(zone),
-			zoneDescriptionForPointOfInterestPrompt(zone),
-			zoneKindBlock,
-		)
-	}
-	genreLabel := pointOfInterestGenrePromptLabel(genre)
-	promptSeed := pointOfInterestGenrePromptSeedOrFallback(genre)
-	return fmt.Sprintf(
-		generateGenreImagePromptPromptTemplate,
-		genreLabel,
-		place.DisplayName.Text,
-		genreLabel,
-		place.DisplayName.Text,
-		place.EditorialSummary.Text,
-		place.DisplayName.Text,
-		place.Types,
-		place.DisplayName.Text,
-		c.generateSophistication(place),
-		place.DisplayName.Text,
-		zoneNameForPointOfInterestPrompt(zone),
-		zoneDescriptionForPointOfInterestPrompt(zone),
-		zoneKindBlock,
-		genreLabel,
-		promptSeed,
-		genreLabel,
-		genreLabel,
+func (c *client) makePointOfInterestImagePromptPrompt(ctx context.Context, place googlemaps.Place, zone *models.Zone, genre *models.ZoneGenre, zoneKind *models.ZoneKind) (db.ResolvedPrompt, error) {
+	return c.resolvePointOfInterestPrompt(
+		ctx,
+		models.PromptKeyPointOfInterestImagePrompt,
+		models.PromptKeyGenrePointOfInterestImagePrompt,
+		place,
+		zone,
+		genre,
+		zoneKind,
 	)
 }
 
-// func (c *client) makeFantasyImagePrompt(place googlemaps.Place) string {
-// 	prompt := fmt.Sprintf(
-// 		generateFantasyImagePromptTemplate,
-// 		place.DisplayName.Text,
-// 		place.Types,
-// 		c.generateSophistication(place),
-// 	)
-// 	log.Printf("Generated fantasy image prompt: %s", prompt)
-// 	return prompt
-// }
-
-func (c *client) makePointOfInterestThemingPrompt(place googlemaps.Place, zone *models.Zone, genre *models.ZoneGenre, zoneKind *models.ZoneKind) string {
-	zoneKindBlock := zoneKindPromptBlock(zoneKind)
+func (c *client) makePointOfInterestThemingPrompt(ctx context.Context, place googlemaps.Place, zone *models.Zone, genre *models.ZoneGenre, zoneKind *models.ZoneKind) (db.ResolvedPrompt, error) {
+	return c.resolvePointOfInterestPrompt(
+		ctx,
+		models.PromptKeyPointOfInterestTheming,
+		models.PromptKeyGenrePointOfInterestTheming,
+		place,
+		zone,
+		genre,
+		zoneKind,
+	)
+}
+
+// resolvePointOfInterestPrompt renders the fantasy prompt, or the genre
+// prompt when the genre moves away from baseline fantasy.
+func (c *client) resolvePointOfInterestPrompt(
+	ctx context.Context,
+	fantasyKey models.PromptKey,
+	genreKey models.PromptKey,
+	place googlemaps.Place,
+	zone *models.Zone,
+	genre *models.ZoneGenre,
+	zoneKind *models.ZoneKind,
+) (db.ResolvedPrompt, error) {
+	variables := map[string]interface{}{
+		"PlaceName":         place.DisplayName.Text,
+		"EditorialSummary":  place.EditorialSummary.Text,
+		"Categories":        fmt.Sprintf("%v", place.Types),
+		"Sophistication":    c.generateSophistication(place),
+		"ZoneName":          zoneNameForPointOfInterestPrompt(zone),
+		"ZoneDescription":   zoneDescriptionForPointOfInterestPrompt(zone),
+		"ZoneKindDirection": c.zoneKindPromptBlock(ctx, zoneKind),
+	}
+	zoneKindSlug := ""
+	if zoneKind != nil {
+		zoneKindSlug = strings.TrimSpace(models.ZoneKindPromptSlug(zoneKind))
+	}
 	if isBaselineFantasyPointOfInterestGenre(genre) {
-		return fmt.Sprintf(
-			generateFantasyPointOfInterestPromptTemplate,
-			place.DisplayName.Text,
-			place.DisplayName.Text,
-			place.EditorialSummary.Text,
-			place.DisplayName.Text,
-			place.Types,
-			place.DisplayName.Text,
-			c.generateSophistication(place),
-			place.DisplayName.Text,
-			zoneNameForPointOfInterestPrompt(zone),
-			zoneDescriptionForPointOfInterestPrompt(zone),
-			zoneKindBlock,
-		)
+		var genreID *uuid.UUID
+		if genre != nil {
+			genreID = &genre.ID
+		}
+		return db.ResolvePrompt(ctx, c.dbClient, fantasyKey, genreID, zoneKindSlug, variables)
 	}
-	genreLabel := pointOfInterestGenrePromptLabel(genre)
-	promptSeed := pointOfInterestGenrePromptSeedOrFallback(genre)
-	return fmt.Sprintf(
-		generateGenrePointOfInterestPromptTemplate,
-		genreLabel,
-		place.DisplayName.Text,
-		genreLabel,
-		place.DisplayName.Text,
-		place.EditorialSummary.Text,
-		place.DisplayName.Text,
-		place.Types,
-		place.DisplayName.Text,
-		c.generateSophistication(place),
-		place.DisplayName.Text,
-		zoneNameForPointOfInterestPrompt(zone),
-		zoneDescriptionForPointOfInterestPrompt(zone),
-		zoneKindBlock,
-		genreLabel,
-		promptSeed,
-		genreLabel,
-	)
+	variables["Genre"] = pointOfInterestGenrePromptLabel(genre)
+	variables["CreativeSeed"] = pointOfInterestGenrePromptSeedOrFallback(genre)
+	return db.ResolvePrompt(ctx, c.dbClient, genreKey, &genre.ID, zoneKindSlug, variables)
 }
 
 func (c *client) generateSophistication(place googlemaps.Place) string {
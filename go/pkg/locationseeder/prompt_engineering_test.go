@@ -1,6 +1,7 @@
 package locationseeder
 
 import (
+	"context"
 	"strings"
 	"testing"
 
@@ -35,7 +36,10 @@ func TestMakePointOfInterestThemingPromptIncludesZoneKindDirection(t *testing.T)
 		Description: "Wild, herbal, canopy-heavy places with old shrine roots and beast-haunted trails.",
 	}
 
-	prompt := client.makePointOfInterestThemingPrompt(place, zone, genre, zoneKind)
+	prompt, err := client.makePointOfInterestThemingPrompt(context.Background(), place, zone, genre, zoneKind)
+	if err != nil {
+		t.Fatalf("makePointOfInterestThemingPrompt: %v", err)
+	}
 
 	for _, needle := range []string{
 		"Zone kind direction:",
@@ -44,8 +48,8 @@ func TestMakePointOfInterestThemingPromptIncludesZoneKindDirection(t *testing.T)
 		"Wild, herbal, canopy-heavy places with old shrine roots and beast-haunted trails.",
 		"Make the location feel natively at home in forest spaces.",
 	} {
-		if !strings.Contains(prompt, needle) {
-			t.Fatalf("expected prompt to include %q, got:\n%s", needle, prompt)
+		if !strings.Contains(prompt.Text, needle) {
+			t.Fatalf("expected prompt to include %q, got:\n%s", needle, prompt.Text)
 		}
 	}
 }
@@ -73,7 +77,10 @@ func TestMakePointOfInterestImagePromptIncludesZoneKindDirection(t *testing.T) {
 		Description: "Harsh, mechanical, forge-lit places with soot, rivets, pipes, and laboring crews.",
 	}
 
-	prompt := client.makePointOfInterestImagePromptPrompt(place, zone, genre, zoneKind)
+	prompt, err := client.makePointOfInterestImagePromptPrompt(context.Background(), place, zone, genre, zoneKind)
+	if err != nil {
+		t.Fatalf("makePointOfInterestImagePromptPrompt: %v", err)
+	}
 
 	for _, needle := range []string{
 		"Zone kind direction:",
@@ -82,8 +89,8 @@ func TestMakePointOfInterestImagePromptIncludesZoneKindDirection(t *testing.T) {
 		"Harsh, mechanical, forge-lit places with soot, rivets, pipes, and laboring crews.",
 		"Let the fantasy name, lore, architecture, props, and image cues reflect that zone kind",
 	} {
-		if !strings.Contains(prompt, needle) {
-			t.Fatalf("expected prompt to include %q, got:\n%s", needle, prompt)
+		if !strings.Contains(prompt.Text, needle) {
+			t.Fatalf("expected prompt to include %q, got:\n%s", needle, prompt.Text)
 		}
 	}
 }
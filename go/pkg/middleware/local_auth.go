@@ -0,0 +1,488 @@
+package middleware
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"log"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/auth"
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	defaultUserCacheTTL        = 30 * time.Second
+	defaultUserCacheSize       = 10000
+	defaultJWKSRefreshInterval = 10 * time.Minute
+	defaultRevokedSessionTTL   = time.Hour
+	// unknownKeyRefreshInterval throttles JWKS refetches triggered by tokens
+	// carrying a key ID we have not seen, so garbage tokens cannot turn
+	// into a flood of requests to the authenticator.
+	unknownKeyRefreshInterval = 30 * time.Second
+	eventsReconnectDelay      = 5 * time.Second
+)
+
+var (
+	errSessionRevoked = errors.New("session has been revoked")
+	errTokenRejected  = errors.New("token rejected")
+)
+
+// authVerifierMetrics is published through expvar as "auth_verifier".
+var authVerifierMetrics = expvar.NewMap("auth_verifier")
+
+// AuthVerifierStats is a snapshot of how requests were authenticated since
+// the process started.
+type AuthVerifierStats struct {
+	LocalVerifications   int64   `json:"localVerifications"`
+	HTTPFallbacks        int64   `json:"httpFallbacks"`
+	UserCacheHits        int64   `json:"userCacheHits"`
+	UserCacheMisses      int64   `json:"userCacheMisses"`
+	UserCacheHitRate     float64 `json:"userCacheHitRate"`
+	UserInvalidations    int64   `json:"userInvalidations"`
+	RevokedSessionDenied int64   `json:"revokedSessionDenied"`
+	JWKSRefreshes        int64   `json:"jwksRefreshes"`
+}
+
+func GetAuthVerifierStats() AuthVerifierStats {
+	stats := AuthVerifierStats{
+		LocalVerifications:   metricValue("local_verifications"),
+		HTTPFallbacks:        metricValue("http_fallbacks"),
+		UserCacheHits:        metricValue("user_cache_hits"),
+		UserCacheMisses:      metricValue("user_cache_misses"),
+		UserInvalidations:    metricValue("user_invalidations"),
+		RevokedSessionDenied: metricValue("revoked_session_denied"),
+		JWKSRefreshes:        metricValue("jwks_refreshes"),
+	}
+	if lookups := stats.UserCacheHits + stats.UserCacheMisses; lookups > 0 {
+		stats.UserCacheHitRate = float64(stats.UserCacheHits) / float64(lookups)
+	}
+	return stats
+}
+
+func metricValue(name string) int64 {
+	if v, ok := authVerifierMetrics.Get(name).(*expvar.Int); ok {
+		return v.Value()
+	}
+	return 0
+}
+
+// LocalVerificationConfig tunes NewLocallyVerifyingAuthClient. Zero values
+// fall back to the defaults above.
+type LocalVerificationConfig struct {
+	// RedisClient receives user and session events from the authenticator.
+	// Without it cached users live out their TTL and revoked sessions are
+	// only caught once their access tokens expire.
+	RedisClient         *redis.Client
+	UserCacheTTL        time.Duration
+	UserCacheSize       int
+	JWKSRefreshInterval time.Duration
+	// RevokedSessionTTL should be at least the authenticator's access
+	// token lifetime.
+	RevokedSessionTTL time.Duration
+}
+
+type cachedUser struct {
+	user      models.User
+	expiresAt time.Time
+}
+
+// localVerifier checks access token signatures against the authenticator's
+// JWKS and serves users from a short-lived cache. Anything it cannot decide
+// on its own — legacy tokens, unknown keys, cache misses, an unreachable
+// JWKS — goes to the authenticator over HTTP exactly as before.
+type localVerifier struct {
+	auth.Client
+	config LocalVerificationConfig
+
+	keysMu        sync.RWMutex
+	keys          map[string]*ecdsa.PublicKey
+	keysFetchedAt time.Time
+	lastRefreshAt time.Time
+
+	cacheMu         sync.Mutex
+	users           map[uuid.UUID]cachedUser
+	revokedSessions map[uuid.UUID]time.Time
+	// rolesChangedAt marks users whose role grants changed; scopes in
+	// their tokens issued before then are stale.
+	rolesChangedAt map[uuid.UUID]time.Time
+}
+
+// localClaims is what verifyLocally vouches for.
+type localClaims struct {
+	userID    uuid.UUID
+	sessionID uuid.UUID
+	issuedAt  time.Time
+	scopes    []string
+}
+
+// NewLocallyVerifyingAuthClient wraps authClient so VerifyToken, and with it
+// WithAuthentication, no longer needs a round trip to the authenticator for
+// every request. Every other method is passed through unchanged.
+func NewLocallyVerifyingAuthClient(authClient auth.Client, config LocalVerificationConfig) auth.Client {
+	if config.UserCacheTTL <= 0 {
+		config.UserCacheTTL = defaultUserCacheTTL
+	}
+	if config.UserCacheSize <= 0 {
+		config.UserCacheSize = defaultUserCacheSize
+	}
+	if config.JWKSRefreshInterval <= 0 {
+		config.JWKSRefreshInterval = defaultJWKSRefreshInterval
+	}
+	if config.RevokedSessionTTL <= 0 {
+		config.RevokedSessionTTL = defaultRevokedSessionTTL
+	}
+
+	v := &localVerifier{
+		Client:          authClient,
+		config:          config,
+		users:           map[uuid.UUID]cachedUser{},
+		revokedSessions: map[uuid.UUID]time.Time{},
+		rolesChangedAt:  map[uuid.UUID]time.Time{},
+	}
+	if config.RedisClient != nil {
+		go v.listenForEvents(context.Background())
+	}
+	return v
+}
+
+func (v *localVerifier) VerifyToken(ctx context.Context, request *auth.VerifyTokenRequest) (*models.User, error) {
+	claims, err := v.verifyLocally(ctx, request.Token)
+	if err != nil {
+		if errors.Is(err, errSessionRevoked) {
+			authVerifierMetrics.Add("revoked_session_denied", 1)
+		}
+		return nil, err
+	}
+	if claims == nil {
+		authVerifierMetrics.Add("http_fallbacks", 1)
+		return v.Client.VerifyToken(ctx, request)
+	}
+	if v.scopesStale(claims) {
+		// Current scopes depend on whether the token's session passed a
+		// second factor, which only the authenticator knows.
+		authVerifierMetrics.Add("http_fallbacks", 1)
+		return v.Client.VerifyToken(ctx, request)
+	}
+	authVerifierMetrics.Add("local_verifications", 1)
+
+	if user := v.cachedUser(claims.userID); user != nil {
+		authVerifierMetrics.Add("user_cache_hits", 1)
+		user.Scopes = claims.scopes
+		return user, nil
+	}
+	authVerifierMetrics.Add("user_cache_misses", 1)
+
+	// The authenticator re-checks the session and loads the user in one go.
+	user, err := v.Client.VerifyToken(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	if user.ID == claims.userID && !v.sessionRevoked(claims.sessionID) {
+		v.cacheUser(user)
+	}
+	user.Scopes = claims.scopes
+	return user, nil
+}
+
+// scopesStale reports whether the user's roles or second factor changed
+// after the token was minted, so the scopes it carries can't be trusted.
+func (v *localVerifier) scopesStale(claims *localClaims) bool {
+	v.cacheMu.Lock()
+	defer v.cacheMu.Unlock()
+	changedAt, changed := v.rolesChangedAt[claims.userID]
+	return changed && !claims.issuedAt.After(changedAt)
+}
+
+// verifyLocally returns the token's claims when it can vouch for the token
+// itself, an error when the token is definitely bad, and nothing when the
+// authenticator has to decide.
+func (v *localVerifier) verifyLocally(ctx context.Context, tokenString string) (*localClaims, error) {
+	if !v.ensureKeys(ctx) {
+		return nil, nil
+	}
+
+	var unknownKey bool
+	token, err := jwt.Parse(tokenString, func(t *jwt.Token) (interface{}, error) {
+		if t.Method != jwt.SigningMethodES256 {
+			return nil, errTokenRejected
+		}
+		keyID, ok := t.Header["kid"].(string)
+		if !ok {
+			unknownKey = true
+			return nil, errTokenRejected
+		}
+		key := v.key(ctx, keyID)
+		if key == nil {
+			unknownKey = true
+			return nil, errTokenRejected
+		}
+		return key, nil
+	})
+	if unknownKey {
+		return nil, nil
+	}
+	if err != nil {
+		var validationErr *jwt.ValidationError
+		if errors.As(err, &validationErr) && validationErr.Errors&(jwt.ValidationErrorExpired|jwt.ValidationErrorSignatureInvalid|jwt.ValidationErrorMalformed|jwt.ValidationErrorNotValidYet) != 0 {
+			return nil, errTokenRejected
+		}
+		return nil, nil
+	}
+	if !token.Valid {
+		return nil, errTokenRejected
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, nil
+	}
+	userID, userOK := uuidClaim(claims, "userID")
+	sessionID, sessionOK := uuidClaim(claims, "sid")
+	if _, hasExpiry := claims["exp"]; !userOK || !sessionOK || !hasExpiry {
+		return nil, nil
+	}
+	if v.sessionRevoked(sessionID) {
+		return nil, errSessionRevoked
+	}
+
+	verified := &localClaims{userID: userID, sessionID: sessionID}
+	if issuedAt, ok := claims["iat"].(float64); ok {
+		verified.issuedAt = time.Unix(int64(issuedAt), 0)
+	}
+	if scopes, ok := claims["scopes"].([]interface{}); ok {
+		for _, scope := range scopes {
+			if str, ok := scope.(string); ok {
+				verified.scopes = append(verified.scopes, str)
+			}
+		}
+	}
+	return verified, nil
+}
+
+func uuidClaim(claims jwt.MapClaims, key string) (uuid.UUID, bool) {
+	raw, ok := claims[key].(string)
+	if !ok {
+		return uuid.Nil, false
+	}
+	id, err := uuid.Parse(raw)
+	return id, err == nil
+}
+
+// ensureKeys loads the JWKS on first use and refreshes it periodically. A
+// failed refresh keeps the keys we already have.
+func (v *localVerifier) ensureKeys(ctx context.Context) bool {
+	v.keysMu.RLock()
+	fresh := v.keys != nil && time.Since(v.keysFetchedAt) < v.config.JWKSRefreshInterval
+	haveKeys := v.keys != nil
+	v.keysMu.RUnlock()
+	if fresh {
+		return true
+	}
+	if v.refreshKeys(ctx) {
+		return true
+	}
+	return haveKeys
+}
+
+// key looks up a signing key, refetching the JWKS once in a while when the
+// authenticator has rotated to a key we do not know yet.
+func (v *localVerifier) key(ctx context.Context, keyID string) *ecdsa.PublicKey {
+	v.keysMu.RLock()
+	key := v.keys[keyID]
+	canRefresh := time.Since(v.lastRefreshAt) >= unknownKeyRefreshInterval
+	v.keysMu.RUnlock()
+	if key != nil || !canRefresh {
+		return key
+	}
+	v.refreshKeys(ctx)
+
+	v.keysMu.RLock()
+	defer v.keysMu.RUnlock()
+	return v.keys[keyID]
+}
+
+func (v *localVerifier) refreshKeys(ctx context.Context) bool {
+	v.keysMu.Lock()
+	v.lastRefreshAt = time.Now()
+	v.keysMu.Unlock()
+
+	jwks, err := v.Client.GetJWKS(ctx)
+	if err != nil {
+		log.Printf("[auth][jwks] fetch failed err=%v", err)
+		return false
+	}
+	keys, err := parseJWKS(jwks)
+	if err != nil {
+		log.Printf("[auth][jwks] parse failed err=%v", err)
+		return false
+	}
+	authVerifierMetrics.Add("jwks_refreshes", 1)
+
+	v.keysMu.Lock()
+	v.keys = keys
+	v.keysFetchedAt = time.Now()
+	v.keysMu.Unlock()
+	return true
+}
+
+func parseJWKS(jwks *auth.JWKS) (map[string]*ecdsa.PublicKey, error) {
+	keys := map[string]*ecdsa.PublicKey{}
+	for _, jwk := range jwks.Keys {
+		if jwk.KeyType != "EC" || jwk.Curve != "P-256" || jwk.KeyID == "" {
+			continue
+		}
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(jwk.Y)
+		if err != nil {
+			return nil, err
+		}
+		key := &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}
+		if !key.Curve.IsOnCurve(key.X, key.Y) {
+			return nil, errors.New("jwks key " + jwk.KeyID + " is not on P-256")
+		}
+		keys[jwk.KeyID] = key
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("jwks has no usable keys")
+	}
+	return keys, nil
+}
+
+// cachedUser returns a copy so handlers can't mutate the shared entry.
+func (v *localVerifier) cachedUser(userID uuid.UUID) *models.User {
+	v.cacheMu.Lock()
+	defer v.cacheMu.Unlock()
+	entry, ok := v.users[userID]
+	if !ok {
+		return nil
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(v.users, userID)
+		return nil
+	}
+	user := entry.user
+	return &user
+}
+
+func (v *localVerifier) cacheUser(user *models.User) {
+	v.cacheMu.Lock()
+	defer v.cacheMu.Unlock()
+	now := time.Now()
+	if len(v.users) >= v.config.UserCacheSize {
+		for id, entry := range v.users {
+			if now.After(entry.expiresAt) {
+				delete(v.users, id)
+			}
+		}
+		if len(v.users) >= v.config.UserCacheSize {
+			v.users = map[uuid.UUID]cachedUser{}
+		}
+	}
+	v.users[user.ID] = cachedUser{user: *user, expiresAt: now.Add(v.config.UserCacheTTL)}
+}
+
+func (v *localVerifier) invalidateUser(userID uuid.UUID) {
+	v.cacheMu.Lock()
+	defer v.cacheMu.Unlock()
+	if _, ok := v.users[userID]; ok {
+		delete(v.users, userID)
+		authVerifierMetrics.Add("user_invalidations", 1)
+	}
+}
+
+func (v *localVerifier) revokeSessions(sessionIDs []uuid.UUID) {
+	v.cacheMu.Lock()
+	defer v.cacheMu.Unlock()
+	now := time.Now()
+	for id, expiresAt := range v.revokedSessions {
+		if now.After(expiresAt) {
+			delete(v.revokedSessions, id)
+		}
+	}
+	for _, id := range sessionIDs {
+		v.revokedSessions[id] = now.Add(v.config.RevokedSessionTTL)
+	}
+}
+
+// rolesChanged is kept for RevokedSessionTTL, after which every token
+// issued before the change has expired.
+func (v *localVerifier) rolesChanged(userID uuid.UUID) {
+	v.cacheMu.Lock()
+	defer v.cacheMu.Unlock()
+	now := time.Now()
+	for id, changedAt := range v.rolesChangedAt {
+		if now.Sub(changedAt) > v.config.RevokedSessionTTL {
+			delete(v.rolesChangedAt, id)
+		}
+	}
+	v.rolesChangedAt[userID] = now
+}
+
+func (v *localVerifier) sessionRevoked(sessionID uuid.UUID) bool {
+	v.cacheMu.Lock()
+	defer v.cacheMu.Unlock()
+	expiresAt, ok := v.revokedSessions[sessionID]
+	return ok && time.Now().Before(expiresAt)
+}
+
+func (v *localVerifier) handleEvent(payload string) {
+	var event auth.Event
+	if err := json.Unmarshal([]byte(payload), &event); err != nil {
+		log.Printf("[auth][events] bad event payload=%q err=%v", payload, err)
+		return
+	}
+	switch event.Type {
+	case auth.EventTypeUserChanged:
+		v.invalidateUser(event.UserID)
+	case auth.EventTypeSessionsRevoked:
+		v.revokeSessions(event.SessionIDs)
+		v.invalidateUser(event.UserID)
+	case auth.EventTypeRolesChanged:
+		v.rolesChanged(event.UserID)
+		v.invalidateUser(event.UserID)
+	}
+}
+
+// listenForEvents keeps a subscription open for the life of the process.
+// While it is down the cache is cleared, since events may have been missed.
+func (v *localVerifier) listenForEvents(ctx context.Context) {
+	for {
+		pubsub := v.config.RedisClient.Subscribe(ctx, auth.EventsChannel)
+		if _, err := pubsub.Receive(ctx); err != nil {
+			log.Printf("[auth][events] subscribe failed err=%v", err)
+		} else {
+			for message := range pubsub.Channel() {
+				v.handleEvent(message.Payload)
+			}
+			log.Printf("[auth][events] subscription closed")
+		}
+		pubsub.Close()
+
+		v.cacheMu.Lock()
+		v.users = map[uuid.UUID]cachedUser{}
+		v.cacheMu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(eventsReconnectDelay):
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package middleware
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/auth"
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
+)
+
+type fakeAuthClient struct {
+	auth.Client
+	jwks        *auth.JWKS
+	user        models.User
+	verifyCalls int
+}
+
+func (f *fakeAuthClient) GetJWKS(ctx context.Context) (*auth.JWKS, error) {
+	return f.jwks, nil
+}
+
+func (f *fakeAuthClient) VerifyToken(ctx context.Context, request *auth.VerifyTokenRequest) (*models.User, error) {
+	f.verifyCalls++
+	user := f.user
+	return &user, nil
+}
+
+func newTestVerifier(t *testing.T) (*localVerifier, *fakeAuthClient, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake := &fakeAuthClient{
+		jwks: &auth.JWKS{Keys: []auth.JWK{{
+			KeyType: "EC",
+			Curve:   "P-256",
+			X:       base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, 32))),
+			Y:       base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, 32))),
+			KeyID:   "test-key",
+		}}},
+		user: models.User{ID: uuid.New(), Name: "Ada"},
+	}
+	return NewLocallyVerifyingAuthClient(fake, LocalVerificationConfig{}).(*localVerifier), fake, key
+}
+
+func signTestToken(t *testing.T, key *ecdsa.PrivateKey, keyID string, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+	if keyID != "" {
+		token.Header["kid"] = keyID
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestLocalVerifierCachesUsersAfterFirstLookup(t *testing.T) {
+	v, fake, key := newTestVerifier(t)
+	token := signTestToken(t, key, "test-key", jwt.MapClaims{
+		"userID": fake.user.ID.String(),
+		"sid":    uuid.New().String(),
+		"exp":    time.Now().Add(time.Minute).Unix(),
+	})
+
+	for i := 0; i < 3; i++ {
+		user, err := v.VerifyToken(context.Background(), &auth.VerifyTokenRequest{Token: token})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if user.ID != fake.user.ID {
+			t.Fatalf("expected user %s, got %s", fake.user.ID, user.ID)
+		}
+	}
+	if fake.verifyCalls != 1 {
+		t.Fatalf("expected one authenticator call, got %d", fake.verifyCalls)
+	}
+
+	payload, _ := json.Marshal(auth.Event{Type: auth.EventTypeUserChanged, UserID: fake.user.ID})
+	v.handleEvent(string(payload))
+	if _, err := v.VerifyToken(context.Background(), &auth.VerifyTokenRequest{Token: token}); err != nil {
+		t.Fatal(err)
+	}
+	if fake.verifyCalls != 2 {
+		t.Fatalf("expected the invalidated user to be reloaded, got %d calls", fake.verifyCalls)
+	}
+}
+
+func TestLocalVerifierRejectsRevokedAndExpiredTokens(t *testing.T) {
+	v, fake, key := newTestVerifier(t)
+	sessionID := uuid.New()
+	token := signTestToken(t, key, "test-key", jwt.MapClaims{
+		"userID": fake.user.ID.String(),
+		"sid":    sessionID.String(),
+		"exp":    time.Now().Add(time.Minute).Unix(),
+	})
+
+	payload, _ := json.Marshal(auth.Event{Type: auth.EventTypeSessionsRevoked, UserID: fake.user.ID, SessionIDs: []uuid.UUID{sessionID}})
+	v.handleEvent(string(payload))
+	if _, err := v.VerifyToken(context.Background(), &auth.VerifyTokenRequest{Token: token}); err == nil {
+		t.Fatal("expected revoked session to be rejected")
+	}
+
+	expired := signTestToken(t, key, "test-key", jwt.MapClaims{
+		"userID": fake.user.ID.String(),
+		"sid":    uuid.New().String(),
+		"exp":    time.Now().Add(-time.Minute).Unix(),
+	})
+	if _, err := v.VerifyToken(context.Background(), &auth.VerifyTokenRequest{Token: expired}); err == nil {
+		t.Fatal("expected expired token to be rejected")
+	}
+	if fake.verifyCalls != 0 {
+		t.Fatalf("expected no authenticator calls, got %d", fake.verifyCalls)
+	}
+}
+
+func TestLocalVerifierFallsBackForLegacyTokens(t *testing.T) {
+	v, fake, key := newTestVerifier(t)
+	legacy := signTestToken(t, key, "", jwt.MapClaims{"userID": fake.user.ID.String()})
+
+	for i := 0; i < 2; i++ {
+		if _, err := v.VerifyToken(context.Background(), &auth.VerifyTokenRequest{Token: legacy}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if fake.verifyCalls != 2 {
+		t.Fatalf("expected legacy tokens to always reach the authenticator, got %d calls", fake.verifyCalls)
+	}
+}
+
+func TestLocalVerifierDistrustsTokenScopesAfterRolesChange(t *testing.T) {
+	v, fake, key := newTestVerifier(t)
+	fake.user.Scopes = []string{"sonar:zones:read"}
+	token := signTestToken(t, key, "test-key", jwt.MapClaims{
+		"userID": fake.user.ID.String(),
+		"sid":    uuid.New().String(),
+		"iat":    time.Now().Add(-time.Minute).Unix(),
+		"exp":    time.Now().Add(time.Minute).Unix(),
+		"scopes": []string{"sonar:*"},
+	})
+
+	user, err := v.VerifyToken(context.Background(), &auth.VerifyTokenRequest{Token: token})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !user.HasScopes("sonar:zones:write") {
+		t.Fatalf("expected the token's scopes, got %v", user.Scopes)
+	}
+
+	payload, _ := json.Marshal(auth.Event{Type: auth.EventTypeRolesChanged, UserID: fake.user.ID})
+	v.handleEvent(string(payload))
+	user, err = v.VerifyToken(context.Background(), &auth.VerifyTokenRequest{Token: token})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.HasScopes("sonar:zones:write") {
+		t.Fatalf("expected the authenticator's current scopes, got %v", user.Scopes)
+	}
+
+	// Every request on the stale token goes back to the authenticator,
+	// since the answer depends on the token's session.
+	calls := fake.verifyCalls
+	if _, err := v.VerifyToken(context.Background(), &auth.VerifyTokenRequest{Token: token}); err != nil {
+		t.Fatal(err)
+	}
+	if fake.verifyCalls != calls+1 {
+		t.Fatalf("expected the stale token to reach the authenticator, got %d calls", fake.verifyCalls-calls)
+	}
+}
@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func newScopedRouter(user models.User) (*gin.Engine, *[]*models.AuditItem) {
+	gin.SetMode(gin.TestMode)
+	var audited []*models.AuditItem
+	audit := func(ctx context.Context, item *models.AuditItem) error {
+		audited = append(audited, item)
+		return nil
+	}
+	r := gin.New()
+	admin := r.Group("/sonar/admin", RequireRouteScopes(&fakeAuthClient{user: user}, audit, "sonar", "/sonar/admin"))
+	admin.GET("/zones/:id", func(ctx *gin.Context) { ctx.Status(http.StatusOK) })
+	admin.DELETE("/zones/:id", func(ctx *gin.Context) { ctx.Status(http.StatusNoContent) })
+	return r, &audited
+}
+
+func serve(r *gin.Engine, method string, path string, header string) int {
+	req := httptest.NewRequest(method, path, nil)
+	if header != "" {
+		req.Header.Set("Authorization", header)
+	}
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestRequireRouteScopesEnforcesReadAndWrite(t *testing.T) {
+	r, audited := newScopedRouter(models.User{ID: uuid.New(), Scopes: []string{"sonar:zones:read"}})
+
+	if code := serve(r, http.MethodGet, "/sonar/admin/zones/1", "Bearer t"); code != http.StatusOK {
+		t.Fatalf("expected read to be allowed, got %d", code)
+	}
+	if code := serve(r, http.MethodDelete, "/sonar/admin/zones/1", "Bearer t"); code != http.StatusForbidden {
+		t.Fatalf("expected write to be refused, got %d", code)
+	}
+	if code := serve(r, http.MethodGet, "/sonar/admin/zones/1", ""); code != http.StatusUnauthorized {
+		t.Fatalf("expected missing token to be refused, got %d", code)
+	}
+
+	if len(*audited) != 2 {
+		t.Fatalf("expected the allowed and refused calls to be audited, got %d", len(*audited))
+	}
+	if got := *(*audited)[0].Scope; got != "sonar:zones:read" {
+		t.Fatalf("expected sonar:zones:read, got %s", got)
+	}
+	if got := *(*audited)[1].StatusCode; got != http.StatusForbidden {
+		t.Fatalf("expected the refusal to be audited as 403, got %d", got)
+	}
+}
+
+func TestRequireRouteScopesHonorsWildcards(t *testing.T) {
+	r, _ := newScopedRouter(models.User{ID: uuid.New(), Scopes: []string{"sonar:*"}})
+	if code := serve(r, http.MethodDelete, "/sonar/admin/zones/1", "Bearer t"); code != http.StatusNoContent {
+		t.Fatalf("expected sonar:* to allow writes, got %d", code)
+	}
+}
+
+func TestRequireRouteScopesVerifiesTokenOnce(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	authClient := &fakeAuthClient{user: models.User{ID: uuid.New(), Scopes: []string{"sonar:zones:read"}}}
+	r := gin.New()
+	admin := r.Group("/sonar/admin", RequireRouteScopes(authClient, nil, "sonar", "/sonar/admin"))
+	admin.GET("/zones/:id", WithAuthentication(authClient, nil, func(ctx *gin.Context) {
+		if _, ok := ctx.Get("user"); !ok {
+			t.Error("expected the verified user in the context")
+		}
+		ctx.Status(http.StatusOK)
+	}))
+
+	if code := serve(r, http.MethodGet, "/sonar/admin/zones/1", "Bearer t"); code != http.StatusOK {
+		t.Fatalf("expected read to be allowed, got %d", code)
+	}
+	if authClient.verifyCalls != 1 {
+		t.Fatalf("expected the token to be verified once, got %d", authClient.verifyCalls)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/MaxBlaushild/poltergeist/pkg/http"
 	"github.com/MaxBlaushild/poltergeist/pkg/liveness"
 	"github.com/MaxBlaushild/poltergeist/pkg/logger"
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
 	"github.com/gin-gonic/gin"
 )
 
@@ -82,25 +83,41 @@ func attachRequestOverrides(ctx *gin.Context) {
 	}
 }
 
-func WithAuthentication(authClient auth.Client, livenessClient liveness.LivenessClient, next gin.HandlerFunc) gin.HandlerFunc {
-	return func(ctx *gin.Context) {
-		authorizationHeader := ctx.Request.Header.Get("Authorization")
-		headerParts := strings.Split(authorizationHeader, " ")
-
-		if len(headerParts) != 2 || headerParts[0] != bearer {
-			ctx.JSON(401, http.ErrorResponse{
-				Error: "invalid authorization header",
-			})
-			return
+// authenticate verifies the bearer token, or reuses the user a group's scope
+// check already verified for this request so the token is only verified once.
+func authenticate(ctx *gin.Context, authClient auth.Client) (*models.User, bool) {
+	if verified, ok := ctx.Get("user"); ok {
+		if user, ok := verified.(*models.User); ok && user != nil {
+			return user, true
 		}
+	}
 
-		user, err := authClient.VerifyToken(ctx, &auth.VerifyTokenRequest{
-			Token: headerParts[1],
+	authorizationHeader := ctx.Request.Header.Get("Authorization")
+	headerParts := strings.Split(authorizationHeader, " ")
+
+	if len(headerParts) != 2 || headerParts[0] != bearer {
+		ctx.JSON(401, http.ErrorResponse{
+			Error: "invalid authorization header",
 		})
-		if err != nil {
-			ctx.JSON(401, http.ErrorResponse{
-				Error: "authorization header not valid",
-			})
+		return nil, false
+	}
+
+	user, err := authClient.VerifyToken(ctx, &auth.VerifyTokenRequest{
+		Token: headerParts[1],
+	})
+	if err != nil {
+		ctx.JSON(401, http.ErrorResponse{
+			Error: "authorization header not valid",
+		})
+		return nil, false
+	}
+	return user, true
+}
+
+func WithAuthentication(authClient auth.Client, livenessClient liveness.LivenessClient, next gin.HandlerFunc) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, ok := authenticate(ctx, authClient)
+		if !ok {
 			return
 		}
 
@@ -111,7 +128,7 @@ func WithAuthentication(authClient auth.Client, livenessClient liveness.Liveness
 		locationHeader := ctx.Request.Header.Get("X-User-Location")
 		logger.Printf(ctx, "[auth] location header=%s", locationHeader)
 		if locationHeader != "" {
-			if err = livenessClient.SetUserLocation(ctx, user.ID, locationHeader); err != nil {
+			if err := livenessClient.SetUserLocation(ctx, user.ID, locationHeader); err != nil {
 				logger.Println(ctx, "error setting user location", err)
 			}
 		}
@@ -124,23 +141,8 @@ func WithAuthentication(authClient auth.Client, livenessClient liveness.Liveness
 
 func WithAuthenticationWithoutLocation(authClient auth.Client, next gin.HandlerFunc) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		authorizationHeader := ctx.Request.Header.Get("Authorization")
-		headerParts := strings.Split(authorizationHeader, " ")
-
-		if len(headerParts) != 2 || headerParts[0] != bearer {
-			ctx.JSON(401, http.ErrorResponse{
-				Error: "invalid authorization header",
-			})
-			return
-		}
-
-		user, err := authClient.VerifyToken(ctx, &auth.VerifyTokenRequest{
-			Token: headerParts[1],
-		})
-		if err != nil {
-			ctx.JSON(401, http.ErrorResponse{
-				Error: "authorization header not valid",
-			})
+		user, ok := authenticate(ctx, authClient)
+		if !ok {
 			return
 		}
 
@@ -0,0 +1,189 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type AchievementMetric string
+
+const (
+	AchievementMetricChallengeCompletions       AchievementMetric = "challenge_completions"
+	AchievementMetricMonsterEncounterVictories  AchievementMetric = "monster_encounter_victories"
+	AchievementMetricTreasureChestsOpened       AchievementMetric = "treasure_chests_opened"
+	AchievementMetricShrineUses                 AchievementMetric = "shrine_uses"
+	AchievementMetricHealingFountainsDiscovered AchievementMetric = "healing_fountains_discovered"
+	AchievementMetricZonesDiscovered            AchievementMetric = "zones_discovered"
+	AchievementMetricUserLevel                  AchievementMetric = "user_level"
+)
+
+var AchievementMetrics = []AchievementMetric{
+	AchievementMetricChallengeCompletions,
+	AchievementMetricMonsterEncounterVictories,
+	AchievementMetricTreasureChestsOpened,
+	AchievementMetricShrineUses,
+	AchievementMetricHealingFountainsDiscovered,
+	AchievementMetricZonesDiscovered,
+	AchievementMetricUserLevel,
+}
+
+func NormalizeAchievementMetric(raw string) (AchievementMetric, bool) {
+	candidate := AchievementMetric(strings.ToLower(strings.TrimSpace(raw)))
+	for _, metric := range AchievementMetrics {
+		if metric == candidate {
+			return metric, true
+		}
+	}
+	return "", false
+}
+
+// SupportsZoneFilters reports whether records behind the metric are tied to a
+// zone. User level is global, so zone and genre filters don't apply to it.
+func (m AchievementMetric) SupportsZoneFilters() bool {
+	return m != AchievementMetricUserLevel
+}
+
+type AchievementItemReward struct {
+	InventoryItemID int `json:"inventoryItemId"`
+	Quantity        int `json:"quantity"`
+}
+
+type AchievementItemRewards []AchievementItemReward
+
+func (r AchievementItemRewards) Value() (driver.Value, error) {
+	if r == nil {
+		return json.Marshal([]AchievementItemReward{})
+	}
+	return json.Marshal(r)
+}
+
+func (r *AchievementItemRewards) Scan(value interface{}) error {
+	if value == nil {
+		*r = AchievementItemRewards{}
+		return nil
+	}
+	var bytes []byte
+	switch v := value.(type) {
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
+		*r = AchievementItemRewards{}
+		return nil
+	}
+	if len(bytes) == 0 {
+		*r = AchievementItemRewards{}
+		return nil
+	}
+	return json.Unmarshal(bytes, r)
+}
+
+// AchievementDefinition is a data-driven achievement: once the user's count
+// for Metric reaches Threshold it unlocks. ZoneID, ZoneKind and GenreID narrow
+// which records are counted.
+type AchievementDefinition struct {
+	ID               uuid.UUID              `json:"id" gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	CreatedAt        time.Time              `json:"createdAt"`
+	UpdatedAt        time.Time              `json:"updatedAt"`
+	Key              string                 `json:"key" gorm:"column:key"`
+	Name             string                 `json:"name" gorm:"column:name"`
+	Description      string                 `json:"description" gorm:"column:description"`
+	IconURL          string                 `json:"iconUrl" gorm:"column:icon_url"`
+	Metric           AchievementMetric      `json:"metric" gorm:"column:metric"`
+	Threshold        int                    `json:"threshold" gorm:"column:threshold"`
+	ZoneID           *uuid.UUID             `json:"zoneId,omitempty" gorm:"column:zone_id"`
+	ZoneKind         string                 `json:"zoneKind" gorm:"column:zone_kind"`
+	GenreID          *uuid.UUID             `json:"genreId,omitempty" gorm:"column:genre_id"`
+	Hidden           bool                   `json:"hidden" gorm:"column:hidden"`
+	Active           bool                   `json:"active" gorm:"column:active"`
+	SortOrder        int                    `json:"sortOrder" gorm:"column:sort_order"`
+	TitleName        string                 `json:"titleName" gorm:"column:title_name"`
+	RewardMode       RewardMode             `json:"rewardMode" gorm:"column:reward_mode"`
+	RandomRewardSize RandomRewardSize       `json:"randomRewardSize" gorm:"column:random_reward_size"`
+	RewardExperience int                    `json:"rewardExperience" gorm:"column:reward_experience"`
+	RewardGold       int                    `json:"rewardGold" gorm:"column:reward_gold"`
+	ItemRewards      AchievementItemRewards `json:"itemRewards" gorm:"column:item_rewards_json;type:jsonb;default:'[]'"`
+}
+
+func (a *AchievementDefinition) TableName() string {
+	return "achievement_definitions"
+}
+
+// TracksZone reports whether a record written in zoneID could move this
+// achievement. Definitions pinned to a different zone can be skipped without
+// counting anything.
+func (a *AchievementDefinition) TracksZone(zoneID *uuid.UUID) bool {
+	if a.ZoneID == nil || zoneID == nil {
+		return true
+	}
+	return *a.ZoneID == *zoneID
+}
+
+func (a *AchievementDefinition) HasRewards() bool {
+	if NormalizeRewardMode(string(a.RewardMode)) == RewardModeRandom {
+		return true
+	}
+	if a.RewardExperience > 0 || a.RewardGold > 0 {
+		return true
+	}
+	for _, reward := range a.ItemRewards {
+		if reward.InventoryItemID > 0 && reward.Quantity > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+type UserAchievement struct {
+	ID               uuid.UUID  `json:"id" gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	CreatedAt        time.Time  `json:"createdAt"`
+	UpdatedAt        time.Time  `json:"updatedAt"`
+	UserID           uuid.UUID  `json:"userId" gorm:"column:user_id"`
+	AchievementID    uuid.UUID  `json:"achievementId" gorm:"column:achievement_id"`
+	Progress         int        `json:"progress" gorm:"column:progress"`
+	UnlockedAt       *time.Time `json:"unlockedAt,omitempty" gorm:"column:unlocked_at"`
+	RewardsGrantedAt *time.Time `json:"rewardsGrantedAt,omitempty" gorm:"column:rewards_granted_at"`
+	Backfilled       bool       `json:"backfilled" gorm:"column:backfilled"`
+
+	Achievement AchievementDefinition `json:"achievement,omitempty" gorm:"foreignKey:AchievementID"`
+}
+
+func (u *UserAchievement) TableName() string {
+	return "user_achievements"
+}
+
+func (u *UserAchievement) IsUnlocked() bool {
+	return u.UnlockedAt != nil
+}
+
+type UserTitle struct {
+	ID            uuid.UUID  `json:"id" gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
+	UserID        uuid.UUID  `json:"userId" gorm:"column:user_id"`
+	AchievementID *uuid.UUID `json:"achievementId,omitempty" gorm:"column:achievement_id"`
+	Title         string     `json:"title" gorm:"column:title"`
+	Active        bool       `json:"active" gorm:"column:active"`
+}
+
+func (u *UserTitle) TableName() string {
+	return "user_titles"
+}
+
+// AchievementEvaluation describes which achievements to re-check for a user.
+// An empty Metrics list checks every metric; AchievementID narrows the run to
+// a single definition. Backfill runs record unlocks without any side effects
+// the caller would otherwise fire, such as notifications.
+type AchievementEvaluation struct {
+	UserID        uuid.UUID
+	Metrics       []AchievementMetric
+	ZoneID        *uuid.UUID
+	AchievementID *uuid.UUID
+	Backfill      bool
+	Now           time.Time
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestNormalizeAchievementMetric(t *testing.T) {
+	metric, ok := NormalizeAchievementMetric(" Shrine_Uses ")
+	if !ok || metric != AchievementMetricShrineUses {
+		t.Fatalf("expected shrine_uses, got %q ok=%v", metric, ok)
+	}
+	if _, ok := NormalizeAchievementMetric("steps_walked"); ok {
+		t.Fatalf("expected unknown metric to be rejected")
+	}
+}
+
+func TestAchievementDefinitionTracksZone(t *testing.T) {
+	zoneID := uuid.New()
+	otherZoneID := uuid.New()
+
+	unpinned := &AchievementDefinition{}
+	if !unpinned.TracksZone(&zoneID) || !unpinned.TracksZone(nil) {
+		t.Fatalf("expected unpinned definition to track every zone")
+	}
+
+	pinned := &AchievementDefinition{ZoneID: &zoneID}
+	if !pinned.TracksZone(&zoneID) {
+		t.Fatalf("expected pinned definition to track its zone")
+	}
+	if pinned.TracksZone(&otherZoneID) {
+		t.Fatalf("expected pinned definition to skip other zones")
+	}
+	if !pinned.TracksZone(nil) {
+		t.Fatalf("expected zone-less evaluations to recount pinned definitions")
+	}
+}
+
+func TestAchievementDefinitionHasRewards(t *testing.T) {
+	definition := &AchievementDefinition{RewardMode: RewardModeExplicit}
+	if definition.HasRewards() {
+		t.Fatalf("expected empty explicit definition to have no rewards")
+	}
+	definition.ItemRewards = AchievementItemRewards{{InventoryItemID: 3, Quantity: 0}}
+	if definition.HasRewards() {
+		t.Fatalf("expected zero-quantity items to be ignored")
+	}
+	definition.RewardGold = 10
+	if !definition.HasRewards() {
+		t.Fatalf("expected gold to count as a reward")
+	}
+	if !(&AchievementDefinition{RewardMode: RewardModeRandom}).HasRewards() {
+		t.Fatalf("expected random mode to always reward")
+	}
+}
@@ -15,4 +15,13 @@ type AuditItem struct {
 	CreatedAt time.Time  `json:"createdAt"`
 	UpdatedAt time.Time  `json:"updatedAt"`
 	Message   string     `json:"message"`
+
+	// The rest are only set on privileged-call entries (see
+	// pkg/middleware.RequireScopes): the scope that admitted the request and
+	// what the request was. UserID is the caller.
+	Scope      *string `json:"scope,omitempty"`
+	Method     *string `json:"method,omitempty"`
+	Path       *string `json:"path,omitempty"`
+	StatusCode *int    `json:"statusCode,omitempty"`
+	IPAddress  *string `json:"ipAddress,omitempty" gorm:"column:ip_address"`
 }
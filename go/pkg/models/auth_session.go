@@ -0,0 +1,45 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuthSession is one signed-in device. Access tokens carry its ID and stop
+// verifying once it is revoked or expired; the refresh token is only ever
+// stored as a hash.
+type AuthSession struct {
+	ID                       uuid.UUID  `json:"id" gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	CreatedAt                time.Time  `json:"createdAt"`
+	UpdatedAt                time.Time  `json:"updatedAt"`
+	UserID                   uuid.UUID  `json:"userId" gorm:"type:uuid"`
+	RefreshTokenHash         string     `json:"-"`
+	PreviousRefreshTokenHash *string    `json:"-"`
+	DeviceName               string     `json:"deviceName"`
+	UserAgent                string     `json:"userAgent"`
+	IPAddress                string     `json:"ipAddress" gorm:"column:ip_address"`
+	LastUsedAt               time.Time  `json:"lastUsedAt"`
+	ExpiresAt                time.Time  `json:"expiresAt"`
+	RevokedAt                *time.Time `json:"revokedAt"`
+	// MFAVerifiedAt is set when the sign-in that started the session
+	// passed a second factor. Only such sessions carry role scopes.
+	MFAVerifiedAt *time.Time `json:"mfaVerifiedAt" gorm:"column:mfa_verified_at"`
+}
+
+func (AuthSession) TableName() string {
+	return "auth_sessions"
+}
+
+// Active reports whether tokens tied to the session should still be honored.
+func (s *AuthSession) Active(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
+
+// HashRefreshToken is how refresh tokens are looked up without storing them.
+func HashRefreshToken(refreshToken string) string {
+	sum := sha256.Sum256([]byte(refreshToken))
+	return hex.EncodeToString(sum[:])
+}
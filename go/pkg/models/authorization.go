@@ -0,0 +1,75 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Roles seeded by the add_roles migration. Services only ever check scopes;
+// role names exist so ops can grant a bundle of them in one step.
+const (
+	RoleSuperAdmin     = "super-admin"
+	RoleSonarAdmin     = "sonar-admin"
+	RoleReefOperator   = "reef-operator"
+	RoleVampireLibrary = "vampire-library-editor"
+)
+
+// ScopeWildcard grants every scope beneath the prefix it ends — "sonar:*"
+// covers "sonar:zones:write", and "*" on its own covers everything.
+const ScopeWildcard = "*"
+
+// Role is a named bundle of scopes, e.g. sonar-admin = ["sonar:*"]. Scopes
+// are "<service>:<resource>:<action>".
+type Role struct {
+	ID          uuid.UUID   `json:"id" gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	CreatedAt   time.Time   `json:"createdAt"`
+	UpdatedAt   time.Time   `json:"updatedAt"`
+	Name        string      `json:"name" gorm:"unique"`
+	Description string      `json:"description"`
+	Scopes      StringArray `json:"scopes" gorm:"type:jsonb"`
+}
+
+func (Role) TableName() string {
+	return "roles"
+}
+
+// UserRole grants a role to a user. GrantedBy is nil for grants made with
+// the ops-only bootstrap command (authenticator/cmd/grant-role).
+type UserRole struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UserID    uuid.UUID  `json:"userId" gorm:"type:uuid"`
+	User      *User      `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	RoleID    uuid.UUID  `json:"roleId" gorm:"type:uuid"`
+	Role      *Role      `json:"role,omitempty" gorm:"foreignKey:RoleID"`
+	GrantedBy *uuid.UUID `json:"grantedBy" gorm:"type:uuid"`
+}
+
+func (UserRole) TableName() string {
+	return "user_roles"
+}
+
+// ScopeAllows reports whether any granted scope covers required.
+func ScopeAllows(granted []string, required string) bool {
+	for _, scope := range granted {
+		if scope == required || scope == ScopeWildcard {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(scope, ":"+ScopeWildcard); ok && strings.HasPrefix(required, prefix+":") {
+			return true
+		}
+	}
+	return false
+}
+
+// HasScopes reports whether the user's token carried every required scope.
+func (u *User) HasScopes(required ...string) bool {
+	for _, scope := range required {
+		if !ScopeAllows(u.Scopes, scope) {
+			return false
+		}
+	}
+	return true
+}
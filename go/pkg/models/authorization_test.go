@@ -0,0 +1,35 @@
+package models
+
+import "testing"
+
+func TestScopeAllows(t *testing.T) {
+	cases := []struct {
+		granted  []string
+		required string
+		want     bool
+	}{
+		{[]string{"sonar:zones:write"}, "sonar:zones:write", true},
+		{[]string{"sonar:zones:read"}, "sonar:zones:write", false},
+		{[]string{"sonar:*"}, "sonar:zones:write", true},
+		{[]string{"sonar:zones:*"}, "sonar:zones:read", true},
+		{[]string{"sonar:*"}, "sonarx:zones:read", false},
+		{[]string{"sonar:*"}, "reef:orders:refund", false},
+		{[]string{"*"}, "reef:orders:refund", true},
+		{nil, "sonar:zones:read", false},
+	}
+	for _, c := range cases {
+		if got := ScopeAllows(c.granted, c.required); got != c.want {
+			t.Errorf("ScopeAllows(%v, %q) = %v, want %v", c.granted, c.required, got, c.want)
+		}
+	}
+}
+
+func TestUserHasScopesRequiresEvery(t *testing.T) {
+	user := &User{Scopes: []string{"reef:orders:read", "reef:orders:refund"}}
+	if !user.HasScopes("reef:orders:read", "reef:orders:refund") {
+		t.Fatal("expected both scopes to be held")
+	}
+	if user.HasScopes("reef:orders:read", "reef:orders:write") {
+		t.Fatal("expected a missing scope to fail the check")
+	}
+}
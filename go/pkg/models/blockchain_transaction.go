@@ -9,8 +9,10 @@ import (
 type BlockchainTransactionType string
 
 const (
-	RegisterCertificateType BlockchainTransactionType = "registerCertificate"
-	AnchorManifestType      BlockchainTransactionType = "anchorManifest"
+	RegisterCertificateType  BlockchainTransactionType = "registerCertificate"
+	AnchorManifestType       BlockchainTransactionType = "anchorManifest"
+	SetCertificateStatusType BlockchainTransactionType = "setCertificateStatus"
+	AnchorManifestRootType   BlockchainTransactionType = "anchorManifestRoot"
 )
 
 type BlockchainTransactionStatus string
@@ -20,6 +22,12 @@ const (
 	ConfirmedStatus BlockchainTransactionStatus = "confirmed"
 	FailedStatus    BlockchainTransactionStatus = "failed"
 	ExpiredStatus   BlockchainTransactionStatus = "expired"
+	// DroppedStatus is for transactions whose nonce was used by another
+	// transaction before any of their own attempts were mined.
+	DroppedStatus BlockchainTransactionStatus = "dropped"
+	// UnsentStatus is for transactions saved before sending whose sending
+	// failed, leaving their nonce free for the next transaction.
+	UnsentStatus BlockchainTransactionStatus = "unsent"
 )
 
 type BlockchainTransaction struct {
@@ -33,11 +41,59 @@ type BlockchainTransaction struct {
 	Data        *string    `gorm:"type:text" json:"data,omitempty"`
 	GasLimit    *uint64    `gorm:"type:bigint" json:"gasLimit,omitempty"`
 	GasPrice    *string    `gorm:"type:text" json:"gasPrice,omitempty"`
+	GasTipCap   *string    `gorm:"type:text" json:"gasTipCap,omitempty"`
+	GasFeeCap   *string    `gorm:"type:text" json:"gasFeeCap,omitempty"`
 	Nonce       uint64     `gorm:"type:bigint;not null;index:idx_chain_from_nonce" json:"nonce"`
 	TxHash      *string    `gorm:"type:text;index" json:"txHash,omitempty"`
 	Status      string     `gorm:"type:text;not null;default:'pending';index" json:"status"`
 	Type        *string    `gorm:"type:text;index" json:"type,omitempty"`
 	BlockNumber *uint64    `gorm:"type:bigint" json:"blockNumber,omitempty"`
+	BlockHash   *string    `gorm:"type:text" json:"blockHash,omitempty"`
 	ConfirmedAt *time.Time `gorm:"type:timestamp" json:"confirmedAt,omitempty"`
 	ExpiresAt   time.Time  `gorm:"type:timestamp;not null;index" json:"expiresAt"`
+	// BroadcastAt is when the latest attempt was sent, and like TxHash is
+	// nil while the transaction is saved but not yet sent. ReplacedTxHashes
+	// holds the hashes of earlier attempts at the same nonce, any of which
+	// may still be the one that's mined.
+	BroadcastAt      *time.Time                   `gorm:"type:timestamp" json:"broadcastAt,omitempty"`
+	ReplacedTxHashes StringArray                  `gorm:"type:jsonb" json:"replacedTxHashes,omitempty"`
+	Events           []BlockchainTransactionEvent `gorm:"foreignKey:BlockchainTransactionID" json:"events,omitempty"`
+}
+
+// BlockchainTransactionEvent records a change to a transaction: its status
+// moving, or a pending transaction being sent again at higher fees.
+type BlockchainTransactionEvent struct {
+	ID                      uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	CreatedAt               time.Time `gorm:"not null" json:"createdAt"`
+	BlockchainTransactionID uuid.UUID `gorm:"type:uuid;not null;index" json:"blockchainTransactionId"`
+	FromStatus              *string   `gorm:"type:text" json:"fromStatus,omitempty"`
+	ToStatus                string    `gorm:"type:text;not null" json:"toStatus"`
+	TxHash                  *string   `gorm:"type:text" json:"txHash,omitempty"`
+	GasPrice                *string   `gorm:"type:text" json:"gasPrice,omitempty"`
+	GasTipCap               *string   `gorm:"type:text" json:"gasTipCap,omitempty"`
+	GasFeeCap               *string   `gorm:"type:text" json:"gasFeeCap,omitempty"`
+	BlockNumber             *uint64   `gorm:"type:bigint" json:"blockNumber,omitempty"`
+	Reason                  string    `gorm:"type:text;not null" json:"reason"`
+}
+
+// BlockchainTransactionStatusChange moves a transaction to Status. Moving a
+// transaction back to pending clears its block.
+type BlockchainTransactionStatusChange struct {
+	Status BlockchainTransactionStatus
+	// TxHash is the attempt that was mined, when it isn't the latest.
+	TxHash      *string
+	BlockNumber *uint64
+	BlockHash   *string
+	ConfirmedAt *time.Time
+	Reason      string
+}
+
+// BlockchainTransactionReplacement is a new attempt at a pending
+// transaction's nonce.
+type BlockchainTransactionReplacement struct {
+	TxHash    string
+	GasPrice  *string
+	GasTipCap *string
+	GasFeeCap *string
+	Reason    string
 }
@@ -0,0 +1,284 @@
+package models
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type BountyCadence string
+
+const (
+	BountyCadenceDaily  BountyCadence = "daily"
+	BountyCadenceWeekly BountyCadence = "weekly"
+)
+
+var BountyCadences = []BountyCadence{
+	BountyCadenceDaily,
+	BountyCadenceWeekly,
+}
+
+func NormalizeBountyCadence(raw string) (BountyCadence, bool) {
+	switch BountyCadence(strings.ToLower(strings.TrimSpace(raw))) {
+	case BountyCadenceDaily:
+		return BountyCadenceDaily, true
+	case BountyCadenceWeekly:
+		return BountyCadenceWeekly, true
+	default:
+		return "", false
+	}
+}
+
+// PeriodStart returns the start of the bounty period containing now. Periods
+// roll over at midnight UTC; weeks start on Monday.
+func (c BountyCadence) PeriodStart(now time.Time) time.Time {
+	utc := now.UTC()
+	day := time.Date(utc.Year(), utc.Month(), utc.Day(), 0, 0, 0, 0, time.UTC)
+	if c != BountyCadenceWeekly {
+		return day
+	}
+	offset := (int(day.Weekday()) + 6) % 7
+	return day.AddDate(0, 0, -offset)
+}
+
+// PreviousPeriodStart returns the start of the period before periodStart.
+func (c BountyCadence) PreviousPeriodStart(periodStart time.Time) time.Time {
+	if c == BountyCadenceWeekly {
+		return periodStart.AddDate(0, 0, -7)
+	}
+	return periodStart.AddDate(0, 0, -1)
+}
+
+// BoardSize is how many bounties of this cadence a player is offered per zone.
+func (c BountyCadence) BoardSize() int {
+	if c == BountyCadenceWeekly {
+		return 2
+	}
+	return 3
+}
+
+type BountyObjectiveType string
+
+const (
+	BountyObjectiveDefeatMonsters     BountyObjectiveType = "defeat_monsters"
+	BountyObjectiveOpenTreasureChests BountyObjectiveType = "open_treasure_chests"
+	BountyObjectiveCompleteScenarios  BountyObjectiveType = "complete_scenarios"
+	BountyObjectiveCompleteChallenges BountyObjectiveType = "complete_challenges"
+)
+
+var BountyObjectiveTypes = []BountyObjectiveType{
+	BountyObjectiveDefeatMonsters,
+	BountyObjectiveOpenTreasureChests,
+	BountyObjectiveCompleteScenarios,
+	BountyObjectiveCompleteChallenges,
+}
+
+func NormalizeBountyObjectiveType(raw string) (BountyObjectiveType, bool) {
+	candidate := BountyObjectiveType(strings.ToLower(strings.TrimSpace(raw)))
+	for _, objectiveType := range BountyObjectiveTypes {
+		if objectiveType == candidate {
+			return objectiveType, true
+		}
+	}
+	return "", false
+}
+
+// BountyTemplate describes one objective that can appear on a zone's bounty
+// board, e.g. "defeat 3 fire monsters" or "complete a scenario at a tavern".
+// ZoneID and ZoneKind restrict which zones offer it; Weight biases how often
+// it's picked.
+type BountyTemplate struct {
+	ID                 uuid.UUID           `json:"id" gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	CreatedAt          time.Time           `json:"createdAt"`
+	UpdatedAt          time.Time           `json:"updatedAt"`
+	Name               string              `json:"name" gorm:"column:name"`
+	Description        string              `json:"description" gorm:"column:description"`
+	Cadence            BountyCadence       `json:"cadence" gorm:"column:cadence"`
+	ObjectiveType      BountyObjectiveType `json:"objectiveType" gorm:"column:objective_type"`
+	TargetCount        int                 `json:"targetCount" gorm:"column:target_count"`
+	MonsterAffinity    string              `json:"monsterAffinity" gorm:"column:monster_affinity"`
+	PointOfInterestTag string              `json:"pointOfInterestTag" gorm:"column:point_of_interest_tag"`
+	ZoneID             *uuid.UUID          `json:"zoneId,omitempty" gorm:"column:zone_id"`
+	ZoneKind           string              `json:"zoneKind" gorm:"column:zone_kind"`
+	Weight             int                 `json:"weight" gorm:"column:weight"`
+	RandomRewardSize   RandomRewardSize    `json:"randomRewardSize" gorm:"column:random_reward_size"`
+	Active             bool                `json:"active" gorm:"column:active"`
+}
+
+func (b *BountyTemplate) TableName() string {
+	return "bounty_templates"
+}
+
+// AvailableInZone reports whether the template can be offered in the zone.
+func (b *BountyTemplate) AvailableInZone(zoneID uuid.UUID, zoneKind string) bool {
+	if b.ZoneID != nil && *b.ZoneID != zoneID {
+		return false
+	}
+	if kind := NormalizeZoneKind(b.ZoneKind); kind != "" && kind != NormalizeZoneKind(zoneKind) {
+		return false
+	}
+	return true
+}
+
+// BountyEvent is a completion the bounty board can count: one monster
+// defeated, chest opened, scenario or challenge completed in a zone.
+type BountyEvent struct {
+	ObjectiveType       BountyObjectiveType
+	ZoneID              uuid.UUID
+	MonsterAffinities   []string
+	PointOfInterestTags []string
+}
+
+func (b *BountyTemplate) Matches(event BountyEvent) bool {
+	if b.ObjectiveType != event.ObjectiveType {
+		return false
+	}
+	if affinity := strings.TrimSpace(b.MonsterAffinity); affinity != "" &&
+		!containsFoldedString(event.MonsterAffinities, affinity) {
+		return false
+	}
+	if tag := strings.TrimSpace(b.PointOfInterestTag); tag != "" &&
+		!containsFoldedString(event.PointOfInterestTags, tag) {
+		return false
+	}
+	return true
+}
+
+func containsFoldedString(values []string, target string) bool {
+	for _, value := range values {
+		if strings.EqualFold(strings.TrimSpace(value), target) {
+			return true
+		}
+	}
+	return false
+}
+
+// SelectBounties picks the templates on a user's board for one zone and
+// period. The pick is weighted and seeded from the user, zone and period, so
+// the same board comes back all day (or week) without being stored.
+func SelectBounties(
+	templates []BountyTemplate,
+	userID uuid.UUID,
+	zoneID uuid.UUID,
+	cadence BountyCadence,
+	periodStart time.Time,
+) []BountyTemplate {
+	candidates := make([]BountyTemplate, 0, len(templates))
+	for _, template := range templates {
+		if template.Active && template.Cadence == cadence {
+			candidates = append(candidates, template)
+		}
+	}
+	// Sort first so the pick doesn't depend on the order rows came back in.
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].ID.String() < candidates[j].ID.String()
+	})
+
+	hasher := fnv.New64a()
+	_, _ = hasher.Write([]byte(fmt.Sprintf(
+		"bounty:%s:user:%s:zone:%s:period:%s",
+		cadence,
+		userID,
+		zoneID,
+		periodStart.UTC().Format("2006-01-02"),
+	)))
+	rng := rand.New(rand.NewSource(int64(hasher.Sum64())))
+
+	selected := make([]BountyTemplate, 0, cadence.BoardSize())
+	for len(selected) < cadence.BoardSize() && len(candidates) > 0 {
+		totalWeight := 0
+		for _, candidate := range candidates {
+			totalWeight += max(1, candidate.Weight)
+		}
+		roll := rng.Intn(totalWeight)
+		pick := 0
+		for idx, candidate := range candidates {
+			roll -= max(1, candidate.Weight)
+			if roll < 0 {
+				pick = idx
+				break
+			}
+		}
+		selected = append(selected, candidates[pick])
+		candidates = append(candidates[:pick], candidates[pick+1:]...)
+	}
+	return selected
+}
+
+type UserBountyProgress struct {
+	ID               uuid.UUID     `json:"id" gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	CreatedAt        time.Time     `json:"createdAt"`
+	UpdatedAt        time.Time     `json:"updatedAt"`
+	UserID           uuid.UUID     `json:"userId" gorm:"column:user_id"`
+	ZoneID           uuid.UUID     `json:"zoneId" gorm:"column:zone_id"`
+	BountyTemplateID uuid.UUID     `json:"bountyTemplateId" gorm:"column:bounty_template_id"`
+	Cadence          BountyCadence `json:"cadence" gorm:"column:cadence"`
+	PeriodStart      time.Time     `json:"periodStart" gorm:"column:period_start"`
+	Progress         int           `json:"progress" gorm:"column:progress"`
+	TargetCount      int           `json:"targetCount" gorm:"column:target_count"`
+	CompletedAt      *time.Time    `json:"completedAt,omitempty" gorm:"column:completed_at"`
+	RewardedAt       *time.Time    `json:"rewardedAt,omitempty" gorm:"column:rewarded_at"`
+
+	BountyTemplate BountyTemplate `json:"bountyTemplate,omitempty" gorm:"foreignKey:BountyTemplateID"`
+}
+
+func (u *UserBountyProgress) TableName() string {
+	return "user_bounty_progress"
+}
+
+func (u *UserBountyProgress) IsClaimable() bool {
+	return u.CompletedAt != nil && u.RewardedAt == nil
+}
+
+// UserBountyStreak counts consecutive periods in which the user claimed at
+// least one bounty of the cadence.
+type UserBountyStreak struct {
+	ID              uuid.UUID     `json:"id" gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	CreatedAt       time.Time     `json:"createdAt"`
+	UpdatedAt       time.Time     `json:"updatedAt"`
+	UserID          uuid.UUID     `json:"userId" gorm:"column:user_id"`
+	Cadence         BountyCadence `json:"cadence" gorm:"column:cadence"`
+	CurrentStreak   int           `json:"currentStreak" gorm:"column:current_streak"`
+	LongestStreak   int           `json:"longestStreak" gorm:"column:longest_streak"`
+	LastPeriodStart *time.Time    `json:"lastPeriodStart,omitempty" gorm:"column:last_period_start"`
+}
+
+func (u *UserBountyStreak) TableName() string {
+	return "user_bounty_streaks"
+}
+
+// Advance records a claim in periodStart. Claims in the same period leave the
+// streak alone, a claim in the next period extends it and anything later
+// starts over at one.
+func (u *UserBountyStreak) Advance(periodStart time.Time) {
+	switch {
+	case u.LastPeriodStart != nil && u.LastPeriodStart.Equal(periodStart):
+		return
+	case u.LastPeriodStart != nil && u.LastPeriodStart.Equal(u.Cadence.PreviousPeriodStart(periodStart)):
+		u.CurrentStreak++
+	default:
+		u.CurrentStreak = 1
+	}
+	u.LongestStreak = max(u.LongestStreak, u.CurrentStreak)
+	start := periodStart
+	u.LastPeriodStart = &start
+}
+
+const (
+	bountyStreakBonusPercentPerPeriod = 10
+	bountyStreakBonusPercentMax       = 50
+)
+
+// BountyStreakBonusPercent is the extra experience and gold a claim earns for
+// the given streak. The first period earns nothing extra.
+func BountyStreakBonusPercent(streak int) int {
+	if streak <= 1 {
+		return 0
+	}
+	return min(bountyStreakBonusPercentMax, (streak-1)*bountyStreakBonusPercentPerPeriod)
+}
@@ -0,0 +1,127 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestBountyCadencePeriodStart(t *testing.T) {
+	// Thursday afternoon.
+	now := time.Date(2026, time.March, 12, 15, 30, 0, 0, time.UTC)
+	if got := BountyCadenceDaily.PeriodStart(now); !got.Equal(time.Date(2026, time.March, 12, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected daily period start %s", got)
+	}
+	if got := BountyCadenceWeekly.PeriodStart(now); !got.Equal(time.Date(2026, time.March, 9, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected weekly period to start Monday, got %s", got)
+	}
+	sunday := time.Date(2026, time.March, 15, 23, 0, 0, 0, time.UTC)
+	if got := BountyCadenceWeekly.PeriodStart(sunday); !got.Equal(time.Date(2026, time.March, 9, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected Sunday to belong to the previous Monday's week, got %s", got)
+	}
+}
+
+func TestSelectBountiesIsDeterministic(t *testing.T) {
+	templates := []BountyTemplate{}
+	for i := 0; i < 8; i++ {
+		templates = append(templates, BountyTemplate{
+			ID:      uuid.New(),
+			Cadence: BountyCadenceDaily,
+			Weight:  i + 1,
+			Active:  true,
+		})
+	}
+	userID := uuid.New()
+	zoneID := uuid.New()
+	day := time.Date(2026, time.March, 12, 0, 0, 0, 0, time.UTC)
+
+	first := SelectBounties(templates, userID, zoneID, BountyCadenceDaily, day)
+	reversed := make([]BountyTemplate, len(templates))
+	for i := range templates {
+		reversed[len(templates)-1-i] = templates[i]
+	}
+	second := SelectBounties(reversed, userID, zoneID, BountyCadenceDaily, day)
+
+	if len(first) != BountyCadenceDaily.BoardSize() {
+		t.Fatalf("expected %d bounties, got %d", BountyCadenceDaily.BoardSize(), len(first))
+	}
+	seen := map[uuid.UUID]bool{}
+	for i := range first {
+		if first[i].ID != second[i].ID {
+			t.Fatalf("expected the same board regardless of input order")
+		}
+		if seen[first[i].ID] {
+			t.Fatalf("expected no duplicate bounties on a board")
+		}
+		seen[first[i].ID] = true
+	}
+}
+
+func TestSelectBountiesSkipsOtherCadencesAndInactive(t *testing.T) {
+	weekly := BountyTemplate{ID: uuid.New(), Cadence: BountyCadenceWeekly, Active: true}
+	inactive := BountyTemplate{ID: uuid.New(), Cadence: BountyCadenceDaily}
+	selected := SelectBounties(
+		[]BountyTemplate{weekly, inactive},
+		uuid.New(),
+		uuid.New(),
+		BountyCadenceDaily,
+		time.Now(),
+	)
+	if len(selected) != 0 {
+		t.Fatalf("expected no daily bounties, got %d", len(selected))
+	}
+}
+
+func TestBountyTemplateMatches(t *testing.T) {
+	template := &BountyTemplate{
+		ObjectiveType:   BountyObjectiveDefeatMonsters,
+		MonsterAffinity: "fire",
+	}
+	if !template.Matches(BountyEvent{
+		ObjectiveType:     BountyObjectiveDefeatMonsters,
+		MonsterAffinities: []string{"ice", "Fire"},
+	}) {
+		t.Fatalf("expected fire monster to match")
+	}
+	if template.Matches(BountyEvent{
+		ObjectiveType:     BountyObjectiveDefeatMonsters,
+		MonsterAffinities: []string{"ice"},
+	}) {
+		t.Fatalf("expected ice monster not to match")
+	}
+	if template.Matches(BountyEvent{ObjectiveType: BountyObjectiveOpenTreasureChests}) {
+		t.Fatalf("expected other objectives not to match")
+	}
+}
+
+func TestUserBountyStreakAdvance(t *testing.T) {
+	day := time.Date(2026, time.March, 12, 0, 0, 0, 0, time.UTC)
+	streak := &UserBountyStreak{Cadence: BountyCadenceDaily}
+
+	streak.Advance(day)
+	streak.Advance(day)
+	if streak.CurrentStreak != 1 {
+		t.Fatalf("expected repeat claims in one day to keep streak at 1, got %d", streak.CurrentStreak)
+	}
+	streak.Advance(day.AddDate(0, 0, 1))
+	if streak.CurrentStreak != 2 || streak.LongestStreak != 2 {
+		t.Fatalf("expected consecutive day to extend streak, got %+v", streak)
+	}
+	streak.Advance(day.AddDate(0, 0, 3))
+	if streak.CurrentStreak != 1 || streak.LongestStreak != 2 {
+		t.Fatalf("expected a missed day to reset streak, got %+v", streak)
+	}
+}
+
+func TestBountyStreakBonusPercent(t *testing.T) {
+	if BountyStreakBonusPercent(1) != 0 {
+		t.Fatalf("expected no bonus on the first day")
+	}
+	if BountyStreakBonusPercent(3) != 20 {
+		t.Fatalf("expected 20%% bonus on day three, got %d", BountyStreakBonusPercent(3))
+	}
+	if BountyStreakBonusPercent(30) != 50 {
+		t.Fatalf("expected bonus to cap at 50%%, got %d", BountyStreakBonusPercent(30))
+	}
+}
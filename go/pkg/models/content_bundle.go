@@ -0,0 +1,689 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ContentBundleFormatVersion is bumped whenever the bundle layout changes in a
+// way older importers can't read.
+const ContentBundleFormatVersion = 1
+
+type ContentBundleKind string
+
+const (
+	ContentBundleKindZoneGenre          ContentBundleKind = "zoneGenre"
+	ContentBundleKindZoneKind           ContentBundleKind = "zoneKind"
+	ContentBundleKindLocationArchetype  ContentBundleKind = "locationArchetype"
+	ContentBundleKindInventoryItem      ContentBundleKind = "inventoryItem"
+	ContentBundleKindSpell              ContentBundleKind = "spell"
+	ContentBundleKindMonsterTemplate    ContentBundleKind = "monsterTemplate"
+	ContentBundleKindChallengeTemplate  ContentBundleKind = "challengeTemplate"
+	ContentBundleKindScenarioTemplate   ContentBundleKind = "scenarioTemplate"
+	ContentBundleKindExpositionTemplate ContentBundleKind = "expositionTemplate"
+	ContentBundleKindShrineTemplate     ContentBundleKind = "shrineTemplate"
+	ContentBundleKindQuestArchetype     ContentBundleKind = "questArchetype"
+	ContentBundleKindQuestArchetypeNode ContentBundleKind = "questArchetypeNode"
+)
+
+// ContentBundle is a portable copy of authored content. Records keep the IDs
+// of the environment they were exported from; importing remaps them.
+// Inventory items are only carried as references and are matched by name,
+// since items are managed separately in every environment.
+type ContentBundle struct {
+	Manifest            ContentBundleManifest          `json:"manifest"`
+	ZoneGenres          []ZoneGenre                    `json:"zoneGenres"`
+	ZoneKinds           []ZoneKind                     `json:"zoneKinds"`
+	LocationArchetypes  []LocationArchetype            `json:"locationArchetypes"`
+	InventoryItems      []ContentBundleItemReference   `json:"inventoryItems"`
+	Spells              []Spell                        `json:"spells"`
+	MonsterTemplates    []ContentBundleMonsterTemplate `json:"monsterTemplates"`
+	ChallengeTemplates  []ChallengeTemplate            `json:"challengeTemplates"`
+	ScenarioTemplates   []ScenarioTemplate             `json:"scenarioTemplates"`
+	ExpositionTemplates []ExpositionTemplate           `json:"expositionTemplates"`
+	ShrineTemplates     []ShrineTemplate               `json:"shrineTemplates"`
+	QuestArchetypes     []ContentBundleQuestArchetype  `json:"questArchetypes"`
+}
+
+type ContentBundleManifest struct {
+	FormatVersion int                       `json:"formatVersion"`
+	ExportedAt    time.Time                 `json:"exportedAt"`
+	Source        string                    `json:"source,omitempty"`
+	Counts        map[ContentBundleKind]int `json:"counts"`
+	Images        []ContentBundleImage      `json:"images,omitempty"`
+	Warnings      []string                  `json:"warnings,omitempty"`
+}
+
+// ContentBundleImage records an image a bundle record points at. Images are
+// referenced by their S3 bucket and key; when Path is set the bytes travel
+// inside the bundle and are re-uploaded on import.
+type ContentBundleImage struct {
+	URL    string `json:"url"`
+	Bucket string `json:"bucket,omitempty"`
+	Key    string `json:"key,omitempty"`
+	Path   string `json:"path,omitempty"`
+}
+
+type ContentBundleItemReference struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type ContentBundleSpellReference struct {
+	SpellID uuid.UUID `json:"spellId"`
+}
+
+type ContentBundleItemQuantity struct {
+	InventoryItemID int `json:"inventoryItemId"`
+	Quantity        int `json:"quantity"`
+}
+
+// ContentBundleMonsterTemplate shadows the template's spell join rows with
+// plain references so the bundle doesn't carry join IDs.
+type ContentBundleMonsterTemplate struct {
+	MonsterTemplate
+	Spells []ContentBundleSpellReference `json:"spells"`
+}
+
+// ContentBundleQuestArchetype carries an archetype together with every node
+// reachable from its root, so each entry is a self-contained graph.
+type ContentBundleQuestArchetype struct {
+	QuestArchetype
+	// Root shadows the preloaded root node; the graph lives in Nodes.
+	Root         *QuestArchetypeNode           `json:"root,omitempty"`
+	ItemRewards  []ContentBundleItemQuantity   `json:"itemRewards"`
+	SpellRewards []ContentBundleSpellReference `json:"spellRewards"`
+	Nodes        []QuestArchetypeNode          `json:"nodes"`
+}
+
+// ContentBundleSource is everything an environment has that a bundle can be
+// built from.
+type ContentBundleSource struct {
+	ZoneGenres          []ZoneGenre
+	ZoneKinds           []ZoneKind
+	LocationArchetypes  []*LocationArchetype
+	InventoryItems      []InventoryItem
+	Spells              []Spell
+	MonsterTemplates    []MonsterTemplate
+	ChallengeTemplates  []ChallengeTemplate
+	ScenarioTemplates   []ScenarioTemplate
+	ExpositionTemplates []ExpositionTemplate
+	ShrineTemplates     []ShrineTemplate
+	QuestArchetypes     []*QuestArchetype
+	QuestArchetypeNodes []*QuestArchetypeNode
+}
+
+// ContentBundleSelection narrows an export. An empty selection exports
+// everything; otherwise the selected archetypes and zone kinds are exported
+// along with everything they depend on.
+type ContentBundleSelection struct {
+	QuestArchetypeIDs []uuid.UUID `json:"questArchetypeIds"`
+	ZoneKinds         []string    `json:"zoneKinds"`
+}
+
+func (s ContentBundleSelection) IsEmpty() bool {
+	return len(s.QuestArchetypeIDs) == 0 && len(s.ZoneKinds) == 0
+}
+
+type contentBundleBuilder struct {
+	source   ContentBundleSource
+	nodes    map[uuid.UUID]*QuestArchetypeNode
+	warnings []string
+
+	genres             map[uuid.UUID]bool
+	zoneKinds          map[string]bool
+	locationArchetypes map[uuid.UUID]bool
+	itemIDs            map[int]bool
+	spells             map[uuid.UUID]bool
+	monsterTemplates   map[uuid.UUID]bool
+	challengeTemplates map[uuid.UUID]bool
+	scenarioTemplates  map[uuid.UUID]bool
+	expositions        map[uuid.UUID]bool
+	shrineTemplates    map[uuid.UUID]bool
+	questArchetypes    map[uuid.UUID]bool
+}
+
+// BuildContentBundle copies the selected content out of source, strips
+// preloaded associations and environment-specific references, and fills in
+// the manifest. Image bytes are left to the caller.
+func BuildContentBundle(source ContentBundleSource, selection ContentBundleSelection, exportedAt time.Time) *ContentBundle {
+	b := &contentBundleBuilder{
+		source:             source,
+		nodes:              map[uuid.UUID]*QuestArchetypeNode{},
+		genres:             map[uuid.UUID]bool{},
+		zoneKinds:          map[string]bool{},
+		locationArchetypes: map[uuid.UUID]bool{},
+		itemIDs:            map[int]bool{},
+		spells:             map[uuid.UUID]bool{},
+		monsterTemplates:   map[uuid.UUID]bool{},
+		challengeTemplates: map[uuid.UUID]bool{},
+		scenarioTemplates:  map[uuid.UUID]bool{},
+		expositions:        map[uuid.UUID]bool{},
+		shrineTemplates:    map[uuid.UUID]bool{},
+		questArchetypes:    map[uuid.UUID]bool{},
+	}
+	for _, node := range source.QuestArchetypeNodes {
+		if node != nil {
+			b.nodes[node.ID] = node
+		}
+	}
+	b.selectContent(selection)
+	return b.build(exportedAt)
+}
+
+func (b *contentBundleBuilder) selectContent(selection ContentBundleSelection) {
+	all := selection.IsEmpty()
+	selectedArchetypes := map[uuid.UUID]bool{}
+	for _, id := range selection.QuestArchetypeIDs {
+		selectedArchetypes[id] = true
+	}
+	selectedZoneKinds := map[string]bool{}
+	for _, slug := range selection.ZoneKinds {
+		selectedZoneKinds[strings.ToLower(strings.TrimSpace(slug))] = true
+	}
+	inSelectedZoneKind := func(zoneKind string) bool {
+		return all || selectedZoneKinds[strings.ToLower(strings.TrimSpace(zoneKind))]
+	}
+
+	for _, kind := range b.source.ZoneKinds {
+		if inSelectedZoneKind(kind.Slug) {
+			b.includeZoneKind(kind.Slug)
+		}
+	}
+	if all {
+		for _, genre := range b.source.ZoneGenres {
+			b.genres[genre.ID] = true
+		}
+		for _, archetype := range b.source.LocationArchetypes {
+			if archetype != nil {
+				b.locationArchetypes[archetype.ID] = true
+			}
+		}
+		for idx := range b.source.ChallengeTemplates {
+			b.includeChallengeTemplate(b.source.ChallengeTemplates[idx].ID)
+		}
+		for idx := range b.source.Spells {
+			b.includeSpell(b.source.Spells[idx].ID)
+		}
+	}
+	for idx := range b.source.MonsterTemplates {
+		if inSelectedZoneKind(b.source.MonsterTemplates[idx].ZoneKind) {
+			b.includeMonsterTemplate(b.source.MonsterTemplates[idx].ID)
+		}
+	}
+	for idx := range b.source.ScenarioTemplates {
+		if inSelectedZoneKind(b.source.ScenarioTemplates[idx].ZoneKind) {
+			b.includeScenarioTemplate(b.source.ScenarioTemplates[idx].ID)
+		}
+	}
+	for idx := range b.source.ExpositionTemplates {
+		if inSelectedZoneKind(b.source.ExpositionTemplates[idx].ZoneKind) {
+			b.includeExpositionTemplate(b.source.ExpositionTemplates[idx].ID)
+		}
+	}
+	for idx := range b.source.ShrineTemplates {
+		if inSelectedZoneKind(b.source.ShrineTemplates[idx].ZoneKind) {
+			b.shrineTemplates[b.source.ShrineTemplates[idx].ID] = true
+			b.includeZoneKind(b.source.ShrineTemplates[idx].ZoneKind)
+		}
+	}
+	for _, archetype := range b.source.QuestArchetypes {
+		if archetype == nil {
+			continue
+		}
+		if selectedArchetypes[archetype.ID] || inSelectedZoneKind(archetype.ZoneKind) {
+			b.includeQuestArchetype(archetype)
+		}
+	}
+}
+
+func (b *contentBundleBuilder) includeZoneKind(slug string) {
+	slug = strings.TrimSpace(slug)
+	if slug != "" {
+		b.zoneKinds[strings.ToLower(slug)] = true
+	}
+}
+
+func (b *contentBundleBuilder) includeItem(id int) {
+	if id > 0 {
+		b.itemIDs[id] = true
+	}
+}
+
+func (b *contentBundleBuilder) includeItemID(id *int) {
+	if id != nil {
+		b.includeItem(*id)
+	}
+}
+
+func (b *contentBundleBuilder) includeSpell(id uuid.UUID) {
+	if id == uuid.Nil || b.spells[id] {
+		return
+	}
+	b.spells[id] = true
+	for idx := range b.source.Spells {
+		if b.source.Spells[idx].ID == id {
+			b.includeGenre(b.source.Spells[idx].GenreID)
+		}
+	}
+}
+
+func (b *contentBundleBuilder) includeGenre(id uuid.UUID) {
+	if id != uuid.Nil {
+		b.genres[id] = true
+	}
+}
+
+func (b *contentBundleBuilder) includeMonsterTemplate(id uuid.UUID) {
+	if b.monsterTemplates[id] {
+		return
+	}
+	for idx := range b.source.MonsterTemplates {
+		template := &b.source.MonsterTemplates[idx]
+		if template.ID != id {
+			continue
+		}
+		b.monsterTemplates[id] = true
+		b.includeGenre(template.GenreID)
+		b.includeZoneKind(template.ZoneKind)
+		for _, spell := range template.Spells {
+			b.includeSpell(spell.SpellID)
+		}
+	}
+}
+
+func (b *contentBundleBuilder) includeChallengeTemplate(id uuid.UUID) {
+	if b.challengeTemplates[id] {
+		return
+	}
+	for idx := range b.source.ChallengeTemplates {
+		template := &b.source.ChallengeTemplates[idx]
+		if template.ID != id {
+			continue
+		}
+		b.challengeTemplates[id] = true
+		if template.LocationArchetypeID != uuid.Nil {
+			b.locationArchetypes[template.LocationArchetypeID] = true
+		}
+		b.includeItemID(template.InventoryItemID)
+		for _, reward := range template.ItemChoiceRewards {
+			b.includeItem(reward.InventoryItemID)
+		}
+	}
+}
+
+func (b *contentBundleBuilder) includeScenarioTemplate(id uuid.UUID) {
+	if b.scenarioTemplates[id] {
+		return
+	}
+	for idx := range b.source.ScenarioTemplates {
+		template := &b.source.ScenarioTemplates[idx]
+		if template.ID != id {
+			continue
+		}
+		b.scenarioTemplates[id] = true
+		b.includeGenre(template.GenreID)
+		b.includeZoneKind(template.ZoneKind)
+		b.includeScenarioRewards(template.ItemRewards, template.ItemChoiceRewards, template.SpellRewards)
+		for _, option := range template.Options {
+			b.includeScenarioRewards(option.ItemRewards, option.ItemChoiceRewards, option.SpellRewards)
+		}
+	}
+}
+
+func (b *contentBundleBuilder) includeScenarioRewards(items ScenarioTemplateRewards, choices ScenarioTemplateRewards, spells ScenarioTemplateSpellRewards) {
+	for _, reward := range items {
+		b.includeItem(reward.InventoryItemID)
+	}
+	for _, reward := range choices {
+		b.includeItem(reward.InventoryItemID)
+	}
+	for _, reward := range spells {
+		b.includeSpell(reward.SpellID)
+	}
+}
+
+func (b *contentBundleBuilder) includeExpositionTemplate(id uuid.UUID) {
+	if b.expositions[id] {
+		return
+	}
+	for idx := range b.source.ExpositionTemplates {
+		template := &b.source.ExpositionTemplates[idx]
+		if template.ID != id {
+			continue
+		}
+		b.expositions[id] = true
+		b.includeZoneKind(template.ZoneKind)
+		for _, reward := range template.ItemRewards {
+			b.includeItem(reward.InventoryItemID)
+		}
+		for _, reward := range template.SpellRewards {
+			b.includeSpell(reward.SpellID)
+		}
+	}
+}
+
+func (b *contentBundleBuilder) includeQuestArchetype(archetype *QuestArchetype) {
+	if b.questArchetypes[archetype.ID] {
+		return
+	}
+	b.questArchetypes[archetype.ID] = true
+	b.includeZoneKind(archetype.ZoneKind)
+	for _, reward := range archetype.ItemRewards {
+		b.includeItem(reward.InventoryItemID)
+	}
+	for _, reward := range archetype.SpellRewards {
+		b.includeSpell(reward.SpellID)
+	}
+	for _, node := range b.reachableNodes(archetype.RootID) {
+		b.includeNode(node)
+	}
+}
+
+func (b *contentBundleBuilder) includeNode(node *QuestArchetypeNode) {
+	if node.LocationArchetypeID != nil {
+		b.locationArchetypes[*node.LocationArchetypeID] = true
+	}
+	if node.ChallengeTemplateID != nil {
+		b.includeChallengeTemplate(*node.ChallengeTemplateID)
+	}
+	if node.ScenarioTemplateID != nil {
+		b.includeScenarioTemplate(*node.ScenarioTemplateID)
+	}
+	if node.ExpositionTemplateID != nil {
+		b.includeExpositionTemplate(*node.ExpositionTemplateID)
+	}
+	for _, raw := range node.MonsterTemplateIDs {
+		if id, err := uuid.Parse(strings.TrimSpace(raw)); err == nil {
+			b.includeMonsterTemplate(id)
+		}
+	}
+	for _, requirement := range node.FetchRequirements {
+		b.includeItem(requirement.InventoryItemID)
+	}
+	for _, reward := range node.EncounterItemRewards {
+		b.includeItem(reward.InventoryItemID)
+	}
+	for _, reward := range node.ExpositionItemRewards {
+		b.includeItem(reward.InventoryItemID)
+	}
+	for _, reward := range node.ExpositionSpellRewards {
+		b.includeSpell(reward.SpellID)
+	}
+	for _, challenge := range node.Challenges {
+		if challenge.ChallengeTemplateID != nil {
+			b.includeChallengeTemplate(*challenge.ChallengeTemplateID)
+		}
+		b.includeItemID(challenge.InventoryItemID)
+	}
+}
+
+// reachableNodes walks the graph from rootID, following success and failure
+// edges, and returns each node once in discovery order.
+func (b *contentBundleBuilder) reachableNodes(rootID uuid.UUID) []*QuestArchetypeNode {
+	return reachableQuestArchetypeNodes(rootID, b.nodes)
+}
+
+func reachableQuestArchetypeNodes(rootID uuid.UUID, nodes map[uuid.UUID]*QuestArchetypeNode) []*QuestArchetypeNode {
+	visited := map[uuid.UUID]bool{}
+	queue := []uuid.UUID{rootID}
+	result := []*QuestArchetypeNode{}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		if visited[id] {
+			continue
+		}
+		visited[id] = true
+		node := nodes[id]
+		if node == nil {
+			continue
+		}
+		result = append(result, node)
+		for _, challenge := range node.Challenges {
+			if challenge.UnlockedNodeID != nil {
+				queue = append(queue, *challenge.UnlockedNodeID)
+			}
+			if challenge.FailureUnlockedNodeID != nil {
+				queue = append(queue, *challenge.FailureUnlockedNodeID)
+			}
+		}
+	}
+	return result
+}
+
+func (b *contentBundleBuilder) warnf(format string, args ...interface{}) {
+	b.warnings = append(b.warnings, fmt.Sprintf(format, args...))
+}
+
+func (b *contentBundleBuilder) build(exportedAt time.Time) *ContentBundle {
+	bundle := &ContentBundle{
+		Manifest: ContentBundleManifest{
+			FormatVersion: ContentBundleFormatVersion,
+			ExportedAt:    exportedAt.UTC(),
+		},
+	}
+	for _, genre := range b.source.ZoneGenres {
+		if b.genres[genre.ID] {
+			bundle.ZoneGenres = append(bundle.ZoneGenres, genre)
+		}
+	}
+	for _, kind := range b.source.ZoneKinds {
+		if b.zoneKinds[strings.ToLower(strings.TrimSpace(kind.Slug))] {
+			bundle.ZoneKinds = append(bundle.ZoneKinds, kind)
+		}
+	}
+	for _, archetype := range b.source.LocationArchetypes {
+		if archetype != nil && b.locationArchetypes[archetype.ID] {
+			copied := *archetype
+			copied.UsedChallenges = nil
+			bundle.LocationArchetypes = append(bundle.LocationArchetypes, copied)
+		}
+	}
+	for _, spell := range b.source.Spells {
+		if b.spells[spell.ID] {
+			spell.Genre = nil
+			spell.ProgressionLinks = nil
+			bundle.Spells = append(bundle.Spells, spell)
+		}
+	}
+	for _, template := range b.source.MonsterTemplates {
+		if !b.monsterTemplates[template.ID] {
+			continue
+		}
+		if len(template.Progressions) > 0 {
+			b.warnf("monster template %q: spell progressions are not exported", template.Name)
+		}
+		entry := ContentBundleMonsterTemplate{Spells: []ContentBundleSpellReference{}}
+		for _, spell := range template.Spells {
+			entry.Spells = append(entry.Spells, ContentBundleSpellReference{SpellID: spell.SpellID})
+		}
+		template.Genre = nil
+		template.Spells = nil
+		template.Progressions = nil
+		entry.MonsterTemplate = template
+		bundle.MonsterTemplates = append(bundle.MonsterTemplates, entry)
+	}
+	for _, template := range b.source.ChallengeTemplates {
+		if b.challengeTemplates[template.ID] {
+			template.LocationArchetype = nil
+			bundle.ChallengeTemplates = append(bundle.ChallengeTemplates, template)
+		}
+	}
+	for _, template := range b.source.ScenarioTemplates {
+		if b.scenarioTemplates[template.ID] {
+			template.Genre = nil
+			bundle.ScenarioTemplates = append(bundle.ScenarioTemplates, template)
+		}
+	}
+	for _, template := range b.source.ExpositionTemplates {
+		if b.expositions[template.ID] {
+			template.Dialogue = b.portableDialogue(template.Dialogue, fmt.Sprintf("exposition template %q", template.Title))
+			bundle.ExpositionTemplates = append(bundle.ExpositionTemplates, template)
+		}
+	}
+	for _, template := range b.source.ShrineTemplates {
+		if b.shrineTemplates[template.ID] {
+			bundle.ShrineTemplates = append(bundle.ShrineTemplates, template)
+		}
+	}
+	for _, archetype := range b.source.QuestArchetypes {
+		if archetype != nil && b.questArchetypes[archetype.ID] {
+			bundle.QuestArchetypes = append(bundle.QuestArchetypes, b.portableQuestArchetype(archetype))
+		}
+	}
+	for _, item := range b.source.InventoryItems {
+		if b.itemIDs[item.ID] {
+			bundle.InventoryItems = append(bundle.InventoryItems, ContentBundleItemReference{ID: item.ID, Name: item.Name})
+			delete(b.itemIDs, item.ID)
+		}
+	}
+	for id := range b.itemIDs {
+		b.warnf("inventory item %d is referenced but does not exist", id)
+	}
+
+	bundle.Manifest.Warnings = b.warnings
+	bundle.Manifest.Counts = bundle.Counts()
+	return bundle
+}
+
+// portableDialogue drops character IDs, which only mean something in the
+// environment the dialogue was written in. Speaker names and portraits stay.
+func (b *contentBundleBuilder) portableDialogue(dialogue DialogueSequence, owner string) DialogueSequence {
+	if len(dialogue) == 0 {
+		return dialogue
+	}
+	result := make(DialogueSequence, 0, len(dialogue))
+	dropped := false
+	for _, message := range dialogue {
+		if message.CharacterID != nil {
+			message.CharacterID = nil
+			dropped = true
+		}
+		result = append(result, message)
+	}
+	if dropped {
+		b.warnf("%s: dialogue character references were dropped", owner)
+	}
+	return result
+}
+
+func (b *contentBundleBuilder) portableQuestArchetype(archetype *QuestArchetype) ContentBundleQuestArchetype {
+	owner := fmt.Sprintf("quest archetype %q", archetype.Name)
+	entry := ContentBundleQuestArchetype{
+		ItemRewards:  []ContentBundleItemQuantity{},
+		SpellRewards: []ContentBundleSpellReference{},
+		Nodes:        []QuestArchetypeNode{},
+	}
+	for _, reward := range archetype.ItemRewards {
+		entry.ItemRewards = append(entry.ItemRewards, ContentBundleItemQuantity{
+			InventoryItemID: reward.InventoryItemID,
+			Quantity:        reward.Quantity,
+		})
+	}
+	for _, reward := range archetype.SpellRewards {
+		entry.SpellRewards = append(entry.SpellRewards, ContentBundleSpellReference{SpellID: reward.SpellID})
+	}
+
+	copied := *archetype
+	if copied.QuestGiverCharacterID != nil {
+		b.warnf("%s: quest giver character was dropped", owner)
+	}
+	copied.QuestGiverCharacterID = nil
+	copied.QuestGiverCharacter = nil
+	copied.AcceptanceDialogue = b.portableDialogue(copied.AcceptanceDialogue, owner)
+	copied.Root = QuestArchetypeNode{}
+	copied.ItemRewards = nil
+	copied.SpellRewards = nil
+	copied.ValidationIssues = nil
+	entry.QuestArchetype = copied
+
+	for _, node := range b.reachableNodes(archetype.RootID) {
+		entry.Nodes = append(entry.Nodes, b.portableNode(node, owner))
+	}
+	return entry
+}
+
+func (b *contentBundleBuilder) portableNode(node *QuestArchetypeNode, owner string) QuestArchetypeNode {
+	copied := *node
+	if copied.FetchCharacterID != nil || copied.FetchCharacterTemplateID != nil {
+		b.warnf("%s: fetch character on node %s was dropped", owner, node.ID)
+	}
+	copied.FetchCharacterID = nil
+	copied.FetchCharacter = nil
+	copied.FetchCharacterTemplateID = nil
+	copied.FetchCharacterTemplate = nil
+	copied.LocationArchetype = nil
+	copied.ChallengeTemplate = nil
+	copied.ScenarioTemplate = nil
+	copied.ExpositionTemplate = nil
+	copied.ExpositionDialogue = b.portableDialogue(copied.ExpositionDialogue, owner)
+	copied.Challenges = make([]QuestArchetypeChallenge, 0, len(node.Challenges))
+	for _, challenge := range node.Challenges {
+		challenge.ChallengeTemplate = nil
+		challenge.UnlockedNode = nil
+		challenge.FailureUnlockedNode = nil
+		copied.Challenges = append(copied.Challenges, challenge)
+	}
+	return copied
+}
+
+// Counts returns how many records of each kind the bundle holds.
+func (b *ContentBundle) Counts() map[ContentBundleKind]int {
+	nodes := 0
+	for _, archetype := range b.QuestArchetypes {
+		nodes += len(archetype.Nodes)
+	}
+	return map[ContentBundleKind]int{
+		ContentBundleKindZoneGenre:          len(b.ZoneGenres),
+		ContentBundleKindZoneKind:           len(b.ZoneKinds),
+		ContentBundleKindLocationArchetype:  len(b.LocationArchetypes),
+		ContentBundleKindInventoryItem:      len(b.InventoryItems),
+		ContentBundleKindSpell:              len(b.Spells),
+		ContentBundleKindMonsterTemplate:    len(b.MonsterTemplates),
+		ContentBundleKindChallengeTemplate:  len(b.ChallengeTemplates),
+		ContentBundleKindScenarioTemplate:   len(b.ScenarioTemplates),
+		ContentBundleKindExpositionTemplate: len(b.ExpositionTemplates),
+		ContentBundleKindShrineTemplate:     len(b.ShrineTemplates),
+		ContentBundleKindQuestArchetype:     len(b.QuestArchetypes),
+		ContentBundleKindQuestArchetypeNode: nodes,
+	}
+}
+
+// EachImageURL calls fn with a pointer to every non-empty image URL in the
+// bundle so callers can collect or rewrite them.
+func (b *ContentBundle) EachImageURL(fn func(url *string)) {
+	visit := func(url *string) {
+		if strings.TrimSpace(*url) != "" {
+			fn(url)
+		}
+	}
+	for idx := range b.ZoneKinds {
+		visit(&b.ZoneKinds[idx].PatternTileURL)
+	}
+	for idx := range b.Spells {
+		visit(&b.Spells[idx].IconURL)
+	}
+	for idx := range b.MonsterTemplates {
+		visit(&b.MonsterTemplates[idx].ImageURL)
+		visit(&b.MonsterTemplates[idx].ThumbnailURL)
+	}
+	for idx := range b.ChallengeTemplates {
+		visit(&b.ChallengeTemplates[idx].ImageURL)
+		visit(&b.ChallengeTemplates[idx].ThumbnailURL)
+	}
+	for idx := range b.ScenarioTemplates {
+		visit(&b.ScenarioTemplates[idx].ImageURL)
+		visit(&b.ScenarioTemplates[idx].ThumbnailURL)
+	}
+	for idx := range b.ExpositionTemplates {
+		visit(&b.ExpositionTemplates[idx].ImageURL)
+		visit(&b.ExpositionTemplates[idx].ThumbnailURL)
+	}
+	for idx := range b.QuestArchetypes {
+		visit(&b.QuestArchetypes[idx].ImageURL)
+	}
+}
@@ -0,0 +1,875 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ContentBundleConflictPolicy string
+
+const (
+	// ContentBundleConflictPolicyFail reports records that differ from an
+	// existing record with the same slug or name and refuses to apply.
+	ContentBundleConflictPolicyFail ContentBundleConflictPolicy = "fail"
+	// ContentBundleConflictPolicySkip keeps the existing record and points the
+	// rest of the bundle at it.
+	ContentBundleConflictPolicySkip ContentBundleConflictPolicy = "skip"
+	// ContentBundleConflictPolicyOverwrite updates the existing record in place.
+	ContentBundleConflictPolicyOverwrite ContentBundleConflictPolicy = "overwrite"
+)
+
+func NormalizeContentBundleConflictPolicy(raw string) (ContentBundleConflictPolicy, bool) {
+	switch ContentBundleConflictPolicy(strings.ToLower(strings.TrimSpace(raw))) {
+	case "", ContentBundleConflictPolicyFail:
+		return ContentBundleConflictPolicyFail, true
+	case ContentBundleConflictPolicySkip:
+		return ContentBundleConflictPolicySkip, true
+	case ContentBundleConflictPolicyOverwrite:
+		return ContentBundleConflictPolicyOverwrite, true
+	default:
+		return "", false
+	}
+}
+
+type ContentBundleImportAction string
+
+const (
+	ContentBundleImportActionCreate    ContentBundleImportAction = "create"
+	ContentBundleImportActionUpdate    ContentBundleImportAction = "update"
+	ContentBundleImportActionUnchanged ContentBundleImportAction = "unchanged"
+	ContentBundleImportActionSkip      ContentBundleImportAction = "skip"
+	ContentBundleImportActionConflict  ContentBundleImportAction = "conflict"
+	ContentBundleImportActionMissing   ContentBundleImportAction = "missing"
+)
+
+// ContentBundleImportChange is one line of an import diff: what happens to a
+// bundle record and, when it matches an existing record, which fields differ.
+type ContentBundleImportChange struct {
+	Kind     ContentBundleKind         `json:"kind"`
+	Key      string                    `json:"key"`
+	Action   ContentBundleImportAction `json:"action"`
+	SourceID string                    `json:"sourceId,omitempty"`
+	TargetID string                    `json:"targetId,omitempty"`
+	Fields   []string                  `json:"fields,omitempty"`
+	Message  string                    `json:"message,omitempty"`
+}
+
+type ContentBundleWriteOp string
+
+const (
+	ContentBundleWriteOpCreate ContentBundleWriteOp = "create"
+	ContentBundleWriteOpUpdate ContentBundleWriteOp = "update"
+	ContentBundleWriteOpDelete ContentBundleWriteOp = "delete"
+)
+
+// ContentBundleWrite is a single row change. Record is a pointer to the model
+// with every ID already remapped into the target environment; monster
+// templates and quest archetypes carry their join rows, and node challenges
+// are written as *QuestArchetypeNodeChallenge with the challenge attached.
+type ContentBundleWrite struct {
+	Op     ContentBundleWriteOp
+	Record interface{}
+}
+
+type ContentBundleImportPlan struct {
+	Policy   ContentBundleConflictPolicy `json:"policy"`
+	Changes  []ContentBundleImportChange `json:"changes"`
+	Warnings []string                    `json:"warnings,omitempty"`
+	Writes   []ContentBundleWrite        `json:"-"`
+}
+
+// HasConflicts reports whether anything would stop the plan from applying.
+func (p *ContentBundleImportPlan) HasConflicts() bool {
+	for _, change := range p.Changes {
+		if change.Action == ContentBundleImportActionConflict || change.Action == ContentBundleImportActionMissing {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *ContentBundleImportPlan) Summary() map[ContentBundleImportAction]int {
+	summary := map[ContentBundleImportAction]int{}
+	for _, change := range p.Changes {
+		summary[change.Action]++
+	}
+	return summary
+}
+
+type contentBundlePlanner struct {
+	policy ContentBundleConflictPolicy
+	plan   *ContentBundleImportPlan
+	ids    map[uuid.UUID]uuid.UUID
+	items  map[int]int
+}
+
+type contentBundleRecordSpec[T any] struct {
+	kind      ContentBundleKind
+	key       func(*T) string
+	id        func(*T) *uuid.UUID
+	createdAt func(*T) *time.Time
+	remap     func(*T)
+	record    func(*T) interface{}
+}
+
+// PlanContentBundleImport matches bundle records against target by slug (zone
+// kinds) or name, remaps every UUID and item reference into the target and
+// returns the diff along with the writes needed to apply it. Nothing is
+// written; a plan with conflicts must not be applied.
+func PlanContentBundleImport(
+	bundle *ContentBundle,
+	target ContentBundleSource,
+	policy ContentBundleConflictPolicy,
+) (*ContentBundleImportPlan, error) {
+	if bundle == nil {
+		return nil, fmt.Errorf("content bundle is required")
+	}
+	if bundle.Manifest.FormatVersion < 1 || bundle.Manifest.FormatVersion > ContentBundleFormatVersion {
+		return nil, fmt.Errorf("unsupported content bundle format version %d", bundle.Manifest.FormatVersion)
+	}
+	if _, ok := NormalizeContentBundleConflictPolicy(string(policy)); !ok {
+		return nil, fmt.Errorf("invalid conflict policy %q", policy)
+	}
+
+	p := &contentBundlePlanner{
+		policy: policy,
+		plan:   &ContentBundleImportPlan{Policy: policy, Changes: []ContentBundleImportChange{}},
+		ids:    map[uuid.UUID]uuid.UUID{},
+		items:  map[int]int{},
+	}
+	for _, warning := range bundle.Manifest.Warnings {
+		p.warnf("export: %s", warning)
+	}
+	existing := BuildContentBundle(target, ContentBundleSelection{}, time.Now())
+
+	p.planInventoryItems(bundle.InventoryItems, target.InventoryItems)
+	planContentBundleRecords(p, bundle.ZoneGenres, existing.ZoneGenres, contentBundleRecordSpec[ZoneGenre]{
+		kind:      ContentBundleKindZoneGenre,
+		key:       func(r *ZoneGenre) string { return r.Name },
+		id:        func(r *ZoneGenre) *uuid.UUID { return &r.ID },
+		createdAt: func(r *ZoneGenre) *time.Time { return &r.CreatedAt },
+	})
+	planContentBundleRecords(p, bundle.ZoneKinds, existing.ZoneKinds, contentBundleRecordSpec[ZoneKind]{
+		kind:      ContentBundleKindZoneKind,
+		key:       func(r *ZoneKind) string { return r.Slug },
+		id:        func(r *ZoneKind) *uuid.UUID { return &r.ID },
+		createdAt: func(r *ZoneKind) *time.Time { return &r.CreatedAt },
+	})
+	planContentBundleRecords(p, bundle.LocationArchetypes, existing.LocationArchetypes, contentBundleRecordSpec[LocationArchetype]{
+		kind:      ContentBundleKindLocationArchetype,
+		key:       func(r *LocationArchetype) string { return r.Name },
+		id:        func(r *LocationArchetype) *uuid.UUID { return &r.ID },
+		createdAt: func(r *LocationArchetype) *time.Time { return &r.CreatedAt },
+	})
+	planContentBundleRecords(p, bundle.Spells, existing.Spells, contentBundleRecordSpec[Spell]{
+		kind:      ContentBundleKindSpell,
+		key:       func(r *Spell) string { return r.Name },
+		id:        func(r *Spell) *uuid.UUID { return &r.ID },
+		createdAt: func(r *Spell) *time.Time { return &r.CreatedAt },
+		remap: func(r *Spell) {
+			r.GenreID = p.mapID(r.GenreID, "spell "+r.Name)
+		},
+	})
+	planContentBundleRecords(p, bundle.MonsterTemplates, existing.MonsterTemplates, contentBundleRecordSpec[ContentBundleMonsterTemplate]{
+		kind:      ContentBundleKindMonsterTemplate,
+		key:       func(r *ContentBundleMonsterTemplate) string { return r.Name },
+		id:        func(r *ContentBundleMonsterTemplate) *uuid.UUID { return &r.ID },
+		createdAt: func(r *ContentBundleMonsterTemplate) *time.Time { return &r.CreatedAt },
+		remap:     p.remapMonsterTemplate,
+		record: func(r *ContentBundleMonsterTemplate) interface{} {
+			template := r.MonsterTemplate
+			template.Spells = make([]MonsterTemplateSpell, 0, len(r.Spells))
+			for _, spell := range r.Spells {
+				template.Spells = append(template.Spells, MonsterTemplateSpell{
+					ID:                uuid.New(),
+					MonsterTemplateID: template.ID,
+					SpellID:           spell.SpellID,
+				})
+			}
+			return &template
+		},
+	})
+	planContentBundleRecords(p, bundle.ChallengeTemplates, existing.ChallengeTemplates, contentBundleRecordSpec[ChallengeTemplate]{
+		kind:      ContentBundleKindChallengeTemplate,
+		key:       func(r *ChallengeTemplate) string { return r.Question },
+		id:        func(r *ChallengeTemplate) *uuid.UUID { return &r.ID },
+		createdAt: func(r *ChallengeTemplate) *time.Time { return &r.CreatedAt },
+		remap:     p.remapChallengeTemplate,
+	})
+	planContentBundleRecords(p, bundle.ScenarioTemplates, existing.ScenarioTemplates, contentBundleRecordSpec[ScenarioTemplate]{
+		kind:      ContentBundleKindScenarioTemplate,
+		key:       func(r *ScenarioTemplate) string { return r.Prompt },
+		id:        func(r *ScenarioTemplate) *uuid.UUID { return &r.ID },
+		createdAt: func(r *ScenarioTemplate) *time.Time { return &r.CreatedAt },
+		remap:     p.remapScenarioTemplate,
+	})
+	planContentBundleRecords(p, bundle.ExpositionTemplates, existing.ExpositionTemplates, contentBundleRecordSpec[ExpositionTemplate]{
+		kind:      ContentBundleKindExpositionTemplate,
+		key:       func(r *ExpositionTemplate) string { return r.Title },
+		id:        func(r *ExpositionTemplate) *uuid.UUID { return &r.ID },
+		createdAt: func(r *ExpositionTemplate) *time.Time { return &r.CreatedAt },
+		remap:     p.remapExpositionTemplate,
+	})
+	planContentBundleRecords(p, bundle.ShrineTemplates, existing.ShrineTemplates, contentBundleRecordSpec[ShrineTemplate]{
+		kind:      ContentBundleKindShrineTemplate,
+		key:       func(r *ShrineTemplate) string { return r.Name },
+		id:        func(r *ShrineTemplate) *uuid.UUID { return &r.ID },
+		createdAt: func(r *ShrineTemplate) *time.Time { return &r.CreatedAt },
+	})
+	p.planQuestArchetypes(bundle.QuestArchetypes, existing.QuestArchetypes)
+	p.checkZoneKinds(bundle, existing)
+
+	return p.plan, nil
+}
+
+func (p *contentBundlePlanner) warnf(format string, args ...interface{}) {
+	p.plan.Warnings = append(p.plan.Warnings, fmt.Sprintf(format, args...))
+}
+
+func (p *contentBundlePlanner) addChange(change ContentBundleImportChange) {
+	p.plan.Changes = append(p.plan.Changes, change)
+}
+
+func (p *contentBundlePlanner) write(op ContentBundleWriteOp, record interface{}) {
+	p.plan.Writes = append(p.plan.Writes, ContentBundleWrite{Op: op, Record: record})
+}
+
+// mapID returns the target ID for a bundle ID. IDs the bundle doesn't define
+// are kept as-is, which only resolves if the target already has that row.
+func (p *contentBundlePlanner) mapID(id uuid.UUID, owner string) uuid.UUID {
+	if id == uuid.Nil {
+		return id
+	}
+	if mapped, ok := p.ids[id]; ok {
+		return mapped
+	}
+	p.warnf("%s references %s, which is not in the bundle", owner, id)
+	return id
+}
+
+func (p *contentBundlePlanner) mapIDPointer(id *uuid.UUID, owner string) *uuid.UUID {
+	if id == nil {
+		return nil
+	}
+	mapped := p.mapID(*id, owner)
+	return &mapped
+}
+
+// mapItem returns the target item ID for a bundle item ID. Unresolved items
+// have already been reported as missing.
+func (p *contentBundlePlanner) mapItem(id int) (int, bool) {
+	mapped, ok := p.items[id]
+	return mapped, ok
+}
+
+func (p *contentBundlePlanner) mapItemPointer(id *int) *int {
+	if id == nil {
+		return nil
+	}
+	if mapped, ok := p.mapItem(*id); ok {
+		return &mapped
+	}
+	return nil
+}
+
+func (p *contentBundlePlanner) planInventoryItems(references []ContentBundleItemReference, items []InventoryItem) {
+	byName := map[string]InventoryItem{}
+	for _, item := range items {
+		key := contentBundleKey(item.Name)
+		if current, ok := byName[key]; ok && !current.Archived {
+			continue
+		}
+		byName[key] = item
+	}
+	for _, reference := range references {
+		change := ContentBundleImportChange{
+			Kind:     ContentBundleKindInventoryItem,
+			Key:      reference.Name,
+			SourceID: fmt.Sprint(reference.ID),
+		}
+		if item, ok := byName[contentBundleKey(reference.Name)]; ok {
+			p.items[reference.ID] = item.ID
+			change.Action = ContentBundleImportActionUnchanged
+			change.TargetID = fmt.Sprint(item.ID)
+		} else {
+			change.Action = ContentBundleImportActionMissing
+			change.Message = "no inventory item with this name exists in the target"
+		}
+		p.addChange(change)
+	}
+}
+
+func planContentBundleRecords[T any](
+	p *contentBundlePlanner,
+	records []T,
+	existing []T,
+	spec contentBundleRecordSpec[T],
+) {
+	byKey := map[string]*T{}
+	for idx := range existing {
+		key := contentBundleKey(spec.key(&existing[idx]))
+		if _, ok := byKey[key]; !ok && key != "" {
+			byKey[key] = &existing[idx]
+		}
+	}
+
+	seen := map[string]uuid.UUID{}
+	for idx := range records {
+		record := records[idx]
+		sourceID := *spec.id(&record)
+		key := contentBundleKey(spec.key(&record))
+		change := ContentBundleImportChange{
+			Kind:     spec.kind,
+			Key:      spec.key(&record),
+			SourceID: sourceID.String(),
+		}
+		if firstID, ok := seen[key]; ok && key != "" {
+			p.ids[sourceID] = firstID
+			change.Action = ContentBundleImportActionConflict
+			change.Message = "the bundle contains this key more than once"
+			p.addChange(change)
+			continue
+		}
+
+		match := byKey[key]
+		if match != nil {
+			p.ids[sourceID] = *spec.id(match)
+		} else {
+			p.ids[sourceID] = uuid.New()
+		}
+		seen[key] = p.ids[sourceID]
+		*spec.id(&record) = p.ids[sourceID]
+		change.TargetID = p.ids[sourceID].String()
+		if spec.remap != nil {
+			spec.remap(&record)
+		}
+
+		if match == nil {
+			change.Action = ContentBundleImportActionCreate
+			p.addChange(change)
+			p.write(ContentBundleWriteOpCreate, contentBundleRecord(spec, &record))
+			continue
+		}
+
+		change.Fields = diffContentBundleRecords(&record, match)
+		switch {
+		case len(change.Fields) == 0:
+			change.Action = ContentBundleImportActionUnchanged
+		case p.policy == ContentBundleConflictPolicySkip:
+			change.Action = ContentBundleImportActionSkip
+		case p.policy == ContentBundleConflictPolicyOverwrite:
+			change.Action = ContentBundleImportActionUpdate
+			*spec.createdAt(&record) = *spec.createdAt(match)
+			p.write(ContentBundleWriteOpUpdate, contentBundleRecord(spec, &record))
+		default:
+			change.Action = ContentBundleImportActionConflict
+			change.Message = "an existing record with this key differs"
+		}
+		p.addChange(change)
+	}
+}
+
+func contentBundleRecord[T any](spec contentBundleRecordSpec[T], record *T) interface{} {
+	if spec.record != nil {
+		return spec.record(record)
+	}
+	copied := *record
+	return &copied
+}
+
+func (p *contentBundlePlanner) remapMonsterTemplate(r *ContentBundleMonsterTemplate) {
+	owner := "monster template " + r.Name
+	r.GenreID = p.mapID(r.GenreID, owner)
+	spells := make([]ContentBundleSpellReference, 0, len(r.Spells))
+	for _, spell := range r.Spells {
+		spells = append(spells, ContentBundleSpellReference{SpellID: p.mapID(spell.SpellID, owner)})
+	}
+	r.Spells = spells
+}
+
+func (p *contentBundlePlanner) remapChallengeTemplate(r *ChallengeTemplate) {
+	owner := "challenge template " + r.Question
+	r.LocationArchetypeID = p.mapID(r.LocationArchetypeID, owner)
+	r.InventoryItemID = p.mapItemPointer(r.InventoryItemID)
+	rewards := ChallengeTemplateItemChoiceRewards{}
+	for _, reward := range r.ItemChoiceRewards {
+		if id, ok := p.mapItem(reward.InventoryItemID); ok {
+			reward.InventoryItemID = id
+			rewards = append(rewards, reward)
+		}
+	}
+	r.ItemChoiceRewards = rewards
+}
+
+func (p *contentBundlePlanner) remapScenarioTemplate(r *ScenarioTemplate) {
+	owner := "scenario template " + r.Prompt
+	r.GenreID = p.mapID(r.GenreID, owner)
+	r.ItemRewards = p.remapScenarioItemRewards(r.ItemRewards)
+	r.ItemChoiceRewards = p.remapScenarioItemRewards(r.ItemChoiceRewards)
+	r.SpellRewards = p.remapScenarioSpellRewards(r.SpellRewards, owner)
+	options := make(ScenarioTemplateOptions, 0, len(r.Options))
+	for _, option := range r.Options {
+		option.ItemRewards = p.remapScenarioItemRewards(option.ItemRewards)
+		option.ItemChoiceRewards = p.remapScenarioItemRewards(option.ItemChoiceRewards)
+		option.SpellRewards = p.remapScenarioSpellRewards(option.SpellRewards, owner)
+		options = append(options, option)
+	}
+	r.Options = options
+}
+
+func (p *contentBundlePlanner) remapScenarioItemRewards(rewards ScenarioTemplateRewards) ScenarioTemplateRewards {
+	result := ScenarioTemplateRewards{}
+	for _, reward := range rewards {
+		if id, ok := p.mapItem(reward.InventoryItemID); ok {
+			reward.InventoryItemID = id
+			result = append(result, reward)
+		}
+	}
+	return result
+}
+
+func (p *contentBundlePlanner) remapScenarioSpellRewards(rewards ScenarioTemplateSpellRewards, owner string) ScenarioTemplateSpellRewards {
+	result := ScenarioTemplateSpellRewards{}
+	for _, reward := range rewards {
+		result = append(result, ScenarioTemplateSpellReward{SpellID: p.mapID(reward.SpellID, owner)})
+	}
+	return result
+}
+
+func (p *contentBundlePlanner) remapExpositionTemplate(r *ExpositionTemplate) {
+	owner := "exposition template " + r.Title
+	items := ExpositionTemplateItemRewards{}
+	for _, reward := range r.ItemRewards {
+		if id, ok := p.mapItem(reward.InventoryItemID); ok {
+			reward.InventoryItemID = id
+			items = append(items, reward)
+		}
+	}
+	r.ItemRewards = items
+	spells := ExpositionTemplateSpellRewards{}
+	for _, reward := range r.SpellRewards {
+		spells = append(spells, ExpositionTemplateSpellReward{SpellID: p.mapID(reward.SpellID, owner)})
+	}
+	r.SpellRewards = spells
+}
+
+// planQuestArchetypes imports each archetype with a freshly keyed copy of its
+// graph. Overwriting an archetype points it at the new graph and deletes the
+// nodes only the old graph used.
+func (p *contentBundlePlanner) planQuestArchetypes(entries []ContentBundleQuestArchetype, existing []ContentBundleQuestArchetype) {
+	byName := map[string]*ContentBundleQuestArchetype{}
+	for idx := range existing {
+		key := contentBundleKey(existing[idx].Name)
+		if _, ok := byName[key]; !ok && key != "" {
+			byName[key] = &existing[idx]
+		}
+	}
+	seen := map[string]bool{}
+
+	for _, entry := range entries {
+		sourceID := entry.ID
+		key := contentBundleKey(entry.Name)
+		change := ContentBundleImportChange{
+			Kind:     ContentBundleKindQuestArchetype,
+			Key:      entry.Name,
+			SourceID: sourceID.String(),
+		}
+		if seen[key] && key != "" {
+			change.Action = ContentBundleImportActionConflict
+			change.Message = "the bundle contains this key more than once"
+			p.addChange(change)
+			continue
+		}
+		seen[key] = true
+
+		match := byName[key]
+		if match != nil {
+			entry.ID = match.ID
+		} else {
+			entry.ID = uuid.New()
+		}
+		p.ids[sourceID] = entry.ID
+		change.TargetID = entry.ID.String()
+		p.remapQuestArchetype(&entry)
+
+		if match == nil {
+			change.Action = ContentBundleImportActionCreate
+			p.addChange(change)
+			p.writeQuestArchetype(entry, ContentBundleWriteOpCreate)
+			continue
+		}
+
+		change.Fields = diffContentBundleRecords(&entry, match, "rootId", "nodes")
+		if !reflect.DeepEqual(
+			contentBundleGraphShape(entry.RootID, entry.Nodes),
+			contentBundleGraphShape(match.RootID, match.Nodes),
+		) {
+			change.Fields = append(change.Fields, "nodes")
+		}
+		switch {
+		case len(change.Fields) == 0:
+			change.Action = ContentBundleImportActionUnchanged
+		case p.policy == ContentBundleConflictPolicySkip:
+			change.Action = ContentBundleImportActionSkip
+		case p.policy == ContentBundleConflictPolicyOverwrite:
+			change.Action = ContentBundleImportActionUpdate
+			entry.CreatedAt = match.CreatedAt
+			p.writeQuestArchetype(entry, ContentBundleWriteOpUpdate)
+			retired := contentBundleRetiredNodes(match, existing)
+			for _, nodeID := range retired {
+				p.write(ContentBundleWriteOpDelete, &QuestArchetypeNode{ID: nodeID})
+			}
+			if len(retired) > 0 {
+				change.Message = fmt.Sprintf("replaces %d nodes of the existing graph", len(retired))
+			}
+		default:
+			change.Action = ContentBundleImportActionConflict
+			change.Message = "an existing record with this key differs"
+		}
+		p.addChange(change)
+	}
+}
+
+func (p *contentBundlePlanner) remapQuestArchetype(entry *ContentBundleQuestArchetype) {
+	owner := "quest archetype " + entry.Name
+	nodeIDs := map[uuid.UUID]uuid.UUID{}
+	for _, node := range entry.Nodes {
+		nodeIDs[node.ID] = uuid.New()
+	}
+	mapNode := func(id *uuid.UUID) *uuid.UUID {
+		if id == nil {
+			return nil
+		}
+		mapped, ok := nodeIDs[*id]
+		if !ok {
+			p.warnf("%s links to node %s, which is not in the bundle", owner, *id)
+			return nil
+		}
+		return &mapped
+	}
+
+	nodes := make([]QuestArchetypeNode, 0, len(entry.Nodes))
+	for _, node := range entry.Nodes {
+		node.ID = nodeIDs[node.ID]
+		node.LocationArchetypeID = p.mapIDPointer(node.LocationArchetypeID, owner)
+		node.ChallengeTemplateID = p.mapIDPointer(node.ChallengeTemplateID, owner)
+		node.ScenarioTemplateID = p.mapIDPointer(node.ScenarioTemplateID, owner)
+		node.ExpositionTemplateID = p.mapIDPointer(node.ExpositionTemplateID, owner)
+		monsterTemplateIDs := StringArray{}
+		for _, raw := range node.MonsterTemplateIDs {
+			id, err := uuid.Parse(strings.TrimSpace(raw))
+			if err != nil {
+				p.warnf("%s has an invalid monster template ID %q", owner, raw)
+				continue
+			}
+			monsterTemplateIDs = append(monsterTemplateIDs, p.mapID(id, owner).String())
+		}
+		node.MonsterTemplateIDs = monsterTemplateIDs
+		fetch := FetchQuestRequirements{}
+		for _, requirement := range node.FetchRequirements {
+			if id, ok := p.mapItem(requirement.InventoryItemID); ok {
+				requirement.InventoryItemID = id
+				fetch = append(fetch, requirement)
+			}
+		}
+		node.FetchRequirements = fetch
+		encounterItems := MonsterEncounterRewardItems{}
+		for _, reward := range node.EncounterItemRewards {
+			if id, ok := p.mapItem(reward.InventoryItemID); ok {
+				reward.InventoryItemID = id
+				encounterItems = append(encounterItems, reward)
+			}
+		}
+		node.EncounterItemRewards = encounterItems
+		expositionItems := QuestArchetypeExpositionItemRewards{}
+		for _, reward := range node.ExpositionItemRewards {
+			if id, ok := p.mapItem(reward.InventoryItemID); ok {
+				reward.InventoryItemID = id
+				expositionItems = append(expositionItems, reward)
+			}
+		}
+		node.ExpositionItemRewards = expositionItems
+		expositionSpells := QuestArchetypeExpositionSpellRewards{}
+		for _, reward := range node.ExpositionSpellRewards {
+			expositionSpells = append(expositionSpells, QuestArchetypeExpositionSpellReward{SpellID: p.mapID(reward.SpellID, owner)})
+		}
+		node.ExpositionSpellRewards = expositionSpells
+
+		challenges := make([]QuestArchetypeChallenge, 0, len(node.Challenges))
+		for _, challenge := range node.Challenges {
+			challenge.ID = uuid.New()
+			challenge.ChallengeTemplateID = p.mapIDPointer(challenge.ChallengeTemplateID, owner)
+			challenge.InventoryItemID = p.mapItemPointer(challenge.InventoryItemID)
+			challenge.UnlockedNodeID = mapNode(challenge.UnlockedNodeID)
+			challenge.FailureUnlockedNodeID = mapNode(challenge.FailureUnlockedNodeID)
+			challenges = append(challenges, challenge)
+		}
+		node.Challenges = challenges
+		nodes = append(nodes, node)
+	}
+	entry.Nodes = nodes
+	if root := mapNode(&entry.RootID); root != nil {
+		entry.RootID = *root
+	}
+
+	items := make([]ContentBundleItemQuantity, 0, len(entry.ItemRewards))
+	for _, reward := range entry.ItemRewards {
+		if id, ok := p.mapItem(reward.InventoryItemID); ok {
+			reward.InventoryItemID = id
+			items = append(items, reward)
+		}
+	}
+	entry.ItemRewards = items
+	spells := make([]ContentBundleSpellReference, 0, len(entry.SpellRewards))
+	for _, reward := range entry.SpellRewards {
+		spells = append(spells, ContentBundleSpellReference{SpellID: p.mapID(reward.SpellID, owner)})
+	}
+	entry.SpellRewards = spells
+}
+
+// writeQuestArchetype queues the archetype's nodes before its challenges, and
+// both before the archetype itself, so every foreign key already exists.
+func (p *contentBundlePlanner) writeQuestArchetype(entry ContentBundleQuestArchetype, op ContentBundleWriteOp) {
+	for _, node := range entry.Nodes {
+		node.Challenges = nil
+		copied := node
+		p.write(ContentBundleWriteOpCreate, &copied)
+	}
+	for _, node := range entry.Nodes {
+		for _, challenge := range node.Challenges {
+			p.write(ContentBundleWriteOpCreate, &QuestArchetypeNodeChallenge{
+				ID:                        uuid.New(),
+				QuestArchetypeNodeID:      node.ID,
+				QuestArchetypeChallengeID: challenge.ID,
+				QuestArchetypeChallenge:   challenge,
+			})
+		}
+	}
+
+	archetype := entry.QuestArchetype
+	archetype.Root = QuestArchetypeNode{}
+	archetype.ItemRewards = make([]QuestArchetypeItemReward, 0, len(entry.ItemRewards))
+	for _, reward := range entry.ItemRewards {
+		archetype.ItemRewards = append(archetype.ItemRewards, QuestArchetypeItemReward{
+			ID:               uuid.New(),
+			QuestArchetypeID: archetype.ID,
+			InventoryItemID:  reward.InventoryItemID,
+			Quantity:         reward.Quantity,
+		})
+	}
+	archetype.SpellRewards = make([]QuestArchetypeSpellReward, 0, len(entry.SpellRewards))
+	for _, reward := range entry.SpellRewards {
+		archetype.SpellRewards = append(archetype.SpellRewards, QuestArchetypeSpellReward{
+			ID:               uuid.New(),
+			QuestArchetypeID: archetype.ID,
+			SpellID:          reward.SpellID,
+		})
+	}
+	p.write(op, &archetype)
+}
+
+// contentBundleRetiredNodes returns the nodes of archetype's current graph
+// that no other archetype reaches.
+func contentBundleRetiredNodes(archetype *ContentBundleQuestArchetype, all []ContentBundleQuestArchetype) []uuid.UUID {
+	shared := map[uuid.UUID]bool{}
+	for idx := range all {
+		if all[idx].ID == archetype.ID {
+			continue
+		}
+		for _, node := range all[idx].Nodes {
+			shared[node.ID] = true
+		}
+	}
+	retired := []uuid.UUID{}
+	for _, node := range archetype.Nodes {
+		if !shared[node.ID] {
+			retired = append(retired, node.ID)
+		}
+	}
+	return retired
+}
+
+func (p *contentBundlePlanner) checkZoneKinds(bundle *ContentBundle, existing *ContentBundle) {
+	known := map[string]bool{}
+	for _, kind := range bundle.ZoneKinds {
+		known[contentBundleKey(kind.Slug)] = true
+	}
+	for _, kind := range existing.ZoneKinds {
+		known[contentBundleKey(kind.Slug)] = true
+	}
+	check := func(zoneKind string, owner string) {
+		if key := contentBundleKey(zoneKind); key != "" && !known[key] {
+			p.warnf("%s uses zone kind %q, which neither the bundle nor the target defines", owner, zoneKind)
+		}
+	}
+	for _, template := range bundle.MonsterTemplates {
+		check(template.ZoneKind, "monster template "+template.Name)
+	}
+	for _, template := range bundle.ScenarioTemplates {
+		check(template.ZoneKind, "scenario template "+template.Prompt)
+	}
+	for _, template := range bundle.ExpositionTemplates {
+		check(template.ZoneKind, "exposition template "+template.Title)
+	}
+	for _, template := range bundle.ShrineTemplates {
+		check(template.ZoneKind, "shrine template "+template.Name)
+	}
+	for _, archetype := range bundle.QuestArchetypes {
+		check(archetype.ZoneKind, "quest archetype "+archetype.Name)
+	}
+}
+
+func contentBundleKey(raw string) string {
+	return strings.ToLower(strings.TrimSpace(raw))
+}
+
+var contentBundleIgnoredFields = []string{"id", "createdAt", "updatedAt", "deletedAt"}
+
+// contentBundleJSONMap flattens a record to its JSON fields so records can be
+// compared the way they'd be exported.
+func contentBundleJSONMap(record interface{}, ignored ...string) map[string]interface{} {
+	fields := map[string]interface{}{}
+	raw, err := json.Marshal(record)
+	if err != nil {
+		return fields
+	}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return fields
+	}
+	for _, field := range contentBundleIgnoredFields {
+		delete(fields, field)
+	}
+	for _, field := range ignored {
+		delete(fields, field)
+	}
+	for field, value := range fields {
+		if value = normalizeContentBundleValue(value); value == nil {
+			delete(fields, field)
+		} else {
+			fields[field] = value
+		}
+	}
+	return fields
+}
+
+// normalizeContentBundleValue treats null, empty lists and empty objects as
+// the same value, since remapping rebuilds nil slices as empty ones.
+func normalizeContentBundleValue(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case []interface{}:
+		if len(typed) == 0 {
+			return nil
+		}
+		for idx := range typed {
+			typed[idx] = normalizeContentBundleValue(typed[idx])
+		}
+	case map[string]interface{}:
+		for key, nested := range typed {
+			if nested = normalizeContentBundleValue(nested); nested == nil {
+				delete(typed, key)
+			} else {
+				typed[key] = nested
+			}
+		}
+		if len(typed) == 0 {
+			return nil
+		}
+	}
+	return value
+}
+
+// diffContentBundleRecords returns the sorted JSON field names that differ
+// between two records, ignoring IDs and timestamps.
+func diffContentBundleRecords(a interface{}, b interface{}, ignored ...string) []string {
+	left := contentBundleJSONMap(a, ignored...)
+	right := contentBundleJSONMap(b, ignored...)
+	changed := []string{}
+	for field, value := range left {
+		if !reflect.DeepEqual(value, right[field]) {
+			changed = append(changed, field)
+		}
+	}
+	for field := range right {
+		if _, ok := left[field]; !ok {
+			changed = append(changed, field)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// contentBundleGraphShape describes a graph without its IDs: nodes in
+// breadth-first order from the root, with each challenge pointing at the
+// position of the nodes it unlocks. Two graphs with the same shape are the
+// same content.
+func contentBundleGraphShape(rootID uuid.UUID, nodes []QuestArchetypeNode) []interface{} {
+	byID := map[uuid.UUID]*QuestArchetypeNode{}
+	for idx := range nodes {
+		byID[nodes[idx].ID] = &nodes[idx]
+	}
+	challengeKey := func(challenge QuestArchetypeChallenge) string {
+		raw, _ := json.Marshal(contentBundleJSONMap(challenge,
+			"unlockedNodeId", "unlockedNode", "failureUnlockedNodeId", "failureUnlockedNode"))
+		return string(raw)
+	}
+	sortedChallenges := func(node *QuestArchetypeNode) []QuestArchetypeChallenge {
+		challenges := append([]QuestArchetypeChallenge{}, node.Challenges...)
+		sort.SliceStable(challenges, func(i, j int) bool {
+			return challengeKey(challenges[i]) < challengeKey(challenges[j])
+		})
+		return challenges
+	}
+
+	order := map[uuid.UUID]int{}
+	queue := []uuid.UUID{rootID}
+	visited := []*QuestArchetypeNode{}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		node := byID[id]
+		if _, ok := order[id]; ok || node == nil {
+			continue
+		}
+		order[id] = len(visited)
+		visited = append(visited, node)
+		for _, challenge := range sortedChallenges(node) {
+			if challenge.UnlockedNodeID != nil {
+				queue = append(queue, *challenge.UnlockedNodeID)
+			}
+			if challenge.FailureUnlockedNodeID != nil {
+				queue = append(queue, *challenge.FailureUnlockedNodeID)
+			}
+		}
+	}
+	position := func(id *uuid.UUID) int {
+		if id == nil {
+			return -1
+		}
+		if idx, ok := order[*id]; ok {
+			return idx
+		}
+		return -1
+	}
+
+	shape := make([]interface{}, 0, len(visited))
+	for _, node := range visited {
+		fields := contentBundleJSONMap(node, "challenges")
+		challenges := []interface{}{}
+		for _, challenge := range sortedChallenges(node) {
+			challenges = append(challenges, map[string]interface{}{
+				"challenge":      challengeKey(challenge),
+				"unlocks":        position(challenge.UnlockedNodeID),
+				"failureUnlocks": position(challenge.FailureUnlockedNodeID),
+			})
+		}
+		fields["challenges"] = challenges
+		shape = append(shape, fields)
+	}
+	return shape
+}
@@ -0,0 +1,230 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func contentBundleTestSource() ContentBundleSource {
+	genre := ZoneGenre{ID: uuid.New(), Name: "Fantasy"}
+	spell := Spell{ID: uuid.New(), Name: "Ember", GenreID: genre.ID}
+	monster := MonsterTemplate{
+		ID:       uuid.New(),
+		Name:     "Wisp",
+		GenreID:  genre.ID,
+		ZoneKind: "forest",
+		Spells:   []MonsterTemplateSpell{{ID: uuid.New(), SpellID: spell.ID}},
+	}
+	locationArchetype := &LocationArchetype{ID: uuid.New(), Name: "Grove"}
+	challenge := ChallengeTemplate{ID: uuid.New(), Question: "Find the oldest tree", LocationArchetypeID: locationArchetype.ID}
+
+	second := &QuestArchetypeNode{
+		ID:                 uuid.New(),
+		NodeType:           QuestArchetypeNodeTypeMonsterEncounter,
+		MonsterTemplateIDs: StringArray{monster.ID.String()},
+		FetchRequirements:  FetchQuestRequirements{{InventoryItemID: 7, Quantity: 1}},
+	}
+	root := &QuestArchetypeNode{
+		ID:                  uuid.New(),
+		LocationArchetypeID: &locationArchetype.ID,
+		ChallengeTemplateID: &challenge.ID,
+	}
+	root.Challenges = []QuestArchetypeChallenge{{ID: uuid.New(), UnlockedNodeID: &second.ID}}
+	giver := uuid.New()
+	archetype := &QuestArchetype{
+		ID:                    uuid.New(),
+		Name:                  "Whispering Woods",
+		ZoneKind:              "forest",
+		RootID:                root.ID,
+		QuestGiverCharacterID: &giver,
+		ItemRewards:           []QuestArchetypeItemReward{{ID: uuid.New(), InventoryItemID: 7, Quantity: 2}},
+	}
+
+	return ContentBundleSource{
+		ZoneGenres:          []ZoneGenre{genre},
+		ZoneKinds:           []ZoneKind{{ID: uuid.New(), Slug: "forest", Name: "Forest"}},
+		LocationArchetypes:  []*LocationArchetype{locationArchetype},
+		InventoryItems:      []InventoryItem{{ID: 7, Name: "Acorn"}},
+		Spells:              []Spell{spell},
+		MonsterTemplates:    []MonsterTemplate{monster},
+		ChallengeTemplates:  []ChallengeTemplate{challenge},
+		QuestArchetypes:     []*QuestArchetype{archetype},
+		QuestArchetypeNodes: []*QuestArchetypeNode{root, second},
+	}
+}
+
+// roundTrip encodes and decodes the bundle the way it travels between
+// environments.
+func roundTrip(t *testing.T, bundle *ContentBundle) *ContentBundle {
+	t.Helper()
+	raw, err := json.Marshal(bundle)
+	if err != nil {
+		t.Fatalf("marshal bundle: %v", err)
+	}
+	decoded := &ContentBundle{}
+	if err := json.Unmarshal(raw, decoded); err != nil {
+		t.Fatalf("unmarshal bundle: %v", err)
+	}
+	return decoded
+}
+
+func actionsByKind(plan *ContentBundleImportPlan) map[ContentBundleKind]ContentBundleImportAction {
+	actions := map[ContentBundleKind]ContentBundleImportAction{}
+	for _, change := range plan.Changes {
+		actions[change.Kind] = change.Action
+	}
+	return actions
+}
+
+func TestBuildContentBundleSelectsDependencies(t *testing.T) {
+	source := contentBundleTestSource()
+	source.ShrineTemplates = []ShrineTemplate{{ID: uuid.New(), Name: "Desert shrine", ZoneKind: "desert"}}
+
+	bundle := BuildContentBundle(source, ContentBundleSelection{QuestArchetypeIDs: []uuid.UUID{source.QuestArchetypes[0].ID}}, time.Now())
+	counts := bundle.Counts()
+	if counts[ContentBundleKindQuestArchetypeNode] != 2 || counts[ContentBundleKindMonsterTemplate] != 1 ||
+		counts[ContentBundleKindSpell] != 1 || counts[ContentBundleKindZoneGenre] != 1 ||
+		counts[ContentBundleKindInventoryItem] != 1 || counts[ContentBundleKindZoneKind] != 1 {
+		t.Fatalf("expected the archetype's dependencies to be exported, got %+v", counts)
+	}
+	if counts[ContentBundleKindShrineTemplate] != 0 {
+		t.Fatalf("expected unrelated shrine templates to be left out")
+	}
+	if bundle.QuestArchetypes[0].QuestGiverCharacterID != nil || len(bundle.Manifest.Warnings) != 1 {
+		t.Fatalf("expected the quest giver to be dropped with a warning, got %+v", bundle.Manifest.Warnings)
+	}
+}
+
+func TestPlanContentBundleImportRemapsIntoEmptyEnvironment(t *testing.T) {
+	source := contentBundleTestSource()
+	bundle := roundTrip(t, BuildContentBundle(source, ContentBundleSelection{}, time.Now()))
+	target := ContentBundleSource{InventoryItems: []InventoryItem{{ID: 42, Name: "acorn"}}}
+
+	plan, err := PlanContentBundleImport(bundle, target, ContentBundleConflictPolicyFail)
+	if err != nil {
+		t.Fatalf("plan: %v", err)
+	}
+	if plan.HasConflicts() {
+		t.Fatalf("expected no conflicts, got %+v", plan.Changes)
+	}
+
+	var archetype *QuestArchetype
+	nodes := map[uuid.UUID]*QuestArchetypeNode{}
+	var monster *MonsterTemplate
+	for _, write := range plan.Writes {
+		switch record := write.Record.(type) {
+		case *QuestArchetype:
+			archetype = record
+		case *QuestArchetypeNode:
+			nodes[record.ID] = record
+		case *MonsterTemplate:
+			monster = record
+		}
+	}
+	if archetype == nil || monster == nil || len(nodes) != 2 {
+		t.Fatalf("expected archetype, monster and both nodes to be written, got %d writes", len(plan.Writes))
+	}
+	if archetype.ID == source.QuestArchetypes[0].ID || monster.ID == source.MonsterTemplates[0].ID {
+		t.Fatalf("expected new IDs in the target")
+	}
+	root := nodes[archetype.RootID]
+	if root == nil {
+		t.Fatalf("expected root to point at an imported node")
+	}
+	if archetype.ItemRewards[0].InventoryItemID != 42 {
+		t.Fatalf("expected item rewards to be matched by name, got %d", archetype.ItemRewards[0].InventoryItemID)
+	}
+	for _, node := range nodes {
+		if len(node.MonsterTemplateIDs) == 1 && node.MonsterTemplateIDs[0] != monster.ID.String() {
+			t.Fatalf("expected monster template IDs to be remapped, got %v", node.MonsterTemplateIDs)
+		}
+		if len(node.FetchRequirements) == 1 && node.FetchRequirements[0].InventoryItemID != 42 {
+			t.Fatalf("expected fetch requirements to be remapped, got %+v", node.FetchRequirements)
+		}
+	}
+	if len(monster.Spells) != 1 || monster.Spells[0].MonsterTemplateID != monster.ID {
+		t.Fatalf("expected monster spells to follow the new template ID, got %+v", monster.Spells)
+	}
+}
+
+func TestPlanContentBundleImportIsIdempotent(t *testing.T) {
+	source := contentBundleTestSource()
+	bundle := roundTrip(t, BuildContentBundle(source, ContentBundleSelection{}, time.Now()))
+
+	plan, err := PlanContentBundleImport(bundle, source, ContentBundleConflictPolicyFail)
+	if err != nil {
+		t.Fatalf("plan: %v", err)
+	}
+	for _, change := range plan.Changes {
+		if change.Action != ContentBundleImportActionUnchanged {
+			t.Fatalf("expected re-importing into the source to change nothing, got %+v", change)
+		}
+	}
+	if len(plan.Writes) != 0 {
+		t.Fatalf("expected no writes, got %d", len(plan.Writes))
+	}
+}
+
+func TestPlanContentBundleImportConflictPolicies(t *testing.T) {
+	source := contentBundleTestSource()
+	bundle := roundTrip(t, BuildContentBundle(source, ContentBundleSelection{}, time.Now()))
+	bundle.Spells[0].ManaCost = 9
+	bundle.QuestArchetypes[0].Nodes[1].TargetLevel = 5
+
+	plan, err := PlanContentBundleImport(bundle, source, ContentBundleConflictPolicyFail)
+	if err != nil {
+		t.Fatalf("plan: %v", err)
+	}
+	actions := actionsByKind(plan)
+	if !plan.HasConflicts() || actions[ContentBundleKindSpell] != ContentBundleImportActionConflict ||
+		actions[ContentBundleKindQuestArchetype] != ContentBundleImportActionConflict {
+		t.Fatalf("expected spell and archetype conflicts, got %+v", plan.Changes)
+	}
+
+	plan, _ = PlanContentBundleImport(bundle, source, ContentBundleConflictPolicySkip)
+	if plan.HasConflicts() || len(plan.Writes) != 0 {
+		t.Fatalf("expected skip to keep existing records, got %+v", plan.Changes)
+	}
+
+	plan, _ = PlanContentBundleImport(bundle, source, ContentBundleConflictPolicyOverwrite)
+	deletes := 0
+	for _, write := range plan.Writes {
+		if write.Op == ContentBundleWriteOpDelete {
+			deletes++
+		}
+		if spell, ok := write.Record.(*Spell); ok && spell.ID != source.Spells[0].ID {
+			t.Fatalf("expected the spell to be updated in place")
+		}
+	}
+	if deletes != 2 {
+		t.Fatalf("expected the old graph's two nodes to be retired, got %d", deletes)
+	}
+	for _, change := range plan.Changes {
+		if change.Kind == ContentBundleKindSpell && (len(change.Fields) != 1 || change.Fields[0] != "manaCost") {
+			t.Fatalf("expected the diff to name manaCost, got %+v", change.Fields)
+		}
+	}
+}
+
+func TestPlanContentBundleImportReportsMissingItems(t *testing.T) {
+	source := contentBundleTestSource()
+	bundle := roundTrip(t, BuildContentBundle(source, ContentBundleSelection{}, time.Now()))
+
+	plan, err := PlanContentBundleImport(bundle, ContentBundleSource{}, ContentBundleConflictPolicySkip)
+	if err != nil {
+		t.Fatalf("plan: %v", err)
+	}
+	if !plan.HasConflicts() || actionsByKind(plan)[ContentBundleKindInventoryItem] != ContentBundleImportActionMissing {
+		t.Fatalf("expected the acorn to be reported missing, got %+v", plan.Changes)
+	}
+}
+
+func TestPlanContentBundleImportRejectsUnknownVersion(t *testing.T) {
+	bundle := &ContentBundle{Manifest: ContentBundleManifest{FormatVersion: ContentBundleFormatVersion + 1}}
+	if _, err := PlanContentBundleImport(bundle, ContentBundleSource{}, ContentBundleConflictPolicyFail); err == nil {
+		t.Fatalf("expected newer bundle formats to be rejected")
+	}
+}
@@ -0,0 +1,283 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+type ContentModerationStatus string
+
+const (
+	ContentModerationStatusPending      ContentModerationStatus = "pending"
+	ContentModerationStatusApproved     ContentModerationStatus = "approved"
+	ContentModerationStatusRejected     ContentModerationStatus = "rejected"
+	ContentModerationStatusRegenerating ContentModerationStatus = "regenerating"
+)
+
+// Generated content that has to pass review before players can see it.
+const (
+	ModeratedContentTypeScenario                     = "scenario"
+	ModeratedContentTypeInventoryItemSuggestionDraft = "inventory_item_suggestion_draft"
+	ModeratedContentTypeZoneSeedDraft                = "zone_seed_draft"
+)
+
+type ContentModerationSeverity string
+
+const (
+	// ContentModerationSeverityBlock marks a finding a reviewer should not
+	// approve past without editing or regenerating the content.
+	ContentModerationSeverityBlock ContentModerationSeverity = "block"
+	ContentModerationSeverityWarn  ContentModerationSeverity = "warn"
+)
+
+const (
+	ContentModerationRuleBlockedTerm     = "blocked_term"
+	ContentModerationRuleBrandTerm       = "brand_term"
+	ContentModerationRuleBusinessName    = "business_name"
+	ContentModerationRuleTooLong         = "too_long"
+	ContentModerationRuleEmpty           = "empty"
+	ContentModerationRuleShouting        = "shouting"
+	ContentModerationRuleLink            = "link"
+	ContentModerationRuleDuplicateImage  = "duplicate_image"
+	ContentModerationRuleImageClassifier = "image_classifier"
+)
+
+type ContentModerationFinding struct {
+	Rule     string                    `json:"rule"`
+	Severity ContentModerationSeverity `json:"severity"`
+	Field    string                    `json:"field,omitempty"`
+	Detail   string                    `json:"detail"`
+}
+
+type ContentModerationFindings []ContentModerationFinding
+
+func (f ContentModerationFindings) Value() (driver.Value, error) {
+	if f == nil {
+		return json.Marshal([]ContentModerationFinding{})
+	}
+	return json.Marshal(f)
+}
+
+func (f *ContentModerationFindings) Scan(value interface{}) error {
+	if value == nil {
+		*f = ContentModerationFindings{}
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("failed to scan ContentModerationFindings: value is not []byte")
+	}
+	return json.Unmarshal(bytes, f)
+}
+
+func (f ContentModerationFindings) HasBlocking() bool {
+	for _, finding := range f {
+		if finding.Severity == ContentModerationSeverityBlock {
+			return true
+		}
+	}
+	return false
+}
+
+// ContentModerationItem is the review queue entry for one piece of generated
+// content. Content with an item that is not approved stays hidden from
+// players; content generated before the queue existed has no item.
+type ContentModerationItem struct {
+	ID               uuid.UUID                 `json:"id" gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	CreatedAt        time.Time                 `json:"createdAt"`
+	UpdatedAt        time.Time                 `json:"updatedAt"`
+	ContentType      string                    `json:"contentType" gorm:"column:content_type"`
+	ContentID        uuid.UUID                 `json:"contentId" gorm:"column:content_id;type:uuid"`
+	Status           ContentModerationStatus   `json:"status"`
+	Summary          string                    `json:"summary"`
+	Findings         ContentModerationFindings `json:"findings" gorm:"type:jsonb"`
+	ImageURL         string                    `json:"imageUrl,omitempty" gorm:"column:image_url"`
+	ImageHash        *int64                    `json:"imageHash,omitempty" gorm:"column:image_hash"`
+	ReviewedByUserID *uuid.UUID                `json:"reviewedByUserId,omitempty" gorm:"column:reviewed_by_user_id;type:uuid"`
+	ReviewedAt       *time.Time                `json:"reviewedAt,omitempty" gorm:"column:reviewed_at"`
+	ReviewNote       string                    `json:"reviewNote,omitempty" gorm:"column:review_note"`
+}
+
+func (ContentModerationItem) TableName() string {
+	return "content_moderation_items"
+}
+
+func (i ContentModerationItem) Blocking() bool {
+	return i.Findings.HasBlocking()
+}
+
+type ModerationTermKind string
+
+const (
+	ModerationTermKindBlocked  ModerationTermKind = "blocked"
+	ModerationTermKindBrand    ModerationTermKind = "brand"
+	ModerationTermKindBusiness ModerationTermKind = "business"
+)
+
+func ParseModerationTermKind(raw string) (ModerationTermKind, bool) {
+	switch ModerationTermKind(strings.ToLower(strings.TrimSpace(raw))) {
+	case ModerationTermKindBlocked:
+		return ModerationTermKindBlocked, true
+	case ModerationTermKindBrand:
+		return ModerationTermKindBrand, true
+	case ModerationTermKindBusiness:
+		return ModerationTermKindBusiness, true
+	default:
+		return "", false
+	}
+}
+
+type ModerationTerm struct {
+	ID              uuid.UUID          `json:"id" gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	CreatedAt       time.Time          `json:"createdAt"`
+	Term            string             `json:"term"`
+	Kind            ModerationTermKind `json:"kind"`
+	CreatedByUserID *uuid.UUID         `json:"createdByUserId,omitempty" gorm:"column:created_by_user_id;type:uuid"`
+}
+
+func (ModerationTerm) TableName() string {
+	return "moderation_terms"
+}
+
+// ModerationTextField is one piece of generated text with the style limits
+// that apply to it. A zero MaxLength means no limit.
+type ModerationTextField struct {
+	Name      string
+	Text      string
+	Required  bool
+	MaxLength int
+}
+
+var moderationLinkPattern = regexp.MustCompile(`(?i)\b(https?://|www\.)\S+|\b[a-z0-9-]+\.(com|net|org|io|co)\b`)
+
+// ModerateText checks generated text against the term lists and style rules.
+// businessNames are real places near the content, such as the names of
+// imported points of interest, that generated fiction must not name.
+func ModerateText(fields []ModerationTextField, terms []ModerationTerm, businessNames []string) ContentModerationFindings {
+	findings := ContentModerationFindings{}
+	for _, field := range fields {
+		text := strings.TrimSpace(field.Text)
+		if text == "" {
+			if field.Required {
+				findings = append(findings, ContentModerationFinding{
+					Rule:     ContentModerationRuleEmpty,
+					Severity: ContentModerationSeverityBlock,
+					Field:    field.Name,
+					Detail:   "required text is empty",
+				})
+			}
+			continue
+		}
+		if field.MaxLength > 0 && len([]rune(text)) > field.MaxLength {
+			findings = append(findings, ContentModerationFinding{
+				Rule:     ContentModerationRuleTooLong,
+				Severity: ContentModerationSeverityWarn,
+				Field:    field.Name,
+				Detail:   fmt.Sprintf("%d characters exceeds the %d character limit", len([]rune(text)), field.MaxLength),
+			})
+		}
+		if isShouting(text) {
+			findings = append(findings, ContentModerationFinding{
+				Rule:     ContentModerationRuleShouting,
+				Severity: ContentModerationSeverityWarn,
+				Field:    field.Name,
+				Detail:   "text is mostly capital letters",
+			})
+		}
+		if link := moderationLinkPattern.FindString(text); link != "" {
+			findings = append(findings, ContentModerationFinding{
+				Rule:     ContentModerationRuleLink,
+				Severity: ContentModerationSeverityBlock,
+				Field:    field.Name,
+				Detail:   fmt.Sprintf("contains link %q", link),
+			})
+		}
+		for _, term := range terms {
+			if !containsModerationTerm(text, term.Term) {
+				continue
+			}
+			rule := ContentModerationRuleBlockedTerm
+			switch term.Kind {
+			case ModerationTermKindBrand:
+				rule = ContentModerationRuleBrandTerm
+			case ModerationTermKindBusiness:
+				rule = ContentModerationRuleBusinessName
+			}
+			findings = append(findings, ContentModerationFinding{
+				Rule:     rule,
+				Severity: ContentModerationSeverityBlock,
+				Field:    field.Name,
+				Detail:   fmt.Sprintf("mentions %q", strings.TrimSpace(term.Term)),
+			})
+		}
+		for _, name := range businessNames {
+			if !containsModerationTerm(text, name) {
+				continue
+			}
+			findings = append(findings, ContentModerationFinding{
+				Rule:     ContentModerationRuleBusinessName,
+				Severity: ContentModerationSeverityBlock,
+				Field:    field.Name,
+				Detail:   fmt.Sprintf("names the real place %q", strings.TrimSpace(name)),
+			})
+		}
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Severity == ContentModerationSeverityBlock && findings[j].Severity != ContentModerationSeverityBlock
+	})
+	return findings
+}
+
+// containsModerationTerm matches whole words case-insensitively so "Target"
+// flags "meet me at Target" but not "targeted".
+func containsModerationTerm(text string, term string) bool {
+	term = strings.ToLower(strings.TrimSpace(term))
+	if len([]rune(term)) < 3 {
+		return false
+	}
+	lower := strings.ToLower(text)
+	for offset := 0; offset < len(lower); {
+		index := strings.Index(lower[offset:], term)
+		if index < 0 {
+			return false
+		}
+		start := offset + index
+		end := start + len(term)
+		if isModerationWordBoundary(lower, start-1) && isModerationWordBoundary(lower, end) {
+			return true
+		}
+		offset = start + 1
+	}
+	return false
+}
+
+func isModerationWordBoundary(text string, index int) bool {
+	if index < 0 || index >= len(text) {
+		return true
+	}
+	r := rune(text[index])
+	return !(unicode.IsLetter(r) || unicode.IsDigit(r))
+}
+
+func isShouting(text string) bool {
+	letters, upper := 0, 0
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		if unicode.IsUpper(r) {
+			upper++
+		}
+	}
+	return letters >= 20 && upper*10 >= letters*7
+}
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestModerateTextFlagsTermsBusinessesAndStyle(t *testing.T) {
+	terms := []ModerationTerm{
+		{Term: "Starbucks", Kind: ModerationTermKindBrand},
+		{Term: "darn", Kind: ModerationTermKindBlocked},
+	}
+	findings := ModerateText([]ModerationTextField{
+		{Name: "prompt", Text: "A wraith lingers outside Starbucks near Joe's Diner.", Required: true, MaxLength: 30},
+		{Name: "option", Text: "Visit www.example.com for answers"},
+		{Name: "title", Text: "", Required: true},
+		{Name: "flavor", Text: "The darned hinge creaks."},
+	}, terms, []string{"Joe's Diner"})
+
+	rules := map[string]int{}
+	for _, finding := range findings {
+		rules[finding.Rule]++
+	}
+	for _, rule := range []string{
+		ContentModerationRuleBrandTerm,
+		ContentModerationRuleBusinessName,
+		ContentModerationRuleTooLong,
+		ContentModerationRuleLink,
+		ContentModerationRuleEmpty,
+	} {
+		if rules[rule] != 1 {
+			t.Fatalf("expected one %s finding, got %v", rule, findings)
+		}
+	}
+	if rules[ContentModerationRuleBlockedTerm] != 0 {
+		t.Fatalf("expected whole-word matching to skip \"darned\", got %v", findings)
+	}
+	if !findings.HasBlocking() || findings[0].Severity != ContentModerationSeverityBlock {
+		t.Fatalf("expected blocking findings first, got %v", findings)
+	}
+}
+
+func TestModerateTextPassesCleanText(t *testing.T) {
+	findings := ModerateText([]ModerationTextField{
+		{Name: "prompt", Text: "Mist curls around the old fountain as a heron watches.", Required: true, MaxLength: 200},
+	}, []ModerationTerm{{Term: "Target", Kind: ModerationTermKindBrand}}, nil)
+	if len(findings) != 0 {
+		t.Fatalf("expected no findings, got %v", findings)
+	}
+}
+
+func TestDifferenceHashMatchesResizedImage(t *testing.T) {
+	small := gradientImage(90, 80, false)
+	large := gradientImage(360, 320, false)
+	flipped := gradientImage(90, 80, true)
+
+	if distance := ImageHashDistance(DifferenceHash(small), DifferenceHash(large)); distance > DuplicateImageHashDistance {
+		t.Fatalf("expected resized copies to match, distance %d", distance)
+	}
+	if distance := ImageHashDistance(DifferenceHash(small), DifferenceHash(flipped)); distance <= DuplicateImageHashDistance {
+		t.Fatalf("expected a mirrored gradient to differ, distance %d", distance)
+	}
+}
+
+func gradientImage(width int, height int, mirrored bool) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			value := x * 255 / width
+			if mirrored {
+				value = 255 - value
+			}
+			value = (value + y*64/height) % 256
+			img.Set(x, y, color.RGBA{R: uint8(value), G: uint8(value), B: uint8(value), A: 255})
+		}
+	}
+	return img
+}
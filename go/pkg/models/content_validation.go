@@ -0,0 +1,711 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+type ContentValidationSeverity string
+
+const (
+	ContentValidationSeverityError   ContentValidationSeverity = "error"
+	ContentValidationSeverityWarning ContentValidationSeverity = "warning"
+)
+
+type ContentValidationCode string
+
+const (
+	ContentValidationCodeMissingNode             ContentValidationCode = "missing_node"
+	ContentValidationCodeMissingQuestArchetype   ContentValidationCode = "missing_quest_archetype"
+	ContentValidationCodeCycle                   ContentValidationCode = "cycle"
+	ContentValidationCodeSameAsPreviousFirstNode ContentValidationCode = "same_as_previous_first_node"
+	ContentValidationCodeUnreachableNode         ContentValidationCode = "unreachable_node"
+	ContentValidationCodeOrphanedNode            ContentValidationCode = "orphaned_node"
+	ContentValidationCodeUnsetStoryFlag          ContentValidationCode = "unset_story_flag"
+	ContentValidationCodeUnobtainableFetchItem   ContentValidationCode = "unobtainable_fetch_item"
+	ContentValidationCodeMissingRewards          ContentValidationCode = "missing_rewards"
+)
+
+// ContentValidationIssue is one problem found in authored quest or story
+// content. Location is a human-readable path from the owning archetype or
+// main story template down to the offending field; the ID fields carry the
+// same path for clients that want to link to it.
+type ContentValidationIssue struct {
+	Severity            ContentValidationSeverity `json:"severity"`
+	Code                ContentValidationCode     `json:"code"`
+	Location            string                    `json:"location"`
+	Message             string                    `json:"message"`
+	QuestArchetypeID    *uuid.UUID                `json:"questArchetypeId,omitempty"`
+	NodeID              *uuid.UUID                `json:"nodeId,omitempty"`
+	ChallengeID         *uuid.UUID                `json:"challengeId,omitempty"`
+	MainStoryTemplateID *uuid.UUID                `json:"mainStoryTemplateId,omitempty"`
+	BeatIndex           *int                      `json:"beatIndex,omitempty"`
+}
+
+func (i ContentValidationIssue) String() string {
+	return fmt.Sprintf("%s [%s] %s: %s", i.Severity, i.Code, i.Location, i.Message)
+}
+
+func (i ContentValidationIssue) IsError() bool {
+	return i.Severity == ContentValidationSeverityError
+}
+
+func (i ContentValidationIssue) key() string {
+	return string(i.Code) + "|" + i.Location
+}
+
+// IntroducedContentValidationErrors returns the errors in after that weren't
+// already in before, so a save is only rejected for problems it causes and
+// not for ones the content already had.
+func IntroducedContentValidationErrors(before []ContentValidationIssue, after []ContentValidationIssue) []ContentValidationIssue {
+	existing := make(map[string]struct{}, len(before))
+	for _, issue := range before {
+		existing[issue.key()] = struct{}{}
+	}
+	introduced := []ContentValidationIssue{}
+	for _, issue := range after {
+		if !issue.IsError() {
+			continue
+		}
+		if _, ok := existing[issue.key()]; ok {
+			continue
+		}
+		introduced = append(introduced, issue)
+	}
+	return introduced
+}
+
+// ContentValidationCatalog is everything the validator cross-references:
+// every archetype and node, main story templates and their world changes,
+// and the items players can get. Flags and item sources are resolved
+// against the whole catalog, so validating one archetype still needs the
+// rest of the content loaded.
+type ContentValidationCatalog struct {
+	QuestArchetypes    []*QuestArchetype
+	Nodes              map[uuid.UUID]*QuestArchetypeNode
+	MainStoryTemplates []MainStoryTemplate
+	WorldChanges       []StoryWorldChange
+	// InventoryItems holds the active (unarchived) items by ID.
+	InventoryItems map[int]InventoryItem
+	// ExtraItemSources marks items granted by content outside quest
+	// archetypes, such as treasure chests.
+	ExtraItemSources map[int]bool
+}
+
+func NewContentValidationCatalog(
+	questArchetypes []*QuestArchetype,
+	nodes []*QuestArchetypeNode,
+	mainStoryTemplates []MainStoryTemplate,
+	worldChanges []StoryWorldChange,
+	inventoryItems []InventoryItem,
+) *ContentValidationCatalog {
+	catalog := &ContentValidationCatalog{
+		QuestArchetypes:    questArchetypes,
+		Nodes:              make(map[uuid.UUID]*QuestArchetypeNode, len(nodes)),
+		MainStoryTemplates: mainStoryTemplates,
+		WorldChanges:       worldChanges,
+		InventoryItems:     make(map[int]InventoryItem, len(inventoryItems)),
+		ExtraItemSources:   map[int]bool{},
+	}
+	for _, node := range nodes {
+		if node != nil {
+			catalog.Nodes[node.ID] = node
+		}
+	}
+	for _, item := range inventoryItems {
+		if !item.Archived {
+			catalog.InventoryItems[item.ID] = item
+		}
+	}
+	return catalog
+}
+
+// PutQuestArchetype adds or replaces an archetype, e.g. to validate an edit
+// before saving it.
+func (c *ContentValidationCatalog) PutQuestArchetype(archetype *QuestArchetype) {
+	for idx, existing := range c.QuestArchetypes {
+		if existing != nil && existing.ID == archetype.ID {
+			c.QuestArchetypes[idx] = archetype
+			return
+		}
+	}
+	c.QuestArchetypes = append(c.QuestArchetypes, archetype)
+}
+
+// PutNode adds or replaces a node. A node with no challenges loaded keeps the
+// challenges already in the catalog, since node payloads don't carry them.
+func (c *ContentValidationCatalog) PutNode(node *QuestArchetypeNode) {
+	if existing, ok := c.Nodes[node.ID]; ok && node.Challenges == nil {
+		node.Challenges = existing.Challenges
+	}
+	c.Nodes[node.ID] = node
+}
+
+// PutChallenge adds the challenge to the node, or replaces it if the node
+// already has a challenge with the same ID.
+func (c *ContentValidationCatalog) PutChallenge(nodeID uuid.UUID, challenge QuestArchetypeChallenge) {
+	node, ok := c.Nodes[nodeID]
+	if !ok {
+		return
+	}
+	for idx := range node.Challenges {
+		if node.Challenges[idx].ID == challenge.ID {
+			node.Challenges[idx] = challenge
+			return
+		}
+	}
+	node.Challenges = append(node.Challenges, challenge)
+}
+
+// NodeIDsForChallenge returns the nodes the challenge hangs off.
+func (c *ContentValidationCatalog) NodeIDsForChallenge(challengeID uuid.UUID) []uuid.UUID {
+	nodeIDs := []uuid.UUID{}
+	for nodeID, node := range c.Nodes {
+		for _, challenge := range node.Challenges {
+			if challenge.ID == challengeID {
+				nodeIDs = append(nodeIDs, nodeID)
+				break
+			}
+		}
+	}
+	return nodeIDs
+}
+
+// QuestArchetypesContainingNodes returns every archetype whose graph includes
+// any of the nodes.
+func (c *ContentValidationCatalog) QuestArchetypesContainingNodes(nodeIDs []uuid.UUID) []*QuestArchetype {
+	archetypes := []*QuestArchetype{}
+	for _, archetype := range c.QuestArchetypes {
+		if archetype == nil {
+			continue
+		}
+		reachable := c.structuralClosure(archetype.RootID)
+		for _, nodeID := range nodeIDs {
+			if _, ok := reachable[nodeID]; ok {
+				archetypes = append(archetypes, archetype)
+				break
+			}
+		}
+	}
+	return archetypes
+}
+
+// Validate checks every archetype and main story template in the catalog,
+// plus nodes that no archetype uses.
+func (c *ContentValidationCatalog) Validate() []ContentValidationIssue {
+	issues := []ContentValidationIssue{}
+	used := map[uuid.UUID]struct{}{}
+	for _, archetype := range c.QuestArchetypes {
+		if archetype == nil {
+			continue
+		}
+		issues = append(issues, c.ValidateQuestArchetype(archetype)...)
+		for nodeID := range c.structuralClosure(archetype.RootID) {
+			used[nodeID] = struct{}{}
+		}
+	}
+	for idx := range c.MainStoryTemplates {
+		issues = append(issues, c.ValidateMainStoryTemplate(&c.MainStoryTemplates[idx])...)
+	}
+
+	orphanIDs := []uuid.UUID{}
+	for nodeID := range c.Nodes {
+		if _, ok := used[nodeID]; !ok {
+			orphanIDs = append(orphanIDs, nodeID)
+		}
+	}
+	sort.Slice(orphanIDs, func(i, j int) bool {
+		return orphanIDs[i].String() < orphanIDs[j].String()
+	})
+	for _, nodeID := range orphanIDs {
+		id := nodeID
+		issues = append(issues, ContentValidationIssue{
+			Severity: ContentValidationSeverityWarning,
+			Code:     ContentValidationCodeOrphanedNode,
+			Location: fmt.Sprintf("node %s", nodeID),
+			Message:  "no quest archetype reaches this node",
+			NodeID:   &id,
+		})
+	}
+	return issues
+}
+
+// ValidateQuestArchetype walks the archetype's node graph from its root and
+// reports structural problems (dangling links, cycles, dead branches, a
+// first node that reuses a previous location) along with content problems
+// (unset story flags, unobtainable fetch items, missing rewards).
+func (c *ContentValidationCatalog) ValidateQuestArchetype(archetype *QuestArchetype) []ContentValidationIssue {
+	if archetype == nil {
+		return nil
+	}
+	v := questArchetypeValidator{
+		catalog:   c,
+		archetype: archetype,
+		base:      fmt.Sprintf("questArchetype %s (%q)", archetype.ID, archetype.Name),
+		issues:    []ContentValidationIssue{},
+	}
+	v.validate()
+	return v.issues
+}
+
+type questArchetypeValidator struct {
+	catalog   *ContentValidationCatalog
+	archetype *QuestArchetype
+	base      string
+	issues    []ContentValidationIssue
+	// flagSources and itemSources are resolved once per archetype.
+	flagSources storyFlagSources
+	itemSources map[int]bool
+	// paths holds the shortest location path to each node reached from
+	// the root, in the order the nodes were reached.
+	paths map[uuid.UUID]string
+	order []uuid.UUID
+}
+
+func (v *questArchetypeValidator) add(
+	severity ContentValidationSeverity,
+	code ContentValidationCode,
+	location string,
+	nodeID *uuid.UUID,
+	challengeID *uuid.UUID,
+	message string,
+	args ...interface{},
+) {
+	archetypeID := v.archetype.ID
+	v.issues = append(v.issues, ContentValidationIssue{
+		Severity:         severity,
+		Code:             code,
+		Location:         location,
+		Message:          fmt.Sprintf(message, args...),
+		QuestArchetypeID: &archetypeID,
+		NodeID:           nodeID,
+		ChallengeID:      challengeID,
+	})
+}
+
+func (v *questArchetypeValidator) validate() {
+	v.flagSources = v.catalog.storyFlagSources()
+	v.itemSources = v.catalog.fixedItemSources()
+	root, ok := v.catalog.Nodes[v.archetype.RootID]
+	if !ok {
+		v.add(ContentValidationSeverityError, ContentValidationCodeMissingNode, v.base+" > root", nil, nil,
+			"root node %s doesn't exist", v.archetype.RootID)
+	} else {
+		v.walk(root)
+		v.checkCycles(root)
+		v.checkUnreachable(root)
+		if NormalizeQuestArchetypeNodeLocationSelectionMode(string(root.LocationSelectionMode)) ==
+			QuestArchetypeNodeLocationSelectionModeSameAsPrevious {
+			rootID := root.ID
+			v.add(ContentValidationSeverityError, ContentValidationCodeSameAsPreviousFirstNode,
+				v.paths[root.ID]+" > locationSelectionMode", &rootID, nil,
+				"the first node has no previous node to share a location with")
+		}
+		for _, nodeID := range v.order {
+			v.checkNode(v.catalog.Nodes[nodeID])
+		}
+	}
+
+	for _, flag := range v.archetype.RequiredStoryFlags {
+		key := NormalizeStoryFlagKey(flag)
+		if key == "" || v.flagSources.setOutside(key, &v.archetype.ID, nil) {
+			continue
+		}
+		v.add(ContentValidationSeverityWarning, ContentValidationCodeUnsetStoryFlag,
+			fmt.Sprintf("%s > requiredStoryFlags[%q]", v.base, key), nil, nil,
+			"no other quest archetype or main story beat sets this flag")
+	}
+
+	if NormalizeRewardMode(string(v.archetype.RewardMode)) == RewardModeExplicit &&
+		v.archetype.RewardExperience <= 0 &&
+		v.archetype.DefaultGold <= 0 &&
+		len(v.archetype.MaterialRewards) == 0 &&
+		len(v.archetype.ItemRewards) == 0 &&
+		len(v.archetype.SpellRewards) == 0 {
+		v.add(ContentValidationSeverityWarning, ContentValidationCodeMissingRewards, v.base+" > rewards", nil, nil,
+			"reward mode is explicit but the archetype grants no experience, gold, materials, items or spells")
+	}
+}
+
+// walk records a breadth-first path to every node linked from the root and
+// reports links to nodes that don't exist.
+func (v *questArchetypeValidator) walk(root *QuestArchetypeNode) {
+	v.paths = map[uuid.UUID]string{root.ID: fmt.Sprintf("%s > node %s", v.base, root.ID)}
+	v.order = []uuid.UUID{root.ID}
+	for idx := 0; idx < len(v.order); idx++ {
+		node := v.catalog.Nodes[v.order[idx]]
+		for _, edge := range questArchetypeNodeEdges(node) {
+			challengeID := edge.challengeID
+			location := fmt.Sprintf("%s > challenge %s", v.paths[node.ID], challengeID)
+			if _, ok := v.catalog.Nodes[edge.target]; !ok {
+				nodeID := node.ID
+				v.add(ContentValidationSeverityError, ContentValidationCodeMissingNode,
+					location+" > "+edge.field, &nodeID, &challengeID,
+					"%s %s doesn't exist", edge.field, edge.target)
+				continue
+			}
+			if _, seen := v.paths[edge.target]; seen {
+				continue
+			}
+			v.paths[edge.target] = fmt.Sprintf("%s > node %s", location, edge.target)
+			v.order = append(v.order, edge.target)
+		}
+	}
+}
+
+// checkCycles reports every link that points back at a node still on the
+// depth-first stack.
+func (v *questArchetypeValidator) checkCycles(root *QuestArchetypeNode) {
+	const (
+		unvisited = iota
+		onStack
+		done
+	)
+	state := map[uuid.UUID]int{}
+	var visit func(node *QuestArchetypeNode)
+	visit = func(node *QuestArchetypeNode) {
+		state[node.ID] = onStack
+		for _, edge := range questArchetypeNodeEdges(node) {
+			target, ok := v.catalog.Nodes[edge.target]
+			if !ok {
+				continue
+			}
+			switch state[edge.target] {
+			case onStack:
+				nodeID := node.ID
+				challengeID := edge.challengeID
+				v.add(ContentValidationSeverityError, ContentValidationCodeCycle,
+					fmt.Sprintf("%s > challenge %s > %s", v.paths[node.ID], challengeID, edge.field),
+					&nodeID, &challengeID,
+					"links back to node %s, so the quest can loop forever", edge.target)
+			case unvisited:
+				visit(target)
+			}
+		}
+		state[node.ID] = done
+	}
+	visit(root)
+}
+
+// checkUnreachable reports nodes that are only linked through failure
+// branches of nodes that retry on failure. Those branches never fire, so the
+// nodes behind them can't be reached in play.
+func (v *questArchetypeValidator) checkUnreachable(root *QuestArchetypeNode) {
+	live := map[uuid.UUID]struct{}{root.ID: {}}
+	queue := []uuid.UUID{root.ID}
+	for len(queue) > 0 {
+		node := v.catalog.Nodes[queue[0]]
+		queue = queue[1:]
+		transitions := node.FailurePolicyNormalized() == QuestNodeFailurePolicyTransition
+		for _, edge := range questArchetypeNodeEdges(node) {
+			if edge.failure && !transitions {
+				continue
+			}
+			if _, ok := v.catalog.Nodes[edge.target]; !ok {
+				continue
+			}
+			if _, seen := live[edge.target]; seen {
+				continue
+			}
+			live[edge.target] = struct{}{}
+			queue = append(queue, edge.target)
+		}
+	}
+	for _, nodeID := range v.order {
+		if _, ok := live[nodeID]; ok {
+			continue
+		}
+		id := nodeID
+		v.add(ContentValidationSeverityWarning, ContentValidationCodeUnreachableNode, v.paths[nodeID], &id, nil,
+			"only linked from failure branches of nodes whose failure policy is %q, so it never unlocks",
+			QuestNodeFailurePolicyRetry)
+	}
+}
+
+func (v *questArchetypeValidator) checkNode(node *QuestArchetypeNode) {
+	nodeID := node.ID
+	location := v.paths[node.ID]
+	switch NormalizeQuestArchetypeNodeType(string(node.NodeType)) {
+	case QuestArchetypeNodeTypeStoryFlag:
+		key := NormalizeStoryFlagKey(node.StoryFlagKey)
+		if key != "" && !v.flagSources.setOutside(key, &v.archetype.ID, nil) {
+			v.add(ContentValidationSeverityWarning, ContentValidationCodeUnsetStoryFlag,
+				fmt.Sprintf("%s > storyFlagKey[%q]", location, key), &nodeID, nil,
+				"the node waits on a flag that no other quest archetype or main story beat sets")
+		}
+	case QuestArchetypeNodeTypeFetchQuest:
+		for _, requirement := range node.FetchRequirements {
+			if reason := v.catalog.unobtainableItemReason(requirement.InventoryItemID, v.itemSources); reason != "" {
+				v.add(ContentValidationSeverityWarning, ContentValidationCodeUnobtainableFetchItem,
+					fmt.Sprintf("%s > fetchRequirements[%d]", location, requirement.InventoryItemID), &nodeID, nil,
+					"%s", reason)
+			}
+		}
+	case QuestArchetypeNodeTypeMonsterEncounter:
+		if NormalizeRewardMode(string(node.EncounterRewardMode)) == RewardModeExplicit &&
+			node.EncounterRewardExperience <= 0 &&
+			node.EncounterRewardGold <= 0 &&
+			len(node.EncounterMaterialRewards) == 0 &&
+			len(node.EncounterItemRewards) == 0 {
+			v.add(ContentValidationSeverityWarning, ContentValidationCodeMissingRewards,
+				location+" > encounterRewards", &nodeID, nil,
+				"encounter reward mode is explicit but it grants no experience, gold, materials or items")
+		}
+	}
+}
+
+type questArchetypeNodeEdge struct {
+	challengeID uuid.UUID
+	field       string
+	target      uuid.UUID
+	failure     bool
+}
+
+func questArchetypeNodeEdges(node *QuestArchetypeNode) []questArchetypeNodeEdge {
+	edges := []questArchetypeNodeEdge{}
+	if node == nil {
+		return edges
+	}
+	for _, challenge := range node.Challenges {
+		if challenge.UnlockedNodeID != nil && *challenge.UnlockedNodeID != uuid.Nil {
+			edges = append(edges, questArchetypeNodeEdge{
+				challengeID: challenge.ID,
+				field:       "unlockedNode",
+				target:      *challenge.UnlockedNodeID,
+			})
+		}
+		if challenge.FailureUnlockedNodeID != nil && *challenge.FailureUnlockedNodeID != uuid.Nil {
+			edges = append(edges, questArchetypeNodeEdge{
+				challengeID: challenge.ID,
+				field:       "failureUnlockedNode",
+				target:      *challenge.FailureUnlockedNodeID,
+				failure:     true,
+			})
+		}
+	}
+	return edges
+}
+
+// structuralClosure returns every existing node linked from rootID through
+// success or failure branches.
+func (c *ContentValidationCatalog) structuralClosure(rootID uuid.UUID) map[uuid.UUID]struct{} {
+	closure := map[uuid.UUID]struct{}{}
+	if _, ok := c.Nodes[rootID]; !ok {
+		return closure
+	}
+	closure[rootID] = struct{}{}
+	queue := []uuid.UUID{rootID}
+	for len(queue) > 0 {
+		node := c.Nodes[queue[0]]
+		queue = queue[1:]
+		for _, edge := range questArchetypeNodeEdges(node) {
+			if _, ok := c.Nodes[edge.target]; !ok {
+				continue
+			}
+			if _, seen := closure[edge.target]; seen {
+				continue
+			}
+			closure[edge.target] = struct{}{}
+			queue = append(queue, edge.target)
+		}
+	}
+	return closure
+}
+
+// storyFlagSetter is one place a story flag gets set: an archetype on
+// completion, or a main story beat.
+type storyFlagSetter struct {
+	questArchetypeID    *uuid.UUID
+	mainStoryTemplateID *uuid.UUID
+	beatOrder           int
+}
+
+type storyFlagSources map[string][]storyFlagSetter
+
+func (c *ContentValidationCatalog) storyFlagSources() storyFlagSources {
+	sources := storyFlagSources{}
+	for _, archetype := range c.QuestArchetypes {
+		if archetype == nil {
+			continue
+		}
+		archetypeID := archetype.ID
+		for _, flag := range archetype.SetStoryFlags {
+			if key := NormalizeStoryFlagKey(flag); key != "" {
+				sources[key] = append(sources[key], storyFlagSetter{questArchetypeID: &archetypeID})
+			}
+		}
+	}
+	for _, template := range c.MainStoryTemplates {
+		templateID := template.ID
+		for _, beat := range template.Beats {
+			for _, flag := range beat.SetStoryFlags {
+				if key := NormalizeStoryFlagKey(flag); key != "" {
+					sources[key] = append(sources[key], storyFlagSetter{
+						mainStoryTemplateID: &templateID,
+						beatOrder:           beat.OrderIndex,
+					})
+				}
+			}
+		}
+	}
+	return sources
+}
+
+// setOutside reports whether anything other than the given archetype sets
+// the flag. A flag an archetype sets itself only takes effect once it's
+// complete, which is too late for its own requirements.
+func (s storyFlagSources) setOutside(key string, questArchetypeID *uuid.UUID, mainStoryTemplateID *uuid.UUID) bool {
+	for _, setter := range s[key] {
+		if questArchetypeID != nil && setter.questArchetypeID != nil && *setter.questArchetypeID == *questArchetypeID {
+			continue
+		}
+		if mainStoryTemplateID != nil && setter.mainStoryTemplateID != nil && *setter.mainStoryTemplateID == *mainStoryTemplateID {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+func (c *ContentValidationCatalog) fixedItemSources() map[int]bool {
+	sources := map[int]bool{}
+	for itemID, ok := range c.ExtraItemSources {
+		if ok {
+			sources[itemID] = true
+		}
+	}
+	for _, archetype := range c.QuestArchetypes {
+		if archetype == nil {
+			continue
+		}
+		for _, reward := range archetype.ItemRewards {
+			sources[reward.InventoryItemID] = true
+		}
+	}
+	for _, node := range c.Nodes {
+		for _, challenge := range node.Challenges {
+			if challenge.InventoryItemID != nil {
+				sources[*challenge.InventoryItemID] = true
+			}
+		}
+		for _, reward := range node.EncounterItemRewards {
+			sources[reward.InventoryItemID] = true
+		}
+		for _, reward := range node.ExpositionItemRewards {
+			sources[reward.InventoryItemID] = true
+		}
+	}
+	return sources
+}
+
+// unobtainableItemReason explains why players can't get the item, or returns
+// "" if they can: it's sold, can drop from random rewards, or some piece of
+// content grants it directly.
+func (c *ContentValidationCatalog) unobtainableItemReason(itemID int, itemSources map[int]bool) string {
+	item, ok := c.InventoryItems[itemID]
+	if !ok {
+		return fmt.Sprintf("item %d doesn't exist or is archived", itemID)
+	}
+	if item.BuyPrice != nil || itemSources[itemID] {
+		return ""
+	}
+	if !item.IsCaptureType && !strings.EqualFold(strings.TrimSpace(item.RarityTier), "Not Droppable") {
+		return ""
+	}
+	return fmt.Sprintf("item %d (%q) isn't sold, can't drop and no reward grants it", itemID, item.Name)
+}
+
+// ValidateMainStoryTemplate checks that each beat's linked archetype exists
+// and that the flags beats and world changes depend on get set somewhere,
+// and within the story, by an earlier beat.
+func (c *ContentValidationCatalog) ValidateMainStoryTemplate(template *MainStoryTemplate) []ContentValidationIssue {
+	if template == nil {
+		return nil
+	}
+	issues := []ContentValidationIssue{}
+	templateID := template.ID
+	base := fmt.Sprintf("mainStoryTemplate %s (%q)", template.ID, template.Name)
+
+	archetypeIDs := make(map[uuid.UUID]struct{}, len(c.QuestArchetypes))
+	for _, archetype := range c.QuestArchetypes {
+		if archetype != nil {
+			archetypeIDs[archetype.ID] = struct{}{}
+		}
+	}
+	flagSources := c.storyFlagSources()
+
+	for idx, beat := range template.Beats {
+		beatIndex := idx
+		location := fmt.Sprintf("%s > beat %d (%q)", base, idx, beat.ChapterTitle)
+		if beat.QuestArchetypeID != nil && *beat.QuestArchetypeID != uuid.Nil {
+			if _, ok := archetypeIDs[*beat.QuestArchetypeID]; !ok {
+				issues = append(issues, ContentValidationIssue{
+					Severity:            ContentValidationSeverityError,
+					Code:                ContentValidationCodeMissingQuestArchetype,
+					Location:            location + " > questArchetypeId",
+					Message:             fmt.Sprintf("quest archetype %s doesn't exist", *beat.QuestArchetypeID),
+					MainStoryTemplateID: &templateID,
+					BeatIndex:           &beatIndex,
+				})
+			}
+		}
+		for _, flag := range beat.RequiredStoryFlags {
+			key := NormalizeStoryFlagKey(flag)
+			if key == "" {
+				continue
+			}
+			message := ""
+			switch {
+			case flagSources.setOutside(key, beat.QuestArchetypeID, &templateID):
+			case setByEarlierBeat(flagSources[key], templateID, beat.OrderIndex):
+			case len(flagSources[key]) > 0:
+				message = "the flag is only set by this beat or later ones in the story"
+			default:
+				message = "no quest archetype or main story beat sets this flag"
+			}
+			if message == "" {
+				continue
+			}
+			issues = append(issues, ContentValidationIssue{
+				Severity:            ContentValidationSeverityWarning,
+				Code:                ContentValidationCodeUnsetStoryFlag,
+				Location:            fmt.Sprintf("%s > requiredStoryFlags[%q]", location, key),
+				Message:             message,
+				MainStoryTemplateID: &templateID,
+				BeatIndex:           &beatIndex,
+			})
+		}
+	}
+
+	for _, change := range c.WorldChanges {
+		if change.MainStoryTemplateID != template.ID {
+			continue
+		}
+		for _, flag := range change.RequiredStoryFlags {
+			key := NormalizeStoryFlagKey(flag)
+			if key == "" || len(flagSources[key]) > 0 {
+				continue
+			}
+			issues = append(issues, ContentValidationIssue{
+				Severity:            ContentValidationSeverityWarning,
+				Code:                ContentValidationCodeUnsetStoryFlag,
+				Location:            fmt.Sprintf("%s > worldChange %s > requiredStoryFlags[%q]", base, change.ID, key),
+				Message:             "the world change waits on a flag that nothing sets",
+				MainStoryTemplateID: &templateID,
+			})
+		}
+	}
+	return issues
+}
+
+func setByEarlierBeat(setters []storyFlagSetter, templateID uuid.UUID, orderIndex int) bool {
+	for _, setter := range setters {
+		if setter.mainStoryTemplateID != nil && *setter.mainStoryTemplateID == templateID && setter.beatOrder < orderIndex {
+			return true
+		}
+	}
+	return false
+}
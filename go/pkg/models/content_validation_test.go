@@ -0,0 +1,210 @@
+package models
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func validationCodes(issues []ContentValidationIssue) map[ContentValidationCode]int {
+	codes := map[ContentValidationCode]int{}
+	for _, issue := range issues {
+		codes[issue.Code]++
+	}
+	return codes
+}
+
+func linkNodes(from *QuestArchetypeNode, success *QuestArchetypeNode, failure *QuestArchetypeNode) uuid.UUID {
+	challenge := QuestArchetypeChallenge{ID: uuid.New()}
+	if success != nil {
+		challenge.UnlockedNodeID = &success.ID
+	}
+	if failure != nil {
+		challenge.FailureUnlockedNodeID = &failure.ID
+	}
+	from.Challenges = append(from.Challenges, challenge)
+	return challenge.ID
+}
+
+func TestValidateQuestArchetypeAcceptsLinearGraph(t *testing.T) {
+	root := &QuestArchetypeNode{ID: uuid.New()}
+	next := &QuestArchetypeNode{ID: uuid.New(), LocationSelectionMode: QuestArchetypeNodeLocationSelectionModeSameAsPrevious}
+	linkNodes(root, next, nil)
+	archetype := &QuestArchetype{ID: uuid.New(), Name: "Linear", RootID: root.ID}
+
+	catalog := NewContentValidationCatalog([]*QuestArchetype{archetype}, []*QuestArchetypeNode{root, next}, nil, nil, nil)
+	if issues := catalog.Validate(); len(issues) != 0 {
+		t.Fatalf("expected no issues, got %+v", issues)
+	}
+}
+
+func TestValidateQuestArchetypeReportsCycleAtLinkingChallenge(t *testing.T) {
+	root := &QuestArchetypeNode{ID: uuid.New()}
+	middle := &QuestArchetypeNode{ID: uuid.New()}
+	linkNodes(root, middle, nil)
+	backLink := linkNodes(middle, root, nil)
+	archetype := &QuestArchetype{ID: uuid.New(), Name: "Loop", RootID: root.ID}
+
+	catalog := NewContentValidationCatalog([]*QuestArchetype{archetype}, []*QuestArchetypeNode{root, middle}, nil, nil, nil)
+	issues := catalog.ValidateQuestArchetype(archetype)
+	if len(issues) != 1 || issues[0].Code != ContentValidationCodeCycle {
+		t.Fatalf("expected a single cycle issue, got %+v", issues)
+	}
+	if issues[0].ChallengeID == nil || *issues[0].ChallengeID != backLink {
+		t.Fatalf("expected the cycle to point at challenge %s, got %+v", backLink, issues[0].ChallengeID)
+	}
+	if !strings.Contains(issues[0].Location, "challenge "+backLink.String()+" > unlockedNode") {
+		t.Fatalf("expected location to name the back link, got %q", issues[0].Location)
+	}
+}
+
+func TestValidateQuestArchetypeReportsRetryFailureBranchAsUnreachable(t *testing.T) {
+	root := &QuestArchetypeNode{ID: uuid.New(), FailurePolicy: QuestNodeFailurePolicyRetry}
+	success := &QuestArchetypeNode{ID: uuid.New()}
+	failure := &QuestArchetypeNode{ID: uuid.New()}
+	linkNodes(root, success, failure)
+	archetype := &QuestArchetype{ID: uuid.New(), RootID: root.ID}
+
+	catalog := NewContentValidationCatalog([]*QuestArchetype{archetype}, []*QuestArchetypeNode{root, success, failure}, nil, nil, nil)
+	issues := catalog.ValidateQuestArchetype(archetype)
+	if len(issues) != 1 || issues[0].Code != ContentValidationCodeUnreachableNode || *issues[0].NodeID != failure.ID {
+		t.Fatalf("expected failure node to be unreachable, got %+v", issues)
+	}
+
+	root.FailurePolicy = QuestNodeFailurePolicyTransition
+	if issues := catalog.ValidateQuestArchetype(archetype); len(issues) != 0 {
+		t.Fatalf("expected transition policy to make the failure branch reachable, got %+v", issues)
+	}
+}
+
+func TestValidateQuestArchetypeReportsStructuralErrors(t *testing.T) {
+	root := &QuestArchetypeNode{ID: uuid.New(), LocationSelectionMode: QuestArchetypeNodeLocationSelectionModeSameAsPrevious}
+	missing := &QuestArchetypeNode{ID: uuid.New()}
+	linkNodes(root, missing, nil)
+	archetype := &QuestArchetype{ID: uuid.New(), RootID: root.ID}
+
+	catalog := NewContentValidationCatalog([]*QuestArchetype{archetype}, []*QuestArchetypeNode{root}, nil, nil, nil)
+	codes := validationCodes(catalog.ValidateQuestArchetype(archetype))
+	if codes[ContentValidationCodeSameAsPreviousFirstNode] != 1 || codes[ContentValidationCodeMissingNode] != 1 {
+		t.Fatalf("expected same_as_previous and missing node errors, got %+v", codes)
+	}
+}
+
+func TestValidateQuestArchetypeChecksStoryFlagsAgainstOtherContent(t *testing.T) {
+	root := &QuestArchetypeNode{ID: uuid.New(), NodeType: QuestArchetypeNodeTypeStoryFlag, StoryFlagKey: "Gate_Open"}
+	archetype := &QuestArchetype{
+		ID:                 uuid.New(),
+		RootID:             root.ID,
+		RequiredStoryFlags: StringArray{"met_the_oracle"},
+		SetStoryFlags:      StringArray{"gate_open"},
+	}
+	catalog := NewContentValidationCatalog([]*QuestArchetype{archetype}, []*QuestArchetypeNode{root}, nil, nil, nil)
+
+	// Setting a flag yourself doesn't satisfy your own requirements.
+	if codes := validationCodes(catalog.ValidateQuestArchetype(archetype)); codes[ContentValidationCodeUnsetStoryFlag] != 2 {
+		t.Fatalf("expected both flags to be reported, got %+v", codes)
+	}
+
+	catalog.MainStoryTemplates = []MainStoryTemplate{{
+		ID: uuid.New(),
+		Beats: MainStoryBeatDrafts{
+			{OrderIndex: 0, SetStoryFlags: StringArray{"met_the_oracle", "gate_open"}},
+		},
+	}}
+	if issues := catalog.ValidateQuestArchetype(archetype); len(issues) != 0 {
+		t.Fatalf("expected flags set by a main story beat to resolve, got %+v", issues)
+	}
+}
+
+func TestValidateQuestArchetypeChecksFetchItemSources(t *testing.T) {
+	root := &QuestArchetypeNode{
+		ID:       uuid.New(),
+		NodeType: QuestArchetypeNodeTypeFetchQuest,
+		FetchRequirements: FetchQuestRequirements{
+			{InventoryItemID: 1, Quantity: 1},
+			{InventoryItemID: 2, Quantity: 1},
+			{InventoryItemID: 3, Quantity: 1},
+			{InventoryItemID: 4, Quantity: 1},
+		},
+	}
+	archetype := &QuestArchetype{ID: uuid.New(), RootID: root.ID}
+	items := []InventoryItem{
+		{ID: 1, Name: "Herb"},
+		{ID: 2, Name: "Relic", RarityTier: "Not Droppable"},
+		{ID: 3, Name: "Key", RarityTier: "Not Droppable"},
+		{ID: 4, Name: "Lost", Archived: true},
+	}
+	catalog := NewContentValidationCatalog([]*QuestArchetype{archetype}, []*QuestArchetypeNode{root}, nil, nil, items)
+	catalog.ExtraItemSources[3] = true
+
+	issues := catalog.ValidateQuestArchetype(archetype)
+	if len(issues) != 2 {
+		t.Fatalf("expected the relic and the archived item to be unobtainable, got %+v", issues)
+	}
+	if !strings.HasSuffix(issues[0].Location, "fetchRequirements[2]") ||
+		!strings.HasSuffix(issues[1].Location, "fetchRequirements[4]") {
+		t.Fatalf("unexpected locations %q and %q", issues[0].Location, issues[1].Location)
+	}
+}
+
+func TestValidateQuestArchetypeReportsMissingExplicitRewards(t *testing.T) {
+	root := &QuestArchetypeNode{
+		ID:                  uuid.New(),
+		NodeType:            QuestArchetypeNodeTypeMonsterEncounter,
+		EncounterRewardMode: RewardModeExplicit,
+	}
+	archetype := &QuestArchetype{ID: uuid.New(), RootID: root.ID, RewardMode: RewardModeExplicit}
+	catalog := NewContentValidationCatalog([]*QuestArchetype{archetype}, []*QuestArchetypeNode{root}, nil, nil, nil)
+
+	if codes := validationCodes(catalog.ValidateQuestArchetype(archetype)); codes[ContentValidationCodeMissingRewards] != 2 {
+		t.Fatalf("expected archetype and encounter reward issues, got %+v", codes)
+	}
+
+	archetype.DefaultGold = 25
+	root.EncounterRewardExperience = 10
+	if issues := catalog.ValidateQuestArchetype(archetype); len(issues) != 0 {
+		t.Fatalf("expected rewards to satisfy the check, got %+v", issues)
+	}
+}
+
+func TestValidateMainStoryTemplateRequiresEarlierBeatToSetFlag(t *testing.T) {
+	template := MainStoryTemplate{
+		ID:   uuid.New(),
+		Name: "The Long Night",
+		Beats: MainStoryBeatDrafts{
+			{OrderIndex: 0, ChapterTitle: "Whispers", RequiredStoryFlags: StringArray{"lantern_lit"}},
+			{OrderIndex: 1, ChapterTitle: "Lantern", SetStoryFlags: StringArray{"lantern_lit"}},
+			{OrderIndex: 2, ChapterTitle: "Dawn", RequiredStoryFlags: StringArray{"lantern_lit"}},
+		},
+	}
+	catalog := NewContentValidationCatalog(nil, nil, []MainStoryTemplate{template}, nil, nil)
+
+	issues := catalog.ValidateMainStoryTemplate(&template)
+	if len(issues) != 1 || issues[0].BeatIndex == nil || *issues[0].BeatIndex != 0 {
+		t.Fatalf("expected only the first beat to be reported, got %+v", issues)
+	}
+}
+
+func TestValidateReportsOrphanedNodes(t *testing.T) {
+	orphan := &QuestArchetypeNode{ID: uuid.New()}
+	catalog := NewContentValidationCatalog(nil, []*QuestArchetypeNode{orphan}, nil, nil, nil)
+	issues := catalog.Validate()
+	if len(issues) != 1 || issues[0].Code != ContentValidationCodeOrphanedNode {
+		t.Fatalf("expected orphaned node warning, got %+v", issues)
+	}
+}
+
+func TestIntroducedContentValidationErrorsIgnoresExistingProblems(t *testing.T) {
+	existing := ContentValidationIssue{Severity: ContentValidationSeverityError, Code: ContentValidationCodeCycle, Location: "a"}
+	added := ContentValidationIssue{Severity: ContentValidationSeverityError, Code: ContentValidationCodeCycle, Location: "b"}
+	warning := ContentValidationIssue{Severity: ContentValidationSeverityWarning, Code: ContentValidationCodeUnsetStoryFlag, Location: "c"}
+
+	introduced := IntroducedContentValidationErrors(
+		[]ContentValidationIssue{existing},
+		[]ContentValidationIssue{existing, added, warning},
+	)
+	if len(introduced) != 1 || introduced[0].Location != "b" {
+		t.Fatalf("expected only the new error, got %+v", introduced)
+	}
+}
@@ -0,0 +1,141 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type GuildRole string
+
+const (
+	GuildRoleLeader  GuildRole = "leader"
+	GuildRoleOfficer GuildRole = "officer"
+	GuildRoleMember  GuildRole = "member"
+)
+
+func NormalizeGuildRole(raw string) (GuildRole, bool) {
+	switch GuildRole(strings.ToLower(strings.TrimSpace(raw))) {
+	case GuildRoleLeader:
+		return GuildRoleLeader, true
+	case GuildRoleOfficer:
+		return GuildRoleOfficer, true
+	case GuildRoleMember:
+		return GuildRoleMember, true
+	default:
+		return "", false
+	}
+}
+
+func (r GuildRole) rank() int {
+	switch r {
+	case GuildRoleLeader:
+		return 3
+	case GuildRoleOfficer:
+		return 2
+	case GuildRoleMember:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Outranks reports whether r sits strictly above other in the guild
+// hierarchy. Members can only be managed by someone who outranks them.
+func (r GuildRole) Outranks(other GuildRole) bool {
+	return r.rank() > other.rank()
+}
+
+func (r GuildRole) CanInvite() bool {
+	return r == GuildRoleLeader || r == GuildRoleOfficer
+}
+
+func (r GuildRole) CanManageMembers() bool {
+	return r == GuildRoleLeader || r == GuildRoleOfficer
+}
+
+type Guild struct {
+	ID                       uuid.UUID `json:"id" gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	CreatedAt                time.Time `json:"createdAt"`
+	UpdatedAt                time.Time `json:"updatedAt"`
+	Name                     string    `json:"name" gorm:"column:name"`
+	Tag                      string    `json:"tag" gorm:"column:tag"`
+	Description              string    `json:"description" gorm:"column:description"`
+	LeaderUserID             uuid.UUID `json:"leaderUserId" gorm:"column:leader_user_id"`
+	Gold                     int       `json:"gold" gorm:"column:gold"`
+	MemberWithdrawalsEnabled bool      `json:"memberWithdrawalsEnabled" gorm:"column:member_withdrawals_enabled"`
+
+	Leader  User          `json:"leader,omitempty" gorm:"foreignKey:LeaderUserID"`
+	Members []GuildMember `json:"members,omitempty" gorm:"foreignKey:GuildID"`
+}
+
+func (g *Guild) TableName() string {
+	return "guilds"
+}
+
+// CanWithdraw reports whether a member holding role may take gold or items
+// out of the guild bank. Leaders and officers always can; regular members
+// only when the guild has opted in.
+func (g *Guild) CanWithdraw(role GuildRole) bool {
+	switch role {
+	case GuildRoleLeader, GuildRoleOfficer:
+		return true
+	case GuildRoleMember:
+		return g.MemberWithdrawalsEnabled
+	default:
+		return false
+	}
+}
+
+type GuildMember struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	GuildID   uuid.UUID `json:"guildId" gorm:"column:guild_id"`
+	UserID    uuid.UUID `json:"userId" gorm:"column:user_id"`
+	Role      GuildRole `json:"role" gorm:"column:role"`
+
+	User User `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
+
+func (m *GuildMember) TableName() string {
+	return "guild_members"
+}
+
+type GuildInviteStatus string
+
+const (
+	GuildInviteStatusPending   GuildInviteStatus = "pending"
+	GuildInviteStatusAccepted  GuildInviteStatus = "accepted"
+	GuildInviteStatusDeclined  GuildInviteStatus = "declined"
+	GuildInviteStatusCancelled GuildInviteStatus = "cancelled"
+)
+
+type GuildInvite struct {
+	ID            uuid.UUID         `json:"id" gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	CreatedAt     time.Time         `json:"createdAt"`
+	UpdatedAt     time.Time         `json:"updatedAt"`
+	GuildID       uuid.UUID         `json:"guildId" gorm:"column:guild_id"`
+	InviterUserID uuid.UUID         `json:"inviterUserId" gorm:"column:inviter_user_id"`
+	InviteeUserID uuid.UUID         `json:"inviteeUserId" gorm:"column:invitee_user_id"`
+	Status        GuildInviteStatus `json:"status" gorm:"column:status"`
+	RespondedAt   *time.Time        `json:"respondedAt,omitempty" gorm:"column:responded_at"`
+
+	Guild   Guild `json:"guild,omitempty" gorm:"foreignKey:GuildID"`
+	Inviter User  `json:"inviter,omitempty" gorm:"foreignKey:InviterUserID"`
+	Invitee User  `json:"invitee,omitempty" gorm:"foreignKey:InviteeUserID"`
+}
+
+func (i *GuildInvite) TableName() string {
+	return "guild_invites"
+}
+
+type GuildLeaderboardEntry struct {
+	Rank            int       `json:"rank" gorm:"-"`
+	GuildID         uuid.UUID `json:"guildId" gorm:"column:guild_id"`
+	Name            string    `json:"name" gorm:"column:name"`
+	Tag             string    `json:"tag" gorm:"column:tag"`
+	MemberCount     int       `json:"memberCount" gorm:"column:member_count"`
+	TotalReputation int       `json:"totalReputation" gorm:"column:total_reputation"`
+}
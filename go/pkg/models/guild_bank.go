@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type GuildBankItem struct {
+	ID              uuid.UUID `json:"id" gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	CreatedAt       time.Time `json:"createdAt"`
+	UpdatedAt       time.Time `json:"updatedAt"`
+	GuildID         uuid.UUID `json:"guildId" gorm:"column:guild_id"`
+	InventoryItemID int       `json:"inventoryItemId" gorm:"column:inventory_item_id"`
+	Quantity        int       `json:"quantity" gorm:"column:quantity"`
+
+	InventoryItem InventoryItem `json:"inventoryItem,omitempty" gorm:"foreignKey:InventoryItemID"`
+}
+
+func (b *GuildBankItem) TableName() string {
+	return "guild_bank_items"
+}
+
+type GuildAuditAction string
+
+const (
+	GuildAuditActionCreated           GuildAuditAction = "created"
+	GuildAuditActionMemberJoined      GuildAuditAction = "member_joined"
+	GuildAuditActionMemberLeft        GuildAuditAction = "member_left"
+	GuildAuditActionMemberRemoved     GuildAuditAction = "member_removed"
+	GuildAuditActionRoleChanged       GuildAuditAction = "role_changed"
+	GuildAuditActionLeaderTransferred GuildAuditAction = "leader_transferred"
+	GuildAuditActionSettingsUpdated   GuildAuditAction = "settings_updated"
+	GuildAuditActionGoldDeposited     GuildAuditAction = "gold_deposited"
+	GuildAuditActionGoldWithdrawn     GuildAuditAction = "gold_withdrawn"
+	GuildAuditActionItemDeposited     GuildAuditAction = "item_deposited"
+	GuildAuditActionItemWithdrawn     GuildAuditAction = "item_withdrawn"
+)
+
+// GuildAuditEntry is an append-only record of bank movements and membership
+// changes. Entries are never updated once written.
+type GuildAuditEntry struct {
+	ID              uuid.UUID        `json:"id" gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	CreatedAt       time.Time        `json:"createdAt"`
+	GuildID         uuid.UUID        `json:"guildId" gorm:"column:guild_id"`
+	ActorUserID     *uuid.UUID       `json:"actorUserId,omitempty" gorm:"column:actor_user_id"`
+	TargetUserID    *uuid.UUID       `json:"targetUserId,omitempty" gorm:"column:target_user_id"`
+	Action          GuildAuditAction `json:"action" gorm:"column:action"`
+	InventoryItemID *int             `json:"inventoryItemId,omitempty" gorm:"column:inventory_item_id"`
+	Quantity        int              `json:"quantity" gorm:"column:quantity"`
+	Gold            int              `json:"gold" gorm:"column:gold"`
+	Details         string           `json:"details" gorm:"column:details"`
+
+	ActorUser     *User          `json:"actorUser,omitempty" gorm:"foreignKey:ActorUserID"`
+	TargetUser    *User          `json:"targetUser,omitempty" gorm:"foreignKey:TargetUserID"`
+	InventoryItem *InventoryItem `json:"inventoryItem,omitempty" gorm:"foreignKey:InventoryItemID"`
+}
+
+func (e *GuildAuditEntry) TableName() string {
+	return "guild_audit_entries"
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// GuildQuestNodeCompletion records the first time any member of a guild
+// finished a quest node. Other members on the same node are credited from it.
+type GuildQuestNodeCompletion struct {
+	ID                uuid.UUID  `json:"id" gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	CreatedAt         time.Time  `json:"createdAt"`
+	GuildID           uuid.UUID  `json:"guildId" gorm:"column:guild_id"`
+	QuestID           uuid.UUID  `json:"questId" gorm:"column:quest_id"`
+	QuestNodeID       uuid.UUID  `json:"questNodeId" gorm:"column:quest_node_id"`
+	CompletedByUserID *uuid.UUID `json:"completedByUserId,omitempty" gorm:"column:completed_by_user_id"`
+
+	CompletedByUser *User `json:"completedByUser,omitempty" gorm:"foreignKey:CompletedByUserID"`
+}
+
+func (c *GuildQuestNodeCompletion) TableName() string {
+	return "guild_quest_node_completions"
+}
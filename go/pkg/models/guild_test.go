@@ -0,0 +1,42 @@
+package models
+
+import "testing"
+
+func TestGuildRoleOutranks(t *testing.T) {
+	if !GuildRoleLeader.Outranks(GuildRoleOfficer) {
+		t.Fatalf("expected leader to outrank officer")
+	}
+	if !GuildRoleOfficer.Outranks(GuildRoleMember) {
+		t.Fatalf("expected officer to outrank member")
+	}
+	if GuildRoleOfficer.Outranks(GuildRoleOfficer) {
+		t.Fatalf("expected officers not to outrank each other")
+	}
+	if GuildRoleMember.Outranks(GuildRoleLeader) {
+		t.Fatalf("expected member not to outrank leader")
+	}
+}
+
+func TestGuildCanWithdraw(t *testing.T) {
+	guild := &Guild{}
+	if !guild.CanWithdraw(GuildRoleLeader) || !guild.CanWithdraw(GuildRoleOfficer) {
+		t.Fatalf("expected leaders and officers to always withdraw")
+	}
+	if guild.CanWithdraw(GuildRoleMember) {
+		t.Fatalf("expected members to be blocked by default")
+	}
+	guild.MemberWithdrawalsEnabled = true
+	if !guild.CanWithdraw(GuildRoleMember) {
+		t.Fatalf("expected members to withdraw once enabled")
+	}
+}
+
+func TestNormalizeGuildRole(t *testing.T) {
+	role, ok := NormalizeGuildRole(" Officer ")
+	if !ok || role != GuildRoleOfficer {
+		t.Fatalf("expected officer, got %q ok=%v", role, ok)
+	}
+	if _, ok := NormalizeGuildRole("emperor"); ok {
+		t.Fatalf("expected unknown role to be rejected")
+	}
+}
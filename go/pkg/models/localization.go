@@ -0,0 +1,400 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultLocale is the language content is authored and generated in. It
+// never has translation rows; the source text is the default.
+const DefaultLocale = "en"
+
+const (
+	ContentTranslationSourceManual  = "manual"
+	ContentTranslationSourceMachine = "machine"
+)
+
+// Content that can carry per-locale text overrides.
+const (
+	TranslatableEntityTypeScenario       = "scenario"
+	TranslatableEntityTypeExposition     = "exposition"
+	TranslatableEntityTypeCharacter      = "character"
+	TranslatableEntityTypeQuest          = "quest"
+	TranslatableEntityTypeInventoryItem  = "inventory_item"
+	TranslatableEntityTypeTutorialConfig = "tutorial_config"
+)
+
+var translatableEntityTypes = []string{
+	TranslatableEntityTypeScenario,
+	TranslatableEntityTypeExposition,
+	TranslatableEntityTypeCharacter,
+	TranslatableEntityTypeQuest,
+	TranslatableEntityTypeInventoryItem,
+	TranslatableEntityTypeTutorialConfig,
+}
+
+func TranslatableEntityTypes() []string {
+	return append([]string(nil), translatableEntityTypes...)
+}
+
+func IsTranslatableEntityType(entityType string) bool {
+	for _, candidate := range translatableEntityTypes {
+		if candidate == entityType {
+			return true
+		}
+	}
+	return false
+}
+
+// TranslationLocale is a language players can be served. Locales with
+// MachineTranslate set are filled in by the translation job.
+type TranslationLocale struct {
+	Code             string    `json:"code" gorm:"primaryKey"`
+	CreatedAt        time.Time `json:"createdAt"`
+	UpdatedAt        time.Time `json:"updatedAt"`
+	Name             string    `json:"name"`
+	Enabled          bool      `json:"enabled"`
+	MachineTranslate bool      `json:"machineTranslate" gorm:"column:machine_translate"`
+}
+
+func (TranslationLocale) TableName() string {
+	return "translation_locales"
+}
+
+// ContentTranslation overrides one field of one entity in one locale.
+// EntityID is text because inventory items have integer IDs. SourceHash is
+// the hash of the English text the translation was made from, so machine
+// translations of text that has since changed are ignored until redone.
+type ContentTranslation struct {
+	ID           uuid.UUID  `json:"id" gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	UpdatedAt    time.Time  `json:"updatedAt"`
+	EntityType   string     `json:"entityType" gorm:"column:entity_type"`
+	EntityID     string     `json:"entityId" gorm:"column:entity_id"`
+	Locale       string     `json:"locale"`
+	Field        string     `json:"field"`
+	Text         string     `json:"text"`
+	SourceHash   string     `json:"sourceHash" gorm:"column:source_hash"`
+	Source       string     `json:"source"`
+	EditedByUser *uuid.UUID `json:"editedByUserId,omitempty" gorm:"column:edited_by_user_id;type:uuid"`
+}
+
+func (ContentTranslation) TableName() string {
+	return "content_translations"
+}
+
+// Current reports whether the translation still applies to the source
+// text. Manual overrides are trusted until an editor replaces them.
+func (t ContentTranslation) Current(sourceText string) bool {
+	return t.Source == ContentTranslationSourceManual || t.SourceHash == TranslationSourceHash(sourceText)
+}
+
+// TranslationGlossaryTerm pins how a term is rendered in a locale for one
+// genre, so item names and other terminology read the same everywhere.
+type TranslationGlossaryTerm struct {
+	ID          uuid.UUID `json:"id" gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+	GenreID     uuid.UUID `json:"genreId" gorm:"column:genre_id;type:uuid"`
+	Locale      string    `json:"locale"`
+	Term        string    `json:"term"`
+	Translation string    `json:"translation"`
+	Notes       string    `json:"notes"`
+}
+
+func (TranslationGlossaryTerm) TableName() string {
+	return "translation_glossary_terms"
+}
+
+func TranslationSourceHash(text string) string {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(text)))
+	return hex.EncodeToString(sum[:8])
+}
+
+// NormalizeLocale canonicalizes a BCP 47 tag to language[-REGION] form,
+// e.g. "pt_br" becomes "pt-BR". It returns "" for anything else.
+func NormalizeLocale(raw string) string {
+	parts := strings.Split(strings.ReplaceAll(strings.TrimSpace(raw), "_", "-"), "-")
+	language := strings.ToLower(parts[0])
+	if len(language) < 2 || len(language) > 3 || !isASCIILetters(language) {
+		return ""
+	}
+	if len(parts) == 1 {
+		return language
+	}
+	region := parts[1]
+	switch {
+	case len(region) == 2 && isASCIILetters(region):
+		return language + "-" + strings.ToUpper(region)
+	case len(region) == 4 && isASCIILetters(region):
+		// Script subtag, e.g. zh-Hant.
+		return language + "-" + strings.ToUpper(region[:1]) + strings.ToLower(region[1:])
+	default:
+		return language
+	}
+}
+
+func isASCIILetters(value string) bool {
+	for _, r := range value {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') {
+			return false
+		}
+	}
+	return value != ""
+}
+
+func localeLanguage(locale string) string {
+	if index := strings.Index(locale, "-"); index >= 0 {
+		return locale[:index]
+	}
+	return locale
+}
+
+// ParseAcceptLanguage returns the locales of an Accept-Language header in
+// preference order. Wildcards and q=0 entries are dropped.
+func ParseAcceptLanguage(header string) []string {
+	type weighted struct {
+		locale  string
+		quality float64
+		order   int
+	}
+	entries := []weighted{}
+	for order, part := range strings.Split(header, ",") {
+		pieces := strings.Split(strings.TrimSpace(part), ";")
+		locale := NormalizeLocale(pieces[0])
+		if locale == "" {
+			continue
+		}
+		quality := 1.0
+		for _, param := range pieces[1:] {
+			param = strings.TrimSpace(param)
+			if !strings.HasPrefix(param, "q=") {
+				continue
+			}
+			if parsed, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64); err == nil {
+				quality = parsed
+			}
+		}
+		if quality <= 0 {
+			continue
+		}
+		entries = append(entries, weighted{locale: locale, quality: quality, order: order})
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].quality > entries[j].quality })
+	locales := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		locales = append(locales, entry.locale)
+	}
+	return locales
+}
+
+// ResolveLocale picks the enabled locale that best serves the request and
+// returns it with the locales to try, most specific first. An exact match
+// wins; otherwise a requested regional locale falls back to its language
+// and a bare language matches any region of it. Requests nothing serves,
+// or that ask for the default language first, get DefaultLocale and no
+// chain.
+func ResolveLocale(acceptLanguage string, enabled []string) (string, []string) {
+	available := map[string]bool{}
+	byLanguage := map[string]string{}
+	sortedEnabled := append([]string(nil), enabled...)
+	sort.Strings(sortedEnabled)
+	for _, raw := range sortedEnabled {
+		locale := NormalizeLocale(raw)
+		if locale == "" {
+			continue
+		}
+		available[locale] = true
+		if _, ok := byLanguage[localeLanguage(locale)]; !ok || locale == localeLanguage(locale) {
+			byLanguage[localeLanguage(locale)] = locale
+		}
+	}
+	for _, requested := range ParseAcceptLanguage(acceptLanguage) {
+		language := localeLanguage(requested)
+		if language == DefaultLocale {
+			return DefaultLocale, nil
+		}
+		chain := []string{}
+		if available[requested] {
+			chain = append(chain, requested)
+		}
+		if requested != language && available[language] {
+			chain = append(chain, language)
+		}
+		if len(chain) == 0 {
+			if match, ok := byLanguage[language]; ok {
+				chain = append(chain, match)
+			}
+		}
+		if len(chain) > 0 {
+			return chain[0], chain
+		}
+	}
+	return DefaultLocale, nil
+}
+
+// TranslatableText points at one piece of player-facing text on an entity.
+// The same list is used to read the English source for translation and to
+// write translated text into a response.
+type TranslatableText struct {
+	Field string
+	Text  *string
+}
+
+// ApplyTranslations overwrites each text with the first current translation
+// found along the locale chain, leaving the English source otherwise.
+func ApplyTranslations(texts []TranslatableText, translations []ContentTranslation, chain []string) {
+	if len(chain) == 0 || len(translations) == 0 {
+		return
+	}
+	byKey := make(map[string]ContentTranslation, len(translations))
+	for _, translation := range translations {
+		byKey[translation.Locale+"\x00"+translation.Field] = translation
+	}
+	for _, text := range texts {
+		if text.Text == nil || strings.TrimSpace(*text.Text) == "" {
+			continue
+		}
+		for _, locale := range chain {
+			translation, ok := byKey[locale+"\x00"+text.Field]
+			if !ok || strings.TrimSpace(translation.Text) == "" || !translation.Current(*text.Text) {
+				continue
+			}
+			*text.Text = translation.Text
+			break
+		}
+	}
+}
+
+func dialogueTranslatableText(prefix string, dialogue DialogueSequence) []TranslatableText {
+	texts := make([]TranslatableText, 0, len(dialogue))
+	for index := range dialogue {
+		texts = append(texts, TranslatableText{Field: fmt.Sprintf("%s.%d", prefix, index), Text: &dialogue[index].Text})
+	}
+	return texts
+}
+
+// ScenarioTranslatableText keys option text by option ID so translations
+// survive options being reordered.
+func ScenarioTranslatableText(scenario *Scenario) []TranslatableText {
+	texts := []TranslatableText{{Field: "prompt", Text: &scenario.Prompt}}
+	for index := range scenario.Options {
+		option := &scenario.Options[index]
+		prefix := "options." + option.ID.String()
+		texts = append(texts,
+			TranslatableText{Field: prefix + ".optionText", Text: &option.OptionText},
+			TranslatableText{Field: prefix + ".successText", Text: &option.SuccessText},
+			TranslatableText{Field: prefix + ".failureText", Text: &option.FailureText},
+			TranslatableText{Field: prefix + ".successHandoffText", Text: &option.SuccessHandoffText},
+			TranslatableText{Field: prefix + ".failureHandoffText", Text: &option.FailureHandoffText},
+		)
+	}
+	return texts
+}
+
+func ExpositionTranslatableText(exposition *Exposition) []TranslatableText {
+	texts := []TranslatableText{
+		{Field: "title", Text: &exposition.Title},
+		{Field: "description", Text: &exposition.Description},
+	}
+	return append(texts, dialogueTranslatableText("dialogue", exposition.Dialogue)...)
+}
+
+func CharacterTranslatableText(character *Character) []TranslatableText {
+	return []TranslatableText{
+		{Field: "name", Text: &character.Name},
+		{Field: "description", Text: &character.Description},
+	}
+}
+
+func QuestTranslatableText(quest *Quest) []TranslatableText {
+	texts := []TranslatableText{
+		{Field: "name", Text: &quest.Name},
+		{Field: "description", Text: &quest.Description},
+	}
+	return append(texts, dialogueTranslatableText("acceptanceDialogue", quest.AcceptanceDialogue)...)
+}
+
+func InventoryItemTranslatableText(item *InventoryItem) []TranslatableText {
+	return []TranslatableText{
+		{Field: "name", Text: &item.Name},
+		{Field: "flavorText", Text: &item.FlavorText},
+		{Field: "effectText", Text: &item.EffectText},
+	}
+}
+
+func TutorialConfigTranslatableText(config *TutorialConfig) []TranslatableText {
+	texts := []TranslatableText{
+		{Field: "scenarioPrompt", Text: &config.ScenarioPrompt},
+		{Field: "scenarioObjectiveCopy", Text: &config.ScenarioObjectiveCopy},
+		{Field: "loadoutObjectiveCopy", Text: &config.LoadoutObjectiveCopy},
+		{Field: "monsterObjectiveCopy", Text: &config.MonsterObjectiveCopy},
+		{Field: "baseKitObjectiveCopy", Text: &config.BaseKitObjectiveCopy},
+		{Field: "hearthObjectiveCopy", Text: &config.HearthObjectiveCopy},
+		{Field: "guideSupportGreeting", Text: &config.GuideSupportGreeting},
+	}
+	for index := range config.Options {
+		texts = append(texts, TranslatableText{Field: fmt.Sprintf("options.%d.optionText", index), Text: &config.Options[index].OptionText})
+	}
+	for _, sequence := range []struct {
+		prefix   string
+		dialogue DialogueSequence
+	}{
+		{"dialogue", config.Dialogue},
+		{"postWelcomeDialogue", config.PostWelcomeDialogue},
+		{"postScenarioDialogue", config.PostScenarioDialogue},
+		{"loadoutDialogue", config.LoadoutDialogue},
+		{"postMonsterDialogue", config.PostMonsterDialogue},
+		{"baseKitDialogue", config.BaseKitDialogue},
+		{"postBasePlacementDialogue", config.PostBasePlacementDialogue},
+		{"postBaseDialogue", config.PostBaseDialogue},
+	} {
+		texts = append(texts, dialogueTranslatableText(sequence.prefix, sequence.dialogue)...)
+	}
+	return texts
+}
+
+// TranslationSource is the English text of one entity as the translation
+// job and the admin editor see it.
+type TranslationSource struct {
+	EntityType string             `json:"entityType"`
+	EntityID   string             `json:"entityId"`
+	GenreID    uuid.UUID          `json:"genreId"`
+	Fields     []TranslatableText `json:"-"`
+}
+
+// SourceText returns the non-empty source fields by name.
+func (s TranslationSource) SourceText() map[string]string {
+	text := make(map[string]string, len(s.Fields))
+	for _, field := range s.Fields {
+		if field.Text == nil || strings.TrimSpace(*field.Text) == "" {
+			continue
+		}
+		text[field.Field] = *field.Text
+	}
+	return text
+}
+
+// GlossaryPromptSection renders glossary terms as instructions for the
+// translation prompt.
+func GlossaryPromptSection(terms []TranslationGlossaryTerm) string {
+	if len(terms) == 0 {
+		return "- none"
+	}
+	lines := make([]string, 0, len(terms))
+	for _, term := range terms {
+		line := fmt.Sprintf("- %q -> %q", strings.TrimSpace(term.Term), strings.TrimSpace(term.Translation))
+		if notes := strings.TrimSpace(term.Notes); notes != "" {
+			line += " (" + notes + ")"
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestParseAcceptLanguageOrdersByQuality(t *testing.T) {
+	got := ParseAcceptLanguage("fr-ca;q=0.5, de_DE, *;q=0.1, es;q=0, pt;q=0.8")
+	want := []string{"de-DE", "pt", "fr-CA"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestResolveLocaleFallsBack(t *testing.T) {
+	enabled := []string{"pt-BR", "fr", "fr-CA", "de"}
+	cases := []struct {
+		header string
+		locale string
+		chain  []string
+	}{
+		{"fr-CA", "fr-CA", []string{"fr-CA", "fr"}},
+		{"fr-BE", "fr", []string{"fr"}},
+		{"pt", "pt-BR", []string{"pt-BR"}},
+		{"ja, de;q=0.5", "de", []string{"de"}},
+		{"en-US, de;q=0.5", DefaultLocale, nil},
+		{"", DefaultLocale, nil},
+	}
+	for _, tc := range cases {
+		locale, chain := ResolveLocale(tc.header, enabled)
+		if locale != tc.locale || !reflect.DeepEqual(chain, tc.chain) {
+			t.Fatalf("%q: expected %s %v, got %s %v", tc.header, tc.locale, tc.chain, locale, chain)
+		}
+	}
+}
+
+func TestApplyTranslationsSkipsStaleMachineText(t *testing.T) {
+	optionID := uuid.New()
+	scenario := &Scenario{
+		Prompt:  "A lantern flickers.",
+		Options: []ScenarioOption{{ID: optionID, OptionText: "Relight it", SuccessText: "It glows."}},
+	}
+	optionField := "options." + optionID.String() + ".optionText"
+	translations := []ContentTranslation{
+		{Locale: "fr", Field: "prompt", Text: "Une lanterne vacille.", Source: ContentTranslationSourceMachine, SourceHash: TranslationSourceHash("A lantern flickers.")},
+		{Locale: "fr", Field: optionField, Text: "Rallumer", Source: ContentTranslationSourceMachine, SourceHash: TranslationSourceHash("Light it")},
+		{Locale: "fr-CA", Field: optionField, Text: "La rallumer", Source: ContentTranslationSourceManual},
+		{Locale: "fr", Field: "options." + optionID.String() + ".successText", Text: "Elle brille.", Source: ContentTranslationSourceMachine, SourceHash: TranslationSourceHash("It shines.")},
+	}
+
+	ApplyTranslations(ScenarioTranslatableText(scenario), translations, []string{"fr-CA", "fr"})
+
+	if scenario.Prompt != "Une lanterne vacille." {
+		t.Fatalf("expected translated prompt, got %q", scenario.Prompt)
+	}
+	if scenario.Options[0].OptionText != "La rallumer" {
+		t.Fatalf("expected the regional manual override, got %q", scenario.Options[0].OptionText)
+	}
+	if scenario.Options[0].SuccessText != "It glows." {
+		t.Fatalf("expected stale machine text to fall back to the source, got %q", scenario.Options[0].SuccessText)
+	}
+}
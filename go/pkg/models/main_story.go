@@ -144,6 +144,8 @@ type MainStoryTemplate struct {
 	ResolutionSummary string              `json:"resolutionSummary" gorm:"column:resolution_summary"`
 	WhyItWorks        string              `json:"whyItWorks" gorm:"column:why_it_works"`
 	Beats             MainStoryBeatDrafts `json:"beats" gorm:"type:jsonb"`
+
+	ValidationIssues []ContentValidationIssue `json:"validationIssues,omitempty" gorm:"-"`
 }
 
 func (MainStoryTemplate) TableName() string {
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ManifestAnchor is a manifest hash queued for anchoring on chain. Once its
+// batch is cut it records its leaf's position in the batch's Merkle tree and
+// the sibling hashes proving it's included under the batch's root.
+type ManifestAnchor struct {
+	ID              uuid.UUID            `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	CreatedAt       time.Time            `gorm:"not null" json:"createdAt"`
+	UpdatedAt       time.Time            `gorm:"not null" json:"updatedAt"`
+	ManifestHash    []byte               `gorm:"type:bytea;not null;uniqueIndex" json:"manifestHash"`
+	CertFingerprint []byte               `gorm:"type:bytea;not null" json:"certFingerprint"`
+	LeafHash        []byte               `gorm:"type:bytea;not null" json:"leafHash"`
+	BatchID         *uuid.UUID           `gorm:"type:uuid;index" json:"batchId,omitempty"`
+	Batch           *ManifestAnchorBatch `gorm:"foreignKey:BatchID" json:"batch,omitempty"`
+	LeafIndex       *int                 `gorm:"type:integer" json:"leafIndex,omitempty"`
+	Proof           StringArray          `gorm:"type:jsonb" json:"proof,omitempty"`
+}
+
+// ManifestAnchorBatch is a Merkle tree over manifest anchors whose root is
+// anchored on chain in a single transaction.
+type ManifestAnchorBatch struct {
+	ID                      uuid.UUID              `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	CreatedAt               time.Time              `gorm:"not null" json:"createdAt"`
+	UpdatedAt               time.Time              `gorm:"not null" json:"updatedAt"`
+	ChainID                 int64                  `gorm:"type:bigint;not null" json:"chainId"`
+	ContractAddress         string                 `gorm:"type:text;not null" json:"contractAddress"`
+	MerkleRoot              []byte                 `gorm:"type:bytea;not null;uniqueIndex" json:"merkleRoot"`
+	LeafCount               int                    `gorm:"type:integer;not null" json:"leafCount"`
+	BlockchainTransactionID *uuid.UUID             `gorm:"type:uuid;index" json:"blockchainTransactionId,omitempty"`
+	BlockchainTransaction   *BlockchainTransaction `gorm:"foreignKey:BlockchainTransactionID" json:"blockchainTransaction,omitempty"`
+}
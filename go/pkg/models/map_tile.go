@@ -0,0 +1,144 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const (
+	MapTileMaxZoom = 22
+	// MapTileMinContentZoom is the lowest zoom that carries point layers.
+	// Below it tiles only draw zone polygons, which keeps city-wide views
+	// small.
+	MapTileMinContentZoom = 12
+	// MapTileExtent is the MVT coordinate space per tile.
+	MapTileExtent = 4096
+	MapTileBuffer = 64
+	// mapTileFormatVersion is folded into every ETag so changing the layer
+	// schema invalidates cached tiles.
+	mapTileFormatVersion = 1
+
+	MapTileContentType = "application/vnd.mapbox-vector-tile"
+)
+
+const (
+	MapTileLayerZones            = "zones"
+	MapTileLayerPointsOfInterest = "pointsOfInterest"
+	MapTileLayerTreasureChests   = "treasureChests"
+	MapTileLayerShrines          = "shrines"
+	MapTileLayerHealingFountains = "healingFountains"
+	MapTileLayerMonsters         = "monsters"
+)
+
+// MapTile addresses a slippy-map tile.
+type MapTile struct {
+	Z int
+	X int
+	Y int
+}
+
+// ParseMapTile parses z/x/y path segments. y may carry a ".mvt" suffix.
+func ParseMapTile(z string, x string, y string) (MapTile, error) {
+	y = strings.TrimSuffix(y, ".mvt")
+	zoom, err := strconv.Atoi(z)
+	if err != nil {
+		return MapTile{}, fmt.Errorf("invalid tile zoom %q", z)
+	}
+	col, err := strconv.Atoi(x)
+	if err != nil {
+		return MapTile{}, fmt.Errorf("invalid tile x %q", x)
+	}
+	row, err := strconv.Atoi(y)
+	if err != nil {
+		return MapTile{}, fmt.Errorf("invalid tile y %q", y)
+	}
+	tile := MapTile{Z: zoom, X: col, Y: row}
+	if err := tile.Validate(); err != nil {
+		return MapTile{}, err
+	}
+	return tile, nil
+}
+
+func (t MapTile) Validate() error {
+	if t.Z < 0 || t.Z > MapTileMaxZoom {
+		return fmt.Errorf("tile zoom must be between 0 and %d", MapTileMaxZoom)
+	}
+	limit := 1 << uint(t.Z)
+	if t.X < 0 || t.X >= limit || t.Y < 0 || t.Y >= limit {
+		return fmt.Errorf("tile %d/%d/%d is out of range", t.Z, t.X, t.Y)
+	}
+	return nil
+}
+
+func (t MapTile) String() string {
+	return fmt.Sprintf("%d/%d/%d", t.Z, t.X, t.Y)
+}
+
+func (t MapTile) IncludesContent() bool {
+	return t.Z >= MapTileMinContentZoom
+}
+
+// Bounds returns the tile's west, south, east and north edges in degrees.
+func (t MapTile) Bounds() (float64, float64, float64, float64) {
+	n := math.Exp2(float64(t.Z))
+	lng := func(x int) float64 { return float64(x)/n*360 - 180 }
+	lat := func(y int) float64 {
+		return math.Atan(math.Sinh(math.Pi*(1-2*float64(y)/n))) * 180 / math.Pi
+	}
+	return lng(t.X), lat(t.Y + 1), lng(t.X + 1), lat(t.Y)
+}
+
+// MapTileViewer is the per-user state a tile is rendered for.
+type MapTileViewer struct {
+	UserID     uuid.UUID
+	StoryFlags []string
+}
+
+// NormalizedStoryFlags returns the viewer's active flags lowercased, trimmed
+// and sorted, matching how required story flags are compared elsewhere.
+func (v MapTileViewer) NormalizedStoryFlags() []string {
+	flags := make([]string, 0, len(v.StoryFlags))
+	seen := map[string]bool{}
+	for _, flag := range v.StoryFlags {
+		key := strings.ToLower(strings.TrimSpace(flag))
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		flags = append(flags, key)
+	}
+	sort.Strings(flags)
+	return flags
+}
+
+// MapTileVersion identifies what a rendered tile depends on. Content covers
+// the zones and map content inside the tile and is shared by every user;
+// Viewer covers the user's discoveries, openings and victories.
+type MapTileVersion struct {
+	Content string
+	Viewer  string
+}
+
+// ETag combines the tile address, both versions and the viewer's story flags
+// into a strong validator for the rendered bytes.
+func (v MapTileVersion) ETag(tile MapTile, viewer MapTileViewer) string {
+	hash := sha256.New()
+	fmt.Fprintf(
+		hash,
+		"v%d|%s|%s|%s|%s|%s",
+		mapTileFormatVersion,
+		tile.String(),
+		v.Content,
+		viewer.UserID,
+		v.Viewer,
+		strings.Join(viewer.NormalizedStoryFlags(), ","),
+	)
+	return `"` + hex.EncodeToString(hash.Sum(nil))[:32] + `"`
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"math"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestParseMapTile(t *testing.T) {
+	tile, err := ParseMapTile("14", "2622", "5721.mvt")
+	if err != nil {
+		t.Fatalf("ParseMapTile returned error: %v", err)
+	}
+	if tile != (MapTile{Z: 14, X: 2622, Y: 5721}) {
+		t.Fatalf("unexpected tile %+v", tile)
+	}
+	if !tile.IncludesContent() {
+		t.Fatalf("expected zoom 14 to include content layers")
+	}
+
+	for _, bad := range [][3]string{
+		{"23", "0", "0"},
+		{"2", "4", "0"},
+		{"2", "0", "-1"},
+		{"z", "0", "0"},
+	} {
+		if _, err := ParseMapTile(bad[0], bad[1], bad[2]); err == nil {
+			t.Fatalf("expected %v to be rejected", bad)
+		}
+	}
+}
+
+func TestMapTileBounds(t *testing.T) {
+	west, south, east, north := MapTile{Z: 1, X: 1, Y: 0}.Bounds()
+	if west != 0 || east != 180 || south != 0 || math.Abs(north-85.0511287798) > 1e-6 {
+		t.Fatalf("unexpected bounds %f %f %f %f", west, south, east, north)
+	}
+}
+
+func TestMapTileVersionETag(t *testing.T) {
+	tile := MapTile{Z: 15, X: 5245, Y: 11443}
+	userID := uuid.New()
+	version := MapTileVersion{Content: "3.100:1.50", Viewer: "2.10"}
+
+	base := version.ETag(tile, MapTileViewer{UserID: userID, StoryFlags: []string{"met_the_king", "Found_Key"}})
+	reordered := version.ETag(tile, MapTileViewer{UserID: userID, StoryFlags: []string{" found_key", "met_the_king", "met_the_king"}})
+	if base != reordered {
+		t.Fatalf("expected story flag order and case not to change the etag")
+	}
+
+	changes := []string{
+		version.ETag(MapTile{Z: 15, X: 5245, Y: 11444}, MapTileViewer{UserID: userID, StoryFlags: []string{"met_the_king", "found_key"}}),
+		MapTileVersion{Content: "3.101:1.50", Viewer: "2.10"}.ETag(tile, MapTileViewer{UserID: userID, StoryFlags: []string{"met_the_king", "found_key"}}),
+		MapTileVersion{Content: "3.100:1.50", Viewer: "3.11"}.ETag(tile, MapTileViewer{UserID: userID, StoryFlags: []string{"met_the_king", "found_key"}}),
+		version.ETag(tile, MapTileViewer{UserID: userID, StoryFlags: []string{"met_the_king"}}),
+		version.ETag(tile, MapTileViewer{UserID: uuid.New(), StoryFlags: []string{"met_the_king", "found_key"}}),
+	}
+	for i, etag := range changes {
+		if etag == base {
+			t.Fatalf("change %d did not alter the etag", i)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type MarketListingStatus string
+
+const (
+	MarketListingStatusActive    MarketListingStatus = "active"
+	MarketListingStatusSold      MarketListingStatus = "sold"
+	MarketListingStatusCancelled MarketListingStatus = "cancelled"
+	MarketListingStatusExpired   MarketListingStatus = "expired"
+)
+
+// MarketListing is an item a player has put up for sale in a zone's
+// marketplace. The listed quantity is held in escrow: it leaves the seller's
+// inventory when the listing is created and is either handed to the buyer or
+// returned to the seller when the listing closes.
+type MarketListing struct {
+	ID              uuid.UUID           `json:"id" gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	CreatedAt       time.Time           `json:"createdAt"`
+	UpdatedAt       time.Time           `json:"updatedAt"`
+	ZoneID          uuid.UUID           `json:"zoneId" gorm:"column:zone_id"`
+	SellerUserID    uuid.UUID           `json:"sellerUserId" gorm:"column:seller_user_id"`
+	BuyerUserID     *uuid.UUID          `json:"buyerUserId,omitempty" gorm:"column:buyer_user_id"`
+	InventoryItemID int                 `json:"inventoryItemId" gorm:"column:inventory_item_id"`
+	Quantity        int                 `json:"quantity" gorm:"column:quantity"`
+	Price           int                 `json:"price" gorm:"column:price"`
+	ListingFee      int                 `json:"listingFee" gorm:"column:listing_fee"`
+	Status          MarketListingStatus `json:"status" gorm:"column:status"`
+	ExpiresAt       time.Time           `json:"expiresAt" gorm:"column:expires_at"`
+	SoldAt          *time.Time          `json:"soldAt,omitempty" gorm:"column:sold_at"`
+	ClosedAt        *time.Time          `json:"closedAt,omitempty" gorm:"column:closed_at"`
+
+	Seller        User           `json:"seller,omitempty" gorm:"foreignKey:SellerUserID"`
+	InventoryItem *InventoryItem `json:"inventoryItem,omitempty" gorm:"foreignKey:InventoryItemID"`
+}
+
+func (m *MarketListing) TableName() string {
+	return "market_listings"
+}
+
+func (m *MarketListing) IsPurchasable(now time.Time) bool {
+	return m.Status == MarketListingStatusActive && now.Before(m.ExpiresAt)
+}
+
+// MarketListingFilter narrows a zone marketplace search. Zero values mean
+// "no constraint" for every field except ZoneID.
+type MarketListingFilter struct {
+	ZoneID        uuid.UUID
+	Tags          []string
+	RarityTiers   []string
+	MinItemLevel  int
+	MaxItemLevel  int
+	ExcludeSeller *uuid.UUID
+	Limit         int
+	Offset        int
+}
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"image"
+	"math/bits"
+)
+
+// DuplicateImageHashDistance is the largest Hamming distance between two
+// difference hashes that still counts as the same picture.
+const DuplicateImageHashDistance = 6
+
+// DifferenceHash computes a 64-bit dHash: the image is reduced to a 9x8
+// grayscale grid and each bit records whether a cell is brighter than its
+// right neighbour. Resizing, recompression and small color shifts leave
+// the hash nearly unchanged, which is what duplicate detection needs.
+func DifferenceHash(img image.Image) int64 {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width == 0 || height == 0 {
+		return 0
+	}
+
+	var grid [8][9]float64
+	for row := 0; row < 8; row++ {
+		for col := 0; col < 9; col++ {
+			x0 := bounds.Min.X + col*width/9
+			x1 := bounds.Min.X + (col+1)*width/9
+			y0 := bounds.Min.Y + row*height/8
+			y1 := bounds.Min.Y + (row+1)*height/8
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+			if y1 <= y0 {
+				y1 = y0 + 1
+			}
+			grid[row][col] = averageLuminance(img, x0, y0, x1, y1)
+		}
+	}
+
+	var hash uint64
+	for row := 0; row < 8; row++ {
+		for col := 0; col < 8; col++ {
+			hash <<= 1
+			if grid[row][col] > grid[row][col+1] {
+				hash |= 1
+			}
+		}
+	}
+	return int64(hash)
+}
+
+func averageLuminance(img image.Image, x0, y0, x1, y1 int) float64 {
+	// Sample at most 4x4 points per cell; large generated images would
+	// otherwise cost a full pixel walk for a 64-bit answer.
+	stepX := max(1, (x1-x0)/4)
+	stepY := max(1, (y1-y0)/4)
+	total, count := 0.0, 0
+	for y := y0; y < y1; y += stepY {
+		for x := x0; x < x1; x += stepX {
+			r, g, b, a := img.At(x, y).RGBA()
+			if a == 0 {
+				// Treat transparency as white so icons on transparent
+				// backgrounds hash by their subject.
+				total += 0xffff
+			} else {
+				total += 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)
+			}
+			count++
+		}
+	}
+	if count == 0 {
+		return 0
+	}
+	return total / float64(count)
+}
+
+func ImageHashDistance(a int64, b int64) int {
+	return bits.OnesCount64(uint64(a) ^ uint64(b))
+}
@@ -41,6 +41,8 @@ type PointOfInterest struct {
 	SpellRewards               []PointOfInterestSpellReward  `json:"spellRewards" gorm:"foreignKey:PointOfInterestID"`
 	GoogleMapsPlaceID          *string                       `json:"googleMapsPlaceId"`
 	GoogleMapsPlaceName        *string                       `json:"googleMapsPlaceName"`
+	Source                     PointOfInterestSource         `json:"source" gorm:"column:source;default:manual"`
+	OSMID                      *string                       `json:"osmId,omitempty" gorm:"column:osm_id"`
 	MarkerCategory             PointOfInterestMarkerCategory `json:"markerCategory" gorm:"column:marker_category"`
 	MarkerCategoryDerived      bool                          `json:"-" gorm:"-"`
 	LastUsedInQuestAt          *time.Time                    `json:"lastUsedInQuestAt,omitempty"`
@@ -49,6 +51,16 @@ type PointOfInterest struct {
 	HasAvailableMainStoryQuest bool                          `json:"hasAvailableMainStoryQuest" gorm:"-"`
 }
 
+// PointOfInterestSource records where a point of interest was seeded from so
+// Google and OpenStreetMap imports of the same place can be reconciled.
+type PointOfInterestSource string
+
+const (
+	PointOfInterestSourceManual PointOfInterestSource = "manual"
+	PointOfInterestSourceGoogle PointOfInterestSource = "google"
+	PointOfInterestSourceOSM    PointOfInterestSource = "osm"
+)
+
 func (p *PointOfInterest) TableName() string {
 	return "points_of_interest"
 }
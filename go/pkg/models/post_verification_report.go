@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+type PostVerificationStatus string
+
+const (
+	PostVerificationVerified  PostVerificationStatus = "verified"
+	PostVerificationUntrusted PostVerificationStatus = "untrusted"
+	PostVerificationInvalid   PostVerificationStatus = "invalid"
+	PostVerificationUnsigned  PostVerificationStatus = "unsigned"
+	PostVerificationLegacy    PostVerificationStatus = "legacy"
+)
+
+// PostVerificationReport records how a post's C2PA manifest verified when
+// it was posted. Report holds the full per-manifest breakdown.
+type PostVerificationReport struct {
+	PostID    uuid.UUID              `gorm:"type:uuid;primaryKey" json:"postId"`
+	CreatedAt time.Time              `gorm:"not null" json:"createdAt"`
+	UpdatedAt time.Time              `gorm:"not null" json:"updatedAt"`
+	Status    PostVerificationStatus `gorm:"type:text;not null" json:"status"`
+	Report    datatypes.JSON         `gorm:"type:jsonb;not null" json:"report"`
+}
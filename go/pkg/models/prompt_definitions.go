@@ -0,0 +1,1015 @@
+package models
+
+const (
+	PromptKeyExpositionTemplateGeneration        PromptKey = "exposition_template_generation"
+	PromptKeyChallengeTemplateGeneration         PromptKey = "challenge_template_generation"
+	PromptKeyShrineTemplateGeneration            PromptKey = "shrine_template_generation"
+	PromptKeyOpenEndedScenarioTemplateGeneration PromptKey = "open_ended_scenario_template_generation"
+	PromptKeyChoiceScenarioTemplateGeneration    PromptKey = "choice_scenario_template_generation"
+	PromptKeyContentTranslation                  PromptKey = "content_translation"
+	PromptKeyOpenEndedScenarioGeneration         PromptKey = "open_ended_scenario_generation"
+	PromptKeyChoiceScenarioGeneration            PromptKey = "choice_scenario_generation"
+	PromptKeySpellFromBrief                      PromptKey = "spell_from_brief"
+	PromptKeyTechniqueFromBrief                  PromptKey = "technique_from_brief"
+	PromptKeyInventoryItemSuggestion             PromptKey = "inventory_item_suggestion"
+	PromptKeyZoneFlavorGeneration                PromptKey = "zone_flavor_generation"
+	PromptKeyZoneKindDirection                   PromptKey = "zone_kind_direction"
+	PromptKeyScenarioGenreDirection              PromptKey = "scenario_genre_direction"
+	PromptKeyScenarioGenreImageDirection         PromptKey = "scenario_genre_image_direction"
+	PromptKeySpellGenreDirection                 PromptKey = "spell_genre_direction"
+	PromptKeySpellGenreIcon                      PromptKey = "spell_genre_icon"
+	PromptKeyMonsterGenreVisualDirection         PromptKey = "monster_genre_visual_direction"
+	PromptKeyZoneNameGuidance                    PromptKey = "zone_name_guidance"
+	PromptKeyPointOfInterestTheming              PromptKey = "point_of_interest_theming"
+	PromptKeyGenrePointOfInterestTheming         PromptKey = "genre_point_of_interest_theming"
+	PromptKeyPointOfInterestImagePrompt          PromptKey = "point_of_interest_image_prompt"
+	PromptKeyGenrePointOfInterestImagePrompt     PromptKey = "genre_point_of_interest_image_prompt"
+	PromptKeyPointOfInterestZoneKindDirection    PromptKey = "point_of_interest_zone_kind_direction"
+	PromptKeyQuestCopy                           PromptKey = "quest_copy"
+	PromptKeyQuestImagePrompt                    PromptKey = "quest_image_prompt"
+	PromptKeyQuestAcceptanceDialogue             PromptKey = "quest_acceptance_dialogue"
+)
+
+// The default bodies are the prompts the generation jobs shipped with. The
+// zone kind and genre instruction blocks are registered as prompts of their
+// own, which the jobs prepend, so a stored version of a task prompt only
+// needs to cover the task itself. Variables are passed already formatted,
+// coordinates included.
+var promptDefinitions = map[PromptKey]PromptDefinition{
+	PromptKeyExpositionTemplateGeneration: {
+		Key:         PromptKeyExpositionTemplateGeneration,
+		JobType:     "generate_exposition_templates",
+		Description: "Batch of reusable exposition templates for a zone kind.",
+		Variables:   []string{"Count", "RecentTemplates"},
+		DefaultBody: defaultExpositionTemplateGenerationPrompt,
+	},
+	PromptKeyChallengeTemplateGeneration: {
+		Key:         PromptKeyChallengeTemplateGeneration,
+		JobType:     "generate_challenge_templates",
+		Description: "Batch of reusable challenge templates for a location archetype.",
+		Variables: []string{
+			"Count",
+			"LocationArchetypeName",
+			"IncludedPlaceTypes",
+			"ExcludedPlaceTypes",
+			"ChallengeExamples",
+			"RecentTemplates",
+		},
+		DefaultBody: defaultChallengeTemplateGenerationPrompt,
+	},
+	PromptKeyShrineTemplateGeneration: {
+		Key:         PromptKeyShrineTemplateGeneration,
+		JobType:     "generate_shrine_templates",
+		Description: "Batch of reusable shrine templates for a zone kind.",
+		Variables:   []string{"Count", "RecentTemplates"},
+		DefaultBody: defaultShrineTemplateGenerationPrompt,
+	},
+	PromptKeyOpenEndedScenarioTemplateGeneration: {
+		Key:         PromptKeyOpenEndedScenarioTemplateGeneration,
+		JobType:     "generate_scenario_templates",
+		Description: "Batch of open-ended scenario templates.",
+		Variables:   []string{"Count", "RecentTemplates", "AllowedItems"},
+		DefaultBody: defaultOpenEndedScenarioTemplateGenerationPrompt,
+	},
+	PromptKeyChoiceScenarioTemplateGeneration: {
+		Key:         PromptKeyChoiceScenarioTemplateGeneration,
+		JobType:     "generate_scenario_templates",
+		Description: "Batch of three-option choice scenario templates.",
+		Variables:   []string{"Count", "RecentTemplates", "AllowedItems"},
+		DefaultBody: defaultChoiceScenarioTemplateGenerationPrompt,
+	},
+	PromptKeyContentTranslation: {
+		Key:         PromptKeyContentTranslation,
+		JobType:     "translate_content",
+		Description: "Translation of one entity's player-facing text into a locale.",
+		Variables:   []string{"Locale", "LocaleName", "Glossary", "Fields"},
+		DefaultBody: defaultContentTranslationPrompt,
+	},
+	PromptKeyOpenEndedScenarioGeneration: {
+		Key:         PromptKeyOpenEndedScenarioGeneration,
+		JobType:     "generate_scenario",
+		Description: "One open-ended scenario for a zone and location.",
+		Variables: []string{
+			"ZoneName",
+			"ZoneDescription",
+			"Latitude",
+			"Longitude",
+			"VarianceDirectives",
+			"RecentScenarios",
+		},
+		DefaultBody: defaultOpenEndedScenarioGenerationPrompt,
+	},
+	PromptKeyChoiceScenarioGeneration: {
+		Key:         PromptKeyChoiceScenarioGeneration,
+		JobType:     "generate_scenario",
+		Description: "One three-option choice scenario for a zone and location.",
+		Variables: []string{
+			"ZoneName",
+			"ZoneDescription",
+			"Latitude",
+			"Longitude",
+			"VarianceDirectives",
+			"RecentScenarios",
+		},
+		DefaultBody: defaultChoiceScenarioGenerationPrompt,
+	},
+	PromptKeySpellFromBrief: {
+		Key:         PromptKeySpellFromBrief,
+		JobType:     "generate_spell_progression_from_prompt",
+		Description: "Seed spell for a progression, from a creator brief.",
+		Variables:   []string{"Genre", "GenreDirection", "Brief"},
+		DefaultBody: defaultSpellFromBriefPrompt,
+	},
+	PromptKeyTechniqueFromBrief: {
+		Key:         PromptKeyTechniqueFromBrief,
+		JobType:     "generate_spell_progression_from_prompt",
+		Description: "Seed combat technique for a progression, from a creator brief.",
+		Variables:   []string{"Genre", "GenreDirection", "Brief"},
+		DefaultBody: defaultTechniqueFromBriefPrompt,
+	},
+	PromptKeyInventoryItemSuggestion: {
+		Key:         PromptKeyInventoryItemSuggestion,
+		JobType:     "generate_inventory_item_suggestions",
+		Description: "Batch of draft inventory items for an admin suggestion job.",
+		Variables: []string{
+			"GenreDirection",
+			"ZoneKindDirection",
+			"Count",
+			"ThemePrompt",
+			"Categories",
+			"RarityTiers",
+			"EquipSlots",
+			"StatTags",
+			"BenefitTags",
+			"StatusNames",
+			"InternalTags",
+			"MinItemLevel",
+			"MaxItemLevel",
+			"ExistingItems",
+		},
+		DefaultBody: defaultInventoryItemSuggestionPrompt,
+	},
+	PromptKeyZoneFlavorGeneration: {
+		Key:         PromptKeyZoneFlavorGeneration,
+		JobType:     "generate_zone_flavor",
+		Description: "Name and description for a zone from its geometry.",
+		Variables:   []string{"ZoneName", "ZoneDescription", "Geometry", "NamingGuidance"},
+		DefaultBody: defaultZoneFlavorGenerationPrompt,
+	},
+	PromptKeyZoneKindDirection: {
+		Key:         PromptKeyZoneKindDirection,
+		JobType:     "generate_scenario_templates",
+		Description: "Zone kind block prepended to template, shrine and item generation prompts.",
+		Variables:   []string{"ZoneKind", "ZoneKindSlug", "CreativeSeed", "ZoneKindLower"},
+		DefaultBody: defaultZoneKindDirectionPrompt,
+	},
+	PromptKeyScenarioGenreDirection: {
+		Key:         PromptKeyScenarioGenreDirection,
+		JobType:     "generate_scenario",
+		Description: "Genre block prepended to scenario prompts outside baseline fantasy.",
+		Variables:   []string{"Genre", "CreativeSeed"},
+		DefaultBody: defaultScenarioGenreDirectionPrompt,
+	},
+	PromptKeyScenarioGenreImageDirection: {
+		Key:         PromptKeyScenarioGenreImageDirection,
+		JobType:     "generate_scenario_image",
+		Description: "Genre direction appended to scenario image prompts outside baseline fantasy.",
+		Variables:   []string{"Genre", "CreativeSeed"},
+		DefaultBody: defaultScenarioGenreImageDirectionPrompt,
+	},
+	PromptKeySpellGenreDirection: {
+		Key:         PromptKeySpellGenreDirection,
+		JobType:     "generate_spell_progression_from_prompt",
+		Description: "Genre block added to spell and technique prompts outside baseline fantasy.",
+		Variables:   []string{"Genre", "CreativeSeed", "Abilities"},
+		DefaultBody: defaultSpellGenreDirectionPrompt,
+	},
+	PromptKeySpellGenreIcon: {
+		Key:         PromptKeySpellGenreIcon,
+		JobType:     "generate_spell_icon",
+		Description: "Spell and technique icon image prompt outside baseline fantasy.",
+		Variables: []string{
+			"AbilityType",
+			"Name",
+			"School",
+			"Description",
+			"EffectText",
+			"CreativeSeed",
+			"Genre",
+		},
+		DefaultBody: defaultSpellGenreIconPrompt,
+	},
+	PromptKeyMonsterGenreVisualDirection: {
+		Key:         PromptKeyMonsterGenreVisualDirection,
+		JobType:     "generate_monster_image",
+		Description: "Genre direction for monster and monster template images outside baseline fantasy.",
+		Variables:   []string{"Genre", "CreativeSeed"},
+		DefaultBody: defaultMonsterGenreVisualDirectionPrompt,
+	},
+	PromptKeyZoneNameGuidance: {
+		Key:         PromptKeyZoneNameGuidance,
+		JobType:     "generate_zone_flavor",
+		Description: "Naming guidance that steers new zone names away from existing ones.",
+		Variables:   []string{"ExistingZoneNames", "OverusedRoots"},
+		DefaultBody: defaultZoneNameGuidancePrompt,
+	},
+	PromptKeyPointOfInterestTheming: {
+		Key:         PromptKeyPointOfInterestTheming,
+		JobType:     "import_point_of_interest",
+		Description: "Fantasy name, description and clue for a real-world place.",
+		Variables: []string{
+			"PlaceName",
+			"EditorialSummary",
+			"Categories",
+			"Sophistication",
+			"ZoneName",
+			"ZoneDescription",
+			"ZoneKindDirection",
+		},
+		DefaultBody: defaultPointOfInterestThemingPrompt,
+	},
+	PromptKeyGenrePointOfInterestTheming: {
+		Key:         PromptKeyGenrePointOfInterestTheming,
+		JobType:     "import_point_of_interest",
+		Description: "Name, description and clue for a real-world place outside baseline fantasy.",
+		Variables: []string{
+			"PlaceName",
+			"EditorialSummary",
+			"Categories",
+			"Sophistication",
+			"ZoneName",
+			"ZoneDescription",
+			"ZoneKindDirection",
+			"Genre",
+			"CreativeSeed",
+		},
+		DefaultBody: defaultGenrePointOfInterestThemingPrompt,
+	},
+	PromptKeyPointOfInterestImagePrompt: {
+		Key:         PromptKeyPointOfInterestImagePrompt,
+		JobType:     "import_point_of_interest",
+		Description: "Image prompt for a real-world place in fantasy.",
+		Variables: []string{
+			"PlaceName",
+			"EditorialSummary",
+			"Categories",
+			"Sophistication",
+			"ZoneName",
+			"ZoneDescription",
+			"ZoneKindDirection",
+		},
+		DefaultBody: defaultPointOfInterestImagePromptPrompt,
+	},
+	PromptKeyGenrePointOfInterestImagePrompt: {
+		Key:         PromptKeyGenrePointOfInterestImagePrompt,
+		JobType:     "import_point_of_interest",
+		Description: "Image prompt for a real-world place outside baseline fantasy.",
+		Variables: []string{
+			"PlaceName",
+			"EditorialSummary",
+			"Categories",
+			"Sophistication",
+			"ZoneName",
+			"ZoneDescription",
+			"ZoneKindDirection",
+			"Genre",
+			"CreativeSeed",
+		},
+		DefaultBody: defaultGenrePointOfInterestImagePromptPrompt,
+	},
+	PromptKeyPointOfInterestZoneKindDirection: {
+		Key:         PromptKeyPointOfInterestZoneKindDirection,
+		JobType:     "import_point_of_interest",
+		Description: "Zone kind block added to point of interest prompts.",
+		Variables:   []string{"ZoneKind", "ZoneKindSlug", "CreativeSeed", "ZoneKindLower"},
+		DefaultBody: defaultPointOfInterestZoneKindDirectionPrompt,
+	},
+	PromptKeyQuestCopy: {
+		Key:         PromptKeyQuestCopy,
+		JobType:     "generate_quest_for_zone",
+		Description: "Quest name and description from its tasks.",
+		Variables:   []string{"Tasks"},
+		DefaultBody: defaultQuestCopyPrompt,
+	},
+	PromptKeyQuestImagePrompt: {
+		Key:         PromptKeyQuestImagePrompt,
+		JobType:     "generate_quest_for_zone",
+		Description: "Description of a quest's hero image.",
+		Variables:   []string{"Quest"},
+		DefaultBody: defaultQuestImagePromptPrompt,
+	},
+	PromptKeyQuestAcceptanceDialogue: {
+		Key:         PromptKeyQuestAcceptanceDialogue,
+		JobType:     "generate_quest_for_zone",
+		Description: "Lines the quest giver says before the player accepts.",
+		Variables:   []string{"QuestName", "QuestDescription", "QuestGiver", "Rewards", "Tasks"},
+		DefaultBody: defaultQuestAcceptanceDialoguePrompt,
+	},
+}
+
+const defaultContentTranslationPrompt = `
+You are localizing text for a fantasy location-based RPG.
+
+Translate every value in the JSON object below from English into {{.LocaleName}} ({{.Locale}}).
+
+Glossary (always use these renderings, matching case and inflection as the language requires):
+{{.Glossary}}
+
+Text to translate:
+{{.Fields}}
+
+Return JSON only, with exactly the same keys:
+{
+  "field key": "translated text"
+}
+
+Rules:
+- Keep the tone, tense and register of the original; this is game fiction, not a manual.
+- Keep names of people and places unless the glossary gives a rendering.
+- Keep numbers and any markup exactly as written.
+- Do not add, drop or merge keys, and do not explain the translation.
+`
+
+const defaultExpositionTemplateGenerationPrompt = `
+You are designing {{.Count}} reusable fantasy MMORPG exposition templates for map encounters.
+
+Recent exposition templates to avoid echoing:
+{{.RecentTemplates}}
+
+Return JSON only:
+{
+  "templates": [
+    {
+      "title": "2-5 word evocative title",
+      "description": "2-4 vivid sentences of setup and atmosphere",
+      "dialogue": [
+        "3-6 short lines of in-world dialogue"
+      ],
+      "rewardMode": "random or explicit",
+      "randomRewardSize": "small or medium or large",
+      "rewardExperience": 0-70,
+      "rewardGold": 0-70
+    }
+  ]
+}
+
+Hard rules:
+- Output exactly {{.Count}} templates.
+- These are reusable templates, not tied to a specific business, landmark, district, or coordinates.
+- Each exposition should feel like a brief discoverable encounter, omen, witness account, magical residue, local warning, or atmospheric vignette.
+- Let the requested zone kind strongly influence imagery, props, hazards, folklore, traversal, and mood.
+- Dialogue should work without a specific named NPC. It can sound like a traveler, spirit, ranger, sentry, survivor, scavenger, or ambient supernatural voice.
+- Keep dialogue lines short, punchy, and easy to present one after another.
+- Keep each template materially distinct from the others and from the recent templates list.
+- If rewardMode is "random", set rewardExperience and rewardGold to 0.
+- If rewardMode is "explicit", keep rewardExperience and rewardGold modest but meaningful.
+`
+
+const defaultChallengeTemplateGenerationPrompt = `
+You are designing {{.Count}} reusable fantasy MMORPG challenge templates for a location archetype.
+
+Location archetype:
+- name: {{.LocationArchetypeName}}
+- included place types: {{.IncludedPlaceTypes}}
+- excluded place types: {{.ExcludedPlaceTypes}}
+- existing built-in archetype challenge examples: {{.ChallengeExamples}}
+
+Recent challenge templates for this archetype to avoid echoing:
+{{.RecentTemplates}}
+
+Return JSON only:
+{
+  "challenges": [
+    {
+      "question": "One short sentence (6-18 words) stating exactly what the player must do",
+      "description": "40-140 words of scene flavor and context that supports the action",
+      "submissionType": "photo or text",
+      "difficulty": 0-40,
+      "reward": 0-100,
+      "statTags": ["0-3 of: strength,dexterity,constitution,intelligence,wisdom,charisma"],
+      "proficiency": "optional short phrase or null"
+    }
+  ]
+}
+
+Hard rules:
+- Output exactly {{.Count}} challenges.
+- These are reusable templates, not tied to any specific business name, coordinates, or one-off landmark.
+- Each challenge should clearly fit the archetype and feel fun to do in a public-space fantasy MMO tone.
+- Every challenge must be a concrete real-world task the player can actually complete on site right now.
+- The result must be gradable from the player's photo or text submission alone.
+- Never rely on fictional missing items, hidden clues, asking strangers, interviewing locals, NPC cooperation, or facts that may not exist at the real place.
+- If the idea is really about how the player would solve a problem, investigate, negotiate, persuade, or respond to a roleplaying situation, that belongs in a scenario template, not a challenge template.
+- Write challenge questions as direct action prompts, not mystery questions.
+- Keep each challenge materially distinct from the others and from the recent templates list.
+- Use only submissionType values: "photo" or "text".
+`
+
+const defaultShrineTemplateGenerationPrompt = `
+You are designing {{.Count}} reusable fantasy MMORPG shrine templates.
+
+Recent shrine templates to avoid echoing:
+{{.RecentTemplates}}
+
+Return JSON only:
+{
+  "templates": [
+    {
+      "name": "Shrine of Vigor",
+      "blessingName": "Blessing of Vigor",
+      "description": "2-4 vivid sentences describing the shrine itself",
+      "effectDescription": "One sentence explaining the boon the player receives",
+      "effectKind": "strength|dexterity|constitution|intelligence|wisdom|charisma|health_regen|mana_regen|physical_damage|arcane_damage|holy_damage|shadow_damage|fire_resistance|ice_resistance|lightning_resistance|poison_resistance|physical_resistance|warding",
+      "baseMagnitude": 1-4
+    }
+  ]
+}
+
+Hard rules:
+- Output exactly {{.Count}} templates.
+- Every shrine is beneficial, mystical, and reusable across many locations.
+- The template name must clearly imply the effect.
+- The blessingName must sound like a status the player receives after invoking the shrine.
+- description should describe the shrine itself, not a one-off event.
+- effectDescription must describe the benefit in player-facing language.
+- baseMagnitude should stay conservative because the blessing scales with player level.
+- Keep the templates materially distinct from one another and from the recent shrine list.
+`
+
+const defaultOpenEndedScenarioTemplateGenerationPrompt = `
+You are designing {{.Count}} reusable fantasy MMORPG scenario templates.
+
+Recent scenario templates to avoid echoing:
+{{.RecentTemplates}}
+
+Return JSON only:
+{
+  "templates": [
+    {
+      "zoneKind": "forest",
+      "prompt": "2-4 vivid sentences",
+      "difficulty": 0-40,
+      "rewardExperience": 0-120,
+      "rewardGold": 0-120,
+      "itemRewards": [
+        { "inventoryItemId": <id from allowed list>, "quantity": 1-3 }
+      ]
+    }
+  ]
+}
+
+Rules:
+- Output exactly {{.Count}} templates.
+- zoneKind must be one of the allowed slugs exactly as written.
+- Choose the single best-fit zone kind for where each template would most naturally belong.
+- These are generic templates, not tied to any specific zone, city, landmark, or coordinates.
+- Each prompt should describe a clear fantasy conflict or opportunity that could fit many places.
+- Keep the scenarios materially distinct from one another and from the recent templates list.
+- itemRewards can be empty.
+- Use only inventoryItemId values from this allowed list:
+{{.AllowedItems}}
+`
+
+const defaultChoiceScenarioTemplateGenerationPrompt = `
+You are designing {{.Count}} reusable fantasy MMORPG scenario templates.
+
+Recent scenario templates to avoid echoing:
+{{.RecentTemplates}}
+
+Return JSON only:
+{
+  "templates": [
+    {
+      "zoneKind": "forest",
+      "prompt": "2-4 vivid sentences",
+      "difficulty": 0-40,
+      "options": [
+        {
+          "optionText": "player action text",
+          "successText": "one or two sentences",
+          "failureText": "one or two sentences",
+          "statTag": "strength|dexterity|constitution|intelligence|wisdom|charisma",
+          "proficiencies": ["0-3 short proficiencies"],
+          "difficulty": null or 0-40,
+          "rewardExperience": 0-80,
+          "rewardGold": 0-80,
+          "itemRewards": [
+            { "inventoryItemId": <id from allowed list>, "quantity": 1-2 }
+          ]
+        }
+      ]
+    }
+  ]
+}
+
+Rules:
+- Output exactly {{.Count}} templates.
+- zoneKind must be one of the allowed slugs exactly as written.
+- Choose the single best-fit zone kind for where each template would most naturally belong.
+- These are generic templates, not tied to any specific zone, city, landmark, or coordinates.
+- Each template prompt should describe a reusable fantasy situation with 3 distinct player options.
+- Keep templates materially distinct from one another and from the recent templates list.
+- Each template must have exactly 3 options.
+- itemRewards can be empty.
+- Use only inventoryItemId values from this allowed list:
+{{.AllowedItems}}
+`
+
+const defaultOpenEndedScenarioGenerationPrompt = `
+You are designing one fantasy RPG map scenario for a location-based game.
+
+Zone:
+- name: {{.ZoneName}}
+- description: {{.ZoneDescription}}
+
+Location context:
+- latitude: {{.Latitude}}
+- longitude: {{.Longitude}}
+
+Variance directives (treat these as hard constraints for diversity):
+{{.VarianceDirectives}}
+
+Recent scenarios in this zone to avoid echoing:
+{{.RecentScenarios}}
+
+Create an OPEN-ENDED scenario (free-text response from player).
+
+Return JSON only:
+{
+  "zoneKind": "forest",
+  "prompt": "2-4 vivid sentences",
+  "difficulty": 0-40,
+  "rewardExperience": 0-120,
+  "rewardGold": 0-120
+}
+
+Rules:
+- zoneKind must be one of the allowed slugs exactly as written.
+- Choose the single best-fit zone kind for this generated scenario.
+- Prompt must be specific to this zone and location, with a clear conflict/opportunity.
+- The scenario must feel materially different from the recent scenarios listed above.
+- Keep tone adventurous and grounded in physical surroundings.
+`
+
+const defaultChoiceScenarioGenerationPrompt = `
+You are designing one fantasy RPG map scenario for a location-based game.
+
+Zone:
+- name: {{.ZoneName}}
+- description: {{.ZoneDescription}}
+
+Location context:
+- latitude: {{.Latitude}}
+- longitude: {{.Longitude}}
+
+Variance directives (treat these as hard constraints for diversity):
+{{.VarianceDirectives}}
+
+Recent scenarios in this zone to avoid echoing:
+{{.RecentScenarios}}
+
+Create a CHOICE-BASED scenario with 3 options.
+
+Return JSON only:
+{
+  "zoneKind": "forest",
+  "prompt": "2-4 vivid sentences",
+  "difficulty": 0-40,
+  "options": [
+    {
+      "optionText": "player action text",
+      "successText": "one or two sentences",
+      "failureText": "one or two sentences",
+      "statTag": "strength|dexterity|constitution|intelligence|wisdom|charisma",
+      "proficiencies": ["0-3 short proficiencies"],
+      "difficulty": null or 0-40,
+      "rewardExperience": 0-80,
+      "rewardGold": 0-80
+    }
+  ]
+}
+
+Rules:
+- zoneKind must be one of the allowed slugs exactly as written.
+- Choose the single best-fit zone kind for this generated scenario.
+- Prompt must be specific to this zone and location, with a clear conflict/opportunity.
+- The scenario must feel materially different from the recent scenarios listed above.
+- options must contain exactly 3 entries and each option should feel distinct.
+- proficiencies should be practical, short labels.
+`
+
+const defaultSpellFromBriefPrompt = `
+You are designing ONE {{.Genre}} RPG spell concept from a creator brief.
+{{.GenreDirection}}
+
+Creator brief:
+{{.Brief}}
+
+Return JSON only:
+{
+  "spell": {
+    "name": "2-4 words",
+    "description": "one vivid sentence, 8-18 words",
+    "effectText": "one concise sentence",
+    "schoolOfMagic": "short label",
+    "manaCost": 0-60,
+    "preferredEffectType": "deal_damage|deal_damage_all_enemies|restore_life_party_member|restore_life_all_party_members|apply_beneficial_statuses|remove_detrimental_statuses"
+  }
+}
+
+Rules:
+- Keep tone adventurous and original.
+- Make the description a single punchy one-liner, not a paragraph.
+- Keep it safe and suitable for a public game.
+- No copyrighted franchises or references.
+`
+
+const defaultTechniqueFromBriefPrompt = `
+You are designing ONE {{.Genre}} RPG combat technique concept from a creator brief.
+{{.GenreDirection}}
+
+Creator brief:
+{{.Brief}}
+
+Return JSON only:
+{
+  "spell": {
+    "name": "2-4 words",
+    "description": "one vivid sentence, 8-18 words",
+    "effectText": "one concise sentence",
+    "schoolOfMagic": "short label, usually Martial",
+    "manaCost": 0,
+    "preferredEffectType": "deal_damage|deal_damage_all_enemies|restore_life_party_member|restore_life_all_party_members|apply_beneficial_statuses|remove_detrimental_statuses"
+  }
+}
+
+Rules:
+- This is a technique, not a spell.
+- Keep it practical and grounded in physical execution.
+- manaCost must be 0.
+- Make the description a single punchy one-liner, not a paragraph.
+- Keep it safe and suitable for a public game.
+- No copyrighted franchises or references.
+`
+
+const defaultInventoryItemSuggestionPrompt = `
+You are designing draft inventory items for StreetSekai, an urban fantasy MMORPG.
+{{.GenreDirection}}
+{{.ZoneKindDirection}}
+
+Generate exactly {{.Count}} item drafts.
+
+Requested direction:
+- theme prompt: {{.ThemePrompt}}
+- categories to bias toward: {{.Categories}}
+- rarity tiers to bias toward: {{.RarityTiers}}
+- equip slots to bias toward: {{.EquipSlots}}
+- stat tags to support when relevant: {{.StatTags}}
+- benefit tags to support when relevant: {{.BenefitTags}}
+- statuses to apply when relevant: {{.StatusNames}}
+- internal tags to bias toward: {{.InternalTags}}
+- item level band: {{.MinItemLevel}} to {{.MaxItemLevel}}
+
+Existing inventory items to avoid echoing:
+{{.ExistingItems}}
+
+Return JSON only:
+{
+  "drafts": [
+    {
+      "category": "equippable|consumable|material|utility",
+      "whyItFits": "1-2 short sentences",
+      "warnings": ["optional warning"],
+      "item": {
+        "name": "string",
+        "flavorText": "1-3 sentences of evocative item description",
+        "effectText": "one short sentence describing the gameplay effect",
+        "zoneKind": "one allowed zone kind slug",
+        "rarityTier": "Common|Uncommon|Epic|Mythic|Not Droppable",
+        "itemLevel": 1,
+        "buyPrice": 10,
+        "unlockTier": 1,
+        "equipSlot": "hat|necklace|chest|legs|shoes|gloves|ring|dominant_hand|off_hand or empty",
+        "strengthMod": 0,
+        "dexterityMod": 0,
+        "constitutionMod": 0,
+        "intelligenceMod": 0,
+        "wisdomMod": 0,
+        "charismaMod": 0,
+        "physicalDamageBonusPercent": 0,
+        "piercingDamageBonusPercent": 0,
+        "slashingDamageBonusPercent": 0,
+        "bludgeoningDamageBonusPercent": 0,
+        "fireDamageBonusPercent": 0,
+        "iceDamageBonusPercent": 0,
+        "lightningDamageBonusPercent": 0,
+        "poisonDamageBonusPercent": 0,
+        "arcaneDamageBonusPercent": 0,
+        "holyDamageBonusPercent": 0,
+        "shadowDamageBonusPercent": 0,
+        "physicalResistancePercent": 0,
+        "piercingResistancePercent": 0,
+        "slashingResistancePercent": 0,
+        "bludgeoningResistancePercent": 0,
+        "fireResistancePercent": 0,
+        "iceResistancePercent": 0,
+        "lightningResistancePercent": 0,
+        "poisonResistancePercent": 0,
+        "arcaneResistancePercent": 0,
+        "holyResistancePercent": 0,
+        "shadowResistancePercent": 0,
+        "handItemCategory": "weapon|shield|orb|staff or empty",
+        "handedness": "one_handed|two_handed or empty",
+        "damageMin": 0,
+        "damageMax": 0,
+        "damageAffinity": "physical|piercing|slashing|bludgeoning|fire|ice|lightning|poison|arcane|holy|shadow or empty",
+        "swipesPerAttack": 0,
+        "blockPercentage": 0,
+        "damageBlocked": 0,
+        "spellDamageBonusPercent": 0,
+        "consumeHealthDelta": 0,
+        "consumeManaDelta": 0,
+        "consumeRevivePartyMemberHealth": 0,
+        "consumeReviveAllDownedPartyMembersHealth": 0,
+        "consumeDealDamage": 0,
+        "consumeDealDamageHits": 0,
+        "consumeDealDamageAllEnemies": 0,
+        "consumeDealDamageAllEnemiesHits": 0,
+        "consumeCreateBase": false,
+        "consumeStatusesToAdd": [],
+        "consumeStatusesToRemove": [],
+        "consumeSpellIds": [],
+        "consumeTeachRecipeIds": [],
+        "alchemyRecipes": [],
+        "workshopRecipes": [],
+        "internalTags": ["snake_case_tag"]
+      }
+    }
+  ]
+}
+
+Rules:
+- Output exactly {{.Count}} drafts.
+- Output JSON only. No markdown.
+- Keep the tone urban fantasy, tactile, and gameable.
+- Drafts should feel materially distinct from one another.
+- Each draft item must include zoneKind.
+- Bias toward items that support recognizable builds, professions, exploration, or social play.
+- If stat tags are requested, strongly prefer items whose actual gameplay bonuses clearly support those stats.
+- If benefit tags are requested, strongly prefer items whose gameplay effects clearly match those requested benefits.
+- If status names are requested, strongly prefer consumables or utility items that actually apply those statuses.
+- Do not invent recipe links, taught recipe IDs, or spell IDs in this generator version. Leave consumeSpellIds, consumeTeachRecipeIds, alchemyRecipes, and workshopRecipes empty.
+- Do not set unlockLocksStrength in generated drafts. Leave lock-unlocking behavior empty unless it is added manually later.
+- Equippable drafts should have coherent stats for their slot and fantasy.
+- Consumables should create clear, concrete gameplay effects.
+- Materials should still feel desirable and specific, not generic vendor trash.
+- Utility items should unlock a play pattern, traversal trick, social angle, or base-related use.
+- If equipSlot is dominant_hand or off_hand, use only valid hand item combinations:
+  - dominant_hand: weapon or staff
+  - off_hand: shield or orb
+  - staff must be two_handed
+  - off_hand items must be one_handed
+- Keep effectText to one line.
+- Use lowercase snake_case for internalTags.
+`
+
+const defaultZoneFlavorGenerationPrompt = `
+You are writing zone naming and flavor text for a fantasy MMORPG-style location-based game.
+
+Zone:
+- current name: {{.ZoneName}}
+- current description: {{.ZoneDescription}}
+
+Geometry context:
+{{.Geometry}}
+
+World naming context:
+{{.NamingGuidance}}
+
+Return JSON only:
+{
+  "name": "1-3 words",
+  "description": "2-4 vivid sentences, about 55-110 words"
+}
+
+Rules:
+- Generate a zone name that fits the description and sounds like an explorable MMO region, district, ward, harbor, pass, or quarter.
+- The name and description must clearly belong to the same fictional place.
+- Write in the same adventurous, mystical voice as a fantasy MMO map or quest journal.
+- Base the fiction on the shape, scale, and positioning cues implied by the coordinates.
+- Treat the real-world geometry as inspiration for in-world terrain, districts, routes, choke points, shorelines, courtyards, or edges.
+- Avoid repeated fantasy crutches and do not reuse an overrepresented opening word from the world naming context.
+- Do not mention GPS, coordinates, polygons, latitude, longitude, OpenStreetMap, apps, or modern map tooling.
+- Do not mention brands, real businesses, or modern infrastructure by name.
+- Keep it useful as a zone description players might read in the UI.
+- Avoid second-person instructions and avoid explicit quest hooks; this is setting flavor, not a task prompt.
+`
+
+const defaultSpellGenreIconPrompt = `A retro 16-bit RPG {{.AbilityType}} icon for {{.Name}}. School: {{.School}}. {{.Description}}. {{.EffectText}}. Genre direction: {{.CreativeSeed}}. Make the icon unmistakably {{.Genre}} rather than default fantasy. No characters, no text, no logos, transparent background, centered composition, crisp outlines, limited palette.`
+
+const defaultPointOfInterestPremise = `
+	You are a video game designer tasked with converting real-world locations into points of interest on a fantasy RPG map.
+
+	Describe how {{.PlaceName}} would appear if it was in a fantasy role playing video game.
+
+	An editorial summary of {{.PlaceName}} is: {{.EditorialSummary}}.
+
+	Some categories that people use to describe {{.PlaceName}} are: {{.Categories}}.
+
+	The sophistication of {{.PlaceName}} is considered to be {{.Sophistication}}.
+
+	Here is a bit about the region {{.PlaceName}} is in:
+
+	Name: {{.ZoneName}}
+	Description: {{.ZoneDescription}}
+
+	{{.ZoneKindDirection}}
+
+	Do not use the location's name in your response.
+`
+
+const defaultGenrePointOfInterestPremise = `
+	You are a video game designer tasked with converting real-world locations into points of interest on a {{.Genre}} RPG map.
+
+	Describe how {{.PlaceName}} would appear if it was in a {{.Genre}} role playing video game.
+
+	An editorial summary of {{.PlaceName}} is: {{.EditorialSummary}}.
+
+	Some categories that people use to describe {{.PlaceName}} are: {{.Categories}}.
+
+	The sophistication of {{.PlaceName}} is considered to be {{.Sophistication}}.
+
+	Here is a bit about the region {{.PlaceName}} is in:
+
+	Name: {{.ZoneName}}
+	Description: {{.ZoneDescription}}
+
+	{{.ZoneKindDirection}}
+
+	Genre direction:
+	- genre: {{.Genre}}
+	- creative seed: {{.CreativeSeed}}
+
+	Additional rules:
+	- Keep the response unmistakably rooted in {{.Genre}} conventions rather than default fantasy.
+	- Use genre-appropriate naming, lore, props, factions, and atmosphere.
+	- Do not use the location's name in your response.
+`
+
+const defaultPointOfInterestThemingPrompt = defaultPointOfInterestPremise + `
+	Please try to keep the description to 50 words or less.
+
+	Please format your response as a JSON object with the following fields:
+	
+	{
+		"name": "string", // The fantasy name of the point of interest
+		"description": "string", // A description of the appearance of the fantasy point of interest and a bit of made up lore about it
+		"clue": "string", // A clue that can be used to find the point of interest in the real world
+	}
+`
+
+const defaultGenrePointOfInterestThemingPrompt = defaultGenrePointOfInterestPremise + `
+	Please try to keep the description to 50 words or less.
+
+	Please format your response as a JSON object with the following fields:
+	
+	{
+		"name": "string", // The genre-appropriate name of the point of interest
+		"description": "string", // A description of the appearance of the point of interest and a bit of made up lore about it
+		"clue": "string", // A clue that can be used to find the point of interest in the real world
+	}
+`
+
+const defaultPointOfInterestImagePromptPrompt = defaultPointOfInterestPremise + `
+	Please describe how the location would look from the outside if it was in a fantasy role playing video game.
+
+	The image should match the aesthetic of retro 16-bit RPG pixel art item and character images:
+	- Crisp outlines, limited color palette, clean background
+	- Centered subject, readable silhouette
+	- No text, no logos, no UI
+	- Exterior view, 3/4 angle or slight isometric perspective
+	- Keep the prompt focused on a single iconic exterior scene
+
+	Please format your response as a JSON object with the following fields:
+	
+	{
+		"text": "string", // A single concise image prompt in the above style
+	}
+`
+
+const defaultGenrePointOfInterestImagePromptPrompt = defaultGenrePointOfInterestPremise + `
+	Please describe how the location would look from the outside if it was in a {{.Genre}} role playing video game.
+
+	The image should match the aesthetic of retro 16-bit RPG pixel art item and character images:
+	- Crisp outlines, limited color palette, clean background
+	- Centered subject, readable silhouette
+	- No text, no logos, no UI
+	- Exterior view, 3/4 angle or slight isometric perspective
+	- Keep the prompt focused on a single iconic exterior scene
+
+	Please format your response as a JSON object with the following fields:
+	
+	{
+		"text": "string", // A single concise image prompt in the above style
+	}
+`
+
+const defaultQuestCopyPrompt = `
+	You are a video game designer tasked with converting real-world tasks and chores into quests for a fantasy RPG.
+
+	The tasks and chores for the quest you are designing are as follows:
+
+	{{.Tasks}}
+
+	Please come up with copy for the quest that incorporates the task and chores into a cohesive quest that might appear in a fantasy role playing game. Try to keep the description to 50 words or less. Feel free to make up names or proper nouns.
+
+	Please format your response as a JSON object with the following fields:
+	{
+		"name": "string", // The name of the overarching quest that includes all of the above tasks and chores
+		"description": "string", // A description of why the chores need to be done in the made-up fantasy world and a bit of lore about the quest
+	}
+`
+
+const defaultQuestImagePromptPrompt = `
+	You are a video game designer tasked with creating visual assets for quests in a fantasy role playing game.
+
+	The quest is this:
+
+	{{.Quest}}
+
+	Please describe what an iconic moment from this quest would look like to an outside observer.
+
+	Please format your response as a JSON object with the following fields:
+	{
+		"description": "string", // A description of what the hero image for the quest would look like
+	}
+`
+
+const defaultQuestAcceptanceDialoguePrompt = `
+	You are a video game writer tasked with scripting the dialogue a quest giver delivers to a player before they accept a quest.
+
+	Quest name: {{.QuestName}}
+	Quest description: {{.QuestDescription}}
+	Quest giver: {{.QuestGiver}}
+	Rewards: {{.Rewards}}
+
+	The tasks and chores for the quest are:
+
+	{{.Tasks}}
+
+	Write 3 to 6 short lines of dialogue. Keep each line under 18 words, in a warm fantasy RPG tone. Speak directly to the player (use "you"). Mention the rewards if provided.
+
+	Please format your response as a JSON object with the following fields:
+	{
+		"acceptanceDialogue": ["string"] // The dialogue lines the player sees before accepting the quest
+	}
+`
+
+const defaultZoneKindDirectionPrompt = `Zone kind direction:
+- zone kind: {{.ZoneKind}}
+- slug: {{.ZoneKindSlug}}
+- creative seed: {{.CreativeSeed}}
+
+Additional rules:
+- The content should feel naturally suited to {{.ZoneKindLower}} zones while remaining reusable across many places of that kind.
+- Let the environment influence props, hazards, traversal, factions, and scene logic.
+`
+
+const defaultScenarioGenreDirectionPrompt = `Genre direction:
+- genre: {{.Genre}}
+- creative seed: {{.CreativeSeed}}
+
+Additional rules:
+- The scenario must feel authentically {{.Genre}} rather than generic fantasy.
+- Use genre-appropriate stakes, props, factions, and environmental logic.
+`
+
+const defaultScenarioGenreImageDirectionPrompt = `Render the scene using unmistakable {{.Genre}} visual language. {{.CreativeSeed}}`
+
+const defaultSpellGenreDirectionPrompt = `Genre direction:
+- genre: {{.Genre}}
+- creative seed: {{.CreativeSeed}}
+
+Additional rules:
+- Override the default fantasy RPG baseline when needed; the generated {{.Abilities}} should feel authentically {{.Genre}}.
+- Favor names, schools, motifs, effects, silhouettes, and combat framing that fit {{.Genre}} conventions.
+- Keep the results playable inside the current RPG mechanics even when the fiction shifts genres.
+`
+
+// An empty CreativeSeed falls back to asking for the genre's own visual
+// language.
+const defaultMonsterGenreVisualDirectionPrompt = `Aggressive {{.Genre}} creature{{if .CreativeSeed}}. Genre direction: {{.CreativeSeed}}{{else}} with genre-authentic visual language{{end}}`
+
+// ExistingZoneNames and OverusedRoots are comma-separated and empty when
+// there are none.
+const defaultZoneNameGuidancePrompt = `{{if .ExistingZoneNames}}- Existing zone names: {{.ExistingZoneNames}}{{else}}- Existing zone names: none yet{{end}}
+{{if .OverusedRoots}}- Avoid starting the new name with these overused opening roots or variants: {{.OverusedRoots}}{{else}}- No repeated opening word is currently dominant, but still avoid cliché repeated prefixes.{{end}}`
+
+const defaultPointOfInterestZoneKindDirectionPrompt = `Zone kind direction:
+	- zone kind: {{.ZoneKind}}
+	- slug: {{.ZoneKindSlug}}
+	- creative seed: {{.CreativeSeed}}
+
+	Additional zone-kind rules:
+	- Make the location feel natively at home in {{.ZoneKindLower}} spaces.
+	- Let the fantasy name, lore, architecture, props, and image cues reflect that zone kind while still honoring the real-world location.`
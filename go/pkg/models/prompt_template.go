@@ -0,0 +1,240 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+type PromptKey string
+
+// PromptDefinition describes a prompt a generation job asks the registry
+// for. DefaultBody is what the job uses until an admin activates a stored
+// version, and is the natural starting point for a first version.
+type PromptDefinition struct {
+	Key         PromptKey `json:"key"`
+	JobType     string    `json:"jobType"`
+	Description string    `json:"description"`
+	Variables   []string  `json:"variables"`
+	DefaultBody string    `json:"defaultBody"`
+}
+
+func PromptDefinitions() []PromptDefinition {
+	definitions := make([]PromptDefinition, 0, len(promptDefinitions))
+	for _, definition := range promptDefinitions {
+		definitions = append(definitions, definition)
+	}
+	sort.Slice(definitions, func(i, j int) bool { return definitions[i].Key < definitions[j].Key })
+	return definitions
+}
+
+func FindPromptDefinition(key PromptKey) (PromptDefinition, bool) {
+	definition, ok := promptDefinitions[key]
+	return definition, ok
+}
+
+// SampleVariables fills every declared variable with a placeholder so a
+// candidate body can be test-rendered before it is saved.
+func (d PromptDefinition) SampleVariables() map[string]interface{} {
+	variables := make(map[string]interface{}, len(d.Variables))
+	for _, name := range d.Variables {
+		variables[name] = fmt.Sprintf("<%s>", name)
+	}
+	return variables
+}
+
+// RenderPrompt executes a prompt body as a text/template. Referencing a
+// variable the caller did not supply is an error rather than "<no value>".
+func RenderPrompt(body string, variables map[string]interface{}) (string, error) {
+	parsed, err := template.New("prompt").Option("missingkey=error").Parse(body)
+	if err != nil {
+		return "", fmt.Errorf("invalid prompt template: %w", err)
+	}
+	var rendered strings.Builder
+	if err := parsed.Execute(&rendered, variables); err != nil {
+		return "", fmt.Errorf("failed to render prompt: %w", err)
+	}
+	return rendered.String(), nil
+}
+
+// PromptTemplate is one stored version of a registered prompt. A version can
+// be scoped to a genre, a zone kind or both; the job uses the most specific
+// active version that matches what it is generating for.
+type PromptTemplate struct {
+	ID              uuid.UUID  `json:"id" gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	UpdatedAt       time.Time  `json:"updatedAt"`
+	Key             PromptKey  `json:"key" gorm:"column:prompt_key"`
+	GenreID         *uuid.UUID `json:"genreId,omitempty" gorm:"column:genre_id;type:uuid"`
+	ZoneKind        string     `json:"zoneKind,omitempty" gorm:"column:zone_kind"`
+	Version         int        `json:"version"`
+	Body            string     `json:"body"`
+	Notes           string     `json:"notes,omitempty"`
+	Active          bool       `json:"active"`
+	CreatedByUserID *uuid.UUID `json:"createdByUserId,omitempty" gorm:"column:created_by_user_id;type:uuid"`
+}
+
+func (PromptTemplate) TableName() string {
+	return "prompt_templates"
+}
+
+// Specificity ranks how narrowly a version is scoped: genre and zone kind
+// beats genre alone, which beats zone kind alone, which beats unscoped.
+func (p PromptTemplate) Specificity() int {
+	specificity := 0
+	if p.GenreID != nil && *p.GenreID != uuid.Nil {
+		specificity += 2
+	}
+	if strings.TrimSpace(p.ZoneKind) != "" {
+		specificity++
+	}
+	return specificity
+}
+
+func (p PromptTemplate) Matches(genreID *uuid.UUID, zoneKind string) bool {
+	if p.GenreID != nil && *p.GenreID != uuid.Nil {
+		if genreID == nil || *genreID != *p.GenreID {
+			return false
+		}
+	}
+	if scoped := NormalizeZoneKind(p.ZoneKind); scoped != "" && scoped != NormalizeZoneKind(zoneKind) {
+		return false
+	}
+	return true
+}
+
+// SelectPromptTemplate picks the most specific active candidate that matches
+// the genre and zone kind, breaking ties toward the newest version. It
+// returns nil when the built-in default should be used.
+func SelectPromptTemplate(candidates []PromptTemplate, genreID *uuid.UUID, zoneKind string) *PromptTemplate {
+	var selected *PromptTemplate
+	for i := range candidates {
+		candidate := &candidates[i]
+		if !candidate.Active || !candidate.Matches(genreID, zoneKind) {
+			continue
+		}
+		if selected == nil ||
+			candidate.Specificity() > selected.Specificity() ||
+			(candidate.Specificity() == selected.Specificity() && candidate.Version > selected.Version) {
+			selected = candidate
+		}
+	}
+	return selected
+}
+
+// GeneratedRecordPrompt records which prompt produced a generated record.
+// A nil PromptTemplateID means the job used the built-in default body.
+type GeneratedRecordPrompt struct {
+	ID               uuid.UUID  `json:"id" gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	CreatedAt        time.Time  `json:"createdAt"`
+	RecordType       string     `json:"recordType" gorm:"column:record_type"`
+	RecordID         uuid.UUID  `json:"recordId" gorm:"column:record_id;type:uuid"`
+	PromptKey        PromptKey  `json:"promptKey" gorm:"column:prompt_key"`
+	PromptTemplateID *uuid.UUID `json:"promptTemplateId,omitempty" gorm:"column:prompt_template_id;type:uuid"`
+	PromptVersion    int        `json:"promptVersion" gorm:"column:prompt_version"`
+}
+
+func (GeneratedRecordPrompt) TableName() string {
+	return "generated_record_prompts"
+}
+
+const (
+	GeneratedRecordTypeScenarioTemplate      = "scenario_template"
+	GeneratedRecordTypeScenarioTemplateDraft = "scenario_template_draft"
+	GeneratedRecordTypeChallengeTemplate     = "challenge_template"
+	GeneratedRecordTypeExpositionTemplate    = "exposition_template"
+	GeneratedRecordTypeShrineTemplate        = "shrine_template"
+	GeneratedRecordTypeScenario              = "scenario"
+	GeneratedRecordTypeSpell                 = "spell"
+	GeneratedRecordTypeMonster               = "monster"
+	GeneratedRecordTypeMonsterTemplate       = "monster_template"
+	GeneratedRecordTypeInventoryItemDraft    = "inventory_item_suggestion_draft"
+	GeneratedRecordTypeZone                  = "zone"
+	GeneratedRecordTypePointOfInterest       = "point_of_interest"
+)
+
+type PromptEvaluationStatus string
+
+const (
+	PromptEvaluationStatusQueued     PromptEvaluationStatus = "queued"
+	PromptEvaluationStatusInProgress PromptEvaluationStatus = "in_progress"
+	PromptEvaluationStatusCompleted  PromptEvaluationStatus = "completed"
+	PromptEvaluationStatusFailed     PromptEvaluationStatus = "failed"
+)
+
+const (
+	PromptEvaluationVariantA = "a"
+	PromptEvaluationVariantB = "b"
+)
+
+// PromptEvaluation runs two versions of one prompt over the same inputs so
+// their outputs can be compared and rated side by side. A nil version ID
+// stands for the built-in default body.
+type PromptEvaluation struct {
+	ID              uuid.UUID                `json:"id" gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	CreatedAt       time.Time                `json:"createdAt"`
+	UpdatedAt       time.Time                `json:"updatedAt"`
+	PromptKey       PromptKey                `json:"promptKey" gorm:"column:prompt_key"`
+	VersionAID      *uuid.UUID               `json:"versionAId,omitempty" gorm:"column:version_a_id;type:uuid"`
+	VersionBID      *uuid.UUID               `json:"versionBId,omitempty" gorm:"column:version_b_id;type:uuid"`
+	Inputs          datatypes.JSON           `json:"inputs" gorm:"type:jsonb"`
+	Status          PromptEvaluationStatus   `json:"status"`
+	ErrorMessage    *string                  `json:"errorMessage,omitempty" gorm:"column:error_message"`
+	CreatedByUserID *uuid.UUID               `json:"createdByUserId,omitempty" gorm:"column:created_by_user_id;type:uuid"`
+	Outputs         []PromptEvaluationOutput `json:"outputs,omitempty" gorm:"foreignKey:EvaluationID"`
+}
+
+func (PromptEvaluation) TableName() string {
+	return "prompt_evaluations"
+}
+
+// PromptEvaluationInput is one fixed input both versions are run against.
+// ZoneKind lets the evaluation prepend the same zone kind block the job would.
+type PromptEvaluationInput struct {
+	ZoneKind  string                 `json:"zoneKind,omitempty"`
+	Variables map[string]interface{} `json:"variables"`
+}
+
+func (e PromptEvaluation) DecodeInputs() ([]PromptEvaluationInput, error) {
+	var inputs []PromptEvaluationInput
+	if len(e.Inputs) == 0 {
+		return inputs, nil
+	}
+	if err := json.Unmarshal(e.Inputs, &inputs); err != nil {
+		return nil, fmt.Errorf("invalid prompt evaluation inputs: %w", err)
+	}
+	return inputs, nil
+}
+
+func (e PromptEvaluation) VersionID(variant string) *uuid.UUID {
+	if variant == PromptEvaluationVariantB {
+		return e.VersionBID
+	}
+	return e.VersionAID
+}
+
+type PromptEvaluationOutput struct {
+	ID               uuid.UUID  `json:"id" gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	CreatedAt        time.Time  `json:"createdAt"`
+	UpdatedAt        time.Time  `json:"updatedAt"`
+	EvaluationID     uuid.UUID  `json:"evaluationId" gorm:"column:evaluation_id;type:uuid"`
+	InputIndex       int        `json:"inputIndex" gorm:"column:input_index"`
+	Variant          string     `json:"variant"`
+	PromptTemplateID *uuid.UUID `json:"promptTemplateId,omitempty" gorm:"column:prompt_template_id;type:uuid"`
+	RenderedPrompt   string     `json:"renderedPrompt" gorm:"column:rendered_prompt"`
+	Output           string     `json:"output"`
+	ErrorMessage     *string    `json:"errorMessage,omitempty" gorm:"column:error_message"`
+	Rating           *int       `json:"rating,omitempty"`
+	RatingComment    string     `json:"ratingComment,omitempty" gorm:"column:rating_comment"`
+	RatedByUserID    *uuid.UUID `json:"ratedByUserId,omitempty" gorm:"column:rated_by_user_id;type:uuid"`
+}
+
+func (PromptEvaluationOutput) TableName() string {
+	return "prompt_evaluation_outputs"
+}
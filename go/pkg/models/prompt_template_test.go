@@ -0,0 +1,66 @@
+package models
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestRenderPromptSubstitutesVariables(t *testing.T) {
+	rendered, err := RenderPrompt("Design {{.Count}} shrines.\nAvoid:\n{{.RecentTemplates}}", map[string]interface{}{
+		"Count":           3,
+		"RecentTemplates": "- Shrine of Dawn",
+	})
+	if err != nil {
+		t.Fatalf("RenderPrompt returned error: %v", err)
+	}
+	if rendered != "Design 3 shrines.\nAvoid:\n- Shrine of Dawn" {
+		t.Fatalf("unexpected rendered prompt: %q", rendered)
+	}
+	if _, err := RenderPrompt("Design {{.Count}} shrines for {{.ZoneKind}}.", map[string]interface{}{"Count": 3}); err == nil {
+		t.Fatal("expected a missing variable to be rejected")
+	}
+	if _, err := RenderPrompt("Design {{.Count shrines.", nil); err == nil {
+		t.Fatal("expected a malformed template to be rejected")
+	}
+}
+
+func TestDefaultPromptBodiesRenderWithDeclaredVariables(t *testing.T) {
+	for _, definition := range PromptDefinitions() {
+		rendered, err := RenderPrompt(definition.DefaultBody, definition.SampleVariables())
+		if err != nil {
+			t.Fatalf("default body for %s failed to render: %v", definition.Key, err)
+		}
+		for _, variable := range definition.Variables {
+			if !strings.Contains(rendered, "<"+variable+">") {
+				t.Fatalf("expected default body for %s to use %s", definition.Key, variable)
+			}
+		}
+	}
+}
+
+func TestSelectPromptTemplatePrefersMostSpecificMatch(t *testing.T) {
+	genreID := uuid.New()
+	otherGenreID := uuid.New()
+	candidates := []PromptTemplate{
+		{Version: 1, Active: true},
+		{Version: 4, Active: false, ZoneKind: "forest"},
+		{Version: 2, Active: true, ZoneKind: "forest"},
+		{Version: 3, Active: true, GenreID: &otherGenreID},
+		{Version: 5, Active: true, GenreID: &genreID},
+	}
+
+	if selected := SelectPromptTemplate(candidates, nil, "Forest"); selected == nil || selected.Version != 2 {
+		t.Fatalf("expected the forest version, got %+v", selected)
+	}
+	if selected := SelectPromptTemplate(candidates, &genreID, "forest"); selected == nil || selected.Version != 5 {
+		t.Fatalf("expected the genre version to outrank the zone kind version, got %+v", selected)
+	}
+	if selected := SelectPromptTemplate(candidates, nil, "desert"); selected == nil || selected.Version != 1 {
+		t.Fatalf("expected the unscoped version, got %+v", selected)
+	}
+	if selected := SelectPromptTemplate(candidates[1:2], nil, "forest"); selected != nil {
+		t.Fatalf("expected inactive versions to be ignored, got %+v", selected)
+	}
+}
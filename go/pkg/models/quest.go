@@ -93,6 +93,8 @@ type Quest struct {
 	RewardExperience               int                        `json:"rewardExperience" gorm:"column:reward_experience"`
 	Gold                           int                        `json:"gold"`
 	MaterialRewards                BaseMaterialRewards        `json:"materialRewards" gorm:"column:material_rewards_json;type:jsonb;default:'[]'"`
+	EstimatedWalkingMeters         int                        `json:"estimatedWalkingMeters" gorm:"column:estimated_walking_meters;default:0"`
+	EstimatedWalkingMinutes        int                        `json:"estimatedWalkingMinutes" gorm:"column:estimated_walking_minutes;default:0"`
 	ItemRewards                    []QuestItemReward          `json:"itemRewards" gorm:"foreignKey:QuestID"`
 	SpellRewards                   []QuestSpellReward         `json:"spellRewards" gorm:"foreignKey:QuestID"`
 	Nodes                          []QuestNode                `json:"nodes" gorm:"foreignKey:QuestID"`
@@ -35,6 +35,8 @@ type QuestArchetype struct {
 	RewardExperience               int                         `json:"rewardExperience" gorm:"column:reward_experience"`
 	RecurrenceFrequency            *string                     `json:"recurrenceFrequency,omitempty"`
 	MaterialRewards                BaseMaterialRewards         `json:"materialRewards" gorm:"column:material_rewards_json;type:jsonb;default:'[]'"`
+	MaxWalkingDistanceMeters       int                         `json:"maxWalkingDistanceMeters" gorm:"column:max_walking_distance_meters;default:0"`
+	MaxWalkingMinutes              int                         `json:"maxWalkingMinutes" gorm:"column:max_walking_minutes;default:0"`
 	CharacterTags                  StringArray                 `json:"characterTags" gorm:"column:character_tags;type:jsonb"`
 	InternalTags                   StringArray                 `json:"internalTags" gorm:"column:internal_tags;type:jsonb"`
 	CreatedAt                      time.Time                   `json:"createdAt"`
@@ -44,4 +46,5 @@ type QuestArchetype struct {
 	RootID                         uuid.UUID                   `json:"rootId"`
 	ItemRewards                    []QuestArchetypeItemReward  `json:"itemRewards" gorm:"foreignKey:QuestArchetypeID"`
 	SpellRewards                   []QuestArchetypeSpellReward `json:"spellRewards" gorm:"foreignKey:QuestArchetypeID"`
+	ValidationIssues               []ContentValidationIssue    `json:"validationIssues,omitempty" gorm:"-"`
 }
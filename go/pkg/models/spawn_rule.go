@@ -0,0 +1,254 @@
+package models
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type SpawnRuleTargetType string
+
+const (
+	SpawnRuleTargetMonsterTemplate SpawnRuleTargetType = "monster_template"
+	SpawnRuleTargetMonsterAffinity SpawnRuleTargetType = "monster_affinity"
+	SpawnRuleTargetScenarioKind    SpawnRuleTargetType = "scenario_kind"
+)
+
+func NormalizeSpawnRuleTargetType(raw string) (SpawnRuleTargetType, bool) {
+	switch SpawnRuleTargetType(strings.ToLower(strings.TrimSpace(raw))) {
+	case SpawnRuleTargetMonsterTemplate:
+		return SpawnRuleTargetMonsterTemplate, true
+	case SpawnRuleTargetMonsterAffinity:
+		return SpawnRuleTargetMonsterAffinity, true
+	case SpawnRuleTargetScenarioKind:
+		return SpawnRuleTargetScenarioKind, true
+	default:
+		return "", false
+	}
+}
+
+type MoonPhase string
+
+const (
+	MoonPhaseNew            MoonPhase = "new"
+	MoonPhaseWaxingCrescent MoonPhase = "waxing_crescent"
+	MoonPhaseFirstQuarter   MoonPhase = "first_quarter"
+	MoonPhaseWaxingGibbous  MoonPhase = "waxing_gibbous"
+	MoonPhaseFull           MoonPhase = "full"
+	MoonPhaseWaningGibbous  MoonPhase = "waning_gibbous"
+	MoonPhaseLastQuarter    MoonPhase = "last_quarter"
+	MoonPhaseWaningCrescent MoonPhase = "waning_crescent"
+)
+
+// MoonPhases is ordered through the lunar cycle, starting at new moon.
+var MoonPhases = []MoonPhase{
+	MoonPhaseNew,
+	MoonPhaseWaxingCrescent,
+	MoonPhaseFirstQuarter,
+	MoonPhaseWaxingGibbous,
+	MoonPhaseFull,
+	MoonPhaseWaningGibbous,
+	MoonPhaseLastQuarter,
+	MoonPhaseWaningCrescent,
+}
+
+const synodicMonthDays = 29.530588853
+
+// referenceNewMoon is the new moon of 6 January 2000, 18:14 UTC.
+var referenceNewMoon = time.Date(2000, time.January, 6, 18, 14, 0, 0, time.UTC)
+
+// MoonPhaseAt approximates the moon phase from the mean synodic month. It is
+// accurate to within about a day, which is plenty for spawn weighting and
+// needs no ephemeris lookups.
+func MoonPhaseAt(at time.Time) MoonPhase {
+	days := at.UTC().Sub(referenceNewMoon).Hours() / 24
+	age := math.Mod(days, synodicMonthDays)
+	if age < 0 {
+		age += synodicMonthDays
+	}
+	// Each phase spans an eighth of the cycle, centered on its nominal age.
+	segment := synodicMonthDays / float64(len(MoonPhases))
+	index := int(math.Floor(age/segment+0.5)) % len(MoonPhases)
+	return MoonPhases[index]
+}
+
+// LocalSpawnTime approximates the local solar time at a longitude. Spawn
+// windows care about whether it's dark out, not civil time zones, so a
+// 15-degrees-per-hour offset is close enough and needs no time zone data.
+func LocalSpawnTime(now time.Time, longitude float64) time.Time {
+	offsetHours := int(math.Round(longitude / 15))
+	offsetHours = max(-12, min(14, offsetHours))
+	return now.UTC().In(time.FixedZone("", offsetHours*3600))
+}
+
+// SpawnConditions is the moment and place a spawn is being chosen for.
+type SpawnConditions struct {
+	Now       time.Time
+	LocalTime time.Time
+	MoonPhase MoonPhase
+	Weather   string
+	ZoneKind  string
+	GenreIDs  []uuid.UUID
+}
+
+// SpawnCandidate is something a spawn rule can weight: a monster seed (its
+// template and affinities) or a scenario kind.
+type SpawnCandidate struct {
+	TemplateID   *uuid.UUID
+	Affinities   []string
+	ScenarioKind string
+}
+
+// SpawnRule adjusts how likely a target is to spawn while its window is open.
+// A rule matches zones by ZoneKind and GenreID (empty matches everything) and
+// its window is the intersection of every populated time, calendar and
+// environment filter. Required rules also hide the target outside the
+// window, which is how night-only monsters and festival spawns work.
+type SpawnRule struct {
+	ID               uuid.UUID           `json:"id" gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	CreatedAt        time.Time           `json:"createdAt"`
+	UpdatedAt        time.Time           `json:"updatedAt"`
+	Name             string              `json:"name" gorm:"column:name"`
+	ZoneKind         string              `json:"zoneKind" gorm:"column:zone_kind"`
+	GenreID          *uuid.UUID          `json:"genreId,omitempty" gorm:"column:genre_id"`
+	TargetType       SpawnRuleTargetType `json:"targetType" gorm:"column:target_type"`
+	TargetValue      string              `json:"targetValue" gorm:"column:target_value"`
+	StartHour        *int                `json:"startHour,omitempty" gorm:"column:start_hour"`
+	EndHour          *int                `json:"endHour,omitempty" gorm:"column:end_hour"`
+	DaysOfWeek       StringArray         `json:"daysOfWeek" gorm:"column:days_of_week;type:jsonb"`
+	MoonPhases       StringArray         `json:"moonPhases" gorm:"column:moon_phases;type:jsonb"`
+	Weather          StringArray         `json:"weather" gorm:"column:weather;type:jsonb"`
+	FestivalDates    StringArray         `json:"festivalDates" gorm:"column:festival_dates;type:jsonb"`
+	ActiveFrom       *time.Time          `json:"activeFrom,omitempty" gorm:"column:active_from"`
+	ActiveUntil      *time.Time          `json:"activeUntil,omitempty" gorm:"column:active_until"`
+	WeightMultiplier float64             `json:"weightMultiplier" gorm:"column:weight_multiplier"`
+	Required         bool                `json:"required" gorm:"column:required"`
+	Active           bool                `json:"active" gorm:"column:active"`
+}
+
+func (s *SpawnRule) TableName() string {
+	return "spawn_rules"
+}
+
+func (s *SpawnRule) BeforeSave(tx *gorm.DB) error {
+	s.ZoneKind = NormalizeZoneKind(s.ZoneKind)
+	if s.DaysOfWeek == nil {
+		s.DaysOfWeek = StringArray{}
+	}
+	if s.MoonPhases == nil {
+		s.MoonPhases = StringArray{}
+	}
+	if s.Weather == nil {
+		s.Weather = StringArray{}
+	}
+	if s.FestivalDates == nil {
+		s.FestivalDates = StringArray{}
+	}
+	return nil
+}
+
+// AppliesToZone reports whether the rule is configured for the zone's kind
+// and genres.
+func (s *SpawnRule) AppliesToZone(conditions SpawnConditions) bool {
+	if kind := NormalizeZoneKind(s.ZoneKind); kind != "" && kind != NormalizeZoneKind(conditions.ZoneKind) {
+		return false
+	}
+	if s.GenreID != nil {
+		for _, genreID := range conditions.GenreIDs {
+			if genreID == *s.GenreID {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// WindowOpen reports whether every time and environment filter on the rule
+// matches the conditions.
+func (s *SpawnRule) WindowOpen(conditions SpawnConditions) bool {
+	if s.ActiveFrom != nil && conditions.Now.Before(*s.ActiveFrom) {
+		return false
+	}
+	if s.ActiveUntil != nil && !conditions.Now.Before(*s.ActiveUntil) {
+		return false
+	}
+	local := conditions.LocalTime
+	if !s.hourInWindow(local.Hour()) {
+		return false
+	}
+	if len(s.DaysOfWeek) > 0 &&
+		!containsFoldedString(s.DaysOfWeek, local.Weekday().String()) {
+		return false
+	}
+	if len(s.FestivalDates) > 0 &&
+		!containsFoldedString(s.FestivalDates, local.Format("01-02")) {
+		return false
+	}
+	if len(s.MoonPhases) > 0 &&
+		!containsFoldedString(s.MoonPhases, string(conditions.MoonPhase)) {
+		return false
+	}
+	if len(s.Weather) > 0 &&
+		!containsFoldedString(s.Weather, conditions.Weather) {
+		return false
+	}
+	return true
+}
+
+// hourInWindow checks the local hour against [StartHour, EndHour). Windows
+// that wrap midnight, such as 20 to 5, are supported.
+func (s *SpawnRule) hourInWindow(hour int) bool {
+	if s.StartHour == nil && s.EndHour == nil {
+		return true
+	}
+	start := 0
+	if s.StartHour != nil {
+		start = *s.StartHour
+	}
+	end := 24
+	if s.EndHour != nil {
+		end = *s.EndHour
+	}
+	if start <= end {
+		return hour >= start && hour < end
+	}
+	return hour >= start || hour < end
+}
+
+// Targets reports whether the rule is about the candidate.
+func (s *SpawnRule) Targets(candidate SpawnCandidate) bool {
+	target := strings.TrimSpace(s.TargetValue)
+	switch s.TargetType {
+	case SpawnRuleTargetMonsterTemplate:
+		return candidate.TemplateID != nil && strings.EqualFold(candidate.TemplateID.String(), target)
+	case SpawnRuleTargetMonsterAffinity:
+		return containsFoldedString(candidate.Affinities, target)
+	case SpawnRuleTargetScenarioKind:
+		return candidate.ScenarioKind != "" && strings.EqualFold(candidate.ScenarioKind, target)
+	default:
+		return false
+	}
+}
+
+// SpawnWeight folds every applicable rule into a relative weight for the
+// candidate. Untouched candidates weigh 1; a zero weight means the candidate
+// can't spawn right now.
+func SpawnWeight(rules []SpawnRule, conditions SpawnConditions, candidate SpawnCandidate) float64 {
+	weight := 1.0
+	for i := range rules {
+		rule := &rules[i]
+		if !rule.Active || !rule.Targets(candidate) || !rule.AppliesToZone(conditions) {
+			continue
+		}
+		if rule.WindowOpen(conditions) {
+			weight *= math.Max(0, rule.WeightMultiplier)
+		} else if rule.Required {
+			return 0
+		}
+	}
+	return weight
+}
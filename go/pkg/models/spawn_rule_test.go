@@ -0,0 +1,101 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestMoonPhaseAt(t *testing.T) {
+	if phase := MoonPhaseAt(time.Date(2024, time.January, 11, 12, 0, 0, 0, time.UTC)); phase != MoonPhaseNew {
+		t.Fatalf("expected new moon, got %s", phase)
+	}
+	if phase := MoonPhaseAt(time.Date(2024, time.January, 25, 18, 0, 0, 0, time.UTC)); phase != MoonPhaseFull {
+		t.Fatalf("expected full moon, got %s", phase)
+	}
+	if phase := MoonPhaseAt(time.Date(1999, time.December, 22, 18, 0, 0, 0, time.UTC)); phase != MoonPhaseFull {
+		t.Fatalf("expected full moon before the reference date, got %s", phase)
+	}
+}
+
+func TestLocalSpawnTime(t *testing.T) {
+	now := time.Date(2026, time.March, 12, 12, 0, 0, 0, time.UTC)
+	if hour := LocalSpawnTime(now, -74).Hour(); hour != 7 {
+		t.Fatalf("expected 07:00 near New York, got %d", hour)
+	}
+	if hour := LocalSpawnTime(now, 139.7).Hour(); hour != 21 {
+		t.Fatalf("expected 21:00 near Tokyo, got %d", hour)
+	}
+}
+
+func TestSpawnRuleHourWindowWrapsMidnight(t *testing.T) {
+	start, end := 20, 5
+	rule := &SpawnRule{StartHour: &start, EndHour: &end}
+	for hour, expected := range map[int]bool{22: true, 2: true, 5: false, 12: false, 20: true} {
+		conditions := SpawnConditions{LocalTime: time.Date(2026, time.March, 12, hour, 0, 0, 0, time.UTC)}
+		if rule.WindowOpen(conditions) != expected {
+			t.Fatalf("hour %d: expected open=%v", hour, expected)
+		}
+	}
+}
+
+func TestSpawnWeightNightOnlyMonster(t *testing.T) {
+	templateID := uuid.New()
+	start, end := 21, 4
+	rules := []SpawnRule{{
+		TargetType:       SpawnRuleTargetMonsterTemplate,
+		TargetValue:      templateID.String(),
+		StartHour:        &start,
+		EndHour:          &end,
+		WeightMultiplier: 3,
+		Required:         true,
+		Active:           true,
+	}}
+	candidate := SpawnCandidate{TemplateID: &templateID}
+	night := SpawnConditions{LocalTime: time.Date(2026, time.March, 12, 23, 0, 0, 0, time.UTC)}
+	day := SpawnConditions{LocalTime: time.Date(2026, time.March, 12, 13, 0, 0, 0, time.UTC)}
+
+	if weight := SpawnWeight(rules, night, candidate); weight != 3 {
+		t.Fatalf("expected boosted weight at night, got %v", weight)
+	}
+	if weight := SpawnWeight(rules, day, candidate); weight != 0 {
+		t.Fatalf("expected night-only monster to be hidden by day, got %v", weight)
+	}
+	if weight := SpawnWeight(rules, day, SpawnCandidate{}); weight != 1 {
+		t.Fatalf("expected untargeted candidate to keep weight 1, got %v", weight)
+	}
+}
+
+func TestSpawnWeightFestivalAndZoneFilters(t *testing.T) {
+	genreID := uuid.New()
+	rules := []SpawnRule{{
+		ZoneKind:         "forest",
+		GenreID:          &genreID,
+		TargetType:       SpawnRuleTargetMonsterAffinity,
+		TargetValue:      "shadow",
+		FestivalDates:    StringArray{"10-31"},
+		MoonPhases:       StringArray{string(MoonPhaseFull)},
+		WeightMultiplier: 5,
+		Active:           true,
+	}}
+	candidate := SpawnCandidate{Affinities: []string{"shadow"}}
+	conditions := SpawnConditions{
+		LocalTime: time.Date(2026, time.October, 31, 22, 0, 0, 0, time.UTC),
+		MoonPhase: MoonPhaseFull,
+		ZoneKind:  "forest",
+		GenreIDs:  []uuid.UUID{genreID},
+	}
+	if weight := SpawnWeight(rules, conditions, candidate); weight != 5 {
+		t.Fatalf("expected festival boost, got %v", weight)
+	}
+	conditions.ZoneKind = "city"
+	if weight := SpawnWeight(rules, conditions, candidate); weight != 1 {
+		t.Fatalf("expected rule for another zone kind to be ignored, got %v", weight)
+	}
+	conditions.ZoneKind = "forest"
+	conditions.MoonPhase = MoonPhaseNew
+	if weight := SpawnWeight(rules, conditions, candidate); weight != 1 {
+		t.Fatalf("expected closed window to leave weight alone, got %v", weight)
+	}
+}
@@ -0,0 +1,159 @@
+package models
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+type TemplateRevisionType string
+
+const (
+	TemplateRevisionTypeScenarioTemplate   TemplateRevisionType = "scenario_template"
+	TemplateRevisionTypeChallengeTemplate  TemplateRevisionType = "challenge_template"
+	TemplateRevisionTypeExpositionTemplate TemplateRevisionType = "exposition_template"
+	TemplateRevisionTypeShrineTemplate     TemplateRevisionType = "shrine_template"
+	TemplateRevisionTypeMonsterTemplate    TemplateRevisionType = "monster_template"
+	TemplateRevisionTypeCharacterTemplate  TemplateRevisionType = "character_template"
+	TemplateRevisionTypeQuestArchetype     TemplateRevisionType = "quest_archetype"
+)
+
+var templateRevisionTypes = map[TemplateRevisionType]bool{
+	TemplateRevisionTypeScenarioTemplate:   true,
+	TemplateRevisionTypeChallengeTemplate:  true,
+	TemplateRevisionTypeExpositionTemplate: true,
+	TemplateRevisionTypeShrineTemplate:     true,
+	TemplateRevisionTypeMonsterTemplate:    true,
+	TemplateRevisionTypeCharacterTemplate:  true,
+	TemplateRevisionTypeQuestArchetype:     true,
+}
+
+// ParseTemplateRevisionType accepts the snake_case type names as well as the
+// kebab-case route segments (scenario-templates, quest-archetypes).
+func ParseTemplateRevisionType(raw string) (TemplateRevisionType, bool) {
+	normalized := strings.ReplaceAll(strings.TrimSpace(strings.ToLower(raw)), "-", "_")
+	normalized = strings.TrimSuffix(normalized, "s")
+	templateType := TemplateRevisionType(normalized)
+	return templateType, templateRevisionTypes[templateType]
+}
+
+type TemplateRevisionStatus string
+
+const (
+	// TemplateRevisionStatusDraft revisions are saved for preview but have
+	// not been applied to the live template.
+	TemplateRevisionStatusDraft TemplateRevisionStatus = "draft"
+	// TemplateRevisionStatusPublished revisions were live at some point; the
+	// most recently published one matches the live template.
+	TemplateRevisionStatusPublished TemplateRevisionStatus = "published"
+)
+
+// TemplateRevision is one saved version of an admin-edited template. The
+// snapshot is the template's full JSON, so any published revision can be
+// restored, and the diff lists the top-level fields that changed relative to
+// the template it replaced.
+type TemplateRevision struct {
+	ID                   uuid.UUID              `json:"id" gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	CreatedAt            time.Time              `json:"createdAt"`
+	UpdatedAt            time.Time              `json:"updatedAt"`
+	TemplateType         TemplateRevisionType   `json:"templateType" gorm:"column:template_type"`
+	TemplateID           uuid.UUID              `json:"templateId" gorm:"column:template_id;type:uuid"`
+	Revision             int                    `json:"revision"`
+	Status               TemplateRevisionStatus `json:"status"`
+	Note                 string                 `json:"note,omitempty"`
+	AuthorUserID         *uuid.UUID             `json:"authorUserId,omitempty" gorm:"column:author_user_id;type:uuid"`
+	PublishedByUserID    *uuid.UUID             `json:"publishedByUserId,omitempty" gorm:"column:published_by_user_id;type:uuid"`
+	PublishedAt          *time.Time             `json:"publishedAt,omitempty" gorm:"column:published_at"`
+	RestoredFromRevision *int                   `json:"restoredFromRevision,omitempty" gorm:"column:restored_from_revision"`
+	// BaseRevision is the published revision a draft was edited from. The
+	// draft can only be published while that revision is still the latest.
+	BaseRevision *int           `json:"baseRevision,omitempty" gorm:"column:base_revision"`
+	Snapshot     datatypes.JSON `json:"snapshot" gorm:"type:jsonb"`
+	Diff         datatypes.JSON `json:"diff" gorm:"type:jsonb"`
+}
+
+func (TemplateRevision) TableName() string {
+	return "template_revisions"
+}
+
+type TemplateFieldChange struct {
+	From json.RawMessage `json:"from,omitempty"`
+	To   json.RawMessage `json:"to,omitempty"`
+}
+
+// templateRevisionIgnoredFields change on every save and would otherwise
+// show up in every diff.
+var templateRevisionIgnoredFields = map[string]bool{
+	"createdAt": true,
+	"updatedAt": true,
+	"deletedAt": true,
+}
+
+// DiffTemplateSnapshots compares two template JSON objects field by field.
+// Fields missing on one side are reported with an empty from or to.
+func DiffTemplateSnapshots(before []byte, after []byte) (map[string]TemplateFieldChange, error) {
+	beforeFields, err := templateSnapshotFields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := templateSnapshotFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := map[string]TemplateFieldChange{}
+	for key, from := range beforeFields {
+		if templateRevisionIgnoredFields[key] {
+			continue
+		}
+		to := afterFields[key]
+		equal, err := templateJSONEqual(from, to)
+		if err != nil {
+			return nil, err
+		}
+		if !equal {
+			changes[key] = TemplateFieldChange{From: from, To: to}
+		}
+	}
+	for key, to := range afterFields {
+		if _, ok := beforeFields[key]; ok || templateRevisionIgnoredFields[key] {
+			continue
+		}
+		if equal, err := templateJSONEqual(nil, to); err != nil {
+			return nil, err
+		} else if !equal {
+			changes[key] = TemplateFieldChange{To: to}
+		}
+	}
+	return changes, nil
+}
+
+func templateSnapshotFields(snapshot []byte) (map[string]json.RawMessage, error) {
+	fields := map[string]json.RawMessage{}
+	if len(snapshot) == 0 {
+		return fields, nil
+	}
+	if err := json.Unmarshal(snapshot, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+func templateJSONEqual(a json.RawMessage, b json.RawMessage) (bool, error) {
+	var left, right interface{}
+	if len(a) > 0 {
+		if err := json.Unmarshal(a, &left); err != nil {
+			return false, err
+		}
+	}
+	if len(b) > 0 {
+		if err := json.Unmarshal(b, &right); err != nil {
+			return false, err
+		}
+	}
+	return reflect.DeepEqual(left, right), nil
+}
@@ -0,0 +1,59 @@
+package models
+
+import "testing"
+
+func TestDiffTemplateSnapshotsReportsChangedFields(t *testing.T) {
+	before := []byte(`{"id":"a","name":"Old","baseMagnitude":3,"tags":["x"],"updatedAt":"2024-01-01T00:00:00Z"}`)
+	after := []byte(`{"id":"a","name":"New","baseMagnitude":3,"tags":["x","y"],"updatedAt":"2024-02-01T00:00:00Z","zoneKind":"forest"}`)
+
+	changes, err := DiffTemplateSnapshots(before, after)
+	if err != nil {
+		t.Fatalf("DiffTemplateSnapshots returned error: %v", err)
+	}
+	if len(changes) != 3 {
+		t.Fatalf("expected 3 changed fields, got %d: %v", len(changes), changes)
+	}
+	if string(changes["name"].From) != `"Old"` || string(changes["name"].To) != `"New"` {
+		t.Fatalf("unexpected name change: %+v", changes["name"])
+	}
+	if _, ok := changes["tags"]; !ok {
+		t.Fatalf("expected tags to be reported as changed")
+	}
+	if change, ok := changes["zoneKind"]; !ok || change.From != nil {
+		t.Fatalf("expected zoneKind to be reported as added, got %+v", change)
+	}
+	if _, ok := changes["updatedAt"]; ok {
+		t.Fatalf("expected updatedAt to be ignored")
+	}
+}
+
+func TestDiffTemplateSnapshotsIgnoresKeyOrderAndWhitespace(t *testing.T) {
+	changes, err := DiffTemplateSnapshots(
+		[]byte(`{"options":{"a":1,"b":[1,2]}}`),
+		[]byte(`{ "options" : { "b" : [1, 2], "a" : 1 } }`),
+	)
+	if err != nil {
+		t.Fatalf("DiffTemplateSnapshots returned error: %v", err)
+	}
+	if len(changes) != 0 {
+		t.Fatalf("expected no changes, got %v", changes)
+	}
+}
+
+func TestParseTemplateRevisionType(t *testing.T) {
+	for raw, expected := range map[string]TemplateRevisionType{
+		"scenario_template":  TemplateRevisionTypeScenarioTemplate,
+		"monster-templates":  TemplateRevisionTypeMonsterTemplate,
+		"quest-archetypes":   TemplateRevisionTypeQuestArchetype,
+		" Shrine-Template ":  TemplateRevisionTypeShrineTemplate,
+		"character_template": TemplateRevisionTypeCharacterTemplate,
+	} {
+		templateType, ok := ParseTemplateRevisionType(raw)
+		if !ok || templateType != expected {
+			t.Fatalf("ParseTemplateRevisionType(%q) = %q, %t; want %q", raw, templateType, ok, expected)
+		}
+	}
+	if _, ok := ParseTemplateRevisionType("zones"); ok {
+		t.Fatalf("expected zones to be rejected")
+	}
+}
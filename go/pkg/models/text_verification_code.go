@@ -13,4 +13,7 @@ type TextVerificationCode struct {
 	PhoneNumber string
 	Code        string
 	Used        bool
+	// Attempts counts wrong guesses at this code; the code is burned once
+	// it reaches the authenticator's limit.
+	Attempts int
 }
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type TradeOfferStatus string
+
+const (
+	TradeOfferStatusPending   TradeOfferStatus = "pending"
+	TradeOfferStatusCompleted TradeOfferStatus = "completed"
+	TradeOfferStatusDeclined  TradeOfferStatus = "declined"
+	TradeOfferStatusCancelled TradeOfferStatus = "cancelled"
+	TradeOfferStatusExpired   TradeOfferStatus = "expired"
+)
+
+type TradeOffer struct {
+	ID                   uuid.UUID        `json:"id" gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	CreatedAt            time.Time        `json:"createdAt"`
+	UpdatedAt            time.Time        `json:"updatedAt"`
+	InitiatorUserID      uuid.UUID        `json:"initiatorUserId" gorm:"column:initiator_user_id"`
+	RecipientUserID      uuid.UUID        `json:"recipientUserId" gorm:"column:recipient_user_id"`
+	InitiatorGold        int              `json:"initiatorGold" gorm:"column:initiator_gold"`
+	RecipientGold        int              `json:"recipientGold" gorm:"column:recipient_gold"`
+	Status               TradeOfferStatus `json:"status" gorm:"column:status"`
+	InitiatorConfirmedAt *time.Time       `json:"initiatorConfirmedAt,omitempty" gorm:"column:initiator_confirmed_at"`
+	RecipientConfirmedAt *time.Time       `json:"recipientConfirmedAt,omitempty" gorm:"column:recipient_confirmed_at"`
+	CompletedAt          *time.Time       `json:"completedAt,omitempty" gorm:"column:completed_at"`
+	ExpiresAt            time.Time        `json:"expiresAt" gorm:"column:expires_at"`
+
+	Initiator User             `json:"initiator,omitempty" gorm:"foreignKey:InitiatorUserID"`
+	Recipient User             `json:"recipient,omitempty" gorm:"foreignKey:RecipientUserID"`
+	Items     []TradeOfferItem `json:"items" gorm:"foreignKey:TradeOfferID"`
+}
+
+func (t *TradeOffer) TableName() string {
+	return "trade_offers"
+}
+
+func (t *TradeOffer) IsParticipant(userID uuid.UUID) bool {
+	return t.InitiatorUserID == userID || t.RecipientUserID == userID
+}
+
+// IsOpen reports whether the offer can still be confirmed, declined or
+// cancelled. Pending offers past their expiry are treated as closed even
+// before anything flips their status.
+func (t *TradeOffer) IsOpen(now time.Time) bool {
+	return t.Status == TradeOfferStatusPending && now.Before(t.ExpiresAt)
+}
+
+func (t *TradeOffer) ItemsOwnedBy(userID uuid.UUID) []TradeOfferItem {
+	items := make([]TradeOfferItem, 0, len(t.Items))
+	for _, item := range t.Items {
+		if item.OwnerUserID == userID {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+type TradeOfferItem struct {
+	ID              uuid.UUID `json:"id" gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	CreatedAt       time.Time `json:"createdAt"`
+	UpdatedAt       time.Time `json:"updatedAt"`
+	TradeOfferID    uuid.UUID `json:"tradeOfferId" gorm:"column:trade_offer_id"`
+	OwnerUserID     uuid.UUID `json:"ownerUserId" gorm:"column:owner_user_id"`
+	InventoryItemID int       `json:"inventoryItemId" gorm:"column:inventory_item_id"`
+	Quantity        int       `json:"quantity" gorm:"column:quantity"`
+
+	InventoryItem *InventoryItem `json:"inventoryItem,omitempty" gorm:"foreignKey:InventoryItemID"`
+}
+
+func (t *TradeOfferItem) TableName() string {
+	return "trade_offer_items"
+}
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserTOTP is a user's authenticator-app enrollment. It only counts once
+// ConfirmedAt is set, i.e. the user has typed back a code from the app. The
+// secret is stored encrypted.
+type UserTOTP struct {
+	UserID           uuid.UUID  `json:"userId" gorm:"type:uuid;primaryKey"`
+	CreatedAt        time.Time  `json:"createdAt"`
+	UpdatedAt        time.Time  `json:"updatedAt"`
+	SecretCiphertext string     `json:"-"`
+	ConfirmedAt      *time.Time `json:"confirmedAt"`
+	// LastUsedStep is the 30-second time step of the last accepted code, so
+	// a code cannot be replayed inside its window.
+	LastUsedStep int64 `json:"-"`
+}
+
+func (UserTOTP) TableName() string {
+	return "user_totp"
+}
+
+func (t *UserTOTP) Confirmed() bool {
+	return t != nil && t.ConfirmedAt != nil
+}
+
+// UserRecoveryCode is a single-use stand-in for a TOTP code, stored hashed.
+type UserRecoveryCode struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UserID    uuid.UUID  `json:"userId" gorm:"type:uuid"`
+	CodeHash  string     `json:"-"`
+	UsedAt    *time.Time `json:"usedAt"`
+}
+
+func (UserRecoveryCode) TableName() string {
+	return "user_recovery_codes"
+}
+
+const (
+	// AuthChallengeKindMFA is the half-finished login handed back when the
+	// first factor succeeded and a TOTP code is still owed.
+	AuthChallengeKindMFA                   = "mfa"
+	AuthChallengeKindPasskeyRegistration   = "passkey_registration"
+	AuthChallengeKindPasskeyAuthentication = "passkey_authentication"
+	// AuthChallengeKindLinkEmail is a code emailed to an address the user
+	// wants to add to their account.
+	AuthChallengeKindLinkEmail = "link_email"
+)
+
+// AuthChallenge is a short-lived, single-use value the client must echo
+// back: a WebAuthn challenge, a pending-MFA token or an emailed code. Only
+// its hash is stored.
+type AuthChallenge struct {
+	ID            uuid.UUID  `json:"id" gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	Kind          string     `json:"kind"`
+	ChallengeHash string     `json:"-"`
+	UserID        *uuid.UUID `json:"userId" gorm:"type:uuid"`
+	Attempts      int        `json:"attempts"`
+	ExpiresAt     time.Time  `json:"expiresAt"`
+	ConsumedAt    *time.Time `json:"consumedAt"`
+}
+
+func (AuthChallenge) TableName() string {
+	return "auth_challenges"
+}
+
+// HashChallenge is how AuthChallenge.ChallengeHash is derived.
+func HashChallenge(challenge string) string {
+	sum := sha256.Sum256([]byte(challenge))
+	return hex.EncodeToString(sum[:])
+}
@@ -45,4 +45,9 @@ type User struct {
 	// gets this column linked onto their existing row (matched by email)
 	// rather than a second duplicate account.
 	GoogleID *string `json:"-" gorm:"column:google_id;unique"`
+
+	// Scopes are the authorization scopes of the user's roles, as carried
+	// in their access token — filled in by token verification, never stored
+	// on the row. See HasScopes.
+	Scopes []string `json:"scopes,omitempty" gorm:"-"`
 }
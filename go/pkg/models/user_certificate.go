@@ -6,14 +6,49 @@ import (
 	"github.com/google/uuid"
 )
 
+type CertificateRevocationReason string
+
+const (
+	// CertificateRevocationKeyCompromise covers lost or stolen devices: the
+	// key may have signed things its owner didn't, at any time.
+	CertificateRevocationKeyCompromise CertificateRevocationReason = "key_compromise"
+	// CertificateRevocationSuperseded marks a certificate replaced by a
+	// renewal. What it signed before then stays valid.
+	CertificateRevocationSuperseded           CertificateRevocationReason = "superseded"
+	CertificateRevocationCessationOfOperation CertificateRevocationReason = "cessation_of_operation"
+)
+
+// UserCertificate is one certificate issued to a user. Renewals and
+// re-enrollments add rows rather than replacing them, so a user's history
+// stays available for verifying what they signed in the past.
 type UserCertificate struct {
-	ID            uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
-	CreatedAt     time.Time `gorm:"not null" json:"createdAt"`
-	UpdatedAt     time.Time `gorm:"not null" json:"updatedAt"`
-	UserID        uuid.UUID `gorm:"type:uuid;not null;uniqueIndex" json:"userId"`
-	Certificate   []byte    `gorm:"type:bytea;not null" json:"-"`
-	CertificatePEM string   `gorm:"type:text;not null" json:"certificatePem"`
-	PublicKey     string    `gorm:"type:text;not null" json:"publicKey"`
-	Fingerprint   []byte    `gorm:"type:bytea;not null;index" json:"fingerprint"`
-	Active        bool      `gorm:"type:boolean;not null;default:false;index" json:"active"`
+	ID               uuid.UUID                    `gorm:"type:uuid;primary_key;default:uuid_generate_v4()" json:"id"`
+	CreatedAt        time.Time                    `gorm:"not null" json:"createdAt"`
+	UpdatedAt        time.Time                    `gorm:"not null" json:"updatedAt"`
+	UserID           uuid.UUID                    `gorm:"type:uuid;not null;index" json:"userId"`
+	Certificate      []byte                       `gorm:"type:bytea;not null" json:"-"`
+	CertificatePEM   string                       `gorm:"type:text;not null" json:"certificatePem"`
+	PublicKey        string                       `gorm:"type:text;not null" json:"publicKey"`
+	Fingerprint      []byte                       `gorm:"type:bytea;not null;index" json:"fingerprint"`
+	Active           bool                         `gorm:"type:boolean;not null;default:false;index" json:"active"`
+	SerialNumber     *string                      `gorm:"type:text" json:"serialNumber,omitempty"`
+	ExpiresAt        time.Time                    `gorm:"not null" json:"expiresAt"`
+	RevokedAt        *time.Time                   `json:"revokedAt,omitempty"`
+	RevocationReason *CertificateRevocationReason `gorm:"type:text" json:"revocationReason,omitempty"`
+	// RenewsCertificateID points at the certificate this one replaces once
+	// it is active.
+	RenewsCertificateID *uuid.UUID `gorm:"type:uuid" json:"renewsCertificateId,omitempty"`
+}
+
+// RevokedAsOf reports whether the certificate was revoked as of at. Key
+// compromise revokes the certificate retroactively, since whoever holds a
+// stolen key can also backdate what they sign with it.
+func (c *UserCertificate) RevokedAsOf(at time.Time) bool {
+	if c.RevokedAt == nil {
+		return false
+	}
+	if c.RevocationReason != nil && *c.RevocationReason == CertificateRevocationKeyCompromise {
+		return true
+	}
+	return !at.Before(*c.RevokedAt)
 }
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WalkingSpeedMetersPerMinute is an unhurried adult walking pace, used to
+// turn route lengths into time estimates and minute budgets into distances.
+const WalkingSpeedMetersPerMinute = 80.0
+
+// WalkingGraphNode is a junction or dead end in the pedestrian network. IDs
+// are OSM node IDs so repeated imports of overlapping extracts line up.
+type WalkingGraphNode struct {
+	ID        int64     `json:"id" gorm:"primaryKey;autoIncrement:false"`
+	CreatedAt time.Time `json:"createdAt"`
+	Latitude  float64   `json:"latitude"`
+	Longitude float64   `json:"longitude"`
+	Geometry  string    `json:"-" gorm:"type:geometry(Point,4326)"`
+}
+
+func (WalkingGraphNode) TableName() string {
+	return "walking_graph_nodes"
+}
+
+// WalkingGraphEdge is a walkable stretch of an OSM way between two junctions.
+// Edges are stored once and walked in both directions.
+type WalkingGraphEdge struct {
+	ID           uuid.UUID `json:"id" gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	CreatedAt    time.Time `json:"createdAt"`
+	FromNodeID   int64     `json:"fromNodeId"`
+	ToNodeID     int64     `json:"toNodeId"`
+	OSMWayID     int64     `json:"osmWayId" gorm:"column:osm_way_id"`
+	Highway      string    `json:"highway"`
+	LengthMeters float64   `json:"lengthMeters"`
+}
+
+func (WalkingGraphEdge) TableName() string {
+	return "walking_graph_edges"
+}
+
+// EstimateWalkingMinutes rounds a walking distance up to whole minutes.
+func EstimateWalkingMinutes(meters float64) int {
+	if meters <= 0 || math.IsInf(meters, 0) || math.IsNaN(meters) {
+		return 0
+	}
+	return int(math.Ceil(meters / WalkingSpeedMetersPerMinute))
+}
+
+// WalkingBudgetMeters is the tighter of the archetype's distance and time
+// budgets, in meters. Zero means the archetype has no budget.
+func (q *QuestArchetype) WalkingBudgetMeters() float64 {
+	if q == nil {
+		return 0
+	}
+	budget := 0.0
+	if q.MaxWalkingDistanceMeters > 0 {
+		budget = float64(q.MaxWalkingDistanceMeters)
+	}
+	if q.MaxWalkingMinutes > 0 {
+		byTime := float64(q.MaxWalkingMinutes) * WalkingSpeedMetersPerMinute
+		if budget == 0 || byTime < budget {
+			budget = byTime
+		}
+	}
+	return budget
+}
+
+// WalkingGraph is the slice of the pedestrian network loaded for routing.
+type WalkingGraph struct {
+	Nodes []WalkingGraphNode
+	Edges []WalkingGraphEdge
+}
@@ -0,0 +1,27 @@
+package models
+
+import "testing"
+
+func TestQuestArchetypeWalkingBudgetMetersUsesTighterLimit(t *testing.T) {
+	if got := (&QuestArchetype{}).WalkingBudgetMeters(); got != 0 {
+		t.Fatalf("expected no budget, got %v", got)
+	}
+	if got := (&QuestArchetype{MaxWalkingDistanceMeters: 1500}).WalkingBudgetMeters(); got != 1500 {
+		t.Fatalf("expected distance budget, got %v", got)
+	}
+	if got := (&QuestArchetype{MaxWalkingMinutes: 10}).WalkingBudgetMeters(); got != 800 {
+		t.Fatalf("expected 10 minutes to be 800 meters, got %v", got)
+	}
+	if got := (&QuestArchetype{MaxWalkingDistanceMeters: 2000, MaxWalkingMinutes: 10}).WalkingBudgetMeters(); got != 800 {
+		t.Fatalf("expected tighter time budget, got %v", got)
+	}
+}
+
+func TestEstimateWalkingMinutesRoundsUp(t *testing.T) {
+	if got := EstimateWalkingMinutes(0); got != 0 {
+		t.Fatalf("expected 0 minutes, got %d", got)
+	}
+	if got := EstimateWalkingMinutes(81); got != 2 {
+		t.Fatalf("expected 2 minutes, got %d", got)
+	}
+}
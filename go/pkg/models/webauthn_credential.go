@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WebAuthnCredential is a passkey registered to a user. CredentialID is
+// base64url as browsers report it; PublicKey is the COSE key from the
+// authenticator's attestation.
+type WebAuthnCredential struct {
+	ID           uuid.UUID   `json:"id" gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	CreatedAt    time.Time   `json:"createdAt"`
+	UpdatedAt    time.Time   `json:"updatedAt"`
+	UserID       uuid.UUID   `json:"userId" gorm:"type:uuid"`
+	CredentialID string      `json:"credentialId"`
+	PublicKey    []byte      `json:"-"`
+	Algorithm    int         `json:"algorithm"`
+	SignCount    uint32      `json:"-"`
+	AAGUID       string      `json:"aaguid" gorm:"column:aaguid"`
+	Name         string      `json:"name"`
+	Transports   StringArray `json:"transports" gorm:"type:jsonb"`
+	LastUsedAt   *time.Time  `json:"lastUsedAt"`
+}
+
+func (WebAuthnCredential) TableName() string {
+	return "webauthn_credentials"
+}
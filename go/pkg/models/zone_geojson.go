@@ -0,0 +1,461 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/paulmach/orb"
+)
+
+// ZoneGeoJSONGeometry is a GeoJSON geometry with its coordinates left raw
+// until the type is known.
+type ZoneGeoJSONGeometry struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+}
+
+type ZoneGeoJSONFeature struct {
+	Type       string                 `json:"type"`
+	ID         string                 `json:"id,omitempty"`
+	Geometry   *ZoneGeoJSONGeometry   `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type ZoneGeoJSONFeatureCollection struct {
+	Type     string               `json:"type"`
+	Features []ZoneGeoJSONFeature `json:"features"`
+}
+
+// polygons decodes Polygon and MultiPolygon coordinates.
+func (g *ZoneGeoJSONGeometry) polygons() ([]orb.Polygon, error) {
+	if g == nil {
+		return nil, errors.New("missing geometry")
+	}
+	switch g.Type {
+	case "Polygon":
+		var coordinates [][][]float64
+		if err := json.Unmarshal(g.Coordinates, &coordinates); err != nil {
+			return nil, fmt.Errorf("invalid polygon coordinates: %w", err)
+		}
+		polygon, err := zoneGeoJSONPolygon(coordinates)
+		if err != nil {
+			return nil, err
+		}
+		return []orb.Polygon{polygon}, nil
+	case "MultiPolygon":
+		var coordinates [][][][]float64
+		if err := json.Unmarshal(g.Coordinates, &coordinates); err != nil {
+			return nil, fmt.Errorf("invalid multipolygon coordinates: %w", err)
+		}
+		polygons := make([]orb.Polygon, 0, len(coordinates))
+		for _, rings := range coordinates {
+			polygon, err := zoneGeoJSONPolygon(rings)
+			if err != nil {
+				return nil, err
+			}
+			polygons = append(polygons, polygon)
+		}
+		return polygons, nil
+	default:
+		return nil, fmt.Errorf("geometry must be a Polygon or MultiPolygon, got %q", g.Type)
+	}
+}
+
+func zoneGeoJSONPolygon(rings [][][]float64) (orb.Polygon, error) {
+	polygon := make(orb.Polygon, 0, len(rings))
+	for _, positions := range rings {
+		ring := make(orb.Ring, 0, len(positions))
+		for _, position := range positions {
+			if len(position) < 2 {
+				return nil, errors.New("coordinate must have a longitude and latitude")
+			}
+			ring = append(ring, orb.Point{position[0], position[1]})
+		}
+		polygon = append(polygon, ring)
+	}
+	return polygon, nil
+}
+
+func zoneGeoJSONString(properties map[string]interface{}, key string) string {
+	value, _ := properties[key].(string)
+	return strings.TrimSpace(value)
+}
+
+// ZoneBoundaryDraft is a zone read from a GeoJSON or KML file before it is
+// saved. Boundary is an open ring of [lng, lat] pairs, the shape
+// UpdateBoundary expects.
+type ZoneBoundaryDraft struct {
+	Name         string      `json:"name"`
+	Description  string      `json:"description"`
+	Kind         string      `json:"kind"`
+	InternalTags StringArray `json:"internalTags"`
+	Boundary     [][]float64 `json:"boundary"`
+}
+
+// Center returns the average of the ring's vertices as (lat, lng).
+func (d ZoneBoundaryDraft) Center() (float64, float64) {
+	if len(d.Boundary) == 0 {
+		return 0, 0
+	}
+	var sumLat, sumLng float64
+	for _, point := range d.Boundary {
+		sumLng += point[0]
+		sumLat += point[1]
+	}
+	count := float64(len(d.Boundary))
+	return sumLat / count, sumLng / count
+}
+
+// ParseZoneBoundaryFile reads zones from GeoJSON or KML, sniffing the format
+// from the first non-space byte.
+func ParseZoneBoundaryFile(data []byte) ([]ZoneBoundaryDraft, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return nil, errors.New("zone boundary file is empty")
+	}
+	if trimmed[0] == '<' {
+		return ParseZoneBoundaryKML(trimmed)
+	}
+	return ParseZoneBoundaryGeoJSON(trimmed)
+}
+
+// ParseZoneBoundaryGeoJSON accepts a FeatureCollection, a single Feature or
+// a bare Polygon/MultiPolygon. Each polygon becomes one zone; holes are
+// dropped since zones only store an outer ring. Feature properties supply
+// name, description, kind and internalTags (or tags).
+func ParseZoneBoundaryGeoJSON(data []byte) ([]ZoneBoundaryDraft, error) {
+	var header struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, fmt.Errorf("invalid geojson: %w", err)
+	}
+
+	var features []ZoneGeoJSONFeature
+	switch header.Type {
+	case "FeatureCollection":
+		var collection ZoneGeoJSONFeatureCollection
+		if err := json.Unmarshal(data, &collection); err != nil {
+			return nil, fmt.Errorf("invalid geojson feature collection: %w", err)
+		}
+		features = collection.Features
+	case "Feature":
+		var feature ZoneGeoJSONFeature
+		if err := json.Unmarshal(data, &feature); err != nil {
+			return nil, fmt.Errorf("invalid geojson feature: %w", err)
+		}
+		features = []ZoneGeoJSONFeature{feature}
+	case "Polygon", "MultiPolygon":
+		var geometry ZoneGeoJSONGeometry
+		if err := json.Unmarshal(data, &geometry); err != nil {
+			return nil, fmt.Errorf("invalid geojson geometry: %w", err)
+		}
+		features = []ZoneGeoJSONFeature{{Type: "Feature", Geometry: &geometry}}
+	default:
+		return nil, fmt.Errorf("unsupported geojson type %q", header.Type)
+	}
+
+	drafts := make([]ZoneBoundaryDraft, 0, len(features))
+	for i, feature := range features {
+		base := ZoneBoundaryDraft{
+			Name:         zoneGeoJSONString(feature.Properties, "name"),
+			Description:  zoneGeoJSONString(feature.Properties, "description"),
+			Kind:         NormalizeZoneKind(zoneGeoJSONString(feature.Properties, "kind")),
+			InternalTags: zoneBoundaryTags(feature.Properties["internalTags"], feature.Properties["tags"]),
+		}
+		if base.Name == "" {
+			base.Name = zoneGeoJSONString(feature.Properties, "title")
+		}
+		if base.Name == "" {
+			return nil, fmt.Errorf("feature %d has no name", i)
+		}
+
+		polygons, err := feature.Geometry.polygons()
+		if err != nil {
+			return nil, fmt.Errorf("feature %d (%s): %w", i, base.Name, err)
+		}
+
+		parsed, err := zoneBoundaryDraftsFromPolygons(base, polygons)
+		if err != nil {
+			return nil, fmt.Errorf("feature %d (%s): %w", i, base.Name, err)
+		}
+		drafts = append(drafts, parsed...)
+	}
+	if len(drafts) == 0 {
+		return nil, errors.New("geojson contains no zones")
+	}
+	return drafts, nil
+}
+
+type kmlContainer struct {
+	Documents  []kmlContainer `xml:"Document"`
+	Folders    []kmlContainer `xml:"Folder"`
+	Placemarks []kmlPlacemark `xml:"Placemark"`
+}
+
+type kmlPlacemark struct {
+	Name          string       `xml:"name"`
+	Description   string       `xml:"description"`
+	Data          []kmlData    `xml:"ExtendedData>Data"`
+	SimpleData    []kmlData    `xml:"ExtendedData>SchemaData>SimpleData"`
+	Polygons      []kmlPolygon `xml:"Polygon"`
+	MultiPolygons []kmlPolygon `xml:"MultiGeometry>Polygon"`
+}
+
+type kmlData struct {
+	Name      string `xml:"name,attr"`
+	Value     string `xml:"value"`
+	CharValue string `xml:",chardata"`
+}
+
+type kmlPolygon struct {
+	Outer string `xml:"outerBoundaryIs>LinearRing>coordinates"`
+}
+
+func (c kmlContainer) placemarks() []kmlPlacemark {
+	placemarks := append([]kmlPlacemark(nil), c.Placemarks...)
+	for _, document := range c.Documents {
+		placemarks = append(placemarks, document.placemarks()...)
+	}
+	for _, folder := range c.Folders {
+		placemarks = append(placemarks, folder.placemarks()...)
+	}
+	return placemarks
+}
+
+func (p kmlPlacemark) data(name string) string {
+	for _, entry := range append(append([]kmlData(nil), p.Data...), p.SimpleData...) {
+		if !strings.EqualFold(entry.Name, name) {
+			continue
+		}
+		if value := strings.TrimSpace(entry.Value); value != "" {
+			return value
+		}
+		return strings.TrimSpace(entry.CharValue)
+	}
+	return ""
+}
+
+// ParseZoneBoundaryKML reads every Placemark polygon in a KML document,
+// including those nested in Documents and Folders. kind and internalTags
+// (or tags) are read from ExtendedData.
+func ParseZoneBoundaryKML(data []byte) ([]ZoneBoundaryDraft, error) {
+	var root kmlContainer
+	if err := xml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("invalid kml: %w", err)
+	}
+
+	var drafts []ZoneBoundaryDraft
+	for i, placemark := range root.placemarks() {
+		kmlPolygons := append(append([]kmlPolygon(nil), placemark.Polygons...), placemark.MultiPolygons...)
+		if len(kmlPolygons) == 0 {
+			continue
+		}
+		base := ZoneBoundaryDraft{
+			Name:        strings.TrimSpace(placemark.Name),
+			Description: strings.TrimSpace(placemark.Description),
+			Kind:        NormalizeZoneKind(placemark.data("kind")),
+		}
+		tags := placemark.data("internalTags")
+		if tags == "" {
+			tags = placemark.data("tags")
+		}
+		base.InternalTags = zoneBoundaryTags(tags)
+		if base.Name == "" {
+			return nil, fmt.Errorf("placemark %d has no name", i)
+		}
+
+		polygons := make([]orb.Polygon, 0, len(kmlPolygons))
+		for _, kmlPolygon := range kmlPolygons {
+			ring, err := parseKMLCoordinates(kmlPolygon.Outer)
+			if err != nil {
+				return nil, fmt.Errorf("placemark %d (%s): %w", i, base.Name, err)
+			}
+			polygons = append(polygons, orb.Polygon{ring})
+		}
+		parsed, err := zoneBoundaryDraftsFromPolygons(base, polygons)
+		if err != nil {
+			return nil, fmt.Errorf("placemark %d (%s): %w", i, base.Name, err)
+		}
+		drafts = append(drafts, parsed...)
+	}
+	if len(drafts) == 0 {
+		return nil, errors.New("kml contains no polygon placemarks")
+	}
+	return drafts, nil
+}
+
+// parseKMLCoordinates parses whitespace-separated "lng,lat[,alt]" tuples.
+func parseKMLCoordinates(raw string) (orb.Ring, error) {
+	var ring orb.Ring
+	for _, tuple := range strings.Fields(raw) {
+		parts := strings.Split(tuple, ",")
+		if len(parts) < 2 {
+			return nil, fmt.Errorf("invalid kml coordinate %q", tuple)
+		}
+		lng, err := strconv.ParseFloat(parts[0], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid kml coordinate %q", tuple)
+		}
+		lat, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid kml coordinate %q", tuple)
+		}
+		ring = append(ring, orb.Point{lng, lat})
+	}
+	return ring, nil
+}
+
+func zoneBoundaryDraftsFromPolygons(base ZoneBoundaryDraft, polygons []orb.Polygon) ([]ZoneBoundaryDraft, error) {
+	drafts := make([]ZoneBoundaryDraft, 0, len(polygons))
+	for i, polygon := range polygons {
+		if len(polygon) == 0 {
+			return nil, errors.New("polygon has no outer ring")
+		}
+		boundary, err := ZoneBoundaryFromRing(polygon[0])
+		if err != nil {
+			return nil, err
+		}
+		draft := base
+		draft.InternalTags = append(StringArray{}, base.InternalTags...)
+		draft.Boundary = boundary
+		if len(polygons) > 1 && i > 0 {
+			draft.Name = fmt.Sprintf("%s (%d)", base.Name, i+1)
+		}
+		drafts = append(drafts, draft)
+	}
+	return drafts, nil
+}
+
+// ZoneBoundaryFromRing validates a ring and returns it open (without the
+// closing vertex) with consecutive duplicates removed.
+func ZoneBoundaryFromRing(ring orb.Ring) ([][]float64, error) {
+	boundary := make([][]float64, 0, len(ring))
+	for _, point := range ring {
+		lng, lat := point[0], point[1]
+		if math.IsNaN(lng) || math.IsNaN(lat) || lng < -180 || lng > 180 || lat < -90 || lat > 90 {
+			return nil, fmt.Errorf("coordinate [%v, %v] is out of range", lng, lat)
+		}
+		if n := len(boundary); n > 0 && boundary[n-1][0] == lng && boundary[n-1][1] == lat {
+			continue
+		}
+		boundary = append(boundary, []float64{lng, lat})
+	}
+	if n := len(boundary); n > 1 && boundary[0][0] == boundary[n-1][0] && boundary[0][1] == boundary[n-1][1] {
+		boundary = boundary[:n-1]
+	}
+	if len(boundary) < 3 {
+		return nil, errors.New("polygon needs at least 3 distinct points")
+	}
+	return boundary, nil
+}
+
+// ZoneBoundaryFromGeoJSON reads the outer ring of a GeoJSON Polygon, as
+// produced by ST_AsGeoJSON.
+func ZoneBoundaryFromGeoJSON(raw []byte) ([][]float64, error) {
+	var geometry ZoneGeoJSONGeometry
+	if err := json.Unmarshal(raw, &geometry); err != nil {
+		return nil, err
+	}
+	if geometry.Type != "Polygon" {
+		return nil, fmt.Errorf("expected a polygon, got %s", geometry.Type)
+	}
+	polygons, err := geometry.polygons()
+	if err != nil {
+		return nil, err
+	}
+	if len(polygons[0]) == 0 {
+		return nil, errors.New("polygon has no outer ring")
+	}
+	return ZoneBoundaryFromRing(polygons[0][0])
+}
+
+// zoneBoundaryTags accepts tags as a JSON array or a comma-separated string
+// and returns the first non-empty candidate, trimmed and deduplicated.
+func zoneBoundaryTags(candidates ...interface{}) StringArray {
+	for _, candidate := range candidates {
+		var values []string
+		switch typed := candidate.(type) {
+		case string:
+			values = strings.Split(typed, ",")
+		case []interface{}:
+			for _, value := range typed {
+				if text, ok := value.(string); ok {
+					values = append(values, text)
+				}
+			}
+		case []string:
+			values = typed
+		}
+
+		tags := StringArray{}
+		seen := map[string]bool{}
+		for _, value := range values {
+			value = strings.TrimSpace(value)
+			if value == "" || seen[value] {
+				continue
+			}
+			seen[value] = true
+			tags = append(tags, value)
+		}
+		if len(tags) > 0 {
+			return tags
+		}
+	}
+	return StringArray{}
+}
+
+// ZonesToGeoJSON exports zones as a FeatureCollection of polygons carrying
+// id, name, description, kind and internalTags. Zones without a usable
+// boundary are skipped.
+func ZonesToGeoJSON(zones []*Zone) ZoneGeoJSONFeatureCollection {
+	collection := ZoneGeoJSONFeatureCollection{
+		Type:     "FeatureCollection",
+		Features: []ZoneGeoJSONFeature{},
+	}
+	for _, zone := range zones {
+		if zone == nil {
+			continue
+		}
+		polygon := zone.decodeBoundaryPolygon()
+		if len(polygon) == 0 || len(polygon[0]) < 4 {
+			polygon = zone.GetPolygon()
+		}
+		if len(polygon) == 0 || len(polygon[0]) < 4 {
+			continue
+		}
+
+		ring := make([][]float64, 0, len(polygon[0]))
+		for _, point := range polygon[0] {
+			ring = append(ring, []float64{point[0], point[1]})
+		}
+		coordinates, err := json.Marshal([][][]float64{ring})
+		if err != nil {
+			continue
+		}
+
+		tags := []string(zone.InternalTags)
+		if tags == nil {
+			tags = []string{}
+		}
+		collection.Features = append(collection.Features, ZoneGeoJSONFeature{
+			Type:     "Feature",
+			ID:       zone.ID.String(),
+			Geometry: &ZoneGeoJSONGeometry{Type: "Polygon", Coordinates: coordinates},
+			Properties: map[string]interface{}{
+				"id":           zone.ID.String(),
+				"name":         zone.Name,
+				"description":  zone.Description,
+				"kind":         zone.Kind,
+				"internalTags": tags,
+			},
+		})
+	}
+	return collection
+}
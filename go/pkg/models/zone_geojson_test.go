@@ -0,0 +1,137 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestParseZoneBoundaryGeoJSONFeatureCollection(t *testing.T) {
+	data := []byte(`{
+		"type": "FeatureCollection",
+		"features": [
+			{
+				"type": "Feature",
+				"properties": {"name": "Capitol Hill", "kind": "Urban Core", "tags": "nightlife, parks"},
+				"geometry": {"type": "Polygon", "coordinates": [[[-122.32, 47.61], [-122.31, 47.61], [-122.31, 47.62], [-122.32, 47.62], [-122.32, 47.61]]]}
+			},
+			{
+				"type": "Feature",
+				"properties": {"name": "Islands", "internalTags": ["water"]},
+				"geometry": {"type": "MultiPolygon", "coordinates": [
+					[[[0, 0], [1, 0], [1, 1], [0, 0]]],
+					[[[2, 2], [3, 2], [3, 3], [2, 2]]]
+				]}
+			}
+		]
+	}`)
+
+	drafts, err := ParseZoneBoundaryFile(data)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(drafts) != 3 {
+		t.Fatalf("expected 3 drafts, got %d", len(drafts))
+	}
+	if drafts[0].Kind != "urban-core" {
+		t.Fatalf("expected normalized kind, got %q", drafts[0].Kind)
+	}
+	if len(drafts[0].Boundary) != 4 {
+		t.Fatalf("expected closing vertex to be dropped, got %d points", len(drafts[0].Boundary))
+	}
+	if len(drafts[0].InternalTags) != 2 || drafts[0].InternalTags[1] != "parks" {
+		t.Fatalf("unexpected tags %v", drafts[0].InternalTags)
+	}
+	if drafts[1].Name != "Islands" || drafts[2].Name != "Islands (2)" {
+		t.Fatalf("unexpected multipolygon names %q, %q", drafts[1].Name, drafts[2].Name)
+	}
+	if lat, lng := drafts[1].Center(); lat != 1.0/3 || lng != 2.0/3 {
+		t.Fatalf("unexpected center %v, %v", lat, lng)
+	}
+}
+
+func TestParseZoneBoundaryGeoJSONRejectsDegenerateRings(t *testing.T) {
+	data := []byte(`{"type": "Feature", "properties": {"name": "Line"}, "geometry": {"type": "Polygon", "coordinates": [[[0, 0], [1, 1], [0, 0]]]}}`)
+	if _, err := ParseZoneBoundaryGeoJSON(data); err == nil {
+		t.Fatal("expected error for a ring with two distinct points")
+	}
+
+	data = []byte(`{"type": "Feature", "properties": {}, "geometry": {"type": "Polygon", "coordinates": [[[0, 0], [1, 0], [1, 1], [0, 0]]]}}`)
+	if _, err := ParseZoneBoundaryGeoJSON(data); err == nil {
+		t.Fatal("expected error for a feature without a name")
+	}
+}
+
+func TestParseZoneBoundaryKML(t *testing.T) {
+	data := []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<kml xmlns="http://www.opengis.net/kml/2.2">
+  <Document>
+    <Folder>
+      <Placemark>
+        <name>Fremont</name>
+        <description>Center of the universe</description>
+        <ExtendedData>
+          <Data name="kind"><value>Artsy</value></Data>
+          <Data name="tags"><value>troll,bridges</value></Data>
+        </ExtendedData>
+        <Polygon>
+          <outerBoundaryIs><LinearRing><coordinates>
+            -122.35,47.65,0 -122.34,47.65,0 -122.34,47.66,0 -122.35,47.65,0
+          </coordinates></LinearRing></outerBoundaryIs>
+        </Polygon>
+      </Placemark>
+      <Placemark>
+        <name>Marker only</name>
+        <Point><coordinates>-122.3,47.6</coordinates></Point>
+      </Placemark>
+    </Folder>
+  </Document>
+</kml>`)
+
+	drafts, err := ParseZoneBoundaryFile(data)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(drafts) != 1 {
+		t.Fatalf("expected 1 draft, got %d", len(drafts))
+	}
+	draft := drafts[0]
+	if draft.Name != "Fremont" || draft.Kind != "artsy" || draft.Description != "Center of the universe" {
+		t.Fatalf("unexpected draft %+v", draft)
+	}
+	if len(draft.Boundary) != 3 || draft.Boundary[0][0] != -122.35 || draft.Boundary[0][1] != 47.65 {
+		t.Fatalf("unexpected boundary %v", draft.Boundary)
+	}
+	if len(draft.InternalTags) != 2 {
+		t.Fatalf("unexpected tags %v", draft.InternalTags)
+	}
+}
+
+func TestZonesToGeoJSONRoundTrips(t *testing.T) {
+	zone := &Zone{
+		ID:           uuid.New(),
+		Name:         "Ballard",
+		Kind:         "maritime",
+		InternalTags: StringArray{"docks"},
+		Boundary:     "POLYGON((-122.39 47.66, -122.37 47.66, -122.37 47.68, -122.39 47.68, -122.39 47.66))",
+	}
+
+	encoded, err := json.Marshal(ZonesToGeoJSON([]*Zone{zone}))
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	drafts, err := ParseZoneBoundaryGeoJSON(encoded)
+	if err != nil {
+		t.Fatalf("parse exported geojson: %v", err)
+	}
+	if len(drafts) != 1 {
+		t.Fatalf("expected 1 draft, got %d", len(drafts))
+	}
+	if drafts[0].Name != "Ballard" || drafts[0].Kind != "maritime" || len(drafts[0].Boundary) != 4 {
+		t.Fatalf("unexpected round trip %+v", drafts[0])
+	}
+	if len(drafts[0].InternalTags) != 1 || drafts[0].InternalTags[0] != "docks" {
+		t.Fatalf("unexpected tags %v", drafts[0].InternalTags)
+	}
+}
@@ -0,0 +1,11 @@
+package models
+
+import "github.com/google/uuid"
+
+// ZoneGeometryEdit reports the outcome of splitting, merging or simplifying
+// zones. ReassignedContent counts moved rows per content table.
+type ZoneGeometryEdit struct {
+	Zones             []*Zone        `json:"zones"`
+	RemovedZoneIDs    []uuid.UUID    `json:"removedZoneIds"`
+	ReassignedContent map[string]int `json:"reassignedContent"`
+}
@@ -14,8 +14,17 @@ type ZoneImport struct {
 	Status       string    `json:"status"`
 	ErrorMessage *string   `json:"errorMessage"`
 	ZoneCount    int       `json:"zoneCount"`
+	// Source is "overpass" for live Nominatim/Overpass lookups or
+	// "osm_extract" when boundaries come from a local PBF file.
+	Source         string  `json:"source" gorm:"column:source;default:overpass"`
+	OSMExtractPath *string `json:"osmExtractPath,omitempty" gorm:"column:osm_extract_path"`
 }
 
+const (
+	ZoneImportSourceOverpass   = "overpass"
+	ZoneImportSourceOSMExtract = "osm_extract"
+)
+
 func (z *ZoneImport) TableName() string {
 	return "zone_imports"
 }
@@ -2,6 +2,7 @@ package server
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/MaxBlaushild/poltergeist/pkg/auth"
 	"github.com/MaxBlaushild/poltergeist/pkg/aws"
@@ -64,9 +65,15 @@ func (s *server) SetupRoutes(r *gin.Engine) {
 	// unauthenticated, unlisted-URL page) now carries real customer names
 	// and shipping addresses, so the whole /operator surface is gated
 	// behind a shared password rather than "nobody will guess the URL."
-	operatorGroup := group.Group("/operator", gin.BasicAuth(gin.Accounts{
-		"operator": s.deps.Config.Secret.AdminToken,
-	}))
+	// Signed-in operators can use their own account instead, holding
+	// reef:<resource>:read or :write (e.g. reef:orders:write), which also
+	// puts every call on the audit trail.
+	operatorGroup := group.Group("/operator", withOperator(
+		middleware.RequireRouteScopes(s.deps.AuthClient, s.deps.DbClient.AuditItem().CreatePrivilegedCall, "reef", "/api/reef/operator"),
+		gin.BasicAuth(gin.Accounts{
+			"operator": s.deps.Config.Secret.AdminToken,
+		}),
+	))
 	operatorGroup.GET("/metrics", s.getOperatorMetrics)
 	operatorGroup.GET("/orders", s.listOperatorOrders)
 	operatorGroup.PATCH("/orders/:id/fulfillment", s.updateOrderFulfillment)
@@ -74,6 +81,18 @@ func (s *server) SetupRoutes(r *gin.Engine) {
 	operatorGroup.POST("/orders/:id/refresh-slant-status", s.refreshSlantStatus)
 }
 
+// withOperator checks a Bearer token's scopes when one is sent and falls
+// back to the shared operator password otherwise.
+func withOperator(scoped gin.HandlerFunc, shared gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if strings.HasPrefix(c.GetHeader("Authorization"), "Bearer ") {
+			scoped(c)
+			return
+		}
+		shared(c)
+	}
+}
+
 // permissiveCORS mirrors go/core's own CORS config (gin-contrib/cors would
 // pull in a gin-gonic/gin major bump requiring Go 1.25, ahead of this
 // repo's go.work toolchain — not worth it for a dev-only convenience path,
@@ -0,0 +1,195 @@
+// Command content-bundle moves authored content between environments. Export
+// writes a bundle directory; import diffs a bundle against the database and
+// applies it in a single transaction.
+//
+//	go run ./cmd/content-bundle --config-name staging --export ./bundle --zone-kinds forest --embed-images
+//	go run ./cmd/content-bundle --config-name prod --import ./bundle --on-conflict skip --dry-run
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/aws"
+	"github.com/MaxBlaushild/poltergeist/pkg/db"
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/MaxBlaushild/poltergeist/sonar/internal/config"
+	"github.com/MaxBlaushild/poltergeist/sonar/internal/contentbundle"
+	"github.com/google/uuid"
+)
+
+func main() {
+	exportDir := flag.String("export", "", "Directory to write an exported bundle to.")
+	importDir := flag.String("import", "", "Bundle directory to import.")
+	questArchetypes := flag.String("quest-archetypes", "", "Comma-separated quest archetype IDs to export.")
+	zoneKinds := flag.String("zone-kinds", "", "Comma-separated zone kind slugs to export.")
+	embedImages := flag.Bool("embed-images", false, "Download referenced images into the bundle.")
+	source := flag.String("source", "", "Label recorded in the manifest as the exporting environment.")
+	onConflict := flag.String("on-conflict", "fail", "What to do with records that differ from existing ones: fail, skip or overwrite.")
+	dryRun := flag.Bool("dry-run", false, "Print the import diff without writing anything.")
+	asJSON := flag.Bool("json", false, "Print the import diff as JSON.")
+
+	cfg, err := config.ParseFlagsAndGetConfig()
+	if err != nil {
+		log.Fatalf("failed to load config: %v", err)
+	}
+	if (*exportDir == "") == (*importDir == "") {
+		log.Fatalf("exactly one of --export or --import is required")
+	}
+
+	dbClient, err := db.NewClient(db.ClientConfig{
+		Name:     cfg.Public.DbName,
+		Host:     cfg.Public.DbHost,
+		Port:     cfg.Public.DbPort,
+		User:     cfg.Public.DbUser,
+		Password: cfg.Secret.DbPassword,
+	})
+	if err != nil {
+		log.Fatalf("failed to connect to db: %v", err)
+	}
+
+	ctx := context.Background()
+	if *exportDir != "" {
+		selection := models.ContentBundleSelection{}
+		for _, raw := range splitList(*questArchetypes) {
+			id, err := uuid.Parse(raw)
+			if err != nil {
+				log.Fatalf("invalid quest archetype ID %q", raw)
+			}
+			selection.QuestArchetypeIDs = append(selection.QuestArchetypeIDs, id)
+		}
+		selection.ZoneKinds = splitList(*zoneKinds)
+		runExport(ctx, dbClient, *exportDir, selection, *source, *embedImages)
+		return
+	}
+
+	policy, ok := models.NormalizeContentBundleConflictPolicy(*onConflict)
+	if !ok {
+		log.Fatalf("--on-conflict must be one of fail, skip or overwrite")
+	}
+	runImport(ctx, dbClient, *importDir, policy, *dryRun, *asJSON)
+}
+
+func splitList(raw string) []string {
+	values := []string{}
+	for _, value := range strings.Split(raw, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+func runExport(
+	ctx context.Context,
+	dbClient db.DbClient,
+	dir string,
+	selection models.ContentBundleSelection,
+	source string,
+	embedImages bool,
+) {
+	bundle, err := contentbundle.Export(ctx, dbClient, selection, source)
+	if err != nil {
+		log.Fatalf("failed to export content: %v", err)
+	}
+	images := map[string][]byte{}
+	if embedImages {
+		var errs []error
+		images, errs = contentbundle.EmbedImages(ctx, &http.Client{Timeout: 30 * time.Second}, bundle)
+		for _, err := range errs {
+			bundle.Manifest.Warnings = append(bundle.Manifest.Warnings, err.Error())
+		}
+	}
+	files, err := contentbundle.Files(bundle, images)
+	if err != nil {
+		log.Fatalf("failed to encode bundle: %v", err)
+	}
+	if err := contentbundle.WriteDir(dir, files); err != nil {
+		log.Fatalf("failed to write bundle: %v", err)
+	}
+	for _, warning := range bundle.Manifest.Warnings {
+		fmt.Printf("warning: %s\n", warning)
+	}
+	fmt.Printf("exported %d quest archetypes, %d templates and %d images to %s\n",
+		len(bundle.QuestArchetypes),
+		len(bundle.ChallengeTemplates)+len(bundle.ScenarioTemplates)+len(bundle.ExpositionTemplates)+
+			len(bundle.ShrineTemplates)+len(bundle.MonsterTemplates),
+		len(images),
+		dir,
+	)
+}
+
+func runImport(
+	ctx context.Context,
+	dbClient db.DbClient,
+	dir string,
+	policy models.ContentBundleConflictPolicy,
+	dryRun bool,
+	asJSON bool,
+) {
+	files, err := contentbundle.ReadDir(dir)
+	if err != nil {
+		log.Fatalf("failed to read bundle: %v", err)
+	}
+	bundle, images, err := contentbundle.FromFiles(files)
+	if err != nil {
+		log.Fatalf("failed to decode bundle: %v", err)
+	}
+	if !dryRun && len(images) > 0 {
+		awsClient := aws.NewAWSClient("us-east-1")
+		if err := contentbundle.RestoreImages(bundle, images, awsClient.UploadImageToS3); err != nil {
+			log.Fatalf("failed to upload embedded images: %v", err)
+		}
+	}
+
+	plan, err := contentbundle.Plan(ctx, dbClient, bundle, policy)
+	if err != nil {
+		log.Fatalf("failed to plan import: %v", err)
+	}
+	if asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(plan); err != nil {
+			log.Fatalf("failed to encode plan: %v", err)
+		}
+	} else {
+		for _, change := range plan.Changes {
+			line := fmt.Sprintf("%-9s %-18s %s", change.Action, change.Kind, change.Key)
+			if len(change.Fields) > 0 {
+				line += " [" + strings.Join(change.Fields, ", ") + "]"
+			}
+			if change.Message != "" {
+				line += " - " + change.Message
+			}
+			fmt.Println(line)
+		}
+		for _, warning := range plan.Warnings {
+			fmt.Printf("warning: %s\n", warning)
+		}
+	}
+
+	if plan.HasConflicts() {
+		fmt.Fprintln(os.Stderr, "import has conflicts; nothing was written")
+		os.Exit(1)
+	}
+	if dryRun {
+		return
+	}
+	if err := dbClient.ContentBundle().Apply(ctx, plan); err != nil {
+		log.Fatalf("failed to apply bundle: %v", err)
+	}
+	summary := plan.Summary()
+	fmt.Printf("imported: %d created, %d updated, %d unchanged, %d skipped\n",
+		summary[models.ContentBundleImportActionCreate],
+		summary[models.ContentBundleImportActionUpdate],
+		summary[models.ContentBundleImportActionUnchanged],
+		summary[models.ContentBundleImportActionSkip],
+	)
+}
@@ -0,0 +1,136 @@
+// Command import-osm seeds points of interest for a zone from a local
+// OpenStreetMap PBF extract instead of the Google Places API. Places are
+// deduped against earlier Google and OSM imports by OSM ID and by name within
+// a short distance.
+//
+//	go run ./cmd/import-osm --config-name local --extract seattle.osm.pbf --zone-id <uuid> --list
+//	go run ./cmd/import-osm --config-name local --extract seattle.osm.pbf --zone-id <uuid> --count 10 --types park,museum
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/aws"
+	"github.com/MaxBlaushild/poltergeist/pkg/db"
+	"github.com/MaxBlaushild/poltergeist/pkg/deep_priest"
+	"github.com/MaxBlaushild/poltergeist/pkg/googlemaps"
+	"github.com/MaxBlaushild/poltergeist/pkg/locationseeder"
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/MaxBlaushild/poltergeist/sonar/internal/config"
+	"github.com/google/uuid"
+)
+
+func parsePlaceTypes(raw string) []googlemaps.PlaceType {
+	var placeTypes []googlemaps.PlaceType
+	for _, value := range strings.Split(raw, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			placeTypes = append(placeTypes, googlemaps.PlaceType(value))
+		}
+	}
+	return placeTypes
+}
+
+func main() {
+	extractPath := flag.String("extract", "", "Path to a .osm.pbf extract covering the zone.")
+	zoneIDFlag := flag.String("zone-id", "", "Zone to seed points of interest into.")
+	genreIDFlag := flag.String("genre-id", "", "Genre to theme points of interest with. Defaults to the zone's default genre.")
+	count := flag.Int("count", 5, "Number of points of interest to seed.")
+	includedTypes := flag.String("types", "", "Comma-separated Google place types to include. Empty includes every mapped type.")
+	excludedTypes := flag.String("exclude-types", "", "Comma-separated Google place types to exclude.")
+	list := flag.Bool("list", false, "Print the candidate places in the zone as JSON without seeding anything.")
+
+	cfg, err := config.ParseFlagsAndGetConfig()
+	if err != nil {
+		log.Fatalf("failed to load config: %v", err)
+	}
+	if *extractPath == "" || *zoneIDFlag == "" {
+		log.Fatalf("--extract and --zone-id are required")
+	}
+	zoneID, err := uuid.Parse(*zoneIDFlag)
+	if err != nil {
+		log.Fatalf("invalid --zone-id: %v", err)
+	}
+
+	dbClient, err := db.NewClient(db.ClientConfig{
+		Name:     cfg.Public.DbName,
+		Host:     cfg.Public.DbHost,
+		Port:     cfg.Public.DbPort,
+		User:     cfg.Public.DbUser,
+		Password: cfg.Secret.DbPassword,
+	})
+	if err != nil {
+		log.Fatalf("failed to connect to db: %v", err)
+	}
+
+	ctx := context.Background()
+	zone, err := dbClient.Zone().FindByID(ctx, zoneID)
+	if err != nil {
+		log.Fatalf("failed to load zone: %v", err)
+	}
+	if zone == nil {
+		log.Fatalf("zone %s not found", zoneID)
+	}
+
+	var genre *models.ZoneGenre
+	if *genreIDFlag != "" {
+		genreID, err := uuid.Parse(*genreIDFlag)
+		if err != nil {
+			log.Fatalf("invalid --genre-id: %v", err)
+		}
+		if genre, err = dbClient.ZoneGenre().FindByID(ctx, genreID); err != nil || genre == nil {
+			log.Fatalf("failed to load genre %s: %v", genreID, err)
+		}
+	}
+
+	data, err := locationseeder.ReadOSMFile(*extractPath)
+	if err != nil {
+		log.Fatalf("failed to read extract: %v", err)
+	}
+	extract := locationseeder.ExtractOSM(data)
+	log.Printf("read %d places and %d boundaries from %s", len(extract.Places), len(extract.Boundaries), *extractPath)
+
+	if *list {
+		var inZone []locationseeder.OSMPlace
+		for _, place := range extract.Places {
+			if zone.IsPointInBoundary(place.Location.Lat, place.Location.Lng) {
+				inZone = append(inZone, place)
+			}
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(inZone); err != nil {
+			log.Fatalf("failed to encode places: %v", err)
+		}
+		return
+	}
+
+	awsClient := aws.NewAWSClient("us-east-1")
+	seeder := locationseeder.NewClient(
+		googlemaps.NewClient(cfg.Secret.GoogleMapsApiKey),
+		dbClient,
+		deep_priest.SummonDeepPriest(),
+		awsClient,
+	)
+
+	pointsOfInterest, err := seeder.SeedPointsOfInterestFromOSM(
+		ctx,
+		*zone,
+		extract.Places,
+		parsePlaceTypes(*includedTypes),
+		parsePlaceTypes(*excludedTypes),
+		int32(*count),
+		genre,
+	)
+	if err != nil {
+		log.Fatalf("failed to seed points of interest: %v", err)
+	}
+	for _, poi := range pointsOfInterest {
+		fmt.Printf("%s\t%s\t%s\n", poi.ID, poi.Name, poi.OriginalName)
+	}
+}
@@ -0,0 +1,60 @@
+// Command import-walking-graph loads the walkable ways from a local
+// OpenStreetMap PBF extract into the pedestrian routing graph that quest
+// generation uses to place nodes within an archetype's walking budget.
+// Re-importing an extract updates existing edges in place.
+//
+//	go run ./cmd/import-walking-graph --config-name local --extract seattle.osm.pbf
+//	go run ./cmd/import-walking-graph --config-name local --extract seattle.osm.pbf --dry-run
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/db"
+	"github.com/MaxBlaushild/poltergeist/pkg/locationseeder"
+	"github.com/MaxBlaushild/poltergeist/sonar/internal/config"
+)
+
+func main() {
+	extractPath := flag.String("extract", "", "Path to a .osm.pbf extract to build the walking graph from.")
+	dryRun := flag.Bool("dry-run", false, "Build the graph and print its size without saving it.")
+
+	cfg, err := config.ParseFlagsAndGetConfig()
+	if err != nil {
+		log.Fatalf("failed to load config: %v", err)
+	}
+	if *extractPath == "" {
+		log.Fatalf("--extract is required")
+	}
+
+	data, err := locationseeder.ReadOSMFile(*extractPath)
+	if err != nil {
+		log.Fatalf("failed to read extract: %v", err)
+	}
+	nodes, edges := locationseeder.BuildWalkingGraph(data)
+	totalMeters := 0.0
+	for _, edge := range edges {
+		totalMeters += edge.LengthMeters
+	}
+	log.Printf("built %d nodes and %d edges (%.1f km) from %s", len(nodes), len(edges), totalMeters/1000, *extractPath)
+	if *dryRun {
+		return
+	}
+
+	dbClient, err := db.NewClient(db.ClientConfig{
+		Name:     cfg.Public.DbName,
+		Host:     cfg.Public.DbHost,
+		Port:     cfg.Public.DbPort,
+		User:     cfg.Public.DbUser,
+		Password: cfg.Secret.DbPassword,
+	})
+	if err != nil {
+		log.Fatalf("failed to connect to db: %v", err)
+	}
+	if err := dbClient.WalkingGraph().Import(context.Background(), nodes, edges); err != nil {
+		log.Fatalf("failed to import walking graph: %v", err)
+	}
+	log.Printf("imported walking graph from %s", *extractPath)
+}
@@ -1,6 +1,9 @@
 package main
 
 import (
+	"context"
+	"log"
+
 	"github.com/MaxBlaushild/poltergeist/pkg/auth"
 	"github.com/MaxBlaushild/poltergeist/pkg/aws"
 	"github.com/MaxBlaushild/poltergeist/pkg/db"
@@ -10,6 +13,7 @@ import (
 	"github.com/MaxBlaushild/poltergeist/pkg/liveness"
 	"github.com/MaxBlaushild/poltergeist/pkg/locationseeder"
 	"github.com/MaxBlaushild/poltergeist/pkg/mapbox"
+	"github.com/MaxBlaushild/poltergeist/pkg/middleware"
 	"github.com/MaxBlaushild/poltergeist/pkg/texter"
 	"github.com/MaxBlaushild/poltergeist/pkg/useapi"
 	"github.com/MaxBlaushild/poltergeist/pkg/util"
@@ -23,6 +27,7 @@ import (
 	"github.com/MaxBlaushild/poltergeist/sonar/internal/questlog"
 	"github.com/MaxBlaushild/poltergeist/sonar/internal/search"
 	"github.com/MaxBlaushild/poltergeist/sonar/internal/server"
+	"github.com/google/uuid"
 	"github.com/hibiken/asynq"
 	"github.com/redis/go-redis/v9"
 )
@@ -46,7 +51,6 @@ func main() {
 
 	deepPriest := deep_priest.SummonDeepPriest()
 	texterClient := texter.NewClient()
-	authClient := auth.NewClient()
 	awsClient := aws.NewAWSClient("us-east-1")
 	judgeClient := judge.NewClient(awsClient, dbClient, deepPriest)
 	quartermaster := quartermaster.NewClient(dbClient)
@@ -63,6 +67,14 @@ func main() {
 		Password: "",
 		DB:       0,
 	})
+	authClient := middleware.NewLocallyVerifyingAuthClient(auth.NewClient(), middleware.LocalVerificationConfig{
+		RedisClient: redisClient,
+	})
+	dbClient.SetUserChangeNotifier(func(ctx context.Context, userID uuid.UUID) {
+		if err := auth.PublishUserChanged(ctx, redisClient, userID); err != nil {
+			log.Printf("[auth][events] publish user changed failed user_id=%s err=%v", userID, err)
+		}
+	})
 	asyncClient := asynq.NewClient(asynq.RedisClientOpt{Addr: redisAddr})
 	dungeonmaster := dungeonmaster.NewClient(googlemapsClient, dbClient, deepPriest, locationSeeder, awsClient, asyncClient)
 	searchClient := search.NewSearchClient(dbClient, deepPriest)
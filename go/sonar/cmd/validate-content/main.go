@@ -0,0 +1,84 @@
+// Command validate-content runs the quest archetype and main story validator
+// over everything in the database and prints each problem with its location.
+// It exits non-zero when any errors are found, so it can gate deploys.
+//
+//	go run ./cmd/validate-content --config-name local
+//	go run ./cmd/validate-content --config-name local --errors-only --json
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/db"
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/MaxBlaushild/poltergeist/sonar/internal/config"
+	"github.com/MaxBlaushild/poltergeist/sonar/internal/contentvalidation"
+)
+
+func main() {
+	errorsOnly := flag.Bool("errors-only", false, "Only report errors, not warnings.")
+	asJSON := flag.Bool("json", false, "Print issues as a JSON array instead of one per line.")
+
+	cfg, err := config.ParseFlagsAndGetConfig()
+	if err != nil {
+		log.Fatalf("failed to load config: %v", err)
+	}
+
+	dbClient, err := db.NewClient(db.ClientConfig{
+		Name:     cfg.Public.DbName,
+		Host:     cfg.Public.DbHost,
+		Port:     cfg.Public.DbPort,
+		User:     cfg.Public.DbUser,
+		Password: cfg.Secret.DbPassword,
+	})
+	if err != nil {
+		log.Fatalf("failed to connect to db: %v", err)
+	}
+
+	catalog, err := contentvalidation.LoadCatalog(context.Background(), dbClient)
+	if err != nil {
+		log.Fatalf("failed to load content: %v", err)
+	}
+
+	issues := []models.ContentValidationIssue{}
+	errorCount, warningCount := 0, 0
+	for _, issue := range catalog.Validate() {
+		if issue.IsError() {
+			errorCount++
+		} else {
+			warningCount++
+			if *errorsOnly {
+				continue
+			}
+		}
+		issues = append(issues, issue)
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(issues); err != nil {
+			log.Fatalf("failed to encode issues: %v", err)
+		}
+	} else {
+		for _, issue := range issues {
+			fmt.Println(issue.String())
+		}
+	}
+
+	log.Printf(
+		"validated %d quest archetypes and %d main story templates: %d errors, %d warnings",
+		len(catalog.QuestArchetypes),
+		len(catalog.MainStoryTemplates),
+		errorCount,
+		warningCount,
+	)
+	if errorCount > 0 {
+		os.Exit(1)
+	}
+}
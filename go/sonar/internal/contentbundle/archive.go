@@ -0,0 +1,177 @@
+package contentbundle
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+)
+
+const (
+	manifestFile = "manifest.json"
+	imagesDir    = "images"
+)
+
+// A bundle on disk is a directory with a manifest, one JSON file per kind and
+// an images/ directory holding any embedded images.
+type bundleFile struct {
+	name  string
+	value func(bundle *models.ContentBundle) interface{}
+}
+
+var bundleFiles = []bundleFile{
+	{"zone_genres.json", func(b *models.ContentBundle) interface{} { return &b.ZoneGenres }},
+	{"zone_kinds.json", func(b *models.ContentBundle) interface{} { return &b.ZoneKinds }},
+	{"location_archetypes.json", func(b *models.ContentBundle) interface{} { return &b.LocationArchetypes }},
+	{"inventory_items.json", func(b *models.ContentBundle) interface{} { return &b.InventoryItems }},
+	{"spells.json", func(b *models.ContentBundle) interface{} { return &b.Spells }},
+	{"monster_templates.json", func(b *models.ContentBundle) interface{} { return &b.MonsterTemplates }},
+	{"challenge_templates.json", func(b *models.ContentBundle) interface{} { return &b.ChallengeTemplates }},
+	{"scenario_templates.json", func(b *models.ContentBundle) interface{} { return &b.ScenarioTemplates }},
+	{"exposition_templates.json", func(b *models.ContentBundle) interface{} { return &b.ExpositionTemplates }},
+	{"shrine_templates.json", func(b *models.ContentBundle) interface{} { return &b.ShrineTemplates }},
+	{"quest_archetypes.json", func(b *models.ContentBundle) interface{} { return &b.QuestArchetypes }},
+}
+
+// Files lays the bundle and its embedded images out as relative paths.
+func Files(bundle *models.ContentBundle, images map[string][]byte) (map[string][]byte, error) {
+	files := map[string][]byte{}
+	encode := func(name string, value interface{}) error {
+		raw, err := json.MarshalIndent(value, "", "  ")
+		if err != nil {
+			return fmt.Errorf("encode %s: %w", name, err)
+		}
+		files[name] = append(raw, '\n')
+		return nil
+	}
+	if err := encode(manifestFile, bundle.Manifest); err != nil {
+		return nil, err
+	}
+	for _, file := range bundleFiles {
+		if err := encode(file.name, file.value(bundle)); err != nil {
+			return nil, err
+		}
+	}
+	for name, data := range images {
+		files[name] = data
+	}
+	return files, nil
+}
+
+// FromFiles is the inverse of Files. Missing kind files are treated as empty
+// so hand-assembled bundles only need the files they use.
+func FromFiles(files map[string][]byte) (*models.ContentBundle, map[string][]byte, error) {
+	bundle := &models.ContentBundle{}
+	raw, ok := files[manifestFile]
+	if !ok {
+		return nil, nil, fmt.Errorf("bundle is missing %s", manifestFile)
+	}
+	if err := json.Unmarshal(raw, &bundle.Manifest); err != nil {
+		return nil, nil, fmt.Errorf("decode %s: %w", manifestFile, err)
+	}
+	for _, file := range bundleFiles {
+		raw, ok := files[file.name]
+		if !ok {
+			continue
+		}
+		if err := json.Unmarshal(raw, file.value(bundle)); err != nil {
+			return nil, nil, fmt.Errorf("decode %s: %w", file.name, err)
+		}
+	}
+	images := map[string][]byte{}
+	for name, data := range files {
+		if strings.HasPrefix(name, imagesDir+"/") {
+			images[name] = data
+		}
+	}
+	return bundle, images, nil
+}
+
+func WriteDir(dir string, files map[string][]byte) error {
+	for name, data := range files {
+		target := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(target, data, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func ReadDir(dir string) (map[string][]byte, error) {
+	files := map[string][]byte{}
+	err := filepath.WalkDir(dir, func(current string, entry os.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, current)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(current)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = data
+		return nil
+	})
+	return files, err
+}
+
+// WriteZip packs files into a zip archive with stable ordering.
+func WriteZip(w io.Writer, files map[string][]byte) error {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	archive := zip.NewWriter(w)
+	for _, name := range names {
+		entry, err := archive.Create(name)
+		if err != nil {
+			return err
+		}
+		if _, err := entry.Write(files[name]); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+func ReadZip(data []byte) (map[string][]byte, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+	files := map[string][]byte{}
+	for _, entry := range archive.File {
+		if entry.FileInfo().IsDir() {
+			continue
+		}
+		name := path.Clean(entry.Name)
+		if strings.HasPrefix(name, "../") || path.IsAbs(name) {
+			return nil, fmt.Errorf("invalid path %q in bundle", entry.Name)
+		}
+		reader, err := entry.Open()
+		if err != nil {
+			return nil, err
+		}
+		content, err := io.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			return nil, err
+		}
+		files[name] = content
+	}
+	return files, nil
+}
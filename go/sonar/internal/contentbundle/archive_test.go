@@ -0,0 +1,61 @@
+package contentbundle
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/google/uuid"
+)
+
+func TestZipRoundTripKeepsBundleAndImages(t *testing.T) {
+	bundle := models.BuildContentBundle(models.ContentBundleSource{
+		ShrineTemplates: []models.ShrineTemplate{{ID: uuid.New(), Name: "Moon shrine"}},
+	}, models.ContentBundleSelection{}, time.Now())
+	images := map[string][]byte{"images/abc.png": []byte("png")}
+
+	files, err := Files(bundle, images)
+	if err != nil {
+		t.Fatalf("files: %v", err)
+	}
+	var archive bytes.Buffer
+	if err := WriteZip(&archive, files); err != nil {
+		t.Fatalf("write zip: %v", err)
+	}
+	read, err := ReadZip(archive.Bytes())
+	if err != nil {
+		t.Fatalf("read zip: %v", err)
+	}
+	decoded, decodedImages, err := FromFiles(read)
+	if err != nil {
+		t.Fatalf("from files: %v", err)
+	}
+	if decoded.Manifest.FormatVersion != models.ContentBundleFormatVersion ||
+		len(decoded.ShrineTemplates) != 1 || decoded.ShrineTemplates[0].Name != "Moon shrine" {
+		t.Fatalf("unexpected bundle after round trip: %+v", decoded)
+	}
+	if string(decodedImages["images/abc.png"]) != "png" {
+		t.Fatalf("expected embedded image to survive, got %v", decodedImages)
+	}
+}
+
+func TestRestoreImagesRewritesUploadedURLs(t *testing.T) {
+	url := "https://crew-points-of-interest.s3.amazonaws.com/spells/ember.png"
+	bundle := &models.ContentBundle{Spells: []models.Spell{{Name: "Ember", IconURL: url}}}
+	bundle.Manifest.Images = DescribeImages(bundle)
+	if bundle.Manifest.Images[0].Bucket != "crew-points-of-interest" || bundle.Manifest.Images[0].Key != "spells/ember.png" {
+		t.Fatalf("expected the S3 location to be parsed, got %+v", bundle.Manifest.Images[0])
+	}
+	bundle.Manifest.Images[0].Path = "images/ember.png"
+
+	err := RestoreImages(bundle, map[string][]byte{"images/ember.png": []byte("png")}, func(bucket, key string, data []byte) (string, error) {
+		return "https://" + bucket + ".s3.amazonaws.com/imported/" + key, nil
+	})
+	if err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	if bundle.Spells[0].IconURL != "https://crew-points-of-interest.s3.amazonaws.com/imported/spells/ember.png" {
+		t.Fatalf("expected icon URL to be rewritten, got %q", bundle.Spells[0].IconURL)
+	}
+}
@@ -0,0 +1,131 @@
+package contentbundle
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+)
+
+// DescribeImages lists the distinct images the bundle references, with the
+// S3 bucket and key parsed out of URLs that point at S3.
+func DescribeImages(bundle *models.ContentBundle) []models.ContentBundleImage {
+	seen := map[string]bool{}
+	images := []models.ContentBundleImage{}
+	bundle.EachImageURL(func(imageURL *string) {
+		if seen[*imageURL] {
+			return
+		}
+		seen[*imageURL] = true
+		image := models.ContentBundleImage{URL: *imageURL}
+		image.Bucket, image.Key = parseS3URL(*imageURL)
+		images = append(images, image)
+	})
+	return images
+}
+
+// parseS3URL understands the https://<bucket>.s3.amazonaws.com/<key> URLs
+// the AWS client returns from uploads.
+func parseS3URL(raw string) (string, string) {
+	parsed, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return "", ""
+	}
+	host := strings.ToLower(parsed.Host)
+	idx := strings.Index(host, ".s3.")
+	if idx <= 0 || !strings.HasSuffix(host, ".amazonaws.com") {
+		return "", ""
+	}
+	key := strings.TrimPrefix(parsed.Path, "/")
+	if key == "" {
+		return "", ""
+	}
+	return host[:idx], key
+}
+
+// EmbedImages downloads every image in the manifest into images/ and records
+// the embedded path. Images that can't be fetched stay referenced by URL.
+func EmbedImages(ctx context.Context, httpClient *http.Client, bundle *models.ContentBundle) (map[string][]byte, []error) {
+	files := map[string][]byte{}
+	errs := []error{}
+	for idx := range bundle.Manifest.Images {
+		image := &bundle.Manifest.Images[idx]
+		data, err := downloadImage(ctx, httpClient, image.URL)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("embed %s: %w", image.URL, err))
+			continue
+		}
+		sum := sha256.Sum256([]byte(image.URL))
+		image.Path = imagesDir + "/" + hex.EncodeToString(sum[:8]) + imageExtension(image.URL)
+		files[image.Path] = data
+	}
+	return files, errs
+}
+
+func downloadImage(ctx context.Context, httpClient *http.Client, imageURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imageURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+func imageExtension(imageURL string) string {
+	parsed, err := url.Parse(imageURL)
+	if err != nil {
+		return ""
+	}
+	ext := path.Ext(parsed.Path)
+	if len(ext) > 6 {
+		return ""
+	}
+	return ext
+}
+
+// Uploader stores image bytes under bucket/key and returns the public URL.
+type Uploader func(bucket string, key string, data []byte) (string, error)
+
+// RestoreImages uploads embedded images into the target environment and
+// points the bundle's records at the uploaded copies. It runs before
+// planning so the diff reflects the final URLs.
+func RestoreImages(bundle *models.ContentBundle, images map[string][]byte, upload Uploader) error {
+	rewrites := map[string]string{}
+	for _, image := range bundle.Manifest.Images {
+		if image.Path == "" {
+			continue
+		}
+		data, ok := images[image.Path]
+		if !ok {
+			return fmt.Errorf("bundle is missing embedded image %s", image.Path)
+		}
+		if image.Bucket == "" || image.Key == "" {
+			return fmt.Errorf("embedded image %s has no S3 location", image.Path)
+		}
+		uploaded, err := upload(image.Bucket, image.Key, data)
+		if err != nil {
+			return fmt.Errorf("upload %s: %w", image.Path, err)
+		}
+		rewrites[image.URL] = uploaded
+	}
+	bundle.EachImageURL(func(imageURL *string) {
+		if rewritten, ok := rewrites[*imageURL]; ok {
+			*imageURL = rewritten
+		}
+	})
+	return nil
+}
@@ -0,0 +1,85 @@
+package contentbundle
+
+import (
+	"context"
+	"time"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/db"
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+)
+
+// LoadSource reads everything in the environment a bundle can be built from
+// or imported against.
+func LoadSource(ctx context.Context, dbClient db.DbClient) (models.ContentBundleSource, error) {
+	source := models.ContentBundleSource{}
+	var err error
+	if source.ZoneGenres, err = dbClient.ZoneGenre().FindAll(ctx, true); err != nil {
+		return source, err
+	}
+	if source.ZoneKinds, err = dbClient.ZoneKind().FindAll(ctx); err != nil {
+		return source, err
+	}
+	if source.LocationArchetypes, err = dbClient.LocationArchetype().FindAll(ctx); err != nil {
+		return source, err
+	}
+	if source.InventoryItems, err = dbClient.InventoryItem().FindAllInventoryItems(ctx); err != nil {
+		return source, err
+	}
+	if source.Spells, err = dbClient.Spell().FindAll(ctx); err != nil {
+		return source, err
+	}
+	if source.MonsterTemplates, err = dbClient.MonsterTemplate().FindAll(ctx); err != nil {
+		return source, err
+	}
+	if source.ChallengeTemplates, err = dbClient.ChallengeTemplate().FindAll(ctx); err != nil {
+		return source, err
+	}
+	if source.ScenarioTemplates, err = dbClient.ScenarioTemplate().FindAll(ctx); err != nil {
+		return source, err
+	}
+	if source.ExpositionTemplates, err = dbClient.ExpositionTemplate().FindAll(ctx); err != nil {
+		return source, err
+	}
+	if source.ShrineTemplates, err = dbClient.ShrineTemplate().FindAll(ctx); err != nil {
+		return source, err
+	}
+	if source.QuestArchetypes, err = dbClient.QuestArchetype().FindAll(ctx); err != nil {
+		return source, err
+	}
+	if source.QuestArchetypeNodes, err = dbClient.QuestArchetypeNode().FindAll(ctx); err != nil {
+		return source, err
+	}
+	return source, nil
+}
+
+// Export builds a bundle of the selected content. Images stay referenced by
+// URL until EmbedImages is called.
+func Export(
+	ctx context.Context,
+	dbClient db.DbClient,
+	selection models.ContentBundleSelection,
+	sourceName string,
+) (*models.ContentBundle, error) {
+	source, err := LoadSource(ctx, dbClient)
+	if err != nil {
+		return nil, err
+	}
+	bundle := models.BuildContentBundle(source, selection, time.Now())
+	bundle.Manifest.Source = sourceName
+	bundle.Manifest.Images = DescribeImages(bundle)
+	return bundle, nil
+}
+
+// Plan diffs bundle against the environment without writing anything.
+func Plan(
+	ctx context.Context,
+	dbClient db.DbClient,
+	bundle *models.ContentBundle,
+	policy models.ContentBundleConflictPolicy,
+) (*models.ContentBundleImportPlan, error) {
+	source, err := LoadSource(ctx, dbClient)
+	if err != nil {
+		return nil, err
+	}
+	return models.PlanContentBundleImport(bundle, source, policy)
+}
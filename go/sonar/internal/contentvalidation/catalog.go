@@ -0,0 +1,51 @@
+package contentvalidation
+
+import (
+	"context"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/db"
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+)
+
+// LoadCatalog reads every quest archetype, node, main story template, world
+// change and item source the validator cross-references.
+func LoadCatalog(ctx context.Context, dbClient db.DbClient) (*models.ContentValidationCatalog, error) {
+	questArchetypes, err := dbClient.QuestArchetype().FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := dbClient.QuestArchetypeNode().FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	mainStoryTemplates, err := dbClient.MainStoryTemplate().FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	worldChanges, err := dbClient.StoryWorldChange().FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	items, err := dbClient.InventoryItem().FindAllActiveInventoryItems(ctx)
+	if err != nil {
+		return nil, err
+	}
+	treasureChests, err := dbClient.TreasureChest().FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	catalog := models.NewContentValidationCatalog(
+		questArchetypes,
+		nodes,
+		mainStoryTemplates,
+		worldChanges,
+		items,
+	)
+	for _, chest := range treasureChests {
+		for _, item := range chest.Items {
+			catalog.ExtraItemSources[item.InventoryItemID] = true
+		}
+	}
+	return catalog, nil
+}
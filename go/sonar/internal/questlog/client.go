@@ -115,6 +115,8 @@ type Quest struct {
 	DebriefedAt               *time.Time                `json:"debriefedAt,omitempty"`
 	TurnedInAt                *time.Time                `json:"turnedInAt,omitempty"`
 	CompletionCount           int                       `json:"completionCount,omitempty"`
+	EstimatedWalkingMeters    int                       `json:"estimatedWalkingMeters,omitempty"`
+	EstimatedWalkingMinutes   int                       `json:"estimatedWalkingMinutes,omitempty"`
 	ReadyToClose              bool                      `json:"readyToClose"`
 	ReadyToTurnIn             bool                      `json:"readyToTurnIn"`
 	CanCloseRemotely          bool                      `json:"canCloseRemotely"`
@@ -409,6 +411,8 @@ func (c *questlogClient) GetQuestLog(ctx context.Context, userID uuid.UUID, zone
 			Category:                 quest.Category,
 			AcceptanceDialogue:       []models.DialogueMessage(quest.AcceptanceDialogue),
 			ImageUrl:                 quest.ImageURL,
+			EstimatedWalkingMeters:   quest.EstimatedWalkingMeters,
+			EstimatedWalkingMinutes:  quest.EstimatedWalkingMinutes,
 			RewardMode:               quest.RewardMode,
 			RandomRewardSize:         quest.RandomRewardSize,
 			Gold:                     quest.Gold,
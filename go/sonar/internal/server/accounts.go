@@ -0,0 +1,155 @@
+package server
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/auth"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Login-method and account routes pass straight through to the
+// authenticator, which owns the users table and the user data registry.
+
+func (s *server) getLoginMethods(ctx *gin.Context) {
+	methods, err := s.authClient.GetLoginMethods(ctx, &auth.VerifyTokenRequest{Token: bearerToken(ctx)})
+	if err != nil {
+		ctx.JSON(authenticatorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, methods)
+}
+
+func (s *server) linkPhoneNumber(ctx *gin.Context) {
+	var requestBody struct {
+		PhoneNumber string `json:"phoneNumber" binding:"required"`
+		Code        string `json:"code" binding:"required"`
+	}
+	if err := ctx.ShouldBindJSON(&requestBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	methods, err := s.authClient.LinkPhoneNumber(ctx, &auth.LinkPhoneRequest{
+		Token:       bearerToken(ctx),
+		PhoneNumber: requestBody.PhoneNumber,
+		Code:        requestBody.Code,
+	})
+	if err != nil {
+		ctx.JSON(authenticatorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, methods)
+}
+
+func (s *server) sendEmailLinkCode(ctx *gin.Context) {
+	var requestBody struct {
+		Email string `json:"email" binding:"required"`
+	}
+	if err := ctx.ShouldBindJSON(&requestBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := s.authClient.SendEmailLinkCode(ctx, &auth.LinkEmailCodeRequest{Token: bearerToken(ctx), Email: requestBody.Email}); err != nil {
+		ctx.JSON(authenticatorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"sent": true})
+}
+
+func (s *server) linkEmail(ctx *gin.Context) {
+	var requestBody struct {
+		Email    string `json:"email" binding:"required"`
+		Code     string `json:"code" binding:"required"`
+		Password string `json:"password" binding:"required"`
+	}
+	if err := ctx.ShouldBindJSON(&requestBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	methods, err := s.authClient.LinkEmail(ctx, &auth.LinkEmailRequest{
+		Token:    bearerToken(ctx),
+		Email:    requestBody.Email,
+		Code:     requestBody.Code,
+		Password: requestBody.Password,
+	})
+	if err != nil {
+		ctx.JSON(authenticatorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, methods)
+}
+
+func (s *server) linkGoogle(ctx *gin.Context) {
+	var requestBody struct {
+		IDToken string `json:"idToken" binding:"required"`
+	}
+	if err := ctx.ShouldBindJSON(&requestBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	methods, err := s.authClient.LinkGoogle(ctx, &auth.LinkGoogleRequest{Token: bearerToken(ctx), IDToken: requestBody.IDToken})
+	if err != nil {
+		ctx.JSON(authenticatorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, methods)
+}
+
+func (s *server) exportAccountData(ctx *gin.Context) {
+	archive, err := s.authClient.ExportUserData(ctx, &auth.VerifyTokenRequest{Token: bearerToken(ctx)})
+	if err != nil {
+		ctx.JSON(authenticatorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.Header("Content-Disposition", `attachment; filename="user-data.zip"`)
+	ctx.Data(http.StatusOK, "application/zip", archive)
+}
+
+func (s *server) deleteAccount(ctx *gin.Context) {
+	if err := s.authClient.DeleteAccount(ctx, &auth.VerifyTokenRequest{Token: bearerToken(ctx)}); err != nil {
+		ctx.JSON(authenticatorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"deleted": true})
+}
+
+func (s *server) mergeUsers(ctx *gin.Context) {
+	user, err := s.getAuthenticatedUser(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	var requestBody struct {
+		FromUserID uuid.UUID `json:"fromUserId" binding:"required"`
+		IntoUserID uuid.UUID `json:"intoUserId" binding:"required"`
+	}
+	if err := ctx.ShouldBindJSON(&requestBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	merged, err := s.authClient.MergeUsers(ctx, &auth.MergeUsersRequest{
+		Token:      bearerToken(ctx),
+		FromUserID: requestBody.FromUserID,
+		IntoUserID: requestBody.IntoUserID,
+	})
+	if err != nil {
+		ctx.JSON(authenticatorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	log.Printf("[auth][accounts] merged from=%s into=%s by=%s", requestBody.FromUserID, requestBody.IntoUserID, user.ID)
+
+	ctx.JSON(http.StatusOK, merged)
+}
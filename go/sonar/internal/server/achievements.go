@@ -0,0 +1,520 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	stdErrors "errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/jobs"
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"gorm.io/gorm"
+)
+
+type achievementView struct {
+	ID               uuid.UUID                     `json:"id"`
+	Key              string                        `json:"key,omitempty"`
+	Name             string                        `json:"name"`
+	Description      string                        `json:"description"`
+	IconURL          string                        `json:"iconUrl,omitempty"`
+	Metric           models.AchievementMetric      `json:"metric,omitempty"`
+	Threshold        int                           `json:"threshold,omitempty"`
+	Hidden           bool                          `json:"hidden"`
+	TitleName        string                        `json:"titleName,omitempty"`
+	RewardExperience int                           `json:"rewardExperience,omitempty"`
+	RewardGold       int                           `json:"rewardGold,omitempty"`
+	ItemRewards      models.AchievementItemRewards `json:"itemRewards,omitempty"`
+	Progress         int                           `json:"progress"`
+	UnlockedAt       *time.Time                    `json:"unlockedAt,omitempty"`
+	RewardsClaimable bool                          `json:"rewardsClaimable"`
+}
+
+type achievementDefinitionRequest struct {
+	Key              string                        `json:"key"`
+	Name             string                        `json:"name"`
+	Description      string                        `json:"description"`
+	IconURL          string                        `json:"iconUrl"`
+	Metric           string                        `json:"metric"`
+	Threshold        int                           `json:"threshold"`
+	ZoneID           *uuid.UUID                    `json:"zoneId"`
+	ZoneKind         string                        `json:"zoneKind"`
+	GenreID          *uuid.UUID                    `json:"genreId"`
+	Hidden           bool                          `json:"hidden"`
+	Active           *bool                         `json:"active"`
+	SortOrder        int                           `json:"sortOrder"`
+	TitleName        string                        `json:"titleName"`
+	RewardMode       string                        `json:"rewardMode"`
+	RandomRewardSize string                        `json:"randomRewardSize"`
+	RewardExperience int                           `json:"rewardExperience"`
+	RewardGold       int                           `json:"rewardGold"`
+	ItemRewards      models.AchievementItemRewards `json:"itemRewards"`
+}
+
+type setActiveTitleRequest struct {
+	TitleID *uuid.UUID `json:"titleId"`
+}
+
+// buildAchievementViews merges definitions with the user's progress. Hidden
+// achievements only reveal their details once unlocked.
+func buildAchievementViews(
+	definitions []models.AchievementDefinition,
+	records []models.UserAchievement,
+) []achievementView {
+	recordByAchievementID := make(map[uuid.UUID]models.UserAchievement, len(records))
+	for _, record := range records {
+		recordByAchievementID[record.AchievementID] = record
+	}
+
+	views := make([]achievementView, 0, len(definitions))
+	for _, definition := range definitions {
+		record, hasRecord := recordByAchievementID[definition.ID]
+		unlocked := hasRecord && record.IsUnlocked()
+		if definition.Hidden && !unlocked {
+			views = append(views, achievementView{
+				ID:     definition.ID,
+				Name:   "Hidden achievement",
+				Hidden: true,
+			})
+			continue
+		}
+
+		view := achievementView{
+			ID:               definition.ID,
+			Key:              definition.Key,
+			Name:             definition.Name,
+			Description:      definition.Description,
+			IconURL:          definition.IconURL,
+			Metric:           definition.Metric,
+			Threshold:        definition.Threshold,
+			Hidden:           definition.Hidden,
+			TitleName:        definition.TitleName,
+			RewardExperience: definition.RewardExperience,
+			RewardGold:       definition.RewardGold,
+			ItemRewards:      definition.ItemRewards,
+		}
+		if hasRecord {
+			view.Progress = min(record.Progress, definition.Threshold)
+			view.UnlockedAt = record.UnlockedAt
+			view.RewardsClaimable = unlocked && record.RewardsGrantedAt == nil
+		}
+		views = append(views, view)
+	}
+	return views
+}
+
+func achievementDefinitionFromRequest(
+	request achievementDefinitionRequest,
+	definition *models.AchievementDefinition,
+) error {
+	key := strings.ToLower(strings.TrimSpace(request.Key))
+	name := strings.TrimSpace(request.Name)
+	if key == "" || name == "" {
+		return fmt.Errorf("key and name are required")
+	}
+	metric, ok := models.NormalizeAchievementMetric(request.Metric)
+	if !ok {
+		return fmt.Errorf("unknown achievement metric %q", request.Metric)
+	}
+	if request.Threshold <= 0 {
+		return fmt.Errorf("threshold must be 1 or greater")
+	}
+	if !metric.SupportsZoneFilters() && (request.ZoneID != nil || request.GenreID != nil || strings.TrimSpace(request.ZoneKind) != "") {
+		return fmt.Errorf("%s achievements cannot be filtered by zone or genre", metric)
+	}
+	if request.RewardExperience < 0 || request.RewardGold < 0 {
+		return fmt.Errorf("rewards cannot be negative")
+	}
+	itemRewards := models.AchievementItemRewards{}
+	for idx, reward := range request.ItemRewards {
+		if reward.InventoryItemID <= 0 || reward.Quantity <= 0 {
+			return fmt.Errorf("itemRewards[%d] needs an inventoryItemId and a positive quantity", idx)
+		}
+		itemRewards = append(itemRewards, reward)
+	}
+	rewardMode := models.RewardModeExplicit
+	if strings.TrimSpace(request.RewardMode) != "" {
+		if !models.IsValidRewardMode(request.RewardMode) {
+			return fmt.Errorf("unknown reward mode %q", request.RewardMode)
+		}
+		rewardMode = models.NormalizeRewardMode(request.RewardMode)
+	}
+
+	definition.Key = key
+	definition.Name = name
+	definition.Description = strings.TrimSpace(request.Description)
+	definition.IconURL = strings.TrimSpace(request.IconURL)
+	definition.Metric = metric
+	definition.Threshold = request.Threshold
+	definition.ZoneID = request.ZoneID
+	definition.ZoneKind = models.NormalizeZoneKind(request.ZoneKind)
+	definition.GenreID = request.GenreID
+	definition.Hidden = request.Hidden
+	definition.Active = request.Active == nil || *request.Active
+	definition.SortOrder = request.SortOrder
+	definition.TitleName = strings.TrimSpace(request.TitleName)
+	definition.RewardMode = rewardMode
+	definition.RandomRewardSize = models.NormalizeRandomRewardSize(request.RandomRewardSize)
+	definition.RewardExperience = request.RewardExperience
+	definition.RewardGold = request.RewardGold
+	definition.ItemRewards = itemRewards
+	return nil
+}
+
+// evaluateAchievements re-checks the user's achievements for one metric after
+// a record for it was written. zoneID is the zone the record belongs to, if
+// any, and lets zone-pinned achievements elsewhere be skipped. Failures are
+// logged rather than surfaced so they never block the action that triggered
+// the evaluation.
+func (s *server) evaluateAchievements(
+	ctx context.Context,
+	userID uuid.UUID,
+	metric models.AchievementMetric,
+	zoneID *uuid.UUID,
+) {
+	unlocked, err := s.dbClient.Achievement().Evaluate(ctx, models.AchievementEvaluation{
+		UserID:  userID,
+		Metrics: []models.AchievementMetric{metric},
+		ZoneID:  zoneID,
+		Now:     time.Now(),
+	})
+	if err != nil {
+		log.Printf("[achievements][evaluate] failed user=%s metric=%s err=%v", userID, metric, err)
+	}
+
+	for i := range unlocked {
+		record := &unlocked[i]
+		if record.RewardsGrantedAt == nil {
+			if _, _, err := s.grantAchievementRewards(ctx, userID, record); err != nil {
+				log.Printf(
+					"[achievements][rewards] failed user=%s achievement=%s err=%v",
+					userID,
+					record.AchievementID,
+					err,
+				)
+			}
+		}
+		s.sendAchievementUnlockedPush(ctx, userID, &record.Achievement)
+	}
+}
+
+// grantAchievementRewards pays out an unlocked achievement through the same
+// reward runtime scenarios use. The reward is claimed before anything is
+// granted, so a concurrent claim can't pay out twice.
+func (s *server) grantAchievementRewards(
+	ctx context.Context,
+	userID uuid.UUID,
+	record *models.UserAchievement,
+) ([]models.ItemAwarded, bool, error) {
+	claimed, err := s.dbClient.Achievement().MarkRewardsGranted(ctx, record.ID, time.Now())
+	if err != nil || !claimed {
+		return nil, false, err
+	}
+
+	definition := &record.Achievement
+	rewardExperience := max(0, definition.RewardExperience)
+	rewardGold := max(0, definition.RewardGold)
+	rewardItems := make([]scenarioRewardItem, 0, len(definition.ItemRewards))
+	for _, reward := range definition.ItemRewards {
+		if reward.InventoryItemID <= 0 || reward.Quantity <= 0 {
+			continue
+		}
+		rewardItems = append(rewardItems, scenarioRewardItem{
+			InventoryItemID: reward.InventoryItemID,
+			Quantity:        reward.Quantity,
+		})
+	}
+
+	if models.NormalizeRewardMode(string(definition.RewardMode)) == models.RewardModeRandom {
+		plan, _, _, err := s.randomRewardPlanForUser(
+			ctx,
+			userID,
+			definition.RandomRewardSize,
+			fmt.Sprintf("achievement:%s:user:%s", definition.ID, userID),
+			nil,
+		)
+		if err != nil {
+			return nil, true, err
+		}
+		rewardExperience += plan.Experience
+		rewardGold += plan.Gold
+		rewardItems = mergeScenarioRewardItems(rewardItems, randomRewardPlanToScenarioItems(plan))
+	}
+
+	itemsAwarded, _, err := s.awardScenarioRewards(
+		ctx,
+		userID,
+		rewardExperience,
+		rewardGold,
+		rewardItems,
+		nil,
+		nil,
+	)
+	return itemsAwarded, true, err
+}
+
+func (s *server) sendAchievementUnlockedPush(
+	ctx context.Context,
+	userID uuid.UUID,
+	definition *models.AchievementDefinition,
+) {
+	if definition == nil {
+		return
+	}
+	body := fmt.Sprintf("You unlocked %s.", definition.Name)
+	if title := strings.TrimSpace(definition.TitleName); title != "" {
+		body = fmt.Sprintf("You unlocked %s and earned the title %q.", definition.Name, title)
+	}
+	s.sendSocialPushToUser(
+		ctx,
+		"achievement-unlocked",
+		userID,
+		"Achievement Unlocked",
+		body,
+		map[string]string{
+			"type":          "achievement_unlocked",
+			"achievementId": definition.ID.String(),
+			"sentAt":        time.Now().UTC().Format(time.RFC3339),
+		},
+	)
+}
+
+func (s *server) getAchievements(ctx *gin.Context) {
+	user, err := s.getAuthenticatedUser(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	definitions, err := s.dbClient.Achievement().FindDefinitions(ctx, false)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	records, err := s.dbClient.Achievement().FindUserAchievements(ctx, user.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, buildAchievementViews(definitions, records))
+}
+
+// claimAchievementRewards pays out unlocked achievements that haven't been
+// rewarded yet, which is where backfilled unlocks end up.
+func (s *server) claimAchievementRewards(ctx *gin.Context) {
+	user, err := s.getAuthenticatedUser(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	pending, err := s.dbClient.Achievement().FindUnclaimedRewards(ctx, user.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	claimedIDs := []uuid.UUID{}
+	itemsAwarded := []models.ItemAwarded{}
+	for i := range pending {
+		items, claimed, err := s.grantAchievementRewards(ctx, user.ID, &pending[i])
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !claimed {
+			continue
+		}
+		claimedIDs = append(claimedIDs, pending[i].AchievementID)
+		itemsAwarded = append(itemsAwarded, items...)
+	}
+
+	updatedUser, err := s.dbClient.User().FindByID(ctx, user.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch updated user: " + err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"user":           updatedUser,
+		"achievementIds": claimedIDs,
+		"itemsAwarded":   itemsAwarded,
+	})
+}
+
+func (s *server) getUserTitles(ctx *gin.Context) {
+	user, err := s.getAuthenticatedUser(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	titles, err := s.dbClient.Achievement().FindUserTitles(ctx, user.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, titles)
+}
+
+func (s *server) setActiveTitle(ctx *gin.Context) {
+	user, err := s.getAuthenticatedUser(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var requestBody setActiveTitleRequest
+	if err := ctx.ShouldBindJSON(&requestBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := s.dbClient.Achievement().SetActiveTitle(ctx, user.ID, requestBody.TitleID); err != nil {
+		if stdErrors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "title not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	titles, err := s.dbClient.Achievement().FindUserTitles(ctx, user.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, titles)
+}
+
+func (s *server) getAchievementDefinitions(ctx *gin.Context) {
+	if _, err := s.getAuthenticatedUser(ctx); err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	definitions, err := s.dbClient.Achievement().FindDefinitions(ctx, true)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	sort.SliceStable(definitions, func(i, j int) bool {
+		return definitions[i].SortOrder < definitions[j].SortOrder
+	})
+	ctx.JSON(http.StatusOK, definitions)
+}
+
+func (s *server) createAchievementDefinition(ctx *gin.Context) {
+	if _, err := s.getAuthenticatedUser(ctx); err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var requestBody achievementDefinitionRequest
+	if err := ctx.ShouldBindJSON(&requestBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	definition := &models.AchievementDefinition{}
+	if err := achievementDefinitionFromRequest(requestBody, definition); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := s.dbClient.Achievement().CreateDefinition(ctx, definition); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusCreated, definition)
+}
+
+func (s *server) updateAchievementDefinition(ctx *gin.Context) {
+	if _, err := s.getAuthenticatedUser(ctx); err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	definitionID, err := uuid.Parse(strings.TrimSpace(ctx.Param("id")))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid achievement ID"})
+		return
+	}
+	definition, err := s.dbClient.Achievement().FindDefinitionByID(ctx, definitionID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if definition == nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "achievement not found"})
+		return
+	}
+
+	var requestBody achievementDefinitionRequest
+	if err := ctx.ShouldBindJSON(&requestBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := achievementDefinitionFromRequest(requestBody, definition); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := s.dbClient.Achievement().UpdateDefinition(ctx, definition); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, definition)
+}
+
+func (s *server) deleteAchievementDefinition(ctx *gin.Context) {
+	if _, err := s.getAuthenticatedUser(ctx); err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	definitionID, err := uuid.Parse(strings.TrimSpace(ctx.Param("id")))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid achievement ID"})
+		return
+	}
+	if err := s.dbClient.Achievement().DeleteDefinition(ctx, definitionID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "achievement deleted"})
+}
+
+// backfillAchievements queues a job that evaluates achievements for every
+// existing user. Pass achievementId to limit it to one definition.
+func (s *server) backfillAchievements(ctx *gin.Context) {
+	if _, err := s.getAuthenticatedUser(ctx); err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	if s.asyncClient == nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "async client unavailable"})
+		return
+	}
+
+	var requestBody jobs.BackfillAchievementsTaskPayload
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&requestBody); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	payloadBytes, err := json.Marshal(requestBody)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if _, err := s.asyncClient.Enqueue(asynq.NewTask(jobs.BackfillAchievementsTaskType, payloadBytes)); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusAccepted, gin.H{"status": "queued"})
+}
@@ -0,0 +1,89 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/google/uuid"
+)
+
+func TestBuildAchievementViewsMasksLockedHiddenAchievements(t *testing.T) {
+	hiddenID := uuid.New()
+	views := buildAchievementViews(
+		[]models.AchievementDefinition{{
+			ID:        hiddenID,
+			Name:      "Secret Keeper",
+			Metric:    models.AchievementMetricShrineUses,
+			Threshold: 5,
+			Hidden:    true,
+		}},
+		[]models.UserAchievement{{AchievementID: hiddenID, Progress: 2}},
+	)
+	if len(views) != 1 {
+		t.Fatalf("expected one view, got %d", len(views))
+	}
+	if views[0].Name == "Secret Keeper" || views[0].Metric != "" || views[0].Progress != 0 {
+		t.Fatalf("expected locked hidden achievement to be masked, got %+v", views[0])
+	}
+}
+
+func TestBuildAchievementViewsReportsProgressAndClaimableRewards(t *testing.T) {
+	definitionID := uuid.New()
+	unlockedAt := time.Now()
+	views := buildAchievementViews(
+		[]models.AchievementDefinition{{
+			ID:         definitionID,
+			Name:       "Chest Hunter",
+			Threshold:  3,
+			Hidden:     true,
+			RewardGold: 50,
+		}},
+		[]models.UserAchievement{{
+			AchievementID: definitionID,
+			Progress:      7,
+			UnlockedAt:    &unlockedAt,
+		}},
+	)
+	if views[0].Name != "Chest Hunter" {
+		t.Fatalf("expected unlocked hidden achievement to be revealed")
+	}
+	if views[0].Progress != 3 {
+		t.Fatalf("expected progress capped at threshold, got %d", views[0].Progress)
+	}
+	if !views[0].RewardsClaimable {
+		t.Fatalf("expected unpaid unlock to be claimable")
+	}
+}
+
+func TestAchievementDefinitionFromRequestRejectsZoneFilteredLevels(t *testing.T) {
+	zoneID := uuid.New()
+	err := achievementDefinitionFromRequest(achievementDefinitionRequest{
+		Key:       "level_ten",
+		Name:      "Seasoned",
+		Metric:    string(models.AchievementMetricUserLevel),
+		Threshold: 10,
+		ZoneID:    &zoneID,
+	}, &models.AchievementDefinition{})
+	if err == nil {
+		t.Fatalf("expected zone filter on user level to be rejected")
+	}
+}
+
+func TestAchievementDefinitionFromRequestDefaultsToExplicitRewards(t *testing.T) {
+	definition := &models.AchievementDefinition{}
+	if err := achievementDefinitionFromRequest(achievementDefinitionRequest{
+		Key:       " Fountain_Finder ",
+		Name:      "Fountain Finder",
+		Metric:    "healing_fountains_discovered",
+		Threshold: 3,
+	}, definition); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if definition.Key != "fountain_finder" {
+		t.Fatalf("expected normalized key, got %q", definition.Key)
+	}
+	if definition.RewardMode != models.RewardModeExplicit || !definition.Active {
+		t.Fatalf("expected explicit, active definition, got %+v", definition)
+	}
+}
@@ -0,0 +1,492 @@
+package server
+
+import (
+	"context"
+	stdErrors "errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/db"
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type bountyView struct {
+	Template    models.BountyTemplate `json:"template"`
+	Cadence     models.BountyCadence  `json:"cadence"`
+	PeriodStart time.Time             `json:"periodStart"`
+	PeriodEnd   time.Time             `json:"periodEnd"`
+	ProgressID  *uuid.UUID            `json:"progressId,omitempty"`
+	Progress    int                   `json:"progress"`
+	TargetCount int                   `json:"targetCount"`
+	CompletedAt *time.Time            `json:"completedAt,omitempty"`
+	RewardedAt  *time.Time            `json:"rewardedAt,omitempty"`
+	Claimable   bool                  `json:"claimable"`
+}
+
+type bountyTemplateRequest struct {
+	Name               string     `json:"name"`
+	Description        string     `json:"description"`
+	Cadence            string     `json:"cadence"`
+	ObjectiveType      string     `json:"objectiveType"`
+	TargetCount        int        `json:"targetCount"`
+	MonsterAffinity    string     `json:"monsterAffinity"`
+	PointOfInterestTag string     `json:"pointOfInterestTag"`
+	ZoneID             *uuid.UUID `json:"zoneId"`
+	ZoneKind           string     `json:"zoneKind"`
+	Weight             int        `json:"weight"`
+	RandomRewardSize   string     `json:"randomRewardSize"`
+	Active             *bool      `json:"active"`
+}
+
+func bountyTemplateFromRequest(
+	request bountyTemplateRequest,
+	template *models.BountyTemplate,
+) error {
+	name := strings.TrimSpace(request.Name)
+	if name == "" {
+		return fmt.Errorf("name is required")
+	}
+	cadence, ok := models.NormalizeBountyCadence(request.Cadence)
+	if !ok {
+		return fmt.Errorf("unknown bounty cadence %q", request.Cadence)
+	}
+	objectiveType, ok := models.NormalizeBountyObjectiveType(request.ObjectiveType)
+	if !ok {
+		return fmt.Errorf("unknown bounty objective %q", request.ObjectiveType)
+	}
+	if request.TargetCount <= 0 {
+		return fmt.Errorf("targetCount must be 1 or greater")
+	}
+	monsterAffinity := strings.TrimSpace(request.MonsterAffinity)
+	if monsterAffinity != "" {
+		if objectiveType != models.BountyObjectiveDefeatMonsters {
+			return fmt.Errorf("monsterAffinity only applies to %s bounties", models.BountyObjectiveDefeatMonsters)
+		}
+		if !models.IsValidDamageAffinity(monsterAffinity) {
+			return fmt.Errorf("unknown monster affinity %q", monsterAffinity)
+		}
+		monsterAffinity = string(models.NormalizeDamageAffinity(monsterAffinity))
+	}
+	pointOfInterestTag := strings.TrimSpace(request.PointOfInterestTag)
+	if pointOfInterestTag != "" && objectiveType != models.BountyObjectiveCompleteScenarios {
+		return fmt.Errorf("pointOfInterestTag only applies to %s bounties", models.BountyObjectiveCompleteScenarios)
+	}
+
+	template.Name = name
+	template.Description = strings.TrimSpace(request.Description)
+	template.Cadence = cadence
+	template.ObjectiveType = objectiveType
+	template.TargetCount = request.TargetCount
+	template.MonsterAffinity = monsterAffinity
+	template.PointOfInterestTag = pointOfInterestTag
+	template.ZoneID = request.ZoneID
+	template.ZoneKind = models.NormalizeZoneKind(request.ZoneKind)
+	template.Weight = max(1, request.Weight)
+	template.RandomRewardSize = models.NormalizeRandomRewardSize(request.RandomRewardSize)
+	template.Active = request.Active == nil || *request.Active
+	return nil
+}
+
+// bountyPeriodEnd is when the board for periodStart rolls over.
+func bountyPeriodEnd(cadence models.BountyCadence, periodStart time.Time) time.Time {
+	if cadence == models.BountyCadenceWeekly {
+		return periodStart.AddDate(0, 0, 7)
+	}
+	return periodStart.AddDate(0, 0, 1)
+}
+
+// zoneBountyTemplates narrows templates to those the zone can offer.
+func zoneBountyTemplates(templates []models.BountyTemplate, zone *models.Zone) []models.BountyTemplate {
+	available := make([]models.BountyTemplate, 0, len(templates))
+	for _, template := range templates {
+		if template.AvailableInZone(zone.ID, zone.Kind) {
+			available = append(available, template)
+		}
+	}
+	return available
+}
+
+// buildBountyBoard lays out a user's bounties for the zone across every
+// cadence, filling in whatever progress has been recorded.
+func buildBountyBoard(
+	templates []models.BountyTemplate,
+	progress []models.UserBountyProgress,
+	userID uuid.UUID,
+	zoneID uuid.UUID,
+	now time.Time,
+) []bountyView {
+	type progressKey struct {
+		templateID  uuid.UUID
+		periodStart int64
+	}
+	progressByKey := make(map[progressKey]models.UserBountyProgress, len(progress))
+	for _, record := range progress {
+		progressByKey[progressKey{record.BountyTemplateID, record.PeriodStart.Unix()}] = record
+	}
+
+	views := []bountyView{}
+	for _, cadence := range models.BountyCadences {
+		periodStart := cadence.PeriodStart(now)
+		for _, template := range models.SelectBounties(templates, userID, zoneID, cadence, periodStart) {
+			view := bountyView{
+				Template:    template,
+				Cadence:     cadence,
+				PeriodStart: periodStart,
+				PeriodEnd:   bountyPeriodEnd(cadence, periodStart),
+				TargetCount: template.TargetCount,
+			}
+			if record, ok := progressByKey[progressKey{template.ID, periodStart.Unix()}]; ok {
+				progressID := record.ID
+				view.ProgressID = &progressID
+				view.Progress = record.Progress
+				view.TargetCount = record.TargetCount
+				view.CompletedAt = record.CompletedAt
+				view.RewardedAt = record.RewardedAt
+				view.Claimable = record.IsClaimable()
+			}
+			views = append(views, view)
+		}
+	}
+	return views
+}
+
+// trackBountyEvent counts a completion against any bounty on the user's
+// current boards for the event's zone. Failures are logged so they never
+// block the action being tracked.
+func (s *server) trackBountyEvent(ctx context.Context, userID uuid.UUID, event models.BountyEvent) {
+	if event.ZoneID == uuid.Nil {
+		return
+	}
+	zone, err := s.dbClient.Zone().FindByID(ctx, event.ZoneID)
+	if err != nil {
+		log.Printf("[bounties][track] zone lookup failed user=%s zone=%s err=%v", userID, event.ZoneID, err)
+		return
+	}
+	templates, err := s.dbClient.Bounty().FindTemplates(ctx, false)
+	if err != nil {
+		log.Printf("[bounties][track] template lookup failed user=%s zone=%s err=%v", userID, event.ZoneID, err)
+		return
+	}
+	templates = zoneBountyTemplates(templates, zone)
+
+	now := time.Now()
+	for _, cadence := range models.BountyCadences {
+		periodStart := cadence.PeriodStart(now)
+		for _, template := range models.SelectBounties(templates, userID, zone.ID, cadence, periodStart) {
+			if !template.Matches(event) {
+				continue
+			}
+			_, completed, err := s.dbClient.Bounty().IncrementProgress(ctx, userID, zone.ID, &template, periodStart, now)
+			if err != nil {
+				log.Printf(
+					"[bounties][track] failed user=%s zone=%s bounty=%s err=%v",
+					userID,
+					zone.ID,
+					template.ID,
+					err,
+				)
+				continue
+			}
+			if completed {
+				s.sendBountyCompletedPush(ctx, userID, zone, &template)
+			}
+		}
+	}
+}
+
+// monsterBoostedAffinities lists the damage affinities a monster's template
+// boosts, which is what bounties and spawn rules treat as its affinities.
+func monsterBoostedAffinities(monster *models.Monster) []string {
+	if monster == nil || monster.Template == nil {
+		return nil
+	}
+	bonuses := monster.Template.AffinityBonuses()
+	affinities := []string{}
+	for _, affinity := range models.DamageAffinities {
+		if bonuses.DamageBonusPercentForAffinity(string(affinity)) > 0 {
+			affinities = append(affinities, string(affinity))
+		}
+	}
+	return affinities
+}
+
+func pointOfInterestBountyTags(pointOfInterest *models.PointOfInterest) []string {
+	if pointOfInterest == nil {
+		return nil
+	}
+	tags := make([]string, 0, len(pointOfInterest.Tags))
+	for _, tag := range pointOfInterest.Tags {
+		tags = append(tags, tag.Value)
+	}
+	return tags
+}
+
+func (s *server) sendBountyCompletedPush(
+	ctx context.Context,
+	userID uuid.UUID,
+	zone *models.Zone,
+	template *models.BountyTemplate,
+) {
+	s.sendSocialPushToUser(
+		ctx,
+		"bounty-completed",
+		userID,
+		"Bounty Complete",
+		fmt.Sprintf("You completed %s in %s. Claim your reward on the bounty board.", template.Name, zone.Name),
+		map[string]string{
+			"type":     "bounty_completed",
+			"zoneId":   zone.ID.String(),
+			"bountyId": template.ID.String(),
+			"sentAt":   time.Now().UTC().Format(time.RFC3339),
+		},
+	)
+}
+
+func (s *server) getZoneBounties(ctx *gin.Context) {
+	user, err := s.getAuthenticatedUser(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	zoneID, err := uuid.Parse(strings.TrimSpace(ctx.Param("id")))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid zone ID"})
+		return
+	}
+	zone, err := s.dbClient.Zone().FindByID(ctx, zoneID)
+	if err != nil {
+		if stdErrors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "zone not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	templates, err := s.dbClient.Bounty().FindTemplates(ctx, false)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	now := time.Now()
+	periodStarts := make([]time.Time, 0, len(models.BountyCadences))
+	for _, cadence := range models.BountyCadences {
+		periodStarts = append(periodStarts, cadence.PeriodStart(now))
+	}
+	progress, err := s.dbClient.Bounty().FindProgressForZone(ctx, user.ID, zone.ID, periodStarts)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	streaks, err := s.dbClient.Bounty().FindStreaks(ctx, user.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"bounties": buildBountyBoard(zoneBountyTemplates(templates, zone), progress, user.ID, zone.ID, now),
+		"streaks":  streaks,
+	})
+}
+
+// claimBounty pays out a completed bounty through the random reward runtime.
+// Consecutive periods with a claim build a streak that scales the experience
+// and gold.
+func (s *server) claimBounty(ctx *gin.Context) {
+	user, err := s.getAuthenticatedUser(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	progressID, err := uuid.Parse(strings.TrimSpace(ctx.Param("id")))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid bounty ID"})
+		return
+	}
+
+	record, streak, err := s.dbClient.Bounty().ClaimReward(ctx, user.ID, progressID, time.Now())
+	if err != nil {
+		if stdErrors.Is(err, db.ErrBountyNotClaimable) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var rewardContext *models.RandomRewardContext
+	if zone, err := s.dbClient.Zone().FindByID(ctx, record.ZoneID); err == nil {
+		rewardContext = buildRandomRewardContextForZone(zone)
+	}
+	plan, _, _, err := s.randomRewardPlanForUser(
+		ctx,
+		user.ID,
+		record.BountyTemplate.RandomRewardSize,
+		fmt.Sprintf("bounty:%s:user:%s", record.ID, user.ID),
+		rewardContext,
+	)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	streakBonusPercent := models.BountyStreakBonusPercent(streak.CurrentStreak)
+	rewardExperience := plan.Experience * (100 + streakBonusPercent) / 100
+	rewardGold := plan.Gold * (100 + streakBonusPercent) / 100
+	itemsAwarded, _, err := s.awardScenarioRewards(
+		ctx,
+		user.ID,
+		rewardExperience,
+		rewardGold,
+		randomRewardPlanToScenarioItems(plan),
+		nil,
+		nil,
+	)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	updatedUser, err := s.dbClient.User().FindByID(ctx, user.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch updated user: " + err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"bounty":             record,
+		"streak":             streak,
+		"streakBonusPercent": streakBonusPercent,
+		"rewardExperience":   rewardExperience,
+		"rewardGold":         rewardGold,
+		"itemsAwarded":       itemsAwarded,
+		"user":               updatedUser,
+	})
+}
+
+func (s *server) getBountyTemplates(ctx *gin.Context) {
+	if _, err := s.getAuthenticatedUser(ctx); err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	templates, err := s.dbClient.Bounty().FindTemplates(ctx, true)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, templates)
+}
+
+func (s *server) createBountyTemplate(ctx *gin.Context) {
+	if _, err := s.getAuthenticatedUser(ctx); err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var requestBody bountyTemplateRequest
+	if err := ctx.ShouldBindJSON(&requestBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	template := &models.BountyTemplate{}
+	if err := bountyTemplateFromRequest(requestBody, template); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := s.dbClient.Bounty().CreateTemplate(ctx, template); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusCreated, template)
+}
+
+func (s *server) updateBountyTemplate(ctx *gin.Context) {
+	if _, err := s.getAuthenticatedUser(ctx); err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	templateID, err := uuid.Parse(strings.TrimSpace(ctx.Param("id")))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid bounty template ID"})
+		return
+	}
+	template, err := s.dbClient.Bounty().FindTemplateByID(ctx, templateID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if template == nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "bounty template not found"})
+		return
+	}
+
+	var requestBody bountyTemplateRequest
+	if err := ctx.ShouldBindJSON(&requestBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := bountyTemplateFromRequest(requestBody, template); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := s.dbClient.Bounty().UpdateTemplate(ctx, template); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, template)
+}
+
+func (s *server) deleteBountyTemplate(ctx *gin.Context) {
+	if _, err := s.getAuthenticatedUser(ctx); err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	templateID, err := uuid.Parse(strings.TrimSpace(ctx.Param("id")))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid bounty template ID"})
+		return
+	}
+	if err := s.dbClient.Bounty().DeleteTemplate(ctx, templateID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "bounty template deleted"})
+}
+
+func (s *server) trackScenarioBountyEvent(
+	ctx context.Context,
+	participantIDs []uuid.UUID,
+	scenario *models.Scenario,
+) {
+	event := models.BountyEvent{
+		ObjectiveType: models.BountyObjectiveCompleteScenarios,
+		ZoneID:        scenario.ZoneID,
+	}
+	if scenario.PointOfInterestID != nil {
+		pointOfInterest, err := s.dbClient.PointOfInterest().FindByID(ctx, *scenario.PointOfInterestID)
+		if err != nil {
+			log.Printf(
+				"[bounties][track] point of interest lookup failed scenario=%s poi=%s err=%v",
+				scenario.ID,
+				*scenario.PointOfInterestID,
+				err,
+			)
+		} else {
+			event.PointOfInterestTags = pointOfInterestBountyTags(pointOfInterest)
+		}
+	}
+	for _, participantID := range participantIDs {
+		s.trackBountyEvent(ctx, participantID, event)
+	}
+}
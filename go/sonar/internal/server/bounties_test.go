@@ -0,0 +1,87 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/google/uuid"
+)
+
+func TestBuildBountyBoardAttachesProgress(t *testing.T) {
+	template := models.BountyTemplate{
+		ID:            uuid.New(),
+		Name:          "Open two chests",
+		Cadence:       models.BountyCadenceDaily,
+		ObjectiveType: models.BountyObjectiveOpenTreasureChests,
+		TargetCount:   2,
+		Active:        true,
+	}
+	userID := uuid.New()
+	zoneID := uuid.New()
+	now := time.Date(2026, time.March, 12, 9, 0, 0, 0, time.UTC)
+	completedAt := now
+	progressID := uuid.New()
+
+	board := buildBountyBoard(
+		[]models.BountyTemplate{template},
+		[]models.UserBountyProgress{{
+			ID:               progressID,
+			BountyTemplateID: template.ID,
+			PeriodStart:      models.BountyCadenceDaily.PeriodStart(now),
+			Progress:         2,
+			TargetCount:      2,
+			CompletedAt:      &completedAt,
+		}},
+		userID,
+		zoneID,
+		now,
+	)
+	if len(board) != 1 {
+		t.Fatalf("expected one bounty, got %d", len(board))
+	}
+	if board[0].ProgressID == nil || *board[0].ProgressID != progressID || !board[0].Claimable {
+		t.Fatalf("expected completed progress to be claimable, got %+v", board[0])
+	}
+	if !board[0].PeriodEnd.Equal(time.Date(2026, time.March, 13, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected period end %s", board[0].PeriodEnd)
+	}
+}
+
+func TestBountyTemplateFromRequestValidatesFilters(t *testing.T) {
+	err := bountyTemplateFromRequest(bountyTemplateRequest{
+		Name:            "Tavern regular",
+		Cadence:         "daily",
+		ObjectiveType:   "open_treasure_chests",
+		TargetCount:     1,
+		MonsterAffinity: "fire",
+	}, &models.BountyTemplate{})
+	if err == nil {
+		t.Fatalf("expected monster affinity on a chest bounty to be rejected")
+	}
+
+	template := &models.BountyTemplate{}
+	if err := bountyTemplateFromRequest(bountyTemplateRequest{
+		Name:            "Burn them down",
+		Cadence:         " Weekly ",
+		ObjectiveType:   "defeat_monsters",
+		TargetCount:     3,
+		MonsterAffinity: "FIRE",
+	}, template); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if template.Cadence != models.BountyCadenceWeekly || template.MonsterAffinity != "fire" || template.Weight != 1 {
+		t.Fatalf("expected normalized template, got %+v", template)
+	}
+}
+
+func TestMonsterBountyAffinities(t *testing.T) {
+	monster := &models.Monster{Template: &models.MonsterTemplate{
+		FireDamageBonusPercent: 15,
+		IceResistancePercent:   20,
+	}}
+	affinities := monsterBoostedAffinities(monster)
+	if len(affinities) != 1 || affinities[0] != "fire" {
+		t.Fatalf("expected only fire affinity, got %v", affinities)
+	}
+}
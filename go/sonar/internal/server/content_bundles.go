@@ -0,0 +1,166 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/db"
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/MaxBlaushild/poltergeist/sonar/internal/contentbundle"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func parseContentBundleSelection(ctx *gin.Context) (models.ContentBundleSelection, error) {
+	selection := models.ContentBundleSelection{}
+	for _, raw := range strings.Split(ctx.Query("questArchetypeIds"), ",") {
+		if strings.TrimSpace(raw) == "" {
+			continue
+		}
+		id, err := uuid.Parse(strings.TrimSpace(raw))
+		if err != nil {
+			return selection, fmt.Errorf("invalid quest archetype ID %q", raw)
+		}
+		selection.QuestArchetypeIDs = append(selection.QuestArchetypeIDs, id)
+	}
+	for _, raw := range strings.Split(ctx.Query("zoneKinds"), ",") {
+		if slug := strings.TrimSpace(raw); slug != "" {
+			selection.ZoneKinds = append(selection.ZoneKinds, slug)
+		}
+	}
+	return selection, nil
+}
+
+// exportContentBundle returns the selected content as a zip of the bundle
+// directory, or as a single JSON document with format=json.
+func (s *server) exportContentBundle(ctx *gin.Context) {
+	if _, err := s.getAuthenticatedUser(ctx); err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	selection, err := parseContentBundleSelection(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	bundle, err := contentbundle.Export(ctx, s.dbClient, selection, strings.TrimSpace(ctx.Query("source")))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	images := map[string][]byte{}
+	if ctx.Query("embedImages") == "true" {
+		var errs []error
+		images, errs = contentbundle.EmbedImages(ctx, &http.Client{Timeout: 30 * time.Second}, bundle)
+		for _, err := range errs {
+			log.Printf("[content-bundle][export] failed to embed image err=%v", err)
+			bundle.Manifest.Warnings = append(bundle.Manifest.Warnings, err.Error())
+		}
+	}
+
+	if ctx.Query("format") == "json" {
+		ctx.JSON(http.StatusOK, bundle)
+		return
+	}
+	files, err := contentbundle.Files(bundle, images)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	var archive bytes.Buffer
+	if err := contentbundle.WriteZip(&archive, files); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	filename := fmt.Sprintf("content-bundle-%s.zip", bundle.Manifest.ExportedAt.Format("20060102-150405"))
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	ctx.Data(http.StatusOK, "application/zip", archive.Bytes())
+}
+
+// importContentBundle accepts a zipped bundle directory or a JSON bundle.
+// With dryRun=true it only returns the diff; otherwise the whole bundle is
+// applied in one transaction, or not at all if anything conflicts.
+func (s *server) importContentBundle(ctx *gin.Context) {
+	if _, err := s.getAuthenticatedUser(ctx); err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	policy, ok := models.NormalizeContentBundleConflictPolicy(ctx.Query("onConflict"))
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "onConflict must be one of fail, skip or overwrite"})
+		return
+	}
+	dryRun := ctx.Query("dryRun") == "true"
+
+	body, err := ctx.GetRawData()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	bundle, images, err := decodeContentBundle(body)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !dryRun && len(images) > 0 {
+		if err := contentbundle.RestoreImages(bundle, images, s.awsClient.UploadImageToS3); err != nil {
+			ctx.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	plan, err := contentbundle.Plan(ctx, s.dbClient, bundle, policy)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	response := gin.H{
+		"dryRun":   dryRun,
+		"applied":  false,
+		"policy":   plan.Policy,
+		"summary":  plan.Summary(),
+		"changes":  plan.Changes,
+		"warnings": plan.Warnings,
+	}
+	if dryRun {
+		ctx.JSON(http.StatusOK, response)
+		return
+	}
+	if err := s.dbClient.ContentBundle().Apply(ctx, plan); err != nil {
+		if errors.Is(err, db.ErrContentBundleConflicts) {
+			response["error"] = err.Error()
+			ctx.JSON(http.StatusConflict, response)
+			return
+		}
+		log.Printf("[content-bundle][import] apply failed err=%v", err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	response["applied"] = true
+	ctx.JSON(http.StatusOK, response)
+}
+
+func decodeContentBundle(body []byte) (*models.ContentBundle, map[string][]byte, error) {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil, nil, errors.New("request body must be a content bundle")
+	}
+	if bytes.HasPrefix(bytes.TrimSpace(body), []byte("{")) {
+		bundle := &models.ContentBundle{}
+		if err := json.Unmarshal(body, bundle); err != nil {
+			return nil, nil, fmt.Errorf("invalid content bundle: %w", err)
+		}
+		return bundle, nil, nil
+	}
+	files, err := contentbundle.ReadZip(body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid content bundle archive: %w", err)
+	}
+	return contentbundle.FromFiles(files)
+}
@@ -0,0 +1,349 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/jobs"
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+)
+
+const defaultContentModerationQueueLimit = 100
+
+// contentModerationBlocksPublish reports why queued content cannot be
+// published yet. Content that was never queued is not held back.
+func contentModerationBlocksPublish(item *models.ContentModerationItem) string {
+	if item == nil || item.Status == models.ContentModerationStatusApproved {
+		return ""
+	}
+	return fmt.Sprintf("content is %s in the moderation queue", item.Status)
+}
+
+// requireContentModerationApproval writes a conflict and returns false when
+// the content is waiting on review.
+func (s *server) requireContentModerationApproval(ctx *gin.Context, contentType string, contentID uuid.UUID) bool {
+	item, err := s.dbClient.ContentModeration().FindByContent(ctx, contentType, contentID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return false
+	}
+	if reason := contentModerationBlocksPublish(item); reason != "" {
+		ctx.JSON(http.StatusConflict, gin.H{"error": reason, "moderationItemId": item.ID})
+		return false
+	}
+	return true
+}
+
+func (s *server) getContentModerationQueue(ctx *gin.Context) {
+	if _, err := s.getAuthenticatedUser(ctx); err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	status := models.ContentModerationStatus(strings.TrimSpace(ctx.DefaultQuery("status", string(models.ContentModerationStatusPending))))
+	if status == "all" {
+		status = ""
+	}
+	limit := defaultContentModerationQueueLimit
+	if raw := strings.TrimSpace(ctx.Query("limit")); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+			return
+		}
+		limit = parsed
+	}
+	items, err := s.dbClient.ContentModeration().FindQueue(ctx, status, strings.TrimSpace(ctx.Query("contentType")), limit)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, items)
+}
+
+func (s *server) findContentModerationItem(ctx *gin.Context) (*models.ContentModerationItem, bool) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid moderation item ID"})
+		return nil, false
+	}
+	item, err := s.dbClient.ContentModeration().FindByID(ctx, id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	if item == nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "moderation item not found"})
+		return nil, false
+	}
+	return item, true
+}
+
+func (s *server) getContentModerationItem(ctx *gin.Context) {
+	if _, err := s.getAuthenticatedUser(ctx); err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	item, ok := s.findContentModerationItem(ctx)
+	if !ok {
+		return
+	}
+	ctx.JSON(http.StatusOK, item)
+}
+
+type reviewContentModerationRequest struct {
+	Note string `json:"note"`
+	// Override is required to approve content with blocking findings.
+	Override bool `json:"override"`
+}
+
+func (s *server) approveContentModerationItem(ctx *gin.Context) {
+	user, err := s.getAuthenticatedUser(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	item, ok := s.findContentModerationItem(ctx)
+	if !ok {
+		return
+	}
+	var requestBody reviewContentModerationRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&requestBody); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if item.Status == models.ContentModerationStatusRegenerating {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "content is being regenerated"})
+		return
+	}
+	if item.Blocking() && !requestBody.Override {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "content has blocking findings; set override to approve anyway", "findings": item.Findings})
+		return
+	}
+	if err := s.dbClient.ContentModeration().Review(ctx, item.ID, models.ContentModerationStatusApproved, &user.ID, requestBody.Note); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if item.ContentType == models.ModeratedContentTypeScenario {
+		// Approved scenarios are about to reach players; translate them now
+		// rather than on first request.
+		if err := s.enqueueContentTranslation(models.TranslatableEntityTypeScenario, item.ContentID.String(), nil, false); err != nil {
+			log.Printf("[localization][translate] failed to enqueue scenario_id=%s err=%v", item.ContentID, err)
+		}
+	}
+	s.respondWithContentModerationItem(ctx, item.ID)
+}
+
+func (s *server) rejectContentModerationItem(ctx *gin.Context) {
+	user, err := s.getAuthenticatedUser(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	item, ok := s.findContentModerationItem(ctx)
+	if !ok {
+		return
+	}
+	var requestBody reviewContentModerationRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&requestBody); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if err := s.dbClient.ContentModeration().Review(ctx, item.ID, models.ContentModerationStatusRejected, &user.ID, requestBody.Note); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	s.respondWithContentModerationItem(ctx, item.ID)
+}
+
+// regenerateContentModerationItem throws the content away and runs the job
+// that produced it again. The new output is queued for review on its own.
+func (s *server) regenerateContentModerationItem(ctx *gin.Context) {
+	user, err := s.getAuthenticatedUser(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	item, ok := s.findContentModerationItem(ctx)
+	if !ok {
+		return
+	}
+	var requestBody reviewContentModerationRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&requestBody); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if item.Status == models.ContentModerationStatusApproved {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "approved content cannot be regenerated"})
+		return
+	}
+
+	var task *asynq.Task
+	switch item.ContentType {
+	case models.ModeratedContentTypeScenario:
+		task, err = s.requeueScenarioGeneration(ctx, item.ContentID)
+	case models.ModeratedContentTypeZoneSeedDraft:
+		task, err = s.requeueZoneSeedDraft(ctx, item.ContentID)
+	default:
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s content cannot be regenerated; reject it and run a new job", item.ContentType)})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := s.dbClient.ContentModeration().Review(ctx, item.ID, models.ContentModerationStatusRegenerating, &user.ID, requestBody.Note); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if _, err := s.asyncClient.Enqueue(task); err != nil {
+		log.Printf("[moderation][regenerate] failed to enqueue item_id=%s content_type=%s err=%v", item.ID, item.ContentType, err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	s.respondWithContentModerationItem(ctx, item.ID)
+}
+
+// requeueScenarioGeneration deletes a generated scenario and puts the job
+// that generated it back in the queue.
+func (s *server) requeueScenarioGeneration(ctx *gin.Context, scenarioID uuid.UUID) (*asynq.Task, error) {
+	job, err := s.dbClient.ScenarioGenerationJob().FindByGeneratedScenarioID(ctx, scenarioID)
+	if err != nil {
+		return nil, err
+	}
+	if job == nil {
+		return nil, fmt.Errorf("scenario %s was not produced by a generation job", scenarioID)
+	}
+	payload, err := json.Marshal(jobs.GenerateScenarioTaskPayload{JobID: job.ID})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.dbClient.Scenario().Delete(ctx, scenarioID); err != nil {
+		return nil, err
+	}
+	job.Status = models.ScenarioGenerationStatusQueued
+	job.GeneratedScenarioID = nil
+	job.ErrorMessage = nil
+	job.UpdatedAt = time.Now()
+	if err := s.dbClient.ScenarioGenerationJob().Update(ctx, job); err != nil {
+		return nil, err
+	}
+	return asynq.NewTask(jobs.GenerateScenarioTaskType, payload), nil
+}
+
+// requeueZoneSeedDraft clears a zone seed draft so the seed job writes a new
+// one; the job keeps its ID, so the new draft replaces this queue entry.
+func (s *server) requeueZoneSeedDraft(ctx *gin.Context, jobID uuid.UUID) (*asynq.Task, error) {
+	job, err := s.dbClient.ZoneSeedJob().FindByID(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if job == nil {
+		return nil, fmt.Errorf("zone seed job %s not found", jobID)
+	}
+	if job.Status != models.ZoneSeedStatusAwaitingApproval && job.Status != models.ZoneSeedStatusFailed {
+		return nil, fmt.Errorf("zone seed job is %s", job.Status)
+	}
+	payload, err := json.Marshal(jobs.SeedZoneDraftTaskPayload{JobID: job.ID})
+	if err != nil {
+		return nil, err
+	}
+	job.Status = models.ZoneSeedStatusQueued
+	job.ErrorMessage = nil
+	job.Draft = models.ZoneSeedDraft{}
+	job.UpdatedAt = time.Now()
+	if err := s.dbClient.ZoneSeedJob().Update(ctx, job); err != nil {
+		return nil, err
+	}
+	return asynq.NewTask(jobs.SeedZoneDraftTaskType, payload), nil
+}
+
+func (s *server) respondWithContentModerationItem(ctx *gin.Context, id uuid.UUID) {
+	item, err := s.dbClient.ContentModeration().FindByID(ctx, id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, item)
+}
+
+func (s *server) getModerationTerms(ctx *gin.Context) {
+	if _, err := s.getAuthenticatedUser(ctx); err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	terms, err := s.dbClient.ContentModeration().FindTerms(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, terms)
+}
+
+type createModerationTermRequest struct {
+	Term string `json:"term"`
+	Kind string `json:"kind"`
+}
+
+func (s *server) createModerationTerm(ctx *gin.Context) {
+	user, err := s.getAuthenticatedUser(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	var requestBody createModerationTermRequest
+	if err := ctx.ShouldBindJSON(&requestBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	kind, ok := models.ParseModerationTermKind(requestBody.Kind)
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "kind must be blocked, brand or business"})
+		return
+	}
+	if len([]rune(strings.TrimSpace(requestBody.Term))) < 3 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "term must be at least 3 characters"})
+		return
+	}
+	term := &models.ModerationTerm{
+		Term:            requestBody.Term,
+		Kind:            kind,
+		CreatedByUserID: &user.ID,
+	}
+	if err := s.dbClient.ContentModeration().CreateTerm(ctx, term); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusCreated, term)
+}
+
+func (s *server) deleteModerationTerm(ctx *gin.Context) {
+	if _, err := s.getAuthenticatedUser(ctx); err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	id, err := uuid.Parse(ctx.Param("termId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid moderation term ID"})
+		return
+	}
+	if err := s.dbClient.ContentModeration().DeleteTerm(ctx, id); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"deleted": true})
+}
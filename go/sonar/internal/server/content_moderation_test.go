@@ -0,0 +1,85 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/db"
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func TestContentModerationBlocksPublish(t *testing.T) {
+	if reason := contentModerationBlocksPublish(nil); reason != "" {
+		t.Fatalf("expected unqueued content to publish, got %q", reason)
+	}
+	approved := &models.ContentModerationItem{Status: models.ContentModerationStatusApproved}
+	if reason := contentModerationBlocksPublish(approved); reason != "" {
+		t.Fatalf("expected approved content to publish, got %q", reason)
+	}
+	for _, status := range []models.ContentModerationStatus{
+		models.ContentModerationStatusPending,
+		models.ContentModerationStatusRejected,
+		models.ContentModerationStatusRegenerating,
+	} {
+		if reason := contentModerationBlocksPublish(&models.ContentModerationItem{Status: status}); reason == "" {
+			t.Fatalf("expected %s content to be held back", status)
+		}
+	}
+}
+
+// The fakes embed the interfaces they stand in for, so a handler reaching
+// for a scenario any other way than FindApprovedByID panics.
+type moderationTestDB struct {
+	db.DbClient
+	scenarios moderationTestScenarios
+}
+
+func (f *moderationTestDB) Scenario() db.ScenarioHandle { return f.scenarios }
+
+// moderationTestScenarios holds scenarios still in the moderation queue,
+// which FindApprovedByID doesn't find.
+type moderationTestScenarios struct {
+	db.ScenarioHandle
+	pending map[uuid.UUID]bool
+}
+
+func (f moderationTestScenarios) FindApprovedByID(_ context.Context, id uuid.UUID) (*models.Scenario, error) {
+	if f.pending[id] {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &models.Scenario{ID: id}, nil
+}
+
+func TestPlayersCannotReachScenariosPendingModeration(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	scenarioID := uuid.New()
+	s := &server{dbClient: &moderationTestDB{scenarios: moderationTestScenarios{pending: map[uuid.UUID]bool{scenarioID: true}}}}
+
+	for _, tc := range []struct {
+		name    string
+		method  string
+		handler gin.HandlerFunc
+	}{
+		{name: "perform", method: http.MethodPost, handler: s.performScenario},
+		{name: "get", method: http.MethodGet, handler: s.getScenario},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			response := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(response)
+			ctx.Request = httptest.NewRequest(tc.method, "/sonar/scenarios/"+scenarioID.String(), nil)
+			ctx.Params = gin.Params{{Key: "id", Value: scenarioID.String()}}
+			ctx.Set("user", &models.User{ID: uuid.New()})
+
+			tc.handler(ctx)
+
+			if response.Code != http.StatusNotFound {
+				t.Fatalf("expected a scenario pending moderation to be not found, got %d: %s", response.Code, response.Body)
+			}
+		})
+	}
+}
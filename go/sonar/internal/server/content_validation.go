@@ -0,0 +1,169 @@
+package server
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/MaxBlaushild/poltergeist/sonar/internal/contentvalidation"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// questArchetypeEditErrors validates every archetype that includes nodeIDs
+// before and after edit is applied to the catalog, and returns the errors the
+// edit would introduce. Warnings never block a save.
+func questArchetypeEditErrors(
+	catalog *models.ContentValidationCatalog,
+	nodeIDs []uuid.UUID,
+	edit func(catalog *models.ContentValidationCatalog),
+) []models.ContentValidationIssue {
+	affected := map[uuid.UUID]struct{}{}
+	before := []models.ContentValidationIssue{}
+	for _, archetype := range catalog.QuestArchetypesContainingNodes(nodeIDs) {
+		affected[archetype.ID] = struct{}{}
+		before = append(before, catalog.ValidateQuestArchetype(archetype)...)
+	}
+
+	edit(catalog)
+
+	after := []models.ContentValidationIssue{}
+	for _, archetype := range catalog.QuestArchetypesContainingNodes(nodeIDs) {
+		affected[archetype.ID] = struct{}{}
+	}
+	for _, archetype := range catalog.QuestArchetypes {
+		if archetype == nil {
+			continue
+		}
+		if _, ok := affected[archetype.ID]; ok {
+			after = append(after, catalog.ValidateQuestArchetype(archetype)...)
+		}
+	}
+	return models.IntroducedContentValidationErrors(before, after)
+}
+
+// mainStoryTemplateEditErrors returns the errors saving template would
+// introduce compared with the stored version, if any.
+func (s *server) mainStoryTemplateEditErrors(
+	ctx context.Context,
+	template *models.MainStoryTemplate,
+) ([]models.ContentValidationIssue, error) {
+	catalog, err := contentvalidation.LoadCatalog(ctx, s.dbClient)
+	if err != nil {
+		return nil, err
+	}
+	before := []models.ContentValidationIssue{}
+	for idx := range catalog.MainStoryTemplates {
+		if catalog.MainStoryTemplates[idx].ID == template.ID {
+			before = catalog.ValidateMainStoryTemplate(&catalog.MainStoryTemplates[idx])
+			break
+		}
+	}
+	after := catalog.ValidateMainStoryTemplate(template)
+	return models.IntroducedContentValidationErrors(before, after), nil
+}
+
+func respondWithContentValidationErrors(ctx *gin.Context, issues []models.ContentValidationIssue) {
+	messages := make([]string, 0, len(issues))
+	for _, issue := range issues {
+		messages = append(messages, issue.String())
+	}
+	ctx.JSON(http.StatusBadRequest, gin.H{
+		"error":  "content validation failed: " + strings.Join(messages, "; "),
+		"issues": issues,
+	})
+}
+
+// attachQuestArchetypeValidationIssues fills in the archetype's validation
+// issues for the response. Validation is advisory here, so failures to load
+// the catalog are only logged.
+func (s *server) attachQuestArchetypeValidationIssues(ctx context.Context, archetype *models.QuestArchetype) {
+	if archetype == nil {
+		return
+	}
+	catalog, err := contentvalidation.LoadCatalog(ctx, s.dbClient)
+	if err != nil {
+		log.Printf("[content-validation][quest-archetype] failed to load catalog questArchetypeID=%s err=%v", archetype.ID, err)
+		return
+	}
+	catalog.PutQuestArchetype(archetype)
+	archetype.ValidationIssues = catalog.ValidateQuestArchetype(archetype)
+}
+
+func (s *server) attachMainStoryTemplateValidationIssues(ctx context.Context, template *models.MainStoryTemplate) {
+	if template == nil {
+		return
+	}
+	catalog, err := contentvalidation.LoadCatalog(ctx, s.dbClient)
+	if err != nil {
+		log.Printf("[content-validation][main-story] failed to load catalog mainStoryTemplateID=%s err=%v", template.ID, err)
+		return
+	}
+	template.ValidationIssues = catalog.ValidateMainStoryTemplate(template)
+}
+
+func (s *server) getQuestArchetypeValidation(ctx *gin.Context) {
+	id, err := uuid.Parse(strings.TrimSpace(ctx.Param("id")))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid quest archetype ID"})
+		return
+	}
+	catalog, err := contentvalidation.LoadCatalog(ctx, s.dbClient)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	for _, archetype := range catalog.QuestArchetypes {
+		if archetype != nil && archetype.ID == id {
+			ctx.JSON(http.StatusOK, gin.H{"issues": catalog.ValidateQuestArchetype(archetype)})
+			return
+		}
+	}
+	ctx.JSON(http.StatusNotFound, gin.H{"error": "quest archetype not found"})
+}
+
+func (s *server) getMainStoryTemplateValidation(ctx *gin.Context) {
+	id, err := uuid.Parse(strings.TrimSpace(ctx.Param("id")))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid main story template ID"})
+		return
+	}
+	catalog, err := contentvalidation.LoadCatalog(ctx, s.dbClient)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	for idx := range catalog.MainStoryTemplates {
+		if catalog.MainStoryTemplates[idx].ID == id {
+			ctx.JSON(http.StatusOK, gin.H{"issues": catalog.ValidateMainStoryTemplate(&catalog.MainStoryTemplates[idx])})
+			return
+		}
+	}
+	ctx.JSON(http.StatusNotFound, gin.H{"error": "main story template not found"})
+}
+
+func (s *server) getContentValidation(ctx *gin.Context) {
+	if _, err := s.getAuthenticatedUser(ctx); err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	catalog, err := contentvalidation.LoadCatalog(ctx, s.dbClient)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	issues := catalog.Validate()
+	errorCount := 0
+	for _, issue := range issues {
+		if issue.IsError() {
+			errorCount++
+		}
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"issues":       issues,
+		"errorCount":   errorCount,
+		"warningCount": len(issues) - errorCount,
+	})
+}
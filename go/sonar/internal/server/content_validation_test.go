@@ -0,0 +1,53 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/google/uuid"
+)
+
+func TestQuestArchetypeEditErrorsRejectsNewCycle(t *testing.T) {
+	root := &models.QuestArchetypeNode{ID: uuid.New()}
+	next := &models.QuestArchetypeNode{ID: uuid.New()}
+	root.Challenges = []models.QuestArchetypeChallenge{{ID: uuid.New(), UnlockedNodeID: &next.ID}}
+	archetype := &models.QuestArchetype{ID: uuid.New(), RootID: root.ID}
+	catalog := models.NewContentValidationCatalog(
+		[]*models.QuestArchetype{archetype},
+		[]*models.QuestArchetypeNode{root, next},
+		nil,
+		nil,
+		nil,
+	)
+
+	issues := questArchetypeEditErrors(catalog, []uuid.UUID{next.ID}, func(catalog *models.ContentValidationCatalog) {
+		catalog.PutChallenge(next.ID, models.QuestArchetypeChallenge{ID: uuid.New(), FailureUnlockedNodeID: &root.ID})
+	})
+	if len(issues) != 1 || issues[0].Code != models.ContentValidationCodeCycle {
+		t.Fatalf("expected the new back link to be rejected as a cycle, got %+v", issues)
+	}
+}
+
+func TestQuestArchetypeEditErrorsIgnoresExistingErrors(t *testing.T) {
+	root := &models.QuestArchetypeNode{
+		ID:                    uuid.New(),
+		LocationSelectionMode: models.QuestArchetypeNodeLocationSelectionModeSameAsPrevious,
+	}
+	archetype := &models.QuestArchetype{ID: uuid.New(), RootID: root.ID}
+	catalog := models.NewContentValidationCatalog(
+		[]*models.QuestArchetype{archetype},
+		[]*models.QuestArchetypeNode{root},
+		nil,
+		nil,
+		nil,
+	)
+
+	edited := *root
+	edited.Difficulty = 3
+	issues := questArchetypeEditErrors(catalog, []uuid.UUID{root.ID}, func(catalog *models.ContentValidationCatalog) {
+		catalog.PutNode(&edited)
+	})
+	if len(issues) != 0 {
+		t.Fatalf("expected an unrelated edit to save despite the existing error, got %+v", issues)
+	}
+}
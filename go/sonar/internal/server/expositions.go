@@ -376,6 +376,7 @@ func (s *server) getExposition(ctx *gin.Context) {
 	); markerURL != "" {
 		exposition.ThumbnailURL = markerURL
 	}
+	s.localizeExpositions(ctx, exposition)
 	ctx.JSON(http.StatusOK, exposition)
 }
 
@@ -442,6 +443,11 @@ func (s *server) getExpositionsForZone(ctx *gin.Context) {
 		}
 		response = append(response, expositions[i])
 	}
+	localized := make([]*models.Exposition, 0, len(response))
+	for i := range response {
+		localized = append(localized, &response[i])
+	}
+	s.localizeExpositions(ctx, localized...)
 	ctx.JSON(http.StatusOK, response)
 }
 
@@ -787,7 +793,7 @@ func (s *server) performExposition(ctx *gin.Context) {
 			continue
 		}
 		sharedQuestNodeIDs[target.Node.ID] = struct{}{}
-		s.shareQuestNodeCompletionWithEligiblePartyMembers(
+		s.shareQuestNodeCompletion(
 			ctx,
 			user,
 			target.Quest,
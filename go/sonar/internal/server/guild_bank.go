@@ -0,0 +1,175 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const guildAuditLogMaxSize = 200
+
+type guildBankTransferRequest struct {
+	Gold            int `json:"gold"`
+	InventoryItemID int `json:"inventoryItemId"`
+	Quantity        int `json:"quantity"`
+}
+
+// validateGuildBankTransfer checks that a deposit or withdrawal moves
+// exactly one kind of thing: either gold or a stack of a single item.
+func validateGuildBankTransfer(request guildBankTransferRequest) (guildBankTransferRequest, error) {
+	if request.Gold < 0 || request.Quantity < 0 {
+		return request, fmt.Errorf("amounts cannot be negative")
+	}
+	hasGold := request.Gold > 0
+	hasItem := request.InventoryItemID > 0
+	switch {
+	case hasGold && hasItem:
+		return request, fmt.Errorf("move gold and items in separate requests")
+	case !hasGold && !hasItem:
+		return request, fmt.Errorf("gold or inventoryItemId is required")
+	}
+	if hasItem && request.Quantity == 0 {
+		request.Quantity = 1
+	}
+	return request, nil
+}
+
+func (s *server) getGuildBank(ctx *gin.Context) {
+	user, err := s.getAuthenticatedUser(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	guildID, err := uuid.Parse(strings.TrimSpace(ctx.Param("id")))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid guild ID"})
+		return
+	}
+	member, err := s.guildMembershipFor(ctx, user.ID, guildID)
+	if err != nil {
+		ctx.JSON(guildErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	guild, err := s.dbClient.Guild().FindByID(ctx, guildID)
+	if err != nil || guild == nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "guild not found"})
+		return
+	}
+	items, err := s.dbClient.GuildBank().FindItems(ctx, guildID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"gold":        guild.Gold,
+		"items":       items,
+		"canWithdraw": guild.CanWithdraw(member.Role),
+	})
+}
+
+func (s *server) depositToGuildBank(ctx *gin.Context) {
+	s.transferGuildBank(ctx, true)
+}
+
+func (s *server) withdrawFromGuildBank(ctx *gin.Context) {
+	s.transferGuildBank(ctx, false)
+}
+
+func (s *server) transferGuildBank(ctx *gin.Context, deposit bool) {
+	user, err := s.getAuthenticatedUser(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	guildID, err := uuid.Parse(strings.TrimSpace(ctx.Param("id")))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid guild ID"})
+		return
+	}
+
+	var requestBody guildBankTransferRequest
+	if err := ctx.Bind(&requestBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	requestBody, err = validateGuildBankTransfer(requestBody)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	bank := s.dbClient.GuildBank()
+	switch {
+	case deposit && requestBody.Gold > 0:
+		err = bank.DepositGold(ctx, guildID, user.ID, requestBody.Gold)
+	case deposit:
+		err = bank.DepositItem(ctx, guildID, user.ID, requestBody.InventoryItemID, requestBody.Quantity)
+	case requestBody.Gold > 0:
+		err = bank.WithdrawGold(ctx, guildID, user.ID, requestBody.Gold)
+	default:
+		err = bank.WithdrawItem(ctx, guildID, user.ID, requestBody.InventoryItemID, requestBody.Quantity)
+	}
+	if err != nil {
+		ctx.JSON(guildErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	updatedUser, err := s.dbClient.User().FindByID(ctx, user.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch updated user: " + err.Error()})
+		return
+	}
+	guild, err := s.dbClient.Guild().FindByID(ctx, guildID)
+	if err != nil || guild == nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch updated guild"})
+		return
+	}
+	items, err := bank.FindItems(ctx, guildID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"user":  updatedUser,
+		"gold":  guild.Gold,
+		"items": items,
+	})
+}
+
+func (s *server) getGuildAuditLog(ctx *gin.Context) {
+	user, err := s.getAuthenticatedUser(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	guildID, err := uuid.Parse(strings.TrimSpace(ctx.Param("id")))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid guild ID"})
+		return
+	}
+	if _, err := s.guildMembershipFor(ctx, user.ID, guildID); err != nil {
+		ctx.JSON(guildErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	limit := 0
+	if parsed, err := strconv.Atoi(strings.TrimSpace(ctx.Query("limit"))); err == nil && parsed > 0 {
+		limit = min(parsed, guildAuditLogMaxSize)
+	}
+	entries, err := s.dbClient.GuildBank().FindAuditEntries(ctx, guildID, limit)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, entries)
+}
@@ -0,0 +1,297 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// guildCompletedNodeIDs indexes a guild's recorded completions by node so a
+// member's current node can be checked against them.
+func guildCompletedNodeIDs(completions []models.GuildQuestNodeCompletion) map[uuid.UUID]struct{} {
+	completed := make(map[uuid.UUID]struct{}, len(completions))
+	for _, completion := range completions {
+		completed[completion.QuestNodeID] = struct{}{}
+	}
+	return completed
+}
+
+// shareQuestNodeCompletionWithGuild records the node against the source
+// user's guild and credits every other member who is currently on it. Unlike
+// party sharing there is no liveness or range requirement: guild progress
+// counts no matter where the member is.
+func (s *server) shareQuestNodeCompletionWithGuild(
+	ctx context.Context,
+	sourceUser *models.User,
+	quest *models.Quest,
+	node *models.QuestNode,
+) {
+	if sourceUser == nil || quest == nil || node == nil {
+		return
+	}
+
+	membership, err := s.dbClient.Guild().FindMembership(ctx, sourceUser.ID)
+	if err != nil {
+		log.Printf(
+			"[quest-share][guild] membership lookup failed source=%s quest=%s node=%s err=%v",
+			sourceUser.ID,
+			quest.ID,
+			node.ID,
+			err,
+		)
+		return
+	}
+	if membership == nil {
+		return
+	}
+
+	sourceUserID := sourceUser.ID
+	if _, err := s.dbClient.GuildQuestProgress().RecordNodeCompletion(ctx, &models.GuildQuestNodeCompletion{
+		GuildID:           membership.GuildID,
+		QuestID:           quest.ID,
+		QuestNodeID:       node.ID,
+		CompletedByUserID: &sourceUserID,
+	}); err != nil {
+		log.Printf(
+			"[quest-share][guild] failed to record completion guild=%s source=%s quest=%s node=%s err=%v",
+			membership.GuildID,
+			sourceUser.ID,
+			quest.ID,
+			node.ID,
+			err,
+		)
+		return
+	}
+
+	memberIDs, err := s.dbClient.Guild().FindMemberUserIDs(ctx, membership.GuildID)
+	if err != nil {
+		log.Printf(
+			"[quest-share][guild] failed to load members guild=%s quest=%s node=%s err=%v",
+			membership.GuildID,
+			quest.ID,
+			node.ID,
+			err,
+		)
+		return
+	}
+
+	completedAt := time.Now()
+	for _, memberID := range memberIDs {
+		if memberID == sourceUser.ID {
+			continue
+		}
+
+		acceptance, err := s.dbClient.QuestAcceptanceV2().FindByUserAndQuest(ctx, memberID, quest.ID)
+		if err != nil {
+			log.Printf(
+				"[quest-share][guild] acceptance lookup failed source=%s member=%s quest=%s node=%s err=%v",
+				sourceUser.ID,
+				memberID,
+				quest.ID,
+				node.ID,
+				err,
+			)
+			continue
+		}
+		if acceptance == nil || acceptance.IsClosed() {
+			continue
+		}
+
+		currentNode, err := s.currentQuestNode(ctx, quest, acceptance)
+		if err != nil {
+			log.Printf(
+				"[quest-share][guild] current node lookup failed source=%s member=%s quest=%s node=%s err=%v",
+				sourceUser.ID,
+				memberID,
+				quest.ID,
+				node.ID,
+				err,
+			)
+			continue
+		}
+		if currentNode == nil || currentNode.ID != node.ID {
+			continue
+		}
+
+		completed, err := s.markQuestNodeCompleteForAcceptance(ctx, quest, acceptance, node.ID, completedAt)
+		if err != nil {
+			log.Printf(
+				"[quest-share][guild] mark complete failed source=%s member=%s quest=%s node=%s err=%v",
+				sourceUser.ID,
+				memberID,
+				quest.ID,
+				node.ID,
+				err,
+			)
+			continue
+		}
+		if !completed {
+			continue
+		}
+
+		s.sendGuildQuestObjectiveSharedPush(ctx, memberID, sourceUser, quest, node)
+	}
+}
+
+// creditGuildQuestProgress advances the user's acceptance through every node
+// their guild has already completed, stopping at the first node the guild
+// hasn't finished. It returns how many nodes were credited.
+func (s *server) creditGuildQuestProgress(
+	ctx context.Context,
+	guildID uuid.UUID,
+	quest *models.Quest,
+	acceptance *models.QuestAcceptanceV2,
+) (int, error) {
+	completions, err := s.dbClient.GuildQuestProgress().FindByGuildAndQuest(ctx, guildID, quest.ID)
+	if err != nil {
+		return 0, err
+	}
+	completed := guildCompletedNodeIDs(completions)
+
+	credited := 0
+	completedAt := time.Now()
+	// Each pass moves the acceptance forward by at least one node, so the
+	// node count bounds the loop even if the graph has cycles.
+	for range quest.Nodes {
+		if acceptance.IsClosed() {
+			break
+		}
+		currentNode, err := s.currentQuestNode(ctx, quest, acceptance)
+		if err != nil {
+			return credited, err
+		}
+		if currentNode == nil {
+			break
+		}
+		if _, ok := completed[currentNode.ID]; !ok {
+			break
+		}
+		advanced, err := s.markQuestNodeCompleteForAcceptance(ctx, quest, acceptance, currentNode.ID, completedAt)
+		if err != nil {
+			return credited, err
+		}
+		if !advanced {
+			break
+		}
+		credited++
+	}
+	return credited, nil
+}
+
+func (s *server) getGuildQuestProgress(ctx *gin.Context) {
+	user, err := s.getAuthenticatedUser(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	guildID, err := uuid.Parse(strings.TrimSpace(ctx.Param("id")))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid guild ID"})
+		return
+	}
+	questID, err := uuid.Parse(strings.TrimSpace(ctx.Param("questId")))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid quest ID"})
+		return
+	}
+	if _, err := s.guildMembershipFor(ctx, user.ID, guildID); err != nil {
+		ctx.JSON(guildErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	completions, err := s.dbClient.GuildQuestProgress().FindByGuildAndQuest(ctx, guildID, questID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, completions)
+}
+
+func (s *server) claimGuildQuestProgress(ctx *gin.Context) {
+	user, err := s.getAuthenticatedUser(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	guildID, err := uuid.Parse(strings.TrimSpace(ctx.Param("id")))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid guild ID"})
+		return
+	}
+	questID, err := uuid.Parse(strings.TrimSpace(ctx.Param("questId")))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid quest ID"})
+		return
+	}
+	if _, err := s.guildMembershipFor(ctx, user.ID, guildID); err != nil {
+		ctx.JSON(guildErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	quest, err := s.dbClient.Quest().FindByID(ctx, questID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if quest == nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "quest not found"})
+		return
+	}
+	acceptance, err := s.dbClient.QuestAcceptanceV2().FindByUserAndQuest(ctx, user.ID, quest.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if acceptance == nil || acceptance.IsClosed() {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "quest is not active"})
+		return
+	}
+
+	credited, err := s.creditGuildQuestProgress(ctx, guildID, quest, acceptance)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"nodesCredited": credited})
+}
+
+func (s *server) sendGuildQuestObjectiveSharedPush(
+	ctx context.Context,
+	recipientUserID uuid.UUID,
+	completedBy *models.User,
+	quest *models.Quest,
+	node *models.QuestNode,
+) {
+	if quest == nil || node == nil {
+		return
+	}
+
+	completedByUserID := ""
+	if completedBy != nil {
+		completedByUserID = completedBy.ID.String()
+	}
+
+	s.sendSocialPushToUser(
+		ctx,
+		"guild-quest-objective-shared",
+		recipientUserID,
+		"Guild Objective Complete",
+		fmt.Sprintf("A guild member completed an objective for %s. You received credit.", quest.Name),
+		map[string]string{
+			"type":              "guild_quest_objective_shared",
+			"questId":           quest.ID.String(),
+			"questNodeId":       node.ID.String(),
+			"completedByUserId": completedByUserID,
+			"sentAt":            time.Now().UTC().Format(time.RFC3339),
+		},
+	)
+}
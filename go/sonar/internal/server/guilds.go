@@ -0,0 +1,582 @@
+package server
+
+import (
+	"context"
+	stdErrors "errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/db"
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	guildNameMinLength          = 3
+	guildNameMaxLength          = 32
+	guildTagMaxLength           = 5
+	guildDescriptionMaxLength   = 500
+	guildLeaderboardDefaultSize = 25
+	guildLeaderboardMaxSize     = 100
+)
+
+type createGuildRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Tag         string `json:"tag"`
+	Description string `json:"description"`
+}
+
+type updateGuildRequest struct {
+	Description              *string `json:"description"`
+	MemberWithdrawalsEnabled *bool   `json:"memberWithdrawalsEnabled"`
+}
+
+type guildUserRequest struct {
+	UserID uuid.UUID `json:"userId" binding:"required"`
+}
+
+type setGuildMemberRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// normalizeGuildName collapses internal whitespace so "Night  Owls" and
+// "Night Owls" can't coexist as separate guilds.
+func normalizeGuildName(raw string) (string, error) {
+	name := strings.Join(strings.Fields(raw), " ")
+	length := len([]rune(name))
+	if length < guildNameMinLength || length > guildNameMaxLength {
+		return "", fmt.Errorf("guild name must be between %d and %d characters", guildNameMinLength, guildNameMaxLength)
+	}
+	return name, nil
+}
+
+func normalizeGuildTag(raw string) (string, error) {
+	tag := strings.ToUpper(strings.TrimSpace(raw))
+	if len([]rune(tag)) > guildTagMaxLength {
+		return "", fmt.Errorf("guild tag can be at most %d characters", guildTagMaxLength)
+	}
+	for _, r := range tag {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return "", fmt.Errorf("guild tag can only contain letters and numbers")
+		}
+	}
+	return tag, nil
+}
+
+func guildErrorStatus(err error) int {
+	switch {
+	case stdErrors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	case stdErrors.Is(err, db.ErrGuildPermissionDenied),
+		stdErrors.Is(err, db.ErrNotGuildMember):
+		return http.StatusForbidden
+	case stdErrors.Is(err, db.ErrGuildNameTaken),
+		stdErrors.Is(err, db.ErrAlreadyInGuild):
+		return http.StatusConflict
+	case stdErrors.Is(err, db.ErrGuildFull),
+		stdErrors.Is(err, db.ErrGuildLeaderMustTransfer),
+		stdErrors.Is(err, db.ErrGuildInviteNotPending),
+		stdErrors.Is(err, db.ErrInsufficientGold),
+		stdErrors.Is(err, db.ErrInsufficientItemQuantity):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// guildMembershipFor returns the user's membership if it belongs to guildID.
+func (s *server) guildMembershipFor(ctx context.Context, userID uuid.UUID, guildID uuid.UUID) (*models.GuildMember, error) {
+	member, err := s.dbClient.Guild().FindMembership(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if member == nil || member.GuildID != guildID {
+		return nil, db.ErrNotGuildMember
+	}
+	return member, nil
+}
+
+func (s *server) createGuild(ctx *gin.Context) {
+	user, err := s.getAuthenticatedUser(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var requestBody createGuildRequest
+	if err := ctx.Bind(&requestBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	name, err := normalizeGuildName(requestBody.Name)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	tag, err := normalizeGuildTag(requestBody.Tag)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	description := strings.TrimSpace(requestBody.Description)
+	if len([]rune(description)) > guildDescriptionMaxLength {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "guild description is too long"})
+		return
+	}
+
+	guild := &models.Guild{
+		Name:         name,
+		Tag:          tag,
+		Description:  description,
+		LeaderUserID: user.ID,
+	}
+	if err := s.dbClient.Guild().Create(ctx, guild); err != nil {
+		ctx.JSON(guildErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	created, err := s.dbClient.Guild().FindByID(ctx, guild.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusCreated, created)
+}
+
+func (s *server) getMyGuild(ctx *gin.Context) {
+	user, err := s.getAuthenticatedUser(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	member, err := s.dbClient.Guild().FindMembership(ctx, user.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if member == nil {
+		ctx.JSON(http.StatusOK, gin.H{"guild": nil})
+		return
+	}
+
+	guild, err := s.dbClient.Guild().FindByID(ctx, member.GuildID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"guild": guild,
+		"role":  member.Role,
+	})
+}
+
+func (s *server) getGuild(ctx *gin.Context) {
+	if _, err := s.getAuthenticatedUser(ctx); err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	guildID, err := uuid.Parse(strings.TrimSpace(ctx.Param("id")))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid guild ID"})
+		return
+	}
+
+	guild, err := s.dbClient.Guild().FindByID(ctx, guildID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if guild == nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "guild not found"})
+		return
+	}
+	ctx.JSON(http.StatusOK, guild)
+}
+
+func (s *server) updateGuild(ctx *gin.Context) {
+	user, err := s.getAuthenticatedUser(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	guildID, err := uuid.Parse(strings.TrimSpace(ctx.Param("id")))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid guild ID"})
+		return
+	}
+
+	var requestBody updateGuildRequest
+	if err := ctx.Bind(&requestBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if requestBody.Description != nil && len([]rune(strings.TrimSpace(*requestBody.Description))) > guildDescriptionMaxLength {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "guild description is too long"})
+		return
+	}
+
+	if err := s.dbClient.Guild().UpdateSettings(
+		ctx,
+		guildID,
+		user.ID,
+		requestBody.Description,
+		requestBody.MemberWithdrawalsEnabled,
+	); err != nil {
+		ctx.JSON(guildErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	guild, err := s.dbClient.Guild().FindByID(ctx, guildID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, guild)
+}
+
+func (s *server) inviteToGuild(ctx *gin.Context) {
+	user, err := s.getAuthenticatedUser(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	guildID, err := uuid.Parse(strings.TrimSpace(ctx.Param("id")))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid guild ID"})
+		return
+	}
+
+	var requestBody guildUserRequest
+	if err := ctx.Bind(&requestBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if requestBody.UserID == user.ID {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "you cannot invite yourself"})
+		return
+	}
+	invitee, err := s.dbClient.User().FindByID(ctx, requestBody.UserID)
+	if err != nil || invitee == nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+
+	invite := &models.GuildInvite{
+		GuildID:       guildID,
+		InviterUserID: user.ID,
+		InviteeUserID: invitee.ID,
+	}
+	if err := s.dbClient.GuildInvite().Create(ctx, invite); err != nil {
+		ctx.JSON(guildErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	created, err := s.dbClient.GuildInvite().FindByID(ctx, invite.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	s.sendGuildInvitePushNotification(ctx, created, user)
+	ctx.JSON(http.StatusCreated, created)
+}
+
+func (s *server) getGuildInvites(ctx *gin.Context) {
+	user, err := s.getAuthenticatedUser(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	guildID, err := uuid.Parse(strings.TrimSpace(ctx.Param("id")))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid guild ID"})
+		return
+	}
+	if _, err := s.guildMembershipFor(ctx, user.ID, guildID); err != nil {
+		ctx.JSON(guildErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	invites, err := s.dbClient.GuildInvite().FindPendingForGuild(ctx, guildID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, invites)
+}
+
+func (s *server) getMyGuildInvites(ctx *gin.Context) {
+	user, err := s.getAuthenticatedUser(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	invites, err := s.dbClient.GuildInvite().FindPendingForUser(ctx, user.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, invites)
+}
+
+func (s *server) acceptGuildInvite(ctx *gin.Context) {
+	user, err := s.getAuthenticatedUser(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	inviteID, err := uuid.Parse(strings.TrimSpace(ctx.Param("id")))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid invite ID"})
+		return
+	}
+
+	if err := s.dbClient.GuildInvite().Accept(ctx, inviteID, user.ID, time.Now()); err != nil {
+		ctx.JSON(guildErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	member, err := s.dbClient.Guild().FindMembership(ctx, user.ID)
+	if err != nil || member == nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load guild membership"})
+		return
+	}
+	guild, err := s.dbClient.Guild().FindByID(ctx, member.GuildID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, guild)
+}
+
+func (s *server) declineGuildInvite(ctx *gin.Context) {
+	s.closeGuildInvite(ctx, models.GuildInviteStatusDeclined)
+}
+
+func (s *server) cancelGuildInvite(ctx *gin.Context) {
+	s.closeGuildInvite(ctx, models.GuildInviteStatusCancelled)
+}
+
+// closeGuildInvite lets the invitee decline an invite, and the inviter or
+// any guild officer cancel one.
+func (s *server) closeGuildInvite(ctx *gin.Context, status models.GuildInviteStatus) {
+	user, err := s.getAuthenticatedUser(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	inviteID, err := uuid.Parse(strings.TrimSpace(ctx.Param("id")))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid invite ID"})
+		return
+	}
+
+	invite, err := s.dbClient.GuildInvite().FindByID(ctx, inviteID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if invite == nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "invite not found"})
+		return
+	}
+
+	switch status {
+	case models.GuildInviteStatusDeclined:
+		if invite.InviteeUserID != user.ID {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "only the invitee can decline this invite"})
+			return
+		}
+	case models.GuildInviteStatusCancelled:
+		if invite.InviterUserID != user.ID {
+			member, err := s.guildMembershipFor(ctx, user.ID, invite.GuildID)
+			if err != nil || !member.Role.CanInvite() {
+				ctx.JSON(http.StatusForbidden, gin.H{"error": "you cannot cancel this invite"})
+				return
+			}
+		}
+	}
+
+	if err := s.dbClient.GuildInvite().Close(ctx, invite.ID, status, time.Now()); err != nil {
+		ctx.JSON(guildErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	updated, err := s.dbClient.GuildInvite().FindByID(ctx, invite.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, updated)
+}
+
+func (s *server) leaveGuild(ctx *gin.Context) {
+	user, err := s.getAuthenticatedUser(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	guildID, err := uuid.Parse(strings.TrimSpace(ctx.Param("id")))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid guild ID"})
+		return
+	}
+
+	disbanded, err := s.dbClient.Guild().RemoveMember(ctx, guildID, user.ID, user.ID)
+	if err != nil {
+		ctx.JSON(guildErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"disbanded": disbanded})
+}
+
+func (s *server) removeGuildMember(ctx *gin.Context) {
+	user, err := s.getAuthenticatedUser(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	guildID, err := uuid.Parse(strings.TrimSpace(ctx.Param("id")))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid guild ID"})
+		return
+	}
+	targetID, err := uuid.Parse(strings.TrimSpace(ctx.Param("userId")))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+	if targetID == user.ID {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "use the leave endpoint to leave your guild"})
+		return
+	}
+
+	if _, err := s.dbClient.Guild().RemoveMember(ctx, guildID, user.ID, targetID); err != nil {
+		ctx.JSON(guildErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	guild, err := s.dbClient.Guild().FindByID(ctx, guildID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, guild)
+}
+
+func (s *server) setGuildMemberRole(ctx *gin.Context) {
+	user, err := s.getAuthenticatedUser(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	guildID, err := uuid.Parse(strings.TrimSpace(ctx.Param("id")))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid guild ID"})
+		return
+	}
+	targetID, err := uuid.Parse(strings.TrimSpace(ctx.Param("userId")))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+
+	var requestBody setGuildMemberRoleRequest
+	if err := ctx.Bind(&requestBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	role, ok := models.NormalizeGuildRole(requestBody.Role)
+	if !ok || role == models.GuildRoleLeader {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "role must be officer or member"})
+		return
+	}
+
+	if err := s.dbClient.Guild().SetMemberRole(ctx, guildID, user.ID, targetID, role); err != nil {
+		ctx.JSON(guildErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	guild, err := s.dbClient.Guild().FindByID(ctx, guildID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, guild)
+}
+
+func (s *server) transferGuildLeadership(ctx *gin.Context) {
+	user, err := s.getAuthenticatedUser(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	guildID, err := uuid.Parse(strings.TrimSpace(ctx.Param("id")))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid guild ID"})
+		return
+	}
+
+	var requestBody guildUserRequest
+	if err := ctx.Bind(&requestBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := s.dbClient.Guild().TransferLeadership(ctx, guildID, user.ID, requestBody.UserID); err != nil {
+		ctx.JSON(guildErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	guild, err := s.dbClient.Guild().FindByID(ctx, guildID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, guild)
+}
+
+func (s *server) getGuildLeaderboard(ctx *gin.Context) {
+	if _, err := s.getAuthenticatedUser(ctx); err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var zoneID *uuid.UUID
+	if rawZoneID := strings.TrimSpace(ctx.Query("zoneId")); rawZoneID != "" {
+		parsed, err := uuid.Parse(rawZoneID)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid zone ID"})
+			return
+		}
+		zoneID = &parsed
+	}
+	limit := guildLeaderboardDefaultSize
+	if parsed, err := strconv.Atoi(strings.TrimSpace(ctx.Query("limit"))); err == nil && parsed > 0 {
+		limit = min(parsed, guildLeaderboardMaxSize)
+	}
+
+	entries, err := s.dbClient.Guild().Leaderboard(ctx, zoneID, limit)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, entries)
+}
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/google/uuid"
+)
+
+func TestNormalizeGuildNameCollapsesWhitespace(t *testing.T) {
+	name, err := normalizeGuildName("  Night   Owls ")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if name != "Night Owls" {
+		t.Fatalf("expected collapsed name, got %q", name)
+	}
+}
+
+func TestNormalizeGuildNameRejectsLength(t *testing.T) {
+	if _, err := normalizeGuildName(" ab "); err == nil {
+		t.Fatalf("expected short name to be rejected")
+	}
+	if _, err := normalizeGuildName("abcdefghijklmnopqrstuvwxyzabcdefg"); err == nil {
+		t.Fatalf("expected long name to be rejected")
+	}
+}
+
+func TestNormalizeGuildTag(t *testing.T) {
+	tag, err := normalizeGuildTag(" nw1 ")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tag != "NW1" {
+		t.Fatalf("expected upper-cased tag, got %q", tag)
+	}
+	if _, err := normalizeGuildTag("N-W"); err == nil {
+		t.Fatalf("expected punctuation to be rejected")
+	}
+	if _, err := normalizeGuildTag("TOOLONG"); err == nil {
+		t.Fatalf("expected long tag to be rejected")
+	}
+}
+
+func TestValidateGuildBankTransfer(t *testing.T) {
+	if _, err := validateGuildBankTransfer(guildBankTransferRequest{}); err == nil {
+		t.Fatalf("expected empty transfer to be rejected")
+	}
+	if _, err := validateGuildBankTransfer(guildBankTransferRequest{Gold: 10, InventoryItemID: 3}); err == nil {
+		t.Fatalf("expected mixed transfer to be rejected")
+	}
+	if _, err := validateGuildBankTransfer(guildBankTransferRequest{Gold: -5}); err == nil {
+		t.Fatalf("expected negative gold to be rejected")
+	}
+
+	request, err := validateGuildBankTransfer(guildBankTransferRequest{InventoryItemID: 3})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if request.Quantity != 1 {
+		t.Fatalf("expected item quantity to default to 1, got %d", request.Quantity)
+	}
+}
+
+func TestGuildCompletedNodeIDs(t *testing.T) {
+	first := uuid.New()
+	second := uuid.New()
+	completed := guildCompletedNodeIDs([]models.GuildQuestNodeCompletion{
+		{QuestNodeID: first},
+		{QuestNodeID: second},
+	})
+	if len(completed) != 2 {
+		t.Fatalf("expected 2 completed nodes, got %d", len(completed))
+	}
+	if _, ok := completed[first]; !ok {
+		t.Fatalf("expected first node to be marked complete")
+	}
+	if _, ok := completed[uuid.New()]; ok {
+		t.Fatalf("expected unknown node to be absent")
+	}
+}
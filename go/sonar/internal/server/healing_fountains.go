@@ -445,6 +445,8 @@ func (s *server) unlockHealingFountain(ctx *gin.Context) {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	fountainZoneID := fountain.ZoneID
+	s.evaluateAchievements(ctx, user.ID, models.AchievementMetricHealingFountainsDiscovered, &fountainZoneID)
 
 	ctx.JSON(http.StatusOK, gin.H{
 		"message":           "healing fountain discovered",
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	stdErrors "errors"
 	"fmt"
+	"log"
 	"math"
 	"net/http"
 	"strconv"
@@ -367,6 +368,9 @@ func (s *server) deleteInventoryItemSuggestionDraft(ctx *gin.Context) {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	if err := s.dbClient.ContentModeration().DeleteByContent(ctx, models.ModeratedContentTypeInventoryItemSuggestionDraft, draftID); err != nil {
+		log.Printf("[moderation][inventory-draft] failed to remove queue entry draft_id=%s err=%v", draftID, err)
+	}
 	ctx.JSON(http.StatusOK, gin.H{"message": "inventory item suggestion draft deleted"})
 }
 
@@ -394,6 +398,9 @@ func (s *server) convertInventoryItemSuggestionDraft(ctx *gin.Context) {
 		ctx.JSON(http.StatusOK, existing)
 		return
 	}
+	if !s.requireContentModerationApproval(ctx, models.ModeratedContentTypeInventoryItemSuggestionDraft, draft.ID) {
+		return
+	}
 
 	item, err := s.materializeInventoryItemSuggestionDraft(ctx, draft)
 	if err != nil {
@@ -0,0 +1,472 @@
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/jobs"
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+)
+
+const requestLocaleChainContextKey = "localization.localeChain"
+
+// requestLocaleChain resolves Accept-Language against the enabled locales
+// once per request and reports the served locale in Content-Language. A
+// nil chain means the English source is served as stored.
+func (s *server) requestLocaleChain(ctx *gin.Context) []string {
+	if cached, ok := ctx.Get(requestLocaleChainContextKey); ok {
+		chain, _ := cached.([]string)
+		return chain
+	}
+	var chain []string
+	locale := models.DefaultLocale
+	if header := strings.TrimSpace(ctx.GetHeader("Accept-Language")); header != "" {
+		enabled, err := s.dbClient.Localization().FindEnabledLocales(ctx)
+		if err != nil {
+			log.Printf("[localization][locale] failed to load locales err=%v", err)
+		} else {
+			codes := make([]string, 0, len(enabled))
+			for _, candidate := range enabled {
+				codes = append(codes, candidate.Code)
+			}
+			locale, chain = models.ResolveLocale(header, codes)
+		}
+	}
+	ctx.Set(requestLocaleChainContextKey, chain)
+	ctx.Header("Content-Language", locale)
+	return chain
+}
+
+// localizeContent applies the request locale's translations to entities of
+// one type, keyed by entity ID. Translation lookups that fail leave the
+// English source in place rather than failing the request.
+func (s *server) localizeContent(ctx *gin.Context, entityType string, texts map[string][]models.TranslatableText) {
+	if len(texts) == 0 {
+		return
+	}
+	chain := s.requestLocaleChain(ctx)
+	if len(chain) == 0 {
+		return
+	}
+	entityIDs := make([]string, 0, len(texts))
+	for entityID := range texts {
+		entityIDs = append(entityIDs, entityID)
+	}
+	translations, err := s.dbClient.Localization().FindTranslations(ctx, entityType, entityIDs, chain)
+	if err != nil {
+		log.Printf("[localization][apply] failed to load translations entity_type=%s err=%v", entityType, err)
+		return
+	}
+	byEntity := map[string][]models.ContentTranslation{}
+	for _, translation := range translations {
+		byEntity[translation.EntityID] = append(byEntity[translation.EntityID], translation)
+	}
+	for entityID, entityTexts := range texts {
+		models.ApplyTranslations(entityTexts, byEntity[entityID], chain)
+	}
+}
+
+func (s *server) localizeScenarios(ctx *gin.Context, scenarios ...*models.Scenario) {
+	texts := make(map[string][]models.TranslatableText, len(scenarios))
+	for _, scenario := range scenarios {
+		texts[scenario.ID.String()] = models.ScenarioTranslatableText(scenario)
+	}
+	s.localizeContent(ctx, models.TranslatableEntityTypeScenario, texts)
+}
+
+func (s *server) localizeExpositions(ctx *gin.Context, expositions ...*models.Exposition) {
+	texts := make(map[string][]models.TranslatableText, len(expositions))
+	for _, exposition := range expositions {
+		texts[exposition.ID.String()] = models.ExpositionTranslatableText(exposition)
+	}
+	s.localizeContent(ctx, models.TranslatableEntityTypeExposition, texts)
+}
+
+func (s *server) localizeCharacters(ctx *gin.Context, characters ...*models.Character) {
+	texts := make(map[string][]models.TranslatableText, len(characters))
+	for _, character := range characters {
+		texts[character.ID.String()] = models.CharacterTranslatableText(character)
+	}
+	s.localizeContent(ctx, models.TranslatableEntityTypeCharacter, texts)
+}
+
+func (s *server) localizeQuests(ctx *gin.Context, quests ...*models.Quest) {
+	texts := make(map[string][]models.TranslatableText, len(quests))
+	for _, quest := range quests {
+		texts[quest.ID.String()] = models.QuestTranslatableText(quest)
+	}
+	s.localizeContent(ctx, models.TranslatableEntityTypeQuest, texts)
+}
+
+func (s *server) localizeInventoryItems(ctx *gin.Context, items ...*models.InventoryItem) {
+	texts := make(map[string][]models.TranslatableText, len(items))
+	for _, item := range items {
+		texts[strconv.Itoa(item.ID)] = models.InventoryItemTranslatableText(item)
+	}
+	s.localizeContent(ctx, models.TranslatableEntityTypeInventoryItem, texts)
+}
+
+func (s *server) localizeTutorialConfig(ctx *gin.Context, config *models.TutorialConfig) {
+	if config == nil {
+		return
+	}
+	s.localizeContent(ctx, models.TranslatableEntityTypeTutorialConfig, map[string][]models.TranslatableText{
+		strconv.Itoa(config.ID): models.TutorialConfigTranslatableText(config),
+	})
+	if config.Character != nil {
+		s.localizeCharacters(ctx, config.Character)
+	}
+}
+
+func (s *server) getTranslationLocales(ctx *gin.Context) {
+	if _, err := s.getAuthenticatedUser(ctx); err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	locales, err := s.dbClient.Localization().FindLocales(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, locales)
+}
+
+type upsertTranslationLocaleRequest struct {
+	Code             string `json:"code"`
+	Name             string `json:"name"`
+	Enabled          *bool  `json:"enabled"`
+	MachineTranslate *bool  `json:"machineTranslate"`
+}
+
+func (s *server) upsertTranslationLocale(ctx *gin.Context) {
+	if _, err := s.getAuthenticatedUser(ctx); err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	var requestBody upsertTranslationLocaleRequest
+	if err := ctx.ShouldBindJSON(&requestBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	code := models.NormalizeLocale(requestBody.Code)
+	if code == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "code must be a language tag such as fr or pt-BR"})
+		return
+	}
+	if code == models.DefaultLocale {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "the default locale is served from the source text"})
+		return
+	}
+	locale := &models.TranslationLocale{
+		Code:             code,
+		Name:             strings.TrimSpace(requestBody.Name),
+		Enabled:          true,
+		MachineTranslate: true,
+	}
+	if requestBody.Enabled != nil {
+		locale.Enabled = *requestBody.Enabled
+	}
+	if requestBody.MachineTranslate != nil {
+		locale.MachineTranslate = *requestBody.MachineTranslate
+	}
+	if err := s.dbClient.Localization().UpsertLocale(ctx, locale); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, locale)
+}
+
+func (s *server) deleteTranslationLocale(ctx *gin.Context) {
+	if _, err := s.getAuthenticatedUser(ctx); err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	code := models.NormalizeLocale(ctx.Param("code"))
+	if code == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid locale"})
+		return
+	}
+	if err := s.dbClient.Localization().DeleteLocale(ctx, code); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"deleted": true})
+}
+
+// parseTranslatableEntity validates the entity path parameters and loads
+// the entity's English text.
+func (s *server) parseTranslatableEntity(ctx *gin.Context) (*models.TranslationSource, bool) {
+	entityType := strings.TrimSpace(ctx.Param("entityType"))
+	entityID := strings.TrimSpace(ctx.Param("entityId"))
+	if !models.IsTranslatableEntityType(entityType) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "unsupported entity type", "entityTypes": models.TranslatableEntityTypes()})
+		return nil, false
+	}
+	validID := false
+	switch entityType {
+	case models.TranslatableEntityTypeInventoryItem, models.TranslatableEntityTypeTutorialConfig:
+		_, err := strconv.Atoi(entityID)
+		validID = err == nil
+	default:
+		_, err := uuid.Parse(entityID)
+		validID = err == nil
+	}
+	if !validID {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid entity ID"})
+		return nil, false
+	}
+	source, err := s.dbClient.Localization().FindTranslationSource(ctx, entityType, entityID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	if source == nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "entity not found"})
+		return nil, false
+	}
+	return source, true
+}
+
+type contentTranslationView struct {
+	models.ContentTranslation
+	Stale bool `json:"stale"`
+}
+
+type contentTranslationsResponse struct {
+	EntityType   string                              `json:"entityType"`
+	EntityID     string                              `json:"entityId"`
+	GenreID      uuid.UUID                           `json:"genreId"`
+	Source       map[string]string                   `json:"source"`
+	Translations map[string][]contentTranslationView `json:"translations"`
+}
+
+func (s *server) getContentTranslations(ctx *gin.Context) {
+	if _, err := s.getAuthenticatedUser(ctx); err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	source, ok := s.parseTranslatableEntity(ctx)
+	if !ok {
+		return
+	}
+	translations, err := s.dbClient.Localization().FindTranslations(ctx, source.EntityType, []string{source.EntityID}, nil)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	sourceText := source.SourceText()
+	response := contentTranslationsResponse{
+		EntityType:   source.EntityType,
+		EntityID:     source.EntityID,
+		GenreID:      source.GenreID,
+		Source:       sourceText,
+		Translations: map[string][]contentTranslationView{},
+	}
+	for _, translation := range translations {
+		response.Translations[translation.Locale] = append(response.Translations[translation.Locale], contentTranslationView{
+			ContentTranslation: translation,
+			Stale:              !translation.Current(sourceText[translation.Field]),
+		})
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+type saveContentTranslationsRequest struct {
+	// Fields maps source field names to the override text for the locale.
+	Fields map[string]string `json:"fields"`
+}
+
+func (s *server) saveContentTranslations(ctx *gin.Context) {
+	user, err := s.getAuthenticatedUser(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	source, ok := s.parseTranslatableEntity(ctx)
+	if !ok {
+		return
+	}
+	locale := models.NormalizeLocale(ctx.Param("locale"))
+	if locale == "" || locale == models.DefaultLocale {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid locale"})
+		return
+	}
+	var requestBody saveContentTranslationsRequest
+	if err := ctx.ShouldBindJSON(&requestBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	sourceText := source.SourceText()
+	rows := make([]models.ContentTranslation, 0, len(requestBody.Fields))
+	for field, text := range requestBody.Fields {
+		original, ok := sourceText[field]
+		if !ok {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "unknown field: " + field})
+			return
+		}
+		text = strings.TrimSpace(text)
+		if text == "" {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "translation for " + field + " is empty"})
+			return
+		}
+		rows = append(rows, models.ContentTranslation{
+			EntityType:   source.EntityType,
+			EntityID:     source.EntityID,
+			Locale:       locale,
+			Field:        field,
+			Text:         text,
+			SourceHash:   models.TranslationSourceHash(original),
+			EditedByUser: &user.ID,
+		})
+	}
+	if err := s.dbClient.Localization().SaveManualTranslations(ctx, rows); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	s.getContentTranslations(ctx)
+}
+
+func (s *server) deleteContentTranslation(ctx *gin.Context) {
+	if _, err := s.getAuthenticatedUser(ctx); err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid translation ID"})
+		return
+	}
+	if err := s.dbClient.Localization().DeleteTranslation(ctx, id); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"deleted": true})
+}
+
+type machineTranslateContentRequest struct {
+	Locales []string `json:"locales"`
+	Force   bool     `json:"force"`
+}
+
+func (s *server) machineTranslateContent(ctx *gin.Context) {
+	if _, err := s.getAuthenticatedUser(ctx); err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	source, ok := s.parseTranslatableEntity(ctx)
+	if !ok {
+		return
+	}
+	var requestBody machineTranslateContentRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&requestBody); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if err := s.enqueueContentTranslation(source.EntityType, source.EntityID, requestBody.Locales, requestBody.Force); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusAccepted, gin.H{"queued": true})
+}
+
+func (s *server) enqueueContentTranslation(entityType string, entityID string, locales []string, force bool) error {
+	payload, err := json.Marshal(jobs.TranslateContentTaskPayload{
+		EntityType: entityType,
+		EntityID:   entityID,
+		Locales:    locales,
+		Force:      force,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = s.asyncClient.Enqueue(asynq.NewTask(jobs.TranslateContentTaskType, payload))
+	return err
+}
+
+func (s *server) getTranslationGlossary(ctx *gin.Context) {
+	if _, err := s.getAuthenticatedUser(ctx); err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	genreID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid genre ID"})
+		return
+	}
+	terms, err := s.dbClient.Localization().FindGlossary(ctx, genreID, models.NormalizeLocale(ctx.Query("locale")))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, terms)
+}
+
+type createTranslationGlossaryTermRequest struct {
+	Locale      string `json:"locale"`
+	Term        string `json:"term"`
+	Translation string `json:"translation"`
+	Notes       string `json:"notes"`
+}
+
+func (s *server) createTranslationGlossaryTerm(ctx *gin.Context) {
+	if _, err := s.getAuthenticatedUser(ctx); err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	genreID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid genre ID"})
+		return
+	}
+	var requestBody createTranslationGlossaryTermRequest
+	if err := ctx.ShouldBindJSON(&requestBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	locale := models.NormalizeLocale(requestBody.Locale)
+	if locale == "" || locale == models.DefaultLocale {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid locale"})
+		return
+	}
+	if strings.TrimSpace(requestBody.Term) == "" || strings.TrimSpace(requestBody.Translation) == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "term and translation are required"})
+		return
+	}
+	term := &models.TranslationGlossaryTerm{
+		GenreID:     genreID,
+		Locale:      locale,
+		Term:        requestBody.Term,
+		Translation: requestBody.Translation,
+		Notes:       strings.TrimSpace(requestBody.Notes),
+	}
+	if err := s.dbClient.Localization().CreateGlossaryTerm(ctx, term); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusCreated, term)
+}
+
+func (s *server) deleteTranslationGlossaryTerm(ctx *gin.Context) {
+	if _, err := s.getAuthenticatedUser(ctx); err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	id, err := uuid.Parse(ctx.Param("termId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid glossary term ID"})
+		return
+	}
+	if err := s.dbClient.Localization().DeleteGlossaryTerm(ctx, id); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"deleted": true})
+}
@@ -366,10 +366,20 @@ func (s *server) createMainStoryTemplate(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	issues, err := s.mainStoryTemplateEditErrors(ctx, template)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(issues) > 0 {
+		respondWithContentValidationErrors(ctx, issues)
+		return
+	}
 	if err := s.dbClient.MainStoryTemplate().Create(ctx, template); err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	s.attachMainStoryTemplateValidationIssues(ctx, template)
 	ctx.JSON(http.StatusOK, template)
 }
 
@@ -401,9 +411,20 @@ func (s *server) updateMainStoryTemplate(ctx *gin.Context) {
 	}
 	template.CreatedAt = existing.CreatedAt
 
+	issues, err := s.mainStoryTemplateEditErrors(ctx, template)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(issues) > 0 {
+		respondWithContentValidationErrors(ctx, issues)
+		return
+	}
+
 	if err := s.dbClient.MainStoryTemplate().Update(ctx, template); err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	s.attachMainStoryTemplateValidationIssues(ctx, template)
 	ctx.JSON(http.StatusOK, template)
 }
@@ -0,0 +1,128 @@
+package server
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	mapTileCacheTTL = 15 * time.Minute
+	// Clients revalidate after a minute; unchanged tiles come back as 304s.
+	mapTileCacheControl = "private, max-age=60, must-revalidate"
+)
+
+func mapTileCacheKey(tile models.MapTile, etag string) string {
+	return "map-tile:" + tile.String() + ":" + strings.Trim(etag, `"`)
+}
+
+func etagMatches(ifNoneMatch string, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *server) cachedMapTile(ctx context.Context, key string) []byte {
+	if s.redisClient == nil {
+		return nil
+	}
+	value, err := s.redisClient.Get(ctx, key).Bytes()
+	if err != nil {
+		if err != redis.Nil {
+			log.Printf("[map-tiles][cache] read failed key=%s err=%v", key, err)
+		}
+		return nil
+	}
+	return value
+}
+
+func (s *server) cacheMapTile(ctx context.Context, key string, tile []byte) {
+	if s.redisClient == nil {
+		return
+	}
+	if err := s.redisClient.Set(ctx, key, tile, mapTileCacheTTL).Err(); err != nil {
+		log.Printf("[map-tiles][cache] write failed key=%s err=%v", key, err)
+	}
+}
+
+// getMapTile serves a Mapbox Vector Tile of zones and map content for the
+// authenticated user. Tiles are keyed by an ETag over the content inside the
+// tile and the user's discovery state, so unchanged tiles cost one aggregate
+// query.
+func (s *server) getMapTile(ctx *gin.Context) {
+	startedAt := time.Now()
+	user, err := s.getAuthenticatedUser(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	tile, err := models.ParseMapTile(ctx.Param("z"), ctx.Param("x"), ctx.Param("y"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	storyFlags, err := s.loadUserStoryFlagMap(ctx, user.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	viewer := models.MapTileViewer{UserID: user.ID}
+	for flag, active := range storyFlags {
+		if active {
+			viewer.StoryFlags = append(viewer.StoryFlags, flag)
+		}
+	}
+
+	requestCtx := ctx.Request.Context()
+	version, err := s.dbClient.MapTile().Version(requestCtx, tile, viewer)
+	if err != nil {
+		log.Printf("[map-tiles][version] failed tile=%s user=%s err=%v", tile, user.ID, err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	etag := version.ETag(tile, viewer)
+	ctx.Header("ETag", etag)
+	ctx.Header("Cache-Control", mapTileCacheControl)
+	ctx.Header("X-Zone-Content-Version", version.Content)
+	ctx.Header("Vary", "Authorization")
+
+	if etagMatches(ctx.GetHeader("If-None-Match"), etag) {
+		ctx.Status(http.StatusNotModified)
+		return
+	}
+
+	cacheKey := mapTileCacheKey(tile, etag)
+	rendered := s.cachedMapTile(requestCtx, cacheKey)
+	cached := rendered != nil
+	if !cached {
+		rendered, err = s.dbClient.MapTile().Render(requestCtx, tile, viewer)
+		if err != nil {
+			log.Printf("[map-tiles][render] failed tile=%s user=%s err=%v", tile, user.ID, err)
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		s.cacheMapTile(requestCtx, cacheKey, rendered)
+	}
+
+	log.Printf(
+		"[map-tiles][serve] tile=%s user=%s bytes=%d cached=%t elapsedMs=%d",
+		tile,
+		user.ID,
+		len(rendered),
+		cached,
+		time.Since(startedAt).Milliseconds(),
+	)
+	ctx.Data(http.StatusOK, models.MapTileContentType, rendered)
+}
@@ -0,0 +1,20 @@
+package server
+
+import "testing"
+
+func TestEtagMatches(t *testing.T) {
+	etag := `"abc123"`
+	cases := map[string]bool{
+		`"abc123"`:          true,
+		`W/"abc123"`:        true,
+		`"other", "abc123"`: true,
+		`*`:                 true,
+		`"other"`:           false,
+		``:                  false,
+	}
+	for header, want := range cases {
+		if got := etagMatches(header, etag); got != want {
+			t.Fatalf("etagMatches(%q) = %t, want %t", header, got, want)
+		}
+	}
+}
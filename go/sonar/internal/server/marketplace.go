@@ -0,0 +1,271 @@
+package server
+
+import (
+	stdErrors "errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/db"
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	marketListingFeePercent        = 5
+	marketListingMinFee            = 1
+	marketListingDefaultDuration   = 72 * time.Hour
+	marketListingMaxDuration       = 7 * 24 * time.Hour
+	marketListingMaxPrice          = 1_000_000
+	marketListingSearchDefaultSize = 50
+	marketListingSearchMaxSize     = 100
+)
+
+type createMarketListingRequest struct {
+	InventoryItemID int `json:"inventoryItemId" binding:"required"`
+	Quantity        int `json:"quantity"`
+	Price           int `json:"price" binding:"required"`
+	DurationHours   int `json:"durationHours"`
+}
+
+// marketListingFee is charged up front when an item is listed and is kept
+// whether or not the listing sells.
+func marketListingFee(price int) int {
+	if price <= 0 {
+		return 0
+	}
+	fee := int(math.Ceil(float64(price) * float64(marketListingFeePercent) / 100.0))
+	return maxInt(fee, marketListingMinFee)
+}
+
+func marketListingDuration(hours int) time.Duration {
+	if hours <= 0 {
+		return marketListingDefaultDuration
+	}
+	duration := time.Duration(hours) * time.Hour
+	if duration > marketListingMaxDuration {
+		return marketListingMaxDuration
+	}
+	return duration
+}
+
+func parseMarketListingCSVQuery(raw string) []string {
+	values := []string{}
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.ToLower(strings.TrimSpace(part))
+		if part != "" {
+			values = append(values, part)
+		}
+	}
+	return values
+}
+
+func parseMarketListingFilter(ctx *gin.Context, zoneID uuid.UUID) models.MarketListingFilter {
+	filter := models.MarketListingFilter{
+		ZoneID:      zoneID,
+		Tags:        parseMarketListingCSVQuery(ctx.Query("tags")),
+		RarityTiers: parseMarketListingCSVQuery(ctx.Query("rarity")),
+		Limit:       marketListingSearchDefaultSize,
+	}
+	if minLevel, err := strconv.Atoi(strings.TrimSpace(ctx.Query("minLevel"))); err == nil && minLevel > 0 {
+		filter.MinItemLevel = minLevel
+	}
+	if maxLevel, err := strconv.Atoi(strings.TrimSpace(ctx.Query("maxLevel"))); err == nil && maxLevel > 0 {
+		filter.MaxItemLevel = maxLevel
+	}
+	if limit, err := strconv.Atoi(strings.TrimSpace(ctx.Query("limit"))); err == nil && limit > 0 {
+		filter.Limit = min(limit, marketListingSearchMaxSize)
+	}
+	if offset, err := strconv.Atoi(strings.TrimSpace(ctx.Query("offset"))); err == nil && offset > 0 {
+		filter.Offset = offset
+	}
+	return filter
+}
+
+func marketListingErrorStatus(err error) int {
+	switch {
+	case stdErrors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	case stdErrors.Is(err, db.ErrMarketListingNotAvailable),
+		stdErrors.Is(err, db.ErrInsufficientGold),
+		stdErrors.Is(err, db.ErrInsufficientItemQuantity):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func (s *server) searchZoneMarketListings(ctx *gin.Context) {
+	user, err := s.getAuthenticatedUser(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	zoneID, err := uuid.Parse(strings.TrimSpace(ctx.Param("id")))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid zone ID"})
+		return
+	}
+
+	filter := parseMarketListingFilter(ctx, zoneID)
+	if strings.EqualFold(strings.TrimSpace(ctx.Query("excludeMine")), "true") {
+		filter.ExcludeSeller = &user.ID
+	}
+
+	listings, err := s.dbClient.MarketListing().Search(ctx, filter, time.Now())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, listings)
+}
+
+func (s *server) createZoneMarketListing(ctx *gin.Context) {
+	user, err := s.getAuthenticatedUser(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	zoneID, err := uuid.Parse(strings.TrimSpace(ctx.Param("id")))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid zone ID"})
+		return
+	}
+	if _, err := s.dbClient.Zone().FindByID(ctx, zoneID); err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "zone not found"})
+		return
+	}
+
+	var requestBody createMarketListingRequest
+	if err := ctx.Bind(&requestBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if requestBody.Quantity <= 0 {
+		requestBody.Quantity = 1
+	}
+	if requestBody.Price <= 0 || requestBody.Price > marketListingMaxPrice {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "price must be between 1 and 1000000"})
+		return
+	}
+
+	item, err := s.dbClient.InventoryItem().FindInventoryItemByID(ctx, requestBody.InventoryItemID)
+	if err != nil || item == nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "inventory item not found"})
+		return
+	}
+	if item.Archived {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "this item cannot be listed"})
+		return
+	}
+
+	fee := marketListingFee(requestBody.Price)
+	listing := &models.MarketListing{
+		ZoneID:          zoneID,
+		SellerUserID:    user.ID,
+		InventoryItemID: item.ID,
+		Quantity:        requestBody.Quantity,
+		Price:           requestBody.Price,
+		ListingFee:      fee,
+		ExpiresAt:       time.Now().Add(marketListingDuration(requestBody.DurationHours)),
+	}
+	if err := s.dbClient.MarketListing().Create(ctx, listing); err != nil {
+		ctx.JSON(marketListingErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	created, err := s.dbClient.MarketListing().FindByID(ctx, listing.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusCreated, created)
+}
+
+func (s *server) getMyMarketListings(ctx *gin.Context) {
+	user, err := s.getAuthenticatedUser(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	listings, err := s.dbClient.MarketListing().FindBySeller(ctx, user.ID, marketListingSearchMaxSize)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, listings)
+}
+
+func (s *server) purchaseMarketListing(ctx *gin.Context) {
+	user, err := s.getAuthenticatedUser(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	listingID, err := uuid.Parse(strings.TrimSpace(ctx.Param("id")))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid listing ID"})
+		return
+	}
+
+	existing, err := s.dbClient.MarketListing().FindByID(ctx, listingID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if existing == nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "listing not found"})
+		return
+	}
+	if existing.SellerUserID == user.ID {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "you cannot buy your own listing"})
+		return
+	}
+
+	listing, err := s.dbClient.MarketListing().Purchase(ctx, listingID, user.ID, time.Now())
+	if err != nil {
+		ctx.JSON(marketListingErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	updatedUser, err := s.dbClient.User().FindByID(ctx, user.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch updated user: " + err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"user":    updatedUser,
+		"listing": listing,
+	})
+}
+
+func (s *server) cancelMarketListing(ctx *gin.Context) {
+	user, err := s.getAuthenticatedUser(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	listingID, err := uuid.Parse(strings.TrimSpace(ctx.Param("id")))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid listing ID"})
+		return
+	}
+
+	listing, err := s.dbClient.MarketListing().Cancel(ctx, listingID, user.ID, time.Now())
+	if err != nil {
+		ctx.JSON(marketListingErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, listing)
+}
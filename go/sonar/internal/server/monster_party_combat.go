@@ -2134,6 +2134,18 @@ func (s *server) finalizeMonsterBattleIfDefeated(
 				return nil, err
 			}
 		}
+		encounterZoneID := encounter.ZoneID
+		for _, participant := range participants {
+			s.evaluateAchievements(ctx, participant.UserID, models.AchievementMetricMonsterEncounterVictories, &encounterZoneID)
+		}
+	}
+	monsterAffinities := monsterBoostedAffinities(monster)
+	for _, participantID := range participantIDs {
+		s.trackBountyEvent(ctx, participantID, models.BountyEvent{
+			ObjectiveType:     models.BountyObjectiveDefeatMonsters,
+			ZoneID:            monster.ZoneID,
+			MonsterAffinities: monsterAffinities,
+		})
 	}
 	if err := s.completeQuestMonsterObjectives(
 		ctx,
@@ -2222,7 +2234,7 @@ func (s *server) completeQuestMonsterObjectives(
 				continue
 			}
 			sharedQuestNodeIDs[currentNode.ID] = struct{}{}
-			s.shareQuestNodeCompletionWithEligiblePartyMembers(ctx, user, quest, currentNode)
+			s.shareQuestNodeCompletion(ctx, user, quest, currentNode)
 		}
 	}
 
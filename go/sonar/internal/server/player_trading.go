@@ -0,0 +1,354 @@
+package server
+
+import (
+	"context"
+	stdErrors "errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/db"
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	tradeOfferTTL             = 24 * time.Hour
+	tradeOfferMaxItemsPerSide = 12
+)
+
+type tradeOfferItemPayload struct {
+	InventoryItemID int `json:"inventoryItemId"`
+	Quantity        int `json:"quantity"`
+}
+
+type createTradeOfferRequest struct {
+	RecipientUserID uuid.UUID               `json:"recipientUserId" binding:"required"`
+	OfferedItems    []tradeOfferItemPayload `json:"offeredItems"`
+	OfferedGold     int                     `json:"offeredGold"`
+	RequestedItems  []tradeOfferItemPayload `json:"requestedItems"`
+	RequestedGold   int                     `json:"requestedGold"`
+}
+
+// normalizeTradeOfferItemPayloads merges repeated entries for the same item
+// and rejects anything that can't be traded, returning entries in item ID
+// order so the stored offer is stable.
+func normalizeTradeOfferItemPayloads(field string, payloads []tradeOfferItemPayload) ([]tradeOfferItemPayload, error) {
+	quantityByItemID := map[int]int{}
+	for idx, payload := range payloads {
+		if payload.InventoryItemID <= 0 {
+			return nil, fmt.Errorf("%s[%d].inventoryItemId is required", field, idx)
+		}
+		if payload.Quantity <= 0 {
+			return nil, fmt.Errorf("%s[%d].quantity must be 1 or greater", field, idx)
+		}
+		quantityByItemID[payload.InventoryItemID] += payload.Quantity
+	}
+	if len(quantityByItemID) > tradeOfferMaxItemsPerSide {
+		return nil, fmt.Errorf("%s can include at most %d different items", field, tradeOfferMaxItemsPerSide)
+	}
+
+	itemIDs := make([]int, 0, len(quantityByItemID))
+	for itemID := range quantityByItemID {
+		itemIDs = append(itemIDs, itemID)
+	}
+	sort.Ints(itemIDs)
+
+	normalized := make([]tradeOfferItemPayload, 0, len(itemIDs))
+	for _, itemID := range itemIDs {
+		normalized = append(normalized, tradeOfferItemPayload{
+			InventoryItemID: itemID,
+			Quantity:        quantityByItemID[itemID],
+		})
+	}
+	return normalized, nil
+}
+
+// availableTradeQuantities returns how many unequipped copies of each item
+// the user holds, which is the most they can put into a trade or listing.
+func (s *server) availableTradeQuantities(ctx context.Context, userID uuid.UUID) (map[int]int, error) {
+	owned, err := s.dbClient.InventoryItem().GetUsersItems(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	equipment, err := s.dbClient.UserEquipment().FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	equippedByOwnedID := map[uuid.UUID]int{}
+	for _, entry := range equipment {
+		equippedByOwnedID[entry.OwnedInventoryItemID]++
+	}
+
+	available := map[int]int{}
+	for _, item := range owned {
+		quantity := item.Quantity - equippedByOwnedID[item.ID]
+		if quantity > 0 {
+			available[item.InventoryItemID] += quantity
+		}
+	}
+	return available, nil
+}
+
+func validateTradeItemsAvailable(field string, items []tradeOfferItemPayload, available map[int]int) error {
+	for _, item := range items {
+		if available[item.InventoryItemID] < item.Quantity {
+			return fmt.Errorf("%s: not enough unequipped copies of item %d", field, item.InventoryItemID)
+		}
+	}
+	return nil
+}
+
+// usersCanTrade reports whether two players are allowed to open a direct
+// trade: they must share a party or be friends.
+func (s *server) usersCanTrade(ctx context.Context, initiator *models.User, recipient *models.User) (bool, error) {
+	if initiator.PartyID != nil && recipient.PartyID != nil && *initiator.PartyID == *recipient.PartyID {
+		return true, nil
+	}
+	return s.dbClient.Friend().Exists(ctx, initiator.ID, recipient.ID)
+}
+
+func tradeOfferErrorStatus(err error) int {
+	switch {
+	case stdErrors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	case stdErrors.Is(err, db.ErrTradeOfferNotOpen),
+		stdErrors.Is(err, db.ErrInsufficientGold),
+		stdErrors.Is(err, db.ErrInsufficientItemQuantity):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func (s *server) createTradeOffer(ctx *gin.Context) {
+	user, err := s.getAuthenticatedUser(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var requestBody createTradeOfferRequest
+	if err := ctx.Bind(&requestBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if requestBody.RecipientUserID == user.ID {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "you cannot trade with yourself"})
+		return
+	}
+	if requestBody.OfferedGold < 0 || requestBody.RequestedGold < 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "gold amounts cannot be negative"})
+		return
+	}
+
+	offeredItems, err := normalizeTradeOfferItemPayloads("offeredItems", requestBody.OfferedItems)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	requestedItems, err := normalizeTradeOfferItemPayloads("requestedItems", requestBody.RequestedItems)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(offeredItems) == 0 && len(requestedItems) == 0 && requestBody.OfferedGold == 0 && requestBody.RequestedGold == 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "a trade must include at least one item or some gold"})
+		return
+	}
+
+	recipient, err := s.dbClient.User().FindByID(ctx, requestBody.RecipientUserID)
+	if err != nil || recipient == nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "recipient not found"})
+		return
+	}
+
+	canTrade, err := s.usersCanTrade(ctx, user, recipient)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !canTrade {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "you can only trade with party members or friends"})
+		return
+	}
+
+	if user.Gold < requestBody.OfferedGold {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "insufficient gold"})
+		return
+	}
+	if recipient.Gold < requestBody.RequestedGold {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "recipient does not have that much gold"})
+		return
+	}
+
+	initiatorAvailable, err := s.availableTradeQuantities(ctx, user.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateTradeItemsAvailable("offeredItems", offeredItems, initiatorAvailable); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	recipientAvailable, err := s.availableTradeQuantities(ctx, recipient.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateTradeItemsAvailable("requestedItems", requestedItems, recipientAvailable); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	now := time.Now()
+	offer := &models.TradeOffer{
+		InitiatorUserID: user.ID,
+		RecipientUserID: recipient.ID,
+		InitiatorGold:   requestBody.OfferedGold,
+		RecipientGold:   requestBody.RequestedGold,
+		Status:          models.TradeOfferStatusPending,
+		ExpiresAt:       now.Add(tradeOfferTTL),
+	}
+	for _, item := range offeredItems {
+		offer.Items = append(offer.Items, models.TradeOfferItem{
+			OwnerUserID:     user.ID,
+			InventoryItemID: item.InventoryItemID,
+			Quantity:        item.Quantity,
+		})
+	}
+	for _, item := range requestedItems {
+		offer.Items = append(offer.Items, models.TradeOfferItem{
+			OwnerUserID:     recipient.ID,
+			InventoryItemID: item.InventoryItemID,
+			Quantity:        item.Quantity,
+		})
+	}
+
+	if err := s.dbClient.TradeOffer().Create(ctx, offer); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create trade offer: " + err.Error()})
+		return
+	}
+
+	created, err := s.dbClient.TradeOffer().FindByID(ctx, offer.ID)
+	if err != nil || created == nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load trade offer"})
+		return
+	}
+
+	s.sendTradeOfferPushNotification(ctx, created, user)
+	ctx.JSON(http.StatusCreated, created)
+}
+
+func (s *server) getTradeOffers(ctx *gin.Context) {
+	user, err := s.getAuthenticatedUser(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var offers []models.TradeOffer
+	if strings.EqualFold(strings.TrimSpace(ctx.Query("history")), "true") {
+		offers, err = s.dbClient.TradeOffer().FindRecentForUser(ctx, user.ID, 50)
+	} else {
+		offers, err = s.dbClient.TradeOffer().FindOpenForUser(ctx, user.ID, time.Now())
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, offers)
+}
+
+func (s *server) loadTradeOfferForParticipant(ctx *gin.Context, userID uuid.UUID) (*models.TradeOffer, bool) {
+	offerID, err := uuid.Parse(strings.TrimSpace(ctx.Param("id")))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid trade offer ID"})
+		return nil, false
+	}
+	offer, err := s.dbClient.TradeOffer().FindByID(ctx, offerID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	if offer == nil || !offer.IsParticipant(userID) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "trade offer not found"})
+		return nil, false
+	}
+	return offer, true
+}
+
+func (s *server) confirmTradeOffer(ctx *gin.Context) {
+	user, err := s.getAuthenticatedUser(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	offer, ok := s.loadTradeOfferForParticipant(ctx, user.ID)
+	if !ok {
+		return
+	}
+
+	updated, err := s.dbClient.TradeOffer().Confirm(ctx, offer.ID, user.ID, time.Now())
+	if err != nil {
+		ctx.JSON(tradeOfferErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	if updated != nil && updated.Status == models.TradeOfferStatusCompleted {
+		otherUserID := updated.InitiatorUserID
+		if otherUserID == user.ID {
+			otherUserID = updated.RecipientUserID
+		}
+		s.sendTradeOfferCompletedPushNotification(ctx, updated, otherUserID)
+	}
+
+	ctx.JSON(http.StatusOK, updated)
+}
+
+func (s *server) declineTradeOffer(ctx *gin.Context) {
+	s.closeTradeOffer(ctx, models.TradeOfferStatusDeclined)
+}
+
+func (s *server) cancelTradeOffer(ctx *gin.Context) {
+	s.closeTradeOffer(ctx, models.TradeOfferStatusCancelled)
+}
+
+func (s *server) closeTradeOffer(ctx *gin.Context, status models.TradeOfferStatus) {
+	user, err := s.getAuthenticatedUser(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	offer, ok := s.loadTradeOfferForParticipant(ctx, user.ID)
+	if !ok {
+		return
+	}
+	if status == models.TradeOfferStatusDeclined && offer.RecipientUserID != user.ID {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "only the recipient can decline a trade offer"})
+		return
+	}
+	if status == models.TradeOfferStatusCancelled && offer.InitiatorUserID != user.ID {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "only the initiator can cancel a trade offer"})
+		return
+	}
+
+	if err := s.dbClient.TradeOffer().Close(ctx, offer.ID, status, time.Now()); err != nil {
+		ctx.JSON(tradeOfferErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	updated, err := s.dbClient.TradeOffer().FindByID(ctx, offer.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, updated)
+}
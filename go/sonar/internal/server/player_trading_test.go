@@ -0,0 +1,86 @@
+package server
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNormalizeTradeOfferItemPayloadsMergesDuplicates(t *testing.T) {
+	normalized, err := normalizeTradeOfferItemPayloads("offeredItems", []tradeOfferItemPayload{
+		{InventoryItemID: 7, Quantity: 1},
+		{InventoryItemID: 3, Quantity: 2},
+		{InventoryItemID: 7, Quantity: 4},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(normalized) != 2 {
+		t.Fatalf("expected 2 merged entries, got %+v", normalized)
+	}
+	if normalized[0].InventoryItemID != 3 || normalized[0].Quantity != 2 {
+		t.Fatalf("expected item 3 x2 first, got %+v", normalized[0])
+	}
+	if normalized[1].InventoryItemID != 7 || normalized[1].Quantity != 5 {
+		t.Fatalf("expected item 7 x5 second, got %+v", normalized[1])
+	}
+}
+
+func TestNormalizeTradeOfferItemPayloadsRejectsInvalidQuantity(t *testing.T) {
+	if _, err := normalizeTradeOfferItemPayloads("offeredItems", []tradeOfferItemPayload{
+		{InventoryItemID: 7, Quantity: 0},
+	}); err == nil {
+		t.Fatal("expected zero quantity to be rejected")
+	}
+	if _, err := normalizeTradeOfferItemPayloads("offeredItems", []tradeOfferItemPayload{
+		{InventoryItemID: 0, Quantity: 1},
+	}); err == nil {
+		t.Fatal("expected missing item ID to be rejected")
+	}
+}
+
+func TestNormalizeTradeOfferItemPayloadsCapsDistinctItems(t *testing.T) {
+	payloads := make([]tradeOfferItemPayload, 0, tradeOfferMaxItemsPerSide+1)
+	for i := 1; i <= tradeOfferMaxItemsPerSide+1; i++ {
+		payloads = append(payloads, tradeOfferItemPayload{InventoryItemID: i, Quantity: 1})
+	}
+	if _, err := normalizeTradeOfferItemPayloads("offeredItems", payloads); err == nil {
+		t.Fatal("expected too many distinct items to be rejected")
+	}
+}
+
+func TestValidateTradeItemsAvailableRequiresUnequippedCopies(t *testing.T) {
+	available := map[int]int{10: 2}
+	if err := validateTradeItemsAvailable("offeredItems", []tradeOfferItemPayload{{InventoryItemID: 10, Quantity: 2}}, available); err != nil {
+		t.Fatalf("expected 2 copies to be tradable, got %v", err)
+	}
+	if err := validateTradeItemsAvailable("offeredItems", []tradeOfferItemPayload{{InventoryItemID: 10, Quantity: 3}}, available); err == nil {
+		t.Fatal("expected 3 copies to exceed availability")
+	}
+}
+
+func TestMarketListingFeeRoundsUpWithMinimum(t *testing.T) {
+	cases := map[int]int{
+		1:    1,
+		19:   1,
+		21:   2,
+		100:  5,
+		1001: 51,
+	}
+	for price, expected := range cases {
+		if got := marketListingFee(price); got != expected {
+			t.Fatalf("expected fee %d for price %d, got %d", expected, price, got)
+		}
+	}
+}
+
+func TestMarketListingDurationClampsToMaximum(t *testing.T) {
+	if got := marketListingDuration(0); got != marketListingDefaultDuration {
+		t.Fatalf("expected default duration, got %s", got)
+	}
+	if got := marketListingDuration(12); got != 12*time.Hour {
+		t.Fatalf("expected 12h duration, got %s", got)
+	}
+	if got := marketListingDuration(24 * 30); got != marketListingMaxDuration {
+		t.Fatalf("expected duration to clamp to max, got %s", got)
+	}
+}
@@ -0,0 +1,489 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/jobs"
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"gorm.io/datatypes"
+)
+
+const maxPromptEvaluationInputs = 20
+
+type promptRegistryEntry struct {
+	models.PromptDefinition
+	ActiveVersions []models.PromptTemplate `json:"activeVersions"`
+}
+
+func (s *server) getPromptDefinitions(ctx *gin.Context) {
+	if _, err := s.getAuthenticatedUser(ctx); err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	definitions := models.PromptDefinitions()
+	entries := make([]promptRegistryEntry, 0, len(definitions))
+	for _, definition := range definitions {
+		versions, err := s.dbClient.PromptTemplate().FindByKey(ctx, definition.Key)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		active := make([]models.PromptTemplate, 0)
+		for _, version := range versions {
+			if version.Active {
+				active = append(active, version)
+			}
+		}
+		entries = append(entries, promptRegistryEntry{PromptDefinition: definition, ActiveVersions: active})
+	}
+	ctx.JSON(http.StatusOK, entries)
+}
+
+func (s *server) parsePromptDefinition(ctx *gin.Context, raw string) (models.PromptDefinition, bool) {
+	definition, ok := models.FindPromptDefinition(models.PromptKey(strings.TrimSpace(raw)))
+	if !ok {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("unknown prompt: %s", raw)})
+		return models.PromptDefinition{}, false
+	}
+	return definition, true
+}
+
+func (s *server) getPromptVersions(ctx *gin.Context) {
+	if _, err := s.getAuthenticatedUser(ctx); err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	definition, ok := s.parsePromptDefinition(ctx, ctx.Param("key"))
+	if !ok {
+		return
+	}
+	versions, err := s.dbClient.PromptTemplate().FindByKey(ctx, definition.Key)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, versions)
+}
+
+type createPromptVersionRequest struct {
+	Body     string     `json:"body"`
+	Notes    string     `json:"notes"`
+	GenreID  *uuid.UUID `json:"genreId"`
+	ZoneKind string     `json:"zoneKind"`
+	Activate bool       `json:"activate"`
+}
+
+func (s *server) createPromptVersion(ctx *gin.Context) {
+	user, err := s.getAuthenticatedUser(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	definition, ok := s.parsePromptDefinition(ctx, ctx.Param("key"))
+	if !ok {
+		return
+	}
+	var requestBody createPromptVersionRequest
+	if err := ctx.ShouldBindJSON(&requestBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if strings.TrimSpace(requestBody.Body) == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "body is required"})
+		return
+	}
+	// A body that references a variable the job never supplies would only
+	// fail at generation time, so catch it while the author is still here.
+	if _, err := models.RenderPrompt(requestBody.Body, definition.SampleVariables()); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if requestBody.GenreID != nil && *requestBody.GenreID == uuid.Nil {
+		requestBody.GenreID = nil
+	}
+
+	version := &models.PromptTemplate{
+		Key:             definition.Key,
+		GenreID:         requestBody.GenreID,
+		ZoneKind:        models.NormalizeZoneKind(requestBody.ZoneKind),
+		Body:            requestBody.Body,
+		Notes:           strings.TrimSpace(requestBody.Notes),
+		CreatedByUserID: &user.ID,
+	}
+	if err := s.dbClient.PromptTemplate().Create(ctx, version); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if requestBody.Activate {
+		if err := s.dbClient.PromptTemplate().Activate(ctx, version.ID); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		version.Active = true
+	}
+	ctx.JSON(http.StatusCreated, version)
+}
+
+func (s *server) findPromptVersion(ctx *gin.Context) (*models.PromptTemplate, bool) {
+	if _, err := s.getAuthenticatedUser(ctx); err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	definition, ok := s.parsePromptDefinition(ctx, ctx.Param("key"))
+	if !ok {
+		return nil, false
+	}
+	versionID, err := uuid.Parse(ctx.Param("versionId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid prompt version ID"})
+		return nil, false
+	}
+	version, err := s.dbClient.PromptTemplate().FindByID(ctx, versionID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	if version == nil || version.Key != definition.Key {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "prompt version not found"})
+		return nil, false
+	}
+	return version, true
+}
+
+func (s *server) activatePromptVersion(ctx *gin.Context) {
+	version, ok := s.findPromptVersion(ctx)
+	if !ok {
+		return
+	}
+	if err := s.dbClient.PromptTemplate().Activate(ctx, version.ID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	version.Active = true
+	ctx.JSON(http.StatusOK, version)
+}
+
+func (s *server) deactivatePromptVersion(ctx *gin.Context) {
+	version, ok := s.findPromptVersion(ctx)
+	if !ok {
+		return
+	}
+	if err := s.dbClient.PromptTemplate().Deactivate(ctx, version.ID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	version.Active = false
+	ctx.JSON(http.StatusOK, version)
+}
+
+type createPromptEvaluationRequest struct {
+	PromptKey  models.PromptKey               `json:"promptKey"`
+	VersionAID *uuid.UUID                     `json:"versionAId"`
+	VersionBID *uuid.UUID                     `json:"versionBId"`
+	Inputs     []models.PromptEvaluationInput `json:"inputs"`
+}
+
+func (s *server) createPromptEvaluation(ctx *gin.Context) {
+	user, err := s.getAuthenticatedUser(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	var requestBody createPromptEvaluationRequest
+	if err := ctx.ShouldBindJSON(&requestBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	definition, ok := s.parsePromptDefinition(ctx, string(requestBody.PromptKey))
+	if !ok {
+		return
+	}
+	if len(requestBody.Inputs) == 0 || len(requestBody.Inputs) > maxPromptEvaluationInputs {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("between 1 and %d inputs are required", maxPromptEvaluationInputs)})
+		return
+	}
+	if sameVersionID(requestBody.VersionAID, requestBody.VersionBID) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "versions A and B must differ"})
+		return
+	}
+	for _, versionID := range []*uuid.UUID{requestBody.VersionAID, requestBody.VersionBID} {
+		if versionID == nil {
+			continue
+		}
+		version, err := s.dbClient.PromptTemplate().FindByID(ctx, *versionID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if version == nil || version.Key != definition.Key {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("prompt version %s does not belong to %s", versionID, definition.Key)})
+			return
+		}
+	}
+	for index, input := range requestBody.Inputs {
+		for _, name := range definition.Variables {
+			if _, ok := input.Variables[name]; !ok {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("input %d is missing variable %s", index, name)})
+				return
+			}
+		}
+	}
+	inputs, err := json.Marshal(requestBody.Inputs)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	evaluation := &models.PromptEvaluation{
+		PromptKey:       definition.Key,
+		VersionAID:      requestBody.VersionAID,
+		VersionBID:      requestBody.VersionBID,
+		Inputs:          datatypes.JSON(inputs),
+		Status:          models.PromptEvaluationStatusQueued,
+		CreatedByUserID: &user.ID,
+	}
+	if err := s.dbClient.PromptEvaluation().Create(ctx, evaluation); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	payloadBytes, err := json.Marshal(jobs.RunPromptEvaluationTaskPayload{EvaluationID: evaluation.ID})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if _, err := s.asyncClient.Enqueue(asynq.NewTask(jobs.RunPromptEvaluationTaskType, payloadBytes)); err != nil {
+		msg := err.Error()
+		evaluation.Status = models.PromptEvaluationStatusFailed
+		evaluation.ErrorMessage = &msg
+		if updateErr := s.dbClient.PromptEvaluation().Update(ctx, evaluation); updateErr != nil {
+			log.Printf("[prompts][evaluations] failed to mark evaluation failed id=%s err=%v", evaluation.ID, updateErr)
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusAccepted, evaluation)
+}
+
+func sameVersionID(a *uuid.UUID, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+func (s *server) getPromptEvaluations(ctx *gin.Context) {
+	if _, err := s.getAuthenticatedUser(ctx); err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	evaluations, err := s.dbClient.PromptEvaluation().FindRecent(ctx, models.PromptKey(strings.TrimSpace(ctx.Query("promptKey"))), 50)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, evaluations)
+}
+
+type promptEvaluationRow struct {
+	InputIndex int                            `json:"inputIndex"`
+	Input      *models.PromptEvaluationInput  `json:"input,omitempty"`
+	A          *models.PromptEvaluationOutput `json:"a,omitempty"`
+	B          *models.PromptEvaluationOutput `json:"b,omitempty"`
+}
+
+type promptEvaluationVariantSummary struct {
+	Outputs       int      `json:"outputs"`
+	Failures      int      `json:"failures"`
+	Ratings       int      `json:"ratings"`
+	AverageRating *float64 `json:"averageRating,omitempty"`
+}
+
+type promptEvaluationComparison struct {
+	Evaluation *models.PromptEvaluation                  `json:"evaluation"`
+	Rows       []promptEvaluationRow                     `json:"rows"`
+	Summary    map[string]promptEvaluationVariantSummary `json:"summary"`
+}
+
+// buildPromptEvaluationComparison lines up both variants' outputs per input
+// and totals their ratings so the two versions can be judged side by side.
+func buildPromptEvaluationComparison(evaluation *models.PromptEvaluation) promptEvaluationComparison {
+	inputs, _ := evaluation.DecodeInputs()
+	rowCount := len(inputs)
+	for _, output := range evaluation.Outputs {
+		if output.InputIndex+1 > rowCount {
+			rowCount = output.InputIndex + 1
+		}
+	}
+	rows := make([]promptEvaluationRow, rowCount)
+	for index := range rows {
+		rows[index].InputIndex = index
+		if index < len(inputs) {
+			rows[index].Input = &inputs[index]
+		}
+	}
+
+	ratingTotals := map[string]int{}
+	summary := map[string]promptEvaluationVariantSummary{
+		models.PromptEvaluationVariantA: {},
+		models.PromptEvaluationVariantB: {},
+	}
+	for i := range evaluation.Outputs {
+		output := &evaluation.Outputs[i]
+		if output.InputIndex < 0 {
+			continue
+		}
+		variantSummary, ok := summary[output.Variant]
+		if !ok {
+			continue
+		}
+		if output.Variant == models.PromptEvaluationVariantB {
+			rows[output.InputIndex].B = output
+		} else {
+			rows[output.InputIndex].A = output
+		}
+		variantSummary.Outputs++
+		if output.ErrorMessage != nil {
+			variantSummary.Failures++
+		}
+		if output.Rating != nil {
+			variantSummary.Ratings++
+			ratingTotals[output.Variant] += *output.Rating
+		}
+		summary[output.Variant] = variantSummary
+	}
+	for variant, variantSummary := range summary {
+		if variantSummary.Ratings == 0 {
+			continue
+		}
+		average := float64(ratingTotals[variant]) / float64(variantSummary.Ratings)
+		variantSummary.AverageRating = &average
+		summary[variant] = variantSummary
+	}
+
+	return promptEvaluationComparison{
+		Evaluation: evaluation,
+		Rows:       rows,
+		Summary:    summary,
+	}
+}
+
+func (s *server) findPromptEvaluation(ctx *gin.Context) (*models.PromptEvaluation, bool) {
+	evaluationID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid prompt evaluation ID"})
+		return nil, false
+	}
+	evaluation, err := s.dbClient.PromptEvaluation().FindByID(ctx, evaluationID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	if evaluation == nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "prompt evaluation not found"})
+		return nil, false
+	}
+	return evaluation, true
+}
+
+func (s *server) getPromptEvaluation(ctx *gin.Context) {
+	if _, err := s.getAuthenticatedUser(ctx); err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	evaluation, ok := s.findPromptEvaluation(ctx)
+	if !ok {
+		return
+	}
+	ctx.JSON(http.StatusOK, buildPromptEvaluationComparison(evaluation))
+}
+
+type ratePromptEvaluationOutputRequest struct {
+	Rating  int    `json:"rating"`
+	Comment string `json:"comment"`
+}
+
+func (s *server) ratePromptEvaluationOutput(ctx *gin.Context) {
+	user, err := s.getAuthenticatedUser(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	evaluation, ok := s.findPromptEvaluation(ctx)
+	if !ok {
+		return
+	}
+	outputID, err := uuid.Parse(ctx.Param("outputId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid output ID"})
+		return
+	}
+	var requestBody ratePromptEvaluationOutputRequest
+	if err := ctx.ShouldBindJSON(&requestBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if requestBody.Rating < 1 || requestBody.Rating > 5 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "rating must be between 1 and 5"})
+		return
+	}
+	output, err := s.dbClient.PromptEvaluation().FindOutputByID(ctx, outputID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if output == nil || output.EvaluationID != evaluation.ID {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "output not found"})
+		return
+	}
+	comment := strings.TrimSpace(requestBody.Comment)
+	if err := s.dbClient.PromptEvaluation().RateOutput(ctx, output.ID, requestBody.Rating, comment, &user.ID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	output.Rating = &requestBody.Rating
+	output.RatingComment = comment
+	output.RatedByUserID = &user.ID
+	ctx.JSON(http.StatusOK, output)
+}
+
+// getGeneratedRecordPrompt reports which prompt version produced a
+// generated record. Records generated before the registry have none.
+func (s *server) getGeneratedRecordPrompt(ctx *gin.Context) {
+	if _, err := s.getAuthenticatedUser(ctx); err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	recordID, err := uuid.Parse(ctx.Param("recordId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid record ID"})
+		return
+	}
+	recordType := strings.ReplaceAll(strings.TrimSpace(ctx.Param("recordType")), "-", "_")
+	recordPrompt, err := s.dbClient.GeneratedRecordPrompt().FindByRecord(ctx, recordType, recordID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if recordPrompt == nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "no prompt recorded for this record"})
+		return
+	}
+	response := gin.H{"provenance": recordPrompt}
+	if recordPrompt.PromptTemplateID != nil {
+		version, err := s.dbClient.PromptTemplate().FindByID(ctx, *recordPrompt.PromptTemplateID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		response["promptVersion"] = version
+	}
+	ctx.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,52 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+func TestBuildPromptEvaluationComparisonPairsVariants(t *testing.T) {
+	four, two, five := 4, 2, 5
+	failure := "render failed"
+	evaluation := &models.PromptEvaluation{
+		PromptKey: models.PromptKeyShrineTemplateGeneration,
+		Inputs:    datatypes.JSON(`[{"zoneKind":"forest","variables":{"Count":2,"RecentTemplates":"- none"}},{"variables":{"Count":3,"RecentTemplates":"- none"}}]`),
+		Outputs: []models.PromptEvaluationOutput{
+			{InputIndex: 0, Variant: models.PromptEvaluationVariantA, Output: "a0", Rating: &four},
+			{InputIndex: 0, Variant: models.PromptEvaluationVariantB, Output: "b0", Rating: &two},
+			{InputIndex: 1, Variant: models.PromptEvaluationVariantA, Output: "a1", Rating: &five},
+			{InputIndex: 1, Variant: models.PromptEvaluationVariantB, ErrorMessage: &failure},
+		},
+	}
+
+	comparison := buildPromptEvaluationComparison(evaluation)
+	if len(comparison.Rows) != 2 {
+		t.Fatalf("expected 2 rows, got %d", len(comparison.Rows))
+	}
+	first := comparison.Rows[0]
+	if first.Input == nil || first.Input.ZoneKind != "forest" || first.A.Output != "a0" || first.B.Output != "b0" {
+		t.Fatalf("unexpected first row: %+v", first)
+	}
+	a := comparison.Summary[models.PromptEvaluationVariantA]
+	if a.Outputs != 2 || a.Ratings != 2 || a.AverageRating == nil || *a.AverageRating != 4.5 {
+		t.Fatalf("unexpected variant A summary: %+v", a)
+	}
+	b := comparison.Summary[models.PromptEvaluationVariantB]
+	if b.Failures != 1 || b.Ratings != 1 || b.AverageRating == nil || *b.AverageRating != 2 {
+		t.Fatalf("unexpected variant B summary: %+v", b)
+	}
+}
+
+func TestSameVersionID(t *testing.T) {
+	id := uuid.New()
+	other := uuid.New()
+	if !sameVersionID(nil, nil) || !sameVersionID(&id, &id) {
+		t.Fatal("expected matching versions to compare equal")
+	}
+	if sameVersionID(&id, nil) || sameVersionID(&id, &other) {
+		t.Fatal("expected different versions to compare unequal")
+	}
+}
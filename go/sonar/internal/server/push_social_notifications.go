@@ -87,6 +87,56 @@ func (s *server) sendFriendInviteAcceptedPushNotification(
 	)
 }
 
+func (s *server) sendTradeOfferPushNotification(
+	ctx context.Context,
+	offer *models.TradeOffer,
+	initiator *models.User,
+) {
+	if offer == nil {
+		log.Printf("[push][trade-offer] skipped: offer is nil")
+		return
+	}
+	data := map[string]string{
+		"type":            "trade_offer",
+		"tradeOfferId":    offer.ID.String(),
+		"initiatorUserId": offer.InitiatorUserID.String(),
+		"recipientUserId": offer.RecipientUserID.String(),
+		"sentAt":          time.Now().UTC().Format(time.RFC3339),
+	}
+	s.sendSocialPushToUser(
+		ctx,
+		"trade-offer",
+		offer.RecipientUserID,
+		"Trade Offer",
+		userDisplayName(initiator)+" wants to trade with you.",
+		data,
+	)
+}
+
+func (s *server) sendTradeOfferCompletedPushNotification(
+	ctx context.Context,
+	offer *models.TradeOffer,
+	userID uuid.UUID,
+) {
+	if offer == nil {
+		log.Printf("[push][trade-offer-completed] skipped: offer is nil")
+		return
+	}
+	data := map[string]string{
+		"type":         "trade_offer_completed",
+		"tradeOfferId": offer.ID.String(),
+		"sentAt":       time.Now().UTC().Format(time.RFC3339),
+	}
+	s.sendSocialPushToUser(
+		ctx,
+		"trade-offer-completed",
+		userID,
+		"Trade Complete",
+		"Your trade has gone through.",
+		data,
+	)
+}
+
 func (s *server) sendSocialPushToUser(
 	ctx context.Context,
 	logScope string,
@@ -139,3 +189,29 @@ func (s *server) sendSocialPushToUser(
 		failedCount,
 	)
 }
+
+func (s *server) sendGuildInvitePushNotification(
+	ctx context.Context,
+	invite *models.GuildInvite,
+	inviter *models.User,
+) {
+	if invite == nil {
+		log.Printf("[push][guild-invite] skipped: invite is nil")
+		return
+	}
+	data := map[string]string{
+		"type":          "guild_invite",
+		"guildInviteId": invite.ID.String(),
+		"guildId":       invite.GuildID.String(),
+		"inviterUserId": invite.InviterUserID.String(),
+		"sentAt":        time.Now().UTC().Format(time.RFC3339),
+	}
+	s.sendSocialPushToUser(
+		ctx,
+		"guild-invite",
+		invite.InviteeUserID,
+		"Guild Invite",
+		userDisplayName(inviter)+" invited you to join "+invite.Guild.Name+".",
+		data,
+	)
+}
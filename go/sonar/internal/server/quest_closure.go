@@ -109,6 +109,7 @@ func (s *server) awardQuestDebriefBonuses(
 			); err != nil {
 				return 0, 0, err
 			}
+			s.evaluateAchievements(ctx, userID, models.AchievementMetricUserLevel, nil)
 		}
 	}
 
@@ -395,7 +395,7 @@ func (s *server) submitQuestFetchTurnIn(ctx *gin.Context) {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	s.shareQuestNodeCompletionWithEligiblePartyMembers(ctx, user, quest, node)
+	s.shareQuestNodeCompletion(ctx, user, quest, node)
 
 	objectivesComplete, err := s.questlogClient.AreQuestObjectivesComplete(
 		ctx,
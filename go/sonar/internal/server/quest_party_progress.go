@@ -333,6 +333,18 @@ func (s *server) markQuestNodeFailedForAcceptance(
 	return s.finalizeQuestClosureIfReady(ctx, quest, acceptance, failedAt)
 }
 
+// shareQuestNodeCompletion passes a node the source user just completed on to
+// nearby party members and to the rest of their guild.
+func (s *server) shareQuestNodeCompletion(
+	ctx context.Context,
+	sourceUser *models.User,
+	quest *models.Quest,
+	node *models.QuestNode,
+) {
+	s.shareQuestNodeCompletionWithEligiblePartyMembers(ctx, sourceUser, quest, node)
+	s.shareQuestNodeCompletionWithGuild(ctx, sourceUser, quest, node)
+}
+
 func (s *server) shareQuestNodeCompletionWithEligiblePartyMembers(
 	ctx context.Context,
 	sourceUser *models.User,
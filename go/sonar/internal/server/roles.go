@@ -0,0 +1,218 @@
+package server
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/auth"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultPrivilegedCallsLimit = 100
+	maxPrivilegedCallsLimit     = 1000
+)
+
+type grantRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+func (s *server) listRoles(ctx *gin.Context) {
+	roles, err := s.dbClient.Authorization().ListRoles(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, roles)
+}
+
+func (s *server) listRoleGrants(ctx *gin.Context) {
+	grants, err := s.dbClient.Authorization().ListGrants(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, grants)
+}
+
+func (s *server) getUserRoles(ctx *gin.Context) {
+	userID, err := uuid.Parse(ctx.Param("userId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+	grants, err := s.dbClient.Authorization().FindRolesForUser(ctx, userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, grants)
+}
+
+// grantRole only hands out scopes the caller holds themselves, so
+// auth:roles:write alone cannot be parlayed into super-admin.
+func (s *server) grantRole(ctx *gin.Context) {
+	user, err := s.getAuthenticatedUser(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	userID, err := uuid.Parse(ctx.Param("userId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+	var requestBody grantRoleRequest
+	if err := ctx.ShouldBindJSON(&requestBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	role, err := s.dbClient.Authorization().FindRoleByName(ctx, strings.TrimSpace(requestBody.Role))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if role == nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "role not found"})
+		return
+	}
+	if !user.HasScopes(role.Scopes...) {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "you can only grant roles whose scopes you hold"})
+		return
+	}
+	if _, err := s.dbClient.User().FindByID(ctx, userID); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := s.dbClient.Authorization().GrantRole(ctx, userID, role.ID, &user.ID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	s.publishRolesChanged(ctx, userID)
+	log.Printf("[auth][roles] granted role=%s user_id=%s by=%s", role.Name, userID, user.ID)
+
+	grants, err := s.dbClient.Authorization().FindRolesForUser(ctx, userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, grants)
+}
+
+func (s *server) revokeRole(ctx *gin.Context) {
+	user, err := s.getAuthenticatedUser(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	userID, err := uuid.Parse(ctx.Param("userId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+
+	role, err := s.dbClient.Authorization().FindRoleByName(ctx, ctx.Param("role"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if role == nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "role not found"})
+		return
+	}
+	if !user.HasScopes(role.Scopes...) {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "you can only revoke roles whose scopes you hold"})
+		return
+	}
+
+	held, err := s.dbClient.Authorization().RevokeRole(ctx, userID, role.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !held {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "user does not hold that role"})
+		return
+	}
+	s.publishRolesChanged(ctx, userID)
+	log.Printf("[auth][roles] revoked role=%s user_id=%s by=%s", role.Name, userID, user.ID)
+
+	ctx.JSON(http.StatusOK, gin.H{"revoked": true})
+}
+
+// publishRolesChanged stops services trusting the scopes in the user's
+// outstanding tokens. If it fails they catch up when the token refreshes.
+func (s *server) publishRolesChanged(ctx *gin.Context, userID uuid.UUID) {
+	if err := auth.PublishRolesChanged(ctx, s.redisClient, userID); err != nil {
+		log.Printf("[auth][events] publish roles changed failed user_id=%s err=%v", userID, err)
+	}
+}
+
+func (s *server) listPrivilegedCalls(ctx *gin.Context) {
+	var userID *uuid.UUID
+	if userIDParam := strings.TrimSpace(ctx.Query("userId")); userIDParam != "" {
+		parsed, err := uuid.Parse(userIDParam)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+			return
+		}
+		userID = &parsed
+	}
+	limit := defaultPrivilegedCallsLimit
+	if limitParam := strings.TrimSpace(ctx.Query("limit")); limitParam != "" {
+		if parsed, err := strconv.Atoi(limitParam); err == nil && parsed > 0 {
+			limit = min(parsed, maxPrivilegedCallsLimit)
+		}
+	}
+
+	calls, err := s.dbClient.AuditItem().GetPrivilegedCalls(ctx, userID, limit)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, calls)
+}
+
+// listBlockedActors shows who the authenticator is currently refusing for
+// SMS or sign-in abuse.
+func (s *server) listBlockedActors(ctx *gin.Context) {
+	blocked, err := s.authClient.GetBlockedActors(ctx, &auth.VerifyTokenRequest{Token: bearerToken(ctx)})
+	if err != nil {
+		ctx.JSON(authenticatorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, blocked)
+}
+
+func (s *server) unblockActor(ctx *gin.Context) {
+	user, err := s.getAuthenticatedUser(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	var requestBody struct {
+		Key string `json:"key" binding:"required"`
+	}
+	if err := ctx.ShouldBindJSON(&requestBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := s.authClient.UnblockActor(ctx, &auth.UnblockActorRequest{Token: bearerToken(ctx), Key: requestBody.Key}); err != nil {
+		ctx.JSON(authenticatorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	log.Printf("[auth][throttle] unblocked key=%s by=%s", requestBody.Key, user.ID)
+
+	ctx.JSON(http.StatusOK, gin.H{"unblocked": true})
+}
@@ -36,6 +36,7 @@ import (
 	"github.com/MaxBlaushild/poltergeist/sonar/internal/charicturist"
 	"github.com/MaxBlaushild/poltergeist/sonar/internal/chat"
 	"github.com/MaxBlaushild/poltergeist/sonar/internal/config"
+	"github.com/MaxBlaushild/poltergeist/sonar/internal/contentvalidation"
 	"github.com/MaxBlaushild/poltergeist/sonar/internal/gameengine"
 	"github.com/MaxBlaushild/poltergeist/sonar/internal/judge"
 	"github.com/MaxBlaushild/poltergeist/sonar/internal/push"
@@ -127,6 +128,7 @@ type server struct {
 	gameEngineClient gameengine.GameEngineClient
 	livenessClient   liveness.LivenessClient
 	pushClient       push.Client
+	spawnEnvironment spawnEnvironmentProvider
 }
 
 type Server interface {
@@ -203,12 +205,53 @@ func NewServer(
 		gameEngineClient: gameEngineClient,
 		livenessClient:   livenessClient,
 		pushClient:       pushClient,
+		spawnEnvironment: newStaticSpawnEnvironmentProvider(defaultSpawnWeather),
 	}
 }
 
 func (s *server) SetupRoutes(r *gin.Engine) {
 	r.POST("/sonar/register", s.register)
 	r.POST("/sonar/login", s.login)
+	r.POST("/sonar/token/refresh", s.refreshToken)
+	r.POST("/sonar/token/exchange", s.exchangeLegacyToken)
+	r.GET("/sonar/sessions", middleware.WithAuthenticationWithoutLocation(s.authClient, s.getSessions))
+	r.DELETE("/sonar/sessions/:id", middleware.WithAuthenticationWithoutLocation(s.authClient, s.revokeSession))
+	r.POST("/sonar/sessions/revoke-all", middleware.WithAuthenticationWithoutLocation(s.authClient, s.revokeAllSessions))
+	r.POST("/sonar/mfa/verify", s.verifyMFA)
+	r.GET("/sonar/mfa", middleware.WithAuthenticationWithoutLocation(s.authClient, s.getTwoFactorStatus))
+	r.POST("/sonar/totp/enroll", middleware.WithAuthenticationWithoutLocation(s.authClient, s.enrollTOTP))
+	r.POST("/sonar/totp/confirm", middleware.WithAuthenticationWithoutLocation(s.authClient, s.confirmTOTP))
+	r.POST("/sonar/totp/disable", middleware.WithAuthenticationWithoutLocation(s.authClient, s.disableTOTP))
+	r.POST("/sonar/totp/recovery-codes", middleware.WithAuthenticationWithoutLocation(s.authClient, s.regenerateRecoveryCodes))
+	r.POST("/sonar/passkeys/login/begin", s.beginPasskeyLogin)
+	r.POST("/sonar/passkeys/login/finish", s.finishPasskeyLogin)
+	r.GET("/sonar/passkeys", middleware.WithAuthenticationWithoutLocation(s.authClient, s.getPasskeys))
+	r.DELETE("/sonar/passkeys/:id", middleware.WithAuthenticationWithoutLocation(s.authClient, s.deletePasskey))
+	r.POST("/sonar/passkeys/register/begin", middleware.WithAuthenticationWithoutLocation(s.authClient, s.beginPasskeyRegistration))
+	r.POST("/sonar/passkeys/register/finish", middleware.WithAuthenticationWithoutLocation(s.authClient, s.finishPasskeyRegistration))
+	r.GET("/sonar/logins", middleware.WithAuthenticationWithoutLocation(s.authClient, s.getLoginMethods))
+	r.POST("/sonar/logins/phone", middleware.WithAuthenticationWithoutLocation(s.authClient, s.linkPhoneNumber))
+	r.POST("/sonar/logins/email/code", middleware.WithAuthenticationWithoutLocation(s.authClient, s.sendEmailLinkCode))
+	r.POST("/sonar/logins/email", middleware.WithAuthenticationWithoutLocation(s.authClient, s.linkEmail))
+	r.POST("/sonar/logins/google", middleware.WithAuthenticationWithoutLocation(s.authClient, s.linkGoogle))
+	r.GET("/sonar/account/export", middleware.WithAuthenticationWithoutLocation(s.authClient, s.exportAccountData))
+	r.DELETE("/sonar/account", middleware.WithAuthenticationWithoutLocation(s.authClient, s.deleteAccount))
+
+	// Every admin route needs sonar:<resource>:read or :write, e.g.
+	// sonar:zones:write, and every call is written to the audit trail.
+	// Roles and the audit trail itself live under auth:* so that holding
+	// sonar:* is not enough to grant yourself more.
+	admin := r.Group("/sonar/admin", middleware.RequireRouteScopes(s.authClient, s.dbClient.AuditItem().CreatePrivilegedCall, "sonar", "/sonar/admin"))
+	authAdmin := r.Group("/sonar/admin", middleware.RequireRouteScopes(s.authClient, s.dbClient.AuditItem().CreatePrivilegedCall, "auth", "/sonar/admin"))
+	authAdmin.GET("/roles", s.listRoles)
+	authAdmin.GET("/roles/grants", s.listRoleGrants)
+	authAdmin.GET("/roles/users/:userId", s.getUserRoles)
+	authAdmin.POST("/roles/users/:userId", s.grantRole)
+	authAdmin.DELETE("/roles/users/:userId/:role", s.revokeRole)
+	authAdmin.GET("/audit/privileged-calls", s.listPrivilegedCalls)
+	authAdmin.GET("/blocked-actors", s.listBlockedActors)
+	authAdmin.POST("/blocked-actors/unblock", s.unblockActor)
+	authAdmin.POST("/users/merge", s.mergeUsers)
 
 	r.GET("/sonar/surveys", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getSurverys))
 	r.POST("/sonar/surveys", middleware.WithAuthentication(s.authClient, s.livenessClient, s.newSurvey))
@@ -253,7 +296,7 @@ func (s *server) SetupRoutes(r *gin.Engine) {
 	r.PUT("/sonar/resource-types/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.updateResourceType))
 	r.DELETE("/sonar/resource-types/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.deleteResourceType))
 	r.POST("/sonar/resource-types/:id/generate-map-icon", middleware.WithAuthentication(s.authClient, s.livenessClient, s.generateResourceTypeMapIcon))
-	r.DELETE("/sonar/admin/content-map-markers/resource-types/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.deleteResourceTypeContentMapMarker))
+	admin.DELETE("/content-map-markers/resource-types/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.deleteResourceTypeContentMapMarker))
 	r.POST("/sonar/resource-types/:id/generate-requirement-items", middleware.WithAuthentication(s.authClient, s.livenessClient, s.generateResourceTypeRequirementItems))
 	r.POST("/sonar/inventory-item-suggestion-jobs", middleware.WithAuthentication(s.authClient, s.livenessClient, s.createInventoryItemSuggestionJob))
 	r.GET("/sonar/inventory-item-suggestion-jobs", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getInventoryItemSuggestionJobs))
@@ -279,8 +322,8 @@ func (s *server) SetupRoutes(r *gin.Engine) {
 	r.GET("/sonar/spells/bulk-generate/:jobId/status", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getBulkGenerateSpellsStatus))
 	r.POST("/sonar/spells/progression-generate", middleware.WithAuthentication(s.authClient, s.livenessClient, s.queueSpellProgressionFromPrompt))
 	r.GET("/sonar/spells/progression-generate/:jobId/status", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getSpellProgressionFromPromptStatus))
-	r.POST("/sonar/admin/spells/rebalance-damage", middleware.WithAuthentication(s.authClient, s.livenessClient, s.queueSpellDamageRebalance))
-	r.GET("/sonar/admin/spells/rebalance-damage/:jobId/status", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getSpellDamageRebalanceJobStatus))
+	admin.POST("/spells/rebalance-damage", middleware.WithAuthentication(s.authClient, s.livenessClient, s.queueSpellDamageRebalance))
+	admin.GET("/spells/rebalance-damage/:jobId/status", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getSpellDamageRebalanceJobStatus))
 	r.GET("/sonar/spells/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getSpell))
 	r.POST("/sonar/spells", middleware.WithAuthentication(s.authClient, s.livenessClient, s.createSpell))
 	r.POST("/sonar/spells/:id/generate-icon", middleware.WithAuthentication(s.authClient, s.livenessClient, s.generateSpellIcon))
@@ -328,21 +371,21 @@ func (s *server) SetupRoutes(r *gin.Engine) {
 	r.POST("/sonar/equipment/unequip", middleware.WithAuthentication(s.authClient, s.livenessClient, s.unequipInventoryItem))
 	r.GET("/sonar/chat", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getChat))
 	r.POST("/sonar/teams/:teamID/inventory/add", s.addItemToTeam)
-	r.POST("/sonar/admin/pointOfInterest/unlock", middleware.WithAuthentication(s.authClient, s.livenessClient, s.unlockPointOfInterestForTeam))
+	admin.POST("/pointOfInterest/unlock", middleware.WithAuthentication(s.authClient, s.livenessClient, s.unlockPointOfInterestForTeam))
 	r.POST("/sonar/pointsOfInterest/group/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.createPointOfInterest))
 	r.POST("/sonar/generateProfilePictureOptions", middleware.WithAuthentication(s.authClient, s.livenessClient, s.generateProfilePictureOptions))
 	r.GET("/sonar/generations/complete", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getCompleteGenerationsForUser))
 	r.POST("/sonar/profilePicture", middleware.WithAuthentication(s.authClient, s.livenessClient, s.setProfilePicture))
-	r.GET("/sonar/admin/insider-trades", middleware.WithAuthentication(s.authClient, s.livenessClient, s.listInsiderTrades))
-	r.GET("/sonar/admin/feedback", middleware.WithAuthentication(s.authClient, s.livenessClient, s.listFeedbackItems))
-	r.GET("/sonar/admin/parties", middleware.WithAuthentication(s.authClient, s.livenessClient, s.adminListParties))
-	r.POST("/sonar/admin/parties", middleware.WithAuthentication(s.authClient, s.livenessClient, s.adminCreateParty))
-	r.GET("/sonar/admin/parties/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.adminGetParty))
-	r.PATCH("/sonar/admin/parties/:id/leader", middleware.WithAuthentication(s.authClient, s.livenessClient, s.adminSetPartyLeader))
-	r.POST("/sonar/admin/parties/:id/members", middleware.WithAuthentication(s.authClient, s.livenessClient, s.adminAddPartyMember))
-	r.DELETE("/sonar/admin/parties/:id/members/:userId", middleware.WithAuthentication(s.authClient, s.livenessClient, s.adminRemovePartyMember))
-	r.DELETE("/sonar/admin/parties/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.adminDeleteParty))
-	r.POST("/sonar/admin/pointsOfInterest/backfill-marker-categories", middleware.WithAuthentication(s.authClient, s.livenessClient, s.backfillPointOfInterestMarkerCategories))
+	admin.GET("/insider-trades", middleware.WithAuthentication(s.authClient, s.livenessClient, s.listInsiderTrades))
+	admin.GET("/feedback", middleware.WithAuthentication(s.authClient, s.livenessClient, s.listFeedbackItems))
+	admin.GET("/parties", middleware.WithAuthentication(s.authClient, s.livenessClient, s.adminListParties))
+	admin.POST("/parties", middleware.WithAuthentication(s.authClient, s.livenessClient, s.adminCreateParty))
+	admin.GET("/parties/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.adminGetParty))
+	admin.PATCH("/parties/:id/leader", middleware.WithAuthentication(s.authClient, s.livenessClient, s.adminSetPartyLeader))
+	admin.POST("/parties/:id/members", middleware.WithAuthentication(s.authClient, s.livenessClient, s.adminAddPartyMember))
+	admin.DELETE("/parties/:id/members/:userId", middleware.WithAuthentication(s.authClient, s.livenessClient, s.adminRemovePartyMember))
+	admin.DELETE("/parties/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.adminDeleteParty))
+	admin.POST("/pointsOfInterest/backfill-marker-categories", middleware.WithAuthentication(s.authClient, s.livenessClient, s.backfillPointOfInterestMarkerCategories))
 	r.PATCH("/sonar/pointsOfInterest/group/:id", s.editPointOfInterestGroup)
 	r.DELETE("/sonar/pointsOfInterest/group/:id", s.deletePointOfInterestGroup)
 	r.POST("/sonar/pointsOfInterest/group/bulk-delete", s.bulkDeletePointOfInterestGroups)
@@ -366,33 +409,34 @@ func (s *server) SetupRoutes(r *gin.Engine) {
 	r.GET("/sonar/matches/hasCurrentMatch", middleware.WithAuthentication(s.authClient, s.livenessClient, s.hasCurrentMatch))
 	r.GET("/sonar/users", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getAllUsers))
 	r.POST("/sonar/users/giveItem", middleware.WithAuthentication(s.authClient, s.livenessClient, s.giveItem))
-	r.GET("/sonar/admin/new-user-starter-config", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getNewUserStarterConfig))
-	r.PUT("/sonar/admin/new-user-starter-config", middleware.WithAuthentication(s.authClient, s.livenessClient, s.updateNewUserStarterConfig))
-	r.GET("/sonar/admin/point-of-interest-exposition-seed-config", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getPointOfInterestExpositionSeedConfig))
-	r.PUT("/sonar/admin/point-of-interest-exposition-seed-config", middleware.WithAuthentication(s.authClient, s.livenessClient, s.updatePointOfInterestExpositionSeedConfig))
-	r.GET("/sonar/admin/point-of-interest-shopkeeper-seed-config", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getPointOfInterestShopkeeperSeedConfig))
-	r.PUT("/sonar/admin/point-of-interest-shopkeeper-seed-config", middleware.WithAuthentication(s.authClient, s.livenessClient, s.updatePointOfInterestShopkeeperSeedConfig))
-	r.GET("/sonar/admin/zone-shroud-config", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getZoneShroudConfig))
-	r.POST("/sonar/admin/zone-shroud-config/generate-pattern-tile", middleware.WithAuthentication(s.authClient, s.livenessClient, s.generateZoneShroudPatternTile))
-	r.GET("/sonar/admin/tutorial", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getTutorialConfig))
-	r.PUT("/sonar/admin/tutorial", middleware.WithAuthentication(s.authClient, s.livenessClient, s.updateTutorialConfig))
-	r.POST("/sonar/admin/tutorial/generate-image", middleware.WithAuthentication(s.authClient, s.livenessClient, s.generateTutorialImage))
-	r.POST("/sonar/admin/tutorial/instantiate-base-quest", middleware.WithAuthentication(s.authClient, s.livenessClient, s.adminInstantiateTutorialBaseQuest))
-	r.POST("/sonar/admin/useOutfitItem", middleware.WithAuthentication(s.authClient, s.livenessClient, s.adminUseOutfitItem))
-	r.POST("/sonar/admin/users/:id/statuses", middleware.WithAuthentication(s.authClient, s.livenessClient, s.adminCreateUserStatus))
-	r.POST("/sonar/admin/users/:id/level-up", middleware.WithAuthentication(s.authClient, s.livenessClient, s.adminGrantUserLevelUp))
-	r.GET("/sonar/admin/users/:id/resources", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getAdminUserResources))
-	r.POST("/sonar/admin/users/:id/resources", middleware.WithAuthentication(s.authClient, s.livenessClient, s.adminAdjustUserResources))
-	r.POST("/sonar/admin/users/:id/zone-discoveries/discover-all", middleware.WithAuthentication(s.authClient, s.livenessClient, s.adminDiscoverAllZonesForUser))
-	r.DELETE("/sonar/admin/users/:id/zone-discoveries", middleware.WithAuthentication(s.authClient, s.livenessClient, s.adminUndiscoverAllZonesForUser))
-	r.GET("/sonar/admin/monster-templates", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getAdminMonsterTemplates))
-	r.GET("/sonar/admin/monsters", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getAdminMonsters))
-	r.GET("/sonar/admin/monster-encounters", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getAdminMonsterEncounters))
-	r.GET("/sonar/admin/challenges", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getAdminChallenges))
-	r.GET("/sonar/admin/challenges/dashboard", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getAdminChallengeDashboard))
-	r.GET("/sonar/admin/scenarios", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getAdminScenarios))
-	r.GET("/sonar/admin/expositions", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getAdminExpositions))
-	r.GET("/sonar/admin/scenario-templates", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getAdminScenarioTemplates))
+	admin.GET("/new-user-starter-config", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getNewUserStarterConfig))
+	admin.PUT("/new-user-starter-config", middleware.WithAuthentication(s.authClient, s.livenessClient, s.updateNewUserStarterConfig))
+	admin.GET("/point-of-interest-exposition-seed-config", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getPointOfInterestExpositionSeedConfig))
+	admin.PUT("/point-of-interest-exposition-seed-config", middleware.WithAuthentication(s.authClient, s.livenessClient, s.updatePointOfInterestExpositionSeedConfig))
+	admin.GET("/point-of-interest-shopkeeper-seed-config", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getPointOfInterestShopkeeperSeedConfig))
+	admin.PUT("/point-of-interest-shopkeeper-seed-config", middleware.WithAuthentication(s.authClient, s.livenessClient, s.updatePointOfInterestShopkeeperSeedConfig))
+	admin.GET("/zone-shroud-config", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getZoneShroudConfig))
+	admin.POST("/zone-shroud-config/generate-pattern-tile", middleware.WithAuthentication(s.authClient, s.livenessClient, s.generateZoneShroudPatternTile))
+	admin.GET("/tutorial", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getTutorialConfig))
+	admin.PUT("/tutorial", middleware.WithAuthentication(s.authClient, s.livenessClient, s.updateTutorialConfig))
+	admin.POST("/tutorial/generate-image", middleware.WithAuthentication(s.authClient, s.livenessClient, s.generateTutorialImage))
+	admin.POST("/tutorial/instantiate-base-quest", middleware.WithAuthentication(s.authClient, s.livenessClient, s.adminInstantiateTutorialBaseQuest))
+	admin.POST("/useOutfitItem", middleware.WithAuthentication(s.authClient, s.livenessClient, s.adminUseOutfitItem))
+	admin.POST("/users/:id/statuses", middleware.WithAuthentication(s.authClient, s.livenessClient, s.adminCreateUserStatus))
+	admin.POST("/users/:id/level-up", middleware.WithAuthentication(s.authClient, s.livenessClient, s.adminGrantUserLevelUp))
+	admin.GET("/users/:id/resources", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getAdminUserResources))
+	admin.POST("/users/:id/resources", middleware.WithAuthentication(s.authClient, s.livenessClient, s.adminAdjustUserResources))
+	admin.POST("/users/:id/zone-discoveries/discover-all", middleware.WithAuthentication(s.authClient, s.livenessClient, s.adminDiscoverAllZonesForUser))
+	admin.DELETE("/users/:id/zone-discoveries", middleware.WithAuthentication(s.authClient, s.livenessClient, s.adminUndiscoverAllZonesForUser))
+	admin.GET("/monster-templates", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getAdminMonsterTemplates))
+	admin.GET("/monsters", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getAdminMonsters))
+	admin.GET("/monster-encounters", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getAdminMonsterEncounters))
+	admin.GET("/challenges", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getAdminChallenges))
+	admin.GET("/challenges/dashboard", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getAdminChallengeDashboard))
+	admin.GET("/scenarios", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getAdminScenarios))
+	admin.GET("/scenarios/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getAdminScenario))
+	admin.GET("/expositions", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getAdminExpositions))
+	admin.GET("/scenario-templates", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getAdminScenarioTemplates))
 	r.PATCH("/sonar/users/:id/gold", middleware.WithAuthentication(s.authClient, s.livenessClient, s.updateUserGold))
 	r.DELETE("/sonar/users/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.deleteUser))
 	r.DELETE("/sonar/users", middleware.WithAuthentication(s.authClient, s.livenessClient, s.deleteUsers))
@@ -411,7 +455,7 @@ func (s *server) SetupRoutes(r *gin.Engine) {
 	r.POST("/sonar/tags/add", middleware.WithAuthentication(s.authClient, s.livenessClient, s.addTagToPointOfInterest))
 	r.DELETE("/sonar/tags/:tagID/pointOfInterest/:pointOfInterestID", middleware.WithAuthentication(s.authClient, s.livenessClient, s.removeTagFromPointOfInterest))
 	r.GET("/sonar/zones", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getZones))
-	r.GET("/sonar/admin/zones", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getAdminZones))
+	admin.GET("/zones", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getAdminZones))
 	r.GET("/sonar/zone-genres", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getZoneGenres))
 	r.GET("/sonar/zoneKinds", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getZoneKinds))
 	r.POST("/sonar/zoneKinds", middleware.WithAuthentication(s.authClient, s.livenessClient, s.createZoneKind))
@@ -421,13 +465,13 @@ func (s *server) SetupRoutes(r *gin.Engine) {
 	r.POST("/sonar/zoneKinds/assign-zones", middleware.WithAuthentication(s.authClient, s.livenessClient, s.assignZoneKindToZones))
 	r.POST("/sonar/zoneKinds/backfill-content-kinds", middleware.WithAuthentication(s.authClient, s.livenessClient, s.backfillContentZoneKinds))
 	r.GET("/sonar/zoneKinds/backfill-content-kinds/:jobId/status", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getBackfillContentZoneKindsStatus))
-	r.POST("/sonar/admin/zone-genres", middleware.WithAuthentication(s.authClient, s.livenessClient, s.createZoneGenre))
-	r.PATCH("/sonar/admin/zone-genres/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.updateZoneGenre))
-	r.DELETE("/sonar/admin/zone-genres/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.deleteZoneGenre))
-	r.GET("/sonar/admin/reward-profiles", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getRewardProfiles))
-	r.POST("/sonar/admin/reward-profiles", middleware.WithAuthentication(s.authClient, s.livenessClient, s.createRewardProfile))
-	r.PATCH("/sonar/admin/reward-profiles/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.updateRewardProfile))
-	r.DELETE("/sonar/admin/reward-profiles/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.deleteRewardProfile))
+	admin.POST("/zone-genres", middleware.WithAuthentication(s.authClient, s.livenessClient, s.createZoneGenre))
+	admin.PATCH("/zone-genres/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.updateZoneGenre))
+	admin.DELETE("/zone-genres/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.deleteZoneGenre))
+	admin.GET("/reward-profiles", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getRewardProfiles))
+	admin.POST("/reward-profiles", middleware.WithAuthentication(s.authClient, s.livenessClient, s.createRewardProfile))
+	admin.PATCH("/reward-profiles/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.updateRewardProfile))
+	admin.DELETE("/reward-profiles/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.deleteRewardProfile))
 	r.GET("/sonar/zones/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getZone))
 	r.POST("/sonar/zones/:id/discover", middleware.WithAuthentication(s.authClient, s.livenessClient, s.discoverZone))
 	r.POST("/sonar/zones", middleware.WithAuthentication(s.authClient, s.livenessClient, s.createZone))
@@ -440,97 +484,138 @@ func (s *server) SetupRoutes(r *gin.Engine) {
 	r.GET("/sonar/zones/imports", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getZoneImports))
 	r.GET("/sonar/zones/imports/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getZoneImport))
 	r.DELETE("/sonar/zones/imports/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.deleteZoneImport))
-	r.POST("/sonar/admin/zones/:id/seed-draft", middleware.WithAuthentication(s.authClient, s.livenessClient, s.seedZoneDraft))
-	r.POST("/sonar/admin/zones/flush-content", middleware.WithAuthentication(s.authClient, s.livenessClient, s.bulkFlushZoneContent))
-	r.POST("/sonar/admin/zones/:id/flush-content", middleware.WithAuthentication(s.authClient, s.livenessClient, s.flushZoneContent))
-	r.GET("/sonar/admin/zones/:id/quests", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getAdminZoneQuests))
-	r.POST("/sonar/admin/district-seed-jobs", middleware.WithAuthentication(s.authClient, s.livenessClient, s.createDistrictSeedJob))
-	r.GET("/sonar/admin/district-seed-jobs", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getDistrictSeedJobs))
-	r.GET("/sonar/admin/district-seed-jobs/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getDistrictSeedJob))
-	r.POST("/sonar/admin/district-seed-jobs/:id/retry", middleware.WithAuthentication(s.authClient, s.livenessClient, s.retryDistrictSeedJob))
-	r.DELETE("/sonar/admin/district-seed-jobs/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.deleteDistrictSeedJob))
-	r.POST("/sonar/admin/zone-seed-jobs/bulk-queue", middleware.WithAuthentication(s.authClient, s.livenessClient, s.bulkQueueZoneSeedJobs))
-	r.POST("/sonar/admin/zone-seed-jobs/bulk-delete", middleware.WithAuthentication(s.authClient, s.livenessClient, s.bulkDeleteZoneSeedJobs))
-	r.GET("/sonar/admin/zone-seed-jobs", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getZoneSeedJobs))
-	r.GET("/sonar/admin/zone-seed-jobs/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getZoneSeedJob))
-	r.POST("/sonar/admin/zone-seed-jobs/:id/approve", middleware.WithAuthentication(s.authClient, s.livenessClient, s.approveZoneSeedJob))
-	r.POST("/sonar/admin/zone-seed-jobs/:id/retry", middleware.WithAuthentication(s.authClient, s.livenessClient, s.retryZoneSeedJob))
-	r.POST("/sonar/admin/zone-seed-jobs/:id/shuffle-challenge", middleware.WithAuthentication(s.authClient, s.livenessClient, s.shuffleZoneSeedJobChallenge))
-	r.DELETE("/sonar/admin/zone-seed-jobs/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.deleteZoneSeedJob))
-	r.POST("/sonar/admin/scenario-generation-jobs", middleware.WithAuthentication(s.authClient, s.livenessClient, s.createScenarioGenerationJob))
-	r.GET("/sonar/admin/scenario-generation-jobs", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getScenarioGenerationJobs))
-	r.GET("/sonar/admin/scenario-generation-jobs/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getScenarioGenerationJob))
-	r.POST("/sonar/admin/scenario-generation-jobs/:id/retry", middleware.WithAuthentication(s.authClient, s.livenessClient, s.retryScenarioGenerationJob))
-	r.POST("/sonar/admin/challenge-generation-jobs", middleware.WithAuthentication(s.authClient, s.livenessClient, s.createChallengeGenerationJob))
-	r.GET("/sonar/admin/challenge-generation-jobs", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getChallengeGenerationJobs))
-	r.GET("/sonar/admin/challenge-generation-jobs/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getChallengeGenerationJob))
-	r.POST("/sonar/admin/scenario-template-generation-jobs", middleware.WithAuthentication(s.authClient, s.livenessClient, s.createScenarioTemplateGenerationJob))
-	r.GET("/sonar/admin/scenario-template-generation-jobs", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getScenarioTemplateGenerationJobs))
-	r.GET("/sonar/admin/scenario-template-generation-jobs/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getScenarioTemplateGenerationJob))
-	r.GET("/sonar/admin/scenario-template-generation-jobs/:id/drafts", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getScenarioTemplateGenerationDrafts))
-	r.POST("/sonar/admin/scenario-template-generation-drafts/:id/convert", middleware.WithAuthentication(s.authClient, s.livenessClient, s.convertScenarioTemplateGenerationDraft))
-	r.DELETE("/sonar/admin/scenario-template-generation-drafts/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.deleteScenarioTemplateGenerationDraft))
-	r.POST("/sonar/admin/exposition-template-generation-jobs", middleware.WithAuthentication(s.authClient, s.livenessClient, s.createExpositionTemplateGenerationJob))
-	r.GET("/sonar/admin/exposition-template-generation-jobs", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getExpositionTemplateGenerationJobs))
-	r.GET("/sonar/admin/exposition-template-generation-jobs/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getExpositionTemplateGenerationJob))
-	r.POST("/sonar/admin/shrine-template-generation-jobs", middleware.WithAuthentication(s.authClient, s.livenessClient, s.createShrineTemplateGenerationJob))
-	r.GET("/sonar/admin/shrine-template-generation-jobs", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getShrineTemplateGenerationJobs))
-	r.GET("/sonar/admin/shrine-template-generation-jobs/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getShrineTemplateGenerationJob))
-	r.POST("/sonar/admin/challenge-template-generation-jobs", middleware.WithAuthentication(s.authClient, s.livenessClient, s.createChallengeTemplateGenerationJob))
-	r.GET("/sonar/admin/challenge-template-generation-jobs", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getChallengeTemplateGenerationJobs))
-	r.GET("/sonar/admin/challenge-template-generation-jobs/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getChallengeTemplateGenerationJob))
-	r.POST("/sonar/admin/zone-flavor-generation-jobs", middleware.WithAuthentication(s.authClient, s.livenessClient, s.createZoneFlavorGenerationJob))
-	r.POST("/sonar/admin/zone-flavor-generation-jobs/bulk-queue", middleware.WithAuthentication(s.authClient, s.livenessClient, s.bulkQueueZoneFlavorGenerationJobs))
-	r.GET("/sonar/admin/zone-flavor-generation-jobs", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getZoneFlavorGenerationJobs))
-	r.GET("/sonar/admin/zone-flavor-generation-jobs/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getZoneFlavorGenerationJob))
-	r.POST("/sonar/admin/zone-tag-generation-jobs", middleware.WithAuthentication(s.authClient, s.livenessClient, s.createZoneTagGenerationJob))
-	r.GET("/sonar/admin/zone-tag-generation-jobs", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getZoneTagGenerationJobs))
-	r.POST("/sonar/admin/zone-tag-generation-jobs/bulk-queue", middleware.WithAuthentication(s.authClient, s.livenessClient, s.bulkQueueZoneTagGenerationJobs))
-	r.GET("/sonar/admin/zone-tag-generation-jobs/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getZoneTagGenerationJob))
-	r.GET("/sonar/admin/content-map-markers", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getContentMapMarkersPageData))
-	r.POST("/sonar/admin/thumbnails/poi-placeholder", middleware.WithAuthentication(s.authClient, s.livenessClient, s.queuePoiPlaceholderThumbnail))
-	r.POST("/sonar/admin/thumbnails/poi-undiscovered", middleware.WithAuthentication(s.authClient, s.livenessClient, s.generatePoiUndiscoveredIcon))
-	r.POST("/sonar/admin/thumbnails/scenario-undiscovered", middleware.WithAuthentication(s.authClient, s.livenessClient, s.generateScenarioUndiscoveredIcon))
-	r.POST("/sonar/admin/thumbnails/exposition-undiscovered", middleware.WithAuthentication(s.authClient, s.livenessClient, s.generateExpositionUndiscoveredIcon))
-	r.POST("/sonar/admin/thumbnails/treasure-chest-undiscovered", middleware.WithAuthentication(s.authClient, s.livenessClient, s.generateTreasureChestUndiscoveredIcon))
-	r.POST("/sonar/admin/thumbnails/monster-undiscovered", middleware.WithAuthentication(s.authClient, s.livenessClient, s.generateMonsterUndiscoveredIcon))
-	r.POST("/sonar/admin/thumbnails/monster-undiscovered/:encounterType", middleware.WithAuthentication(s.authClient, s.livenessClient, s.generateMonsterUndiscoveredIcon))
-	r.POST("/sonar/admin/thumbnails/character-undiscovered", middleware.WithAuthentication(s.authClient, s.livenessClient, s.generateCharacterUndiscoveredIcon))
-	r.POST("/sonar/admin/thumbnails/healing-fountain-discovered", middleware.WithAuthentication(s.authClient, s.livenessClient, s.generateHealingFountainDiscoveredIcon))
-	r.POST("/sonar/admin/thumbnails/shrine-discovered", middleware.WithAuthentication(s.authClient, s.livenessClient, s.generateShrineDiscoveredIcon))
-	r.POST("/sonar/admin/thumbnails/base", middleware.WithAuthentication(s.authClient, s.livenessClient, s.generateBaseDiscoveredIcon))
-	r.GET("/sonar/admin/thumbnails/poi-marker-categories", middleware.WithAuthentication(s.authClient, s.livenessClient, s.listPointOfInterestMarkerCategoryIcons))
-	r.POST("/sonar/admin/thumbnails/poi-marker-categories/:category", middleware.WithAuthentication(s.authClient, s.livenessClient, s.generatePointOfInterestMarkerCategoryIcon))
-	r.GET("/sonar/admin/thumbnails/base-grass", middleware.WithAuthentication(s.authClient, s.livenessClient, s.listBaseGrassTiles))
-	r.POST("/sonar/admin/thumbnails/base-grass/:gridX/:gridY", middleware.WithAuthentication(s.authClient, s.livenessClient, s.generateBaseGrassTile))
-	r.POST("/sonar/admin/users/profile-picture-placeholder", middleware.WithAuthentication(s.authClient, s.livenessClient, s.generateUserProfilePicturePlaceholder))
-	r.GET("/sonar/admin/thumbnails/poi-undiscovered/status", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getPoiUndiscoveredIconStatus))
-	r.GET("/sonar/admin/thumbnails/scenario-undiscovered/status", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getScenarioUndiscoveredIconStatus))
-	r.GET("/sonar/admin/thumbnails/exposition-undiscovered/status", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getExpositionUndiscoveredIconStatus))
-	r.GET("/sonar/admin/thumbnails/treasure-chest-undiscovered/status", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getTreasureChestUndiscoveredIconStatus))
-	r.GET("/sonar/admin/thumbnails/monster-undiscovered/status", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getMonsterUndiscoveredIconStatus))
-	r.GET("/sonar/admin/thumbnails/monster-undiscovered/:encounterType/status", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getMonsterUndiscoveredIconStatus))
-	r.GET("/sonar/admin/thumbnails/character-undiscovered/status", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getCharacterUndiscoveredIconStatus))
-	r.GET("/sonar/admin/thumbnails/healing-fountain-discovered/status", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getHealingFountainDiscoveredIconStatus))
-	r.GET("/sonar/admin/thumbnails/shrine-discovered/status", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getShrineDiscoveredIconStatus))
-	r.GET("/sonar/admin/thumbnails/base/status", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getBaseDiscoveredIconStatus))
-	r.GET("/sonar/admin/thumbnails/poi-marker-categories/:category/status", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getPointOfInterestMarkerCategoryIconStatus))
-	r.GET("/sonar/admin/thumbnails/base-grass/:gridX/:gridY/status", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getBaseGrassTileStatus))
-	r.GET("/sonar/admin/users/profile-picture-placeholder/status", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getUserProfilePicturePlaceholderStatus))
-	r.DELETE("/sonar/admin/thumbnails/poi-undiscovered", middleware.WithAuthentication(s.authClient, s.livenessClient, s.deletePoiUndiscoveredIcon))
-	r.DELETE("/sonar/admin/thumbnails/scenario-undiscovered", middleware.WithAuthentication(s.authClient, s.livenessClient, s.deleteScenarioUndiscoveredIcon))
-	r.DELETE("/sonar/admin/thumbnails/exposition-undiscovered", middleware.WithAuthentication(s.authClient, s.livenessClient, s.deleteExpositionUndiscoveredIcon))
-	r.DELETE("/sonar/admin/thumbnails/treasure-chest-undiscovered", middleware.WithAuthentication(s.authClient, s.livenessClient, s.deleteTreasureChestUndiscoveredIcon))
-	r.DELETE("/sonar/admin/thumbnails/monster-undiscovered", middleware.WithAuthentication(s.authClient, s.livenessClient, s.deleteMonsterUndiscoveredIcon))
-	r.DELETE("/sonar/admin/thumbnails/monster-undiscovered/:encounterType", middleware.WithAuthentication(s.authClient, s.livenessClient, s.deleteMonsterUndiscoveredIcon))
-	r.DELETE("/sonar/admin/thumbnails/character-undiscovered", middleware.WithAuthentication(s.authClient, s.livenessClient, s.deleteCharacterUndiscoveredIcon))
-	r.DELETE("/sonar/admin/thumbnails/healing-fountain-discovered", middleware.WithAuthentication(s.authClient, s.livenessClient, s.deleteHealingFountainDiscoveredIcon))
-	r.DELETE("/sonar/admin/thumbnails/shrine-discovered", middleware.WithAuthentication(s.authClient, s.livenessClient, s.deleteShrineDiscoveredIcon))
-	r.DELETE("/sonar/admin/thumbnails/base", middleware.WithAuthentication(s.authClient, s.livenessClient, s.deleteBaseDiscoveredIcon))
-	r.DELETE("/sonar/admin/thumbnails/poi-marker-categories/:category", middleware.WithAuthentication(s.authClient, s.livenessClient, s.deletePointOfInterestMarkerCategoryIcon))
-	r.DELETE("/sonar/admin/thumbnails/base-grass/:gridX/:gridY", middleware.WithAuthentication(s.authClient, s.livenessClient, s.deleteBaseGrassTile))
+	admin.POST("/zones/:id/seed-draft", middleware.WithAuthentication(s.authClient, s.livenessClient, s.seedZoneDraft))
+	admin.POST("/zones/flush-content", middleware.WithAuthentication(s.authClient, s.livenessClient, s.bulkFlushZoneContent))
+	admin.POST("/zones/:id/flush-content", middleware.WithAuthentication(s.authClient, s.livenessClient, s.flushZoneContent))
+	admin.GET("/zones/:id/quests", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getAdminZoneQuests))
+	admin.GET("/zones/geojson", middleware.WithAuthentication(s.authClient, s.livenessClient, s.exportZonesGeoJSON))
+	admin.POST("/zones/boundaries/import", middleware.WithAuthentication(s.authClient, s.livenessClient, s.importZoneBoundaries))
+	admin.POST("/zones/merge", middleware.WithAuthentication(s.authClient, s.livenessClient, s.mergeZones))
+	admin.POST("/zones/simplify", middleware.WithAuthentication(s.authClient, s.livenessClient, s.simplifyZones))
+	admin.POST("/zones/:id/split", middleware.WithAuthentication(s.authClient, s.livenessClient, s.splitZone))
+	admin.GET("/template-revisions/:type/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getTemplateRevisions))
+	admin.POST("/template-revisions/:type/:id/drafts", middleware.WithAuthentication(s.authClient, s.livenessClient, s.createTemplateDraft))
+	admin.GET("/template-revisions/:type/:id/:revision", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getTemplateRevision))
+	admin.POST("/template-revisions/:type/:id/:revision/publish", middleware.WithAuthentication(s.authClient, s.livenessClient, s.publishTemplateRevision))
+	admin.POST("/template-revisions/:type/:id/:revision/rollback", middleware.WithAuthentication(s.authClient, s.livenessClient, s.rollbackTemplateRevision))
+	admin.DELETE("/template-revisions/:type/:id/:revision", middleware.WithAuthentication(s.authClient, s.livenessClient, s.deleteTemplateDraft))
+	admin.GET("/prompts", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getPromptDefinitions))
+	admin.GET("/prompts/:key/versions", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getPromptVersions))
+	admin.POST("/prompts/:key/versions", middleware.WithAuthentication(s.authClient, s.livenessClient, s.createPromptVersion))
+	admin.POST("/prompts/:key/versions/:versionId/activate", middleware.WithAuthentication(s.authClient, s.livenessClient, s.activatePromptVersion))
+	admin.POST("/prompts/:key/versions/:versionId/deactivate", middleware.WithAuthentication(s.authClient, s.livenessClient, s.deactivatePromptVersion))
+	admin.GET("/prompt-evaluations", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getPromptEvaluations))
+	admin.POST("/prompt-evaluations", middleware.WithAuthentication(s.authClient, s.livenessClient, s.createPromptEvaluation))
+	admin.GET("/prompt-evaluations/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getPromptEvaluation))
+	admin.PUT("/prompt-evaluations/:id/outputs/:outputId/rating", middleware.WithAuthentication(s.authClient, s.livenessClient, s.ratePromptEvaluationOutput))
+	admin.GET("/generated-prompts/:recordType/:recordId", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getGeneratedRecordPrompt))
+	admin.GET("/moderation", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getContentModerationQueue))
+	admin.GET("/moderation/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getContentModerationItem))
+	admin.POST("/moderation/:id/approve", middleware.WithAuthentication(s.authClient, s.livenessClient, s.approveContentModerationItem))
+	admin.POST("/moderation/:id/reject", middleware.WithAuthentication(s.authClient, s.livenessClient, s.rejectContentModerationItem))
+	admin.POST("/moderation/:id/regenerate", middleware.WithAuthentication(s.authClient, s.livenessClient, s.regenerateContentModerationItem))
+	admin.GET("/moderation-terms", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getModerationTerms))
+	admin.POST("/moderation-terms", middleware.WithAuthentication(s.authClient, s.livenessClient, s.createModerationTerm))
+	admin.DELETE("/moderation-terms/:termId", middleware.WithAuthentication(s.authClient, s.livenessClient, s.deleteModerationTerm))
+	admin.GET("/locales", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getTranslationLocales))
+	admin.POST("/locales", middleware.WithAuthentication(s.authClient, s.livenessClient, s.upsertTranslationLocale))
+	admin.DELETE("/locales/:code", middleware.WithAuthentication(s.authClient, s.livenessClient, s.deleteTranslationLocale))
+	admin.GET("/translations/:entityType/:entityId", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getContentTranslations))
+	admin.PUT("/translations/:entityType/:entityId/:locale", middleware.WithAuthentication(s.authClient, s.livenessClient, s.saveContentTranslations))
+	admin.POST("/translations/:entityType/:entityId/machine-translate", middleware.WithAuthentication(s.authClient, s.livenessClient, s.machineTranslateContent))
+	admin.DELETE("/content-translations/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.deleteContentTranslation))
+	admin.GET("/zone-genres/:id/glossary", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getTranslationGlossary))
+	admin.POST("/zone-genres/:id/glossary", middleware.WithAuthentication(s.authClient, s.livenessClient, s.createTranslationGlossaryTerm))
+	admin.DELETE("/glossary-terms/:termId", middleware.WithAuthentication(s.authClient, s.livenessClient, s.deleteTranslationGlossaryTerm))
+	admin.GET("/auth-metrics", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getAuthMetrics))
+	admin.POST("/district-seed-jobs", middleware.WithAuthentication(s.authClient, s.livenessClient, s.createDistrictSeedJob))
+	admin.GET("/district-seed-jobs", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getDistrictSeedJobs))
+	admin.GET("/district-seed-jobs/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getDistrictSeedJob))
+	admin.POST("/district-seed-jobs/:id/retry", middleware.WithAuthentication(s.authClient, s.livenessClient, s.retryDistrictSeedJob))
+	admin.DELETE("/district-seed-jobs/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.deleteDistrictSeedJob))
+	admin.POST("/zone-seed-jobs/bulk-queue", middleware.WithAuthentication(s.authClient, s.livenessClient, s.bulkQueueZoneSeedJobs))
+	admin.POST("/zone-seed-jobs/bulk-delete", middleware.WithAuthentication(s.authClient, s.livenessClient, s.bulkDeleteZoneSeedJobs))
+	admin.GET("/zone-seed-jobs", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getZoneSeedJobs))
+	admin.GET("/zone-seed-jobs/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getZoneSeedJob))
+	admin.POST("/zone-seed-jobs/:id/approve", middleware.WithAuthentication(s.authClient, s.livenessClient, s.approveZoneSeedJob))
+	admin.POST("/zone-seed-jobs/:id/retry", middleware.WithAuthentication(s.authClient, s.livenessClient, s.retryZoneSeedJob))
+	admin.POST("/zone-seed-jobs/:id/shuffle-challenge", middleware.WithAuthentication(s.authClient, s.livenessClient, s.shuffleZoneSeedJobChallenge))
+	admin.DELETE("/zone-seed-jobs/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.deleteZoneSeedJob))
+	admin.POST("/scenario-generation-jobs", middleware.WithAuthentication(s.authClient, s.livenessClient, s.createScenarioGenerationJob))
+	admin.GET("/scenario-generation-jobs", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getScenarioGenerationJobs))
+	admin.GET("/scenario-generation-jobs/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getScenarioGenerationJob))
+	admin.POST("/scenario-generation-jobs/:id/retry", middleware.WithAuthentication(s.authClient, s.livenessClient, s.retryScenarioGenerationJob))
+	admin.POST("/challenge-generation-jobs", middleware.WithAuthentication(s.authClient, s.livenessClient, s.createChallengeGenerationJob))
+	admin.GET("/challenge-generation-jobs", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getChallengeGenerationJobs))
+	admin.GET("/challenge-generation-jobs/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getChallengeGenerationJob))
+	admin.POST("/scenario-template-generation-jobs", middleware.WithAuthentication(s.authClient, s.livenessClient, s.createScenarioTemplateGenerationJob))
+	admin.GET("/scenario-template-generation-jobs", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getScenarioTemplateGenerationJobs))
+	admin.GET("/scenario-template-generation-jobs/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getScenarioTemplateGenerationJob))
+	admin.GET("/scenario-template-generation-jobs/:id/drafts", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getScenarioTemplateGenerationDrafts))
+	admin.POST("/scenario-template-generation-drafts/:id/convert", middleware.WithAuthentication(s.authClient, s.livenessClient, s.convertScenarioTemplateGenerationDraft))
+	admin.DELETE("/scenario-template-generation-drafts/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.deleteScenarioTemplateGenerationDraft))
+	admin.POST("/exposition-template-generation-jobs", middleware.WithAuthentication(s.authClient, s.livenessClient, s.createExpositionTemplateGenerationJob))
+	admin.GET("/exposition-template-generation-jobs", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getExpositionTemplateGenerationJobs))
+	admin.GET("/exposition-template-generation-jobs/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getExpositionTemplateGenerationJob))
+	admin.POST("/shrine-template-generation-jobs", middleware.WithAuthentication(s.authClient, s.livenessClient, s.createShrineTemplateGenerationJob))
+	admin.GET("/shrine-template-generation-jobs", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getShrineTemplateGenerationJobs))
+	admin.GET("/shrine-template-generation-jobs/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getShrineTemplateGenerationJob))
+	admin.POST("/challenge-template-generation-jobs", middleware.WithAuthentication(s.authClient, s.livenessClient, s.createChallengeTemplateGenerationJob))
+	admin.GET("/challenge-template-generation-jobs", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getChallengeTemplateGenerationJobs))
+	admin.GET("/challenge-template-generation-jobs/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getChallengeTemplateGenerationJob))
+	admin.POST("/zone-flavor-generation-jobs", middleware.WithAuthentication(s.authClient, s.livenessClient, s.createZoneFlavorGenerationJob))
+	admin.POST("/zone-flavor-generation-jobs/bulk-queue", middleware.WithAuthentication(s.authClient, s.livenessClient, s.bulkQueueZoneFlavorGenerationJobs))
+	admin.GET("/zone-flavor-generation-jobs", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getZoneFlavorGenerationJobs))
+	admin.GET("/zone-flavor-generation-jobs/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getZoneFlavorGenerationJob))
+	admin.POST("/zone-tag-generation-jobs", middleware.WithAuthentication(s.authClient, s.livenessClient, s.createZoneTagGenerationJob))
+	admin.GET("/zone-tag-generation-jobs", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getZoneTagGenerationJobs))
+	admin.POST("/zone-tag-generation-jobs/bulk-queue", middleware.WithAuthentication(s.authClient, s.livenessClient, s.bulkQueueZoneTagGenerationJobs))
+	admin.GET("/zone-tag-generation-jobs/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getZoneTagGenerationJob))
+	admin.GET("/content-map-markers", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getContentMapMarkersPageData))
+	admin.POST("/thumbnails/poi-placeholder", middleware.WithAuthentication(s.authClient, s.livenessClient, s.queuePoiPlaceholderThumbnail))
+	admin.POST("/thumbnails/poi-undiscovered", middleware.WithAuthentication(s.authClient, s.livenessClient, s.generatePoiUndiscoveredIcon))
+	admin.POST("/thumbnails/scenario-undiscovered", middleware.WithAuthentication(s.authClient, s.livenessClient, s.generateScenarioUndiscoveredIcon))
+	admin.POST("/thumbnails/exposition-undiscovered", middleware.WithAuthentication(s.authClient, s.livenessClient, s.generateExpositionUndiscoveredIcon))
+	admin.POST("/thumbnails/treasure-chest-undiscovered", middleware.WithAuthentication(s.authClient, s.livenessClient, s.generateTreasureChestUndiscoveredIcon))
+	admin.POST("/thumbnails/monster-undiscovered", middleware.WithAuthentication(s.authClient, s.livenessClient, s.generateMonsterUndiscoveredIcon))
+	admin.POST("/thumbnails/monster-undiscovered/:encounterType", middleware.WithAuthentication(s.authClient, s.livenessClient, s.generateMonsterUndiscoveredIcon))
+	admin.POST("/thumbnails/character-undiscovered", middleware.WithAuthentication(s.authClient, s.livenessClient, s.generateCharacterUndiscoveredIcon))
+	admin.POST("/thumbnails/healing-fountain-discovered", middleware.WithAuthentication(s.authClient, s.livenessClient, s.generateHealingFountainDiscoveredIcon))
+	admin.POST("/thumbnails/shrine-discovered", middleware.WithAuthentication(s.authClient, s.livenessClient, s.generateShrineDiscoveredIcon))
+	admin.POST("/thumbnails/base", middleware.WithAuthentication(s.authClient, s.livenessClient, s.generateBaseDiscoveredIcon))
+	admin.GET("/thumbnails/poi-marker-categories", middleware.WithAuthentication(s.authClient, s.livenessClient, s.listPointOfInterestMarkerCategoryIcons))
+	admin.POST("/thumbnails/poi-marker-categories/:category", middleware.WithAuthentication(s.authClient, s.livenessClient, s.generatePointOfInterestMarkerCategoryIcon))
+	admin.GET("/thumbnails/base-grass", middleware.WithAuthentication(s.authClient, s.livenessClient, s.listBaseGrassTiles))
+	admin.POST("/thumbnails/base-grass/:gridX/:gridY", middleware.WithAuthentication(s.authClient, s.livenessClient, s.generateBaseGrassTile))
+	admin.POST("/users/profile-picture-placeholder", middleware.WithAuthentication(s.authClient, s.livenessClient, s.generateUserProfilePicturePlaceholder))
+	admin.GET("/thumbnails/poi-undiscovered/status", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getPoiUndiscoveredIconStatus))
+	admin.GET("/thumbnails/scenario-undiscovered/status", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getScenarioUndiscoveredIconStatus))
+	admin.GET("/thumbnails/exposition-undiscovered/status", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getExpositionUndiscoveredIconStatus))
+	admin.GET("/thumbnails/treasure-chest-undiscovered/status", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getTreasureChestUndiscoveredIconStatus))
+	admin.GET("/thumbnails/monster-undiscovered/status", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getMonsterUndiscoveredIconStatus))
+	admin.GET("/thumbnails/monster-undiscovered/:encounterType/status", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getMonsterUndiscoveredIconStatus))
+	admin.GET("/thumbnails/character-undiscovered/status", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getCharacterUndiscoveredIconStatus))
+	admin.GET("/thumbnails/healing-fountain-discovered/status", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getHealingFountainDiscoveredIconStatus))
+	admin.GET("/thumbnails/shrine-discovered/status", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getShrineDiscoveredIconStatus))
+	admin.GET("/thumbnails/base/status", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getBaseDiscoveredIconStatus))
+	admin.GET("/thumbnails/poi-marker-categories/:category/status", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getPointOfInterestMarkerCategoryIconStatus))
+	admin.GET("/thumbnails/base-grass/:gridX/:gridY/status", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getBaseGrassTileStatus))
+	admin.GET("/users/profile-picture-placeholder/status", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getUserProfilePicturePlaceholderStatus))
+	admin.DELETE("/thumbnails/poi-undiscovered", middleware.WithAuthentication(s.authClient, s.livenessClient, s.deletePoiUndiscoveredIcon))
+	admin.DELETE("/thumbnails/scenario-undiscovered", middleware.WithAuthentication(s.authClient, s.livenessClient, s.deleteScenarioUndiscoveredIcon))
+	admin.DELETE("/thumbnails/exposition-undiscovered", middleware.WithAuthentication(s.authClient, s.livenessClient, s.deleteExpositionUndiscoveredIcon))
+	admin.DELETE("/thumbnails/treasure-chest-undiscovered", middleware.WithAuthentication(s.authClient, s.livenessClient, s.deleteTreasureChestUndiscoveredIcon))
+	admin.DELETE("/thumbnails/monster-undiscovered", middleware.WithAuthentication(s.authClient, s.livenessClient, s.deleteMonsterUndiscoveredIcon))
+	admin.DELETE("/thumbnails/monster-undiscovered/:encounterType", middleware.WithAuthentication(s.authClient, s.livenessClient, s.deleteMonsterUndiscoveredIcon))
+	admin.DELETE("/thumbnails/character-undiscovered", middleware.WithAuthentication(s.authClient, s.livenessClient, s.deleteCharacterUndiscoveredIcon))
+	admin.DELETE("/thumbnails/healing-fountain-discovered", middleware.WithAuthentication(s.authClient, s.livenessClient, s.deleteHealingFountainDiscoveredIcon))
+	admin.DELETE("/thumbnails/shrine-discovered", middleware.WithAuthentication(s.authClient, s.livenessClient, s.deleteShrineDiscoveredIcon))
+	admin.DELETE("/thumbnails/base", middleware.WithAuthentication(s.authClient, s.livenessClient, s.deleteBaseDiscoveredIcon))
+	admin.DELETE("/thumbnails/poi-marker-categories/:category", middleware.WithAuthentication(s.authClient, s.livenessClient, s.deletePointOfInterestMarkerCategoryIcon))
+	admin.DELETE("/thumbnails/base-grass/:gridX/:gridY", middleware.WithAuthentication(s.authClient, s.livenessClient, s.deleteBaseGrassTile))
 	r.GET("/sonar/zones/:id/pins", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getZonePins))
 	r.GET("/sonar/zones/:id/map-snapshot", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getZoneMapSnapshot))
+	r.GET("/sonar/tiles/:z/:x/:y", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getMapTile))
 	r.GET("/sonar/zones/:id/quest-availability-overlay", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getZoneQuestAvailabilityOverlay))
 	r.GET("/sonar/zones/:id/pointsOfInterest", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getPointsOfInterestForZone))
 	r.POST("/sonar/zones/:id/pointsOfInterest", middleware.WithAuthentication(s.authClient, s.livenessClient, s.generatePointsOfInterestForZone))
@@ -547,7 +632,7 @@ func (s *server) SetupRoutes(r *gin.Engine) {
 	r.GET("/sonar/google/places", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getGooglePlaces))
 	r.GET("/sonar/google/place/:placeID", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getGooglePlace))
 	r.POST("/sonar/zones/:id/quests/:questArchTypeID/generate", middleware.WithAuthentication(s.authClient, s.livenessClient, s.generateQuest))
-	r.GET("/sonar/admin/quests", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getAdminQuests))
+	admin.GET("/quests", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getAdminQuests))
 	r.GET("/sonar/quests", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getQuests))
 	r.GET("/sonar/quests/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getQuest))
 	r.POST("/sonar/quests", middleware.WithAuthentication(s.authClient, s.livenessClient, s.createQuest))
@@ -593,14 +678,14 @@ func (s *server) SetupRoutes(r *gin.Engine) {
 	r.DELETE("/sonar/mainStoryDistrictRuns/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.deleteMainStoryDistrictRun))
 	r.POST("/sonar/questArchetypes", middleware.WithAuthentication(s.authClient, s.livenessClient, s.createQuestArchetype))
 	r.DELETE("/sonar/questArchetypes/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.deleteQuestArchetype))
-	r.PATCH("/sonar/questArchetypes/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.updateQuestArchetype))
+	r.PATCH("/sonar/questArchetypes/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.withTemplateRevisions(models.TemplateRevisionTypeQuestArchetype, s.updateQuestArchetype)))
 	r.POST("/sonar/questArchetypes/:id/generate", middleware.WithAuthentication(s.authClient, s.livenessClient, s.generateQuestForQuestArchetype))
 	r.POST("/sonar/questArchetypeNodes", middleware.WithAuthentication(s.authClient, s.livenessClient, s.createQuestArchetypeNode))
-	r.PATCH("/sonar/questArchetypeNodes/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.updateQuestArchetypeNode))
-	r.POST("/sonar/questArchetypes/:id/challenges", middleware.WithAuthentication(s.authClient, s.livenessClient, s.generateQuestArchetypeChallenge))
+	r.PATCH("/sonar/questArchetypeNodes/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.withQuestArchetypeNodeRevisions(s.updateQuestArchetypeNode)))
+	r.POST("/sonar/questArchetypes/:id/challenges", middleware.WithAuthentication(s.authClient, s.livenessClient, s.withQuestArchetypeNodeRevisions(s.generateQuestArchetypeChallenge)))
 	r.GET("/sonar/questArchetypes/:id/challenges", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getQuestArchetypeChallenges))
-	r.PATCH("/sonar/questArchetypeChallenges/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.updateQuestArchetypeChallenge))
-	r.DELETE("/sonar/questArchetypeChallenges/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.deleteQuestArchetypeChallenge))
+	r.PATCH("/sonar/questArchetypeChallenges/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.withQuestArchetypeChallengeRevisions(s.updateQuestArchetypeChallenge)))
+	r.DELETE("/sonar/questArchetypeChallenges/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.withQuestArchetypeChallengeRevisions(s.deleteQuestArchetypeChallenge)))
 	r.POST("/sonar/zones/:id/questArchetypes", middleware.WithAuthentication(s.authClient, s.livenessClient, s.generateQuestArchetypesForZone))
 	r.GET("/sonar/zoneQuestArchetypes", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getZoneQuestArchetypes))
 	r.POST("/sonar/zoneQuestArchetypes", middleware.WithAuthentication(s.authClient, s.livenessClient, s.createZoneQuestArchetype))
@@ -660,6 +745,61 @@ func (s *server) SetupRoutes(r *gin.Engine) {
 	r.DELETE("/sonar/character-actions/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.deleteCharacterAction))
 	r.POST("/sonar/character-actions/:id/purchase", middleware.WithAuthentication(s.authClient, s.livenessClient, s.purchaseFromShop))
 	r.POST("/sonar/character-actions/:id/sell", middleware.WithAuthentication(s.authClient, s.livenessClient, s.sellToShop))
+	r.GET("/sonar/trades", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getTradeOffers))
+	r.POST("/sonar/trades", middleware.WithAuthentication(s.authClient, s.livenessClient, s.createTradeOffer))
+	r.POST("/sonar/trades/:id/confirm", middleware.WithAuthentication(s.authClient, s.livenessClient, s.confirmTradeOffer))
+	r.POST("/sonar/trades/:id/decline", middleware.WithAuthentication(s.authClient, s.livenessClient, s.declineTradeOffer))
+	r.POST("/sonar/trades/:id/cancel", middleware.WithAuthentication(s.authClient, s.livenessClient, s.cancelTradeOffer))
+	r.GET("/sonar/zones/:id/marketplace", middleware.WithAuthentication(s.authClient, s.livenessClient, s.searchZoneMarketListings))
+	r.POST("/sonar/zones/:id/marketplace", middleware.WithAuthentication(s.authClient, s.livenessClient, s.createZoneMarketListing))
+	r.GET("/sonar/marketplace/listings/mine", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getMyMarketListings))
+	r.POST("/sonar/marketplace/listings/:id/purchase", middleware.WithAuthentication(s.authClient, s.livenessClient, s.purchaseMarketListing))
+	r.POST("/sonar/marketplace/listings/:id/cancel", middleware.WithAuthentication(s.authClient, s.livenessClient, s.cancelMarketListing))
+	r.POST("/sonar/guilds", middleware.WithAuthentication(s.authClient, s.livenessClient, s.createGuild))
+	r.GET("/sonar/guilds/mine", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getMyGuild))
+	r.GET("/sonar/guilds/leaderboard", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getGuildLeaderboard))
+	r.GET("/sonar/guilds/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getGuild))
+	r.PATCH("/sonar/guilds/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.updateGuild))
+	r.GET("/sonar/guilds/:id/invites", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getGuildInvites))
+	r.POST("/sonar/guilds/:id/invites", middleware.WithAuthentication(s.authClient, s.livenessClient, s.inviteToGuild))
+	r.POST("/sonar/guilds/:id/leave", middleware.WithAuthentication(s.authClient, s.livenessClient, s.leaveGuild))
+	r.POST("/sonar/guilds/:id/leader", middleware.WithAuthentication(s.authClient, s.livenessClient, s.transferGuildLeadership))
+	r.POST("/sonar/guilds/:id/members/:userId/role", middleware.WithAuthentication(s.authClient, s.livenessClient, s.setGuildMemberRole))
+	r.DELETE("/sonar/guilds/:id/members/:userId", middleware.WithAuthentication(s.authClient, s.livenessClient, s.removeGuildMember))
+	r.GET("/sonar/guilds/:id/bank", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getGuildBank))
+	r.POST("/sonar/guilds/:id/bank/deposit", middleware.WithAuthentication(s.authClient, s.livenessClient, s.depositToGuildBank))
+	r.POST("/sonar/guilds/:id/bank/withdraw", middleware.WithAuthentication(s.authClient, s.livenessClient, s.withdrawFromGuildBank))
+	r.GET("/sonar/guilds/:id/audit", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getGuildAuditLog))
+	r.GET("/sonar/guilds/:id/quests/:questId/progress", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getGuildQuestProgress))
+	r.POST("/sonar/guilds/:id/quests/:questId/claim", middleware.WithAuthentication(s.authClient, s.livenessClient, s.claimGuildQuestProgress))
+	r.GET("/sonar/guildInvites", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getMyGuildInvites))
+	r.POST("/sonar/guildInvites/:id/accept", middleware.WithAuthentication(s.authClient, s.livenessClient, s.acceptGuildInvite))
+	r.POST("/sonar/guildInvites/:id/decline", middleware.WithAuthentication(s.authClient, s.livenessClient, s.declineGuildInvite))
+	r.POST("/sonar/guildInvites/:id/cancel", middleware.WithAuthentication(s.authClient, s.livenessClient, s.cancelGuildInvite))
+	r.GET("/sonar/achievements", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getAchievements))
+	r.POST("/sonar/achievements/claim", middleware.WithAuthentication(s.authClient, s.livenessClient, s.claimAchievementRewards))
+	r.GET("/sonar/titles", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getUserTitles))
+	r.POST("/sonar/titles/active", middleware.WithAuthentication(s.authClient, s.livenessClient, s.setActiveTitle))
+	admin.GET("/achievements", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getAchievementDefinitions))
+	admin.POST("/achievements", middleware.WithAuthentication(s.authClient, s.livenessClient, s.createAchievementDefinition))
+	admin.POST("/achievements/backfill", middleware.WithAuthentication(s.authClient, s.livenessClient, s.backfillAchievements))
+	admin.PATCH("/achievements/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.updateAchievementDefinition))
+	admin.DELETE("/achievements/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.deleteAchievementDefinition))
+	r.GET("/sonar/zones/:id/bounties", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getZoneBounties))
+	r.POST("/sonar/bounties/:id/claim", middleware.WithAuthentication(s.authClient, s.livenessClient, s.claimBounty))
+	admin.GET("/bounty-templates", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getBountyTemplates))
+	admin.POST("/bounty-templates", middleware.WithAuthentication(s.authClient, s.livenessClient, s.createBountyTemplate))
+	admin.PATCH("/bounty-templates/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.updateBountyTemplate))
+	admin.DELETE("/bounty-templates/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.deleteBountyTemplate))
+	admin.GET("/spawn-rules", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getSpawnRules))
+	admin.POST("/spawn-rules", middleware.WithAuthentication(s.authClient, s.livenessClient, s.createSpawnRule))
+	admin.PATCH("/spawn-rules/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.updateSpawnRule))
+	admin.DELETE("/spawn-rules/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.deleteSpawnRule))
+	admin.GET("/content-validation", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getContentValidation))
+	r.GET("/sonar/questArchetypes/:id/validation", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getQuestArchetypeValidation))
+	r.GET("/sonar/mainStoryTemplates/:id/validation", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getMainStoryTemplateValidation))
+	admin.GET("/content-bundle", middleware.WithAuthentication(s.authClient, s.livenessClient, s.exportContentBundle))
+	admin.POST("/content-bundle/import", middleware.WithAuthentication(s.authClient, s.livenessClient, s.importContentBundle))
 	r.POST("/sonar/friendInvites/accept", middleware.WithAuthentication(s.authClient, s.livenessClient, s.acceptFriendInvite))
 	r.POST("/sonar/friendInvites/create", middleware.WithAuthentication(s.authClient, s.livenessClient, s.createFriendInvite))
 	r.GET("/sonar/partyInvites", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getPartyInvites))
@@ -679,7 +819,7 @@ func (s *server) SetupRoutes(r *gin.Engine) {
 	r.DELETE("/sonar/treasure-chests/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.deleteTreasureChest))
 	r.POST("/sonar/treasure-chests/bulk-delete", middleware.WithAuthentication(s.authClient, s.livenessClient, s.bulkDeleteTreasureChests))
 	r.POST("/sonar/treasure-chests/:id/open", middleware.WithAuthentication(s.authClient, s.livenessClient, s.openTreasureChest))
-	r.POST("/sonar/admin/treasure-chests/reconfigure-lock-distribution", middleware.WithAuthentication(s.authClient, s.livenessClient, s.reconfigureTreasureChestLockDistribution))
+	admin.POST("/treasure-chests/reconfigure-lock-distribution", middleware.WithAuthentication(s.authClient, s.livenessClient, s.reconfigureTreasureChestLockDistribution))
 	r.GET("/sonar/healing-fountains", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getHealingFountains))
 	r.GET("/sonar/healing-fountains/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getHealingFountain))
 	r.GET("/sonar/zones/:id/healing-fountains", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getHealingFountainsForZone))
@@ -693,7 +833,7 @@ func (s *server) SetupRoutes(r *gin.Engine) {
 	r.GET("/sonar/shrine-templates", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getShrineTemplates))
 	r.GET("/sonar/shrine-templates/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getShrineTemplate))
 	r.POST("/sonar/shrine-templates", middleware.WithAuthentication(s.authClient, s.livenessClient, s.createShrineTemplate))
-	r.PUT("/sonar/shrine-templates/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.updateShrineTemplate))
+	r.PUT("/sonar/shrine-templates/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.withTemplateRevisions(models.TemplateRevisionTypeShrineTemplate, s.updateShrineTemplate)))
 	r.DELETE("/sonar/shrine-templates/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.deleteShrineTemplate))
 	r.GET("/sonar/shrines", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getShrines))
 	r.GET("/sonar/shrines/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getShrine))
@@ -711,14 +851,14 @@ func (s *server) SetupRoutes(r *gin.Engine) {
 	r.DELETE("/sonar/resources/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.deleteResource))
 	r.POST("/sonar/resources/:id/generate-requirement-items", middleware.WithAuthentication(s.authClient, s.livenessClient, s.generateResourceRequirementItems))
 	r.POST("/sonar/resources/:id/gather", middleware.WithAuthentication(s.authClient, s.livenessClient, s.gatherResource))
-	r.GET("/sonar/admin/bases", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getAllBases))
-	r.DELETE("/sonar/admin/bases/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.deleteBase))
-	r.GET("/sonar/admin/base-structures", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getAdminBaseStructures))
-	r.PUT("/sonar/admin/base-structures/:id/prompts", middleware.WithAuthentication(s.authClient, s.livenessClient, s.updateBaseStructurePrompts))
-	r.PUT("/sonar/admin/base-structures/:id/hearth-recovery-config", middleware.WithAuthentication(s.authClient, s.livenessClient, s.updateBaseStructureHearthRecoveryConfig))
-	r.PUT("/sonar/admin/base-structures/:id/chaos-engine-config", middleware.WithAuthentication(s.authClient, s.livenessClient, s.updateBaseStructureChaosEngineConfig))
-	r.POST("/sonar/admin/base-structures/:id/levels/:level/generate-image", middleware.WithAuthentication(s.authClient, s.livenessClient, s.generateBaseStructureLevelImage))
-	r.POST("/sonar/admin/base-structures/:id/levels/:level/generate-top-down-image", middleware.WithAuthentication(s.authClient, s.livenessClient, s.generateBaseStructureLevelTopDownImage))
+	admin.GET("/bases", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getAllBases))
+	admin.DELETE("/bases/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.deleteBase))
+	admin.GET("/base-structures", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getAdminBaseStructures))
+	admin.PUT("/base-structures/:id/prompts", middleware.WithAuthentication(s.authClient, s.livenessClient, s.updateBaseStructurePrompts))
+	admin.PUT("/base-structures/:id/hearth-recovery-config", middleware.WithAuthentication(s.authClient, s.livenessClient, s.updateBaseStructureHearthRecoveryConfig))
+	admin.PUT("/base-structures/:id/chaos-engine-config", middleware.WithAuthentication(s.authClient, s.livenessClient, s.updateBaseStructureChaosEngineConfig))
+	admin.POST("/base-structures/:id/levels/:level/generate-image", middleware.WithAuthentication(s.authClient, s.livenessClient, s.generateBaseStructureLevelImage))
+	admin.POST("/base-structures/:id/levels/:level/generate-top-down-image", middleware.WithAuthentication(s.authClient, s.livenessClient, s.generateBaseStructureLevelTopDownImage))
 	r.GET("/sonar/monster-templates", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getMonsterTemplates))
 	r.GET("/sonar/monster-templates/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getMonsterTemplate))
 	r.POST("/sonar/monster-templates", middleware.WithAuthentication(s.authClient, s.livenessClient, s.createMonsterTemplate))
@@ -729,12 +869,12 @@ func (s *server) SetupRoutes(r *gin.Engine) {
 	r.GET("/sonar/monster-template-suggestion-jobs/:id/drafts", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getMonsterTemplateSuggestionDrafts))
 	r.POST("/sonar/monster-template-suggestion-drafts/:id/convert", middleware.WithAuthentication(s.authClient, s.livenessClient, s.convertMonsterTemplateSuggestionDraft))
 	r.DELETE("/sonar/monster-template-suggestion-drafts/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.deleteMonsterTemplateSuggestionDraft))
-	r.POST("/sonar/admin/monster-templates/generate-images", middleware.WithAuthentication(s.authClient, s.livenessClient, s.generateMonsterTemplateImages))
-	r.POST("/sonar/admin/monster-templates/refresh-affinities", middleware.WithAuthentication(s.authClient, s.livenessClient, s.refreshMonsterTemplateAffinities))
-	r.GET("/sonar/admin/monster-templates/refresh-affinities/:jobId/status", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getRefreshMonsterTemplateAffinitiesStatus))
-	r.POST("/sonar/admin/monster-templates/reset-progressions", middleware.WithAuthentication(s.authClient, s.livenessClient, s.resetMonsterTemplateProgressions))
-	r.GET("/sonar/admin/monster-templates/reset-progressions/:jobId/status", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getResetMonsterTemplateProgressionsStatus))
-	r.PUT("/sonar/monster-templates/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.updateMonsterTemplate))
+	admin.POST("/monster-templates/generate-images", middleware.WithAuthentication(s.authClient, s.livenessClient, s.generateMonsterTemplateImages))
+	admin.POST("/monster-templates/refresh-affinities", middleware.WithAuthentication(s.authClient, s.livenessClient, s.refreshMonsterTemplateAffinities))
+	admin.GET("/monster-templates/refresh-affinities/:jobId/status", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getRefreshMonsterTemplateAffinitiesStatus))
+	admin.POST("/monster-templates/reset-progressions", middleware.WithAuthentication(s.authClient, s.livenessClient, s.resetMonsterTemplateProgressions))
+	admin.GET("/monster-templates/reset-progressions/:jobId/status", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getResetMonsterTemplateProgressionsStatus))
+	r.PUT("/sonar/monster-templates/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.withTemplateRevisions(models.TemplateRevisionTypeMonsterTemplate, s.updateMonsterTemplate)))
 	r.POST("/sonar/monster-templates/:id/generate-image", middleware.WithAuthentication(s.authClient, s.livenessClient, s.generateMonsterTemplateImage))
 	r.DELETE("/sonar/monster-templates/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.deleteMonsterTemplate))
 	r.GET("/sonar/monsters", middleware.WithAuthentication(s.authClient, s.livenessClient, s.getMonsters))
@@ -784,8 +924,8 @@ func (s *server) SetupRoutes(r *gin.Engine) {
 	r.POST("/sonar/exposition-templates", middleware.WithAuthentication(s.authClient, s.livenessClient, s.createExpositionTemplate))
 	r.POST("/sonar/scenarios/bulk-delete", middleware.WithAuthentication(s.authClient, s.livenessClient, s.bulkDeleteScenarios))
 	r.PUT("/sonar/scenarios/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.updateScenario))
-	r.PUT("/sonar/scenario-templates/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.updateScenarioTemplate))
-	r.PUT("/sonar/exposition-templates/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.updateExpositionTemplate))
+	r.PUT("/sonar/scenario-templates/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.withTemplateRevisions(models.TemplateRevisionTypeScenarioTemplate, s.updateScenarioTemplate)))
+	r.PUT("/sonar/exposition-templates/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.withTemplateRevisions(models.TemplateRevisionTypeExpositionTemplate, s.updateExpositionTemplate)))
 	r.PATCH("/sonar/scenarios/:id/location", middleware.WithAuthentication(s.authClient, s.livenessClient, s.updateScenarioLocation))
 	r.POST("/sonar/scenarios/:id/generate-image", middleware.WithAuthentication(s.authClient, s.livenessClient, s.generateScenarioImage))
 	r.DELETE("/sonar/scenarios/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.deleteScenario))
@@ -812,8 +952,8 @@ func (s *server) SetupRoutes(r *gin.Engine) {
 	r.POST("/sonar/challenge-templates", middleware.WithAuthentication(s.authClient, s.livenessClient, s.createChallengeTemplate))
 	r.POST("/sonar/character-templates", middleware.WithAuthentication(s.authClient, s.livenessClient, s.createCharacterTemplate))
 	r.PUT("/sonar/challenges/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.updateChallenge))
-	r.PUT("/sonar/challenge-templates/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.updateChallengeTemplate))
-	r.PUT("/sonar/character-templates/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.updateCharacterTemplate))
+	r.PUT("/sonar/challenge-templates/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.withTemplateRevisions(models.TemplateRevisionTypeChallengeTemplate, s.updateChallengeTemplate)))
+	r.PUT("/sonar/character-templates/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.withTemplateRevisions(models.TemplateRevisionTypeCharacterTemplate, s.updateCharacterTemplate)))
 	r.PATCH("/sonar/challenges/:id/location", middleware.WithAuthentication(s.authClient, s.livenessClient, s.updateChallengeLocation))
 	r.POST("/sonar/challenges/:id/submit", middleware.WithAuthentication(s.authClient, s.livenessClient, s.submitStandaloneChallenge))
 	r.POST("/sonar/challenges/:id/rewards/choose-item", middleware.WithAuthenticationWithoutLocation(s.authClient, s.chooseChallengeItemChoiceReward))
@@ -822,7 +962,7 @@ func (s *server) SetupRoutes(r *gin.Engine) {
 	r.DELETE("/sonar/challenges/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.deleteChallenge))
 	r.DELETE("/sonar/challenge-templates/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.deleteChallengeTemplate))
 	r.DELETE("/sonar/character-templates/:id", middleware.WithAuthentication(s.authClient, s.livenessClient, s.deleteCharacterTemplate))
-	r.POST("/sonar/admin/treasure-chests/seed", middleware.WithAuthentication(s.authClient, s.livenessClient, s.seedTreasureChests))
+	admin.POST("/treasure-chests/seed", middleware.WithAuthentication(s.authClient, s.livenessClient, s.seedTreasureChests))
 }
 
 func (s *server) ListenAndServe(port string) {
@@ -3504,6 +3644,26 @@ func (s *server) generateQuestArchetypeChallenge(ctx *gin.Context) {
 		return
 	}
 
+	if requestBody.FailureUnlockedNodeID != nil && *requestBody.FailureUnlockedNodeID != uuid.Nil {
+		// Linking to an existing node is the only way a new challenge can
+		// close a cycle, so check it before anything is written.
+		catalog, err := contentvalidation.LoadCatalog(ctx, s.dbClient)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		issues := questArchetypeEditErrors(catalog, []uuid.UUID{questArchetypeNodeID}, func(catalog *models.ContentValidationCatalog) {
+			catalog.PutChallenge(questArchetypeNodeID, models.QuestArchetypeChallenge{
+				ID:                    uuid.New(),
+				FailureUnlockedNodeID: requestBody.FailureUnlockedNodeID,
+			})
+		})
+		if len(issues) > 0 {
+			respondWithContentValidationErrors(ctx, issues)
+			return
+		}
+	}
+
 	var newNodeID *uuid.UUID
 	if requestBody.hasExplicitConfig() {
 		id := uuid.New()
@@ -3692,6 +3852,25 @@ func (s *server) updateQuestArchetypeChallenge(ctx *gin.Context) {
 	}
 	existing.UpdatedAt = time.Now()
 
+	if requestBody.FailureUnlockedNodeID != nil {
+		catalog, err := contentvalidation.LoadCatalog(ctx, s.dbClient)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		edited := *existing
+		parentNodeIDs := catalog.NodeIDsForChallenge(edited.ID)
+		issues := questArchetypeEditErrors(catalog, parentNodeIDs, func(catalog *models.ContentValidationCatalog) {
+			for _, nodeID := range parentNodeIDs {
+				catalog.PutChallenge(nodeID, edited)
+			}
+		})
+		if len(issues) > 0 {
+			respondWithContentValidationErrors(ctx, issues)
+			return
+		}
+	}
+
 	if err := s.dbClient.QuestArchetypeChallenge().Update(ctx, existing); err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -3800,6 +3979,20 @@ func (s *server) updateQuestArchetypeNode(ctx *gin.Context) {
 	}
 	questArchetypeNode.UpdatedAt = time.Now()
 
+	catalog, err := contentvalidation.LoadCatalog(ctx, s.dbClient)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	edited := *questArchetypeNode
+	issues := questArchetypeEditErrors(catalog, []uuid.UUID{questArchetypeNodeID}, func(catalog *models.ContentValidationCatalog) {
+		catalog.PutNode(&edited)
+	})
+	if len(issues) > 0 {
+		respondWithContentValidationErrors(ctx, issues)
+		return
+	}
+
 	if err := s.dbClient.QuestArchetypeNode().Update(ctx, questArchetypeNode); err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -3844,6 +4037,8 @@ func (s *server) updateQuestArchetype(ctx *gin.Context) {
 		ClosurePolicy                  string                              `json:"closurePolicy"`
 		DebriefPolicy                  string                              `json:"debriefPolicy"`
 		ReturnBonusGold                *int                                `json:"returnBonusGold"`
+		MaxWalkingDistanceMeters       *int                                `json:"maxWalkingDistanceMeters"`
+		MaxWalkingMinutes              *int                                `json:"maxWalkingMinutes"`
 		ReturnBonusExperience          *int                                `json:"returnBonusExperience"`
 		ReturnBonusRelationshipEffects *models.CharacterRelationshipState  `json:"returnBonusRelationshipEffects"`
 		AcceptanceDialogue             []models.DialogueMessage            `json:"acceptanceDialogue"`
@@ -3884,6 +4079,14 @@ func (s *server) updateQuestArchetype(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "returnBonusGold must be zero or greater"})
 		return
 	}
+	if requestBody.MaxWalkingDistanceMeters != nil && *requestBody.MaxWalkingDistanceMeters < 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "maxWalkingDistanceMeters must be zero or greater"})
+		return
+	}
+	if requestBody.MaxWalkingMinutes != nil && *requestBody.MaxWalkingMinutes < 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "maxWalkingMinutes must be zero or greater"})
+		return
+	}
 	if requestBody.ReturnBonusExperience != nil && *requestBody.ReturnBonusExperience < 0 {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "returnBonusExperience must be zero or greater"})
 		return
@@ -3986,6 +4189,12 @@ func (s *server) updateQuestArchetype(ctx *gin.Context) {
 	if requestBody.ReturnBonusGold != nil {
 		questArchetype.ReturnBonusGold = *requestBody.ReturnBonusGold
 	}
+	if requestBody.MaxWalkingDistanceMeters != nil {
+		questArchetype.MaxWalkingDistanceMeters = *requestBody.MaxWalkingDistanceMeters
+	}
+	if requestBody.MaxWalkingMinutes != nil {
+		questArchetype.MaxWalkingMinutes = *requestBody.MaxWalkingMinutes
+	}
 	if requestBody.ReturnBonusExperience != nil {
 		questArchetype.ReturnBonusExperience = *requestBody.ReturnBonusExperience
 	}
@@ -4042,6 +4251,7 @@ func (s *server) updateQuestArchetype(ctx *gin.Context) {
 		ctx.JSON(http.StatusOK, questArchetype)
 		return
 	}
+	s.attachQuestArchetypeValidationIssues(ctx, updated)
 	ctx.JSON(http.StatusOK, updated)
 }
 
@@ -4406,6 +4616,11 @@ func (s *server) getQuests(ctx *gin.Context) {
 		}
 		visibleQuests = append(visibleQuests, quest)
 	}
+	localized := make([]*models.Quest, 0, len(visibleQuests))
+	for i := range visibleQuests {
+		localized = append(localized, &visibleQuests[i])
+	}
+	s.localizeQuests(ctx, localized...)
 	ctx.JSON(http.StatusOK, visibleQuests)
 }
 
@@ -4443,6 +4658,7 @@ func (s *server) getQuest(ctx *gin.Context) {
 		ctx.JSON(http.StatusNotFound, gin.H{"error": "quest not found"})
 		return
 	}
+	s.localizeQuests(ctx, quest)
 	ctx.JSON(http.StatusOK, quest)
 }
 
@@ -5190,6 +5406,8 @@ func (s *server) createQuestArchetype(ctx *gin.Context) {
 		ClosurePolicy                  string                              `json:"closurePolicy"`
 		DebriefPolicy                  string                              `json:"debriefPolicy"`
 		ReturnBonusGold                *int                                `json:"returnBonusGold"`
+		MaxWalkingDistanceMeters       *int                                `json:"maxWalkingDistanceMeters"`
+		MaxWalkingMinutes              *int                                `json:"maxWalkingMinutes"`
 		ReturnBonusExperience          *int                                `json:"returnBonusExperience"`
 		ReturnBonusRelationshipEffects *models.CharacterRelationshipState  `json:"returnBonusRelationshipEffects"`
 		AcceptanceDialogue             []models.DialogueMessage            `json:"acceptanceDialogue"`
@@ -5231,6 +5449,14 @@ func (s *server) createQuestArchetype(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "returnBonusGold must be zero or greater"})
 		return
 	}
+	if requestBody.MaxWalkingDistanceMeters != nil && *requestBody.MaxWalkingDistanceMeters < 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "maxWalkingDistanceMeters must be zero or greater"})
+		return
+	}
+	if requestBody.MaxWalkingMinutes != nil && *requestBody.MaxWalkingMinutes < 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "maxWalkingMinutes must be zero or greater"})
+		return
+	}
 	if requestBody.ReturnBonusExperience != nil && *requestBody.ReturnBonusExperience < 0 {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "returnBonusExperience must be zero or greater"})
 		return
@@ -5334,6 +5560,12 @@ func (s *server) createQuestArchetype(ctx *gin.Context) {
 	if requestBody.ReturnBonusGold != nil {
 		questArchType.ReturnBonusGold = *requestBody.ReturnBonusGold
 	}
+	if requestBody.MaxWalkingDistanceMeters != nil {
+		questArchType.MaxWalkingDistanceMeters = *requestBody.MaxWalkingDistanceMeters
+	}
+	if requestBody.MaxWalkingMinutes != nil {
+		questArchType.MaxWalkingMinutes = *requestBody.MaxWalkingMinutes
+	}
 	if requestBody.ReturnBonusExperience != nil {
 		questArchType.ReturnBonusExperience = *requestBody.ReturnBonusExperience
 	}
@@ -5341,6 +5573,19 @@ func (s *server) createQuestArchetype(ctx *gin.Context) {
 		questArchType.ReturnBonusRelationshipEffects = normalizeCharacterRelationshipState(*requestBody.ReturnBonusRelationshipEffects)
 	}
 
+	catalog, err := contentvalidation.LoadCatalog(ctx, s.dbClient)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	issues := questArchetypeEditErrors(catalog, []uuid.UUID{questArchType.RootID}, func(catalog *models.ContentValidationCatalog) {
+		catalog.PutQuestArchetype(questArchType)
+	})
+	if len(issues) > 0 {
+		respondWithContentValidationErrors(ctx, issues)
+		return
+	}
+
 	err = s.dbClient.QuestArchetype().Create(ctx, questArchType)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -5369,6 +5614,7 @@ func (s *server) createQuestArchetype(ctx *gin.Context) {
 		ctx.JSON(http.StatusOK, questArchType)
 		return
 	}
+	s.attachQuestArchetypeValidationIssues(ctx, created)
 	ctx.JSON(http.StatusOK, created)
 }
 
@@ -6105,6 +6351,7 @@ func (s *server) discoverZone(ctx *gin.Context) {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	s.evaluateAchievements(ctx, user.ID, models.AchievementMetricZonesDiscovered, &zoneID)
 
 	plan, _, _, err := s.randomRewardPlanForUser(
 		ctx,
@@ -6342,6 +6589,9 @@ func parseDistrictZoneIDs(rawIDs []string) ([]uuid.UUID, error) {
 func (s *server) importZonesForMetro(ctx *gin.Context) {
 	var requestBody struct {
 		MetroName string `json:"metroName"`
+		// OSMExtractPath is a .osm.pbf file on the job runner's disk. When
+		// set, boundaries are read from it instead of Nominatim/Overpass.
+		OSMExtractPath string `json:"osmExtractPath"`
 	}
 
 	if err := ctx.Bind(&requestBody); err != nil {
@@ -6361,6 +6611,15 @@ func (s *server) importZonesForMetro(ctx *gin.Context) {
 		MetroName: metroName,
 		Status:    "queued",
 		ZoneCount: 0,
+		Source:    models.ZoneImportSourceOverpass,
+	}
+	if extractPath := strings.TrimSpace(requestBody.OSMExtractPath); extractPath != "" {
+		if !strings.HasSuffix(extractPath, ".osm.pbf") {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "osmExtractPath must point to a .osm.pbf file"})
+			return
+		}
+		importItem.Source = models.ZoneImportSourceOSMExtract
+		importItem.OSMExtractPath = &extractPath
 	}
 	if err := s.dbClient.ZoneImport().Create(ctx, importItem); err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -6913,6 +7172,9 @@ func (s *server) approveZoneSeedJob(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "zone seed job is not awaiting approval"})
 		return
 	}
+	if !s.requireContentModerationApproval(ctx, models.ModeratedContentTypeZoneSeedDraft, job.ID) {
+		return
+	}
 
 	job.Status = models.ZoneSeedStatusApproved
 	job.UpdatedAt = time.Now()
@@ -7181,6 +7443,9 @@ func (s *server) deleteZoneSeedJob(ctx *gin.Context) {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	if err := s.dbClient.ContentModeration().DeleteByContent(ctx, models.ModeratedContentTypeZoneSeedDraft, job.ID); err != nil {
+		log.Printf("[moderation][zone-seed] failed to remove queue entry job_id=%s err=%v", job.ID, err)
+	}
 
 	ctx.JSON(http.StatusOK, gin.H{"deleted": true})
 }
@@ -9846,6 +10111,11 @@ func (s *server) getAllInventoryItems(ctx *gin.Context) {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	localized := make([]*models.InventoryItem, 0, len(items))
+	for i := range items {
+		localized = append(localized, &items[i])
+	}
+	s.localizeInventoryItems(ctx, localized...)
 	ctx.JSON(http.StatusOK, items)
 }
 
@@ -9866,6 +10136,7 @@ func (s *server) getInventoryItem(ctx *gin.Context) {
 		ctx.JSON(http.StatusNotFound, gin.H{"error": "inventory item not found"})
 		return
 	}
+	s.localizeInventoryItems(ctx, item)
 
 	ctx.JSON(http.StatusOK, item)
 }
@@ -12555,11 +12826,17 @@ func (s *server) submitStandaloneChallenge(ctx *gin.Context) {
 			}
 		}
 	}
+	challengeZoneID := challenge.ZoneID
 	for _, participantID := range participantIDs {
 		if err := s.dbClient.Challenge().UpsertCompletion(ctx, participantID, challenge.ID); err != nil {
 			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
+		s.evaluateAchievements(ctx, participantID, models.AchievementMetricChallengeCompletions, &challengeZoneID)
+		s.trackBountyEvent(ctx, participantID, models.BountyEvent{
+			ObjectiveType: models.BountyObjectiveCompleteChallenges,
+			ZoneID:        challenge.ZoneID,
+		})
 	}
 
 	response := map[string]interface{}{
@@ -12997,7 +13274,7 @@ func (s *server) submitQuestNode(ctx *gin.Context) {
 		return
 	}
 	if shouldAward {
-		s.shareQuestNodeCompletionWithEligiblePartyMembers(ctx, user, quest, node)
+		s.shareQuestNodeCompletion(ctx, user, quest, node)
 	}
 
 	completed, err := s.questlogClient.AreQuestObjectivesComplete(ctx, user.ID, quest.ID)
@@ -13551,12 +13828,7 @@ func (s *server) login(ctx *gin.Context) {
 		return
 	}
 
-	payload := gin.H{
-		"user":  authenticateResponse.User,
-		"token": authenticateResponse.Token,
-	}
-
-	ctx.JSON(200, payload)
+	ctx.JSON(200, authenticateResponse)
 }
 
 func (s *server) newSurvey(ctx *gin.Context) {
@@ -13629,10 +13901,7 @@ func (s *server) register(ctx *gin.Context) {
 		return
 	}
 
-	ctx.JSON(200, gin.H{
-		"user":  authenticateResponse.User,
-		"token": authenticateResponse.Token,
-	})
+	ctx.JSON(200, authenticateResponse)
 }
 
 func (s *server) createNeighbor(c *gin.Context) {
@@ -14263,133 +14532,8 @@ func (s *server) deleteUser(ctx *gin.Context) {
 		return
 	}
 
-	// Delete all dependent data before deleting the user
-	// 1. Delete all discoveries
-	if err := s.dbClient.PointOfInterestDiscovery().DeleteByUserID(ctx, userID); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete discoveries: " + err.Error()})
-		return
-	}
-	if err := s.dbClient.ZoneDiscovery().DeleteByUserID(ctx, userID); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete zone discoveries: " + err.Error()})
-		return
-	}
-
-	// 2. Delete all submissions
-	if err := s.dbClient.PointOfInterestChallenge().DeleteAllSubmissionsForUser(ctx, userID); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete submissions: " + err.Error()})
-		return
-	}
-
-	// 3. Delete all activities
-	if err := s.dbClient.Activity().DeleteAllForUser(ctx, userID); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete activities: " + err.Error()})
-		return
-	}
-
-	// 4. Delete all tracked point of interest groups
-	if err := s.dbClient.TrackedQuest().DeleteAllForUser(ctx, userID); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete tracked groups: " + err.Error()})
-		return
-	}
-
-	// 5. Delete all friend relationships
-	if err := s.dbClient.Friend().DeleteAllForUser(ctx, userID); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete friends: " + err.Error()})
-		return
-	}
-
-	// 6. Delete all friend invites
-	if err := s.dbClient.FriendInvite().DeleteAllForUser(ctx, userID); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete friend invites: " + err.Error()})
-		return
-	}
-
-	// 7. Delete all party invites
-	if err := s.dbClient.PartyInvite().DeleteAllForUser(ctx, userID); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete party invites: " + err.Error()})
-		return
-	}
-
-	// 8. Delete all image generations
-	if err := s.dbClient.ImageGeneration().DeleteAllForUser(ctx, userID); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete image generations: " + err.Error()})
-		return
-	}
-
-	// 9. Delete all audit items
-	if err := s.dbClient.AuditItem().DeleteAllForUser(ctx, userID); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete audit items: " + err.Error()})
-		return
-	}
-
-	// 10. Delete all how many answers
-	if err := s.dbClient.HowManyAnswer().DeleteAllForUser(ctx, userID); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete how many answers: " + err.Error()})
-		return
-	}
-
-	// 11. Delete all how many subscriptions
-	if err := s.dbClient.HowManySubscription().DeleteAllForUser(ctx, userID); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete how many subscriptions: " + err.Error()})
-		return
-	}
-
-	// 12. Delete all sonar survey submissions
-	if err := s.dbClient.SonarSurveySubmission().DeleteAllForUser(ctx, userID); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete sonar survey submissions: " + err.Error()})
-		return
-	}
-
-	// 13. Delete all match users
-	if err := s.dbClient.MatchUser().DeleteAllForUser(ctx, userID); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete match users: " + err.Error()})
-		return
-	}
-
-	// 14. Delete all user levels
-	if err := s.dbClient.UserLevel().DeleteAllForUser(ctx, userID); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete user levels: " + err.Error()})
-		return
-	}
-
-	// 15. Delete all user zone reputations
-	if err := s.dbClient.UserZoneReputation().DeleteAllForUser(ctx, userID); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete user zone reputations: " + err.Error()})
-		return
-	}
-
-	// 16. Delete all user proficiencies
-	if err := s.dbClient.UserProficiency().DeleteAllForUser(ctx, userID); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete user proficiencies: " + err.Error()})
-		return
-	}
-
-	// 17. Delete all user statuses
-	if err := s.dbClient.UserStatus().DeleteAllForUser(ctx, userID); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete user statuses: " + err.Error()})
-		return
-	}
-
-	// 18. Delete all owned inventory items
-	if err := s.dbClient.InventoryItem().DeleteAllForUser(ctx, userID); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete owned inventory items: " + err.Error()})
-		return
-	}
-
-	// 19. Delete all user team relationships
-	if err := s.dbClient.UserTeam().DeleteAllForUser(ctx, userID); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete user team relationships: " + err.Error()})
-		return
-	}
-
-	// 20. Delete all parties where user is leader
-	if err := s.dbClient.Party().DeleteAllForUser(ctx, userID); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete parties: " + err.Error()})
-		return
-	}
-
-	// 21. Finally, delete the user
-	if err := s.dbClient.User().Delete(ctx, userID); err != nil {
+	// Each product declares what deleting a user does to its tables.
+	if err := s.dbClient.UserData().Delete(ctx, userID); err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -14807,6 +14951,7 @@ func (s *server) adminGrantUserLevelUp(ctx *gin.Context) {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to restore resources: " + err.Error()})
 		return
 	}
+	s.evaluateAchievements(ctx, userID, models.AchievementMetricUserLevel, nil)
 
 	activityData, err := json.Marshal(models.LevelUpActivity{
 		NewLevel: updatedLevel.Level,
@@ -15172,6 +15317,9 @@ func (s *server) adminDiscoverAllZonesForUser(ctx *gin.Context) {
 		existingByZone[zone.ID] = struct{}{}
 		createdCount++
 	}
+	if createdCount > 0 {
+		s.evaluateAchievements(ctx, userID, models.AchievementMetricZonesDiscovered, nil)
+	}
 
 	ctx.JSON(http.StatusOK, gin.H{
 		"message":         fmt.Sprintf("Discovered %d additional zones without granting rewards or experience.", createdCount),
@@ -15254,75 +15402,8 @@ func (s *server) deleteUsers(ctx *gin.Context) {
 		return
 	}
 
-	// Delete each user with all their dependencies
 	for _, userID := range requestBody.UserIDs {
-		// Delete all dependent data for this user
-		// 1. Delete all discoveries
-		if err := s.dbClient.PointOfInterestDiscovery().DeleteByUserID(ctx, userID); err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete discoveries for user " + userID.String() + ": " + err.Error()})
-			return
-		}
-		if err := s.dbClient.ZoneDiscovery().DeleteByUserID(ctx, userID); err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete zone discoveries for user " + userID.String() + ": " + err.Error()})
-			return
-		}
-
-		// 2. Delete all submissions
-		if err := s.dbClient.PointOfInterestChallenge().DeleteAllSubmissionsForUser(ctx, userID); err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete submissions for user " + userID.String() + ": " + err.Error()})
-			return
-		}
-
-		// 3. Delete all activities
-		if err := s.dbClient.Activity().DeleteAllForUser(ctx, userID); err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete activities for user " + userID.String() + ": " + err.Error()})
-			return
-		}
-
-		// 4. Delete all tracked point of interest groups
-		if err := s.dbClient.TrackedQuest().DeleteAllForUser(ctx, userID); err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete tracked groups for user " + userID.String() + ": " + err.Error()})
-			return
-		}
-
-		// 5. Delete all friend relationships
-		if err := s.dbClient.Friend().DeleteAllForUser(ctx, userID); err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete friends for user " + userID.String() + ": " + err.Error()})
-			return
-		}
-
-		// 6. Delete all friend invites
-		if err := s.dbClient.FriendInvite().DeleteAllForUser(ctx, userID); err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete friend invites for user " + userID.String() + ": " + err.Error()})
-			return
-		}
-
-		// 7. Delete all party invites
-		if err := s.dbClient.PartyInvite().DeleteAllForUser(ctx, userID); err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete party invites for user " + userID.String() + ": " + err.Error()})
-			return
-		}
-
-		// 8. Delete all image generations
-		if err := s.dbClient.ImageGeneration().DeleteAllForUser(ctx, userID); err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete image generations for user " + userID.String() + ": " + err.Error()})
-			return
-		}
-
-		// 9. Delete all user proficiencies
-		if err := s.dbClient.UserProficiency().DeleteAllForUser(ctx, userID); err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete user proficiencies for user " + userID.String() + ": " + err.Error()})
-			return
-		}
-
-		// 10. Delete all user statuses
-		if err := s.dbClient.UserStatus().DeleteAllForUser(ctx, userID); err != nil {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete user statuses for user " + userID.String() + ": " + err.Error()})
-			return
-		}
-
-		// 11. Finally, delete the user
-		if err := s.dbClient.User().Delete(ctx, userID); err != nil {
+		if err := s.dbClient.UserData().Delete(ctx, userID); err != nil {
 			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete user " + userID.String() + ": " + err.Error()})
 			return
 		}
@@ -15399,6 +15480,7 @@ func (s *server) getCharacters(ctx *gin.Context) {
 		}
 		visibleCharacters = append(visibleCharacters, character)
 	}
+	s.localizeCharacters(ctx, visibleCharacters...)
 
 	ctx.JSON(http.StatusOK, visibleCharacters)
 }
@@ -15461,6 +15543,7 @@ func (s *server) getCharacter(ctx *gin.Context) {
 		availability[character.ID].HasAvailableMainStoryQuest
 	applyCharacterStoryVariant(character, activeStoryFlags)
 	applyCharacterRelationship(character, relationshipMap)
+	s.localizeCharacters(ctx, character)
 
 	ctx.JSON(http.StatusOK, character)
 }
@@ -17462,6 +17545,13 @@ func (s *server) openTreasureChest(ctx *gin.Context) {
 	}
 	if err := s.dbClient.TreasureChest().CreateUserTreasureChestOpening(ctx, opening); err != nil {
 		// Log error but don't fail the request
+	} else {
+		chestZoneID := treasureChest.ZoneID
+		s.evaluateAchievements(ctx, user.ID, models.AchievementMetricTreasureChestsOpened, &chestZoneID)
+		s.trackBountyEvent(ctx, user.ID, models.BountyEvent{
+			ObjectiveType: models.BountyObjectiveOpenTreasureChests,
+			ZoneID:        treasureChest.ZoneID,
+		})
 	}
 
 	// Fetch updated user
@@ -19083,6 +19173,7 @@ func (s *server) awardScenarioRewards(
 			if _, err := s.dbClient.UserCharacterStats().RestoreResourcesToFull(ctx, userID); err != nil {
 				return nil, nil, err
 			}
+			s.evaluateAchievements(ctx, userID, models.AchievementMetricUserLevel, nil)
 		}
 	}
 
@@ -19206,6 +19297,11 @@ func (s *server) getScenarios(ctx *gin.Context) {
 			AttemptedByUser: attemptedMap[scenario.ID],
 		})
 	}
+	localized := make([]*models.Scenario, 0, len(response))
+	for i := range response {
+		localized = append(localized, &response[i].Scenario)
+	}
+	s.localizeScenarios(ctx, localized...)
 	ctx.JSON(http.StatusOK, response)
 }
 
@@ -19251,6 +19347,42 @@ func (s *server) getAdminScenarios(ctx *gin.Context) {
 	})
 }
 
+// getAdminScenario returns a scenario whatever its moderation status, for
+// editing; players get it from getScenario once it's approved.
+func (s *server) getAdminScenario(ctx *gin.Context) {
+	user, err := s.getAuthenticatedUser(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	scenarioID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid scenario ID"})
+		return
+	}
+
+	scenario, err := s.dbClient.Scenario().FindByID(ctx, scenarioID)
+	if err != nil {
+		if stdErrors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "scenario not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	attempt, err := s.dbClient.Scenario().FindAttemptByUserAndScenario(ctx, user.ID, scenarioID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, scenarioWithUserStatus{
+		Scenario:        *scenario,
+		AttemptedByUser: attempt != nil,
+	})
+}
+
 func (s *server) getScenario(ctx *gin.Context) {
 	user, err := s.getAuthenticatedUser(ctx)
 	if err != nil {
@@ -19265,7 +19397,7 @@ func (s *server) getScenario(ctx *gin.Context) {
 		return
 	}
 
-	scenario, err := s.dbClient.Scenario().FindByID(ctx, scenarioID)
+	scenario, err := s.dbClient.Scenario().FindApprovedByID(ctx, scenarioID)
 	if err != nil {
 		if stdErrors.Is(err, gorm.ErrRecordNotFound) {
 			ctx.JSON(http.StatusNotFound, gin.H{"error": "scenario not found"})
@@ -19308,6 +19440,7 @@ func (s *server) getScenario(ctx *gin.Context) {
 	); markerURL != "" {
 		scenarioWithMarker.ThumbnailURL = markerURL
 	}
+	s.localizeScenarios(ctx, &scenarioWithMarker)
 
 	ctx.JSON(http.StatusOK, scenarioWithUserStatus{
 		Scenario:        scenarioWithMarker,
@@ -19619,7 +19752,7 @@ func (s *server) performScenario(ctx *gin.Context) {
 		return
 	}
 
-	scenario, err := s.dbClient.Scenario().FindByID(ctx, scenarioID)
+	scenario, err := s.dbClient.Scenario().FindApprovedByID(ctx, scenarioID)
 	if err != nil {
 		if stdErrors.Is(err, gorm.ErrRecordNotFound) {
 			ctx.JSON(http.StatusNotFound, gin.H{"error": "scenario not found"})
@@ -20135,6 +20268,9 @@ func (s *server) performScenario(ctx *gin.Context) {
 			return
 		}
 	}
+	if success {
+		s.trackScenarioBountyEvent(ctx, participantIDs, scenario)
+	}
 	if isTutorialScenario {
 		requiredEquipItemIDs, requiredUseItemIDs, err := s.tutorialLoadoutRequirementItemIDs(
 			ctx,
@@ -20179,7 +20315,7 @@ func (s *server) performScenario(ctx *gin.Context) {
 				continue
 			}
 			sharedQuestNodeIDs[target.Node.ID] = struct{}{}
-			s.shareQuestNodeCompletionWithEligiblePartyMembers(
+			s.shareQuestNodeCompletion(
 				ctx,
 				user,
 				target.Quest,
@@ -0,0 +1,123 @@
+package server
+
+import (
+	stdErrors "errors"
+	"net/http"
+	"strings"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/auth"
+	pkghttp "github.com/MaxBlaushild/poltergeist/pkg/http"
+	"github.com/MaxBlaushild/poltergeist/pkg/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// authenticatorStatus passes the authenticator's status through so clients
+// can tell an expired or revoked session (401) from an outage.
+func authenticatorStatus(err error) int {
+	var statusErr *pkghttp.StatusError
+	if stdErrors.As(err, &statusErr) {
+		return statusErr.StatusCode
+	}
+	return http.StatusBadGateway
+}
+
+func bearerToken(ctx *gin.Context) string {
+	return strings.TrimPrefix(ctx.GetHeader("Authorization"), "Bearer ")
+}
+
+func (s *server) refreshToken(ctx *gin.Context) {
+	var requestBody auth.RefreshTokenRequest
+	if err := ctx.Bind(&requestBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	authenticateResponse, err := s.authClient.RefreshToken(ctx, &requestBody)
+	if err != nil {
+		ctx.JSON(authenticatorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, authenticateResponse)
+}
+
+// exchangeLegacyToken lets app builds from before sessions trade their
+// non-expiring token for an access and refresh token pair.
+func (s *server) exchangeLegacyToken(ctx *gin.Context) {
+	var requestBody auth.VerifyTokenRequest
+	if err := ctx.Bind(&requestBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	authenticateResponse, err := s.authClient.ExchangeLegacyToken(ctx, &requestBody)
+	if err != nil {
+		ctx.JSON(authenticatorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, authenticateResponse)
+}
+
+func (s *server) getSessions(ctx *gin.Context) {
+	sessions, err := s.authClient.GetSessions(ctx, &auth.VerifyTokenRequest{Token: bearerToken(ctx)})
+	if err != nil {
+		ctx.JSON(authenticatorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, sessions)
+}
+
+func (s *server) revokeSession(ctx *gin.Context) {
+	sessionID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid session ID"})
+		return
+	}
+
+	if err := s.authClient.RevokeSession(ctx, &auth.RevokeSessionRequest{
+		Token:     bearerToken(ctx),
+		SessionID: sessionID,
+	}); err != nil {
+		ctx.JSON(authenticatorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "ok"})
+}
+
+func (s *server) revokeAllSessions(ctx *gin.Context) {
+	var requestBody struct {
+		KeepCurrent bool `json:"keepCurrent"`
+	}
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&requestBody); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	response, err := s.authClient.RevokeAllSessions(ctx, &auth.RevokeAllSessionsRequest{
+		Token:       bearerToken(ctx),
+		KeepCurrent: requestBody.KeepCurrent,
+	})
+	if err != nil {
+		ctx.JSON(authenticatorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// getAuthMetrics reports how often requests were authenticated locally and
+// how well the user cache is doing.
+func (s *server) getAuthMetrics(ctx *gin.Context) {
+	if _, err := s.getAuthenticatedUser(ctx); err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, middleware.GetAuthVerifierStats())
+}
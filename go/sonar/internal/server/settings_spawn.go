@@ -8,6 +8,7 @@ import (
 	"math"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/MaxBlaushild/poltergeist/pkg/jobs"
 	"github.com/MaxBlaushild/poltergeist/pkg/models"
@@ -17,12 +18,43 @@ import (
 	"github.com/hibiken/asynq"
 )
 
-var nearbyScenarioPrompts = []string{
-	"A faint shimmer appears between the cracks in the pavement. Describe how you investigate it.",
-	"A local spirit leaves a coded message nearby. Explain how you decipher it.",
-	"A hidden stash has been disturbed. Describe your approach to securing the area.",
-	"You notice fresh signs of arcane activity. Explain how you track down the source.",
-	"A whispering relic surfaces near you. Describe how you safely handle it.",
+// nearbyScenarioPrompt is a prompt for a spontaneous scenario. Kind is what
+// spawn rules target when they weight scenarios.
+type nearbyScenarioPrompt struct {
+	Kind   string
+	Prompt string
+}
+
+var nearbyScenarioPrompts = []nearbyScenarioPrompt{
+	{Kind: "investigation", Prompt: "A faint shimmer appears between the cracks in the pavement. Describe how you investigate it."},
+	{Kind: "spirit", Prompt: "A local spirit leaves a coded message nearby. Explain how you decipher it."},
+	{Kind: "security", Prompt: "A hidden stash has been disturbed. Describe your approach to securing the area."},
+	{Kind: "arcane", Prompt: "You notice fresh signs of arcane activity. Explain how you track down the source."},
+	{Kind: "relic", Prompt: "A whispering relic surfaces near you. Describe how you safely handle it."},
+}
+
+const fallbackNearbyScenarioPrompt = "A strange opportunity appears nearby. Describe what you do."
+
+func isNearbyScenarioKind(kind string) bool {
+	for _, prompt := range nearbyScenarioPrompts {
+		if prompt.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// chooseNearbyScenarioPrompt picks a prompt weighted by the spawn rules.
+func chooseNearbyScenarioPrompt(rules []models.SpawnRule, conditions models.SpawnConditions) nearbyScenarioPrompt {
+	weights := make([]float64, len(nearbyScenarioPrompts))
+	for idx, prompt := range nearbyScenarioPrompts {
+		weights[idx] = models.SpawnWeight(rules, conditions, models.SpawnCandidate{ScenarioKind: prompt.Kind})
+	}
+	choice := weightedSpawnChoice(weights)
+	if choice < 0 {
+		return nearbyScenarioPrompt{Prompt: fallbackNearbyScenarioPrompt}
+	}
+	return nearbyScenarioPrompts[choice]
 }
 
 var fallbackNearbyMonsterNames = []string{
@@ -57,12 +89,16 @@ func (s *server) spawnNearbyScenarioAndMonster(ctx *gin.Context) {
 		return
 	}
 
+	spawnConditions := s.spawnConditionsForZone(ctx, zone, userLat, userLng, time.Now())
+	spawnRules := s.activeSpawnRules(ctx)
+	scenarioPrompt := chooseNearbyScenarioPrompt(spawnRules, spawnConditions)
+
 	scenarioLat, scenarioLng := randomPointNear(userLat, userLng, scenarioInteractRadiusMeters)
 	scenario := &models.Scenario{
 		ZoneID:                    zone.ID,
 		Latitude:                  scenarioLat,
 		Longitude:                 scenarioLng,
-		Prompt:                    randomStringChoice(nearbyScenarioPrompts, "A strange opportunity appears nearby. Describe what you do."),
+		Prompt:                    scenarioPrompt.Prompt,
 		ImageURL:                  poiPlaceholderImageURL,
 		ThumbnailURL:              scenarioUndiscoveredIconKey,
 		ScaleWithUserLevel:        true,
@@ -107,7 +143,7 @@ func (s *server) spawnNearbyScenarioAndMonster(ctx *gin.Context) {
 		log.Printf("spawnNearbyScenarioAndMonster: failed to queue scenario image generation for %s: %v", scenario.ID, err)
 	}
 
-	monsterSeed, err := s.randomMonsterSeedForZone(ctx, zone.ID)
+	monsterSeed, err := s.randomMonsterSeedForZone(ctx, zone.ID, spawnRules, spawnConditions)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -168,6 +204,7 @@ func (s *server) spawnNearbyScenarioAndMonster(ctx *gin.Context) {
 			"distanceMeters":     util.HaversineDistance(userLat, userLng, scenario.Latitude, scenario.Longitude),
 			"scaleWithUserLevel": scenario.ScaleWithUserLevel,
 			"imageQueued":        scenarioImageQueued,
+			"kind":               scenarioPrompt.Kind,
 		},
 		"monster": gin.H{
 			"id":             monster.ID,
@@ -183,6 +220,11 @@ func (s *server) spawnNearbyScenarioAndMonster(ctx *gin.Context) {
 			"distanceMeters":     util.HaversineDistance(userLat, userLng, encounter.Latitude, encounter.Longitude),
 			"scaleWithUserLevel": encounter.ScaleWithUserLevel,
 		},
+		"spawnConditions": gin.H{
+			"localTime": spawnConditions.LocalTime.Format(time.RFC3339),
+			"moonPhase": spawnConditions.MoonPhase,
+			"weather":   spawnConditions.Weather,
+		},
 	})
 }
 
@@ -201,7 +243,15 @@ func (s *server) enqueueScenarioImageGenerationTask(scenarioID uuid.UUID) error
 	return err
 }
 
-func (s *server) randomMonsterSeedForZone(ctx context.Context, zoneID uuid.UUID) (*models.Monster, error) {
+// randomMonsterSeedForZone picks an existing monster to clone for a spawn,
+// weighted by the spawn rules in effect. It returns nil when nothing can
+// spawn, e.g. when every candidate is night-only and it's daytime.
+func (s *server) randomMonsterSeedForZone(
+	ctx context.Context,
+	zoneID uuid.UUID,
+	rules []models.SpawnRule,
+	conditions models.SpawnConditions,
+) (*models.Monster, error) {
 	monsters, err := s.dbClient.Monster().FindByZoneIDExcludingQuestNodes(ctx, zoneID)
 	if err != nil {
 		return nil, err
@@ -215,7 +265,14 @@ func (s *server) randomMonsterSeedForZone(ctx context.Context, zoneID uuid.UUID)
 	if len(monsters) == 0 {
 		return nil, nil
 	}
-	choice := secureRandomIntBetween(0, len(monsters)-1)
+	weights := make([]float64, len(monsters))
+	for idx := range monsters {
+		weights[idx] = models.SpawnWeight(rules, conditions, monsterSpawnCandidate(&monsters[idx]))
+	}
+	choice := weightedSpawnChoice(weights)
+	if choice < 0 {
+		return nil, nil
+	}
 	selected := monsters[choice]
 	return &selected, nil
 }
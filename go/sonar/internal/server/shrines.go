@@ -323,6 +323,8 @@ func (s *server) useShrine(ctx *gin.Context) {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	shrineZoneID := shrine.ZoneID
+	s.evaluateAchievements(ctx, user.ID, models.AchievementMetricShrineUses, &shrineZoneID)
 
 	cooldown := shrineCooldownDuration(shrine)
 	nextAvailableAt := now.Add(cooldown)
@@ -0,0 +1,355 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const defaultSpawnWeather = "clear"
+
+// spawnEnvironmentProvider reports live conditions, such as weather, that
+// spawn rules can key on. It's an interface so a real weather feed can be
+// plugged in without touching spawn selection.
+type spawnEnvironmentProvider interface {
+	Weather(ctx context.Context, latitude float64, longitude float64, at time.Time) (string, error)
+}
+
+// staticSpawnEnvironmentProvider reports the same weather everywhere.
+type staticSpawnEnvironmentProvider struct {
+	weather string
+}
+
+func newStaticSpawnEnvironmentProvider(weather string) spawnEnvironmentProvider {
+	return &staticSpawnEnvironmentProvider{weather: weather}
+}
+
+func (p *staticSpawnEnvironmentProvider) Weather(
+	ctx context.Context,
+	latitude float64,
+	longitude float64,
+	at time.Time,
+) (string, error) {
+	return p.weather, nil
+}
+
+type spawnRuleRequest struct {
+	Name             string     `json:"name"`
+	ZoneKind         string     `json:"zoneKind"`
+	GenreID          *uuid.UUID `json:"genreId"`
+	TargetType       string     `json:"targetType"`
+	TargetValue      string     `json:"targetValue"`
+	StartHour        *int       `json:"startHour"`
+	EndHour          *int       `json:"endHour"`
+	DaysOfWeek       []string   `json:"daysOfWeek"`
+	MoonPhases       []string   `json:"moonPhases"`
+	Weather          []string   `json:"weather"`
+	FestivalDates    []string   `json:"festivalDates"`
+	ActiveFrom       *time.Time `json:"activeFrom"`
+	ActiveUntil      *time.Time `json:"activeUntil"`
+	WeightMultiplier *float64   `json:"weightMultiplier"`
+	Required         bool       `json:"required"`
+	Active           *bool      `json:"active"`
+}
+
+func normalizeSpawnRuleList(values []string, fieldName string, valid func(string) bool) (models.StringArray, error) {
+	normalized := models.StringArray{}
+	for _, value := range values {
+		trimmed := strings.ToLower(strings.TrimSpace(value))
+		if trimmed == "" {
+			continue
+		}
+		if valid != nil && !valid(trimmed) {
+			return nil, fmt.Errorf("invalid %s value %q", fieldName, value)
+		}
+		normalized = append(normalized, trimmed)
+	}
+	return normalized, nil
+}
+
+func isValidSpawnRuleDayOfWeek(value string) bool {
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if strings.EqualFold(day.String(), value) {
+			return true
+		}
+	}
+	return false
+}
+
+func isValidSpawnRuleMoonPhase(value string) bool {
+	for _, phase := range models.MoonPhases {
+		if string(phase) == value {
+			return true
+		}
+	}
+	return false
+}
+
+func isValidSpawnRuleFestivalDate(value string) bool {
+	_, err := time.Parse("01-02", value)
+	return err == nil
+}
+
+func spawnRuleFromRequest(request spawnRuleRequest, rule *models.SpawnRule) error {
+	name := strings.TrimSpace(request.Name)
+	if name == "" {
+		return fmt.Errorf("name is required")
+	}
+	targetType, ok := models.NormalizeSpawnRuleTargetType(request.TargetType)
+	if !ok {
+		return fmt.Errorf("unknown spawn rule target type %q", request.TargetType)
+	}
+	targetValue := strings.TrimSpace(request.TargetValue)
+	switch targetType {
+	case models.SpawnRuleTargetMonsterTemplate:
+		templateID, err := uuid.Parse(targetValue)
+		if err != nil {
+			return fmt.Errorf("targetValue must be a monster template ID")
+		}
+		targetValue = templateID.String()
+	case models.SpawnRuleTargetMonsterAffinity:
+		if !models.IsValidDamageAffinity(targetValue) {
+			return fmt.Errorf("unknown monster affinity %q", targetValue)
+		}
+		targetValue = string(models.NormalizeDamageAffinity(targetValue))
+	case models.SpawnRuleTargetScenarioKind:
+		targetValue = strings.ToLower(targetValue)
+		if !isNearbyScenarioKind(targetValue) {
+			return fmt.Errorf("unknown scenario kind %q", targetValue)
+		}
+	}
+	if request.StartHour != nil && (*request.StartHour < 0 || *request.StartHour > 23) {
+		return fmt.Errorf("startHour must be between 0 and 23")
+	}
+	if request.EndHour != nil && (*request.EndHour < 0 || *request.EndHour > 24) {
+		return fmt.Errorf("endHour must be between 0 and 24")
+	}
+	if request.ActiveFrom != nil && request.ActiveUntil != nil && !request.ActiveUntil.After(*request.ActiveFrom) {
+		return fmt.Errorf("activeUntil must be after activeFrom")
+	}
+	weightMultiplier := 1.0
+	if request.WeightMultiplier != nil {
+		weightMultiplier = *request.WeightMultiplier
+	}
+	if weightMultiplier < 0 || math.IsNaN(weightMultiplier) || math.IsInf(weightMultiplier, 0) {
+		return fmt.Errorf("weightMultiplier must be 0 or greater")
+	}
+
+	daysOfWeek, err := normalizeSpawnRuleList(request.DaysOfWeek, "daysOfWeek", isValidSpawnRuleDayOfWeek)
+	if err != nil {
+		return err
+	}
+	moonPhases, err := normalizeSpawnRuleList(request.MoonPhases, "moonPhases", isValidSpawnRuleMoonPhase)
+	if err != nil {
+		return err
+	}
+	weather, err := normalizeSpawnRuleList(request.Weather, "weather", nil)
+	if err != nil {
+		return err
+	}
+	festivalDates, err := normalizeSpawnRuleList(request.FestivalDates, "festivalDates", isValidSpawnRuleFestivalDate)
+	if err != nil {
+		return err
+	}
+
+	rule.Name = name
+	rule.ZoneKind = models.NormalizeZoneKind(request.ZoneKind)
+	rule.GenreID = request.GenreID
+	rule.TargetType = targetType
+	rule.TargetValue = targetValue
+	rule.StartHour = request.StartHour
+	rule.EndHour = request.EndHour
+	rule.DaysOfWeek = daysOfWeek
+	rule.MoonPhases = moonPhases
+	rule.Weather = weather
+	rule.FestivalDates = festivalDates
+	rule.ActiveFrom = request.ActiveFrom
+	rule.ActiveUntil = request.ActiveUntil
+	rule.WeightMultiplier = weightMultiplier
+	rule.Required = request.Required
+	rule.Active = request.Active == nil || *request.Active
+	return nil
+}
+
+// spawnConditionsForZone gathers the time, moon and weather for a spawn at
+// the given coordinates. Lookups that fail fall back to neutral values so a
+// spawn is never blocked on them.
+func (s *server) spawnConditionsForZone(
+	ctx context.Context,
+	zone *models.Zone,
+	latitude float64,
+	longitude float64,
+	now time.Time,
+) models.SpawnConditions {
+	conditions := models.SpawnConditions{
+		Now:       now,
+		LocalTime: models.LocalSpawnTime(now, longitude),
+		MoonPhase: models.MoonPhaseAt(now),
+		Weather:   defaultSpawnWeather,
+		ZoneKind:  zone.Kind,
+	}
+
+	if s.spawnEnvironment != nil {
+		weather, err := s.spawnEnvironment.Weather(ctx, latitude, longitude, now)
+		if err != nil {
+			log.Printf("[spawn-rules] weather lookup failed zone=%s err=%v", zone.ID, err)
+		} else if trimmed := strings.ToLower(strings.TrimSpace(weather)); trimmed != "" {
+			conditions.Weather = trimmed
+		}
+	}
+
+	scores, err := s.dbClient.ZoneGenreScore().FindByZoneIDs(ctx, []uuid.UUID{zone.ID}, false)
+	if err != nil {
+		log.Printf("[spawn-rules] genre lookup failed zone=%s err=%v", zone.ID, err)
+	}
+	for _, score := range scores {
+		if score.Score > 0 {
+			conditions.GenreIDs = append(conditions.GenreIDs, score.GenreID)
+		}
+	}
+	return conditions
+}
+
+// activeSpawnRules loads the rules to apply to a spawn. A lookup failure
+// means spawning without modifiers rather than not spawning at all.
+func (s *server) activeSpawnRules(ctx context.Context) []models.SpawnRule {
+	rules, err := s.dbClient.SpawnRule().FindAll(ctx, false)
+	if err != nil {
+		log.Printf("[spawn-rules] failed to load rules err=%v", err)
+		return nil
+	}
+	return rules
+}
+
+func monsterSpawnCandidate(monster *models.Monster) models.SpawnCandidate {
+	return models.SpawnCandidate{
+		TemplateID: monster.TemplateID,
+		Affinities: monsterBoostedAffinities(monster),
+	}
+}
+
+// weightedSpawnChoice picks an index in proportion to weights. It returns -1
+// when nothing has a positive weight.
+func weightedSpawnChoice(weights []float64) int {
+	const resolution = 1000
+	scaled := make([]int, len(weights))
+	total := 0
+	for idx, weight := range weights {
+		if weight <= 0 {
+			continue
+		}
+		scaled[idx] = max(1, int(math.Round(weight*resolution)))
+		total += scaled[idx]
+	}
+	if total == 0 {
+		return -1
+	}
+	roll := secureRandomIntBetween(0, total-1)
+	for idx, weight := range scaled {
+		roll -= weight
+		if roll < 0 {
+			return idx
+		}
+	}
+	return len(weights) - 1
+}
+
+func (s *server) getSpawnRules(ctx *gin.Context) {
+	if _, err := s.getAuthenticatedUser(ctx); err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	rules, err := s.dbClient.SpawnRule().FindAll(ctx, true)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, rules)
+}
+
+func (s *server) createSpawnRule(ctx *gin.Context) {
+	if _, err := s.getAuthenticatedUser(ctx); err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var requestBody spawnRuleRequest
+	if err := ctx.ShouldBindJSON(&requestBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	rule := &models.SpawnRule{}
+	if err := spawnRuleFromRequest(requestBody, rule); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := s.dbClient.SpawnRule().Create(ctx, rule); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusCreated, rule)
+}
+
+func (s *server) updateSpawnRule(ctx *gin.Context) {
+	if _, err := s.getAuthenticatedUser(ctx); err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	ruleID, err := uuid.Parse(strings.TrimSpace(ctx.Param("id")))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid spawn rule ID"})
+		return
+	}
+	rule, err := s.dbClient.SpawnRule().FindByID(ctx, ruleID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if rule == nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "spawn rule not found"})
+		return
+	}
+
+	var requestBody spawnRuleRequest
+	if err := ctx.ShouldBindJSON(&requestBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := spawnRuleFromRequest(requestBody, rule); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := s.dbClient.SpawnRule().Update(ctx, rule); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, rule)
+}
+
+func (s *server) deleteSpawnRule(ctx *gin.Context) {
+	if _, err := s.getAuthenticatedUser(ctx); err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	ruleID, err := uuid.Parse(strings.TrimSpace(ctx.Param("id")))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid spawn rule ID"})
+		return
+	}
+	if err := s.dbClient.SpawnRule().Delete(ctx, ruleID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "spawn rule deleted"})
+}
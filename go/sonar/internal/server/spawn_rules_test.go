@@ -0,0 +1,78 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/google/uuid"
+)
+
+func TestWeightedSpawnChoiceSkipsZeroWeights(t *testing.T) {
+	if choice := weightedSpawnChoice([]float64{0, 0}); choice != -1 {
+		t.Fatalf("expected no choice when every weight is zero, got %d", choice)
+	}
+	for i := 0; i < 20; i++ {
+		if choice := weightedSpawnChoice([]float64{0, 0.5, 0}); choice != 1 {
+			t.Fatalf("expected the only positive weight to win, got %d", choice)
+		}
+	}
+}
+
+func TestChooseNearbyScenarioPromptHonorsRequiredRules(t *testing.T) {
+	rules := []models.SpawnRule{}
+	for _, prompt := range nearbyScenarioPrompts {
+		if prompt.Kind == "spirit" {
+			continue
+		}
+		rules = append(rules, models.SpawnRule{
+			TargetType:  models.SpawnRuleTargetScenarioKind,
+			TargetValue: prompt.Kind,
+			DaysOfWeek:  models.StringArray{"saturday"},
+			Required:    true,
+			Active:      true,
+		})
+	}
+	// A Thursday, so only the unrestricted spirit prompt can appear.
+	conditions := models.SpawnConditions{LocalTime: time.Date(2026, time.March, 12, 12, 0, 0, 0, time.UTC)}
+	for i := 0; i < 10; i++ {
+		if prompt := chooseNearbyScenarioPrompt(rules, conditions); prompt.Kind != "spirit" {
+			t.Fatalf("expected spirit prompt, got %q", prompt.Kind)
+		}
+	}
+}
+
+func TestSpawnRuleFromRequestValidation(t *testing.T) {
+	rule := &models.SpawnRule{}
+	templateID := uuid.New()
+	start := 21
+	if err := spawnRuleFromRequest(spawnRuleRequest{
+		Name:        "Night stalker",
+		TargetType:  "monster_template",
+		TargetValue: " " + templateID.String() + " ",
+		StartHour:   &start,
+		DaysOfWeek:  []string{"Friday", " "},
+		MoonPhases:  []string{"FULL"},
+		Required:    true,
+	}, rule); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rule.TargetValue != templateID.String() || rule.WeightMultiplier != 1 || !rule.Active {
+		t.Fatalf("expected normalized rule, got %+v", rule)
+	}
+	if len(rule.DaysOfWeek) != 1 || rule.DaysOfWeek[0] != "friday" || rule.MoonPhases[0] != "full" {
+		t.Fatalf("expected normalized lists, got %+v", rule)
+	}
+
+	invalid := []spawnRuleRequest{
+		{Name: "Bad kind", TargetType: "scenario_kind", TargetValue: "karaoke"},
+		{Name: "Bad day", TargetType: "monster_affinity", TargetValue: "fire", DaysOfWeek: []string{"funday"}},
+		{Name: "Bad date", TargetType: "monster_affinity", TargetValue: "fire", FestivalDates: []string{"13-01"}},
+		{Name: "Bad moon", TargetType: "monster_affinity", TargetValue: "fire", MoonPhases: []string{"blue"}},
+	}
+	for _, request := range invalid {
+		if err := spawnRuleFromRequest(request, &models.SpawnRule{}); err == nil {
+			t.Fatalf("expected %q to be rejected", request.Name)
+		}
+	}
+}
@@ -0,0 +1,811 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/db"
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// templateRevisionSource loads and restores one kind of revisioned template.
+// Snapshots are the template model marshalled to JSON, so restore is the
+// inverse of load. Both take the client to use so publishing can run them
+// inside a transaction.
+type templateRevisionSource struct {
+	load    func(ctx context.Context, dbClient db.DbClient, id uuid.UUID) (interface{}, error)
+	decode  func(snapshot []byte) error
+	restore func(ctx context.Context, dbClient db.DbClient, id uuid.UUID, snapshot []byte) error
+}
+
+func decodeTemplateSnapshot[T any](snapshot []byte) (*T, error) {
+	var template T
+	if err := json.Unmarshal(snapshot, &template); err != nil {
+		return nil, fmt.Errorf("invalid template snapshot: %w", err)
+	}
+	return &template, nil
+}
+
+func templateSnapshotDecoder[T any]() func([]byte) error {
+	return func(snapshot []byte) error {
+		_, err := decodeTemplateSnapshot[T](snapshot)
+		return err
+	}
+}
+
+// loadedTemplate keeps a typed nil pointer from turning into a non-nil
+// interface.
+func loadedTemplate[T any](template *T, err error) (interface{}, error) {
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if template == nil {
+		return nil, nil
+	}
+	return template, nil
+}
+
+func templateRevisionSourceFor(templateType models.TemplateRevisionType) (templateRevisionSource, bool) {
+	switch templateType {
+	case models.TemplateRevisionTypeScenarioTemplate:
+		return templateRevisionSource{
+			load: func(ctx context.Context, dbClient db.DbClient, id uuid.UUID) (interface{}, error) {
+				return loadedTemplate(dbClient.ScenarioTemplate().FindByID(ctx, id))
+			},
+			decode: templateSnapshotDecoder[models.ScenarioTemplate](),
+			restore: func(ctx context.Context, dbClient db.DbClient, id uuid.UUID, snapshot []byte) error {
+				template, err := decodeTemplateSnapshot[models.ScenarioTemplate](snapshot)
+				if err != nil {
+					return err
+				}
+				return dbClient.ScenarioTemplate().Update(ctx, id, template)
+			},
+		}, true
+	case models.TemplateRevisionTypeChallengeTemplate:
+		return templateRevisionSource{
+			load: func(ctx context.Context, dbClient db.DbClient, id uuid.UUID) (interface{}, error) {
+				return loadedTemplate(dbClient.ChallengeTemplate().FindByID(ctx, id))
+			},
+			decode: templateSnapshotDecoder[models.ChallengeTemplate](),
+			restore: func(ctx context.Context, dbClient db.DbClient, id uuid.UUID, snapshot []byte) error {
+				template, err := decodeTemplateSnapshot[models.ChallengeTemplate](snapshot)
+				if err != nil {
+					return err
+				}
+				return dbClient.ChallengeTemplate().Update(ctx, id, template)
+			},
+		}, true
+	case models.TemplateRevisionTypeExpositionTemplate:
+		return templateRevisionSource{
+			load: func(ctx context.Context, dbClient db.DbClient, id uuid.UUID) (interface{}, error) {
+				return loadedTemplate(dbClient.ExpositionTemplate().FindByID(ctx, id))
+			},
+			decode: templateSnapshotDecoder[models.ExpositionTemplate](),
+			restore: func(ctx context.Context, dbClient db.DbClient, id uuid.UUID, snapshot []byte) error {
+				template, err := decodeTemplateSnapshot[models.ExpositionTemplate](snapshot)
+				if err != nil {
+					return err
+				}
+				return dbClient.ExpositionTemplate().Update(ctx, id, template)
+			},
+		}, true
+	case models.TemplateRevisionTypeShrineTemplate:
+		return templateRevisionSource{
+			load: func(ctx context.Context, dbClient db.DbClient, id uuid.UUID) (interface{}, error) {
+				return loadedTemplate(dbClient.ShrineTemplate().FindByID(ctx, id))
+			},
+			decode: templateSnapshotDecoder[models.ShrineTemplate](),
+			restore: func(ctx context.Context, dbClient db.DbClient, id uuid.UUID, snapshot []byte) error {
+				template, err := decodeTemplateSnapshot[models.ShrineTemplate](snapshot)
+				if err != nil {
+					return err
+				}
+				return dbClient.ShrineTemplate().Update(ctx, id, template)
+			},
+		}, true
+	case models.TemplateRevisionTypeCharacterTemplate:
+		return templateRevisionSource{
+			load: func(ctx context.Context, dbClient db.DbClient, id uuid.UUID) (interface{}, error) {
+				return loadedTemplate(dbClient.CharacterTemplate().FindByID(ctx, id))
+			},
+			decode: templateSnapshotDecoder[models.CharacterTemplate](),
+			restore: func(ctx context.Context, dbClient db.DbClient, id uuid.UUID, snapshot []byte) error {
+				template, err := decodeTemplateSnapshot[models.CharacterTemplate](snapshot)
+				if err != nil {
+					return err
+				}
+				return dbClient.CharacterTemplate().Update(ctx, id, template)
+			},
+		}, true
+	case models.TemplateRevisionTypeMonsterTemplate:
+		return templateRevisionSource{
+			load: func(ctx context.Context, dbClient db.DbClient, id uuid.UUID) (interface{}, error) {
+				return loadedTemplate(dbClient.MonsterTemplate().FindByID(ctx, id))
+			},
+			decode: templateSnapshotDecoder[models.MonsterTemplate](),
+			restore: func(ctx context.Context, dbClient db.DbClient, id uuid.UUID, snapshot []byte) error {
+				template, err := decodeTemplateSnapshot[models.MonsterTemplate](snapshot)
+				if err != nil {
+					return err
+				}
+				progressions := template.Progressions
+				for i := range progressions {
+					progressions[i].Progression = models.SpellProgression{}
+				}
+				spells := template.Spells
+				for i := range spells {
+					spells[i].Spell = models.Spell{}
+				}
+				if err := dbClient.MonsterTemplate().Update(ctx, id, template); err != nil {
+					return err
+				}
+				if err := dbClient.MonsterTemplate().ReplaceProgressions(ctx, id, progressions); err != nil {
+					return err
+				}
+				return dbClient.MonsterTemplate().ReplaceSpells(ctx, id, spells)
+			},
+		}, true
+	case models.TemplateRevisionTypeQuestArchetype:
+		return templateRevisionSource{
+			load: func(ctx context.Context, dbClient db.DbClient, id uuid.UUID) (interface{}, error) {
+				archetype, err := dbClient.QuestArchetype().FindByID(ctx, id)
+				if err != nil || archetype == nil {
+					return loadedTemplate(archetype, err)
+				}
+				nodes, err := dbClient.QuestArchetypeNode().FindGraph(ctx, archetype.RootID)
+				if err != nil {
+					return nil, err
+				}
+				return &questArchetypeRevision{QuestArchetype: *archetype, Nodes: nodes}, nil
+			},
+			decode: templateSnapshotDecoder[questArchetypeRevision](),
+			restore: func(ctx context.Context, dbClient db.DbClient, id uuid.UUID, snapshot []byte) error {
+				revision, err := decodeTemplateSnapshot[questArchetypeRevision](snapshot)
+				if err != nil {
+					return err
+				}
+				live, err := dbClient.QuestArchetype().FindByID(ctx, id)
+				if err != nil {
+					return err
+				}
+				if live == nil {
+					return gorm.ErrRecordNotFound
+				}
+				archetype := &revision.QuestArchetype
+				archetype.ID = id
+				archetype.CreatedAt = live.CreatedAt
+				archetype.DeletedAt = live.DeletedAt
+				archetype.QuestGiverCharacter = nil
+				if revision.Nodes == nil {
+					// Snapshots from before the graph was recorded, and drafts
+					// made from the archetype alone, keep the live graph.
+					archetype.RootID = live.RootID
+				} else {
+					if !questArchetypeGraphHasNode(revision.Nodes, archetype.RootID) {
+						return fmt.Errorf("snapshot graph does not include root node %s", archetype.RootID)
+					}
+					if err := dbClient.QuestArchetypeNode().RestoreGraph(ctx, revision.Nodes); err != nil {
+						return err
+					}
+				}
+				if err := dbClient.QuestArchetype().Update(ctx, archetype); err != nil {
+					return err
+				}
+				if err := dbClient.QuestArchetypeItemReward().ReplaceForQuestArchetype(ctx, id, archetype.ItemRewards); err != nil {
+					return err
+				}
+				return dbClient.QuestArchetypeSpellReward().ReplaceForQuestArchetype(ctx, id, archetype.SpellRewards)
+			},
+		}, true
+	default:
+		return templateRevisionSource{}, false
+	}
+}
+
+// questArchetypeRevision is a quest archetype's snapshot: the archetype as
+// its GET endpoint returns it, plus every node reachable from its root, since
+// the graph is what the archetype generates quests from.
+type questArchetypeRevision struct {
+	models.QuestArchetype
+	Nodes []models.QuestArchetypeNode `json:"nodes,omitempty"`
+}
+
+func questArchetypeGraphHasNode(nodes []models.QuestArchetypeNode, nodeID uuid.UUID) bool {
+	for _, node := range nodes {
+		if node.ID == nodeID {
+			return true
+		}
+	}
+	return false
+}
+
+func loadTemplateSnapshot(
+	ctx context.Context,
+	dbClient db.DbClient,
+	source templateRevisionSource,
+	templateID uuid.UUID,
+) ([]byte, error) {
+	template, err := source.load(ctx, dbClient, templateID)
+	if err != nil || template == nil {
+		return nil, err
+	}
+	return json.Marshal(template)
+}
+
+func templateRevisionAuthorID(user *models.User) *uuid.UUID {
+	if user == nil {
+		return nil
+	}
+	id := user.ID
+	return &id
+}
+
+// ensureTemplateRevisionBaseline records the template as it was before its
+// first tracked change, so the original can always be rolled back to.
+func ensureTemplateRevisionBaseline(
+	ctx context.Context,
+	dbClient db.DbClient,
+	templateType models.TemplateRevisionType,
+	templateID uuid.UUID,
+	before []byte,
+) error {
+	latest, err := dbClient.TemplateRevision().FindLatestPublished(ctx, templateType, templateID)
+	if err != nil || latest != nil {
+		return err
+	}
+	now := time.Now()
+	return dbClient.TemplateRevision().Create(ctx, &models.TemplateRevision{
+		TemplateType: templateType,
+		TemplateID:   templateID,
+		Status:       models.TemplateRevisionStatusPublished,
+		Note:         "baseline",
+		PublishedAt:  &now,
+		Snapshot:     datatypes.JSON(before),
+		Diff:         datatypes.JSON(`{}`),
+	})
+}
+
+// recordTemplateRevision stores a published revision for a change that has
+// already been applied to the live template. Saves that change nothing are
+// not recorded.
+func recordTemplateRevision(
+	ctx context.Context,
+	dbClient db.DbClient,
+	templateType models.TemplateRevisionType,
+	templateID uuid.UUID,
+	before []byte,
+	after []byte,
+	author *uuid.UUID,
+	note string,
+	restoredFromRevision *int,
+) (*models.TemplateRevision, error) {
+	if err := ensureTemplateRevisionBaseline(ctx, dbClient, templateType, templateID, before); err != nil {
+		return nil, err
+	}
+	changes, err := models.DiffTemplateSnapshots(before, after)
+	if err != nil {
+		return nil, err
+	}
+	if len(changes) == 0 && restoredFromRevision == nil {
+		return nil, nil
+	}
+	diff, err := json.Marshal(changes)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	revision := &models.TemplateRevision{
+		TemplateType:         templateType,
+		TemplateID:           templateID,
+		Status:               models.TemplateRevisionStatusPublished,
+		Note:                 note,
+		AuthorUserID:         author,
+		PublishedByUserID:    author,
+		PublishedAt:          &now,
+		RestoredFromRevision: restoredFromRevision,
+		Snapshot:             datatypes.JSON(after),
+		Diff:                 datatypes.JSON(diff),
+	}
+	if err := dbClient.TemplateRevision().Create(ctx, revision); err != nil {
+		return nil, err
+	}
+	return revision, nil
+}
+
+// templateRevisionResolver finds the templates a request is about to change.
+type templateRevisionResolver func(ctx *gin.Context) ([]uuid.UUID, error)
+
+// templateIDParam resolves the template named by the route's :id. Invalid IDs
+// resolve to nothing and are left for the handler to reject.
+func templateIDParam(ctx *gin.Context) ([]uuid.UUID, error) {
+	templateID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		return nil, nil
+	}
+	return []uuid.UUID{templateID}, nil
+}
+
+// withTemplateRevisions wraps a template's existing update handler so every
+// successful save is recorded as a published revision.
+func (s *server) withTemplateRevisions(
+	templateType models.TemplateRevisionType,
+	handler gin.HandlerFunc,
+) gin.HandlerFunc {
+	return s.withResolvedTemplateRevisions(templateType, templateIDParam, handler)
+}
+
+// withQuestArchetypeNodeRevisions records a revision of every quest archetype
+// whose graph contains the node named by the route's :id.
+func (s *server) withQuestArchetypeNodeRevisions(handler gin.HandlerFunc) gin.HandlerFunc {
+	return s.withResolvedTemplateRevisions(models.TemplateRevisionTypeQuestArchetype, func(ctx *gin.Context) ([]uuid.UUID, error) {
+		nodeID, err := uuid.Parse(ctx.Param("id"))
+		if err != nil {
+			return nil, nil
+		}
+		return s.dbClient.QuestArchetype().FindIDsByNode(ctx, nodeID)
+	}, handler)
+}
+
+// withQuestArchetypeChallengeRevisions records a revision of every quest
+// archetype whose graph contains the challenge named by the route's :id.
+func (s *server) withQuestArchetypeChallengeRevisions(handler gin.HandlerFunc) gin.HandlerFunc {
+	return s.withResolvedTemplateRevisions(models.TemplateRevisionTypeQuestArchetype, func(ctx *gin.Context) ([]uuid.UUID, error) {
+		challengeID, err := uuid.Parse(ctx.Param("id"))
+		if err != nil {
+			return nil, nil
+		}
+		return s.dbClient.QuestArchetype().FindIDsByChallenge(ctx, challengeID)
+	}, handler)
+}
+
+func (s *server) withResolvedTemplateRevisions(
+	templateType models.TemplateRevisionType,
+	resolve templateRevisionResolver,
+	handler gin.HandlerFunc,
+) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		source, ok := templateRevisionSourceFor(templateType)
+		if !ok {
+			handler(ctx)
+			return
+		}
+		templateIDs, err := resolve(ctx)
+		if err != nil {
+			log.Printf("[templates][revisions] failed to find templates type=%s err=%v", templateType, err)
+			handler(ctx)
+			return
+		}
+
+		type trackedTemplate struct {
+			id     uuid.UUID
+			before []byte
+		}
+		tracked := make([]trackedTemplate, 0, len(templateIDs))
+		for _, templateID := range templateIDs {
+			before, err := loadTemplateSnapshot(ctx, s.dbClient, source, templateID)
+			if err != nil {
+				log.Printf("[templates][revisions] failed to load template type=%s id=%s err=%v", templateType, templateID, err)
+				continue
+			}
+			if before != nil {
+				tracked = append(tracked, trackedTemplate{id: templateID, before: before})
+			}
+		}
+
+		handler(ctx)
+		if status := ctx.Writer.Status(); status < http.StatusOK || status >= http.StatusMultipleChoices {
+			return
+		}
+
+		user, _ := s.getAuthenticatedUser(ctx)
+		for _, template := range tracked {
+			after, err := loadTemplateSnapshot(ctx, s.dbClient, source, template.id)
+			if err != nil || after == nil {
+				log.Printf("[templates][revisions] failed to reload template type=%s id=%s err=%v", templateType, template.id, err)
+				continue
+			}
+			if _, err := recordTemplateRevision(ctx, s.dbClient, templateType, template.id, template.before, after, templateRevisionAuthorID(user), "", nil); err != nil {
+				log.Printf("[templates][revisions] failed to record revision type=%s id=%s err=%v", templateType, template.id, err)
+			}
+		}
+	}
+}
+
+type templateRevisionTarget struct {
+	templateType models.TemplateRevisionType
+	templateID   uuid.UUID
+	source       templateRevisionSource
+	user         *models.User
+}
+
+func (s *server) parseTemplateRevisionTarget(ctx *gin.Context) (*templateRevisionTarget, bool) {
+	user, err := s.getAuthenticatedUser(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	templateType, ok := models.ParseTemplateRevisionType(ctx.Param("type"))
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("unsupported template type: %s", ctx.Param("type"))})
+		return nil, false
+	}
+	source, _ := templateRevisionSourceFor(templateType)
+	templateID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid template ID"})
+		return nil, false
+	}
+	return &templateRevisionTarget{
+		templateType: templateType,
+		templateID:   templateID,
+		source:       source,
+		user:         user,
+	}, true
+}
+
+func (s *server) findTemplateRevision(ctx *gin.Context, target *templateRevisionTarget) (*models.TemplateRevision, bool) {
+	revisionNumber, err := strconv.Atoi(ctx.Param("revision"))
+	if err != nil || revisionNumber <= 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid revision"})
+		return nil, false
+	}
+	revision, err := s.dbClient.TemplateRevision().FindByRevision(ctx, target.templateType, target.templateID, revisionNumber)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	if revision == nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "revision not found"})
+		return nil, false
+	}
+	return revision, true
+}
+
+func (s *server) loadLiveTemplateSnapshot(ctx *gin.Context, target *templateRevisionTarget) ([]byte, bool) {
+	live, err := loadTemplateSnapshot(ctx, s.dbClient, target.source, target.templateID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	if live == nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "template not found"})
+		return nil, false
+	}
+	return live, true
+}
+
+func (s *server) getTemplateRevisions(ctx *gin.Context) {
+	target, ok := s.parseTemplateRevisionTarget(ctx)
+	if !ok {
+		return
+	}
+	revisions, err := s.dbClient.TemplateRevision().FindByTemplate(ctx, target.templateType, target.templateID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, revisions)
+}
+
+// getTemplateRevision returns one revision along with how it differs from
+// the live template, which is the preview for a draft.
+func (s *server) getTemplateRevision(ctx *gin.Context) {
+	target, ok := s.parseTemplateRevisionTarget(ctx)
+	if !ok {
+		return
+	}
+	revision, ok := s.findTemplateRevision(ctx, target)
+	if !ok {
+		return
+	}
+	live, ok := s.loadLiveTemplateSnapshot(ctx, target)
+	if !ok {
+		return
+	}
+	diffFromLive, err := models.DiffTemplateSnapshots(live, revision.Snapshot)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"revision":     revision,
+		"diffFromLive": diffFromLive,
+	})
+}
+
+type createTemplateDraftRequest struct {
+	// Snapshot is the full template as returned by its GET endpoint, with
+	// the proposed edits applied.
+	Snapshot json.RawMessage `json:"snapshot"`
+	Note     string          `json:"note"`
+}
+
+func (s *server) createTemplateDraft(ctx *gin.Context) {
+	target, ok := s.parseTemplateRevisionTarget(ctx)
+	if !ok {
+		return
+	}
+	var requestBody createTemplateDraftRequest
+	if err := ctx.ShouldBindJSON(&requestBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(requestBody.Snapshot) == 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "snapshot is required"})
+		return
+	}
+	if err := target.source.decode(requestBody.Snapshot); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	live, ok := s.loadLiveTemplateSnapshot(ctx, target)
+	if !ok {
+		return
+	}
+	if err := ensureTemplateRevisionBaseline(ctx, s.dbClient, target.templateType, target.templateID, live); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	base, err := s.dbClient.TemplateRevision().FindLatestPublished(ctx, target.templateType, target.templateID)
+	if err != nil || base == nil {
+		if err == nil {
+			err = fmt.Errorf("template has no published revision")
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	changes, err := models.DiffTemplateSnapshots(live, requestBody.Snapshot)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	diff, err := json.Marshal(changes)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	draft := &models.TemplateRevision{
+		TemplateType: target.templateType,
+		TemplateID:   target.templateID,
+		Status:       models.TemplateRevisionStatusDraft,
+		Note:         strings.TrimSpace(requestBody.Note),
+		AuthorUserID: templateRevisionAuthorID(target.user),
+		BaseRevision: &base.Revision,
+		Snapshot:     datatypes.JSON(requestBody.Snapshot),
+		Diff:         datatypes.JSON(diff),
+	}
+	if err := s.dbClient.TemplateRevision().Create(ctx, draft); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusCreated, draft)
+}
+
+// draftBaseConflict reports whether the live template has changed since the
+// draft was created. Publishing restores the draft's full snapshot, so
+// publishing over a newer revision would silently revert it.
+func draftBaseConflict(draft *models.TemplateRevision, latest *models.TemplateRevision) error {
+	if draft.BaseRevision == nil {
+		return fmt.Errorf("draft revision %d has no base revision; create the draft again from the live template", draft.Revision)
+	}
+	if latest == nil || latest.Revision != *draft.BaseRevision {
+		latestRevision := 0
+		if latest != nil {
+			latestRevision = latest.Revision
+		}
+		return fmt.Errorf("the template has changed since draft revision %d was created from revision %d (latest is %d); create the draft again from the live template", draft.Revision, *draft.BaseRevision, latestRevision)
+	}
+	return nil
+}
+
+// errTemplateNotFound is returned from inside a publish or rollback when the
+// template itself is gone.
+var errTemplateNotFound = errors.New("template not found")
+
+// templateRevisionConflict is a publish or rollback refused because of the
+// template's history, as opposed to one that failed.
+type templateRevisionConflict struct {
+	err error
+}
+
+func (c templateRevisionConflict) Error() string {
+	return c.err.Error()
+}
+
+func templateRevisionErrorStatus(err error) int {
+	var conflict templateRevisionConflict
+	switch {
+	case errors.Is(err, errTemplateNotFound):
+		return http.StatusNotFound
+	case errors.As(err, &conflict):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// restoreTemplateRevision makes the revision's snapshot the live template and
+// returns the template as it was before and after.
+func restoreTemplateRevision(
+	ctx context.Context,
+	tx db.DbClient,
+	target *templateRevisionTarget,
+	revision *models.TemplateRevision,
+) ([]byte, []byte, error) {
+	before, err := loadTemplateSnapshot(ctx, tx, target.source, target.templateID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if before == nil {
+		return nil, nil, errTemplateNotFound
+	}
+	if err := ensureTemplateRevisionBaseline(ctx, tx, target.templateType, target.templateID, before); err != nil {
+		return nil, nil, err
+	}
+	if err := target.source.restore(ctx, tx, target.templateID, revision.Snapshot); err != nil {
+		return nil, nil, err
+	}
+	after, err := loadTemplateSnapshot(ctx, tx, target.source, target.templateID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if after == nil {
+		return nil, nil, errTemplateNotFound
+	}
+	return before, after, nil
+}
+
+// publishTemplateRevision makes a draft live. The base check, the restore
+// and marking the draft published share one transaction holding the lock on
+// the template's latest revision, so two publishes can't both pass the check.
+func (s *server) publishTemplateRevision(ctx *gin.Context) {
+	target, ok := s.parseTemplateRevisionTarget(ctx)
+	if !ok {
+		return
+	}
+	revision, ok := s.findTemplateRevision(ctx, target)
+	if !ok {
+		return
+	}
+	if revision.Status != models.TemplateRevisionStatusDraft {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "only draft revisions can be published; roll back to restore a published revision"})
+		return
+	}
+	err := s.dbClient.Transaction(ctx, func(tx db.DbClient) error {
+		latest, err := tx.TemplateRevision().LockLatestPublished(ctx, target.templateType, target.templateID)
+		if err != nil {
+			return err
+		}
+		// Read the draft again under the lock, in case it was published
+		// while this request waited for it.
+		draft, err := tx.TemplateRevision().FindByRevision(ctx, target.templateType, target.templateID, revision.Revision)
+		if err != nil {
+			return err
+		}
+		if draft == nil || draft.Status != models.TemplateRevisionStatusDraft {
+			return templateRevisionConflict{err: fmt.Errorf("draft revision %d has already been published or deleted", revision.Revision)}
+		}
+		if err := draftBaseConflict(draft, latest); err != nil {
+			return templateRevisionConflict{err: err}
+		}
+		before, after, err := restoreTemplateRevision(ctx, tx, target, draft)
+		if err != nil {
+			return err
+		}
+		changes, err := models.DiffTemplateSnapshots(before, after)
+		if err != nil {
+			return err
+		}
+		diff, err := json.Marshal(changes)
+		if err != nil {
+			return err
+		}
+
+		// The stored snapshot becomes what was actually saved, since updates
+		// normalize some fields.
+		draft.Snapshot = datatypes.JSON(after)
+		draft.Diff = datatypes.JSON(diff)
+		draft.PublishedByUserID = templateRevisionAuthorID(target.user)
+		if err := tx.TemplateRevision().MarkPublished(ctx, draft); err != nil {
+			return err
+		}
+		revision = draft
+		return nil
+	})
+	if err != nil {
+		status := templateRevisionErrorStatus(err)
+		if status == http.StatusInternalServerError {
+			log.Printf("[templates][revisions] publish failed type=%s id=%s revision=%d err=%v", target.templateType, target.templateID, revision.Revision, err)
+		}
+		ctx.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	log.Printf("[templates][revisions] published type=%s id=%s revision=%d", target.templateType, target.templateID, revision.Revision)
+	ctx.JSON(http.StatusOK, revision)
+}
+
+// rollbackTemplateRevision makes an earlier published revision live again.
+// The rollback is itself recorded as a new revision, so it can be undone. Like
+// publishing, it runs in one transaction under the latest revision's lock.
+func (s *server) rollbackTemplateRevision(ctx *gin.Context) {
+	target, ok := s.parseTemplateRevisionTarget(ctx)
+	if !ok {
+		return
+	}
+	revision, ok := s.findTemplateRevision(ctx, target)
+	if !ok {
+		return
+	}
+	if revision.Status != models.TemplateRevisionStatusPublished {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "only published revisions can be rolled back to; publish the draft instead"})
+		return
+	}
+	var rollback *models.TemplateRevision
+	err := s.dbClient.Transaction(ctx, func(tx db.DbClient) error {
+		if _, err := tx.TemplateRevision().LockLatestPublished(ctx, target.templateType, target.templateID); err != nil {
+			return err
+		}
+		before, after, err := restoreTemplateRevision(ctx, tx, target, revision)
+		if err != nil {
+			return err
+		}
+		restoredFrom := revision.Revision
+		rollback, err = recordTemplateRevision(
+			ctx,
+			tx,
+			target.templateType,
+			target.templateID,
+			before,
+			after,
+			templateRevisionAuthorID(target.user),
+			fmt.Sprintf("rollback to revision %d", revision.Revision),
+			&restoredFrom,
+		)
+		return err
+	})
+	if err != nil {
+		status := templateRevisionErrorStatus(err)
+		if status == http.StatusInternalServerError {
+			log.Printf("[templates][revisions] rollback failed type=%s id=%s revision=%d err=%v", target.templateType, target.templateID, revision.Revision, err)
+		}
+		ctx.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	log.Printf("[templates][revisions] rolled back type=%s id=%s to=%d", target.templateType, target.templateID, revision.Revision)
+	ctx.JSON(http.StatusOK, rollback)
+}
+
+func (s *server) deleteTemplateDraft(ctx *gin.Context) {
+	target, ok := s.parseTemplateRevisionTarget(ctx)
+	if !ok {
+		return
+	}
+	revision, ok := s.findTemplateRevision(ctx, target)
+	if !ok {
+		return
+	}
+	if revision.Status != models.TemplateRevisionStatusDraft {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "published revisions are kept as history"})
+		return
+	}
+	if err := s.dbClient.TemplateRevision().Delete(ctx, revision.ID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"message": "draft deleted successfully"})
+}
@@ -0,0 +1,212 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/db"
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func TestTemplateRevisionSourceCoversEveryType(t *testing.T) {
+	for _, templateType := range []models.TemplateRevisionType{
+		models.TemplateRevisionTypeScenarioTemplate,
+		models.TemplateRevisionTypeChallengeTemplate,
+		models.TemplateRevisionTypeExpositionTemplate,
+		models.TemplateRevisionTypeShrineTemplate,
+		models.TemplateRevisionTypeMonsterTemplate,
+		models.TemplateRevisionTypeCharacterTemplate,
+		models.TemplateRevisionTypeQuestArchetype,
+	} {
+		source, ok := templateRevisionSourceFor(templateType)
+		if !ok || source.load == nil || source.decode == nil || source.restore == nil {
+			t.Fatalf("expected a complete revision source for %s", templateType)
+		}
+	}
+	if _, ok := templateRevisionSourceFor(models.TemplateRevisionType("zone")); ok {
+		t.Fatal("expected no revision source for zones")
+	}
+}
+
+func TestTemplateSnapshotRoundTrip(t *testing.T) {
+	original := models.ShrineTemplate{Name: "Shrine of Dawn", EffectKind: models.ShrineEffectKindWisdom, BaseMagnitude: 4}
+	snapshot, err := json.Marshal(original)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	decoded, err := decodeTemplateSnapshot[models.ShrineTemplate](snapshot)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if decoded.Name != original.Name || decoded.EffectKind != original.EffectKind || decoded.BaseMagnitude != original.BaseMagnitude {
+		t.Fatalf("expected %+v, got %+v", original, *decoded)
+	}
+	if err := templateSnapshotDecoder[models.ShrineTemplate]()([]byte(`{"baseMagnitude":"high"}`)); err == nil {
+		t.Fatal("expected a type mismatch to be rejected")
+	}
+}
+
+func TestLoadedTemplateTreatsMissingAsNil(t *testing.T) {
+	var missing *models.ShrineTemplate
+	if template, err := loadedTemplate(missing, nil); template != nil || err != nil {
+		t.Fatalf("expected nil template for a nil pointer, got %v, %v", template, err)
+	}
+	if template, err := loadedTemplate(missing, fmt.Errorf("lookup: %w", gorm.ErrRecordNotFound)); template != nil || err != nil {
+		t.Fatalf("expected not found to be treated as missing, got %v, %v", template, err)
+	}
+	if _, err := loadedTemplate(missing, fmt.Errorf("connection reset")); err == nil {
+		t.Fatal("expected other errors to be returned")
+	}
+}
+
+func TestDraftBaseConflict(t *testing.T) {
+	base := 3
+	draft := &models.TemplateRevision{Revision: 5, Status: models.TemplateRevisionStatusDraft, BaseRevision: &base}
+
+	if err := draftBaseConflict(draft, &models.TemplateRevision{Revision: 3}); err != nil {
+		t.Fatalf("expected a draft on the latest revision to publish, got %v", err)
+	}
+	if err := draftBaseConflict(draft, &models.TemplateRevision{Revision: 4}); err == nil {
+		t.Fatal("expected an edit made after the draft to conflict")
+	}
+	if err := draftBaseConflict(draft, nil); err == nil {
+		t.Fatal("expected a template without history to conflict")
+	}
+	if err := draftBaseConflict(&models.TemplateRevision{Revision: 2}, &models.TemplateRevision{Revision: 1}); err == nil {
+		t.Fatal("expected a draft without a base revision to conflict")
+	}
+}
+
+func TestQuestArchetypeRevisionIncludesNodeGraph(t *testing.T) {
+	rootID := uuid.New()
+	unlockedID := uuid.New()
+	revision := questArchetypeRevision{
+		QuestArchetype: models.QuestArchetype{ID: uuid.New(), Name: "Lost Lantern", RootID: rootID},
+		Nodes: []models.QuestArchetypeNode{
+			{ID: rootID, Challenges: []models.QuestArchetypeChallenge{{ID: uuid.New(), UnlockedNodeID: &unlockedID}}},
+			{ID: unlockedID, ObjectiveDescription: "Return the lantern"},
+		},
+	}
+	snapshot, err := json.Marshal(revision)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(snapshot, &fields); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if _, ok := fields["name"]; !ok {
+		t.Fatal("expected archetype fields at the top level of the snapshot")
+	}
+	if _, ok := fields["nodes"]; !ok {
+		t.Fatal("expected the node graph in the snapshot")
+	}
+
+	decoded, err := decodeTemplateSnapshot[questArchetypeRevision](snapshot)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(decoded.Nodes) != 2 || decoded.Nodes[1].ObjectiveDescription != "Return the lantern" {
+		t.Fatalf("expected the graph to round trip, got %+v", decoded.Nodes)
+	}
+	if !questArchetypeGraphHasNode(decoded.Nodes, rootID) || questArchetypeGraphHasNode(decoded.Nodes, uuid.New()) {
+		t.Fatal("expected the root to be found in the graph and nothing else")
+	}
+
+	// A draft made from the archetype's GET response has no graph, which
+	// restore treats as keeping the live one.
+	archetypeOnly, err := decodeTemplateSnapshot[questArchetypeRevision]([]byte(`{"name":"Lost Lantern"}`))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if archetypeOnly.Nodes != nil {
+		t.Fatalf("expected no graph, got %+v", archetypeOnly.Nodes)
+	}
+}
+
+// The publish fakes embed the interfaces they stand in for, so a publish
+// that reads the latest revision without the lock, or restores a template,
+// panics.
+type publishTestDB struct {
+	db.DbClient
+	revisions    publishTestRevisions
+	transactions int
+}
+
+func (f *publishTestDB) TemplateRevision() db.TemplateRevisionHandle { return f.revisions }
+
+func (f *publishTestDB) Transaction(_ context.Context, fn func(tx db.DbClient) error) error {
+	f.transactions++
+	return fn(f)
+}
+
+// publishTestRevisions serves the draft before the transaction and
+// underLock once the latest revision has been locked.
+type publishTestRevisions struct {
+	db.TemplateRevisionHandle
+	draft     *models.TemplateRevision
+	underLock *models.TemplateRevision
+	latest    *models.TemplateRevision
+	locked    *bool
+}
+
+func (f publishTestRevisions) FindByRevision(context.Context, models.TemplateRevisionType, uuid.UUID, int) (*models.TemplateRevision, error) {
+	if *f.locked {
+		return f.underLock, nil
+	}
+	return f.draft, nil
+}
+
+func (f publishTestRevisions) LockLatestPublished(context.Context, models.TemplateRevisionType, uuid.UUID) (*models.TemplateRevision, error) {
+	*f.locked = true
+	return f.latest, nil
+}
+
+func TestPublishChecksTheDraftUnderTheLock(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	base := 1
+	draft := &models.TemplateRevision{Revision: 3, Status: models.TemplateRevisionStatusDraft, BaseRevision: &base}
+	published := *draft
+	published.Status = models.TemplateRevisionStatusPublished
+
+	for _, tc := range []struct {
+		name      string
+		underLock *models.TemplateRevision
+		latest    *models.TemplateRevision
+	}{
+		{name: "newer revision", underLock: draft, latest: &models.TemplateRevision{Revision: 2, Status: models.TemplateRevisionStatusPublished}},
+		{name: "already published", underLock: &published, latest: &models.TemplateRevision{Revision: 1, Status: models.TemplateRevisionStatusPublished}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dbClient := &publishTestDB{revisions: publishTestRevisions{draft: draft, underLock: tc.underLock, latest: tc.latest, locked: new(bool)}}
+			s := &server{dbClient: dbClient}
+			templateID := uuid.New()
+			response := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(response)
+			ctx.Request = httptest.NewRequest(http.MethodPost, "/", nil)
+			ctx.Params = gin.Params{
+				{Key: "type", Value: string(models.TemplateRevisionTypeShrineTemplate)},
+				{Key: "id", Value: templateID.String()},
+				{Key: "revision", Value: "3"},
+			}
+			ctx.Set("user", &models.User{ID: uuid.New()})
+
+			s.publishTemplateRevision(ctx)
+
+			if response.Code != http.StatusConflict {
+				t.Fatalf("expected 409, got %d: %s", response.Code, response.Body)
+			}
+			if dbClient.transactions != 1 {
+				t.Fatalf("expected the publish to run in one transaction, ran %d", dbClient.transactions)
+			}
+		})
+	}
+}
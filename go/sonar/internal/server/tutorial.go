@@ -280,6 +280,7 @@ func (s *server) getTutorialStatus(ctx *gin.Context) {
 		return
 	}
 	if state == nil {
+		s.localizeTutorialConfig(ctx, config)
 		ctx.JSON(http.StatusOK, buildTutorialStatusResponse(config, nil))
 		return
 	}
@@ -290,6 +291,7 @@ func (s *server) getTutorialStatus(ctx *gin.Context) {
 		return
 	}
 
+	s.localizeTutorialConfig(ctx, config)
 	ctx.JSON(http.StatusOK, buildTutorialStatusResponse(config, state))
 }
 
@@ -0,0 +1,184 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/auth"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Two-factor and passkey routes pass straight through to the authenticator,
+// with the caller's bearer token standing in for the body's token.
+
+func (s *server) verifyMFA(ctx *gin.Context) {
+	var requestBody auth.VerifyMFARequest
+	if err := ctx.Bind(&requestBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	authenticateResponse, err := s.authClient.VerifyMFA(ctx, &requestBody)
+	if err != nil {
+		ctx.JSON(authenticatorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, authenticateResponse)
+}
+
+func (s *server) getTwoFactorStatus(ctx *gin.Context) {
+	status, err := s.authClient.GetTwoFactorStatus(ctx, &auth.VerifyTokenRequest{Token: bearerToken(ctx)})
+	if err != nil {
+		ctx.JSON(authenticatorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, status)
+}
+
+func (s *server) enrollTOTP(ctx *gin.Context) {
+	enrollment, err := s.authClient.EnrollTOTP(ctx, &auth.VerifyTokenRequest{Token: bearerToken(ctx)})
+	if err != nil {
+		ctx.JSON(authenticatorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, enrollment)
+}
+
+type totpCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+func (s *server) confirmTOTP(ctx *gin.Context) {
+	var requestBody totpCodeRequest
+	if err := ctx.Bind(&requestBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	codes, err := s.authClient.ConfirmTOTP(ctx, &auth.TOTPCodeRequest{Token: bearerToken(ctx), Code: requestBody.Code})
+	if err != nil {
+		ctx.JSON(authenticatorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, codes)
+}
+
+func (s *server) disableTOTP(ctx *gin.Context) {
+	var requestBody totpCodeRequest
+	if err := ctx.Bind(&requestBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := s.authClient.DisableTOTP(ctx, &auth.TOTPCodeRequest{Token: bearerToken(ctx), Code: requestBody.Code}); err != nil {
+		ctx.JSON(authenticatorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "ok"})
+}
+
+func (s *server) regenerateRecoveryCodes(ctx *gin.Context) {
+	var requestBody totpCodeRequest
+	if err := ctx.Bind(&requestBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	codes, err := s.authClient.RegenerateRecoveryCodes(ctx, &auth.TOTPCodeRequest{Token: bearerToken(ctx), Code: requestBody.Code})
+	if err != nil {
+		ctx.JSON(authenticatorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, codes)
+}
+
+func (s *server) getPasskeys(ctx *gin.Context) {
+	passkeys, err := s.authClient.GetPasskeys(ctx, &auth.VerifyTokenRequest{Token: bearerToken(ctx)})
+	if err != nil {
+		ctx.JSON(authenticatorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, passkeys)
+}
+
+func (s *server) deletePasskey(ctx *gin.Context) {
+	passkeyID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid passkey ID"})
+		return
+	}
+
+	if err := s.authClient.DeletePasskey(ctx, &auth.DeletePasskeyRequest{Token: bearerToken(ctx), PasskeyID: passkeyID}); err != nil {
+		ctx.JSON(authenticatorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "ok"})
+}
+
+func (s *server) beginPasskeyRegistration(ctx *gin.Context) {
+	options, err := s.authClient.BeginPasskeyRegistration(ctx, &auth.VerifyTokenRequest{Token: bearerToken(ctx)})
+	if err != nil {
+		ctx.JSON(authenticatorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, options)
+}
+
+func (s *server) finishPasskeyRegistration(ctx *gin.Context) {
+	var requestBody struct {
+		Name       string          `json:"name"`
+		Credential json.RawMessage `json:"credential" binding:"required"`
+	}
+	if err := ctx.Bind(&requestBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	passkey, err := s.authClient.FinishPasskeyRegistration(ctx, &auth.PasskeyRegistrationRequest{
+		Token:      bearerToken(ctx),
+		Name:       requestBody.Name,
+		Credential: requestBody.Credential,
+	})
+	if err != nil {
+		ctx.JSON(authenticatorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, passkey)
+}
+
+func (s *server) beginPasskeyLogin(ctx *gin.Context) {
+	options, err := s.authClient.BeginPasskeyLogin(ctx)
+	if err != nil {
+		ctx.JSON(authenticatorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, options)
+}
+
+func (s *server) finishPasskeyLogin(ctx *gin.Context) {
+	var requestBody auth.PasskeyLoginRequest
+	if err := ctx.Bind(&requestBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	authenticateResponse, err := s.authClient.FinishPasskeyLogin(ctx, &requestBody)
+	if err != nil {
+		ctx.JSON(authenticatorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, authenticateResponse)
+}
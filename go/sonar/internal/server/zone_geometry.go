@@ -0,0 +1,274 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/db"
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	maxZoneBoundaryFileBytes = 10 << 20
+	zoneGeoJSONContentType   = "application/geo+json"
+)
+
+func parseZoneIDList(rawIDs []string) ([]uuid.UUID, error) {
+	zoneIDs := make([]uuid.UUID, 0, len(rawIDs))
+	for _, rawID := range rawIDs {
+		rawID = strings.TrimSpace(rawID)
+		if rawID == "" {
+			continue
+		}
+		zoneID, err := uuid.Parse(rawID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid zone ID: %s", rawID)
+		}
+		zoneIDs = append(zoneIDs, zoneID)
+	}
+	return zoneIDs, nil
+}
+
+func zoneGeometryErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, db.ErrZoneSplitLineMissesZone), errors.Is(err, db.ErrZonesNotAdjacent):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// exportZonesGeoJSON returns zones as a GeoJSON FeatureCollection. zoneIds
+// is a comma-separated filter; without it every zone is exported.
+func (s *server) exportZonesGeoJSON(ctx *gin.Context) {
+	if _, err := s.getAuthenticatedUser(ctx); err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	zoneIDs, err := parseZoneIDList(strings.Split(ctx.Query("zoneIds"), ","))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var zones []*models.Zone
+	if len(zoneIDs) == 0 {
+		zones, err = s.dbClient.Zone().FindAll(ctx)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	} else {
+		for _, zoneID := range zoneIDs {
+			zone, err := s.dbClient.Zone().FindByID(ctx, zoneID)
+			if err != nil {
+				ctx.JSON(zoneGeometryErrorStatus(err), gin.H{"error": err.Error()})
+				return
+			}
+			zones = append(zones, zone)
+		}
+	}
+
+	body, err := json.Marshal(models.ZonesToGeoJSON(zones))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.Header("Content-Disposition", `attachment; filename="zones.geojson"`)
+	ctx.Data(http.StatusOK, zoneGeoJSONContentType, body)
+}
+
+// importZoneBoundaries creates zones from a GeoJSON or KML request body.
+// Zones whose name matches an existing zone are skipped. dryRun=true parses
+// the file and reports what would be created without saving.
+func (s *server) importZoneBoundaries(ctx *gin.Context) {
+	if _, err := s.getAuthenticatedUser(ctx); err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	data, err := io.ReadAll(io.LimitReader(ctx.Request.Body, maxZoneBoundaryFileBytes+1))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(data) > maxZoneBoundaryFileBytes {
+		ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "zone boundary file is too large"})
+		return
+	}
+
+	drafts, err := models.ParseZoneBoundaryFile(data)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	existing, err := s.dbClient.Zone().FindAll(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	taken := make(map[string]bool, len(existing))
+	for _, zone := range existing {
+		taken[strings.ToLower(strings.TrimSpace(zone.Name))] = true
+	}
+	toCreate := make([]models.ZoneBoundaryDraft, 0, len(drafts))
+	skipped := []string{}
+	for _, draft := range drafts {
+		key := strings.ToLower(draft.Name)
+		if taken[key] {
+			skipped = append(skipped, draft.Name)
+			continue
+		}
+		taken[key] = true
+		toCreate = append(toCreate, draft)
+	}
+
+	if ctx.Query("dryRun") == "true" {
+		ctx.JSON(http.StatusOK, gin.H{
+			"dryRun":  true,
+			"zones":   toCreate,
+			"skipped": skipped,
+		})
+		return
+	}
+
+	zones, err := s.dbClient.Zone().ImportBoundaries(ctx, toCreate)
+	if err != nil {
+		log.Printf("[zones][import-boundaries] failed count=%d err=%v", len(toCreate), err)
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	log.Printf("[zones][import-boundaries] created=%d skipped=%d", len(zones), len(skipped))
+	ctx.JSON(http.StatusOK, gin.H{
+		"zones":   zones,
+		"skipped": skipped,
+	})
+}
+
+type splitZoneRequest struct {
+	// Line is a list of [lng, lat] points that crosses the zone.
+	Line [][]float64 `json:"line"`
+}
+
+func (s *server) splitZone(ctx *gin.Context) {
+	if _, err := s.getAuthenticatedUser(ctx); err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	zoneID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid zone ID"})
+		return
+	}
+
+	var requestBody splitZoneRequest
+	if err := ctx.ShouldBindJSON(&requestBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(requestBody.Line) < 2 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "line needs at least 2 points"})
+		return
+	}
+	for _, point := range requestBody.Line {
+		if len(point) != 2 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "line points must be [lng, lat]"})
+			return
+		}
+	}
+
+	edit, err := s.dbClient.Zone().Split(ctx, zoneID, requestBody.Line)
+	if err != nil {
+		log.Printf("[zones][split] failed zone=%s err=%v", zoneID, err)
+		ctx.JSON(zoneGeometryErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, edit)
+}
+
+type mergeZonesRequest struct {
+	// ZoneIDs are merged into the first zone, which keeps its ID.
+	ZoneIDs []string `json:"zoneIds"`
+	Name    string   `json:"name"`
+}
+
+func (s *server) mergeZones(ctx *gin.Context) {
+	if _, err := s.getAuthenticatedUser(ctx); err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var requestBody mergeZonesRequest
+	if err := ctx.ShouldBindJSON(&requestBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	zoneIDs, err := parseZoneIDList(requestBody.ZoneIDs)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(zoneIDs) < 2 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "at least two zone IDs are required"})
+		return
+	}
+
+	edit, err := s.dbClient.Zone().Merge(ctx, zoneIDs, requestBody.Name)
+	if err != nil {
+		log.Printf("[zones][merge] failed zones=%v err=%v", zoneIDs, err)
+		ctx.JSON(zoneGeometryErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, edit)
+}
+
+type simplifyZonesRequest struct {
+	ZoneIDs         []string `json:"zoneIds"`
+	ToleranceMeters float64  `json:"toleranceMeters"`
+}
+
+func (s *server) simplifyZones(ctx *gin.Context) {
+	if _, err := s.getAuthenticatedUser(ctx); err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var requestBody simplifyZonesRequest
+	if err := ctx.ShouldBindJSON(&requestBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	zoneIDs, err := parseZoneIDList(requestBody.ZoneIDs)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(zoneIDs) == 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "at least one zone ID is required"})
+		return
+	}
+	if requestBody.ToleranceMeters <= 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "toleranceMeters must be greater than 0"})
+		return
+	}
+
+	edit, err := s.dbClient.Zone().Simplify(ctx, zoneIDs, requestBody.ToleranceMeters)
+	if err != nil {
+		log.Printf("[zones][simplify] failed zones=%v err=%v", zoneIDs, err)
+		ctx.JSON(zoneGeometryErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, edit)
+}
@@ -0,0 +1,37 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/db"
+	"gorm.io/gorm"
+)
+
+func TestParseZoneIDListSkipsBlanks(t *testing.T) {
+	zoneIDs, err := parseZoneIDList([]string{"", " 5f4b3c1e-4a7e-4c2b-9d8e-1a2b3c4d5e6f ", ""})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(zoneIDs) != 1 {
+		t.Fatalf("expected 1 zone ID, got %d", len(zoneIDs))
+	}
+	if _, err := parseZoneIDList([]string{"not-a-uuid"}); err == nil {
+		t.Fatal("expected error for an invalid zone ID")
+	}
+}
+
+func TestZoneGeometryErrorStatus(t *testing.T) {
+	cases := map[error]int{
+		gorm.ErrRecordNotFound:                            http.StatusNotFound,
+		fmt.Errorf("wrapped: %w", db.ErrZonesNotAdjacent): http.StatusBadRequest,
+		db.ErrZoneSplitLineMissesZone:                     http.StatusBadRequest,
+		fmt.Errorf("connection reset"):                    http.StatusInternalServerError,
+	}
+	for err, want := range cases {
+		if got := zoneGeometryErrorStatus(err); got != want {
+			t.Fatalf("zoneGeometryErrorStatus(%v) = %d, want %d", err, got, want)
+		}
+	}
+}
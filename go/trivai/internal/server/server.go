@@ -228,6 +228,13 @@ func (s *Server) login(ctx *gin.Context) {
 		return
 	}
 
+	// Users with two-factor on get an MFA token instead of a session and
+	// finish signing in with VerifyMFA.
+	if authenticateResponse.MFARequired {
+		ctx.JSON(200, authenticateResponse)
+		return
+	}
+
 	payload := gin.H{
 		"user":  authenticateResponse.User,
 		"token": authenticateResponse.Token,
@@ -75,6 +75,10 @@ func playerFromContext(ctx *gin.Context) *models.VampirePlayer {
 	return player
 }
 
+// libraryWriteScope admits a signed-in account to the shared content
+// library without a row in the super-user list.
+const libraryWriteScope = "vampire:library:write"
+
 const (
 	currentUserContextKey = "vampireUser"
 	instanceIDContextKey  = "vampireInstanceId"
@@ -219,17 +223,23 @@ func gmNameFromContext(ctx *gin.Context) string {
 // withSuperUser guards the shared content library editor (/admin/*) — the
 // only accounts allowed to edit characters, houses, items, and quiz
 // questions. Separate from, and stricter than, withInstanceAdmin: a Host or
-// Co-Host on some instance is not automatically a super user.
+// Co-Host on some instance is not automatically a super user. Holding the
+// vampire:library:write scope (the vampire-library-editor or super-admin
+// role) counts too, alongside the dashboard's own super-user list.
 func (s *server) withSuperUser(ctx *gin.Context) {
 	user := s.authenticateUser(ctx)
 	if user == nil {
 		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "sign in required"})
 		return
 	}
-	ok, err := s.dbClient.Vampire().IsSuperUser(ctx, user.ID)
-	if err != nil {
-		ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+	ok := user.HasScopes(libraryWriteScope)
+	if !ok {
+		var err error
+		ok, err = s.dbClient.Vampire().IsSuperUser(ctx, user.ID)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
 	}
 	if !ok {
 		ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "you don't have access to the shared content library"})
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"context"
+	"log"
 	"strings"
 
 	"github.com/MaxBlaushild/poltergeist/pkg/auth"
@@ -35,11 +37,28 @@ func main() {
 
 	awsClient := aws.NewAWSClient("us-east-1")
 
-	certClient, err := cert.NewClient(cfg.Secret.CAPrivateKey)
+	certConfig := cert.Config{
+		PrivateKeyPEM:      cfg.Secret.CAPrivateKey,
+		CertificatePEM:     cfg.Secret.CACertificate,
+		RootCertificatePEM: cfg.Secret.CARootCertificate,
+	}
+	if baseURL := strings.TrimSuffix(cfg.Public.CertificateBaseURL, "/"); baseURL != "" {
+		certConfig.CRLURL = baseURL + "/verifiable-sn/certificate/crl"
+		certConfig.OCSPURL = baseURL + "/verifiable-sn/ocsp"
+	}
+	certClient, err := cert.NewClientFromConfig(certConfig)
 	if err != nil {
 		panic(err)
 	}
 
+	// Certificates stored before serial numbers were recorded can't be
+	// found by the OCSP responder until this fills them in.
+	if backfilled, err := dbClient.UserCertificate().BackfillSerialNumbers(context.Background()); err != nil {
+		log.Printf("Failed to backfill certificate serial numbers: %v", err)
+	} else if backfilled > 0 {
+		log.Printf("Backfilled serial numbers for %d certificates", backfilled)
+	}
+
 	// Validate required configuration
 	if cfg.Public.EthereumTransactorURL == "" {
 		panic("ETHEREUM_TRANSACTOR_URL is required")
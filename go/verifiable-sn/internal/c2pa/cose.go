@@ -0,0 +1,219 @@
+package c2pa
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+	"math/big"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// C2PA signs claims with COSE_Sign1 (RFC 9052) and a detached payload: the
+// signature box carries the signature and the claim box the signed bytes.
+
+const (
+	coseSign1Tag      = 18
+	coseHeaderAlg     = 1
+	coseHeaderX5Chain = 33
+)
+
+// COSE algorithm identifiers C2PA allows.
+const (
+	algES256 = -7
+	algES384 = -35
+	algES512 = -36
+	algPS256 = -37
+	algPS384 = -38
+	algPS512 = -39
+	algEdDSA = -8
+)
+
+var algorithmNames = map[int64]string{
+	algES256: "ES256",
+	algES384: "ES384",
+	algES512: "ES512",
+	algPS256: "PS256",
+	algPS384: "PS384",
+	algPS512: "PS512",
+	algEdDSA: "Ed25519",
+}
+
+type coseSign1 struct {
+	_           struct{} `cbor:",toarray"`
+	Protected   []byte
+	Unprotected map[interface{}]interface{}
+	Payload     []byte
+	Signature   []byte
+
+	protected map[interface{}]interface{}
+}
+
+func parseCoseSign1(data []byte) (*coseSign1, error) {
+	content := data
+	var tagged cbor.RawTag
+	if err := cbor.Unmarshal(data, &tagged); err == nil {
+		if tagged.Number != coseSign1Tag {
+			return nil, fmt.Errorf("unexpected CBOR tag %d, expected COSE_Sign1", tagged.Number)
+		}
+		content = tagged.Content
+	}
+
+	var sign1 coseSign1
+	if err := cbor.Unmarshal(content, &sign1); err != nil {
+		return nil, fmt.Errorf("failed to parse COSE_Sign1: %w", err)
+	}
+	if len(sign1.Protected) > 0 {
+		if err := cbor.Unmarshal(sign1.Protected, &sign1.protected); err != nil {
+			return nil, fmt.Errorf("failed to parse COSE protected header: %w", err)
+		}
+	}
+	return &sign1, nil
+}
+
+// header looks label up in the protected header, then the unprotected one.
+// Older C2PA manifests put the certificate chain in the unprotected header.
+func (s *coseSign1) header(label int64) (interface{}, bool) {
+	if value, ok := lookupHeader(s.protected, label); ok {
+		return value, true
+	}
+	return lookupHeader(s.Unprotected, label)
+}
+
+func lookupHeader(headers map[interface{}]interface{}, label int64) (interface{}, bool) {
+	for key, value := range headers {
+		if k, ok := cborInt(key); ok && k == label {
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+// algorithm returns the signature algorithm, which must be protected.
+func (s *coseSign1) algorithm() (int64, error) {
+	value, ok := lookupHeader(s.protected, coseHeaderAlg)
+	if !ok {
+		return 0, fmt.Errorf("COSE protected header has no algorithm")
+	}
+	alg, ok := cborInt(value)
+	if !ok {
+		return 0, fmt.Errorf("unsupported COSE algorithm %v", value)
+	}
+	if _, known := algorithmNames[alg]; !known {
+		return 0, fmt.Errorf("unsupported COSE algorithm %d", alg)
+	}
+	return alg, nil
+}
+
+// certificateChain returns the x5chain header, leaf first.
+func (s *coseSign1) certificateChain() ([][]byte, error) {
+	value, ok := s.header(coseHeaderX5Chain)
+	if !ok {
+		return nil, fmt.Errorf("COSE headers have no x5chain")
+	}
+	switch v := value.(type) {
+	case []byte:
+		return [][]byte{v}, nil
+	case []interface{}:
+		chain := make([][]byte, 0, len(v))
+		for _, item := range v {
+			der, ok := item.([]byte)
+			if !ok {
+				return nil, fmt.Errorf("x5chain entry has invalid type %T", item)
+			}
+			chain = append(chain, der)
+		}
+		if len(chain) == 0 {
+			return nil, fmt.Errorf("x5chain is empty")
+		}
+		return chain, nil
+	default:
+		return nil, fmt.Errorf("x5chain has invalid type %T", value)
+	}
+}
+
+// verify checks the signature over payload, which for C2PA is the claim.
+func (s *coseSign1) verify(alg int64, certificate *x509.Certificate, payload []byte) error {
+	protected := s.Protected
+	if protected == nil {
+		protected = []byte{}
+	}
+	toBeSigned, err := cbor.Marshal([]interface{}{"Signature1", protected, []byte{}, payload})
+	if err != nil {
+		return fmt.Errorf("failed to encode Sig_structure: %w", err)
+	}
+
+	switch alg {
+	case algES256, algES384, algES512:
+		key, ok := certificate.PublicKey.(*ecdsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("%s signature with a %T key", algorithmNames[alg], certificate.PublicKey)
+		}
+		// COSE encodes ECDSA signatures as r || s, each the curve's size.
+		size := (key.Curve.Params().BitSize + 7) / 8
+		if len(s.Signature) != 2*size {
+			return fmt.Errorf("ECDSA signature is %d bytes, expected %d", len(s.Signature), 2*size)
+		}
+		r := new(big.Int).SetBytes(s.Signature[:size])
+		sig := new(big.Int).SetBytes(s.Signature[size:])
+		if !ecdsa.Verify(key, digest(hashForAlgorithm(alg), toBeSigned), r, sig) {
+			return fmt.Errorf("ECDSA signature does not match")
+		}
+	case algPS256, algPS384, algPS512:
+		key, ok := certificate.PublicKey.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("%s signature with a %T key", algorithmNames[alg], certificate.PublicKey)
+		}
+		hash := hashForAlgorithm(alg)
+		if err := rsa.VerifyPSS(key, hash, digest(hash, toBeSigned), s.Signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}); err != nil {
+			return fmt.Errorf("RSA-PSS signature does not match: %w", err)
+		}
+	case algEdDSA:
+		key, ok := certificate.PublicKey.(ed25519.PublicKey)
+		if !ok {
+			return fmt.Errorf("Ed25519 signature with a %T key", certificate.PublicKey)
+		}
+		if !ed25519.Verify(key, toBeSigned, s.Signature) {
+			return fmt.Errorf("Ed25519 signature does not match")
+		}
+	default:
+		return fmt.Errorf("unsupported COSE algorithm %d", alg)
+	}
+	return nil
+}
+
+func hashForAlgorithm(alg int64) crypto.Hash {
+	switch alg {
+	case algES384, algPS384:
+		return crypto.SHA384
+	case algES512, algPS512:
+		return crypto.SHA512
+	default:
+		return crypto.SHA256
+	}
+}
+
+func digest(hash crypto.Hash, data []byte) []byte {
+	h := hash.New()
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+func cborInt(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case int64:
+		return v, true
+	case uint64:
+		if v > 1<<63-1 {
+			return 0, false
+		}
+		return int64(v), true
+	case int:
+		return int64(v), true
+	default:
+		return 0, false
+	}
+}
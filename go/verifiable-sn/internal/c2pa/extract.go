@@ -0,0 +1,158 @@
+package c2pa
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+)
+
+var (
+	jpegSOI      = []byte{0xff, 0xd8}
+	pngSignature = []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1a, '\n'}
+)
+
+// IsManifestStore reports whether data is a bare JUMBF manifest store, as
+// uploaded for sidecar (.c2pa) manifests, rather than an asset.
+func IsManifestStore(data []byte) bool {
+	return len(data) >= 8 && string(data[4:8]) == "jumb"
+}
+
+// ExtractManifestStore returns the JUMBF manifest store embedded in a JPEG
+// or PNG, or nil if the asset doesn't carry one.
+func ExtractManifestStore(asset []byte) ([]byte, error) {
+	switch {
+	case bytes.HasPrefix(asset, jpegSOI):
+		return extractFromJPEG(asset)
+	case bytes.HasPrefix(asset, pngSignature):
+		return extractFromPNG(asset)
+	default:
+		return nil, nil
+	}
+}
+
+func detectFormat(asset []byte) string {
+	switch {
+	case bytes.HasPrefix(asset, jpegSOI):
+		return "image/jpeg"
+	case bytes.HasPrefix(asset, pngSignature):
+		return "image/png"
+	default:
+		return ""
+	}
+}
+
+type jumbfSegment struct {
+	sequence uint32
+	data     []byte
+}
+
+// extractFromJPEG reassembles JUMBF from APP11 segments. Each segment starts
+// with the JPEG XT common identifier "JP", a box instance number and a
+// sequence number. A box too big for one segment is split across segments
+// sharing an instance number, each after the first repeating the box header.
+func extractFromJPEG(data []byte) ([]byte, error) {
+	instances := map[uint16][]jumbfSegment{}
+	var order []uint16
+
+	pos := len(jpegSOI)
+	for pos+4 <= len(data) {
+		if data[pos] != 0xff {
+			return nil, fmt.Errorf("invalid JPEG marker at offset %d", pos)
+		}
+		marker := data[pos+1]
+		if marker == 0xff {
+			// Fill byte
+			pos++
+			continue
+		}
+		if marker == 0xda || marker == 0xd9 {
+			// Metadata segments all come before the first scan.
+			break
+		}
+		if marker == 0x01 || (marker >= 0xd0 && marker <= 0xd7) {
+			pos += 2
+			continue
+		}
+
+		length := int(binary.BigEndian.Uint16(data[pos+2 : pos+4]))
+		if length < 2 || pos+2+length > len(data) {
+			return nil, fmt.Errorf("truncated JPEG segment at offset %d", pos)
+		}
+		segment := data[pos+4 : pos+2+length]
+		if marker == 0xeb && len(segment) >= 8 && segment[0] == 'J' && segment[1] == 'P' {
+			instance := binary.BigEndian.Uint16(segment[2:4])
+			if _, seen := instances[instance]; !seen {
+				order = append(order, instance)
+			}
+			instances[instance] = append(instances[instance], jumbfSegment{
+				sequence: binary.BigEndian.Uint32(segment[4:8]),
+				data:     segment[8:],
+			})
+		}
+		pos += 2 + length
+	}
+
+	for _, instance := range order {
+		store, err := reassembleJUMBF(instances[instance])
+		if err != nil {
+			return nil, err
+		}
+		if isC2PAStore(store) {
+			return store, nil
+		}
+	}
+	return nil, nil
+}
+
+func reassembleJUMBF(segments []jumbfSegment) ([]byte, error) {
+	sort.SliceStable(segments, func(i, j int) bool { return segments[i].sequence < segments[j].sequence })
+
+	first := segments[0].data
+	if len(first) < 8 {
+		return nil, fmt.Errorf("truncated JUMBF box in APP11 segment")
+	}
+	headerSize := 8
+	if binary.BigEndian.Uint32(first[0:4]) == 1 {
+		headerSize = 16
+	}
+
+	store := append([]byte{}, first...)
+	for _, segment := range segments[1:] {
+		if len(segment.data) < headerSize {
+			return nil, fmt.Errorf("truncated JUMBF continuation in APP11 segment")
+		}
+		store = append(store, segment.data[headerSize:]...)
+	}
+	return store, nil
+}
+
+// extractFromPNG returns the contents of the caBX chunk C2PA uses for PNGs.
+func extractFromPNG(data []byte) ([]byte, error) {
+	pos := len(pngSignature)
+	for pos+8 <= len(data) {
+		length := int(binary.BigEndian.Uint32(data[pos : pos+4]))
+		chunkType := string(data[pos+4 : pos+8])
+		end := pos + 8 + length + 4
+		if length < 0 || end > len(data) {
+			return nil, fmt.Errorf("truncated PNG %q chunk at offset %d", chunkType, pos)
+		}
+		switch chunkType {
+		case "caBX":
+			return data[pos+8 : pos+8+length], nil
+		case "IEND":
+			return nil, nil
+		}
+		pos = end
+	}
+	return nil, nil
+}
+
+// isC2PAStore checks the description box of a JUMBF superbox for the
+// manifest store type, without parsing the rest.
+func isC2PAStore(data []byte) bool {
+	if !IsManifestStore(data) || len(data) < 32 || string(data[12:16]) != "jumd" {
+		return false
+	}
+	return bytes.Equal(data[16:32], manifestStoreUUID[:])
+}
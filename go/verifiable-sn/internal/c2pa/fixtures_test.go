@@ -0,0 +1,271 @@
+package c2pa
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/binary"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// The fixtures build manifest stores the way a C2PA signer lays them out:
+// a store superbox of manifests, each an assertion store, a claim hashing
+// the assertions and a COSE_Sign1 over the claim.
+
+var (
+	manifestUUID       = jumbfUUID("c2ma")
+	assertionStoreUUID = jumbfUUID("c2as")
+	claimUUID          = jumbfUUID("c2cl")
+	signatureUUID      = jumbfUUID("c2cs")
+	cborUUID           = jumbfUUID("cbor")
+)
+
+func jumbfUUID(prefix string) [16]byte {
+	var id [16]byte
+	copy(id[:], prefix)
+	copy(id[4:], []byte{0x00, 0x11, 0x00, 0x10, 0x80, 0x00, 0x00, 0xaa, 0x00, 0x38, 0x9b, 0x71})
+	return id
+}
+
+func testBox(boxType string, payload []byte) []byte {
+	out := make([]byte, 8, 8+len(payload))
+	binary.BigEndian.PutUint32(out, uint32(8+len(payload)))
+	copy(out[4:], boxType)
+	return append(out, payload...)
+}
+
+func testSuperbox(typeUUID [16]byte, label string, contents ...[]byte) []byte {
+	description := append(append(typeUUID[:], 0x03), label...)
+	payload := testBox("jumd", append(description, 0))
+	for _, content := range contents {
+		payload = append(payload, content...)
+	}
+	return testBox("jumb", payload)
+}
+
+func testCBORBox(t *testing.T, label string, value interface{}) []byte {
+	t.Helper()
+	encoded, err := cbor.Marshal(value)
+	if err != nil {
+		t.Fatalf("encode %s: %v", label, err)
+	}
+	return testSuperbox(cborUUID, label, testBox("cbor", encoded))
+}
+
+// hashedRef points at a superbox and hashes it the way claims and
+// ingredients do: over everything after the superbox's own header.
+func hashedRef(url string, superbox []byte) map[string]interface{} {
+	hash := sha256.Sum256(superbox[8:])
+	return map[string]interface{}{"url": url, "hash": hash[:]}
+}
+
+type testSigner struct {
+	key   *ecdsa.PrivateKey
+	chain [][]byte
+}
+
+type testCA struct {
+	certificate *x509.Certificate
+	key         *ecdsa.PrivateKey
+	pool        *x509.CertPool
+}
+
+func newTestCA(t *testing.T, name string) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-30 * 24 * time.Hour),
+		NotAfter:              time.Now().Add(30 * 24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(certificate)
+	return &testCA{certificate: certificate, key: key, pool: pool}
+}
+
+func (ca *testCA) issue(t *testing.T, name string) *testSigner {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-7 * 24 * time.Hour),
+		NotAfter:     time.Now().Add(7 * 24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.certificate, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testSigner{key: key, chain: [][]byte{der}}
+}
+
+// VerifyCertificate makes testCA a TrustAnchor for the certificates it
+// issued.
+func (ca *testCA) VerifyCertificate(certificateDER []byte, at time.Time) (*x509.Certificate, error) {
+	certificate, err := x509.ParseCertificate(certificateDER)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := certificate.Verify(x509.VerifyOptions{
+		Roots:       ca.pool,
+		CurrentTime: at,
+		KeyUsages:   []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}); err != nil {
+		return nil, err
+	}
+	return certificate, nil
+}
+
+// sign returns a tagged COSE_Sign1 over claim with a detached payload.
+func (s *testSigner) sign(t *testing.T, claim []byte) []byte {
+	t.Helper()
+	protected, err := cbor.Marshal(map[int]interface{}{coseHeaderAlg: algES256, coseHeaderX5Chain: s.chain})
+	if err != nil {
+		t.Fatal(err)
+	}
+	toBeSigned, err := cbor.Marshal([]interface{}{"Signature1", protected, []byte{}, claim})
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := sha256.Sum256(toBeSigned)
+	r, sig, err := ecdsa.Sign(rand.Reader, s.key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	sig.FillBytes(signature[32:])
+
+	encoded, err := cbor.Marshal(cbor.Tag{Number: coseSign1Tag, Content: []interface{}{protected, map[int]interface{}{}, nil, signature}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return encoded
+}
+
+type testExclusion struct {
+	Start  int64 `cbor:"start"`
+	Length int64 `cbor:"length"`
+}
+
+type testIngredient struct {
+	label    string
+	manifest []byte
+}
+
+type testManifest struct {
+	label    string
+	signer   *testSigner
+	signedAt time.Time
+	// asset, when set, is bound to the claim by a data hash over the bytes
+	// outside exclusions.
+	asset       []byte
+	exclusions  []testExclusion
+	ingredients []testIngredient
+	// tamper edits the claim after it is signed.
+	tamper func(claim map[string]interface{})
+}
+
+// build returns the manifest's superbox.
+func (m testManifest) build(t *testing.T) []byte {
+	t.Helper()
+	labels := []string{actionsLabel}
+	assertions := [][]byte{testCBORBox(t, actionsLabel, map[string]interface{}{
+		"actions": []interface{}{map[string]interface{}{"action": "c2pa.created", "when": m.signedAt.UTC().Format(time.RFC3339)}},
+	})}
+	if m.asset != nil {
+		h := sha256.New()
+		pos := int64(0)
+		for _, exclusion := range m.exclusions {
+			if exclusion.Start < pos || exclusion.Start > int64(len(m.asset)) || exclusion.Length > int64(len(m.asset))-exclusion.Start {
+				// Left for Verify to reject.
+				break
+			}
+			h.Write(m.asset[pos:exclusion.Start])
+			pos = exclusion.Start + exclusion.Length
+		}
+		h.Write(m.asset[pos:])
+		exclusions := m.exclusions
+		if exclusions == nil {
+			exclusions = []testExclusion{}
+		}
+		labels = append(labels, dataHashLabel)
+		assertions = append(assertions, testCBORBox(t, dataHashLabel, map[string]interface{}{
+			"exclusions": exclusions,
+			"alg":        "sha256",
+			"hash":       h.Sum(nil),
+		}))
+	}
+	for i, in := range m.ingredients {
+		label := ingredientLabel
+		if i > 0 {
+			label += "__" + string(rune('0'+i))
+		}
+		labels = append(labels, label)
+		assertions = append(assertions, testCBORBox(t, label, map[string]interface{}{
+			"dc:title":       in.label + ".jpg",
+			"relationship":   "parentOf",
+			"activeManifest": hashedRef(jumbfURIPrefix+"/"+manifestStoreLabel+"/"+in.label, in.manifest),
+		}))
+	}
+
+	var refs []interface{}
+	for i, assertion := range assertions {
+		refs = append(refs, hashedRef(jumbfURIPrefix+"c2pa.assertions/"+labels[i], assertion))
+	}
+	claim := map[string]interface{}{
+		"claim_generator": "verifiable-sn test",
+		"dc:title":        m.label + ".jpg",
+		"dc:format":       "image/jpeg",
+		"instanceID":      "xmp:iid:" + m.label,
+		"alg":             "sha256",
+		"assertions":      refs,
+	}
+	claimBytes, err := cbor.Marshal(claim)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature := m.signer.sign(t, claimBytes)
+	if m.tamper != nil {
+		m.tamper(claim)
+		if claimBytes, err = cbor.Marshal(claim); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return testSuperbox(manifestUUID, m.label,
+		testSuperbox(assertionStoreUUID, "c2pa.assertions", assertions...),
+		testSuperbox(claimUUID, claimLabel, testBox("cbor", claimBytes)),
+		testSuperbox(signatureUUID, signatureLabel, testBox("cbor", signature)),
+	)
+}
+
+func testStore(manifests ...[]byte) []byte {
+	return testSuperbox(manifestStoreUUID, manifestStoreLabel, manifests...)
+}
@@ -0,0 +1,135 @@
+package c2pa
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// JUMBF (ISO/IEC 19566-5) is a tree of ISO BMFF style boxes. Superboxes
+// ("jumb") start with a description box ("jumd") carrying a type UUID and a
+// label, followed by content boxes or further superboxes. A C2PA manifest
+// store is a superbox of manifests, each holding an assertion store, a claim
+// and the claim's signature.
+
+// maxSuperboxDepth bounds recursion on hostile input. Real manifest stores
+// nest four levels deep.
+const maxSuperboxDepth = 16
+
+// manifestStoreUUID is the description box type of a C2PA manifest store.
+var manifestStoreUUID = [16]byte{'c', '2', 'p', 'a', 0x00, 0x11, 0x00, 0x10, 0x80, 0x00, 0x00, 0xaa, 0x00, 0x38, 0x9b, 0x71}
+
+type box struct {
+	boxType string
+	payload []byte
+}
+
+func readBoxes(data []byte) ([]box, error) {
+	var boxes []box
+	for len(data) > 0 {
+		if len(data) < 8 {
+			return nil, fmt.Errorf("truncated box header")
+		}
+		size := uint64(binary.BigEndian.Uint32(data[0:4]))
+		boxType := string(data[4:8])
+		headerSize := uint64(8)
+		switch size {
+		case 0:
+			// A zero size means the box runs to the end of its container.
+			size = uint64(len(data))
+		case 1:
+			if len(data) < 16 {
+				return nil, fmt.Errorf("truncated extended size for %q box", boxType)
+			}
+			size = binary.BigEndian.Uint64(data[8:16])
+			headerSize = 16
+		}
+		if size < headerSize || size > uint64(len(data)) {
+			return nil, fmt.Errorf("invalid size %d for %q box", size, boxType)
+		}
+		boxes = append(boxes, box{boxType: boxType, payload: data[headerSize:size]})
+		data = data[size:]
+	}
+	return boxes, nil
+}
+
+type superbox struct {
+	label    string
+	typeUUID [16]byte
+	// payload is everything after the superbox's own header: the description
+	// box and the contents. C2PA hashes assertions and ingredient manifests
+	// over exactly these bytes.
+	payload  []byte
+	children []*superbox
+	contents []box
+}
+
+func parseSuperbox(payload []byte, depth int) (*superbox, error) {
+	if depth > maxSuperboxDepth {
+		return nil, fmt.Errorf("superboxes nested more than %d deep", maxSuperboxDepth)
+	}
+
+	boxes, err := readBoxes(payload)
+	if err != nil {
+		return nil, err
+	}
+	if len(boxes) == 0 || boxes[0].boxType != "jumd" {
+		return nil, fmt.Errorf("superbox is missing its description box")
+	}
+
+	sb := &superbox{payload: payload}
+	if err := sb.parseDescription(boxes[0].payload); err != nil {
+		return nil, err
+	}
+
+	for _, b := range boxes[1:] {
+		if b.boxType != "jumb" {
+			sb.contents = append(sb.contents, b)
+			continue
+		}
+		child, err := parseSuperbox(b.payload, depth+1)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", sb.label, err)
+		}
+		sb.children = append(sb.children, child)
+	}
+
+	return sb, nil
+}
+
+func (sb *superbox) parseDescription(description []byte) error {
+	if len(description) < 17 {
+		return fmt.Errorf("truncated description box")
+	}
+	copy(sb.typeUUID[:], description[:16])
+
+	// Bit 1 of the toggles byte says a null-terminated label follows.
+	if description[16]&0x02 != 0 {
+		rest := description[17:]
+		end := bytes.IndexByte(rest, 0)
+		if end < 0 {
+			return fmt.Errorf("unterminated superbox label")
+		}
+		sb.label = string(rest[:end])
+	}
+	return nil
+}
+
+func (sb *superbox) child(label string) *superbox {
+	for _, c := range sb.children {
+		if c.label == label {
+			return c
+		}
+	}
+	return nil
+}
+
+// content returns the payload of the first content box of boxType, or nil.
+func (sb *superbox) content(boxType string) []byte {
+	for _, b := range sb.contents {
+		if b.boxType == boxType {
+			return b.payload
+		}
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package c2pa
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+)
+
+func pngChunk(chunkType string, data []byte) []byte {
+	out := make([]byte, 8, 12+len(data))
+	binary.BigEndian.PutUint32(out, uint32(len(data)))
+	copy(out[4:], chunkType)
+	out = append(out, data...)
+	// Nothing checks chunk CRCs, so they're left zero.
+	return append(out, 0, 0, 0, 0)
+}
+
+// embedPNG puts store in a caBX chunk, returning the image and the range
+// the chunk occupies.
+func embedPNG(store []byte) ([]byte, testExclusion) {
+	asset := append(append([]byte{}, pngSignature...), pngChunk("IHDR", make([]byte, 13))...)
+	start := int64(len(asset))
+	asset = append(asset, pngChunk("caBX", store)...)
+	exclusion := testExclusion{Start: start, Length: int64(len(asset)) - start}
+	asset = append(asset, pngChunk("IDAT", []byte("not really deflate"))...)
+	return append(asset, pngChunk("IEND", nil)...), exclusion
+}
+
+// embedJPEG splits store across two APP11 segments, the second repeating
+// the box header, returning the image and the range the segments occupy.
+func embedJPEG(store []byte) ([]byte, testExclusion) {
+	segment := func(sequence uint32, data []byte) []byte {
+		out := []byte{0xff, 0xeb, 0, 0, 'J', 'P', 0, 1, 0, 0, 0, 0}
+		binary.BigEndian.PutUint16(out[2:4], uint16(2+8+len(data)))
+		binary.BigEndian.PutUint32(out[8:12], sequence)
+		return append(out, data...)
+	}
+	half := len(store) / 2
+	asset := append([]byte{}, jpegSOI...)
+	start := int64(len(asset))
+	asset = append(asset, segment(1, store[:half])...)
+	asset = append(asset, segment(2, append(append([]byte{}, store[:8]...), store[half:]...))...)
+	exclusion := testExclusion{Start: start, Length: int64(len(asset)) - start}
+	asset = append(asset, 0xff, 0xda, 0, 2)
+	return append(asset, []byte("not really entropy coded data")...), exclusion
+}
+
+// signedEmbedded signs a manifest bound to the asset it's embedded in.
+// The store's size decides the exclusion, which is part of the store, so
+// it's embedded until the size settles.
+func signedEmbedded(t *testing.T, signer *testSigner, embed func([]byte) ([]byte, testExclusion)) ([]byte, []byte) {
+	t.Helper()
+	m := testManifest{label: "urn:uuid:embedded", signer: signer, signedAt: time.Now()}
+	store := testStore(m.build(t))
+	for i := 0; i < 4; i++ {
+		asset, exclusion := embed(store)
+		m.asset, m.exclusions = asset, []testExclusion{exclusion}
+		next := testStore(m.build(t))
+		if len(next) == len(store) {
+			asset, _ = embed(next)
+			return asset, next
+		}
+		store = next
+	}
+	t.Fatal("manifest store size didn't settle")
+	return nil, nil
+}
+
+func TestVerifyEmbeddedManifest(t *testing.T) {
+	ca := newTestCA(t, "verifiable-sn test CA")
+	signer := ca.issue(t, "Ada")
+
+	for _, tc := range []struct {
+		name   string
+		embed  func([]byte) ([]byte, testExclusion)
+		format string
+	}{
+		{name: "png", embed: embedPNG, format: "image/png"},
+		{name: "jpeg", embed: embedJPEG, format: "image/jpeg"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			asset, store := signedEmbedded(t, signer, tc.embed)
+
+			extracted, err := ExtractManifestStore(asset)
+			if err != nil {
+				t.Fatalf("extract: %v", err)
+			}
+			if !bytes.Equal(extracted, store) {
+				t.Fatal("expected the embedded manifest store back")
+			}
+
+			report := Verify(asset, nil, Options{Trust: ca})
+			if report.Status != StatusVerified || report.Format != tc.format {
+				t.Fatalf("expected a verified %s, got %s %s: %+v", tc.format, report.Status, report.Format, report.Manifests)
+			}
+
+			// Every truncation of the image has to come back as a report,
+			// not a panic, and none of them verify.
+			for i := 0; i < len(asset); i++ {
+				if report := Verify(asset[:i], nil, Options{Trust: ca}); report.Status == StatusVerified {
+					t.Fatalf("expected the image truncated to %d bytes not to verify", i)
+				}
+			}
+		})
+	}
+}
+
+func TestVerifyMalformedManifestStore(t *testing.T) {
+	ca := newTestCA(t, "verifiable-sn test CA")
+	signer := ca.issue(t, "Ada")
+	asset := []byte("pretend these are the bytes of a JPEG")
+	store := testStore(testManifest{label: "urn:uuid:valid", signer: signer, signedAt: time.Now(), asset: asset}.build(t))
+
+	nested := testSuperbox(cborUUID, "leaf")
+	for i := 0; i < maxSuperboxDepth+1; i++ {
+		nested = testSuperbox(cborUUID, "level", nested)
+	}
+	extendedSize := append([]byte{0, 0, 0, 1, 'j', 'u', 'm', 'b'}, make([]byte, 8)...)
+	binary.BigEndian.PutUint64(extendedSize[8:], 1<<40)
+
+	for _, tc := range []struct {
+		name  string
+		store []byte
+	}{
+		{name: "empty", store: []byte{}},
+		{name: "truncated box header", store: store[:5]},
+		{name: "size past the end", store: append([]byte{0, 0, 0xff, 0xff}, store[4:64]...)},
+		{name: "size smaller than its header", store: append([]byte{0, 0, 0, 4}, store[4:]...)},
+		{name: "truncated extended size", store: []byte{0, 0, 0, 1, 'j', 'u', 'm', 'b', 0, 0}},
+		{name: "extended size past the end", store: extendedSize},
+		{name: "two top-level boxes", store: append(append([]byte{}, store...), store...)},
+		{name: "not a superbox", store: testBox("free", store[8:])},
+		{name: "missing description box", store: testBox("jumb", testBox("cbor", []byte{0xa0}))},
+		{name: "truncated description box", store: testBox("jumb", testBox("jumd", manifestStoreUUID[:8]))},
+		{name: "unterminated label", store: testBox("jumb", testBox("jumd", append(append(manifestStoreUUID[:], 0x03), "c2pa"...)))},
+		{name: "wrong store type", store: testSuperbox(cborUUID, manifestStoreLabel, testSuperbox(manifestUUID, "urn:uuid:valid"))},
+		{name: "no manifests", store: testSuperbox(manifestStoreUUID, manifestStoreLabel)},
+		{name: "nested too deep", store: testSuperbox(manifestStoreUUID, manifestStoreLabel, nested)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			report := Verify(asset, tc.store, Options{Trust: ca})
+			if report.Status != StatusInvalid {
+				t.Fatalf("expected the store to be invalid, got %s", report.Status)
+			}
+			if len(report.Results) != 1 || report.Results[0].Code != CodeManifestStoreMalformed {
+				t.Fatalf("expected %s, got %+v", CodeManifestStoreMalformed, report.Results)
+			}
+		})
+	}
+
+	if report := Verify(asset, store, Options{Trust: ca}); report.Status != StatusVerified {
+		t.Fatalf("expected the untouched store to verify, got %s: %+v", report.Status, report.Manifests)
+	}
+	for i := 0; i < len(store); i++ {
+		if report := Verify(asset, store[:i], Options{Trust: ca}); report.Status == StatusVerified {
+			t.Fatalf("expected the store truncated to %d bytes not to verify", i)
+		}
+	}
+}
@@ -0,0 +1,755 @@
+package c2pa
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// Status summarises a verification.
+type Status string
+
+const (
+	// StatusVerified means every check passed and the active manifest was
+	// signed by a certificate from our CA that was good at signing time.
+	StatusVerified Status = "verified"
+	// StatusUntrusted means the manifest is intact and correctly signed, but
+	// by a certificate that doesn't chain to our CA.
+	StatusUntrusted Status = "untrusted"
+	// StatusInvalid means a signature, hash or certificate check failed.
+	StatusInvalid Status = "invalid"
+	// StatusUnsigned means the asset carries no manifest.
+	StatusUnsigned Status = "unsigned"
+	// StatusLegacy marks posts signed with the app's original CBOR manifest,
+	// which predates C2PA signing. Its certificate can be checked but it
+	// carries no signature to verify.
+	StatusLegacy Status = "legacy"
+)
+
+// Validation codes, from the C2PA specification's validation status codes
+// where it has one.
+const (
+	CodeClaimMissing                = "claim.missing"
+	CodeClaimMalformed              = "claim.malformed"
+	CodeClaimSignatureMissing       = "claimSignature.missing"
+	CodeClaimSignatureValidated     = "claimSignature.validated"
+	CodeClaimSignatureMismatch      = "claimSignature.mismatch"
+	CodeSigningCredentialTrusted    = "signingCredential.trusted"
+	CodeSigningCredentialUntrusted  = "signingCredential.untrusted"
+	CodeSigningCredentialInvalid    = "signingCredential.invalid"
+	CodeSigningCredentialExpired    = "signingCredential.expired"
+	CodeSigningCredentialRevoked    = "signingCredential.revoked"
+	CodeSigningTimeInvalid          = "signingTime.invalid"
+	CodeAlgorithmUnsupported        = "algorithm.unsupported"
+	CodeAssertionMissing            = "assertion.missing"
+	CodeAssertionHashedURIMatch     = "assertion.hashedURI.match"
+	CodeAssertionHashedURIMismatch  = "assertion.hashedURI.mismatch"
+	CodeAssertionDataHashMatch      = "assertion.dataHash.match"
+	CodeAssertionDataHashMismatch   = "assertion.dataHash.mismatch"
+	CodeAssertionDataHashMalformed  = "assertion.dataHash.malformed"
+	CodeHardBindingsMissing         = "claim.hardBindings.missing"
+	CodeHardBindingUnsupported      = "hardBinding.unsupported"
+	CodeIngredientHashedURIMatch    = "ingredient.hashedURI.match"
+	CodeIngredientHashedURIMismatch = "ingredient.hashedURI.mismatch"
+	CodeIngredientManifestMissing   = "ingredient.manifest.missing"
+	CodeIngredientManifestInvalid   = "ingredient.manifest.invalid"
+	CodeIngredientManifestTooDeep   = "ingredient.manifest.tooDeep"
+	CodeManifestStoreMalformed      = "manifestStore.malformed"
+	CodeManifestStoreMissing        = "manifestStore.missing"
+)
+
+// JUMBF labels C2PA assigns to the parts of a manifest.
+const (
+	manifestStoreLabel     = "c2pa"
+	claimLabel             = "c2pa.claim"
+	claimV2Label           = "c2pa.claim.v2"
+	signatureLabel         = "c2pa.signature"
+	actionsLabel           = "c2pa.actions"
+	dataHashLabel          = "c2pa.hash.data"
+	hardBindingLabelPrefix = "c2pa.hash."
+	ingredientLabel        = "c2pa.ingredient"
+	jumbfURIPrefix         = "self#jumbf="
+)
+
+const (
+	defaultClaimHashAlgorithm = "sha256"
+	// maxIngredientDepth bounds how far back through ingredients' own
+	// ingredients verification goes.
+	maxIngredientDepth = 8
+)
+
+// A manifest's signing time is only what its actions claim, so it is
+// believed for at most ClaimedTimeWindow before we receive the manifest,
+// and not at all when it is more than MaxClockSkew after.
+const (
+	ClaimedTimeWindow = 24 * time.Hour
+	MaxClockSkew      = 5 * time.Minute
+)
+
+// SigningTime returns the time to check a signer's certificate as of: the
+// claimed signing time when it falls within ClaimedTimeWindow before
+// receivedAt, and receivedAt otherwise, so a revoked or expired certificate
+// can't be used by backdating its manifests. A claimed time in the future
+// is an error.
+func SigningTime(claimed *time.Time, receivedAt time.Time) (time.Time, error) {
+	if claimed == nil {
+		return receivedAt, nil
+	}
+	if claimed.After(receivedAt.Add(MaxClockSkew)) {
+		return time.Time{}, fmt.Errorf("claimed signing time %s is in the future", claimed.UTC().Format(time.RFC3339))
+	}
+	if claimed.Before(receivedAt.Add(-ClaimedTimeWindow)) || claimed.After(receivedAt) {
+		return receivedAt, nil
+	}
+	return *claimed, nil
+}
+
+// Report is the outcome of verifying an asset's manifest store.
+type Report struct {
+	Status Status `json:"status"`
+	// Format is the asset's media type, when it's one we can read.
+	Format string `json:"format,omitempty"`
+	// ActiveManifest is the label of the manifest describing the asset
+	// itself; the others are its ingredients' manifests.
+	ActiveManifest string `json:"activeManifest,omitempty"`
+	// ManifestStoreHash is the hex SHA-256 of the manifest store, which is
+	// what posts anchor on chain.
+	ManifestStoreHash string           `json:"manifestStoreHash,omitempty"`
+	Manifests         []ManifestReport `json:"manifests"`
+	// Results holds failures that aren't specific to one manifest.
+	Results    []Result  `json:"results,omitempty"`
+	VerifiedAt time.Time `json:"verifiedAt"`
+}
+
+// ManifestReport describes one manifest and every check made on it.
+type ManifestReport struct {
+	Label          string       `json:"label"`
+	Status         Status       `json:"status"`
+	ClaimGenerator string       `json:"claimGenerator,omitempty"`
+	Title          string       `json:"title,omitempty"`
+	Format         string       `json:"format,omitempty"`
+	InstanceID     string       `json:"instanceId,omitempty"`
+	Signer         *Signer      `json:"signer,omitempty"`
+	SignedAt       *time.Time   `json:"signedAt,omitempty"`
+	Assertions     []string     `json:"assertions"`
+	Ingredients    []Ingredient `json:"ingredients,omitempty"`
+	Results        []Result     `json:"results"`
+}
+
+// Signer describes the certificate a claim was signed with.
+type Signer struct {
+	Subject      string    `json:"subject"`
+	Issuer       string    `json:"issuer"`
+	SerialNumber string    `json:"serialNumber"`
+	Fingerprint  string    `json:"fingerprint"`
+	NotBefore    time.Time `json:"notBefore"`
+	NotAfter     time.Time `json:"notAfter"`
+	Algorithm    string    `json:"algorithm,omitempty"`
+	Trusted      bool      `json:"trusted"`
+}
+
+// NewSigner describes certificate, untrusted until shown otherwise.
+func NewSigner(certificate *x509.Certificate) *Signer {
+	fingerprint := sha256.Sum256(certificate.Raw)
+	return &Signer{
+		Subject:      certificate.Subject.String(),
+		Issuer:       certificate.Issuer.String(),
+		SerialNumber: certificate.SerialNumber.Text(16),
+		Fingerprint:  hex.EncodeToString(fingerprint[:]),
+		NotBefore:    certificate.NotBefore,
+		NotAfter:     certificate.NotAfter,
+	}
+}
+
+// Ingredient is an asset the manifest's asset was made from.
+type Ingredient struct {
+	Title        string `json:"title,omitempty"`
+	Format       string `json:"format,omitempty"`
+	Relationship string `json:"relationship,omitempty"`
+	// Manifest is the label of the ingredient's own manifest, if it had one.
+	Manifest string `json:"manifest,omitempty"`
+	Status   Status `json:"status,omitempty"`
+}
+
+// Result is a single check.
+type Result struct {
+	Code        string `json:"code"`
+	Success     bool   `json:"success"`
+	URL         string `json:"url,omitempty"`
+	Explanation string `json:"explanation,omitempty"`
+}
+
+// Active returns the report for the active manifest, or nil.
+func (r *Report) Active() *ManifestReport {
+	for i := range r.Manifests {
+		if r.Manifests[i].Label == r.ActiveManifest {
+			return &r.Manifests[i]
+		}
+	}
+	return nil
+}
+
+// TrustAnchor checks that a signing certificate chains to a root we trust.
+// cert.Client satisfies it.
+type TrustAnchor interface {
+	VerifyCertificate(certificateDER []byte, at time.Time) (*x509.Certificate, error)
+}
+
+type Options struct {
+	Trust TrustAnchor
+	// CheckStatus, if set, is called for trusted signers with the SHA-256
+	// fingerprint of their certificate and the signing time. It returns an
+	// error if the certificate couldn't sign at that time, e.g. because it
+	// had been revoked.
+	CheckStatus func(fingerprint []byte, signedAt time.Time) error
+}
+
+// Verify checks the manifest store embedded in asset, or sidecar when it's
+// non-nil, against the asset's bytes.
+//
+// C2PA has no trusted signing time short of an RFC 3161 timestamp, which we
+// don't verify, so each manifest's certificate is checked as of the latest
+// action it records only when SigningTime believes it, and as of now
+// otherwise.
+func Verify(asset []byte, sidecar []byte, opts Options) *Report {
+	report := &Report{
+		Format:     detectFormat(asset),
+		Manifests:  []ManifestReport{},
+		VerifiedAt: time.Now(),
+	}
+
+	storeBytes := sidecar
+	if storeBytes == nil {
+		extracted, err := ExtractManifestStore(asset)
+		if err != nil {
+			return report.fail(StatusInvalid, CodeManifestStoreMalformed, err.Error())
+		}
+		if extracted == nil {
+			return report.fail(StatusUnsigned, CodeManifestStoreMissing, "the asset carries no C2PA manifest")
+		}
+		storeBytes = extracted
+	}
+	storeHash := sha256.Sum256(storeBytes)
+	report.ManifestStoreHash = hex.EncodeToString(storeHash[:])
+
+	store, err := parseManifestStore(storeBytes)
+	if err != nil {
+		return report.fail(StatusInvalid, CodeManifestStoreMalformed, err.Error())
+	}
+
+	v := &verifier{store: store, opts: opts, receivedAt: report.VerifiedAt, reports: map[string]*ManifestReport{}}
+	active := store.root.children[len(store.root.children)-1]
+	report.ActiveManifest = active.label
+	v.verifyManifest(active, asset, 0)
+
+	for _, m := range v.order {
+		report.Manifests = append(report.Manifests, *v.reports[m])
+	}
+	report.Status = v.reports[active.label].Status
+	return report
+}
+
+func (r *Report) fail(status Status, code string, explanation string) *Report {
+	r.Status = status
+	r.Results = append(r.Results, Result{Code: code, Explanation: explanation})
+	return r
+}
+
+type manifestStore struct {
+	root *superbox
+}
+
+func parseManifestStore(data []byte) (*manifestStore, error) {
+	boxes, err := readBoxes(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(boxes) != 1 || boxes[0].boxType != "jumb" {
+		return nil, fmt.Errorf("manifest store must be a single JUMBF superbox")
+	}
+	root, err := parseSuperbox(boxes[0].payload, 0)
+	if err != nil {
+		return nil, err
+	}
+	if root.typeUUID != manifestStoreUUID || root.label != manifestStoreLabel {
+		return nil, fmt.Errorf("JUMBF superbox %q is not a C2PA manifest store", root.label)
+	}
+	if len(root.children) == 0 {
+		return nil, fmt.Errorf("manifest store has no manifests")
+	}
+	return &manifestStore{root: root}, nil
+}
+
+// resolve finds the superbox a JUMBF URI points at. Relative URIs are
+// relative to the manifest that contains them.
+func (s *manifestStore) resolve(manifest *superbox, uri string) *superbox {
+	path, ok := strings.CutPrefix(uri, jumbfURIPrefix)
+	if !ok {
+		return nil
+	}
+
+	current := manifest
+	if strings.HasPrefix(path, "/") {
+		current = &superbox{children: []*superbox{s.root}}
+	}
+	for _, label := range strings.Split(strings.Trim(path, "/"), "/") {
+		if label == "" {
+			continue
+		}
+		if current = current.child(label); current == nil {
+			return nil
+		}
+	}
+	return current
+}
+
+type hashedURI struct {
+	URL  string `cbor:"url"`
+	Alg  string `cbor:"alg,omitempty"`
+	Hash []byte `cbor:"hash"`
+}
+
+type claim struct {
+	ClaimGenerator     string          `cbor:"claim_generator"`
+	ClaimGeneratorInfo cbor.RawMessage `cbor:"claim_generator_info"`
+	Assertions         []hashedURI     `cbor:"assertions"`
+	CreatedAssertions  []hashedURI     `cbor:"created_assertions"`
+	GatheredAssertions []hashedURI     `cbor:"gathered_assertions"`
+	Alg                string          `cbor:"alg"`
+	Format             string          `cbor:"dc:format"`
+	Title              string          `cbor:"dc:title"`
+	InstanceID         string          `cbor:"instanceID"`
+}
+
+// generator returns the claim generator, which v2 claims describe with a
+// claim_generator_info map and v1 claims with a string.
+func (c *claim) generator() string {
+	if c.ClaimGenerator != "" {
+		return c.ClaimGenerator
+	}
+	type info struct {
+		Name    string `cbor:"name"`
+		Version string `cbor:"version"`
+	}
+	var single info
+	if err := cbor.Unmarshal(c.ClaimGeneratorInfo, &single); err != nil || single.Name == "" {
+		var list []info
+		if err := cbor.Unmarshal(c.ClaimGeneratorInfo, &list); err != nil || len(list) == 0 {
+			return ""
+		}
+		single = list[0]
+	}
+	return strings.TrimSpace(single.Name + " " + single.Version)
+}
+
+func (c *claim) assertionRefs() []hashedURI {
+	refs := append([]hashedURI{}, c.Assertions...)
+	refs = append(refs, c.CreatedAssertions...)
+	return append(refs, c.GatheredAssertions...)
+}
+
+type dataHash struct {
+	Exclusions []struct {
+		Start  int64 `cbor:"start"`
+		Length int64 `cbor:"length"`
+	} `cbor:"exclusions"`
+	Alg  string `cbor:"alg"`
+	Hash []byte `cbor:"hash"`
+}
+
+type actions struct {
+	Actions []struct {
+		Action string      `cbor:"action"`
+		When   interface{} `cbor:"when"`
+	} `cbor:"actions"`
+}
+
+type ingredient struct {
+	Title          string     `cbor:"dc:title"`
+	Format         string     `cbor:"dc:format"`
+	Relationship   string     `cbor:"relationship"`
+	C2PAManifest   *hashedURI `cbor:"c2pa_manifest"`
+	ActiveManifest *hashedURI `cbor:"activeManifest"`
+}
+
+type resolvedAssertion struct {
+	ref hashedURI
+	box *superbox
+}
+
+type verifier struct {
+	store *manifestStore
+	opts  Options
+	// receivedAt is the trusted time signing times are bounded by.
+	receivedAt time.Time
+	reports    map[string]*ManifestReport
+	order      []string
+}
+
+// verifyManifest checks a manifest's claim signature, assertions and
+// ingredients. asset is only given for the active manifest, whose hard
+// binding ties it to the asset's bytes; ingredients' bytes aren't present.
+func (v *verifier) verifyManifest(manifest *superbox, asset []byte, depth int) *ManifestReport {
+	report := &ManifestReport{Label: manifest.label, Assertions: []string{}, Results: []Result{}}
+	v.reports[manifest.label] = report
+	v.order = append(v.order, manifest.label)
+	defer func() { report.Status = manifestStatus(report.Results) }()
+
+	claimBox := manifest.child(claimV2Label)
+	if claimBox == nil {
+		claimBox = manifest.child(claimLabel)
+	}
+	var claimBytes []byte
+	if claimBox != nil {
+		claimBytes = claimBox.content("cbor")
+	}
+	if claimBytes == nil {
+		report.add(false, CodeClaimMissing, "", "the manifest has no claim")
+		return report
+	}
+	var c claim
+	if err := cbor.Unmarshal(claimBytes, &c); err != nil {
+		report.add(false, CodeClaimMalformed, "", err.Error())
+		return report
+	}
+	report.ClaimGenerator = c.generator()
+	report.Title = c.Title
+	report.Format = c.Format
+	report.InstanceID = c.InstanceID
+	claimAlg := c.Alg
+	if claimAlg == "" {
+		claimAlg = defaultClaimHashAlgorithm
+	}
+
+	// Assertions first, so the signing time is known before the
+	// certificate is checked.
+	var signedAt *time.Time
+	var hardBindings []resolvedAssertion
+	var ingredients []*superbox
+	for _, ref := range c.assertionRefs() {
+		report.Assertions = append(report.Assertions, ref.URL[strings.LastIndex(ref.URL, "/")+1:])
+
+		assertion := v.store.resolve(manifest, ref.URL)
+		if assertion == nil {
+			report.add(false, CodeAssertionMissing, ref.URL, "the claim references an assertion the manifest doesn't contain")
+			continue
+		}
+		if !v.checkHashedURI(report, ref, assertion, claimAlg, CodeAssertionHashedURIMatch, CodeAssertionHashedURIMismatch) {
+			continue
+		}
+
+		switch base := baseLabel(assertion.label); {
+		case base == actionsLabel:
+			if when := latestAction(assertion.content("cbor")); when != nil && (signedAt == nil || when.After(*signedAt)) {
+				signedAt = when
+			}
+		case strings.HasPrefix(base, hardBindingLabelPrefix):
+			hardBindings = append(hardBindings, resolvedAssertion{ref: ref, box: assertion})
+		case base == ingredientLabel:
+			ingredients = append(ingredients, assertion)
+		}
+	}
+	report.SignedAt = signedAt
+
+	v.verifySignature(report, manifest, claimBytes)
+
+	if asset != nil {
+		v.verifyHardBinding(report, hardBindings, asset, claimAlg)
+	}
+
+	for _, assertion := range ingredients {
+		v.verifyIngredient(report, manifest, assertion, claimAlg, depth)
+	}
+
+	return report
+}
+
+func (v *verifier) verifySignature(report *ManifestReport, manifest *superbox, claimBytes []byte) {
+	signatureBox := manifest.child(signatureLabel)
+	var signatureBytes []byte
+	if signatureBox != nil {
+		signatureBytes = signatureBox.content("cbor")
+	}
+	if signatureBytes == nil {
+		report.add(false, CodeClaimSignatureMissing, "", "the manifest has no claim signature")
+		return
+	}
+
+	sign1, err := parseCoseSign1(signatureBytes)
+	if err != nil {
+		report.add(false, CodeClaimSignatureMismatch, signatureLabel, err.Error())
+		return
+	}
+	alg, err := sign1.algorithm()
+	if err != nil {
+		report.add(false, CodeAlgorithmUnsupported, signatureLabel, err.Error())
+		return
+	}
+	chain, err := sign1.certificateChain()
+	if err != nil {
+		report.add(false, CodeSigningCredentialInvalid, signatureLabel, err.Error())
+		return
+	}
+	certificate, err := x509.ParseCertificate(chain[0])
+	if err != nil {
+		report.add(false, CodeSigningCredentialInvalid, signatureLabel, fmt.Sprintf("failed to parse signing certificate: %v", err))
+		return
+	}
+
+	fingerprint := sha256.Sum256(chain[0])
+	report.Signer = NewSigner(certificate)
+	report.Signer.Algorithm = algorithmNames[alg]
+
+	payload := claimBytes
+	if sign1.Payload != nil && !bytes.Equal(sign1.Payload, claimBytes) {
+		report.add(false, CodeClaimSignatureMismatch, signatureLabel, "the signature covers a different payload than the claim")
+		return
+	}
+	if err := sign1.verify(alg, certificate, payload); err != nil {
+		report.add(false, CodeClaimSignatureMismatch, signatureLabel, err.Error())
+		return
+	}
+	report.add(true, CodeClaimSignatureValidated, signatureLabel, "")
+
+	signedAt, err := SigningTime(report.SignedAt, v.receivedAt)
+	if err != nil {
+		report.add(false, CodeSigningTimeInvalid, signatureLabel, err.Error())
+		return
+	}
+	if v.opts.Trust == nil {
+		report.add(false, CodeSigningCredentialUntrusted, signatureLabel, "no trust anchor is configured")
+		return
+	}
+	if _, err := v.opts.Trust.VerifyCertificate(chain[0], signedAt); err != nil {
+		var invalid x509.CertificateInvalidError
+		if errors.As(err, &invalid) && invalid.Reason == x509.Expired {
+			report.add(false, CodeSigningCredentialExpired, signatureLabel, err.Error())
+			return
+		}
+		report.add(false, CodeSigningCredentialUntrusted, signatureLabel, err.Error())
+		return
+	}
+	if v.opts.CheckStatus != nil {
+		if err := v.opts.CheckStatus(fingerprint[:], signedAt); err != nil {
+			report.add(false, CodeSigningCredentialRevoked, signatureLabel, err.Error())
+			return
+		}
+	}
+	report.Signer.Trusted = true
+	report.add(true, CodeSigningCredentialTrusted, signatureLabel, "")
+}
+
+// verifyHardBinding checks the asset's bytes against the claim. Only data
+// hashes, which cover JPEG and PNG, are supported; video uses BMFF hashes.
+func (v *verifier) verifyHardBinding(report *ManifestReport, bindings []resolvedAssertion, asset []byte, claimAlg string) {
+	if len(bindings) == 0 {
+		report.add(false, CodeHardBindingsMissing, "", "the claim doesn't bind to the asset's bytes")
+		return
+	}
+
+	for _, b := range bindings {
+		ref := b.ref
+		if baseLabel(b.box.label) != dataHashLabel {
+			report.add(false, CodeHardBindingUnsupported, ref.URL, "only c2pa.hash.data bindings can be verified")
+			continue
+		}
+
+		var binding dataHash
+		if err := cbor.Unmarshal(b.box.content("cbor"), &binding); err != nil {
+			report.add(false, CodeAssertionDataHashMalformed, ref.URL, err.Error())
+			continue
+		}
+		alg := binding.Alg
+		if alg == "" {
+			alg = claimAlg
+		}
+		h, err := newHash(alg)
+		if err != nil {
+			report.add(false, CodeAlgorithmUnsupported, ref.URL, err.Error())
+			continue
+		}
+
+		// The exclusions cover the manifest store itself, which can't hash
+		// its own bytes.
+		sort.Slice(binding.Exclusions, func(i, j int) bool { return binding.Exclusions[i].Start < binding.Exclusions[j].Start })
+		pos := int64(0)
+		malformed := false
+		for _, exclusion := range binding.Exclusions {
+			// Compared without adding, so huge values can't overflow past
+			// the bounds check.
+			if exclusion.Start < pos || exclusion.Length < 0 || exclusion.Start > int64(len(asset)) || exclusion.Length > int64(len(asset))-exclusion.Start {
+				malformed = true
+				break
+			}
+			h.Write(asset[pos:exclusion.Start])
+			pos = exclusion.Start + exclusion.Length
+		}
+		if malformed {
+			report.add(false, CodeAssertionDataHashMalformed, ref.URL, "exclusion ranges overlap or fall outside the asset")
+			continue
+		}
+		h.Write(asset[pos:])
+
+		if !bytes.Equal(h.Sum(nil), binding.Hash) {
+			report.add(false, CodeAssertionDataHashMismatch, ref.URL, "the asset has been modified since it was signed")
+			continue
+		}
+		report.add(true, CodeAssertionDataHashMatch, ref.URL, "")
+	}
+}
+
+func (v *verifier) verifyIngredient(report *ManifestReport, manifest *superbox, assertion *superbox, claimAlg string, depth int) {
+	var in ingredient
+	if err := cbor.Unmarshal(assertion.content("cbor"), &in); err != nil {
+		report.add(false, CodeClaimMalformed, assertion.label, fmt.Sprintf("malformed ingredient: %v", err))
+		return
+	}
+	entry := Ingredient{Title: in.Title, Format: in.Format, Relationship: in.Relationship}
+	defer func() { report.Ingredients = append(report.Ingredients, entry) }()
+
+	ref := in.ActiveManifest
+	if ref == nil {
+		ref = in.C2PAManifest
+	}
+	if ref == nil {
+		// The ingredient had no provenance of its own.
+		return
+	}
+
+	ingredientManifest := v.store.resolve(manifest, ref.URL)
+	if ingredientManifest == nil {
+		report.add(false, CodeIngredientManifestMissing, ref.URL, "the ingredient's manifest isn't in the manifest store")
+		return
+	}
+	entry.Manifest = ingredientManifest.label
+	if !v.checkHashedURI(report, *ref, ingredientManifest, claimAlg, CodeIngredientHashedURIMatch, CodeIngredientHashedURIMismatch) {
+		return
+	}
+
+	ingredientReport, seen := v.reports[ingredientManifest.label]
+	if !seen {
+		if depth >= maxIngredientDepth {
+			report.add(false, CodeIngredientManifestTooDeep, ref.URL, fmt.Sprintf("ingredients are nested more than %d deep", maxIngredientDepth))
+			return
+		}
+		ingredientReport = v.verifyManifest(ingredientManifest, nil, depth+1)
+	}
+	entry.Status = ingredientReport.Status
+
+	// An ingredient from someone else's camera or editor can be untrusted,
+	// but one whose signature or hashes don't check out taints the asset.
+	if ingredientReport.Status == StatusInvalid {
+		report.add(false, CodeIngredientManifestInvalid, ref.URL, fmt.Sprintf("the manifest of ingredient %q failed verification", in.Title))
+	}
+}
+
+func (v *verifier) checkHashedURI(report *ManifestReport, ref hashedURI, target *superbox, claimAlg string, matchCode string, mismatchCode string) bool {
+	alg := ref.Alg
+	if alg == "" {
+		alg = claimAlg
+	}
+	h, err := newHash(alg)
+	if err != nil {
+		report.add(false, CodeAlgorithmUnsupported, ref.URL, err.Error())
+		return false
+	}
+	h.Write(target.payload)
+	if !bytes.Equal(h.Sum(nil), ref.Hash) {
+		report.add(false, mismatchCode, ref.URL, "the content doesn't match the hash the claim recorded")
+		return false
+	}
+	report.add(true, matchCode, ref.URL, "")
+	return true
+}
+
+func (r *ManifestReport) add(success bool, code string, url string, explanation string) {
+	r.Results = append(r.Results, Result{Code: code, Success: success, URL: url, Explanation: explanation})
+}
+
+func manifestStatus(results []Result) Status {
+	status := StatusVerified
+	for _, result := range results {
+		if result.Success {
+			continue
+		}
+		if result.Code != CodeSigningCredentialUntrusted {
+			return StatusInvalid
+		}
+		status = StatusUntrusted
+	}
+	return status
+}
+
+// baseLabel strips the version and instance suffixes from an assertion
+// label, so "c2pa.ingredient.v3__2" becomes "c2pa.ingredient".
+func baseLabel(label string) string {
+	if i := strings.Index(label, "__"); i >= 0 {
+		label = label[:i]
+	}
+	if i := strings.LastIndex(label, ".v"); i >= 0 && isDigits(label[i+2:]) {
+		label = label[:i]
+	}
+	return label
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func latestAction(data []byte) *time.Time {
+	var a actions
+	if err := cbor.Unmarshal(data, &a); err != nil {
+		return nil
+	}
+	var latest *time.Time
+	for _, action := range a.Actions {
+		var when time.Time
+		switch w := action.When.(type) {
+		case time.Time:
+			when = w
+		case string:
+			parsed, err := time.Parse(time.RFC3339, w)
+			if err != nil {
+				continue
+			}
+			when = parsed
+		default:
+			continue
+		}
+		if latest == nil || when.After(*latest) {
+			latest = &when
+		}
+	}
+	return latest
+}
+
+func newHash(alg string) (hash.Hash, error) {
+	switch alg {
+	case "sha256":
+		return sha256.New(), nil
+	case "sha384":
+		return sha512.New384(), nil
+	case "sha512":
+		return sha512.New(), nil
+	default:
+		return nil, fmt.Errorf("unsupported hash algorithm %q", alg)
+	}
+}
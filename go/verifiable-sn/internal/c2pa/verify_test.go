@@ -0,0 +1,257 @@
+package c2pa
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func resultCodes(report *ManifestReport, success bool) map[string]bool {
+	codes := map[string]bool{}
+	for _, result := range report.Results {
+		if result.Success == success {
+			codes[result.Code] = true
+		}
+	}
+	return codes
+}
+
+func fingerprintOf(signer *testSigner) []byte {
+	fingerprint := sha256.Sum256(signer.chain[0])
+	return fingerprint[:]
+}
+
+// testChain builds n manifests, each an ingredient of the next, with the
+// last one active and bound to asset when it's given.
+func testChain(t *testing.T, signer *testSigner, signedAt time.Time, n int, asset []byte) []byte {
+	t.Helper()
+	var manifests [][]byte
+	var previous *testIngredient
+	for i := 0; i < n; i++ {
+		m := testManifest{label: fmt.Sprintf("urn:uuid:chain-%d", i), signer: signer, signedAt: signedAt}
+		if previous != nil {
+			m.ingredients = []testIngredient{*previous}
+		}
+		if i == n-1 {
+			m.asset = asset
+		}
+		built := m.build(t)
+		manifests = append(manifests, built)
+		previous = &testIngredient{label: m.label, manifest: built}
+	}
+	return testStore(manifests...)
+}
+
+func TestVerify(t *testing.T) {
+	ca := newTestCA(t, "verifiable-sn test CA")
+	signer := ca.issue(t, "Ada")
+	revoked := ca.issue(t, "Mallory")
+	stranger := newTestCA(t, "someone else's CA").issue(t, "Eve")
+
+	asset := []byte("pretend these are the bytes of a JPEG")
+	recently := time.Now().Add(-time.Hour)
+
+	for _, tc := range []struct {
+		name    string
+		store   func(t *testing.T) []byte
+		asset   []byte
+		status  Status
+		passed  []string
+		failed  []string
+		signers int
+	}{
+		{
+			name: "valid manifest",
+			store: func(t *testing.T) []byte {
+				return testStore(testManifest{label: "urn:uuid:valid", signer: signer, signedAt: recently, asset: asset}.build(t))
+			},
+			status: StatusVerified,
+			passed: []string{CodeClaimSignatureValidated, CodeSigningCredentialTrusted, CodeAssertionHashedURIMatch, CodeAssertionDataHashMatch},
+		},
+		{
+			name: "tampered claim",
+			store: func(t *testing.T) []byte {
+				return testStore(testManifest{
+					label: "urn:uuid:tampered", signer: signer, signedAt: recently, asset: asset,
+					tamper: func(claim map[string]interface{}) { claim["dc:title"] = "someone else's photo.jpg" },
+				}.build(t))
+			},
+			status: StatusInvalid,
+			failed: []string{CodeClaimSignatureMismatch},
+		},
+		{
+			name: "wrong asset hash",
+			store: func(t *testing.T) []byte {
+				return testStore(testManifest{label: "urn:uuid:edited", signer: signer, signedAt: recently, asset: asset}.build(t))
+			},
+			asset:  []byte("pretend these are the bytes of an edited JPEG"),
+			status: StatusInvalid,
+			failed: []string{CodeAssertionDataHashMismatch},
+		},
+		{
+			name: "malformed exclusions",
+			store: func(t *testing.T) []byte {
+				return testStore(testManifest{
+					label: "urn:uuid:excluded", signer: signer, signedAt: recently, asset: asset,
+					exclusions: []testExclusion{{Start: 1 << 62, Length: 1 << 62}},
+				}.build(t))
+			},
+			status: StatusInvalid,
+			failed: []string{CodeAssertionDataHashMalformed},
+		},
+		{
+			name: "untrusted root",
+			store: func(t *testing.T) []byte {
+				return testStore(testManifest{label: "urn:uuid:stranger", signer: stranger, signedAt: recently, asset: asset}.build(t))
+			},
+			status: StatusUntrusted,
+			passed: []string{CodeClaimSignatureValidated, CodeAssertionDataHashMatch},
+			failed: []string{CodeSigningCredentialUntrusted},
+		},
+		{
+			name: "revoked signer",
+			store: func(t *testing.T) []byte {
+				return testStore(testManifest{label: "urn:uuid:revoked", signer: revoked, signedAt: recently, asset: asset}.build(t))
+			},
+			status: StatusInvalid,
+			passed: []string{CodeClaimSignatureValidated},
+			failed: []string{CodeSigningCredentialRevoked},
+		},
+		{
+			name: "signing time in the future",
+			store: func(t *testing.T) []byte {
+				return testStore(testManifest{label: "urn:uuid:future", signer: signer, signedAt: time.Now().Add(time.Hour), asset: asset}.build(t))
+			},
+			status: StatusInvalid,
+			failed: []string{CodeSigningTimeInvalid},
+		},
+		{
+			name: "trusted ingredient",
+			store: func(t *testing.T) []byte {
+				return testChain(t, signer, recently, 2, asset)
+			},
+			status:  StatusVerified,
+			passed:  []string{CodeIngredientHashedURIMatch},
+			signers: 2,
+		},
+		{
+			name: "ingredient chain too deep",
+			store: func(t *testing.T) []byte {
+				return testChain(t, signer, recently, maxIngredientDepth+2, asset)
+			},
+			status:  StatusInvalid,
+			failed:  []string{CodeIngredientManifestInvalid},
+			signers: maxIngredientDepth + 1,
+		},
+		{
+			name: "ingredient cycle",
+			store: func(t *testing.T) []byte {
+				// Two manifests can't hash each other, so a cycle always
+				// breaks on a hash somewhere; what matters is that it ends.
+				a := testManifest{label: "urn:uuid:a", signer: signer, signedAt: recently}.build(t)
+				b := testManifest{label: "urn:uuid:b", signer: signer, signedAt: recently, ingredients: []testIngredient{{label: "urn:uuid:a", manifest: a}}}.build(t)
+				a = testManifest{label: "urn:uuid:a", signer: signer, signedAt: recently, asset: asset, ingredients: []testIngredient{{label: "urn:uuid:b", manifest: b}}}.build(t)
+				return testStore(b, a)
+			},
+			status: StatusInvalid,
+			failed: []string{CodeIngredientManifestInvalid},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assetBytes := asset
+			if tc.asset != nil {
+				assetBytes = tc.asset
+			}
+			checked := 0
+			report := Verify(assetBytes, tc.store(t), Options{
+				Trust: ca,
+				CheckStatus: func(fingerprint []byte, signedAt time.Time) error {
+					checked++
+					if !signedAt.Equal(recently.Truncate(time.Second)) {
+						t.Errorf("expected the claimed signing time to be checked, got %s", signedAt)
+					}
+					if bytes.Equal(fingerprint, fingerprintOf(revoked)) {
+						return errors.New("revoked")
+					}
+					return nil
+				},
+			})
+
+			if report.Status != tc.status {
+				t.Fatalf("expected %s, got %s: %+v", tc.status, report.Status, report.Manifests)
+			}
+			active := report.Active()
+			if active == nil {
+				t.Fatal("expected a report for the active manifest")
+			}
+			passed, failed := resultCodes(active, true), resultCodes(active, false)
+			for _, code := range tc.passed {
+				if !passed[code] {
+					t.Errorf("expected %s to pass, got %+v", code, active.Results)
+				}
+			}
+			for _, code := range tc.failed {
+				if !failed[code] {
+					t.Errorf("expected %s to fail, got %+v", code, active.Results)
+				}
+			}
+			if tc.signers > 0 && checked != tc.signers {
+				t.Errorf("expected %d signers to be checked, got %d", tc.signers, checked)
+			}
+		})
+	}
+}
+
+func TestVerifyIngredientChainStopsAtMaxDepth(t *testing.T) {
+	ca := newTestCA(t, "verifiable-sn test CA")
+	signer := ca.issue(t, "Ada")
+
+	report := Verify(nil, testChain(t, signer, time.Now(), maxIngredientDepth+2, nil), Options{Trust: ca})
+	if len(report.Manifests) != maxIngredientDepth+1 {
+		t.Fatalf("expected %d manifests to be verified, got %d", maxIngredientDepth+1, len(report.Manifests))
+	}
+	deepest := report.Manifests[len(report.Manifests)-1]
+	if !resultCodes(&deepest, false)[CodeIngredientManifestTooDeep] {
+		t.Fatalf("expected the deepest manifest to stop at its ingredient, got %+v", deepest.Results)
+	}
+}
+
+func TestSigningTime(t *testing.T) {
+	receivedAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) *time.Time {
+		when := receivedAt.Add(d)
+		return &when
+	}
+
+	for _, tc := range []struct {
+		name    string
+		claimed *time.Time
+		want    time.Time
+		wantErr bool
+	}{
+		{name: "no claim", claimed: nil, want: receivedAt},
+		{name: "recent claim", claimed: at(-time.Hour), want: *at(-time.Hour)},
+		{name: "claim older than the window", claimed: at(-ClaimedTimeWindow - time.Second), want: receivedAt},
+		{name: "claim within the clock skew", claimed: at(time.Minute), want: receivedAt},
+		{name: "claim in the future", claimed: at(MaxClockSkew + time.Second), wantErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := SigningTime(tc.claimed, receivedAt)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %s", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !got.Equal(tc.want) {
+				t.Fatalf("expected %s, got %s", tc.want, got)
+			}
+		})
+	}
+}
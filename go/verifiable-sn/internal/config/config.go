@@ -10,6 +10,8 @@ import (
 type SecretConfig struct {
 	DbPassword            string
 	CAPrivateKey          string
+	CACertificate         string
+	CARootCertificate     string
 	InstagramClientSecret string
 	TwitterClientSecret   string
 }
@@ -23,6 +25,7 @@ type PublicConfig struct {
 	RedisUrl              string `mapstructure:"REDIS_URL"`
 	EthereumTransactorURL string `mapstructure:"ETHEREUM_TRANSACTOR_URL"`
 	C2PAContractAddress   string `mapstructure:"C2PA_CONTRACT_ADDRESS"`
+	CertificateBaseURL    string `mapstructure:"CERTIFICATE_BASE_URL"`
 	InstagramClientID     string `mapstructure:"INSTAGRAM_CLIENT_ID"`
 	InstagramRedirectURL  string `mapstructure:"INSTAGRAM_REDIRECT_URL"`
 	InstagramAuthURL      string `mapstructure:"INSTAGRAM_AUTH_URL"`
@@ -72,7 +75,9 @@ func ParseFlagsAndGetConfig() (*Config, error) {
 	return &Config{
 		Secret: SecretConfig{
 			DbPassword:            os.Getenv("DB_PASSWORD"),
-			CAPrivateKey:          os.Getenv("CA_PRIVATE_KEY"), // Optional - if empty, CA will be generated
+			CAPrivateKey:          os.Getenv("CA_PRIVATE_KEY"),      // Optional - if empty, CA will be generated
+			CACertificate:         os.Getenv("CA_CERTIFICATE"),      // Optional - an intermediate for CA_PRIVATE_KEY, issued by
+			CARootCertificate:     os.Getenv("CA_ROOT_CERTIFICATE"), // the root in CA_ROOT_CERTIFICATE whose key stays offline
 			InstagramClientSecret: os.Getenv("INSTAGRAM_CLIENT_SECRET"),
 			TwitterClientSecret:   os.Getenv("TWITTER_CLIENT_SECRET"),
 		},
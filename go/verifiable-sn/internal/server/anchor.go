@@ -0,0 +1,99 @@
+package server
+
+import (
+	"encoding/hex"
+	"net/http"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// manifestAnchorQueued is the status of a manifest waiting for its batch's
+// root to be sent.
+const manifestAnchorQueued = "queued"
+
+// GetPostAnchorProof returns the proof that a post's manifest is in a batch
+// anchored on chain. Anyone can check it offline: hash the leaf up through
+// the proof, sorting each pair, and compare against the root recorded by the
+// anchoring transaction, or call verifyManifestInclusion on the contract.
+func (s *server) GetPostAnchorProof(ctx *gin.Context) {
+	_, err := s.GetAuthenticatedUser(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	postID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid post id format",
+		})
+		return
+	}
+
+	post, err := s.dbClient.Post().FindByID(ctx, postID)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": "post not found",
+		})
+		return
+	}
+
+	if len(post.ManifestHash) == 0 {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": "post does not have a manifest hash",
+		})
+		return
+	}
+
+	anchor, err := s.dbClient.ManifestAnchor().FindByManifestHash(ctx, post.ManifestHash)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if anchor == nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": "manifest has not been queued for anchoring",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, manifestAnchorProof(anchor))
+}
+
+// manifestAnchorProof describes where a manifest's anchor stands, with its
+// inclusion proof once its batch has been cut.
+func manifestAnchorProof(anchor *models.ManifestAnchor) gin.H {
+	proof := gin.H{
+		"status":          manifestAnchorQueued,
+		"manifestHash":    "0x" + hex.EncodeToString(anchor.ManifestHash),
+		"certFingerprint": "0x" + hex.EncodeToString(anchor.CertFingerprint),
+		"leaf":            "0x" + hex.EncodeToString(anchor.LeafHash),
+	}
+
+	batch := anchor.Batch
+	if batch == nil {
+		return proof
+	}
+
+	proof["leafIndex"] = anchor.LeafIndex
+	proof["proof"] = anchor.Proof
+	proof["root"] = "0x" + hex.EncodeToString(batch.MerkleRoot)
+	proof["leafCount"] = batch.LeafCount
+	proof["chainId"] = batch.ChainID
+	proof["contractAddress"] = batch.ContractAddress
+
+	if tx := batch.BlockchainTransaction; tx != nil {
+		proof["status"] = tx.Status
+		proof["txHash"] = tx.TxHash
+		proof["blockNumber"] = tx.BlockNumber
+		proof["confirmedAt"] = tx.ConfirmedAt
+	}
+
+	return proof
+}
@@ -12,6 +12,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
 	"github.com/fxamacker/cbor/v2"
 )
 
@@ -287,6 +288,31 @@ func ValidateManifest(manifestBytes []byte) (manifestHash []byte, certFingerprin
 	return manifestHash, certFingerprint, nil
 }
 
+// CheckCertificateStatus checks that certificate could sign a manifest at
+// signedAt: inside its validity period and not revoked as of then. Note
+// that a key-compromise revocation applies however early signedAt is (see
+// models.UserCertificate.RevokedAsOf).
+func CheckCertificateStatus(certificate *models.UserCertificate, signedAt time.Time) error {
+	parsed, err := x509.ParseCertificate(certificate.Certificate)
+	if err != nil {
+		return fmt.Errorf("failed to parse certificate: %w", err)
+	}
+
+	if signedAt.Before(parsed.NotBefore) || signedAt.After(parsed.NotAfter) {
+		return fmt.Errorf("signed at %s, outside the certificate's validity period", signedAt.UTC().Format(time.RFC3339))
+	}
+
+	if certificate.RevokedAsOf(signedAt) {
+		reason := "unspecified"
+		if certificate.RevocationReason != nil {
+			reason = string(*certificate.RevocationReason)
+		}
+		return fmt.Errorf("certificate was revoked at %s (%s)", certificate.RevokedAt.UTC().Format(time.RFC3339), reason)
+	}
+
+	return nil
+}
+
 // DownloadManifestFromS3 downloads a manifest from an S3 URL
 func DownloadManifestFromS3(manifestURI string) ([]byte, error) {
 	// Parse URL
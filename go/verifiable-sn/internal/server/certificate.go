@@ -3,18 +3,20 @@ package server
 import (
 	"crypto"
 	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/sha256"
 	"crypto/x509"
 	"encoding/asn1"
 	"encoding/base64"
 	"encoding/hex"
-	"encoding/pem"
 	"fmt"
+	"io"
 	"math/big"
 	"net/http"
 	"strings"
 	"time"
 
+	"github.com/MaxBlaushild/poltergeist/pkg/cert"
 	ethereum_transactor "github.com/MaxBlaushild/poltergeist/pkg/ethereum_transactor"
 	"github.com/MaxBlaushild/poltergeist/pkg/models"
 	"github.com/ethereum/go-ethereum/accounts/abi"
@@ -73,7 +75,7 @@ func (s *server) EnrollCertificate(ctx *gin.Context) {
 		})
 		return
 	}
-	if existingCert != nil {
+	if existingCert != nil && time.Now().Before(existingCert.ExpiresAt) {
 		ctx.JSON(http.StatusBadRequest, gin.H{
 			"error": "user already has a certificate",
 		})
@@ -88,45 +90,128 @@ func (s *server) EnrollCertificate(ctx *gin.Context) {
 		return
 	}
 
-	// Parse the public key from PEM
-	block, _ := pem.Decode([]byte(requestBody.PublicKey))
-	if block == nil {
+	if err := verifyProofOfPossession(user.ID, requestBody); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "failed to decode public key PEM",
+			"error": err.Error(),
 		})
 		return
 	}
 
-	publicKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	// Issue certificate (1 year validity)
+	certificateDER, certificatePEM, fingerprint, err := s.certClient.IssueCertificate(requestBody.PublicKey, user.ID, certificateValidity)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": fmt.Sprintf("failed to parse public key: %v", err),
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("failed to issue certificate: %v", err),
 		})
 		return
 	}
 
-	// Verify proof of possession: decode and verify the challenge signature
-	challengeSignature, err := base64.StdEncoding.DecodeString(requestBody.ChallengeSignature)
+	// Store certificate in database (created as inactive by default)
+	_, err = s.dbClient.UserCertificate().Create(ctx, user.ID, certificateDER, certificatePEM, requestBody.PublicKey, fingerprint)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("failed to store certificate: %v", err),
+		})
+		return
+	}
+
+	if err := s.registerCertificateOnChain(ctx, certificateDER, fingerprint); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, EnrollCertificateResponse{
+		CertificatePEM: certificatePEM,
+		Fingerprint:    fmt.Sprintf("%x", fingerprint),
+		PublicKey:      requestBody.PublicKey,
+	})
+}
+
+// certificateValidity is how long issued certificates last, and
+// certificateRenewalWindow how long before expiry they can be renewed.
+const (
+	certificateValidity      = 365 * 24 * time.Hour
+	certificateRenewalWindow = 30 * 24 * time.Hour
+)
+
+// RenewCertificate issues a replacement for the user's current certificate
+// shortly before it expires. The device may keep its key or present a new
+// one; either way it proves possession as at enrollment. The replacement
+// supersedes the old certificate once its on-chain registration confirms,
+// and the old one stays in the user's history so earlier posts still verify.
+func (s *server) RenewCertificate(ctx *gin.Context) {
+	user, err := s.GetAuthenticatedUser(ctx)
 	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	current, err := s.dbClient.UserCertificate().FindByUserID(ctx, user.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if current == nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": "certificate not found",
+		})
+		return
+	}
+	if !current.Active {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "certificate is not active",
+		})
+		return
+	}
+	if !time.Now().Before(current.ExpiresAt) {
 		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": "failed to decode challenge signature",
+			"error": "certificate has expired; enroll a new one",
+		})
+		return
+	}
+	if time.Until(current.ExpiresAt) > certificateRenewalWindow {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("certificates can be renewed within %d days of expiry", int(certificateRenewalWindow.Hours()/24)),
 		})
 		return
 	}
 
-	// Create a challenge based on the user ID and public key
-	challenge := createChallenge(user.ID, requestBody.PublicKey)
+	pending, err := s.dbClient.UserCertificate().FindPendingRenewal(ctx, current.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if pending != nil {
+		ctx.JSON(http.StatusConflict, gin.H{
+			"error": "a renewal is already waiting to be registered",
+		})
+		return
+	}
 
-	// Verify the signature
-	if err := verifySignature(challenge, challengeSignature, publicKey); err != nil {
+	var requestBody EnrollCertificateRequest
+	if err := ctx.ShouldBindJSON(&requestBody); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error": fmt.Sprintf("invalid signature: %v", err),
+			"error": err.Error(),
 		})
 		return
 	}
 
-	// Issue certificate (1 year validity)
-	certificateDER, certificatePEM, fingerprint, err := s.certClient.IssueCertificate(requestBody.PublicKey, user.ID, 365*24*time.Hour)
+	if err := verifyProofOfPossession(user.ID, requestBody); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	certificateDER, certificatePEM, fingerprint, err := s.certClient.IssueCertificate(requestBody.PublicKey, user.ID, certificateValidity)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"error": fmt.Sprintf("failed to issue certificate: %v", err),
@@ -134,8 +219,7 @@ func (s *server) EnrollCertificate(ctx *gin.Context) {
 		return
 	}
 
-	// Store certificate in database (created as inactive by default)
-	_, err = s.dbClient.UserCertificate().Create(ctx, user.ID, certificateDER, certificatePEM, requestBody.PublicKey, fingerprint)
+	_, err = s.dbClient.UserCertificate().CreateRenewal(ctx, current, certificateDER, certificatePEM, requestBody.PublicKey, fingerprint)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"error": fmt.Sprintf("failed to store certificate: %v", err),
@@ -143,48 +227,144 @@ func (s *server) EnrollCertificate(ctx *gin.Context) {
 		return
 	}
 
-	// Extract issuer and subject from X.509 certificate
-	issuer, subject, err := extractIssuerAndSubject(certificateDER)
+	if err := s.registerCertificateOnChain(ctx, certificateDER, fingerprint); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, EnrollCertificateResponse{
+		CertificatePEM: certificatePEM,
+		Fingerprint:    fmt.Sprintf("%x", fingerprint),
+		PublicKey:      requestBody.PublicKey,
+	})
+}
+
+type RevokeCertificateRequest struct {
+	// Reason defaults to key_compromise, which is what a lost or stolen
+	// device amounts to.
+	Reason *models.CertificateRevocationReason `json:"reason"`
+}
+
+// RevokeCertificate revokes the user's current certificate, along with any
+// renewal of it still waiting to be registered, so the user can enroll
+// again from a new device.
+func (s *server) RevokeCertificate(ctx *gin.Context) {
+	user, err := s.GetAuthenticatedUser(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	// The body is optional.
+	var requestBody RevokeCertificateRequest
+	if err := ctx.ShouldBindJSON(&requestBody); err != nil && err != io.EOF {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	reason := models.CertificateRevocationKeyCompromise
+	if requestBody.Reason != nil {
+		reason = *requestBody.Reason
+	}
+	if reason != models.CertificateRevocationKeyCompromise && reason != models.CertificateRevocationCessationOfOperation {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "reason must be key_compromise or cessation_of_operation",
+		})
+		return
+	}
+
+	current, err := s.dbClient.UserCertificate().FindByUserID(ctx, user.ID)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf("failed to extract certificate fields: %v", err),
+			"error": err.Error(),
+		})
+		return
+	}
+	if current == nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": "certificate not found",
 		})
 		return
 	}
 
-	// Generate ABI-encoded registerCertificate function call
-	encodedData, err := encodeRegisterCertificateCall(fingerprint, issuer, subject)
+	pending, err := s.dbClient.UserCertificate().FindPendingRenewal(ctx, current.ID)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf("failed to encode function call: %v", err),
+			"error": err.Error(),
 		})
 		return
 	}
 
-	registerCertificateType := string(models.RegisterCertificateType)
+	if err := s.dbClient.UserCertificate().Revoke(ctx, current.ID, reason); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("failed to revoke certificate: %v", err),
+		})
+		return
+	}
 
-	// Create blockchain transaction via ethereum-transactor service
-	dataHex := "0x" + hex.EncodeToString(encodedData)
-	_, err = s.ethereumTransactorClient.CreateTransaction(ctx, ethereum_transactor.CreateTransactionRequest{
-		To:    &s.c2PAContractAddress,
-		Value: "0",
-		Data:  &dataHex,
-		Type:  &registerCertificateType,
+	// The database is what CRLs, OCSP and post validation read; the on-chain
+	// registry follows it so anchoring contracts stop accepting the key.
+	fingerprints := [][]byte{current.Fingerprint}
+	if pending != nil {
+		fingerprints = append(fingerprints, pending.Fingerprint)
+	}
+	for _, fingerprint := range fingerprints {
+		if err := s.deactivateCertificateOnChain(ctx, fingerprint); err != nil {
+			fmt.Printf("Warning: failed to deactivate certificate %x on chain: %v\n", fingerprint, err)
+		}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"revoked": true,
+		"reason":  reason,
 	})
+}
+
+// GetCertificateHistory lists every certificate the user has been issued,
+// newest first.
+func (s *server) GetCertificateHistory(ctx *gin.Context) {
+	user, err := s.GetAuthenticatedUser(ctx)
 	if err != nil {
-		// Log error but don't fail the enrollment - certificate is created, just not registered on-chain yet
-		// In production, you might want to queue this for retry
-		fmt.Printf("Warning: failed to create blockchain transaction: %v\n", err)
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"error": err.Error(),
+		})
+		return
 	}
-	// Note: The ethereum-transactor service stores the transaction in its database.
-	// The job runner will find transactions by type "registerCertificate" and match fingerprints
-	// from the transaction data to activate certificates when they confirm.
 
-	ctx.JSON(http.StatusOK, EnrollCertificateResponse{
-		CertificatePEM: certificatePEM,
-		Fingerprint:    fmt.Sprintf("%x", fingerprint),
-		PublicKey:      requestBody.PublicKey,
-	})
+	certs, err := s.dbClient.UserCertificate().FindAllByUserID(ctx, user.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	history := make([]gin.H, 0, len(certs))
+	for _, c := range certs {
+		entry := gin.H{
+			"id":           c.ID,
+			"fingerprint":  fmt.Sprintf("%x", c.Fingerprint),
+			"createdAt":    c.CreatedAt,
+			"expiresAt":    c.ExpiresAt,
+			"active":       c.Active,
+			"serialNumber": c.SerialNumber,
+		}
+		if c.RevokedAt != nil {
+			entry["revokedAt"] = c.RevokedAt
+			entry["revocationReason"] = c.RevocationReason
+		}
+		if c.RenewsCertificateID != nil {
+			entry["renewsCertificateId"] = c.RenewsCertificateID
+		}
+		history = append(history, entry)
+	}
+
+	ctx.JSON(http.StatusOK, history)
 }
 
 func (s *server) GetCertificate(ctx *gin.Context) {
@@ -227,6 +407,7 @@ func (s *server) GetCertificate(ctx *gin.Context) {
 		"fingerprint":    fmt.Sprintf("%x", cert.Fingerprint),
 		"publicKey":      cert.PublicKey,
 		"createdAt":      cert.CreatedAt,
+		"expiresAt":      cert.ExpiresAt,
 		"active":         cert.Active,
 	}
 
@@ -297,6 +478,7 @@ func (s *server) GetUserCertificate(ctx *gin.Context) {
 		"fingerprint":    fmt.Sprintf("%x", cert.Fingerprint),
 		"publicKey":      cert.PublicKey,
 		"createdAt":      cert.CreatedAt,
+		"expiresAt":      cert.ExpiresAt,
 		"active":         cert.Active,
 	}
 
@@ -310,6 +492,91 @@ func (s *server) GetUserCertificate(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, response)
 }
 
+// verifyProofOfPossession checks that the device holds the private half of
+// the ECDSA P-256 key it wants certified.
+func verifyProofOfPossession(userID uuid.UUID, request EnrollCertificateRequest) error {
+	publicKey, err := cert.ParsePublicKey(request.PublicKey)
+	if err != nil {
+		return err
+	}
+	ecdsaPubKey, ok := publicKey.(*ecdsa.PublicKey)
+	if !ok || ecdsaPubKey.Curve != elliptic.P256() {
+		return fmt.Errorf("public key must be ECDSA P-256")
+	}
+
+	// Verify proof of possession: decode and verify the challenge signature
+	challengeSignature, err := base64.StdEncoding.DecodeString(request.ChallengeSignature)
+	if err != nil {
+		return fmt.Errorf("failed to decode challenge signature")
+	}
+
+	// Create a challenge based on the user ID and public key
+	challenge := createChallenge(userID, request.PublicKey)
+
+	// Verify the signature
+	if err := verifySignature(challenge, challengeSignature, publicKey); err != nil {
+		return fmt.Errorf("invalid signature: %v", err)
+	}
+
+	return nil
+}
+
+// registerCertificateOnChain queues the registerCertificate transaction that,
+// once confirmed, activates the certificate.
+func (s *server) registerCertificateOnChain(ctx *gin.Context, certificateDER []byte, fingerprint []byte) error {
+	// Extract issuer and subject from X.509 certificate
+	issuer, subject, err := extractIssuerAndSubject(certificateDER)
+	if err != nil {
+		return fmt.Errorf("failed to extract certificate fields: %v", err)
+	}
+
+	// Generate ABI-encoded registerCertificate function call
+	encodedData, err := encodeRegisterCertificateCall(fingerprint, issuer, subject)
+	if err != nil {
+		return fmt.Errorf("failed to encode function call: %v", err)
+	}
+
+	registerCertificateType := string(models.RegisterCertificateType)
+
+	// Create blockchain transaction via ethereum-transactor service
+	dataHex := "0x" + hex.EncodeToString(encodedData)
+	_, err = s.ethereumTransactorClient.CreateTransaction(ctx, ethereum_transactor.CreateTransactionRequest{
+		To:    &s.c2PAContractAddress,
+		Value: "0",
+		Data:  &dataHex,
+		Type:  &registerCertificateType,
+	})
+	if err != nil {
+		// Log error but don't fail the enrollment - certificate is created, just not registered on-chain yet
+		// In production, you might want to queue this for retry
+		fmt.Printf("Warning: failed to create blockchain transaction: %v\n", err)
+	}
+	// Note: The ethereum-transactor service stores the transaction in its database.
+	// The job runner will find transactions by type "registerCertificate" and match fingerprints
+	// from the transaction data to activate certificates when they confirm.
+
+	return nil
+}
+
+// deactivateCertificateOnChain queues a setCertificateStatus(fingerprint, false)
+// transaction for a revoked certificate.
+func (s *server) deactivateCertificateOnChain(ctx *gin.Context, fingerprint []byte) error {
+	encodedData, err := encodeSetCertificateStatusCall(fingerprint, false)
+	if err != nil {
+		return err
+	}
+
+	setCertificateStatusType := string(models.SetCertificateStatusType)
+	dataHex := "0x" + hex.EncodeToString(encodedData)
+	_, err = s.ethereumTransactorClient.CreateTransaction(ctx, ethereum_transactor.CreateTransactionRequest{
+		To:    &s.c2PAContractAddress,
+		Value: "0",
+		Data:  &dataHex,
+		Type:  &setCertificateStatusType,
+	})
+	return err
+}
+
 // createChallenge creates a deterministic challenge based on user ID and public key
 func createChallenge(userID uuid.UUID, publicKeyPEM string) []byte {
 	data := fmt.Sprintf("%s:%s", userID.String(), publicKeyPEM)
@@ -324,9 +591,13 @@ func verifySignature(messageHash []byte, signature []byte, publicKey crypto.Publ
 		return fmt.Errorf("public key is not ECDSA")
 	}
 
-	// ECDSA signature from Secure Enclave is typically 64 bytes (r and s, each 32 bytes)
+	// Secure enclaves hand back either raw r||s (64 bytes, each 32 bytes)
+	// or an ASN.1 DER signature, depending on the API used.
 	if len(signature) != 64 {
-		return fmt.Errorf("invalid signature length: expected 64 bytes, got %d", len(signature))
+		if !ecdsa.VerifyASN1(ecdsaPubKey, messageHash, signature) {
+			return fmt.Errorf("signature verification failed")
+		}
+		return nil
 	}
 
 	// Split signature into r and s
@@ -417,36 +688,19 @@ func extractIssuerAndSubject(certDER []byte) (string, string, error) {
 	return issuer, subject, nil
 }
 
-// encodeAnchorManifestCall ABI encodes the anchorManifest(bytes32,string,string,bytes32) function call
-func encodeAnchorManifestCall(manifestHash []byte, manifestURI string, assetID string, certFingerprint []byte) ([]byte, error) {
-	// Function signature: anchorManifest(bytes32,string,string,bytes32)
-	// We'll use the ABI package to encode this properly
-
-	// Define the ABI for the function
-	abiJSON := `[{"constant":false,"inputs":[{"name":"manifestHash","type":"bytes32"},{"name":"manifestUri","type":"string"},{"name":"assetId","type":"string"},{"name":"certFingerprint","type":"bytes32"}],"name":"anchorManifest","outputs":[{"name":"","type":"bool"}],"payable":false,"stateMutability":"nonpayable","type":"function"}]`
+// encodeSetCertificateStatusCall ABI encodes the setCertificateStatus(bytes32,bool) function call
+func encodeSetCertificateStatusCall(fingerprint []byte, active bool) ([]byte, error) {
+	abiJSON := `[{"constant":false,"inputs":[{"name":"fingerprint","type":"bytes32"},{"name":"active","type":"bool"}],"name":"setCertificateStatus","outputs":[],"payable":false,"stateMutability":"nonpayable","type":"function"}]`
 
 	contractABI, err := abi.JSON(strings.NewReader(abiJSON))
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse ABI: %w", err)
 	}
 
-	// Convert hashes to bytes32
-	var manifestHashBytes32 [32]byte
-	copy(manifestHashBytes32[:], manifestHash)
-	if len(manifestHash) < 32 {
-		// Pad with zeros if needed
-		copy(manifestHashBytes32[:], manifestHash)
-	}
-
-	var certFingerprintBytes32 [32]byte
-	copy(certFingerprintBytes32[:], certFingerprint)
-	if len(certFingerprint) < 32 {
-		// Pad with zeros if needed
-		copy(certFingerprintBytes32[:], certFingerprint)
-	}
+	var fingerprintBytes32 [32]byte
+	copy(fingerprintBytes32[:], fingerprint)
 
-	// Encode the function call
-	data, err := contractABI.Pack("anchorManifest", manifestHashBytes32, manifestURI, assetID, certFingerprintBytes32)
+	data, err := contractABI.Pack("setCertificateStatus", fingerprintBytes32, active)
 	if err != nil {
 		return nil, fmt.Errorf("failed to pack function call: %w", err)
 	}
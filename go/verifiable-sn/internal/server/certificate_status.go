@@ -0,0 +1,204 @@
+package server
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/cert"
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/gin-gonic/gin"
+)
+
+// These routes are public: verifiers checking a signature need them without
+// an account, and everything they return is signed or already public.
+
+// maxOCSPRequestSize bounds POSTed OCSP requests, which are a few hundred
+// bytes in practice.
+const maxOCSPRequestSize = 16 * 1024
+
+// GetCertificateChain returns the issuing CA and root as a PEM bundle, for
+// devices to embed alongside their own certificate when signing.
+func (s *server) GetCertificateChain(ctx *gin.Context) {
+	var bundle []byte
+	for _, certificate := range s.certClient.GetCertificateChain() {
+		bundle = append(bundle, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate.Raw})...)
+	}
+
+	ctx.Data(http.StatusOK, "application/x-pem-file", bundle)
+}
+
+// GetCertificateRevocationList returns a freshly signed DER CRL of every
+// revoked certificate that hasn't yet expired.
+func (s *server) GetCertificateRevocationList(ctx *gin.Context) {
+	revoked, err := s.dbClient.UserCertificate().FindRevoked(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	entries := make([]cert.RevokedCertificate, 0, len(revoked))
+	for _, certificate := range revoked {
+		parsed, err := x509.ParseCertificate(certificate.Certificate)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"error": fmt.Sprintf("failed to parse certificate %s: %v", certificate.ID, err),
+			})
+			return
+		}
+		entries = append(entries, cert.RevokedCertificate{
+			SerialNumber: parsed.SerialNumber,
+			RevokedAt:    *certificate.RevokedAt,
+			Reason:       revocationReasonCode(certificate.RevocationReason),
+		})
+	}
+
+	crl, err := s.certClient.CreateRevocationList(entries)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	ctx.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", int(cert.RevocationInfoValidity.Seconds())))
+	ctx.Data(http.StatusOK, "application/pkix-crl", crl)
+}
+
+// RespondOCSP is an RFC 6960 responder. Requests arrive either as a POSTed
+// DER body or, for GET, base64 in the last path segment.
+func (s *server) RespondOCSP(ctx *gin.Context) {
+	var requestDER []byte
+	if ctx.Request.Method == http.MethodGet {
+		encoded, err := url.PathUnescape(strings.TrimPrefix(ctx.Param("request"), "/"))
+		if err == nil {
+			requestDER, err = base64.StdEncoding.DecodeString(encoded)
+		}
+		if err != nil {
+			ctx.Data(http.StatusBadRequest, "text/plain", []byte("invalid OCSP request encoding"))
+			return
+		}
+	} else {
+		body, err := io.ReadAll(io.LimitReader(ctx.Request.Body, maxOCSPRequestSize))
+		if err != nil {
+			ctx.Data(http.StatusBadRequest, "text/plain", []byte("failed to read OCSP request"))
+			return
+		}
+		requestDER = body
+	}
+
+	response, err := s.certClient.RespondOCSP(requestDER, func(serialNumber *big.Int) (cert.CertificateStatus, error) {
+		certificate, err := s.dbClient.UserCertificate().FindBySerialNumber(ctx, serialNumber.Text(16))
+		if err != nil {
+			return cert.CertificateStatus{}, err
+		}
+		return ocspStatus(certificate), nil
+	})
+	if err != nil {
+		fmt.Printf("Warning: failed to answer OCSP request: %v\n", err)
+	}
+
+	ctx.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", int(cert.RevocationInfoValidity.Seconds())))
+	ctx.Data(http.StatusOK, "application/ocsp-response", response)
+}
+
+// GetCertificateStatus is the JSON counterpart to the OCSP responder, keyed
+// by the hex fingerprint the app and manifests already use. With an `at`
+// query parameter (RFC 3339) it also reports whether the certificate could
+// sign at that time, e.g. a manifest's signing time.
+func (s *server) GetCertificateStatus(ctx *gin.Context) {
+	fingerprint, err := HexToBytes(ctx.Param("fingerprint"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("invalid certificate fingerprint format: %v", err),
+		})
+		return
+	}
+
+	var at *time.Time
+	if atParam := ctx.Query("at"); atParam != "" {
+		parsed, err := time.Parse(time.RFC3339, atParam)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error": "at must be an RFC 3339 timestamp",
+			})
+			return
+		}
+		at = &parsed
+	}
+
+	certificate, err := s.dbClient.UserCertificate().FindByFingerprint(ctx, fingerprint)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	now := time.Now()
+	response := gin.H{
+		"status":     "unknown",
+		"thisUpdate": now,
+		"nextUpdate": now.Add(cert.RevocationInfoValidity),
+	}
+	if certificate != nil {
+		response["status"] = "good"
+		response["active"] = certificate.Active
+		response["expiresAt"] = certificate.ExpiresAt
+		if certificate.RevokedAt != nil {
+			response["status"] = "revoked"
+			response["revokedAt"] = certificate.RevokedAt
+			response["revocationReason"] = certificate.RevocationReason
+		}
+		if at != nil {
+			err := CheckCertificateStatus(certificate, *at)
+			response["validAt"] = err == nil
+			if err != nil {
+				response["reason"] = err.Error()
+			}
+		}
+	} else if at != nil {
+		response["validAt"] = false
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+func ocspStatus(certificate *models.UserCertificate) cert.CertificateStatus {
+	if certificate == nil {
+		return cert.CertificateStatus{Status: cert.StatusUnknown}
+	}
+	if certificate.RevokedAt == nil {
+		return cert.CertificateStatus{Status: cert.StatusGood}
+	}
+	return cert.CertificateStatus{
+		Status:    cert.StatusRevoked,
+		RevokedAt: *certificate.RevokedAt,
+		Reason:    revocationReasonCode(certificate.RevocationReason),
+	}
+}
+
+func revocationReasonCode(reason *models.CertificateRevocationReason) cert.RevocationReason {
+	if reason == nil {
+		return cert.ReasonUnspecified
+	}
+	switch *reason {
+	case models.CertificateRevocationKeyCompromise:
+		return cert.ReasonKeyCompromise
+	case models.CertificateRevocationSuperseded:
+		return cert.ReasonSuperseded
+	case models.CertificateRevocationCessationOfOperation:
+		return cert.ReasonCessationOfOperation
+	default:
+		return cert.ReasonUnspecified
+	}
+}
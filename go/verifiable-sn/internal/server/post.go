@@ -1,6 +1,7 @@
 package server
 
 import (
+	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
 	"net/http"
@@ -8,6 +9,7 @@ import (
 
 	ethereum_transactor "github.com/MaxBlaushild/poltergeist/pkg/ethereum_transactor"
 	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/MaxBlaushild/poltergeist/verifiable-sn/internal/c2pa"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
@@ -62,6 +64,7 @@ func (s *server) CreatePost(ctx *gin.Context) {
 	var certFingerprintBytes []byte
 	var assetID *string
 	var manifestCreatedAt *time.Time
+	var verificationReport *c2pa.Report
 
 	// If manifest data is provided, validate it
 	if requestBody.ManifestURL != nil && *requestBody.ManifestURL != "" &&
@@ -76,15 +79,49 @@ func (s *server) CreatePost(ctx *gin.Context) {
 			return
 		}
 
-		// Validate manifest
-		computedHash, computedFingerprint, err := ValidateManifest(manifestBytes)
-		if err != nil {
-			fmt.Printf("Manifest validation error: %v\n", err)
-			fmt.Printf("Manifest size: %d bytes\n", len(manifestBytes))
-			ctx.JSON(http.StatusBadRequest, gin.H{
-				"error": fmt.Sprintf("manifest validation failed: %v", err),
-			})
-			return
+		var computedHash []byte
+		var computedFingerprint []byte
+		if c2pa.IsManifestStore(manifestBytes) {
+			// A C2PA manifest store: verify its signature and hashes against
+			// the uploaded media.
+			asset, err := downloadAsset(requestBody.ImageURL)
+			if err != nil {
+				ctx.JSON(http.StatusBadRequest, gin.H{
+					"error": err.Error(),
+				})
+				return
+			}
+
+			verificationReport = s.verifyC2PA(ctx, asset, manifestBytes)
+			if verificationReport.Status != c2pa.StatusVerified {
+				ctx.JSON(http.StatusBadRequest, gin.H{
+					"error":  fmt.Sprintf("manifest verification failed: %s", verificationReport.Status),
+					"report": verificationReport,
+				})
+				return
+			}
+
+			active := verificationReport.Active()
+			hash := sha256.Sum256(manifestBytes)
+			computedHash = hash[:]
+			computedFingerprint, _ = HexToBytes(active.Signer.Fingerprint)
+			manifestCreatedAt = active.SignedAt
+		} else {
+			// The app's original CBOR manifest
+			computedHash, computedFingerprint, err = ValidateManifest(manifestBytes)
+			if err != nil {
+				fmt.Printf("Manifest validation error: %v\n", err)
+				fmt.Printf("Manifest size: %d bytes\n", len(manifestBytes))
+				ctx.JSON(http.StatusBadRequest, gin.H{
+					"error": fmt.Sprintf("manifest validation failed: %v", err),
+				})
+				return
+			}
+
+			manifestCreatedAt, err = ExtractManifestTimestamp(manifestBytes)
+			if err != nil {
+				fmt.Printf("Warning: failed to extract manifest timestamp: %v\n", err)
+			}
 		}
 
 		// Verify provided hash matches computed hash
@@ -182,6 +219,27 @@ func (s *server) CreatePost(ctx *gin.Context) {
 			return
 		}
 
+		// Check the certificate as of when the manifest claims it was
+		// signed, if that's recent enough to believe, and as of now
+		// otherwise.
+		signedAt, err := c2pa.SigningTime(manifestCreatedAt, time.Now())
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		if err := CheckCertificateStatus(cert, signedAt); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("certificate was not valid when the manifest was signed: %v", err),
+			})
+			return
+		}
+
+		if verificationReport == nil {
+			verificationReport = legacyVerificationReport(manifestBytes, cert, signedAt)
+		}
+
 		// Set manifest data
 		manifestHashBytes = computedHash
 		manifestURI = requestBody.ManifestURL
@@ -189,11 +247,6 @@ func (s *server) CreatePost(ctx *gin.Context) {
 		if requestBody.AssetID != nil {
 			assetID = requestBody.AssetID
 		}
-
-		manifestCreatedAt, err = ExtractManifestTimestamp(manifestBytes)
-		if err != nil {
-			fmt.Printf("Warning: failed to extract manifest timestamp: %v\n", err)
-		}
 	}
 
 	// Default mediaType to "image" if not provided (backward compatibility)
@@ -203,6 +256,17 @@ func (s *server) CreatePost(ctx *gin.Context) {
 		mediaType = &defaultMediaType
 	}
 
+	// Images posted without a separate manifest may embed one. Verify it for
+	// the record, but don't hold up the post over it.
+	if verificationReport == nil && *mediaType == "image" {
+		asset, err := downloadAsset(requestBody.ImageURL)
+		if err != nil {
+			fmt.Printf("Warning: failed to download image for verification: %v\n", err)
+		} else {
+			verificationReport = s.verifyC2PA(ctx, asset, nil)
+		}
+	}
+
 	// Create post
 	post, err := s.dbClient.Post().Create(
 		ctx,
@@ -223,6 +287,10 @@ func (s *server) CreatePost(ctx *gin.Context) {
 		return
 	}
 
+	if verificationReport != nil {
+		s.saveVerificationReport(ctx, post.ID, verificationReport)
+	}
+
 	// Create tags if provided
 	if len(requestBody.Tags) > 0 {
 		if err := s.dbClient.PostTag().CreateForPost(ctx, post.ID, requestBody.Tags); err != nil {
@@ -235,34 +303,15 @@ func (s *server) CreatePost(ctx *gin.Context) {
 		}
 	}
 
-	// If manifest was provided, create blockchain transaction for anchoring
-	if manifestHashBytes != nil && manifestURI != nil && certFingerprintBytes != nil {
-		// Encode anchorManifest function call
-		assetIDStr := ""
-		if assetID != nil {
-			assetIDStr = *assetID
-		}
-
-		encodedData, err := encodeAnchorManifestCall(manifestHashBytes, *manifestURI, assetIDStr, certFingerprintBytes)
+	// If manifest was provided, queue it to be anchored in the next batch
+	if manifestHashBytes != nil && certFingerprintBytes != nil {
+		err = s.ethereumTransactorClient.QueueManifestAnchor(ctx, ethereum_transactor.QueueManifestAnchorRequest{
+			ManifestHash:    "0x" + hex.EncodeToString(manifestHashBytes),
+			CertFingerprint: "0x" + hex.EncodeToString(certFingerprintBytes),
+		})
 		if err != nil {
 			// Log error but don't fail the post creation
-			fmt.Printf("Warning: failed to encode anchorManifest call: %v\n", err)
-		} else {
-			anchorManifestType := string(models.AnchorManifestType)
-
-			// Create blockchain transaction via ethereum-transactor service
-			dataHex := "0x" + hex.EncodeToString(encodedData)
-			_, err = s.ethereumTransactorClient.CreateTransaction(ctx, ethereum_transactor.CreateTransactionRequest{
-				To:    &s.c2PAContractAddress,
-				Value: "0",
-				Data:  &dataHex,
-				Type:  &anchorManifestType,
-			})
-			if err != nil {
-				// Log error but don't fail the post creation
-				// In production, you might want to queue this for retry
-				fmt.Printf("Warning: failed to create blockchain transaction for manifest anchoring: %v\n", err)
-			}
+			fmt.Printf("Warning: failed to queue manifest for anchoring: %v\n", err)
 		}
 	}
 
@@ -1109,6 +1158,20 @@ func (s *server) GetBlockchainTransactionByManifestHash(ctx *gin.Context) {
 		return
 	}
 
+	// Manifests posted since batching share their batch's transaction.
+	if tx == nil {
+		anchor, err := s.dbClient.ManifestAnchor().FindByManifestHash(ctx, post.ManifestHash)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		if anchor != nil && anchor.Batch != nil {
+			tx = anchor.Batch.BlockchainTransaction
+		}
+	}
+
 	if tx == nil {
 		ctx.JSON(http.StatusNotFound, gin.H{
 			"error": "blockchain transaction not found for this manifest",
@@ -81,7 +81,9 @@ func (s *server) SetupRoutes(r *gin.Engine) {
 	r.GET("/verifiable-sn/posts/:id", middleware.WithAuthenticationWithoutLocation(s.authClient, s.GetPost))
 	r.POST("/verifiable-sn/posts/:id/tags", middleware.WithAuthenticationWithoutLocation(s.authClient, s.AddPostTags))
 	r.DELETE("/verifiable-sn/posts/:id/tags", middleware.WithAuthenticationWithoutLocation(s.authClient, s.RemovePostTag))
+	r.GET("/verifiable-sn/posts/:id/verification", middleware.WithAuthenticationWithoutLocation(s.authClient, s.GetPostVerification))
 	r.GET("/verifiable-sn/posts/:id/blockchain-transaction", middleware.WithAuthenticationWithoutLocation(s.authClient, s.GetBlockchainTransactionByManifestHash))
+	r.GET("/verifiable-sn/posts/:id/anchor-proof", middleware.WithAuthenticationWithoutLocation(s.authClient, s.GetPostAnchorProof))
 	r.DELETE("/verifiable-sn/posts/:id", middleware.WithAuthenticationWithoutLocation(s.authClient, s.DeletePost))
 	r.POST("/verifiable-sn/posts/:id/flag", middleware.WithAuthenticationWithoutLocation(s.authClient, s.FlagPost))
 	r.POST("/verifiable-sn/posts/:id/reactions", middleware.WithAuthenticationWithoutLocation(s.authClient, s.CreateReaction))
@@ -141,6 +143,17 @@ func (s *server) SetupRoutes(r *gin.Engine) {
 	r.POST("/verifiable-sn/certificate/enroll", middleware.WithAuthenticationWithoutLocation(s.authClient, s.EnrollCertificate))
 	r.GET("/verifiable-sn/certificate", middleware.WithAuthenticationWithoutLocation(s.authClient, s.GetCertificate))
 	r.GET("/verifiable-sn/certificate/user/:userId", middleware.WithAuthenticationWithoutLocation(s.authClient, s.GetUserCertificate))
+	r.POST("/verifiable-sn/certificate/renew", middleware.WithAuthenticationWithoutLocation(s.authClient, s.RenewCertificate))
+	r.POST("/verifiable-sn/certificate/revoke", middleware.WithAuthenticationWithoutLocation(s.authClient, s.RevokeCertificate))
+	r.GET("/verifiable-sn/certificate/history", middleware.WithAuthenticationWithoutLocation(s.authClient, s.GetCertificateHistory))
+	r.GET("/verifiable-sn/certificate/chain", s.GetCertificateChain)
+	r.GET("/verifiable-sn/certificate/crl", s.GetCertificateRevocationList)
+	r.GET("/verifiable-sn/certificate/status/:fingerprint", s.GetCertificateStatus)
+	r.POST("/verifiable-sn/ocsp", s.RespondOCSP)
+	r.GET("/verifiable-sn/ocsp/*request", s.RespondOCSP)
+
+	// Public C2PA verification
+	r.POST("/verifiable-sn/verify", s.VerifyAsset)
 
 	// Admin routes (flagged posts)
 	r.GET("/verifiable-sn/admin/flagged-posts", middleware.WithAuthenticationWithoutLocation(s.authClient, s.GetFlaggedPosts))
@@ -0,0 +1,270 @@
+package server
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/MaxBlaushild/poltergeist/pkg/models"
+	"github.com/MaxBlaushild/poltergeist/verifiable-sn/internal/c2pa"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// maxVerificationAssetSize bounds the images downloaded or uploaded for
+// verification.
+const maxVerificationAssetSize = 50 << 20
+
+// legacyManifestLabel stands in for the manifest label in reports on posts
+// signed with the app's original CBOR manifest, which has none.
+const legacyManifestLabel = "legacy"
+
+// verifyC2PA verifies an asset's manifest against our CA, checking signers'
+// certificates against their recorded revocations.
+func (s *server) verifyC2PA(ctx *gin.Context, asset []byte, sidecar []byte) *c2pa.Report {
+	return c2pa.Verify(asset, sidecar, c2pa.Options{
+		Trust: s.certClient,
+		CheckStatus: func(fingerprint []byte, signedAt time.Time) error {
+			certificate, err := s.dbClient.UserCertificate().FindByFingerprint(ctx, fingerprint)
+			if err != nil {
+				return err
+			}
+			if certificate == nil {
+				return fmt.Errorf("certificate was not issued to any user")
+			}
+			return CheckCertificateStatus(certificate, signedAt)
+		},
+	})
+}
+
+// legacyVerificationReport describes a post signed with the app's original
+// CBOR manifest, whose certificate has been checked but which has no claim
+// signature or hard binding to verify.
+func legacyVerificationReport(manifestBytes []byte, certificate *models.UserCertificate, signedAt time.Time) *c2pa.Report {
+	manifestHash := sha256.Sum256(manifestBytes)
+	manifest := c2pa.ManifestReport{
+		Label:      legacyManifestLabel,
+		Status:     c2pa.StatusLegacy,
+		SignedAt:   &signedAt,
+		Assertions: []string{},
+		Results: []c2pa.Result{
+			{
+				Code:        c2pa.CodeClaimSignatureMissing,
+				Explanation: "the manifest predates C2PA signing, so only its certificate was checked",
+			},
+		},
+	}
+	if parsed, err := x509.ParseCertificate(certificate.Certificate); err == nil {
+		manifest.Signer = c2pa.NewSigner(parsed)
+		manifest.Signer.Trusted = true
+		manifest.Results = append(manifest.Results, c2pa.Result{Code: c2pa.CodeSigningCredentialTrusted, Success: true})
+	}
+
+	return &c2pa.Report{
+		Status:            c2pa.StatusLegacy,
+		ActiveManifest:    legacyManifestLabel,
+		ManifestStoreHash: hex.EncodeToString(manifestHash[:]),
+		Manifests:         []c2pa.ManifestReport{manifest},
+		VerifiedAt:        time.Now(),
+	}
+}
+
+func (s *server) saveVerificationReport(ctx *gin.Context, postID uuid.UUID, report *c2pa.Report) {
+	encoded, err := json.Marshal(report)
+	if err != nil {
+		fmt.Printf("Warning: failed to encode verification report for post %s: %v\n", postID, err)
+		return
+	}
+	if err := s.dbClient.PostVerificationReport().Upsert(ctx, postID, models.PostVerificationStatus(report.Status), encoded); err != nil {
+		fmt.Printf("Warning: failed to save verification report for post %s: %v\n", postID, err)
+	}
+}
+
+// GetPostVerification returns the report stored when the post was created.
+// The signer's certificate is checked again, since a key compromise reported
+// after posting invalidates everything the key signed.
+func (s *server) GetPostVerification(ctx *gin.Context) {
+	_, err := s.GetAuthenticatedUser(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	postID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid post id format",
+		})
+		return
+	}
+
+	stored, err := s.dbClient.PostVerificationReport().FindByPostID(ctx, postID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if stored == nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": "post has no verification report",
+		})
+		return
+	}
+
+	var report c2pa.Report
+	if err := json.Unmarshal(stored.Report, &report); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("failed to decode verification report: %v", err),
+		})
+		return
+	}
+
+	if active := report.Active(); active != nil && active.Signer != nil && active.Signer.Trusted {
+		if err := s.recheckSigner(ctx, active, stored.CreatedAt); err != nil {
+			active.Status = c2pa.StatusInvalid
+			active.Results = append(active.Results, c2pa.Result{
+				Code:        c2pa.CodeSigningCredentialRevoked,
+				URL:         "c2pa.signature",
+				Explanation: err.Error(),
+			})
+			report.Status = c2pa.StatusInvalid
+		}
+	}
+
+	ctx.JSON(http.StatusOK, report)
+}
+
+// recheckSigner returns an error if the manifest's signing certificate has
+// since been revoked as of when it signed, bounding the claimed signing
+// time by when it was posted.
+func (s *server) recheckSigner(ctx *gin.Context, manifest *c2pa.ManifestReport, postedAt time.Time) error {
+	fingerprint, err := HexToBytes(manifest.Signer.Fingerprint)
+	if err != nil {
+		return nil
+	}
+	certificate, err := s.dbClient.UserCertificate().FindByFingerprint(ctx, fingerprint)
+	if err != nil || certificate == nil {
+		return nil
+	}
+
+	signedAt, err := c2pa.SigningTime(manifest.SignedAt, postedAt)
+	if err != nil {
+		return err
+	}
+	return CheckCertificateStatus(certificate, signedAt)
+}
+
+// VerifyAsset lets anyone check an image's provenance. The image is
+// uploaded as the multipart "file" field, with an optional sidecar manifest
+// store as "manifest" for images that don't embed one. Manifests we've
+// anchored on chain come back with their inclusion proof, or for manifests
+// anchored before batching, their anchoring transaction.
+func (s *server) VerifyAsset(ctx *gin.Context) {
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, 2*maxVerificationAssetSize)
+
+	asset, err := readFormFile(ctx, "file")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	if asset == nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "file is required",
+		})
+		return
+	}
+
+	sidecar, err := readFormFile(ctx, "manifest")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	report := s.verifyC2PA(ctx, asset, sidecar)
+	response := gin.H{
+		"report": report,
+	}
+
+	if report.ManifestStoreHash != "" {
+		manifestHash, _ := hex.DecodeString(report.ManifestStoreHash)
+		anchor, err := s.dbClient.ManifestAnchor().FindByManifestHash(ctx, manifestHash)
+		if err != nil {
+			fmt.Printf("Warning: failed to look up manifest anchor: %v\n", err)
+		} else if anchor != nil {
+			response["anchor"] = manifestAnchorProof(anchor)
+		} else {
+			// Manifests posted before batching were anchored one per transaction.
+			tx, err := s.dbClient.BlockchainTransaction().FindByManifestHash(ctx, manifestHash)
+			if err != nil {
+				fmt.Printf("Warning: failed to look up manifest anchor: %v\n", err)
+			} else if tx != nil {
+				response["anchor"] = gin.H{
+					"chainId":     tx.ChainID,
+					"txHash":      tx.TxHash,
+					"status":      tx.Status,
+					"blockNumber": tx.BlockNumber,
+					"confirmedAt": tx.ConfirmedAt,
+				}
+			}
+		}
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// readFormFile reads a multipart file field, returning nil if it's absent.
+func readFormFile(ctx *gin.Context, name string) ([]byte, error) {
+	header, err := ctx.FormFile(name)
+	if err == http.ErrMissingFile {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	if header.Size > maxVerificationAssetSize {
+		return nil, fmt.Errorf("%s is larger than %d MB", name, maxVerificationAssetSize>>20)
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	defer file.Close()
+
+	return io.ReadAll(file)
+}
+
+// downloadAsset downloads a post's media for verification.
+func downloadAsset(assetURL string) ([]byte, error) {
+	resp, err := http.Get(assetURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download asset: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download asset: status %d", resp.StatusCode)
+	}
+
+	asset, err := io.ReadAll(io.LimitReader(resp.Body, maxVerificationAssetSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read asset: %w", err)
+	}
+	if len(asset) > maxVerificationAssetSize {
+		return nil, fmt.Errorf("asset is larger than %d MB", maxVerificationAssetSize>>20)
+	}
+
+	return asset, nil
+}
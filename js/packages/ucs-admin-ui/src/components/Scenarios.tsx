@@ -1000,7 +1000,7 @@ export const Scenarios = () => {
   const refreshScenarioById = useCallback(
     async (scenarioId: string) => {
       const latest = await apiClient.get<ScenarioRecord>(
-        `/sonar/scenarios/${scenarioId}`
+        `/sonar/admin/scenarios/${scenarioId}`
       );
       setRecords((prev) =>
         prev.map((record) => (record.id === scenarioId ? latest : record))
@@ -1701,7 +1701,7 @@ export const Scenarios = () => {
       return;
     }
     void apiClient
-      .get<ScenarioRecord>(`/sonar/scenarios/${deepLinkedScenarioId}`)
+      .get<ScenarioRecord>(`/sonar/admin/scenarios/${deepLinkedScenarioId}`)
       .then((record) => {
         if (!record) {
           didHydrateDeepLinkedScenarioRef.current = true;
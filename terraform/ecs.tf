@@ -531,6 +531,10 @@ module "ecs" {
             {
               name  = "RPC_URL"
               value = "https://sepolia.base.org"
+            },
+            {
+              name  = "C2PA_CONTRACT_ADDRESS"
+              value = "0x653d604fdaA2320DF90cc4e9dFd5aabe86BD91A9"
             }
           ]
           portMappings = [